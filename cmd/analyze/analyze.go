@@ -1,20 +1,28 @@
 package analyze
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
 	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
 	"github.com/eoinhurrell/mdnotes/internal/config"
 	"github.com/eoinhurrell/mdnotes/internal/errors"
+	"github.com/eoinhurrell/mdnotes/internal/git"
+	"github.com/eoinhurrell/mdnotes/internal/i18n"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/selector"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
@@ -30,12 +38,18 @@ func NewAnalyzeCommand() *cobra.Command {
 
 	// Add subcommands
 	cmd.AddCommand(newStatsCommand())
+	cmd.AddCommand(newFieldsCommand())
 	cmd.AddCommand(newDuplicatesCommand())
 	cmd.AddCommand(newHealthCommand())
 	cmd.AddCommand(newLinksCommand())
 	cmd.AddCommand(newContentCommand())
 	cmd.AddCommand(newTrendsCommand())
 	cmd.AddCommand(newInboxCommand())
+	cmd.AddCommand(newOrphansCommand())
+	cmd.AddCommand(newKeywordsCommand())
+	cmd.AddCommand(newChurnCommand())
+
+	cmd.PersistentFlags().Bool("no-cache", false, "Recompute everything instead of reusing results stored in the vault index for unchanged files")
 
 	return cmd
 }
@@ -44,18 +58,43 @@ func newStatsCommand() *cobra.Command {
 	var (
 		outputFormat string
 		outputFile   string
+		top          int
+		topBy        []string
+		byFolder     bool
+		depth        int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "stats [vault-path]",
 		Short: "Generate vault statistics",
-		Long:  `Generate comprehensive statistics about your vault including file counts, frontmatter usage, and tag distribution`,
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Generate comprehensive statistics about your vault including file counts, frontmatter usage, and tag distribution.
+
+With --top, the report also ranks the files at the vault's extremes: the
+largest (bytes), the longest (words), the oldest (days since last modified),
+and those with the most inbound and outbound links to other notes.
+
+With --by-folder, the report also breaks the vault down by folder, down to
+--depth levels: each folder's notes, words, notes modified in the last 30
+days, last modification and most used tags. A folder counts every note beneath
+it. To drill into one area, pass its folder as the path.
+
+Examples:
+  mdnotes analyze stats --top 10 .
+  mdnotes analyze stats --top 5 --top-by size,inbound --format json .
+  mdnotes analyze stats --by-folder --depth 2 .
+  mdnotes analyze stats --by-folder Projects/`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
 			if len(args) > 0 {
 				vaultPath = args[0]
 			}
+			if top < 0 {
+				return fmt.Errorf("--top must not be negative")
+			}
+			if depth < 1 {
+				return fmt.Errorf("--depth must be at least 1")
+			}
 
 			// Load configuration
 			cfg, err := loadConfig(cmd)
@@ -74,8 +113,24 @@ func newStatsCommand() *cobra.Command {
 				fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
 			}
 
-			// Select files using unified architecture
-			selection, err := fileSelector.SelectFiles(vaultPath, mode)
+			// Stream files into the statistics as they are scanned, keeping
+			// them only when a ranking or folder breakdown needs them afterwards.
+			// The scan and the analysis share the vault index, so results
+			// stored while streaming are saved along with the scan.
+			idx := vault.FindIndex(vaultPath)
+			fileSelector = fileSelector.WithIndex(idx)
+			ana, saveCache := newIndexedAnalyzer(cmd, idx)
+			ana.SetLinkParser(processor.NewLinkParser())
+			acc := ana.NewStatsAccumulator()
+			keepFiles := top > 0 || byFolder
+			var files []*vault.VaultFile
+			parseErrors, err := fileSelector.StreamFiles(context.Background(), vaultPath, mode, func(file *vault.VaultFile) error {
+				acc.Add(file)
+				if keepFiles {
+					files = append(files, file)
+				}
+				return nil
+			})
 			if err != nil {
 				if os.IsNotExist(err) {
 					return errors.NewFileNotFoundError(vaultPath,
@@ -88,21 +143,30 @@ func newStatsCommand() *cobra.Command {
 			}
 
 			// Report any parsing errors encountered
-			if len(selection.ParseErrors) > 0 {
-				_, _ = fmt.Fprintf(os.Stderr, "Warning: %d files had parsing errors:\n", len(selection.ParseErrors))
-				for _, parseErr := range selection.ParseErrors {
+			if len(parseErrors) > 0 {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: %d files had parsing errors:\n", len(parseErrors))
+				for _, parseErr := range parseErrors {
 					_, _ = fmt.Fprintf(os.Stderr, "  ✗ %s: %v\n", parseErr.Path, parseErr.Error)
 				}
 				_, _ = fmt.Fprintf(os.Stderr, "\n")
 			}
 
-			files := selection.Files
-
-			// Generate statistics
-			ana := analyzer.NewAnalyzer()
-			stats := ana.GenerateStats(files)
+			stats := acc.Stats()
+			saveCache()
+			if top > 0 {
+				stats.Top, err = ana.TopFiles(files, top, topBy)
+				if err != nil {
+					return err
+				}
+			}
+			if byFolder {
+				stats.Folders = ana.FolderStats(files, depth)
+			}
 
 			// Output results
+			if outputFormat == "ndjson" {
+				return writeNDJSON(outputFile, []analyzer.VaultStats{stats})
+			}
 			if outputFormat == "json" {
 				data, err := json.MarshalIndent(stats, "", "  ")
 				if err != nil {
@@ -110,13 +174,13 @@ func newStatsCommand() *cobra.Command {
 				}
 
 				if outputFile != "" {
-					return os.WriteFile(outputFile, data, 0644)
+					return safety.WriteFile(outputFile, data, 0644)
 				}
 				fmt.Println(string(data))
 			} else {
 				output := formatStatsText(stats)
 				if outputFile != "" {
-					return os.WriteFile(outputFile, []byte(output), 0644)
+					return safety.WriteFile(outputFile, []byte(output), 0644)
 				}
 				_, _ = fmt.Print(output)
 			}
@@ -125,7 +189,106 @@ func newStatsCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	cmd.Flags().IntVar(&top, "top", 0, "List the top N files for each ranking")
+	cmd.Flags().StringSliceVar(&topBy, "top-by", analyzer.TopCriteria, "Rankings to list with --top (size, words, age, inbound, outbound)")
+	cmd.Flags().BoolVar(&byFolder, "by-folder", false, "Break statistics down by folder")
+	cmd.Flags().IntVar(&depth, "depth", 1, "Folder levels to break down with --by-folder")
+
+	return cmd
+}
+
+func newFieldsCommand() *cobra.Command {
+	var (
+		outputFormat string
+		outputFile   string
+		fields       []string
+		top          int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fields [vault-path]",
+		Short: "Analyze frontmatter field values",
+		Long: `Analyze individual frontmatter fields in depth: value distribution, missing
+counts, type mix and example values. Without --field, every field found in the
+vault is analyzed.
+
+Examples:
+  mdnotes analyze fields --field status --field rating .
+  mdnotes analyze fields --field status --format json .`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultPath := "."
+			if len(args) > 0 {
+				vaultPath = args[0]
+			}
+
+			if outputFormat != "text" && outputFormat != "json" && outputFormat != "ndjson" {
+				return fmt.Errorf("unsupported format: %s (supported: text, json, ndjson)", outputFormat)
+			}
+
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return errors.NewConfigError("", err.Error())
+			}
+
+			mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+			if err != nil {
+				return errors.WrapError(err, "file selection config", "")
+			}
+			if len(fileSelector.IgnorePatterns) == 0 {
+				fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+			}
+
+			ana := analyzer.NewAnalyzer()
+			acc := ana.NewFieldAccumulator(fields...)
+			totalFiles := 0
+			parseErrors, err := fileSelector.StreamFiles(context.Background(), vaultPath, mode, func(file *vault.VaultFile) error {
+				acc.Add(file)
+				totalFiles++
+				return nil
+			})
+			if err != nil {
+				if os.IsNotExist(err) {
+					return errors.NewFileNotFoundError(vaultPath,
+						"Ensure the vault path exists and contains markdown files. Use 'ls' to verify the directory structure.")
+				}
+				return errors.WrapError(err, "vault scanning", vaultPath)
+			}
+
+			if len(parseErrors) > 0 {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: %d files had parsing errors\n\n", len(parseErrors))
+			}
+
+			analyses := acc.Analyses()
+
+			if outputFormat == "ndjson" {
+				return writeNDJSON(outputFile, analyses)
+			}
+
+			var output []byte
+			if outputFormat == "json" {
+				output, err = json.MarshalIndent(analyses, "", "  ")
+				if err != nil {
+					return fmt.Errorf("marshaling JSON: %w", err)
+				}
+				output = append(output, '\n')
+			} else {
+				output = []byte(formatFieldsText(analyses, totalFiles, top))
+			}
+
+			if outputFile != "" {
+				return safety.WriteFile(outputFile, output, 0644)
+			}
+			_, _ = os.Stdout.Write(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&fields, "field", nil, "Field to analyze (repeatable; default: all fields)")
+	cmd.Flags().IntVar(&top, "top", 10, "Number of values to show per field in text output (0 for all)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson)")
 	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
 
 	return cmd
@@ -142,14 +305,18 @@ func newDuplicatesCommand() *cobra.Command {
 		Use:   "duplicates [vault-path]",
 		Short: "Find duplicate files",
 		Long: `Find duplicate files in your vault including:
-  - Content duplicates (identical file content)
+  - Content duplicates (identical file content, ignoring line endings and
+    trailing whitespace; frontmatter is only compared with --include-frontmatter)
   - Obsidian copies (files with ' 1', ' 2' suffixes)
   - Sync conflicts (syncthing, dropbox, etc.)
+  - Similar content (--type similar): notes sharing at least --similarity of
+    their words, found with MinHash so large vaults stay fast
   
 Example:
   mdnotes analyze duplicates --type obsidian
   mdnotes analyze duplicates --type sync-conflicts
-  mdnotes analyze duplicates --type content`,
+  mdnotes analyze duplicates --type content
+  mdnotes analyze duplicates --type similar --similarity 0.7`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
@@ -198,12 +365,38 @@ Example:
 				_, _ = fmt.Fprintf(os.Stderr, "\n")
 			}
 
-			ana := analyzer.NewAnalyzer()
+			if minSimilarity <= 0 || minSimilarity > 1 {
+				return fmt.Errorf("--similarity must be greater than 0 and at most 1, got %g", minSimilarity)
+			}
+
+			hashName := cfg.Analysis.Duplicates.Hash
+			if cmd.Flags().Changed("hash") {
+				hashName, _ = cmd.Flags().GetString("hash")
+			}
+			hasher, err := analyzer.NewHasher(hashName)
+			if err != nil {
+				if !cmd.Flags().Changed("hash") {
+					return errors.NewConfigError("", "analysis.duplicates.hash: "+err.Error())
+				}
+				return err
+			}
+			includeFrontmatter := cfg.Analysis.Duplicates.IncludeFrontmatter
+			if cmd.Flags().Changed("include-frontmatter") {
+				includeFrontmatter, _ = cmd.Flags().GetBool("include-frontmatter")
+			}
+
+			ana, saveCache := newAnalyzer(cmd, vaultPath)
+			defer saveCache()
+			ana.SetSimilarityThreshold(minSimilarity)
+			ana.SetContentHashing(hasher, includeFrontmatter)
 
 			// Find different types of duplicates based on flag
 			switch duplicateType {
 			case "obsidian":
 				obsidianCopies := ana.FindObsidianCopies(files)
+				if outputFormat == "ndjson" {
+					return writeNDJSON("", obsidianCopies)
+				}
 				if outputFormat == "json" {
 					data, err := json.MarshalIndent(obsidianCopies, "", "  ")
 					if err != nil {
@@ -216,6 +409,9 @@ Example:
 				}
 			case "sync-conflicts":
 				syncConflicts := ana.FindSyncConflictFiles(files)
+				if outputFormat == "ndjson" {
+					return writeNDJSON("", syncConflicts)
+				}
 				if outputFormat == "json" {
 					data, err := json.MarshalIndent(syncConflicts, "", "  ")
 					if err != nil {
@@ -228,6 +424,9 @@ Example:
 				}
 			case "content":
 				contentDuplicates := ana.FindContentDuplicates(files, analyzer.ExactMatch)
+				if outputFormat == "ndjson" {
+					return writeNDJSON("", contentDuplicates)
+				}
 				if outputFormat == "json" {
 					data, err := json.MarshalIndent(contentDuplicates, "", "  ")
 					if err != nil {
@@ -238,12 +437,30 @@ Example:
 					output := formatContentDuplicatesText(contentDuplicates)
 					_, _ = fmt.Print(output)
 				}
+			case "similar":
+				similarDuplicates := ana.FindContentDuplicates(files, analyzer.SimilarityMatch)
+				if outputFormat == "ndjson" {
+					return writeNDJSON("", similarDuplicates)
+				}
+				if outputFormat == "json" {
+					data, err := json.MarshalIndent(similarDuplicates, "", "  ")
+					if err != nil {
+						return fmt.Errorf("marshaling JSON: %w", err)
+					}
+					fmt.Println(string(data))
+				} else {
+					output := formatSimilarContentText(similarDuplicates, minSimilarity)
+					_, _ = fmt.Print(output)
+				}
 			default:
 				// Show all types by default
 				obsidianCopies := ana.FindObsidianCopies(files)
 				syncConflicts := ana.FindSyncConflictFiles(files)
 				contentDuplicates := ana.FindContentDuplicates(files, analyzer.ExactMatch)
 
+				if outputFormat == "ndjson" {
+					return writeDuplicatesNDJSON(obsidianCopies, syncConflicts, contentDuplicates)
+				}
 				if outputFormat == "json" {
 					result := map[string]interface{}{
 						"obsidian_copies":    obsidianCopies,
@@ -265,21 +482,38 @@ Example:
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
-	cmd.Flags().Float64Var(&minSimilarity, "similarity", 0.8, "Minimum similarity threshold (0.0-1.0)")
-	cmd.Flags().StringVarP(&duplicateType, "type", "t", "all", "Type of duplicates to find (all, obsidian, sync-conflicts, content)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson)")
+	cmd.Flags().Float64Var(&minSimilarity, "similarity", analyzer.DefaultSimilarityThreshold, "Minimum share of words similar notes have in common, for --type similar (0.0-1.0)")
+	cmd.Flags().String("hash", analyzer.DefaultHasher, "Hash for exact content duplicates (xxhash, sha256, md5)")
+	cmd.Flags().Bool("include-frontmatter", false, "Compare frontmatter as well as the body when finding exact content duplicates")
+	cmd.Flags().StringVarP(&duplicateType, "type", "t", "all", "Type of duplicates to find (all, obsidian, sync-conflicts, content, similar)")
 
 	return cmd
 }
 
 func newHealthCommand() *cobra.Command {
-	var outputFormat string
+	var (
+		outputFormat string
+		explain      bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "health [vault-path]",
 		Short: "Check vault health",
-		Long:  `Generate a comprehensive health report for your vault`,
-		Args:  cobra.MaximumNArgs(1),
+		Long: `Generate a comprehensive health report for your vault.
+
+The score starts at 100 and each health rule takes off its weight times what
+it measures: the share of files missing frontmatter (missing_frontmatter) or
+orphaned (orphaned_files), the share of links that are broken (broken_links),
+and the number of duplicate entries (duplicates). Rules tolerate measures up
+to their threshold. Weights, thresholds and which rules run are set in the
+analysis.health.rules section of the config; --explain shows each rule's
+contribution.
+
+Examples:
+  mdnotes analyze health .
+  mdnotes analyze health --explain .`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
 			if len(args) > 0 {
@@ -327,11 +561,21 @@ func newHealthCommand() *cobra.Command {
 			}
 
 			// Generate health report
-			ana := analyzer.NewAnalyzer()
+			ana, saveCache := newAnalyzer(cmd, vaultPath)
+			ana.SetLinkParser(processor.NewLinkParser())
+			rules, err := cli.HealthRules(cfg.Analysis.Health)
+			if err != nil {
+				return errors.NewConfigError("", err.Error())
+			}
+			ana.SetHealthRules(rules)
 			stats := ana.GenerateStats(files)
 			health := ana.GetHealthScore(stats)
+			saveCache()
 
 			// Output results
+			if outputFormat == "ndjson" {
+				return writeNDJSON("", []analyzer.HealthScore{health})
+			}
 			if outputFormat == "json" {
 				data, err := json.MarshalIndent(health, "", "  ")
 				if err != nil {
@@ -340,6 +584,9 @@ func newHealthCommand() *cobra.Command {
 				fmt.Println(string(data))
 			} else {
 				output := formatHealthText(health)
+				if explain {
+					output += formatHealthRulesText(health.Rules)
+				}
 				_, _ = fmt.Print(output)
 			}
 
@@ -347,7 +594,8 @@ func newHealthCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson)")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Show how each health rule contributed to the score")
 
 	return cmd
 }
@@ -362,58 +610,406 @@ func loadConfig(cmd *cobra.Command) (*config.Config, error) {
 	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
 }
 
-func formatStatsText(stats analyzer.VaultStats) string {
-	output := fmt.Sprintf(`Vault Statistics
-================
+// newAnalyzer creates an analyzer that reuses results stored in the vault
+// index for unchanged files. Call the returned function once analysis is done
+// to save the index; it is not written in dry-run mode.
+func newAnalyzer(cmd *cobra.Command, vaultPath string) (*analyzer.Analyzer, func()) {
+	return newIndexedAnalyzer(cmd, vault.FindIndex(vaultPath))
+}
 
-Files:
-  Total files: %d
-  Files with frontmatter: %d
-  Files without frontmatter: %d
+// newIndexedAnalyzer is newAnalyzer for an index the caller has already
+// loaded, so a scan and the analysis can share it. Results aren't cached
+// without an index or with --no-cache.
+func newIndexedAnalyzer(cmd *cobra.Command, idx *vault.Index) (*analyzer.Analyzer, func()) {
+	ana := analyzer.NewAnalyzer()
 
-Content:
-  Total size: %d bytes
-  Average file size: %.1f bytes
+	noCache, _ := cmd.Flags().GetBool("no-cache")
+	if noCache || idx == nil {
+		return ana, func() {}
+	}
 
-Frontmatter Fields:
-`, stats.TotalFiles, stats.FilesWithFrontmatter, stats.FilesWithoutFrontmatter,
-		stats.TotalSize, float64(stats.TotalSize)/float64(stats.TotalFiles))
+	cache := analyzer.NewResultCache(idx)
+	ana.SetCache(cache)
+
+	return ana, func() {
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if verbose {
+			reused, recomputed := cache.Stats()
+			_, _ = fmt.Fprintf(os.Stderr, "Analysis cache: %d results reused, %d recomputed\n", reused, recomputed)
+		}
+		if dryRun {
+			return
+		}
+		if err := idx.Save(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// configureQuality applies the configured quality profile: criterion weights,
+// readability language, band thresholds, field criteria and quality plugins. It returns the name of
+// the profile used, or "" without one.
+func configureQuality(ana *analyzer.Analyzer, quality config.QualityConfig, profile, vaultPath string) (string, error) {
+	quality, err := quality.WithProfile(profile)
+	if err != nil {
+		return "", err
+	}
+	ana.SetQualityWeights(quality.Weights)
+	ana.SetReadabilityLanguage(quality.Language)
+	if err := ana.SetQualityThresholds(quality.Thresholds); err != nil {
+		return "", fmt.Errorf("quality thresholds: %w", err)
+	}
+	for _, field := range quality.Fields {
+		weight := field.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		ana.AddQualityScorer(analyzer.NewFieldQualityScorer(field.Name, field.Fields), weight)
+	}
+
+	dir, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving vault path: %w", err)
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for _, plugin := range quality.Plugins {
+		timeout, _ := time.ParseDuration(plugin.Timeout)
+		scorer, err := analyzer.NewExecQualityScorer(plugin.Name, plugin.Command, plugin.Args, dir, timeout)
+		if err != nil {
+			return "", err
+		}
+		weight := plugin.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		ana.AddQualityScorer(scorer, weight)
+	}
+	return quality.Profile, nil
+}
+
+// scanVault walks the vault, reusing the vault index if one has been built
+func scanVault(cmd *cobra.Command, vaultPath string, ignorePatterns []string) ([]*vault.VaultFile, error) {
+	var files []*vault.VaultFile
+	err := streamVault(cmd, vaultPath, ignorePatterns, vault.FindIndex(vaultPath), func(file *vault.VaultFile) {
+		files = append(files, file)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// streamVault walks the vault, passing each file to fn as it is parsed rather
+// than holding them all, and reusing idx, the vault index, if it isn't nil
+func streamVault(cmd *cobra.Command, vaultPath string, ignorePatterns []string, idx *vault.Index, fn func(*vault.VaultFile)) error {
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(ignorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(idx),
+		vault.WithWorkers(workers),
+	)
+	err := scanner.WalkWithCallback(vaultPath, func(file *vault.VaultFile) error {
+		fn(file)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); idx != nil && !dryRun {
+		if err := idx.Save(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// writeNDJSON streams records one per line to outputFile, or stdout if empty
+func writeNDJSON[T any](outputFile string, records []T) error {
+	if outputFile == "" {
+		return cli.WriteNDJSON(os.Stdout, records)
+	}
+
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	if err := cli.WriteNDJSON(f, records); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeDuplicatesNDJSON streams every kind of duplicate, tagging each record with its type
+func writeDuplicatesNDJSON(obsidianCopies []analyzer.ObsidianCopy, syncConflicts []analyzer.SyncConflictFile, contentDuplicates []analyzer.ContentDuplicate) error {
+	w := cli.NewNDJSONWriter(os.Stdout)
+	for _, c := range obsidianCopies {
+		if err := w.Write(struct {
+			Type string `json:"type"`
+			analyzer.ObsidianCopy
+		}{"obsidian_copy", c}); err != nil {
+			return err
+		}
+	}
+	for _, c := range syncConflicts {
+		if err := w.Write(struct {
+			Type string `json:"type"`
+			analyzer.SyncConflictFile
+		}{"sync_conflict", c}); err != nil {
+			return err
+		}
+	}
+	for _, d := range contentDuplicates {
+		if err := w.Write(struct {
+			Type string `json:"type"`
+			analyzer.ContentDuplicate
+		}{"content_duplicate", d}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkRecord is the per-file NDJSON form of a link analysis
+type linkRecord struct {
+	Path          string   `json:"path"`
+	OutboundLinks []string `json:"outbound_links"`
+	InboundCount  int      `json:"inbound_count"`
+	Orphaned      bool     `json:"orphaned"`
+	Cluster       int      `json:"cluster,omitempty"` // 1-based index into the clusters, largest first
+	InCycle       bool     `json:"in_cycle,omitempty"`
+}
+
+// linkRecords flattens a link analysis into one record per file, sorted by path
+func linkRecords(analysis analyzer.LinkAnalysis) []linkRecord {
+	inbound := make(map[string]int)
+	for _, targets := range analysis.LinkGraph {
+		for _, target := range targets {
+			inbound[target]++
+		}
+	}
+	orphaned := make(map[string]bool, len(analysis.OrphanedFiles))
+	for _, path := range analysis.OrphanedFiles {
+		orphaned[path] = true
+	}
+	cluster := make(map[string]int)
+	for i, c := range analysis.Clusters {
+		for _, path := range c.Files {
+			cluster[path] = i + 1
+		}
+	}
+	inCycle := make(map[string]bool)
+	for _, cycle := range analysis.Cycles {
+		for _, path := range cycle.Files {
+			inCycle[path] = true
+		}
+	}
+
+	paths := make([]string, 0, len(analysis.LinkGraph))
+	for path := range analysis.LinkGraph {
+		paths = append(paths, path)
+	}
+	for path := range orphaned {
+		if _, ok := analysis.LinkGraph[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	records := make([]linkRecord, 0, len(paths))
+	for _, path := range paths {
+		records = append(records, linkRecord{
+			Path:          path,
+			OutboundLinks: analysis.LinkGraph[path],
+			InboundCount:  inbound[path],
+			Orphaned:      orphaned[path],
+			Cluster:       cluster[path],
+			InCycle:       inCycle[path],
+		})
+	}
+	return records
+}
+
+func formatStatsText(stats analyzer.VaultStats) string {
+	output := underline(i18n.T("Vault Statistics")) + "\n" +
+		i18n.T("Files:") + "\n" +
+		"  " + i18n.T("Total files: %d", stats.TotalFiles) + "\n" +
+		"  " + i18n.T("Files with frontmatter: %d", stats.FilesWithFrontmatter) + "\n" +
+		"  " + i18n.T("Files without frontmatter: %d", stats.FilesWithoutFrontmatter) + "\n\n" +
+		i18n.T("Content:") + "\n" +
+		"  " + i18n.T("Total size: %d bytes", stats.TotalSize) + "\n" +
+		"  " + i18n.T("Average file size: %.1f bytes", float64(stats.TotalSize)/float64(stats.TotalFiles)) + "\n\n" +
+		i18n.T("Frontmatter Fields:") + "\n"
 
 	for field, count := range stats.FieldPresence {
 		percentage := float64(count) / float64(stats.TotalFiles) * 100
-		output += fmt.Sprintf("  %s: %d files (%.1f%%)\n", field, count, percentage)
+		output += "  " + i18n.T("%s: %d files (%.1f%%)", field, count, percentage) + "\n"
 	}
 
 	if len(stats.TagDistribution) > 0 {
-		output += "\nTop Tags:\n"
+		output += "\n" + i18n.T("Top Tags:") + "\n"
 		for tag, count := range stats.TagDistribution {
-			output += fmt.Sprintf("  #%s: %d files\n", tag, count)
+			output += "  " + i18n.T("#%s: %d files", tag, count) + "\n"
 		}
 	}
 
+	output += formatTopFilesText(stats.Top)
+	output += formatFoldersText(stats.Folders)
+
 	return output
 }
 
-func formatHealthText(health analyzer.HealthScore) string {
-	return fmt.Sprintf(`Vault Health Report
-==================
+// folderTopTags is how many of each folder's tags the text report lists
+const folderTopTags = 3
 
-Health Level: %s
-Score: %.1f/100
+func formatFoldersText(folders []analyzer.FolderStats) string {
+	if len(folders) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n", i18n.T("Folders:"))
+	for _, folder := range folders {
+		indent := strings.Repeat("  ", max(folder.Depth, 1))
+		name := folder.Path
+		if name == "" {
+			name = i18n.T("(vault root)")
+		}
+		fmt.Fprintf(&b, "%s%s\n", indent, i18n.T("%s: %d files, %d words, %d modified in the last %d days, last modified %s",
+			name, folder.Files, folder.Words, folder.RecentFiles, analyzer.RecentDays, folder.LastModified.Format("2006-01-02")))
 
-Issues Found:
-%s
+		tags := make([]string, 0, len(folder.TagDistribution))
+		for tag := range folder.TagDistribution {
+			tags = append(tags, tag)
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if folder.TagDistribution[tags[i]] != folder.TagDistribution[tags[j]] {
+				return folder.TagDistribution[tags[i]] > folder.TagDistribution[tags[j]]
+			}
+			return tags[i] < tags[j]
+		})
+		if len(tags) > folderTopTags {
+			tags = tags[:folderTopTags]
+		}
+		for i, tag := range tags {
+			tags[i] = fmt.Sprintf("#%s (%d)", tag, folder.TagDistribution[tag])
+		}
+		if len(tags) > 0 {
+			fmt.Fprintf(&b, "%s  %s\n", indent, i18n.T("Top tags: %s", strings.Join(tags, ", ")))
+		}
+	}
+	return b.String()
+}
+
+// underline underlines a report title to its width
+func underline(title string) string {
+	return title + "\n" + strings.Repeat("=", utf8.RuneCountInString(title)) + "\n"
+}
 
-Suggestions:
-%s
-`, health.Level, health.Score,
-		formatIssues(health.Issues),
-		formatSuggestions(health.Suggestions))
+// topHeadings titles each ranking of the --top report and labels its values
+var topHeadings = map[string][2]string{
+	"size":     {"Largest Files", "bytes"},
+	"words":    {"Longest Files", "words"},
+	"age":      {"Oldest Files", "days since modified"},
+	"inbound":  {"Most Linked Files", "inbound links"},
+	"outbound": {"Most Linking Files", "outbound links"},
+}
+
+func formatTopFilesText(top analyzer.TopFiles) string {
+	var b strings.Builder
+	for _, criterion := range analyzer.TopCriteria {
+		ranked, ok := top[criterion]
+		if !ok {
+			continue
+		}
+		heading := topHeadings[criterion]
+		fmt.Fprintf(&b, "\n%s:\n", i18n.Text(heading[0]))
+		for i, file := range ranked {
+			fmt.Fprintf(&b, "  %2d. %s (%d %s)\n", i+1, file.Path, file.Value, i18n.Text(heading[1]))
+		}
+	}
+	return b.String()
+}
+
+func formatFieldsText(analyses []analyzer.FieldAnalysis, totalFiles, top int) string {
+	var b strings.Builder
+	b.WriteString("Field Analysis\n==============\n")
+
+	if len(analyses) == 0 {
+		b.WriteString("\nNo frontmatter fields found\n")
+		return b.String()
+	}
+
+	for _, fa := range analyses {
+		fmt.Fprintf(&b, "\n%s\n", fa.FieldName)
+		coverage := 0.0
+		if totalFiles > 0 {
+			coverage = float64(fa.TotalFiles) / float64(totalFiles) * 100
+		}
+		fmt.Fprintf(&b, "  Present: %d files (%.1f%%), missing: %d\n", fa.TotalFiles, coverage, fa.MissingCount)
+		if fa.TotalFiles == 0 {
+			continue
+		}
+
+		types := make([]string, 0, len(fa.TypeDistribution))
+		for typeName, count := range fa.TypeDistribution {
+			types = append(types, fmt.Sprintf("%s (%d)", typeName, count))
+		}
+		sort.Strings(types)
+		fmt.Fprintf(&b, "  Type: %s", fa.PredominantType)
+		if len(types) > 1 {
+			fmt.Fprintf(&b, " [mixed: %s]", strings.Join(types, ", "))
+		}
+		b.WriteString("\n")
+
+		fmt.Fprintf(&b, "  Unique values: %d\n", fa.UniqueValues)
+		values := fa.SortedValues()
+		if top > 0 && len(values) > top {
+			values = values[:top]
+		}
+		for _, vc := range values {
+			fmt.Fprintf(&b, "    %-30s %d\n", vc.Value, vc.Count)
+		}
+		if len(values) < fa.UniqueValues {
+			fmt.Fprintf(&b, "    ... and %d more\n", fa.UniqueValues-len(values))
+		}
+	}
+
+	return b.String()
+}
+
+func formatHealthText(health analyzer.HealthScore) string {
+	return underline(i18n.T("Vault Health Report")) + "\n" +
+		i18n.T("Health Level: %s", i18n.Text(string(health.Level))) + "\n" +
+		i18n.T("Score: %.1f/100", health.Score) + "\n\n" +
+		i18n.T("Issues Found:") + "\n" +
+		formatIssues(health.Issues) + "\n\n" +
+		i18n.T("Suggestions:") + "\n" +
+		formatSuggestions(health.Suggestions) + "\n"
+}
+
+func formatHealthRulesText(rules []analyzer.RuleScore) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n", i18n.T("Rule Contributions:"))
+	for _, rule := range rules {
+		if !rule.Enabled {
+			fmt.Fprintf(&b, "  %s\n", i18n.T("%s: disabled", rule.ID))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s\n", i18n.T("%s: %.1f point penalty (%d affected; measured %.2f, weight %.1f, threshold %.2f)",
+			rule.ID, rule.Penalty, rule.Count, rule.Value, rule.Weight, rule.Threshold))
+	}
+	return b.String()
 }
 
 func formatIssues(issues []string) string {
 	if len(issues) == 0 {
-		return "  No issues found. Great job!"
+		return "  " + i18n.T("No issues found. Great job!")
 	}
 
 	output := ""
@@ -425,7 +1021,7 @@ func formatIssues(issues []string) string {
 
 func formatSuggestions(suggestions []string) string {
 	if len(suggestions) == 0 {
-		return "  No suggestions at this time."
+		return "  " + i18n.T("No suggestions at this time.")
 	}
 
 	output := ""
@@ -442,14 +1038,19 @@ func newLinksCommand() *cobra.Command {
 		showGraph      bool
 		maxDepth       int
 		minConnections int
+		showClusters   bool
 	)
 
 	cmd := &cobra.Command{
 		Use:     "links [vault-path]",
 		Aliases: []string{"l"},
 		Short:   "Analyze link structure and connectivity",
-		Long:    `Analyze the link structure of your vault, including connectivity graphs and orphaned files`,
-		Args:    cobra.MaximumNArgs(1),
+		Long: `Analyze the link structure of your vault, including connectivity graphs and orphaned files.
+
+The link graph can be exported for Graphviz (dot), Gephi (graphml, gexf) or
+embedding in a note (mermaid). Exports include notes with at least
+--min-connections links and, with --clusters, color or label notes by cluster.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
 			if len(args) > 0 {
@@ -462,23 +1063,31 @@ func newLinksCommand() *cobra.Command {
 				return fmt.Errorf("loading config: %w", err)
 			}
 
-			// Scan vault files
-			scanner := vault.NewScanner(
-				vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
-				vault.WithContinueOnErrors(),
-			)
-			files, err := scanner.Walk(vaultPath)
-			if err != nil {
+			// Fold each file into the link graph as it is scanned, keeping
+			// only its paths, links and tags. The scan and the analysis share
+			// the vault index.
+			idx := vault.FindIndex(vaultPath)
+			ana, saveCache := newIndexedAnalyzer(cmd, idx)
+			ana.SetLinkParser(processor.NewLinkParser())
+			acc := ana.NewLinkAccumulator()
+			if err := streamVault(cmd, vaultPath, cfg.Vault.IgnorePatterns, idx, acc.Add); err != nil {
 				return fmt.Errorf("scanning vault: %w", err)
 			}
-
-			// Generate link analysis
-			ana := analyzer.NewAnalyzer()
-			linkParser := processor.NewLinkParser()
-			ana.SetLinkParser(linkParser)
-			linkAnalysis := ana.AnalyzeLinks(files)
+			linkAnalysis := acc.Analysis()
+			saveCache()
+			if !showClusters {
+				linkAnalysis.Cycles = nil
+				linkAnalysis.Clusters = nil
+			}
 
 			// Output results
+			if analyzer.IsGraphFormat(outputFormat) {
+				graph := analyzer.NewNoteGraph(acc.Files(), linkAnalysis.Clusters, minConnections)
+				return analyzer.WriteGraph(os.Stdout, graph, outputFormat)
+			}
+			if outputFormat == "ndjson" {
+				return writeNDJSON("", linkRecords(linkAnalysis))
+			}
 			if outputFormat == "json" {
 				data, err := json.MarshalIndent(linkAnalysis, "", "  ")
 				if err != nil {
@@ -494,10 +1103,11 @@ func newLinksCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson, dot, graphml, mermaid, gexf)")
 	cmd.Flags().BoolVar(&showGraph, "graph", false, "Show text-based link graph visualization")
 	cmd.Flags().IntVar(&maxDepth, "depth", 3, "Maximum depth for graph visualization")
 	cmd.Flags().IntVar(&minConnections, "min-connections", 1, "Minimum connections to show in graph")
+	cmd.Flags().BoolVar(&showClusters, "clusters", false, "Report link cycles and clusters of closely linked notes")
 
 	return cmd
 }
@@ -508,14 +1118,22 @@ func newContentCommand() *cobra.Command {
 		outputFormat  string
 		includeScores bool
 		minScore      float64
+		suggestSplits bool
+		splitMinWords int
+		profileName   string
 	)
 
 	cmd := &cobra.Command{
 		Use:     "content [vault-path]",
 		Aliases: []string{"c"},
 		Short:   "Analyze content quality and completeness",
-		Long:    `Analyze the quality of content in your vault, including completeness scores and suggestions`,
-		Args:    cobra.MaximumNArgs(1),
+		Long: `Analyze the quality of content in your vault, including completeness scores and suggestions.
+
+With --suggest-splits, long multi-topic notes with a low atomicity score get a
+plan for splitting them: their H1 sections (or H2 sections, with at most one
+H1) with word counts, and an 'mdnotes split' command moving each section of at
+least --split-min-words words into a note of its own.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
 			if len(args) > 0 {
@@ -531,21 +1149,38 @@ func newContentCommand() *cobra.Command {
 				return fmt.Errorf("loading config: %w", err)
 			}
 
-			// Scan vault files
-			scanner := vault.NewScanner(
-				vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
-				vault.WithContinueOnErrors(),
-			)
-			files, err := scanner.Walk(vaultPath)
+			// Score each file as it is scanned, keeping only its scores. The
+			// scan and the analysis share the vault index.
+			idx := vault.FindIndex(vaultPath)
+			ana, saveCache := newIndexedAnalyzer(cmd, idx)
+			profile, err := configureQuality(ana, cfg.Analysis.Quality, profileName, vaultPath)
 			if err != nil {
+				return err
+			}
+			acc := ana.NewContentAccumulator()
+			if suggestSplits {
+				acc.SuggestSplits(analyzer.DefaultSplitMaxAtomicity, splitMinWords)
+			}
+			if err := streamVault(cmd, vaultPath, cfg.Vault.IgnorePatterns, idx, acc.Add); err != nil {
 				return fmt.Errorf("scanning vault: %w", err)
 			}
-
-			// Generate content analysis
-			ana := analyzer.NewAnalyzer()
-			contentAnalysis := ana.AnalyzeContentQuality(files)
+			contentAnalysis := acc.Analysis()
+			contentAnalysis.Profile = profile
+			saveCache()
+			if suggestSplits {
+				for i := range contentAnalysis.SplitSuggestions {
+					suggestion := &contentAnalysis.SplitSuggestions[i]
+					suggestion.Command = splitCommand(filepath.Join(vaultPath, suggestion.Path), suggestion.SplitHeadings())
+				}
+			}
 
 			// Output results
+			if outputFormat == "ndjson" {
+				if suggestSplits {
+					return writeNDJSON("", contentAnalysis.SplitSuggestions)
+				}
+				return writeNDJSON("", contentAnalysis.FileScores)
+			}
 			if outputFormat == "json" {
 				data, err := json.MarshalIndent(contentAnalysis, "", "  ")
 				if err != nil {
@@ -554,6 +1189,9 @@ func newContentCommand() *cobra.Command {
 				fmt.Println(string(data))
 			} else {
 				output := formatContentAnalysisText(contentAnalysis, includeScores, minScore, verbose)
+				if suggestSplits {
+					output += formatSplitSuggestionsText(contentAnalysis.SplitSuggestions)
+				}
 				_, _ = fmt.Print(output)
 			}
 
@@ -561,27 +1199,87 @@ func newContentCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, table, csv)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson, table, csv)")
 	cmd.Flags().BoolVar(&includeScores, "scores", false, "Include individual file quality scores")
 	cmd.Flags().Float64Var(&minScore, "min-score", 0.0, "Minimum quality score to display (0.0-100)")
+	cmd.Flags().BoolVar(&suggestSplits, "suggest-splits", false, "Suggest how to split long multi-topic notes, with split commands")
+	cmd.Flags().IntVar(&splitMinWords, "split-min-words", analyzer.DefaultSplitMinWords, "Fewest words in a section worth splitting into its own note")
+	cmd.Flags().StringVar(&profileName, "profile", "", "Quality profile from analysis.quality.profiles (default: analysis.quality.profile)")
 
 	return cmd
 }
 
+func formatSplitSuggestionsText(suggestions []analyzer.SplitSuggestion) string {
+	var b strings.Builder
+	b.WriteString("\n" + i18n.T("Split Suggestions:") + "\n")
+	if len(suggestions) == 0 {
+		b.WriteString("  " + i18n.T("No notes need splitting") + "\n")
+		return b.String()
+	}
+	for _, suggestion := range suggestions {
+		b.WriteString("\n  " + i18n.T("%s (atomicity %.0f%%, %d words)", suggestion.Path, suggestion.AtomicityScore*100, suggestion.Words) + "\n")
+		for _, section := range suggestion.Sections {
+			marker := " "
+			if section.Split {
+				marker = "→"
+			}
+			b.WriteString("    " + i18n.T("%s %s (line %d, %d words)", marker, section.Heading, section.Line, section.Words) + "\n")
+		}
+		b.WriteString("    " + i18n.T("Run: %s", suggestion.Command) + "\n")
+	}
+	return b.String()
+}
+
+// splitCommand returns the mdnotes split command splitting headings out of the
+// note at path, quoted for a POSIX shell
+func splitCommand(path string, headings []string) string {
+	parts := []string{"mdnotes", "split", shellQuote(path)}
+	for _, heading := range headings {
+		parts = append(parts, "--heading", shellQuote(heading))
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // newTrendsCommand creates the vault growth trends analysis command
 func newTrendsCommand() *cobra.Command {
 	var (
 		outputFormat string
 		timespan     string
 		granularity  string
+		dateSources  []string
+		createdField string
 	)
 
 	cmd := &cobra.Command{
 		Use:     "trends [vault-path]",
 		Aliases: []string{"t"},
 		Short:   "Analyze vault growth trends and patterns",
-		Long:    `Analyze growth trends, writing patterns, and temporal statistics for your vault`,
-		Args:    cobra.MaximumNArgs(1),
+		Long: `Analyze growth trends, writing patterns, and temporal statistics for your vault.
+
+Each note is dated by the first source in --date-source that knows when it
+was written:
+
+  created   the created frontmatter field (or --created-field)
+  filename  a date in the filename, such as 2025-03-01 or 20250301120000
+  git       the note's first commit, following renames
+  modified  the file's modification time
+
+Modification times are reset by sync tools and bulk edits, so they come last.
+The chain, field and filename pattern can be set in the config file:
+
+analysis:
+  trends:
+    date_sources: [created, filename, git, modified]
+    created_field: created
+    filename_pattern: '(\d{4}-\d{2}-\d{2})'`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
 			if len(args) > 0 {
@@ -595,26 +1293,48 @@ func newTrendsCommand() *cobra.Command {
 			}
 
 			// Scan vault files
-			scanner := vault.NewScanner(
-				vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
-				vault.WithContinueOnErrors(),
-			)
-			files, err := scanner.Walk(vaultPath)
+			files, err := scanVault(cmd, vaultPath, cfg.Vault.IgnorePatterns)
 			if err != nil {
 				return fmt.Errorf("scanning vault: %w", err)
 			}
 
+			// Date notes by the configured chain of sources
+			trendsCfg := cfg.Analysis.Trends
+			if cmd.Flags().Changed("date-source") {
+				trendsCfg.DateSources = dateSources
+			}
+			if cmd.Flags().Changed("created-field") {
+				trendsCfg.CreatedField = createdField
+			}
+			dates, err := analyzer.NewNoteDates(trendsCfg.DateSources, trendsCfg.CreatedField, trendsCfg.FilenamePattern)
+			if err != nil {
+				return err
+			}
+			if dates.UsesGit() {
+				first, err := git.FirstCommits(vaultPath)
+				if err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "Warning: not dating notes by git history: %v\n", err)
+				}
+				dates.SetGitDates(first)
+			}
+
 			// Generate trends analysis
 			ana := analyzer.NewAnalyzer()
+			ana.SetNoteDates(dates)
 			trendsAnalysis := ana.AnalyzeTrends(files, timespan, granularity)
 
 			// Output results
+			if outputFormat == "ndjson" {
+				return writeNDJSON("", []analyzer.TrendsAnalysis{trendsAnalysis})
+			}
 			if outputFormat == "json" {
 				data, err := json.MarshalIndent(trendsAnalysis, "", "  ")
 				if err != nil {
 					return fmt.Errorf("marshaling JSON: %w", err)
 				}
 				fmt.Println(string(data))
+			} else if outputFormat == "heatmap" {
+				fmt.Print(formatTrendsHeatmap(trendsAnalysis))
 			} else {
 				output := formatTrendsAnalysisText(trendsAnalysis)
 				_, _ = fmt.Print(output)
@@ -624,9 +1344,11 @@ func newTrendsCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson, heatmap)")
 	cmd.Flags().StringVar(&timespan, "timespan", "1y", "Time span to analyze (1w, 1m, 3m, 6m, 1y, all)")
 	cmd.Flags().StringVar(&granularity, "granularity", "month", "Time granularity (day, week, month, quarter)")
+	cmd.Flags().StringSliceVar(&dateSources, "date-source", nil, "Sources of note dates, tried in order: created, filename, git, modified (default: analysis.trends.date_sources, or created,filename,modified)")
+	cmd.Flags().StringVar(&createdField, "created-field", "", "Frontmatter field holding a note's creation date (default: analysis.trends.created_field, or created)")
 
 	return cmd
 }
@@ -680,48 +1402,68 @@ Connectivity:
 		}
 	}
 
+	if len(analysis.Cycles) > 0 {
+		output += fmt.Sprintf("\nLink Cycles (%d):\n", len(analysis.Cycles))
+		for i, cycle := range analysis.Cycles {
+			if i >= 10 { // Show top 10
+				break
+			}
+			output += fmt.Sprintf("  %d. %d notes: %s\n", i+1, len(cycle.Files), strings.Join(cycle.Files, ", "))
+		}
+	}
+
+	if len(analysis.Clusters) > 0 {
+		output += fmt.Sprintf("\nClusters (%d):\n", len(analysis.Clusters))
+		for i, cluster := range analysis.Clusters {
+			if i >= 10 { // Show top 10
+				break
+			}
+			output += fmt.Sprintf("  %d. %d notes, %d internal links\n", i+1, cluster.Size, cluster.InternalLinks)
+			output += fmt.Sprintf("     Representatives: %s\n", strings.Join(cluster.Representatives, ", "))
+			if len(cluster.Tags) > 0 {
+				output += fmt.Sprintf("     Tags: %s\n", strings.Join(cluster.Tags, ", "))
+			}
+		}
+	}
+
 	return output
 }
 
 func formatContentAnalysisText(analysis analyzer.ContentAnalysis, includeScores bool, minScore float64, verbose bool) string {
-	output := fmt.Sprintf(`Zettelkasten Content Quality Analysis
-====================================
-
-Overall Quality Score: %.1f/100
-
-Scoring based on Zettelkasten principles:
-  1. Readability (Flesch-Kincaid Reading Ease)
-  2. Link Density (outbound links per 100 words)
-  3. Completeness (title, summary, word count)
-  4. Atomicity (one concept per note)
-  5. Recency (recently modified content)
-
-Distribution:
-  Excellent (90-100): %d files
-  Good (75-89): %d files  
-  Fair (60-74): %d files
-  Poor (40-59): %d files
-  Critical (0-39): %d files
-
-Content Metrics:
-  Average content length: %.0f characters
-  Average word count: %.0f words
-  Files with frontmatter: %d
-  Files with headings: %d
-  Files with links: %d
-
-`, analysis.OverallScore,
-		analysis.ScoreDistribution["excellent"], analysis.ScoreDistribution["good"],
-		analysis.ScoreDistribution["fair"], analysis.ScoreDistribution["poor"],
-		analysis.ScoreDistribution["critical"],
-		analysis.AvgContentLength, analysis.AvgWordCount,
-		analysis.FilesWithFrontmatter, analysis.FilesWithHeadings, analysis.FilesWithLinks)
+	t := analysis.ScoreThresholds
+	if t == nil {
+		t = analyzer.DefaultQualityThresholds
+	}
+	profile := ""
+	if analysis.Profile != "" {
+		profile = i18n.T("Quality profile: %s", analysis.Profile) + "\n"
+	}
+	output := underline(i18n.T("Zettelkasten Content Quality Analysis")) + "\n" +
+		i18n.T("Overall Quality Score: %.1f/100", analysis.OverallScore) + "\n" + profile + "\n" +
+		i18n.T("Scoring based on Zettelkasten principles:") + "\n" +
+		"  1. " + i18n.T("Readability (Flesch-Kincaid Reading Ease)") + "\n" +
+		"  2. " + i18n.T("Link Density (outbound links per 100 words)") + "\n" +
+		"  3. " + i18n.T("Completeness (title, summary, word count)") + "\n" +
+		"  4. " + i18n.T("Atomicity (one concept per note)") + "\n" +
+		"  5. " + i18n.T("Recency (recently modified content)") + "\n\n" +
+		i18n.T("Distribution:") + "\n" +
+		"  " + i18n.T("Excellent (%.0f-100): %d files", t["excellent"], analysis.ScoreDistribution["excellent"]) + "\n" +
+		"  " + i18n.T("Good (%.0f-%.0f): %d files", t["good"], t["excellent"]-1, analysis.ScoreDistribution["good"]) + "\n" +
+		"  " + i18n.T("Fair (%.0f-%.0f): %d files", t["fair"], t["good"]-1, analysis.ScoreDistribution["fair"]) + "\n" +
+		"  " + i18n.T("Poor (%.0f-%.0f): %d files", t["poor"], t["fair"]-1, analysis.ScoreDistribution["poor"]) + "\n" +
+		"  " + i18n.T("Critical (0-%.0f): %d files", t["poor"]-1, analysis.ScoreDistribution["critical"]) + "\n\n" +
+		i18n.T("Content Metrics:") + "\n" +
+		"  " + i18n.T("Average content length: %.0f characters", analysis.AvgContentLength) + "\n" +
+		"  " + i18n.T("Average word count: %.0f words", analysis.AvgWordCount) + "\n" +
+		"  " + i18n.T("Files with frontmatter: %d", analysis.FilesWithFrontmatter) + "\n" +
+		"  " + i18n.T("Files with headings: %d", analysis.FilesWithHeadings) + "\n" +
+		"  " + i18n.T("Files with links: %d", analysis.FilesWithLinks) + "\n\n"
 
 	// Show worst-scoring files in the summary
 	if len(analysis.FileScores) > 0 {
 		worstFiles := getWorstScoringFiles(analysis.FileScores, 5)
 		if len(worstFiles) > 0 {
-			output += "⚠️  Files Needing Attention (lowest scores):\n"
+			output += "⚠️  " + i18n.T("Files Needing Attention (lowest scores):") + "\n"
 			for i, score := range worstFiles {
 				output += fmt.Sprintf("  %d. %.1f  %s\n", i+1, score.Score, score.Path)
 				if len(score.SuggestedFixes) > 0 && len(score.SuggestedFixes[0]) > 0 {
@@ -733,7 +1475,7 @@ Content Metrics:
 	}
 
 	if len(analysis.QualityIssues) > 0 {
-		output += "Quality Issues Found:\n"
+		output += i18n.T("Quality Issues Found:") + "\n"
 		for _, issue := range analysis.QualityIssues {
 			output += fmt.Sprintf("  - %s\n", issue)
 		}
@@ -741,7 +1483,7 @@ Content Metrics:
 	}
 
 	if len(analysis.Suggestions) > 0 {
-		output += "Improvement Suggestions:\n"
+		output += i18n.T("Improvement Suggestions:") + "\n"
 		for _, suggestion := range analysis.Suggestions {
 			output += fmt.Sprintf("  - %s\n", suggestion)
 		}
@@ -751,10 +1493,10 @@ Content Metrics:
 	// Show individual file scores
 	if includeScores && len(analysis.FileScores) > 0 {
 		if verbose {
-			output += fmt.Sprintf("📊 Individual File Scores (showing files >= %.1f):\n", minScore)
-			output += "====================================================================\n"
-			output += "Score  File                                    Read Link Comp Atom Rec\n"
-			output += "--------------------------------------------------------------------\n"
+			output += "📊 " + i18n.T("Individual File Scores (showing files >= %.1f):", minScore) + "\n"
+			output += "=========================================================================\n"
+			output += "Score  File                                    Read Link Comp Atom Rec  Lang\n"
+			output += "-------------------------------------------------------------------------\n"
 			for _, score := range analysis.FileScores {
 				if score.Score >= minScore {
 					// Truncate path if too long
@@ -763,19 +1505,32 @@ Content Metrics:
 						displayPath = "..." + displayPath[len(displayPath)-32:]
 					}
 
-					output += fmt.Sprintf("%-6.1f %-35s %4.0f %4.0f %4.0f %4.0f %4.0f\n",
+					output += fmt.Sprintf("%-6.1f %-35s %4.0f %4.0f %4.0f %4.0f %4.0f  %s\n",
 						score.Score, displayPath,
 						score.ReadabilityScore*100, score.LinkDensityScore*100,
-						score.CompletenessScore*100, score.AtomicityScore*100, score.RecencyScore*100)
+						score.CompletenessScore*100, score.AtomicityScore*100, score.RecencyScore*100, score.Language)
+
+					if len(score.PluginScores) > 0 {
+						names := make([]string, 0, len(score.PluginScores))
+						for name := range score.PluginScores {
+							names = append(names, name)
+						}
+						sort.Strings(names)
+						var parts []string
+						for _, name := range names {
+							parts = append(parts, fmt.Sprintf("%s %.0f", name, score.PluginScores[name]*100))
+						}
+						output += "       " + i18n.T("Plugins: %s", strings.Join(parts, ", ")) + "\n"
+					}
 
 					if verbose && len(score.SuggestedFixes) > 0 {
-						output += fmt.Sprintf("       Improvements: %s\n", strings.Join(score.SuggestedFixes, "; "))
+						output += "       " + i18n.T("Improvements: %s", strings.Join(score.SuggestedFixes, "; ")) + "\n"
 					}
 				}
 			}
-			output += "\nMetrics: Read=Readability, Link=Link Density, Comp=Completeness, Atom=Atomicity, Rec=Recency\n"
+			output += "\n" + i18n.T("Metrics: Read=Readability, Link=Link Density, Comp=Completeness, Atom=Atomicity, Rec=Recency") + "\n"
 		} else {
-			output += fmt.Sprintf("Individual File Scores (showing files >= %.1f):\n", minScore)
+			output += i18n.T("Individual File Scores (showing files >= %.1f):", minScore) + "\n"
 			output += "================================================================\n"
 			for _, score := range analysis.FileScores {
 				if score.Score >= minScore {
@@ -840,6 +1595,16 @@ Activity Patterns:
 		analysis.MostActiveDay, analysis.MostActiveMonth,
 		analysis.WritingStreak, analysis.ActiveDays, analysis.TotalDays, analysis.ActivityPercentage)
 
+	var dated []string
+	for _, source := range []string{analyzer.DateSourceCreated, analyzer.DateSourceFilename, analyzer.DateSourceGit, analyzer.DateSourceModified} {
+		if n := analysis.DateSources[source]; n > 0 {
+			dated = append(dated, fmt.Sprintf("%s %d", source, n))
+		}
+	}
+	if len(dated) > 0 {
+		output += "Notes dated by: " + strings.Join(dated, ", ") + "\n\n"
+	}
+
 	if len(analysis.Timeline) > 0 {
 		output += "Timeline (last 12 periods):\n"
 		for i, point := range analysis.Timeline {
@@ -984,6 +1749,27 @@ func formatContentDuplicatesText(duplicates []analyzer.ContentDuplicate) string
 	return output
 }
 
+// formatSimilarContentText formats groups of notes with similar content
+func formatSimilarContentText(duplicates []analyzer.ContentDuplicate, threshold float64) string {
+	if len(duplicates) == 0 {
+		return "No similar content found.\n"
+	}
+
+	output := fmt.Sprintf("Found %d groups of notes with at least %.0f%% of their words in common:\n\n", len(duplicates), threshold*100)
+
+	for i, dup := range duplicates {
+		output += fmt.Sprintf("Group %d (%d files):\n", i+1, dup.Count)
+		for _, file := range dup.Files {
+			output += fmt.Sprintf("  - %s\n", file)
+		}
+		output += "\n"
+	}
+
+	output += "💡 Suggestion: Review similar notes and consider merging them.\n"
+
+	return output
+}
+
 // formatAllDuplicatesText formats all duplicate types in a single report
 func formatAllDuplicatesText(obsidianCopies []analyzer.ObsidianCopy, syncConflicts []analyzer.SyncConflictFile, contentDuplicates []analyzer.ContentDuplicate) string {
 	output := "# Duplicate Analysis Report\n\n"
@@ -1088,6 +1874,9 @@ func newInboxCommand() *cobra.Command {
 			inboxAnalysis := ana.AnalyzeInbox(files, cfg.Analysis.InboxHeadings, sortBy, minItems)
 
 			// Output results
+			if outputFormat == "ndjson" {
+				return writeNDJSON("", inboxAnalysis.InboxSections)
+			}
 			if outputFormat == "json" {
 				data, err := json.MarshalIndent(inboxAnalysis, "", "  ")
 				if err != nil {
@@ -1103,7 +1892,7 @@ func newInboxCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "text", "Output format (text, json, ndjson)")
 	cmd.Flags().StringVar(&sortBy, "sort", "size", "Sort inbox items by: size, count, urgency")
 	cmd.Flags().IntVar(&minItems, "min-items", 1, "Minimum number of items to show section")
 
@@ -0,0 +1,49 @@
+package analyze
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+)
+
+func runAnalyzeCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.AddCommand(NewAnalyzeCommand())
+
+	rootCmd.SetArgs(append([]string{"analyze"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestStatsCommand_CountsLinks(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("a.md", "See [[b]] and [c](c.md)\n")
+	write("b.md", "Back to [[a|A]]\n")
+	write("c.md", "Links to [[missing]]\n")
+	write("d.md", "Nothing links here\n")
+
+	// Orphans are matched against paths as scanned, so scan from the vault
+	t.Chdir(dir)
+	output := filepath.Join(t.TempDir(), "stats.json")
+	require.NoError(t, runAnalyzeCommand(t, "stats", "--no-cache", "--format", "json", "--output", output, "."))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var stats analyzer.VaultStats
+	require.NoError(t, json.Unmarshal(data, &stats))
+	assert.Equal(t, 4, stats.TotalLinks)
+	assert.Equal(t, 1, stats.BrokenLinksCount)
+	assert.Equal(t, []string{"d.md"}, stats.OrphanedFiles)
+}
@@ -0,0 +1,133 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/git"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+)
+
+// newChurnCommand creates the git history analysis command
+func newChurnCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "churn [vault-path]",
+		Short: "Show the most edited notes and who edited them, from git history",
+		Long: `Read the git history of a vault kept in a git repository and report the notes
+edited in the most commits, with the lines added and removed and who made the
+changes, along with each author's commits, notes edited and lines changed.
+
+Only notes still in the vault are listed unless --include-deleted is given.
+Renamed notes start a new history at their new path. Merge commits are
+skipped.`,
+		Example: `  # Most edited notes of all time
+  mdnotes analyze churn ~/vault
+
+  # The last three months, as JSON
+  mdnotes analyze churn --since "3 months" --format json ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runChurn,
+	}
+
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json, ndjson)")
+	cmd.Flags().String("since", "", "Only count commits since a date or a duration before now, e.g. 2025-01-01 or '90 days'")
+	cmd.Flags().Int("limit", 20, "Notes to list in text output (0 for all)")
+	cmd.Flags().Bool("include-deleted", false, "Include notes no longer in the vault")
+
+	return cmd
+}
+
+func runChurn(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	format, _ := cmd.Flags().GetString("format")
+	sinceValue, _ := cmd.Flags().GetString("since")
+	limit, _ := cmd.Flags().GetInt("limit")
+	includeDeleted, _ := cmd.Flags().GetBool("include-deleted")
+
+	if format != "text" && format != "json" && format != "ndjson" {
+		return fmt.Errorf("invalid format '%s' - valid options are: text, json, ndjson", format)
+	}
+	var since time.Time
+	if sinceValue != "" {
+		var err error
+		if since, err = query.ParseDateOrAgo(sinceValue); err != nil {
+			return fmt.Errorf("invalid --since value '%s': %w", sinceValue, err)
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	files, err := scanVault(cmd, vaultPath, cfg.Vault.IgnorePatterns)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	existing := make(map[string]bool, len(files))
+	for _, file := range files {
+		existing[filepath.ToSlash(file.RelativePath)] = true
+	}
+
+	commits, err := git.Log(vaultPath, since)
+	if err != nil {
+		return err
+	}
+	churn := analyzer.AnalyzeChurn(commits, existing, includeDeleted)
+
+	switch format {
+	case "ndjson":
+		return writeNDJSON("", churn.Notes)
+	case "json":
+		data, err := json.MarshalIndent(churn, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Print(formatChurnText(churn, limit))
+	}
+	return nil
+}
+
+// formatChurnText formats the most edited notes, up to limit, and all authors
+func formatChurnText(churn analyzer.ChurnAnalysis, limit int) string {
+	var output strings.Builder
+	output.WriteString(underline("Note Churn") + "\n")
+	fmt.Fprintf(&output, "Commits: %d\nNotes edited: %d\nAuthors: %d\n", churn.Commits, len(churn.Notes), len(churn.Authors))
+	if len(churn.Notes) == 0 {
+		return output.String()
+	}
+
+	notes := churn.Notes
+	if limit > 0 && len(notes) > limit {
+		notes = notes[:limit]
+	}
+	output.WriteString("\nMost edited notes:\n")
+	for _, note := range notes {
+		path := note.Path
+		if !note.Exists {
+			path += " (deleted)"
+		}
+		fmt.Fprintf(&output, "  %4d commits  +%-6d -%-6d %s  %s  %s\n", note.Commits, note.Added, note.Deleted,
+			note.LastChange.Format("2006-01-02"), path, strings.Join(note.Authors, ", "))
+	}
+	if len(notes) < len(churn.Notes) {
+		fmt.Fprintf(&output, "  …and %d more\n", len(churn.Notes)-len(notes))
+	}
+
+	output.WriteString("\nAuthors:\n")
+	for _, author := range churn.Authors {
+		fmt.Fprintf(&output, "  %-24s %4d commits %5d notes  +%d -%d  %s to %s\n", author.Name, author.Commits, author.Notes,
+			author.Added, author.Deleted, author.First.Format("2006-01-02"), author.Last.Format("2006-01-02"))
+	}
+	return output.String()
+}
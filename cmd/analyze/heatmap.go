@@ -0,0 +1,193 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+)
+
+// heatmapLevels shade calendar days from no notes to the busiest day
+var heatmapLevels = []string{"·", "░", "▒", "▓", "█"}
+
+// sparkBlocks draw sparklines, from no notes to the most in one period
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// heatmapWeeks is the widest calendar drawn on one row, a year of weeks
+const heatmapWeeks = 53
+
+// sparklineWidth is the most periods a sparkline shows, the latest ones
+const sparklineWidth = 52
+
+// heatmapTags is how many of the most used tags get a sparkline
+const heatmapTags = 10
+
+// formatTrendsHeatmap draws the notes written each day as a calendar of weeks
+// in the style of GitHub's contribution graph, followed by sparklines of notes
+// per period overall and for the most used tags
+func formatTrendsHeatmap(analysis analyzer.TrendsAnalysis) string {
+	var output strings.Builder
+	output.WriteString(underline("Writing Activity") + "\n")
+
+	start, end, ok := activityRange(analysis)
+	if !ok {
+		output.WriteString("No notes in this period.\n")
+		return output.String()
+	}
+	fmt.Fprintf(&output, "%s to %s: %d notes on %d days\n\n", start.Format("2006-01-02"), end.Format("2006-01-02"),
+		analysis.TotalFilesCreated, analysis.ActiveDays)
+
+	busiest := 0
+	for _, count := range analysis.DailyActivity {
+		busiest = max(busiest, count)
+	}
+
+	// Calendars of up to a year each, ending with the latest week
+	weekStart := mondayOf(start)
+	weeks := int(mondayOf(end).Sub(weekStart).Hours()/24/7) + 1
+	for first := 0; first < weeks; first += heatmapWeeks {
+		last := min(first+heatmapWeeks, weeks)
+		if first > 0 {
+			output.WriteString("\n")
+		}
+		writeCalendar(&output, analysis.DailyActivity, weekStart.AddDate(0, 0, 7*first), last-first, start, end, busiest)
+	}
+	notes := "notes"
+	if busiest == 1 {
+		notes = "note"
+	}
+	fmt.Fprintf(&output, "\n     Less %s More (busiest day: %d %s)\n", strings.Join(heatmapLevels, " "), busiest, notes)
+
+	periods := analyzer.PeriodRange(start, end, analysis.Granularity)
+	if len(periods) > sparklineWidth {
+		periods = periods[len(periods)-sparklineWidth:]
+	}
+	fmt.Fprintf(&output, "\nNotes per %s (%s to %s):\n", analysis.Granularity, periods[0], periods[len(periods)-1])
+	fmt.Fprintf(&output, "  %-20s %s %d\n", "all notes", sparkline(analysis.Timeline, periods), analysis.TotalFilesCreated)
+
+	tags := make([]string, 0, len(analysis.TagTrends))
+	for tag := range analysis.TagTrends {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if analysis.TagTrends[tags[i]].Count != analysis.TagTrends[tags[j]].Count {
+			return analysis.TagTrends[tags[i]].Count > analysis.TagTrends[tags[j]].Count
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > heatmapTags {
+		tags = tags[:heatmapTags]
+	}
+	for _, tag := range tags {
+		trend := analysis.TagTrends[tag]
+		fmt.Fprintf(&output, "  %-20s %s %d\n", truncateLabel("#"+tag, 20), sparkline(trend.Timeline, periods), trend.Count)
+	}
+	return output.String()
+}
+
+// activityRange returns the days the heatmap covers: the analysis period, or
+// from the first active day when the period is unbounded
+func activityRange(analysis analyzer.TrendsAnalysis) (start, end time.Time, ok bool) {
+	if len(analysis.DailyActivity) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	end = analysis.EndDate
+	if !analysis.StartDate.IsZero() {
+		return analysis.StartDate, end, true
+	}
+	first := ""
+	for day := range analysis.DailyActivity {
+		if first == "" || day < first {
+			first = day
+		}
+	}
+	start, err := time.ParseInLocation("2006-01-02", first, end.Location())
+	return start, end, err == nil
+}
+
+// writeCalendar draws weeks columns of days from the Monday weekStart, with
+// a row per weekday and month names above the weeks they start in. Days
+// outside start to end are left blank.
+func writeCalendar(output *strings.Builder, daily map[string]int, weekStart time.Time, weeks int, start, end time.Time, busiest int) {
+	labels := []rune(strings.Repeat(" ", weeks+3))
+	for week := 0; week < weeks; week++ {
+		monday := weekStart.AddDate(0, 0, 7*week)
+		if week == 0 || monday.AddDate(0, 0, -7).Month() != monday.Month() {
+			// Skip a label that would run into the previous one
+			if week > 0 && labels[week-1] != ' ' {
+				continue
+			}
+			copy(labels[week:], []rune(monday.Format("Jan")))
+		}
+	}
+	output.WriteString("     " + strings.TrimRight(string(labels), " ") + "\n")
+
+	startDay := start.Format("2006-01-02")
+	endDay := end.Format("2006-01-02")
+	for weekday := 0; weekday < 7; weekday++ {
+		name := weekStart.AddDate(0, 0, weekday).Format("Mon")
+		row := []string{name + "  "}
+		for week := 0; week < weeks; week++ {
+			day := weekStart.AddDate(0, 0, 7*week+weekday).Format("2006-01-02")
+			if day < startDay || day > endDay {
+				row = append(row, " ")
+				continue
+			}
+			row = append(row, heatmapLevels[heatmapLevel(daily[day], busiest)])
+		}
+		output.WriteString(strings.TrimRight(strings.Join(row, ""), " ") + "\n")
+	}
+}
+
+// heatmapLevel shades count relative to the busiest day, keeping any activity
+// visible
+func heatmapLevel(count, busiest int) int {
+	if count <= 0 || busiest <= 0 {
+		return 0
+	}
+	levels := len(heatmapLevels) - 1
+	return max(1, (count*levels+busiest-1)/busiest)
+}
+
+// sparkline draws a timeline's counts over periods, oldest first. Periods
+// without notes get the lowest block.
+func sparkline(timeline []analyzer.TimelinePoint, periods []string) string {
+	counts := make(map[string]int, len(timeline))
+	for _, point := range timeline {
+		counts[point.Period] = point.Count
+	}
+	most := 0
+	for _, period := range periods {
+		most = max(most, counts[period])
+	}
+
+	var line strings.Builder
+	for _, period := range periods {
+		count := counts[period]
+		level := 0
+		if most > 0 && count > 0 {
+			// Any notes at all show above the baseline
+			level = 1 + (count*(len(sparkBlocks)-2)+most-1)/most
+			level = min(level, len(sparkBlocks)-1)
+		}
+		line.WriteRune(sparkBlocks[level])
+	}
+	return line.String()
+}
+
+// mondayOf returns the Monday starting date's week, at midnight
+func mondayOf(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return time.Date(date.Year(), date.Month(), date.Day()-offset, 0, 0, 0, 0, date.Location())
+}
+
+// truncateLabel shortens label to width runes
+func truncateLabel(label string, width int) string {
+	runes := []rune(label)
+	if len(runes) <= width {
+		return label
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,223 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// newKeywordsCommand creates the keyword extraction command
+func newKeywordsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "keywords [vault-path]",
+		Aliases: []string{"kw"},
+		Short:   "Extract keywords per note and vault-wide term trends",
+		Long: `Find the words that characterize each note, scored by TF-IDF against the rest
+of the vault, and the terms used most across the vault. Rising terms are
+those used by a larger share of notes in the latest period, by modification
+date, than in the vault as a whole.
+
+Words under four letters and common words in English, German, French,
+Spanish, Italian, Dutch and Swedish are ignored, as are code blocks and URLs.
+
+--write stores each note's keywords in a frontmatter list (keywords:, or
+--field), replacing what was there. Locked notes are left alone, and the
+changes can be reverted with 'mdnotes undo'.`,
+		Example: `  # Vault-wide terms, and those trending this month
+  mdnotes analyze keywords ~/vault
+
+  # Every note's top 3 keywords, as JSON
+  mdnotes analyze keywords --limit 3 --format json ~/vault
+
+  # Write them into frontmatter
+  mdnotes analyze keywords --limit 5 --write ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runKeywords,
+	}
+
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json, ndjson)")
+	cmd.Flags().Int("limit", 5, "Keywords per note")
+	cmd.Flags().Int("top", 20, "Vault-wide terms to show")
+	cmd.Flags().String("granularity", "month", "Period of term trends (day, week, month, quarter)")
+	cmd.Flags().Bool("per-note", false, "List each note's keywords in text output")
+	cmd.Flags().Bool("write", false, "Write each note's keywords into its frontmatter")
+	cmd.Flags().String("field", "keywords", "Frontmatter field --write sets")
+
+	return cmd
+}
+
+func runKeywords(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	format, _ := cmd.Flags().GetString("format")
+	limit, _ := cmd.Flags().GetInt("limit")
+	top, _ := cmd.Flags().GetInt("top")
+	granularity, _ := cmd.Flags().GetString("granularity")
+	perNote, _ := cmd.Flags().GetBool("per-note")
+	write, _ := cmd.Flags().GetBool("write")
+	field, _ := cmd.Flags().GetString("field")
+
+	if format != "text" && format != "json" && format != "ndjson" {
+		return fmt.Errorf("invalid format '%s' - valid options are: text, json, ndjson", format)
+	}
+	switch granularity {
+	case "day", "week", "month", "quarter":
+	default:
+		return fmt.Errorf("invalid granularity '%s' - valid options are: day, week, month, quarter", granularity)
+	}
+	if limit < 1 || top < 1 {
+		return fmt.Errorf("--limit and --top must be at least 1")
+	}
+	if write && strings.TrimSpace(field) == "" {
+		return fmt.Errorf("--field must not be empty")
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	files, err := scanVault(cmd, vaultPath, cfg.Vault.IgnorePatterns)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+
+	analysis := analyzer.NewAnalyzer().AnalyzeKeywords(files, analyzer.KeywordOptions{
+		PerNote:     limit,
+		Top:         top,
+		Granularity: granularity,
+	})
+
+	if write {
+		return writeKeywords(cmd, vaultPath, files, analysis.Notes, field)
+	}
+
+	switch format {
+	case "ndjson":
+		return writeNDJSON("", analysis.Notes)
+	case "json":
+		data, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Print(formatKeywordsText(analysis, perNote))
+	}
+	return nil
+}
+
+// writeKeywords sets field to each note's keywords, journaling the changes
+func writeKeywords(cmd *cobra.Command, vaultPath string, files []*vault.VaultFile, notes []analyzer.NoteKeywords, field string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	byPath := make(map[string]*vault.VaultFile, len(files))
+	for _, file := range files {
+		byPath[file.RelativePath] = file
+	}
+
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(vaultPath))
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("keywords")
+
+	updated := 0
+	for _, note := range notes {
+		file := byPath[note.Path]
+		if len(note.Keywords) == 0 {
+			continue
+		}
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %s\n", file.RelativePath, reason)
+			}
+			continue
+		}
+
+		terms := make([]interface{}, len(note.Keywords))
+		for i, keyword := range note.Keywords {
+			terms[i] = keyword.Term
+		}
+		if current, ok := file.Frontmatter[field].([]interface{}); ok && reflect.DeepEqual(current, terms) {
+			continue
+		}
+		updated++
+
+		if dryRun {
+			fmt.Printf("Would set %s of %s to %s\n", field, file.RelativePath, keywordTerms(note.Keywords))
+			continue
+		}
+		file.SetField(field, terms)
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ Set %s of %s to %s\n", field, file.RelativePath, keywordTerms(note.Keywords))
+		}
+	}
+
+	if !quiet {
+		verb := "Updated"
+		if dryRun {
+			verb = "Would update"
+		}
+		fmt.Printf("\n%s keywords of %d notes\n", verb, updated)
+	}
+	return nil
+}
+
+func keywordTerms(keywords []analyzer.Keyword) string {
+	terms := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		terms[i] = keyword.Term
+	}
+	return strings.Join(terms, ", ")
+}
+
+// formatKeywordsText formats the vault's top and rising terms, and with
+// perNote every note's keywords
+func formatKeywordsText(analysis analyzer.KeywordAnalysis, perNote bool) string {
+	var output strings.Builder
+	output.WriteString(underline("Keyword Analysis") + "\n")
+	fmt.Fprintf(&output, "Notes: %d\nDistinct terms: %d\n", analysis.TotalNotes, analysis.TotalTerms)
+
+	if len(analysis.TopTerms) > 0 {
+		output.WriteString("\nMost used terms:\n")
+		for _, trend := range analysis.TopTerms {
+			fmt.Fprintf(&output, "  %-24s %4d notes %6d uses\n", trend.Term, trend.Notes, trend.Count)
+		}
+	}
+
+	if len(analysis.Rising) > 0 {
+		fmt.Fprintf(&output, "\nRising in %s:\n", analysis.LatestPeriod)
+		for _, trend := range analysis.Rising {
+			fmt.Fprintf(&output, "  %-24s %+5.1f points (%d notes)\n", trend.Term, trend.Growth, trend.Notes)
+		}
+	}
+
+	if perNote {
+		output.WriteString("\nKeywords per note:\n")
+		for _, note := range analysis.Notes {
+			if len(note.Keywords) > 0 {
+				fmt.Fprintf(&output, "  %s: %s\n", note.Path, keywordTerms(note.Keywords))
+			}
+		}
+	}
+	return output.String()
+}
@@ -0,0 +1,348 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// newOrphansCommand creates the orphan triage command
+func newOrphansCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "orphans [vault-path]",
+		Aliases: []string{"o"},
+		Short:   "Find and triage notes nothing links to",
+		Long: `List notes that no other note links to, and with --dead-ends notes that link
+to nothing, then act on them in bulk.
+
+Each note is reported as an orphan (no inbound links), a dead end (no
+outbound links) or isolated (neither). --min-age skips notes modified
+recently, which are often still being worked on, and --exclude-folders skips
+folders such as daily notes or templates where unlinked notes are expected.
+
+Actions:
+  --tag orphan       Add a tag to every reported note
+  --move-to inbox/   Move every reported note into a folder, updating links
+  --report md        Write a Markdown checklist, to --output or stdout
+
+Locked notes are never changed, and 'mdnotes undo' reverts tags and moves.`,
+		Example: `  # Orphans untouched for 90 days, outside daily notes
+  mdnotes analyze orphans --min-age 90d --exclude-folders daily ~/vault
+
+  # Tag them and collect them in the inbox for review
+  mdnotes analyze orphans --min-age 90d --tag orphan --move-to inbox/ ~/vault
+
+  # A checklist note to work through
+  mdnotes analyze orphans --dead-ends --report md --output "Orphan triage.md" ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runOrphans,
+	}
+
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json, ndjson)")
+	cmd.Flags().Bool("dead-ends", false, "Also report notes that link to nothing")
+	cmd.Flags().String("min-age", "", "Only report notes not modified for this long, e.g. 30d or '6 months'")
+	cmd.Flags().StringSlice("exclude-folders", nil, "Vault-relative folders to skip")
+	cmd.Flags().String("tag", "", "Add this tag to every reported note")
+	cmd.Flags().String("move-to", "", "Move every reported note into this vault-relative folder")
+	cmd.Flags().String("report", "", "Write a triage report in this format (md)")
+	cmd.Flags().StringP("output", "o", "", "Note to write the report to, relative to the vault (default: stdout)")
+
+	return cmd
+}
+
+func runOrphans(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	format, _ := cmd.Flags().GetString("format")
+	deadEnds, _ := cmd.Flags().GetBool("dead-ends")
+	minAgeValue, _ := cmd.Flags().GetString("min-age")
+	excludeFolders, _ := cmd.Flags().GetStringSlice("exclude-folders")
+	tag, _ := cmd.Flags().GetString("tag")
+	moveTo, _ := cmd.Flags().GetString("move-to")
+	report, _ := cmd.Flags().GetString("report")
+	output, _ := cmd.Flags().GetString("output")
+
+	if format != "text" && format != "json" && format != "ndjson" {
+		return fmt.Errorf("invalid format '%s' - valid options are: text, json, ndjson", format)
+	}
+	if report != "" && report != "md" {
+		return fmt.Errorf("invalid report format '%s' - valid options are: md", report)
+	}
+	if output != "" && report == "" {
+		return fmt.Errorf("--output needs --report")
+	}
+	if output != "" && !strings.HasSuffix(output, ".md") {
+		output += ".md"
+	}
+	tag = strings.TrimPrefix(tag, "#")
+	if tag != "" {
+		if err := processor.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+	var minAge time.Duration
+	if minAgeValue != "" {
+		var err error
+		if minAge, err = query.ParseDuration(minAgeValue); err != nil {
+			return fmt.Errorf("invalid --min-age value '%s': %w", minAgeValue, err)
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	files, err := scanVault(cmd, vaultPath, cfg.Vault.IgnorePatterns)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	if output != "" {
+		// The report links to every orphan, so it mustn't count as linking to them
+		files = withoutNote(files, output)
+	}
+
+	ana, saveCache := newAnalyzer(cmd, vaultPath)
+	ana.SetLinkParser(processor.NewLinkParser())
+	orphans := ana.FindOrphans(files, analyzer.OrphanOptions{
+		DeadEnds:       deadEnds,
+		MinAge:         minAge,
+		ExcludeFolders: excludeFolders,
+	})
+	saveCache()
+
+	if tag != "" || moveTo != "" {
+		if err := triageOrphans(cmd, vaultPath, files, orphans, tag, moveTo, cfg.Vault.IgnorePatterns); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case report != "" && output != "":
+		return writeOrphanReport(cmd, vaultPath, output, formatOrphanReport(orphans, files, time.Now()))
+	case report != "":
+		fmt.Print(formatOrphanReport(orphans, files, time.Now()))
+	case tag != "" || moveTo != "":
+		// The actions already listed each note
+	case format == "ndjson":
+		return writeNDJSON("", orphans)
+	case format == "json":
+		if orphans == nil {
+			orphans = []analyzer.OrphanNote{}
+		}
+		data, err := json.MarshalIndent(orphans, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Print(formatOrphansText(orphans))
+	}
+	return nil
+}
+
+// triageOrphans tags and moves the reported notes, journaling every change
+func triageOrphans(cmd *cobra.Command, vaultPath string, files []*vault.VaultFile, orphans []analyzer.OrphanNote, tag, moveTo string, ignorePatterns []string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	root, err := filepath.Abs(safety.FindVaultRoot(vaultPath))
+	if err != nil {
+		return fmt.Errorf("resolving vault root: %w", err)
+	}
+	byPath := make(map[string]*vault.VaultFile, len(files))
+	for _, file := range files {
+		byPath[filepath.ToSlash(file.RelativePath)] = file
+	}
+	var selected []*vault.VaultFile
+	for _, orphan := range orphans {
+		file := byPath[orphan.Path]
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %s\n", orphan.Path, reason)
+			}
+			continue
+		}
+		selected = append(selected, file)
+	}
+
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("orphan triage")
+
+	tagged := 0
+	if tag != "" {
+		tags := processor.NewTagProcessor()
+		for _, file := range selected {
+			if !tags.Add(file, tag) {
+				continue
+			}
+			tagged++
+			if dryRun {
+				fmt.Printf("Would tag %s with #%s\n", file.RelativePath, tag)
+				continue
+			}
+			content, err := file.Serialize()
+			if err != nil {
+				return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+			}
+			if err := tx.RecordWrite(file.Path); err != nil {
+				return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+			}
+			if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+				return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+			}
+			if !quiet {
+				fmt.Printf("✓ Tagged %s with #%s\n", file.RelativePath, tag)
+			}
+		}
+	}
+
+	moved := 0
+	if moveTo != "" {
+		// Paths relative to the vault root, which the archiver plans moves from
+		for _, file := range selected {
+			if abs, err := filepath.Abs(file.Path); err == nil {
+				if rel, err := filepath.Rel(root, abs); err == nil {
+					file.RelativePath = filepath.ToSlash(rel)
+				}
+			}
+		}
+		mover, err := processor.NewArchiver(root, moveTo, nil)
+		if err != nil {
+			return err
+		}
+		options := processor.RenameOptions{
+			IgnorePatterns: ignorePatterns,
+			Verbose:        verbose,
+			Transaction:    tx,
+		}
+		for _, action := range mover.Plan(selected) {
+			target, _ := filepath.Rel(root, action.Target)
+			if dryRun {
+				fmt.Printf("Would move %s to %s\n", action.File.RelativePath, filepath.ToSlash(target))
+				moved++
+				continue
+			}
+			if err := mover.Apply(context.Background(), action, options); err != nil {
+				return fmt.Errorf("moving %s: %w", action.File.RelativePath, err)
+			}
+			moved++
+			if !quiet {
+				fmt.Printf("✓ Moved %s to %s\n", action.File.RelativePath, filepath.ToSlash(target))
+			}
+		}
+	}
+
+	if !quiet {
+		verb := "Triaged"
+		if dryRun {
+			verb = "Dry run completed. Would triage"
+		}
+		fmt.Printf("\n%s %d of %d orphaned notes (%d tagged, %d moved).\n", verb, max(tagged, moved), len(orphans), tagged, moved)
+	}
+	return nil
+}
+
+// formatOrphansText formats orphaned notes as a table grouped by kind
+func formatOrphansText(orphans []analyzer.OrphanNote) string {
+	if len(orphans) == 0 {
+		return "No orphaned notes found\n"
+	}
+
+	var output strings.Builder
+	output.WriteString(underline(fmt.Sprintf("Orphaned Notes (%d)", len(orphans))))
+	for _, kind := range []string{analyzer.IsolatedKind, analyzer.OrphanKind, analyzer.DeadEndKind} {
+		for _, orphan := range orphans {
+			if orphan.Kind != kind {
+				continue
+			}
+			fmt.Fprintf(&output, "  %-9s %s (%d words, %d days old)\n", orphan.Kind, orphan.Path, orphan.Words, orphan.AgeDays)
+		}
+	}
+	return output.String()
+}
+
+// formatOrphanReport renders orphaned notes as a Markdown checklist, one
+// section per kind
+func formatOrphanReport(orphans []analyzer.OrphanNote, files []*vault.VaultFile, now time.Time) string {
+	names := processor.NoteNames(files)
+	sections := []struct{ kind, title string }{
+		{analyzer.IsolatedKind, "Isolated (no links in or out)"},
+		{analyzer.OrphanKind, "Orphans (nothing links here)"},
+		{analyzer.DeadEndKind, "Dead ends (links to nothing)"},
+	}
+
+	var output strings.Builder
+	output.WriteString("# Orphan triage\n\n")
+	fmt.Fprintf(&output, "%d notes to review, found %s.\n", len(orphans), now.Format("2006-01-02"))
+	for _, section := range sections {
+		var lines []string
+		for _, orphan := range orphans {
+			if orphan.Kind == section.kind {
+				lines = append(lines, fmt.Sprintf("- [ ] %s (%d words, %d days old)",
+					processor.NoteLink(orphan.Path, "wiki", names), orphan.Words, orphan.AgeDays))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&output, "\n## %s\n\n%s\n", section.title, strings.Join(lines, "\n"))
+	}
+	return output.String()
+}
+
+// writeOrphanReport writes the report to a note in the vault, recording it for undo
+func writeOrphanReport(cmd *cobra.Command, vaultPath, output, markdown string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+	if dryRun {
+		fmt.Printf("Would write orphan report to %s\n", output)
+		return nil
+	}
+
+	path := filepath.Join(vaultPath, output)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating report folder: %w", err)
+	}
+	tx := cli.BeginTransaction(cmd, vaultPath)
+	defer cli.CommitTransaction(cmd, tx)
+
+	if err := tx.RecordWrite(path); err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	if err := safety.WriteFile(path, []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("✓ Wrote orphan report to %s\n", output)
+	}
+	return nil
+}
+
+// withoutNote drops the note at relPath from files
+func withoutNote(files []*vault.VaultFile, relPath string) []*vault.VaultFile {
+	relPath = filepath.Clean(relPath)
+	kept := files[:0]
+	for _, file := range files {
+		if filepath.Clean(file.RelativePath) != relPath {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
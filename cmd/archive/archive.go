@@ -0,0 +1,208 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+)
+
+// NewArchiveCommand creates the archive command
+func NewArchiveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archive [path]",
+		Short: "Move notes into an archive folder, updating links to them",
+		Long: `Move the notes selected with --query, --from-file or --from-stdin, or a
+single note given as path, into an archive folder. Links to each note are
+updated like 'rename' does, so nothing breaks.
+
+--dest may use template variables, e.g. "archive/{{file.dir}}" keeps the
+folder structure and "archive/{{created|date:2006}}" files notes by year.
+Notes already in the archive folder and locked notes are left alone.
+
+--stamp sets 'archived: true' on each note before it moves; --set sets other
+fields, whose values may also use template variables.
+
+Every change is recorded in the change journal, so 'mdnotes undo' reverts a
+whole run.`,
+		Example: `  # Preview archiving finished notes not touched since 2023
+  mdnotes archive --query "status = 'done' AND modified before '2023-01-01'" --dest archive/ --dry-run ~/vault
+
+  # Archive them, marking each note as archived
+  mdnotes archive --query "status = 'done' AND modified before '2023-01-01'" --dest archive/ --stamp ~/vault
+
+  # Archive a single note, keeping its folder and recording the date
+  mdnotes archive --dest "archive/{{file.dir}}" --set archived_on="{{current_date}}" ~/vault/projects/launch.md`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runArchive,
+	}
+
+	cmd.Flags().String("dest", "archive", "Archive folder relative to the vault root; may use template variables")
+	cmd.Flags().Bool("stamp", false, "Set 'archived: true' in the frontmatter of archived notes")
+	cmd.Flags().StringArray("set", nil, "Set a frontmatter field on archived notes as field=value (repeatable)")
+
+	return cmd
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	dest, _ := cmd.Flags().GetString("dest")
+	stamp, _ := cmd.Flags().GetBool("stamp")
+	sets, _ := cmd.Flags().GetStringArray("set")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	fields, err := parseFields(sets)
+	if err != nil {
+		return err
+	}
+	if stamp {
+		fields["archived"] = true
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if mode == selector.AutoDetect {
+		// Archiving a whole folder is almost never intended
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return fmt.Errorf("select the notes to archive with --query, --from-file or --from-stdin, or give a single note as path")
+		}
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(path, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	root := safety.FindVaultRoot(path)
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	for _, file := range selection.Files {
+		if abs, err := filepath.Abs(file.Path); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				file.RelativePath = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	archiver, err := processor.NewArchiver(root, dest, fields)
+	if err != nil {
+		return err
+	}
+	actions := archiver.Plan(selection.Files)
+
+	tx := cli.BeginTransaction(cmd, root)
+	options := processor.RenameOptions{
+		IgnorePatterns: cfg.Vault.IgnorePatterns,
+		Verbose:        verbose,
+		Transaction:    tx,
+	}
+
+	archived := 0
+	var failures []error
+	for _, action := range actions {
+		description := describeAction(action, root)
+		if dryRun {
+			fmt.Printf("Would archive %s: %s\n", action.File.RelativePath, description)
+			continue
+		}
+		if err := archiver.Apply(context.Background(), action, options); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", action.File.RelativePath, err))
+			fmt.Printf("✗ %s: %v\n", action.File.RelativePath, err)
+			continue
+		}
+		archived++
+		if !quiet {
+			fmt.Printf("✓ %s: %s\n", action.File.RelativePath, description)
+		}
+	}
+	cli.CommitTransaction(cmd, tx)
+
+	if dryRun {
+		fmt.Printf("\nDry run completed. Would archive %d of %d selected notes.\n", len(actions), len(selection.Files))
+	} else if !quiet {
+		fmt.Printf("\nArchived %d of %d selected notes.\n", archived, len(selection.Files))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d notes could not be archived", len(failures))
+	}
+	return nil
+}
+
+// parseFields parses field=value pairs, typing values like 'frontmatter set'
+// does. Values with template variables stay strings, rendered per note.
+func parseFields(sets []string) (map[string]interface{}, error) {
+	typeCaster := processor.NewTypeCaster()
+	fields := make(map[string]interface{})
+	for _, set := range sets {
+		field, value, ok := strings.Cut(set, "=")
+		if !ok || field == "" {
+			return nil, fmt.Errorf("invalid --set '%s': expected field=value", set)
+		}
+		if strings.Contains(value, "{{") {
+			fields[field] = value
+			continue
+		}
+		typed, err := typeCaster.Cast(value, typeCaster.AutoDetect(value))
+		if err != nil {
+			typed = value
+		}
+		fields[field] = typed
+	}
+	return fields, nil
+}
+
+// describeAction summarizes the fields an action sets and where it moves the note
+func describeAction(action processor.LifecycleAction, root string) string {
+	fields := make([]string, 0, len(action.Set))
+	for field := range action.Set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var parts []string
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("set %s=%v", field, action.Set[field]))
+	}
+	if action.Target != "" {
+		target := action.Target
+		if rel, err := filepath.Rel(root, target); err == nil {
+			target = filepath.ToSlash(rel)
+		}
+		parts = append(parts, "move to "+target)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
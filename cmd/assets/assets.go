@@ -0,0 +1,292 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+)
+
+// NewAssetsCommand creates the assets command
+func NewAssetsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "assets",
+		Short: "Manage attachments: images, PDFs and other files notes use",
+		Long: `Commands for the attachments of a vault, every file that is not a note or a
+canvas.`,
+	}
+
+	cmd.AddCommand(newAuditCommand())
+	cmd.AddCommand(newOptimizeCommand())
+	cmd.AddCommand(newOrganizeCommand())
+
+	return cmd
+}
+
+// newAuditCommand creates the assets audit command
+func newAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit [vault-path]",
+		Short: "Find unused, duplicated and oversized attachments",
+		Long: `Check the vault's attachments against the notes that use them, and report
+those no note or canvas references, those stored more than once with the same
+content, and those larger than --max-size.
+
+References are links and embeds, HTML src and href attributes, frontmatter
+values naming a file (cover: images/cover.jpg) and canvas file cards. They
+resolve as Obsidian resolves them: relative to the note, from the vault root,
+or by file name anywhere in the vault. An attachment whose name is shared
+with another is counted as used if either is referenced, so nothing in use is
+reported unreferenced. Hidden folders such as .obsidian are not scanned.
+
+--delete-unreferenced removes the unreferenced attachments, and --move-to
+moves them into a folder instead, keeping their paths below it, for a look
+before deleting. Both are recorded in the change journal and can be
+reverted with 'mdnotes undo'.`,
+		Example: `  # Report on every attachment in the vault
+  mdnotes assets audit ~/vault
+
+  # Only the attachments folder, flagging files over 2MB
+  mdnotes assets audit ~/vault --folder attachments --max-size 2MB
+
+  # Set the unused ones aside, then delete them
+  mdnotes assets audit ~/vault --move-to _unused
+  mdnotes assets audit ~/vault --delete-unreferenced --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runAudit,
+	}
+
+	cmd.Flags().StringSlice("folder", nil, "Folder to audit, relative to the vault (repeatable; default: the whole vault)")
+	cmd.Flags().String("max-size", "5MB", "Size above which attachments are reported as oversized (0 to not report any)")
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json)")
+	cmd.Flags().Bool("delete-unreferenced", false, "Delete attachments no note references")
+	cmd.Flags().String("move-to", "", "Move attachments no note references into this folder, relative to the vault")
+
+	return cmd
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	folders, _ := cmd.Flags().GetStringSlice("folder")
+	maxSizeFlag, _ := cmd.Flags().GetString("max-size")
+	format, _ := cmd.Flags().GetString("format")
+	deleteUnreferenced, _ := cmd.Flags().GetBool("delete-unreferenced")
+	moveTo, _ := cmd.Flags().GetString("move-to")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format '%s' - valid options are: text, json", format)
+	}
+	if deleteUnreferenced && moveTo != "" {
+		return fmt.Errorf("--delete-unreferenced and --move-to can't be used together")
+	}
+	maxSize, err := processor.ParseByteSize(maxSizeFlag)
+	if err != nil {
+		return fmt.Errorf("--max-size: %w", err)
+	}
+	if moveTo != "" {
+		moveTo = path.Clean(filepath.ToSlash(moveTo))
+		if path.IsAbs(moveTo) || moveTo == "." || strings.HasPrefix(moveTo, "../") || moveTo == ".." {
+			return fmt.Errorf("--move-to must be a folder inside the vault")
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	vaultAbs, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return fmt.Errorf("resolving vault path: %w", err)
+	}
+
+	// References come from every note, whatever the selection flags say
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if mode != selector.AutoDetect {
+		return fmt.Errorf("assets audit reads every note in the vault and doesn't take --query, --from-file or --from-stdin")
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(vaultAbs, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	audit, err := processor.AuditAssets(vaultAbs, selection.Files, processor.AssetAuditOptions{
+		Folders:        folders,
+		IgnorePatterns: fileSelector.IgnorePatterns,
+		MaxSize:        maxSize,
+	})
+	if err != nil {
+		return fmt.Errorf("auditing attachments: %w", err)
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(audit, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printAudit(audit, maxSize, verbose)
+	}
+
+	if deleteUnreferenced || moveTo != "" {
+		return cleanUnreferenced(cmd, vaultAbs, audit, moveTo)
+	}
+	return nil
+}
+
+// printAudit prints an audit as text
+func printAudit(audit *processor.AssetAudit, maxSize int64, verbose bool) {
+	fmt.Printf("Attachments: %d (%s)\n", audit.Assets, formatSize(audit.TotalSize))
+	fmt.Println(strings.Repeat("=", 40))
+
+	fmt.Printf("\nUnreferenced (%d):\n", len(audit.Unreferenced))
+	for _, asset := range audit.Unreferenced {
+		fmt.Printf("  %s (%s)\n", asset.Path, formatSize(asset.Size))
+	}
+
+	fmt.Printf("\nDuplicates (%d groups):\n", len(audit.Duplicates))
+	for _, group := range audit.Duplicates {
+		fmt.Printf("  %d copies of %s each:\n", len(group.Paths), formatSize(group.Size))
+		for _, p := range group.Paths {
+			fmt.Printf("    %s\n", p)
+		}
+	}
+
+	if maxSize > 0 {
+		fmt.Printf("\nOver %s (%d):\n", formatSize(maxSize), len(audit.Oversized))
+		for _, asset := range audit.Oversized {
+			fmt.Printf("  %s (%s)\n", asset.Path, formatSize(asset.Size))
+			if verbose {
+				for _, ref := range asset.References {
+					fmt.Printf("    used by %s\n", ref)
+				}
+			}
+		}
+	}
+
+	fmt.Printf("\nReclaimable: %s\n", formatSize(audit.WastedSize()))
+}
+
+// cleanUnreferenced deletes the unreferenced attachments of an audit, or
+// moves them below moveTo when it is set, journaling the changes
+func cleanUnreferenced(cmd *cobra.Command, vaultPath string, audit *processor.AssetAudit, moveTo string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(vaultPath))
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("assets audit")
+
+	if !quiet {
+		fmt.Println()
+	}
+	done := 0
+	for _, asset := range audit.Unreferenced {
+		source := filepath.Join(vaultPath, filepath.FromSlash(asset.Path))
+		if moveTo == "" {
+			if dryRun {
+				fmt.Printf("Would delete %s\n", asset.Path)
+				done++
+				continue
+			}
+			if err := tx.RecordDelete(source); err != nil {
+				return fmt.Errorf("recording removal of %s: %w", asset.Path, err)
+			}
+			if err := os.Remove(source); err != nil {
+				return fmt.Errorf("removing %s: %w", asset.Path, err)
+			}
+			if !quiet {
+				fmt.Printf("✓ Deleted %s\n", asset.Path)
+			}
+			done++
+			continue
+		}
+
+		// Attachments set aside earlier stay where they are
+		if strings.HasPrefix(asset.Path, moveTo+"/") {
+			continue
+		}
+		destRel := path.Join(moveTo, asset.Path)
+		dest := filepath.Join(vaultPath, filepath.FromSlash(destRel))
+		if _, err := os.Stat(dest); err == nil {
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %s already exists\n", asset.Path, destRel)
+			}
+			continue
+		}
+		if dryRun {
+			fmt.Printf("Would move %s to %s\n", asset.Path, destRel)
+			done++
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", filepath.Dir(destRel), err)
+		}
+		if err := tx.RecordMove(source, dest); err != nil {
+			return fmt.Errorf("recording move of %s: %w", asset.Path, err)
+		}
+		if err := os.Rename(source, dest); err != nil {
+			return fmt.Errorf("moving %s: %w", asset.Path, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ Moved %s to %s\n", asset.Path, destRel)
+		}
+		done++
+	}
+
+	if !quiet {
+		verb := "Deleted"
+		if moveTo != "" {
+			verb = "Moved"
+		}
+		if dryRun {
+			verb = "Would " + strings.ToLower(strings.TrimSuffix(verb, "d"))
+		}
+		fmt.Printf("\n%s %d unreferenced attachments\n", verb, done)
+	}
+	return nil
+}
+
+// formatSize formats a byte count for display
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,127 @@
+package assets
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runAssetsCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.AddCommand(NewAssetsCommand())
+
+	rootCmd.SetArgs(append([]string{"assets"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestAuditCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	write("note.md", "![[used.png]]\n")
+	write("attachments/used.png", "used")
+	write("attachments/old.png", "old")
+	write("attachments/old.pdf", "old pdf")
+
+	require.NoError(t, runAssetsCommand(t, "audit", dir, "--format", "json"))
+	require.Error(t, runAssetsCommand(t, "audit", dir, "--delete-unreferenced", "--move-to", "_unused"))
+	require.Error(t, runAssetsCommand(t, "audit", dir, "--move-to", "../outside"))
+
+	// Dry runs leave the attachments alone
+	require.NoError(t, runAssetsCommand(t, "audit", dir, "--move-to", "_unused", "--dry-run"))
+	assert.FileExists(t, filepath.Join(dir, "attachments", "old.png"))
+
+	require.NoError(t, runAssetsCommand(t, "audit", dir, "--move-to", "_unused"))
+	assert.NoFileExists(t, filepath.Join(dir, "attachments", "old.png"))
+	assert.FileExists(t, filepath.Join(dir, "_unused", "attachments", "old.png"))
+	assert.FileExists(t, filepath.Join(dir, "attachments", "used.png"))
+
+	require.NoError(t, runAssetsCommand(t, "audit", dir, "--folder", "_unused", "--delete-unreferenced"))
+	assert.NoFileExists(t, filepath.Join(dir, "_unused", "attachments", "old.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "_unused", "attachments", "old.pdf"))
+	assert.FileExists(t, filepath.Join(dir, "attachments", "used.png"))
+}
+
+func TestOrganizeCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	write("notes/trip.md", "![[images/beach.jpg|400]]\n![map](../scans/map.pdf)\n")
+	write("images/beach.jpg", "beach")
+	write("scans/map.pdf", "map")
+	write("scans/unused.pdf", "unused")
+
+	require.Error(t, runAssetsCommand(t, "organize", dir, "--strategy", "by-size"))
+	require.Error(t, runAssetsCommand(t, "organize", dir, "--dest", "../outside"))
+
+	// Dry runs leave the vault alone
+	require.NoError(t, runAssetsCommand(t, "organize", dir, "--strategy", "by-type", "--dry-run"))
+	assert.FileExists(t, filepath.Join(dir, "images", "beach.jpg"))
+
+	require.NoError(t, runAssetsCommand(t, "organize", dir, "--strategy", "by-type"))
+	assert.FileExists(t, filepath.Join(dir, "attachments", "images", "beach.jpg"))
+	assert.FileExists(t, filepath.Join(dir, "attachments", "pdfs", "map.pdf"))
+	assert.FileExists(t, filepath.Join(dir, "scans", "unused.pdf"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "notes", "trip.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "![[attachments/images/beach.jpg|400]]\n![map](../attachments/pdfs/map.pdf)\n", string(content))
+}
+
+func TestOptimizeCommand(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 600; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x * y), A: 255})
+		}
+	}
+	write := func(name string, encode func(*os.File) error) {
+		f, err := os.Create(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.NoError(t, encode(f))
+		require.NoError(t, f.Close())
+	}
+	write("photo.jpg", func(f *os.File) error { return jpeg.Encode(f, img, &jpeg.Options{Quality: 100}) })
+	write("screen.png", func(f *os.File) error { return png.Encode(f, img) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "note.md"), []byte("![[screen.png]]\n"), 0644))
+	before, err := os.Stat(filepath.Join(dir, "photo.jpg"))
+	require.NoError(t, err)
+
+	require.Error(t, runAssetsCommand(t, "optimize", dir, "--quality", "0"))
+	require.Error(t, runAssetsCommand(t, "optimize", dir, "--webp", "--cwebp", "cwebp-that-does-not-exist"))
+
+	require.NoError(t, runAssetsCommand(t, "optimize", dir, "--min-size", "0", "--max-dimension", "150", "--dry-run"))
+	unchanged, err := os.Stat(filepath.Join(dir, "photo.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, before.Size(), unchanged.Size())
+
+	require.NoError(t, runAssetsCommand(t, "optimize", dir, "--min-size", "0", "--max-dimension", "150"))
+	for _, name := range []string{"photo.jpg", "screen.png"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		require.NoError(t, err)
+		config, _, err := image.DecodeConfig(f)
+		f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, 150, config.Width, name)
+		assert.Equal(t, 100, config.Height, name)
+	}
+	after, err := os.Stat(filepath.Join(dir, "photo.jpg"))
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+}
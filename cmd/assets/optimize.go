@@ -0,0 +1,317 @@
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/diff"
+	"github.com/eoinhurrell/mdnotes/internal/imageopt"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+)
+
+// newOptimizeCommand creates the assets optimize command
+func newOptimizeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "optimize [vault-path]",
+		Short: "Shrink large images by scaling them down and compressing them",
+		Long: `Scale down and recompress the PNG and JPEG images larger than --min-size.
+Images wider or taller than --max-dimension are scaled to fit it, keeping
+their aspect ratio; JPEGs are encoded again at --quality and PNGs with the
+best compression. Photos stored rotated are turned upright, as their EXIF
+data is dropped. An image is only replaced when the result is smaller.
+
+--webp converts PNGs, typically screenshots, to WebP at --quality with the
+cwebp tool from libwebp, and rewrites the embeds and links to them the way
+'mdnotes assets organize' does. PNGs used by locked notes or from HTML, or
+referenced by a name other attachments share, are kept as PNG.
+
+Use --dry-run to see what would shrink, by how much, and the notes that
+would change. Changes are recorded in the change journal and can be
+reverted with 'mdnotes undo'.`,
+		Example: `  # See what would be saved
+  mdnotes assets optimize ~/vault --dry-run
+
+  # Photos no wider than 1600px at quality 75
+  mdnotes assets optimize ~/vault --max-dimension 1600 --quality 75
+
+  # Screenshots to WebP, updating the notes embedding them
+  mdnotes assets optimize ~/vault --folder attachments --webp`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runOptimize,
+	}
+
+	cmd.Flags().String("min-size", "500KB", "Only optimize images larger than this")
+	cmd.Flags().Int("max-dimension", 2560, "Longest side, in pixels, images are scaled down to (0 to keep their size)")
+	cmd.Flags().Int("quality", 82, "JPEG and WebP quality, 1 to 100")
+	cmd.Flags().Bool("webp", false, "Convert PNG images to WebP, updating links to them")
+	cmd.Flags().String("cwebp", "cwebp", "cwebp executable used to write WebP")
+	cmd.Flags().StringSlice("folder", nil, "Only optimize images in this folder, relative to the vault (repeatable; default: the whole vault)")
+
+	return cmd
+}
+
+// optimizedImage is an image and what it shrinks to
+type optimizedImage struct {
+	path   string
+	size   int64
+	result *imageopt.Result
+	to     string // New path when converted to another format
+}
+
+func runOptimize(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	minSizeFlag, _ := cmd.Flags().GetString("min-size")
+	maxDimension, _ := cmd.Flags().GetInt("max-dimension")
+	quality, _ := cmd.Flags().GetInt("quality")
+	webp, _ := cmd.Flags().GetBool("webp")
+	cwebp, _ := cmd.Flags().GetString("cwebp")
+	folders, _ := cmd.Flags().GetStringSlice("folder")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	minSize, err := processor.ParseByteSize(minSizeFlag)
+	if err != nil {
+		return fmt.Errorf("--min-size: %w", err)
+	}
+	if quality < 1 || quality > 100 {
+		return fmt.Errorf("--quality must be between 1 and 100")
+	}
+	if maxDimension < 0 {
+		return fmt.Errorf("--max-dimension must not be negative")
+	}
+	if webp {
+		if _, err := imageopt.FindCwebp(cwebp); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	vaultAbs, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return fmt.Errorf("resolving vault path: %w", err)
+	}
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if mode != selector.AutoDetect {
+		return fmt.Errorf("assets optimize reads every note in the vault and doesn't take --query, --from-file or --from-stdin")
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+
+	assets, err := processor.ListAssets(vaultAbs, processor.AssetAuditOptions{Folders: folders, IgnorePatterns: fileSelector.IgnorePatterns})
+	if err != nil {
+		return fmt.Errorf("finding images: %w", err)
+	}
+
+	options := imageopt.Options{MaxDimension: maxDimension, Quality: quality, CwebpPath: cwebp}
+	optimize := func(p string, format string) (*imageopt.Result, []byte, error) {
+		data, err := os.ReadFile(filepath.Join(vaultAbs, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, nil, err
+		}
+		opts := options
+		opts.Format = format
+		result, err := imageopt.Optimize(cmd.Context(), data, opts)
+		return result, data, err
+	}
+
+	var images []*optimizedImage
+	renames := make(map[string]string)
+	checked := 0
+	for _, asset := range assets {
+		ext := strings.ToLower(path.Ext(asset.Path))
+		if (ext != ".png" && ext != ".jpg" && ext != ".jpeg") || asset.Size <= minSize {
+			continue
+		}
+		checked++
+		format := ""
+		if webp && ext == ".png" {
+			format = imageopt.WebP
+		}
+		result, data, err := optimize(asset.Path, format)
+		if err != nil {
+			if errors.Is(err, imageopt.ErrUnsupported) {
+				err = fmt.Errorf("not a PNG or JPEG image")
+			}
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %v\n", asset.Path, err)
+			}
+			continue
+		}
+		if len(result.Data) >= len(data) {
+			if verbose && !quiet {
+				fmt.Printf("  %s is as small as it gets\n", asset.Path)
+			}
+			continue
+		}
+		image := &optimizedImage{path: asset.Path, size: asset.Size, result: result}
+		if format == imageopt.WebP {
+			renames[asset.Path] = strings.TrimSuffix(asset.Path, path.Ext(asset.Path)) + ".webp"
+		}
+		images = append(images, image)
+	}
+
+	// Converted images change name, so the notes using them change too
+	plan := &processor.AssetOrganizePlan{}
+	if len(renames) > 0 {
+		selection, err := fileSelector.SelectFiles(vaultAbs, mode)
+		if err != nil {
+			return fmt.Errorf("selecting files: %w", err)
+		}
+		if len(selection.ParseErrors) > 0 && verbose {
+			selection.PrintParseErrors()
+		}
+		plan, err = processor.PlanAssetRenames(vaultAbs, selection.Files, renames, fileSelector.IgnorePatterns)
+		if err != nil {
+			return fmt.Errorf("planning renames: %w", err)
+		}
+		moved := make(map[string]string, len(plan.Moves))
+		for _, move := range plan.Moves {
+			moved[move.From] = move.To
+		}
+		skipped := make(map[string]string, len(plan.Skipped))
+		for _, skip := range plan.Skipped {
+			skipped[skip.Path] = skip.Reason
+		}
+
+		kept := images[:0]
+		for _, image := range images {
+			if _, ok := renames[image.path]; !ok {
+				kept = append(kept, image)
+				continue
+			}
+			if to, ok := moved[image.path]; ok {
+				image.to = to
+				kept = append(kept, image)
+				continue
+			}
+			// Left a PNG, but it may still shrink
+			if !quiet {
+				fmt.Printf("⚠ Keeping %s as PNG: %s\n", image.path, skipped[image.path])
+			}
+			result, data, err := optimize(image.path, "")
+			if err == nil && len(result.Data) < len(data) {
+				image.result = result
+				kept = append(kept, image)
+			}
+		}
+		images = kept
+	}
+
+	if len(images) == 0 {
+		if !quiet {
+			fmt.Printf("No images to optimize (%d checked)\n", checked)
+		}
+		return nil
+	}
+
+	var before, after int64
+	for _, image := range images {
+		before += image.size
+		after += int64(len(image.result.Data))
+	}
+
+	if dryRun {
+		for _, image := range images {
+			if image.to != "" {
+				fmt.Printf("Would convert %s to %s: %s\n", image.path, path.Base(image.to), describeOptimization(image))
+			} else {
+				fmt.Printf("Would shrink %s: %s\n", image.path, describeOptimization(image))
+			}
+		}
+		fmt.Printf("Would save %s (%s -> %s) across %d images\n", formatSize(before-after), formatSize(before), formatSize(after), len(images))
+		if len(plan.Edits) > 0 {
+			fmt.Printf("Would update %d references in %d files\n", plan.Links, len(plan.Edits))
+			for _, edit := range plan.Edits {
+				fmt.Println()
+				fmt.Print(diff.Unified("a/"+edit.Path, "b/"+edit.Path, edit.Before, edit.After, diff.DefaultContext))
+			}
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(vaultAbs))
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("assets optimize")
+
+	for _, image := range images {
+		file := filepath.Join(vaultAbs, filepath.FromSlash(image.path))
+		if image.to != "" {
+			target := filepath.Join(vaultAbs, filepath.FromSlash(image.to))
+			if err := tx.RecordMove(file, target); err != nil {
+				return fmt.Errorf("recording move of %s: %w", image.path, err)
+			}
+			if err := os.Rename(file, target); err != nil {
+				return fmt.Errorf("renaming %s: %w", image.path, err)
+			}
+			file = target
+		}
+		if err := tx.RecordWrite(file); err != nil {
+			return fmt.Errorf("recording change to %s: %w", image.path, err)
+		}
+		if err := safety.WriteFile(file, image.result.Data, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", image.path, err)
+		}
+		if verbose && !quiet {
+			if image.to != "" {
+				fmt.Printf("✓ Converted %s to %s: %s\n", image.path, path.Base(image.to), describeOptimization(image))
+			} else {
+				fmt.Printf("✓ Shrank %s: %s\n", image.path, describeOptimization(image))
+			}
+		}
+	}
+	if err := applyEdits(tx, vaultAbs, plan.Edits); err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("✓ Optimized %d images, saving %s (%s -> %s)\n", len(images), formatSize(before-after), formatSize(before), formatSize(after))
+		if len(plan.Edits) > 0 {
+			fmt.Printf("✓ Updated %d references in %d files\n", plan.Links, len(plan.Edits))
+		}
+	}
+	return nil
+}
+
+// describeOptimization describes how an image shrinks
+func describeOptimization(image *optimizedImage) string {
+	r := image.result
+	description := fmt.Sprintf("%s -> %s", formatSize(image.size), formatSize(int64(len(r.Data))))
+	if r.Resized() {
+		description += fmt.Sprintf(" (%dx%d -> %dx%d)", r.OriginalWidth, r.OriginalHeight, r.Width, r.Height)
+	}
+	return description
+}
+
+// applyEdits writes the notes and canvases changed by moving attachments
+func applyEdits(tx *safety.Transaction, vaultPath string, edits []processor.AssetEdit) error {
+	for _, edit := range edits {
+		file := filepath.Join(vaultPath, filepath.FromSlash(edit.Path))
+		if err := tx.RecordWrite(file); err != nil {
+			return fmt.Errorf("recording change to %s: %w", edit.Path, err)
+		}
+		if err := safety.WriteFile(file, []byte(edit.After), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", edit.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package assets
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/diff"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+)
+
+// newOrganizeCommand creates the assets organize command
+func newOrganizeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "organize [vault-path]",
+		Short: "Move attachments into a folder structure and update the notes using them",
+		Long: `Move the attachments notes use into a structure under --dest, rewriting every
+embed and link to them, ![[...]] and markdown images alike, along with
+frontmatter values and canvas cards.
+
+Strategies:
+  per-note  A folder per note, mirroring its path: attachments/projects/plan/
+            Attachments used by more than one note go in attachments/_shared/
+  by-type   A folder per kind of file: images, pdfs, audio, video, documents, other
+  by-date   A folder per year and month the file was last modified: attachments/2024/03/
+
+Links keep their form: embeds by name stay names while the name is unique,
+paths are pointed at the new place, relative links stay relative, and sizes
+(![[photo.png|300]]), aliases and fragments are kept. A file already at the
+destination is not overwritten; the one moving is numbered instead, as
+"photo 1.png".
+
+Attachments nothing references are left where they are, as are those used by
+locked notes or from HTML, and those referenced by a name more than one
+attachment has, since the link can't be told apart. Use --dry-run to see the
+moves and a diff of each note that would change. Moves and edits are
+recorded in the change journal and can be reverted with 'mdnotes undo'.`,
+		Example: `  # Preview a folder per note under attachments/
+  mdnotes assets organize ~/vault --strategy per-note --dry-run
+
+  # Sort the files in inbox/ into media/images, media/pdfs, ...
+  mdnotes assets organize ~/vault --strategy by-type --dest media --folder inbox
+
+  # Year and month folders
+  mdnotes assets organize ~/vault --strategy by-date`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runOrganize,
+	}
+
+	cmd.Flags().String("strategy", processor.OrganizePerNote, "Where attachments go: per-note, by-type or by-date")
+	cmd.Flags().String("dest", "attachments", "Folder the structure is built in, relative to the vault")
+	cmd.Flags().StringSlice("folder", nil, "Only move attachments in this folder, relative to the vault (repeatable; default: the whole vault)")
+
+	return cmd
+}
+
+func runOrganize(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	strategy, _ := cmd.Flags().GetString("strategy")
+	dest, _ := cmd.Flags().GetString("dest")
+	folders, _ := cmd.Flags().GetStringSlice("folder")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if !processor.IsValidOrganizeStrategy(strategy) {
+		return fmt.Errorf("invalid strategy '%s' - valid options are: %s, %s, %s", strategy,
+			processor.OrganizePerNote, processor.OrganizeByType, processor.OrganizeByDate)
+	}
+	dest = path.Clean(filepath.ToSlash(dest))
+	if path.IsAbs(dest) || dest == ".." || strings.HasPrefix(dest, "../") {
+		return fmt.Errorf("--dest must be a folder inside the vault")
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	vaultAbs, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return fmt.Errorf("resolving vault path: %w", err)
+	}
+
+	// Every note's references are rewritten, whatever the selection flags say
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if mode != selector.AutoDetect {
+		return fmt.Errorf("assets organize reads every note in the vault and doesn't take --query, --from-file or --from-stdin")
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(vaultAbs, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	plan, err := processor.PlanAssetOrganization(vaultAbs, selection.Files, processor.AssetOrganizeOptions{
+		Strategy:       strategy,
+		Dest:           dest,
+		Folders:        folders,
+		IgnorePatterns: fileSelector.IgnorePatterns,
+	})
+	if err != nil {
+		return fmt.Errorf("planning moves: %w", err)
+	}
+
+	if !quiet {
+		for _, skip := range plan.Skipped {
+			fmt.Printf("⚠ Skipping %s: %s\n", skip.Path, skip.Reason)
+		}
+		if verbose && plan.Unreferenced > 0 {
+			fmt.Printf("Leaving %d unreferenced attachments where they are\n", plan.Unreferenced)
+		}
+	}
+	if len(plan.Moves) == 0 {
+		if !quiet {
+			fmt.Println("No attachments to move")
+		}
+		return nil
+	}
+
+	if dryRun {
+		fmt.Printf("Would move %d attachments:\n", len(plan.Moves))
+		for _, move := range plan.Moves {
+			fmt.Printf("  %s -> %s\n", move.From, move.To)
+		}
+		if len(plan.Edits) > 0 {
+			fmt.Printf("Would update %d references in %d files\n", plan.Links, len(plan.Edits))
+			for _, edit := range plan.Edits {
+				fmt.Println()
+				fmt.Print(diff.Unified("a/"+edit.Path, "b/"+edit.Path, edit.Before, edit.After, diff.DefaultContext))
+			}
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(vaultAbs))
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("assets organize")
+
+	for _, move := range plan.Moves {
+		source := filepath.Join(vaultAbs, filepath.FromSlash(move.From))
+		target := filepath.Join(vaultAbs, filepath.FromSlash(move.To))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", path.Dir(move.To), err)
+		}
+		if err := tx.RecordMove(source, target); err != nil {
+			return fmt.Errorf("recording move of %s: %w", move.From, err)
+		}
+		if err := os.Rename(source, target); err != nil {
+			return fmt.Errorf("moving %s: %w", move.From, err)
+		}
+		if verbose && !quiet {
+			fmt.Printf("✓ Moved %s -> %s\n", move.From, move.To)
+		}
+	}
+	if err := applyEdits(tx, vaultAbs, plan.Edits); err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("✓ Moved %d attachments\n", len(plan.Moves))
+		if len(plan.Edits) > 0 {
+			fmt.Printf("✓ Updated %d references in %d files\n", plan.Links, len(plan.Edits))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	mdconfig "github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/secrets"
+)
+
+// NewConfigCommand creates the config command
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration and credentials",
+		Long: `Manage mdnotes configuration.
+
+Credentials such as API tokens don't need to be written into the config file,
+where they would be synced and backed up along with the vault. Any setting can
+reference an environment variable as ${VAR_NAME}, and the credential settings
+
+  linkding.api_token, readwise.api_token, archive.api_token, embeddings.api_key
+
+can reference a secret in the OS keychain as ${keychain:NAME}, stored with
+'config set-secret'. 'mdnotes doctor' warns about credentials written out in
+the config file.`,
+	}
+
+	cmd.AddCommand(newSetSecretCommand())
+
+	return cmd
+}
+
+func newSetSecretCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-secret <name>",
+		Short: "Store a credential in the OS keychain",
+		Long: `Store a secret, such as an API token, in the OS keychain under name, to be
+referenced from the config file as ${keychain:name}.
+
+The value is read from standard input, so it stays out of shell history and
+the process list; at a terminal you are prompted for it. Storing a name again
+replaces its value.
+
+The keychain is the login keychain on macOS (through the 'security' tool) and
+the Secret Service, such as GNOME Keyring or KWallet, elsewhere (through
+'secret-tool' from libsecret). On Windows, use environment variables.
+
+Secrets for the credential settings are conventionally named
+
+  linkding.token, readwise.token, archive.token, embeddings.api_key`,
+		Example: `  # Store the linkding token, entering it at the prompt
+  mdnotes config set-secret linkding.token
+
+  # Store a token from a password manager
+  op read op://vault/linkding/token | mdnotes config set-secret linkding.token
+
+  # Then reference it in the config
+  linkding:
+    api_token: ${keychain:linkding.token}`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSetSecret,
+	}
+
+	return cmd
+}
+
+func runSetSecret(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+	name := args[0]
+
+	if err := mdconfig.ValidateSecretName(name); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Fprintf(cmd.OutOrStdout(), "Would store secret '%s' in the keychain\n", name)
+		return nil
+	}
+
+	in := cmd.InOrStdin()
+	if file, ok := in.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Value for %s: ", name)
+		}
+	}
+	value, err := readSecret(in)
+	if err != nil {
+		return err
+	}
+
+	if err := secrets.Default.Set(name, value); err != nil {
+		return fmt.Errorf("storing secret '%s': %w", name, err)
+	}
+
+	if !quiet {
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Stored secret '%s' in the keychain\n", name)
+		setting := "<setting>"
+		if s, ok := mdconfig.LookupSecretSetting(name); ok {
+			setting = s.Setting
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Reference it in the config as %s: ${keychain:%s}\n", setting, name)
+	}
+	return nil
+}
+
+// readSecret reads a secret from the first line of in
+func readSecret(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading secret: %w", err)
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("no secret given on standard input")
+	}
+	return value, nil
+}
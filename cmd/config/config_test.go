@@ -0,0 +1,52 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/secrets"
+)
+
+func runConfig(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.AddCommand(NewConfigCommand())
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"config"}, args...))
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestSetSecret(t *testing.T) {
+	saved := secrets.Default
+	defer func() { secrets.Default = saved }()
+	store := secrets.Memory{}
+	secrets.Default = store
+
+	out, err := runConfig(t, "tok-123\n", "set-secret", "linkding.token")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-123", store["linkding.token"])
+	assert.Contains(t, out, "linkding.api_token: ${keychain:linkding.token}")
+
+	out, err = runConfig(t, "other\n", "set-secret", "--dry-run", "readwise.token")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Would store secret 'readwise.token'")
+	assert.NotContains(t, store, "readwise.token")
+
+	_, err = runConfig(t, "", "set-secret", "readwise.token")
+	assert.ErrorContains(t, err, "no secret given")
+
+	_, err = runConfig(t, "x\n", "set-secret", "bad name")
+	assert.ErrorContains(t, err, "invalid secret name")
+}
@@ -0,0 +1,249 @@
+package content
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewContentCommand creates the content command
+func NewContentCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "content",
+		Short: "Clean up the raw content of markdown files",
+		Long:  "Commands for normalizing whitespace and other file-level formatting in Obsidian notes",
+	}
+
+	cmd.AddCommand(NewNormalizeCommand())
+	cmd.AddCommand(NewStyleCommand())
+
+	return cmd
+}
+
+// NewNormalizeCommand creates the content normalize command
+func NewNormalizeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "normalize [path]",
+		Aliases: []string{"n"},
+		Short:   "Normalize line endings and trailing whitespace",
+		Long: `Normalize whitespace across markdown files, including frontmatter.
+
+Mixed CRLF line endings from Windows collaborators cause noisy diffs and can
+break regex-based processing. Lines ending in exactly two spaces are markdown
+hard line breaks and are kept when trimming trailing whitespace.
+
+Example:
+  mdnotes content normalize --line-endings lf --trim-trailing-space --final-newline /vault/path`,
+		Args: cobra.ExactArgs(1),
+		RunE: runNormalize,
+	}
+
+	cmd.Flags().String("line-endings", processor.LineEndingsLF, "Line endings: lf, crlf, keep")
+	cmd.Flags().Bool("trim-trailing-space", false, "Remove trailing whitespace from lines")
+	cmd.Flags().Bool("final-newline", false, "End every file with exactly one newline")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runNormalize(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	lineEndings, _ := cmd.Flags().GetString("line-endings")
+	trimTrailing, _ := cmd.Flags().GetBool("trim-trailing-space")
+	finalNewline, _ := cmd.Flags().GetBool("final-newline")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	rules := processor.NormalizeRules{
+		LineEndings:       strings.ToLower(lineEndings),
+		TrimTrailingSpace: trimTrailing,
+		FinalNewline:      finalNewline,
+	}
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	// Setup file processor; content is rewritten byte-for-byte rather than re-serialized
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			normalized, stats := processor.Normalize(file.Content, rules)
+			if stats == (processor.NormalizeStats{}) {
+				return false, nil
+			}
+			file.Content = normalized
+
+			if verbose {
+				var changes []string
+				if stats.LineEndingsChanged {
+					changes = append(changes, "converted line endings to "+rules.LineEndings)
+				}
+				if stats.LinesTrimmed > 0 {
+					changes = append(changes, fmt.Sprintf("trimmed %d lines", stats.LinesTrimmed))
+				}
+				if stats.FinalNewlineChanged {
+					changes = append(changes, "fixed final newline")
+				}
+				fmt.Printf("Examining: %s - %s\n", file.RelativePath, strings.Join(changes, ", "))
+			}
+			return true, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+		Serialize: func(file *vault.VaultFile) ([]byte, error) {
+			return file.Content, nil
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// NewStyleCommand creates the content style command
+func NewStyleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "style [path]",
+		Aliases: []string{"st"},
+		Short:   "Apply typography and markdown style rules",
+		Long: `Apply opt-in typography and markdown style rules to note bodies.
+Each rule is enabled by its own flag; rules run in the order listed below.
+Code blocks, inline code, links and URLs are never changed.
+
+Rules:
+  --quotes curly|straight          Typographic quotes, or plain ASCII quotes
+  --strong asterisk|underscore     Bold markers (** or __)
+  --emphasis asterisk|underscore   Italic markers (* or _)
+  --bullets -|*|+                  Unordered list markers
+  --checkboxes                     Normalize task checkboxes to "- [ ] task" / "- [x] task"
+  --list-indent 2|4                Spaces per list nesting level (tabs count as 4 columns)
+  --code-lang                      Add language hints to unlabelled code fences
+
+Example:
+  mdnotes content style --quotes curly --emphasis underscore --bullets - /vault/path`,
+		Args: cobra.ExactArgs(1),
+		RunE: runStyle,
+	}
+
+	cmd.Flags().String("quotes", "", "Quote style: curly, straight")
+	cmd.Flags().String("strong", "", "Bold marker: asterisk, underscore")
+	cmd.Flags().String("emphasis", "", "Italic marker: asterisk, underscore")
+	cmd.Flags().String("bullets", "", "List marker: -, *, +")
+	cmd.Flags().Int("list-indent", 0, "Spaces per list nesting level: 2, 4 (default: leave as is)")
+	cmd.Flags().Bool("checkboxes", false, "Normalize task checkbox spacing and case")
+	cmd.Flags().Bool("code-lang", false, "Infer language hints for unlabelled fenced code blocks")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runStyle(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	quotes, _ := cmd.Flags().GetString("quotes")
+	strong, _ := cmd.Flags().GetString("strong")
+	emphasis, _ := cmd.Flags().GetString("emphasis")
+	bullets, _ := cmd.Flags().GetString("bullets")
+	listIndent, _ := cmd.Flags().GetInt("list-indent")
+	checkboxes, _ := cmd.Flags().GetBool("checkboxes")
+	codeLang, _ := cmd.Flags().GetBool("code-lang")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	styleProcessor, err := processor.NewStyleProcessor(processor.StyleRules{
+		Quotes:     quotes,
+		Strong:     strong,
+		Emphasis:   emphasis,
+		Bullets:    bullets,
+		ListIndent: listIndent,
+		Checkboxes: checkboxes,
+		CodeLang:   codeLang,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: use --quotes, --strong, --emphasis, --bullets, --list-indent, --checkboxes or --code-lang", err)
+	}
+
+	// Setup file processor
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			changes := styleProcessor.Process(file)
+			if len(changes) == 0 {
+				return false, nil
+			}
+
+			if verbose {
+				var parts []string
+				for name, count := range changes {
+					parts = append(parts, fmt.Sprintf("%s: %d", name, count))
+				}
+				sort.Strings(parts)
+				fmt.Printf("Examining: %s - %s\n", file.RelativePath, strings.Join(parts, ", "))
+			}
+			return true, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
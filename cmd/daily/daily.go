@@ -0,0 +1,398 @@
+package daily
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewDailyCommand creates the daily command
+func NewDailyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "daily",
+		Short: "Create daily notes and report on missing days",
+		Long: `Create daily notes and report on the days that have none.
+
+Daily notes live in one folder and are named after their date. The folder,
+date format and template come from the daily section of the config file,
+falling back to Obsidian's daily notes settings and then to YYYY-MM-DD notes in
+the vault root:
+
+daily:
+  folder: journal           # Relative to the vault root
+  format: YYYY-MM-DD        # May contain '/', e.g. YYYY/MM/YYYY-MM-DD
+  template: daily           # In the templates directory, or a vault path
+
+Templates are filled in as for 'mdnotes new', with current_date set to the
+note's date, title to its file name and date to its date.`,
+	}
+
+	cmd.PersistentFlags().String("vault", ".", "Vault the daily notes are in")
+
+	cmd.AddCommand(NewCreateCommand())
+	cmd.AddCommand(NewBackfillCommand())
+	cmd.AddCommand(NewReportCommand())
+
+	return cmd
+}
+
+// NewCreateCommand creates the daily create command
+func NewCreateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create [date]",
+		Short: "Create the daily note for today or another day",
+		Long: `Create the daily note for a day, today by default. The day may be today,
+yesterday, tomorrow, a number of days from today such as -1 or +7, or a
+YYYY-MM-DD date. An existing note is left alone.`,
+		Example: `  # Create today's note
+  mdnotes daily create
+
+  # Create the note for a given day
+  mdnotes daily create 2024-03-05 --vault ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runCreate,
+	}
+}
+
+// NewBackfillCommand creates the daily backfill command
+func NewBackfillCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Create the missing daily notes in a range of days",
+		Long: `Create a daily note for every day from --from to --to that has none.
+Existing notes are left alone.`,
+		Example: `  # Fill in the notes missed this month
+  mdnotes daily backfill --from 2024-03-01 --to yesterday
+
+  # Preview the notes that would be created
+  mdnotes daily backfill --from -30 --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: runBackfill,
+	}
+
+	cmd.Flags().String("from", "", "First day to create a note for (required)")
+	cmd.Flags().String("to", "today", "Last day to create a note for")
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
+
+// NewReportCommand creates the daily report command
+func NewReportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report daily note streaks and missing days",
+		Long: `Report how many days from --from to --to have a daily note, the current
+and longest streaks of consecutive days, and the runs of missing days.
+
+A day without a note yet doesn't end the current streak when it is the last
+day of the report, so today's note can still be written.`,
+		Example: `  # Report on every day since the first daily note
+  mdnotes daily report
+
+  # Report on the last 90 days as JSON
+  mdnotes daily report --from -90 --format json`,
+		Args: cobra.NoArgs,
+		RunE: runReport,
+	}
+
+	cmd.Flags().String("from", "", "First day of the report (default: the first daily note)")
+	cmd.Flags().String("to", "today", "Last day of the report")
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json)")
+	cmd.Flags().Int("limit", 10, "Maximum runs of missing days listed, most recent first (0 for all)")
+
+	return cmd
+}
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	value := "today"
+	if len(args) > 0 {
+		value = args[0]
+	}
+	date, err := processor.ParseDay(value, time.Now())
+	if err != nil {
+		return err
+	}
+	return createNotes(cmd, date, date)
+}
+
+func runBackfill(cmd *cobra.Command, args []string) error {
+	fromValue, _ := cmd.Flags().GetString("from")
+	toValue, _ := cmd.Flags().GetString("to")
+
+	now := time.Now()
+	from, err := processor.ParseDay(fromValue, now)
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	to, err := processor.ParseDay(toValue, now)
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--from %s is after --to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	return createNotes(cmd, from, to)
+}
+
+// createNotes creates the missing daily notes from one day to another
+func createNotes(cmd *cobra.Command, from, to time.Time) error {
+	vaultPath, _ := cmd.Flags().GetString("vault")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	root := safety.FindVaultRoot(vaultPath)
+	daily, err := processor.LoadDailyNotes(cfg.Daily, root)
+	if err != nil {
+		return err
+	}
+
+	var template []byte
+	if daily.Template != "" {
+		path, err := templateFile(cfg.Templates, root, daily.Template)
+		if err != nil {
+			return err
+		}
+		if template, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("reading template '%s': %w", daily.Template, err)
+		}
+	}
+
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+
+	now := time.Now()
+	created, existing := 0, 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		relPath := daily.Path(day)
+		fullPath := filepath.Join(root, relPath)
+		if _, err := os.Stat(fullPath); err == nil {
+			existing++
+			if verbose || (from.Equal(to) && !quiet) {
+				fmt.Printf("- %s already exists\n", relPath)
+			}
+			continue
+		}
+
+		// current_date is the note's day, at the current time of day
+		output, err := renderNote(template, daily, day.Add(now.Sub(processor.StartOfDay(now))), relPath)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", relPath, err)
+		}
+		created++
+
+		if dryRun {
+			if !quiet {
+				fmt.Printf("Would create %s\n", relPath)
+			}
+			if verbose {
+				fmt.Printf("\n%s\n", output)
+			}
+			continue
+		}
+
+		if err := tx.RecordWrite(fullPath); err != nil {
+			return fmt.Errorf("recording change: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("creating folder: %w", err)
+		}
+		if err := safety.WriteFile(fullPath, output, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", relPath, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ Created %s\n", relPath)
+		}
+	}
+
+	if !from.Equal(to) && !quiet {
+		verb := "Created"
+		if dryRun {
+			verb = "Would create"
+		}
+		fmt.Printf("\n%s %d daily notes, %d already existed\n", verb, created, existing)
+	}
+	return nil
+}
+
+// renderNote fills in the daily note template for date, or returns a note
+// with just a heading when there is no template
+func renderNote(template []byte, daily *processor.DailyNotes, date time.Time, relPath string) ([]byte, error) {
+	title := filepath.Base(daily.Name(date))
+	if template == nil {
+		return []byte("# " + title + "\n"), nil
+	}
+
+	renderer := templates.NewRenderer()
+	renderer.SetCurrentTime(date)
+	vars := templates.Vars{
+		"title":    title,
+		"date":     vault.Date{Time: processor.StartOfDay(date)},
+		"template": daily.Template,
+		"folder":   strings.TrimPrefix(filepath.ToSlash(filepath.Dir(relPath)), "."),
+		"filename": title,
+	}
+	note, err := renderer.RenderNote(template, vars)
+	if err != nil {
+		return nil, fmt.Errorf("rendering template '%s': %w", daily.Template, err)
+	}
+	return note.Serialize()
+}
+
+// templateFile finds the daily note template: a name in the templates
+// directory, or a path relative to the vault root as Obsidian stores it
+func templateFile(cfg config.TemplatesConfig, root, name string) (string, error) {
+	if !strings.HasSuffix(name, ".md") {
+		name += ".md"
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		dir = "templates"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+
+	for _, path := range []string{filepath.Join(dir, name), filepath.Join(root, name)} {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("daily note template '%s' not found in %s or the vault root", strings.TrimSuffix(name, ".md"), dir)
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	vaultPath, _ := cmd.Flags().GetString("vault")
+	fromValue, _ := cmd.Flags().GetString("from")
+	toValue, _ := cmd.Flags().GetString("to")
+	format, _ := cmd.Flags().GetString("format")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format '%s' - valid options are: text, json", format)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	root := safety.FindVaultRoot(vaultPath)
+	daily, err := processor.LoadDailyNotes(cfg.Daily, root)
+	if err != nil {
+		return err
+	}
+
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+	)
+	files, err := scanner.Walk(root)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+
+	now := time.Now()
+	to, err := processor.ParseDay(toValue, now)
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	var from time.Time
+	if fromValue != "" {
+		if from, err = processor.ParseDay(fromValue, now); err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+	} else if first, ok := daily.FirstDate(files); ok {
+		from = first
+	} else {
+		return fmt.Errorf("no daily notes found in %s", filepath.Join(root, daily.Folder))
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--from %s is after --to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+
+	report := daily.Report(files, from, to)
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printReport(report, limit)
+	return nil
+}
+
+// printReport prints a daily report as text
+func printReport(report processor.DailyReport, limit int) {
+	fmt.Printf("Daily notes from %s to %s\n", formatDay(report.From), formatDay(report.To))
+	fmt.Println(strings.Repeat("=", 40))
+	fmt.Printf("Days with a note:  %d of %d (%.0f%%)\n", report.Present, report.Days, float64(report.Present)/float64(report.Days)*100)
+	fmt.Printf("Current streak:    %s\n", pluralDays(report.CurrentStreak))
+	if report.LongestStreak.Start.IsZero() {
+		fmt.Printf("Longest streak:    0 days\n")
+	} else {
+		fmt.Printf("Longest streak:    %s (%s)\n", pluralDays(report.LongestStreak.Days()), formatRange(report.LongestStreak))
+	}
+
+	if len(report.Gaps) == 0 {
+		fmt.Printf("\n✓ No missing days\n")
+		return
+	}
+	fmt.Printf("\nMissing days: %d\n", report.Missing)
+	for i := len(report.Gaps) - 1; i >= 0; i-- {
+		if limit > 0 && len(report.Gaps)-i > limit {
+			fmt.Printf("  ... and %d earlier\n", i+1)
+			break
+		}
+		gap := report.Gaps[i]
+		if gap.Days() == 1 {
+			fmt.Printf("  %s\n", formatRange(gap))
+		} else {
+			fmt.Printf("  %s (%s)\n", formatRange(gap), pluralDays(gap.Days()))
+		}
+	}
+}
+
+func formatDay(day time.Time) string {
+	return day.Format("2006-01-02")
+}
+
+func formatRange(r processor.DateRange) string {
+	if r.Days() == 1 {
+		return formatDay(r.Start)
+	}
+	return formatDay(r.Start) + " to " + formatDay(r.End)
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
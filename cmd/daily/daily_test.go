@@ -0,0 +1,107 @@
+package daily
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestVault creates a vault with a daily note template and a config
+// placing daily notes in journal/
+func createTestVault(t *testing.T) (vault, configPath string) {
+	vault = t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(vault, ".obsidian"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(vault, "templates"), 0755))
+
+	template := `---
+created: {{current_date}}
+tags: [daily]
+---
+# {{title}}
+
+Previous: [[{{current_date - 1d|date:YYYY-MM-DD}}]]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(vault, "templates", "daily.md"), []byte(template), 0644))
+
+	configPath = filepath.Join(vault, "config.yaml")
+	config := `version: "1.0"
+daily:
+  folder: journal
+  template: daily
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+	return vault, configPath
+}
+
+func runDailyCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.PersistentFlags().String("config", "", "Config file")
+	rootCmd.AddCommand(NewDailyCommand())
+
+	rootCmd.SetArgs(append([]string{"daily"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestDailyCommand_Create(t *testing.T) {
+	vault, configPath := createTestVault(t)
+
+	require.NoError(t, runDailyCommand(t, "create", "2024-03-05", "--vault", vault, "--config", configPath))
+
+	path := filepath.Join(vault, "journal", "2024-03-05.md")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "created: 2024-03-05\n")
+	assert.Contains(t, string(content), "# 2024-03-05\n\nPrevious: [[2024-03-04]]\n")
+
+	// Creating it again leaves the note alone
+	require.NoError(t, os.WriteFile(path, []byte("# Edited\n"), 0644))
+	require.NoError(t, runDailyCommand(t, "create", "2024-03-05", "--vault", vault, "--config", configPath))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# Edited\n", string(content))
+}
+
+func TestDailyCommand_Backfill(t *testing.T) {
+	vault, configPath := createTestVault(t)
+	require.NoError(t, runDailyCommand(t, "create", "2024-03-02", "--vault", vault, "--config", configPath))
+
+	require.NoError(t, runDailyCommand(t, "backfill", "--from", "2024-03-01", "--to", "2024-03-03", "--vault", vault, "--config", configPath, "--dry-run"))
+	_, err := os.Stat(filepath.Join(vault, "journal", "2024-03-01.md"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, runDailyCommand(t, "backfill", "--from", "2024-03-01", "--to", "2024-03-03", "--vault", vault, "--config", configPath))
+	for _, date := range []string{"2024-03-01", "2024-03-02", "2024-03-03"} {
+		assert.FileExists(t, filepath.Join(vault, "journal", date+".md"))
+	}
+}
+
+func TestDailyCommand_Errors(t *testing.T) {
+	vault, configPath := createTestVault(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{"invalid date", []string{"create", "someday"}, "invalid date 'someday'"},
+		{"reversed range", []string{"backfill", "--from", "2024-03-05", "--to", "2024-03-01"}, "is after --to"},
+		{"no daily notes", []string{"report"}, "no daily notes found"},
+		{"invalid format", []string{"report", "--format", "xml"}, "invalid format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "--vault", vault, "--config", configPath)
+			err := runDailyCommand(t, args...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	textdiff "github.com/eoinhurrell/mdnotes/internal/diff"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewDiffCommand creates the diff command
+func NewDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <path-a> <path-b>",
+		Short: "Compare the notes of two vaults",
+		Long: `Compare two vault trees, such as a vault and an export of it, a backup, or a
+synced copy, and report the notes added, removed, renamed and changed, field
+by field for frontmatter, along with links added and removed between notes.
+
+Notes are matched by their path relative to each tree. A note that moved
+without changes is reported as renamed. Links are compared after resolving
+them to notes, so converting links between wiki and markdown style, or
+pointing them at a renamed note, isn't a change. Attachments aren't compared.
+
+--show-diffs adds a unified diff of each changed note's body. With
+--exit-code the command fails when the trees differ, for use in scripts.`,
+		Example: `  # Check what an export changed
+  mdnotes diff ~/vault ~/export
+
+  # Compare against a backup, with body diffs
+  mdnotes diff --show-diffs ~/backups/vault ~/vault
+
+  # Fail a sync check if anything differs
+  mdnotes diff --exit-code --format json ~/vault ~/synced`,
+		Args: cobra.ExactArgs(2),
+		RunE: runDiff,
+	}
+
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json, markdown)")
+	cmd.Flags().Bool("show-diffs", false, "Show a unified diff of each changed note's body")
+	cmd.Flags().Bool("exit-code", false, "Fail when the trees differ")
+
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	showDiffs, _ := cmd.Flags().GetBool("show-diffs")
+	exitCode, _ := cmd.Flags().GetBool("exit-code")
+
+	if format != "text" && format != "json" && format != "markdown" {
+		return fmt.Errorf("invalid format '%s' - valid options are: text, json, markdown", format)
+	}
+	for _, path := range args {
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	from, err := scanTree(args[0], cfg.Vault.IgnorePatterns)
+	if err != nil {
+		return err
+	}
+	to, err := scanTree(args[1], cfg.Vault.IgnorePatterns)
+	if err != nil {
+		return err
+	}
+
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	result := ana.DiffVaults(from, to, args[0], args[1])
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "markdown":
+		fmt.Print(result.Markdown())
+		if showDiffs {
+			fmt.Print(bodyDiffs(result, from, to, "\n```diff\n", "```\n"))
+		}
+	default:
+		fmt.Print(result.Text())
+		if showDiffs {
+			fmt.Print(bodyDiffs(result, from, to, "\n", ""))
+		}
+	}
+
+	if exitCode && !result.Empty() {
+		return fmt.Errorf("vaults differ: %d added, %d removed, %d changed, %d renamed notes", result.Added, result.Removed, result.Changed, result.Renamed)
+	}
+	return nil
+}
+
+// scanTree parses the notes under root
+func scanTree(root string, ignorePatterns []string) ([]*vault.VaultFile, error) {
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(ignorePatterns),
+		vault.WithContinueOnErrors(),
+	)
+	files, err := scanner.Walk(root)
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// bodyDiffs returns a unified diff of the body of each changed note, each
+// wrapped in before and after
+func bodyDiffs(result *analyzer.VaultDiff, from, to []*vault.VaultFile, before, after string) string {
+	bodies := func(files []*vault.VaultFile) map[string]string {
+		byPath := make(map[string]string, len(files))
+		for _, file := range files {
+			byPath[filepath.ToSlash(file.RelativePath)] = file.Body
+		}
+		return byPath
+	}
+	oldBodies, newBodies := bodies(from), bodies(to)
+
+	var b strings.Builder
+	for _, note := range result.Notes {
+		if !note.BodyChanged {
+			continue
+		}
+		patch := textdiff.Unified("a/"+note.Path, "b/"+note.Path, oldBodies[note.Path], newBodies[note.Path], textdiff.DefaultContext)
+		if patch != "" {
+			b.WriteString(before + patch + after)
+		}
+	}
+	return b.String()
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,42 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runDiffCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("quiet", true, "Suppress output")
+	rootCmd.AddCommand(NewDiffCommand())
+
+	rootCmd.SetArgs(append([]string{"diff"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestDiffCommand(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	write := func(dir, path, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	write(a, "note.md", "---\nstatus: draft\n---\nSee [[other]].\n")
+	write(a, "other.md", "Other.\n")
+	write(b, "note.md", "---\nstatus: draft\n---\nSee [other](other.md).\n")
+	write(b, "other.md", "Other.\n")
+
+	// Only the link style differs, which isn't a change to the graph, but
+	// the body still changed
+	require.NoError(t, runDiffCommand(t, a, b))
+	assert.ErrorContains(t, runDiffCommand(t, "--exit-code", a, b), "vaults differ: 0 added, 0 removed, 1 changed")
+
+	write(b, "note.md", "---\nstatus: draft\n---\nSee [[other]].\n")
+	require.NoError(t, runDiffCommand(t, "--exit-code", "--format", "json", a, b))
+
+	assert.ErrorContains(t, runDiffCommand(t, "--format", "csv", a, b), "invalid format")
+	assert.ErrorContains(t, runDiffCommand(t, a, filepath.Join(b, "missing")), "not a directory")
+}
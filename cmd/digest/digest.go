@@ -0,0 +1,226 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/events"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewDigestCommand creates the digest command
+func NewDigestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "digest [vault-path]",
+		Short: "Summarize what changed in the vault over a period",
+		Long: `Produce a markdown digest of the vault over a period: new notes, heavily
+edited notes, new links between notes, completed tasks and the change in the
+vault's health score.
+
+Each run saves a snapshot of the vault in .mdnotes/digests, and the next
+digest compares against the latest snapshot taken by the start of its period.
+Without one, new notes are found by their created field and completed tasks
+by their completion date ("✅ 2025-01-31"), and edits and new links are
+reported from the next digest on.
+
+The digest is printed, written to a note with --output, or published as a
+"digest" event to the event log and webhook configured under events with
+--notify.`,
+		Example: `  # Digest of the last week
+  mdnotes digest ~/vault
+
+  # Write the digest to a note in the vault
+  mdnotes digest ~/vault --since "7 days" --output "reviews/weekly.md"
+
+  # Send the digest to the configured webhook
+  mdnotes digest ~/vault --since 2025-01-01 --notify`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runDigest,
+	}
+
+	cmd.Flags().String("since", "7 days", "Start of the period, as a date or a duration before now")
+	cmd.Flags().StringP("output", "o", "", "Note to write the digest to, relative to the vault")
+	cmd.Flags().Bool("notify", false, "Publish the digest to the configured event log and webhook")
+	cmd.Flags().Int("min-change", analyzer.DefaultDigestMinChange, "Word count change that makes a note heavily edited")
+	cmd.Flags().Int("limit", 10, "Maximum entries listed per section (0 for all)")
+	cmd.Flags().StringP("format", "f", "markdown", "Output format (markdown, json)")
+	cmd.Flags().String("created-field", "created", "Frontmatter field holding a note's creation date")
+
+	return cmd
+}
+
+func runDigest(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	sinceValue, _ := cmd.Flags().GetString("since")
+	output, _ := cmd.Flags().GetString("output")
+	notify, _ := cmd.Flags().GetBool("notify")
+	minChange, _ := cmd.Flags().GetInt("min-change")
+	limit, _ := cmd.Flags().GetInt("limit")
+	format, _ := cmd.Flags().GetString("format")
+	createdField, _ := cmd.Flags().GetString("created-field")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if format != "markdown" && format != "json" {
+		return fmt.Errorf("invalid format '%s' - valid options are: markdown, json", format)
+	}
+	since, err := query.ParseDateOrAgo(sinceValue)
+	if err != nil {
+		return fmt.Errorf("invalid --since value '%s': %w", sinceValue, err)
+	}
+	now := time.Now()
+	if since.After(now) {
+		return fmt.Errorf("--since %s is in the future", sinceValue)
+	}
+	if output != "" && !strings.HasSuffix(output, ".md") {
+		output += ".md"
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	var publisher *events.Publisher
+	if notify {
+		if publisher = events.NewPublisher(cfg.Events); publisher == nil {
+			return fmt.Errorf("--notify needs an event log or webhook configured under events")
+		}
+	}
+
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(vault.FindIndex(vaultPath)),
+	)
+	files, err := scanner.Walk(vaultPath)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	if output != "" {
+		// Earlier digests written to the same note aren't part of the digest
+		files = withoutNote(files, output)
+	}
+
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	rules, err := cli.HealthRules(cfg.Analysis.Health)
+	if err != nil {
+		return err
+	}
+	ana.SetHealthRules(rules)
+	snapshot := ana.Snapshot(files, now)
+	baseline, err := analyzer.LoadDigestBaseline(vaultPath, since)
+	if err != nil {
+		return err
+	}
+	digest := ana.Digest(files, snapshot, baseline, analyzer.DigestOptions{
+		Since:        since,
+		Until:        now,
+		MinChange:    minChange,
+		CreatedField: createdField,
+	})
+	markdown := digest.Markdown(limit)
+
+	switch {
+	case format == "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(digest); err != nil {
+			return fmt.Errorf("encoding digest: %w", err)
+		}
+	case output == "" && !notify:
+		fmt.Print(markdown)
+	}
+
+	if dryRun {
+		if output != "" {
+			fmt.Printf("Would write digest to %s\n", output)
+		}
+		if notify {
+			fmt.Println("Would publish digest event")
+		}
+		return nil
+	}
+
+	if output != "" {
+		if err := writeDigestNote(cmd, vaultPath, output, markdown); err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Printf("✓ Wrote digest to %s\n", output)
+		}
+	}
+	if notify {
+		event := events.Event{
+			Time:   now,
+			Type:   events.Digest,
+			Path:   output,
+			New:    markdown,
+			Source: cmd.CommandPath(),
+		}
+		if err := publisher.Publish([]events.Event{event}); err != nil {
+			return fmt.Errorf("publishing digest: %w", err)
+		}
+		if !quiet {
+			fmt.Println("✓ Published digest event")
+		}
+	}
+
+	if err := analyzer.SaveDigestSnapshot(vaultPath, snapshot); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
+
+// writeDigestNote writes the digest to a note in the vault, recording it for undo
+func writeDigestNote(cmd *cobra.Command, vaultPath, output, markdown string) error {
+	path := filepath.Join(vaultPath, output)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating digest folder: %w", err)
+	}
+
+	tx := cli.BeginTransaction(cmd, vaultPath)
+	defer cli.CommitTransaction(cmd, tx)
+
+	if err := tx.RecordWrite(path); err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	if err := safety.WriteFile(path, []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("writing digest: %w", err)
+	}
+	return nil
+}
+
+// withoutNote drops the note at relPath from files
+func withoutNote(files []*vault.VaultFile, relPath string) []*vault.VaultFile {
+	relPath = filepath.Clean(relPath)
+	kept := files[:0]
+	for _, file := range files {
+		if filepath.Clean(file.RelativePath) != relPath {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,598 @@
+package doctor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/rgsearch"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/pkg/plugins"
+)
+
+// checkStatus is the outcome of a single check
+type checkStatus int
+
+const (
+	statusOK checkStatus = iota
+	statusInfo
+	statusWarn
+	statusFail
+)
+
+// symbol returns the marker printed before a check
+func (s checkStatus) symbol() string {
+	switch s {
+	case statusInfo:
+		return "ℹ"
+	case statusWarn:
+		return "⚠"
+	case statusFail:
+		return "✗"
+	default:
+		return "✓"
+	}
+}
+
+// check is the result of one diagnostic, with a remediation step for problems
+type check struct {
+	Status  checkStatus
+	Summary string
+	Fix     string
+}
+
+// checkGroup groups related checks under a heading
+type checkGroup struct {
+	Name   string
+	Checks []check
+}
+
+// Path length limits per platform; Windows tools without long path support
+// stop at MAX_PATH
+const (
+	maxPathWindows     = 260
+	maxPathDarwin      = 1024
+	maxPathUnix        = 4096
+	largeVaultNotes    = 2000
+	inotifyWatchesFile = "/proc/sys/fs/inotify/max_user_watches"
+)
+
+// NewDoctorCommand creates the doctor command
+func NewDoctorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor [vault-path]",
+		Short: "Check the environment and vault for common problems",
+		Long: `Run a one-shot diagnostic of the mdnotes environment and a vault.
+
+Doctor checks platform limits that affect watch mode and long file paths
+(including paths too long for Windows, for vaults synced between machines),
+whether the config file loads and validates, the vault's notes and parse
+errors, the health of the vault index, locked notes and the change journal,
+and whether configured plugins load. Every problem comes with a suggested fix.
+
+Please include the output of 'mdnotes doctor' when reporting an issue.`,
+		Example: `  # Check the current directory
+  mdnotes doctor
+
+  # Check a specific vault with a specific config
+  mdnotes doctor ~/vault --config ~/vault/mdnotes.yaml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runDoctor,
+	}
+
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+
+	configPath, cfg, cfgErr := loadConfig(cmd)
+	sections := []checkGroup{
+		environmentSection(cmd.Root().Version),
+		configSection(configPath, cfg, cfgErr),
+	}
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	sections = append(sections, vaultSections(vaultPath, cfg)...)
+	sections = append(sections, pluginSection(cfg))
+
+	warnings, failures := printSections(sections)
+
+	fmt.Println()
+	switch {
+	case failures > 0:
+		fmt.Printf("%d problems and %d warnings found\n", failures, warnings)
+		return fmt.Errorf("doctor found %d problems", failures)
+	case warnings > 0:
+		fmt.Printf("No problems found, %d warnings\n", warnings)
+	default:
+		fmt.Println("No problems found")
+	}
+	return nil
+}
+
+// printSections prints every check and returns the number of warnings and failures
+func printSections(sections []checkGroup) (warnings, failures int) {
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(section.Name)
+		for _, c := range section.Checks {
+			fmt.Printf("  %s %s\n", c.Status.symbol(), c.Summary)
+			if c.Fix != "" && c.Status >= statusWarn {
+				fmt.Printf("      → %s\n", c.Fix)
+			}
+			switch c.Status {
+			case statusWarn:
+				warnings++
+			case statusFail:
+				failures++
+			}
+		}
+	}
+	return warnings, failures
+}
+
+// loadConfig loads the config the way other commands do, reporting which file was used
+func loadConfig(cmd *cobra.Command) (string, *config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath == "" {
+		for _, path := range config.GetDefaultConfigPaths() {
+			if _, err := os.Stat(path); err == nil {
+				configPath = path
+				break
+			}
+		}
+	}
+	if configPath == "" {
+		return "", config.DefaultConfig(), nil
+	}
+
+	cfg, err := config.LoadConfigFromFile(configPath)
+	return configPath, cfg, err
+}
+
+func environmentSection(version string) checkGroup {
+	if version == "" {
+		version = "unknown"
+	}
+	checks := []check{{
+		Status:  statusInfo,
+		Summary: fmt.Sprintf("mdnotes %s, %s %s/%s", strings.SplitN(version, "\n", 2)[0], runtime.Version(), runtime.GOOS, runtime.GOARCH),
+	}}
+
+	if searcher := rgsearch.NewSearcher(); searcher.IsAvailable() {
+		rgVersion, _ := searcher.GetVersion()
+		checks = append(checks, check{Status: statusOK, Summary: fmt.Sprintf("ripgrep available (%s)", strings.TrimSpace(rgVersion))})
+	} else {
+		checks = append(checks, check{
+			Status:  statusWarn,
+			Summary: "ripgrep not found; rename falls back to scanning every file for links",
+			Fix:     "Install ripgrep (https://github.com/BurntSushi/ripgrep) and make sure 'rg' is on your PATH",
+		})
+	}
+
+	return checkGroup{Name: "Environment", Checks: checks}
+}
+
+func configSection(configPath string, cfg *config.Config, loadErr error) checkGroup {
+	section := checkGroup{Name: "Configuration"}
+
+	if loadErr != nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("Config %s could not be loaded: %v", configPath, loadErr),
+			Fix:     "Fix the YAML syntax error above, or pass a working file with --config",
+		})
+		return section
+	}
+
+	if configPath == "" {
+		section.Checks = append(section.Checks, check{
+			Status:  statusInfo,
+			Summary: "No config file found; using defaults",
+		})
+	} else {
+		section.Checks = append(section.Checks, check{Status: statusOK, Summary: "Loaded " + configPath})
+	}
+
+	if err := cfg.Validate(); err != nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("Config is invalid: %v", err),
+			Fix:     "Correct the setting named above in " + configPath,
+		})
+	} else {
+		section.Checks = append(section.Checks, check{Status: statusOK, Summary: "Config is valid"})
+	}
+
+	if cfg.Vault.Path != "" {
+		if info, err := os.Stat(cfg.Vault.Path); err != nil || !info.IsDir() {
+			section.Checks = append(section.Checks, check{
+				Status:  statusWarn,
+				Summary: fmt.Sprintf("vault.path %s is not a directory", cfg.Vault.Path),
+				Fix:     "Point vault.path at your vault, or remove it to use the current directory",
+			})
+		}
+	}
+
+	if cfg.Linkding.APIURL != "" && cfg.Linkding.APIToken == "" {
+		section.Checks = append(section.Checks, check{
+			Status:  statusWarn,
+			Summary: "linkding.api_url is set but linkding.api_token is empty",
+			Fix:     "Set api_token: ${LINKDING_TOKEN} in the config and export LINKDING_TOKEN, or store it with 'mdnotes config set-secret linkding.token' and set api_token: ${keychain:linkding.token}",
+		})
+	}
+
+	for _, s := range config.SecretSettings {
+		if _, err := cfg.Secret(s.Setting); err != nil {
+			section.Checks = append(section.Checks, check{
+				Status:  statusWarn,
+				Summary: err.Error(),
+				Fix:     fmt.Sprintf("Store the secret with 'mdnotes config set-secret', or change %s in the config", s.Setting),
+			})
+		}
+	}
+
+	if plaintext := cfg.PlaintextSecrets(); len(plaintext) > 0 {
+		section.Checks = append(section.Checks, check{
+			Status:  statusWarn,
+			Summary: fmt.Sprintf("Credentials are written out in %s: %s", configPath, strings.Join(plaintext, ", ")),
+			Fix:     "Store each with 'mdnotes config set-secret NAME' and reference it as ${keychain:NAME}, or use an environment variable as ${VAR_NAME}",
+		})
+	}
+
+	return section
+}
+
+// vaultSections scans the vault once and reports on its notes, platform
+// limits, the index and the journal
+func vaultSections(vaultPath string, cfg *config.Config) []checkGroup {
+	section := checkGroup{Name: "Vault"}
+
+	info, err := os.Stat(vaultPath)
+	if err != nil || !info.IsDir() {
+		section.Checks = append(section.Checks, check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("%s is not a directory", vaultPath),
+			Fix:     "Run doctor from your vault or pass the vault path as an argument",
+		})
+		return []checkGroup{section}
+	}
+
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns), vault.WithContinueOnErrors())
+	files, err := scanner.Walk(vaultPath)
+	if err != nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("Scanning the vault failed: %v", err),
+			Fix:     "Check the permissions of the path named above",
+		})
+		return []checkGroup{section}
+	}
+	parseErrors := scanner.GetParseErrors()
+
+	section.Checks = append(section.Checks, check{
+		Status:  statusOK,
+		Summary: fmt.Sprintf("%d notes in %s", len(files)+len(parseErrors), vaultPath),
+	})
+	section.Checks = append(section.Checks, parseErrorCheck(parseErrors))
+	section.Checks = append(section.Checks, lockedNotesCheck(files))
+	section.Checks = append(section.Checks, pathLengthCheck(vaultPath))
+	section.Checks = append(section.Checks, watchLimitCheck(vaultPath, len(files)+len(parseErrors)))
+
+	return []checkGroup{
+		section,
+		indexSection(vaultPath, files, parseErrors),
+		journalSection(vaultPath),
+	}
+}
+
+func parseErrorCheck(parseErrors []vault.ParseError) check {
+	if len(parseErrors) == 0 {
+		return check{Status: statusOK, Summary: "All notes parse"}
+	}
+
+	var paths []string
+	for i, parseErr := range parseErrors {
+		if i == 3 {
+			paths = append(paths, "...")
+			break
+		}
+		paths = append(paths, parseErr.Path)
+	}
+	return check{
+		Status:  statusWarn,
+		Summary: fmt.Sprintf("%d notes have unparseable frontmatter (%s)", len(parseErrors), strings.Join(paths, ", ")),
+		Fix:     "Run 'mdnotes frontmatter check --repair' to fix common breakages",
+	}
+}
+
+func lockedNotesCheck(files []*vault.VaultFile) check {
+	locked := 0
+	for _, file := range files {
+		if file.IsLocked() {
+			locked++
+		}
+	}
+	if locked == 0 {
+		return check{Status: statusOK, Summary: "No locked notes"}
+	}
+	return check{
+		Status:  statusInfo,
+		Summary: fmt.Sprintf("%d notes are locked and will be skipped by mutating commands", locked),
+	}
+}
+
+// pathLengthCheck flags paths that exceed this platform's limit, or that would
+// break when the vault is synced to Windows
+func pathLengthCheck(vaultPath string) check {
+	maxPath := maxPathUnix
+	switch runtime.GOOS {
+	case "windows":
+		maxPath = maxPathWindows
+	case "darwin":
+		maxPath = maxPathDarwin
+	}
+
+	root, err := filepath.Abs(vaultPath)
+	if err != nil {
+		root = vaultPath
+	}
+
+	var tooLong, windowsTooLong []string
+	longest := 0
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if len(path) > maxPath {
+			tooLong = append(tooLong, path)
+		} else if len(path) > maxPathWindows {
+			windowsTooLong = append(windowsTooLong, path)
+		}
+		longest = max(longest, len(path))
+		return nil
+	})
+
+	switch {
+	case len(tooLong) > 0:
+		return check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("%d paths exceed the %s limit of %d characters, e.g. %s", len(tooLong), runtime.GOOS, maxPath, tooLong[0]),
+			Fix:     "Move the vault closer to the filesystem root or flatten deeply nested folders",
+		}
+	case len(windowsTooLong) > 0:
+		return check{
+			Status:  statusWarn,
+			Summary: fmt.Sprintf("%d paths are longer than %d characters and will fail on Windows, e.g. %s", len(windowsTooLong), maxPathWindows, windowsTooLong[0]),
+			Fix:     "If the vault is synced to Windows, shorten these paths with 'mdnotes rename' or enable long path support there",
+		}
+	}
+	return check{
+		Status:  statusOK,
+		Summary: fmt.Sprintf("Path lengths within limits (longest %d of %d characters)", longest, maxPath),
+	}
+}
+
+// watchLimitCheck compares the platform's file watch limits with what
+// 'mdnotes watch' needs for this vault
+func watchLimitCheck(vaultPath string, notes int) check {
+	switch runtime.GOOS {
+	case "linux":
+		dirs := 0
+		_ = filepath.WalkDir(vaultPath, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				dirs++
+			}
+			return nil
+		})
+
+		data, err := os.ReadFile(inotifyWatchesFile)
+		if err != nil {
+			return check{Status: statusInfo, Summary: "Could not read the inotify watch limit"}
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return check{Status: statusInfo, Summary: "Could not read the inotify watch limit"}
+		}
+		if dirs > limit {
+			return check{
+				Status:  statusFail,
+				Summary: fmt.Sprintf("Watch mode needs %d inotify watches but the limit is %d", dirs, limit),
+				Fix:     fmt.Sprintf("Raise it with: sudo sysctl fs.inotify.max_user_watches=%d", max(dirs*2, 524288)),
+			}
+		}
+		return check{Status: statusOK, Summary: fmt.Sprintf("inotify watch limit %d covers %d directories", limit, dirs)}
+
+	case "darwin", "freebsd", "openbsd", "netbsd", "dragonfly":
+		// kqueue needs a file descriptor for every watched file and directory
+		limit, ok := openFileLimit()
+		if !ok {
+			return check{Status: statusInfo, Summary: "Could not read the open file limit"}
+		}
+		if uint64(notes) >= limit {
+			return check{
+				Status:  statusWarn,
+				Summary: fmt.Sprintf("Watch mode opens a file descriptor per file but the limit is %d for %d notes", limit, notes),
+				Fix:     fmt.Sprintf("Raise it before watching with: ulimit -n %d", notes*2),
+			}
+		}
+		return check{Status: statusOK, Summary: fmt.Sprintf("Open file limit %d covers %d notes", limit, notes)}
+	}
+
+	return check{Status: statusInfo, Summary: fmt.Sprintf("No file watch limits to check on %s", runtime.GOOS)}
+}
+
+func indexSection(vaultPath string, files []*vault.VaultFile, parseErrors []vault.ParseError) checkGroup {
+	section := checkGroup{Name: "Index"}
+	notes := len(files) + len(parseErrors)
+
+	idx := vault.FindIndex(vaultPath)
+	if idx == nil {
+		result := check{Status: statusInfo, Summary: "No vault index"}
+		if notes >= largeVaultNotes {
+			result = check{
+				Status:  statusWarn,
+				Summary: fmt.Sprintf("No vault index for %d notes; every command re-parses the whole vault", notes),
+				Fix:     "Run 'mdnotes index build' to speed up repeated scans",
+			}
+		}
+		section.Checks = append(section.Checks, result)
+		return section
+	}
+
+	if _, err := os.Stat(idx.Path() + ".tmp"); err == nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusWarn,
+			Summary: "A temporary index file was left behind by an interrupted save",
+			Fix:     "Delete " + idx.Path() + ".tmp",
+		})
+	}
+
+	if idx.Len() == 0 && notes > 0 {
+		section.Checks = append(section.Checks, check{
+			Status:  statusWarn,
+			Summary: "The vault index is empty, unreadable or from an older version",
+			Fix:     "Run 'mdnotes index build'",
+		})
+		return section
+	}
+
+	stale := 0
+	for _, file := range files {
+		if !idx.IsCurrent(file.Path) {
+			stale++
+		}
+	}
+	removed := idx.Prune()
+
+	if stale > 0 || removed > 0 {
+		section.Checks = append(section.Checks, check{
+			Status:  statusInfo,
+			Summary: fmt.Sprintf("Index at %s covers %d notes; %d changed and %d deleted since last indexed", idx.Root(), idx.Len(), stale, removed),
+			Fix:     "Run 'mdnotes index update' to refresh it",
+		})
+	} else {
+		section.Checks = append(section.Checks, check{
+			Status:  statusOK,
+			Summary: fmt.Sprintf("Index at %s is up to date (%d notes)", idx.Root(), idx.Len()),
+		})
+	}
+	return section
+}
+
+func journalSection(vaultPath string) checkGroup {
+	section := checkGroup{Name: "Change journal"}
+	root := safety.FindVaultRoot(vaultPath)
+	journal := safety.NewJournal(root)
+
+	transactions, err := journal.List()
+	if err != nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusWarn,
+			Summary: fmt.Sprintf("The change journal could not be read: %v", err),
+			Fix:     fmt.Sprintf("Remove unreadable transactions from %s", filepath.Join(root, safety.DefaultJournalDir)),
+		})
+		return section
+	}
+
+	if len(transactions) == 0 {
+		section.Checks = append(section.Checks, check{Status: statusOK, Summary: "No recorded transactions"})
+	} else {
+		section.Checks = append(section.Checks, check{
+			Status:  statusOK,
+			Summary: fmt.Sprintf("%d recorded transactions, latest %s (%s)", len(transactions), transactions[0].ID, transactions[0].Command),
+		})
+	}
+
+	// The journal, index and analysis cache all live under .mdnotes
+	stateDir := filepath.Join(root, ".mdnotes")
+	if err := checkWritable(stateDir); err != nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("%s is not writable: %v", stateDir, err),
+			Fix:     "Fix the directory's permissions; undo, the index and caches can't be saved",
+		})
+	}
+	return section
+}
+
+// checkWritable verifies a file can be created in dir, creating dir if needed
+func checkWritable(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		dir = filepath.Dir(dir)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+func pluginSection(cfg *config.Config) checkGroup {
+	section := checkGroup{Name: "Plugins"}
+
+	if !cfg.Plugins.Enabled {
+		section.Checks = append(section.Checks, check{Status: statusInfo, Summary: "Plugins are disabled"})
+		return section
+	}
+
+	if runtime.GOOS == "windows" {
+		section.Checks = append(section.Checks, check{
+			Status:  statusWarn,
+			Summary: "Plugins are enabled but Go plugins aren't supported on Windows",
+			Fix:     "Set plugins.enabled: false",
+		})
+		return section
+	}
+
+	for _, path := range cfg.Plugins.SearchPaths {
+		if _, err := os.Stat(path); err != nil {
+			section.Checks = append(section.Checks, check{
+				Status:  statusInfo,
+				Summary: fmt.Sprintf("Plugin path %s does not exist", path),
+			})
+		}
+	}
+
+	manager := plugins.NewPluginManager(plugins.ManagerConfig{
+		Enabled:     cfg.Plugins.Enabled,
+		SearchPaths: cfg.Plugins.SearchPaths,
+		Plugins:     cfg.Plugins.Plugins,
+	})
+	if err := manager.LoadPlugins(); err != nil {
+		section.Checks = append(section.Checks, check{
+			Status:  statusFail,
+			Summary: fmt.Sprintf("Plugins failed to load: %v", err),
+			Fix:     "Rebuild the plugins with the same Go version and module versions as mdnotes",
+		})
+		return section
+	}
+
+	var names []string
+	for _, info := range manager.ListPlugins() {
+		names = append(names, info.Name)
+	}
+	summary := "No plugins found in the plugin paths"
+	if len(names) > 0 {
+		summary = fmt.Sprintf("%d plugins loaded: %s", len(names), strings.Join(names, ", "))
+	}
+	section.Checks = append(section.Checks, check{Status: statusOK, Summary: summary})
+	return section
+}
@@ -0,0 +1,111 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/secrets"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func statuses(group checkGroup) []checkStatus {
+	var result []checkStatus
+	for _, c := range group.Checks {
+		result = append(result, c.Status)
+	}
+	return result
+}
+
+func TestConfigSection(t *testing.T) {
+	group := configSection("mdnotes.yaml", nil, errors.New("parsing YAML: bad indent"))
+	assert.Equal(t, []checkStatus{statusFail}, statuses(group))
+
+	group = configSection("", config.DefaultConfig(), nil)
+	assert.Equal(t, []checkStatus{statusInfo, statusOK}, statuses(group))
+
+	cfg := config.DefaultConfig()
+	cfg.Linkding.APIURL = "https://links.example.com"
+	cfg.Linkding.APIToken = ""
+	cfg.Vault.Path = filepath.Join(t.TempDir(), "missing")
+	group = configSection("mdnotes.yaml", cfg, nil)
+	assert.Equal(t, []checkStatus{statusOK, statusOK, statusWarn, statusWarn}, statuses(group))
+}
+
+func TestConfigSection_PlaintextSecrets(t *testing.T) {
+	cfg, err := config.LoadConfig(strings.NewReader("version: \"1.0\"\nreadwise:\n  api_token: abc123\n"))
+	require.NoError(t, err)
+	group := configSection("mdnotes.yaml", cfg, nil)
+	require.Equal(t, []checkStatus{statusOK, statusOK, statusWarn}, statuses(group))
+	assert.Contains(t, group.Checks[2].Summary, "readwise.api_token")
+}
+
+func TestConfigSection_MissingKeychainSecret(t *testing.T) {
+	saved := secrets.Default
+	defer func() { secrets.Default = saved }()
+	secrets.Default = secrets.Memory{}
+
+	cfg, err := config.LoadConfig(strings.NewReader("version: \"1.0\"\nreadwise:\n  api_token: ${keychain:readwise.token}\n"))
+	require.NoError(t, err)
+	group := configSection("mdnotes.yaml", cfg, nil)
+	require.Equal(t, []checkStatus{statusOK, statusOK, statusWarn}, statuses(group))
+	assert.Contains(t, group.Checks[2].Summary, "secret 'readwise.token' is not in the keychain")
+}
+
+func TestVaultSections(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("ok.md", "---\ntitle: ok\n---\n")
+	write("locked.md", "---\nmdnotes: locked\n---\n")
+	write("broken.md", "---\ntitle: [unclosed\n---\n")
+	write(strings.Repeat("n", 200)+"/"+strings.Repeat("x", 100)+".md", "long path")
+
+	groups := vaultSections(dir, config.DefaultConfig())
+	require.Len(t, groups, 3)
+
+	notes := groups[0]
+	assert.Equal(t, "Vault", notes.Name)
+	assert.Contains(t, notes.Checks[0].Summary, "4 notes")
+	assert.Equal(t, statusWarn, notes.Checks[1].Status)
+	assert.Contains(t, notes.Checks[1].Summary, "broken.md")
+	assert.Equal(t, statusInfo, notes.Checks[2].Status)
+	assert.Contains(t, notes.Checks[2].Summary, "1 notes are locked")
+	assert.Equal(t, statusWarn, notes.Checks[3].Status)
+	assert.Contains(t, notes.Checks[3].Summary, "will fail on Windows")
+
+	index := groups[1]
+	assert.Equal(t, []checkStatus{statusInfo}, statuses(index))
+
+	journal := groups[2]
+	assert.Equal(t, []checkStatus{statusOK}, statuses(journal))
+}
+
+func TestIndexSection(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("# Note"), 0644))
+
+	idx := vault.NewIndex(dir)
+	files, err := vault.NewScanner(vault.WithIndex(idx)).Walk(dir)
+	require.NoError(t, err)
+	require.NoError(t, idx.Save())
+
+	group := indexSection(dir, files, nil)
+	assert.Equal(t, []checkStatus{statusOK}, statuses(group))
+	assert.Contains(t, group.Checks[0].Summary, "up to date (1 notes)")
+
+	require.NoError(t, os.WriteFile(path, []byte("# Note, edited"), 0644))
+	require.NoError(t, os.WriteFile(idx.Path()+".tmp", nil, 0644))
+	group = indexSection(dir, files, nil)
+	assert.Equal(t, []checkStatus{statusWarn, statusInfo}, statuses(group))
+	assert.Contains(t, group.Checks[1].Summary, "1 changed and 0 deleted")
+}
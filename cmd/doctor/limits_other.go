@@ -0,0 +1,8 @@
+//go:build !unix
+
+package doctor
+
+// openFileLimit is not available on this platform
+func openFileLimit() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package doctor
+
+import "syscall"
+
+// openFileLimit returns the soft limit on open file descriptors
+func openFileLimit() (uint64, bool) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, false
+	}
+	return uint64(limit.Cur), true
+}
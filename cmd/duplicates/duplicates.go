@@ -0,0 +1,363 @@
+package duplicates
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewDuplicatesCommand creates the duplicates command
+func NewDuplicatesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "Resolve duplicated notes",
+		Long: `Commands for cleaning up the Obsidian copies ("Note 1.md") and sync-conflict
+files 'mdnotes analyze duplicates' reports.`,
+	}
+
+	cmd.AddCommand(NewResolveCommand())
+
+	return cmd
+}
+
+// NewResolveCommand creates the duplicates resolve command
+func NewResolveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resolve [path]",
+		Short: "Merge Obsidian copies and sync-conflict files into the original note",
+		Long: `Resolve each note that has Obsidian copies or sync-conflict files by keeping
+one version's content at the original note's path and deleting the copies.
+
+The version kept is chosen by --strategy:
+  keep-original  the original note (default)
+  keep-newest    the most recently modified version
+  keep-largest   the largest version
+
+or one by one with --interactive. Copies are found by name, so a copy whose
+body differs from the original's, by more than --similarity of their words,
+may be a different note, like "Chapter 1.md" beside "Chapter.md". Such copies
+are marked in the listing and left alone by --strategy; resolve them with
+--interactive. Frontmatter is merged into the kept note:
+fields missing from it are copied from the other versions and list fields such
+as tags and aliases combine every version's values. Links to the deleted copies
+are rewritten to point at the original. Groups containing a locked file are
+skipped.
+
+Without --strategy or --interactive, duplicates are only listed. Deleted
+files can be restored with 'mdnotes undo'.`,
+		Example: `  # List duplicated notes
+  mdnotes duplicates resolve ~/vault
+
+  # Keep the newest version of each note, previewing first
+  mdnotes duplicates resolve ~/vault --strategy keep-newest --dry-run
+  mdnotes duplicates resolve ~/vault --strategy keep-newest
+
+  # Choose the version to keep for each note
+  mdnotes duplicates resolve ~/vault --interactive`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runResolve,
+	}
+
+	cmd.Flags().String("strategy", "", "Version to keep: keep-original, keep-newest or keep-largest")
+	cmd.Flags().BoolP("interactive", "i", false, "Choose the version to keep for each note")
+	cmd.Flags().Float64("similarity", analyzer.DefaultSimilarityThreshold, "Minimum share of words a copy has in common with the original for --strategy to resolve it (0.0-1.0)")
+
+	return cmd
+}
+
+func runResolve(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	strategy, _ := cmd.Flags().GetString("strategy")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	similarity, _ := cmd.Flags().GetFloat64("similarity")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+	if strategy != "" && interactive {
+		return fmt.Errorf("--strategy and --interactive can't be used together")
+	}
+	if strategy != "" && !slices.Contains(processor.DuplicateStrategies, strategy) {
+		return fmt.Errorf("invalid strategy '%s' - valid options are: %s", strategy, strings.Join(processor.DuplicateStrategies, ", "))
+	}
+	if similarity <= 0 || similarity > 1 {
+		return fmt.Errorf("--similarity must be greater than 0 and at most 1, got %g", similarity)
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	selection, err := fileSelector.SelectFiles(path, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+	files := selection.Files
+
+	groups := processor.FindDuplicateGroups(files)
+	if len(groups) == 0 {
+		if !quiet {
+			fmt.Println("No duplicates found")
+		}
+		return nil
+	}
+
+	var locked []*vault.VaultFile
+	for _, file := range files {
+		if file.LockReason() != "" {
+			locked = append(locked, file)
+		}
+	}
+	updater := processor.NewLinkUpdater()
+
+	input := bufio.NewReader(cmd.InOrStdin())
+	prompting := interactive
+	var resolutions []processor.DuplicateResolution
+
+	for _, group := range groups {
+		matching, diverged := processor.SplitDivergedCopies(group, similarity)
+		if !quiet {
+			printGroup(group, diverged)
+		}
+		if reason := lockedFile(group); reason != "" {
+			if !quiet {
+				fmt.Printf("  ⚠ Skipped: %s\n", reason)
+			}
+			continue
+		}
+
+		var chosen *vault.VaultFile
+		switch {
+		case strategy != "":
+			// Only copies matching the original are resolved automatically
+			for _, file := range diverged {
+				if !quiet {
+					fmt.Printf("  ⚠ Skipped %s: its content differs from the original; resolve it with --interactive\n", file.RelativePath)
+				}
+			}
+			if len(matching.Copies) == 0 {
+				continue
+			}
+			group = matching
+			chosen, err = processor.ChooseDuplicate(group, strategy)
+			if err != nil {
+				return err
+			}
+		case prompting:
+			choice, quit := promptKeep(input, len(group.Copies)+1)
+			if quit {
+				prompting = false
+			} else if choice >= 0 {
+				chosen = group.Files()[choice]
+			}
+		}
+		if chosen == nil {
+			continue
+		}
+
+		resolution := processor.ResolveDuplicates(group, chosen)
+		// Links in locked notes can't be updated, so copies they link to stay
+		resolution.Removed = slices.DeleteFunc(resolution.Removed, func(file *vault.VaultFile) bool {
+			note := lockedLinker(updater, locked, file, resolution.Kept)
+			if note != nil && !quiet {
+				fmt.Printf("  ⚠ Kept %s: locked note %s links to it\n", file.RelativePath, note.RelativePath)
+			}
+			return note != nil
+		})
+		resolutions = append(resolutions, resolution)
+		if !quiet {
+			printResolution(resolution, dryRun)
+		}
+	}
+
+	// Point links at the kept notes, leaving the files about to be deleted
+	removed := make(map[*vault.VaultFile]bool)
+	changed := make(map[*vault.VaultFile]bool)
+	for _, resolution := range resolutions {
+		for _, file := range resolution.Removed {
+			removed[file] = true
+		}
+		if resolution.Chosen != resolution.Kept || len(resolution.Merged) > 0 {
+			changed[resolution.Kept] = true
+		}
+	}
+	relinked := 0
+	if moves := processor.DuplicateMoves(resolutions); len(moves) > 0 {
+		for _, file := range files {
+			if !removed[file] && file.LockReason() == "" && updater.UpdateFile(file, moves) {
+				changed[file] = true
+				relinked++
+				if verbose {
+					fmt.Printf("Updated links in: %s\n", file.RelativePath)
+				}
+			}
+		}
+	}
+
+	if !dryRun && len(resolutions) > 0 {
+		if err := applyResolutions(cmd, path, files, changed, removed); err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		if dryRun {
+			fmt.Printf("\nResolve completed: would remove %d duplicates of %d notes and update links in %d files\n",
+				len(removed), len(resolutions), relinked)
+		} else {
+			fmt.Printf("\nResolve completed: removed %d duplicates of %d notes and updated links in %d files\n",
+				len(removed), len(resolutions), relinked)
+		}
+		if strategy == "" && !interactive {
+			fmt.Println("Run with --strategy <strategy> or --interactive to resolve duplicates")
+		}
+	}
+	return nil
+}
+
+// printGroup lists the versions of a duplicated note, numbered for the
+// prompt, marking the copies whose content differs from the original
+func printGroup(group processor.DuplicateGroup, diverged []*vault.VaultFile) {
+	fmt.Printf("%s:\n", group.Original.RelativePath)
+	for i, file := range group.Files() {
+		label := ""
+		if i == 0 {
+			label = ", original"
+		} else if slices.Contains(diverged, file) {
+			label = ", content differs"
+		}
+		fmt.Printf("    %d. %s (%s, %d bytes%s)\n",
+			i+1, file.RelativePath, file.Modified.Format("2006-01-02 15:04"), len(file.Content), label)
+	}
+}
+
+// printResolution reports the version kept, the copies removed and the
+// frontmatter fields merged
+func printResolution(resolution processor.DuplicateResolution, dryRun bool) {
+	verb := "Kept"
+	if dryRun {
+		verb = "Would keep"
+	}
+	kept := resolution.Kept.RelativePath
+	if resolution.Chosen != resolution.Kept {
+		kept = fmt.Sprintf("%s with the content of %s", kept, resolution.Chosen.RelativePath)
+	}
+	fmt.Printf("  ✓ %s %s\n", verb, kept)
+	for _, file := range resolution.Removed {
+		if dryRun {
+			fmt.Printf("  Would remove: %s\n", file.RelativePath)
+		} else {
+			fmt.Printf("  ✓ Removed: %s\n", file.RelativePath)
+		}
+	}
+	if len(resolution.Merged) > 0 {
+		fmt.Printf("  Merged fields: %s\n", strings.Join(resolution.Merged, ", "))
+	}
+}
+
+// lockedFile describes the first locked file of group, or returns "" when none
+// is locked
+func lockedFile(group processor.DuplicateGroup) string {
+	for _, file := range group.Files() {
+		if reason := file.LockReason(); reason != "" {
+			return fmt.Sprintf("%s is locked: %s", file.RelativePath, reason)
+		}
+	}
+	return ""
+}
+
+// lockedLinker returns the first of the locked notes with a link to copy,
+// which resolving would point at kept, or nil if none links to it
+func lockedLinker(updater *processor.LinkUpdater, locked []*vault.VaultFile, copy, kept *vault.VaultFile) *vault.VaultFile {
+	moves := []processor.FileMove{{From: copy.RelativePath, To: kept.RelativePath}}
+	for _, note := range locked {
+		if note != copy && updater.UpdateReferences(note.Body, moves) != note.Body {
+			return note
+		}
+	}
+	return nil
+}
+
+// promptKeep asks which version to keep, returning its index, -1 to skip the
+// note, or quit to stop asking
+func promptKeep(input *bufio.Reader, count int) (choice int, quit bool) {
+	for {
+		fmt.Printf("    Keep [1-%d], [s]kip or [q]uit: ", count)
+		answer, err := input.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if err != nil && answer == "" {
+			if err == io.EOF {
+				fmt.Println()
+			}
+			return -1, true
+		}
+
+		switch answer {
+		case "s", "skip", "":
+			return -1, false
+		case "q", "quit":
+			return -1, true
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= count {
+			return n - 1, false
+		}
+	}
+}
+
+// applyResolutions writes the changed notes and deletes the removed copies,
+// recording everything in the journal
+func applyResolutions(cmd *cobra.Command, path string, files []*vault.VaultFile, changed, removed map[*vault.VaultFile]bool) error {
+	tx := cli.BeginTransaction(cmd, path)
+	defer cli.CommitTransaction(cmd, tx)
+
+	for _, file := range files {
+		if !changed[file] {
+			continue
+		}
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+	}
+
+	for _, file := range files {
+		if !removed[file] {
+			continue
+		}
+		if err := tx.RecordDelete(file.Path); err != nil {
+			return fmt.Errorf("recording removal of %s: %w", file.RelativePath, err)
+		}
+		if err := os.Remove(file.Path); err != nil {
+			return fmt.Errorf("removing %s: %w", file.RelativePath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package duplicates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runDuplicatesCommand(t *testing.T, stdin string, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.AddCommand(NewDuplicatesCommand())
+
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"duplicates"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestResolveCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	write("Note.md", "---\ntags: [a]\n---\nNotes from the launch meeting with the whole team on Monday\n")
+	write("Note 1.md", "---\ntags: [b]\n---\nNotes from the launch meeting with the whole team on Monday about the budget\n")
+	write("Chapter.md", "The opening chapter sets the scene\n")
+	write("Chapter 1.md", "An unrelated note that only happens to share a name\n")
+	write("Draft.md", "draft\n")
+	write("Draft 1.md", "draft copy\n")
+	write("index.md", "[[Note 1]] [[Draft 1]]\n")
+
+	// Listing only
+	require.NoError(t, runDuplicatesCommand(t, "", "resolve", dir))
+	assert.FileExists(t, filepath.Join(dir, "Note 1.md"))
+
+	// Dry run
+	require.NoError(t, runDuplicatesCommand(t, "", "resolve", "--strategy", "keep-largest", "--dry-run", dir))
+	assert.FileExists(t, filepath.Join(dir, "Note 1.md"))
+	assert.Equal(t, "[[Note 1]] [[Draft 1]]\n", read("index.md"))
+
+	// Skip Chapter, keep the original Draft, skip Note
+	require.NoError(t, runDuplicatesCommand(t, "s\n1\ns\n", "resolve", "--interactive", dir))
+	assert.NoFileExists(t, filepath.Join(dir, "Draft 1.md"))
+	assert.Equal(t, "draft\n", read("Draft.md"))
+	assert.FileExists(t, filepath.Join(dir, "Note 1.md"))
+	assert.Equal(t, "[[Note 1]] [[Draft]]\n", read("index.md"))
+
+	require.NoError(t, runDuplicatesCommand(t, "", "resolve", "--strategy", "keep-largest", dir))
+	assert.NoFileExists(t, filepath.Join(dir, "Note 1.md"))
+	assert.Equal(t, "---\ntags:\n    - b\n    - a\n---\n\nNotes from the launch meeting with the whole team on Monday about the budget\n", read("Note.md"))
+	assert.Equal(t, "[[Note]] [[Draft]]\n", read("index.md"))
+
+	// A copy whose content differs may be a different note, so a strategy
+	// leaves it alone
+	assert.FileExists(t, filepath.Join(dir, "Chapter 1.md"))
+	assert.Equal(t, "An unrelated note that only happens to share a name\n", read("Chapter 1.md"))
+	assert.Equal(t, "The opening chapter sets the scene\n", read("Chapter.md"))
+
+	assert.ErrorContains(t, runDuplicatesCommand(t, "", "resolve", "--strategy", "keep-oldest", dir), "invalid strategy")
+	assert.ErrorContains(t, runDuplicatesCommand(t, "", "resolve", "--strategy", "keep-newest", "-i", dir), "can't be used together")
+	assert.ErrorContains(t, runDuplicatesCommand(t, "", "resolve", "--similarity", "0", dir), "--similarity must be greater than 0")
+}
+
+func TestResolveCommand_LockedLinkingNote(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	locked := "---\nmdnotes: locked\n---\nSee [[Note 1]] and [[Draft 1]]\n"
+	write("Note.md", "Notes from the launch meeting\n")
+	write("Note 1.md", "Notes from the launch meeting\n")
+	write("Draft.md", "draft\n")
+	write("Draft 1.md", "draft\n")
+	write("locked.md", locked)
+	write("index.md", "[[Note 1]] [[Draft 1]]\n")
+	write("Other.md", "other\n")
+	write("Other 1.md", "other\n")
+
+	require.NoError(t, runDuplicatesCommand(t, "", "resolve", "--strategy", "keep-original", dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "locked.md"))
+	require.NoError(t, err)
+	assert.Equal(t, locked, string(content))
+	// Copies the locked note links to are kept so its links still resolve
+	assert.FileExists(t, filepath.Join(dir, "Note 1.md"))
+	assert.FileExists(t, filepath.Join(dir, "Draft 1.md"))
+	assert.NoFileExists(t, filepath.Join(dir, "Other 1.md"))
+}
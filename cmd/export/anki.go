@@ -0,0 +1,258 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/anki"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// newAnkiCommand creates the export anki command
+func newAnkiCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "anki <output-file> [vault-path]",
+		Short: "Export flashcards from notes as an Anki deck",
+		Long: `Turn notes into Anki flashcards, written as a CSV file for Anki's importer
+(File > Import) or as an .apkg package.
+
+Each heading at --heading-level with text under it is a basic card: the
+heading is the question and its section, down to the next heading of the same
+or a higher level, the answer. Each paragraph with cloze deletions, as in
+"The capital of France is {{c1::Paris}}", is a cloze card. Cards are rendered
+as HTML; wiki links become their text.
+
+Every note with cards gets a stable ID in its frontmatter (anki_id:, or
+--id-field) the first time it is exported. Cards are identified by that ID
+and their heading, or their position among the note's cloze paragraphs, so
+importing a later export updates the cards already in Anki, with their review
+history, rather than adding them again; notes can be renamed or moved
+freely. Editing a heading makes it a new card. IDs are written through the
+journal and can be reverted with 'mdnotes undo'.`,
+		Example: `  # Flashcards from notes tagged flashcards, for Anki's importer
+  mdnotes export anki ./flashcards.csv ~/vault --query "tags contains 'flashcards'"
+
+  # An .apkg package, questions under level 3 headings
+  mdnotes export anki ./spanish.apkg ~/vault --query "folder = 'languages/'" --heading-level 3
+
+  # See which cards would be exported
+  mdnotes export anki ./deck.csv ~/vault --dry-run --verbose`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runAnki,
+	}
+
+	cmd.Flags().String("query", "", "Query selecting the notes to take cards from")
+	cmd.Flags().String("format", "", "Output format: csv or apkg (default: from the output file extension)")
+	cmd.Flags().String("deck", "", "Deck the cards go in (default: output file name)")
+	cmd.Flags().Int("heading-level", 2, "Level of the headings that are questions")
+	cmd.Flags().String("id-field", "anki_id", "Frontmatter field holding each note's stable ID")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns for scanning vault")
+
+	return cmd
+}
+
+func runAnki(cmd *cobra.Command, args []string) error {
+	outputPath := args[0]
+	vaultPath := "."
+	if len(args) > 1 {
+		vaultPath = args[1]
+	}
+
+	query, _ := cmd.Flags().GetString("query")
+	format, _ := cmd.Flags().GetString("format")
+	deck, _ := cmd.Flags().GetString("deck")
+	headingLevel, _ := cmd.Flags().GetInt("heading-level")
+	idField, _ := cmd.Flags().GetString("id-field")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	}
+	if format != "csv" && format != "apkg" {
+		return NewExportError(ErrInvalidInput, fmt.Sprintf("invalid format '%s' - valid options are: csv, apkg", format))
+	}
+	if headingLevel < 1 || headingLevel > 6 {
+		return NewExportError(ErrInvalidInput, "--heading-level must be between 1 and 6")
+	}
+	if strings.TrimSpace(idField) == "" {
+		return NewExportError(ErrInvalidInput, "--id-field must not be empty")
+	}
+	if query != "" {
+		if err := validateQuerySyntax(query); err != nil {
+			return NewExportError(ErrQuery, err.Error())
+		}
+	}
+	if deck == "" {
+		deck = strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	}
+
+	vaultAbs, err := resolveVaultPath(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	fileSelector = fileSelector.WithIgnorePatterns(append(fileSelector.IgnorePatterns, ignorePatterns...))
+	if query != "" {
+		fileSelector = fileSelector.WithQuery(query)
+		mode = selector.FilesFromQuery
+	}
+	selection, err := fileSelector.SelectFiles(vaultAbs, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+	files := selection.Files
+	sort.Slice(files, func(i, j int) bool { return files[i].RelativePath < files[j].RelativePath })
+
+	type noteCards struct {
+		file  *vault.VaultFile
+		cards []anki.Card
+	}
+	var notes []noteCards
+	for _, file := range files {
+		if cards := anki.ExtractCards(file.Body, headingLevel); len(cards) > 0 {
+			notes = append(notes, noteCards{file, cards})
+		}
+	}
+	if len(notes) == 0 {
+		if !quiet {
+			fmt.Println("No flashcards found")
+		}
+		return nil
+	}
+
+	noteFiles := make([]*vault.VaultFile, len(notes))
+	for i, note := range notes {
+		noteFiles[i] = note.file
+	}
+	ids, assigned, err := assignAnkiIDs(cmd, vaultAbs, noteFiles, idField)
+	if err != nil {
+		return err
+	}
+
+	tags := processor.NewTagProcessor()
+	var entries []anki.Entry
+	for _, note := range notes {
+		for _, card := range note.cards {
+			entries = append(entries, anki.NewEntry(ids[note.file.RelativePath], note.file.RelativePath, card, tags.Tags(note.file)))
+			if verbose {
+				fmt.Printf("  %s card from %s: %s\n", card.Kind, note.file.RelativePath, firstLine(card.Front))
+			}
+		}
+	}
+
+	if dryRun {
+		if !quiet {
+			fmt.Printf("Would export %d cards from %d notes to %s (deck %q), assigning IDs to %d notes\n",
+				len(entries), len(notes), outputPath, deck, assigned)
+		}
+		return nil
+	}
+
+	var output bytes.Buffer
+	if format == "apkg" {
+		err = anki.WritePackage(&output, deck, entries, time.Now())
+	} else {
+		err = anki.WriteCSV(&output, deck, entries)
+	}
+	if err != nil {
+		return fmt.Errorf("building %s deck: %w", format, err)
+	}
+	if err := safety.WriteFile(outputPath, output.Bytes(), 0644); err != nil {
+		return NewExportErrorWithCause(ErrFileSystem, fmt.Sprintf("writing %s", outputPath), err)
+	}
+
+	if !quiet {
+		fmt.Printf("✓ Exported %d cards from %d notes to %s (deck %q)\n", len(entries), len(notes), outputPath, deck)
+		if assigned > 0 {
+			fmt.Printf("  Assigned IDs to %d notes\n", assigned)
+		}
+	}
+	return nil
+}
+
+// assignAnkiIDs returns each note's ID from its field, giving notes without
+// one, or sharing one with an earlier note, a new ID written to their
+// frontmatter. Locked notes are left alone and identified by their path. It
+// also returns the number of IDs assigned.
+func assignAnkiIDs(cmd *cobra.Command, vaultPath string, files []*vault.VaultFile, field string) (map[string]string, int, error) {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(vaultPath))
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("anki")
+
+	ids := make(map[string]string, len(files))
+	used := make(map[string]string)
+	assigned := 0
+	for _, file := range files {
+		if value, ok := file.Frontmatter[field]; ok && value != nil {
+			id := strings.TrimSpace(fmt.Sprint(value))
+			if other, taken := used[id]; id != "" && !taken {
+				ids[file.RelativePath] = id
+				used[id] = file.RelativePath
+				continue
+			} else if taken && !quiet {
+				fmt.Printf("⚠ %s has the same %s as %s\n", file.RelativePath, field, other)
+			}
+		}
+
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %s\n", file.RelativePath, reason)
+			}
+			ids[file.RelativePath] = filepath.ToSlash(file.RelativePath)
+			continue
+		}
+
+		id := uuid.NewString()
+		ids[file.RelativePath] = id
+		used[id] = file.RelativePath
+		assigned++
+		if dryRun {
+			fmt.Printf("Would set %s of %s\n", field, file.RelativePath)
+			continue
+		}
+		file.SetField(field, id)
+		content, err := file.Serialize()
+		if err != nil {
+			return nil, assigned, fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return nil, assigned, fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return nil, assigned, fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+	}
+	return ids, assigned, nil
+}
+
+// firstLine returns the first line of text
+func firstLine(text string) string {
+	if idx := strings.Index(text, "\n"); idx != -1 {
+		return text[:idx]
+	}
+	return text
+}
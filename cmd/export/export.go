@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/eoinhurrell/mdnotes/internal/preview"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
 )
 
@@ -54,9 +57,73 @@ ADVANCED FEATURES:
   # Include files that link to exported files (recursive)
   mdnotes export ./network --with-backlinks
 
+  # Include notes within two links of the matches, in either direction
+  mdnotes export ./topic --query "tags contains 'rust'" --with-links --depth 2 --link-direction both
+
   # Normalize filenames for web compatibility
   mdnotes export ./web --slugify --flatten
 
+STATIC SITES:
+  # Lay out the export as a Hugo, Jekyll or Astro site
+  mdnotes export ./site --target hugo --query "tags contains 'published'"
+
+  Site targets write notes where the generator expects them (Hugo content/,
+  Astro src/pages/), rename frontmatter fields (created becomes date in Hugo
+  and Jekyll, pubDate in Astro; modified becomes lastmod, last_modified_at or
+  updatedDate), add a title from the filename when there is none, and turn
+  links between notes into the generator's references (Hugo ref shortcodes,
+  Jekyll link tags, Astro routes). Assets are always included, in static/,
+  assets/ or public/, and each folder gets a section index (_index.md for
+  Hugo, index.md otherwise).
+
+  # A curated vault to push with Obsidian Publish
+  mdnotes export ./publish --target obsidian-publish
+
+  The obsidian-publish target keeps notes, wiki links and assets at their
+  vault paths, so publish.css can refer to assets the way the vault does, and
+  copies publish.css, publish.js and favicon.ico from the vault root. Links
+  to notes left out become plain text, and publish-index.json lists each
+  note's path, site URL (its permalink field or its path), title, aliases,
+  tags and links, for sitemaps. Without --query only notes with
+  publish: true are exported.
+
+SINGLE DOCUMENTS:
+  # Publish a vault subsection as an ebook
+  mdnotes export ./rust.epub --bundle onefile --query "tags contains 'rust'" --order links
+
+  # Merged markdown, or a PDF with a table of contents
+  mdnotes export ./notes.md --bundle onefile
+  mdnotes export ./notes.pdf --bundle onefile --title "Notes" --pandoc-arg=--toc
+
+  Bundles merge the selected notes into one document, each note a chapter
+  titled by its title field or filename with its headings nested below.
+  Links between bundled notes point to their chapters, links to other notes
+  become plain text, and images are embedded. --order sorts chapters by path,
+  title, created date, or by following links from the notes no other bundled
+  note links to. pdf, epub and docx are rendered with pandoc, which must be
+  installed (PDFs also need a LaTeX engine); the format defaults to the
+  output file's extension.
+
+GRAPHS:
+  # A canvas of a topic's notes and the links between them, to open in Obsidian
+  mdnotes export ~/vault/rust.canvas ~/vault --bundle graph --query "tags contains 'rust'" --with-links
+
+  # The same graph as JSON nodes and edges
+  mdnotes export ./rust.json --bundle graph --query "tags contains 'rust'"
+
+  Graph bundles write the selected notes and the links between them instead
+  of the notes. A JSON Canvas (.canvas) has a card per note, laid out in
+  columns following links from the notes nothing else selected links to, and
+  an arrow per linked pair; cards refer to notes by vault path, so save the
+  canvas in the vault to open it. JSON graphs list each note's path, title
+  and tags, and each linked pair with its number of links.
+
+FLASHCARDS:
+  # Heading questions and cloze deletions as an Anki deck
+  mdnotes export anki ./flashcards.apkg --query "tags contains 'flashcards'"
+
+  See 'mdnotes export anki --help'.
+
 PERFORMANCE OPTIONS:
   # Use parallel processing (auto-detects CPU count)
   mdnotes export ./output --parallel 0
@@ -67,10 +134,23 @@ PERFORMANCE OPTIONS:
   # Set timeout for large exports
   mdnotes export ./huge-vault --timeout 30m
 
+  # Re-export into the same folder, only rewriting what changed
+  mdnotes export ./site --target hugo --incremental
+
+  Incremental exports keep a manifest of content hashes in the output folder
+  (.mdnotes-export.json). Later runs with --incremental only write new and
+  changed files, copy assets whose source changed, and remove files of the
+  previous export that are no longer exported. The first run needs an empty
+  folder; files edited in the output by hand aren't detected.
+
 PREVIEW AND DEBUGGING:
   # Preview what would be exported without copying
   mdnotes export ./output --dry-run
 
+  # Browse the rendered export in a local web server before writing it
+  mdnotes export ./output --preview
+  mdnotes export ./output --preview --preview-addr 127.0.0.1:9000
+
   # Show detailed progress information
   mdnotes export ./output --verbose
 
@@ -101,11 +181,26 @@ PERFORMANCE GUIDELINES:
 	cmd.Flags().Bool("process-links", true, "Process and rewrite links in exported files")
 	cmd.Flags().Bool("include-assets", false, "Copy referenced assets (images, PDFs, etc.) to output directory")
 	cmd.Flags().Bool("with-backlinks", false, "Include files that link to exported files (recursive)")
+	cmd.Flags().Bool("with-links", false, "Include notes within --depth links of the exported files")
+	cmd.Flags().Int("depth", 1, "Link hops followed by --with-links (0 = no limit)")
+	cmd.Flags().String("link-direction", processor.LinkDirectionForward, "Links followed by --with-links: forward, backward or both")
 	cmd.Flags().Bool("slugify", false, "Convert filenames to URL-safe slugs")
 	cmd.Flags().Bool("flatten", false, "Put all files in a single directory")
+	cmd.Flags().String("target", "", "Lay out the export for a static site generator or Obsidian Publish: hugo, jekyll, astro or obsidian-publish")
+	cmd.Flags().String("bundle", "", "Write the export as a single file at the output path: onefile (merged document) or graph (notes and links)")
+	cmd.Flags().String("format", "", "Bundle format: md, pdf, epub or docx, or canvas or json for graphs (default: from the output file extension)")
+	cmd.Flags().String("order", processor.BundleOrderPath, "Order of notes in a bundle: path, title, created or links")
+	cmd.Flags().String("title", "", "Bundle document title (default: output file name)")
+	cmd.Flags().String("pandoc", "pandoc", "pandoc executable used to render pdf, epub and docx bundles")
+	cmd.Flags().StringArray("pandoc-arg", nil, "Extra argument passed to pandoc (repeatable)")
 	cmd.Flags().Duration("timeout", 10*time.Minute, "Maximum time to wait for export to complete")
 	cmd.Flags().Int("parallel", 0, "Number of parallel workers for file processing (0 = auto-detect)")
 	cmd.Flags().Bool("optimize-memory", false, "Use memory-optimized processing for large vaults")
+	cmd.Flags().Bool("incremental", false, "Only write files changed since the last incremental export to the output folder, removing ones no longer exported")
+	cmd.Flags().Bool("preview", false, "Serve the would-be export as HTML on a local web server instead of writing it")
+	cmd.Flags().String("preview-addr", "127.0.0.1:8080", "Address for the --preview web server")
+
+	cmd.AddCommand(newAnkiCommand())
 
 	return cmd
 }
@@ -125,11 +220,24 @@ func runExport(cmd *cobra.Command, args []string) error {
 	processLinks, _ := cmd.Flags().GetBool("process-links")
 	includeAssets, _ := cmd.Flags().GetBool("include-assets")
 	withBacklinks, _ := cmd.Flags().GetBool("with-backlinks")
+	withLinks, _ := cmd.Flags().GetBool("with-links")
+	linkDepth, _ := cmd.Flags().GetInt("depth")
+	linkDirection, _ := cmd.Flags().GetString("link-direction")
 	slugify, _ := cmd.Flags().GetBool("slugify")
 	flatten, _ := cmd.Flags().GetBool("flatten")
+	target, _ := cmd.Flags().GetString("target")
+	bundle, _ := cmd.Flags().GetString("bundle")
+	bundleFormat, _ := cmd.Flags().GetString("format")
+	bundleOrder, _ := cmd.Flags().GetString("order")
+	bundleTitle, _ := cmd.Flags().GetString("title")
+	pandoc, _ := cmd.Flags().GetString("pandoc")
+	pandocArgs, _ := cmd.Flags().GetStringArray("pandoc-arg")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 	parallelWorkers, _ := cmd.Flags().GetInt("parallel")
 	optimizeMemory, _ := cmd.Flags().GetBool("optimize-memory")
+	incremental, _ := cmd.Flags().GetBool("incremental")
+	previewMode, _ := cmd.Flags().GetBool("preview")
+	previewAddr, _ := cmd.Flags().GetString("preview-addr")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
@@ -148,15 +256,86 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return NewExportError(ErrInvalidInput, err.Error())
 	}
 
+	if linkDepth < 0 {
+		return NewExportError(ErrInvalidInput, "--depth must not be negative")
+	}
+	if !processor.IsValidLinkDirection(linkDirection) {
+		return NewExportError(ErrInvalidInput, fmt.Sprintf("invalid link direction '%s' - valid options are: forward, backward, both", linkDirection))
+	}
+	if target != "" {
+		if !processor.IsValidSiteTarget(target) {
+			return NewExportError(ErrInvalidInput, fmt.Sprintf("invalid target '%s' - valid options are: hugo, jekyll, astro, obsidian-publish", target))
+		}
+		// Publish exports are the notes marked for publishing unless a query says otherwise
+		if target == string(processor.ObsidianPublishTarget) && query == "" {
+			query = processor.DefaultPublishQuery
+		}
+		// Site links point at the generator's asset folder, so the assets must be there
+		includeAssets = true
+	}
+
+	if bundle != "" {
+		if bundle != processor.OneFileBundle && bundle != processor.GraphBundle {
+			return NewExportError(ErrInvalidInput, fmt.Sprintf("invalid bundle '%s' - valid options are: onefile, graph", bundle))
+		}
+		if target != "" || previewMode || incremental {
+			return NewExportError(ErrInvalidInput, "--bundle cannot be combined with --target, --preview or --incremental")
+		}
+		var err error
+		if outputPath, bundleFormat, err = resolveBundleFormat(outputPath, bundle, bundleFormat); err != nil {
+			return NewExportError(ErrInvalidInput, err.Error())
+		}
+		if bundle == processor.GraphBundle && (cmd.Flags().Changed("order") || cmd.Flags().Changed("title")) {
+			return NewExportError(ErrInvalidInput, "--order and --title apply to onefile bundles")
+		}
+		if !processor.IsValidBundleOrder(bundleOrder) {
+			return NewExportError(ErrInvalidInput, fmt.Sprintf("invalid order '%s' - valid options are: path, title, created, links", bundleOrder))
+		}
+		if bundleTitle == "" {
+			bundleTitle = strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+		}
+	} else if cmd.Flags().Changed("format") || cmd.Flags().Changed("order") || cmd.Flags().Changed("title") {
+		return NewExportError(ErrInvalidInput, "--format, --order and --title apply to --bundle exports")
+	}
+
+	if incremental && previewMode {
+		return NewExportError(ErrInvalidInput, "--incremental cannot be combined with --preview")
+	}
+
 	// Validate link strategy (already done in validateExportInputs)
 	// This is kept for backward compatibility but validation is now centralized
 
-	// Validate and resolve paths
-	vaultAbs, outputAbs, err := validateAndResolvePaths(vaultPath, outputPath, dryRun)
+	// Validate and resolve paths; previews never write to the output path
+	var vaultAbs, outputAbs string
+	var err error
+	if bundle != "" {
+		vaultAbs, outputAbs, err = validateAndResolveBundlePaths(vaultPath, outputPath, dryRun)
+	} else {
+		// An incremental export updates the output of the previous one in place
+		allowExisting := dryRun || previewMode
+		if incremental {
+			if abs, err := filepath.Abs(outputPath); err == nil && processor.HasExportManifest(abs) {
+				allowExisting = true
+			}
+		}
+		vaultAbs, outputAbs, err = validateAndResolvePaths(vaultPath, outputPath, allowExisting)
+	}
 	if err != nil {
 		return err
 	}
 
+	if previewMode {
+		// Export into a scratch directory so link rewriting and assets match the real output
+		previewDir, err := os.MkdirTemp("", "mdnotes-preview-*")
+		if err != nil {
+			return NewExportErrorWithCause(ErrFileSystem, "Cannot create preview directory", err)
+		}
+		defer os.RemoveAll(previewDir)
+
+		outputAbs = previewDir
+		dryRun = false
+	}
+
 	if verbose {
 		fmt.Printf("Exporting from: %s\n", vaultAbs)
 		fmt.Printf("Exporting to: %s\n", outputAbs)
@@ -177,10 +356,21 @@ func runExport(cmd *cobra.Command, args []string) error {
 		LinkStrategy:    linkStrategy,
 		IncludeAssets:   includeAssets,
 		WithBacklinks:   withBacklinks,
+		WithLinks:       withLinks,
+		LinkDepth:       linkDepth,
+		LinkDirection:   linkDirection,
 		Slugify:         slugify,
 		Flatten:         flatten,
 		ParallelWorkers: parallelWorkers,
 		OptimizeMemory:  optimizeMemory,
+		Target:          target,
+		Bundle:          bundle,
+		BundleFormat:    bundleFormat,
+		BundleOrder:     bundleOrder,
+		BundleTitle:     bundleTitle,
+		Pandoc:          pandoc,
+		PandocArgs:      pandocArgs,
+		Incremental:     incremental,
 	}
 
 	exportProcessor := processor.NewExportProcessor(options)
@@ -191,6 +381,10 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return handleExportError(err, options)
 	}
 
+	if previewMode {
+		return servePreview(result, outputAbs, previewAddr, quiet)
+	}
+
 	// Display results with enhanced summary
 	if dryRun {
 		displayDryRunSummary(result, verbose)
@@ -203,6 +397,21 @@ func runExport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// servePreview serves an exported directory over HTTP until interrupted
+func servePreview(result *processor.ExportResult, previewDir, addr string, quiet bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return preview.Serve(ctx, addr, previewDir, func(listenAddr string) {
+		if !quiet {
+			fmt.Printf("Previewing %d files (%s) from %s\n",
+				result.FilesSelected, formatSize(result.TotalSize), result.VaultPath)
+			fmt.Printf("Nothing has been written to the output folder.\n\n")
+		}
+		fmt.Printf("Serving preview at http://%s/ (press Ctrl+C to stop)\n", listenAddr)
+	})
+}
+
 // displayDryRunSummary shows what would be exported without doing it
 func displayDryRunSummary(result *processor.ExportResult, verbose bool) {
 	fmt.Printf("\nExport Summary (Dry Run)\n")
@@ -249,6 +458,12 @@ func displayDryRunSummary(result *processor.ExportResult, verbose bool) {
 		}
 	}
 
+	// Show linked notes statistics if any
+	if result.LinkedIncluded > 0 {
+		fmt.Printf("\nLinked notes (would be included):\n")
+		fmt.Printf("  • Additional files via links: %d\n", result.LinkedIncluded)
+	}
+
 	// Show backlinks statistics if any
 	if result.BacklinksIncluded > 0 {
 		fmt.Printf("\nBacklinks (would be included):\n")
@@ -261,6 +476,26 @@ func displayDryRunSummary(result *processor.ExportResult, verbose bool) {
 		fmt.Printf("  • Files to rename: %d\n", result.FilesRenamed)
 	}
 
+	// Show site layout statistics if any
+	if result.SectionIndexes > 0 {
+		fmt.Printf("\nSite layout (would be performed):\n")
+		fmt.Printf("  • Section indexes to generate: %d\n", result.SectionIndexes)
+	}
+	if result.PublishIndex != "" {
+		fmt.Printf("\nObsidian Publish (would be performed):\n")
+		fmt.Printf("  • Index of published notes: %s\n", result.PublishIndex)
+	}
+
+	// Show bundle details if any
+	if result.BundleFormat != "" {
+		fmt.Printf("\nBundle (would be written):\n")
+		if processor.IsValidGraphFormat(result.BundleFormat) {
+			fmt.Printf("  • A %s graph of %d notes and %d links between them\n", result.BundleFormat, len(result.SelectedFiles), result.GraphLinks)
+		} else {
+			fmt.Printf("  • One %s document of %d notes, in the order listed with --verbose\n", result.BundleFormat, len(result.SelectedFiles))
+		}
+	}
+
 	// Show individual files if verbose
 	if verbose && len(result.SelectedFiles) > 0 {
 		fmt.Printf("\nFiles that would be exported:\n")
@@ -307,6 +542,12 @@ func displayExportSummary(result *processor.ExportResult, outputPath string, ver
 		}
 	}
 
+	// Show linked notes statistics if any
+	if result.LinkedIncluded > 0 {
+		fmt.Printf("\nLinked notes:\n")
+		fmt.Printf("  • Additional files via links: %d\n", result.LinkedIncluded)
+	}
+
 	// Show backlinks statistics if any
 	if result.BacklinksIncluded > 0 {
 		fmt.Printf("\nBacklinks:\n")
@@ -319,6 +560,36 @@ func displayExportSummary(result *processor.ExportResult, outputPath string, ver
 		fmt.Printf("  • Files renamed: %d\n", result.FilesRenamed)
 	}
 
+	// Show site layout statistics if any
+	if result.SectionIndexes > 0 {
+		fmt.Printf("\nSite layout:\n")
+		fmt.Printf("  • Section indexes generated: %d\n", result.SectionIndexes)
+	}
+	if result.PublishIndex != "" {
+		fmt.Printf("\nObsidian Publish:\n")
+		fmt.Printf("  • Index of published notes: %s\n", result.PublishIndex)
+		if result.PublishSiteFiles > 0 {
+			fmt.Printf("  • Site files copied (publish.css, publish.js, favicon.ico): %d\n", result.PublishSiteFiles)
+		}
+	}
+
+	// Show incremental export statistics if any
+	if result.FilesUnchanged > 0 || result.FilesRemoved > 0 {
+		fmt.Printf("\nIncremental export:\n")
+		fmt.Printf("  • Unchanged files skipped: %d\n", result.FilesUnchanged)
+		fmt.Printf("  • Files no longer exported removed: %d\n", result.FilesRemoved)
+	}
+
+	// Show bundle details if any
+	if result.BundleFormat != "" {
+		fmt.Printf("\nBundle:\n")
+		if processor.IsValidGraphFormat(result.BundleFormat) {
+			fmt.Printf("  • A %s graph of %d notes and %d links between them\n", result.BundleFormat, result.FilesExported, result.GraphLinks)
+		} else {
+			fmt.Printf("  • One %s document of %d notes\n", result.BundleFormat, result.FilesExported)
+		}
+	}
+
 	if verbose {
 		fmt.Printf("\nProcessing details:\n")
 		fmt.Printf("  Files scanned: %d\n", result.FilesScanned)
@@ -490,43 +761,108 @@ func validateOutputPathSafety(outputPath string) error {
 
 // validateAndResolvePaths validates and resolves both vault and output paths
 func validateAndResolvePaths(vaultPath, outputPath string, dryRun bool) (string, string, error) {
-	// Resolve vault path
-	vaultAbs, err := filepath.Abs(vaultPath)
+	vaultAbs, err := resolveVaultPath(vaultPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Resolve output path
+	outputAbs, err := filepath.Abs(outputPath)
 	if err != nil {
 		return "", "", NewExportErrorWithCause(ErrInvalidInput,
+			fmt.Sprintf("Invalid output path '%s'", outputPath), err)
+	}
+
+	// Check output path constraints
+	if err := validateOutputPath(outputAbs, dryRun); err != nil {
+		return "", "", err
+	}
+
+	return vaultAbs, outputAbs, nil
+}
+
+// resolveVaultPath resolves the vault path and checks it is a readable directory
+func resolveVaultPath(vaultPath string) (string, error) {
+	vaultAbs, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return "", NewExportErrorWithCause(ErrInvalidInput,
 			fmt.Sprintf("Invalid vault path '%s'", vaultPath), err)
 	}
 
 	// Check vault exists and is accessible
 	vaultInfo, err := os.Stat(vaultAbs)
 	if os.IsNotExist(err) {
-		return "", "", NewExportError(ErrFileSystem,
+		return "", NewExportError(ErrFileSystem,
 			fmt.Sprintf("vault path does not exist: %s", vaultAbs))
 	}
 	if err != nil {
-		return "", "", NewExportErrorWithCause(ErrPermission,
+		return "", NewExportErrorWithCause(ErrPermission,
 			fmt.Sprintf("Cannot access vault path: %s", vaultAbs), err)
 	}
 	if !vaultInfo.IsDir() {
-		return "", "", NewExportError(ErrInvalidInput,
+		return "", NewExportError(ErrInvalidInput,
 			fmt.Sprintf("Vault path is not a directory: %s", vaultAbs))
 	}
+	return vaultAbs, nil
+}
+
+// validateAndResolveBundlePaths validates and resolves the vault path and the
+// file a bundle is written to, which must not already exist
+func validateAndResolveBundlePaths(vaultPath, outputPath string, dryRun bool) (string, string, error) {
+	vaultAbs, err := resolveVaultPath(vaultPath)
+	if err != nil {
+		return "", "", err
+	}
 
-	// Resolve output path
 	outputAbs, err := filepath.Abs(outputPath)
 	if err != nil {
 		return "", "", NewExportErrorWithCause(ErrInvalidInput,
 			fmt.Sprintf("Invalid output path '%s'", outputPath), err)
 	}
 
-	// Check output path constraints
-	if err := validateOutputPath(outputAbs, dryRun); err != nil {
-		return "", "", err
+	if info, err := os.Stat(outputAbs); err == nil {
+		if info.IsDir() {
+			return "", "", NewExportError(ErrInvalidInput,
+				fmt.Sprintf("Output path is a directory: %s\n\n--bundle writes a single file, such as book.epub", outputAbs))
+		}
+		if !dryRun {
+			return "", "", NewExportError(ErrInvalidInput,
+				fmt.Sprintf("output file already exists: %s", outputAbs))
+		}
+	} else if !os.IsNotExist(err) {
+		return "", "", NewExportErrorWithCause(ErrPermission,
+			fmt.Sprintf("Cannot access output path: %s", outputAbs), err)
 	}
 
 	return vaultAbs, outputAbs, nil
 }
 
+// resolveBundleFormat returns the bundle's output path and format. The format
+// defaults to the output file's extension, which is added when missing.
+func resolveBundleFormat(outputPath, bundle, format string) (string, string, error) {
+	valid, options := processor.IsValidBundleFormat, "md, pdf, epub or docx"
+	if bundle == processor.GraphBundle {
+		valid, options = processor.IsValidGraphFormat, "canvas or json"
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(outputPath)), ".")
+	if format == "" {
+		if !valid(ext) {
+			return "", "", fmt.Errorf("cannot tell the bundle format from '%s' - use --format %s", outputPath, options)
+		}
+		return outputPath, ext, nil
+	}
+	if !valid(format) {
+		return "", "", fmt.Errorf("invalid format '%s' - valid options are: %s", format, strings.ReplaceAll(options, " or", ","))
+	}
+	if ext == "" {
+		return outputPath + "." + format, format, nil
+	}
+	if ext != format {
+		return "", "", fmt.Errorf("output file '%s' does not match --format %s", outputPath, format)
+	}
+	return outputPath, format, nil
+}
+
 // validateOutputPath validates the output path constraints
 func validateOutputPath(outputAbs string, dryRun bool) error {
 	if info, err := os.Stat(outputAbs); err == nil {
@@ -1,6 +1,7 @@
 package export
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -442,6 +443,248 @@ priority: 3
 	assert.NoFileExists(t, filepath.Join(outputDir, "published_note.md"))
 }
 
+func TestExportCommand_SiteTarget(t *testing.T) {
+	vaultDir := createTestVault(t)
+	outputDir := createOutputDir(t)
+
+	createTestFile(t, vaultDir, "posts/hello.md", `---
+created: 2024-01-01
+---
+
+# Hello
+
+See [[world]] and ![[images/photo.png]]`)
+	createTestFile(t, vaultDir, "posts/world.md", `# World`)
+	createTestFile(t, vaultDir, "images/photo.png", "png")
+
+	args := []string{outputDir, vaultDir, "--target", "hugo"}
+	output, err := runExportCommand(t, args)
+
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Section indexes generated: 1")
+
+	// Notes, assets and section indexes go where Hugo expects them
+	assert.FileExists(t, filepath.Join(outputDir, "content", "posts", "hello.md"))
+	assert.FileExists(t, filepath.Join(outputDir, "content", "posts", "_index.md"))
+	assert.FileExists(t, filepath.Join(outputDir, "static", "images", "photo.png"))
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "content", "posts", "hello.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "date: 2024-01-01")
+	assert.Contains(t, string(content), "title: hello")
+	assert.Contains(t, string(content), `[world]({{< ref "posts/world.md" >}})`)
+	assert.Contains(t, string(content), "![photo.png](/images/photo.png)")
+
+	// Unknown targets are rejected
+	output, err = runExportCommand(t, []string{createOutputDir(t), vaultDir, "--target", "gatsby"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "invalid target 'gatsby'")
+}
+
+func TestExportCommand_ObsidianPublishTarget(t *testing.T) {
+	vaultDir := createTestVault(t)
+	outputDir := createOutputDir(t)
+
+	createTestFile(t, vaultDir, "notes/hello.md", `---
+publish: true
+---
+
+# Hello
+
+See [[world#Intro|the world]], [[draft]] and ![[images/photo.png|200]]`)
+	createTestFile(t, vaultDir, "world.md", "---\npublish: true\npermalink: the-world\n---\n\n# Intro")
+	createTestFile(t, vaultDir, "draft.md", "---\npublish: false\n---\n\n# Draft")
+	createTestFile(t, vaultDir, "images/photo.png", "png")
+	createTestFile(t, vaultDir, "publish.css", "body { color: red; }")
+
+	output, err := runExportCommand(t, []string{outputDir, vaultDir, "--target", "obsidian-publish"})
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "Index of published notes: publish-index.json")
+
+	// Only notes marked for publishing are exported, at their vault paths
+	assert.FileExists(t, filepath.Join(outputDir, "notes", "hello.md"))
+	assert.FileExists(t, filepath.Join(outputDir, "world.md"))
+	assert.NoFileExists(t, filepath.Join(outputDir, "draft.md"))
+	assert.FileExists(t, filepath.Join(outputDir, "images", "photo.png"))
+	assert.FileExists(t, filepath.Join(outputDir, "publish.css"))
+
+	// Wiki links survive, links to unpublished notes become text
+	content, err := os.ReadFile(filepath.Join(outputDir, "notes", "hello.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "See [[world#Intro|the world]], draft and ![[images/photo.png|200]]")
+	assert.NotContains(t, string(content), "title:")
+
+	index, err := os.ReadFile(filepath.Join(outputDir, "publish-index.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `"url": "/notes/hello"`)
+	assert.Contains(t, string(index), `"url": "/the-world"`)
+}
+
+func TestExportCommand_GraphBundle(t *testing.T) {
+	vaultDir := createTestVault(t)
+	outputDir := createOutputDir(t)
+
+	createTestFile(t, vaultDir, "rust/intro.md", "---\ntags: [rust]\n---\n\nStart with [[ownership]], not [[private]]")
+	createTestFile(t, vaultDir, "rust/ownership.md", "---\ntags: [rust]\n---\n\nBack to [[intro]]")
+	createTestFile(t, vaultDir, "private.md", "Links to [[intro]]")
+
+	canvasPath := filepath.Join(vaultDir, "rust.canvas")
+	output, err := runExportCommand(t, []string{canvasPath, vaultDir, "--bundle", "graph", "--query", "tags contains 'rust'"})
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "A canvas graph of 2 notes and 2 links between them")
+
+	content, err := os.ReadFile(canvasPath)
+	require.NoError(t, err)
+	var canvas struct {
+		Nodes []struct {
+			Type string `json:"type"`
+			File string `json:"file"`
+		} `json:"nodes"`
+		Edges []struct {
+			FromNode string `json:"fromNode"`
+			ToNode   string `json:"toNode"`
+		} `json:"edges"`
+	}
+	require.NoError(t, json.Unmarshal(content, &canvas))
+	require.Len(t, canvas.Nodes, 2)
+	assert.Equal(t, "file", canvas.Nodes[0].Type)
+	assert.ElementsMatch(t, []string{"rust/intro.md", "rust/ownership.md"}, []string{canvas.Nodes[0].File, canvas.Nodes[1].File})
+	assert.Len(t, canvas.Edges, 2)
+
+	graphPath := filepath.Join(outputDir, "graph.json")
+	output, err = runExportCommand(t, []string{graphPath, vaultDir, "--bundle", "graph"})
+	require.NoError(t, err, output)
+	content, err = os.ReadFile(graphPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"source": "private.md"`)
+
+	output, err = runExportCommand(t, []string{filepath.Join(outputDir, "graph.md"), vaultDir, "--bundle", "graph"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "use --format canvas or json")
+}
+
+func TestExportCommand_Anki(t *testing.T) {
+	vaultDir := createTestVault(t)
+	outputDir := createOutputDir(t)
+
+	createTestFile(t, vaultDir, "spanish.md", `---
+tags: [flashcards]
+---
+
+# Spanish
+
+## What is "hello"?
+
+Hola
+
+The capital of Spain is {{c1::Madrid}}.`)
+	createTestFile(t, vaultDir, "other.md", "## Not a card\n\nNot tagged")
+
+	deckPath := filepath.Join(outputDir, "spanish.csv")
+	output, err := runExportCommand(t, []string{"anki", deckPath, vaultDir, "--query", "tags contains 'flashcards'"})
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "Exported 2 cards from 1 notes")
+
+	deck, err := os.ReadFile(deckPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(deck), "#guid column:3")
+	assert.Contains(t, string(deck), "<p>What is &#34;hello&#34;?</p>,<p>Hola</p>,flashcards")
+	assert.Contains(t, string(deck), "Cloze,spanish,")
+	assert.NotContains(t, string(deck), "Not a card")
+
+	// The note keeps its ID, so a second export has the same GUIDs
+	note, err := os.ReadFile(filepath.Join(vaultDir, "spanish.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(note), "anki_id: ")
+
+	output, err = runExportCommand(t, []string{"anki", deckPath, vaultDir, "--query", "tags contains 'flashcards'"})
+	require.NoError(t, err, output)
+	assert.NotContains(t, output, "Assigned IDs")
+	again, err := os.ReadFile(deckPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(deck), string(again))
+}
+
+func TestExportCommand_Bundle(t *testing.T) {
+	vaultDir := createTestVault(t)
+	outputDir := createOutputDir(t)
+
+	createTestFile(t, vaultDir, "guide/start.md", `---
+title: Getting Started
+tags: [guide]
+---
+
+# Getting Started
+
+Read [[setup]] next. ![[images/photo.png]]`)
+	createTestFile(t, vaultDir, "guide/setup.md", `---
+tags: [guide]
+---
+
+## Install`)
+	createTestFile(t, vaultDir, "private.md", `Not in the book`)
+	createTestFile(t, vaultDir, "images/photo.png", "png")
+
+	bookPath := filepath.Join(outputDir, "book")
+	args := []string{bookPath, vaultDir, "--bundle", "onefile", "--format", "md", "--order", "links", "--query", "tags contains 'guide'"}
+	output, err := runExportCommand(t, args)
+
+	require.NoError(t, err)
+	assert.Contains(t, output, "One md document of 2 notes")
+
+	content, err := os.ReadFile(bookPath + ".md")
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: book\n---\n\n"+
+		"# Getting Started {#guide-start}\n\nRead [setup](#guide-setup) next. ![photo.png](../"+filepath.Base(vaultDir)+"/images/photo.png)\n\n"+
+		"# setup {#guide-setup}\n\n### Install\n", string(content))
+
+	// Bundles are single new files
+	_, err = runExportCommand(t, []string{bookPath + ".md", vaultDir, "--bundle", "onefile"})
+	assert.Error(t, err)
+
+	output, err = runExportCommand(t, []string{filepath.Join(outputDir, "book.txt"), vaultDir, "--bundle", "onefile"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "cannot tell the bundle format")
+
+	output, err = runExportCommand(t, []string{filepath.Join(outputDir, "book.pdf"), vaultDir, "--bundle", "onefile", "--format", "epub"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "does not match --format epub")
+
+	output, err = runExportCommand(t, []string{createOutputDir(t), vaultDir, "--order", "links"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "apply to --bundle exports")
+}
+
+func TestExportCommand_Incremental(t *testing.T) {
+	vaultDir := createTestVault(t)
+	outputDir := createOutputDir(t)
+	createTestFile(t, vaultDir, "one.md", "# One")
+	createTestFile(t, vaultDir, "two.md", "# Two")
+
+	_, err := runExportCommand(t, []string{outputDir, vaultDir, "--incremental"})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(outputDir, ".mdnotes-export.json"))
+
+	// Later runs update the previous export in place
+	require.NoError(t, os.Remove(filepath.Join(vaultDir, "two.md")))
+	output, err := runExportCommand(t, []string{outputDir, vaultDir, "--incremental"})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Unchanged files skipped: 1")
+	assert.Contains(t, output, "Files no longer exported removed: 1")
+	assert.NoFileExists(t, filepath.Join(outputDir, "two.md"))
+
+	// Other folders must still be empty
+	otherDir := createOutputDir(t)
+	createTestFile(t, otherDir, "mine.md", "# Mine")
+	output, err = runExportCommand(t, []string{otherDir, vaultDir, "--incremental"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "output directory is not empty")
+
+	output, err = runExportCommand(t, []string{filepath.Join(outputDir, "book.md"), vaultDir, "--bundle", "onefile", "--incremental"})
+	assert.Error(t, err)
+	assert.Contains(t, output, "cannot be combined")
+}
+
 func TestFormatSize(t *testing.T) {
 	tests := []struct {
 		bytes    int64
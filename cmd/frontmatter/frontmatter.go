@@ -2,21 +2,32 @@ package frontmatter
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
+	"github.com/eoinhurrell/mdnotes/internal/cli"
 	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/diff"
 	"github.com/eoinhurrell/mdnotes/internal/downloader"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
 	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/internal/workerpool"
 )
 
 // NewFrontmatterCommand creates the frontmatter command
@@ -30,11 +41,16 @@ func NewFrontmatterCommand() *cobra.Command {
 
 	cmd.AddCommand(NewEnsureCommand())
 	cmd.AddCommand(NewSetCommand())
+	cmd.AddCommand(NewRemoveCommand())
+	cmd.AddCommand(NewRenameFieldCommand())
+	cmd.AddCommand(NewImportCommand())
 	cmd.AddCommand(NewCastCommand())
 	cmd.AddCommand(NewSyncCommand())
 	cmd.AddCommand(NewCheckCommand())
 	cmd.AddCommand(NewQueryCommand())
 	cmd.AddCommand(NewDownloadCommand())
+	cmd.AddCommand(NewConvertCommand())
+	cmd.AddCommand(NewTidyCommand())
 
 	return cmd
 }
@@ -50,7 +66,15 @@ If a field is missing, it will be added with the provided default value.
 Supports template variables like {{filename}} and {{current_date}}.
 
 Special default values:
-  null - Sets the field to null (not the string "null")`,
+  null - Sets the field to null (not the string "null")
+
+Note templates and generated files are skipped and reported, since adding
+fields would corrupt them. A file counts as one when it is in a template
+folder (templates, _templates or the configured templates.dir), matches a
+frontmatter.template_files pattern, has a "DO NOT EDIT" or "<!-- generated"
+marker near the top, or uses <% %> or {{ }} outside code in its body. Use
+--templates warn to process them with a warning, or --templates process to
+treat them like any other note.`,
 		Args: cobra.ExactArgs(1),
 		RunE: runEnsure,
 	}
@@ -60,6 +84,7 @@ Special default values:
 	cmd.Flags().StringSlice("type", nil, "Type rules in format field:type (optional, for type checking)")
 	cmd.Flags().Bool("recursive", true, "Process subdirectories")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+	cmd.Flags().String("templates", "", "How to treat template and generated files: skip, warn or process (default from config, else skip)")
 
 	_ = cmd.MarkFlagRequired("field")
 	_ = cmd.MarkFlagRequired("default")
@@ -75,6 +100,7 @@ func runEnsure(cmd *cobra.Command, args []string) error {
 	defaults, _ := cmd.Flags().GetStringSlice("default")
 	typeRules, _ := cmd.Flags().GetStringSlice("type")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	templateMode, _ := cmd.Flags().GetString("templates")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
@@ -88,6 +114,11 @@ func runEnsure(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("number of fields (%d) must match number of defaults (%d)", len(fields), len(defaults))
 	}
 
+	templateMode, detector, err := templateFileDetector(cmd, templateMode)
+	if err != nil {
+		return err
+	}
+
 	// Parse type rules
 	types := make(map[string]string)
 	for _, rule := range typeRules {
@@ -111,6 +142,9 @@ func runEnsure(cmd *cobra.Command, args []string) error {
 		if defaultValue == "null" {
 			fieldDefaults[field] = nil
 		} else {
+			if err := templates.NewRenderer().Validate(defaultValue); err != nil {
+				return fmt.Errorf("default for '%s': %w", field, err)
+			}
 			fieldDefaults[field] = defaultValue
 
 			// Implicit array detection: if default value has bracket notation and no explicit type is set
@@ -138,9 +172,17 @@ func runEnsure(cmd *cobra.Command, args []string) error {
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			fileModified := false
+			defer func() {
+				if fileModified && templateMode == "warn" {
+					if reason := detector.Reason(file); reason != "" {
+						fmt.Printf("⚠ %s looks like a template or generated file (%s) but was modified\n", file.RelativePath, reason)
+					}
+				}
+			}()
 
 			// Phase 1: Ensure fields exist with default values
 			for field, defaultValue := range fieldDefaults {
@@ -188,8 +230,13 @@ func runEnsure(cmd *cobra.Command, args []string) error {
 		},
 	}
 
+	if templateMode == "skip" {
+		fileProcessor.SkipFile = detector.Reason
+	}
+
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -200,6 +247,33 @@ func runEnsure(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// templateFileDetector returns how ensure treats template and generated
+// files, from mode or else the config, and the detector recognising them
+func templateFileDetector(cmd *cobra.Command, mode string) (string, *processor.TemplateDetector, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigWithPath(configPath)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	settings := cfg.Frontmatter.TemplateFiles
+
+	if mode == "" {
+		mode = settings.Mode
+	}
+	switch mode {
+	case "":
+		mode = "skip"
+	case "skip", "warn", "process":
+	default:
+		return "", nil, fmt.Errorf("invalid --templates '%s': expected skip, warn or process", mode)
+	}
+
+	detector := processor.NewTemplateDetector(append([]string{cfg.Templates.Dir}, settings.Folders...)...)
+	detector.Patterns = settings.Patterns
+	detector.IgnoreSyntax = settings.IgnoreSyntax
+	return mode, detector, nil
+}
+
 // NewSetCommand creates the frontmatter set command
 func NewSetCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -208,10 +282,16 @@ func NewSetCommand() *cobra.Command {
 		Short:   "Set frontmatter fields to specific values",
 		Long: `Set frontmatter fields to specific values in all markdown files.
 Unlike 'ensure', this command always updates the field to the specified value,
-even if it already exists. Supports template variables and type casting.
+even if it already exists. Supports type casting.
+
+Values are set literally unless --template is given, which expands template
+variables like {{filename}} or {{file.mtime|date:YYYY-MM-DD}} for each file.
 
 Special values:
-  null - Sets the field to null (not the string "null")`,
+  null - Sets the field to null (not the string "null")
+
+With --preserve-edits, fields are only overwritten if mdnotes set them and
+they haven't been edited since, as recorded by frontmatter provenance.`,
 		Args: cobra.ExactArgs(1),
 		RunE: runSet,
 	}
@@ -219,6 +299,8 @@ Special values:
 	cmd.Flags().StringSlice("field", nil, "Field name to set (can be specified multiple times)")
 	cmd.Flags().StringSlice("value", nil, "Value for field (can be specified multiple times)")
 	cmd.Flags().StringSlice("type", nil, "Type rules in format field:type (optional, for type casting)")
+	cmd.Flags().Bool("template", false, "Expand template variables in values for each file")
+	cmd.Flags().Bool("preserve-edits", false, "Don't overwrite values mdnotes didn't set or that were edited by hand (uses provenance)")
 	cmd.Flags().Bool("recursive", true, "Process subdirectories")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
 
@@ -236,6 +318,8 @@ func runSet(cmd *cobra.Command, args []string) error {
 	values, _ := cmd.Flags().GetStringSlice("value")
 	typeRules, _ := cmd.Flags().GetStringSlice("type")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	preserveEdits, _ := cmd.Flags().GetBool("preserve-edits")
+	expandTemplates, _ := cmd.Flags().GetBool("template")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
@@ -245,10 +329,24 @@ func runSet(cmd *cobra.Command, args []string) error {
 		verbose = false
 	}
 
+	var metaField string
+	if preserveEdits {
+		metaField = provenanceField(cmd)
+	}
+
 	if len(fields) != len(values) {
 		return fmt.Errorf("number of fields (%d) must match number of values (%d)", len(fields), len(values))
 	}
 
+	renderer := templates.NewRenderer()
+	if expandTemplates {
+		for i, field := range fields {
+			if err := renderer.Validate(values[i]); err != nil {
+				return fmt.Errorf("value for '%s': %w", field, err)
+			}
+		}
+	}
+
 	// Parse type rules
 	types := make(map[string]string)
 	for _, rule := range typeRules {
@@ -295,20 +393,35 @@ func runSet(cmd *cobra.Command, args []string) error {
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			fileModified := false
 
 			for field, value := range fieldValues {
+				if preserveEdits && editedByHand(file, metaField, field) {
+					if verbose {
+						fmt.Printf("Examining: %s - Kept '%s' (not set by mdnotes or edited since)\n", file.RelativePath, field)
+					}
+					continue
+				}
+
 				// Get current value for comparison
 				currentValue, exists := file.GetField(field)
 
 				// Set the new value
 				processedValue := value
+				if template, ok := value.(string); ok && expandTemplates {
+					rendered, err := renderer.Render(template, templates.FileScope(file))
+					if err != nil {
+						return fileModified, fmt.Errorf("expanding value for '%s': %w", field, err)
+					}
+					processedValue = rendered
+				}
 
 				// Apply type casting if specified
-				if expectedType, hasType := types[field]; hasType && value != nil {
-					if castValue, err := typeCaster.Cast(value, expectedType); err == nil {
+				if expectedType, hasType := types[field]; hasType && processedValue != nil {
+					if castValue, err := typeCaster.Cast(processedValue, expectedType); err == nil {
 						processedValue = castValue
 						if verbose {
 							fmt.Printf("Examining: %s - Cast value for '%s' to %s\n", file.RelativePath, field, expectedType)
@@ -346,6 +459,205 @@ func runSet(cmd *cobra.Command, args []string) error {
 
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// NewRemoveCommand creates the frontmatter remove command
+func NewRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove [path]",
+		Aliases: []string{"rm"},
+		Short:   "Remove frontmatter fields",
+		Long: `Remove frontmatter fields from all markdown files.
+The remaining fields keep their order.
+
+Example:
+  # Drop a field left over from an old import
+  mdnotes frontmatter remove --field legacy_id /vault/path`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRemove,
+	}
+
+	cmd.Flags().StringSlice("field", nil, "Field name to remove (can be specified multiple times)")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	_ = cmd.MarkFlagRequired("field")
+
+	return cmd
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	fields, _ := cmd.Flags().GetStringSlice("field")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	fmProcessor := processor.NewFrontmatterProcessor()
+
+	// Setup file processor
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			fileModified := false
+			for _, field := range fields {
+				if fmProcessor.Remove(file, field) {
+					fileModified = true
+					if verbose {
+						fmt.Printf("Examining: %s - Removed field '%s'\n", file.RelativePath, field)
+					}
+				}
+			}
+			return fileModified, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// NewRenameFieldCommand creates the frontmatter rename-field command
+func NewRenameFieldCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rename-field [path]",
+		Aliases: []string{"rf"},
+		Short:   "Rename a frontmatter field",
+		Long: `Rename a frontmatter field in all markdown files, keeping its value
+and its position among the other fields.
+
+When a file already has the new field, --on-conflict decides what happens:
+  skip       Leave both fields alone and report the file (default)
+  overwrite  Replace the existing value with the renamed field's value
+  merge      Combine both values into a single list of unique items
+
+Example:
+  # Migrate to Obsidian's aliases property, keeping any aliases already set
+  mdnotes frontmatter rename-field --from alias --to aliases --on-conflict merge /vault/path`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRenameField,
+	}
+
+	cmd.Flags().String("from", "", "Field to rename")
+	cmd.Flags().String("to", "", "New field name")
+	cmd.Flags().String("on-conflict", string(processor.RenameSkip), "When the new field exists: skip, overwrite, merge")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+func runRenameField(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	onConflict, _ := cmd.Flags().GetString("on-conflict")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	if from == "" || to == "" {
+		return fmt.Errorf("--from and --to must not be empty")
+	}
+	if from == to {
+		return fmt.Errorf("--from and --to are the same field '%s'", from)
+	}
+	conflict, err := processor.ParseRenameConflict(onConflict)
+	if err != nil {
+		return err
+	}
+
+	fmProcessor := processor.NewFrontmatterProcessor()
+
+	// Setup file processor
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			if _, exists := file.GetField(from); !exists {
+				return false, nil
+			}
+			_, taken := file.GetField(to)
+
+			if !fmProcessor.Rename(file, from, to, conflict) {
+				if !quiet {
+					fmt.Printf("⚠ Skipped: %s (already has '%s')\n", file.RelativePath, to)
+				}
+				return false, nil
+			}
+
+			if verbose {
+				action := "Renamed"
+				switch {
+				case taken && conflict == processor.RenameOverwrite:
+					action = "Overwrote"
+				case taken && conflict == processor.RenameMerge:
+					action = "Merged"
+				}
+				fmt.Printf("Examining: %s - %s field '%s' -> '%s'\n", file.RelativePath, action, from, to)
+			}
+			return true, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -356,6 +668,164 @@ func runSet(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// NewImportCommand creates the frontmatter import command
+func NewImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [path]",
+		Short: "Set frontmatter fields from a CSV or JSON table",
+		Long: `Set frontmatter fields from a CSV, TSV or JSON table in one pass.
+
+Each row is matched to notes by the --key column. With --key path (the
+default) or --key file, the key is the note's path relative to [path], with or
+without the .md extension, or just its file name. Any other key matches notes
+whose frontmatter field of that name has the same value.
+
+Every other column sets the frontmatter field of the same name. Empty cells
+are skipped, "null" clears a field, and bracketed lists like [a, b] become
+arrays. Use --type to cast other columns.
+
+JSON tables are arrays of objects; their values keep their JSON types.`,
+		Example: `  # Apply a spreadsheet export keyed by note path
+  mdnotes frontmatter import --from books.csv --key path ~/vault/books
+
+  # Match notes by their isbn field and import only two columns
+  mdnotes frontmatter import --from books.csv --key isbn --fields rating,read_date --type read_date:date .`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runImport,
+	}
+
+	cmd.Flags().String("from", "", "Table to import (CSV, TSV or JSON; - for stdin)")
+	cmd.Flags().String("key", "path", "Column matching rows to notes")
+	cmd.Flags().String("format", "", "Table format: csv, tsv or json (default: from the file extension)")
+	cmd.Flags().StringSlice("fields", nil, "Only import these columns")
+	cmd.Flags().StringSlice("type", nil, "Type rules in format column:type (optional, for type casting)")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	_ = cmd.MarkFlagRequired("from")
+
+	return cmd
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	from, _ := cmd.Flags().GetString("from")
+	key, _ := cmd.Flags().GetString("key")
+	format, _ := cmd.Flags().GetString("format")
+	fields, _ := cmd.Flags().GetStringSlice("fields")
+	typeRules, _ := cmd.Flags().GetStringSlice("type")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	table, err := readImportTable(from, format)
+	if err != nil {
+		return err
+	}
+	importer, err := processor.NewFrontmatterImporter(table, key)
+	if err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		available := make(map[string]bool, len(importer.Columns))
+		for _, column := range importer.Columns {
+			available[column] = true
+		}
+		for _, field := range fields {
+			if !available[field] {
+				return fmt.Errorf("column '%s' not found in table", field)
+			}
+		}
+		importer.Columns = fields
+	}
+
+	for _, rule := range typeRules {
+		parts := strings.SplitN(rule, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid type rule %s - use column:type format", rule)
+		}
+		importer.Types[parts[0]] = parts[1]
+	}
+
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			row, ok := importer.Match(file)
+			if !ok {
+				return false, nil
+			}
+			changed, err := importer.Apply(file, row)
+			if err != nil {
+				return false, err
+			}
+			if verbose && len(changed) > 0 {
+				fmt.Printf("Examining: %s - Imported %s\n", file.RelativePath, strings.Join(changed, ", "))
+			}
+			return len(changed) > 0, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+	}
+
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		for _, unmatched := range importer.Unmatched() {
+			fmt.Printf("⚠ No note matches %s '%s'\n", key, unmatched)
+		}
+	}
+
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// readImportTable reads the table at from, or stdin for "-"
+func readImportTable(from, format string) (*processor.ImportTable, error) {
+	if format == "" {
+		format = processor.ImportFormatForPath(from)
+	}
+
+	if from == "-" {
+		return processor.ReadImportTable(os.Stdin, format)
+	}
+
+	f, err := os.Open(from)
+	if err != nil {
+		return nil, fmt.Errorf("opening table: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	table, err := processor.ReadImportTable(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", from, err)
+	}
+	return table, nil
+}
+
 // NewCastCommand creates the frontmatter cast command
 func NewCastCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -414,6 +884,7 @@ func runCast(cmd *cobra.Command, args []string) error {
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			fileModified := false
@@ -470,6 +941,7 @@ func runCast(cmd *cobra.Command, args []string) error {
 
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -487,13 +959,17 @@ func NewSyncCommand() *cobra.Command {
 		Aliases: []string{"sy"},
 		Short:   "Sync frontmatter fields with file system data",
 		Long: `Synchronize frontmatter fields with file system metadata.
-Update fields based on filename patterns, modification times, or path structure.`,
+Update fields based on filename patterns, modification times, or path structure.
+
+With --preserve-edits, fields are only overwritten if mdnotes set them and
+they haven't been edited since, as recorded by frontmatter provenance.`,
 		Args: cobra.ExactArgs(1),
 		RunE: runSync,
 	}
 
 	cmd.Flags().StringSlice("field", nil, "Field names to sync")
 	cmd.Flags().StringSlice("source", nil, "Data sources for fields (field:source)")
+	cmd.Flags().Bool("preserve-edits", false, "Don't overwrite values mdnotes didn't set or that were edited by hand (uses provenance)")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
 
 	_ = cmd.MarkFlagRequired("field")
@@ -509,6 +985,7 @@ func runSync(cmd *cobra.Command, args []string) error {
 	fields, _ := cmd.Flags().GetStringSlice("field")
 	sources, _ := cmd.Flags().GetStringSlice("source")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	preserveEdits, _ := cmd.Flags().GetBool("preserve-edits")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
@@ -518,6 +995,11 @@ func runSync(cmd *cobra.Command, args []string) error {
 		verbose = false
 	}
 
+	var metaField string
+	if preserveEdits {
+		metaField = provenanceField(cmd)
+	}
+
 	if len(fields) != len(sources) {
 		return fmt.Errorf("number of fields (%d) must match number of sources (%d)", len(fields), len(sources))
 	}
@@ -536,11 +1018,18 @@ func runSync(cmd *cobra.Command, args []string) error {
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			fileModified := false
 			for field, source := range fieldSources {
-				if sync.SyncField(file, field, source) {
+				if preserveEdits && editedByHand(file, metaField, field) {
+					if verbose {
+						fmt.Printf("Examining: %s - Kept '%s' (not set by mdnotes or edited since)\n", file.RelativePath, field)
+					}
+					continue
+				}
+				if sync.SyncField(file, field, source) {
 					fileModified = true
 					if verbose {
 						value, _ := file.GetField(field)
@@ -561,6 +1050,113 @@ func runSync(cmd *cobra.Command, args []string) error {
 
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// provenanceField returns the frontmatter field provenance is recorded in,
+// warning when provenance isn't enabled since every existing value then
+// counts as edited by hand
+func provenanceField(cmd *cobra.Command) string {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigWithPath(configPath)
+	if err != nil || !cfg.Frontmatter.Provenance.Enabled {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: frontmatter provenance is not enabled; --preserve-edits will only fill in missing fields\n")
+		return vault.DefaultProvenanceField
+	}
+	if cfg.Frontmatter.Provenance.Field == "" {
+		return vault.DefaultProvenanceField
+	}
+	return cfg.Frontmatter.Provenance.Field
+}
+
+// editedByHand reports whether field has a value mdnotes didn't set, or that
+// was changed after mdnotes set it
+func editedByHand(file *vault.VaultFile, metaField, field string) bool {
+	_, exists := file.GetField(field)
+	return exists && !file.IsManaged(metaField, field)
+}
+
+// NewConvertCommand creates the frontmatter convert command
+func NewConvertCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "convert [path]",
+		Aliases: []string{"cv"},
+		Short:   "Convert frontmatter between YAML, TOML and JSON",
+		Long: `Convert frontmatter to a single format. Files with TOML (+++) or JSON
+frontmatter, as produced by Hugo and some importers, are read automatically;
+this command rewrites them so the whole vault uses one format.
+
+Example:
+  # Normalize the vault onto YAML frontmatter
+  mdnotes frontmatter convert --to yaml /vault/path`,
+		Args: cobra.ExactArgs(1),
+		RunE: runConvert,
+	}
+
+	cmd.Flags().String("to", "yaml", "Target format: yaml, toml, json")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	to, _ := cmd.Flags().GetString("to")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	target, err := vault.ParseFrontmatterFormat(to)
+	if err != nil {
+		return err
+	}
+
+	// Setup file processor
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			if !file.HasFrontmatter() || file.FrontmatterFormat == target {
+				return false, nil
+			}
+
+			if verbose {
+				fmt.Printf("Examining: %s - Converting %s frontmatter to %s\n", file.RelativePath, file.FrontmatterFormat, target)
+			}
+			file.FrontmatterFormat = target
+			return true, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -571,6 +1167,163 @@ func runSync(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// NewTidyCommand creates the frontmatter tidy command
+func NewTidyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tidy [path]",
+		Aliases: []string{"td"},
+		Short:   "Rewrite YAML frontmatter with canonical key order and formatting",
+		Long: `Rewrite YAML frontmatter blocks with a consistent layout so formatting
+differences don't show up in diffs. Only formatting changes: keys, values and
+comments are kept, and note bodies are not touched.
+
+  --order      Keys to place first, in this order; other keys keep their order
+  --sort       Sort the keys not named by --order alphabetically
+  --quotes     minimal (only where YAML needs them), double or single
+  --dates      iso (2024-01-02T15:04:05Z) or space (2024-01-02 15:04:05)
+  --arrays     block (one item per line) or flow ([a, b]); flow applies
+               only to lists of plain values
+  --indent     Spaces per nesting level
+
+Defaults for each flag are read from frontmatter.tidy in the config file:
+
+  frontmatter:
+    tidy:
+      key_order: [title, aliases, tags, created, modified]
+      sort_keys: true
+      quotes: minimal
+      dates: iso
+      arrays: block
+
+Example:
+  mdnotes frontmatter tidy --order title,tags,created --quotes minimal --arrays block /vault/path`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTidy,
+	}
+
+	cmd.Flags().StringSlice("order", nil, "Keys to place first, in order")
+	cmd.Flags().Bool("sort", false, "Sort remaining keys alphabetically")
+	cmd.Flags().String("quotes", "", "Quote style: minimal, double, single")
+	cmd.Flags().String("dates", "", "Date style: iso, space")
+	cmd.Flags().String("arrays", "", "Array style: block, flow")
+	cmd.Flags().Int("indent", 0, "Spaces per nesting level (default 4)")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runTidy(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	rules, err := tidyRules(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Setup file processor; the body is kept byte-for-byte rather than re-serialized
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			if file.FrontmatterFormat != vault.FormatYAML {
+				return false, nil
+			}
+
+			tidied, changed, err := processor.TidyFrontmatter(file.Content, rules)
+			if err != nil {
+				return false, err
+			}
+			if !changed {
+				return false, nil
+			}
+			file.Content = tidied
+
+			if verbose {
+				fmt.Printf("Examining: %s - Tidied frontmatter\n", file.RelativePath)
+			}
+			return true, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			} else if !modified && verbose {
+				fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+			}
+		},
+		Serialize: func(file *vault.VaultFile) ([]byte, error) {
+			return file.Content, nil
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// tidyRules builds the tidy rules from the config, overridden by any flags given
+func tidyRules(cmd *cobra.Command) (processor.TidyRules, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := loadConfigWithPath(configPath)
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	settings := cfg.Frontmatter.Tidy
+
+	rules := processor.TidyRules{
+		KeyOrder: settings.KeyOrder,
+		SortKeys: settings.SortKeys,
+		Quotes:   settings.Quotes,
+		Dates:    settings.Dates,
+		Arrays:   settings.Arrays,
+		Indent:   settings.Indent,
+	}
+	if cmd.Flags().Changed("order") {
+		rules.KeyOrder, _ = cmd.Flags().GetStringSlice("order")
+	}
+	if cmd.Flags().Changed("sort") {
+		rules.SortKeys, _ = cmd.Flags().GetBool("sort")
+	}
+	if cmd.Flags().Changed("quotes") {
+		rules.Quotes, _ = cmd.Flags().GetString("quotes")
+	}
+	if cmd.Flags().Changed("dates") {
+		rules.Dates, _ = cmd.Flags().GetString("dates")
+	}
+	if cmd.Flags().Changed("arrays") {
+		rules.Arrays, _ = cmd.Flags().GetString("arrays")
+	}
+	if cmd.Flags().Changed("indent") {
+		rules.Indent, _ = cmd.Flags().GetInt("indent")
+	}
+	if rules.Indent == 0 {
+		rules.Indent = 4
+	}
+
+	return rules, rules.Validate()
+}
+
 // NewCheckCommand creates the frontmatter check command
 func NewCheckCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -579,8 +1332,39 @@ func NewCheckCommand() *cobra.Command {
 		Short:   "Check frontmatter for parsing issues and validate against rules",
 		Long: `Check all markdown files for frontmatter parsing issues and validate against rules.
 This command identifies files with malformed YAML frontmatter and can also validate
-that frontmatter meets specified requirements like required fields and type constraints.`,
-		Args: cobra.ExactArgs(1),
+that frontmatter meets specified requirements like required fields and type constraints.
+
+Duplicate top-level keys are reported with their line numbers. Use --fix to
+resolve them:
+  keep-first   Keep the first occurrence of each duplicated key
+  keep-last    Keep the last occurrence (what most YAML parsers silently do)
+  merge        Combine all values into a single list of unique items
+
+Use --repair to fix common YAML breakages before checking: a byte order mark
+before the opening ---, a missing closing ---, tab indentation, and unquoted
+values starting with '#' or containing ': '. A diff of each repair is shown;
+combine with --dry-run to review repairs without writing them.
+
+Use --schema to validate against a YAML or JSON schema file describing each
+field's type, whether it is required, allowed values and a pattern, with
+overrides for notes in particular folders:
+
+  fields:
+    title:    {type: string, required: true}
+    status:   {type: string, enum: [draft, active, done], default: draft}
+    priority: {type: integer}
+    created:  {type: date, required: true, default: "{{file.mtime|date:YYYY-MM-DD}}"}
+    tags:     {type: array}
+  folders:
+    journal:
+      fields:
+        mood: {type: string, enum: [good, ok, bad]}
+
+Types are string, number, integer, boolean, date and array. A folder's field
+definitions replace the vault-wide ones for notes under it. With --schema,
+--fix also sets defaults for missing fields, casts values that convert
+cleanly to their type and corrects the case of enum values.`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: runCheck,
 	}
 
@@ -588,18 +1372,24 @@ that frontmatter meets specified requirements like required fields and type cons
 	cmd.Flags().StringSlice("type", nil, "Type rules in format field:type")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
 	cmd.Flags().Bool("parsing-only", false, "Only check for YAML parsing issues, skip validation rules")
+	cmd.Flags().String("fix", "", "Resolve duplicate keys: keep-first, keep-last, merge; with --schema, also apply schema defaults and coercions")
+	cmd.Flags().Lookup("fix").NoOptDefVal = "schema"
+	cmd.Flags().Bool("repair", false, "Repair common frontmatter breakages, showing a diff of each repair")
+	cmd.Flags().String("schema", "", "Validate against a YAML or JSON schema file")
 
 	return cmd
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
-	path := args[0]
-
 	// Get flags
 	required, _ := cmd.Flags().GetStringSlice("required")
 	typeRules, _ := cmd.Flags().GetStringSlice("type")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
 	parsingOnly, _ := cmd.Flags().GetBool("parsing-only")
+	fix, _ := cmd.Flags().GetString("fix")
+	repair, _ := cmd.Flags().GetBool("repair")
+	schemaPath, _ := cmd.Flags().GetString("schema")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
 
@@ -608,6 +1398,40 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		verbose = false
 	}
 
+	// A bare --fix takes no value, so "--fix merge path" arrives as two arguments
+	if fix == "schema" && len(args) == 2 {
+		if _, err := vault.ParseDuplicateKeyStrategy(args[0]); err == nil {
+			fix, args = args[0], args[1:]
+		}
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("accepts 1 arg(s), received %d", len(args))
+	}
+	path := args[0]
+
+	var fixStrategy vault.DuplicateKeyStrategy
+	if fix != "" && fix != "schema" {
+		var err error
+		if fixStrategy, err = vault.ParseDuplicateKeyStrategy(fix); err != nil {
+			return err
+		}
+	}
+
+	var schema *processor.Schema
+	if schemaPath != "" {
+		var err error
+		if schema, err = processor.LoadSchema(schemaPath); err != nil {
+			return err
+		}
+		if absPath, err := filepath.Abs(path); err == nil {
+			if base, err := filepath.Rel(safety.FindVaultRoot(absPath), absPath); err == nil && base != "." {
+				schema.Base = filepath.ToSlash(base)
+			}
+		}
+	} else if fix == "schema" {
+		return fmt.Errorf("--fix needs a duplicate key strategy (keep-first, keep-last, merge) or --schema")
+	}
+
 	// Parse type rules
 	types := make(map[string]string)
 	for _, rule := range typeRules {
@@ -620,45 +1444,64 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Scan files using the proper scanner with ignore patterns
-	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
+	tx := cli.BeginTransaction(cmd, path)
+	defer cli.CommitTransaction(cmd, tx)
+
+	if repair {
+		if err := repairFrontmatterFiles(path, ignorePatterns, dryRun, quiet, tx); err != nil {
+			return err
+		}
+	}
+
+	// Scan files using the proper scanner with ignore patterns, collecting parse
+	// errors instead of stopping at the first broken file
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
 	files, err := scanner.Walk(path)
 	if err != nil {
 		return fmt.Errorf("scanning directory: %w", err)
 	}
+	parseErrors := scanner.GetParseErrors()
+	totalFiles := len(files) + len(parseErrors)
 
-	if len(files) == 0 {
+	if totalFiles == 0 {
 		fmt.Println("No markdown files found")
 		return nil
 	}
 
 	// Phase 1: Check for parsing issues
 	var parsingIssues []string
-	var validFiles []*vault.VaultFile
+	validFiles := files
 
-	for _, file := range files {
-		// Files from scanner are already parsed, but check if there were errors
-		if file.Frontmatter == nil {
-			// Try to parse again to get the specific error
-			content, readErr := os.ReadFile(file.Path)
-			if readErr != nil {
-				parsingIssues = append(parsingIssues, fmt.Sprintf("✗ %s: Failed to read file - %v", file.RelativePath, readErr))
-				continue
-			}
+	if verbose {
+		for _, file := range files {
+			fmt.Printf("Examining: %s - Parsing OK\n", file.RelativePath)
+		}
+	}
 
-			parseErr := file.Parse(content)
-			if parseErr != nil {
-				parsingIssues = append(parsingIssues, fmt.Sprintf("✗ %s: %v", file.RelativePath, parseErr))
-				if verbose {
-					fmt.Printf("✗ %s: %v\n", file.RelativePath, parseErr)
+	for _, parseErr := range parseErrors {
+		var dupErr *vault.DuplicateKeyError
+		if fixStrategy != "" && errors.As(parseErr.Error, &dupErr) {
+			fixed, fixErr := fixDuplicateKeys(filepath.Join(path, parseErr.Path), fixStrategy, dryRun, tx)
+			if fixErr == nil {
+				if !quiet {
+					if dryRun {
+						fmt.Printf("Would fix: %s - %v\n", parseErr.Path, dupErr)
+					} else {
+						fmt.Printf("✓ Fixed: %s - %v\n", parseErr.Path, dupErr)
+					}
+				}
+				if fixed != nil {
+					fixed.RelativePath = parseErr.Path
+					validFiles = append(validFiles, fixed)
 				}
 				continue
 			}
+			parseErr.Error = fixErr
 		}
 
-		validFiles = append(validFiles, file)
+		parsingIssues = append(parsingIssues, fmt.Sprintf("✗ %s: %v", parseErr.Path, parseErr.Error))
 		if verbose {
-			fmt.Printf("Examining: %s - Parsing OK\n", file.RelativePath)
+			fmt.Printf("✗ %s: %v\n", parseErr.Path, parseErr.Error)
 		}
 	}
 
@@ -669,7 +1512,7 @@ func runCheck(cmd *cobra.Command, args []string) error {
 				fmt.Println(issue)
 			}
 		}
-		fmt.Printf("\nFound %d files with parsing issues out of %d total files\n", len(parsingIssues), len(files))
+		fmt.Printf("\nFound %d files with parsing issues out of %d total files\n", len(parsingIssues), totalFiles)
 
 		// If only checking parsing, return here
 		if parsingOnly {
@@ -678,7 +1521,8 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	}
 
 	// Phase 2: Validate against rules (if not parsing-only and rules are specified)
-	if !parsingOnly && (len(required) > 0 || len(types) > 0) {
+	hasRules := len(required) > 0 || len(types) > 0 || schema != nil
+	if !parsingOnly && hasRules {
 		validator := processor.NewValidator(processor.ValidationRules{
 			Required: required,
 			Types:    types,
@@ -686,7 +1530,16 @@ func runCheck(cmd *cobra.Command, args []string) error {
 
 		totalValidationErrors := 0
 		for _, file := range validFiles {
+			if schema != nil && fix != "" {
+				if err := fixSchemaFields(schema, file, dryRun, quiet, tx); err != nil {
+					fmt.Printf("✗ %s: %v\n", file.RelativePath, err)
+				}
+			}
+
 			errors := validator.Validate(file)
+			if schema != nil {
+				errors = append(errors, schema.Validate(file)...)
+			}
 			if len(errors) > 0 {
 				totalValidationErrors += len(errors)
 				fmt.Printf("✗ %s (validation):\n", file.RelativePath)
@@ -698,29 +1551,181 @@ func runCheck(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		if totalValidationErrors > 0 {
-			fmt.Printf("\nValidation failed: %d validation errors in %d files\n", totalValidationErrors, len(validFiles))
-			if len(parsingIssues) > 0 {
-				return fmt.Errorf("found both parsing issues and validation errors")
+		if totalValidationErrors > 0 {
+			fmt.Printf("\nValidation failed: %d validation errors in %d files\n", totalValidationErrors, len(validFiles))
+			if len(parsingIssues) > 0 {
+				return fmt.Errorf("found both parsing issues and validation errors")
+			}
+			return fmt.Errorf("validation failed")
+		} else {
+			fmt.Printf("\nValidation passed: %d files validated\n", len(validFiles))
+		}
+	}
+
+	// Final summary
+	if len(parsingIssues) == 0 {
+		if parsingOnly || !hasRules {
+			fmt.Printf("✓ All %d files have valid frontmatter\n", totalFiles)
+		}
+	} else {
+		return fmt.Errorf("frontmatter issues found")
+	}
+
+	return nil
+}
+
+// fixSchemaFields applies schema defaults and coercions to file, writing it
+// unless dryRun. Locked files are left alone.
+func fixSchemaFields(schema *processor.Schema, file *vault.VaultFile, dryRun, quiet bool, tx *safety.Transaction) error {
+	if file.LockReason() != "" {
+		return nil
+	}
+	fixes := schema.Fix(file)
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(fixes))
+	for i, fix := range fixes {
+		descriptions[i] = fix.String()
+	}
+	if dryRun {
+		if !quiet {
+			fmt.Printf("Would fix: %s - %s\n", file.RelativePath, strings.Join(descriptions, ", "))
+		}
+		return nil
+	}
+
+	content, err := file.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing: %w", err)
+	}
+	if err := tx.RecordWrite(file.Path); err != nil {
+		return fmt.Errorf("journaling: %w", err)
+	}
+	if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+		return fmt.Errorf("writing: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("✓ Fixed: %s - %s\n", file.RelativePath, strings.Join(descriptions, ", "))
+	}
+	return nil
+}
+
+// repairFrontmatterFiles applies vault.RepairFrontmatter to every markdown file
+// under path, printing a diff for each repaired file
+func repairFrontmatterFiles(path string, ignorePatterns []string, dryRun, quiet bool, tx *safety.Transaction) error {
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
+	files, err := scanner.Walk(path)
+	if err != nil {
+		return fmt.Errorf("scanning directory: %w", err)
+	}
+
+	relPaths := make(map[string]string)
+	for _, file := range files {
+		relPaths[file.Path] = file.RelativePath
+	}
+	for _, parseErr := range scanner.GetParseErrors() {
+		relPaths[filepath.Join(path, parseErr.Path)] = parseErr.Path
+	}
+
+	paths := make([]string, 0, len(relPaths))
+	for p := range relPaths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	repaired := 0
+	for _, filePath := range paths {
+		relPath := relPaths[filePath]
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			fmt.Printf("✗ %s: Failed to read file - %v\n", relPath, err)
+			continue
+		}
+
+		fixed, repairs := vault.RepairFrontmatter(content)
+		if len(repairs) == 0 {
+			continue
+		}
+
+		check := &vault.VaultFile{Path: filePath}
+		if err := check.Parse(fixed); err != nil {
+			fmt.Printf("✗ %s: could not repair frontmatter: %v\n", relPath, err)
+			continue
+		}
+		if reason := check.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipped: %s (locked: %s)\n", relPath, reason)
+			}
+			continue
+		}
+
+		if !quiet {
+			fmt.Print(diff.Unified("a/"+relPath, "b/"+relPath, string(content), string(fixed), diff.DefaultContext))
+		}
+
+		if !dryRun {
+			if err := tx.RecordWrite(filePath); err != nil {
+				fmt.Printf("✗ %s: Failed to journal file - %v\n", relPath, err)
+				continue
+			}
+			if err := safety.WriteFile(filePath, fixed, 0644); err != nil {
+				fmt.Printf("✗ %s: Failed to write file - %v\n", relPath, err)
+				continue
 			}
-			return fmt.Errorf("validation failed")
-		} else {
-			fmt.Printf("\nValidation passed: %d files validated\n", len(validFiles))
 		}
-	}
+		repaired++
 
-	// Final summary
-	if len(parsingIssues) == 0 {
-		if parsingOnly || (len(required) == 0 && len(types) == 0) {
-			fmt.Printf("✓ All %d files have valid frontmatter\n", len(files))
+		if !quiet {
+			verb := "Repaired"
+			if dryRun {
+				verb = "Would repair"
+			}
+			fmt.Printf("✓ %s: %s (%s)\n\n", verb, relPath, strings.Join(repairs, ", "))
 		}
-	} else {
-		return fmt.Errorf("frontmatter issues found")
 	}
 
+	if !quiet && repaired == 0 {
+		fmt.Println("No frontmatter repairs needed")
+	}
 	return nil
 }
 
+// fixDuplicateKeys resolves duplicate frontmatter keys in a file and returns the
+// re-parsed file. Locked files are reported as errors rather than modified. In
+// dry-run mode nothing is written and the returned file is nil.
+func fixDuplicateKeys(path string, strategy vault.DuplicateKeyStrategy, dryRun bool, tx *safety.Transaction) (*vault.VaultFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	fixed, _, err := vault.FixDuplicateKeys(content, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &vault.VaultFile{Path: path}
+	if err := file.Parse(fixed); err != nil {
+		return nil, fmt.Errorf("frontmatter still invalid after fix: %w", err)
+	}
+	if reason := file.LockReason(); reason != "" {
+		return nil, fmt.Errorf("file is locked (%s), not fixing duplicate keys", reason)
+	}
+	if dryRun {
+		return nil, nil
+	}
+
+	if err := tx.RecordWrite(path); err != nil {
+		return nil, fmt.Errorf("journaling file: %w", err)
+	}
+	if err := safety.WriteFile(path, fixed, 0644); err != nil {
+		return nil, fmt.Errorf("writing file: %w", err)
+	}
+	return vault.LoadVaultFile(path)
+}
+
 // NewDownloadCommand creates the frontmatter download command
 func NewDownloadCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -735,6 +1740,13 @@ The command:
 3. Renames the original field to <field>-original
 4. Replaces the field value with a wiki link to the downloaded file
 
+Downloads run in parallel (downloads.workers, or --parallel) and a URL used
+by several notes is fetched once. Network errors, server errors and rate
+limiting are retried downloads.retries times with a doubling delay. Only
+content types in downloads.allowed_types are saved. Downloads are recorded in
+downloads.state_file, so a rerun skips URLs already fetched and reuses files
+whose content matches by ETag or hash.
+
 Example:
   # Download all web resources in frontmatter
   mdnotes frontmatter download /vault/path
@@ -749,6 +1761,7 @@ Example:
 	}
 
 	cmd.Flags().StringSlice("field", nil, "Only download specific fields (default: all URL fields)")
+	cmd.Flags().Int("parallel", 0, "Downloads to run at once (default: downloads.workers from the config)")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
 	cmd.Flags().String("config", "", "Config file path")
 
@@ -762,6 +1775,7 @@ func runDownload(cmd *cobra.Command, args []string) error {
 	targetFields, _ := cmd.Flags().GetStringSlice("field")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
 	configPath, _ := cmd.Flags().GetString("config")
+	parallel, _ := cmd.Flags().GetInt("parallel")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
@@ -776,15 +1790,28 @@ func runDownload(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	// Config files written before these settings existed leave them empty
+	defaults := config.DefaultConfig().Downloads
+	if parallel <= 0 {
+		parallel = cfg.Downloads.Workers
+	}
+	if parallel <= 0 {
+		parallel = defaults.Workers
+	}
+	statePath := cfg.Downloads.StateFile
+	if statePath == "" {
+		statePath = defaults.StateFile
+	}
 
 	// Create downloader
-	downloader, err := newDownloaderFromConfig(cfg)
+	dl, err := newDownloaderFromConfig(cfg)
 	if err != nil {
 		return fmt.Errorf("creating downloader: %w", err)
 	}
 
 	// Load files (handle both files and directories)
-	files, err := loadFilesForProcessing(path, ignorePatterns)
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+	files, err := loadFilesForProcessing(path, ignorePatterns, workers)
 	if err != nil {
 		return fmt.Errorf("loading files: %w", err)
 	}
@@ -798,33 +1825,77 @@ func runDownload(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Scanned %d files\n", len(files))
 	}
 
-	// Process files
+	// Find the URLs to download
+	var jobs []downloadJob
+	for _, file := range files {
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipped: %s (locked: %s)\n", file.RelativePath, reason)
+			}
+			continue
+		}
+		jobs = append(jobs, collectDownloads(file, targetFields)...)
+	}
+	urls := uniqueDownloadURLs(jobs)
+
+	if dryRun {
+		for _, job := range jobs {
+			fmt.Printf("Would download: %s.%s = %s\n", job.file.RelativePath, job.field, job.url)
+		}
+		fmt.Printf("\nDry run completed. Would download %d resources (%d URLs) from %d files.\n",
+			len(jobs), len(urls), countDownloadFiles(jobs))
+		return nil
+	}
+
+	if len(jobs) > 0 {
+		if !filepath.IsAbs(statePath) {
+			statePath = filepath.Join(safety.FindVaultRoot(path), statePath)
+		}
+		state, err := downloader.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+		dl.SetState(state)
+	}
+
+	// Each URL is downloaded once, however many notes use it
+	outcomes := downloadURLs(dl, urls, parallel, verbose)
+
 	totalDownloads := 0
 	totalFiles := 0
 	errors := []error{}
+	for i := 0; i < len(jobs); {
+		file := jobs[i].file
+		downloads := 0
+		for ; i < len(jobs) && jobs[i].file == file; i++ {
+			job := jobs[i]
+			outcome := outcomes[job.url]
+			if outcome.err != nil {
+				errors = append(errors, fmt.Errorf("%s.%s: %w", file.RelativePath, job.field, outcome.err))
+				continue
+			}
 
-	for _, file := range files {
-		downloads, fileErrors := processFileDownloads(file, downloader, targetFields, dryRun, verbose)
-		if len(downloads) > 0 {
-			totalFiles++
-			totalDownloads += len(downloads)
-
-			// Save file if not dry run and has modifications
-			if !dryRun && len(downloads) > 0 {
-				content, err := file.Serialize()
-				if err != nil {
-					errors = append(errors, fmt.Errorf("serializing %s: %w", file.RelativePath, err))
-					continue
-				}
+			// Update frontmatter
+			file.Frontmatter[job.field+"-original"] = job.url
+			file.Frontmatter[job.field] = downloader.GenerateWikiLink(outcome.result.LocalPath)
+			downloads++
+		}
+		if downloads == 0 {
+			continue
+		}
+		totalFiles++
+		totalDownloads += downloads
 
-				if err := os.WriteFile(file.Path, content, 0644); err != nil {
-					errors = append(errors, fmt.Errorf("saving %s: %w", file.RelativePath, err))
-					continue
-				}
-			}
+		content, err := file.Serialize()
+		if err != nil {
+			errors = append(errors, fmt.Errorf("serializing %s: %w", file.RelativePath, err))
+			continue
 		}
 
-		errors = append(errors, fileErrors...)
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			errors = append(errors, fmt.Errorf("saving %s: %w", file.RelativePath, err))
+			continue
+		}
 	}
 
 	// Print summary
@@ -834,11 +1905,7 @@ func runDownload(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if dryRun {
-		fmt.Printf("\nDry run completed. Would download %d resources from %d files.\n", totalDownloads, totalFiles)
-	} else {
-		fmt.Printf("\nCompleted. Downloaded %d resources from %d files.\n", totalDownloads, totalFiles)
-	}
+	fmt.Printf("\nCompleted. Downloaded %d resources from %d files.\n", totalDownloads, totalFiles)
 
 	if len(errors) > 0 {
 		return fmt.Errorf("%d errors occurred during processing", len(errors))
@@ -863,87 +1930,134 @@ func newDownloaderFromConfig(cfg *config.Config) (*downloader.Downloader, error)
 	return downloader.NewDownloader(cfg.Downloads)
 }
 
-func processFileDownloads(file *vault.VaultFile, dl *downloader.Downloader, targetFields []string, dryRun, verbose bool) ([]string, []error) {
-	var downloads []string
-	var errors []error
+// downloadJob is a frontmatter field holding a URL to download
+type downloadJob struct {
+	file  *vault.VaultFile
+	field string
+	url   string
+}
 
-	// Get base filename for generating download names
-	baseFilename := strings.TrimSuffix(filepath.Base(file.RelativePath), filepath.Ext(file.RelativePath))
+// downloadOutcome is what downloading a URL came to
+type downloadOutcome struct {
+	result *downloader.DownloadResult
+	err    error
+}
 
+// collectDownloads returns the fields of a file holding downloadable URLs,
+// in field order
+func collectDownloads(file *vault.VaultFile, targetFields []string) []downloadJob {
+	var jobs []downloadJob
 	for field, value := range file.Frontmatter {
 		// Skip if targeting specific fields and this isn't one of them
-		if len(targetFields) > 0 {
-			found := false
-			for _, target := range targetFields {
-				if field == target {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
+		if len(targetFields) > 0 && !slices.Contains(targetFields, field) {
+			continue
 		}
 
 		// Check if value is a string URL
 		urlStr, ok := value.(string)
-		if !ok {
+		if !ok || !downloader.IsValidURL(urlStr) {
 			continue
 		}
+		jobs = append(jobs, downloadJob{file: file, field: field, url: urlStr})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].field < jobs[j].field })
+	return jobs
+}
 
-		if !downloader.IsValidURL(urlStr) {
-			continue
+// uniqueDownloadURLs returns the first job for each URL, in order
+func uniqueDownloadURLs(jobs []downloadJob) []downloadJob {
+	seen := make(map[string]bool, len(jobs))
+	var urls []downloadJob
+	for _, job := range jobs {
+		if !seen[job.url] {
+			seen[job.url] = true
+			urls = append(urls, job)
 		}
+	}
+	return urls
+}
 
-		// Found a downloadable URL
-		if dryRun {
-			fmt.Printf("Would download: %s.%s = %s\n", file.RelativePath, field, urlStr)
-			downloads = append(downloads, field)
-			continue
-		}
+// countDownloadFiles returns how many files the jobs come from
+func countDownloadFiles(jobs []downloadJob) int {
+	files := make(map[*vault.VaultFile]bool)
+	for _, job := range jobs {
+		files[job.file] = true
+	}
+	return len(files)
+}
 
-		if verbose {
-			fmt.Printf("Downloading: %s.%s = %s\n", file.RelativePath, field, urlStr)
-		}
+// downloadURLs downloads each URL on a pool of workers. A URL is saved under
+// the name of the first note and field using it.
+func downloadURLs(dl *downloader.Downloader, urls []downloadJob, workers int, verbose bool) map[string]downloadOutcome {
+	outcomes := make(map[string]downloadOutcome, len(urls))
+	if len(urls) == 0 {
+		return outcomes
+	}
 
-		// Download the resource
-		ctx := context.Background()
-		result, err := dl.DownloadResource(ctx, urlStr, baseFilename, field)
-		if err != nil {
-			errors = append(errors, fmt.Errorf("%s.%s: %w", file.RelativePath, field, err))
-			continue
-		}
+	pool := workerpool.NewWorkerPool(workerpool.Config{
+		MaxWorkers:  min(max(workers, 1), len(urls)),
+		QueueSize:   len(urls),
+		TaskTimeout: dl.MaxDuration(),
+	})
+	defer func() { _ = pool.Shutdown(time.Second) }()
 
-		if verbose {
-			if result.Skipped {
-				fmt.Printf("⚠ Skipped: %s (file already exists) -> %s\n", urlStr, result.LocalPath)
-			} else {
-				fmt.Printf("✓ Downloaded: %s (%d bytes) -> %s\n", urlStr, result.Size, result.LocalPath)
+	results := make([]downloadOutcome, len(urls))
+	tasks := make([]workerpool.Task, len(urls))
+	for i, job := range urls {
+		tasks[i] = func(ctx context.Context) error {
+			if verbose {
+				fmt.Printf("Downloading: %s.%s = %s\n", job.file.RelativePath, job.field, job.url)
+			}
+			baseFilename := strings.TrimSuffix(filepath.Base(job.file.RelativePath), filepath.Ext(job.file.RelativePath))
+			result, err := dl.DownloadResource(ctx, job.url, baseFilename, job.field)
+			results[i] = downloadOutcome{result: result, err: err}
+			if err != nil || !verbose {
+				return err
+			}
+			switch {
+			case result.Cached:
+				fmt.Printf("⚠ Skipped: %s (already downloaded) -> %s\n", job.url, result.LocalPath)
+			case result.Skipped:
+				fmt.Printf("⚠ Skipped: %s (file already exists) -> %s\n", job.url, result.LocalPath)
+			default:
+				fmt.Printf("✓ Downloaded: %s (%d bytes) -> %s\n", job.url, result.Size, result.LocalPath)
 			}
+			return nil
 		}
-
-		// Update frontmatter
-		originalField := field + "-original"
-		file.Frontmatter[originalField] = urlStr
-		file.Frontmatter[field] = downloader.GenerateWikiLink(result.LocalPath)
-
-		downloads = append(downloads, field)
 	}
+	pool.ProcessBatch(tasks)
 
-	return downloads, errors
+	for i, job := range urls {
+		outcome := results[i]
+		if outcome.result == nil && outcome.err == nil {
+			outcome.err = fmt.Errorf("download did not finish")
+		}
+		outcomes[job.url] = outcome
+	}
+	return outcomes
 }
 
 // loadFilesForProcessing loads files from the given path, handling both files and directories
-func loadFilesForProcessing(path string, ignorePatterns []string) ([]*vault.VaultFile, error) {
+func loadFilesForProcessing(path string, ignorePatterns []string, workers int) ([]*vault.VaultFile, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("path error: %w", err)
 	}
 
 	if info.IsDir() {
-		// Use scanner for directories
-		scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
-		return scanner.Walk(path)
+		// Use scanner for directories, reusing the vault index if one has been built
+		idx := vault.FindIndex(path)
+		scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithIndex(idx), vault.WithWorkers(workers))
+		files, err := scanner.Walk(path)
+		if err != nil {
+			return nil, err
+		}
+		if idx != nil {
+			if err := idx.Save(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+		return files, nil
 	} else {
 		// Handle single file
 		if !strings.HasSuffix(path, ".md") {
@@ -1001,6 +2115,21 @@ Enhanced Query Language:
     --where "tags contains 'work' OR tags contains 'project'"  # Either condition
     --where "(priority > 5 OR status = 'urgent') AND tags contains 'active'"
 
+  Presence and types:
+    --where "created not exists AND status = 'draft'"   # Field is missing
+    --where "due is null OR summary is empty"           # No value, or "", [] or {}
+    --where "typeof(tags) = 'string'"                   # string, number, boolean, date, array, object, null
+
+  Content:
+    --where "body contains 'TODO'"                # Text after the frontmatter
+    --where "heading contains 'Action items'"     # Any heading
+    --where "wordcount > 1000"                    # Words in the body
+
+  Sorting and limits:
+    --where "status = 'draft' ORDER BY file.mtime DESC LIMIT 10"   # 10 most recently modified drafts
+    --where "ORDER BY priority DESC, title"                         # Every note, sorted
+    --where "type = 'book'" --sort "rating desc"                    # --sort replaces ORDER BY
+
 Other query types:
   # Find files missing specific fields
   mdnotes fm query . --missing "created"
@@ -1014,6 +2143,21 @@ Other query types:
   # Just count matching files
   mdnotes fm query . --where "status = 'draft'" --count
   
+  # Count notes and average priority per status
+  mdnotes fm query . --group-by status --aggregate "count(), avg(priority)"
+  
+  # Count notes per tag (list fields count once per item)
+  mdnotes fm query . --where "status != 'done'" --group-by tags --format csv
+  
+  # Stream one JSON object per line, a page at a time
+  mdnotes fm query . --where "status = 'draft'" --format ndjson --limit 1000 --offset 2000
+  
+  # Markdown for a note: a list of links, or a table with --field
+  mdnotes fm query . --where "status = 'reading'" --field author,rating --format markdown
+  
+  # Keep a dashboard note up to date, between markers in the note
+  mdnotes fm query . --where "status = 'reading'" --output Dashboard.md --between-markers
+  
   # Auto-fix missing fields
   mdnotes fm query . --missing "created" --fix-with "{{current_date}}"
   
@@ -1029,13 +2173,22 @@ Piping support:
 
 	// Query criteria flags
 	cmd.Flags().String("where", "", "Filter expression (e.g., \"status = 'draft'\", \"priority > 3\")")
-	cmd.Flags().String("missing", "", "Find files missing this field")
+	cmd.Flags().String("missing", "", "Find files missing this field (with other criteria, use --where \"field not exists\")")
 	cmd.Flags().String("duplicates", "", "Find files with duplicate values for this field")
 
+	// Aggregation flags
+	cmd.Flags().StringSlice("group-by", nil, "Group matching files by these fields")
+	cmd.Flags().String("aggregate", "", "Aggregates per group: count(), count(field), sum, avg, min, max (default: count())")
+
 	// Output control flags (consistent with other commands)
 	cmd.Flags().StringSlice("field", nil, "Select specific fields to display (comma-separated)")
-	cmd.Flags().String("format", "table", "Output format: table, json, csv, yaml, paths")
+	cmd.Flags().String("format", "table", "Output format: table, json, ndjson, csv, yaml, paths, markdown")
+	cmd.Flags().String("output", "", "Write markdown results to this note instead of printing them")
+	cmd.Flags().Bool("between-markers", false, "With --output, replace only the results between mdnotes:query markers in the note")
 	cmd.Flags().Bool("count", false, "Show only the count of matching files")
+	cmd.Flags().String("sort", "", "Sort results by fields, e.g. \"file.mtime desc, title\" (replaces the query's ORDER BY)")
+	cmd.Flags().Int("limit", 0, "Maximum number of results to output (0 = no limit)")
+	cmd.Flags().Int("offset", 0, "Number of results to skip before output")
 	cmd.Flags().Bool("paths-only", false, "Output only file paths (for piping to other commands)")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
 
@@ -1052,10 +2205,17 @@ func runQuery(cmd *cobra.Command, args []string) error {
 	whereExpr, _ := cmd.Flags().GetString("where")
 	missingField, _ := cmd.Flags().GetString("missing")
 	duplicatesField, _ := cmd.Flags().GetString("duplicates")
+	groupBy, _ := cmd.Flags().GetStringSlice("group-by")
+	aggregateSpec, _ := cmd.Flags().GetString("aggregate")
 	fields, _ := cmd.Flags().GetStringSlice("field")
 	format, _ := cmd.Flags().GetString("format")
 	count, _ := cmd.Flags().GetBool("count")
+	sortSpec, _ := cmd.Flags().GetString("sort")
+	limit, _ := cmd.Flags().GetInt("limit")
+	offset, _ := cmd.Flags().GetInt("offset")
 	pathsOnly, _ := cmd.Flags().GetBool("paths-only")
+	output, _ := cmd.Flags().GetString("output")
+	betweenMarkers, _ := cmd.Flags().GetBool("between-markers")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
 	fixWith, _ := cmd.Flags().GetString("fix-with")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
@@ -1074,7 +2234,9 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		criteriaCount++
 	}
 
-	if criteriaCount == 0 {
+	aggregating := len(groupBy) > 0 || aggregateSpec != ""
+
+	if criteriaCount == 0 && !aggregating {
 		return fmt.Errorf("must specify one of: --where, --missing, or --duplicates")
 	}
 	if criteriaCount > 1 {
@@ -1084,6 +2246,22 @@ func runQuery(cmd *cobra.Command, args []string) error {
 	if fixWith != "" && missingField == "" {
 		return fmt.Errorf("--fix-with can only be used with --missing")
 	}
+	if err := templates.NewRenderer().Validate(fixWith); err != nil {
+		return fmt.Errorf("--fix-with: %w", err)
+	}
+
+	if limit < 0 || offset < 0 {
+		return fmt.Errorf("--limit and --offset must not be negative")
+	}
+
+	var sortKeys []query.SortKey
+	if sortSpec != "" {
+		keys, err := query.ParseSortKeys(sortSpec)
+		if err != nil {
+			return fmt.Errorf("--sort: %w", err)
+		}
+		sortKeys = keys
+	}
 
 	if pathsOnly && format != "table" {
 		return fmt.Errorf("--paths-only cannot be used with --format (use --paths-only OR --format)")
@@ -1093,8 +2271,36 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		format = "paths"
 	}
 
+	if betweenMarkers && output == "" {
+		return fmt.Errorf("--between-markers can only be used with --output")
+	}
+	if output != "" {
+		if !cmd.Flags().Changed("format") {
+			format = "markdown"
+		}
+		if format != "markdown" || count {
+			return fmt.Errorf("--output writes markdown results and can't be used with --count, --paths-only or another --format")
+		}
+	}
+
+	var aggregates []query.Aggregate
+	if aggregating {
+		if count || pathsOnly || limit > 0 || offset > 0 || len(fields) > 0 || len(sortKeys) > 0 {
+			return fmt.Errorf("--group-by and --aggregate cannot be used with --count, --paths-only, --field, --sort, --limit or --offset")
+		}
+		if aggregateSpec == "" {
+			aggregateSpec = "count()"
+		}
+		parsed, err := query.ParseAggregates(aggregateSpec)
+		if err != nil {
+			return err
+		}
+		aggregates = parsed
+	}
+
 	// Load files using existing helper
-	files, err := loadFilesForProcessing(path, ignorePatterns)
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+	files, err := loadFilesForProcessing(path, ignorePatterns, workers)
 	if err != nil {
 		return fmt.Errorf("loading files: %w", err)
 	}
@@ -1115,11 +2321,48 @@ func runQuery(cmd *cobra.Command, args []string) error {
 
 	// Process files based on query type
 	if whereExpr != "" {
-		matchingFiles = processWhereQuery(files, whereExpr, verbose, quiet)
+		matchingFiles = processWhereQuery(files, whereExpr, sortKeys, verbose, quiet)
 	} else if missingField != "" {
-		matchingFiles, modifications = processMissingQuery(files, missingField, fixWith, dryRun, verbose, quiet)
+		tx := cli.BeginTransaction(cmd, path)
+		matchingFiles, modifications = processMissingQuery(files, missingField, fixWith, dryRun, verbose, quiet, tx)
+		cli.CommitTransaction(cmd, tx)
 	} else if duplicatesField != "" {
 		matchingFiles = processDuplicatesQuery(files, duplicatesField, verbose, quiet)
+	} else {
+		matchingFiles = files
+	}
+	if whereExpr == "" && len(sortKeys) > 0 {
+		sorter := &query.Query{OrderBy: sortKeys}
+		sorter.Bind(files, processor.NewLinkParser())
+		sorter.Sort(matchingFiles)
+	}
+
+	if format == "markdown" && !count {
+		var rendered string
+		if aggregating {
+			rendered = query.GroupAndAggregate(matchingFiles, groupBy, aggregates).Markdown()
+		} else {
+			rendered = processor.QueryMarkdown(paginate(matchingFiles, offset, limit), fields, processor.NoteNames(files))
+		}
+		if output == "" && rendered == "" {
+			if !quiet {
+				fmt.Println("No files match the criteria")
+			}
+			return nil
+		}
+		if output == "" {
+			fmt.Print(rendered)
+			return nil
+		}
+		return writeQueryNote(cmd, output, rendered, betweenMarkers, dryRun, verbose, quiet)
+	}
+
+	if aggregating {
+		result := query.GroupAndAggregate(matchingFiles, groupBy, aggregates)
+		if err := outputAggregate(result, format, quiet); err != nil {
+			return fmt.Errorf("outputting results: %w", err)
+		}
+		return nil
 	}
 
 	// Handle count-only output
@@ -1140,6 +2383,8 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	matchingFiles = paginate(matchingFiles, offset, limit)
+
 	// Output results in requested format
 	if err := outputResults(matchingFiles, fields, format, quiet); err != nil {
 		return fmt.Errorf("outputting results: %w", err)
@@ -1157,13 +2402,13 @@ func runQuery(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// Enhanced where expression parser using the new query language
-func processWhereQuery(files []*vault.VaultFile, whereExpr string, verbose, quiet bool) []*vault.VaultFile {
+// Enhanced where expression parser using the new query language. sortKeys,
+// when given, replace the query's ORDER BY clause.
+func processWhereQuery(files []*vault.VaultFile, whereExpr string, sortKeys []query.SortKey, verbose, quiet bool) []*vault.VaultFile {
 	var matches []*vault.VaultFile
 
 	// Parse the expression using the enhanced query parser
-	parser := query.NewParser(whereExpr)
-	expr, err := parser.Parse()
+	q, err := query.ParseQuery(whereExpr)
 	if err != nil {
 		if !quiet {
 			fmt.Printf("Error parsing query expression: %v\n", err)
@@ -1172,13 +2417,18 @@ func processWhereQuery(files []*vault.VaultFile, whereExpr string, verbose, quie
 			fmt.Printf("  Contains operator: tags contains 'urgent', title contains 'project'\n")
 			fmt.Printf("  Date comparisons: created after '2024-01-01', modified before '2024-12-01', updated within '7 days'\n")
 			fmt.Printf("  Logical operators: priority > 3 AND status != 'done', tags contains 'work' OR tags contains 'project'\n")
+			fmt.Printf("  Sorting and limits: status = 'draft' ORDER BY file.mtime DESC LIMIT 10\n")
 		}
 		return matches
 	}
+	if len(sortKeys) > 0 {
+		q.OrderBy = sortKeys
+	}
+	q.Bind(files, processor.NewLinkParser())
 
 	// Evaluate the expression against each file
 	for _, file := range files {
-		if expr.Evaluate(file) {
+		if q.Match(file) {
 			matches = append(matches, file)
 			if verbose {
 				fmt.Printf("Examining: %s - Matches query\n", file.RelativePath)
@@ -1188,12 +2438,13 @@ func processWhereQuery(files []*vault.VaultFile, whereExpr string, verbose, quie
 		}
 	}
 
-	return matches
+	return q.Arrange(matches)
 }
 
-func processMissingQuery(files []*vault.VaultFile, field, fixWith string, dryRun, verbose, quiet bool) ([]*vault.VaultFile, int) {
+func processMissingQuery(files []*vault.VaultFile, field, fixWith string, dryRun, verbose, quiet bool, tx *safety.Transaction) ([]*vault.VaultFile, int) {
 	var matches []*vault.VaultFile
 	modifications := 0
+	renderer := templates.NewRenderer()
 
 	for _, file := range files {
 		if _, exists := file.GetField(field); !exists {
@@ -1205,23 +2456,26 @@ func processMissingQuery(files []*vault.VaultFile, field, fixWith string, dryRun
 
 			// Auto-fix if requested
 			if fixWith != "" {
-				if dryRun {
+				if reason := file.LockReason(); reason != "" {
+					if !quiet {
+						fmt.Printf("⚠ Skipped fix: %s (locked: %s)\n", file.RelativePath, reason)
+					}
+				} else if processedValue, err := renderer.Render(fixWith, templates.FileScope(file)); err != nil {
+					fmt.Printf("✗ %s: Cannot fix '%s': %v\n", file.RelativePath, field, err)
+				} else if dryRun {
 					if verbose {
-						fmt.Printf("Would fix: %s - Would add field '%s' = %s\n", file.RelativePath, field, fixWith)
+						fmt.Printf("Would fix: %s - Would add field '%s' = %s\n", file.RelativePath, field, processedValue)
 					}
 				} else {
-					// Process template variables
-					processedValue := fixWith
-					if strings.Contains(fixWith, "{{current_date}}") {
-						processedValue = strings.ReplaceAll(processedValue, "{{current_date}}", "2024-12-18") // TODO: use actual date
-					}
-
 					file.SetField(field, processedValue)
 
 					// Save file
 					content, err := file.Serialize()
 					if err == nil {
-						err = os.WriteFile(file.Path, content, 0644)
+						err = tx.RecordWrite(file.Path)
+					}
+					if err == nil {
+						err = safety.WriteFile(file.Path, content, 0644)
 						if err == nil {
 							modifications++
 							if verbose {
@@ -1270,6 +2524,8 @@ func outputResults(files []*vault.VaultFile, fields []string, format string, qui
 		return outputTable(files, fields, quiet)
 	case "json":
 		return outputJSON(files, fields)
+	case "ndjson":
+		return outputNDJSON(files, fields)
 	case "csv":
 		return outputCSV(files, fields)
 	case "yaml":
@@ -1277,7 +2533,7 @@ func outputResults(files []*vault.VaultFile, fields []string, format string, qui
 	case "paths":
 		return outputPaths(files)
 	default:
-		return fmt.Errorf("unsupported format: %s (supported: table, json, csv, yaml, paths)", format)
+		return fmt.Errorf("unsupported format: %s (supported: table, json, ndjson, csv, yaml, paths, markdown)", format)
 	}
 }
 
@@ -1357,32 +2613,214 @@ func outputTable(files []*vault.VaultFile, fields []string, quiet bool) error {
 	return nil
 }
 
-func outputJSON(files []*vault.VaultFile, fields []string) error {
-	var results []map[string]interface{}
-
-	for _, file := range files {
-		result := map[string]interface{}{
-			"file": file.RelativePath,
+// outputAggregate writes a grouped result table. Missing group values are
+// shown as "(none)" in tables and CSV, and as null in JSON.
+func outputAggregate(result *query.AggregateResult, format string, quiet bool) error {
+	columns := result.Columns()
+	records := make([]map[string]interface{}, len(result.Rows))
+	cells := make([][]string, len(result.Rows))
+	for i, row := range result.Rows {
+		records[i] = make(map[string]interface{}, len(columns))
+		for j, key := range row.Keys {
+			var value interface{}
+			if key != "" {
+				value = key
+			}
+			records[i][columns[j]] = value
+			if key == "" {
+				key = "(none)"
+			}
+			cells[i] = append(cells[i], key)
 		}
+		for j, value := range row.Values {
+			records[i][columns[len(row.Keys)+j]] = value
+			cells[i] = append(cells[i], formatAggregateValue(value))
+		}
+	}
 
-		if len(fields) == 0 {
-			// Include all frontmatter
-			for k, v := range file.Frontmatter {
-				result[k] = v
+	switch format {
+	case "table":
+		widths := make([]int, len(columns))
+		for i, column := range columns {
+			widths[i] = len(column)
+		}
+		for _, row := range cells {
+			for i, cell := range row {
+				if len(cell) > widths[i] {
+					widths[i] = len(cell)
+				}
 			}
-		} else {
-			// Include only specified fields
-			for _, field := range fields {
-				if field == "file" {
-					continue // already added
+		}
+		printRow := func(row []string) {
+			for i, cell := range row {
+				if i > 0 {
+					fmt.Print(" │ ")
 				}
-				if value, exists := file.GetField(field); exists {
-					result[field] = value
+				fmt.Printf("%-*s", widths[i], cell)
+			}
+			fmt.Println()
+		}
+		if !quiet {
+			printRow(columns)
+			for i := range columns {
+				if i > 0 {
+					fmt.Print("─┼─")
 				}
+				fmt.Print(strings.Repeat("─", widths[i]))
+			}
+			fmt.Println()
+		}
+		for _, row := range cells {
+			printRow(row)
+		}
+		return nil
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case "ndjson":
+		w := cli.NewNDJSONWriter(os.Stdout)
+		for _, record := range records {
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write(columns)
+		for _, row := range cells {
+			_ = w.Write(row)
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		return fmt.Errorf("unsupported format for aggregation: %s (supported: table, json, ndjson, csv, markdown)", format)
+	}
+}
+
+// writeQueryNote writes markdown query results to the note at output,
+// replacing its body, or with betweenMarkers only the results between the
+// query markers, which are added at the end of the note when it has none.
+// Frontmatter is kept and locked notes are refused.
+func writeQueryNote(cmd *cobra.Command, output, rendered string, betweenMarkers, dryRun, verbose, quiet bool) error {
+	note := &vault.VaultFile{Path: output, RelativePath: output}
+	exists := false
+	if content, err := os.ReadFile(output); err == nil {
+		exists = true
+		if err := note.Parse(content); err != nil {
+			return fmt.Errorf("parsing %s: %w", output, err)
+		}
+		if reason := note.LockReason(); reason != "" {
+			return fmt.Errorf("%s is locked: %s", output, reason)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", output, err)
+	}
+
+	body := rendered
+	if betweenMarkers {
+		body = processor.ReplaceMarkedBlock(note.Body, processor.QueryStartMarker, processor.QueryEndMarker, rendered)
+	}
+	if exists && body == note.Body {
+		if !quiet {
+			fmt.Printf("%s is up to date\n", output)
+		}
+		return nil
+	}
+	note.Body = body
+
+	verb, done := "update", "Updated"
+	if !exists {
+		verb, done = "create", "Created"
+	}
+	if dryRun {
+		if !quiet {
+			fmt.Printf("Would %s %s\n", verb, output)
+		}
+		if verbose {
+			fmt.Printf("\n%s", rendered)
+		}
+		return nil
+	}
+
+	content, err := note.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", output, err)
+	}
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(output))
+	defer cli.CommitTransaction(cmd, tx)
+	if err := tx.RecordWrite(output); err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("creating folder: %w", err)
+	}
+	if err := safety.WriteFile(output, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", output, err)
+	}
+	if !quiet {
+		fmt.Printf("✓ %s %s\n", done, output)
+	}
+	return nil
+}
+
+// formatAggregateValue renders an aggregate for text output, rounding
+// fractions to two decimal places
+func formatAggregateValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// paginate returns the page of files starting at offset, with at most limit entries (0 = all)
+func paginate(files []*vault.VaultFile, offset, limit int) []*vault.VaultFile {
+	if offset >= len(files) {
+		return nil
+	}
+	files = files[offset:]
+	if limit > 0 && limit < len(files) {
+		files = files[:limit]
+	}
+	return files
+}
+
+// queryRecord builds the output record for a file: its path plus the selected
+// fields, or all frontmatter when no fields are selected
+func queryRecord(file *vault.VaultFile, fields []string) map[string]interface{} {
+	result := map[string]interface{}{
+		"file": file.RelativePath,
+	}
+
+	if len(fields) == 0 {
+		// Include all frontmatter
+		for k, v := range file.Frontmatter {
+			result[k] = v
+		}
+	} else {
+		// Include only specified fields
+		for _, field := range fields {
+			if field == "file" {
+				continue // already added
+			}
+			if value, exists := file.GetField(field); exists {
+				result[field] = value
 			}
 		}
+	}
+
+	return result
+}
 
-		results = append(results, result)
+func outputJSON(files []*vault.VaultFile, fields []string) error {
+	var results []map[string]interface{}
+	for _, file := range files {
+		results = append(results, queryRecord(file, fields))
 	}
 
 	encoder := json.NewEncoder(os.Stdout)
@@ -1390,6 +2828,18 @@ func outputJSON(files []*vault.VaultFile, fields []string) error {
 	return encoder.Encode(results)
 }
 
+// outputNDJSON writes one JSON object per line, so large result sets can be
+// consumed as they are written
+func outputNDJSON(files []*vault.VaultFile, fields []string) error {
+	w := cli.NewNDJSONWriter(os.Stdout)
+	for _, file := range files {
+		if err := w.Write(queryRecord(file, fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func outputCSV(files []*vault.VaultFile, fields []string) error {
 	// Default fields if none specified
 	if len(fields) == 0 {
@@ -1,14 +1,20 @@
 package frontmatter
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
 
 // Helper function to create a temporary test vault
@@ -232,6 +238,39 @@ func TestEnsureCommand_NonexistentDirectory(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestEnsureCommand_SkipsTemplates(t *testing.T) {
+	tmpDir := createTestVault(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "templates"), 0755))
+
+	note := createTestFile(t, tmpDir, "note.md", "# Note\n\n```\n{{ not a template }}\n```\n")
+	inFolder := createTestFile(t, tmpDir, "templates/daily.md", "# Daily\n")
+	templater := createTestFile(t, tmpDir, "meeting.md", "# <% tp.file.title %>\n")
+	placeholders := createTestFile(t, tmpDir, "core.md", "# Core\n\nDate: {{date}}\n")
+
+	err := runCommand(t, NewEnsureCommand(), []string{"--field", "status", "--default", "draft", tmpDir})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(note)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "status: draft")
+
+	for _, path := range []string{inFolder, templater, placeholders} {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "status:", path)
+	}
+
+	// Templates are processed when asked to
+	err = runCommand(t, NewEnsureCommand(), []string{"--field", "status", "--default", "draft", "--templates", "process", tmpDir})
+	require.NoError(t, err)
+	content, err = os.ReadFile(templater)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "status: draft")
+
+	err = runCommand(t, NewEnsureCommand(), []string{"--field", "status", "--default", "draft", "--templates", "never", tmpDir})
+	assert.ErrorContains(t, err, "invalid --templates")
+}
+
 func TestSetCommand_Basic(t *testing.T) {
 	tmpDir := createTestVault(t)
 
@@ -267,6 +306,78 @@ status: draft
 	assert.Contains(t, contentStr, "modified: '{{current_date}}'")
 }
 
+func TestSetCommand_PreserveEdits(t *testing.T) {
+	tmpDir := createTestVault(t)
+	testFile := createTestFile(t, tmpDir, "note.md", "---\nstatus: mine\n---\n# Note")
+
+	// Without provenance every existing value counts as edited by hand
+	err := runCommand(t, NewSetCommand(), []string{"--field", "status", "--value", "done", "--field", "owner", "--value", "me", "--preserve-edits", tmpDir})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "status: mine")
+	assert.Contains(t, string(content), "owner: me")
+}
+
+func TestSetCommand_Template(t *testing.T) {
+	tmpDir := createTestVault(t)
+	testFile := createTestFile(t, tmpDir, "My Note.md", "---\npriority: 2\n---\n# Note")
+
+	err := runCommand(t, NewSetCommand(), []string{
+		"--field", "slug", "--value", "{{title|slug}}",
+		"--field", "priority", "--value", "{{priority + 1}}", "--type", "priority:number",
+		"--template", tmpDir,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "slug: my-note")
+	assert.Contains(t, string(content), "priority: 3")
+
+	err = runCommand(t, NewSetCommand(), []string{"--field", "slug", "--value", "{{title|nope}}", "--template", tmpDir})
+	assert.ErrorContains(t, err, "unknown filter 'nope'")
+}
+
+func TestQueryCommand_FixWith(t *testing.T) {
+	tmpDir := createTestVault(t)
+	testFile := createTestFile(t, tmpDir, "note.md", "---\ntitle: Note\n---\n# Note")
+
+	err := runCommand(t, NewQueryCommand(), []string{"--missing", "created", "--fix-with", "{{current_date}}", tmpDir})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "created: \""+time.Now().Format("2006-01-02")+"\"")
+}
+
+func TestImportCommand_Basic(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	dune := createTestFile(t, tmpDir, "dune.md", "---\ntitle: Dune\nrating: 3\n---\n\n# Dune")
+	hyperion := createTestFile(t, tmpDir, "hyperion.md", "# Hyperion")
+	table := createTestFile(t, t.TempDir(), "books.csv", "path,rating,tags\ndune.md,5,\"[scifi, classic]\"\nhyperion,4,\n")
+
+	cmd := NewImportCommand()
+	err := runCommand(t, cmd, []string{"--from", table, "--key", "path", tmpDir})
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(dune)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "title: Dune")
+	assert.Contains(t, string(content), "rating: \"5\"")
+	assert.Contains(t, string(content), "- scifi")
+
+	content, err = os.ReadFile(hyperion)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "rating: \"4\"")
+	assert.NotContains(t, string(content), "tags")
+
+	err = runCommand(t, NewImportCommand(), []string{"--from", table, "--key", "isbn", tmpDir})
+	assert.ErrorContains(t, err, "key column 'isbn' not found")
+}
+
 func TestCheckCommand_Basic(t *testing.T) {
 	tmpDir := createTestVault(t)
 
@@ -310,6 +421,54 @@ priority: "not a number"
 	assert.Error(t, err)
 }
 
+func TestCheckCommand_Schema(t *testing.T) {
+	tmpDir := createTestVault(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".obsidian"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "journal"), 0755))
+
+	schemaPath := createTestFile(t, tmpDir, "schema.yaml", `fields:
+  title: {type: string, required: true}
+  status: {type: string, enum: [draft, active, done], default: draft}
+  priority: {type: integer}
+folders:
+  journal:
+    fields:
+      mood: {type: string, enum: [good, bad]}
+`)
+	createTestFile(t, tmpDir, "note.md", "---\ntitle: Note\nstatus: Active\npriority: \"2\"\n---\n")
+	createTestFile(t, tmpDir, "journal/day.md", "---\ntitle: Day\nmood: GOOD\n---\n")
+
+	err := runCommand(t, NewCheckCommand(), []string{"--schema", schemaPath, tmpDir})
+	assert.ErrorContains(t, err, "validation failed")
+
+	// A bare --fix applies defaults and coercions, after which the vault passes
+	require.NoError(t, runCommand(t, NewCheckCommand(), []string{"--schema", schemaPath, "--fix", tmpDir}))
+
+	note, err := vault.LoadVaultFile(filepath.Join(tmpDir, "note.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "active", note.Frontmatter["status"])
+	assert.Equal(t, 2, note.Frontmatter["priority"])
+
+	day, err := vault.LoadVaultFile(filepath.Join(tmpDir, "journal", "day.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "good", day.Frontmatter["mood"])
+	assert.Equal(t, "draft", day.Frontmatter["status"])
+}
+
+func TestCheckCommand_FixStrategyArgument(t *testing.T) {
+	tmpDir := createTestVault(t)
+	path := createTestFile(t, tmpDir, "dup.md", "---\ntags: a\ntags: b\n---\n")
+
+	// "--fix merge path" still passes the strategy to --fix
+	require.NoError(t, runCommand(t, NewCheckCommand(), []string{"--fix", "merge", tmpDir}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "- a\n")
+
+	err = runCommand(t, NewCheckCommand(), []string{"--fix", tmpDir})
+	assert.ErrorContains(t, err, "--schema")
+}
+
 func TestQueryCommand_Basic(t *testing.T) {
 	tmpDir := createTestVault(t)
 
@@ -347,6 +506,102 @@ tags: [blog, published]
 	assert.NoError(t, err)
 }
 
+func TestPaginate(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "a.md"}, {RelativePath: "b.md"}, {RelativePath: "c.md"},
+	}
+	paths := func(files []*vault.VaultFile) []string {
+		var result []string
+		for _, f := range files {
+			result = append(result, f.RelativePath)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"a.md", "b.md", "c.md"}, paths(paginate(files, 0, 0)))
+	assert.Equal(t, []string{"b.md", "c.md"}, paths(paginate(files, 1, 0)))
+	assert.Equal(t, []string{"b.md"}, paths(paginate(files, 1, 1)))
+	assert.Equal(t, []string{"a.md", "b.md", "c.md"}, paths(paginate(files, 0, 10)))
+	assert.Empty(t, paginate(files, 5, 1))
+}
+
+func TestQueryCommand_NDJSON(t *testing.T) {
+	tmpDir := createTestVault(t)
+	createTestFile(t, tmpDir, "a.md", "---\nstatus: draft\n---\n# A")
+	createTestFile(t, tmpDir, "b.md", "---\nstatus: draft\n---\n# B")
+
+	cmd := NewQueryCommand()
+	err := runCommand(t, cmd, []string{"--where", "status = 'draft'", "--format", "ndjson", "--limit", "1", tmpDir})
+	assert.NoError(t, err)
+
+	cmd = NewQueryCommand()
+	err = runCommand(t, cmd, []string{"--where", "status = 'draft'", "--limit", "-1", tmpDir})
+	assert.Error(t, err)
+}
+
+func TestQueryCommand_Aggregate(t *testing.T) {
+	tmpDir := createTestVault(t)
+	createTestFile(t, tmpDir, "a.md", "---\nstatus: draft\npriority: 2\n---\n# A")
+	createTestFile(t, tmpDir, "b.md", "---\nstatus: done\npriority: 5\n---\n# B")
+
+	// Aggregating doesn't need a --where filter
+	err := runCommand(t, NewQueryCommand(), []string{"--group-by", "status", "--aggregate", "count(), avg(priority)", "--format", "json", tmpDir})
+	assert.NoError(t, err)
+
+	err = runCommand(t, NewQueryCommand(), []string{"--group-by", "status", "--count", tmpDir})
+	assert.Error(t, err)
+
+	err = runCommand(t, NewQueryCommand(), []string{"--aggregate", "median(priority)", tmpDir})
+	assert.ErrorContains(t, err, "unknown aggregate function")
+
+	err = runCommand(t, NewQueryCommand(), []string{"--group-by", "status", "--format", "yaml", tmpDir})
+	assert.ErrorContains(t, err, "unsupported format for aggregation")
+}
+
+func TestQueryCommand_MarkdownOutput(t *testing.T) {
+	tmpDir := createTestVault(t)
+	createTestFile(t, tmpDir, "a.md", "---\nstatus: reading\nrating: 4\n---\n# A")
+	createTestFile(t, tmpDir, "b.md", "---\nstatus: done\n---\n# B")
+	dashboard := createTestFile(t, tmpDir, "Dashboard.md", "# Dashboard\n\nIntro\n")
+
+	args := []string{"--where", "status = 'reading'", "--field", "rating", "--output", dashboard, "--between-markers", tmpDir}
+	require.NoError(t, runCommand(t, NewQueryCommand(), args))
+	require.NoError(t, runCommand(t, NewQueryCommand(), args))
+
+	content, err := os.ReadFile(dashboard)
+	require.NoError(t, err)
+	assert.Equal(t, "# Dashboard\n\nIntro\n\n"+processor.QueryStartMarker+"\n| file | rating |\n| --- | --- |\n| [[a]] | 4 |\n"+processor.QueryEndMarker+"\n", string(content))
+
+	err = runCommand(t, NewQueryCommand(), []string{"--where", "status = 'reading'", "--output", dashboard, "--format", "csv", tmpDir})
+	assert.ErrorContains(t, err, "--output writes markdown")
+
+	err = runCommand(t, NewQueryCommand(), []string{"--where", "status = 'reading'", "--between-markers", tmpDir})
+	assert.ErrorContains(t, err, "--between-markers can only be used with --output")
+}
+
+func TestQueryCommand_SortAndLimit(t *testing.T) {
+	tmpDir := createTestVault(t)
+	createTestFile(t, tmpDir, "a.md", "---\npriority: 2\n---\n# A")
+	createTestFile(t, tmpDir, "b.md", "---\npriority: 10\n---\n# B")
+	createTestFile(t, tmpDir, "c.md", "---\npriority: 5\n---\n# C")
+	createTestFile(t, tmpDir, "d.md", "# D")
+	output := filepath.Join(tmpDir, "Top.md")
+
+	require.NoError(t, runCommand(t, NewQueryCommand(), []string{"--where", "priority > 0 ORDER BY priority DESC LIMIT 2", "--output", output, tmpDir}))
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "- [[b]]\n- [[c]]\n", string(content))
+
+	// --sort replaces the ORDER BY clause; notes without the field come last
+	require.NoError(t, runCommand(t, NewQueryCommand(), []string{"--where", "ORDER BY priority DESC", "--sort", "priority", "--limit", "4", "--output", output, tmpDir}))
+	content, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "- [[a]]\n- [[c]]\n- [[b]]\n- [[Top]]\n", string(content))
+
+	err = runCommand(t, NewQueryCommand(), []string{"--group-by", "priority", "--sort", "priority", tmpDir})
+	assert.ErrorContains(t, err, "--sort")
+}
+
 func TestCastCommand_Basic(t *testing.T) {
 	tmpDir := createTestVault(t)
 
@@ -425,6 +680,162 @@ title: Sync Test
 	assert.Contains(t, contentStr, "modified:")
 }
 
+func TestEnsureCommand_SkipsLockedFiles(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	lockedContent := "---\nmdnotes: locked\ntitle: Generated\n---\n\n# Generated\n"
+	lockedFile := createTestFile(t, tmpDir, "locked.md", lockedContent)
+	markerContent := "# Managed\n\n<!-- mdnotes:locked -->\n"
+	markerFile := createTestFile(t, tmpDir, "marker.md", markerContent)
+	openFile := createTestFile(t, tmpDir, "open.md", "# Open\n")
+
+	cmd := NewEnsureCommand()
+	err := runCommand(t, cmd, []string{"--field", "tags", "--default", "[]", tmpDir})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(lockedFile)
+	require.NoError(t, err)
+	assert.Equal(t, lockedContent, string(content))
+
+	content, err = os.ReadFile(markerFile)
+	require.NoError(t, err)
+	assert.Equal(t, markerContent, string(content))
+
+	content, err = os.ReadFile(openFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "tags: []")
+}
+
+func TestRemoveCommand_Basic(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	testFile := createTestFile(t, tmpDir, "note.md", "---\ntitle: Note\nlegacy_id: 42\nstatus: draft\n---\n\nBody\n")
+
+	cmd := NewRemoveCommand()
+	err := runCommand(t, cmd, []string{"--field", "legacy_id", tmpDir})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Note\nstatus: draft\n---\n\nBody\n", string(content))
+}
+
+func TestRenameFieldCommand_Conflicts(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	renamed := createTestFile(t, tmpDir, "renamed.md", "---\ntitle: A\nalias: Other\nstatus: draft\n---\n\nBody\n")
+	conflictContent := "---\nalias: Other\naliases:\n    - Old\n---\n\nBody\n"
+	conflict := createTestFile(t, tmpDir, "conflict.md", conflictContent)
+
+	err := runCommand(t, NewRenameFieldCommand(), []string{"--from", "alias", "--to", "aliases", tmpDir})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(renamed)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: A\naliases: Other\nstatus: draft\n---\n\nBody\n", string(content))
+
+	content, err = os.ReadFile(conflict)
+	require.NoError(t, err)
+	assert.Equal(t, conflictContent, string(content), "conflicting file is skipped by default")
+
+	err = runCommand(t, NewRenameFieldCommand(), []string{"--from", "alias", "--to", "aliases", "--on-conflict", "merge", tmpDir})
+	require.NoError(t, err)
+
+	content, err = os.ReadFile(conflict)
+	require.NoError(t, err)
+	assert.Equal(t, "---\naliases:\n    - Old\n    - Other\n---\n\nBody\n", string(content))
+}
+
+func TestRenameFieldCommand_SameField(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	err := runCommand(t, NewRenameFieldCommand(), []string{"--from", "alias", "--to", "alias", tmpDir})
+	assert.Error(t, err)
+}
+
+func TestTidyCommand_Basic(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	content := "---\ntags: [b, a]\ncreated: 2024-1-5\ntitle: \"Tidy\"\n---\n\nBody  with  spacing\n"
+	testFile := createTestFile(t, tmpDir, "tidy.md", content)
+	tidyContent := "---\ntitle: Tidy\n---\n\nBody\n"
+	tidyFile := createTestFile(t, tmpDir, "done.md", tidyContent)
+
+	cmd := NewTidyCommand()
+	err := runCommand(t, cmd, []string{
+		"--order", "title,created",
+		"--quotes", "minimal",
+		"--dates", "iso",
+		"--arrays", "block",
+		tmpDir,
+	})
+	require.NoError(t, err)
+
+	updated, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Tidy\ncreated: 2024-01-05\ntags:\n    - b\n    - a\n---\n\nBody  with  spacing\n", string(updated))
+
+	unchanged, err := os.ReadFile(tidyFile)
+	require.NoError(t, err)
+	assert.Equal(t, tidyContent, string(unchanged))
+}
+
+func TestTidyCommand_InvalidStyle(t *testing.T) {
+	tmpDir := createTestVault(t)
+
+	cmd := NewTidyCommand()
+	err := runCommand(t, cmd, []string{"--arrays", "inline", tmpDir})
+	assert.Error(t, err)
+}
+
+func TestDownloadCommand_SharedURLs(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/missing.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png content for " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	tmpDir := createTestVault(t)
+	attachments := filepath.Join(tmpDir, "attachments")
+	configPath := createTestFile(t, tmpDir, "config.yaml", `downloads:
+  attachments_dir: `+attachments+`
+  workers: 2
+  retries: 0
+`)
+	notes := filepath.Join(tmpDir, "notes")
+	require.NoError(t, os.MkdirAll(notes, 0755))
+	createTestFile(t, notes, "a.md", "---\ncover: "+server.URL+"/cover.png\n---\n\n# A\n")
+	createTestFile(t, notes, "b.md", "---\ncover: "+server.URL+"/cover.png\nbanner: "+server.URL+"/banner.png\n---\n\n# B\n")
+	createTestFile(t, notes, "c.md", "---\ncover: "+server.URL+"/missing.png\n---\n\n# C\n")
+
+	err := runCommand(t, NewDownloadCommand(), []string{"--config", configPath, notes})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 errors")
+
+	// The shared cover was downloaded once, under the first note's name
+	assert.Equal(t, int32(3), requests.Load())
+	b, err := os.ReadFile(filepath.Join(notes, "b.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "cover: '![[a-cover.png]]'")
+	assert.Contains(t, string(b), "banner: '![[b-banner.png]]'")
+	assert.Contains(t, string(b), "cover-original: "+server.URL+"/cover.png")
+	assert.FileExists(t, filepath.Join(attachments, "a-cover.png"))
+	assert.NoFileExists(t, filepath.Join(attachments, "b-cover.png"))
+
+	c, err := os.ReadFile(filepath.Join(notes, "c.md"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(c), "cover-original")
+
+	// The state file lets a later run skip what was downloaded
+	assert.FileExists(t, filepath.Join(notes, ".mdnotes", "downloads.json"))
+}
+
 // Benchmark tests
 func BenchmarkEnsureCommand(b *testing.B) {
 	tmpDir := createTestVault(&testing.T{})
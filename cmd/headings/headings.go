@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/eoinhurrell/mdnotes/internal/cli"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
@@ -21,6 +22,8 @@ func NewHeadingsCommand() *cobra.Command {
 	cmd.AddCommand(NewAnalyzeCommand())
 	cmd.AddCommand(NewFixCommand())
 	cmd.AddCommand(NewCleanCommand())
+	cmd.AddCommand(NewTOCCommand())
+	cmd.AddCommand(NewNumberCommand())
 
 	return cmd
 }
@@ -165,6 +168,7 @@ func runFix(cmd *cobra.Command, args []string) error {
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			originalBody := file.Body
@@ -196,6 +200,7 @@ func runFix(cmd *cobra.Command, args []string) error {
 
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -260,6 +265,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			originalBody := file.Body
@@ -303,6 +309,7 @@ func runClean(cmd *cobra.Command, args []string) error {
 
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -328,6 +335,226 @@ func runClean(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// NewTOCCommand creates the headings toc command
+func NewTOCCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "toc [path]",
+		Short: "Add or update a table of contents in notes",
+		Long: `Add a table of contents listing a note's headings, or update the one
+already there. The table sits between <!-- mdnotes:toc start --> and
+<!-- mdnotes:toc end --> markers; a new one goes after the note's H1, or at
+the top without one. Running again only changes the table when the headings
+have, and text outside the markers is never touched.
+
+Headings from --min-level (default 2, leaving out the title) to --depth
+(default 3) are listed, nested as they are in the note, with links to each
+heading: [[#Heading]] wiki links, or [Heading](#Heading) with --format markdown.
+
+Examples:
+  # Add tables of contents listing H2 to H4
+  mdnotes headings toc --depth 4 /path/to/vault
+
+  # Remove them again
+  mdnotes headings toc --remove /path/to/vault`,
+		Args: cobra.ExactArgs(1),
+		RunE: runTOC,
+	}
+
+	cmd.Flags().Int("min-level", 2, "Shallowest heading level to list")
+	cmd.Flags().Int("depth", 3, "Deepest heading level to list")
+	cmd.Flags().String("format", "wiki", "Link format (wiki, markdown)")
+	cmd.Flags().Bool("remove", false, "Remove tables of contents instead")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runTOC(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	minLevel, _ := cmd.Flags().GetInt("min-level")
+	depth, _ := cmd.Flags().GetInt("depth")
+	format, _ := cmd.Flags().GetString("format")
+	remove, _ := cmd.Flags().GetBool("remove")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	generator, err := processor.NewTOCGenerator(processor.TOCOptions{
+		MinLevel:   minLevel,
+		MaxLevel:   depth,
+		LinkFormat: format,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Setup file processor
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			originalBody := file.Body
+
+			if remove {
+				file.Body = processor.RemoveTOC(file.Body)
+			} else {
+				file.Body = generator.Update(file.Body)
+			}
+
+			modified := file.Body != originalBody
+			if verbose {
+				if modified {
+					fmt.Printf("Examining: %s - Updated table of contents\n", file.RelativePath)
+				} else {
+					fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+				}
+			}
+
+			return modified, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			}
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
+// NewNumberCommand creates the headings number command
+func NewNumberCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "number [path]",
+		Short: "Number headings hierarchically",
+		Long: `Prefix headings with hierarchical numbers, such as "2.1 Background".
+
+Headings from --min-level (default 2, leaving out the title) are numbered,
+--max-depth levels deep (default 3); an H1 starts the numbering again.
+Numbers follow the heading tree, so an H4 directly under an H2 is numbered
+as its child. Running again refreshes the numbers after headings are added,
+removed or moved, and --remove strips them. Links within the note to the
+renamed headings, such as [[#Background]], are updated to match.
+
+A heading already starting with a number, like "10 Tips", is treated as
+numbered, and its number is replaced.
+
+Styles:
+  1.1.1    1 Introduction, 1.1 Background
+  1.1.1.   1. Introduction, 1.1. Background
+
+Examples:
+  # Number H2 to H4
+  mdnotes headings number --style 1.1.1 --max-depth 3 /path/to/vault
+
+  # Remove the numbers
+  mdnotes headings number --remove /path/to/vault`,
+		Args: cobra.ExactArgs(1),
+		RunE: runNumber,
+	}
+
+	cmd.Flags().String("style", processor.HeadingNumberDotted, "Numbering style (1.1.1, 1.1.1.)")
+	cmd.Flags().Int("min-level", 2, "Shallowest heading level to number")
+	cmd.Flags().Int("max-depth", 3, "How many heading levels to number")
+	cmd.Flags().Bool("remove", false, "Remove heading numbers instead")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runNumber(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	// Get flags
+	style, _ := cmd.Flags().GetString("style")
+	minLevel, _ := cmd.Flags().GetInt("min-level")
+	maxDepth, _ := cmd.Flags().GetInt("max-depth")
+	remove, _ := cmd.Flags().GetBool("remove")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	numberer, err := processor.NewHeadingNumberer(processor.HeadingNumberOptions{
+		Style:    style,
+		MinLevel: minLevel,
+		MaxDepth: maxDepth,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Setup file processor
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			var changed int
+			if remove {
+				changed = numberer.Strip(file)
+			} else {
+				changed = numberer.Number(file)
+			}
+
+			if verbose {
+				if changed > 0 {
+					fmt.Printf("Examining: %s - Renumbered %d headings\n", file.RelativePath, changed)
+				} else {
+					fmt.Printf("Examining: %s - No changes needed\n", file.RelativePath)
+				}
+			}
+
+			return changed > 0, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			}
+		},
+	}
+
+	// Process files
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	fileProcessor.PrintSummary(result)
+
+	return nil
+}
+
 func formatIssue(issue processor.HeadingIssue) string {
 	switch issue.Type {
 	case "multiple_h1":
@@ -0,0 +1,434 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/readwise"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/internal/zotero"
+)
+
+// NewImportCommand creates the import command
+func NewImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import notes from external services",
+		Long:  `Create and update notes from content kept in external services`,
+	}
+
+	cmd.AddCommand(NewReadwiseCommand())
+	cmd.AddCommand(NewZoteroCommand())
+
+	return cmd
+}
+
+// NewReadwiseCommand creates the import readwise command
+func NewReadwiseCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "readwise [vault-path]",
+		Short: "Import Readwise and Readwise Reader highlights",
+		Long: `Import highlights from Readwise and Readwise Reader into a literature note
+per source (book, article, tweet or podcast).
+
+Each note gets author, url, category, readwise_id and synced_at frontmatter
+and a "## Highlights" section listing the highlights, each ending in a
+^rw<id> block ID with its note nested beneath. Later imports add new
+highlights, update edited ones and remove deleted ones in place, leaving the
+rest of the note alone, so notes can be moved and annotated freely. Locked
+notes are skipped.
+
+Only highlights updated since the last import are fetched; the time of the
+last import is kept in .mdnotes/readwise-state.json in the vault. Use --full
+to fetch everything again.
+
+Configuration:
+  The access token (from https://readwise.io/access_token) is read from
+  --token or .obsidian-admin.yaml:
+
+  readwise:
+    api_token: "${READWISE_TOKEN}"
+    dest: "sources"`,
+		Example: `  # Import highlights into sources/
+  mdnotes import readwise ~/vault --token "$READWISE_TOKEN" --dest sources/
+
+  # Preview the first full import
+  mdnotes import readwise ~/vault --full --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runReadwise,
+	}
+
+	cmd.Flags().String("token", "", "Readwise access token (default: readwise.api_token)")
+	cmd.Flags().String("dest", "", "Folder for new notes, relative to the vault (default: readwise.dest, or Readwise)")
+	cmd.Flags().Bool("full", false, "Import all highlights, not only those updated since the last import")
+
+	return cmd
+}
+
+func runReadwise(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	token, _ := cmd.Flags().GetString("token")
+	dest, _ := cmd.Flags().GetString("dest")
+	full, _ := cmd.Flags().GetBool("full")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if token == "" {
+		if token, err = cfg.Secret("readwise.api_token"); err != nil {
+			return err
+		}
+	}
+	if token == "" {
+		return fmt.Errorf("readwise token not configured - use --token or readwise.api_token")
+	}
+	if dest == "" {
+		dest = cfg.Readwise.Dest
+	}
+	if dest == "" {
+		dest = "Readwise"
+	}
+	var opts []readwise.ClientOption
+	if cfg.Readwise.APIURL != "" {
+		opts = append(opts, readwise.WithBaseURL(cfg.Readwise.APIURL))
+	}
+	client := readwise.NewClient(token, opts...)
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(vaultPath, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	statePath := filepath.Join(safety.FindVaultRoot(vaultPath), processor.DefaultReadwiseStateFile)
+	state, err := processor.LoadReadwiseState(statePath)
+	if err != nil {
+		return err
+	}
+	updatedAfter := state.LastSync
+	if full {
+		updatedAfter = time.Time{}
+	}
+	if verbose && !updatedAfter.IsZero() {
+		fmt.Printf("Fetching highlights updated since %s\n", updatedAfter.Format(time.RFC3339))
+	}
+
+	// Highlights updated while exporting are fetched again next time
+	started := time.Now()
+	books, err := client.Export(context.Background(), updatedAfter)
+	if err != nil {
+		return fmt.Errorf("fetching readwise highlights: %w", err)
+	}
+
+	importer := processor.NewReadwiseImporter(vaultPath, dest)
+	importer.Now = started
+	results := importer.Import(books, selection.Files)
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Action]++
+		if quiet || (result.Action == "unchanged" && !verbose) {
+			continue
+		}
+		printResult(result, dryRun)
+	}
+
+	if !dryRun {
+		var files []*vault.VaultFile
+		for _, result := range results {
+			if result.Action == "created" || result.Action == "updated" {
+				files = append(files, result.File)
+			}
+		}
+		if err := writeFiles(cmd, vaultPath, files); err != nil {
+			return err
+		}
+		state.LastSync = started
+		if err := state.Save(statePath); err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		summary := "Import completed"
+		if dryRun {
+			summary = "Dry run completed"
+		}
+		fmt.Printf("\n%s: %d created, %d updated, %d unchanged, %d skipped\n",
+			summary, counts["created"], counts["updated"], counts["unchanged"], counts["skipped"])
+	}
+	return nil
+}
+
+// printResult reports what importing a source did to its note
+func printResult(result processor.ReadwiseImportResult, dryRun bool) {
+	verb := "Updated"
+	if result.Action == "created" {
+		verb = "Created"
+	}
+	if dryRun {
+		verb = "Would " + strings.ToLower(verb[:len(verb)-1])
+	}
+
+	switch result.Action {
+	case "created", "updated":
+		fmt.Printf("✓ %s %s (%d added, %d updated, %d removed)\n",
+			verb, result.File.RelativePath, result.Added, result.Updated, result.Removed)
+	case "skipped":
+		fmt.Printf("⚠ Skipped %s: locked (%s)\n", result.File.RelativePath, result.File.LockReason())
+	case "unchanged":
+		fmt.Printf("- %s: unchanged\n", result.File.RelativePath)
+	}
+}
+
+// NewZoteroCommand creates the import zotero command
+func NewZoteroCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "zotero [vault-path]",
+		Short: "Create and refresh literature notes from a Zotero library",
+		Long: `Create and refresh a literature note per Zotero item, named after its
+Better BibTeX citation key.
+
+Items are read from a Better BibTeX export given with --file (BibTeX or
+BibLaTeX .bib, or Better CSL JSON .json), or otherwise from the local API of a
+running Zotero 7 (enable it under Settings > Advanced). Items from the API need
+a citation key, stored by Zotero or pinned by Better BibTeX in Extra.
+
+Each note's frontmatter gets citekey, title, authors, year, doi, url, journal,
+item_type and abstract; existing notes, found by their citekey field anywhere
+in the vault, are refreshed without touching their body. Pandoc citations of
+imported items elsewhere in the vault, such as [@doe2020] or
+[see @doe2020, p. 3], are rewritten as wiki links to the literature notes
+unless --link-citations=false. Locked notes are skipped.
+
+Configuration:
+  zotero:
+    file: "${HOME}/Zotero/library.bib"
+    dest: "references"`,
+		Example: `  # Import from a Better BibTeX export into references/
+  mdnotes import zotero ~/vault --file library.bib --dest references/
+
+  # Import from the running Zotero, previewing first
+  mdnotes import zotero ~/vault --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runZotero,
+	}
+
+	cmd.Flags().String("file", "", "Better BibTeX export to read, .bib or .json (default: zotero.file, or the local Zotero API)")
+	cmd.Flags().String("api-url", "", "Zotero local API address (default: zotero.api_url, or "+zotero.DefaultAPIURL+")")
+	cmd.Flags().String("dest", "", "Folder for new notes, relative to the vault (default: zotero.dest, or References)")
+	cmd.Flags().Bool("link-citations", true, "Rewrite Pandoc citations of imported items as wiki links")
+
+	return cmd
+}
+
+func runZotero(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	file, _ := cmd.Flags().GetString("file")
+	apiURL, _ := cmd.Flags().GetString("api-url")
+	dest, _ := cmd.Flags().GetString("dest")
+	linkCitations, _ := cmd.Flags().GetBool("link-citations")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if file == "" && apiURL == "" {
+		file = cfg.Zotero.File
+	}
+	if apiURL == "" {
+		apiURL = cfg.Zotero.APIURL
+	}
+	if apiURL == "" {
+		apiURL = zotero.DefaultAPIURL
+	}
+	if dest == "" {
+		dest = cfg.Zotero.Dest
+	}
+	if dest == "" {
+		dest = "References"
+	}
+
+	var items []zotero.Item
+	if file != "" {
+		items, err = zotero.ReadFile(file)
+	} else {
+		items, err = zotero.NewClient(apiURL).Items(context.Background())
+	}
+	if err != nil {
+		return fmt.Errorf("reading zotero library: %w", err)
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(vaultPath, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	results := processor.NewZoteroImporter(vaultPath, dest).Import(items, selection.Files)
+
+	counts := make(map[string]int)
+	changed := make(map[*vault.VaultFile]bool)
+	notes := make(map[string]string)
+	for _, result := range results {
+		counts[result.Action]++
+		notes[result.CiteKey] = strings.TrimSuffix(filepath.Base(result.File.RelativePath), ".md")
+		if result.Action == "created" || result.Action == "updated" {
+			changed[result.File] = true
+		}
+		if quiet || (result.Action == "unchanged" && !verbose) {
+			continue
+		}
+		printZoteroResult(result, dryRun)
+	}
+	if missing := len(items) - len(results); missing > 0 && !quiet {
+		fmt.Printf("⚠ Skipped %d items without a citation key\n", missing)
+	}
+
+	citations := 0
+	if linkCitations {
+		for _, file := range selection.Files {
+			if file.IsLocked() {
+				continue
+			}
+			if n := processor.LinkCitations(file, notes); n > 0 {
+				citations += n
+				changed[file] = true
+				if verbose {
+					fmt.Printf("Linked %d citations in: %s\n", n, file.RelativePath)
+				}
+			}
+		}
+	}
+
+	if !dryRun {
+		var files []*vault.VaultFile
+		for _, result := range results {
+			if result.Action == "created" {
+				files = append(files, result.File)
+			}
+		}
+		for _, file := range selection.Files {
+			if changed[file] {
+				files = append(files, file)
+			}
+		}
+		if err := writeFiles(cmd, vaultPath, files); err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		summary := "Import completed"
+		if dryRun {
+			summary = "Dry run completed"
+		}
+		fmt.Printf("\n%s: %d created, %d updated, %d unchanged, %d skipped, %d citations linked\n",
+			summary, counts["created"], counts["updated"], counts["unchanged"], counts["skipped"], citations)
+	}
+	return nil
+}
+
+// printZoteroResult reports what importing an item did to its note
+func printZoteroResult(result processor.ZoteroImportResult, dryRun bool) {
+	verb := "Updated"
+	if result.Action == "created" {
+		verb = "Created"
+	}
+	if dryRun {
+		verb = "Would " + strings.ToLower(verb[:len(verb)-1])
+	}
+
+	switch result.Action {
+	case "created", "updated":
+		fmt.Printf("✓ %s %s\n", verb, result.File.RelativePath)
+	case "skipped":
+		fmt.Printf("⚠ Skipped %s: locked (%s)\n", result.File.RelativePath, result.File.LockReason())
+	case "unchanged":
+		fmt.Printf("- %s: unchanged\n", result.File.RelativePath)
+	}
+}
+
+// writeFiles saves files, creating their folders, and records them in the
+// journal
+func writeFiles(cmd *cobra.Command, vaultPath string, files []*vault.VaultFile) error {
+	tx := cli.BeginTransaction(cmd, vaultPath)
+	defer cli.CommitTransaction(cmd, tx)
+
+	for _, file := range files {
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return fmt.Errorf("creating folder for %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+	}
+	return nil
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	if configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,284 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/embeddings"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewIndexCommand creates the index command
+func NewIndexCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Manage the persistent vault index",
+		Long: `Manage the vault index, which stores parsed frontmatter, headings and links
+for every note in .mdnotes/index.gob.
+
+Once built, analyze, query, links check and other commands that scan a
+directory reuse indexed results for notes whose modification time and size
+haven't changed, and only re-parse the rest. Scans keep the index up to date
+as they go; 'index update' also drops notes that have been deleted.`,
+		Example: `  # Build the index for the current vault
+  mdnotes index build
+
+  # Refresh it after editing notes outside mdnotes
+  mdnotes index update ~/vault
+
+  # Store embeddings for semantic search
+  mdnotes index embed --provider ollama ~/vault
+
+  # Remove it, returning to full scans
+  mdnotes index clear`,
+	}
+
+	cmd.AddCommand(newBuildCommand())
+	cmd.AddCommand(newUpdateCommand())
+	cmd.AddCommand(newEmbedCommand())
+	cmd.AddCommand(newClearCommand())
+
+	return cmd
+}
+
+func newBuildCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "build [vault-path]",
+		Short: "Build the vault index from scratch",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultPath := vaultPathArg(args)
+			return runIndex(cmd, vault.NewIndex(vaultPath), vaultPath)
+		},
+	}
+}
+
+func newUpdateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [vault-path]",
+		Short: "Re-index changed notes and drop deleted ones",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultPath := vaultPathArg(args)
+			idx, err := vault.LoadIndex(vaultPath)
+			if err != nil {
+				// Nothing built yet, so updating is the same as building
+				idx = vault.NewIndex(vaultPath)
+			}
+			return runIndex(cmd, idx, vaultPath)
+		},
+	}
+}
+
+func newEmbedCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "embed [vault-path]",
+		Short: "Store note embeddings in the index for semantic search",
+		Long: `Update the vault index and store a vector for every note that doesn't
+have a current one, for 'mdnotes search semantic'.
+
+Providers:
+  local   Hashed word vectors computed by mdnotes; no setup or network (default)
+  ollama  A local Ollama server (default model nomic-embed-text)
+  openai  The OpenAI embeddings API (default model text-embedding-3-small),
+          using embeddings.api_key or OPENAI_API_KEY
+
+Editing a note drops its vector, so re-running embed only sends changed
+notes to the provider. Switching model re-embeds everything.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runEmbed,
+	}
+
+	cmd.Flags().String("provider", "", "Embeddings provider: local, ollama or openai (default: embeddings.provider, or local)")
+	cmd.Flags().String("model", "", "Provider model (default: embeddings.model, or the provider's default)")
+	cmd.Flags().Int("batch-size", 32, "Notes sent to the provider per request")
+	cmd.Flags().Bool("force", false, "Re-embed notes that already have a current vector")
+
+	return cmd
+}
+
+func runEmbed(cmd *cobra.Command, args []string) error {
+	vaultPath := vaultPathArg(args)
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	force, _ := cmd.Flags().GetBool("force")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	provider, err := newEmbeddingsProvider(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	idx, err := vault.LoadIndex(vaultPath)
+	if err != nil {
+		idx = vault.NewIndex(vaultPath)
+	}
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(idx),
+		vault.WithWorkers(workers),
+	)
+	files, err := scanner.Walk(vaultPath)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	idx.Prune()
+
+	if dryRun {
+		pending := 0
+		for _, file := range files {
+			if _, ok := idx.Embedding(file.Path, provider.Model()); !ok || force {
+				pending++
+			}
+		}
+		fmt.Printf("Dry run completed. Would embed %d of %d notes with %s.\n", pending, len(files), provider.Model())
+		return nil
+	}
+
+	// Interrupting still saves the vectors stored so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	stats, embedErr := embeddings.EmbedFiles(ctx, provider, idx, files, batchSize, force)
+	// Keep whatever was embedded before a failure, so a re-run picks up from there
+	if err := idx.Save(); err != nil {
+		return err
+	}
+	if embedErr != nil {
+		return embedErr
+	}
+
+	if !quiet {
+		fmt.Printf("✓ Embedded %d notes with %s in %s (%d unchanged)\n",
+			stats.Embedded, provider.Model(), time.Since(start).Round(time.Millisecond), stats.Unchanged)
+		if stats.Skipped > 0 {
+			fmt.Printf("⚠ %d notes could not be indexed and have no embedding\n", stats.Skipped)
+		}
+	}
+	return nil
+}
+
+// newEmbeddingsProvider creates the provider chosen by flags, falling back to config
+func newEmbeddingsProvider(cmd *cobra.Command, cfg *config.Config) (embeddings.Provider, error) {
+	opts := embeddings.Options{
+		Provider: cfg.Embeddings.Provider,
+		Model:    cfg.Embeddings.Model,
+		APIURL:   cfg.Embeddings.APIURL,
+	}
+	name, _ := cmd.Flags().GetString("provider")
+	model, _ := cmd.Flags().GetString("model")
+	opts = opts.Override(name, model)
+	if opts.Provider == embeddings.ProviderOpenAI {
+		key, err := cfg.Secret("embeddings.api_key")
+		if err != nil {
+			return nil, err
+		}
+		opts.APIKey = key
+	}
+	return embeddings.NewProvider(opts)
+}
+
+func newClearCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear [vault-path]",
+		Short: "Delete the vault index",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vaultPath := vaultPathArg(args)
+			dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+			quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+			if dryRun {
+				fmt.Printf("Would remove %s\n", vault.NewIndex(vaultPath).Path())
+				return nil
+			}
+			if err := vault.RemoveIndex(vaultPath); err != nil {
+				return err
+			}
+			if !quiet {
+				fmt.Println("✓ Vault index removed")
+			}
+			return nil
+		},
+	}
+}
+
+// runIndex scans the vault through idx, re-parsing changed notes, and saves it
+func runIndex(cmd *cobra.Command, idx *vault.Index, vaultPath string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	start := time.Now()
+	idx.SetLinkParser(processor.NewLinkParser())
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(idx),
+	)
+	if _, err := scanner.Walk(vaultPath); err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	removed := idx.Prune()
+
+	parseErrors := scanner.GetParseErrors()
+	if verbose {
+		for _, parseErr := range parseErrors {
+			fmt.Printf("  ✗ %s: %v\n", parseErr.Path, parseErr.Error)
+		}
+	}
+
+	reused, parsed := idx.Stats()
+	if dryRun {
+		fmt.Printf("Dry run completed. Would index %d notes (%d unchanged, %d parsed, %d removed).\n",
+			idx.Len(), reused, parsed, removed)
+		return nil
+	}
+
+	if err := idx.Save(); err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("✓ Indexed %d notes in %s (%d unchanged, %d parsed, %d removed)\n",
+			idx.Len(), time.Since(start).Round(time.Millisecond), reused, parsed, removed)
+		if len(parseErrors) > 0 {
+			fmt.Printf("⚠ %d notes could not be parsed and are not indexed\n", len(parseErrors))
+		}
+	}
+	return nil
+}
+
+func vaultPathArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
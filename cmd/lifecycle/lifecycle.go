@@ -0,0 +1,237 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewLifecycleCommand creates the lifecycle command
+func NewLifecycleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lifecycle",
+		Short: "Archive and update notes automatically with lifecycle rules",
+		Long: `Apply lifecycle rules that update and move notes when they match a query.
+
+Rules are configured in the YAML configuration file and tried in order; the
+first rule matching a note applies:
+
+lifecycle:
+  rules:
+    - name: archive-done
+      when: "status = 'done' AND modified before '90 days'"
+      set:
+        status: archived
+        archived: "{{current_date}}"
+      move_to: "archive/{{created|date:2006}}"
+
+'when' uses the query syntax of 'frontmatter query'; 'before' and 'after'
+also accept durations like '90 days', meaning that long ago. String values in
+'set' and 'move_to' may use template variables. Moves update links to the
+note like 'rename' does.
+
+Every change is recorded in the change journal with the rule that made it, so
+'mdnotes undo' reverts a whole run. To apply rules as notes change, add a
+watch rule with the action "mdnotes lifecycle run {{file}}".`,
+	}
+
+	cmd.AddCommand(newRunCommand())
+
+	return cmd
+}
+
+func newRunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run [path]",
+		Short: "Apply lifecycle rules to notes",
+		Long:  `Apply lifecycle rules to every note under path (default: current directory), or to a single note`,
+		Example: `  # Preview what the rules would change
+  mdnotes lifecycle run --dry-run ~/vault
+
+  # Apply only one rule
+  mdnotes lifecycle run --rule archive-done ~/vault
+
+  # Apply rules to a single note, e.g. from a watch rule
+  mdnotes lifecycle run ~/vault/projects/launch.md`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runLifecycle,
+	}
+
+	cmd.Flags().StringSlice("rule", nil, "Only apply the named rules (repeatable)")
+
+	return cmd
+}
+
+func runLifecycle(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	ruleNames, _ := cmd.Flags().GetStringSlice("rule")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	rules, err := selectRules(cfg.Lifecycle.Rules, ruleNames)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no lifecycle rules configured. Add rules to the 'lifecycle.rules' section in your config file")
+	}
+
+	root := safety.FindVaultRoot(path)
+	lp, err := processor.NewLifecycleProcessor(rules, root)
+	if err != nil {
+		return err
+	}
+
+	files, err := loadNotes(path, root, cfg.Vault.IgnorePatterns)
+	if err != nil {
+		return err
+	}
+	actions := lp.Plan(files)
+
+	tx := cli.BeginTransaction(cmd, root)
+	options := processor.RenameOptions{
+		IgnorePatterns: cfg.Vault.IgnorePatterns,
+		Verbose:        verbose,
+		Transaction:    tx,
+	}
+
+	applied := 0
+	var failures []error
+	for _, action := range actions {
+		description := describeAction(action, root)
+		if dryRun {
+			fmt.Printf("Would apply %s to %s: %s\n", action.Rule, action.File.RelativePath, description)
+			continue
+		}
+		if err := lp.Apply(context.Background(), action, options); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", action.File.RelativePath, err))
+			fmt.Printf("✗ %s: %v\n", action.File.RelativePath, err)
+			continue
+		}
+		applied++
+		if !quiet {
+			fmt.Printf("✓ %s: %s (%s)\n", action.File.RelativePath, description, action.Rule)
+		}
+	}
+	cli.CommitTransaction(cmd, tx)
+
+	if dryRun {
+		fmt.Printf("\nDry run completed. Would apply lifecycle rules to %d of %d notes.\n", len(actions), len(files))
+	} else if !quiet {
+		fmt.Printf("\nApplied lifecycle rules to %d of %d notes.\n", applied, len(files))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d lifecycle actions failed", len(failures))
+	}
+	return nil
+}
+
+// selectRules returns the rules named, in configuration order, or all of them
+func selectRules(rules []config.LifecycleRule, names []string) ([]config.LifecycleRule, error) {
+	if len(names) == 0 {
+		return rules, nil
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var selected []config.LifecycleRule
+	for _, rule := range rules {
+		if wanted[rule.Name] {
+			selected = append(selected, rule)
+			delete(wanted, rule.Name)
+		}
+	}
+	if len(wanted) > 0 {
+		var unknown []string
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown lifecycle rule: %s", strings.Join(unknown, ", "))
+	}
+	return selected, nil
+}
+
+// loadNotes loads the note at path, or every note under it, with relative
+// paths from the vault root
+func loadNotes(path, root string, ignorePatterns []string) ([]*vault.VaultFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("accessing path: %w", err)
+	}
+
+	var files []*vault.VaultFile
+	if info.IsDir() {
+		scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
+		if files, err = scanner.Walk(path); err != nil {
+			return nil, fmt.Errorf("scanning vault: %w", err)
+		}
+	} else {
+		file, err := vault.LoadVaultFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = []*vault.VaultFile{file}
+	}
+
+	for _, file := range files {
+		if abs, err := filepath.Abs(file.Path); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				file.RelativePath = filepath.ToSlash(rel)
+			}
+		}
+	}
+	return files, nil
+}
+
+// describeAction summarizes the fields an action sets and where it moves the note
+func describeAction(action processor.LifecycleAction, root string) string {
+	fields := make([]string, 0, len(action.Set))
+	for field := range action.Set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var parts []string
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("set %s=%v", field, action.Set[field]))
+	}
+	if action.Target != "" {
+		target := action.Target
+		if rel, err := filepath.Rel(root, target); err == nil {
+			target = filepath.ToSlash(rel)
+		}
+		parts = append(parts, "move to "+target)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -4,12 +4,19 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
+	"github.com/eoinhurrell/mdnotes/internal/cli"
 	"github.com/eoinhurrell/mdnotes/internal/config"
 	"github.com/eoinhurrell/mdnotes/internal/linkding"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/selector"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
@@ -39,6 +46,14 @@ func newSyncCommand() *cobra.Command {
 		syncTitle        bool
 		syncTags         bool
 		skipVerification bool
+		direction        string
+		prefer           string
+		noteDir          string
+		concurrency      int
+		rateLimit        int
+		burst            int
+		batchSize        int
+		resume           bool
 	)
 
 	cmd := &cobra.Command{
@@ -56,7 +71,34 @@ Configuration:
     api_url: "${LINKDING_URL}"
     api_token: "${LINKDING_TOKEN}"
     sync_title: true
-    sync_tags: true`,
+    sync_tags: true
+    note_dir: "Literature"
+
+Two-way sync:
+  With --direction pull or both, bookmarks are also pulled into notes. Notes
+  are matched to bookmarks by linkding_id, then by URL, and their url, title,
+  description and tags are kept equal. Changes made on one side since the last
+  sync are copied to the other; when a note and its bookmark both changed,
+  --prefer decides which wins (local, remote, or newest by modification time).
+  Bookmarks without a note are imported as new notes in --note-dir. The state
+  of the last sync is kept in .mdnotes/linkding-state.json in the vault.
+
+Large vaults:
+  Requests are rate limited with a token bucket: --rate requests per second,
+  with bursts of up to --burst requests. --concurrency syncs several notes at
+  once within that limit. Pushed notes are written in batches of --batch-size,
+  and progress is saved to .mdnotes/linkding-cursor.json after each batch, so
+  an interrupted sync continues where it stopped with --resume. Notes that
+  failed are retried by the next --resume.`,
+		Example: `  # Push new notes with URLs to linkding
+  mdnotes linkding sync ~/vault
+
+  # Sync both ways, letting bookmarks win conflicts
+  mdnotes linkding sync ~/vault --direction both --prefer remote --note-dir Literature
+
+  # Push thousands of notes gently, and pick up after an interruption
+  mdnotes linkding sync ~/vault --concurrency 4 --rate 10 --burst 4
+  mdnotes linkding sync ~/vault --concurrency 4 --rate 10 --burst 4 --resume`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			vaultPath := "."
@@ -74,6 +116,13 @@ Configuration:
 				verbose = false
 			}
 
+			if !slices.Contains(processor.LinkdingDirections, direction) {
+				return fmt.Errorf("invalid direction '%s' - valid options are: %s", direction, strings.Join(processor.LinkdingDirections, ", "))
+			}
+			if !slices.Contains(processor.LinkdingPolicies, prefer) {
+				return fmt.Errorf("invalid prefer '%s' - valid options are: %s", prefer, strings.Join(processor.LinkdingPolicies, ", "))
+			}
+
 			// Load configuration
 			cfg, err := loadConfig(cmd)
 			if err != nil {
@@ -84,12 +133,43 @@ Configuration:
 			if cfg.Linkding.APIURL == "" {
 				return fmt.Errorf("linkding.api_url not configured")
 			}
-			if cfg.Linkding.APIToken == "" {
+			token, err := cfg.Secret("linkding.api_token")
+			if err != nil {
+				return err
+			}
+			if token == "" {
 				return fmt.Errorf("linkding.api_token not configured")
 			}
 
+			// Flags override the configured request limits
+			if !cmd.Flags().Changed("concurrency") && cfg.Linkding.Concurrency > 0 {
+				concurrency = cfg.Linkding.Concurrency
+			}
+			if !cmd.Flags().Changed("rate") {
+				rateLimit = cfg.Linkding.RateLimit
+			}
+			if !cmd.Flags().Changed("burst") {
+				burst = cfg.Linkding.Burst
+			}
+			if concurrency < 1 {
+				return fmt.Errorf("invalid concurrency %d - must be at least 1", concurrency)
+			}
+			if batchSize < 1 {
+				return fmt.Errorf("invalid batch size %d - must be at least 1", batchSize)
+			}
+			if rateLimit < 0 || burst < 0 {
+				return fmt.Errorf("invalid rate limit - --rate and --burst must not be negative")
+			}
+
 			// Create Linkding client
-			client := linkding.NewClient(cfg.Linkding.APIURL, cfg.Linkding.APIToken)
+			var clientOpts []linkding.ClientOption
+			if rateLimit > 0 {
+				clientOpts = append(clientOpts, linkding.WithRateLimit(rateLimit))
+			}
+			if burst > 0 {
+				clientOpts = append(clientOpts, linkding.WithBurst(burst))
+			}
+			client := linkding.NewClient(cfg.Linkding.APIURL, token, clientOpts...)
 
 			// Get file selection configuration from global flags
 			mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
@@ -120,6 +200,24 @@ Configuration:
 
 			files := selection.Files
 
+			// Pulling reconciles both sides rather than only adding bookmarks
+			if direction != processor.LinkdingPush {
+				if noteDir == "" {
+					noteDir = cfg.Linkding.NoteDir
+				}
+				syncConfig := processor.LinkdingSyncConfig{
+					URLField:   urlField,
+					TitleField: titleField,
+					TagsField:  tagsField,
+					DryRun:     dryRun,
+					Direction:  direction,
+					Prefer:     prefer,
+					NoteDir:    noteDir,
+					VaultRoot:  vaultPath,
+				}
+				return runTwoWaySync(cmd, vaultPath, files, syncConfig, client)
+			}
+
 			// Create sync configuration
 			syncConfig := processor.LinkdingSyncConfig{
 				URLField:         urlField,
@@ -130,6 +228,8 @@ Configuration:
 				SyncTags:         syncTags || cfg.Linkding.SyncTags,
 				DryRun:           dryRun,
 				SkipVerification: skipVerification,
+				Concurrency:      concurrency,
+				BatchSize:        batchSize,
 			}
 
 			// Add progress callback for verbose mode
@@ -154,14 +254,43 @@ Configuration:
 			syncProcessor := processor.NewLinkdingSync(syncConfig)
 			syncProcessor.SetClient(client)
 
+			// Locked files are never synced since syncing writes the bookmark ID back
+			var unlockedFiles []*vault.VaultFile
+			for _, file := range files {
+				if reason := file.LockReason(); reason != "" {
+					if verbose {
+						fmt.Printf("Examining: %s - Skipped (locked: %s)\n", file.RelativePath, reason)
+					}
+					continue
+				}
+				unlockedFiles = append(unlockedFiles, file)
+			}
+
+			// Notes are synced in path order so progress can be saved as a cursor
+			cursorPath := filepath.Join(safety.FindVaultRoot(vaultPath), processor.DefaultLinkdingCursorFile)
+			cursor := &processor.LinkdingCursor{}
+			if resume {
+				cursor, err = processor.LoadLinkdingCursor(cursorPath)
+				if err != nil {
+					return err
+				}
+			}
+
 			// Find files to sync (all files with URLs)
-			syncableFiles := syncProcessor.FindAllSyncableFiles(files)
+			syncableFiles := cursor.Remaining(syncProcessor.FindAllSyncableFiles(unlockedFiles))
 			if len(syncableFiles) == 0 {
 				if !quiet {
-					fmt.Println("No files with URLs found.")
+					if cursor.After != "" {
+						fmt.Println("No files left to sync.")
+					} else {
+						fmt.Println("No files with URLs found.")
+					}
 				}
 				return nil
 			}
+			if cursor.After != "" && !quiet {
+				fmt.Printf("Resuming after %s (%d files left)\n", cursor.After, len(syncableFiles))
+			}
 
 			if verbose {
 				fmt.Printf("Found %d files with URLs to process:\n", len(syncableFiles))
@@ -205,11 +334,37 @@ Configuration:
 				return nil
 			}
 
-			// Perform sync
-			ctx := context.Background()
-			results, err := syncProcessor.SyncBatch(ctx, syncableFiles)
-			if err != nil {
-				return fmt.Errorf("syncing files: %w", err)
+			// Save the bookmark IDs and the cursor after each batch, so an
+			// interrupted sync loses at most the batch in progress
+			tx := cli.BeginTransaction(cmd, vaultPath)
+			defer cli.CommitTransaction(cmd, tx)
+			syncProcessor.SetBatchCallback(func(batch []processor.SyncResult) error {
+				for _, result := range batch {
+					if result.Action != "created" && result.Action != "updated" {
+						continue
+					}
+					content, err := result.File.Serialize()
+					if err != nil {
+						fmt.Printf("Warning: Failed to serialize %s: %v\n", result.File.RelativePath, err)
+						continue
+					}
+					if err := tx.RecordWrite(result.File.Path); err != nil {
+						return fmt.Errorf("recording change to %s: %w", result.File.RelativePath, err)
+					}
+					if err := safety.WriteFile(result.File.Path, content, 0644); err != nil {
+						fmt.Printf("Warning: Failed to save %s: %v\n", result.File.RelativePath, err)
+					}
+				}
+				cursor.Advance(batch)
+				return cursor.Save(cursorPath)
+			})
+
+			// Perform sync, stopping cleanly on interrupt
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			results, syncErr := syncProcessor.SyncBatch(ctx, syncableFiles)
+			if syncErr != nil && ctx.Err() == nil {
+				return fmt.Errorf("syncing files: %w", syncErr)
 			}
 
 			// Report results summary
@@ -241,20 +396,17 @@ Configuration:
 				fmt.Printf("\nSync completed: %d created, %d verified, %d updated, %d skipped, %d errors\n", created, verified, updated, skipped, errors)
 			}
 
-			// Save files with updated Linkding IDs
-			if created > 0 || updated > 0 {
-				for _, result := range results {
-					if result.Action == "created" || result.Action == "updated" {
-						content, err := result.File.Serialize()
-						if err != nil {
-							fmt.Printf("Warning: Failed to serialize %s: %v\n", result.File.RelativePath, err)
-							continue
-						}
-						if err := os.WriteFile(result.File.Path, content, 0644); err != nil {
-							fmt.Printf("Warning: Failed to save %s: %v\n", result.File.RelativePath, err)
-						}
-					}
+			if syncErr != nil {
+				return fmt.Errorf("sync interrupted after %d of %d files - run again with --resume to continue", len(results), len(syncableFiles))
+			}
+			if len(cursor.Retry) > 0 {
+				if !quiet {
+					fmt.Printf("%d files failed - run again with --resume to retry them\n", len(cursor.Retry))
 				}
+				return nil
+			}
+			if err := os.Remove(cursorPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing linkding cursor: %w", err)
 			}
 
 			return nil
@@ -267,10 +419,120 @@ Configuration:
 	cmd.Flags().BoolVar(&syncTitle, "sync-title", false, "Sync title to Linkding")
 	cmd.Flags().BoolVar(&syncTags, "sync-tags", false, "Sync tags to Linkding")
 	cmd.Flags().BoolVar(&skipVerification, "skip-verification", false, "Only sync new items, skip verification of existing bookmarks")
+	cmd.Flags().StringVar(&direction, "direction", processor.LinkdingPush, "Sync direction: push, pull or both")
+	cmd.Flags().StringVar(&prefer, "prefer", processor.PreferNewest, "Side that wins when a note and its bookmark both changed: local, remote or newest")
+	cmd.Flags().StringVar(&noteDir, "note-dir", "", "Folder for notes pulled from linkding (default: linkding.note_dir, or the vault root)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Notes to sync in parallel (overrides linkding.concurrency)")
+	cmd.Flags().IntVar(&rateLimit, "rate", 0, "Maximum requests per second (default: linkding.rate_limit, or 5)")
+	cmd.Flags().IntVar(&burst, "burst", 0, "Requests allowed at once before --rate applies (default: linkding.burst, or 2)")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 50, "Notes written and checkpointed per batch")
+	cmd.Flags().BoolVar(&resume, "resume", false, "Continue an interrupted sync from its saved cursor")
 
 	return cmd
 }
 
+// runTwoWaySync reconciles notes and bookmarks, writing the changed and
+// imported notes through the journal and saving the sync state
+func runTwoWaySync(cmd *cobra.Command, vaultPath string, files []*vault.VaultFile, syncConfig processor.LinkdingSyncConfig, client processor.LinkdingClient) error {
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+	if quiet {
+		verbose = false
+	}
+
+	statePath := filepath.Join(safety.FindVaultRoot(vaultPath), processor.DefaultLinkdingStateFile)
+	state, err := processor.LoadLinkdingState(statePath)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		syncConfig.ProgressCallback = func(result processor.SyncResult) {
+			conflict := ""
+			if result.Conflict {
+				conflict = " (conflict)"
+			}
+			switch result.Action {
+			case "created", "linked", "pushed", "pulled", "imported":
+				fmt.Printf("✓ %s: %s bookmark ID %d%s\n", result.File.RelativePath, strings.ToUpper(result.Action[:1])+result.Action[1:], result.BookmarkID, conflict)
+			case "skipped":
+				fmt.Printf("- %s: Skipped%s\n", result.File.RelativePath, conflict)
+			case "error":
+				fmt.Printf("✗ %s: Error - %v\n", result.File.RelativePath, result.Error)
+			}
+		}
+	}
+
+	syncProcessor := processor.NewLinkdingSync(syncConfig)
+	syncProcessor.SetClient(client)
+	results, err := syncProcessor.Reconcile(context.Background(), files, state)
+	if err != nil {
+		return fmt.Errorf("syncing with linkding: %w", err)
+	}
+
+	counts := make(map[string]int)
+	conflicts := 0
+	for _, result := range results {
+		counts[result.Action]++
+		if result.Conflict {
+			conflicts++
+		}
+		// Errors are always shown, even in non-verbose mode
+		if result.Action == "error" && !verbose {
+			fmt.Printf("✗ %s: Error - %v\n", result.File.RelativePath, result.Error)
+		}
+	}
+
+	if !syncConfig.DryRun {
+		if err := writeSyncedNotes(cmd, vaultPath, results); err != nil {
+			return err
+		}
+		if err := state.Save(statePath); err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		summary := "Sync completed"
+		if syncConfig.DryRun {
+			summary = "Dry run completed"
+		}
+		fmt.Printf("\n%s: %d created, %d linked, %d pushed, %d pulled, %d imported, %d unchanged, %d skipped, %d errors (%d conflicts)\n",
+			summary, counts["created"], counts["linked"], counts["pushed"], counts["pulled"], counts["imported"],
+			counts["unchanged"], counts["skipped"], counts["error"], conflicts)
+	}
+	return nil
+}
+
+// writeSyncedNotes saves the notes a two-way sync changed or imported,
+// recording them in the journal
+func writeSyncedNotes(cmd *cobra.Command, vaultPath string, results []processor.SyncResult) error {
+	tx := cli.BeginTransaction(cmd, vaultPath)
+	defer cli.CommitTransaction(cmd, tx)
+
+	for _, result := range results {
+		switch result.Action {
+		case "created", "linked", "pulled", "imported":
+		default:
+			continue
+		}
+		content, err := result.File.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", result.File.RelativePath, err)
+		}
+		if err := tx.RecordWrite(result.File.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", result.File.RelativePath, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(result.File.Path), 0755); err != nil {
+			return fmt.Errorf("creating folder for %s: %w", result.File.RelativePath, err)
+		}
+		if err := safety.WriteFile(result.File.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", result.File.RelativePath, err)
+		}
+	}
+	return nil
+}
+
 func newListCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "list [vault-path]",
@@ -474,7 +736,11 @@ Configuration:
 			if cfg.Linkding.APIURL == "" {
 				return fmt.Errorf("linkding.api_url not configured")
 			}
-			if cfg.Linkding.APIToken == "" {
+			token, err := cfg.Secret("linkding.api_token")
+			if err != nil {
+				return err
+			}
+			if token == "" {
 				return fmt.Errorf("linkding.api_token not configured")
 			}
 
@@ -492,7 +758,7 @@ Configuration:
 			}
 
 			// Create Linkding client
-			client := linkding.NewClient(cfg.Linkding.APIURL, cfg.Linkding.APIToken)
+			client := linkding.NewClient(cfg.Linkding.APIURL, token)
 
 			// Create get processor
 			getProcessor := processor.NewLinkdingGet(processor.LinkdingGetConfig{
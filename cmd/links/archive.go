@@ -0,0 +1,267 @@
+package links
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/archive"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/downloader"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Frontmatter fields the archive command writes
+const (
+	archiveURLField = "archive_url"
+	archivedAtField = "archived_at"
+)
+
+// NewArchiveCommand creates the links archive command
+func NewArchiveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "archive [path]",
+		Short: "Snapshot the pages notes link to with the Wayback Machine or ArchiveBox",
+		Long: `Take a snapshot of the page in each note's url field, with the Internet
+Archive's Wayback Machine or a self-hosted ArchiveBox, and store where it is
+and when it was taken as archive_url and archived_at in the frontmatter.
+
+Notes that already have an archive_url are skipped unless --force is given.
+A page archived before is not captured again; its latest snapshot is used,
+unless --fresh asks for a new one. A URL several notes share is archived
+once. Requests are spaced out to stay within the provider's rate limit,
+which can be changed with --rate.
+
+Configuration:
+  archive:
+    provider: archivebox            # wayback (default) or archivebox
+    api_url: http://localhost:8000
+    api_token: "${ARCHIVEBOX_API_KEY}"
+    rate_limit: 30                  # requests a minute
+
+Examples:
+  # Archive the source of every clipping
+  mdnotes links archive --where "tags has 'clipping'" /path/to/vault
+
+  # Use the link field instead, with a new snapshot each time
+  mdnotes links archive --field link --fresh /path/to/vault
+
+  # Archive to a local ArchiveBox
+  mdnotes links archive --provider archivebox /path/to/vault`,
+		Args: cobra.ExactArgs(1),
+		RunE: runArchive,
+	}
+
+	cmd.Flags().String("field", "url", "Frontmatter field holding the URL to archive")
+	cmd.Flags().String("where", "", "Only archive notes matching this query expression")
+	cmd.Flags().String("provider", "", "Archive to use: wayback or archivebox (default: archive.provider, or wayback)")
+	cmd.Flags().Int("rate", 0, "Requests a minute (default: archive.rate_limit, or the provider's limit)")
+	cmd.Flags().Bool("fresh", false, "Take a new snapshot even when the page was archived before")
+	cmd.Flags().Bool("force", false, "Archive notes that already have an archive_url")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runArchive(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	field, _ := cmd.Flags().GetString("field")
+	whereExpr, _ := cmd.Flags().GetString("where")
+	providerName, _ := cmd.Flags().GetString("provider")
+	rateLimit, _ := cmd.Flags().GetInt("rate")
+	fresh, _ := cmd.Flags().GetBool("fresh")
+	force, _ := cmd.Flags().GetBool("force")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	opts := archive.Options{
+		Provider:  cfg.Archive.Provider,
+		APIURL:    cfg.Archive.APIURL,
+		APIToken:  cfg.Archive.APIToken,
+		RateLimit: cfg.Archive.RateLimit,
+	}
+	// The configured server belongs to the configured provider
+	if providerName != "" && providerName != opts.Provider {
+		opts = archive.Options{Provider: providerName}
+	}
+	if rateLimit > 0 {
+		opts.RateLimit = rateLimit
+	}
+	if opts.APIToken != "" {
+		if opts.APIToken, err = cfg.Secret("archive.api_token"); err != nil {
+			return err
+		}
+	}
+	provider, err := archive.NewProvider(opts)
+	if err != nil {
+		return err
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(ignorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(path, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	files := selection.Files
+	if whereExpr != "" {
+		expr, err := query.NewParser(whereExpr).Parse()
+		if err != nil {
+			return fmt.Errorf("parsing --where expression: %w", err)
+		}
+		query.Bind(expr, files, processor.NewLinkParser())
+
+		var matched []*vault.VaultFile
+		for _, file := range files {
+			if expr.Evaluate(file) {
+				matched = append(matched, file)
+			}
+		}
+		files = matched
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].RelativePath < files[j].RelativePath
+	})
+
+	tx := cli.BeginTransaction(cmd, path)
+	defer cli.CommitTransaction(cmd, tx)
+
+	// Notes sharing a URL share its snapshot
+	snapshots := make(map[string]*archive.Snapshot)
+	archived, captured, skipped, failed := 0, 0, 0, 0
+
+	for _, file := range files {
+		value, _ := file.GetField(field)
+		pageURL, ok := value.(string)
+		if !ok || !downloader.IsValidURL(pageURL) {
+			continue
+		}
+		if reason := file.LockReason(); reason != "" {
+			skipped++
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: locked (%s)\n", file.RelativePath, reason)
+			}
+			continue
+		}
+		if existing, ok := file.GetField(archiveURLField); ok && existing != nil && existing != "" && !force {
+			skipped++
+			if verbose {
+				fmt.Printf("- %s: already archived\n", file.RelativePath)
+			}
+			continue
+		}
+
+		if dryRun {
+			archived++
+			fmt.Printf("Would archive %s: %s\n", file.RelativePath, pageURL)
+			continue
+		}
+
+		snapshot, ok := snapshots[pageURL]
+		if !ok {
+			var isNew bool
+			snapshot, isNew, err = takeSnapshot(cmd, provider, pageURL, fresh)
+			if err != nil {
+				failed++
+				fmt.Printf("✗ %s: %v\n", file.RelativePath, err)
+				continue
+			}
+			snapshots[pageURL] = snapshot
+			if isNew {
+				captured++
+			}
+		}
+
+		file.SetField(archiveURLField, snapshot.URL)
+		file.SetField(archivedAtField, snapshot.Archived.UTC().Format(time.RFC3339))
+		if err := writeArchivedFile(tx, file); err != nil {
+			return err
+		}
+		archived++
+		if !quiet {
+			fmt.Printf("✓ Archived %s: %s\n", file.RelativePath, snapshot.URL)
+		}
+	}
+
+	if !quiet {
+		if dryRun {
+			fmt.Printf("\nDry run completed: would archive %d notes with %s, %d skipped\n", archived, provider.Name(), skipped)
+		} else {
+			fmt.Printf("\nArchived %d notes with %s (%d new snapshots), %d skipped, %d failed\n",
+				archived, provider.Name(), captured, skipped, failed)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d notes could not be archived", failed)
+	}
+	return nil
+}
+
+// takeSnapshot returns the latest snapshot of a page, taking one when there
+// is none or fresh is set, and whether it is new
+func takeSnapshot(cmd *cobra.Command, provider archive.Provider, pageURL string, fresh bool) (*archive.Snapshot, bool, error) {
+	if !fresh {
+		snapshot, err := provider.Lookup(cmd.Context(), pageURL)
+		if err != nil {
+			return nil, false, err
+		}
+		if snapshot != nil {
+			return snapshot, false, nil
+		}
+	}
+	snapshot, err := provider.Save(cmd.Context(), pageURL)
+	return snapshot, err == nil, err
+}
+
+// writeArchivedFile saves a note as soon as it is archived, so an
+// interrupted run keeps what it did
+func writeArchivedFile(tx *safety.Transaction, file *vault.VaultFile) error {
+	content, err := file.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+	}
+	if err := tx.RecordWrite(file.Path); err != nil {
+		return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+	}
+	if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+	}
+	return nil
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+
+	if configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
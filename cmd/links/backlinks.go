@@ -0,0 +1,125 @@
+package links
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewBacklinksCommand creates the links backlinks command
+func NewBacklinksCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backlinks [path]",
+		Short: "Write each note's backlinks into the note",
+		Long: `Write the notes linking to each note into it, as a "## Backlinks" section
+at the end of the note or as a frontmatter list, for publishing where
+dynamic backlinks aren't available.
+
+Backlinks are computed from every note in the vault, even when only some
+notes are updated. Running the command again updates the section or list in
+place: links inside backlinks sections are ignored, notes that lose their
+last backlink lose the section, and unchanged notes are not rewritten. Use
+--remove to take backlinks out again.
+
+Examples:
+  # Add or update backlinks sections
+  mdnotes links backlinks /path/to/vault
+
+  # Use a frontmatter list instead
+  mdnotes links backlinks --frontmatter --field backlinks /path/to/vault
+
+  # Remove the sections
+  mdnotes links backlinks --remove /path/to/vault`,
+		Args: cobra.ExactArgs(1),
+		RunE: runBacklinks,
+	}
+
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+	cmd.Flags().String("heading", processor.DefaultBacklinksHeading, "Heading of the backlinks section")
+	cmd.Flags().Bool("frontmatter", false, "Write backlinks to a frontmatter list instead of a section")
+	cmd.Flags().String("field", processor.DefaultBacklinksField, "Frontmatter field for --frontmatter")
+	cmd.Flags().String("link-format", "wiki", "Format of the backlinks (wiki, markdown)")
+	cmd.Flags().Bool("remove", false, "Remove backlinks sections, or the field with --frontmatter")
+
+	return cmd
+}
+
+func runBacklinks(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	heading, _ := cmd.Flags().GetString("heading")
+	frontmatter, _ := cmd.Flags().GetBool("frontmatter")
+	field, _ := cmd.Flags().GetString("field")
+	linkFormat, _ := cmd.Flags().GetString("link-format")
+	remove, _ := cmd.Flags().GetBool("remove")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+
+	backlinks, err := processor.NewBacklinksProcessor(processor.BacklinksOptions{
+		Heading:     heading,
+		Field:       field,
+		Frontmatter: frontmatter,
+		LinkFormat:  linkFormat,
+		Remove:      remove,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Inbound links may come from anywhere in the vault
+	if !remove {
+		scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
+		allFiles, err := scanner.Walk(safety.FindVaultRoot(path))
+		if err != nil {
+			return fmt.Errorf("scanning vault: %w", err)
+		}
+		backlinks.Prepare(allFiles)
+	}
+
+	fileProcessor := &processor.FileProcessor{
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
+		IgnorePatterns: ignorePatterns,
+		ProcessFile: func(file *vault.VaultFile) (bool, error) {
+			modified, err := backlinks.UpdateFile(file)
+			if err != nil {
+				return false, fmt.Errorf("updating backlinks in %s: %w", file.RelativePath, err)
+			}
+			if verbose {
+				if modified {
+					fmt.Printf("Examining: %s - Updated backlinks\n", file.RelativePath)
+				} else {
+					fmt.Printf("Examining: %s - Backlinks up to date\n", file.RelativePath)
+				}
+			}
+			return modified, nil
+		},
+		OnFileProcessed: func(file *vault.VaultFile, modified bool) {
+			if modified && !verbose && !quiet {
+				fmt.Printf("✓ Processed: %s\n", file.RelativePath)
+			}
+		},
+	}
+
+	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
+	if err != nil {
+		return err
+	}
+
+	fileProcessor.PrintSummary(result)
+	return nil
+}
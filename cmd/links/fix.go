@@ -0,0 +1,310 @@
+package links
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewFixCommand creates the links fix command
+func NewFixCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fix [path]",
+		Short: "Suggest and apply repairs for broken internal links",
+		Long: `Suggest repairs for the broken internal links 'links check' reports, and
+optionally apply them.
+
+Suggestions come from:
+  - notes moved by earlier mdnotes commands, recorded in the change journal
+  - notes listing the link's target among their frontmatter aliases
+  - notes with similar names, ignoring case, -, _ and timestamp ID prefixes
+
+Each suggestion has a score from 0 to 1. Without --auto or --interactive,
+suggestions are only listed. With --auto, the best suggestion is applied when
+it scores at least the threshold and is better than the runner-up. With
+--interactive, you choose a suggestion for each broken link, after --auto has
+applied the confident ones. Applied fixes can be reverted with 'mdnotes undo'.
+
+Examples:
+  # List suggested repairs
+  mdnotes links fix /path/to/vault
+
+  # Apply confident repairs, previewing first
+  mdnotes links fix --auto 0.9 --dry-run /path/to/vault
+  mdnotes links fix --auto 0.9 /path/to/vault
+
+  # Choose repairs one by one
+  mdnotes links fix --interactive /path/to/vault`,
+		Args: cobra.ExactArgs(1),
+		RunE: runFix,
+	}
+
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+	cmd.Flags().Bool("file-relative", false, "Check markdown links relative to each file's directory instead of vault root")
+	cmd.Flags().Float64("auto", 0, "Apply the best suggestion when it scores at least this (0-1; 0 disables)")
+	cmd.Flags().BoolP("interactive", "i", false, "Confirm a repair for each broken link")
+	cmd.Flags().Float64("min-score", processor.DefaultRepairMinScore, "Lowest score of suggestions from similar names (0-1)")
+	cmd.Flags().Int("suggestions", 3, "Maximum suggestions per broken link")
+
+	return cmd
+}
+
+// linkRepair is a chosen replacement of a broken link
+type linkRepair struct {
+	link        vault.Link
+	replacement string
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	fileRelative, _ := cmd.Flags().GetBool("file-relative")
+	auto, _ := cmd.Flags().GetFloat64("auto")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	minScore, _ := cmd.Flags().GetFloat64("min-score")
+	limit, _ := cmd.Flags().GetInt("suggestions")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if quiet {
+		verbose = false
+	}
+	if auto < 0 || auto > 1 {
+		return fmt.Errorf("--auto must be between 0 and 1, got %g", auto)
+	}
+	if minScore < 0 || minScore > 1 {
+		return fmt.Errorf("--min-score must be between 0 and 1, got %g", minScore)
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	fileSelector = fileSelector.WithIgnorePatterns(append(fileSelector.IgnorePatterns, ignorePatterns...))
+
+	selection, err := fileSelector.SelectFiles(path, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	files := selection.Files
+	if len(files) == 0 {
+		if !quiet {
+			fmt.Println("No markdown files found")
+		}
+		return nil
+	}
+
+	vaultRoot, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("getting absolute path for vault: %w", err)
+	}
+	existingFiles, baseNameFiles := buildLinkIndex(files)
+
+	repairer := processor.NewLinkRepairer(files)
+	repairer.MinScore = minScore
+	if err := addJournalRenames(repairer, vaultRoot); err != nil && verbose {
+		fmt.Printf("⚠ Could not read renames from the change journal: %v\n", err)
+	}
+
+	linkParser := processor.NewLinkParser()
+	input := bufio.NewReader(cmd.InOrStdin())
+	prompting := interactive
+
+	brokenLinks, fixedLinks := 0, 0
+	repairs := make(map[*vault.VaultFile][]linkRepair)
+
+	for _, file := range files {
+		// Leave links in locked notes untouched
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipped %s (locked: %s)\n", file.RelativePath, reason)
+			}
+			continue
+		}
+
+		// Positions must match the body that is rewritten
+		linkParser.UpdateFile(file)
+
+		for _, link := range file.Links {
+			targetToCheck := resolveTargetPath(link, file, vaultRoot, fileRelative)
+			if checkLinkExists(targetToCheck, existingFiles, baseNameFiles, link.Type) {
+				continue
+			}
+			brokenLinks++
+
+			suggestions := repairer.Suggest(link, limit)
+			if !quiet {
+				fmt.Printf("✗ %s: broken link %s\n", file.RelativePath, formatLinkForDisplay(link))
+				if len(suggestions) == 0 {
+					fmt.Println("    No suggestions")
+				}
+				for i, suggestion := range suggestions {
+					fmt.Printf("    %d. %s (%.0f%%, %s)\n", i+1, suggestion.Target, suggestion.Score*100, suggestion.Reason)
+				}
+			}
+			if len(suggestions) == 0 {
+				continue
+			}
+
+			var chosen *processor.RepairSuggestion
+			if auto > 0 && suggestions[0].Score >= auto && (len(suggestions) == 1 || suggestions[0].Score > suggestions[1].Score) {
+				chosen = &suggestions[0]
+			} else if prompting {
+				choice, quit := promptRepair(input, len(suggestions))
+				if quit {
+					prompting = false
+				} else if choice >= 0 {
+					chosen = &suggestions[choice]
+				}
+			}
+			if chosen == nil {
+				continue
+			}
+
+			replacement := repairer.RepairedLink(link, *chosen)
+			repairs[file] = append(repairs[file], linkRepair{link: link, replacement: replacement})
+			fixedLinks++
+			if !quiet {
+				verb := "Fixed"
+				if dryRun {
+					verb = "Would fix"
+				}
+				fmt.Printf("  ✓ %s: %s → %s\n", verb, link.RawText, replacement)
+			}
+		}
+	}
+
+	if !dryRun && len(repairs) > 0 {
+		if err := applyRepairs(cmd, vaultRoot, files, repairs); err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		switch {
+		case brokenLinks == 0:
+			fmt.Println("\nNo broken links found")
+		case dryRun:
+			fmt.Printf("\nFix completed: would fix %d of %d broken links\n", fixedLinks, brokenLinks)
+		default:
+			fmt.Printf("\nFix completed: fixed %d of %d broken links\n", fixedLinks, brokenLinks)
+		}
+		if brokenLinks > 0 && auto == 0 && !interactive {
+			fmt.Println("Run with --auto <score> or --interactive to apply repairs")
+		}
+	}
+	return nil
+}
+
+// addJournalRenames adds the moves recorded in the vault's change journal,
+// oldest first, skipping undone transactions. Journal paths are relative to
+// the vault root, which may be above the checked path.
+func addJournalRenames(repairer *processor.LinkRepairer, checkedPath string) error {
+	root := safety.FindVaultRoot(checkedPath)
+	transactions, err := safety.NewJournal(root).List()
+	if err != nil {
+		return err
+	}
+	for i := len(transactions) - 1; i >= 0; i-- {
+		tx := transactions[i]
+		if tx.UndoneAt != nil {
+			continue
+		}
+		for _, entry := range tx.Entries {
+			if entry.Op != safety.OpMove {
+				continue
+			}
+			from, errFrom := filepath.Rel(checkedPath, filepath.Join(root, entry.From))
+			to, errTo := filepath.Rel(checkedPath, filepath.Join(root, entry.Path))
+			if errFrom != nil || errTo != nil {
+				continue
+			}
+			repairer.AddRename(from, to)
+		}
+	}
+	return nil
+}
+
+// promptRepair asks which suggestion to apply, returning its index, -1 to
+// skip the link, or quit to stop asking
+func promptRepair(input *bufio.Reader, count int) (choice int, quit bool) {
+	choices := "1"
+	if count > 1 {
+		choices = fmt.Sprintf("1-%d", count)
+	}
+	for {
+		fmt.Printf("    Replace with [%s], [s]kip or [q]uit: ", choices)
+		answer, err := input.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if err != nil && answer == "" {
+			if err == io.EOF {
+				fmt.Println()
+			}
+			return -1, true
+		}
+
+		switch answer {
+		case "s", "skip", "":
+			return -1, false
+		case "q", "quit":
+			return -1, true
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= count {
+			return n - 1, false
+		}
+	}
+}
+
+// applyRepairs rewrites the repaired links in each file, recording the
+// changes in the journal
+func applyRepairs(cmd *cobra.Command, vaultRoot string, files []*vault.VaultFile, repairs map[*vault.VaultFile][]linkRepair) error {
+	tx := cli.BeginTransaction(cmd, vaultRoot)
+	defer cli.CommitTransaction(cmd, tx)
+
+	for _, file := range files {
+		fileRepairs := repairs[file]
+		if len(fileRepairs) == 0 {
+			continue
+		}
+
+		// Replace from the end so earlier positions stay valid
+		sort.Slice(fileRepairs, func(i, j int) bool {
+			return fileRepairs[i].link.Position.Start > fileRepairs[j].link.Position.Start
+		})
+		body := file.Body
+		for _, repair := range fileRepairs {
+			body = body[:repair.link.Position.Start] + repair.replacement + body[repair.link.Position.End:]
+		}
+		file.Body = body
+
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+	}
+	return nil
+}
@@ -2,11 +2,14 @@ package links
 
 import (
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/eoinhurrell/mdnotes/internal/cli"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
 	"github.com/eoinhurrell/mdnotes/internal/selector"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
@@ -17,11 +20,14 @@ func NewLinksCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "links",
 		Short: "Manage links in markdown files",
-		Long:  "Commands for checking, converting, and updating links in Obsidian notes",
+		Long:  "Commands for checking, fixing, converting, archiving, and updating links in Obsidian notes",
 	}
 
 	cmd.AddCommand(NewCheckCommand())
 	cmd.AddCommand(NewConvertCommand())
+	cmd.AddCommand(NewFixCommand())
+	cmd.AddCommand(NewBacklinksCommand())
+	cmd.AddCommand(NewArchiveCommand())
 
 	return cmd
 }
@@ -38,18 +44,34 @@ Reports links that point to non-existent files.
 By default, markdown links are checked relative to the vault root (Obsidian behavior).
 Wiki links are always checked relative to the vault root.
 
+With --external, web links in note bodies are checked too. Each URL is
+requested once, several at a time, with HEAD (falling back to GET). Links
+answering 404 or 410, or whose host doesn't exist, are dead; links moved
+permanently are reported with where they moved to, and ones that time out or
+fail otherwise are reported as warnings. Results are cached in the vault
+index (see 'mdnotes index build') for --cache-ttl, so later runs only check
+new or stale links. --tag-dead adds a tag to notes with dead links.
+
 Examples:
   # Check links (default: vault-relative)
   mdnotes links check /path/to/vault
   
   # Check links relative to each file's directory
-  mdnotes links check --file-relative /path/to/vault`,
+  mdnotes links check --file-relative /path/to/vault
+
+  # Check web links too, tagging notes with dead ones
+  mdnotes links check --external --tag-dead broken-link /path/to/vault`,
 		Args: cobra.ExactArgs(1),
 		RunE: runCheck,
 	}
 
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
 	cmd.Flags().Bool("file-relative", false, "Check markdown links relative to each file's directory instead of vault root")
+	cmd.Flags().Bool("external", false, "Also check web links")
+	cmd.Flags().Duration("timeout", 10*time.Second, "Timeout for each web link request")
+	cmd.Flags().Int("concurrency", 8, "Web links checked at once")
+	cmd.Flags().Duration("cache-ttl", 24*time.Hour, "How long cached web link results are trusted (0 to check every link)")
+	cmd.Flags().String("tag-dead", "", "Tag notes with dead web links with this tag")
 
 	return cmd
 }
@@ -60,6 +82,12 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	// Get flags
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
 	fileRelative, _ := cmd.Flags().GetBool("file-relative")
+	external, _ := cmd.Flags().GetBool("external")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	cacheTTL, _ := cmd.Flags().GetDuration("cache-ttl")
+	tagDead, _ := cmd.Flags().GetString("tag-dead")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
 
@@ -68,6 +96,16 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		verbose = false
 	}
 
+	if tagDead != "" {
+		if !external {
+			return fmt.Errorf("--tag-dead requires --external")
+		}
+		tagDead = processor.NormalizeTag(tagDead)
+		if err := processor.ValidateTag(tagDead); err != nil {
+			return err
+		}
+	}
+
 	// Get file selection configuration from global flags
 	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
 	if err != nil {
@@ -84,6 +122,10 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		fileSelector = fileSelector.WithIgnorePatterns(localIgnore)
 	}
 
+	// Links are stored in the vault index, if one is in use
+	linkParser := processor.NewLinkParser()
+	fileSelector = fileSelector.WithLinkParser(linkParser)
+
 	// Select files using unified architecture
 	selection, err := fileSelector.SelectFiles(path, mode)
 	if err != nil {
@@ -115,32 +157,17 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting absolute path for vault: %w", err)
 	}
 
-	// Create maps for different types of file lookups
-	existingFiles := make(map[string]bool)     // vault-relative paths
-	baseNameFiles := make(map[string][]string) // basename -> list of full paths
-	for _, file := range files {
-		// Normalize path separators for consistent lookup
-		normalizedPath := filepath.ToSlash(file.RelativePath)
-		existingFiles[normalizedPath] = true
-
-		// Also add without .md extension for exact matches
-		if strings.HasSuffix(normalizedPath, ".md") {
-			withoutExt := strings.TrimSuffix(normalizedPath, ".md")
-			existingFiles[withoutExt] = true
-
-			// For wiki links: map basename to full paths (Obsidian behavior)
-			baseName := filepath.Base(withoutExt)
-			baseNameFiles[baseName] = append(baseNameFiles[baseName], normalizedPath)
-		}
-	}
+	existingFiles, baseNameFiles := buildLinkIndex(files)
 
 	// Check links
-	linkParser := processor.NewLinkParser()
 	brokenLinks := 0
 	totalLinks := 0
 
 	for _, file := range files {
-		linkParser.UpdateFile(file)
+		// Files served from the vault index already carry their links
+		if file.Links == nil {
+			linkParser.UpdateFile(file)
+		}
 
 		fileHasBrokenLinks := false
 		fileLinksCount := 0
@@ -184,18 +211,45 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	var web webLinkReport
+	if external {
+		web, err = checkWebLinks(cmd, path, files, webLinkOptions{
+			timeout:     timeout,
+			concurrency: concurrency,
+			cacheTTL:    cacheTTL,
+			tagDead:     tagDead,
+			dryRun:      dryRun,
+			verbose:     verbose,
+			quiet:       quiet,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Summary
 	if brokenLinks > 0 {
 		if !quiet {
 			fmt.Printf("\nCheck completed: %d broken links found out of %d total links\n", brokenLinks, totalLinks)
 		}
-		return fmt.Errorf("found %d broken links", brokenLinks)
 	} else {
 		if !quiet {
 			fmt.Printf("\nCheck completed: all %d links are valid\n", totalLinks)
 		}
 	}
+	if external && !quiet {
+		fmt.Printf("Web links: %d checked (%d unique, %d cached), %d dead, %d redirected, %d failed\n",
+			web.total, web.unique, web.cached, web.dead, web.redirected, web.failed)
+	}
 
+	switch {
+	case brokenLinks > 0 && web.dead > 0:
+		return fmt.Errorf("found %d broken links and %d dead web links", brokenLinks, web.dead)
+	case brokenLinks > 0:
+		return fmt.Errorf("found %d broken links", brokenLinks)
+	case web.dead > 0:
+		return fmt.Errorf("found %d dead web links", web.dead)
+	}
 	return nil
 }
 
@@ -207,7 +261,22 @@ func NewConvertCommand() *cobra.Command {
 		Short:   "Convert between link formats",
 		Long: `Convert links between wiki and markdown formats.
 Wiki format: [[note]] or [[note|alias]]
-Markdown format: [text](note.md)`,
+Markdown format: [text](note.md)
+
+Links to headings and blocks, such as [[note#Heading]] and [[note#^block|alias]],
+and embeds, such as ![[image.png|300]] and ![[note#Summary]], are converted too.
+Paths in markdown links are URL-encoded.
+
+Converted links keep the path they were written with, unless one of
+--shortest, --relative or --absolute is given, matching Obsidian's
+"New link format" setting:
+  --shortest   the file name, or the vault path when the name isn't unique
+  --relative   the path relative to the linking note's folder
+  --absolute   the path from the vault root
+
+Examples:
+  # Convert wiki links to markdown, relative to each note
+  mdnotes links convert --from wiki --to markdown --relative /path/to/vault`,
 		Args: cobra.ExactArgs(1),
 		RunE: runConvert,
 	}
@@ -215,6 +284,9 @@ Markdown format: [text](note.md)`,
 	cmd.Flags().String("from", "wiki", "Source format (wiki, markdown)")
 	cmd.Flags().String("to", "markdown", "Target format (wiki, markdown)")
 	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+	cmd.Flags().Bool("shortest", false, "Write the shortest path that identifies each linked file")
+	cmd.Flags().Bool("relative", false, "Write paths relative to each note's folder")
+	cmd.Flags().Bool("absolute", false, "Write paths from the vault root")
 
 	return cmd
 }
@@ -226,6 +298,9 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	fromFormat, _ := cmd.Flags().GetString("from")
 	toFormat, _ := cmd.Flags().GetString("to")
 	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	shortest, _ := cmd.Flags().GetBool("shortest")
+	relative, _ := cmd.Flags().GetBool("relative")
+	absolute, _ := cmd.Flags().GetBool("absolute")
 	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
 	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
@@ -260,14 +335,38 @@ func runConvert(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	pathStyle := processor.PathAsWritten
+	styles := 0
+	for style, set := range map[processor.LinkPathStyle]bool{
+		processor.PathShortest: shortest,
+		processor.PathRelative: relative,
+		processor.PathAbsolute: absolute,
+	} {
+		if set {
+			pathStyle = style
+			styles++
+		}
+	}
+	if styles > 1 {
+		return fmt.Errorf("only one of --shortest, --relative and --absolute can be given")
+	}
+
 	// Create processor
 	converter := processor.NewLinkConverter()
+	if pathStyle != processor.PathAsWritten {
+		vaultFiles, err := listVaultFiles(path)
+		if err != nil {
+			return fmt.Errorf("listing vault files: %w", err)
+		}
+		converter = converter.WithPathStyle(pathStyle, vaultFiles)
+	}
 
 	// Setup file processor
 	fileProcessor := &processor.FileProcessor{
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Quiet:          quiet,
+		Transaction:    cli.BeginTransaction(cmd, path),
 		IgnorePatterns: ignorePatterns,
 		ProcessFile: func(file *vault.VaultFile) (bool, error) {
 			modified := converter.ConvertFile(file, from, to)
@@ -289,6 +388,7 @@ func runConvert(cmd *cobra.Command, args []string) error {
 
 	// Process files
 	result, err := fileProcessor.ProcessPath(path)
+	cli.CommitTransaction(cmd, fileProcessor.Transaction)
 	if err != nil {
 		return err
 	}
@@ -309,6 +409,56 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// listVaultFiles returns the slash-separated paths of the notes and
+// attachments under root, skipping hidden files and folders
+func listVaultFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// buildLinkIndex maps the files' vault-relative paths, with and without .md,
+// and the basenames wiki links resolve by to their paths
+func buildLinkIndex(files []*vault.VaultFile) (existingFiles map[string]bool, baseNameFiles map[string][]string) {
+	existingFiles = make(map[string]bool)     // vault-relative paths
+	baseNameFiles = make(map[string][]string) // basename -> list of full paths
+	for _, file := range files {
+		// Normalize path separators for consistent lookup
+		normalizedPath := filepath.ToSlash(file.RelativePath)
+		existingFiles[normalizedPath] = true
+
+		// Also add without .md extension for exact matches
+		if strings.HasSuffix(normalizedPath, ".md") {
+			withoutExt := strings.TrimSuffix(normalizedPath, ".md")
+			existingFiles[withoutExt] = true
+
+			// For wiki links: map basename to full paths (Obsidian behavior)
+			baseName := filepath.Base(withoutExt)
+			baseNameFiles[baseName] = append(baseNameFiles[baseName], normalizedPath)
+		}
+	}
+	return existingFiles, baseNameFiles
+}
+
 // resolveTargetPath determines the actual path to check based on link type and settings
 func resolveTargetPath(link vault.Link, file *vault.VaultFile, vaultRoot string, fileRelative bool) string {
 	target := link.Target
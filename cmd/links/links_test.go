@@ -1,9 +1,17 @@
 package links
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
@@ -187,7 +195,7 @@ func TestNewLinksCommand(t *testing.T) {
 
 	// Should have subcommands
 	subcommands := cmd.Commands()
-	assert.Len(t, subcommands, 2)
+	assert.Len(t, subcommands, 5)
 }
 
 func TestNewCheckCommand(t *testing.T) {
@@ -201,4 +209,228 @@ func TestNewCheckCommand(t *testing.T) {
 	// Should have flags
 	assert.NotNil(t, cmd.Flags().Lookup("ignore"))
 	assert.NotNil(t, cmd.Flags().Lookup("file-relative"))
+	assert.NotNil(t, cmd.Flags().Lookup("external"))
+	assert.NotNil(t, cmd.Flags().Lookup("tag-dead"))
+}
+
+func runLinksCommand(t *testing.T, stdin string, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.PersistentFlags().String("config", "", "Config file")
+	rootCmd.AddCommand(NewLinksCommand())
+
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"links"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestFixCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	write("notes/roadmap.md", "---\naliases: [Big Plan]\n---\n# Roadmap\n")
+	write("notes/meeting-notes.md", "# Meetings\n")
+	write("index.md", "[[Big Plan]] [[Meeting Notes#Monday]] [[roadmapp]] [[nothing like it]]\n")
+
+	// Suggestions only
+	require.NoError(t, runLinksCommand(t, "", "fix", dir))
+	assert.Equal(t, "[[Big Plan]] [[Meeting Notes#Monday]] [[roadmapp]] [[nothing like it]]\n", read("index.md"))
+
+	// Dry run
+	require.NoError(t, runLinksCommand(t, "", "fix", "--auto", "0.9", "--dry-run", dir))
+	assert.Equal(t, "[[Big Plan]] [[Meeting Notes#Monday]] [[roadmapp]] [[nothing like it]]\n", read("index.md"))
+
+	// Confident repairs; the misspelling scores below the threshold
+	require.NoError(t, runLinksCommand(t, "", "fix", "--auto", "0.9", dir))
+	assert.Equal(t, "[[roadmap|Big Plan]] [[meeting-notes#Monday]] [[roadmapp]] [[nothing like it]]\n", read("index.md"))
+
+	// The rest are confirmed one by one
+	require.NoError(t, runLinksCommand(t, "1\n", "fix", "--interactive", dir))
+	assert.Equal(t, "[[roadmap|Big Plan]] [[meeting-notes#Monday]] [[roadmap]] [[nothing like it]]\n", read("index.md"))
+
+	assert.ErrorContains(t, runLinksCommand(t, "", "fix", "--auto", "2", dir), "--auto must be between 0 and 1")
+}
+
+func TestFixCommand_SkipsLockedNotes(t *testing.T) {
+	dir := t.TempDir()
+	locked := "---\nmdnotes: locked\n---\n[[roadmapp]]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "roadmap.md"), []byte("# Roadmap\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "locked.md"), []byte(locked), 0644))
+
+	require.NoError(t, runLinksCommand(t, "", "fix", "--auto", "0.1", dir))
+	content, err := os.ReadFile(filepath.Join(dir, "locked.md"))
+	require.NoError(t, err)
+	assert.Equal(t, locked, string(content))
+}
+
+func TestBacklinksCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".obsidian"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Index\n\n[[topic]]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "topic.md"), []byte("# Topic\n"), 0644))
+
+	// Only notes/ is updated, but backlinks come from the whole vault
+	require.NoError(t, runLinksCommand(t, "", "backlinks", filepath.Join(dir, "notes")))
+	content, err := os.ReadFile(filepath.Join(dir, "notes", "topic.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Topic\n\n## Backlinks\n\n- [[index]]\n", string(content))
+
+	info, err := os.Stat(filepath.Join(dir, "notes", "topic.md"))
+	require.NoError(t, err)
+	require.NoError(t, runLinksCommand(t, "", "backlinks", dir))
+	again, err := os.Stat(filepath.Join(dir, "notes", "topic.md"))
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime(), again.ModTime(), "unchanged notes are not rewritten")
+
+	require.NoError(t, runLinksCommand(t, "", "backlinks", "--remove", dir))
+	content, err = os.ReadFile(filepath.Join(dir, "notes", "topic.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Topic\n", string(content))
+}
+
+func TestArchiveCommand(t *testing.T) {
+	var saves []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cdx/search/cdx":
+			if r.URL.Query().Get("url") == "https://example.com/old" {
+				_, _ = w.Write([]byte(`[["timestamp","original"],["20200101000000","https://example.com/old"]]`))
+				return
+			}
+			_, _ = w.Write([]byte("[]"))
+		case strings.HasPrefix(r.URL.Path, "/save/"):
+			page := strings.TrimPrefix(r.URL.Path, "/save/")
+			saves = append(saves, page)
+			if page == "https://example.com/broken" {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Location", "/web/20240301120000/"+page)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("archive:\n  api_url: "+server.URL+"\n  rate_limit: 6000\n"), 0644))
+
+	write("new.md", "---\nurl: https://example.com/new\ntags: [clipping]\n---\n# New\n")
+	write("shared.md", "---\nurl: https://example.com/new\ntags: [clipping]\n---\n# Shared\n")
+	write("old.md", "---\nurl: https://example.com/old\ntags: [clipping]\n---\n# Old\n")
+	write("done.md", "---\nurl: https://example.com/done\narchive_url: https://web.archive.org/web/1/x\ntags: [clipping]\n---\n# Done\n")
+	write("other.md", "---\nurl: https://example.com/other\n---\n# Other\n")
+	write("broken.md", "---\nurl: https://example.com/broken\ntags: [clipping]\n---\n# Broken\n")
+
+	// Nothing is requested in a dry run
+	require.NoError(t, runLinksCommand(t, "", "archive", "--config", configPath, "--where", "tags has 'clipping'", "--dry-run", dir))
+	assert.Empty(t, saves)
+
+	err := runLinksCommand(t, "", "archive", "--config", configPath, "--where", "tags has 'clipping'", dir)
+	assert.ErrorContains(t, err, "1 notes could not be archived")
+
+	// A page shared by two notes is saved once; one archived before isn't saved again
+	assert.Equal(t, []string{"https://example.com/broken", "https://example.com/new"}, saves)
+	assert.Contains(t, read("new.md"), "archive_url: "+server.URL+"/web/20240301120000/https://example.com/new\narchived_at: \"2024-03-01T12:00:00Z\"")
+	assert.Contains(t, read("shared.md"), "archive_url: "+server.URL+"/web/20240301120000/https://example.com/new")
+	assert.Contains(t, read("old.md"), "archive_url: "+server.URL+"/web/20200101000000/https://example.com/old")
+	assert.Contains(t, read("done.md"), "archive_url: https://web.archive.org/web/1/x")
+	assert.NotContains(t, read("other.md"), "archive_url")
+	assert.NotContains(t, read("broken.md"), "archive_url")
+}
+
+func TestCheckCommand_External(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/ok", "/new":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+	write("good.md", "# Good\n\nSee "+server.URL+"/ok and ["+server.URL+"/moved]("+server.URL+"/moved).\n")
+	write("dead.md", "---\ntags: [reading]\n---\n# Dead\n\n[Gone]("+server.URL+"/gone)\n")
+	write("shared.md", "# Shared\n\nAlso "+server.URL+"/ok\n")
+
+	// Results are only cached once the vault has an index
+	idx := vault.NewIndex(dir)
+	idx.SetWebLink("https://example.com/unrelated", vault.WebLinkCheck{Status: "ok", Checked: time.Now()})
+	require.NoError(t, idx.Save())
+
+	assert.ErrorContains(t, runLinksCommand(t, "", "check", "--tag-dead", "broken-link", dir), "--tag-dead requires --external")
+	assert.ErrorContains(t, runLinksCommand(t, "", "check", "--external", "--tag-dead", "bad tag", dir), "invalid tag")
+	assert.Empty(t, requests)
+
+	err := runLinksCommand(t, "", "check", "--external", "--tag-dead", "broken-link", "--dry-run", dir)
+	assert.ErrorContains(t, err, "found 1 dead web links")
+	assert.NotContains(t, read("dead.md"), "broken-link")
+	// Each URL is requested once, following the redirect
+	assert.ElementsMatch(t, []string{"/ok", "/moved", "/new", "/gone", "/gone"}, requests)
+
+	// The dry run cached its results, so nothing is requested again
+	requests = nil
+	err = runLinksCommand(t, "", "check", "--external", "--tag-dead", "broken-link", dir)
+	assert.ErrorContains(t, err, "found 1 dead web links")
+	assert.Empty(t, requests)
+	assert.Contains(t, read("dead.md"), "tags:\n    - reading\n    - broken-link")
+	assert.NotContains(t, read("good.md"), "broken-link")
+
+	// Without the cache every link is checked again
+	err = runLinksCommand(t, "", "check", "--external", "--cache-ttl", "0", dir)
+	assert.ErrorContains(t, err, "found 1 dead web links")
+	assert.NotEmpty(t, requests)
+}
+
+func TestConvertCommand_PathStyle(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+	write("projects/plan.md", "# Plan\n\nSee [[Roadmap#Q1 Goals|goals]] and ![[chart.png|400]]\n")
+	write("Roadmap.md", "# Roadmap\n")
+	write("assets/chart.png", "png")
+
+	assert.ErrorContains(t, runLinksCommand(t, "", "convert", "--relative", "--absolute", dir), "only one of")
+
+	require.NoError(t, runLinksCommand(t, "", "convert", "--relative", dir))
+	assert.Contains(t, read("projects/plan.md"), "See [goals](../Roadmap.md#Q1%20Goals) and ![|400](../assets/chart.png)")
 }
@@ -0,0 +1,178 @@
+package links
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/linkcheck"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// webLinkOptions configures checking the web links in notes
+type webLinkOptions struct {
+	timeout     time.Duration
+	concurrency int
+	cacheTTL    time.Duration
+	tagDead     string
+	dryRun      bool
+	verbose     bool
+	quiet       bool
+}
+
+// webLinkReport counts what checking web links found
+type webLinkReport struct {
+	total      int // Links, counting each note's separately
+	unique     int // Distinct URLs
+	cached     int // URLs whose result came from the index
+	dead       int
+	redirected int
+	failed     int // Timed out or failed for another reason
+}
+
+// checkWebLinks checks the web links in files, reports the ones that don't
+// work and, with a dead tag, tags the notes with dead links. Results are
+// cached in the vault index, when there is one.
+func checkWebLinks(cmd *cobra.Command, path string, files []*vault.VaultFile, opts webLinkOptions) (webLinkReport, error) {
+	var report webLinkReport
+
+	links := make(map[*vault.VaultFile][]processor.WebLink, len(files))
+	var urls []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		links[file] = processor.ExtractWebLinks(file)
+		report.total += len(links[file])
+		for _, link := range links[file] {
+			if !seen[link.URL] {
+				seen[link.URL] = true
+				urls = append(urls, link.URL)
+			}
+		}
+	}
+	report.unique = len(urls)
+
+	// Results still fresh in the index aren't checked again
+	idx := vault.FindIndex(path)
+	results := make(map[string]linkcheck.Result, len(urls))
+	var unchecked []string
+	for _, url := range urls {
+		if idx != nil && opts.cacheTTL > 0 {
+			if cached, ok := idx.WebLink(url, opts.cacheTTL); ok {
+				results[url] = linkcheck.Result{
+					URL:      url,
+					Status:   cached.Status,
+					Code:     cached.Code,
+					Location: cached.Location,
+					Error:    cached.Error,
+					Checked:  cached.Checked,
+				}
+				report.cached++
+				continue
+			}
+		}
+		unchecked = append(unchecked, url)
+	}
+	if opts.verbose {
+		fmt.Printf("Checking %d web links (%d cached)\n", len(unchecked), report.cached)
+	}
+
+	checker := linkcheck.NewChecker(linkcheck.Options{Workers: opts.concurrency, Timeout: opts.timeout})
+	for url, result := range checker.Check(cmd.Context(), unchecked) {
+		results[url] = result
+		// Timeouts and server errors are worth another try next time
+		if idx != nil && !result.Transient() {
+			idx.SetWebLink(url, vault.WebLinkCheck{
+				Status:   result.Status,
+				Code:     result.Code,
+				Location: result.Location,
+				Error:    result.Error,
+				Checked:  result.Checked,
+			})
+		}
+	}
+	if idx != nil {
+		if err := idx.Save(); err != nil && !opts.quiet {
+			fmt.Printf("⚠ Could not cache link results: %v\n", err)
+		}
+	}
+
+	var deadFiles []*vault.VaultFile
+	for _, file := range files {
+		hasDead := false
+		for _, link := range links[file] {
+			result := results[link.URL]
+			switch result.Status {
+			case linkcheck.StatusDead:
+				report.dead++
+				hasDead = true
+				fmt.Printf("✗ %s:%d: dead link %s (%s)\n", file.RelativePath, link.Line, link.URL, result.Describe())
+			case linkcheck.StatusRedirect:
+				report.redirected++
+				if !opts.quiet {
+					fmt.Printf("↪ %s:%d: %s %s\n", file.RelativePath, link.Line, link.URL, result.Describe())
+				}
+			case linkcheck.StatusTimeout, linkcheck.StatusError:
+				report.failed++
+				if !opts.quiet {
+					fmt.Printf("⚠ %s:%d: %s %s\n", file.RelativePath, link.Line, link.URL, result.Describe())
+				}
+			default:
+				if opts.verbose {
+					fmt.Printf("✓ %s:%d: %s\n", file.RelativePath, link.Line, link.URL)
+				}
+			}
+		}
+		if hasDead {
+			deadFiles = append(deadFiles, file)
+		}
+	}
+
+	if opts.tagDead != "" && len(deadFiles) > 0 {
+		if err := tagDeadLinks(cmd, path, deadFiles, opts); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// tagDeadLinks adds the dead link tag to notes with dead links
+func tagDeadLinks(cmd *cobra.Command, path string, files []*vault.VaultFile, opts webLinkOptions) error {
+	tx := cli.BeginTransaction(cmd, path)
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("links check --tag-dead")
+
+	tags := processor.NewTagProcessor()
+	for _, file := range files {
+		if reason := file.LockReason(); reason != "" {
+			if !opts.quiet {
+				fmt.Printf("⚠ Skipping %s: %s\n", file.RelativePath, reason)
+			}
+			continue
+		}
+		if !tags.Add(file, opts.tagDead) {
+			continue
+		}
+		if opts.dryRun {
+			fmt.Printf("Would tag %s with #%s\n", file.RelativePath, opts.tagDead)
+			continue
+		}
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+		if !opts.quiet {
+			fmt.Printf("✓ Tagged %s with #%s\n", file.RelativePath, opts.tagDead)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/mcp"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// NewMCPCommand creates the mcp command
+func NewMCPCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp [vault-path]",
+		Short: "Serve the vault to LLM assistants over the Model Context Protocol",
+		Long: `Run a Model Context Protocol server on stdin and stdout, so LLM assistants
+can search, read and update notes through mdnotes.
+
+Tools:
+  query_notes         Find notes matching a query expression (saved queries as @name)
+  get_note            Read a note's frontmatter and body
+  link_graph          The link graph as JSON, Mermaid or DOT
+  vault_stats         Vault statistics, as 'analyze stats'
+  update_frontmatter  Set and remove frontmatter fields (not with --read-only)
+
+--allow limits the notes tools can see and change to those matching the
+given vault-relative patterns; "folder/*" covers everything under folder.
+Locked notes are never changed, and changes are recorded in the change
+journal, so 'mdnotes undo' reverts them.`,
+		Example: `  # Register with an MCP client, e.g. in its server configuration:
+  #   "command": "mdnotes", "args": ["mcp", "--read-only", "/path/to/vault"]
+
+  # Only expose the projects and areas folders, with editing
+  mdnotes mcp --allow "projects/*" --allow "areas/*" ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runMCP,
+	}
+
+	cmd.Flags().Bool("read-only", false, "Disable tools that change notes")
+	cmd.Flags().StringSlice("allow", nil, "Only expose notes matching these vault-relative patterns (default: all notes)")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	if info, err := os.Stat(vaultPath); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault path %s is not a directory", vaultPath)
+	}
+	root, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return fmt.Errorf("resolving vault path: %w", err)
+	}
+
+	readOnly, _ := cmd.Flags().GetBool("read-only")
+	allow, _ := cmd.Flags().GetStringSlice("allow")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	v := mcp.NewVault(mcp.Options{
+		Root:           root,
+		ReadOnly:       readOnly,
+		Allow:          allow,
+		IgnorePatterns: ignorePatterns,
+		Workers:        workers,
+		ResolveQuery:   cfg.ResolveQuery,
+		Commit: func(tx *safety.Transaction) {
+			cli.CommitTransaction(cmd, tx)
+		},
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// stdout carries the protocol, so nothing else may be printed there
+	return mcp.NewServer(v, "mdnotes", cmd.Root().Version).Serve(ctx, os.Stdin, os.Stdout)
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
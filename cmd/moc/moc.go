@@ -0,0 +1,222 @@
+package moc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewMOCCommand creates the moc command
+func NewMOCCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "moc",
+		Short: "Maintain maps of content",
+		Long: `Maintain maps of content (MOCs), index notes that link to the notes in a
+folder.`,
+	}
+
+	cmd.AddCommand(newGenerateCommand())
+
+	return cmd
+}
+
+func newGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate [vault-path]",
+		Short: "Create or update a map of content for a folder",
+		Long: `Write a note linking to every note in a folder, optionally grouped by a
+frontmatter field such as status or tags. A note with several tags is listed
+under each of them, and notes without the field go under "Other".
+
+The list is written between these markers:
+
+  <!-- mdnotes:moc start -->
+  <!-- mdnotes:moc end -->
+
+Running the command again replaces only what is between them, so text added
+around the list is kept. A note without the markers gets the list appended,
+and a missing note is created. Locked notes are left alone.
+
+--output is relative to the vault root. Group headings and entries are
+templates, as in 'mdnotes rename': headings can use {{group}} and {{count}},
+entries {{link}} and the note's variables, such as {{title}} or {{status}}.
+Defaults for --group-by and the templates can be set in the config file:
+
+moc:
+  group_by: status
+  heading_template: "## {{group|title}} ({{count}})"
+  item_template: "- {{link}} — {{description}}"
+  ungrouped: Other`,
+		Example: `  # Index the projects folder by status
+  mdnotes moc generate --folder projects/ --output "Projects MOC.md" --group-by status
+
+  # Group by tag, showing each note's due date
+  mdnotes moc generate --folder reading --output reading/index.md --group-by tags \
+    --item-template "- {{link}}{{if due}} (due {{due}}){{end}}"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runGenerate,
+	}
+
+	cmd.Flags().String("folder", "", "Folder whose notes are listed, relative to the vault root (default: the whole vault)")
+	cmd.Flags().String("output", "", "Map of content note to write, relative to the vault root")
+	cmd.Flags().String("group-by", "", "Frontmatter field to group notes by (default: moc.group_by)")
+	cmd.Flags().String("heading-template", "", "Template of group headings (default: moc.heading_template, or \"## {{group}}\")")
+	cmd.Flags().String("item-template", "", "Template of each entry (default: moc.item_template, or \"- {{link}}\")")
+	cmd.Flags().String("ungrouped", "", "Group of notes without the field (default: moc.ungrouped, or \"Other\")")
+	cmd.Flags().String("link-format", "wiki", "Format of links (wiki, markdown)")
+	cmd.Flags().Bool("recursive", true, "Include notes in subfolders")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+	_ = cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+
+	folder, _ := cmd.Flags().GetString("folder")
+	output, _ := cmd.Flags().GetString("output")
+	linkFormat, _ := cmd.Flags().GetString("link-format")
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	opts := processor.MOCOptions{
+		GroupBy:         flagOr(cmd, "group-by", cfg.MOC.GroupBy),
+		HeadingTemplate: flagOr(cmd, "heading-template", cfg.MOC.HeadingTemplate),
+		ItemTemplate:    flagOr(cmd, "item-template", cfg.MOC.ItemTemplate),
+		Ungrouped:       flagOr(cmd, "ungrouped", cfg.MOC.Ungrouped),
+		LinkFormat:      linkFormat,
+	}
+	generator, err := processor.NewMOCGenerator(opts)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(strings.ToLower(output), ".md") {
+		output += ".md"
+	}
+	if filepath.IsAbs(output) || strings.HasPrefix(filepath.Clean(output), "..") {
+		return fmt.Errorf("--output must be a path inside the vault, relative to its root")
+	}
+	root := safety.FindVaultRoot(vaultPath)
+	if folder != "" {
+		if info, err := os.Stat(filepath.Join(root, folder)); err != nil || !info.IsDir() {
+			return fmt.Errorf("folder %s not found in vault %s", folder, root)
+		}
+	}
+
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(ignorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(vault.FindIndex(root)),
+		vault.WithWorkers(workers),
+	)
+	files, err := scanner.Walk(root)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+
+	outputRel := filepath.ToSlash(filepath.Clean(output))
+	children := processor.MOCChildren(files, folder, outputRel, recursive)
+	block, err := generator.Render(children, processor.NoteNames(files))
+	if err != nil {
+		return err
+	}
+
+	fullPath := filepath.Join(root, outputRel)
+	note := &vault.VaultFile{Path: fullPath, RelativePath: outputRel}
+	exists := false
+	if content, err := os.ReadFile(fullPath); err == nil {
+		exists = true
+		if err := note.Parse(content); err != nil {
+			return fmt.Errorf("parsing %s: %w", outputRel, err)
+		}
+		if reason := note.LockReason(); reason != "" {
+			return fmt.Errorf("%s is locked: %s", outputRel, reason)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", outputRel, err)
+	} else {
+		title := strings.TrimSuffix(filepath.Base(outputRel), filepath.Ext(outputRel))
+		note.Body = "# " + title + "\n"
+	}
+
+	body := processor.UpdateMOCBlock(note.Body, block)
+	if exists && body == note.Body {
+		if !quiet {
+			fmt.Printf("%s is up to date (%d notes)\n", outputRel, len(children))
+		}
+		return nil
+	}
+	note.Body = body
+
+	verb, done := "update", "Updated"
+	if !exists {
+		verb, done = "create", "Created"
+	}
+	if dryRun {
+		if !quiet {
+			fmt.Printf("Would %s %s listing %d notes\n", verb, outputRel, len(children))
+		}
+		if verbose {
+			fmt.Printf("\n%s\n", block)
+		}
+		return nil
+	}
+
+	content, err := note.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", outputRel, err)
+	}
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+	if err := tx.RecordWrite(fullPath); err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("creating folder: %w", err)
+	}
+	if err := safety.WriteFile(fullPath, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", outputRel, err)
+	}
+	if !quiet {
+		fmt.Printf("✓ %s %s listing %d notes\n", done, outputRel, len(children))
+	}
+	return nil
+}
+
+// flagOr returns the flag's value when it was given, or fallback
+func flagOr(cmd *cobra.Command, name, fallback string) string {
+	if cmd.Flags().Changed(name) {
+		value, _ := cmd.Flags().GetString(name)
+		return value
+	}
+	return fallback
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,66 @@
+package moc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runMOCCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("quiet", true, "Suppress output")
+	rootCmd.AddCommand(NewMOCCommand())
+
+	rootCmd.SetArgs(append([]string{"moc"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestGenerateCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".mdnotes"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "projects"), 0755))
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	write("projects/garden.md", "---\nstatus: active\n---\nBeds.\n")
+	write("projects/shed.md", "---\nstatus: done\n---\nRoof.\n")
+	write("inbox.md", "Elsewhere.\n")
+
+	args := []string{"generate", "--folder", "projects/", "--output", "Projects MOC.md", "--group-by", "status", dir}
+
+	require.NoError(t, runMOCCommand(t, append([]string{"--dry-run"}, args...)...))
+	assert.NoFileExists(t, filepath.Join(dir, "Projects MOC.md"))
+
+	require.NoError(t, runMOCCommand(t, args...))
+	assert.Equal(t, "# Projects MOC\n\n"+
+		"<!-- mdnotes:moc start -->\n"+
+		"## active\n\n- [[garden]]\n\n"+
+		"## done\n\n- [[shed]]\n"+
+		"<!-- mdnotes:moc end -->\n", read("Projects MOC.md"))
+
+	// Text around the markers survives regeneration
+	moc := read("Projects MOC.md") + "\nWritten by hand.\n"
+	write("Projects MOC.md", moc)
+	require.NoError(t, runMOCCommand(t, args...))
+	assert.Equal(t, moc, read("Projects MOC.md"))
+
+	write("projects/pond.md", "---\nstatus: active\n---\nFish.\n")
+	require.NoError(t, runMOCCommand(t, args...))
+	assert.Contains(t, read("Projects MOC.md"), "## active\n\n- [[garden]]\n- [[pond]]\n")
+	assert.Contains(t, read("Projects MOC.md"), "\nWritten by hand.\n")
+
+	write("locked.md", "<!-- mdnotes:locked -->\n")
+	assert.ErrorContains(t, runMOCCommand(t, "generate", "--output", "locked.md", dir), "locked")
+	assert.ErrorContains(t, runMOCCommand(t, "generate", "--folder", "missing", "--output", "x.md", dir), "not found")
+}
@@ -0,0 +1,318 @@
+package newnote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+)
+
+// Defaults used when the templates section of the config leaves them unset
+const (
+	DefaultTemplateDir = "templates"
+	DefaultFilename    = "{{title}}"
+	DefaultTitle       = "Untitled"
+)
+
+// NewNewCommand creates the new command
+func NewNewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "new [template] [title]",
+		Short: "Create a note from a template",
+		Long: `Create a note from a template in the vault's templates directory.
+
+Templates are markdown files whose frontmatter and body may use template
+variables, which are filled in when the note is created:
+
+  ---
+  title: {{title}}
+  created: {{current_date}}
+  review: {{current_date + 7d}}
+  tags: [meeting]
+  ---
+  # {{title}}
+
+Placeholders may be left unquoted in frontmatter, and a value that is a
+single placeholder keeps its type, so dates stay dates. Besides the built-in
+variables, templates can use title, template, folder and filename, and every
+--set field.
+
+Where notes go is configured in the templates section of the config file:
+
+templates:
+  dir: templates            # Relative to the vault root
+  default: note             # Template used when none is named
+  folder: inbox             # Folder for new notes
+  filename: "{{title}}"     # File name, without .md
+  rules:
+    meeting:
+      folder: "meetings/{{current_date|date:YYYY}}"
+      filename: "{{current_date|date:YYYY-MM-DD}} {{title}}"
+
+With a single argument, a name that is not a template is taken as the title
+of a note created from the default template.`,
+		Example: `  # Create a meeting note
+  mdnotes new meeting "Weekly sync"
+
+  # Create a note from the default template
+  mdnotes new "Idea for a talk"
+
+  # Set extra fields and preview the note
+  mdnotes new project "Launch" --set status=active --set priority=2 --dry-run`,
+		Args:              cobra.MaximumNArgs(2),
+		RunE:              runNew,
+		ValidArgsFunction: completeTemplates,
+	}
+
+	cmd.Flags().String("vault", ".", "Vault to create the note in")
+	cmd.Flags().String("folder", "", "Folder for the note, overriding the configured folder (may use template variables)")
+	cmd.Flags().StringArray("set", nil, "Set a frontmatter field and template variable as field=value (repeatable)")
+
+	return cmd
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	vaultPath, _ := cmd.Flags().GetString("vault")
+	folderFlag, _ := cmd.Flags().GetString("folder")
+	sets, _ := cmd.Flags().GetStringArray("set")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	root := safety.FindVaultRoot(vaultPath)
+	templateDir := templateDirectory(cfg.Templates, root)
+
+	name, title, err := resolveArgs(args, cfg.Templates.Default, templateDir)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(templatePath(templateDir, name))
+	if err != nil {
+		return fmt.Errorf("reading template '%s': %w", name, err)
+	}
+
+	// Fields from --set are typed like 'frontmatter set' values
+	typeCaster := processor.NewTypeCaster()
+	fields := make(map[string]interface{})
+	var fieldOrder []string
+	for _, set := range sets {
+		field, value, ok := strings.Cut(set, "=")
+		if !ok || field == "" {
+			return fmt.Errorf("invalid --set '%s': expected field=value", set)
+		}
+		typed, err := typeCaster.Cast(value, typeCaster.AutoDetect(value))
+		if err != nil {
+			typed = value
+		}
+		if _, exists := fields[field]; !exists {
+			fieldOrder = append(fieldOrder, field)
+		}
+		fields[field] = typed
+	}
+
+	vars := templates.Vars{}
+	for field, value := range fields {
+		vars[field] = value
+	}
+	vars["title"] = title
+	vars["template"] = name
+
+	// Place the note
+	renderer := templates.NewRenderer()
+	rule := cfg.Templates.Rules[name]
+	folderTemplate := firstNonEmpty(folderFlag, rule.Folder, cfg.Templates.Folder)
+	filenameTemplate := firstNonEmpty(rule.Filename, cfg.Templates.Filename, DefaultFilename)
+
+	folder, err := renderer.Render(folderTemplate, vars)
+	if err != nil {
+		return fmt.Errorf("folder: %w", err)
+	}
+	filename, err := renderer.Render(filenameTemplate, vars)
+	if err != nil {
+		return fmt.Errorf("filename: %w", err)
+	}
+	filename = sanitizeFilename(filename)
+	if filename == "" {
+		return fmt.Errorf("filename template '%s' rendered an empty name", filenameTemplate)
+	}
+
+	relPath := filepath.ToSlash(filepath.Join(strings.Trim(folder, "/"), filename+".md"))
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return fmt.Errorf("note path '%s' is outside the vault", relPath)
+	}
+	fullPath := filepath.Join(root, relPath)
+	if _, err := os.Stat(fullPath); err == nil {
+		return fmt.Errorf("%s already exists", relPath)
+	}
+
+	vars["folder"] = strings.TrimPrefix(filepath.ToSlash(filepath.Dir(relPath)), ".")
+	vars["filename"] = filename
+
+	// Fill in the template
+	note, err := renderer.RenderNote(content, vars)
+	if err != nil {
+		return fmt.Errorf("rendering template '%s': %w", name, err)
+	}
+	for _, field := range fieldOrder {
+		note.SetField(field, fields[field])
+	}
+	note.Path = fullPath
+	note.RelativePath = relPath
+
+	output, err := note.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing note: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Would create %s from template '%s'\n", relPath, name)
+		if verbose {
+			fmt.Printf("\n%s", output)
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, root)
+	if err := tx.RecordWrite(fullPath); err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("creating folder: %w", err)
+	}
+	if err := safety.WriteFile(fullPath, output, 0644); err != nil {
+		return fmt.Errorf("writing note: %w", err)
+	}
+	cli.CommitTransaction(cmd, tx)
+
+	if !quiet {
+		fmt.Printf("✓ Created %s from template '%s'\n", relPath, name)
+	}
+	return nil
+}
+
+// resolveArgs returns the template and title to use. A single argument that
+// doesn't name a template is the title of a note from the default template.
+func resolveArgs(args []string, defaultTemplate, templateDir string) (name, title string, err error) {
+	switch len(args) {
+	case 2:
+		name, title = args[0], args[1]
+	case 1:
+		if templateExists(templateDir, args[0]) || defaultTemplate == "" {
+			name = args[0]
+		} else {
+			name, title = defaultTemplate, args[0]
+		}
+	default:
+		name = defaultTemplate
+	}
+
+	if name == "" {
+		return "", "", fmt.Errorf("no template given and no default template configured. Set 'templates.default' in your config file or name a template: %s", availableTemplates(templateDir))
+	}
+	if !templateExists(templateDir, name) {
+		return "", "", fmt.Errorf("template '%s' not found in %s. Available templates: %s", name, templateDir, availableTemplates(templateDir))
+	}
+	if title == "" {
+		title = DefaultTitle
+	}
+	return name, title, nil
+}
+
+// templateDirectory returns the absolute template directory of the vault
+func templateDirectory(cfg config.TemplatesConfig, root string) string {
+	dir := firstNonEmpty(cfg.Dir, DefaultTemplateDir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(root, dir)
+}
+
+// templatePath returns the file of the named template; names may omit .md
+func templatePath(dir, name string) string {
+	if !strings.HasSuffix(name, ".md") {
+		name += ".md"
+	}
+	return filepath.Join(dir, name)
+}
+
+func templateExists(dir, name string) bool {
+	info, err := os.Stat(templatePath(dir, name))
+	return err == nil && !info.IsDir()
+}
+
+// listTemplates returns the names of the templates in dir, including those in
+// subdirectories
+func listTemplates(dir string) []string {
+	var names []string
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".md"))
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names
+}
+
+func availableTemplates(dir string) string {
+	names := listTemplates(dir)
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// completeTemplates completes template names for the first argument
+func completeTemplates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		cfg = &config.Config{}
+	}
+	vaultPath, _ := cmd.Flags().GetString("vault")
+	return listTemplates(templateDirectory(cfg.Templates, safety.FindVaultRoot(vaultPath))), cobra.ShellCompDirectiveNoFileComp
+}
+
+// sanitizeFilename replaces characters that can't appear in a note's file name
+func sanitizeFilename(name string) string {
+	replacer := strings.NewReplacer("/", "-", "\\", "-", ":", "-", "\n", " ", "\r", "")
+	return strings.TrimSpace(replacer.Replace(name))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,122 @@
+package newnote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestVault creates a vault with a meeting template and a config placing
+// meeting notes in a dated folder
+func createTestVault(t *testing.T) (vault, configPath string) {
+	vault = t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(vault, ".obsidian"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(vault, "templates"), 0755))
+
+	template := `---
+title: {{title}}
+created: {{current_date}}
+tags: [meeting]
+---
+
+# {{title}}
+{{if project}}Project: {{project}}{{end}}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(vault, "templates", "meeting.md"), []byte(template), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(vault, "templates", "idea.md"), []byte("# {{title}}\n"), 0644))
+
+	configPath = filepath.Join(vault, "config.yaml")
+	config := `version: "1.0"
+templates:
+  default: idea
+  folder: inbox
+  rules:
+    meeting:
+      folder: "meetings/{{current_date|date:YYYY}}"
+      filename: "{{title|slug}}"
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+	return vault, configPath
+}
+
+func runNewCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.PersistentFlags().String("config", "", "Config file")
+	rootCmd.AddCommand(NewNewCommand())
+
+	rootCmd.SetArgs(append([]string{"new"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestNewCommand_FromTemplate(t *testing.T) {
+	vault, configPath := createTestVault(t)
+
+	err := runNewCommand(t, "meeting", "Weekly Sync", "--vault", vault, "--config", configPath, "--set", "project=Apollo", "--set", "priority=2")
+	require.NoError(t, err)
+
+	path := filepath.Join(vault, "meetings", time.Now().Format("2006"), "weekly-sync.md")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	note := string(content)
+	assert.Contains(t, note, "title: Weekly Sync\n")
+	assert.Contains(t, note, "created: "+time.Now().Format("2006-01-02")+"\n")
+	assert.Contains(t, note, "priority: 2\n")
+	assert.Contains(t, note, "project: Apollo\n")
+	assert.Contains(t, note, "# Weekly Sync\nProject: Apollo\n")
+
+	// Creating the same note again fails rather than overwriting it
+	err = runNewCommand(t, "meeting", "Weekly Sync", "--vault", vault, "--config", configPath)
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestNewCommand_DefaultTemplate(t *testing.T) {
+	vault, configPath := createTestVault(t)
+
+	require.NoError(t, runNewCommand(t, "A talk idea", "--vault", vault, "--config", configPath))
+
+	content, err := os.ReadFile(filepath.Join(vault, "inbox", "A talk idea.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# A talk idea\n", string(content))
+}
+
+func TestNewCommand_DryRun(t *testing.T) {
+	vault, configPath := createTestVault(t)
+
+	require.NoError(t, runNewCommand(t, "idea", "Draft", "--vault", vault, "--config", configPath, "--dry-run"))
+
+	_, err := os.Stat(filepath.Join(vault, "inbox"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewCommand_Errors(t *testing.T) {
+	vault, configPath := createTestVault(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{"unknown template", []string{"nope", "Title"}, "template 'nope' not found"},
+		{"invalid set", []string{"idea", "Title", "--set", "status"}, "expected field=value"},
+		{"outside vault", []string{"idea", "Title", "--folder", "../elsewhere"}, "outside the vault"},
+		{"bad folder template", []string{"idea", "Title", "--folder", "{{title|nope}}"}, "unknown filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append(tt.args, "--vault", vault, "--config", configPath)
+			err := runNewCommand(t, args...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package random
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/review"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewRandomCommand creates the random command
+func NewRandomCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "random [path]",
+		Short: "Pick random notes, optionally weighted",
+		Long: `Pick one or more random notes from the vault.
+
+Weighting expressions:
+  age          Days since last modification (older notes are more likely)
+  recency      1/(1+age) (recently modified notes are more likely)
+  words        Word count of the note body
+  <field>      Any numeric frontmatter field (e.g. rating)
+  1/<term>     Invert any of the above
+
+Examples:
+  # One random evergreen note, favouring notes not touched in a while
+  mdnotes random --where "tags has 'evergreen'" --weight-by "1/recency" .
+
+  # Five random notes as paths for piping
+  mdnotes random -n 5 --format paths .`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runRandom,
+	}
+
+	addPickFlags(cmd)
+
+	return cmd
+}
+
+// NewReviewCommand creates the review command
+func NewReviewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "review [path]",
+		Short: "Resurface notes from a persistent review queue",
+		Long: `Resurface notes from a persistent review queue.
+
+Notes shown by review are remembered in the queue file so they are not
+repeated until every note in the pool has been surfaced, at which point
+a new cycle starts. Accepts the same --where and --weight-by options as
+'mdnotes random'.
+
+Examples:
+  # Daily review of three evergreen notes
+  mdnotes review --where "tags has 'evergreen'" --weight-by age -n 3 .
+
+  # Start over
+  mdnotes review --reset .`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runReview,
+	}
+
+	addPickFlags(cmd)
+	cmd.Flags().String("queue-file", "", "Queue file path (default: <vault>/"+review.DefaultQueueFile+")")
+	cmd.Flags().Bool("reset", false, "Clear the review queue before picking")
+
+	return cmd
+}
+
+// addPickFlags adds the flags shared by random and review
+func addPickFlags(cmd *cobra.Command) {
+	cmd.Flags().String("where", "", "Filter expression (e.g., \"tags has 'evergreen'\")")
+	cmd.Flags().String("weight-by", "", "Weighting expression (age, recency, words, <field>, 1/<term>)")
+	cmd.Flags().IntP("count", "n", 1, "Number of notes to pick")
+	cmd.Flags().Uint64("seed", 0, "Random seed for reproducible picks (default: time-based)")
+	cmd.Flags().String("format", "text", "Output format: text, json, paths")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+}
+
+func runRandom(cmd *cobra.Command, args []string) error {
+	path := pathArg(args)
+
+	pool, picker, err := loadPool(cmd, path)
+	if err != nil {
+		return err
+	}
+
+	count, _ := cmd.Flags().GetInt("count")
+	format, _ := cmd.Flags().GetString("format")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if len(pool) == 0 {
+		if !quiet {
+			fmt.Println("No notes match the criteria")
+		}
+		return nil
+	}
+
+	return outputPicks(picker.Pick(pool, count), format)
+}
+
+func runReview(cmd *cobra.Command, args []string) error {
+	path := pathArg(args)
+
+	pool, picker, err := loadPool(cmd, path)
+	if err != nil {
+		return err
+	}
+
+	count, _ := cmd.Flags().GetInt("count")
+	format, _ := cmd.Flags().GetString("format")
+	queueFile, _ := cmd.Flags().GetString("queue-file")
+	reset, _ := cmd.Flags().GetBool("reset")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	if queueFile == "" {
+		queueFile = filepath.Join(vaultRoot(path), review.DefaultQueueFile)
+	}
+
+	queue, err := review.LoadQueue(queueFile)
+	if err != nil {
+		return err
+	}
+
+	if reset {
+		queue.Reset()
+	}
+
+	if len(pool) == 0 {
+		if !quiet {
+			fmt.Println("No notes match the criteria")
+		}
+		return nil
+	}
+
+	picks := queue.Next(picker, pool, count)
+	if err := outputPicks(picks, format); err != nil {
+		return err
+	}
+
+	if !dryRun {
+		if err := queue.Save(); err != nil {
+			return err
+		}
+	}
+
+	if !quiet && format == "text" {
+		fmt.Printf("\n%d of %d notes remaining in this review cycle\n", queue.Remaining(pool), len(pool))
+	}
+
+	return nil
+}
+
+// loadPool selects and filters files and builds a picker from the command flags
+func loadPool(cmd *cobra.Command, path string) ([]*vault.VaultFile, *review.Picker, error) {
+	whereExpr, _ := cmd.Flags().GetString("where")
+	weightBy, _ := cmd.Flags().GetString("weight-by")
+	seed, _ := cmd.Flags().GetUint64("seed")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	format, _ := cmd.Flags().GetString("format")
+
+	switch format {
+	case "text", "json", "paths":
+	default:
+		return nil, nil, fmt.Errorf("unsupported format: %s (supported: text, json, paths)", format)
+	}
+
+	weighting, err := review.ParseWeighting(weightBy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing --weight-by: %w", err)
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cmd.Flags().Changed("ignore") {
+		fileSelector = fileSelector.WithIgnorePatterns(ignorePatterns)
+	}
+
+	selection, err := fileSelector.SelectFiles(path, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := selection.Files
+	if whereExpr != "" {
+		expr, err := query.NewParser(whereExpr).Parse()
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing --where expression: %w", err)
+		}
+		query.Bind(expr, files, processor.NewLinkParser())
+
+		var filtered []*vault.VaultFile
+		for _, file := range files {
+			if expr.Evaluate(file) {
+				filtered = append(filtered, file)
+			}
+		}
+		files = filtered
+	}
+
+	// Stable ordering so seeded picks are reproducible regardless of walk order
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].RelativePath < files[j].RelativePath
+	})
+
+	return files, review.NewPicker(weighting, seed), nil
+}
+
+// outputPicks prints picked files in the requested format
+func outputPicks(files []*vault.VaultFile, format string) error {
+	switch format {
+	case "paths":
+		for _, file := range files {
+			fmt.Println(file.Path)
+		}
+	case "json":
+		var results []map[string]interface{}
+		for _, file := range files {
+			result := map[string]interface{}{
+				"file":     file.RelativePath,
+				"path":     file.Path,
+				"modified": file.Modified,
+			}
+			if title, exists := file.GetField("title"); exists {
+				result["title"] = title
+			}
+			results = append(results, result)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	default:
+		for _, file := range files {
+			if title, exists := file.GetField("title"); exists {
+				fmt.Printf("%s (%v)\n", file.RelativePath, title)
+			} else {
+				fmt.Println(file.RelativePath)
+			}
+		}
+	}
+	return nil
+}
+
+// pathArg returns the path argument or the current directory
+func pathArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
+
+// vaultRoot returns the directory used to locate the review queue
+func vaultRoot(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
@@ -0,0 +1,246 @@
+package refactor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewMergeCommand creates the refactor merge command
+func NewMergeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge [file] [file]",
+		Short: "Merge one note into another",
+		Long: `Merge two notes into one. The note not named by --into (the first note by
+default) is appended to it under a level 2 heading - its H1, title or file
+name, or --heading - with its own headings demoted beneath it.
+
+Frontmatter is reconciled: the kept note's fields win, fields it lacks are
+copied across, and tags, aliases and other lists combine both notes' values.
+The merged note's name is added to the aliases. Links to the merged note
+across the vault are pointed at the kept one, and the merged note is then
+deleted, or moved to --archive and marked with 'merged_into'.
+
+Examples:
+  # Merge a duplicate into the original, previewing first
+  mdnotes refactor merge notes/Coffee.md inbox/coffee-notes.md --dry-run
+  mdnotes refactor merge notes/Coffee.md inbox/coffee-notes.md
+
+  # Keep the second note, archiving the first
+  mdnotes refactor merge a.md b.md --into b.md --archive archive/merged`,
+		Args: cobra.ExactArgs(2),
+		RunE: runMerge,
+	}
+
+	cmd.Flags().String("into", "", "Note to keep, one of the two given (default: the first)")
+	cmd.Flags().String("heading", "", "Heading to place the merged content under")
+	cmd.Flags().String("archive", "", "Move the merged note to this folder, relative to the vault root, instead of deleting it")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns for scanning the vault")
+
+	return cmd
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	into, _ := cmd.Flags().GetString("into")
+	heading, _ := cmd.Flags().GetString("heading")
+	archive, _ := cmd.Flags().GetString("archive")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	var paths [2]string
+	for i, arg := range args {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", arg, err)
+		}
+		paths[i] = abs
+	}
+	if paths[0] == paths[1] {
+		return fmt.Errorf("can't merge %s into itself", args[0])
+	}
+	if into != "" {
+		abs, err := filepath.Abs(into)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", into, err)
+		}
+		switch abs {
+		case paths[0]:
+		case paths[1]:
+			paths[0], paths[1] = paths[1], paths[0]
+		default:
+			return fmt.Errorf("--into must be one of the notes being merged")
+		}
+	}
+
+	root := safety.FindVaultRoot(paths[0])
+	var notes [2]*vault.VaultFile
+	for i, path := range paths {
+		note, err := readNote(root, path)
+		if err != nil {
+			return err
+		}
+		notes[i] = note
+	}
+	kept, merged := notes[0], notes[1]
+
+	archivePath := ""
+	if archive != "" {
+		archivePath = filepath.Join(root, archive, filepath.Base(merged.RelativePath))
+		if rel, err := filepath.Rel(root, archivePath); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("archive folder %s is outside the vault", archive)
+		}
+		if _, err := os.Stat(archivePath); err == nil {
+			return fmt.Errorf("%s already exists", archivePath)
+		}
+	}
+
+	merge := processor.MergeNotes(kept, merged, heading)
+
+	// Point links to the merged note at the kept one, including the kept
+	// note's own and those in the content merged into it
+	moves := []processor.FileMove{merge.MergeMove()}
+	updater := processor.NewLinkUpdater()
+	updater.UpdateFile(kept, moves)
+
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
+	files, err := scanner.Walk(root)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	var relinked []*vault.VaultFile
+	for _, file := range files {
+		if rel := filepath.Clean(file.RelativePath); rel == filepath.Clean(kept.RelativePath) || rel == filepath.Clean(merged.RelativePath) {
+			continue
+		}
+		if updater.UpdateReferences(file.Body, moves) == file.Body {
+			continue
+		}
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %s\n", file.RelativePath, reason)
+			}
+			continue
+		}
+		updater.UpdateFile(file, moves)
+		relinked = append(relinked, file)
+	}
+
+	if dryRun {
+		fmt.Printf("Would merge %s into %s under '## %s'\n", merged.RelativePath, kept.RelativePath, merge.Heading)
+		if len(merge.Merged) > 0 {
+			fmt.Printf("  Merged fields: %s\n", strings.Join(merge.Merged, ", "))
+		}
+		for _, file := range relinked {
+			fmt.Printf("Would update links in %s\n", file.RelativePath)
+		}
+		if archivePath != "" {
+			fmt.Printf("Would archive %s to %s\n", merged.RelativePath, filepath.Join(archive, filepath.Base(merged.RelativePath)))
+		} else {
+			fmt.Printf("Would remove %s\n", merged.RelativePath)
+		}
+		if verbose {
+			output, err := kept.Serialize()
+			if err != nil {
+				return fmt.Errorf("serializing %s: %w", kept.RelativePath, err)
+			}
+			fmt.Printf("\n%s", output)
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("refactor merge " + merged.RelativePath)
+
+	for _, file := range append([]*vault.VaultFile{kept}, relinked...) {
+		if err := writeNote(tx, file); err != nil {
+			return err
+		}
+	}
+	if !quiet {
+		fmt.Printf("✓ Merged %s into %s\n", merged.RelativePath, kept.RelativePath)
+		if len(merge.Merged) > 0 {
+			fmt.Printf("  Merged fields: %s\n", strings.Join(merge.Merged, ", "))
+		}
+		for _, file := range relinked {
+			fmt.Printf("✓ Updated links in %s\n", file.RelativePath)
+		}
+	}
+
+	if archivePath != "" {
+		merged.SetField("merged_into", "[["+strings.TrimSuffix(filepath.ToSlash(kept.RelativePath), ".md")+"]]")
+		if err := writeNote(tx, merged); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+			return fmt.Errorf("creating archive folder: %w", err)
+		}
+		if err := tx.RecordMove(merged.Path, archivePath); err != nil {
+			return fmt.Errorf("recording move of %s: %w", merged.RelativePath, err)
+		}
+		if err := os.Rename(merged.Path, archivePath); err != nil {
+			return fmt.Errorf("archiving %s: %w", merged.RelativePath, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ Archived %s to %s\n", merged.RelativePath, filepath.Join(archive, filepath.Base(merged.RelativePath)))
+		}
+		return nil
+	}
+
+	if err := tx.RecordDelete(merged.Path); err != nil {
+		return fmt.Errorf("recording removal of %s: %w", merged.RelativePath, err)
+	}
+	if err := os.Remove(merged.Path); err != nil {
+		return fmt.Errorf("removing %s: %w", merged.RelativePath, err)
+	}
+	if !quiet {
+		fmt.Printf("✓ Removed %s\n", merged.RelativePath)
+	}
+	return nil
+}
+
+// readNote reads and parses the note at path, an absolute path in the vault
+// at root, refusing locked notes
+func readNote(root, path string) (*vault.VaultFile, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("%s is outside the vault", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading note: %w", err)
+	}
+	note := &vault.VaultFile{Path: path, RelativePath: rel}
+	if err := note.Parse(content); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", rel, err)
+	}
+	if reason := note.LockReason(); reason != "" {
+		return nil, fmt.Errorf("%s is locked: %s", rel, reason)
+	}
+	return note, nil
+}
+
+// writeNote serializes and writes note, recording it in tx
+func writeNote(tx *safety.Transaction, note *vault.VaultFile) error {
+	content, err := note.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", note.RelativePath, err)
+	}
+	if err := tx.RecordWrite(note.Path); err != nil {
+		return fmt.Errorf("recording change to %s: %w", note.RelativePath, err)
+	}
+	if err := safety.WriteFile(note.Path, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", note.RelativePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package refactor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewRefactorCommand creates the refactor command
+func NewRefactorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refactor",
+		Short: "Restructure notes, keeping links to them working",
+		Long: `Commands that move content between notes, or merge notes, and update the
+links that point to it.`,
+	}
+
+	cmd.AddCommand(NewExtractCommand())
+	cmd.AddCommand(NewMergeCommand())
+
+	return cmd
+}
+
+// NewExtractCommand creates the refactor extract command
+func NewExtractCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "extract [file]",
+		Short: "Move a section of a note into a new note",
+		Long: `Move the section under a heading into a new note. The section runs from its
+heading to the next heading of the same or a higher level; in the new note its
+heading becomes a level 1 heading, and a link to the new note is left where
+the section was.
+
+The new note is created at --to, relative to the note's folder, or named after
+the heading. Frontmatter fields given with --carry are copied to it. Links
+across the vault to the moved heading and its subheadings are pointed at the
+new note, and links inside the section to headings left behind at the old one.
+
+Examples:
+  # Move a section into a note of its own
+  mdnotes refactor extract notes/reading.md --heading "## Fiction" --to "Fiction.md"
+
+  # Carry the tags and source across, and preview the changes
+  mdnotes refactor extract notes/reading.md --heading "Fiction" --carry tags,source --dry-run --verbose`,
+		Args: cobra.ExactArgs(1),
+		RunE: runExtract,
+	}
+
+	cmd.Flags().String("heading", "", "Heading of the section to extract")
+	cmd.Flags().String("to", "", "Path of the new note, relative to the note's folder (default: the heading)")
+	cmd.Flags().StringSlice("carry", []string{"tags"}, "Frontmatter fields to copy to the new note")
+	cmd.Flags().Bool("link", true, "Leave a link to the new note where the section was")
+	cmd.Flags().Bool("update-links", true, "Update links to the section in other notes")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns for scanning the vault")
+	_ = cmd.MarkFlagRequired("heading")
+
+	return cmd
+}
+
+func runExtract(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	heading, _ := cmd.Flags().GetString("heading")
+	to, _ := cmd.Flags().GetString("to")
+	carry, _ := cmd.Flags().GetStringSlice("carry")
+	link, _ := cmd.Flags().GetBool("link")
+	updateLinks, _ := cmd.Flags().GetBool("update-links")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", path, err)
+	}
+	root := safety.FindVaultRoot(absPath)
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("reading note: %w", err)
+	}
+	file := &vault.VaultFile{Path: absPath, RelativePath: relPath}
+	if err := file.Parse(content); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if reason := file.LockReason(); reason != "" {
+		return fmt.Errorf("%s is locked: %s", path, reason)
+	}
+
+	if to == "" {
+		section, err := processor.FindSection(file.Body, heading)
+		if err != nil {
+			return fmt.Errorf("extracting from %s: %w", path, err)
+		}
+		to = processor.SectionNoteName(section.Heading.Text) + ".md"
+	} else if !strings.HasSuffix(strings.ToLower(to), ".md") {
+		to += ".md"
+	}
+	notePath := filepath.Join(filepath.Dir(absPath), to)
+	noteRel, err := filepath.Rel(root, notePath)
+	if err != nil || strings.HasPrefix(noteRel, "..") {
+		return fmt.Errorf("%s is outside the vault", to)
+	}
+	if _, err := os.Stat(notePath); err == nil {
+		return fmt.Errorf("%s already exists", noteRel)
+	}
+
+	note := &vault.VaultFile{Path: notePath, RelativePath: noteRel}
+	splitter := &processor.NoteSplitter{Link: link}
+	move, err := splitter.Extract(file, heading, note, carry)
+	if err != nil {
+		return fmt.Errorf("extracting from %s: %w", path, err)
+	}
+
+	// Other notes linking to the moved headings
+	var updated []*vault.VaultFile
+	if updateLinks {
+		scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
+		files, err := scanner.Walk(root)
+		if err != nil {
+			return fmt.Errorf("scanning vault: %w", err)
+		}
+		for _, other := range files {
+			if filepath.Clean(other.RelativePath) == filepath.Clean(relPath) {
+				continue
+			}
+			body, count := processor.UpdateSectionLinks(other.Body, other.RelativePath, move)
+			if count == 0 {
+				continue
+			}
+			if reason := other.LockReason(); reason != "" {
+				if !quiet {
+					fmt.Printf("⚠ Skipping %s: %s\n", other.RelativePath, reason)
+				}
+				continue
+			}
+			other.Body = body
+			updated = append(updated, other)
+			if verbose {
+				fmt.Printf("%s: %d links to update\n", other.RelativePath, count)
+			}
+		}
+	}
+
+	noteOutput, err := note.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", noteRel, err)
+	}
+	output, err := file.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", relPath, err)
+	}
+
+	if dryRun {
+		fmt.Printf("Would create %s\n", noteRel)
+		if verbose {
+			fmt.Printf("\n%s\n", noteOutput)
+		}
+		fmt.Printf("Would update %s\n", relPath)
+		if verbose {
+			fmt.Printf("\n%s\n", output)
+		}
+		for _, other := range updated {
+			fmt.Printf("Would update links in %s\n", other.RelativePath)
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("refactor extract " + relPath)
+
+	write := func(target string, data []byte) error {
+		if err := tx.RecordWrite(target); err != nil {
+			return fmt.Errorf("recording change: %w", err)
+		}
+		if err := safety.WriteFile(target, data, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", target, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(notePath), 0755); err != nil {
+		return fmt.Errorf("creating folder for %s: %w", noteRel, err)
+	}
+	if err := write(notePath, noteOutput); err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("✓ Created %s\n", noteRel)
+	}
+	if err := write(absPath, output); err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("✓ Updated %s\n", relPath)
+	}
+	for _, other := range updated {
+		data, err := other.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", other.RelativePath, err)
+		}
+		if err := write(other.Path, data); err != nil {
+			return err
+		}
+		if !quiet {
+			fmt.Printf("✓ Updated links in %s\n", other.RelativePath)
+		}
+	}
+	return nil
+}
@@ -10,21 +10,35 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/diff"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/pkg/template"
 )
 
 // NewRenameCommand creates the rename command
 func NewRenameCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "rename [path] [template_or_target]",
+		Use:     "rename [path...] [template_or_target]",
 		Aliases: []string{"r"},
 		Short:   "Rename files and update all references",
 		Long: `Rename markdown files and automatically update all references throughout the vault.
 This command ensures vault integrity by updating both wiki links ([[file]]) and markdown links ([text](file.md)) 
 that point to renamed files.
 
-Works with both single files and entire directories.
+Works with both single files and entire directories. A directory or glob alone renames
+each file in place using a template. When the last argument is a directory (ending in
+"/" or existing), every other argument - files, globs or directories - is moved into it
+and links to all moved files are updated in a single pass. Directories move whole,
+keeping their structure; destinations are relative to the vault root.
+
+--rule renames every selected file - those under path, or those chosen with --query,
+--from-file or --from-stdin - to the name the rule template gives it, always applying
+the rule, and updates links to all renamed files in a single pass.
 
 Examples:
   # Rename a single file
@@ -40,12 +54,26 @@ Examples:
   # Rename all files in directory with custom template
   mdnotes rename /path/to/vault/ "{{created|date:2006-01-02}}-{{title|slug}}.md"
   
-  # Preview changes without applying them
+  # Rename files matching a glob using default template
+  mdnotes rename "inbox/*.md"
+
+  # Move files matching a glob into a directory, updating links to all of them
+  mdnotes rename "projects/*.md" areas/projects/
+
+  # Move several files, or a whole directory, into a directory
+  mdnotes rename draft.md ideas.md archive/
+  mdnotes rename projects/ areas/
+
+  # Rename notes matching a query to a canonical naming scheme
+  mdnotes rename --rule "{{created|date:YYYYMMDDHHmm}}-{{title|slug}}.md" --query "type = 'zettel'"
+
+  # Preview changes without applying them, with a diff of updated links
   mdnotes rename --dry-run /path/to/vault/
+  mdnotes rename --dry-run "projects/*.md" areas/projects/
   
   # Rename with verbose output
   mdnotes rename --verbose /path/to/vault/`,
-		Args: cobra.RangeArgs(1, 2),
+		Args: cobra.ArbitraryArgs,
 		RunE: runRename,
 	}
 
@@ -53,6 +81,7 @@ Examples:
 	cmd.Flags().String("vault", ".", "Vault root directory for link updates")
 	cmd.Flags().String("template", "{{created|date:20060102150405}}-{{filename|slug_underscore}}.md", "Template for default rename target")
 	cmd.Flags().Int("workers", runtime.NumCPU(), "Number of worker goroutines for parallel processing")
+	cmd.Flags().String("rule", "", "Rename every selected file to this naming template, e.g. \"{{created|date:YYYYMMDDHHmm}}-{{title|slug}}.md\"")
 
 	return cmd
 }
@@ -60,10 +89,17 @@ Examples:
 func runRename(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	if rule, _ := cmd.Flags().GetString("rule"); rule != "" {
+		return runRuleRename(ctx, cmd, args, rule)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("requires a path to rename, or --rule")
+	}
+
 	path := args[0]
 	var templateOrTarget string
-	if len(args) == 2 {
-		templateOrTarget = args[1]
+	if len(args) >= 2 {
+		templateOrTarget = args[len(args)-1]
 	}
 
 	// Get flags
@@ -80,6 +116,33 @@ func runRename(cmd *cobra.Command, args []string) error {
 		verbose = false
 	}
 
+	// Several sources, or any source moving into a directory, are moved together
+	if len(args) >= 2 && isDestinationDir(templateOrTarget) {
+		vaultAbs, err := filepath.Abs(vaultRoot)
+		if err != nil {
+			return fmt.Errorf("getting absolute path for vault: %w", err)
+		}
+		tx := cli.BeginTransaction(cmd, vaultAbs)
+		defer cli.CommitTransaction(cmd, tx)
+		return runBatchMove(ctx, args[:len(args)-1], templateOrTarget, vaultAbs,
+			ignorePatterns, workers, dryRun, verbose, quiet, tx)
+	}
+	if len(args) > 2 {
+		return fmt.Errorf("moving several sources needs a destination directory, e.g. \"%s/\"", strings.TrimSuffix(templateOrTarget, "/"))
+	}
+
+	// A glob renames each matching file using the template
+	if hasGlobMeta(path) {
+		vaultAbs, err := filepath.Abs(vaultRoot)
+		if err != nil {
+			return fmt.Errorf("getting absolute path for vault: %w", err)
+		}
+		tx := cli.BeginTransaction(cmd, vaultAbs)
+		defer cli.CommitTransaction(cmd, tx)
+		return runGlobRename(ctx, path, vaultAbs, templateOrTarget, defaultTemplate,
+			ignorePatterns, workers, dryRun, verbose, quiet, tx)
+	}
+
 	// Validate path exists
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -100,20 +163,23 @@ func runRename(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting absolute path for vault: %w", err)
 	}
 
+	tx := cli.BeginTransaction(cmd, vaultAbs)
+	defer cli.CommitTransaction(cmd, tx)
+
 	if info.IsDir() {
 		// Directory mode: rename all markdown files using template
 		return runDirectoryRename(ctx, pathAbs, vaultAbs, templateOrTarget, defaultTemplate,
-			ignorePatterns, workers, dryRun, verbose, quiet)
+			ignorePatterns, workers, dryRun, verbose, quiet, tx)
 	} else {
 		// Single file mode: existing logic
 		return runSingleFileRename(ctx, pathAbs, vaultAbs, templateOrTarget, defaultTemplate,
-			ignorePatterns, workers, dryRun, verbose, quiet)
+			ignorePatterns, workers, dryRun, verbose, quiet, tx)
 	}
 }
 
 // runSingleFileRename handles renaming a single file
 func runSingleFileRename(ctx context.Context, sourceAbs, vaultAbs, templateOrTarget, defaultTemplate string,
-	ignorePatterns []string, workers int, dryRun, verbose, quiet bool) error {
+	ignorePatterns []string, workers int, dryRun, verbose, quiet bool, tx *safety.Transaction) error {
 
 	var newName string
 	if templateOrTarget != "" {
@@ -179,6 +245,7 @@ func runSingleFileRename(ctx context.Context, sourceAbs, vaultAbs, templateOrTar
 		DryRun:         dryRun,
 		Verbose:        verbose,
 		Workers:        workers,
+		Transaction:    tx,
 	}
 
 	renameProcessor := processor.NewRenameProcessor(options)
@@ -207,6 +274,7 @@ func runSingleFileRename(ctx context.Context, sourceAbs, vaultAbs, templateOrTar
 		} else {
 			fmt.Println("No references found to update")
 		}
+		printSkippedFiles(result.SkippedFiles)
 	} else {
 		if !quiet {
 			fmt.Printf("✓ Renamed: %s -> %s\n", sourceRel, targetRel)
@@ -216,21 +284,32 @@ func runSingleFileRename(ctx context.Context, sourceAbs, vaultAbs, templateOrTar
 			if verbose {
 				fmt.Printf("Processed %d files in %v\n", result.FilesScanned, result.Duration)
 			}
+			printSkippedFiles(result.SkippedFiles)
 		}
 	}
 
 	return nil
 }
 
+// printSkippedFiles reports linking files whose links were left untouched
+func printSkippedFiles(skipped []processor.SkippedFile) {
+	for _, file := range skipped {
+		fmt.Printf("⚠ Skipped link update in %s (locked: %s)\n", file.Path, file.Reason)
+	}
+}
+
 // runDirectoryRename handles renaming all markdown files in a directory
 func runDirectoryRename(ctx context.Context, pathAbs, vaultAbs, templateOrTarget, defaultTemplate string,
-	ignorePatterns []string, workers int, dryRun, verbose, quiet bool) error {
+	ignorePatterns []string, workers int, dryRun, verbose, quiet bool, tx *safety.Transaction) error {
 
 	// Determine template to use
 	template := defaultTemplate
 	if templateOrTarget != "" {
 		template = templateOrTarget
 	}
+	if err := templates.NewRenderer().Validate(template); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
 
 	// Use Scanner to find all markdown files
 	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
@@ -239,6 +318,14 @@ func runDirectoryRename(ctx context.Context, pathAbs, vaultAbs, templateOrTarget
 		return fmt.Errorf("scanning directory: %w", err)
 	}
 
+	return runTemplateRename(ctx, files, vaultAbs, template, templateOrTarget != "",
+		ignorePatterns, workers, dryRun, verbose, quiet, tx)
+}
+
+// runTemplateRename renames each of files in place using template
+func runTemplateRename(ctx context.Context, files []*vault.VaultFile, vaultAbs, template string, customTemplate bool,
+	ignorePatterns []string, workers int, dryRun, verbose, quiet bool, tx *safety.Transaction) error {
+
 	if len(files) == 0 {
 		if !quiet {
 			fmt.Println("No markdown files found")
@@ -248,7 +335,7 @@ func runDirectoryRename(ctx context.Context, pathAbs, vaultAbs, templateOrTarget
 
 	if verbose {
 		fmt.Printf("Found %d markdown files to process\n", len(files))
-		if templateOrTarget != "" {
+		if customTemplate {
 			fmt.Printf("Using custom template: %s\n", template)
 		} else {
 			fmt.Printf("Using default template: %s\n", template)
@@ -274,6 +361,15 @@ func runDirectoryRename(ctx context.Context, pathAbs, vaultAbs, templateOrTarget
 			sourcePath: file.Path,
 		}
 
+		// Locked files keep their names
+		if reason := file.LockReason(); reason != "" {
+			if verbose {
+				fmt.Printf("Examining: %s - Skipped (locked: %s)\n", file.RelativePath, reason)
+			}
+			operations = append(operations, op)
+			continue
+		}
+
 		// Generate target name using template
 		generatedName, err := processor.GenerateNameFromTemplate(file.Path, template)
 		if err != nil {
@@ -397,6 +493,7 @@ func runDirectoryRename(ctx context.Context, pathAbs, vaultAbs, templateOrTarget
 			DryRun:         false, // Already checked above
 			Verbose:        false, // Control output at this level
 			Workers:        workers,
+			Transaction:    tx,
 		}
 
 		renameProcessor := processor.NewRenameProcessor(options)
@@ -446,6 +543,362 @@ func runDirectoryRename(ctx context.Context, pathAbs, vaultAbs, templateOrTarget
 	return nil
 }
 
+// runRuleRename renames every selected file to the name rule gives it,
+// updating links to all of them in one pass. Files are those under path
+// (default: current directory), or those chosen with --query, --from-file or
+// --from-stdin.
+func runRuleRename(ctx context.Context, cmd *cobra.Command, args []string, rule string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("--rule takes at most one path; select files with --query, --from-file or --from-stdin")
+	}
+	path := "."
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	vaultRoot, _ := cmd.Flags().GetString("vault")
+	workers, _ := cmd.Flags().GetInt("workers")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+	if quiet {
+		verbose = false
+	}
+
+	if err := templates.NewRenderer().Validate(rule); err != nil {
+		return fmt.Errorf("invalid rule: %w", err)
+	}
+	vaultAbs, err := filepath.Abs(vaultRoot)
+	if err != nil {
+		return fmt.Errorf("getting absolute path for vault: %w", err)
+	}
+
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("getting file selection config: %w", err)
+	}
+	selection, err := fileSelector.WithIgnorePatterns(ignorePatterns).SelectFiles(path, mode)
+	if err != nil {
+		return fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	moves, problems := planRuleMoves(selection.Files, rule, vaultAbs, verbose)
+	if len(problems) > 0 {
+		fmt.Printf("Errors encountered:\n")
+		for _, problem := range problems {
+			fmt.Printf("  - %v\n", problem)
+		}
+		if !dryRun {
+			return fmt.Errorf("cannot proceed with %d errors", len(problems))
+		}
+	}
+	if len(moves) == 0 {
+		if !quiet {
+			fmt.Println("No files need renaming")
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, vaultAbs)
+	defer cli.CommitTransaction(cmd, tx)
+	return applyMoves(ctx, moves, vaultAbs, "Renamed", "Would rename", ignorePatterns, workers, dryRun, verbose, quiet, tx)
+}
+
+// planRuleMoves names each file with rule. Names with a path are relative to
+// the vault root, others stay in the file's directory. Files already named by
+// the rule and locked files are left out; names that can't be rendered or
+// collide are returned as problems.
+func planRuleMoves(files []*vault.VaultFile, rule, vaultAbs string, verbose bool) ([]processor.FileMove, []error) {
+	engine := template.NewEngine()
+	var moves []processor.FileMove
+	var problems []error
+	targets := make(map[string]string)
+
+	for _, file := range files {
+		sourceAbs, err := filepath.Abs(file.Path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", file.Path, err))
+			continue
+		}
+		sourceRel := sourceAbs
+		if rel, err := filepath.Rel(vaultAbs, sourceAbs); err == nil {
+			sourceRel = filepath.ToSlash(rel)
+		}
+		file.RelativePath = sourceRel
+
+		if reason := file.LockReason(); reason != "" {
+			if verbose {
+				fmt.Printf("Examining: %s - Skipped (locked: %s)\n", sourceRel, reason)
+			}
+			continue
+		}
+
+		name, err := engine.Render(rule, file)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", sourceRel, err))
+			continue
+		}
+		if strings.Trim(strings.TrimSuffix(name, ".md"), "-_ ") == "" {
+			problems = append(problems, fmt.Errorf("%s: rule gives an empty name", sourceRel))
+			continue
+		}
+		targetAbs := filepath.Join(filepath.Dir(sourceAbs), name)
+		if strings.Contains(name, "/") {
+			targetAbs = filepath.Join(vaultAbs, name)
+		}
+		if !strings.HasSuffix(targetAbs, ".md") {
+			targetAbs += ".md"
+		}
+		if isSameFile(sourceAbs, targetAbs) {
+			continue
+		}
+
+		targetRel := targetAbs
+		if rel, err := filepath.Rel(vaultAbs, targetAbs); err == nil {
+			targetRel = filepath.ToSlash(rel)
+		}
+		if other, exists := targets[targetAbs]; exists {
+			problems = append(problems, fmt.Errorf("target name conflict: both %s and %s would be renamed to %s", other, sourceRel, targetRel))
+			continue
+		}
+		if _, err := os.Stat(targetAbs); err == nil {
+			problems = append(problems, fmt.Errorf("%s: target file already exists: %s", sourceRel, targetRel))
+			continue
+		}
+		targets[targetAbs] = sourceRel
+		moves = append(moves, processor.FileMove{From: sourceAbs, To: targetAbs})
+	}
+	return moves, problems
+}
+
+// isDestinationDir reports whether target names a directory to move files
+// into: one ending in a path separator, or an existing directory
+func isDestinationDir(target string) bool {
+	if strings.HasSuffix(target, "/") || strings.HasSuffix(target, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(target)
+	return err == nil && info.IsDir()
+}
+
+// hasGlobMeta reports whether path contains glob pattern characters
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandSource returns the absolute paths of the files or directories source
+// names, expanding globs
+func expandSource(source string) ([]string, error) {
+	matches := []string{source}
+	if hasGlobMeta(source) {
+		var err error
+		if matches, err = filepath.Glob(source); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", source, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", source)
+		}
+	}
+
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if _, err := os.Stat(match); os.IsNotExist(err) {
+			return nil, fmt.Errorf("path does not exist: %s", match)
+		} else if err != nil {
+			return nil, fmt.Errorf("accessing path: %w", err)
+		}
+		abs, err := filepath.Abs(match)
+		if err != nil {
+			return nil, fmt.Errorf("getting absolute path: %w", err)
+		}
+		paths = append(paths, abs)
+	}
+	return paths, nil
+}
+
+// runGlobRename renames each markdown file matching pattern using a template
+func runGlobRename(ctx context.Context, pattern, vaultAbs, templateOrTarget, defaultTemplate string,
+	ignorePatterns []string, workers int, dryRun, verbose, quiet bool, tx *safety.Transaction) error {
+
+	template := defaultTemplate
+	if templateOrTarget != "" {
+		template = templateOrTarget
+	}
+	if err := templates.NewRenderer().Validate(template); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	paths, err := expandSource(pattern)
+	if err != nil {
+		return err
+	}
+	var files []*vault.VaultFile
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.IsDir() || !strings.HasSuffix(path, ".md") {
+			continue
+		}
+		file, err := vault.LoadVaultFile(path)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(vaultAbs, path); err == nil {
+			file.RelativePath = rel
+		}
+		files = append(files, file)
+	}
+
+	return runTemplateRename(ctx, files, vaultAbs, template, templateOrTarget != "",
+		ignorePatterns, workers, dryRun, verbose, quiet, tx)
+}
+
+// planMoves maps each source file, glob match or markdown file under a source
+// directory to its place in destAbs. Directories move whole, keeping their
+// structure. Locked notes are left out and reported in locked.
+func planMoves(sources []string, destAbs string, ignorePatterns []string) (moves []processor.FileMove, locked []processor.SkippedFile, err error) {
+	seen := make(map[string]bool)
+	add := func(source, target string) {
+		if seen[source] || source == target {
+			return
+		}
+		seen[source] = true
+		moves = append(moves, processor.FileMove{From: source, To: target})
+	}
+
+	for _, source := range sources {
+		paths, err := expandSource(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("accessing path: %w", err)
+			}
+			if !info.IsDir() {
+				if file, err := vault.LoadVaultFile(path); err == nil && file.LockReason() != "" {
+					locked = append(locked, processor.SkippedFile{Path: path, Reason: file.LockReason(), Locked: true})
+					continue
+				}
+				add(path, filepath.Join(destAbs, filepath.Base(path)))
+				continue
+			}
+
+			scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
+			files, err := scanner.Walk(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("scanning directory: %w", err)
+			}
+			for _, file := range files {
+				if reason := file.LockReason(); reason != "" {
+					locked = append(locked, processor.SkippedFile{Path: file.Path, Reason: reason, Locked: true})
+					continue
+				}
+				add(file.Path, filepath.Join(destAbs, filepath.Base(path), file.RelativePath))
+			}
+		}
+	}
+	return moves, locked, nil
+}
+
+// runBatchMove moves sources into the dest directory, updating links to all
+// of them in one pass
+func runBatchMove(ctx context.Context, sources []string, dest, vaultAbs string,
+	ignorePatterns []string, workers int, dryRun, verbose, quiet bool, tx *safety.Transaction) error {
+
+	destAbs := dest
+	if !filepath.IsAbs(dest) {
+		destAbs = filepath.Join(vaultAbs, dest)
+	}
+	if info, err := os.Stat(destAbs); err == nil && !info.IsDir() {
+		return fmt.Errorf("destination is not a directory: %s", dest)
+	}
+
+	moves, locked, err := planMoves(sources, destAbs, ignorePatterns)
+	if err != nil {
+		return err
+	}
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(vaultAbs, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return path
+	}
+	for _, file := range locked {
+		fmt.Printf("⚠ Skipped %s (locked: %s)\n", relative(file.Path), file.Reason)
+	}
+	if len(moves) == 0 {
+		if !quiet {
+			fmt.Println("No files need moving")
+		}
+		return nil
+	}
+
+	return applyMoves(ctx, moves, vaultAbs, "Moved", "Would move", ignorePatterns, workers, dryRun, verbose, quiet, tx)
+}
+
+// applyMoves moves files, updating links to all of them in one pass, and
+// reports the moves with done or wouldDo as verb. Dry runs show a diff of the
+// link updates.
+func applyMoves(ctx context.Context, moves []processor.FileMove, vaultAbs, done, wouldDo string,
+	ignorePatterns []string, workers int, dryRun, verbose, quiet bool, tx *safety.Transaction) error {
+
+	options := processor.RenameOptions{
+		VaultRoot:      vaultAbs,
+		IgnorePatterns: ignorePatterns,
+		DryRun:         dryRun,
+		Verbose:        verbose,
+		Workers:        workers,
+		Transaction:    tx,
+	}
+	renameProcessor := processor.NewRenameProcessor(options)
+	defer func() {
+		if cleanupErr := renameProcessor.Cleanup(); cleanupErr != nil && verbose {
+			fmt.Printf("Warning: error during cleanup: %v\n", cleanupErr)
+		}
+	}()
+
+	result, err := renameProcessor.ProcessMoves(ctx, moves, options)
+	if err != nil {
+		return fmt.Errorf("processing moves: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("%s %d files:\n", wouldDo, len(result.Moves))
+		for _, move := range result.Moves {
+			fmt.Printf("  %s -> %s\n", move.From, move.To)
+		}
+		if result.FilesModified > 0 {
+			fmt.Printf("Would update %d links in %d files\n", result.LinksUpdated, result.FilesModified)
+			for _, edit := range result.Edits {
+				fmt.Println()
+				fmt.Print(diff.Unified("a/"+edit.Path, "b/"+edit.Path, edit.Before, edit.After, diff.DefaultContext))
+			}
+		} else {
+			fmt.Println("No references found to update")
+		}
+		printSkippedFiles(result.SkippedFiles)
+		return nil
+	}
+
+	if !quiet {
+		for _, move := range result.Moves {
+			fmt.Printf("✓ %s: %s -> %s\n", done, move.From, move.To)
+		}
+		if result.FilesModified > 0 {
+			fmt.Printf("✓ Updated %d links in %d files\n", result.LinksUpdated, result.FilesModified)
+		}
+		if verbose {
+			fmt.Printf("Processed %d files in %v\n", result.FilesScanned, result.Duration)
+		}
+		printSkippedFiles(result.SkippedFiles)
+	}
+	return nil
+}
+
 // isSameFile checks if two paths refer to the same file, handling case-insensitive filesystems
 func isSameFile(path1, path2 string) bool {
 	// Quick check for exact match
@@ -776,6 +776,156 @@ created: 2023-01-02
 	}
 }
 
+func TestRenameCommand_GlobMove(t *testing.T) {
+	tmpDir := createTestVault(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "projects"), 0755))
+
+	createTestFile(t, tmpDir, "projects/alpha.md", "# Alpha\n\nSee [[beta]]")
+	createTestFile(t, tmpDir, "projects/beta.md", "# Beta")
+	createTestFile(t, tmpDir, "projects/notes.txt", "not markdown")
+	createTestFile(t, tmpDir, "index.md", "[[alpha]] and [Beta](projects/beta.md)")
+
+	args := []string{
+		filepath.Join(tmpDir, "projects", "*.md"),
+		"areas/projects/",
+		"--vault", tmpDir,
+	}
+	require.NoError(t, runCommandWithRoot(t, NewRenameCommand(), args))
+
+	assert.FileExists(t, filepath.Join(tmpDir, "areas", "projects", "alpha.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "areas", "projects", "beta.md"))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "projects", "alpha.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "projects", "notes.txt"), "only matching files move")
+
+	index, err := os.ReadFile(filepath.Join(tmpDir, "index.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "[[areas/projects/alpha]] and [Beta](areas/projects/beta.md)", string(index))
+
+	alpha, err := os.ReadFile(filepath.Join(tmpDir, "areas", "projects", "alpha.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(alpha), "[[areas/projects/beta]]", "links between moved files are updated")
+}
+
+func TestRenameCommand_DirectoryMove(t *testing.T) {
+	tmpDir := createTestVault(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "projects", "sub"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "areas"), 0755))
+
+	createTestFile(t, tmpDir, "projects/top.md", "# Top")
+	createTestFile(t, tmpDir, "projects/sub/nested.md", "# Nested")
+	createTestFile(t, tmpDir, "draft.md", "# Draft")
+	createTestFile(t, tmpDir, "index.md", "[[projects/sub/nested]] [[top]] [[draft]]")
+
+	// An existing directory is a destination even without a trailing slash
+	args := []string{
+		filepath.Join(tmpDir, "projects"),
+		filepath.Join(tmpDir, "draft.md"),
+		filepath.Join(tmpDir, "areas"),
+		"--vault", tmpDir,
+	}
+	require.NoError(t, runCommandWithRoot(t, NewRenameCommand(), args))
+
+	assert.FileExists(t, filepath.Join(tmpDir, "areas", "projects", "top.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "areas", "projects", "sub", "nested.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "areas", "draft.md"))
+
+	index, err := os.ReadFile(filepath.Join(tmpDir, "index.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "[[areas/projects/sub/nested]] [[areas/projects/top]] [[areas/draft]]", string(index))
+}
+
+func TestRenameCommand_BatchMoveDryRun(t *testing.T) {
+	tmpDir := createTestVault(t)
+	source := createTestFile(t, tmpDir, "alpha.md", "# Alpha")
+	createTestFile(t, tmpDir, "index.md", "[[alpha]]")
+
+	args := []string{source, "archive/", "--vault", tmpDir, "--dry-run"}
+	require.NoError(t, runCommandWithRoot(t, NewRenameCommand(), args))
+
+	assert.FileExists(t, source)
+	assert.NoDirExists(t, filepath.Join(tmpDir, "archive"))
+	index, err := os.ReadFile(filepath.Join(tmpDir, "index.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "[[alpha]]", string(index))
+}
+
+func TestRenameCommand_BatchMoveErrors(t *testing.T) {
+	tmpDir := createTestVault(t)
+	alpha := createTestFile(t, tmpDir, "alpha.md", "# Alpha")
+	beta := createTestFile(t, tmpDir, "beta.md", "# Beta")
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "archive"), 0755))
+	createTestFile(t, tmpDir, "archive/beta.md", "# Archived Beta")
+
+	err := runCommandWithRoot(t, NewRenameCommand(), []string{alpha, beta, "gamma.md", "--vault", tmpDir})
+	assert.ErrorContains(t, err, "destination directory")
+
+	err = runCommandWithRoot(t, NewRenameCommand(), []string{filepath.Join(tmpDir, "*.txt"), "archive/", "--vault", tmpDir})
+	assert.ErrorContains(t, err, "no files match")
+
+	// A conflict stops the whole batch before anything moves
+	err = runCommandWithRoot(t, NewRenameCommand(), []string{alpha, beta, "archive/", "--vault", tmpDir})
+	assert.ErrorContains(t, err, "already exists")
+	assert.FileExists(t, alpha)
+	assert.FileExists(t, beta)
+}
+
+// runRuleCommand runs rename with the global file selection flags available
+func runRuleCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Detailed output")
+	rootCmd.PersistentFlags().Bool("quiet", false, "Suppress output")
+	rootCmd.PersistentFlags().String("query", "", "Filter files using query expression")
+	rootCmd.PersistentFlags().String("from-file", "", "Read file list from specified file")
+	rootCmd.PersistentFlags().Bool("from-stdin", false, "Read file list from stdin")
+	rootCmd.AddCommand(NewRenameCommand())
+
+	rootCmd.SetArgs(append([]string{"rename"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestRenameCommand_Rule(t *testing.T) {
+	tmpDir := createTestVault(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "zettel"), 0755))
+
+	createTestFile(t, tmpDir, "zettel/first.md", "---\ntype: zettel\ntitle: First Idea\ncreated: 2024-03-05T14:30:00\n---\nSee [[second]]\n")
+	createTestFile(t, tmpDir, "zettel/second.md", "---\ntype: zettel\ntitle: Second Idea\ncreated: 2024-03-06\n---\nBody\n")
+	createTestFile(t, tmpDir, "index.md", "---\ntype: note\n---\n[[first]] and [Second](zettel/second.md)\n")
+
+	rule := "{{created|date:YYYYMMDDHHmm}}-{{title|slug}}.md"
+	require.NoError(t, runRuleCommand(t, tmpDir, "--rule", rule, "--query", "type = 'zettel'", "--vault", tmpDir))
+
+	assert.FileExists(t, filepath.Join(tmpDir, "zettel", "202403051430-first-idea.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "zettel", "202403060000-second-idea.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "index.md"), "notes not matching the query keep their names")
+
+	index, err := os.ReadFile(filepath.Join(tmpDir, "index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "[[zettel/202403051430-first-idea]] and [Second](zettel/202403060000-second-idea.md)")
+
+	first, err := os.ReadFile(filepath.Join(tmpDir, "zettel", "202403051430-first-idea.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(first), "[[zettel/202403060000-second-idea]]")
+
+	// Files already named by the rule are left alone
+	require.NoError(t, runRuleCommand(t, tmpDir, "--rule", rule, "--query", "type = 'zettel'", "--vault", tmpDir))
+	assert.FileExists(t, filepath.Join(tmpDir, "zettel", "202403051430-first-idea.md"))
+}
+
+func TestRenameCommand_RuleConflict(t *testing.T) {
+	tmpDir := createTestVault(t)
+	createTestFile(t, tmpDir, "a.md", "---\ntitle: Same\n---\n")
+	createTestFile(t, tmpDir, "b.md", "---\ntitle: Same\n---\n")
+
+	err := runRuleCommand(t, tmpDir, "--rule", "{{title|slug}}", "--vault", tmpDir)
+	assert.ErrorContains(t, err, "cannot proceed")
+	assert.FileExists(t, filepath.Join(tmpDir, "a.md"))
+	assert.FileExists(t, filepath.Join(tmpDir, "b.md"))
+
+	err = runRuleCommand(t, tmpDir, "--rule", "{{title", "--vault", tmpDir)
+	assert.ErrorContains(t, err, "invalid rule")
+}
+
 func BenchmarkRenameCommand_FilenameOnly(b *testing.B) {
 	tmpDir := createTestVault(&testing.T{})
 	defer os.RemoveAll(tmpDir)
@@ -0,0 +1,305 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewReportCommand creates the report command
+func NewReportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Refresh dashboard notes from configured reports",
+		Long: `Keep dashboard notes in the vault up to date. Each report selects notes with a
+query or an analysis and writes them, through a template, into an output note:
+
+reports:
+  - name: reading-queue
+    output: Dashboards/Reading.md
+    query: "type = 'book' AND status = 'queued'"
+    fields: [author, added]
+    limit: 20
+    template: "{{count}} books waiting:\n\n{{results}}"
+  - name: orphans
+    output: Dashboards/Orphans.md
+    analysis: orphans
+    every: 1 week
+
+Results are a list of links, a table when 'fields' are given, or a table of
+aggregates with 'group_by' and 'aggregate', as 'frontmatter query' renders
+them. 'query' may name a saved query (@name) or use ORDER BY and LIMIT;
+'analysis' is orphans or dead-ends. Templates may use results, count, name and
+current_date.
+
+Each report is written between its own markers, so several reports can share
+a note and text around them is kept. Reports with 'every' are skipped while
+their note changed more recently than that. To refresh reports as notes
+change, add a watch rule with the action "mdnotes report run".`,
+	}
+
+	cmd.AddCommand(newRunCommand())
+
+	return cmd
+}
+
+func newRunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run [vault]",
+		Short: "Refresh report notes",
+		Long:  `Refresh the configured reports in the vault containing path (default: current directory)`,
+		Example: `  # Refresh every report due, with reports defined in their own file
+  mdnotes report run --config reports.yaml ~/vault
+
+  # Refresh one report now, previewing the result
+  mdnotes report run --report reading-queue --force --dry-run --verbose ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runReports,
+	}
+
+	cmd.Flags().StringSlice("report", nil, "Only refresh the named reports (repeatable)")
+	cmd.Flags().Bool("force", false, "Refresh reports even if their 'every' interval hasn't passed")
+
+	return cmd
+}
+
+// reportNote is an output note and the reports written into it
+type reportNote struct {
+	file     *vault.VaultFile
+	original string
+	exists   bool
+	reports  []string
+}
+
+func runReports(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	reportNames, _ := cmd.Flags().GetStringSlice("report")
+	force, _ := cmd.Flags().GetBool("force")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	configured, err := selectReports(cfg.Reports, reportNames)
+	if err != nil {
+		return err
+	}
+	if len(configured) == 0 {
+		return fmt.Errorf("no reports configured. Add reports to the 'reports' section in your config file")
+	}
+	var reports []*processor.Report
+	for _, rc := range configured {
+		r, err := processor.NewReport(rc, cfg)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, r)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", path, err)
+	}
+	root := safety.FindVaultRoot(absPath)
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns), vault.WithContinueOnErrors())
+	files, err := scanner.Walk(root)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	names := processor.NoteNames(files)
+
+	now := time.Now()
+	notes := make(map[string]*reportNote)
+	var order []string
+	for _, r := range reports {
+		note, ok := notes[r.Output]
+		if !ok {
+			note, err = readReportNote(root, r.Output)
+			if err != nil {
+				return fmt.Errorf("report '%s': %w", r.Name, err)
+			}
+			notes[r.Output] = note
+			order = append(order, r.Output)
+		}
+		if reason := note.file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipping report %s: %s is locked: %s\n", r.Name, r.Output, reason)
+			}
+			continue
+		}
+		if !force && note.exists && !r.Due(note.file.Modified, now) {
+			if verbose {
+				fmt.Printf("Skipping report %s: %s changed less than %s ago\n", r.Name, r.Output, r.Every)
+			}
+			continue
+		}
+
+		var selected []*vault.VaultFile
+		if r.Analysis != "" {
+			selected = analysisNotes(r, files)
+		} else {
+			selected = r.Select(files)
+		}
+		content, err := r.Render(selected, names)
+		if err != nil {
+			return err
+		}
+		note.file.Body = r.Apply(note.file.Body, content)
+		note.reports = append(note.reports, r.Name)
+		if verbose {
+			fmt.Printf("Report %s: %d notes\n", r.Name, len(selected))
+		}
+	}
+
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+	tx.SetReason("report run")
+
+	for _, output := range order {
+		note := notes[output]
+		if len(note.reports) == 0 {
+			continue
+		}
+		if note.exists && note.file.Body == note.original {
+			if !quiet {
+				fmt.Printf("%s is up to date\n", output)
+			}
+			continue
+		}
+
+		verb, done := "update", "Updated"
+		if !note.exists {
+			verb, done = "create", "Created"
+		}
+		if dryRun {
+			fmt.Printf("Would %s %s (%s)\n", verb, output, strings.Join(note.reports, ", "))
+			if verbose {
+				fmt.Printf("\n%s\n", note.file.Body)
+			}
+			continue
+		}
+
+		content, err := note.file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", output, err)
+		}
+		if err := tx.RecordWrite(note.file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", output, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(note.file.Path), 0755); err != nil {
+			return fmt.Errorf("creating folder for %s: %w", output, err)
+		}
+		if err := safety.WriteFile(note.file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", output, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ %s %s (%s)\n", done, output, strings.Join(note.reports, ", "))
+		}
+	}
+	return nil
+}
+
+// readReportNote reads the output note at rel in the vault at root, or
+// starts a new one if it doesn't exist
+func readReportNote(root, rel string) (*reportNote, error) {
+	path := filepath.Join(root, rel)
+	if r, err := filepath.Rel(root, path); err != nil || strings.HasPrefix(r, "..") {
+		return nil, fmt.Errorf("output %s is outside the vault", rel)
+	}
+
+	file := &vault.VaultFile{Path: path, RelativePath: rel}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return &reportNote{file: file}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rel, err)
+	}
+	if err := file.Parse(content); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", rel, err)
+	}
+	file.Modified = info.ModTime()
+	return &reportNote{file: file, original: file.Body, exists: true}, nil
+}
+
+// analysisNotes returns the notes a report's analysis finds, in path order
+func analysisNotes(r *processor.Report, files []*vault.VaultFile) []*vault.VaultFile {
+	sources := r.Sources(files)
+	byPath := make(map[string]*vault.VaultFile, len(sources))
+	for _, file := range sources {
+		byPath[filepath.ToSlash(file.RelativePath)] = file
+	}
+
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	deadEnds := r.Analysis == "dead-ends"
+	var notes []*vault.VaultFile
+	for _, orphan := range ana.FindOrphans(sources, analyzer.OrphanOptions{DeadEnds: deadEnds}) {
+		if deadEnds && orphan.Kind == analyzer.OrphanKind {
+			continue
+		}
+		if file, ok := byPath[orphan.Path]; ok {
+			notes = append(notes, file)
+		}
+	}
+	return notes
+}
+
+// selectReports returns the reports named, in configuration order, or all of them
+func selectReports(reports []config.ReportConfig, names []string) ([]config.ReportConfig, error) {
+	if len(names) == 0 {
+		return reports, nil
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var selected []config.ReportConfig
+	for _, report := range reports {
+		if wanted[report.Name] {
+			selected = append(selected, report)
+			delete(wanted, report.Name)
+		}
+	}
+	if len(wanted) > 0 {
+		var unknown []string
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown report: %s", strings.Join(unknown, ", "))
+	}
+	return selected, nil
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
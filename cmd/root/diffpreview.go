@@ -0,0 +1,216 @@
+package root
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/diff"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// diffSupported lists the commands --diff can preview. It runs them for real
+// against a copy of the vault, so they must change nothing but notes and
+// files in the vault: no remote services, downloads, caches or files
+// elsewhere. Shortcuts are listed by their own names.
+var diffSupported = []string{
+	"archive",
+	"assets organize",
+	"content normalize", "content style",
+	"daily backfill", "daily create",
+	"duplicates resolve",
+	"frontmatter cast", "frontmatter check", "frontmatter convert", "frontmatter ensure", "frontmatter import",
+	"frontmatter query", "frontmatter remove", "frontmatter rename-field", "frontmatter set", "frontmatter sync",
+	"frontmatter tidy",
+	"headings clean", "headings fix", "headings number", "headings toc",
+	"lifecycle run",
+	"links backlinks", "links convert", "links fix",
+	"moc generate",
+	"new",
+	"refactor extract", "refactor merge",
+	"rename",
+	"report run",
+	"split",
+	"tags merge", "tags rename", "tags sync",
+	"e", "f", "q", "s",
+}
+
+// fileDiff is a file a dry run would change, as reported by --diff
+type fileDiff struct {
+	Kind        safety.SandboxChangeKind `json:"kind"`
+	Path        string                   `json:"path"`
+	From        string                   `json:"from,omitempty"` // Path before a rename
+	Frontmatter []analyzer.FieldChange   `json:"frontmatter,omitempty"`
+	Diff        string                   `json:"diff,omitempty"` // Unified diff of the file, empty for binary files
+	Binary      bool                     `json:"binary,omitempty"`
+}
+
+// diffPreview is the output of --diff-format json
+type diffPreview struct {
+	Files []fileDiff `json:"files"`
+}
+
+// setupDiffPreview makes a dry run of cmd show a diff of each file it would
+// change. The command runs for real against a shadow copy of the vault, as
+// with --sandbox, and the copy is compared with the vault. Paths outside the
+// vault are rejected, since the command would write to them for real.
+func setupDiffPreview(cmd *cobra.Command, args []string) error {
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); !dryRun {
+		return fmt.Errorf("--diff requires --dry-run")
+	}
+	if sandbox, _ := cmd.Flags().GetBool("sandbox"); sandbox {
+		return fmt.Errorf("--diff can't be combined with --sandbox, which already shows diffs")
+	}
+	format, _ := cmd.Flags().GetString("diff-format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid diff format '%s' - valid options are: text, json", format)
+	}
+
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if !slices.Contains(diffSupported, path) {
+		return fmt.Errorf("--diff is not supported for %s; it can preview only commands that change nothing but the vault", cmd.CommandPath())
+	}
+
+	root, err := sandboxVaultRoot(cmd, args, "--diff")
+	if err != nil {
+		return err
+	}
+	wrapRun(cmd, func(cmd *cobra.Command, args []string, run func(*cobra.Command, []string) error) error {
+		return runDiffPreview(cmd, args, root, format, run)
+	})
+	return nil
+}
+
+func runDiffPreview(cmd *cobra.Command, args []string, root, format string, run func(*cobra.Command, []string) error) error {
+	sandbox, mappedArgs, leave, err := enterSandbox(cmd, args, root, true)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	// The command makes its changes to the copy, so its own messages, which
+	// would report them as done and name the copy, are dropped
+	if err := cmd.Root().PersistentFlags().Set("dry-run", "false"); err != nil {
+		return err
+	}
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", os.DevNull, err)
+	}
+	os.Stdout = devNull
+	runErr := run(cmd, mappedArgs)
+	os.Stdout = stdout
+	_ = devNull.Close()
+	_ = cmd.Root().PersistentFlags().Set("dry-run", "true")
+
+	changes, err := sandbox.Changes()
+	if err != nil {
+		return fmt.Errorf("comparing sandbox: %w", err)
+	}
+	preview := buildDiffPreview(sandbox.Root(), sandbox.Shadow(), changes)
+
+	if format == "json" {
+		data, err := json.MarshalIndent(preview, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(preview.Text())
+	}
+	return runErr
+}
+
+// buildDiffPreview diffs each changed file between the vault at root and
+// its changed copy at shadow
+func buildDiffPreview(root, shadow string, changes []safety.SandboxChange) *diffPreview {
+	preview := &diffPreview{Files: []fileDiff{}}
+	for _, change := range changes {
+		file := fileDiff{Kind: change.Kind, Path: change.Path, From: change.From}
+
+		var old, updated []byte
+		var errOld, errNew error
+		switch change.Kind {
+		case safety.SandboxModified:
+			old, errOld = os.ReadFile(filepath.Join(root, change.Path))
+			updated, errNew = os.ReadFile(filepath.Join(shadow, change.Path))
+		case safety.SandboxAdded:
+			updated, errNew = os.ReadFile(filepath.Join(shadow, change.Path))
+		case safety.SandboxDeleted:
+			old, errOld = os.ReadFile(filepath.Join(root, change.Path))
+		case safety.SandboxRenamed:
+			// Renamed files are unchanged by definition
+			preview.Files = append(preview.Files, file)
+			continue
+		}
+		if errOld != nil || errNew != nil || !isText(old) || !isText(updated) {
+			file.Binary = true
+			preview.Files = append(preview.Files, file)
+			continue
+		}
+
+		fromName, toName := "a/"+change.Path, "b/"+change.Path
+		switch change.Kind {
+		case safety.SandboxAdded:
+			fromName = "/dev/null"
+		case safety.SandboxDeleted:
+			toName = "/dev/null"
+		}
+		file.Diff = diff.Unified(fromName, toName, string(old), string(updated), diff.DefaultContext)
+
+		if strings.EqualFold(filepath.Ext(change.Path), ".md") {
+			before, after := parseNote(change.Path, old), parseNote(change.Path, updated)
+			file.Frontmatter = analyzer.CompareNotes(before, after).Frontmatter
+		}
+		preview.Files = append(preview.Files, file)
+	}
+	return preview
+}
+
+// parseNote parses content as a note, for comparing frontmatter; content
+// that can't be parsed compares as a note without any
+func parseNote(path string, content []byte) *vault.VaultFile {
+	file := &vault.VaultFile{RelativePath: path}
+	if err := file.Parse(content); err != nil {
+		return &vault.VaultFile{RelativePath: path}
+	}
+	return file
+}
+
+// Text renders the preview as a summary followed by a unified diff of each
+// text file
+func (p *diffPreview) Text() string {
+	var b strings.Builder
+	if len(p.Files) == 0 {
+		b.WriteString("Dry run: no files would change.\n")
+		return b.String()
+	}
+
+	counts := make(map[safety.SandboxChangeKind]int)
+	for _, file := range p.Files {
+		counts[file.Kind]++
+	}
+	fmt.Fprintf(&b, "Dry run: %d files would change: %d modified, %d renamed, %d added, %d deleted\n", len(p.Files),
+		counts[safety.SandboxModified], counts[safety.SandboxRenamed], counts[safety.SandboxAdded], counts[safety.SandboxDeleted])
+
+	for _, file := range p.Files {
+		b.WriteString("\n")
+		switch {
+		case file.Kind == safety.SandboxRenamed:
+			fmt.Fprintf(&b, "rename %s → %s\n", file.From, file.Path)
+		case file.Binary:
+			fmt.Fprintf(&b, "Binary file %s would be %s\n", file.Path, file.Kind)
+		default:
+			b.WriteString(file.Diff)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+func TestBuildDiffPreview(t *testing.T) {
+	root, shadow := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write(root, "a.md", "---\nstatus: draft\n---\n# A\n")
+	write(shadow, "a.md", "---\nstatus: done\n---\n# A\n")
+	write(shadow, "new.md", "# New\n")
+	write(root, "gone.png", "\x89PNG\x00")
+
+	preview := buildDiffPreview(root, shadow, []safety.SandboxChange{
+		{Kind: safety.SandboxModified, Path: "a.md"},
+		{Kind: safety.SandboxDeleted, Path: "gone.png"},
+		{Kind: safety.SandboxAdded, Path: "new.md"},
+		{Kind: safety.SandboxRenamed, Path: "c.md", From: "b.md"},
+	})
+	require.Len(t, preview.Files, 4)
+
+	modified := preview.Files[0]
+	require.Len(t, modified.Frontmatter, 1)
+	assert.Equal(t, "status", modified.Frontmatter[0].Field)
+	assert.Equal(t, "done", modified.Frontmatter[0].New)
+	assert.Contains(t, modified.Diff, "-status: draft\n+status: done\n")
+
+	assert.True(t, preview.Files[1].Binary)
+	assert.Contains(t, preview.Files[2].Diff, "--- /dev/null\n+++ b/new.md\n")
+
+	text := preview.Text()
+	assert.Contains(t, text, "4 files would change: 1 modified, 1 renamed, 1 added, 1 deleted\n")
+	assert.Contains(t, text, "Binary file gone.png would be deleted\n")
+	assert.Contains(t, text, "rename b.md → c.md\n")
+
+	assert.Equal(t, "Dry run: no files would change.\n", (&diffPreview{}).Text())
+}
+
+func TestSetupDiffPreview(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"frontmatter", "set", "--diff", "--field", "a", "--value", "b", "."}, "--diff requires --dry-run"},
+		{[]string{"frontmatter", "set", "--dry-run", "--diff", "--diff-format", "xml", "--field", "a", "--value", "b", "."}, "invalid diff format 'xml'"},
+		{[]string{"export", "--dry-run", "--diff", "out", "."}, "--diff is not supported for mdnotes export"},
+		{[]string{"frontmatter", "download", "--dry-run", "--diff", "."}, "--diff is not supported for mdnotes frontmatter download"},
+		{[]string{"links", "check", "--dry-run", "--diff", "--external", "."}, "--diff is not supported for mdnotes links check"},
+	} {
+		cmd, args, err := NewRootCommand().Find(tc.args)
+		require.NoError(t, err)
+		require.NoError(t, cmd.ParseFlags(args))
+		assert.ErrorContains(t, setupDiffPreview(cmd, cmd.Flags().Args()), tc.err)
+	}
+}
@@ -0,0 +1,193 @@
+package root
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/i18n"
+)
+
+// presetCommandKey returns the dotted config key for a command (e.g. "frontmatter.ensure")
+func presetCommandKey(cmd *cobra.Command) string {
+	var parts []string
+	for c := cmd; c != nil && c.HasParent(); c = c.Parent() {
+		parts = append([]string{c.Name()}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+// loadPresetConfig loads the config used for command presets. An explicit --config
+// must load; a broken fallback config only produces a warning so unrelated
+// commands keep working.
+func loadPresetConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+
+	cfg, err := config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: ignoring command presets: %v\n", err)
+		return config.DefaultConfig(), nil
+	}
+	return cfg, nil
+}
+
+// applyCommandPresets sets flag values from the config's per-command defaults.
+// Flags given on the command line are left alone. It returns the names of flags
+// that were set from config.
+func applyCommandPresets(cmd *cobra.Command, cfg *config.Config) ([]string, error) {
+	key := presetCommandKey(cmd)
+	defaults := cfg.CommandDefaults(key)
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var applied []string
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return applied, fmt.Errorf("commands.%s.defaults: unknown flag --%s", key, name)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		if err := setFlagFromPreset(cmd.Flags(), flag, defaults[name]); err != nil {
+			return applied, fmt.Errorf("commands.%s.defaults.%s: %w", key, name, err)
+		}
+		applied = append(applied, name)
+	}
+
+	return applied, nil
+}
+
+// setFlagFromPreset sets a flag from a YAML value. Lists are only accepted for
+// repeatable (slice/array) flags and are applied one element at a time.
+func setFlagFromPreset(flags *pflag.FlagSet, flag *pflag.Flag, value interface{}) error {
+	items, isList := value.([]interface{})
+	if !isList {
+		return flags.Set(flag.Name, fmt.Sprintf("%v", value))
+	}
+
+	if !strings.HasSuffix(flag.Value.Type(), "Slice") && !strings.HasSuffix(flag.Value.Type(), "Array") {
+		return fmt.Errorf("flag --%s does not accept a list", flag.Name)
+	}
+
+	for _, item := range items {
+		if err := flags.Set(flag.Name, fmt.Sprintf("%v", item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printEffectiveFlags prints every flag of a command with its value and where it came from
+func printEffectiveFlags(cmd *cobra.Command, fromConfig []string) {
+	configSet := make(map[string]bool, len(fromConfig))
+	for _, name := range fromConfig {
+		configSet[name] = true
+	}
+
+	fmt.Printf("Effective flags for '%s':\n", cmd.CommandPath())
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" || flag.Name == "show-effective-flags" {
+			return
+		}
+
+		source := "default"
+		if configSet[flag.Name] {
+			source = "config"
+		} else if flag.Changed {
+			source = "cli"
+		}
+		fmt.Printf("  --%-22s %-30s (%s)\n", flag.Name, flag.Value.String(), source)
+	})
+}
+
+// queryFlags are the flags that take a query expression, which may name a
+// saved query from config as "@name"
+var queryFlags = []string{"query", "where"}
+
+// resolveSavedQueries replaces "@name" values of the query flags with the
+// expression saved under that name in the config's queries
+func resolveSavedQueries(cmd *cobra.Command, cfg *config.Config) error {
+	for _, name := range queryFlags {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Value.Type() != "string" {
+			continue
+		}
+		value := flag.Value.String()
+		expression, err := cfg.ResolveQuery(value)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", name, err)
+		}
+		if expression != value {
+			if err := flag.Value.Set(expression); err != nil {
+				return fmt.Errorf("--%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// commandPresetsHook applies config presets before a command runs and handles
+// --show-effective-flags, which prints the merged flags instead of running.
+func commandPresetsHook(cmd *cobra.Command, args []string) error {
+	cfg, err := loadPresetConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	// --lang wins over the config's locale
+	locale := cfg.Locale
+	if lang, _ := cmd.Flags().GetString("lang"); lang != "" {
+		locale = lang
+	}
+	if locale != "" {
+		if err := i18n.SetLocale(locale); err != nil {
+			return err
+		}
+	}
+
+	applied, err := applyCommandPresets(cmd, cfg)
+	if err != nil {
+		return err
+	}
+	if err := resolveSavedQueries(cmd, cfg); err != nil {
+		return err
+	}
+
+	if show, _ := cmd.Flags().GetBool("show-effective-flags"); show {
+		printEffectiveFlags(cmd, applied)
+
+		// Inspecting flags should not require a complete invocation or run the command
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			delete(flag.Annotations, cobra.BashCompOneRequiredFlag)
+		})
+		cmd.Run = func(cmd *cobra.Command, args []string) {}
+		cmd.RunE = nil
+		return nil
+	}
+
+	if showDiff, _ := cmd.Flags().GetBool("diff"); showDiff {
+		return setupDiffPreview(cmd, args)
+	}
+	if sandbox, _ := cmd.Flags().GetBool("sandbox"); sandbox {
+		return setupSandbox(cmd, args)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package root
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+)
+
+func newPresetTestCommand() (*cobra.Command, *cobra.Command) {
+	root := &cobra.Command{Use: "mdnotes"}
+	group := &cobra.Command{Use: "frontmatter"}
+	leaf := &cobra.Command{Use: "ensure", Run: func(cmd *cobra.Command, args []string) {}}
+	leaf.Flags().StringSlice("ignore", []string{".obsidian/*"}, "")
+	leaf.Flags().StringSlice("type", nil, "")
+	leaf.Flags().Bool("recursive", true, "")
+	group.AddCommand(leaf)
+	root.AddCommand(group)
+	return root, leaf
+}
+
+func TestApplyCommandPresets(t *testing.T) {
+	_, leaf := newPresetTestCommand()
+	assert.Equal(t, "frontmatter.ensure", presetCommandKey(leaf))
+
+	require.NoError(t, leaf.Flags().Parse([]string{"--type", "x:string"}))
+
+	cfg := &config.Config{Commands: map[string]config.CommandPreset{
+		"frontmatter.ensure": {Defaults: map[string]interface{}{
+			"ignore":    []interface{}{"a/*", "b/*"},
+			"type":      []interface{}{"tags:array"},
+			"recursive": false,
+		}},
+	}}
+
+	applied, err := applyCommandPresets(leaf, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ignore", "recursive"}, applied)
+
+	ignore, _ := leaf.Flags().GetStringSlice("ignore")
+	assert.Equal(t, []string{"a/*", "b/*"}, ignore, "config replaces the built-in default")
+
+	types, _ := leaf.Flags().GetStringSlice("type")
+	assert.Equal(t, []string{"x:string"}, types, "CLI flags override config")
+
+	recursive, _ := leaf.Flags().GetBool("recursive")
+	assert.False(t, recursive)
+}
+
+func TestApplyCommandPresets_Errors(t *testing.T) {
+	_, leaf := newPresetTestCommand()
+
+	cfg := &config.Config{Commands: map[string]config.CommandPreset{
+		"frontmatter.ensure": {Defaults: map[string]interface{}{"nonexistent": "x"}},
+	}}
+	_, err := applyCommandPresets(leaf, cfg)
+	assert.ErrorContains(t, err, "unknown flag --nonexistent")
+
+	_, leaf = newPresetTestCommand()
+	cfg.Commands["frontmatter.ensure"] = config.CommandPreset{Defaults: map[string]interface{}{
+		"recursive": []interface{}{true},
+	}}
+	_, err = applyCommandPresets(leaf, cfg)
+	assert.ErrorContains(t, err, "does not accept a list")
+}
+
+func TestResolveSavedQueries(t *testing.T) {
+	root, leaf := newPresetTestCommand()
+	root.PersistentFlags().String("query", "", "")
+	leaf.Flags().String("where", "", "")
+	require.NoError(t, root.ParseFlags(nil))
+	require.NoError(t, leaf.ParseFlags([]string{"--query", "@drafts", "--where", "priority > 3"}))
+
+	cfg := &config.Config{Queries: map[string]string{"drafts": "status = 'draft'"}}
+	require.NoError(t, resolveSavedQueries(leaf, cfg))
+
+	query, _ := root.PersistentFlags().GetString("query")
+	assert.Equal(t, "status = 'draft'", query, "the global flag sees the expression")
+	where, _ := leaf.Flags().GetString("where")
+	assert.Equal(t, "priority > 3", where, "expressions are left alone")
+
+	_, leaf = newPresetTestCommand()
+	leaf.Flags().String("where", "", "")
+	require.NoError(t, leaf.ParseFlags([]string{"--where", "@missing"}))
+	assert.ErrorContains(t, resolveSavedQueries(leaf, cfg), "--where: unknown saved query '@missing' - defined queries: @drafts")
+}
@@ -2,21 +2,51 @@ package root
 
 import (
 	"os"
+	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/eoinhurrell/mdnotes/cmd/analyze"
+	"github.com/eoinhurrell/mdnotes/cmd/archive"
+	"github.com/eoinhurrell/mdnotes/cmd/assets"
+	configcmd "github.com/eoinhurrell/mdnotes/cmd/config"
+	"github.com/eoinhurrell/mdnotes/cmd/content"
+	"github.com/eoinhurrell/mdnotes/cmd/daily"
+	"github.com/eoinhurrell/mdnotes/cmd/diff"
+	"github.com/eoinhurrell/mdnotes/cmd/digest"
+	"github.com/eoinhurrell/mdnotes/cmd/doctor"
+	"github.com/eoinhurrell/mdnotes/cmd/duplicates"
 	"github.com/eoinhurrell/mdnotes/cmd/export"
 	"github.com/eoinhurrell/mdnotes/cmd/frontmatter"
 	"github.com/eoinhurrell/mdnotes/cmd/headings"
+	"github.com/eoinhurrell/mdnotes/cmd/importer"
+	"github.com/eoinhurrell/mdnotes/cmd/index"
+	"github.com/eoinhurrell/mdnotes/cmd/lifecycle"
 	"github.com/eoinhurrell/mdnotes/cmd/linkding"
 	"github.com/eoinhurrell/mdnotes/cmd/links"
+	"github.com/eoinhurrell/mdnotes/cmd/mcp"
+	"github.com/eoinhurrell/mdnotes/cmd/moc"
+	"github.com/eoinhurrell/mdnotes/cmd/newnote"
 	"github.com/eoinhurrell/mdnotes/cmd/plugins"
 	"github.com/eoinhurrell/mdnotes/cmd/profile"
+	"github.com/eoinhurrell/mdnotes/cmd/random"
+	"github.com/eoinhurrell/mdnotes/cmd/refactor"
 	"github.com/eoinhurrell/mdnotes/cmd/rename"
+	"github.com/eoinhurrell/mdnotes/cmd/report"
+	"github.com/eoinhurrell/mdnotes/cmd/search"
+	"github.com/eoinhurrell/mdnotes/cmd/serve"
+	"github.com/eoinhurrell/mdnotes/cmd/split"
+	"github.com/eoinhurrell/mdnotes/cmd/suggest"
+	"github.com/eoinhurrell/mdnotes/cmd/tags"
+	"github.com/eoinhurrell/mdnotes/cmd/tasks"
+	"github.com/eoinhurrell/mdnotes/cmd/undo"
 	"github.com/eoinhurrell/mdnotes/cmd/watch"
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/config"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/selector"
 )
 
@@ -32,30 +62,65 @@ for managing frontmatter, headings, links, and file organization.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd.Help()
 		},
+		PersistentPreRunE: commandPresetsHook,
 	}
+	selector.DefaultLinkParser = processor.NewLinkParser()
 
 	// Add global flags
 	cmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them; shows exactly what would be changed")
 	cmd.PersistentFlags().Bool("verbose", false, "Detailed output; prints filepath of every file examined and actions taken")
 	cmd.PersistentFlags().Bool("quiet", false, "Suppress all output except errors and final summary; overrides --verbose")
 	cmd.PersistentFlags().String("config", "", "Config file (default: .obsidian-admin.yaml)")
+	cmd.PersistentFlags().Bool("show-effective-flags", false, "Print the command's flags merged with config presets and exit")
+	cmd.PersistentFlags().String("lang", "", "Language of reports and suggestions: en, es, de or fr (default: config locale, or en)")
+	cmd.PersistentFlags().Bool("git-commit", false, "Commit the files a command changes to git, with a message listing them")
+	cmd.PersistentFlags().Bool("sandbox", false, "Run against a temporary copy of the vault and report the resulting changes and health instead of modifying it")
+	cmd.PersistentFlags().Bool("diff", false, "With --dry-run, show a unified diff of each file the command would change instead of its messages (for commands that only change the vault)")
+	cmd.PersistentFlags().String("diff-format", "text", "Format of --diff output: text or json")
 
 	// Add global file selection flags
 	cmd.PersistentFlags().String("query", "", "Filter files using query expression (e.g., \"tags contains 'published'\")")
 	cmd.PersistentFlags().String("from-file", "", "Read file list from specified file (one file path per line)")
 	cmd.PersistentFlags().Bool("from-stdin", false, "Read file list from stdin (one file path per line)")
 	cmd.PersistentFlags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns for file scanning")
+	cmd.PersistentFlags().Int("workers", runtime.NumCPU(), "Files to parse in parallel when scanning the vault; 1 scans sequentially")
 
 	// Add subcommands
 	cmd.AddCommand(analyze.NewAnalyzeCommand())
+	cmd.AddCommand(archive.NewArchiveCommand())
+	cmd.AddCommand(assets.NewAssetsCommand())
+	cmd.AddCommand(configcmd.NewConfigCommand())
+	cmd.AddCommand(content.NewContentCommand())
+	cmd.AddCommand(daily.NewDailyCommand())
+	cmd.AddCommand(diff.NewDiffCommand())
+	cmd.AddCommand(digest.NewDigestCommand())
+	cmd.AddCommand(doctor.NewDoctorCommand())
+	cmd.AddCommand(duplicates.NewDuplicatesCommand())
 	cmd.AddCommand(export.NewExportCommand())
 	cmd.AddCommand(frontmatter.NewFrontmatterCommand())
 	cmd.AddCommand(headings.NewHeadingsCommand())
+	cmd.AddCommand(importer.NewImportCommand())
+	cmd.AddCommand(index.NewIndexCommand())
+	cmd.AddCommand(lifecycle.NewLifecycleCommand())
 	cmd.AddCommand(links.NewLinksCommand())
 	cmd.AddCommand(linkding.NewLinkdingCommand())
+	cmd.AddCommand(mcp.NewMCPCommand())
+	cmd.AddCommand(moc.NewMOCCommand())
+	cmd.AddCommand(newnote.NewNewCommand())
 	cmd.AddCommand(plugins.NewPluginsCommand())
 	cmd.AddCommand(profile.NewProfileCommand())
+	cmd.AddCommand(refactor.NewRefactorCommand())
+	cmd.AddCommand(report.NewReportCommand())
+	cmd.AddCommand(random.NewRandomCommand())
+	cmd.AddCommand(random.NewReviewCommand())
 	cmd.AddCommand(rename.NewRenameCommand())
+	cmd.AddCommand(search.NewSearchCommand())
+	cmd.AddCommand(serve.NewServeCommand())
+	cmd.AddCommand(split.NewSplitCommand())
+	cmd.AddCommand(suggest.NewSuggestCommand())
+	cmd.AddCommand(tags.NewTagsCommand())
+	cmd.AddCommand(tasks.NewTasksCommand())
+	cmd.AddCommand(undo.NewUndoCommand())
 	cmd.AddCommand(watch.Cmd)
 
 	// Add ultra-short global shortcuts for most common commands
@@ -201,6 +266,9 @@ func setupCustomCompletions(cmd *cobra.Command) {
 	// Set completion for global flags
 	_ = cmd.RegisterFlagCompletionFunc("config", CompleteConfigFiles)
 	_ = cmd.RegisterFlagCompletionFunc("query", CompleteQueryExpressions)
+	_ = cmd.RegisterFlagCompletionFunc("diff-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	_ = cmd.RegisterFlagCompletionFunc("from-file", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{"txt", "list"}, cobra.ShellCompDirectiveFilterFileExt
 	})
@@ -209,7 +277,7 @@ func setupCustomCompletions(cmd *cobra.Command) {
 	// Add completion for commands that need path arguments
 	for _, subCmd := range cmd.Commands() {
 		switch subCmd.Name() {
-		case "frontmatter", "headings", "links", "analyze":
+		case "frontmatter", "headings", "links", "analyze", "random", "review":
 			// These commands take vault/directory paths
 			subCmd.ValidArgsFunction = CompleteDirs
 
@@ -221,6 +289,10 @@ func setupCustomCompletions(cmd *cobra.Command) {
 			// Rename takes a source file as first argument
 			subCmd.ValidArgsFunction = CompleteMarkdownFiles
 
+		case "archive":
+			// Archive takes a vault path or a single note
+			subCmd.ValidArgsFunction = CompleteMarkdownFiles
+
 		case "linkding":
 			// Linkding takes vault paths
 			subCmd.ValidArgsFunction = CompleteDirs
@@ -252,14 +324,28 @@ func setupCustomCompletions(cmd *cobra.Command) {
 		switch subCmd.Name() {
 		case "frontmatter":
 			setupFrontmatterCompletions(subCmd)
+		case "content":
+			setupContentCompletions(subCmd)
 		case "export":
 			setupExportCompletions(subCmd)
 		case "rename":
 			setupRenameCompletions(subCmd)
+		case "undo":
+			setupUndoCompletions(subCmd)
 		case "analyze":
 			setupAnalyzeCompletions(subCmd)
 		case "links":
 			setupLinksCompletions(subCmd)
+		case "index":
+			setupIndexCompletions(subCmd)
+		case "doctor":
+			subCmd.ValidArgsFunction = CompleteDirs
+		case "lifecycle":
+			setupLifecycleCompletions(subCmd)
+		case "report":
+			setupReportCompletions(subCmd)
+		case "config":
+			setupConfigCompletions(subCmd)
 		case "linkding":
 			setupLinkdingCompletions(subCmd)
 		}
@@ -300,7 +386,7 @@ func CompleteIgnorePatterns(cmd *cobra.Command, args []string, toComplete string
 func setupFrontmatterCompletions(cmd *cobra.Command) {
 	for _, subCmd := range cmd.Commands() {
 		switch subCmd.Name() {
-		case "ensure", "set", "cast", "sync", "check", "download", "query":
+		case "ensure", "set", "cast", "sync", "check", "download", "query", "convert", "import":
 			// All frontmatter subcommands take paths
 			subCmd.ValidArgsFunction = CompleteDirs
 		}
@@ -324,9 +410,36 @@ func setupFrontmatterCompletions(cmd *cobra.Command) {
 			_ = subCmd.RegisterFlagCompletionFunc("missing", CompleteFrontmatterFields)
 			_ = subCmd.RegisterFlagCompletionFunc("duplicates", CompleteFrontmatterFields)
 			_ = subCmd.RegisterFlagCompletionFunc("field", CompleteFrontmatterFields)
+			_ = subCmd.RegisterFlagCompletionFunc("group-by", CompleteFrontmatterFields)
+			_ = subCmd.RegisterFlagCompletionFunc("aggregate", cobra.FixedCompletions([]string{"count()", "sum(", "avg(", "min(", "max("}, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace))
 			_ = subCmd.RegisterFlagCompletionFunc("filter", CompleteQueryFilters)
 		case "download":
 			_ = subCmd.RegisterFlagCompletionFunc("field", CompleteCommonFields)
+		case "convert":
+			_ = subCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions([]string{"yaml", "toml", "json"}, cobra.ShellCompDirectiveNoFileComp))
+		case "import":
+			_ = subCmd.RegisterFlagCompletionFunc("from", cobra.FixedCompletions([]string{"csv", "tsv", "json"}, cobra.ShellCompDirectiveFilterFileExt))
+			_ = subCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"csv", "tsv", "json"}, cobra.ShellCompDirectiveNoFileComp))
+			_ = subCmd.RegisterFlagCompletionFunc("type", CompleteFieldTypesWithFormat)
+		}
+	}
+}
+
+// setupContentCompletions sets up completion for content subcommands
+func setupContentCompletions(cmd *cobra.Command) {
+	for _, subCmd := range cmd.Commands() {
+		subCmd.ValidArgsFunction = CompleteDirs
+
+		switch subCmd.Name() {
+		case "normalize":
+			_ = subCmd.RegisterFlagCompletionFunc("line-endings", cobra.FixedCompletions([]string{"lf", "crlf", "keep"}, cobra.ShellCompDirectiveNoFileComp))
+		case "style":
+			markers := cobra.FixedCompletions([]string{"asterisk", "underscore"}, cobra.ShellCompDirectiveNoFileComp)
+			_ = subCmd.RegisterFlagCompletionFunc("quotes", cobra.FixedCompletions([]string{"curly", "straight"}, cobra.ShellCompDirectiveNoFileComp))
+			_ = subCmd.RegisterFlagCompletionFunc("strong", markers)
+			_ = subCmd.RegisterFlagCompletionFunc("emphasis", markers)
+			_ = subCmd.RegisterFlagCompletionFunc("bullets", cobra.FixedCompletions([]string{"-", "*", "+"}, cobra.ShellCompDirectiveNoFileComp))
+			_ = subCmd.RegisterFlagCompletionFunc("list-indent", cobra.FixedCompletions([]string{"2", "4"}, cobra.ShellCompDirectiveNoFileComp))
 		}
 	}
 }
@@ -347,6 +460,27 @@ func setupRenameCompletions(cmd *cobra.Command) {
 	_ = cmd.RegisterFlagCompletionFunc("vault", CompleteDirs)
 }
 
+// setupUndoCompletions sets up completions for the undo command
+func setupUndoCompletions(cmd *cobra.Command) {
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		vaultPath, _ := cmd.Flags().GetString("vault")
+		transactions, _ := safety.NewJournal(safety.FindVaultRoot(vaultPath)).List()
+
+		var ids []string
+		for _, tx := range transactions {
+			if tx.UndoneAt == nil {
+				ids = append(ids, tx.ID+"\t"+tx.Command)
+			}
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	_ = cmd.RegisterFlagCompletionFunc("vault", CompleteDirs)
+}
+
 // CompleteCommonFields provides completion for common frontmatter fields
 func CompleteCommonFields(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	fields := []string{
@@ -370,7 +504,13 @@ func CompleteCommonFields(cmd *cobra.Command, args []string, toComplete string)
 
 // CompleteOutputFormats provides completion for output format flags
 func CompleteOutputFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	formats := []string{"text", "json", "csv", "yaml", "table"}
+	formats := []string{"text", "json", "ndjson", "csv", "yaml", "table"}
+	return formats, cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteLinkAnalysisFormats provides completion for analyze links output formats
+func CompleteLinkAnalysisFormats(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	formats := append([]string{"text", "json", "ndjson"}, analyzer.GraphFormats...)
 	return formats, cobra.ShellCompDirectiveNoFileComp
 }
 
@@ -457,6 +597,7 @@ func CompleteDefaultValues(cmd *cobra.Command, args []string, toComplete string)
 		"{{current_date}}", // template variable
 		"{{filename}}",     // template variable
 		"{{uuid}}",         // template variable
+		"{{title|slug}}",   // template variable with filter
 	}
 	return defaults, cobra.ShellCompDirectiveNoFileComp
 }
@@ -547,11 +688,17 @@ func setupAnalyzeCompletions(cmd *cobra.Command) {
 	for _, subCmd := range cmd.Commands() {
 		subCmd.ValidArgsFunction = CompleteDirs
 
-		// All analyze commands have format flag
-		_ = subCmd.RegisterFlagCompletionFunc("format", CompleteOutputFormats)
+		// All analyze commands have format flag; links can also export its graph
+		if subCmd.Name() == "links" {
+			_ = subCmd.RegisterFlagCompletionFunc("format", CompleteLinkAnalysisFormats)
+		} else {
+			_ = subCmd.RegisterFlagCompletionFunc("format", CompleteOutputFormats)
+		}
 		_ = subCmd.RegisterFlagCompletionFunc("output", CompleteOutputFiles)
 
 		switch subCmd.Name() {
+		case "fields":
+			_ = subCmd.RegisterFlagCompletionFunc("field", CompleteCommonFields)
 		case "duplicates":
 			_ = subCmd.RegisterFlagCompletionFunc("type", CompleteDuplicateTypes)
 		case "trends":
@@ -580,6 +727,58 @@ func setupLinksCompletions(cmd *cobra.Command) {
 	}
 }
 
+// setupIndexCompletions sets up completions for the index command
+func setupIndexCompletions(cmd *cobra.Command) {
+	for _, subCmd := range cmd.Commands() {
+		subCmd.ValidArgsFunction = CompleteDirs
+	}
+}
+
+// setupLifecycleCompletions sets up completion for lifecycle subcommands
+func setupLifecycleCompletions(cmd *cobra.Command) {
+	for _, subCmd := range cmd.Commands() {
+		// Lifecycle rules apply to a directory of notes or a single note
+		subCmd.ValidArgsFunction = CompleteMarkdownFiles
+	}
+}
+
+// setupReportCompletions sets up completion for report subcommands
+func setupReportCompletions(cmd *cobra.Command) {
+	for _, subCmd := range cmd.Commands() {
+		subCmd.ValidArgsFunction = CompleteDirs
+		_ = subCmd.RegisterFlagCompletionFunc("report", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var names []string
+			for _, report := range cfg.Reports {
+				names = append(names, report.Name)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+}
+
+// setupConfigCompletions sets up completion for config subcommands
+func setupConfigCompletions(cmd *cobra.Command) {
+	for _, subCmd := range cmd.Commands() {
+		if subCmd.Name() != "set-secret" {
+			continue
+		}
+		subCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var names []string
+			for _, s := range config.SecretSettings {
+				names = append(names, s.Secret+"\t"+s.Setting)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+}
+
 // setupExportCompletions sets up completion for export command
 func setupExportCompletions(cmd *cobra.Command) {
 	// Export takes output directory as first argument, vault path as second
@@ -587,6 +786,8 @@ func setupExportCompletions(cmd *cobra.Command) {
 
 	// Query flag can use existing query completions (to be enhanced in Task 1.2)
 	_ = cmd.RegisterFlagCompletionFunc("query", CompleteQueryExpressions)
+	_ = cmd.RegisterFlagCompletionFunc("depth", cobra.FixedCompletions([]string{"1", "2", "3", "0"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("link-direction", cobra.FixedCompletions([]string{"forward", "backward", "both"}, cobra.ShellCompDirectiveNoFileComp))
 }
 
 // CompleteQueryExpressions provides completion for query expressions
@@ -598,6 +799,17 @@ func CompleteQueryExpressions(cmd *cobra.Command, args []string, toComplete stri
 		"created after '2024-01-01'",
 		"folder = 'areas/'",
 		"title contains 'project'",
+		"body contains 'TODO'",
+		"wordcount > 500",
+	}
+	// Saved queries from config come first
+	if cfg, err := config.LoadConfigWithFallback(config.GetDefaultConfigPaths()); err == nil {
+		var saved []string
+		for name := range cfg.Queries {
+			saved = append(saved, "@"+name)
+		}
+		sort.Strings(saved)
+		expressions = append(saved, expressions...)
 	}
 	return expressions, cobra.ShellCompDirectiveNoFileComp
 }
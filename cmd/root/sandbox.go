@@ -0,0 +1,320 @@
+package root
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/diff"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// vaultHealth summarizes the state of a vault for comparing it before and
+// after a sandboxed operation
+type vaultHealth struct {
+	Notes              int
+	ParseErrors        int
+	BrokenLinks        int
+	OrphanedNotes      int
+	WithoutFrontmatter int
+}
+
+// setupSandbox makes cmd run against a shadow copy of the vault its arguments
+// point into, reporting what changed instead of changing the vault
+func setupSandbox(cmd *cobra.Command, args []string) error {
+	if cmd.Name() == "watch" {
+		return fmt.Errorf("--sandbox is not supported for %s", cmd.CommandPath())
+	}
+	root, err := sandboxVaultRoot(cmd, args, "--sandbox")
+	if err != nil {
+		return err
+	}
+	wrapRun(cmd, func(cmd *cobra.Command, args []string, run func(*cobra.Command, []string) error) error {
+		return runInSandbox(cmd, args, root, run)
+	})
+	return nil
+}
+
+// sandboxVaultRoot checks that cmd can run against a shadow copy of a vault,
+// for the named flag, and returns the root of the vault its arguments point
+// into
+func sandboxVaultRoot(cmd *cobra.Command, args []string, flagName string) (string, error) {
+	if cmd.Run == nil && cmd.RunE == nil {
+		return "", fmt.Errorf("%s is not supported for %s", flagName, cmd.CommandPath())
+	}
+	if gitCommit, _ := cmd.Flags().GetBool("git-commit"); gitCommit {
+		return "", fmt.Errorf("%s can't be combined with --git-commit", flagName)
+	}
+	fromFile, _ := cmd.Flags().GetString("from-file")
+	fromStdin, _ := cmd.Flags().GetBool("from-stdin")
+	if fromFile != "" || fromStdin {
+		return "", fmt.Errorf("%s can't be combined with --from-file or --from-stdin", flagName)
+	}
+
+	// The vault is the one containing the first existing path argument, or
+	// the current directory
+	root := safety.FindVaultRoot(".")
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil {
+			root = safety.FindVaultRoot(arg)
+			break
+		}
+	}
+	return root, nil
+}
+
+// wrapRun replaces cmd's run function with wrapper, which is given the
+// original to call
+func wrapRun(cmd *cobra.Command, wrapper func(cmd *cobra.Command, args []string, run func(*cobra.Command, []string) error) error) {
+	run := cmd.RunE
+	if run == nil {
+		runFn := cmd.Run
+		run = func(cmd *cobra.Command, args []string) error {
+			runFn(cmd, args)
+			return nil
+		}
+	}
+	cmd.Run = nil
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return wrapper(cmd, args, run)
+	}
+}
+
+func runInSandbox(cmd *cobra.Command, args []string, root string, run func(*cobra.Command, []string) error) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+
+	sandbox, mappedArgs, leave, err := enterSandbox(cmd, args, root, false)
+	if err != nil {
+		return err
+	}
+	defer leave()
+
+	if !quiet {
+		fmt.Printf("Sandbox: running against a copy of %s\n\n", sandbox.Root())
+	}
+	runErr := run(cmd, mappedArgs)
+
+	changes, err := sandbox.Changes()
+	if err != nil {
+		return fmt.Errorf("comparing sandbox: %w", err)
+	}
+	before := measureVaultHealth(sandbox.Root(), ignorePatterns)
+	after := measureVaultHealth(sandbox.Shadow(), ignorePatterns)
+	fmt.Print(formatSandboxReport(sandbox, changes, before, after, !quiet))
+
+	return runErr
+}
+
+// enterSandbox copies the vault at root into a sandbox and moves into it:
+// path arguments and flags are rewritten to point at the copy, the working
+// directory changes to it and cmd's context is marked so its changes aren't
+// published. With vaultOnly, paths outside the vault are rejected, except in
+// flags that only name input files. leave returns to the working directory
+// and removes the sandbox.
+func enterSandbox(cmd *cobra.Command, args []string, root string, vaultOnly bool) (sandbox *safety.Sandbox, mappedArgs []string, leave func(), err error) {
+	sandbox, err = safety.NewSandbox(root)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	closeSandbox := func() { _ = sandbox.Close() }
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		closeSandbox()
+		return nil, nil, nil, fmt.Errorf("getting working directory: %w", err)
+	}
+	outside := func(value string) error {
+		return fmt.Errorf("--diff can't preview changes to %s, which is outside the vault %s", value, sandbox.Root())
+	}
+	mappedArgs = make([]string, len(args))
+	for i, arg := range args {
+		mappedArgs[i] = sandboxPath(sandbox, cwd, arg)
+		if vaultOnly && outsideSandbox(sandbox, mappedArgs[i]) {
+			closeSandbox()
+			return nil, nil, nil, outside(arg)
+		}
+	}
+	var flagErr error
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Value.Type() != "string" || flagErr != nil {
+			return
+		}
+		mapped := sandboxPath(sandbox, cwd, flag.Value.String())
+		if vaultOnly && outsideSandbox(sandbox, mapped) && !slices.Contains(inputPathFlags, flag.Name) {
+			flagErr = outside(flag.Value.String())
+		} else if mapped != flag.Value.String() {
+			flagErr = flag.Value.Set(mapped)
+		}
+	})
+	if flagErr != nil {
+		closeSandbox()
+		return nil, nil, nil, flagErr
+	}
+
+	// Run from the copy of the working directory, or the copy of the vault
+	// root when working outside it, so defaults like "." stay in the sandbox
+	shadowCwd, ok := sandbox.Path(cwd)
+	if !ok {
+		shadowCwd = sandbox.Shadow()
+	}
+	if err := os.Chdir(shadowCwd); err != nil {
+		closeSandbox()
+		return nil, nil, nil, fmt.Errorf("entering sandbox: %w", err)
+	}
+	ctx := cmd.Context()
+	cmd.SetContext(cli.WithSandbox(ctx))
+	return sandbox, mappedArgs, func() {
+		_ = os.Chdir(cwd)
+		cmd.SetContext(ctx)
+		closeSandbox()
+	}, nil
+}
+
+// inputPathFlags are flags that name files a command only reads, which may
+// be outside the vault even with --diff
+var inputPathFlags = []string{"config", "from", "schema", "templates"}
+
+// outsideSandbox reports whether value, as rewritten by sandboxPath, is a
+// path outside the sandbox's copy of the vault
+func outsideSandbox(sandbox *safety.Sandbox, value string) bool {
+	if !filepath.IsAbs(value) {
+		return false
+	}
+	rel, err := filepath.Rel(sandbox.Shadow(), value)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sandboxPath rewrites an argument or flag value that names a path, relative
+// to cwd, so it works from inside the sandbox: paths in the vault point at
+// their copy and other paths become absolute. A value names a path if it
+// exists, or if it looks like one (has a directory part or a .md extension)
+// and its directory exists.
+func sandboxPath(sandbox *safety.Sandbox, cwd, value string) string {
+	if value == "" {
+		return value
+	}
+	abs := value
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(cwd, value)
+	}
+	looksLikePath := strings.ContainsRune(value, filepath.Separator) || strings.ContainsRune(value, '/') ||
+		strings.EqualFold(filepath.Ext(value), ".md")
+	if !pathExists(abs) && !(looksLikePath && pathExists(filepath.Dir(abs))) {
+		return value
+	}
+	if shadow, ok := sandbox.Path(abs); ok {
+		return shadow
+	}
+	return abs
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func measureVaultHealth(root string, ignorePatterns []string) vaultHealth {
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns), vault.WithContinueOnErrors())
+	files, err := scanner.Walk(root)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	links := ana.AnalyzeLinks(files)
+
+	health := vaultHealth{
+		Notes:         len(files) + len(scanner.GetParseErrors()),
+		ParseErrors:   len(scanner.GetParseErrors()),
+		BrokenLinks:   links.BrokenLinks,
+		OrphanedNotes: len(links.OrphanedFiles),
+	}
+	for _, file := range files {
+		if len(file.Frontmatter) == 0 {
+			health.WithoutFrontmatter++
+		}
+	}
+	return health
+}
+
+func formatSandboxReport(sandbox *safety.Sandbox, changes []safety.SandboxChange, before, after vaultHealth, showDiffs bool) string {
+	var b strings.Builder
+	b.WriteString("\nSandbox Report\n==============\n")
+	fmt.Fprintf(&b, "Changes were made to a temporary copy; %s was not modified.\n\n", sandbox.Root())
+
+	if len(changes) == 0 {
+		b.WriteString("No files changed.\n")
+	} else {
+		counts := make(map[safety.SandboxChangeKind]int)
+		for _, change := range changes {
+			counts[change.Kind]++
+		}
+		fmt.Fprintf(&b, "Changes (%d files): %d modified, %d renamed, %d added, %d deleted\n", len(changes),
+			counts[safety.SandboxModified], counts[safety.SandboxRenamed], counts[safety.SandboxAdded], counts[safety.SandboxDeleted])
+		for _, change := range changes {
+			switch change.Kind {
+			case safety.SandboxModified:
+				fmt.Fprintf(&b, "  M %s\n", change.Path)
+			case safety.SandboxRenamed:
+				fmt.Fprintf(&b, "  R %s → %s\n", change.From, change.Path)
+			case safety.SandboxAdded:
+				fmt.Fprintf(&b, "  A %s\n", change.Path)
+			case safety.SandboxDeleted:
+				fmt.Fprintf(&b, "  D %s\n", change.Path)
+			}
+		}
+
+		if showDiffs {
+			for _, change := range changes {
+				if change.Kind != safety.SandboxModified {
+					continue
+				}
+				old, errOld := os.ReadFile(filepath.Join(sandbox.Root(), change.Path))
+				updated, errNew := os.ReadFile(filepath.Join(sandbox.Shadow(), change.Path))
+				if errOld != nil || errNew != nil || !isText(old) || !isText(updated) {
+					continue
+				}
+				b.WriteString("\n")
+				b.WriteString(diff.Unified("a/"+change.Path, "b/"+change.Path, string(old), string(updated), diff.DefaultContext))
+			}
+		}
+	}
+
+	b.WriteString("\nHealth:\n")
+	rows := []struct {
+		name          string
+		before, after int
+	}{
+		{"Notes", before.Notes, after.Notes},
+		{"Parse errors", before.ParseErrors, after.ParseErrors},
+		{"Broken links", before.BrokenLinks, after.BrokenLinks},
+		{"Orphaned notes", before.OrphanedNotes, after.OrphanedNotes},
+		{"Notes without frontmatter", before.WithoutFrontmatter, after.WithoutFrontmatter},
+	}
+	for _, row := range rows {
+		line := fmt.Sprintf("  %-26s %d → %d", row.name+":", row.before, row.after)
+		if delta := row.after - row.before; delta != 0 {
+			line += fmt.Sprintf(" (%+d)", delta)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// isText reports whether content looks like text worth diffing
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.ContainsRune(content, 0)
+}
@@ -0,0 +1,76 @@
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+func TestSandboxPath(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "vault")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "notes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes", "a.md"), []byte("a"), 0644))
+
+	sandbox, err := safety.NewSandbox(root)
+	require.NoError(t, err)
+	defer func() { _ = sandbox.Close() }()
+	shadow := sandbox.Shadow()
+
+	// Working outside the vault
+	assert.Equal(t, filepath.Join(shadow, "notes", "a.md"), sandboxPath(sandbox, parent, "vault/notes/a.md"))
+	assert.Equal(t, filepath.Join(shadow, "notes", "new.md"), sandboxPath(sandbox, parent, "vault/notes/new.md"))
+	assert.Equal(t, shadow, sandboxPath(sandbox, parent, filepath.Join(root)))
+	assert.Equal(t, filepath.Join(parent, "out.json"), sandboxPath(sandbox, parent, "./out.json"))
+
+	// Working inside the vault
+	assert.Equal(t, filepath.Join(shadow, "notes"), sandboxPath(sandbox, root, "notes"))
+	assert.Equal(t, filepath.Join(shadow, "b.md"), sandboxPath(sandbox, root, "b.md"))
+
+	// Values that aren't paths
+	assert.Equal(t, "title", sandboxPath(sandbox, root, "title"))
+	assert.Equal(t, "tags contains 'x'", sandboxPath(sandbox, root, "tags contains 'x'"))
+	assert.Equal(t, "", sandboxPath(sandbox, root, ""))
+
+	assert.False(t, outsideSandbox(sandbox, sandboxPath(sandbox, parent, "vault/notes/a.md")))
+	assert.False(t, outsideSandbox(sandbox, shadow))
+	assert.True(t, outsideSandbox(sandbox, sandboxPath(sandbox, parent, "./out.json")))
+	assert.False(t, outsideSandbox(sandbox, "title"))
+}
+
+func TestEnterSandbox_VaultOnly(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "vault")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	outside := filepath.Join(parent, "out.csv")
+
+	cmd, _, err := NewRootCommand().Find([]string{"frontmatter", "query"})
+	require.NoError(t, err)
+	require.NoError(t, cmd.ParseFlags([]string{"--output", outside, "--config", filepath.Join(parent, "mdnotes.yaml")}))
+
+	_, _, _, err = enterSandbox(cmd, []string{root}, root, true)
+	assert.ErrorContains(t, err, "can't preview changes to "+outside+", which is outside the vault")
+
+	_, _, _, err = enterSandbox(cmd, []string{parent}, root, true)
+	assert.ErrorContains(t, err, "can't preview changes to "+parent)
+}
+
+func TestFormatSandboxReport(t *testing.T) {
+	sandbox, err := safety.NewSandbox(t.TempDir())
+	require.NoError(t, err)
+	defer func() { _ = sandbox.Close() }()
+
+	report := formatSandboxReport(sandbox, []safety.SandboxChange{
+		{Kind: safety.SandboxRenamed, Path: "new.md", From: "old.md"},
+	}, vaultHealth{Notes: 2, BrokenLinks: 3}, vaultHealth{Notes: 2, BrokenLinks: 1}, true)
+
+	assert.Contains(t, report, "1 renamed")
+	assert.Contains(t, report, "  R old.md → new.md\n")
+	assert.Contains(t, report, "Notes:                     2 → 2\n")
+	assert.Contains(t, report, "Broken links:              3 → 1 (-2)\n")
+}
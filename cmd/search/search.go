@@ -0,0 +1,209 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/embeddings"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewSearchCommand creates the search command
+func NewSearchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search notes by meaning",
+		Long:  `Search the vault with ranking that goes beyond exact field matches.`,
+	}
+
+	cmd.AddCommand(newSemanticCommand())
+
+	return cmd
+}
+
+// Result is a ranked note in search output
+type Result struct {
+	embeddings.Result
+	Title string `json:"title"`
+}
+
+func newSemanticCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "semantic <query> [vault-path]",
+		Short: "Find the notes closest in meaning to a query",
+		Long: `Rank notes by the similarity of their embeddings to the query's, using the
+vectors 'mdnotes index embed' stored in the vault index.
+
+--keyword-weight blends in keyword relevance (BM25 over titles and bodies):
+0 ranks by embeddings alone, 1 by keywords alone. The query is embedded with
+the same provider and model as the notes, so use the same --provider and
+--model here as with 'index embed', or set them in the embeddings config.`,
+		Example: `  # The 10 notes closest to a question
+  mdnotes search semantic "how do I plan a garden" ~/vault
+
+  # Favour exact terms more, as JSON
+  mdnotes search semantic "kubernetes upgrade" --keyword-weight 0.6 --top-k 5 --format json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runSemantic,
+	}
+
+	cmd.Flags().Int("top-k", 10, "Number of notes to show")
+	cmd.Flags().Float64("keyword-weight", 0.3, "Share of the score from keyword matching, from 0 to 1")
+	cmd.Flags().String("provider", "", "Embeddings provider: local, ollama or openai (default: embeddings.provider, or local)")
+	cmd.Flags().String("model", "", "Provider model (default: embeddings.model, or the provider's default)")
+	cmd.Flags().String("format", "text", "Output format: text or json")
+
+	return cmd
+}
+
+func runSemantic(cmd *cobra.Command, args []string) error {
+	query := strings.TrimSpace(args[0])
+	vaultPath := "."
+	if len(args) > 1 {
+		vaultPath = args[1]
+	}
+	if query == "" {
+		return fmt.Errorf("query is empty")
+	}
+
+	topK, _ := cmd.Flags().GetInt("top-k")
+	keywordWeight, _ := cmd.Flags().GetFloat64("keyword-weight")
+	format, _ := cmd.Flags().GetString("format")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+	if keywordWeight < 0 || keywordWeight > 1 {
+		return fmt.Errorf("--keyword-weight must be between 0 and 1")
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format %q (use text or json)", format)
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	provider, err := newEmbeddingsProvider(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	idx := vault.FindIndex(vaultPath)
+	if idx == nil {
+		return fmt.Errorf("no vault index found; run 'mdnotes index embed' first")
+	}
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(idx),
+		vault.WithWorkers(workers),
+	)
+	files, err := scanner.Walk(vaultPath)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+	if err := idx.Save(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	model := provider.Model()
+	docs := make([]embeddings.Document, len(files))
+	titles := make(map[string]string, len(files))
+	missing := 0
+	for i, file := range files {
+		docs[i] = embeddings.Document{Path: file.RelativePath, Text: embeddings.NoteText(file)}
+		if vector, ok := idx.Embedding(file.Path, model); ok {
+			docs[i].Vector = vector
+		} else {
+			missing++
+		}
+		titles[file.RelativePath] = noteTitle(file)
+	}
+	if missing == len(files) && keywordWeight < 1 {
+		return fmt.Errorf("no notes have %s embeddings; run 'mdnotes index embed' with the same provider and model", model)
+	}
+
+	if missing > 0 {
+		// On stderr, so JSON output stays parseable
+		_, _ = fmt.Fprintf(os.Stderr, "⚠ %d notes have no current embedding; run 'mdnotes index embed' to include them\n", missing)
+	}
+
+	var queryVector []float32
+	if keywordWeight < 1 {
+		vectors, err := provider.Embed(context.Background(), []string{query})
+		if err != nil {
+			return fmt.Errorf("embedding query: %w", err)
+		}
+		queryVector = vectors[0]
+	}
+
+	ranked := embeddings.Rank(query, queryVector, docs, keywordWeight, topK)
+	results := make([]Result, len(ranked))
+	for i, r := range ranked {
+		results[i] = Result{Result: r, Title: titles[r.Path]}
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"query":   query,
+			"model":   model,
+			"results": results,
+		})
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No notes match %q\n", query)
+	} else {
+		fmt.Printf("Top %d notes for %q:\n", len(results), query)
+		for _, r := range results {
+			fmt.Printf("  %.3f  %s", r.Score, r.Path)
+			if r.Title != "" {
+				fmt.Printf(" — %s", r.Title)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+// noteTitle returns the frontmatter title, falling back to the file name
+func noteTitle(file *vault.VaultFile) string {
+	if title, ok := file.Frontmatter["title"].(string); ok && title != "" {
+		return title
+	}
+	return strings.TrimSuffix(filepath.Base(file.RelativePath), filepath.Ext(file.RelativePath))
+}
+
+// newEmbeddingsProvider creates the provider chosen by flags, falling back to config
+func newEmbeddingsProvider(cmd *cobra.Command, cfg *config.Config) (embeddings.Provider, error) {
+	opts := embeddings.Options{
+		Provider: cfg.Embeddings.Provider,
+		Model:    cfg.Embeddings.Model,
+		APIURL:   cfg.Embeddings.APIURL,
+	}
+	name, _ := cmd.Flags().GetString("provider")
+	model, _ := cmd.Flags().GetString("model")
+	opts = opts.Override(name, model)
+	if opts.Provider == embeddings.ProviderOpenAI {
+		key, err := cfg.Secret("embeddings.api_key")
+		if err != nil {
+			return nil, err
+		}
+		opts.APIKey = key
+	}
+	return embeddings.NewProvider(opts)
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
@@ -0,0 +1,160 @@
+package serve
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/server"
+)
+
+// TokenEnvVar is the environment variable the API token is read from when
+// --token isn't given
+const TokenEnvVar = "MDNOTES_SERVE_TOKEN"
+
+// NewServeCommand creates the serve command
+func NewServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve [vault-path]",
+		Short: "Serve the vault over a local REST/JSON API",
+		Long: `Serve vault stats, queries, the link graph and note editing over HTTP,
+so dashboards and scripts can use vault data without running mdnotes for
+every request.
+
+Endpoints:
+  GET    /api/stats                  Vault statistics, as 'analyze stats --format json'
+  GET    /api/query?where=EXPR       Notes matching a query (saved queries as @name);
+                                     add limit=N and body=true as needed
+  GET    /api/graph?format=FMT       Link graph as json, dot, graphml, mermaid or gexf
+  GET    /api/files                  Every note with its frontmatter
+  GET    /api/files/{path}           A note's frontmatter and body
+  PUT    /api/files/{path}           Create or replace a note: {"content": "..."} or
+                                     {"frontmatter": {...}, "body": "..."}
+  PATCH  /api/files/{path}           Set or remove fields and optionally replace the body:
+                                     {"frontmatter": {...}, "remove": [...], "body": "..."}
+  DELETE /api/files/{path}           Delete a note
+
+Every request must carry "Authorization: Bearer <token>". The token is taken
+from --token or ` + TokenEnvVar + `; if neither is set, a random token is
+generated and printed to stderr at startup, even with --quiet. Changes are
+recorded in the change journal (undo with 'mdnotes undo') and locked notes are
+never modified.`,
+		Example: `  # Serve the current vault on the default address
+  mdnotes serve
+
+  # Read-only API on another port with a fixed token
+  MDNOTES_SERVE_TOKEN=secret mdnotes serve --addr 127.0.0.1:9000 --read-only ~/vault
+
+  # Query it
+  curl -H "Authorization: Bearer secret" "http://127.0.0.1:9000/api/query?where=status%3D'draft'"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runServe,
+	}
+
+	cmd.Flags().String("addr", "127.0.0.1:8765", "Address to listen on")
+	cmd.Flags().String("token", "", "Bearer token clients must send (default: $"+TokenEnvVar+" or a generated token)")
+	cmd.Flags().Bool("no-auth", false, "Disable authentication (only safe on a trusted loopback address)")
+	cmd.Flags().Bool("read-only", false, "Reject requests that create, change or delete notes")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	vaultPath := "."
+	if len(args) > 0 {
+		vaultPath = args[0]
+	}
+	if info, err := os.Stat(vaultPath); err != nil || !info.IsDir() {
+		return fmt.Errorf("vault path %s is not a directory", vaultPath)
+	}
+	root, err := filepath.Abs(vaultPath)
+	if err != nil {
+		return fmt.Errorf("resolving vault path: %w", err)
+	}
+
+	addr, _ := cmd.Flags().GetString("addr")
+	token, _ := cmd.Flags().GetString("token")
+	noAuth, _ := cmd.Flags().GetBool("no-auth")
+	readOnly, _ := cmd.Flags().GetBool("read-only")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+
+	generated := false
+	if noAuth {
+		token = ""
+	} else if token == "" {
+		token = os.Getenv(TokenEnvVar)
+		if token == "" {
+			if token, err = generateToken(); err != nil {
+				return err
+			}
+			generated = true
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	handler := server.New(server.Options{
+		Root:           root,
+		Token:          token,
+		ReadOnly:       readOnly,
+		IgnorePatterns: ignorePatterns,
+		Workers:        workers,
+		ResolveQuery:   cfg.ResolveQuery,
+		Commit: func(tx *safety.Transaction) {
+			cli.CommitTransaction(cmd, tx)
+		},
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return server.Serve(ctx, addr, handler, func(listenAddr string) {
+		// Nothing else knows a generated token, so it's printed even when quiet
+		if generated {
+			fmt.Fprintf(os.Stderr, "Token: %s\n", token)
+		}
+		if quiet {
+			return
+		}
+		fmt.Printf("Serving %s at http://%s/api/ (press Ctrl+C to stop)\n", vaultPath, listenAddr)
+		if noAuth {
+			fmt.Println("Warning: authentication is disabled")
+		}
+		if readOnly {
+			fmt.Println("Read-only: changes are rejected")
+		}
+	})
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
+
+// generateToken returns a random 32-character hex token
+func generateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,120 @@
+package split
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewSplitCommand creates the split command
+func NewSplitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "split [file]",
+		Short: "Split sections of a note into notes of their own",
+		Long: `Move sections of a note into new notes named after their headings, in the
+same folder. Each section runs from its heading to the next heading of the
+same or a higher level. In the new note the section's heading becomes a level
+1 heading, and a link to the new note is left where the section was.
+
+Headings may be given with their level, as in "## Topic", or as plain text.
+'mdnotes analyze content --suggest-splits' proposes split commands for long,
+multi-topic notes.
+
+Examples:
+  # Split two sections out of a note
+  mdnotes split notes/reading.md --heading "## Fiction" --heading "## History"
+
+  # Preview the split
+  mdnotes split notes/reading.md --heading "Fiction" --dry-run --verbose`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSplit,
+	}
+
+	cmd.Flags().StringArray("heading", nil, "Heading of a section to split out (repeatable)")
+	cmd.Flags().Bool("link", true, "Leave a link to each new note where its section was")
+	_ = cmd.MarkFlagRequired("heading")
+
+	return cmd
+}
+
+func runSplit(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	headings, _ := cmd.Flags().GetStringArray("heading")
+	link, _ := cmd.Flags().GetBool("link")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading note: %w", err)
+	}
+	file := &vault.VaultFile{Path: path, RelativePath: path}
+	if err := file.Parse(content); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if reason := file.LockReason(); reason != "" {
+		return fmt.Errorf("%s is locked: %s", path, reason)
+	}
+
+	splitter := &processor.NoteSplitter{Link: link}
+	notes, err := splitter.Split(file, headings)
+	if err != nil {
+		return fmt.Errorf("splitting %s: %w", path, err)
+	}
+	for _, note := range notes {
+		if _, err := os.Stat(note.Path); err == nil {
+			return fmt.Errorf("%s already exists", note.Path)
+		}
+	}
+
+	output, err := file.Serialize()
+	if err != nil {
+		return fmt.Errorf("serializing %s: %w", path, err)
+	}
+
+	if dryRun {
+		for _, note := range notes {
+			fmt.Printf("Would create %s\n", note.Path)
+			if verbose {
+				fmt.Printf("\n%s\n", note.Body)
+			}
+		}
+		fmt.Printf("Would update %s\n", path)
+		if verbose {
+			fmt.Printf("\n%s", output)
+		}
+		return nil
+	}
+
+	tx := cli.BeginTransaction(cmd, safety.FindVaultRoot(path))
+	defer cli.CommitTransaction(cmd, tx)
+
+	for _, note := range notes {
+		if err := tx.RecordWrite(note.Path); err != nil {
+			return fmt.Errorf("recording change: %w", err)
+		}
+		if err := safety.WriteFile(note.Path, []byte(note.Body), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", note.Path, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ Created %s\n", note.Path)
+		}
+	}
+	if err := tx.RecordWrite(path); err != nil {
+		return fmt.Errorf("recording change: %w", err)
+	}
+	if err := safety.WriteFile(path, output, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if !quiet {
+		fmt.Printf("✓ Updated %s\n", path)
+	}
+	return nil
+}
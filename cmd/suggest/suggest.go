@@ -0,0 +1,285 @@
+package suggest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultRelatedHeading is the heading of the section --apply writes
+const DefaultRelatedHeading = "Related"
+
+// NewSuggestCommand creates the suggest command
+func NewSuggestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "suggest",
+		Short: "Suggest improvements to notes",
+		Long:  `Suggest changes that connect notes better, based on the rest of the vault.`,
+	}
+
+	cmd.AddCommand(newLinksCommand())
+
+	return cmd
+}
+
+// Suggestions are the notes suggested as links from one note
+type Suggestions struct {
+	Path    string                 `json:"path"`
+	Related []analyzer.RelatedNote `json:"related"`
+}
+
+func newLinksCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "links [path]",
+		Short: "Suggest notes to link to",
+		Long: `Recommend notes worth linking to, ranked by shared tags, co-citation in the
+link graph (notes that link to both, or that both link to) and text
+similarity. Notes a note already links to are never suggested.
+
+Given a note, suggestions are for that note. Given a directory (default: the
+current one), they are for its orphaned notes, those no other note links to.
+
+--apply writes the suggestions into a "## Related" section, updating it in
+place on later runs. Links inside that section are ignored when ranking, so
+re-running gives the same suggestions until the vault changes. Locked notes
+are left alone, and changes can be reverted with 'mdnotes undo'.`,
+		Example: `  # Suggestions for one note
+  mdnotes suggest links projects/garden.md
+
+  # Suggestions for every orphaned note, as JSON
+  mdnotes suggest links --format json ~/vault
+
+  # Write the top 3 into each orphan's Related section
+  mdnotes suggest links --apply --limit 3 ~/vault`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runLinks,
+	}
+
+	cmd.Flags().Int("limit", 5, "Suggestions per note")
+	cmd.Flags().Float64("min-score", 0.1, "Minimum score, from 0 to 1, for a suggestion")
+	cmd.Flags().Bool("apply", false, "Write suggestions into a Related section")
+	cmd.Flags().String("heading", DefaultRelatedHeading, "Heading of the section written by --apply")
+	cmd.Flags().String("link-format", "wiki", "Format of links written by --apply (wiki, markdown)")
+	cmd.Flags().String("format", "text", "Output format: text or json")
+	cmd.Flags().StringSlice("ignore", []string{".obsidian/*", "*.tmp"}, "Ignore patterns")
+
+	return cmd
+}
+
+func runLinks(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	limit, _ := cmd.Flags().GetInt("limit")
+	minScore, _ := cmd.Flags().GetFloat64("min-score")
+	apply, _ := cmd.Flags().GetBool("apply")
+	heading, _ := cmd.Flags().GetString("heading")
+	linkFormat, _ := cmd.Flags().GetString("link-format")
+	format, _ := cmd.Flags().GetString("format")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format %q (use text or json)", format)
+	}
+	if linkFormat != "wiki" && linkFormat != "markdown" {
+		return fmt.Errorf("invalid link format '%s' (supported: wiki, markdown)", linkFormat)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("accessing %s: %w", path, err)
+	}
+	root := safety.FindVaultRoot(path)
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(ignorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(vault.FindIndex(root)),
+		vault.WithWorkers(workers),
+	)
+	files, err := scanner.Walk(root)
+	if err != nil {
+		return fmt.Errorf("scanning vault: %w", err)
+	}
+
+	// Rank without the Related sections, so applied suggestions don't count
+	// as links and re-runs are stable
+	section := processor.NewListSection(heading)
+	linkParser := processor.NewLinkParser()
+	stripped := make([]*vault.VaultFile, len(files))
+	for i, file := range files {
+		body, _ := section.Remove(file.Body)
+		stripped[i] = &vault.VaultFile{
+			Path:         file.Path,
+			RelativePath: file.RelativePath,
+			Frontmatter:  file.Frontmatter,
+			Body:         body,
+		}
+		linkParser.UpdateFile(stripped[i])
+	}
+
+	targets, err := selectTargets(path, info.IsDir(), stripped)
+	if err != nil {
+		return err
+	}
+
+	finder := analyzer.NewAnalyzer().NewRelatedFinder(stripped)
+	var results []Suggestions
+	for _, i := range targets {
+		if related := finder.Related(stripped[i], limit, minScore); len(related) > 0 {
+			results = append(results, Suggestions{Path: stripped[i].RelativePath, Related: related})
+		}
+	}
+
+	if apply {
+		return applySuggestions(cmd, root, files, results, section, linkFormat)
+	}
+	return printSuggestions(results, len(targets), format)
+}
+
+// selectTargets returns the indexes of the notes to suggest links for: the
+// note at path, or the orphaned notes under the directory at path
+func selectTargets(path string, isDir bool, files []*vault.VaultFile) ([]int, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", path, err)
+	}
+
+	var targets []int
+	if !isDir {
+		for i, file := range files {
+			if filePath, err := filepath.Abs(file.Path); err == nil && filePath == abs {
+				return []int{i}, nil
+			}
+		}
+		return nil, fmt.Errorf("%s is not a note in the vault", path)
+	}
+
+	inbound, _ := analyzer.LinkCounts(files)
+	for i, file := range files {
+		filePath, err := filepath.Abs(file.Path)
+		if err != nil || !strings.HasPrefix(filePath, abs+string(filepath.Separator)) {
+			continue
+		}
+		if inbound[filepath.ToSlash(file.RelativePath)] == 0 {
+			targets = append(targets, i)
+		}
+	}
+	return targets, nil
+}
+
+func printSuggestions(results []Suggestions, checked int, format string) error {
+	if format == "json" {
+		if results == nil {
+			results = []Suggestions{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No link suggestions for %d notes\n", checked)
+		return nil
+	}
+	for _, s := range results {
+		fmt.Printf("%s\n", s.Path)
+		for _, r := range s.Related {
+			fmt.Printf("  %.2f  %s", r.Score, r.Path)
+			if reasons := describe(r); reasons != "" {
+				fmt.Printf("  (%s)", reasons)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
+
+// describe summarizes why a note was suggested
+func describe(r analyzer.RelatedNote) string {
+	var reasons []string
+	if len(r.SharedTags) > 0 {
+		reasons = append(reasons, "tags: "+strings.Join(r.SharedTags, ", "))
+	}
+	if r.CoCited > 0 {
+		reasons = append(reasons, fmt.Sprintf("co-cited by %d", r.CoCited))
+	}
+	if r.Coupled > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d shared links", r.Coupled))
+	}
+	if r.Similarity > 0 {
+		reasons = append(reasons, fmt.Sprintf("similarity %.2f", r.Similarity))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+func applySuggestions(cmd *cobra.Command, root string, files []*vault.VaultFile, results []Suggestions, section *processor.ListSection, linkFormat string) error {
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	byPath := make(map[string]*vault.VaultFile, len(files))
+	for _, file := range files {
+		byPath[file.RelativePath] = file
+	}
+	names := processor.NoteNames(files)
+
+	tx := cli.BeginTransaction(cmd, root)
+	defer cli.CommitTransaction(cmd, tx)
+
+	updated := 0
+	for _, s := range results {
+		file := byPath[s.Path]
+		if reason := file.LockReason(); reason != "" {
+			if !quiet {
+				fmt.Printf("⚠ Skipping %s: %s\n", file.RelativePath, reason)
+			}
+			continue
+		}
+
+		links := make([]string, len(s.Related))
+		for i, r := range s.Related {
+			links[i] = processor.NoteLink(r.Path, linkFormat, names)
+		}
+		body := section.Update(file.Body, links)
+		if body == file.Body {
+			continue
+		}
+		updated++
+
+		if dryRun {
+			fmt.Printf("Would add %d related links to %s\n", len(links), file.RelativePath)
+			continue
+		}
+		file.Body = body
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		if err := tx.RecordWrite(file.Path); err != nil {
+			return fmt.Errorf("recording change to %s: %w", file.RelativePath, err)
+		}
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file.RelativePath, err)
+		}
+		if !quiet {
+			fmt.Printf("✓ Added %d related links to %s\n", len(links), file.RelativePath)
+		}
+	}
+
+	if !quiet && updated == 0 {
+		fmt.Println("Related sections are up to date")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package suggest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSuggestCommand(t *testing.T, args ...string) error {
+	rootCmd := &cobra.Command{Use: "mdnotes"}
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Preview changes without applying them")
+	rootCmd.PersistentFlags().Bool("quiet", true, "Suppress output")
+	rootCmd.AddCommand(NewSuggestCommand())
+
+	rootCmd.SetArgs(append([]string{"suggest"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestLinksCommand_Apply(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".mdnotes"), 0755))
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	write("garden.md", "---\ntags: [garden]\n---\nTomatoes and compost in raised beds.\n")
+	write("compost.md", "---\ntags: [garden]\n---\nCompost feeds raised beds.\n")
+	write("locked.md", "---\ntags: [garden]\nmdnotes: locked\n---\nCompost and raised beds.\n")
+	write("k8s.md", "Kubernetes cluster upgrade.\n")
+
+	require.NoError(t, runSuggestCommand(t, "links", "--apply", "--dry-run", dir))
+	assert.NotContains(t, read("garden.md"), "## Related")
+
+	require.NoError(t, runSuggestCommand(t, "links", "--apply", filepath.Join(dir, "garden.md")))
+	garden := read("garden.md")
+	assert.Contains(t, garden, "## Related\n\n- [[locked]]\n- [[compost]]\n")
+	assert.NotContains(t, read("compost.md"), "## Related", "only the given note changes")
+
+	// Suggestions ignore the section they wrote, so a re-run changes nothing
+	require.NoError(t, runSuggestCommand(t, "links", "--apply", filepath.Join(dir, "garden.md")))
+	assert.Equal(t, garden, read("garden.md"))
+
+	// Every orphan gets a section, except locked notes and notes with no suggestions
+	require.NoError(t, runSuggestCommand(t, "links", "--apply", dir))
+	assert.Contains(t, read("compost.md"), "## Related")
+	assert.NotContains(t, read("locked.md"), "## Related")
+	assert.Equal(t, "Kubernetes cluster upgrade.\n", read("k8s.md"))
+
+	assert.ErrorContains(t, runSuggestCommand(t, "links", "--format", "xml", dir), "unsupported format")
+}
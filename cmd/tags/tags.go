@@ -0,0 +1,463 @@
+package tags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NewTagsCommand creates the tags command
+func NewTagsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "List, rename and merge tags across the vault",
+		Long: `Commands for managing tags. Tags are read from the frontmatter tags field
+(a list or a comma separated string) and from inline #tags in note bodies;
+inline tags in code blocks, inline code, links and URLs are left alone.
+
+Tags are matched case-insensitively, as Obsidian does, and nested tags follow
+their parent: renaming "project" also renames "project/active".`,
+	}
+
+	cmd.AddCommand(NewListCommand())
+	cmd.AddCommand(NewRenameCommand())
+	cmd.AddCommand(NewMergeCommand())
+	cmd.AddCommand(NewSyncCommand())
+
+	return cmd
+}
+
+// NewListCommand creates the tags list command
+func NewListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list [path]",
+		Aliases: []string{"ls"},
+		Short:   "List tags with the number of files using each",
+		Example: `  # Tags by number of files
+  mdnotes tags list ~/vault
+
+  # Nested tags as a hierarchy
+  mdnotes tags list ~/vault --tree`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runList,
+	}
+
+	cmd.Flags().Bool("tree", false, "Show nested tags (parent/child) as a hierarchy")
+
+	return cmd
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	tree, _ := cmd.Flags().GetBool("tree")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
+	files, err := scanner.Walk(path)
+	if err != nil {
+		return fmt.Errorf("scanning directory: %w", err)
+	}
+
+	tagProcessor := processor.NewTagProcessor()
+	var fileTags [][]string
+	for _, file := range files {
+		if tags := tagProcessor.Tags(file); len(tags) > 0 {
+			fileTags = append(fileTags, tags)
+		}
+	}
+	if len(fileTags) == 0 {
+		fmt.Println("No tags found")
+		return nil
+	}
+
+	roots := processor.BuildTagTree(fileTags)
+	if tree {
+		printTree(roots, "", true)
+		return nil
+	}
+
+	var all []*processor.TagNode
+	var collect func(nodes []*processor.TagNode)
+	collect = func(nodes []*processor.TagNode) {
+		for _, node := range nodes {
+			if node.Files > 0 {
+				all = append(all, node)
+			}
+			collect(node.Children)
+		}
+	}
+	collect(roots)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Files > all[j].Files
+	})
+
+	for _, node := range all {
+		fmt.Printf("#%s: %d files\n", node.Tag, node.Files)
+	}
+	fmt.Printf("\n%d tags in %d files\n", len(all), len(fileTags))
+	return nil
+}
+
+// printTree prints tags as an indented hierarchy with file counts; a parent's
+// count includes the files of the tags nested under it
+func printTree(nodes []*processor.TagNode, prefix string, top bool) {
+	for i, node := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		if top {
+			branch, next = "#", ""
+		}
+		fmt.Printf("%s%s%s (%d)\n", prefix, branch, node.Name, node.Total)
+		printTree(node.Children, prefix+next, false)
+	}
+}
+
+// NewRenameCommand creates the tags rename command
+func NewRenameCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <old> <new> [path]",
+		Short: "Rename a tag everywhere it is used",
+		Long: `Rename a tag in frontmatter tags and inline #tags across the vault. Tags
+nested under it are renamed too, so renaming "project" to "work" turns
+"#project/active" into "#work/active".
+
+All changes are worked out before any file is written, and if a write fails
+the files already written are restored. Locked files are skipped.`,
+		Example: `  # Rename a tag
+  mdnotes tags rename todo task ~/vault
+
+  # Move a tag under a parent
+  mdnotes tags rename python lang/python ~/vault --dry-run`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "."
+			if len(args) > 2 {
+				path = args[2]
+			}
+			return runRetag(cmd, path, []string{args[0]}, args[1])
+		},
+	}
+
+	return cmd
+}
+
+// NewMergeCommand creates the tags merge command
+func NewMergeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge <tag,tag...> <target> [path]",
+		Short: "Merge several tags into one",
+		Long: `Merge several tags into a single tag across the vault, in frontmatter tags
+and inline #tags. Frontmatter tag lists that end up with the target more
+than once keep a single entry. Tags nested under a merged tag move under the
+target. The tags and target can also be given as one argument in the form
+"a,b -> c".
+
+All changes are worked out before any file is written, and if a write fails
+the files already written are restored. Locked files are skipped.`,
+		Example: `  # Merge two tags into a third
+  mdnotes tags merge ml,machine-learning ai ~/vault
+
+  # The same, written as a mapping
+  mdnotes tags merge "todo,to-do -> task" ~/vault`,
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, target, path, err := parseMergeArgs(args)
+			if err != nil {
+				return err
+			}
+			return runRetag(cmd, path, sources, target)
+		},
+	}
+
+	return cmd
+}
+
+// parseMergeArgs splits merge arguments of the form "a,b c [path]" or
+// "a,b -> c" [path]
+func parseMergeArgs(args []string) ([]string, string, string, error) {
+	parts := args
+	if before, after, found := strings.Cut(args[0], "->"); found {
+		parts = append([]string{strings.TrimSpace(before), strings.TrimSpace(after)}, args[1:]...)
+	}
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, "", "", fmt.Errorf("expected: tags merge <tag,tag...> <target> [path], or tags merge \"<tag,tag...> -> <target>\" [path]")
+	}
+
+	var sources []string
+	for _, tag := range strings.Split(parts[0], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			sources = append(sources, tag)
+		}
+	}
+	path := "."
+	if len(parts) == 3 {
+		path = parts[2]
+	}
+	return sources, parts[1], path, nil
+}
+
+// runRetag renames each of sources to target across the files under path
+func runRetag(cmd *cobra.Command, path string, sources []string, target string) error {
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	target = processor.NormalizeTag(target)
+	if err := processor.ValidateTag(target); err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no tags to rename")
+	}
+	renames := make(map[string]string)
+	for _, source := range sources {
+		source = processor.NormalizeTag(source)
+		if err := processor.ValidateTag(source); err != nil {
+			return err
+		}
+		if source == target {
+			// Merging a tag into itself leaves it as it is
+			if len(sources) > 1 {
+				continue
+			}
+			return fmt.Errorf("tag '%s' is already named '%s'", source, target)
+		}
+		renames[source] = target
+	}
+
+	// Any unreadable file aborts before anything is changed
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
+	files, err := scanner.Walk(path)
+	if err != nil {
+		return fmt.Errorf("scanning directory: %w", err)
+	}
+
+	tagProcessor := processor.NewTagProcessor()
+	var modified []*vault.VaultFile
+	var skipped []processor.SkippedFile
+	var total processor.TagChange
+	for _, file := range files {
+		if reason := file.LockReason(); reason != "" {
+			// Only report locked files that use the tag
+			if tagProcessor.Uses(file, renames) {
+				skipped = append(skipped, processor.SkippedFile{Path: file.RelativePath, Reason: reason, Locked: true})
+			}
+			continue
+		}
+
+		change := tagProcessor.Rename(file, renames)
+		if change.Total() == 0 {
+			continue
+		}
+		modified = append(modified, file)
+		total.Frontmatter += change.Frontmatter
+		total.Inline += change.Inline
+
+		if verbose {
+			fmt.Printf("Examining: %s - %d frontmatter, %d inline tags\n", file.RelativePath, change.Frontmatter, change.Inline)
+		} else if !quiet {
+			if dryRun {
+				fmt.Printf("Would update: %s (%d tags)\n", file.RelativePath, change.Total())
+			} else {
+				fmt.Printf("✓ Updated: %s (%d tags)\n", file.RelativePath, change.Total())
+			}
+		}
+	}
+
+	if !dryRun {
+		if err := saveFiles(cmd, path, modified); err != nil {
+			return err
+		}
+	}
+
+	if quiet {
+		return nil
+	}
+	if len(modified) == 0 && len(skipped) == 0 {
+		fmt.Printf("No files use %s\n", formatTags(sources))
+	}
+	printSummary(skipped, len(files), len(modified), dryRun)
+	if total.Total() > 0 {
+		fmt.Printf("  - Frontmatter tags renamed: %d\n", total.Frontmatter)
+		fmt.Printf("  - Inline tags renamed: %d\n", total.Inline)
+	}
+
+	return nil
+}
+
+// NewSyncCommand creates the tags sync command
+func NewSyncCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync [path]",
+		Short: "Copy tags between inline #tags and the frontmatter tags field",
+		Long: `Keep inline #tags and the frontmatter tags field consistent, so tools that
+only read one of them see every tag.
+
+inline-to-frontmatter adds each note's inline #tags, including nested tags
+such as #project/foo, to its frontmatter tags field. With --remove the inline
+tags are then removed from the body, along with lines holding nothing else.
+
+frontmatter-to-inline appends the frontmatter tags missing from the body as a
+line of #tags at the end of the note. With --remove the tags field is then
+removed; tags that can't be written inline, such as ones containing spaces,
+are kept in it.
+
+All changes are worked out before any file is written, and if a write fails
+the files already written are restored. Locked files are skipped.`,
+		Example: `  # Gather inline tags into frontmatter
+  mdnotes tags sync ~/vault
+
+  # Move inline tags into frontmatter, removing them from the body
+  mdnotes tags sync ~/vault --remove
+
+  # Write frontmatter tags into the body
+  mdnotes tags sync ~/vault --direction frontmatter-to-inline --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runSync,
+	}
+
+	cmd.Flags().String("direction", "inline-to-frontmatter", "Direction to copy tags: inline-to-frontmatter or frontmatter-to-inline")
+	cmd.Flags().Bool("remove", false, "Remove tags from where they were copied from: the body, or the frontmatter tags field")
+
+	return cmd
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	direction, _ := cmd.Flags().GetString("direction")
+	remove, _ := cmd.Flags().GetBool("remove")
+	ignorePatterns, _ := cmd.Flags().GetStringSlice("ignore")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	// Override verbose if quiet is specified
+	if quiet {
+		verbose = false
+	}
+
+	tagProcessor := processor.NewTagProcessor()
+	var syncFile func(file *vault.VaultFile) processor.TagSync
+	switch direction {
+	case "inline-to-frontmatter":
+		syncFile = func(file *vault.VaultFile) processor.TagSync {
+			return tagProcessor.SyncToFrontmatter(file, remove)
+		}
+	case "frontmatter-to-inline":
+		syncFile = func(file *vault.VaultFile) processor.TagSync {
+			return tagProcessor.SyncToInline(file, remove)
+		}
+	default:
+		return fmt.Errorf("invalid direction '%s' - valid options are: inline-to-frontmatter, frontmatter-to-inline", direction)
+	}
+
+	// Any unreadable file aborts before anything is changed
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(ignorePatterns))
+	files, err := scanner.Walk(path)
+	if err != nil {
+		return fmt.Errorf("scanning directory: %w", err)
+	}
+
+	var modified []*vault.VaultFile
+	var skipped []processor.SkippedFile
+	var total processor.TagSync
+	for _, file := range files {
+		sync := syncFile(file)
+		if len(sync.Invalid) > 0 && !quiet {
+			fmt.Printf("⚠ %s: tags can't be written inline: %s\n", file.RelativePath, strings.Join(sync.Invalid, ", "))
+		}
+		if !sync.Changed() {
+			continue
+		}
+		// Locked files are synced in memory only, to report the ones left out of sync
+		if reason := file.LockReason(); reason != "" {
+			skipped = append(skipped, processor.SkippedFile{Path: file.RelativePath, Reason: reason, Locked: true})
+			continue
+		}
+
+		modified = append(modified, file)
+		total.Added += sync.Added
+		total.Removed += sync.Removed
+
+		if verbose {
+			fmt.Printf("Examining: %s - %d tags added, %d removed\n", file.RelativePath, sync.Added, sync.Removed)
+		} else if !quiet {
+			if dryRun {
+				fmt.Printf("Would update: %s (%d tags added, %d removed)\n", file.RelativePath, sync.Added, sync.Removed)
+			} else {
+				fmt.Printf("✓ Updated: %s (%d tags added, %d removed)\n", file.RelativePath, sync.Added, sync.Removed)
+			}
+		}
+	}
+
+	if !dryRun {
+		if err := saveFiles(cmd, path, modified); err != nil {
+			return err
+		}
+	}
+
+	if quiet {
+		return nil
+	}
+	printSummary(skipped, len(files), len(modified), dryRun)
+	if total.Changed() {
+		fmt.Printf("  - Tags added: %d\n", total.Added)
+		fmt.Printf("  - Tags removed: %d\n", total.Removed)
+	}
+
+	return nil
+}
+
+// saveFiles writes the modified files as one journaled change
+func saveFiles(cmd *cobra.Command, path string, files []*vault.VaultFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	tx := cli.BeginTransaction(cmd, path)
+	if err := processor.NewTagProcessor().Save(files, tx); err != nil {
+		return err
+	}
+	cli.CommitTransaction(cmd, tx)
+	return nil
+}
+
+// printSummary reports skipped locked files and the files examined and modified
+func printSummary(skipped []processor.SkippedFile, examined, modified int, dryRun bool) {
+	for _, s := range skipped {
+		fmt.Printf("⚠ Skipped: %s (locked: %s)\n", s.Path, s.Reason)
+	}
+	if dryRun {
+		fmt.Printf("\nDry run summary: %d files would be examined, %d would be modified\n", examined, modified)
+	} else {
+		fmt.Printf("\nSummary: %d files examined, %d modified\n", examined, modified)
+	}
+}
+
+// formatTags lists tags as "#a, #b"
+func formatTags(tags []string) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = "#" + processor.NormalizeTag(tag)
+	}
+	return strings.Join(formatted, ", ")
+}
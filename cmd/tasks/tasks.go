@@ -0,0 +1,380 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/selector"
+)
+
+// NewTasksCommand creates the tasks command
+func NewTasksCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tasks",
+		Short: "List and report on checkbox tasks across the vault",
+		Long: `List and report on the checkbox tasks ("- [ ] task") in the vault's notes.
+
+Obsidian Tasks plugin metadata is understood: dates (📅 due, ⏳ scheduled,
+🛫 start, ➕ created, ✅ completed, ❌ cancelled), priorities (🔺 highest,
+⏫ high, 🔼 medium, 🔽 low, ⏬ lowest) and recurrence (🔁). Statuses are todo
+"[ ]", done "[x]", in_progress "[/]" and cancelled "[-]"; other characters
+are reported as they are.
+
+Tasks are filtered with --where, using the query language with these fields:
+
+  text, status, done, priority, due, scheduled, start, created, completed,
+  cancelled, recurrence, tags, section, line
+  note.<field>   a frontmatter field of the task's note
+  file.path, file.name, ...   the task's note
+
+Dates are YYYY-MM-DD, so "due before '2025-01-01'" and "due = '2025-01-01'"
+both work. --query selects the notes to read tasks from.`,
+	}
+
+	cmd.AddCommand(NewListCommand())
+	cmd.AddCommand(NewReportCommand())
+
+	return cmd
+}
+
+// NewListCommand creates the tasks list command
+func NewListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list [path]",
+		Aliases: []string{"ls"},
+		Short:   "List tasks matching a query",
+		Long: `List the open tasks (to do or in progress) matching --where, or every
+matching task with --all, sorted by due date by default.`,
+		Example: `  # Open tasks due before the new year
+  mdnotes tasks list ~/vault --where "due before '2025-01-01'"
+
+  # High priority tasks in active projects, as JSON
+  mdnotes tasks list --where "(priority = 'highest' OR priority = 'high') AND note.status = 'active'" --format json
+
+  # Completed tasks this week as a markdown checklist
+  mdnotes tasks list --all --where "done = true AND completed within '7 days'" --format markdown`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runList,
+	}
+
+	cmd.Flags().String("where", "", "Query expression tasks must match")
+	cmd.Flags().Bool("all", false, "Include done and cancelled tasks")
+	cmd.Flags().String("sort", "due", "Sort order (due, priority, path)")
+	cmd.Flags().StringP("format", "f", "table", "Output format (table, json, markdown)")
+	cmd.Flags().Int("limit", 0, "Maximum tasks listed (0 for all)")
+
+	return cmd
+}
+
+// NewReportCommand creates the tasks report command
+func NewReportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report [path]",
+		Short: "Summarize tasks: overdue, due soon, and open tasks by note and tag",
+		Long: `Summarize the tasks matching --where: counts by status and priority,
+overdue tasks, tasks due within the next week, the notes and tags with the
+most open tasks, and tasks completed on each of the last seven days.`,
+		Example: `  # Report on every task in the vault
+  mdnotes tasks report ~/vault
+
+  # Report on work tasks as a markdown note
+  mdnotes tasks report --where "tags contains 'work'" --format markdown > "Task report.md"`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runReport,
+	}
+
+	cmd.Flags().String("where", "", "Query expression tasks must match")
+	cmd.Flags().StringP("format", "f", "table", "Output format (table, json, markdown)")
+	cmd.Flags().Int("limit", 10, "Maximum entries listed per section (0 for all)")
+
+	return cmd
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	sortBy, _ := cmd.Flags().GetString("sort")
+	format, _ := cmd.Flags().GetString("format")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	if sortBy != "due" && sortBy != "priority" && sortBy != "path" {
+		return fmt.Errorf("invalid sort '%s' - valid options are: due, priority, path", sortBy)
+	}
+
+	tasks, err := loadTasks(cmd, args)
+	if err != nil {
+		return err
+	}
+	if !all {
+		open := tasks[:0]
+		for _, task := range tasks {
+			if task.Open() {
+				open = append(open, task)
+			}
+		}
+		tasks = open
+	}
+	processor.SortTasks(tasks, sortBy)
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tasks)
+	case "markdown":
+		for _, task := range tasks {
+			fmt.Println(markdownTask(task))
+		}
+	default:
+		if len(tasks) == 0 {
+			fmt.Println("No tasks found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tDUE\tPRIORITY\tTASK\tLOCATION")
+		for _, task := range tasks {
+			fmt.Fprintf(w, "[%s]\t%s\t%s\t%s\t%s:%d\n", task.Symbol, orDash(task.Due), task.Priority, task.Text, task.Path, task.Line)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	tasks, err := loadTasks(cmd, args)
+	if err != nil {
+		return err
+	}
+	report := processor.NewTaskReport(tasks, time.Now())
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "markdown":
+		fmt.Print(markdownReport(report, limit))
+	default:
+		printReport(report, limit)
+	}
+	return nil
+}
+
+// loadTasks reads the tasks of the selected notes that match --where
+func loadTasks(cmd *cobra.Command, args []string) ([]processor.Task, error) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	where, _ := cmd.Flags().GetString("where")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+
+	var expr query.Expression
+	if where != "" {
+		var err error
+		if expr, err = query.NewParser(where).Parse(); err != nil {
+			return nil, fmt.Errorf("parsing --where expression: %w", err)
+		}
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	mode, fileSelector, err := selector.GetGlobalSelectionConfig(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("getting file selection config: %w", err)
+	}
+	if len(fileSelector.IgnorePatterns) == 0 {
+		fileSelector = fileSelector.WithIgnorePatterns(cfg.Vault.IgnorePatterns)
+	}
+	selection, err := fileSelector.SelectFiles(path, mode)
+	if err != nil {
+		return nil, fmt.Errorf("selecting files: %w", err)
+	}
+	if len(selection.ParseErrors) > 0 && verbose {
+		selection.PrintParseErrors()
+	}
+
+	var tasks []processor.Task
+	for _, file := range selection.Files {
+		for _, task := range processor.ParseTasks(file) {
+			if expr == nil || expr.Evaluate(task.AsFile()) {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// printReport prints a task report as text
+func printReport(report processor.TaskReport, limit int) {
+	fmt.Printf("Tasks as of %s\n", report.Date)
+	fmt.Println(strings.Repeat("=", 40))
+	fmt.Printf("Open:        %d (%d in progress)\n", report.Open, report.InProgress)
+	fmt.Printf("Done:        %d\n", report.Done)
+	fmt.Printf("Cancelled:   %d\n", report.Cancelled)
+	fmt.Printf("Overdue:     %d\n", len(report.Overdue))
+	fmt.Printf("Due soon:    %d\n", len(report.DueSoon))
+	fmt.Printf("No due date: %d\n", report.Undated)
+
+	if len(report.Priorities) > 0 {
+		fmt.Printf("\nOpen by priority:\n")
+		for _, count := range report.Priorities {
+			fmt.Printf("  %-8s %d\n", count.Name, count.Count)
+		}
+	}
+
+	printTaskSection := func(title string, tasks []processor.Task) {
+		if len(tasks) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", title)
+		for i, task := range tasks {
+			if limit > 0 && i == limit {
+				fmt.Printf("  ... and %d more\n", len(tasks)-limit)
+				break
+			}
+			fmt.Printf("  %s  %s (%s:%d)\n", task.Due, task.Text, task.Path, task.Line)
+		}
+	}
+	printTaskSection("⚠ Overdue", report.Overdue)
+	printTaskSection("Due in the next week", report.DueSoon)
+
+	printCounts := func(title string, counts []processor.TaskCount, prefix string) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", title)
+		for i, count := range counts {
+			if limit > 0 && i == limit {
+				fmt.Printf("  ... and %d more\n", len(counts)-limit)
+				break
+			}
+			fmt.Printf("  %4d  %s%s\n", count.Count, prefix, count.Name)
+		}
+	}
+	printCounts("Notes with the most open tasks", report.Notes, "")
+	printCounts("Tags with the most open tasks", report.Tags, "#")
+
+	if len(report.Completed) > 0 {
+		fmt.Printf("\nCompleted in the last week:\n")
+		for _, day := range lastWeek(report.Date) {
+			fmt.Printf("  %s  %d\n", day, report.Completed[day])
+		}
+	}
+}
+
+// markdownReport renders a task report as a note, with tasks as links to the
+// notes they are in
+func markdownReport(report processor.TaskReport, limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Tasks as of %s\n\n", report.Date)
+	fmt.Fprintf(&b, "| Open | In progress | Done | Cancelled | Overdue | Due soon | No due date |\n")
+	fmt.Fprintf(&b, "| ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %d | %d |\n",
+		report.Open, report.InProgress, report.Done, report.Cancelled, len(report.Overdue), len(report.DueSoon), report.Undated)
+
+	writeTasks := func(title string, tasks []processor.Task) {
+		if len(tasks) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for i, task := range tasks {
+			if limit > 0 && i == limit {
+				fmt.Fprintf(&b, "\n…and %d more\n", len(tasks)-limit)
+				break
+			}
+			b.WriteString(markdownTask(task) + "\n")
+		}
+	}
+	writeTasks("Overdue", report.Overdue)
+	writeTasks("Due in the next week", report.DueSoon)
+
+	writeCounts := func(title string, counts []processor.TaskCount, format func(string) string) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for i, count := range counts {
+			if limit > 0 && i == limit {
+				break
+			}
+			fmt.Fprintf(&b, "- %s: %d\n", format(count.Name), count.Count)
+		}
+	}
+	writeCounts("Open by priority", report.Priorities, func(name string) string { return name })
+	writeCounts("Notes with the most open tasks", report.Notes, noteLink)
+	writeCounts("Tags with the most open tasks", report.Tags, func(name string) string { return "#" + name })
+	return b.String()
+}
+
+// markdownTask renders a task as a checklist item linking to its note
+func markdownTask(task processor.Task) string {
+	line := fmt.Sprintf("- [%s] %s", task.Symbol, task.Text)
+	if task.Due != "" {
+		line += " 📅 " + task.Due
+	}
+	return line + " (" + noteLink(task.Path) + ")"
+}
+
+// noteLink returns a wiki link to the note at a relative path
+func noteLink(path string) string {
+	return "[[" + strings.TrimSuffix(path, ".md") + "]]"
+}
+
+// lastWeek returns the seven days ending on day, oldest first
+func lastWeek(day string) []string {
+	end, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return nil
+	}
+	days := make([]string, 7)
+	for i := range days {
+		days[i] = end.AddDate(0, 0, i-6).Format("2006-01-02")
+	}
+	return days
+}
+
+func checkFormat(format string) error {
+	if format != "table" && format != "json" && format != "markdown" {
+		return fmt.Errorf("invalid format '%s' - valid options are: table, json, markdown", format)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		return config.LoadConfigFromFile(configPath)
+	}
+	return config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+}
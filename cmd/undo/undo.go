@@ -0,0 +1,140 @@
+package undo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// NewUndoCommand creates the undo command
+func NewUndoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo [transaction-id]",
+		Short: "Revert the changes made by a previous command",
+		Long: `Revert the file changes made by a previous mutating command.
+
+Commands that modify files (frontmatter, headings, content, links, rename,
+tags and duplicates) record a transaction in the vault's .mdnotes/journal
+directory holding the original content of every file they changed or deleted.
+Undo restores those files, moves renamed files back and removes files the
+command created.
+
+Without an ID the most recent transaction that hasn't been undone is reverted.
+Files edited since the transaction are left alone unless --force is given.`,
+		Example: `  # Revert the last operation
+  mdnotes undo
+
+  # List recorded transactions
+  mdnotes undo --list
+
+  # Revert a specific transaction, discarding later edits to its files
+  mdnotes undo 20250101-120000-a1b2c3 --force`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runUndo,
+	}
+
+	cmd.Flags().String("vault", ".", "Vault root directory holding the journal")
+	cmd.Flags().Bool("list", false, "List recorded transactions instead of undoing")
+	cmd.Flags().Bool("force", false, "Undo even if files changed since the transaction")
+
+	return cmd
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	vaultPath, _ := cmd.Flags().GetString("vault")
+	list, _ := cmd.Flags().GetBool("list")
+	force, _ := cmd.Flags().GetBool("force")
+	dryRun, _ := cmd.Root().PersistentFlags().GetBool("dry-run")
+	verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+	quiet, _ := cmd.Root().PersistentFlags().GetBool("quiet")
+
+	journal := safety.NewJournal(safety.FindVaultRoot(vaultPath))
+
+	if list {
+		return listTransactions(journal)
+	}
+
+	var tx *safety.Transaction
+	var err error
+	if len(args) == 1 {
+		tx, err = journal.Load(args[0])
+	} else {
+		tx, err = journal.Last()
+	}
+	if errors.Is(err, safety.ErrNoTransaction) {
+		if !quiet {
+			fmt.Println("Nothing to undo")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun || verbose {
+		for i := len(tx.Entries) - 1; i >= 0; i-- {
+			fmt.Printf("  %s\n", describeRevert(tx.Entries[i]))
+		}
+	}
+
+	if dryRun {
+		if conflicts := tx.Conflicts(); len(conflicts) > 0 && !force {
+			fmt.Printf("⚠ Changed since transaction: %v\n", conflicts)
+		}
+		fmt.Printf("\nDry run completed. Would undo %s (%s, %d files).\n", tx.ID, tx.Command, len(tx.Entries))
+		return nil
+	}
+
+	if err := journal.Undo(tx, force); err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("✓ Undid %s (%s, %d files)\n", tx.ID, tx.Command, len(tx.Entries))
+	}
+	return nil
+}
+
+// listTransactions prints the journal, newest first
+func listTransactions(journal *safety.Journal) error {
+	transactions, err := journal.List()
+	if err != nil {
+		return err
+	}
+	if len(transactions) == 0 {
+		fmt.Println("No transactions recorded")
+		return nil
+	}
+
+	for _, tx := range transactions {
+		status := ""
+		if tx.UndoneAt != nil {
+			status = " (undone)"
+		}
+		fmt.Printf("%s  %s  %-30s %d files%s\n",
+			tx.ID, tx.CreatedAt.Format("2006-01-02 15:04"), tx.Command, len(tx.Entries), status)
+	}
+	return nil
+}
+
+// describeRevert describes how an entry will be reverted
+func describeRevert(entry safety.JournalEntry) string {
+	var description string
+	switch entry.Op {
+	case safety.OpMove:
+		description = fmt.Sprintf("move %s -> %s", entry.Path, entry.From)
+	case safety.OpCreate:
+		description = fmt.Sprintf("remove %s", entry.Path)
+	case safety.OpDelete:
+		description = fmt.Sprintf("recreate %s", entry.Path)
+	default:
+		description = fmt.Sprintf("restore %s", entry.Path)
+	}
+	if entry.Reason != "" {
+		description += fmt.Sprintf(" (%s)", entry.Reason)
+	}
+	return description
+}
@@ -0,0 +1,106 @@
+package watch
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/cli"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/metrics"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Vault metric names
+const (
+	metricHealthScore   = "mdnotes_vault_health_score"
+	metricFiles         = "mdnotes_vault_files"
+	metricLinks         = "mdnotes_vault_links"
+	metricBrokenLinks   = "mdnotes_vault_broken_links"
+	metricOrphanedFiles = "mdnotes_vault_orphaned_files"
+	metricParseErrors   = "mdnotes_vault_parse_errors"
+)
+
+// registerVaultMetrics declares the metrics updated by vault scans
+func registerVaultMetrics(registry *metrics.Registry) {
+	registry.Register(metricHealthScore, metrics.Gauge, "Vault health score from 0 to 100, as reported by 'mdnotes analyze health'")
+	registry.Register(metricFiles, metrics.Gauge, "Markdown files in the vault")
+	registry.Register(metricLinks, metrics.Gauge, "Internal links in the vault")
+	registry.Register(metricBrokenLinks, metrics.Gauge, "Links to notes that don't exist")
+	registry.Register(metricOrphanedFiles, metrics.Gauge, "Notes no other note links to")
+	registry.Register(metricParseErrors, metrics.Gauge, "Notes whose frontmatter could not be parsed")
+}
+
+// scanVaultPeriodically refreshes the vault metrics immediately and then every
+// interval until ctx is cancelled
+func scanVaultPeriodically(ctx context.Context, cfg *config.Config, registry *metrics.Registry, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		scanVault(ctx, cfg, registry)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scanVault analyzes the configured vault and records its health metrics
+func scanVault(ctx context.Context, cfg *config.Config, registry *metrics.Registry) {
+	start := time.Now()
+	vaultPath := cfg.Vault.Path
+	if vaultPath == "" {
+		vaultPath = "."
+	}
+
+	// Reuse the vault index, if one has been built, so periodic scans only
+	// re-parse and re-analyze changed notes
+	idx := vault.FindIndex(vaultPath)
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	if idx != nil {
+		ana.SetCache(analyzer.NewResultCache(idx))
+	}
+	// The config was validated when the watcher started
+	if rules, err := cli.HealthRules(cfg.Analysis.Health); err == nil {
+		ana.SetHealthRules(rules)
+	}
+
+	// Stream files into the statistics so large vaults aren't held in memory
+	scanner := vault.NewScanner(vault.WithIgnorePatterns(cfg.Vault.IgnorePatterns), vault.WithContinueOnErrors(), vault.WithIndex(idx))
+	acc := ana.NewStatsAccumulator()
+	for file := range scanner.Iter(ctx, vaultPath) {
+		acc.Add(file)
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Error scanning vault for metrics: %v", err)
+		registry.Inc(processor.MetricErrors, metrics.Labels{"stage": "vault_scan"})
+		return
+	}
+	registry.Set(metricParseErrors, nil, float64(len(scanner.GetParseErrors())))
+
+	stats := acc.Stats()
+	health := ana.GetHealthScore(stats)
+	if idx != nil {
+		if err := idx.Save(); err != nil {
+			log.Printf("Error saving vault index: %v", err)
+		}
+	}
+
+	registry.Set(metricHealthScore, nil, health.Score)
+	registry.Set(metricFiles, nil, float64(stats.TotalFiles))
+	registry.Set(metricLinks, nil, float64(stats.TotalLinks))
+	registry.Set(metricBrokenLinks, nil, float64(stats.BrokenLinksCount))
+	registry.Set(metricOrphanedFiles, nil, float64(len(stats.OrphanedFiles)))
+
+	task := metrics.Labels{"task": "vault_scan"}
+	registry.Set(processor.MetricRunDuration, task, time.Since(start).Seconds())
+	registry.Set(processor.MetricRunTimestamp, task, float64(time.Now().Unix()))
+}
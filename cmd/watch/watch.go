@@ -1,15 +1,18 @@
 package watch
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/metrics"
 	"github.com/eoinhurrell/mdnotes/internal/processor"
 )
 
@@ -29,14 +32,41 @@ watch:
     - name: "Auto-ensure frontmatter"
       paths: ["./notes/", "./inbox/"]
       events: ["create", "write"]
-      actions: ["mdnotes frontmatter ensure {{file}}"]
-    - name: "Sync with Linkding"
-      paths: ["./notes/"]
+      actions:
+        - mdnotes frontmatter ensure --field tags --default "[]" {{file}}
+        - mdnotes headings fix {{file}}
+    - name: "Publish finished posts"
+      paths: ["./blog/"]
+      files: ["*.md"]
+      query: "status = 'published'"
       events: ["write"]
-      actions: ["mdnotes linkding sync {{file}}"]
+      timeout: "30s"
+      stop_on_error: true
+      actions:
+        - mdnotes linkding sync {{file}}
+        - ./scripts/publish.sh {{file}} {{event}}
+
+Rules match a changed file by event, by path, by optional 'files' glob
+patterns on the file name or its path within the rule path, and by an
+optional frontmatter 'query'. Their actions run in order: "mdnotes ..."
+actions run mdnotes itself, anything else runs that program directly, with
+{{file}}, {{dir}}, {{basename}}, {{event}} and {{rule}} replaced and
+MDNOTES_FILE, MDNOTES_EVENT and MDNOTES_RULE set in its environment. Use
+"sh -c '...'" for shell features. Changes made by the actions themselves
+don't trigger the rules again.
+
+When an events log or webhook is configured, every change seen is also
+published as a structured event:
+
+events:
+  log: ".mdnotes/events.ndjson"
 
 The watch command will run in the foreground by default. Use --daemon to run
-in the background (requires external process management).`,
+in the background (requires external process management).
+
+Use --metrics-addr to expose Prometheus metrics at /metrics: events handled,
+files processed, errors, last run durations and, refreshed every
+--metrics-interval, the vault's health score, broken link and orphan counts.`,
 	Example: `  # Start watching with default config
   mdnotes watch
 
@@ -44,18 +74,25 @@ in the background (requires external process management).`,
   mdnotes watch --config .obsidian-admin.yaml
 
   # Run in daemon mode (background)
-  mdnotes watch --daemon`,
+  mdnotes watch --daemon
+
+  # Expose Prometheus metrics at http://localhost:9469/metrics
+  mdnotes watch --daemon --metrics-addr :9469`,
 	RunE: runWatch,
 }
 
 var (
-	configPath string
-	daemon     bool
+	configPath      string
+	daemon          bool
+	metricsAddr     string
+	metricsInterval time.Duration
 )
 
 func init() {
 	Cmd.Flags().StringVarP(&configPath, "config", "c", "", "Path to configuration file")
 	Cmd.Flags().BoolVarP(&daemon, "daemon", "d", false, "Run in daemon mode (background)")
+	Cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at this address (e.g. :9469)")
+	Cmd.Flags().DurationVar(&metricsInterval, "metrics-interval", 5*time.Minute, "How often vault health metrics are refreshed")
 }
 
 func runWatch(cmd *cobra.Command, args []string) error {
@@ -98,6 +135,12 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  Rule %d: %s\n", i+1, rule.Name)
 		fmt.Printf("    Paths: %v\n", rule.Paths)
 		fmt.Printf("    Events: %v\n", rule.Events)
+		if len(rule.Files) > 0 {
+			fmt.Printf("    Files: %v\n", rule.Files)
+		}
+		if rule.Query != "" {
+			fmt.Printf("    Query: %s\n", rule.Query)
+		}
 		fmt.Printf("    Actions: %v\n", rule.Actions)
 	}
 
@@ -107,6 +150,40 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("creating watch processor: %w", err)
 	}
 
+	// mdnotes actions run this binary with the same configuration
+	if executable, err := os.Executable(); err == nil {
+		var args []string
+		if configPath != "" {
+			args = []string{"--config", configPath}
+		}
+		watchProcessor.SetMdnotesCommand(executable, args...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if metricsAddr != "" {
+		if metricsInterval <= 0 {
+			return fmt.Errorf("--metrics-interval must be positive")
+		}
+		registry := metrics.NewRegistry()
+		watchProcessor.SetMetrics(registry)
+		registerVaultMetrics(registry)
+
+		serverErr := make(chan error, 1)
+		ready := make(chan string, 1)
+		go func() {
+			serverErr <- metrics.Serve(ctx, metricsAddr, registry, func(addr string) { ready <- addr })
+		}()
+		select {
+		case err := <-serverErr:
+			return err
+		case addr := <-ready:
+			fmt.Printf("Serving metrics at http://%s/metrics\n", addr)
+		}
+		go scanVaultPeriodically(ctx, cfg, registry, metricsInterval)
+	}
+
 	if err := watchProcessor.Start(); err != nil {
 		return fmt.Errorf("starting watch processor: %w", err)
 	}
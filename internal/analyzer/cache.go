@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"encoding/json"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Names of the analysis results stored in the vault index
+const (
+	bodyHashResult = "body_hash:" // Followed by how the hash was made
+	scoresResult   = "content_scores"
+)
+
+// ResultCache reuses per-file analysis results stored in the vault index.
+// Results are kept with each file's index entry, so they are only reused while
+// the file is unchanged and are dropped when a changed file is re-indexed;
+// after editing a few files only those files are re-analyzed.
+type ResultCache struct {
+	index  *vault.Index
+	hits   int
+	misses int
+}
+
+// contentScores are the content quality components that don't depend on time
+type contentScores struct {
+	LinkCount       int     `json:"link_count"`
+	DefaultLanguage string  `json:"default_language,omitempty"` // Readability language setting the scores used
+	Language        string  `json:"language,omitempty"`         // Language readability was scored in
+	Readability     float64 `json:"readability"`
+	LinkDensity     float64 `json:"link_density"`
+	Completeness    float64 `json:"completeness"`
+	Atomicity       float64 `json:"atomicity"`
+}
+
+// NewResultCache creates a cache keeping results in index. Save the index once
+// analysis is done to keep them for later runs.
+func NewResultCache(index *vault.Index) *ResultCache {
+	return &ResultCache{index: index}
+}
+
+// Stats returns how many cached results were reused and recomputed this run
+func (c *ResultCache) Stats() (reused, recomputed int) {
+	return c.hits, c.misses
+}
+
+// links returns the file's parsed links, parsing them with parser on a cache miss
+func (c *ResultCache) links(file *vault.VaultFile, parser LinkParser) []vault.Link {
+	if links, ok := c.index.Links(file); ok {
+		c.hits++
+		return links
+	}
+
+	c.misses++
+	parser.UpdateFile(file)
+	c.index.SetLinks(file, file.Links)
+	return file.Links
+}
+
+// bodyHash returns the content hash used for duplicate detection, made as
+// kind describes; a hash made another way is recomputed
+func (c *ResultCache) bodyHash(file *vault.VaultFile, kind string, compute func() string) string {
+	if hash, ok := c.index.Result(file, bodyHashResult+kind); ok {
+		c.hits++
+		return string(hash)
+	}
+
+	c.misses++
+	hash := compute()
+	c.index.SetResult(file, bodyHashResult+kind, []byte(hash))
+	return hash
+}
+
+// scores returns the file's content quality components, computing them on a
+// cache miss. Link density depends on the parsed links, so a cached result is
+// only reused for the same link count, and readability for the same default
+// language.
+func (c *ResultCache) scores(file *vault.VaultFile, defaultLanguage string, compute func() contentScores) contentScores {
+	var scores contentScores
+	if data, ok := c.index.Result(file, scoresResult); ok && json.Unmarshal(data, &scores) == nil &&
+		scores.LinkCount == len(file.Links) && scores.DefaultLanguage == defaultLanguage {
+		c.hits++
+		return scores
+	}
+
+	c.misses++
+	scores = compute()
+	if data, err := json.Marshal(scores); err == nil {
+		c.index.SetResult(file, scoresResult, data)
+	}
+	return scores
+}
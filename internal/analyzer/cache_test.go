@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// countingParser records how many files it parsed
+type countingParser struct {
+	parsed int
+}
+
+func (p *countingParser) UpdateFile(file *vault.VaultFile) {
+	p.parsed++
+	file.Links = nil
+	for _, word := range strings.Fields(file.Body) {
+		if strings.HasPrefix(word, "[[") && strings.HasSuffix(word, "]]") {
+			file.Links = append(file.Links, vault.Link{Type: vault.WikiLink, Target: strings.Trim(word, "[]")})
+		}
+	}
+}
+
+func cacheTestFiles(bodies ...string) []*vault.VaultFile {
+	var files []*vault.VaultFile
+	for i, body := range bodies {
+		name := string(rune('a'+i)) + ".md"
+		files = append(files, &vault.VaultFile{
+			Path:         name,
+			RelativePath: name,
+			Content:      []byte(body),
+			Body:         body,
+		})
+	}
+	return files
+}
+
+func TestResultCache_ReusesUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(body), 0644))
+	}
+
+	run := func() (*countingParser, *ResultCache, ContentAnalysis) {
+		idx, err := vault.LoadIndex(dir)
+		if err != nil {
+			idx = vault.NewIndex(dir)
+		}
+		files, err := vault.NewScanner(vault.WithIndex(idx)).Walk(dir)
+		require.NoError(t, err)
+
+		parser := &countingParser{}
+		cache := NewResultCache(idx)
+		ana := NewAnalyzer()
+		ana.SetLinkParser(parser)
+		ana.SetCache(cache)
+
+		ana.AnalyzeLinks(files)
+		content := ana.AnalyzeContentQuality(files)
+		require.NoError(t, idx.Save())
+		return parser, cache, content
+	}
+
+	write("a.md", "see [[b]]")
+	write("b.md", "see [[c]] too")
+	write("c.md", "alone")
+	parser, cache, first := run()
+	assert.Equal(t, 3, parser.parsed)
+	reused, recomputed := cache.Stats()
+	assert.Equal(t, 0, reused)
+	assert.Equal(t, 6, recomputed)
+
+	// Second run with one file changed only reparses that file
+	write("c.md", "alone, now with [[a]]")
+	parser, cache, second := run()
+	assert.Equal(t, 1, parser.parsed)
+	reused, recomputed = cache.Stats()
+	assert.Equal(t, 4, reused)
+	assert.Equal(t, 2, recomputed)
+
+	// Cached results match a fresh computation
+	fresh := NewAnalyzer()
+	fresh.SetLinkParser(&countingParser{})
+	files, err := vault.NewScanner().Walk(dir)
+	require.NoError(t, err)
+	fresh.AnalyzeLinks(files)
+	assert.Equal(t, fresh.AnalyzeContentQuality(files).FileScores, second.FileScores)
+	assert.Len(t, first.FileScores, 3)
+}
+
+func TestResultCache_UnindexedFilesAreRecomputed(t *testing.T) {
+	cache := NewResultCache(vault.NewIndex(t.TempDir()))
+	ana := NewAnalyzer()
+	ana.SetCache(cache)
+
+	for range 2 {
+		ana.FindContentDuplicates(cacheTestFiles("one", "two"), ExactMatch)
+	}
+	reused, recomputed := cache.Stats()
+	assert.Equal(t, 0, reused)
+	assert.Equal(t, 4, recomputed)
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/git"
+)
+
+// NoteChurn is how often a note was edited in the vault's git history
+type NoteChurn struct {
+	Path       string    `json:"path"`
+	Commits    int       `json:"commits"`
+	Added      int       `json:"lines_added"`
+	Deleted    int       `json:"lines_deleted"`
+	Authors    []string  `json:"authors"`
+	LastChange time.Time `json:"last_change"`
+	Exists     bool      `json:"exists"` // Still in the vault
+}
+
+// AuthorStats is one author's share of the vault's history
+type AuthorStats struct {
+	Name    string    `json:"name"`
+	Email   string    `json:"email"`
+	Commits int       `json:"commits"`
+	Notes   int       `json:"notes"` // Distinct notes edited
+	Added   int       `json:"lines_added"`
+	Deleted int       `json:"lines_deleted"`
+	First   time.Time `json:"first_commit"`
+	Last    time.Time `json:"last_commit"`
+}
+
+// ChurnAnalysis is the edit history of a vault's notes
+type ChurnAnalysis struct {
+	Commits int           `json:"commits"` // Commits touching notes
+	Notes   []NoteChurn   `json:"notes"`
+	Authors []AuthorStats `json:"authors"`
+}
+
+// AnalyzeChurn counts edits per note and per author over commits, from
+// git.Log read at the vault root. Only markdown files count. existing holds
+// the vault's current notes by slash-separated relative path; notes no longer
+// in it are left out unless includeDeleted is set. Notes are ordered by
+// commits, then lines changed, and authors, identified by email, by commits.
+func AnalyzeChurn(commits []git.Commit, existing map[string]bool, includeDeleted bool) ChurnAnalysis {
+	analysis := ChurnAnalysis{Notes: []NoteChurn{}, Authors: []AuthorStats{}}
+	notes := make(map[string]*NoteChurn)
+	noteAuthors := make(map[string]map[string]bool)
+	authors := make(map[string]*AuthorStats)
+	authorNotes := make(map[string]map[string]bool)
+
+	// Commits are newest first, so the first name seen for an email is the latest
+	for _, commit := range commits {
+		counted := false
+		key := strings.ToLower(commit.Email)
+		if key == "" {
+			key = commit.Author
+		}
+		for _, change := range commit.Files {
+			relPath := path.Clean(change.Path)
+			if !strings.EqualFold(path.Ext(relPath), ".md") {
+				continue
+			}
+			if !existing[relPath] && !includeDeleted {
+				continue
+			}
+
+			note, ok := notes[relPath]
+			if !ok {
+				note = &NoteChurn{Path: relPath, LastChange: commit.Time, Exists: existing[relPath]}
+				notes[relPath] = note
+				noteAuthors[relPath] = make(map[string]bool)
+			}
+			note.Commits++
+			note.Added += change.Added
+			note.Deleted += change.Deleted
+			if !noteAuthors[relPath][commit.Author] {
+				noteAuthors[relPath][commit.Author] = true
+				note.Authors = append(note.Authors, commit.Author)
+			}
+
+			author, ok := authors[key]
+			if !ok {
+				author = &AuthorStats{Name: commit.Author, Email: commit.Email, Last: commit.Time}
+				authors[key] = author
+				authorNotes[key] = make(map[string]bool)
+			}
+			if !counted {
+				author.Commits++
+				author.First = commit.Time
+				analysis.Commits++
+				counted = true
+			}
+			author.Added += change.Added
+			author.Deleted += change.Deleted
+			authorNotes[key][relPath] = true
+		}
+	}
+
+	for _, note := range notes {
+		analysis.Notes = append(analysis.Notes, *note)
+	}
+	sort.Slice(analysis.Notes, func(i, j int) bool {
+		a, b := analysis.Notes[i], analysis.Notes[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		if a.Added+a.Deleted != b.Added+b.Deleted {
+			return a.Added+a.Deleted > b.Added+b.Deleted
+		}
+		return a.Path < b.Path
+	})
+
+	for key, author := range authors {
+		author.Notes = len(authorNotes[key])
+		analysis.Authors = append(analysis.Authors, *author)
+	}
+	sort.Slice(analysis.Authors, func(i, j int) bool {
+		a, b := analysis.Authors[i], analysis.Authors[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Name < b.Name
+	})
+	return analysis
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/git"
+)
+
+func TestAnalyzeChurn(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.UTC) }
+	// Newest first, as git log lists them
+	commits := []git.Commit{
+		{Author: "Ann Smith", Email: "ann@example.com", Time: day(3), Files: []git.FileChange{
+			{Path: "a.md", Added: 5, Deleted: 1},
+			{Path: "img.png"},
+		}},
+		{Author: "Bob", Email: "bob@example.com", Time: day(2), Files: []git.FileChange{
+			{Path: "a.md", Added: 1},
+			{Path: "b.md", Added: 10},
+			{Path: "gone.md", Added: 3},
+		}},
+		{Author: "Ann", Email: "Ann@example.com", Time: day(1), Files: []git.FileChange{
+			{Path: "b.md", Added: 2},
+		}},
+		{Author: "Bob", Email: "bob@example.com", Time: day(1), Files: []git.FileChange{
+			{Path: "README.txt", Added: 2},
+		}},
+	}
+	existing := map[string]bool{"a.md": true, "b.md": true}
+
+	churn := AnalyzeChurn(commits, existing, false)
+	assert.Equal(t, 3, churn.Commits)
+	require.Len(t, churn.Notes, 2)
+	// Equal commits, so the note with more lines changed comes first
+	assert.Equal(t, NoteChurn{Path: "b.md", Commits: 2, Added: 12, Authors: []string{"Bob", "Ann"}, LastChange: day(2), Exists: true}, churn.Notes[0])
+	assert.Equal(t, NoteChurn{Path: "a.md", Commits: 2, Added: 6, Deleted: 1, Authors: []string{"Ann Smith", "Bob"}, LastChange: day(3), Exists: true}, churn.Notes[1])
+
+	// Authors are told apart by email, named as in their latest commit
+	require.Len(t, churn.Authors, 2)
+	assert.Equal(t, AuthorStats{Name: "Ann Smith", Email: "ann@example.com", Commits: 2, Notes: 2, Added: 7, Deleted: 1, First: day(1), Last: day(3)}, churn.Authors[0])
+	assert.Equal(t, AuthorStats{Name: "Bob", Email: "bob@example.com", Commits: 1, Notes: 2, Added: 11, First: day(2), Last: day(2)}, churn.Authors[1])
+
+	churn = AnalyzeChurn(commits, existing, true)
+	require.Len(t, churn.Notes, 3)
+	assert.Equal(t, "gone.md", churn.Notes[2].Path)
+	assert.False(t, churn.Notes[2].Exists)
+	assert.Equal(t, 14, churn.Authors[1].Added)
+}
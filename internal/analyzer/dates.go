@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Sources of the date a note was written, tried in order by trends analysis
+const (
+	DateSourceCreated  = "created"  // The created frontmatter field
+	DateSourceFilename = "filename" // A date in the note's filename
+	DateSourceGit      = "git"      // The note's first commit
+	DateSourceModified = "modified" // The file's modification time
+)
+
+// DefaultDateSources are tried when no sources are configured. Modification
+// times come last because sync tools and bulk edits reset them.
+var DefaultDateSources = []string{DateSourceCreated, DateSourceFilename, DateSourceModified}
+
+// DefaultFilenameDatePattern finds dates such as 2025-03-01, 20250301 and the
+// 20250301120000 prefix of zettelkasten-style names
+const DefaultFilenameDatePattern = `(\d{4}-\d{2}-\d{2}|\d{14}|\d{8})`
+
+// filenameDateLayouts are the layouts a date found in a filename is read with
+var filenameDateLayouts = []string{"2006-01-02", "20060102150405", "20060102", "2006_01_02", "2006.01.02"}
+
+// NoteDates decides when each note was written, trying sources in order
+type NoteDates struct {
+	sources         []string
+	createdField    string
+	filenamePattern *regexp.Regexp
+	git             map[string]time.Time
+}
+
+// NewNoteDates validates a chain of date sources, the created field ("" for
+// created) and the filename pattern ("" for DefaultFilenameDatePattern), whose
+// first group, or whole match, is the date. No sources means the defaults.
+func NewNoteDates(sources []string, createdField, filenamePattern string) (*NoteDates, error) {
+	if len(sources) == 0 {
+		sources = DefaultDateSources
+	}
+	for _, source := range sources {
+		switch source {
+		case DateSourceCreated, DateSourceFilename, DateSourceGit, DateSourceModified:
+		default:
+			return nil, fmt.Errorf("unknown date source '%s' - valid sources are: created, filename, git, modified", source)
+		}
+	}
+	if createdField == "" {
+		createdField = "created"
+	}
+	if filenamePattern == "" {
+		filenamePattern = DefaultFilenameDatePattern
+	}
+	pattern, err := regexp.Compile(filenamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filename date pattern: %w", err)
+	}
+	return &NoteDates{sources: sources, createdField: createdField, filenamePattern: pattern}, nil
+}
+
+// UsesGit reports whether the chain includes the git source, which needs
+// SetGitDates
+func (d *NoteDates) UsesGit() bool {
+	for _, source := range d.sources {
+		if source == DateSourceGit {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGitDates sets each note's first commit, by slash-separated relative path
+func (d *NoteDates) SetGitDates(first map[string]time.Time) {
+	d.git = first
+}
+
+// Date returns when file was written and the source that said so. The file's
+// modification time is the last resort, whatever the chain.
+func (d *NoteDates) Date(file *vault.VaultFile) (time.Time, string) {
+	for _, source := range d.sources {
+		switch source {
+		case DateSourceCreated:
+			if date, ok := parseCreated(file.Frontmatter[d.createdField]); ok {
+				return date, source
+			}
+		case DateSourceFilename:
+			if date, ok := d.filenameDate(file.RelativePath); ok {
+				return date, source
+			}
+		case DateSourceGit:
+			if date, ok := d.git[filepath.ToSlash(file.RelativePath)]; ok {
+				return date, source
+			}
+		case DateSourceModified:
+			if !file.Modified.IsZero() {
+				return file.Modified, source
+			}
+		}
+	}
+	return file.Modified, DateSourceModified
+}
+
+// filenameDate reads a date from the name of the note at relPath
+func (d *NoteDates) filenameDate(relPath string) (time.Time, bool) {
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(relPath)), path.Ext(relPath))
+	match := d.filenamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	value := match[0]
+	if len(match) > 1 {
+		value = match[1]
+	}
+	for _, layout := range filenameDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// SetNoteDates sets how trends analysis dates notes; by default it uses
+// DefaultDateSources
+func (a *Analyzer) SetNoteDates(dates *NoteDates) {
+	a.noteDates = dates
+}
+
+// noteDate returns when file was written according to the note dates
+func (a *Analyzer) noteDate(file *vault.VaultFile) (time.Time, string) {
+	if a.noteDates == nil {
+		a.noteDates, _ = NewNoteDates(nil, "", "")
+	}
+	return a.noteDates.Date(file)
+}
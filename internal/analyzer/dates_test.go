@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestNoteDates_Date(t *testing.T) {
+	local := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+	modified := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	files := map[string]*vault.VaultFile{
+		"created": {RelativePath: "2024-01-01 journal.md", Modified: modified,
+			Frontmatter: map[string]interface{}{"created": vault.Date{Time: local(2023, 5, 4)}}},
+		"string":   {RelativePath: "a.md", Modified: modified, Frontmatter: map[string]interface{}{"created": "2023-05-04"}},
+		"dashed":   {RelativePath: "daily/2024-01-02.md", Modified: modified},
+		"zettel":   {RelativePath: "20240103101500-idea.md", Modified: modified},
+		"compact":  {RelativePath: "notes 20240104.md", Modified: modified},
+		"bad date": {RelativePath: "2024-13-40.md", Modified: modified},
+		"git":      {RelativePath: "tracked.md", Modified: modified},
+		"none":     {RelativePath: "plain.md", Modified: modified},
+	}
+
+	dates, err := NewNoteDates(nil, "", "")
+	require.NoError(t, err)
+	assert.False(t, dates.UsesGit())
+	check := func(name string, want time.Time, wantSource string) {
+		t.Helper()
+		got, source := dates.Date(files[name])
+		assert.True(t, want.Equal(got), "%s: got %s, want %s", name, got, want)
+		assert.Equal(t, wantSource, source, name)
+	}
+	check("created", local(2023, 5, 4), DateSourceCreated)
+	check("string", local(2023, 5, 4), DateSourceCreated)
+	check("dashed", local(2024, 1, 2), DateSourceFilename)
+	check("zettel", time.Date(2024, 1, 3, 10, 15, 0, 0, time.Local), DateSourceFilename)
+	check("compact", local(2024, 1, 4), DateSourceFilename)
+	check("bad date", modified, DateSourceModified)
+	check("git", modified, DateSourceModified)
+
+	// Git dates come before the filename when listed first, and the
+	// modification time is the last resort even when not listed
+	dates, err = NewNoteDates([]string{DateSourceGit, DateSourceFilename}, "", "")
+	require.NoError(t, err)
+	assert.True(t, dates.UsesGit())
+	dates.SetGitDates(map[string]time.Time{"tracked.md": local(2022, 2, 2), "daily/2024-01-02.md": local(2022, 3, 3)})
+	check("git", local(2022, 2, 2), DateSourceGit)
+	check("dashed", local(2022, 3, 3), DateSourceGit)
+	check("created", local(2024, 1, 1), DateSourceFilename)
+	check("none", modified, DateSourceModified)
+
+	// A custom field and pattern
+	dates, err = NewNoteDates([]string{DateSourceCreated, DateSourceFilename}, "date", `^(\d{4}_\d{2}_\d{2})`)
+	require.NoError(t, err)
+	files["custom"] = &vault.VaultFile{RelativePath: "2021_07_08 notes.md", Modified: modified,
+		Frontmatter: map[string]interface{}{"created": "2023-05-04"}}
+	check("custom", local(2021, 7, 8), DateSourceFilename)
+
+	_, err = NewNoteDates([]string{"birthday"}, "", "")
+	assert.ErrorContains(t, err, "unknown date source 'birthday'")
+	_, err = NewNoteDates(nil, "", "(")
+	assert.ErrorContains(t, err, "invalid filename date pattern")
+}
+
+func TestAnalyzer_AnalyzeTrendsDateSources(t *testing.T) {
+	now := time.Now()
+	lastMonth := now.AddDate(0, -1, 0)
+	files := []*vault.VaultFile{
+		// Touched by a sync today, but written last month
+		{RelativePath: "a.md", Modified: now, Frontmatter: map[string]interface{}{"created": vault.Date{Time: lastMonth}}},
+		{RelativePath: lastMonth.Format("2006-01-02") + ".md", Modified: now},
+		{RelativePath: "b.md", Modified: now},
+		// Written before the timespan
+		{RelativePath: "old.md", Modified: now, Frontmatter: map[string]interface{}{"created": "2001-01-01"}},
+	}
+
+	analysis := NewAnalyzer().AnalyzeTrends(files, "1y", "month")
+	assert.Equal(t, 3, analysis.TotalFilesCreated)
+	assert.Equal(t, map[string]int{DateSourceCreated: 1, DateSourceFilename: 1, DateSourceModified: 1}, analysis.DateSources)
+	assert.Equal(t, []TimelinePoint{
+		{Period: now.Format("2006-01"), Count: 1},
+		{Period: lastMonth.Format("2006-01"), Count: 2},
+	}, analysis.Timeline)
+}
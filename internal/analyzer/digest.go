@@ -0,0 +1,414 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultDigestDir is where digest snapshots are kept, relative to the vault root
+const DefaultDigestDir = ".mdnotes/digests"
+
+// DefaultDigestMinChange is the word count change that makes a note heavily edited
+const DefaultDigestMinChange = 100
+
+// digestSnapshotLayout names snapshot files, so they sort by time
+const digestSnapshotLayout = "20060102-150405"
+
+var (
+	taskPattern     = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	taskDonePattern = regexp.MustCompile(`\s*✅\s*(\d{4}-\d{2}-\d{2})`)
+)
+
+// NoteSnapshot is what a digest compares about a note between runs
+type NoteSnapshot struct {
+	Words     int      `json:"words"`
+	Links     []string `json:"links,omitempty"`      // Resolved notes it links to
+	OpenTasks []string `json:"open_tasks,omitempty"` // Text of unchecked tasks
+}
+
+// DigestSnapshot records the vault's state when a digest was made
+type DigestSnapshot struct {
+	Time   time.Time               `json:"time"`
+	Health float64                 `json:"health"`
+	Notes  map[string]NoteSnapshot `json:"notes"`
+}
+
+// EditedNote is a note whose length changed by at least the minimum change
+type EditedNote struct {
+	Path        string `json:"path"`
+	WordsBefore int    `json:"words_before"`
+	WordsAfter  int    `json:"words_after"`
+}
+
+// NewLink is a link added between two notes
+type NewLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// CompletedTask is a task checked off during the digest period
+type CompletedTask struct {
+	Path string     `json:"path"`
+	Text string     `json:"text"`
+	Done *time.Time `json:"done,omitempty"` // Completion date, when the task records one
+}
+
+// Digest summarizes what changed in a vault over a period
+type Digest struct {
+	Since          time.Time       `json:"since"`
+	Until          time.Time       `json:"until"`
+	Baseline       *time.Time      `json:"baseline,omitempty"` // Time of the snapshot compared against
+	NewNotes       []string        `json:"new_notes"`
+	EditedNotes    []EditedNote    `json:"edited_notes"`
+	NewLinks       []NewLink       `json:"new_links"`
+	CompletedTasks []CompletedTask `json:"completed_tasks"`
+	Health         float64         `json:"health"`
+	HealthBefore   *float64        `json:"health_before,omitempty"`
+}
+
+// DigestOptions configures a digest
+type DigestOptions struct {
+	Since        time.Time
+	Until        time.Time
+	MinChange    int    // Word count change for a note to count as heavily edited
+	CreatedField string // Frontmatter field holding a note's creation date
+}
+
+// Snapshot records the current state of files for later digests. Links are
+// only recorded when a link parser is set.
+func (a *Analyzer) Snapshot(files []*vault.VaultFile, now time.Time) *DigestSnapshot {
+	snapshot := &DigestSnapshot{
+		Time:   now,
+		Health: a.GetHealthScore(a.GenerateStats(files)).Score,
+		Notes:  make(map[string]NoteSnapshot, len(files)),
+	}
+
+	graph := noteGraph(files)
+	for _, file := range files {
+		relPath := filepath.ToSlash(file.RelativePath)
+		note := NoteSnapshot{
+			Words: len(strings.Fields(file.Body)),
+			Links: graph[relPath],
+		}
+		for _, task := range parseTasks(file.Body) {
+			if !task.done {
+				note.OpenTasks = append(note.OpenTasks, task.text)
+			}
+		}
+		snapshot.Notes[relPath] = note
+	}
+	return snapshot
+}
+
+// Digest compares the current snapshot of files with baseline, an earlier
+// snapshot or nil. Without a baseline, new notes are found by their creation
+// date and completed tasks by their completion date only; edits and new links
+// need a baseline, except the links of new notes.
+func (a *Analyzer) Digest(files []*vault.VaultFile, current, baseline *DigestSnapshot, options DigestOptions) *Digest {
+	if options.MinChange <= 0 {
+		options.MinChange = DefaultDigestMinChange
+	}
+	if options.CreatedField == "" {
+		options.CreatedField = "created"
+	}
+
+	digest := &Digest{
+		Since:          options.Since,
+		Until:          options.Until,
+		NewNotes:       []string{},
+		EditedNotes:    []EditedNote{},
+		NewLinks:       []NewLink{},
+		CompletedTasks: []CompletedTask{},
+		Health:         current.Health,
+	}
+	if baseline != nil {
+		digest.Baseline = &baseline.Time
+		digest.HealthBefore = &baseline.Health
+	}
+
+	inPeriod := func(t time.Time) bool {
+		return !t.Before(options.Since) && !t.After(options.Until)
+	}
+	// Creation and completion dates are often whole days, so count the
+	// period's first day in full for them
+	firstDay := time.Date(options.Since.Year(), options.Since.Month(), options.Since.Day(), 0, 0, 0, 0, options.Since.Location())
+	onPeriodDay := func(t time.Time) bool {
+		return !t.Before(firstDay) && !t.After(options.Until)
+	}
+
+	for _, file := range files {
+		relPath := filepath.ToSlash(file.RelativePath)
+		note := current.Notes[relPath]
+
+		var before *NoteSnapshot
+		if baseline != nil {
+			if previous, ok := baseline.Notes[relPath]; ok {
+				before = &previous
+			}
+		}
+
+		// New notes are dated by their creation field, or by their
+		// modification time when they are missing from the baseline
+		created, hasCreated := parseCreated(file.Frontmatter[options.CreatedField])
+		isNew := false
+		switch {
+		case hasCreated:
+			isNew = onPeriodDay(created)
+		case baseline != nil && before == nil:
+			isNew = inPeriod(file.Modified)
+		}
+		if isNew {
+			digest.NewNotes = append(digest.NewNotes, relPath)
+		}
+
+		if before != nil && inPeriod(file.Modified) {
+			if change := note.Words - before.Words; change >= options.MinChange || -change >= options.MinChange {
+				digest.EditedNotes = append(digest.EditedNotes, EditedNote{Path: relPath, WordsBefore: before.Words, WordsAfter: note.Words})
+			}
+		}
+
+		if isNew || before != nil {
+			var previous []string
+			if before != nil {
+				previous = before.Links
+			}
+			for _, target := range difference(note.Links, previous) {
+				digest.NewLinks = append(digest.NewLinks, NewLink{Source: relPath, Target: target})
+			}
+		}
+
+		var wasOpen map[string]bool
+		if before != nil {
+			wasOpen = make(map[string]bool, len(before.OpenTasks))
+			for _, text := range before.OpenTasks {
+				wasOpen[text] = true
+			}
+		}
+		for _, task := range parseTasks(file.Body) {
+			if !task.done {
+				continue
+			}
+			switch {
+			case task.doneAt != nil:
+				if onPeriodDay(*task.doneAt) {
+					digest.CompletedTasks = append(digest.CompletedTasks, CompletedTask{Path: relPath, Text: task.text, Done: task.doneAt})
+				}
+			case wasOpen[task.text]:
+				digest.CompletedTasks = append(digest.CompletedTasks, CompletedTask{Path: relPath, Text: task.text})
+			}
+		}
+	}
+
+	sort.Strings(digest.NewNotes)
+	sort.SliceStable(digest.EditedNotes, func(i, j int) bool {
+		return wordChange(digest.EditedNotes[i]) > wordChange(digest.EditedNotes[j])
+	})
+	return digest
+}
+
+func wordChange(note EditedNote) int {
+	change := note.WordsAfter - note.WordsBefore
+	if change < 0 {
+		return -change
+	}
+	return change
+}
+
+// difference returns the strings in sorted a that aren't in b
+func difference(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		seen[s] = true
+	}
+	var result []string
+	for _, s := range a {
+		if !seen[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+type task struct {
+	text   string
+	done   bool
+	doneAt *time.Time
+}
+
+// parseTasks finds the checked and unchecked tasks in a note's body. A
+// completion date in the Tasks plugin's "✅ 2025-01-31" form is removed from
+// the text so a task matches its unchecked self.
+func parseTasks(body string) []task {
+	var tasks []task
+	for _, line := range strings.Split(body, "\n") {
+		matches := taskPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		t := task{done: matches[1] != " "}
+		text := matches[2]
+		if done := taskDonePattern.FindStringSubmatch(text); done != nil {
+			if date, err := time.ParseInLocation("2006-01-02", done[1], time.Local); err == nil {
+				t.doneAt = &date
+			}
+			text = taskDonePattern.ReplaceAllString(text, "")
+		}
+		t.text = strings.TrimSpace(text)
+		if t.text == "" {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// parseCreated reads a creation date from a frontmatter value
+func parseCreated(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case vault.Date:
+		return v.Time, true
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// SaveDigestSnapshot writes a snapshot to the vault's digest directory
+func SaveDigestSnapshot(vaultPath string, snapshot *DigestSnapshot) error {
+	dir := filepath.Join(vaultPath, DefaultDigestDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating digest directory: %w", err)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("encoding digest snapshot: %w", err)
+	}
+	path := filepath.Join(dir, snapshot.Time.Format(digestSnapshotLayout)+".json")
+	if err := safety.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing digest snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadDigestBaseline returns the snapshot to compare a digest since the given
+// time against: the latest one taken by then, or failing that the earliest
+// one after it. It returns nil when there are no snapshots.
+func LoadDigestBaseline(vaultPath string, since time.Time) (*DigestSnapshot, error) {
+	dir := filepath.Join(vaultPath, DefaultDigestDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading digest directory: %w", err)
+	}
+
+	var chosen string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		taken, err := time.ParseInLocation(digestSnapshotLayout, strings.TrimSuffix(name, ".json"), time.Local)
+		if err != nil {
+			continue
+		}
+		// Entries are sorted, so the last one taken by then wins
+		if !taken.After(since) || chosen == "" {
+			chosen = name
+		}
+		if taken.After(since) {
+			break
+		}
+	}
+	if chosen == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, chosen))
+	if err != nil {
+		return nil, fmt.Errorf("reading digest snapshot: %w", err)
+	}
+	var snapshot DigestSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parsing digest snapshot %s: %w", chosen, err)
+	}
+	return &snapshot, nil
+}
+
+// Markdown renders the digest as a note, linking to notes by path
+func (d *Digest) Markdown(limit int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Vault digest %s to %s\n\n", d.Since.Format("2006-01-02"), d.Until.Format("2006-01-02"))
+	if d.Baseline != nil {
+		fmt.Fprintf(&b, "Compared with the vault as of %s.\n", d.Baseline.Format("2006-01-02 15:04"))
+	} else {
+		b.WriteString("No earlier digest to compare with, so edits and new links are only reported from the next digest on.\n")
+	}
+
+	noteLink := func(path string) string {
+		return "[[" + strings.TrimSuffix(path, ".md") + "]]"
+	}
+
+	writeSection := func(title string, count int, item func(i int) string) {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", title, count)
+		if count == 0 {
+			b.WriteString("None.\n")
+			return
+		}
+		shown := count
+		if limit > 0 && shown > limit {
+			shown = limit
+		}
+		for i := 0; i < shown; i++ {
+			b.WriteString("- " + item(i) + "\n")
+		}
+		if shown < count {
+			fmt.Fprintf(&b, "- …and %d more\n", count-shown)
+		}
+	}
+
+	writeSection("New notes", len(d.NewNotes), func(i int) string {
+		return noteLink(d.NewNotes[i])
+	})
+	writeSection("Heavily edited notes", len(d.EditedNotes), func(i int) string {
+		note := d.EditedNotes[i]
+		return fmt.Sprintf("%s: %+d words (%d → %d)", noteLink(note.Path), note.WordsAfter-note.WordsBefore, note.WordsBefore, note.WordsAfter)
+	})
+	writeSection("New links", len(d.NewLinks), func(i int) string {
+		link := d.NewLinks[i]
+		return noteLink(link.Source) + " → " + noteLink(link.Target)
+	})
+	writeSection("Completed tasks", len(d.CompletedTasks), func(i int) string {
+		t := d.CompletedTasks[i]
+		item := t.Text + " (" + noteLink(t.Path)
+		if t.Done != nil {
+			item += ", " + t.Done.Format("2006-01-02")
+		}
+		return item + ")"
+	})
+
+	b.WriteString("\n## Health\n\n")
+	if d.HealthBefore != nil {
+		fmt.Fprintf(&b, "Score %.1f/100 (%+.1f)\n", d.Health, math.Round((d.Health-*d.HealthBefore)*10)/10)
+	} else {
+		fmt.Fprintf(&b, "Score %.1f/100\n", d.Health)
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAnalyzer_DigestWithoutBaseline(t *testing.T) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	files := []*vault.VaultFile{
+		{RelativePath: "new.md", Frontmatter: map[string]interface{}{"created": today}, Modified: now,
+			Body:  "See [[old]]\n\n- [x] Ship it ✅ " + today + "\n- [ ] Tell people\n",
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "old"}}},
+		{RelativePath: "old.md", Frontmatter: map[string]interface{}{"created": "2020-01-01"}, Modified: now,
+			Body:  "- [x] Long done ✅ 2020-01-02\n- [x] Undated\n",
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "new"}}},
+	}
+
+	ana := NewAnalyzer()
+	digest := ana.Digest(files, ana.Snapshot(files, now), nil, DigestOptions{Since: now.Add(-7 * 24 * time.Hour), Until: now})
+
+	assert.Equal(t, []string{"new.md"}, digest.NewNotes)
+	assert.Empty(t, digest.EditedNotes)
+	// Only the links of new notes are known to be new
+	assert.Equal(t, []NewLink{{Source: "new.md", Target: "old.md"}}, digest.NewLinks)
+	require.Len(t, digest.CompletedTasks, 1)
+	assert.Equal(t, "Ship it", digest.CompletedTasks[0].Text)
+	assert.Equal(t, today, digest.CompletedTasks[0].Done.Format("2006-01-02"))
+	assert.Nil(t, digest.Baseline)
+	assert.Nil(t, digest.HealthBefore)
+}
+
+func TestAnalyzer_DigestWithBaseline(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+	baseline := &DigestSnapshot{
+		Time:   since,
+		Health: 50,
+		Notes: map[string]NoteSnapshot{
+			"grown.md":     {Words: 5, OpenTasks: []string{"Write it up"}},
+			"untouched.md": {Words: 300, Links: []string{"grown.md"}},
+		},
+	}
+	files := []*vault.VaultFile{
+		{RelativePath: "grown.md", Modified: now,
+			Body:  words(200) + "\n- [x] Write it up\n[[added]]\n",
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "added"}}},
+		{RelativePath: "untouched.md", Modified: since.Add(-time.Hour), Body: words(10),
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "grown"}}},
+		{RelativePath: "added.md", Modified: now, Body: "fresh"},
+	}
+
+	ana := NewAnalyzer()
+	digest := ana.Digest(files, ana.Snapshot(files, now), baseline, DigestOptions{Since: since, Until: now})
+
+	assert.Equal(t, []string{"added.md"}, digest.NewNotes)
+	// untouched.md shrank, but wasn't modified during the period
+	assert.Equal(t, []EditedNote{{Path: "grown.md", WordsBefore: 5, WordsAfter: 206}}, digest.EditedNotes)
+	assert.Equal(t, []NewLink{{Source: "grown.md", Target: "added.md"}}, digest.NewLinks)
+	assert.Equal(t, []CompletedTask{{Path: "grown.md", Text: "Write it up"}}, digest.CompletedTasks)
+	require.NotNil(t, digest.HealthBefore)
+	assert.Equal(t, 50.0, *digest.HealthBefore)
+	assert.Equal(t, since, *digest.Baseline)
+}
+
+func TestDigest_Markdown(t *testing.T) {
+	before := 60.0
+	done := time.Date(2025, 1, 6, 0, 0, 0, 0, time.Local)
+	digest := &Digest{
+		Since:          time.Date(2025, 1, 1, 9, 0, 0, 0, time.Local),
+		Until:          time.Date(2025, 1, 8, 9, 0, 0, 0, time.Local),
+		NewNotes:       []string{"a.md", "notes/b.md"},
+		EditedNotes:    []EditedNote{{Path: "c.md", WordsBefore: 300, WordsAfter: 120}},
+		NewLinks:       []NewLink{},
+		CompletedTasks: []CompletedTask{{Path: "c.md", Text: "Review", Done: &done}},
+		Health:         72.5,
+		HealthBefore:   &before,
+	}
+
+	md := digest.Markdown(1)
+	assert.Contains(t, md, "# Vault digest 2025-01-01 to 2025-01-08\n")
+	assert.Contains(t, md, "## New notes (2)\n\n- [[a]]\n- …and 1 more\n")
+	assert.Contains(t, md, "- [[c]]: -180 words (300 → 120)\n")
+	assert.Contains(t, md, "## New links (0)\n\nNone.\n")
+	assert.Contains(t, md, "- Review ([[c]], 2025-01-06)\n")
+	assert.Contains(t, md, "Score 72.5/100 (+12.5)\n")
+	assert.Contains(t, md, "No earlier digest")
+}
+
+func TestLoadDigestBaseline(t *testing.T) {
+	dir := t.TempDir()
+
+	baseline, err := LoadDigestBaseline(dir, time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, baseline)
+
+	first := time.Date(2025, 1, 1, 9, 0, 0, 0, time.Local)
+	for i, health := range []float64{10, 20, 30} {
+		snapshot := &DigestSnapshot{Time: first.Add(time.Duration(i) * 7 * 24 * time.Hour), Health: health}
+		require.NoError(t, SaveDigestSnapshot(dir, snapshot))
+	}
+
+	tests := []struct {
+		name   string
+		since  time.Time
+		health float64
+	}{
+		{"latest taken by since", first.Add(10 * 24 * time.Hour), 20},
+		{"taken exactly at since", first.Add(14 * 24 * time.Hour), 30},
+		{"earliest after since", first.Add(-24 * time.Hour), 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline, err := LoadDigestBaseline(dir, tt.since)
+			require.NoError(t, err)
+			require.NotNil(t, baseline)
+			assert.Equal(t, tt.health, baseline.Health)
+		})
+	}
+}
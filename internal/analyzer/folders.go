@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// RecentDays is how far back FolderStats counts a note as recently modified
+const RecentDays = 30
+
+// FolderStats summarizes the notes under one folder of a vault
+type FolderStats struct {
+	Path            string         `json:"path"` // slash-separated; "" for notes at the vault root
+	Depth           int            `json:"depth"`
+	Files           int            `json:"files"`
+	Words           int            `json:"words"`
+	Size            int64          `json:"size"`
+	RecentFiles     int            `json:"recent_files"` // modified in the last RecentDays days
+	LastModified    time.Time      `json:"last_modified"`
+	TagDistribution map[string]int `json:"tag_distribution"`
+}
+
+// FolderStats breaks files down by folder, down to depth levels below the
+// vault root. A folder counts every note beneath it, so notes deeper than
+// depth roll up into their ancestor at that depth, and notes at the vault root
+// are counted under "". Folders are sorted by path, parents before children.
+func (a *Analyzer) FolderStats(files []*vault.VaultFile, depth int) []FolderStats {
+	recent := time.Now().AddDate(0, 0, -RecentDays)
+	folders := make(map[string]*FolderStats)
+
+	add := func(path string, level int, file *vault.VaultFile) {
+		folder, ok := folders[path]
+		if !ok {
+			folder = &FolderStats{Path: path, Depth: level, TagDistribution: make(map[string]int)}
+			folders[path] = folder
+		}
+		folder.Files++
+		folder.Words += len(strings.Fields(file.Body))
+		folder.Size += int64(len(file.Content))
+		if file.Modified.After(recent) {
+			folder.RecentFiles++
+		}
+		if file.Modified.After(folder.LastModified) {
+			folder.LastModified = file.Modified
+		}
+		if tags, ok := file.Frontmatter["tags"]; ok {
+			for _, tag := range a.extractTags(tags) {
+				folder.TagDistribution[tag]++
+			}
+		}
+	}
+
+	for _, file := range files {
+		dir := filepath.ToSlash(filepath.Dir(file.RelativePath))
+		if dir == "." || dir == "/" {
+			add("", 0, file)
+			continue
+		}
+		parts := strings.Split(dir, "/")
+		for level := 1; level <= depth && level <= len(parts); level++ {
+			add(strings.Join(parts[:level], "/"), level, file)
+		}
+	}
+
+	result := make([]FolderStats, 0, len(folders))
+	for _, folder := range folders {
+		result = append(result, *folder)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		// Compare by segment so "a/b" sorts right after "a", before "a-b"
+		return strings.ReplaceAll(result[i].Path, "/", "\x00") < strings.ReplaceAll(result[j].Path, "/", "\x00")
+	})
+	return result
+}
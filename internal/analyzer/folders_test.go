@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAnalyzer_FolderStats(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -90)
+	files := []*vault.VaultFile{
+		{RelativePath: "inbox.md", Body: "one two", Content: []byte("one two"), Modified: now},
+		{RelativePath: "Projects/alpha/plan.md", Body: "a b c", Content: []byte("a b c"), Modified: now,
+			Frontmatter: map[string]interface{}{"tags": []interface{}{"work", "plan"}}},
+		{RelativePath: "Projects/alpha/deep/notes.md", Body: "x", Content: []byte("x"), Modified: old,
+			Frontmatter: map[string]interface{}{"tags": "work"}},
+		{RelativePath: "Projects/beta.md", Body: "y z", Content: []byte("y z"), Modified: old},
+		{RelativePath: "Projects-old/gamma.md", Body: "", Modified: old},
+	}
+
+	folders := NewAnalyzer().FolderStats(files, 2)
+	paths := make([]string, len(folders))
+	for i, folder := range folders {
+		paths[i] = folder.Path
+	}
+	assert.Equal(t, []string{"", "Projects", "Projects/alpha", "Projects-old"}, paths)
+
+	root := folders[0]
+	assert.Equal(t, 1, root.Files)
+	assert.Equal(t, 0, root.Depth)
+
+	projects := folders[1]
+	assert.Equal(t, 3, projects.Files)
+	assert.Equal(t, 6, projects.Words)
+	assert.Equal(t, int64(9), projects.Size)
+	assert.Equal(t, 1, projects.RecentFiles)
+	assert.Equal(t, now, projects.LastModified)
+	assert.Equal(t, map[string]int{"work": 2, "plan": 1}, projects.TagDistribution)
+
+	// Notes deeper than depth roll up into their ancestor
+	alpha := folders[2]
+	assert.Equal(t, 2, alpha.Depth)
+	assert.Equal(t, 2, alpha.Files)
+
+	folders = NewAnalyzer().FolderStats(files, 1)
+	require.Len(t, folders, 3)
+	assert.Equal(t, 3, folders[1].Files)
+	assert.Equal(t, old, folders[2].LastModified)
+}
@@ -0,0 +1,321 @@
+package analyzer
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// maxLabelPropagationRounds bounds cluster detection on graphs that oscillate
+const maxLabelPropagationRounds = 50
+
+// LinkCycle is a set of notes that can all reach each other by following
+// links: a strongly connected component of the link graph with more than one note
+type LinkCycle struct {
+	Files []string `json:"files"`
+}
+
+// LinkCluster is a community of notes that link to each other more than to the
+// rest of the vault
+type LinkCluster struct {
+	Size            int      `json:"size"`
+	InternalLinks   int      `json:"internal_links"`
+	Representatives []string `json:"representatives"` // best connected notes within the cluster
+	Tags            []string `json:"tags,omitempty"`  // most common tags among its notes
+	Files           []string `json:"files"`
+}
+
+// linkResolver maps link targets to the notes they point to, the way Obsidian
+// does: case-insensitively, with wiki links also resolving by note name
+type linkResolver struct {
+	notes map[string]string // lowercased path without .md -> relative path
+	names map[string]string // lowercased note name -> relative path
+}
+
+func newLinkResolver(files []*vault.VaultFile) *linkResolver {
+	r := &linkResolver{
+		notes: make(map[string]string, len(files)),
+		names: make(map[string]string, len(files)),
+	}
+	for _, file := range files {
+		relPath := filepath.ToSlash(file.RelativePath)
+		key := strings.ToLower(strings.TrimSuffix(relPath, ".md"))
+		r.notes[key] = relPath
+
+		// Prefer the shortest path, then alphabetical, when names are ambiguous
+		name := path.Base(key)
+		if existing, ok := r.names[name]; !ok || len(relPath) < len(existing) || (len(relPath) == len(existing) && relPath < existing) {
+			r.names[name] = relPath
+		}
+	}
+	return r
+}
+
+// resolve returns the note a link in file points to, or "" if it is broken.
+// checked is false for links to attachments and URLs, which aren't notes.
+func (r *linkResolver) resolve(file *vault.VaultFile, link vault.Link) (target string, checked bool) {
+	target = filepath.ToSlash(link.Target)
+	if target == "" || strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+		return "", false
+	}
+	if ext := path.Ext(target); ext != ".md" && attachmentExtRegex.MatchString(ext) {
+		return "", false
+	}
+
+	key := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(target, "/"), ".md"))
+	if note, ok := r.notes[key]; ok {
+		return note, true
+	}
+	if link.Type == vault.MarkdownLink {
+		dir := path.Dir(filepath.ToSlash(file.RelativePath))
+		return r.notes[path.Join(dir, key)], true
+	}
+	return r.names[path.Base(key)], true
+}
+
+// noteGraph returns the resolved link graph: each note's distinct link
+// targets, sorted, excluding broken links and links to itself. Links must
+// already be parsed.
+func noteGraph(files []*vault.VaultFile) map[string][]string {
+	resolver := newLinkResolver(files)
+	graph := make(map[string][]string, len(files))
+	for _, file := range files {
+		source := filepath.ToSlash(file.RelativePath)
+		seen := make(map[string]bool)
+		for _, link := range file.Links {
+			target, _ := resolver.resolve(file, link)
+			if target == "" || target == source || seen[target] {
+				continue
+			}
+			seen[target] = true
+			graph[source] = append(graph[source], target)
+		}
+		sort.Strings(graph[source])
+	}
+	return graph
+}
+
+// LinkCounts returns, for each note by slash-separated relative path, how many
+// distinct notes link to it and how many it links to, counted over the
+// resolved link graph. Links must already be parsed.
+func LinkCounts(files []*vault.VaultFile) (in, out map[string]int) {
+	graph := noteGraph(files)
+	in = make(map[string]int, len(files))
+	out = make(map[string]int, len(files))
+	for source, targets := range graph {
+		out[source] = len(targets)
+		for _, target := range targets {
+			in[target]++
+		}
+	}
+	return in, out
+}
+
+// findLinkCycles returns the strongly connected components of graph with more
+// than one note, largest first, using Tarjan's algorithm
+func findLinkCycles(graph map[string][]string) []LinkCycle {
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles []LinkCycle
+
+	var visit func(node string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, target := range graph[node] {
+			if _, visited := index[target]; !visited {
+				visit(target)
+				lowLink[node] = min(lowLink[node], lowLink[target])
+			} else if onStack[target] {
+				lowLink[node] = min(lowLink[node], index[target])
+			}
+		}
+
+		if lowLink[node] != index[node] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == node {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, LinkCycle{Files: component})
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := index[node]; !visited {
+			visit(node)
+		}
+	}
+
+	sort.SliceStable(cycles, func(i, j int) bool {
+		if len(cycles[i].Files) != len(cycles[j].Files) {
+			return len(cycles[i].Files) > len(cycles[j].Files)
+		}
+		return cycles[i].Files[0] < cycles[j].Files[0]
+	})
+	return cycles
+}
+
+// findLinkClusters groups linked notes into communities by label propagation
+// over the undirected link graph: every note repeatedly adopts the label most
+// common among its neighbours until labels settle. Notes are visited in path
+// order and ties go to the smallest label, so results are deterministic.
+func (a *Analyzer) findLinkClusters(files []*vault.VaultFile, graph map[string][]string) []LinkCluster {
+	neighbours := make(map[string]map[string]bool)
+	addEdge := func(from, to string) {
+		if neighbours[from] == nil {
+			neighbours[from] = make(map[string]bool)
+		}
+		neighbours[from][to] = true
+	}
+	for source, targets := range graph {
+		for _, target := range targets {
+			addEdge(source, target)
+			addEdge(target, source)
+		}
+	}
+
+	nodes := make([]string, 0, len(neighbours))
+	for node := range neighbours {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	labels := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		labels[node] = i
+	}
+
+	for round := 0; round < maxLabelPropagationRounds; round++ {
+		changed := false
+		for _, node := range nodes {
+			counts := make(map[int]int)
+			for neighbour := range neighbours[node] {
+				counts[labels[neighbour]]++
+			}
+			maxCount := 0
+			for _, count := range counts {
+				maxCount = max(maxCount, count)
+			}
+			// Keep the current label when it is among the most common
+			best := labels[node]
+			if counts[best] < maxCount {
+				best = -1
+				for label, count := range counts {
+					if count == maxCount && (best < 0 || label < best) {
+						best = label
+					}
+				}
+			}
+			if best != labels[node] {
+				labels[node] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	members := make(map[int][]string)
+	for _, node := range nodes {
+		members[labels[node]] = append(members[labels[node]], node)
+	}
+
+	tagsByPath := make(map[string][]string, len(files))
+	for _, file := range files {
+		tagsByPath[filepath.ToSlash(file.RelativePath)] = a.extractTags(file.Frontmatter["tags"])
+	}
+
+	var clusters []LinkCluster
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		clusters = append(clusters, a.describeCluster(group, graph, neighbours, tagsByPath))
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Size != clusters[j].Size {
+			return clusters[i].Size > clusters[j].Size
+		}
+		return clusters[i].Files[0] < clusters[j].Files[0]
+	})
+	return clusters
+}
+
+// describeCluster summarizes a cluster's notes, picking its best connected
+// notes as representatives and its most common tags as the topic
+func (a *Analyzer) describeCluster(group []string, graph map[string][]string, neighbours map[string]map[string]bool, tagsByPath map[string][]string) LinkCluster {
+	inCluster := make(map[string]bool, len(group))
+	for _, node := range group {
+		inCluster[node] = true
+	}
+
+	cluster := LinkCluster{Size: len(group), Files: group}
+	degree := make(map[string]int, len(group))
+	tagCounts := make(map[string]int)
+	for _, node := range group {
+		for _, target := range graph[node] {
+			if inCluster[target] {
+				cluster.InternalLinks++
+			}
+		}
+		for neighbour := range neighbours[node] {
+			if inCluster[neighbour] {
+				degree[node]++
+			}
+		}
+		for _, tag := range tagsByPath[node] {
+			if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+				tagCounts[tag]++
+			}
+		}
+	}
+
+	ranked := append([]string(nil), group...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return degree[ranked[i]] > degree[ranked[j]]
+	})
+	cluster.Representatives = ranked[:min(3, len(ranked))]
+
+	// Only tags shared by several notes say something about the cluster's topic
+	tags := make([]string, 0, len(tagCounts))
+	for tag, count := range tagCounts {
+		if count > 1 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tagCounts[tags[i]] != tagCounts[tags[j]] {
+			return tagCounts[tags[i]] > tagCounts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	cluster.Tags = tags[:min(3, len(tags))]
+
+	return cluster
+}
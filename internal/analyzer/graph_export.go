@@ -0,0 +1,296 @@
+package analyzer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// GraphFormats lists the formats WriteGraph can produce
+var GraphFormats = []string{"dot", "graphml", "mermaid", "gexf"}
+
+// IsGraphFormat reports whether format is one of GraphFormats
+func IsGraphFormat(format string) bool {
+	for _, f := range GraphFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// NoteGraph is the link graph between notes, ready for export to graph tools
+type NoteGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a note in a NoteGraph
+type GraphNode struct {
+	ID        string `json:"id"`    // vault-relative path
+	Label     string `json:"label"` // note name without extension
+	InDegree  int    `json:"in_degree"`
+	OutDegree int    `json:"out_degree"`
+	Cluster   int    `json:"cluster,omitempty"` // 1-based index into the link clusters, 0 if none
+}
+
+// GraphEdge is a link from one note to another
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// NewNoteGraph builds the graph of resolved links between files, whose links
+// must already be parsed (as AnalyzeLinks does). Notes with fewer than
+// minConnections links in or out are left out along with their edges.
+// clusters, if given, label each node with the cluster it belongs to.
+func NewNoteGraph(files []*vault.VaultFile, clusters []LinkCluster, minConnections int) *NoteGraph {
+	links := noteGraph(files)
+
+	inDegree := make(map[string]int)
+	for _, targets := range links {
+		for _, target := range targets {
+			inDegree[target]++
+		}
+	}
+	clusterOf := make(map[string]int)
+	for i, cluster := range clusters {
+		for _, file := range cluster.Files {
+			clusterOf[file] = i + 1
+		}
+	}
+
+	g := &NoteGraph{}
+	included := make(map[string]bool)
+	for _, file := range files {
+		id := filepath.ToSlash(file.RelativePath)
+		node := GraphNode{
+			ID:        id,
+			Label:     strings.TrimSuffix(path.Base(id), ".md"),
+			InDegree:  inDegree[id],
+			OutDegree: len(links[id]),
+			Cluster:   clusterOf[id],
+		}
+		if node.InDegree+node.OutDegree < minConnections {
+			continue
+		}
+		included[id] = true
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+
+	for _, node := range g.Nodes {
+		for _, target := range links[node.ID] {
+			if included[target] {
+				g.Edges = append(g.Edges, GraphEdge{Source: node.ID, Target: target})
+			}
+		}
+	}
+	return g
+}
+
+// WriteGraph writes g to w in one of GraphFormats
+func WriteGraph(w io.Writer, g *NoteGraph, format string) error {
+	switch format {
+	case "dot":
+		return writeDOT(w, g)
+	case "graphml":
+		return writeGraphML(w, g)
+	case "mermaid":
+		return writeMermaid(w, g)
+	case "gexf":
+		return writeGEXF(w, g)
+	default:
+		return fmt.Errorf("unsupported graph format %q (supported: %s)", format, strings.Join(GraphFormats, ", "))
+	}
+}
+
+// writeDOT writes a Graphviz digraph, with clusters as node colors
+func writeDOT(w io.Writer, g *NoteGraph) error {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph mdnotes {\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, node := range g.Nodes {
+		attrs := "label=" + quote(node.Label)
+		if node.Cluster > 0 {
+			attrs += fmt.Sprintf(", style=\"rounded,filled\", colorscheme=set312, fillcolor=%d", (node.Cluster-1)%12+1)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", quote(node.ID), attrs)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", quote(edge.Source), quote(edge.Target))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMermaid writes a Mermaid flowchart, which needs generated node IDs
+// since note paths may contain characters Mermaid can't parse
+func writeMermaid(w io.Writer, g *NoteGraph) error {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+		label := strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(node.Label)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node.ID], label)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.Source], ids[edge.Target])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// writeGraphML writes a GraphML document, readable by Gephi, yEd and NetworkX
+func writeGraphML(w io.Writer, g *NoteGraph) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{ID: "in_degree", For: "node", AttrName: "in_degree", AttrType: "int"},
+			{ID: "out_degree", For: "node", AttrName: "out_degree", AttrType: "int"},
+			{ID: "cluster", For: "node", AttrName: "cluster", AttrType: "int"},
+		},
+	}
+	doc.Graph.ID = "mdnotes"
+	doc.Graph.EdgeDefault = "directed"
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: node.ID,
+			Data: []graphMLData{
+				{Key: "label", Value: node.Label},
+				{Key: "in_degree", Value: fmt.Sprint(node.InDegree)},
+				{Key: "out_degree", Value: fmt.Sprint(node.OutDegree)},
+				{Key: "cluster", Value: fmt.Sprint(node.Cluster)},
+			},
+		})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: edge.Source, Target: edge.Target})
+	}
+	return writeXML(w, doc)
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID     int    `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type gexfDocument struct {
+	XMLName xml.Name `xml:"gexf"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   struct {
+		DefaultEdgeType string `xml:"defaultedgetype,attr"`
+		Attributes      struct {
+			Class      string          `xml:"class,attr"`
+			Attributes []gexfAttribute `xml:"attribute"`
+		} `xml:"attributes"`
+		Nodes []gexfNode `xml:"nodes>node"`
+		Edges []gexfEdge `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+// writeGEXF writes a GEXF 1.3 document, Gephi's native format
+func writeGEXF(w io.Writer, g *NoteGraph) error {
+	doc := gexfDocument{XMLNS: "http://gexf.net/1.3", Version: "1.3"}
+	doc.Graph.DefaultEdgeType = "directed"
+	doc.Graph.Attributes.Class = "node"
+	doc.Graph.Attributes.Attributes = []gexfAttribute{
+		{ID: "path", Title: "path", Type: "string"},
+		{ID: "cluster", Title: "cluster", Type: "integer"},
+	}
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    node.ID,
+			Label: node.Label,
+			AttValues: []gexfAttValue{
+				{For: "path", Value: node.ID},
+				{For: "cluster", Value: fmt.Sprint(node.Cluster)},
+			},
+		})
+	}
+	for i, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{ID: i, Source: edge.Source, Target: edge.Target})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding XML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func exportTestGraph(minConnections int) *NoteGraph {
+	files := []*vault.VaultFile{
+		{RelativePath: "a.md", Links: wikiLinks(`Say "hi"`, "missing")},
+		{RelativePath: `notes/Say "hi".md`, Links: wikiLinks("a")},
+		{RelativePath: "alone.md"},
+	}
+	clusters := []LinkCluster{{Files: []string{"a.md", `notes/Say "hi".md`}}}
+	return NewNoteGraph(files, clusters, minConnections)
+}
+
+func TestNewNoteGraph(t *testing.T) {
+	g := exportTestGraph(1)
+	require.Len(t, g.Nodes, 2)
+	assert.Equal(t, GraphNode{ID: "a.md", Label: "a", InDegree: 1, OutDegree: 1, Cluster: 1}, g.Nodes[0])
+	assert.Equal(t, []GraphEdge{
+		{Source: "a.md", Target: `notes/Say "hi".md`},
+		{Source: `notes/Say "hi".md`, Target: "a.md"},
+	}, g.Edges)
+
+	assert.Len(t, exportTestGraph(0).Nodes, 3)
+	assert.Empty(t, exportTestGraph(3).Edges)
+}
+
+func TestWriteGraph(t *testing.T) {
+	g := exportTestGraph(1)
+
+	write := func(format string) string {
+		var buf bytes.Buffer
+		require.NoError(t, WriteGraph(&buf, g, format))
+		return buf.String()
+	}
+
+	dot := write("dot")
+	assert.True(t, strings.HasPrefix(dot, "digraph mdnotes {"))
+	assert.Contains(t, dot, `"a.md" -> "notes/Say \"hi\".md";`)
+	assert.Contains(t, dot, `[label="Say \"hi\"", style="rounded,filled", colorscheme=set312, fillcolor=1]`)
+
+	mermaid := write("mermaid")
+	assert.Contains(t, mermaid, "graph LR\n")
+	assert.Contains(t, mermaid, `n1["Say #quot;hi#quot;"]`)
+	assert.Contains(t, mermaid, "n0 --> n1\n")
+
+	for _, format := range []string{"graphml", "gexf"} {
+		out := write(format)
+		dec := xml.NewDecoder(strings.NewReader(out))
+		for {
+			if _, err := dec.Token(); err != nil {
+				assert.ErrorIs(t, err, io.EOF, format)
+				break
+			}
+		}
+		assert.Contains(t, out, `source="a.md" target="notes/Say &#34;hi&#34;.md"`, format)
+	}
+
+	err := WriteGraph(&bytes.Buffer{}, g, "png")
+	assert.ErrorContains(t, err, "unsupported graph format")
+	assert.False(t, IsGraphFormat("json"))
+}
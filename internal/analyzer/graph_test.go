@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func wikiLinks(targets ...string) []vault.Link {
+	links := make([]vault.Link, 0, len(targets))
+	for _, target := range targets {
+		links = append(links, vault.Link{Type: vault.WikiLink, Target: target})
+	}
+	return links
+}
+
+func TestLinkResolver(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md"},
+		{RelativePath: "notes/Topic.md"},
+		{RelativePath: "archive/notes/topic.md"},
+		{RelativePath: "archive/other.md"},
+	}
+	resolver := newLinkResolver(files)
+
+	tests := []struct {
+		name    string
+		link    vault.Link
+		target  string
+		checked bool
+	}{
+		{"exact path", vault.Link{Type: vault.WikiLink, Target: "notes/topic"}, "notes/Topic.md", true},
+		{"note name prefers shortest path", vault.Link{Type: vault.WikiLink, Target: "TOPIC"}, "notes/Topic.md", true},
+		{"vault path before relative path", vault.Link{Type: vault.MarkdownLink, Target: "notes/topic.md"}, "notes/Topic.md", true},
+		{"relative markdown link", vault.Link{Type: vault.MarkdownLink, Target: "other.md"}, "archive/other.md", true},
+		{"broken", vault.Link{Type: vault.WikiLink, Target: "missing"}, "", true},
+		{"attachment", vault.Link{Type: vault.EmbedLink, Target: "image.png"}, "", false},
+		{"url", vault.Link{Type: vault.MarkdownLink, Target: "https://example.com"}, "", false},
+	}
+
+	source := &vault.VaultFile{RelativePath: "archive/index.md"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, checked := resolver.resolve(source, tt.link)
+			assert.Equal(t, tt.target, target)
+			assert.Equal(t, tt.checked, checked)
+		})
+	}
+}
+
+func TestAnalyzer_AnalyzeLinksCyclesAndClusters(t *testing.T) {
+	files := []*vault.VaultFile{
+		// A cycle of three notes about Go
+		{RelativePath: "go/a.md", Links: wikiLinks("b", "a"), Frontmatter: map[string]interface{}{"tags": []interface{}{"go", "lang"}}},
+		{RelativePath: "go/b.md", Links: wikiLinks("c"), Frontmatter: map[string]interface{}{"tags": []interface{}{"go"}}},
+		{RelativePath: "go/c.md", Links: wikiLinks("a", "b"), Frontmatter: map[string]interface{}{"tags": "go"}},
+		// A separate pair that only links one way
+		{RelativePath: "cooking/bread.md", Links: wikiLinks("yeast", "missing")},
+		{RelativePath: "cooking/yeast.md"},
+		{RelativePath: "alone.md"},
+	}
+
+	analysis := NewAnalyzer().AnalyzeLinks(files)
+
+	require.Len(t, analysis.Cycles, 1)
+	assert.Equal(t, []string{"go/a.md", "go/b.md", "go/c.md"}, analysis.Cycles[0].Files)
+
+	require.Len(t, analysis.Clusters, 2)
+	goCluster := analysis.Clusters[0]
+	assert.Equal(t, 3, goCluster.Size)
+	assert.Equal(t, 4, goCluster.InternalLinks)
+	assert.Equal(t, []string{"go/a.md", "go/b.md", "go/c.md"}, goCluster.Files)
+	assert.Equal(t, []string{"go"}, goCluster.Tags)
+	assert.Len(t, goCluster.Representatives, 3)
+
+	cooking := analysis.Clusters[1]
+	assert.Equal(t, []string{"cooking/bread.md", "cooking/yeast.md"}, cooking.Files)
+	assert.Equal(t, 1, cooking.InternalLinks)
+	assert.Empty(t, cooking.Tags)
+
+	assert.Equal(t, 1, analysis.BrokenLinks)
+}
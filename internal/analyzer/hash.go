@@ -0,0 +1,156 @@
+package analyzer
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Hasher hashes note content for exact duplicate detection
+type Hasher interface {
+	Name() string
+	Sum(data []byte) string
+}
+
+// DefaultHasher is the hash exact duplicate detection uses unless configured
+const DefaultHasher = "xxhash"
+
+// HasherNames lists the hashes NewHasher accepts
+var HasherNames = []string{"xxhash", "sha256", "md5"}
+
+// NewHasher returns the hasher called name: xxhash (fast, the default),
+// sha256 or md5
+func NewHasher(name string) (Hasher, error) {
+	switch name {
+	case "", "xxhash":
+		return xxhashHasher{}, nil
+	case "sha256":
+		return sha256Hasher{}, nil
+	case "md5":
+		return md5Hasher{}, nil
+	default:
+		return nil, fmt.Errorf("unknown hash '%s' - valid options are: %s", name, strings.Join(HasherNames, ", "))
+	}
+}
+
+// SetContentHashing sets the hasher exact duplicate detection uses, and
+// whether frontmatter is hashed along with the body
+func (a *Analyzer) SetContentHashing(hasher Hasher, includeFrontmatter bool) {
+	a.hasher = hasher
+	a.hashFull = includeFrontmatter
+}
+
+type xxhashHasher struct{}
+
+func (xxhashHasher) Name() string { return "xxhash" }
+
+func (xxhashHasher) Sum(data []byte) string {
+	return fmt.Sprintf("%016x", xxhash64(data))
+}
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Name() string { return "sha256" }
+
+func (sha256Hasher) Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type md5Hasher struct{}
+
+func (md5Hasher) Name() string { return "md5" }
+
+func (md5Hasher) Sum(data []byte) string {
+	return fmt.Sprintf("%x", md5.Sum(data))
+}
+
+// NormalizeContent prepares note content for hashing so notes differing only
+// in line endings or surrounding whitespace hash alike: line endings become
+// \n, trailing whitespace is removed from each line, and leading and trailing
+// blank lines are dropped
+func NormalizeContent(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// XXH64 primes
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 is the XXH64 hash of data with seed 0
+func xxhash64(data []byte) uint64 {
+	n := len(data)
+	var h uint64
+
+	if n >= 32 {
+		// Variables, since the seeds wrap around as constants can't
+		prime1, prime2 := xxPrime1, xxPrime2
+		v1 := prime1 + prime2
+		v2 := prime2
+		v3 := uint64(0)
+		v4 := -prime1
+		for len(data) >= 32 {
+			v1 = xxRound(v1, binary.LittleEndian.Uint64(data[0:8]))
+			v2 = xxRound(v2, binary.LittleEndian.Uint64(data[8:16]))
+			v3 = xxRound(v3, binary.LittleEndian.Uint64(data[16:24]))
+			v4 = xxRound(v4, binary.LittleEndian.Uint64(data[24:32]))
+			data = data[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMergeRound(h, v1)
+		h = xxMergeRound(h, v2)
+		h = xxMergeRound(h, v3)
+		h = xxMergeRound(h, v4)
+	} else {
+		h = xxPrime5
+	}
+	h += uint64(n)
+
+	for len(data) >= 8 {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(data[:8]))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+		data = data[8:]
+	}
+	if len(data) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(data[:4])) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		data = data[4:]
+	}
+	for _, b := range data {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
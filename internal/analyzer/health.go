@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"github.com/eoinhurrell/mdnotes/internal/i18n"
+)
+
+// HealthRule is one check the vault health score is made of. A rule measures a
+// problem in the vault's statistics, and each enabled rule whose measure is
+// above Threshold takes Weight × the measure off a score of 100.
+type HealthRule struct {
+	ID        string
+	Weight    float64
+	Threshold float64
+	Enabled   bool
+	// Measure returns the size of the problem, as the share of files or links
+	// affected or as a count, and the number of things affected
+	Measure    func(stats VaultStats) (value float64, count int)
+	Issue      string // Message describing the problem; %d is the count
+	Suggestion string
+}
+
+// RuleScore is how one rule contributed to a health score
+type RuleScore struct {
+	ID        string  `json:"id"`
+	Enabled   bool    `json:"enabled"`
+	Weight    float64 `json:"weight"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"`
+	Count     int     `json:"count"`
+	Penalty   float64 `json:"penalty"`
+}
+
+// DefaultHealthRules returns the built-in health rules with their default
+// weights and thresholds
+func DefaultHealthRules() []HealthRule {
+	return []HealthRule{
+		{
+			ID:      "missing_frontmatter",
+			Weight:  30,
+			Enabled: true,
+			Measure: func(stats VaultStats) (float64, int) {
+				return share(stats.FilesWithoutFrontmatter, stats.TotalFiles), stats.FilesWithoutFrontmatter
+			},
+			Issue:      "%d files missing frontmatter",
+			Suggestion: "Add frontmatter to files using 'mdnotes frontmatter ensure'",
+		},
+		{
+			ID:      "orphaned_files",
+			Weight:  20,
+			Enabled: true,
+			Measure: func(stats VaultStats) (float64, int) {
+				// A single note can't link to anything
+				if stats.TotalFiles <= 1 {
+					return 0, 0
+				}
+				return share(len(stats.OrphanedFiles), stats.TotalFiles), len(stats.OrphanedFiles)
+			},
+			Issue:      "%d orphaned files",
+			Suggestion: "Review orphaned files and add links to integrate them",
+		},
+		{
+			ID:      "broken_links",
+			Weight:  25,
+			Enabled: true,
+			Measure: func(stats VaultStats) (float64, int) {
+				return share(stats.BrokenLinksCount, stats.TotalLinks), stats.BrokenLinksCount
+			},
+			Issue:      "%d broken links",
+			Suggestion: "Fix broken links using 'mdnotes links check'",
+		},
+		{
+			ID:      "duplicates",
+			Weight:  5,
+			Enabled: true,
+			Measure: func(stats VaultStats) (float64, int) {
+				return float64(stats.DuplicateCount), stats.DuplicateCount
+			},
+			Issue:      "%d duplicate entries",
+			Suggestion: "Review and resolve duplicate content",
+		},
+	}
+}
+
+// share returns n as a fraction of total, or 0 when total is 0
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// SetHealthRules sets the rules GetHealthScore scores a vault by, in place of
+// DefaultHealthRules
+func (a *Analyzer) SetHealthRules(rules []HealthRule) {
+	a.healthRules = rules
+}
+
+// GetHealthScore scores a vault's health out of 100 with the analyzer's health
+// rules, reporting each rule's contribution
+func (a *Analyzer) GetHealthScore(stats VaultStats) HealthScore {
+	rules := a.healthRules
+	if rules == nil {
+		rules = DefaultHealthRules()
+	}
+
+	health := HealthScore{Score: 100}
+	for _, rule := range rules {
+		value, count := rule.Measure(stats)
+		result := RuleScore{
+			ID:        rule.ID,
+			Enabled:   rule.Enabled,
+			Weight:    rule.Weight,
+			Threshold: rule.Threshold,
+			Value:     value,
+			Count:     count,
+		}
+		if rule.Enabled && count > 0 && value > rule.Threshold {
+			result.Penalty = rule.Weight * value
+			health.Score -= result.Penalty
+			health.Issues = append(health.Issues, i18n.T(rule.Issue, count))
+			health.Suggestions = append(health.Suggestions, i18n.Text(rule.Suggestion))
+		}
+		health.Rules = append(health.Rules, result)
+	}
+
+	// Ensure score doesn't go below 0
+	if health.Score < 0 {
+		health.Score = 0
+	}
+
+	switch {
+	case health.Score >= 90:
+		health.Level = Excellent
+	case health.Score >= 75:
+		health.Level = Good
+	case health.Score >= 60:
+		health.Level = Fair
+	case health.Score >= 40:
+		health.Level = Poor
+	default:
+		health.Level = Critical
+	}
+	return health
+}
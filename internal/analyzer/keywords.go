@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Keyword is a term that characterizes a note, scored by TF-IDF
+type Keyword struct {
+	Term  string  `json:"term"`
+	Score float64 `json:"score"`
+	Count int     `json:"count"`
+}
+
+// NoteKeywords are the best keywords of one note
+type NoteKeywords struct {
+	Path     string    `json:"path"`
+	Keywords []Keyword `json:"keywords"`
+}
+
+// TermTrend is how widely a term is used across the vault and over time
+type TermTrend struct {
+	Term     string          `json:"term"`
+	Notes    int             `json:"notes"`    // Notes using the term
+	Count    int             `json:"count"`    // Uses in all notes
+	Timeline []TimelinePoint `json:"timeline"` // Notes using the term per period, most recent first
+	// Growth is the share of the latest period's notes using the term minus
+	// the share of all notes, in percentage points
+	Growth float64 `json:"growth"`
+}
+
+// KeywordOptions configures AnalyzeKeywords
+type KeywordOptions struct {
+	PerNote     int    // Keywords per note; 0 means 5
+	Top         int    // Vault-wide terms reported; 0 means 20
+	Granularity string // Trend periods: day, week, month (default) or quarter
+}
+
+// KeywordAnalysis holds per-note keywords and vault-wide term trends
+type KeywordAnalysis struct {
+	TotalNotes   int            `json:"total_notes"`
+	TotalTerms   int            `json:"total_terms"` // Distinct terms
+	Granularity  string         `json:"granularity"`
+	LatestPeriod string         `json:"latest_period,omitempty"`
+	TopTerms     []TermTrend    `json:"top_terms"`
+	Rising       []TermTrend    `json:"rising"`
+	Notes        []NoteKeywords `json:"notes"`
+}
+
+// AnalyzeKeywords extracts TF-IDF keywords for every note, along with the
+// vault's most used terms and those rising in the latest period, by
+// modification date
+func (a *Analyzer) AnalyzeKeywords(files []*vault.VaultFile, opts KeywordOptions) KeywordAnalysis {
+	if opts.PerNote <= 0 {
+		opts.PerNote = 5
+	}
+	if opts.Top <= 0 {
+		opts.Top = 20
+	}
+	if opts.Granularity == "" {
+		opts.Granularity = "month"
+	}
+	analysis := KeywordAnalysis{
+		TotalNotes:  len(files),
+		Granularity: opts.Granularity,
+		TopTerms:    []TermTrend{},
+		Rising:      []TermTrend{},
+		Notes:       []NoteKeywords{},
+	}
+	if len(files) == 0 {
+		return analysis
+	}
+
+	counts := make([]map[string]int, len(files))
+	docFreq := make(map[string]int)
+	uses := make(map[string]int)
+	periodNotes := make(map[string]int)
+	termPeriods := make(map[string]map[string]int)
+	for i, file := range files {
+		counts[i] = termFrequencies(a.extractReadableText(file.Body))
+		period := a.formatPeriod(file.Modified, opts.Granularity)
+		periodNotes[period]++
+		for term, n := range counts[i] {
+			docFreq[term]++
+			uses[term] += n
+			if termPeriods[term] == nil {
+				termPeriods[term] = make(map[string]int)
+			}
+			termPeriods[term][period]++
+		}
+	}
+	analysis.TotalTerms = len(docFreq)
+
+	for i, file := range files {
+		analysis.Notes = append(analysis.Notes, NoteKeywords{
+			Path:     file.RelativePath,
+			Keywords: topKeywords(counts[i], docFreq, len(files), opts.PerNote),
+		})
+	}
+	sort.Slice(analysis.Notes, func(i, j int) bool { return analysis.Notes[i].Path < analysis.Notes[j].Path })
+
+	// Trends compare the latest period with the vault as a whole
+	for period := range periodNotes {
+		if period > analysis.LatestPeriod {
+			analysis.LatestPeriod = period
+		}
+	}
+	trends := make([]TermTrend, 0, len(docFreq))
+	for term, notes := range docFreq {
+		trend := TermTrend{Term: term, Notes: notes, Count: uses[term]}
+		latestShare := float64(termPeriods[term][analysis.LatestPeriod]) / float64(periodNotes[analysis.LatestPeriod])
+		trend.Growth = math.Round((latestShare-float64(notes)/float64(len(files)))*1000) / 10
+		trends = append(trends, trend)
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Notes != trends[j].Notes {
+			return trends[i].Notes > trends[j].Notes
+		}
+		if trends[i].Count != trends[j].Count {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Term < trends[j].Term
+	})
+	for _, trend := range trends[:min(opts.Top, len(trends))] {
+		trend.Timeline = a.buildTimeline(termPeriods[trend.Term], opts.Granularity)
+		analysis.TopTerms = append(analysis.TopTerms, trend)
+	}
+
+	// Rising terms are used in more than one note, so a single new note
+	// doesn't make all of its words trend
+	if len(periodNotes) > 1 {
+		sort.SliceStable(trends, func(i, j int) bool { return trends[i].Growth > trends[j].Growth })
+		for _, trend := range trends {
+			if len(analysis.Rising) == opts.Top || trend.Growth <= 0 {
+				break
+			}
+			if trend.Notes < 2 {
+				continue
+			}
+			trend.Timeline = a.buildTimeline(termPeriods[trend.Term], opts.Granularity)
+			analysis.Rising = append(analysis.Rising, trend)
+		}
+	}
+	return analysis
+}
+
+// topKeywords ranks a note's terms by TF-IDF, with sublinear term frequency
+// and smoothed inverse document frequency so that a vault of one note still
+// has keywords
+func topKeywords(counts, docFreq map[string]int, notes, limit int) []Keyword {
+	keywords := make([]Keyword, 0, len(counts))
+	for term, n := range counts {
+		idf := math.Log(float64(1+notes)/float64(1+docFreq[term])) + 1
+		score := (1 + math.Log(float64(n))) * idf
+		keywords = append(keywords, Keyword{Term: term, Score: math.Round(score*1000) / 1000, Count: n})
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Score != keywords[j].Score {
+			return keywords[i].Score > keywords[j].Score
+		}
+		return keywords[i].Term < keywords[j].Term
+	})
+	if len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
+
+// termFrequencies counts the significant words in text: lowercased words of
+// four or more letters, possessives trimmed, that aren't common words in any
+// supported language
+func termFrequencies(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		if strings.Contains(field, "://") {
+			continue
+		}
+		word := strings.TrimFunc(field, func(r rune) bool { return !unicode.IsLetter(r) })
+		word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+		if len([]rune(word)) < 4 || strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && r != '-' }) >= 0 {
+			continue
+		}
+		if isStopword(word) {
+			continue
+		}
+		counts[word]++
+	}
+	return counts
+}
+
+// isStopword reports whether word is too common to say what a note is about
+func isStopword(word string) bool {
+	if commonWords[word] {
+		return true
+	}
+	for _, words := range stopwords {
+		for _, w := range words {
+			if w == word {
+				return true
+			}
+		}
+	}
+	return false
+}
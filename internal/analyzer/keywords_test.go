@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAnalyzer_AnalyzeKeywords(t *testing.T) {
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	note := func(path, body string, modified time.Time) *vault.VaultFile {
+		return &vault.VaultFile{RelativePath: path, Body: body, Modified: modified}
+	}
+	files := []*vault.VaultFile{
+		note("garden.md", "Tomatoes need compost. Compost and more compost for the garden beds.", jan),
+		note("shed.md", "The garden shed needs paint. Paint the shed doors.", jan),
+		note("pond.md", "Fish in the garden pond eat algae. Kubernetes at work, with `kubectl apply` commands.", mar),
+		note("k8s.md", "Kubernetes upgrade notes: drain nodes before the Kubernetes upgrade.\n\n```\nkubectl drain compost\n```", mar),
+	}
+
+	analysis := NewAnalyzer().AnalyzeKeywords(files, KeywordOptions{PerNote: 2, Top: 3})
+	assert.Equal(t, 4, analysis.TotalNotes)
+	assert.Equal(t, "2024-03", analysis.LatestPeriod)
+
+	keywords := make(map[string][]string)
+	for _, n := range analysis.Notes {
+		for _, k := range n.Keywords {
+			keywords[n.Path] = append(keywords[n.Path], k.Term)
+		}
+	}
+	assert.Equal(t, []string{"compost", "beds"}, keywords["garden.md"], "frequent words rank first, vault-wide ones lower")
+	assert.Equal(t, []string{"upgrade", "kubernetes"}, keywords["k8s.md"], "terms in fewer notes say more")
+	assert.NotContains(t, keywords["pond.md"], "kubectl", "code is not prose")
+
+	require.NotEmpty(t, analysis.TopTerms)
+	assert.Equal(t, "garden", analysis.TopTerms[0].Term)
+	assert.Equal(t, 3, analysis.TopTerms[0].Notes)
+	assert.Equal(t, []TimelinePoint{{Period: "2024-03", Count: 1}, {Period: "2024-01", Count: 2}}, analysis.TopTerms[0].Timeline)
+
+	// kubernetes is in both March notes and no January ones
+	require.NotEmpty(t, analysis.Rising)
+	assert.Equal(t, "kubernetes", analysis.Rising[0].Term)
+	assert.Equal(t, 50.0, analysis.Rising[0].Growth)
+}
+
+func TestTermFrequencies(t *testing.T) {
+	counts := termFrequencies("The Garden's garden, and (gardens)! Die Katze und der Hund. See https://example.com/garden x2y2")
+	assert.Equal(t, map[string]int{"garden": 2, "gardens": 1, "katze": 1, "hund": 1}, counts)
+}
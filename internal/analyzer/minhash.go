@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultSimilarityThreshold is the word-set Jaccard similarity at which two
+// notes are reported as near-duplicates
+const DefaultSimilarityThreshold = 0.8
+
+// minhashSize is the number of hash functions in a MinHash signature
+const minhashSize = 128
+
+// SetSimilarityThreshold sets the similarity, from 0 to 1, at which
+// SimilarityMatch reports notes as duplicates
+func (a *Analyzer) SetSimilarityThreshold(threshold float64) {
+	a.similarity = threshold
+}
+
+// findSimilarContentDuplicates groups notes whose sets of words have a
+// Jaccard similarity of at least the analyzer's threshold. MinHash signatures
+// and locality-sensitive hashing find candidate pairs in near-linear time;
+// each candidate is then checked against its exact similarity. Notes similar
+// to each other through a chain of pairs end up in the same group. Notes
+// without words are left out, as exact matching covers them.
+func (a *Analyzer) findSimilarContentDuplicates(files []*vault.VaultFile) []ContentDuplicate {
+	threshold := a.similarity
+	if threshold <= 0 {
+		threshold = DefaultSimilarityThreshold
+	}
+
+	words := make([]map[string]bool, len(files))
+	signatures := make([][]uint64, len(files))
+	for i, file := range files {
+		words[i] = wordSet(file.Body)
+		if len(words[i]) > 0 {
+			signatures[i] = minhashSignature(words[i])
+		}
+	}
+
+	// Notes sharing every row of any band become candidates
+	bands, rows := lshBands(threshold)
+	parent := make([]int, len(files))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	checked := make(map[[2]int]bool)
+	for band := 0; band < bands; band++ {
+		buckets := make(map[uint64][]int)
+		for i, signature := range signatures {
+			if signature == nil {
+				continue
+			}
+			// Colliding keys only add candidates, which are checked anyway
+			var key uint64
+			for _, v := range signature[band*rows : (band+1)*rows] {
+				key = mix64(key ^ v)
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+		for _, bucket := range buckets {
+			for x := 0; x < len(bucket); x++ {
+				for y := x + 1; y < len(bucket); y++ {
+					i, j := bucket[x], bucket[y]
+					if checked[[2]int{i, j}] || find(i) == find(j) {
+						continue
+					}
+					checked[[2]int{i, j}] = true
+					if jaccard(words[i], words[j]) >= threshold {
+						parent[find(j)] = find(i)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range files {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var duplicates []ContentDuplicate
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		duplicate := ContentDuplicate{
+			Hash:  fmt.Sprintf("similar_%d", members[0]),
+			Count: len(members),
+			Size:  len(files[members[0]].Body),
+		}
+		for _, i := range members {
+			duplicate.Files = append(duplicate.Files, files[i].Path)
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].Count != duplicates[j].Count {
+			return duplicates[i].Count > duplicates[j].Count
+		}
+		return duplicates[i].Files[0] < duplicates[j].Files[0]
+	})
+	return duplicates
+}
+
+// SimilarBodies reports whether two note bodies are duplicates by the tests
+// FindContentDuplicates uses: their normalized hashes match, or their word
+// sets have at least the analyzer's similarity threshold in common
+func (a *Analyzer) SimilarBodies(body1, body2 string) bool {
+	hasher := a.hasher
+	if hasher == nil {
+		hasher, _ = NewHasher(DefaultHasher)
+	}
+	if hasher.Sum([]byte(NormalizeContent(body1))) == hasher.Sum([]byte(NormalizeContent(body2))) {
+		return true
+	}
+	threshold := a.similarity
+	if threshold <= 0 {
+		threshold = DefaultSimilarityThreshold
+	}
+	words1, words2 := wordSet(body1), wordSet(body2)
+	return len(words1) > 0 && len(words2) > 0 && jaccard(words1, words2) >= threshold
+}
+
+// lshBands splits a signature into bands of rows so that pairs at about 0.1
+// below threshold still have an even chance of sharing a band, keeping missed
+// near-duplicates rare
+func lshBands(threshold float64) (bands, rows int) {
+	target := math.Max(threshold-0.1, 0.05)
+	rows = 1
+	for r := 2; r <= minhashSize; r++ {
+		b := minhashSize / r
+		if math.Pow(1/float64(b), 1/float64(r)) > target {
+			break
+		}
+		rows = r
+	}
+	return minhashSize / rows, rows
+}
+
+// minhashSignature returns the minimum of each of minhashSize hash functions
+// over words
+func minhashSignature(words map[string]bool) []uint64 {
+	signature := make([]uint64, minhashSize)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	for word := range words {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(word))
+		base := h.Sum64()
+		for i := range signature {
+			if v := mix64(base ^ uint64(i)*0x9e3779b97f4a7c15); v < signature[i] {
+				signature[i] = v
+			}
+		}
+	}
+	return signature
+}
+
+// mix64 is the splitmix64 finalizer, turning one hash into many independent ones
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// wordSet returns the distinct lowercased words of text
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		words[word] = true
+	}
+	return words
+}
+
+// jaccard returns the Jaccard similarity of two word sets
+func jaccard(set1, set2 map[string]bool) float64 {
+	if len(set1) == 0 && len(set2) == 0 {
+		return 1.0
+	}
+	intersection := 0
+	for word := range set1 {
+		if set2[word] {
+			intersection++
+		}
+	}
+	union := len(set1) + len(set2) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAnalyzer_FindSimilarContentDuplicates(t *testing.T) {
+	base := "the quick brown fox jumps over the lazy dog near a quiet river bank today"
+	files := []*vault.VaultFile{
+		{Path: "a.md", Body: base},
+		{Path: "b.md", Body: base + " again"},       // 13 of 14 words shared with a.md
+		{Path: "c.md", Body: strings.ToUpper(base)}, // case is ignored
+		{Path: "d.md", Body: "completely unrelated words here"},
+		{Path: "e.md", Body: ""},
+		{Path: "f.md", Body: ""},
+	}
+
+	analyzer := NewAnalyzer()
+	duplicates := analyzer.FindContentDuplicates(files, SimilarityMatch)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, []string{"a.md", "b.md", "c.md"}, duplicates[0].Files)
+	assert.Equal(t, 3, duplicates[0].Count)
+
+	// A stricter threshold only keeps identical word sets
+	analyzer.SetSimilarityThreshold(0.95)
+	duplicates = analyzer.FindContentDuplicates(files, SimilarityMatch)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, []string{"a.md", "c.md"}, duplicates[0].Files)
+}
+
+func TestAnalyzer_FindSimilarContentDuplicatesAtScale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large vault test in short mode")
+	}
+
+	// 5000 distinct notes, with a near-copy of every hundredth one
+	var files []*vault.VaultFile
+	for i := 0; i < 5000; i++ {
+		words := make([]string, 40)
+		for w := range words {
+			words[w] = fmt.Sprintf("w%d_%d", i, w)
+		}
+		files = append(files, &vault.VaultFile{Path: fmt.Sprintf("note%d.md", i), Body: strings.Join(words, " ")})
+		if i%100 == 0 {
+			files = append(files, &vault.VaultFile{Path: fmt.Sprintf("copy%d.md", i), Body: strings.Join(words[1:], " ")})
+		}
+	}
+
+	duplicates := NewAnalyzer().FindContentDuplicates(files, SimilarityMatch)
+	assert.Len(t, duplicates, 50)
+}
+
+func TestLSHBands(t *testing.T) {
+	for _, threshold := range []float64{0.3, 0.5, 0.8, 0.95, 1} {
+		bands, rows := lshBands(threshold)
+		assert.LessOrEqual(t, bands*rows, minhashSize)
+		assert.GreaterOrEqual(t, bands, 1)
+	}
+	// Higher thresholds use longer bands, so fewer pairs become candidates
+	_, low := lshBands(0.5)
+	_, high := lshBands(0.9)
+	assert.Less(t, low, high)
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Kinds of poorly connected notes
+const (
+	OrphanKind   = "orphan"   // Nothing links to it
+	DeadEndKind  = "dead-end" // It links to nothing
+	IsolatedKind = "isolated" // Both: it has no links either way
+)
+
+// OrphanNote is a note that is missing inbound or outbound links
+type OrphanNote struct {
+	Path     string    `json:"path"`
+	Kind     string    `json:"kind"`
+	Inbound  int       `json:"inbound"`
+	Outbound int       `json:"outbound"`
+	Modified time.Time `json:"modified"`
+	AgeDays  int       `json:"age_days"`
+	Words    int       `json:"words"`
+}
+
+// OrphanOptions selects which poorly connected notes FindOrphans reports
+type OrphanOptions struct {
+	DeadEnds       bool          // Also report notes that link to nothing
+	MinAge         time.Duration // Skip notes modified more recently than this
+	ExcludeFolders []string      // Vault-relative folders whose notes are skipped
+	Now            time.Time     // Reference time for ages; zero means now
+}
+
+// FindOrphans returns notes nothing links to, and with DeadEnds notes that
+// link to nothing, sorted by path. Links are resolved like Obsidian does, so
+// broken links don't count, and the whole vault should be given even when
+// only some notes are of interest.
+func (a *Analyzer) FindOrphans(files []*vault.VaultFile, opts OrphanOptions) []OrphanNote {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, file := range files {
+		a.parseLinks(file)
+	}
+	inbound, outbound := LinkCounts(files)
+
+	var excluded []string
+	for _, folder := range opts.ExcludeFolders {
+		if folder = strings.Trim(path.Clean("/"+filepath.ToSlash(folder)), "/"); folder != "" {
+			excluded = append(excluded, folder+"/")
+		}
+	}
+
+	var notes []OrphanNote
+	for _, file := range files {
+		relPath := filepath.ToSlash(file.RelativePath)
+		if hasAnyPrefix(relPath, excluded) {
+			continue
+		}
+		if opts.MinAge > 0 && now.Sub(file.Modified) < opts.MinAge {
+			continue
+		}
+
+		note := OrphanNote{
+			Path:     relPath,
+			Inbound:  inbound[relPath],
+			Outbound: outbound[relPath],
+			Modified: file.Modified,
+			AgeDays:  int(now.Sub(file.Modified).Hours() / 24),
+			Words:    len(strings.Fields(file.Body)),
+		}
+		switch {
+		case note.Inbound == 0 && note.Outbound == 0:
+			note.Kind = IsolatedKind
+		case note.Inbound == 0:
+			note.Kind = OrphanKind
+		case note.Outbound == 0 && opts.DeadEnds:
+			note.Kind = DeadEndKind
+		default:
+			continue
+		}
+		notes = append(notes, note)
+	}
+
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Path < notes[j].Path })
+	return notes
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestFindOrphans(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	note := func(path string, age time.Duration, links ...string) *vault.VaultFile {
+		return &vault.VaultFile{RelativePath: path, Modified: now.Add(-age), Body: "some words here", Links: wikiLinks(links...)}
+	}
+	day := 24 * time.Hour
+	files := []*vault.VaultFile{
+		note("hub.md", 100*day, "leaf", "missing"),
+		note("leaf.md", 100*day),
+		note("alone.md", 100*day),
+		note("fresh.md", day),
+		note("daily/2024-01-01.md", 100*day),
+	}
+
+	orphans := NewAnalyzer().FindOrphans(files, OrphanOptions{Now: now})
+	assert.Equal(t, []string{"alone.md", "daily/2024-01-01.md", "fresh.md", "hub.md"}, orphanPaths(orphans))
+	assert.Equal(t, IsolatedKind, orphans[0].Kind)
+	assert.Equal(t, OrphanKind, orphans[3].Kind, "hub links out, but nothing links to it")
+	assert.Equal(t, 1, orphans[3].Outbound, "broken links don't count")
+	assert.Equal(t, 100, orphans[0].AgeDays)
+	assert.Equal(t, 3, orphans[0].Words)
+
+	orphans = NewAnalyzer().FindOrphans(files, OrphanOptions{
+		Now:            now,
+		DeadEnds:       true,
+		MinAge:         30 * day,
+		ExcludeFolders: []string{"daily/"},
+	})
+	assert.Equal(t, []string{"alone.md", "hub.md", "leaf.md"}, orphanPaths(orphans))
+	assert.Equal(t, DeadEndKind, orphans[2].Kind)
+}
+
+func orphanPaths(orphans []OrphanNote) []string {
+	var paths []string
+	for _, orphan := range orphans {
+		paths = append(paths, orphan.Path)
+	}
+	return paths
+}
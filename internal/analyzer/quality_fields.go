@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/i18n"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// FieldQualityScorer scores notes on having frontmatter fields, such as a
+// source for literature notes: 1 when any of its fields has a value, else 0
+type FieldQualityScorer struct {
+	name   string
+	fields []string
+}
+
+// NewFieldQualityScorer creates a scorer for the criterion name that looks
+// for any of fields
+func NewFieldQualityScorer(name string, fields []string) *FieldQualityScorer {
+	return &FieldQualityScorer{name: name, fields: fields}
+}
+
+// Name returns the criterion name the scorer was configured with
+func (s *FieldQualityScorer) Name() string {
+	return s.name
+}
+
+// Score checks the note's frontmatter for the fields
+func (s *FieldQualityScorer) Score(_ context.Context, file *vault.VaultFile) (PluginScore, error) {
+	for _, field := range s.fields {
+		if hasValue(file.Frontmatter[field]) {
+			return PluginScore{Score: 1}, nil
+		}
+	}
+	return PluginScore{
+		Score:       0,
+		Suggestions: []string{i18n.T("Add %s to frontmatter", strings.Join(s.fields, " or "))},
+	}, nil
+}
+
+// hasValue reports whether a frontmatter value is set to something
+func hasValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(v) != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,292 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// QualityCriteria lists the built-in content quality criteria
+var QualityCriteria = []string{"readability", "link_density", "completeness", "atomicity", "recency"}
+
+// QualityBands lists the bands notes are sorted into by score, best first.
+// A note is in the first band whose threshold its 0-100 score reaches.
+var QualityBands = []string{"excellent", "good", "fair", "poor", "critical"}
+
+// DefaultQualityThresholds are the lowest 0-100 scores of each band but the
+// last, which takes everything below poor
+var DefaultQualityThresholds = map[string]float64{"excellent": 90, "good": 75, "fair": 60, "poor": 40}
+
+// DefaultQualityPluginTimeout bounds how long a quality plugin may take per note
+const DefaultQualityPluginTimeout = 10 * time.Second
+
+// QualityScorer scores notes on a custom quality criterion
+type QualityScorer interface {
+	Name() string
+	Score(ctx context.Context, file *vault.VaultFile) (PluginScore, error)
+}
+
+// PluginScore is a quality plugin's verdict on one note
+type PluginScore struct {
+	Score       float64  `json:"score"` // 0.0-1.0
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// qualityPluginInput is the JSON an ExecQualityScorer writes to the command
+type qualityPluginInput struct {
+	Path         string                 `json:"path"`
+	AbsolutePath string                 `json:"absolute_path"`
+	Frontmatter  map[string]interface{} `json:"frontmatter"`
+	Body         string                 `json:"body"`
+	Modified     time.Time              `json:"modified"`
+}
+
+// ExecQualityScorer scores notes by running a command once per note. The
+// command reads the note as JSON on stdin and writes a PluginScore as JSON to
+// stdout; the note's path is also in the MDNOTES_FILE environment variable.
+type ExecQualityScorer struct {
+	name    string
+	Command string
+	Args    []string
+	Dir     string // Working directory, usually the vault root
+	Timeout time.Duration
+}
+
+// NewExecQualityScorer creates a scorer for command, resolving relative
+// command paths against dir and checking the command exists
+func NewExecQualityScorer(name, command string, args []string, dir string, timeout time.Duration) (*ExecQualityScorer, error) {
+	if strings.ContainsRune(command, filepath.Separator) && !filepath.IsAbs(command) {
+		command = filepath.Join(dir, command)
+	}
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf("quality plugin '%s': %w", name, err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultQualityPluginTimeout
+	}
+	return &ExecQualityScorer{name: name, Command: path, Args: args, Dir: dir, Timeout: timeout}, nil
+}
+
+// Name returns the criterion name the scorer was configured with
+func (s *ExecQualityScorer) Name() string {
+	return s.name
+}
+
+// Score runs the command for one note
+func (s *ExecQualityScorer) Score(ctx context.Context, file *vault.VaultFile) (PluginScore, error) {
+	absPath, err := filepath.Abs(file.Path)
+	if err != nil {
+		absPath = file.Path
+	}
+	input, err := json.Marshal(qualityPluginInput{
+		Path:         file.RelativePath,
+		AbsolutePath: absPath,
+		Frontmatter:  file.Frontmatter,
+		Body:         file.Body,
+		Modified:     file.Modified,
+	})
+	if err != nil {
+		return PluginScore{}, fmt.Errorf("encoding note: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.Command, s.Args...)
+	cmd.Dir = s.Dir
+	cmd.Env = append(os.Environ(), "MDNOTES_FILE="+absPath)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = time.Second // Don't wait on children still holding stdout
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return PluginScore{}, fmt.Errorf("timed out after %s", s.Timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return PluginScore{}, fmt.Errorf("%w: %s", err, msg)
+		}
+		return PluginScore{}, err
+	}
+
+	var result PluginScore
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return PluginScore{}, fmt.Errorf("invalid output, expected {\"score\": 0-1}: %w", err)
+	}
+	if result.Score < 0 || result.Score > 1 {
+		return PluginScore{}, fmt.Errorf("score %g is outside 0-1", result.Score)
+	}
+	return result, nil
+}
+
+type weightedScorer struct {
+	scorer QualityScorer
+	weight float64
+}
+
+// SetQualityWeights sets the weights of the built-in criteria by name. Criteria
+// without a weight weigh 1.
+func (a *Analyzer) SetQualityWeights(weights map[string]float64) {
+	a.qualityWeights = weights
+}
+
+// AddQualityScorer adds a custom quality criterion, merged into each file's
+// score with the given weight
+func (a *Analyzer) AddQualityScorer(scorer QualityScorer, weight float64) {
+	a.qualityScorers = append(a.qualityScorers, weightedScorer{scorer: scorer, weight: weight})
+}
+
+// SetQualityThresholds overrides the lowest scores, from 0 to 100, of the
+// excellent, good, fair and poor bands. Bands without a threshold keep their
+// default, and thresholds must fall from excellent to poor.
+func (a *Analyzer) SetQualityThresholds(thresholds map[string]float64) error {
+	merged := make(map[string]float64, len(DefaultQualityThresholds))
+	for band, threshold := range DefaultQualityThresholds {
+		merged[band] = threshold
+	}
+	for band, threshold := range thresholds {
+		if _, ok := DefaultQualityThresholds[band]; !ok {
+			return fmt.Errorf("unknown quality band '%s' (valid: excellent, good, fair, poor)", band)
+		}
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("threshold for '%s' must be between 0 and 100", band)
+		}
+		merged[band] = threshold
+	}
+	bands := QualityBands[:len(QualityBands)-1]
+	for i := 1; i < len(bands); i++ {
+		if merged[bands[i]] >= merged[bands[i-1]] {
+			return fmt.Errorf("threshold for '%s' must be below the one for '%s'", bands[i], bands[i-1])
+		}
+	}
+	a.qualityThresholds = merged
+	return nil
+}
+
+// QualityThresholds returns the lowest 0-100 score of each band but critical
+func (a *Analyzer) QualityThresholds() map[string]float64 {
+	if a.qualityThresholds == nil {
+		return DefaultQualityThresholds
+	}
+	return a.qualityThresholds
+}
+
+// qualityBand returns the band of a 0-100 score
+func (a *Analyzer) qualityBand(score float64) string {
+	thresholds := a.QualityThresholds()
+	for _, band := range QualityBands[:len(QualityBands)-1] {
+		if score >= thresholds[band] {
+			return band
+		}
+	}
+	return QualityBands[len(QualityBands)-1]
+}
+
+func (a *Analyzer) criterionWeight(criterion string) float64 {
+	if weight, ok := a.qualityWeights[criterion]; ok {
+		return weight
+	}
+	return 1
+}
+
+// weightedQualityScore combines the built-in criteria, in QualityCriteria
+// order, with plugin scores by weight
+func (a *Analyzer) weightedQualityScore(builtin []float64, plugins map[string]float64) float64 {
+	var total, weights float64
+	for i, score := range builtin {
+		weight := a.criterionWeight(QualityCriteria[i])
+		total += score * weight
+		weights += weight
+	}
+	for _, ws := range a.qualityScorers {
+		if score, ok := plugins[ws.scorer.Name()]; ok {
+			total += score * ws.weight
+			weights += ws.weight
+		}
+	}
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// pluginResults holds every plugin's score for every file by relative path
+type pluginResults struct {
+	scores map[string]map[string]PluginScore
+	errors map[string][]error // By plugin name
+}
+
+// runQualityScorers scores all files with every plugin, in parallel
+func (a *Analyzer) runQualityScorers(files []*vault.VaultFile) pluginResults {
+	results := pluginResults{
+		scores: make(map[string]map[string]PluginScore),
+		errors: make(map[string][]error),
+	}
+	if len(a.qualityScorers) == 0 {
+		return results
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for _, file := range files {
+		for _, ws := range a.qualityScorers {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(file *vault.VaultFile, scorer QualityScorer) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				score, err := scorer.Score(context.Background(), file)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					results.errors[scorer.Name()] = append(results.errors[scorer.Name()], fmt.Errorf("%s: %w", file.RelativePath, err))
+					return
+				}
+				if results.scores[file.RelativePath] == nil {
+					results.scores[file.RelativePath] = make(map[string]PluginScore)
+				}
+				results.scores[file.RelativePath][scorer.Name()] = score
+			}(file, ws.scorer)
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// issues describes plugin failures for the quality report
+func (r pluginResults) issues() []string {
+	names := make([]string, 0, len(r.errors))
+	for name := range r.errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []string
+	for _, name := range names {
+		errs := r.errors[name]
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		noun := "files"
+		if len(errs) == 1 {
+			noun = "file"
+		}
+		issues = append(issues, fmt.Sprintf("Quality plugin '%s' failed on %d %s, which were scored without it (first error: %v)", name, len(errs), noun, errs[0]))
+	}
+	return issues
+}
@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// fixedScorer gives every note the same score, or fails on the listed paths
+type fixedScorer struct {
+	name   string
+	score  PluginScore
+	failOn map[string]bool
+}
+
+func (s fixedScorer) Name() string { return s.name }
+
+func (s fixedScorer) Score(_ context.Context, file *vault.VaultFile) (PluginScore, error) {
+	if s.failOn[file.RelativePath] {
+		return PluginScore{}, errors.New("boom")
+	}
+	return s.score, nil
+}
+
+func TestAnalyzer_WeightedQualityScore(t *testing.T) {
+	ana := NewAnalyzer()
+	builtin := []float64{1, 1, 0, 0, 0}
+
+	assert.InDelta(t, 0.4, ana.weightedQualityScore(builtin, nil), 0.0001)
+
+	ana.SetQualityWeights(map[string]float64{"readability": 3, "recency": 0})
+	assert.InDelta(t, 4.0/6.0, ana.weightedQualityScore(builtin, nil), 0.0001)
+
+	ana.AddQualityScorer(fixedScorer{name: "spelling"}, 2)
+	assert.InDelta(t, 6.0/8.0, ana.weightedQualityScore(builtin, map[string]float64{"spelling": 1}), 0.0001)
+
+	// A plugin without a score for the file doesn't count
+	assert.InDelta(t, 4.0/6.0, ana.weightedQualityScore(builtin, nil), 0.0001)
+}
+
+func TestAnalyzer_ContentQualityWithPlugins(t *testing.T) {
+	files := cacheTestFiles("# One\n\nSome text.", "# Two\n\nMore text.")
+	for _, file := range files {
+		file.Modified = time.Now()
+	}
+
+	baseline := NewAnalyzer().AnalyzeContentQuality(files)
+
+	ana := NewAnalyzer()
+	ana.AddQualityScorer(fixedScorer{
+		name:   "spelling",
+		score:  PluginScore{Score: 1, Suggestions: []string{"Fix 2 typos"}},
+		failOn: map[string]bool{"b.md": true},
+	}, 1)
+	analysis := ana.AnalyzeContentQuality(files)
+
+	scores := make(map[string]FileQualityScore)
+	for _, score := range analysis.FileScores {
+		scores[score.Path] = score
+	}
+	before := make(map[string]FileQualityScore)
+	for _, score := range baseline.FileScores {
+		before[score.Path] = score
+	}
+
+	assert.Equal(t, map[string]float64{"spelling": 1}, scores["a.md"].PluginScores)
+	assert.Contains(t, scores["a.md"].SuggestedFixes, "Fix 2 typos")
+	assert.Greater(t, scores["a.md"].Score, before["a.md"].Score)
+
+	// The failed file is scored on the built-in criteria only
+	assert.Nil(t, scores["b.md"].PluginScores)
+	assert.InDelta(t, before["b.md"].Score, scores["b.md"].Score, 0.0001)
+	assert.Contains(t, analysis.QualityIssues, "Quality plugin 'spelling' failed on 1 file, which were scored without it (first error: b.md: boom)")
+}
+
+func TestAnalyzer_QualityThresholds(t *testing.T) {
+	ana := NewAnalyzer()
+	assert.Equal(t, "excellent", ana.qualityBand(90))
+	assert.Equal(t, "good", ana.qualityBand(89.9))
+	assert.Equal(t, "critical", ana.qualityBand(39))
+
+	require.NoError(t, ana.SetQualityThresholds(map[string]float64{"excellent": 80, "poor": 20}))
+	assert.Equal(t, "excellent", ana.qualityBand(85))
+	assert.Equal(t, "good", ana.qualityBand(79))
+	assert.Equal(t, "poor", ana.qualityBand(30))
+	assert.Equal(t, map[string]float64{"excellent": 80, "good": 75, "fair": 60, "poor": 20}, ana.QualityThresholds())
+
+	assert.ErrorContains(t, ana.SetQualityThresholds(map[string]float64{"good": 95}), "must be below")
+	assert.ErrorContains(t, ana.SetQualityThresholds(map[string]float64{"great": 95}), "unknown quality band")
+	assert.ErrorContains(t, ana.SetQualityThresholds(map[string]float64{"poor": -1}), "between 0 and 100")
+}
+
+func TestAnalyzer_ContentQualityProfile(t *testing.T) {
+	files := cacheTestFiles("# One\n\nSome text.", "# Two\n\nMore text.")
+	files[0].Frontmatter = map[string]interface{}{"source": "https://example.com"}
+	files[1].Frontmatter = map[string]interface{}{"source": ""}
+
+	// An archive vault: recency is disabled, and notes should cite a source
+	ana := NewAnalyzer()
+	ana.SetQualityWeights(map[string]float64{"recency": 0})
+	ana.AddQualityScorer(NewFieldQualityScorer("has_source", []string{"source", "url"}), 2)
+	analysis := ana.AnalyzeContentQuality(files)
+
+	scores := make(map[string]FileQualityScore)
+	for _, score := range analysis.FileScores {
+		scores[score.Path] = score
+	}
+	assert.Equal(t, map[string]float64{"has_source": 1}, scores["a.md"].PluginScores)
+	assert.Equal(t, map[string]float64{"has_source": 0}, scores["b.md"].PluginScores)
+	assert.Contains(t, scores["b.md"].SuggestedFixes, "Add source or url to frontmatter")
+	assert.NotContains(t, scores["b.md"].SuggestedFixes, "Review and update this note - it hasn't been modified recently",
+		"disabled criteria get no suggestions")
+	assert.Greater(t, scores["a.md"].Score, scores["b.md"].Score)
+	assert.Equal(t, DefaultQualityThresholds, analysis.ScoreThresholds)
+}
+
+func TestExecQualityScorer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+
+	dir := t.TempDir()
+	write := func(name, script string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+	}
+	write("good.sh", `grep -q '"body":"short"' && echo '{"score": 0.25, "suggestions": ["Expand"]}' || echo '{"score": 1}'`)
+	write("bad.sh", `echo 'not json'`)
+	write("range.sh", `echo '{"score": 2}'`)
+	write("fail.sh", `echo 'no dictionary' >&2; exit 3`)
+	write("slow.sh", `sleep 5`)
+
+	file := &vault.VaultFile{Path: filepath.Join(dir, "note.md"), RelativePath: "note.md", Body: "short"}
+
+	scorer, err := NewExecQualityScorer("length", "./good.sh", nil, dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "length", scorer.Name())
+	assert.Equal(t, DefaultQualityPluginTimeout, scorer.Timeout)
+
+	result, err := scorer.Score(context.Background(), file)
+	require.NoError(t, err)
+	assert.Equal(t, PluginScore{Score: 0.25, Suggestions: []string{"Expand"}}, result)
+
+	tests := []struct {
+		script   string
+		timeout  time.Duration
+		errorMsg string
+	}{
+		{"bad.sh", 0, "invalid output"},
+		{"range.sh", 0, "outside 0-1"},
+		{"fail.sh", 0, "no dictionary"},
+		{"slow.sh", 100 * time.Millisecond, "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			scorer, err := NewExecQualityScorer("test", "./"+tt.script, nil, dir, tt.timeout)
+			require.NoError(t, err)
+			_, err = scorer.Score(context.Background(), file)
+			assert.ErrorContains(t, err, tt.errorMsg)
+		})
+	}
+
+	_, err = NewExecQualityScorer("missing", "./missing.sh", nil, dir, 0)
+	assert.ErrorContains(t, err, "quality plugin 'missing'")
+}
@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// AutoLanguage detects each note's language from its text
+const AutoLanguage = "auto"
+
+// DefaultLanguage is assumed when a note's language can't be told
+const DefaultLanguage = "en"
+
+// LanguageFields are the frontmatter fields that set a note's language
+var LanguageFields = []string{"lang", "language"}
+
+// readabilityFormula is a Flesch-style reading ease formula,
+// base - perSentence×ASL - perSyllable×ASW, where ASL is words per sentence
+// and ASW syllables per word. The result is on Flesch's 0-100 scale.
+type readabilityFormula struct {
+	base, perSentence, perSyllable float64
+}
+
+// readabilityFormulas are adaptations of Flesch reading ease to languages
+// whose words run to more syllables than English ones
+var readabilityFormulas = map[string]readabilityFormula{
+	"en": {206.835, 1.015, 84.6}, // Flesch
+	"de": {180, 1, 58.5},         // Amstad
+	"fr": {207, 1.015, 73.6},     // Kandel & Moles
+	"es": {206.84, 1.02, 60},     // Fernández Huerta
+	"it": {206, 1, 65},           // Franchina & Vacca
+	"nl": {206.835, 0.93, 77},    // Douma
+}
+
+// stopwords are frequent short words that tell languages apart
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "with", "for", "this", "was", "are", "not", "you", "but"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "sich", "auf", "ich", "auch", "wir"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "un", "du", "que", "pas", "pour", "dans", "qui", "sur", "nous"},
+	"es": {"el", "los", "las", "y", "es", "del", "una", "que", "por", "para", "con", "no", "se", "como", "pero", "muy"},
+	"it": {"il", "lo", "gli", "e", "di", "che", "non", "una", "per", "sono", "con", "della", "è", "anche", "come", "ma"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "dat", "op", "te", "zijn", "met", "voor", "ook", "maar", "wij"},
+	"sv": {"och", "att", "det", "som", "en", "är", "på", "för", "med", "inte", "den", "till", "jag", "har", "av", "om"},
+}
+
+// languageNames maps names people write in lang fields to language codes
+var languageNames = map[string]string{
+	"english": "en", "german": "de", "deutsch": "de", "french": "fr", "français": "fr",
+	"francais": "fr", "spanish": "es", "español": "es", "espanol": "es", "italian": "it",
+	"italiano": "it", "dutch": "nl", "nederlands": "nl", "swedish": "sv", "svenska": "sv",
+	"danish": "da", "dansk": "da", "norwegian": "no", "norsk": "no", "finnish": "fi", "suomi": "fi",
+}
+
+// SetReadabilityLanguage sets the language of notes without a lang field:
+// a code such as "de", or AutoLanguage (the default) to detect it
+func (a *Analyzer) SetReadabilityLanguage(language string) {
+	a.readabilityLanguage = NormalizeLanguage(language)
+}
+
+// NormalizeLanguage turns a language code, tag or name, such as "de-AT" or
+// "German", into a two-letter code; "auto" and "" stay as they are
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageNames[language]; ok {
+		return code
+	}
+	if i := strings.IndexAny(language, "-_"); i > 0 {
+		language = language[:i]
+	}
+	return language
+}
+
+// DetectLanguage guesses the language of text from its most common short
+// words, returning "" when there are too few of them to tell
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		counts[word]++
+	}
+
+	best, bestHits, total := "", 0, 0
+	for _, lang := range []string{"en", "de", "fr", "es", "it", "nl", "sv"} {
+		hits := 0
+		for _, word := range stopwords[lang] {
+			hits += counts[word]
+		}
+		total += hits
+		if hits > bestHits {
+			best, bestHits = lang, hits
+		}
+	}
+	// Too little evidence, or no clear winner
+	if bestHits < 3 || float64(bestHits) < 0.4*float64(total) {
+		return ""
+	}
+	return best
+}
+
+// noteLanguage returns the language a note's readability is scored in: its
+// lang field, else the configured language, else a detected one
+func (a *Analyzer) noteLanguage(file *vault.VaultFile, text string) string {
+	for _, field := range LanguageFields {
+		if lang, ok := file.Frontmatter[field].(string); ok && strings.TrimSpace(lang) != "" {
+			return NormalizeLanguage(lang)
+		}
+	}
+	if a.readabilityLanguage != "" && a.readabilityLanguage != AutoLanguage {
+		return a.readabilityLanguage
+	}
+	if lang := DetectLanguage(text); lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// readingEase scores text on Flesch's 0-100 scale using the formula for
+// language. Languages without a Flesch adaptation are scored with LIX, which
+// needs no syllable counts and works across European languages.
+func (a *Analyzer) readingEase(text, language string) float64 {
+	sentences := a.countSentences(text)
+	words := strings.Fields(text)
+	if sentences == 0 || len(words) == 0 {
+		return 0
+	}
+	asl := float64(len(words)) / float64(sentences)
+
+	formula, ok := readabilityFormulas[language]
+	if !ok {
+		return lixEase(words, asl)
+	}
+
+	var syllables int
+	if language == "en" {
+		syllables = a.countSyllables(text)
+	} else {
+		for _, word := range words {
+			syllables += countVowelGroups(strings.ToLower(word), language == "fr")
+		}
+	}
+	asw := float64(syllables) / float64(len(words))
+	return formula.base - formula.perSentence*asl - formula.perSyllable*asw
+}
+
+// lixEase maps Björnsson's LIX, words per sentence plus the percentage of
+// words over six letters, onto reading ease: LIX 20 (very easy) is 100 and
+// LIX 60 (very hard) is 0
+func lixEase(words []string, asl float64) float64 {
+	long := 0
+	for _, word := range words {
+		letters := 0
+		for _, r := range word {
+			if unicode.IsLetter(r) {
+				letters++
+			}
+		}
+		if letters > 6 {
+			long++
+		}
+	}
+	lix := asl + 100*float64(long)/float64(len(words))
+	return math.Max(0, 100-(lix-20)*2.5)
+}
+
+// countVowelGroups estimates a word's syllables as its groups of vowels,
+// accented ones included. With silentE a final e, as in French, isn't one.
+func countVowelGroups(word string, silentE bool) int {
+	groups := 0
+	inVowel := false
+	var last rune
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		vowel := strings.ContainsRune("aeiouyàáâãäåæèéêëìíîïòóôõöøœùúûüý", r)
+		if vowel && !inVowel {
+			groups++
+		}
+		inVowel = vowel
+		last = r
+	}
+	if silentE && last == 'e' && groups > 1 && !strings.HasSuffix(word, "ee") {
+		groups--
+	}
+	if groups == 0 {
+		return 1
+	}
+	return groups
+}
@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+const (
+	englishText = "The cat sat on the mat. It was warm and the sun was out. This is a good day for the cat."
+	germanText  = "Die Katze sitzt auf der Matte. Es ist warm und die Sonne scheint. Das ist ein guter Tag für die Katze, und sie schläft nicht."
+	frenchText  = "Le chat est sur le tapis. Il fait chaud et le soleil brille. C'est une belle journée pour le chat, qui ne dort pas."
+)
+
+func TestDetectLanguage(t *testing.T) {
+	assert.Equal(t, "en", DetectLanguage(englishText))
+	assert.Equal(t, "de", DetectLanguage(germanText))
+	assert.Equal(t, "fr", DetectLanguage(frenchText))
+	assert.Equal(t, "", DetectLanguage("Kubernetes cluster upgrade"), "too few common words to tell")
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	assert.Equal(t, "de", NormalizeLanguage("de-AT"))
+	assert.Equal(t, "de", NormalizeLanguage("German"))
+	assert.Equal(t, "fr", NormalizeLanguage(" Français "))
+	assert.Equal(t, "pt", NormalizeLanguage("pt_BR"))
+	assert.Equal(t, AutoLanguage, NormalizeLanguage("auto"))
+}
+
+func TestReadability_LanguageSpecific(t *testing.T) {
+	note := func(body string, frontmatter map[string]interface{}) *vault.VaultFile {
+		return &vault.VaultFile{RelativePath: "note.md", Body: body, Frontmatter: frontmatter}
+	}
+	ana := NewAnalyzer()
+
+	formal := "Die Bundesregierung beschließt umfangreiche Maßnahmen zur Förderung der erneuerbaren Energien. " +
+		"Die Umsetzung erfordert eine enge Zusammenarbeit mit den Ländern und nicht nur mit der Industrie."
+	score, lang := ana.readability(note(formal, nil))
+	assert.Equal(t, "de", lang)
+	// Amstad's formula expects German's longer words, so the same note scores
+	// higher than under English Flesch
+	english := ana.readingEase(ana.extractReadableText(formal), "en") / 100
+	assert.Greater(t, score, english)
+
+	_, lang = ana.readability(note(englishText, map[string]interface{}{"lang": "fr-CA"}))
+	assert.Equal(t, "fr", lang, "a lang field overrides detection")
+
+	ana.SetReadabilityLanguage("German")
+	_, lang = ana.readability(note("Kubernetes cluster upgrade.", nil))
+	assert.Equal(t, "de", lang, "the configured language replaces detection")
+
+	// Languages without a Flesch adaptation are scored with LIX
+	swedish := note("Katten sitter på mattan. Det är varmt och solen skiner.", map[string]interface{}{"lang": "sv"})
+	score, lang = ana.readability(swedish)
+	assert.Equal(t, "sv", lang)
+	assert.Greater(t, score, 0.5)
+	long := note(strings.Repeat("Informationsteknologiska utvecklingsprojekt karakteriseras ", 10)+".", map[string]interface{}{"lang": "sv"})
+	score, _ = ana.readability(long)
+	assert.Equal(t, 0.0, score)
+}
+
+func TestCountVowelGroups(t *testing.T) {
+	assert.Equal(t, 2, countVowelGroups("schönheit", false))
+	assert.Equal(t, 3, countVowelGroups("éléphant", false))
+	assert.Equal(t, 1, countVowelGroups("belle", true), "French final e is silent")
+	assert.Equal(t, 1, countVowelGroups("brr", false))
+}
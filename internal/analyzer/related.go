@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Weights of the signals behind related-note scores
+const (
+	relatedTagWeight      = 0.3
+	relatedCitationWeight = 0.3
+	relatedTextWeight     = 0.4
+)
+
+// RelatedNote is a note suggested as a link from another, with the evidence
+// behind the suggestion
+type RelatedNote struct {
+	Path       string   `json:"path"`
+	Score      float64  `json:"score"`
+	SharedTags []string `json:"shared_tags,omitempty"`
+	CoCited    int      `json:"co_cited,omitempty"`   // notes linking to both
+	Coupled    int      `json:"coupled,omitempty"`    // notes both link to
+	Similarity float64  `json:"similarity,omitempty"` // TF-IDF cosine of their bodies
+}
+
+// RelatedFinder ranks notes that a note doesn't link to yet by how related
+// they are: shared tags, co-citation in the link graph (notes linking to both,
+// or both linking to the same notes) and text similarity
+type RelatedFinder struct {
+	files   []*vault.VaultFile
+	graph   map[string][]string
+	inbound map[string][]string
+	tags    map[string]map[string]bool
+	vectors map[string]map[string]float64
+}
+
+// NewRelatedFinder prepares files, which should be the whole vault with links
+// parsed, for Related lookups
+func (a *Analyzer) NewRelatedFinder(files []*vault.VaultFile) *RelatedFinder {
+	idx := &RelatedFinder{
+		files:   files,
+		graph:   noteGraph(files),
+		inbound: make(map[string][]string),
+		tags:    make(map[string]map[string]bool, len(files)),
+	}
+	for source, targets := range idx.graph {
+		for _, target := range targets {
+			idx.inbound[target] = append(idx.inbound[target], source)
+		}
+	}
+
+	docFreq := make(map[string]int)
+	counts := make(map[string]map[string]int, len(files))
+	for _, file := range files {
+		key := filepath.ToSlash(file.RelativePath)
+		idx.tags[key] = make(map[string]bool)
+		for _, tag := range a.extractTags(file.Frontmatter["tags"]) {
+			if tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#")); tag != "" {
+				idx.tags[key][tag] = true
+			}
+		}
+		counts[key] = termCounts(file.Body)
+		for term := range counts[key] {
+			docFreq[term]++
+		}
+	}
+
+	// TF-IDF vectors, normalized so their dot product is the cosine
+	idx.vectors = make(map[string]map[string]float64, len(files))
+	for key, terms := range counts {
+		vec := make(map[string]float64, len(terms))
+		var norm float64
+		for term, n := range terms {
+			if docFreq[term] == len(files) {
+				continue // in every note, so says nothing
+			}
+			w := (1 + math.Log(float64(n))) * math.Log(float64(len(files))/float64(docFreq[term]))
+			vec[term] = w
+			norm += w * w
+		}
+		norm = math.Sqrt(norm)
+		for term := range vec {
+			vec[term] /= norm
+		}
+		idx.vectors[key] = vec
+	}
+	return idx
+}
+
+// Related returns at most limit notes related to source and scoring at least
+// minScore, best first
+func (idx *RelatedFinder) Related(source *vault.VaultFile, limit int, minScore float64) []RelatedNote {
+	key := filepath.ToSlash(source.RelativePath)
+	linked := make(map[string]bool)
+	for _, target := range idx.graph[key] {
+		linked[target] = true
+	}
+
+	var results []RelatedNote
+	for _, file := range idx.files {
+		other := filepath.ToSlash(file.RelativePath)
+		if other == key || linked[other] {
+			continue
+		}
+
+		note := RelatedNote{Path: other}
+		for tag := range idx.tags[key] {
+			if idx.tags[other][tag] {
+				note.SharedTags = append(note.SharedTags, tag)
+			}
+		}
+		sort.Strings(note.SharedTags)
+		note.CoCited = overlap(idx.inbound[key], idx.inbound[other])
+		note.Coupled = overlap(idx.graph[key], idx.graph[other])
+		note.Similarity = dot(idx.vectors[key], idx.vectors[other])
+
+		tagScore := 0.0
+		if len(note.SharedTags) > 0 {
+			tagScore = jaccard(idx.tags[key], idx.tags[other])
+		}
+		// Salton's cosine over both kinds of shared neighbour
+		citationScore := 0.0
+		if degrees := float64(len(idx.inbound[key])+len(idx.graph[key])) * float64(len(idx.inbound[other])+len(idx.graph[other])); degrees > 0 {
+			citationScore = math.Min(1, float64(note.CoCited+note.Coupled)/math.Sqrt(degrees))
+		}
+		note.Score = relatedTagWeight*tagScore + relatedCitationWeight*citationScore + relatedTextWeight*note.Similarity
+		note.Score = math.Round(note.Score*1000) / 1000
+		note.Similarity = math.Round(note.Similarity*1000) / 1000
+
+		if note.Score > 0 && note.Score >= minScore {
+			results = append(results, note)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// termCounts counts the words of three or more letters in text, skipping
+// link targets and URLs so shared links aren't mistaken for shared prose
+func termCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		if strings.Contains(field, "://") {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(field, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			if len([]rune(word)) >= 3 {
+				counts[word]++
+			}
+		}
+	}
+	return counts
+}
+
+func overlap(a, b []string) int {
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	n := 0
+	for _, s := range b {
+		if set[s] {
+			n++
+		}
+	}
+	return n
+}
+
+func dot(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var sum float64
+	for term, w := range a {
+		sum += w * b[term]
+	}
+	return sum
+}
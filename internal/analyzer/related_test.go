@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestRelatedFinder(t *testing.T) {
+	note := func(path string, tags []interface{}, body string, links ...string) *vault.VaultFile {
+		file := &vault.VaultFile{RelativePath: path, Frontmatter: map[string]interface{}{}, Body: body, Links: wikiLinks(links...)}
+		if tags != nil {
+			file.Frontmatter["tags"] = tags
+		}
+		return file
+	}
+	files := []*vault.VaultFile{
+		note("garden.md", []interface{}{"garden", "food"}, "Tomatoes and compost in raised beds.", "seeds"),
+		note("compost.md", []interface{}{"#garden"}, "Compost heaps feed raised beds.", "seeds"),
+		note("seeds.md", nil, "Saving seeds between seasons."),
+		note("hub.md", nil, "Everything outdoors", "garden", "compost"),
+		note("k8s.md", []interface{}{"work"}, "Kubernetes cluster upgrade."),
+	}
+
+	finder := NewAnalyzer().NewRelatedFinder(files)
+	related := finder.Related(files[0], 0, 0.1)
+	require.NotEmpty(t, related)
+	assert.Equal(t, "compost.md", related[0].Path)
+	assert.Equal(t, []string{"garden"}, related[0].SharedTags)
+	assert.Equal(t, 1, related[0].CoCited, "hub links to both")
+	assert.Equal(t, 1, related[0].Coupled, "both link to seeds")
+	assert.Greater(t, related[0].Similarity, 0.0)
+	for _, r := range related {
+		assert.NotEqual(t, "seeds.md", r.Path, "already linked notes aren't suggested")
+		assert.NotEqual(t, "k8s.md", r.Path)
+	}
+
+	assert.Len(t, finder.Related(files[0], 1, 0), 1)
+	assert.Empty(t, finder.Related(files[4], 0, 0.1))
+}
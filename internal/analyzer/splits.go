@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Defaults for split suggestions
+const (
+	DefaultSplitMaxAtomicity = 0.7 // Notes scoring below this are considered
+	DefaultSplitMinWords     = 100 // Sections shorter than this stay in the note
+)
+
+// SplitSection is a section of a note at the level it would be split at
+type SplitSection struct {
+	Heading string `json:"heading"` // With its level, as in "## Topic"
+	Line    int    `json:"line"`
+	Words   int    `json:"words"`
+	Split   bool   `json:"split"` // Long enough to be a note of its own
+}
+
+// SplitSuggestion proposes splitting a long, multi-topic note at its H1 or H2
+// sections
+type SplitSuggestion struct {
+	Path           string         `json:"path"`
+	AtomicityScore float64        `json:"atomicity_score"`
+	Words          int            `json:"words"`
+	Sections       []SplitSection `json:"sections"`
+	Command        string         `json:"command,omitempty"` // mdnotes split command carrying it out
+}
+
+// SplitHeadings returns the headings of the sections to split out
+func (s SplitSuggestion) SplitHeadings() []string {
+	var headings []string
+	for _, section := range s.Sections {
+		if section.Split {
+			headings = append(headings, section.Heading)
+		}
+	}
+	return headings
+}
+
+// SuggestSplits proposes splits for notes whose atomicity score is below
+// maxAtomicity. Notes with several H1s split at H1, others at H2; a note is
+// only suggested when it has at least two such sections and one of them has
+// minWords words. Suggestions are ordered from the least atomic note.
+func (a *Analyzer) SuggestSplits(files []*vault.VaultFile, maxAtomicity float64, minWords int) []SplitSuggestion {
+	var suggestions []SplitSuggestion
+	for _, file := range files {
+		if suggestion, ok := a.suggestSplit(file, maxAtomicity, minWords); ok {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	sortSplitSuggestions(suggestions)
+	return suggestions
+}
+
+// suggestSplit proposes a split for one note, as SuggestSplits does
+func (a *Analyzer) suggestSplit(file *vault.VaultFile, maxAtomicity float64, minWords int) (SplitSuggestion, bool) {
+	score := a.calculateAtomicityScore(file)
+	if score >= maxAtomicity {
+		return SplitSuggestion{}, false
+	}
+
+	headings := vault.ExtractHeadings(file.Body)
+	level := 2
+	h1s := 0
+	for _, h := range headings {
+		if h.Level == 1 {
+			h1s++
+		}
+	}
+	if h1s > 1 {
+		level = 1
+	}
+
+	lines := strings.Split(file.Body, "\n")
+	var sections []SplitSection
+	splits := 0
+	for i, h := range headings {
+		if h.Level != level {
+			continue
+		}
+		end := len(lines)
+		for _, next := range headings[i+1:] {
+			if next.Level <= level {
+				end = next.Line - 1
+				break
+			}
+		}
+		words := len(strings.Fields(strings.Join(lines[h.Line:end], "\n")))
+		section := SplitSection{
+			Heading: strings.Repeat("#", level) + " " + h.Text,
+			Line:    h.Line,
+			Words:   words,
+			Split:   words >= minWords,
+		}
+		if section.Split {
+			splits++
+		}
+		sections = append(sections, section)
+	}
+	if len(sections) < 2 || splits == 0 {
+		return SplitSuggestion{}, false
+	}
+
+	return SplitSuggestion{
+		Path:           file.RelativePath,
+		AtomicityScore: score,
+		Words:          len(strings.Fields(file.Body)),
+		Sections:       sections,
+	}, true
+}
+
+// sortSplitSuggestions orders suggestions from the least atomic note
+func sortSplitSuggestions(suggestions []SplitSuggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].AtomicityScore < suggestions[j].AtomicityScore
+	})
+}
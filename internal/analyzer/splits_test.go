@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func words(n int) string {
+	return strings.TrimSpace(strings.Repeat("word ", n))
+}
+
+func TestAnalyzer_SuggestSplits(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "long.md", Body: "# Long\n\n## One\n\n" + words(400) + "\n\n### Detail\n\n" + words(100) + "\n\n## Two\n\n" + words(300) + "\n\n## Three\n\n" + words(10) + "\n"},
+		{RelativePath: "books.md", Body: "# Fiction\n\n" + words(700) + "\n\n# History\n\n" + words(50) + "\n"},
+		{RelativePath: "atomic.md", Body: "# Atomic\n\n## One\n\n" + words(50) + "\n\n## Two\n\n" + words(50) + "\n"},
+		{RelativePath: "single.md", Body: "# Single\n\n" + words(1200) + "\n"},
+	}
+
+	suggestions := NewAnalyzer().SuggestSplits(files, 0.9, DefaultSplitMinWords)
+	require.Len(t, suggestions, 2)
+	assert.LessOrEqual(t, suggestions[0].AtomicityScore, suggestions[1].AtomicityScore)
+
+	byPath := make(map[string]SplitSuggestion)
+	for _, s := range suggestions {
+		byPath[s.Path] = s
+	}
+
+	// Split at H1 since the note has several
+	books := byPath["books.md"]
+	assert.Equal(t, []SplitSection{
+		{Heading: "# Fiction", Line: 1, Words: 700, Split: true},
+		{Heading: "# History", Line: 5, Words: 50, Split: false},
+	}, books.Sections)
+	assert.Equal(t, []string{"# Fiction"}, books.SplitHeadings())
+
+	long := byPath["long.md"]
+	assert.Equal(t, 820, long.Words)
+	assert.Equal(t, []SplitSection{
+		{Heading: "## One", Line: 3, Words: 502, Split: true},
+		{Heading: "## Two", Line: 11, Words: 300, Split: true},
+		{Heading: "## Three", Line: 15, Words: 10, Split: false},
+	}, long.Sections)
+	assert.Equal(t, []string{"## One", "## Two"}, long.SplitHeadings())
+	assert.Less(t, long.AtomicityScore, 0.9)
+}
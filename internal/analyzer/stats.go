@@ -1,7 +1,7 @@
 package analyzer
 
 import (
-	"crypto/md5"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"sort"
@@ -9,12 +9,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/eoinhurrell/mdnotes/internal/i18n"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
 
 // Analyzer provides vault analysis capabilities
 type Analyzer struct {
-	linkParser LinkParser
+	linkParser          LinkParser
+	cache               *ResultCache
+	qualityWeights      map[string]float64
+	qualityScorers      []weightedScorer
+	qualityThresholds   map[string]float64
+	readabilityLanguage string
+	healthRules         []HealthRule
+	similarity          float64
+	hasher              Hasher
+	hashFull            bool // hash frontmatter along with the body
+	noteDates           *NoteDates
 }
 
 // LinkParser interface for parsing links (to avoid circular imports)
@@ -32,6 +43,24 @@ func (a *Analyzer) SetLinkParser(parser LinkParser) {
 	a.linkParser = parser
 }
 
+// SetCache sets a result cache so unchanged files reuse earlier link parsing,
+// duplicate hashing and content quality results
+func (a *Analyzer) SetCache(cache *ResultCache) {
+	a.cache = cache
+}
+
+// parseLinks populates a file's links using the link parser, if one is set
+func (a *Analyzer) parseLinks(file *vault.VaultFile) {
+	if a.linkParser == nil {
+		return
+	}
+	if a.cache != nil {
+		file.Links = a.cache.links(file, a.linkParser)
+		return
+	}
+	a.linkParser.UpdateFile(file)
+}
+
 // VaultStats represents statistics about a vault
 type VaultStats struct {
 	TotalFiles              int                       `json:"total_files"`
@@ -49,6 +78,8 @@ type VaultStats struct {
 	BrokenLinksCount        int                       `json:"broken_links_count"`
 	LastModified            time.Time                 `json:"last_modified"`
 	OldestFile              time.Time                 `json:"oldest_file"`
+	Top                     TopFiles                  `json:"top,omitempty"`
+	Folders                 []FolderStats             `json:"folders,omitempty"`
 }
 
 // Duplicate represents a set of duplicate values
@@ -108,6 +139,7 @@ type HealthScore struct {
 	Score       float64     `json:"score"`
 	Issues      []string    `json:"issues"`
 	Suggestions []string    `json:"suggestions"`
+	Rules       []RuleScore `json:"rules"`
 }
 
 // HealthLevel represents different health levels
@@ -123,64 +155,11 @@ const (
 
 // GenerateStats generates comprehensive statistics for a vault
 func (a *Analyzer) GenerateStats(files []*vault.VaultFile) VaultStats {
-	stats := VaultStats{
-		TotalFiles:       len(files),
-		TagDistribution:  make(map[string]int),
-		FieldPresence:    make(map[string]int),
-		TypeDistribution: make(map[string]map[string]int),
-	}
-
-	if len(files) == 0 {
-		return stats
-	}
-
-	var totalSize int64
-	var lastModified, oldestFile time.Time
-	firstFile := true
-
+	acc := a.NewStatsAccumulator()
 	for _, file := range files {
-		// File size and dates
-		fileSize := int64(len(file.Content))
-		totalSize += fileSize
-
-		if firstFile || file.Modified.After(lastModified) {
-			lastModified = file.Modified
-		}
-		if firstFile || file.Modified.Before(oldestFile) {
-			oldestFile = file.Modified
-		}
-		firstFile = false
-
-		// Frontmatter analysis
-		if len(file.Frontmatter) > 0 {
-			stats.FilesWithFrontmatter++
-			a.analyzeFrontmatter(file.Frontmatter, &stats)
-		} else {
-			stats.FilesWithoutFrontmatter++
-		}
-
-		// Parse links if parser is available
-		if a.linkParser != nil {
-			a.linkParser.UpdateFile(file)
-		}
-
-		// Count links and headings
-		stats.TotalLinks += len(file.Links)
-		stats.TotalHeadings += len(file.Headings)
+		acc.Add(file)
 	}
-
-	stats.TotalSize = totalSize
-	stats.AverageFileSize = float64(totalSize) / float64(len(files))
-	stats.LastModified = lastModified
-	stats.OldestFile = oldestFile
-
-	// Find orphaned files
-	orphaned := a.FindOrphanedFiles(files)
-	for _, file := range orphaned {
-		stats.OrphanedFiles = append(stats.OrphanedFiles, file.Path)
-	}
-
-	return stats
+	return acc.Stats()
 }
 
 // analyzeFrontmatter analyzes frontmatter fields
@@ -322,24 +301,48 @@ func (a *Analyzer) FindContentDuplicates(files []*vault.VaultFile, matchType Dup
 	}
 }
 
-// findExactContentDuplicates finds files with identical content
+// findExactContentDuplicates finds files with identical content, comparing
+// normalized bodies, or whole files when frontmatter is included
 func (a *Analyzer) findExactContentDuplicates(files []*vault.VaultFile) []ContentDuplicate {
-	hashMap := make(map[string][]string)
+	hasher := a.hasher
+	if hasher == nil {
+		hasher, _ = NewHasher(DefaultHasher)
+	}
+	kind := hasher.Name()
+	if a.hashFull {
+		kind += "+frontmatter"
+	}
 
+	hashMap := make(map[string][]*vault.VaultFile)
 	for _, file := range files {
-		// Hash the body content (excluding frontmatter)
-		hash := fmt.Sprintf("%x", md5.Sum([]byte(file.Body)))
-		hashMap[hash] = append(hashMap[hash], file.Path)
+		compute := func() string {
+			content := file.Body
+			if a.hashFull && file.Content != nil {
+				content = string(file.Content)
+			}
+			return hasher.Sum([]byte(NormalizeContent(content)))
+		}
+		var hash string
+		if a.cache != nil {
+			hash = a.cache.bodyHash(file, kind, compute)
+		} else {
+			hash = compute()
+		}
+		hashMap[hash] = append(hashMap[hash], file)
 	}
 
 	var duplicates []ContentDuplicate
-	for hash, paths := range hashMap {
-		if len(paths) > 1 {
+	for hash, group := range hashMap {
+		if len(group) > 1 {
+			paths := make([]string, len(group))
+			for i, file := range group {
+				paths[i] = file.Path
+			}
 			duplicates = append(duplicates, ContentDuplicate{
 				Hash:  hash,
 				Files: paths,
-				Count: len(paths),
-				Size:  len(files[0].Body), // Approximate size
+				Count: len(group),
+				Size:  len(group[0].Body),
 			})
 		}
 	}
@@ -352,132 +355,76 @@ func (a *Analyzer) findExactContentDuplicates(files []*vault.VaultFile) []Conten
 	return duplicates
 }
 
-// findSimilarContentDuplicates finds files with similar content (basic implementation)
-func (a *Analyzer) findSimilarContentDuplicates(files []*vault.VaultFile) []ContentDuplicate {
-	// This is a simplified similarity check based on common words
-	// A more sophisticated implementation would use algorithms like Jaccard similarity
-
-	var duplicates []ContentDuplicate
-
-	for i, file1 := range files {
-		var similarFiles []string
-		similarFiles = append(similarFiles, file1.Path)
-
-		for j, file2 := range files {
-			if i >= j {
-				continue
-			}
-
-			similarity := a.calculateSimilarity(file1.Body, file2.Body)
-			if similarity > 0.8 { // 80% similarity threshold
-				similarFiles = append(similarFiles, file2.Path)
-			}
-		}
-
-		if len(similarFiles) > 1 {
-			duplicates = append(duplicates, ContentDuplicate{
-				Hash:  fmt.Sprintf("similar_%d", i),
-				Files: similarFiles,
-				Count: len(similarFiles),
-				Size:  len(file1.Body),
-			})
-		}
-	}
-
-	return duplicates
-}
-
-// calculateSimilarity calculates basic similarity between two texts
-func (a *Analyzer) calculateSimilarity(text1, text2 string) float64 {
-	words1 := strings.Fields(strings.ToLower(text1))
-	words2 := strings.Fields(strings.ToLower(text2))
-
-	if len(words1) == 0 && len(words2) == 0 {
-		return 1.0
-	}
-
-	if len(words1) == 0 || len(words2) == 0 {
-		return 0.0
-	}
-
-	// Simple Jaccard similarity
-	set1 := make(map[string]bool)
-	for _, word := range words1 {
-		set1[word] = true
-	}
-
-	set2 := make(map[string]bool)
-	for _, word := range words2 {
-		set2[word] = true
-	}
-
-	intersection := 0
-	for word := range set1 {
-		if set2[word] {
-			intersection++
-		}
-	}
-
-	union := len(set1) + len(set2) - intersection
-	return float64(intersection) / float64(union)
-}
-
 // AnalyzeField performs detailed analysis of a specific field
 func (a *Analyzer) AnalyzeField(files []*vault.VaultFile, fieldName string) FieldAnalysis {
-	analysis := FieldAnalysis{
-		FieldName:         fieldName,
-		ValueDistribution: make(map[interface{}]int),
-		TypeDistribution:  make(map[string]int),
+	acc := a.NewFieldAccumulator(fieldName)
+	for _, file := range files {
+		acc.Add(file)
 	}
+	return acc.Analyses()[0]
+}
 
-	var examples []interface{}
-	seenValues := make(map[interface{}]bool)
-	filesWithField := 0
-
+// FieldNames returns the sorted set of frontmatter field names used across files
+func (a *Analyzer) FieldNames(files []*vault.VaultFile) []string {
+	seen := make(map[string]bool)
+	var names []string
 	for _, file := range files {
-		if value, exists := file.Frontmatter[fieldName]; exists {
-			filesWithField++
-
-			// Count value occurrences - need to handle unhashable types
-			var valueKey interface{}
-			switch v := value.(type) {
-			case []interface{}:
-				// Convert to string for map key
-				valueKey = fmt.Sprintf("%v", v)
-			default:
-				valueKey = value
+		for field := range file.Frontmatter {
+			if !seen[field] {
+				seen[field] = true
+				names = append(names, field)
 			}
-			analysis.ValueDistribution[valueKey]++
-
-			// Count type occurrences
-			typeName := a.getTypeName(value)
-			analysis.TypeDistribution[typeName]++
-
-			// Collect examples
-			if !seenValues[valueKey] && len(examples) < 5 {
-				examples = append(examples, value)
-				seenValues[valueKey] = true
-			}
-		} else {
-			analysis.MissingCount++
 		}
 	}
+	sort.Strings(names)
+	return names
+}
 
-	analysis.TotalFiles = filesWithField
-
-	analysis.UniqueValues = len(analysis.ValueDistribution)
-	analysis.Examples = examples
+// ValueCount is a single entry in a field's value distribution
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
 
-	// Find predominant type
-	maxCount := 0
-	for typeName, count := range analysis.TypeDistribution {
-		if count > maxCount {
-			maxCount = count
-			analysis.PredominantType = typeName
-		}
+// SortedValues returns the value distribution ordered by count (descending), then value
+func (fa FieldAnalysis) SortedValues() []ValueCount {
+	values := make([]ValueCount, 0, len(fa.ValueDistribution))
+	for value, count := range fa.ValueDistribution {
+		values = append(values, ValueCount{Value: fmt.Sprintf("%v", value), Count: count})
 	}
-
-	return analysis
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
+
+// MarshalJSON encodes the value distribution as an ordered list, since its
+// interface{} keys cannot be represented as a JSON object
+func (fa FieldAnalysis) MarshalJSON() ([]byte, error) {
+	type fieldAnalysisJSON struct {
+		FieldName         string         `json:"field_name"`
+		TotalFiles        int            `json:"total_files"`
+		MissingCount      int            `json:"missing_count"`
+		UniqueValues      int            `json:"unique_values"`
+		ValueDistribution []ValueCount   `json:"value_distribution"`
+		TypeDistribution  map[string]int `json:"type_distribution"`
+		PredominantType   string         `json:"predominant_type"`
+		Examples          []interface{}  `json:"examples"`
+	}
+
+	return json.Marshal(fieldAnalysisJSON{
+		FieldName:         fa.FieldName,
+		TotalFiles:        fa.TotalFiles,
+		MissingCount:      fa.MissingCount,
+		UniqueValues:      fa.UniqueValues,
+		ValueDistribution: fa.SortedValues(),
+		TypeDistribution:  fa.TypeDistribution,
+		PredominantType:   fa.PredominantType,
+		Examples:          fa.Examples,
+	})
 }
 
 // FindOrphanedFiles finds files that are not linked by any other files
@@ -515,6 +462,26 @@ func (a *Analyzer) FindOrphanedFiles(files []*vault.VaultFile) []*vault.VaultFil
 	return orphaned
 }
 
+// attachmentExtRegex matches file extensions of non-note link targets
+var attachmentExtRegex = regexp.MustCompile(`^\.[A-Za-z0-9]{1,5}$`)
+
+// CountBrokenLinks counts links to notes that don't exist among files. Files'
+// links must already be parsed. Like Obsidian, targets match case-insensitively
+// and wiki links also resolve by note name anywhere in the vault; links to
+// attachments and external URLs are not checked.
+func (a *Analyzer) CountBrokenLinks(files []*vault.VaultFile) int {
+	resolver := newLinkResolver(files)
+	broken := 0
+	for _, file := range files {
+		for _, link := range file.Links {
+			if target, checked := resolver.resolve(file, link); checked && target == "" {
+				broken++
+			}
+		}
+	}
+	return broken
+}
+
 // LinkAnalysis represents comprehensive link structure analysis
 type LinkAnalysis struct {
 	TotalFiles             int                 `json:"total_files"`
@@ -530,6 +497,8 @@ type LinkAnalysis struct {
 	LinkDensity            float64             `json:"link_density"`
 	LinkGraph              map[string][]string `json:"link_graph"`
 	CentralFiles           []CentralFile       `json:"central_files"`
+	Cycles                 []LinkCycle         `json:"cycles,omitempty"`
+	Clusters               []LinkCluster       `json:"clusters,omitempty"`
 }
 
 // CentralFile represents a file with its centrality score
@@ -542,6 +511,8 @@ type CentralFile struct {
 type ContentAnalysis struct {
 	OverallScore         float64            `json:"overall_score"`
 	ScoreDistribution    map[string]int     `json:"score_distribution"`
+	ScoreThresholds      map[string]float64 `json:"score_thresholds"`  // Lowest score of each band but critical
+	Profile              string             `json:"profile,omitempty"` // Quality profile the scores used
 	AvgContentLength     float64            `json:"avg_content_length"`
 	AvgWordCount         float64            `json:"avg_word_count"`
 	FilesWithFrontmatter int                `json:"files_with_frontmatter"`
@@ -550,6 +521,7 @@ type ContentAnalysis struct {
 	QualityIssues        []string           `json:"quality_issues"`
 	Suggestions          []string           `json:"suggestions"`
 	FileScores           []FileQualityScore `json:"file_scores"`
+	SplitSuggestions     []SplitSuggestion  `json:"split_suggestions,omitempty"`
 }
 
 // FileQualityScore represents the quality score of an individual file
@@ -562,6 +534,12 @@ type FileQualityScore struct {
 	AtomicityScore    float64  `json:"atomicity_score"`
 	RecencyScore      float64  `json:"recency_score"`
 	SuggestedFixes    []string `json:"suggested_fixes"`
+
+	// PluginScores holds the 0.0-1.0 score of each quality plugin by name
+	PluginScores map[string]float64 `json:"plugin_scores,omitempty"`
+
+	// Language is the language readability was scored in
+	Language string `json:"language,omitempty"`
 }
 
 // TrendsAnalysis represents vault growth and trend analysis
@@ -583,6 +561,8 @@ type TrendsAnalysis struct {
 	ActivityPercentage float64             `json:"activity_percentage"`
 	Timeline           []TimelinePoint     `json:"timeline"`
 	TagTrends          map[string]TagTrend `json:"tag_trends"`
+	DateSources        map[string]int      `json:"date_sources"`   // Notes dated by each source
+	DailyActivity      map[string]int      `json:"daily_activity"` // Notes per day, by YYYY-MM-DD
 }
 
 // TimelinePoint represents a point in the timeline
@@ -593,8 +573,9 @@ type TimelinePoint struct {
 
 // TagTrend represents trending information for a tag
 type TagTrend struct {
-	Count      int     `json:"count"`
-	GrowthRate float64 `json:"growth_rate"`
+	Count      int             `json:"count"`
+	GrowthRate float64         `json:"growth_rate"`
+	Timeline   []TimelinePoint `json:"timeline"` // Notes with the tag per period, most recent first
 }
 
 // InboxAnalysis represents analysis of INBOX sections that need processing
@@ -620,6 +601,16 @@ type InboxSection struct {
 
 // AnalyzeLinks performs comprehensive link structure analysis
 func (a *Analyzer) AnalyzeLinks(files []*vault.VaultFile) LinkAnalysis {
+	acc := a.NewLinkAccumulator()
+	for _, file := range files {
+		acc.Add(file)
+	}
+	return acc.Analysis()
+}
+
+// analyzeLinkGraph analyzes the link structure of files whose links are
+// already parsed. Only paths, links and tags are used.
+func (a *Analyzer) analyzeLinkGraph(files []*vault.VaultFile) LinkAnalysis {
 	analysis := LinkAnalysis{
 		TotalFiles:   len(files),
 		LinkGraph:    make(map[string][]string),
@@ -636,11 +627,6 @@ func (a *Analyzer) AnalyzeLinks(files []*vault.VaultFile) LinkAnalysis {
 	totalLinks := 0
 
 	for _, file := range files {
-		// Parse links if parser is available
-		if a.linkParser != nil {
-			a.linkParser.UpdateFile(file)
-		}
-
 		// Count outbound links
 		if len(file.Links) > 0 {
 			analysis.FilesWithOutboundLinks++
@@ -688,10 +674,16 @@ func (a *Analyzer) AnalyzeLinks(files []*vault.VaultFile) LinkAnalysis {
 	for _, file := range orphaned {
 		analysis.OrphanedFiles = append(analysis.OrphanedFiles, file.RelativePath)
 	}
+	analysis.BrokenLinks = a.CountBrokenLinks(files)
 
 	// Calculate centrality scores
 	analysis.CentralFiles = a.calculateCentralityScores(files, inboundLinks, outboundCounts)
 
+	// Find cycles and clusters in the graph of links between existing notes
+	graph := noteGraph(files)
+	analysis.Cycles = findLinkCycles(graph)
+	analysis.Clusters = a.findLinkClusters(files, graph)
+
 	return analysis
 }
 
@@ -724,100 +716,32 @@ func (a *Analyzer) calculateCentralityScores(files []*vault.VaultFile, inboundLi
 
 // AnalyzeContentQuality performs comprehensive content quality analysis
 func (a *Analyzer) AnalyzeContentQuality(files []*vault.VaultFile) ContentAnalysis {
-	analysis := ContentAnalysis{
-		ScoreDistribution: make(map[string]int),
-		QualityIssues:     []string{},
-		Suggestions:       []string{},
-		FileScores:        []FileQualityScore{},
-	}
-
-	if len(files) == 0 {
-		return analysis
-	}
-
-	var totalContentLength, totalWordCount float64
-	var totalScore float64
-
-	// Initialize score distribution
-	analysis.ScoreDistribution["excellent"] = 0
-	analysis.ScoreDistribution["good"] = 0
-	analysis.ScoreDistribution["fair"] = 0
-	analysis.ScoreDistribution["poor"] = 0
-	analysis.ScoreDistribution["critical"] = 0
-
+	acc := a.NewContentAccumulator()
 	for _, file := range files {
-		// Calculate file quality score with detailed breakdown
-		overallScore := a.calculateFileQualityScore(file)
-
-		// Calculate individual scores for detailed breakdown
-		readabilityScore := a.calculateReadabilityScore(file)
-		linkDensityScore := a.calculateLinkDensityScore(file)
-		completenessScore := a.calculateCompletenessScore(file)
-		atomicityScore := a.calculateAtomicityScore(file)
-		recencyScore := a.calculateRecencyScore(file)
-
-		// Generate suggested fixes
-		suggestedFixes := a.generateFileQualityFixes(file, readabilityScore, linkDensityScore, completenessScore, atomicityScore, recencyScore)
-
-		analysis.FileScores = append(analysis.FileScores, FileQualityScore{
-			Path:              file.RelativePath,
-			Score:             overallScore * 100, // Convert to 0-100 scale
-			ReadabilityScore:  readabilityScore,
-			LinkDensityScore:  linkDensityScore,
-			CompletenessScore: completenessScore,
-			AtomicityScore:    atomicityScore,
-			RecencyScore:      recencyScore,
-			SuggestedFixes:    suggestedFixes,
-		})
-
-		totalScore += overallScore
-
-		// Categorize score
-		switch {
-		case overallScore >= 0.9:
-			analysis.ScoreDistribution["excellent"]++
-		case overallScore >= 0.75:
-			analysis.ScoreDistribution["good"]++
-		case overallScore >= 0.6:
-			analysis.ScoreDistribution["fair"]++
-		case overallScore >= 0.4:
-			analysis.ScoreDistribution["poor"]++
-		default:
-			analysis.ScoreDistribution["critical"]++
-		}
-
-		// Content metrics
-		contentLength := float64(len(file.Body))
-		wordCount := float64(len(strings.Fields(file.Body)))
-		totalContentLength += contentLength
-		totalWordCount += wordCount
-
-		// Count files with various features
-		if len(file.Frontmatter) > 0 {
-			analysis.FilesWithFrontmatter++
-		}
-		if len(file.Headings) > 0 {
-			analysis.FilesWithHeadings++
-		}
-		if len(file.Links) > 0 {
-			analysis.FilesWithLinks++
+		acc.Add(file)
+	}
+	return acc.Analysis()
+}
+
+// contentScores calculates the content-dependent quality components of a file,
+// reusing cached results for unchanged files
+func (a *Analyzer) contentScores(file *vault.VaultFile) contentScores {
+	compute := func() contentScores {
+		readability, language := a.readability(file)
+		return contentScores{
+			LinkCount:       len(file.Links),
+			DefaultLanguage: a.readabilityLanguage,
+			Language:        language,
+			Readability:     readability,
+			LinkDensity:     a.calculateLinkDensityScore(file),
+			Completeness:    a.calculateCompletenessScore(file),
+			Atomicity:       a.calculateAtomicityScore(file),
 		}
 	}
-
-	// Calculate overall metrics
-	analysis.OverallScore = (totalScore / float64(len(files))) * 100
-	analysis.AvgContentLength = totalContentLength / float64(len(files))
-	analysis.AvgWordCount = totalWordCount / float64(len(files))
-
-	// Generate quality issues and suggestions
-	analysis.QualityIssues, analysis.Suggestions = a.generateQualityInsights(analysis, len(files))
-
-	// Sort file scores by score descending
-	sort.Slice(analysis.FileScores, func(i, j int) bool {
-		return analysis.FileScores[i].Score > analysis.FileScores[j].Score
-	})
-
-	return analysis
+	if a.cache != nil {
+		return a.cache.scores(file, a.readabilityLanguage, compute)
+	}
+	return compute()
 }
 
 // calculateFileQualityScore calculates a Zettelkasten quality score for an individual file
@@ -829,13 +753,11 @@ func (a *Analyzer) calculateFileQualityScore(file *vault.VaultFile) float64 {
 	atomicity := a.calculateAtomicityScore(file)
 	recency := a.calculateRecencyScore(file)
 
-	// Weighted average (equal weights for each criterion)
-	totalScore := (readability + linkDensity + completeness + atomicity + recency) / 5.0
-
-	return totalScore
+	// Weighted average; criteria weigh 1 unless configured otherwise
+	return a.weightedQualityScore([]float64{readability, linkDensity, completeness, atomicity, recency}, nil)
 }
 
-// CalculateReadabilityScore calculates Flesch-Kincaid Reading Ease score (0.0-1.0)
+// CalculateReadabilityScore calculates reading ease in the note's language (0.0-1.0)
 func (a *Analyzer) CalculateReadabilityScore(file *vault.VaultFile) float64 {
 	return a.calculateReadabilityScore(file)
 }
@@ -860,38 +782,31 @@ func (a *Analyzer) CalculateRecencyScore(file *vault.VaultFile) float64 {
 	return a.calculateRecencyScore(file)
 }
 
-// calculateReadabilityScore calculates Flesch-Kincaid Reading Ease score (0.0-1.0)
+// calculateReadabilityScore calculates reading ease (0.0-1.0) with the
+// formula for the note's language: Flesch for English, its adaptations for
+// German, French, Spanish, Italian and Dutch, and LIX for other languages
 func (a *Analyzer) calculateReadabilityScore(file *vault.VaultFile) float64 {
+	score, _ := a.readability(file)
+	return score
+}
+
+// readability returns a note's reading ease (0.0-1.0) and the language it
+// was scored in
+func (a *Analyzer) readability(file *vault.VaultFile) (float64, string) {
 	if len(file.Body) == 0 {
-		return 0.0
+		return 0.0, ""
 	}
 
 	// Extract text for readability analysis
 	text := a.extractReadableText(file.Body)
 	if len(text) == 0 {
-		return 0.0
+		return 0.0, ""
 	}
 
-	// Calculate Flesch-Kincaid Reading Ease
-	sentences := a.countSentences(text)
-	words := len(strings.Fields(text))
-	syllables := a.countSyllables(text)
-
-	if sentences == 0 || words == 0 {
-		return 0.0
-	}
-
-	// Flesch Reading Ease formula: 206.835 - (1.015 × ASL) - (84.6 × ASW)
-	// ASL = Average Sentence Length = words/sentences
-	// ASW = Average Syllables per Word = syllables/words
-	asl := float64(words) / float64(sentences)
-	asw := float64(syllables) / float64(words)
-
-	fleschScore := 206.835 - (1.015 * asl) - (84.6 * asw)
-
-	// Convert Flesch score (0-100) to 0-1 scale
+	// Convert reading ease (0-100) to 0-1 scale
 	// Scores: 90-100=very easy, 80-89=easy, 70-79=fairly easy, 60-69=standard, 50-59=fairly difficult, 30-49=difficult, 0-29=very difficult
-	normalizedScore := fleschScore / 100.0
+	language := a.noteLanguage(file, text)
+	normalizedScore := a.readingEase(text, language) / 100.0
 	if normalizedScore > 1.0 {
 		normalizedScore = 1.0
 	}
@@ -899,7 +814,7 @@ func (a *Analyzer) calculateReadabilityScore(file *vault.VaultFile) float64 {
 		normalizedScore = 0.0
 	}
 
-	return normalizedScore
+	return normalizedScore, language
 }
 
 // calculateLinkDensityScore calculates outbound links per 100 words (0.0-1.0)
@@ -1146,14 +1061,8 @@ func (a *Analyzer) calculateTopicCoherence(text string) float64 {
 		return 1.0 // Short text is assumed coherent
 	}
 
-	// Count word frequencies
-	wordFreq := make(map[string]int)
-	for _, word := range words {
-		// Skip very short words and common words
-		if len(word) >= 4 && !a.isCommonWord(word) {
-			wordFreq[word]++
-		}
-	}
+	// Count word frequencies, skipping very short words and common words
+	wordFreq := termFrequencies(text)
 
 	if len(wordFreq) == 0 {
 		return 0.5 // Neutral if no significant words
@@ -1202,57 +1111,60 @@ func (a *Analyzer) calculateTopicCoherence(text string) float64 {
 	return coherence
 }
 
-// isCommonWord checks if a word is a common English word that shouldn't count for topic coherence
-func (a *Analyzer) isCommonWord(word string) bool {
-	commonWords := map[string]bool{
-		"that": true, "with": true, "have": true, "this": true, "will": true,
-		"your": true, "from": true, "they": true, "know": true, "want": true,
-		"been": true, "good": true, "much": true, "some": true, "time": true,
-		"very": true, "when": true, "come": true, "here": true, "just": true,
-		"like": true, "long": true, "make": true, "many": true, "over": true,
-		"such": true, "take": true, "than": true, "them": true, "well": true,
-		"were": true, "also": true, "back": true, "call": true, "came": true,
-		"each": true, "find": true, "give": true, "hand": true, "high": true,
-		"keep": true, "last": true, "left": true, "life": true, "live": true,
-		"look": true, "made": true, "most": true, "move": true, "must": true,
-		"name": true, "need": true, "next": true, "open": true, "part": true,
-		"play": true, "said": true, "same": true, "seem": true, "show": true,
-		"side": true, "tell": true, "turn": true, "used": true, "ways": true,
-		"week": true, "went": true, "what": true, "work": true, "year": true,
-		"years": true, "about": true, "after": true, "again": true, "before": true,
-		"being": true, "could": true, "every": true, "first": true, "found": true,
-		"great": true, "group": true, "might": true, "never": true, "often": true,
-		"other": true, "place": true, "right": true, "should": true, "small": true,
-		"still": true, "their": true, "there": true, "these": true, "think": true,
-		"three": true, "through": true, "under": true, "until": true, "water": true,
-		"where": true, "which": true, "while": true, "world": true, "would": true,
-		"write": true, "young": true,
-	}
-
-	return commonWords[word]
+// commonWords are common English words that say nothing about a note's topic
+var commonWords = map[string]bool{
+	"that": true, "with": true, "have": true, "this": true, "will": true,
+	"your": true, "from": true, "they": true, "know": true, "want": true,
+	"been": true, "good": true, "much": true, "some": true, "time": true,
+	"very": true, "when": true, "come": true, "here": true, "just": true,
+	"like": true, "long": true, "make": true, "many": true, "over": true,
+	"such": true, "take": true, "than": true, "them": true, "well": true,
+	"were": true, "also": true, "back": true, "call": true, "came": true,
+	"each": true, "find": true, "give": true, "hand": true, "high": true,
+	"keep": true, "last": true, "left": true, "life": true, "live": true,
+	"look": true, "made": true, "most": true, "move": true, "must": true,
+	"name": true, "need": true, "next": true, "open": true, "part": true,
+	"play": true, "said": true, "same": true, "seem": true, "show": true,
+	"side": true, "tell": true, "turn": true, "used": true, "ways": true,
+	"week": true, "went": true, "what": true, "work": true, "year": true,
+	"years": true, "about": true, "after": true, "again": true, "before": true,
+	"being": true, "could": true, "every": true, "first": true, "found": true,
+	"great": true, "group": true, "might": true, "never": true, "often": true,
+	"other": true, "place": true, "right": true, "should": true, "small": true,
+	"still": true, "their": true, "there": true, "these": true, "think": true,
+	"three": true, "through": true, "under": true, "until": true, "water": true,
+	"where": true, "which": true, "while": true, "world": true, "would": true,
+	"write": true, "young": true,
 }
 
 // generateFileQualityFixes generates specific improvement suggestions for a file
 func (a *Analyzer) generateFileQualityFixes(file *vault.VaultFile, readability, linkDensity, completeness, atomicity, recency float64) []string {
 	var fixes []string
 
+	// Criteria weighing 0 are disabled, so they get no suggestions
+	for i, score := range []*float64{&readability, &linkDensity, &completeness, &atomicity, &recency} {
+		if a.criterionWeight(QualityCriteria[i]) == 0 {
+			*score = 1
+		}
+	}
+
 	// Readability fixes
 	if readability < 0.4 {
-		fixes = append(fixes, "Simplify sentence structure for better readability")
-		fixes = append(fixes, "Use shorter sentences and common vocabulary")
+		fixes = append(fixes, i18n.T("Simplify sentence structure for better readability"))
+		fixes = append(fixes, i18n.T("Use shorter sentences and common vocabulary"))
 	}
 
 	// Link density fixes
 	if linkDensity < 0.3 {
-		fixes = append(fixes, "Add more links to related concepts (aim for 2-4 links per 100 words)")
+		fixes = append(fixes, i18n.T("Add more links to related concepts (aim for 2-4 links per 100 words)"))
 	} else if linkDensity < 0.6 {
-		fixes = append(fixes, "Consider adding a few more relevant links")
+		fixes = append(fixes, i18n.T("Consider adding a few more relevant links"))
 	}
 
 	// Completeness fixes
 	if completeness < 0.7 {
 		if _, hasTitle := file.Frontmatter["title"]; !hasTitle {
-			fixes = append(fixes, "Add a descriptive title in frontmatter")
+			fixes = append(fixes, i18n.T("Add a descriptive title in frontmatter"))
 		}
 
 		summaryFields := []string{"summary", "description", "abstract", "excerpt"}
@@ -1264,12 +1176,12 @@ func (a *Analyzer) generateFileQualityFixes(file *vault.VaultFile, readability,
 			}
 		}
 		if !hasSummary {
-			fixes = append(fixes, "Add a summary or description in frontmatter")
+			fixes = append(fixes, i18n.T("Add a summary or description in frontmatter"))
 		}
 
 		wordCount := len(strings.Fields(file.Body))
 		if wordCount < 50 {
-			fixes = append(fixes, "Expand content - add more detail and context")
+			fixes = append(fixes, i18n.T("Expand content - add more detail and context"))
 		}
 	}
 
@@ -1277,7 +1189,7 @@ func (a *Analyzer) generateFileQualityFixes(file *vault.VaultFile, readability,
 	if atomicity < 0.6 {
 		wordCount := len(strings.Fields(file.Body))
 		if wordCount > 500 {
-			fixes = append(fixes, "Consider breaking this into smaller, more focused notes")
+			fixes = append(fixes, i18n.T("Consider breaking this into smaller, more focused notes"))
 		}
 
 		h1Count := 0
@@ -1287,19 +1199,19 @@ func (a *Analyzer) generateFileQualityFixes(file *vault.VaultFile, readability,
 			}
 		}
 		if h1Count > 1 {
-			fixes = append(fixes, "Split multiple main topics into separate notes")
+			fixes = append(fixes, i18n.T("Split multiple main topics into separate notes"))
 		}
 	}
 
 	// Recency fixes
 	if recency < 0.5 {
-		fixes = append(fixes, "Review and update this note - it hasn't been modified recently")
-		fixes = append(fixes, "Add current date to track when content was last reviewed")
+		fixes = append(fixes, i18n.T("Review and update this note - it hasn't been modified recently"))
+		fixes = append(fixes, i18n.T("Add current date to track when content was last reviewed"))
 	}
 
 	// General suggestions based on overall quality
 	if len(fixes) == 0 {
-		fixes = append(fixes, "This note has good quality - consider linking it to related concepts")
+		fixes = append(fixes, i18n.T("This note has good quality - consider linking it to related concepts"))
 	}
 
 	return fixes
@@ -1311,41 +1223,43 @@ func (a *Analyzer) generateQualityInsights(analysis ContentAnalysis, totalFiles
 
 	// Check for common quality issues
 	if analysis.FilesWithFrontmatter < totalFiles/2 {
-		issues = append(issues, fmt.Sprintf("%.0f%% of files lack frontmatter", float64(totalFiles-analysis.FilesWithFrontmatter)/float64(totalFiles)*100))
-		suggestions = append(suggestions, "Add frontmatter to files using 'mdnotes frontmatter ensure'")
+		issues = append(issues, i18n.T("%.0f%% of files lack frontmatter", float64(totalFiles-analysis.FilesWithFrontmatter)/float64(totalFiles)*100))
+		suggestions = append(suggestions, i18n.T("Add frontmatter to files using 'mdnotes frontmatter ensure'"))
 	}
 
 	if analysis.FilesWithHeadings < totalFiles/3 {
-		issues = append(issues, fmt.Sprintf("%.0f%% of files lack heading structure", float64(totalFiles-analysis.FilesWithHeadings)/float64(totalFiles)*100))
-		suggestions = append(suggestions, "Add headings to improve content structure")
+		issues = append(issues, i18n.T("%.0f%% of files lack heading structure", float64(totalFiles-analysis.FilesWithHeadings)/float64(totalFiles)*100))
+		suggestions = append(suggestions, i18n.T("Add headings to improve content structure"))
 	}
 
 	if analysis.FilesWithLinks < totalFiles/4 {
-		issues = append(issues, "Low interconnectivity between files")
-		suggestions = append(suggestions, "Add more links between related content")
+		issues = append(issues, i18n.T("Low interconnectivity between files"))
+		suggestions = append(suggestions, i18n.T("Add more links between related content"))
 	}
 
 	if analysis.AvgWordCount < 100 {
-		issues = append(issues, "Many files have very short content")
-		suggestions = append(suggestions, "Consider expanding content or combining related short files")
+		issues = append(issues, i18n.T("Many files have very short content"))
+		suggestions = append(suggestions, i18n.T("Consider expanding content or combining related short files"))
 	}
 
 	// Score-based insights
 	criticalFiles := analysis.ScoreDistribution["critical"] + analysis.ScoreDistribution["poor"]
 	if criticalFiles > totalFiles/4 {
-		issues = append(issues, fmt.Sprintf("%d files have poor quality scores", criticalFiles))
-		suggestions = append(suggestions, "Focus on improving content structure and completeness")
+		issues = append(issues, i18n.T("%d files have poor quality scores", criticalFiles))
+		suggestions = append(suggestions, i18n.T("Focus on improving content structure and completeness"))
 	}
 
 	return issues, suggestions
 }
 
-// AnalyzeTrends performs vault growth and trend analysis
+// AnalyzeTrends performs vault growth and trend analysis, dating each note
+// by the first of the note date sources that knows when it was written
 func (a *Analyzer) AnalyzeTrends(files []*vault.VaultFile, timespan, granularity string) TrendsAnalysis {
 	analysis := TrendsAnalysis{
 		Granularity: granularity,
 		Timeline:    []TimelinePoint{},
 		TagTrends:   make(map[string]TagTrend),
+		DateSources: make(map[string]int),
 	}
 
 	if len(files) == 0 {
@@ -1366,21 +1280,24 @@ func (a *Analyzer) AnalyzeTrends(files []*vault.VaultFile, timespan, granularity
 	monthActivity := make(map[string]int)
 	periodActivity := make(map[string]int)
 	tagFrequency := make(map[string]int)
+	tagPeriods := make(map[string]map[string]int)
 
 	for _, file := range files {
-		if file.Modified.After(startDate) && file.Modified.Before(endDate) {
+		date, source := a.noteDate(file)
+		if date.After(startDate) && date.Before(endDate) {
 			filesInRange = append(filesInRange, file)
+			analysis.DateSources[source]++
 
 			// Track daily activity
-			dayKey := file.Modified.Format("2006-01-02")
+			dayKey := date.Format("2006-01-02")
 			dayActivity[dayKey]++
 
 			// Track monthly activity
-			monthKey := file.Modified.Format("2006-01")
+			monthKey := date.Format("2006-01")
 			monthActivity[monthKey]++
 
 			// Track period activity based on granularity
-			periodKey := a.formatPeriod(file.Modified, granularity)
+			periodKey := a.formatPeriod(date, granularity)
 			periodActivity[periodKey]++
 
 			// Track tag trends
@@ -1388,12 +1305,17 @@ func (a *Analyzer) AnalyzeTrends(files []*vault.VaultFile, timespan, granularity
 				extractedTags := a.extractTags(tags)
 				for _, tag := range extractedTags {
 					tagFrequency[tag]++
+					if tagPeriods[tag] == nil {
+						tagPeriods[tag] = make(map[string]int)
+					}
+					tagPeriods[tag][periodKey]++
 				}
 			}
 		}
 	}
 
 	analysis.TotalFilesCreated = len(filesInRange)
+	analysis.DailyActivity = dayActivity
 
 	// Calculate growth metrics
 	totalDays := int(endDate.Sub(startDate).Hours() / 24)
@@ -1435,6 +1357,7 @@ func (a *Analyzer) AnalyzeTrends(files []*vault.VaultFile, timespan, granularity
 		analysis.TagTrends[tag] = TagTrend{
 			Count:      count,
 			GrowthRate: float64(count) / float64(analysis.TotalFilesCreated) * 100,
+			Timeline:   a.buildTimeline(tagPeriods[tag], granularity),
 		}
 	}
 
@@ -1463,6 +1386,13 @@ func (a *Analyzer) parseTimespan(timespan string, endDate time.Time) time.Time {
 }
 
 func (a *Analyzer) formatPeriod(date time.Time, granularity string) string {
+	return PeriodKey(date, granularity)
+}
+
+// PeriodKey names the period of the given granularity (day, week, month or
+// quarter) that date falls in, as trends timelines do: 2025-03-01, 2025-W09,
+// 2025-03 or 2025-Q1
+func PeriodKey(date time.Time, granularity string) string {
 	switch granularity {
 	case "day":
 		return date.Format("2006-01-02")
@@ -1508,6 +1438,34 @@ func (a *Analyzer) calculateWritingStreak(dayActivity map[string]int, endDate ti
 	return streak
 }
 
+// PeriodRange lists the periods from the one containing start to the one
+// containing end, oldest first
+func PeriodRange(start, end time.Time, granularity string) []string {
+	var periods []string
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for date := start; !date.After(end); {
+		key := PeriodKey(date, granularity)
+		if len(periods) == 0 || periods[len(periods)-1] != key {
+			periods = append(periods, key)
+		}
+		switch granularity {
+		case "day":
+			date = date.AddDate(0, 0, 1)
+		case "week":
+			date = date.AddDate(0, 0, 7)
+		case "quarter":
+			// Step from the first of the month so short months aren't skipped
+			date = time.Date(date.Year(), date.Month()+3, 1, 0, 0, 0, 0, date.Location())
+		default:
+			date = time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, date.Location())
+		}
+	}
+	if key := PeriodKey(end, granularity); len(periods) > 0 && periods[len(periods)-1] != key {
+		periods = append(periods, key)
+	}
+	return periods
+}
+
 func (a *Analyzer) buildTimeline(periodActivity map[string]int, granularity string) []TimelinePoint {
 	var timeline []TimelinePoint
 
@@ -1621,71 +1579,6 @@ func (a *Analyzer) FindSyncConflictFiles(files []*vault.VaultFile) []SyncConflic
 }
 
 // GetHealthScore calculates an overall health score for the vault
-func (a *Analyzer) GetHealthScore(stats VaultStats) HealthScore {
-	score := 100.0
-	var issues []string
-	var suggestions []string
-
-	// Penalize missing frontmatter
-	if stats.FilesWithoutFrontmatter > 0 {
-		penalty := float64(stats.FilesWithoutFrontmatter) / float64(stats.TotalFiles) * 30
-		score -= penalty
-		issues = append(issues, fmt.Sprintf("%d files missing frontmatter", stats.FilesWithoutFrontmatter))
-		suggestions = append(suggestions, "Add frontmatter to files using 'mdnotes frontmatter ensure'")
-	}
-
-	// Penalize orphaned files (but only if there are multiple files)
-	if len(stats.OrphanedFiles) > 0 && stats.TotalFiles > 1 {
-		penalty := float64(len(stats.OrphanedFiles)) / float64(stats.TotalFiles) * 20
-		score -= penalty
-		issues = append(issues, fmt.Sprintf("%d orphaned files", len(stats.OrphanedFiles)))
-		suggestions = append(suggestions, "Review orphaned files and add links to integrate them")
-	}
-
-	// Penalize broken links
-	if stats.BrokenLinksCount > 0 {
-		penalty := float64(stats.BrokenLinksCount) / float64(stats.TotalLinks) * 25
-		score -= penalty
-		issues = append(issues, fmt.Sprintf("%d broken links", stats.BrokenLinksCount))
-		suggestions = append(suggestions, "Fix broken links using 'mdnotes links check'")
-	}
-
-	// Penalize duplicates
-	if stats.DuplicateCount > 0 {
-		penalty := float64(stats.DuplicateCount) * 5
-		score -= penalty
-		issues = append(issues, fmt.Sprintf("%d duplicate entries", stats.DuplicateCount))
-		suggestions = append(suggestions, "Review and resolve duplicate content")
-	}
-
-	// Ensure score doesn't go below 0
-	if score < 0 {
-		score = 0
-	}
-
-	// Determine health level
-	var level HealthLevel
-	switch {
-	case score >= 90:
-		level = Excellent
-	case score >= 75:
-		level = Good
-	case score >= 60:
-		level = Fair
-	case score >= 40:
-		level = Poor
-	default:
-		level = Critical
-	}
-
-	return HealthScore{
-		Level:       level,
-		Score:       score,
-		Issues:      issues,
-		Suggestions: suggestions,
-	}
-}
-
 // AnalyzeInbox analyzes INBOX sections and pending content that needs processing
 func (a *Analyzer) AnalyzeInbox(files []*vault.VaultFile, inboxHeadings []string, sortBy string, minItems int) *InboxAnalysis {
 	analysis := &InboxAnalysis{
@@ -1883,27 +1776,27 @@ func (a *Analyzer) generateActionSuggestions(content string, itemCount int) []st
 	lowerContent := strings.ToLower(content)
 
 	if itemCount > 10 {
-		suggestions = append(suggestions, "Break down into smaller tasks")
+		suggestions = append(suggestions, i18n.T("Break down into smaller tasks"))
 	}
 
 	if itemCount > 5 {
-		suggestions = append(suggestions, "Prioritize by urgency")
+		suggestions = append(suggestions, i18n.T("Prioritize by urgency"))
 	}
 
 	if strings.Contains(lowerContent, "link") || strings.Contains(lowerContent, "url") {
-		suggestions = append(suggestions, "Process links with linkding sync")
+		suggestions = append(suggestions, i18n.T("Process links with linkding sync"))
 	}
 
 	if strings.Contains(lowerContent, "note") || strings.Contains(lowerContent, "idea") {
-		suggestions = append(suggestions, "Convert to permanent notes")
+		suggestions = append(suggestions, i18n.T("Convert to permanent notes"))
 	}
 
 	if strings.Contains(lowerContent, "book") || strings.Contains(lowerContent, "article") {
-		suggestions = append(suggestions, "Add to reading list")
+		suggestions = append(suggestions, i18n.T("Add to reading list"))
 	}
 
 	if len(suggestions) == 0 {
-		suggestions = append(suggestions, "Review and organize content")
+		suggestions = append(suggestions, i18n.T("Review and organize content"))
 	}
 
 	return suggestions
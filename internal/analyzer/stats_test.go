@@ -1,10 +1,12 @@
 package analyzer
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
@@ -224,6 +226,29 @@ func TestAnalyzer_FindOrphanedFiles(t *testing.T) {
 	assert.NotContains(t, orphanedPaths, "linked.md")        // This is linked by linker.md
 }
 
+func TestAnalyzer_CountBrokenLinks(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md", Links: []vault.Link{
+			{Type: vault.WikiLink, Target: "Deep Note"}, // resolves by name
+			{Type: vault.WikiLink, Target: "missing"},   // broken
+			{Type: vault.MarkdownLink, Target: "notes/deep note.md"},
+			{Type: vault.MarkdownLink, Target: "gone.md"}, // broken
+			{Type: vault.EmbedLink, Target: "image.png"},  // attachment, not checked
+			{Type: vault.MarkdownLink, Target: "https://example.com"},
+		}},
+		{RelativePath: "notes/deep note.md", Links: []vault.Link{
+			{Type: vault.MarkdownLink, Target: "sibling.md"}, // file-relative
+			{Type: vault.WikiLink, Target: "index"},
+		}},
+		{RelativePath: "notes/sibling.md"},
+	}
+
+	assert.Equal(t, 2, analyzer.CountBrokenLinks(files))
+	assert.Equal(t, 2, analyzer.AnalyzeLinks(files).BrokenLinks)
+}
+
 func TestAnalyzer_GetHealthScore(t *testing.T) {
 	analyzer := NewAnalyzer()
 
@@ -273,6 +298,38 @@ func TestAnalyzer_GetHealthScore(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_HealthRules(t *testing.T) {
+	stats := VaultStats{
+		TotalFiles:              10,
+		FilesWithoutFrontmatter: 2,
+		OrphanedFiles:           []string{"a.md"},
+		TotalLinks:              20,
+		BrokenLinksCount:        5,
+		DuplicateCount:          1,
+	}
+
+	health := NewAnalyzer().GetHealthScore(stats)
+	// 100 - 30×0.2 - 20×0.1 - 25×0.25 - 5×1
+	assert.InDelta(t, 80.75, health.Score, 0.001)
+	assert.Equal(t, Good, health.Level)
+	require.Len(t, health.Rules, 4)
+	assert.Equal(t, RuleScore{ID: "broken_links", Enabled: true, Weight: 25, Value: 0.25, Count: 5, Penalty: 6.25}, health.Rules[2])
+
+	rules := DefaultHealthRules()
+	rules[1].Threshold = 0.1 // Tolerate up to 10% orphans
+	rules[2].Weight = 100
+	rules[3].Enabled = false
+	analyzer := NewAnalyzer()
+	analyzer.SetHealthRules(rules)
+
+	health = analyzer.GetHealthScore(stats)
+	assert.InDelta(t, 69, health.Score, 0.001)
+	assert.Len(t, health.Issues, 2)
+	assert.Zero(t, health.Rules[1].Penalty)
+	assert.False(t, health.Rules[3].Enabled)
+	assert.Equal(t, 1, health.Rules[3].Count)
+}
+
 // Helper function to create test vault
 func createTestVault(t *testing.T) *TestVault {
 	files := []*vault.VaultFile{
@@ -347,3 +404,99 @@ type TestVault struct {
 	Files []*vault.VaultFile
 	Path  string
 }
+
+func TestFieldAnalysis_JSONAndSortedValues(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	files := []*vault.VaultFile{
+		{Path: "a.md", Frontmatter: map[string]interface{}{"status": "draft", "meta": map[string]interface{}{"k": 1}}},
+		{Path: "b.md", Frontmatter: map[string]interface{}{"status": "draft", "aliases": []string{"x"}}},
+		{Path: "c.md", Frontmatter: map[string]interface{}{"status": "done"}},
+	}
+
+	assert.Equal(t, []string{"aliases", "meta", "status"}, analyzer.FieldNames(files))
+
+	analysis := analyzer.AnalyzeField(files, "status")
+	assert.Equal(t, []ValueCount{{Value: "draft", Count: 2}, {Value: "done", Count: 1}}, analysis.SortedValues())
+
+	data, err := json.Marshal(analysis)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"value_distribution":[{"value":"draft","count":2},{"value":"done","count":1}]`)
+
+	// Unhashable values must not panic
+	assert.Equal(t, 1, analyzer.AnalyzeField(files, "meta").UniqueValues)
+	assert.Equal(t, 1, analyzer.AnalyzeField(files, "aliases").UniqueValues)
+}
+
+func TestAnalyzer_ContentHashing(t *testing.T) {
+	files := []*vault.VaultFile{
+		{Path: "unix.md", Body: "# Title\n\nSame text\n", Content: []byte("---\nstatus: draft\n---\n# Title\n\nSame text\n")},
+		{Path: "windows.md", Body: "# Title  \r\n\r\nSame text\r\n\r\n", Content: []byte("---\r\nstatus: done\r\n---\r\n# Title  \r\n\r\nSame text\r\n\r\n")},
+		{Path: "other.md", Body: "# Other\n"},
+	}
+
+	for _, name := range HasherNames {
+		t.Run(name, func(t *testing.T) {
+			hasher, err := NewHasher(name)
+			require.NoError(t, err)
+			analyzer := NewAnalyzer()
+			analyzer.SetContentHashing(hasher, false)
+
+			// Line endings and trailing whitespace are normalized away
+			duplicates := analyzer.FindContentDuplicates(files, ExactMatch)
+			require.Len(t, duplicates, 1)
+			assert.ElementsMatch(t, []string{"unix.md", "windows.md"}, duplicates[0].Files)
+
+			// The frontmatter differs
+			analyzer.SetContentHashing(hasher, true)
+			assert.Empty(t, analyzer.FindContentDuplicates(files, ExactMatch))
+		})
+	}
+
+	_, err := NewHasher("crc32")
+	assert.ErrorContains(t, err, "unknown hash 'crc32'")
+}
+
+func TestXXHash64(t *testing.T) {
+	// Reference values published with xxHash
+	tests := map[string]uint64{
+		"":    0xef46db3751d8e999,
+		"a":   0xd24ec4f1a98c6e5b,
+		"abc": 0x44bc2cf5ad770999,
+		"Nobody inspects the spammish repetition": 0xfbcea83c8a378bf1,
+	}
+	for input, want := range tests {
+		assert.Equal(t, want, xxhash64([]byte(input)), input)
+	}
+}
+
+func TestPeriodRange(t *testing.T) {
+	start := time.Date(2024, 12, 30, 15, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []string{"2024-12", "2025-01", "2025-02", "2025-03"}, PeriodRange(start, end, "month"))
+	assert.Equal(t, []string{"2024-Q4", "2025-Q1"}, PeriodRange(start, end, "quarter"))
+	weeks := PeriodRange(start, end, "week")
+	assert.Equal(t, "2025-W01", weeks[0])
+	assert.Equal(t, "2025-W09", weeks[len(weeks)-1])
+	assert.Len(t, weeks, 9)
+	assert.Len(t, PeriodRange(start, end, "day"), 63)
+}
+
+func TestAnalyzer_AnalyzeTrendsActivity(t *testing.T) {
+	now := time.Now()
+	lastMonth := now.AddDate(0, -1, 0)
+	files := []*vault.VaultFile{
+		{RelativePath: "a.md", Modified: now, Frontmatter: map[string]interface{}{"tags": []interface{}{"work"}}},
+		{RelativePath: "b.md", Modified: now, Frontmatter: map[string]interface{}{"tags": []interface{}{"work", "home"}}},
+		{RelativePath: "c.md", Modified: lastMonth, Frontmatter: map[string]interface{}{"tags": []interface{}{"work"}}},
+	}
+
+	analysis := NewAnalyzer().AnalyzeTrends(files, "1y", "month")
+	assert.Equal(t, map[string]int{now.Format("2006-01-02"): 2, lastMonth.Format("2006-01-02"): 1}, analysis.DailyActivity)
+	assert.Equal(t, []TimelinePoint{
+		{Period: now.Format("2006-01"), Count: 2},
+		{Period: lastMonth.Format("2006-01"), Count: 1},
+	}, analysis.TagTrends["work"].Timeline)
+	assert.Equal(t, []TimelinePoint{{Period: now.Format("2006-01"), Count: 1}}, analysis.TagTrends["home"].Timeline)
+}
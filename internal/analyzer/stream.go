@@ -0,0 +1,412 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// StatsAccumulator builds VaultStats one file at a time, so a vault can be
+// analyzed while it is scanned rather than after loading every file. Only each
+// file's path and links are kept, for orphan and broken link detection.
+type StatsAccumulator struct {
+	analyzer     *Analyzer
+	stats        VaultStats
+	linked       []*vault.VaultFile
+	lastModified time.Time
+	oldestFile   time.Time
+}
+
+// NewStatsAccumulator returns an empty accumulator using the analyzer's link
+// parser and cache
+func (a *Analyzer) NewStatsAccumulator() *StatsAccumulator {
+	return &StatsAccumulator{
+		analyzer: a,
+		stats: VaultStats{
+			TagDistribution:  make(map[string]int),
+			FieldPresence:    make(map[string]int),
+			TypeDistribution: make(map[string]map[string]int),
+		},
+	}
+}
+
+// Add folds a file into the statistics. The file can be discarded afterwards.
+func (acc *StatsAccumulator) Add(file *vault.VaultFile) {
+	stats := &acc.stats
+	if stats.TotalFiles == 0 || file.Modified.After(acc.lastModified) {
+		acc.lastModified = file.Modified
+	}
+	if stats.TotalFiles == 0 || file.Modified.Before(acc.oldestFile) {
+		acc.oldestFile = file.Modified
+	}
+	stats.TotalFiles++
+	stats.TotalSize += int64(len(file.Content))
+
+	// Frontmatter analysis
+	if len(file.Frontmatter) > 0 {
+		stats.FilesWithFrontmatter++
+		acc.analyzer.analyzeFrontmatter(file.Frontmatter, stats)
+	} else {
+		stats.FilesWithoutFrontmatter++
+	}
+
+	// Parse links if parser is available
+	acc.analyzer.parseLinks(file)
+
+	// Count links and headings
+	stats.TotalLinks += len(file.Links)
+	stats.TotalHeadings += len(file.Headings)
+
+	acc.linked = append(acc.linked, linkedFile(file))
+}
+
+// linkedFile returns a copy of file with only its paths and links, all that
+// link analysis needs, so the file itself can be discarded
+func linkedFile(file *vault.VaultFile) *vault.VaultFile {
+	return &vault.VaultFile{
+		Path:         file.Path,
+		RelativePath: file.RelativePath,
+		Links:        file.Links,
+	}
+}
+
+// Stats returns the statistics for the files added so far
+func (acc *StatsAccumulator) Stats() VaultStats {
+	stats := acc.stats
+	if stats.TotalFiles == 0 {
+		return stats
+	}
+
+	stats.AverageFileSize = float64(stats.TotalSize) / float64(stats.TotalFiles)
+	stats.LastModified = acc.lastModified
+	stats.OldestFile = acc.oldestFile
+
+	// Find orphaned files
+	for _, file := range acc.analyzer.FindOrphanedFiles(acc.linked) {
+		stats.OrphanedFiles = append(stats.OrphanedFiles, file.Path)
+	}
+	stats.BrokenLinksCount = acc.analyzer.CountBrokenLinks(acc.linked)
+
+	return stats
+}
+
+// LinkAccumulator builds a LinkAnalysis one file at a time, keeping only each
+// file's paths, links and tags
+type LinkAccumulator struct {
+	analyzer *Analyzer
+	linked   []*vault.VaultFile
+}
+
+// NewLinkAccumulator returns an empty accumulator using the analyzer's link
+// parser and cache
+func (a *Analyzer) NewLinkAccumulator() *LinkAccumulator {
+	return &LinkAccumulator{analyzer: a}
+}
+
+// Add parses a file's links and adds them to the link graph. The file can be
+// discarded afterwards.
+func (acc *LinkAccumulator) Add(file *vault.VaultFile) {
+	acc.analyzer.parseLinks(file)
+	linked := linkedFile(file)
+	// Clusters are described by their notes' tags
+	if tags, ok := file.Frontmatter["tags"]; ok {
+		linked.Frontmatter = map[string]interface{}{"tags": tags}
+	}
+	acc.linked = append(acc.linked, linked)
+}
+
+// Files returns the paths, links and tags of the files added so far, for
+// NewNoteGraph
+func (acc *LinkAccumulator) Files() []*vault.VaultFile {
+	return acc.linked
+}
+
+// Analysis returns the link analysis of the files added so far
+func (acc *LinkAccumulator) Analysis() LinkAnalysis {
+	return acc.analyzer.analyzeLinkGraph(acc.linked)
+}
+
+// contentBatchSize is how many files ContentAccumulator holds at a time, so
+// quality plugins can score them in parallel
+const contentBatchSize = 64
+
+// ContentAccumulator builds a ContentAnalysis one file at a time, keeping
+// each file's scores but not the file itself
+type ContentAccumulator struct {
+	analyzer *Analyzer
+	analysis ContentAnalysis
+	pending  []*vault.VaultFile // Awaiting quality plugins
+	files    int
+	errors   map[string][]error // Quality plugin failures, by plugin name
+
+	totalScore, totalContentLength, totalWordCount float64
+
+	splits            bool
+	splitMaxAtomicity float64
+	splitMinWords     int
+}
+
+// NewContentAccumulator returns an empty accumulator using the analyzer's
+// quality settings, plugins and cache
+func (a *Analyzer) NewContentAccumulator() *ContentAccumulator {
+	return &ContentAccumulator{
+		analyzer: a,
+		analysis: ContentAnalysis{
+			ScoreDistribution: make(map[string]int),
+			ScoreThresholds:   a.QualityThresholds(),
+			QualityIssues:     []string{},
+			Suggestions:       []string{},
+			FileScores:        []FileQualityScore{},
+		},
+		errors: make(map[string][]error),
+	}
+}
+
+// SuggestSplits makes the analysis include split suggestions, as
+// Analyzer.SuggestSplits makes them
+func (acc *ContentAccumulator) SuggestSplits(maxAtomicity float64, minWords int) {
+	acc.splits = true
+	acc.splitMaxAtomicity = maxAtomicity
+	acc.splitMinWords = minWords
+}
+
+// Add folds a file into the analysis. The file is held until its batch has
+// been scored by the quality plugins.
+func (acc *ContentAccumulator) Add(file *vault.VaultFile) {
+	acc.pending = append(acc.pending, file)
+	if len(acc.pending) >= contentBatchSize {
+		acc.flush()
+	}
+}
+
+// flush scores the pending files
+func (acc *ContentAccumulator) flush() {
+	if len(acc.pending) == 0 {
+		return
+	}
+	a := acc.analyzer
+	analysis := &acc.analysis
+	plugins := a.runQualityScorers(acc.pending)
+	for name, errs := range plugins.errors {
+		acc.errors[name] = append(acc.errors[name], errs...)
+	}
+
+	for _, file := range acc.pending {
+		// Calculate individual scores for detailed breakdown; recency depends on
+		// the current time so it is never cached
+		scores := a.contentScores(file)
+		readabilityScore := scores.Readability
+		linkDensityScore := scores.LinkDensity
+		completenessScore := scores.Completeness
+		atomicityScore := scores.Atomicity
+		recencyScore := a.calculateRecencyScore(file)
+
+		// Generate suggested fixes
+		suggestedFixes := a.generateFileQualityFixes(file, readabilityScore, linkDensityScore, completenessScore, atomicityScore, recencyScore)
+
+		// Merge in plugin criteria and their suggestions
+		var pluginScores map[string]float64
+		for _, ws := range a.qualityScorers {
+			result, ok := plugins.scores[file.RelativePath][ws.scorer.Name()]
+			if !ok {
+				continue
+			}
+			if pluginScores == nil {
+				pluginScores = make(map[string]float64)
+			}
+			pluginScores[ws.scorer.Name()] = result.Score
+			suggestedFixes = append(suggestedFixes, result.Suggestions...)
+		}
+
+		// Weighted average, as in calculateFileQualityScore
+		overallScore := a.weightedQualityScore([]float64{readabilityScore, linkDensityScore, completenessScore, atomicityScore, recencyScore}, pluginScores)
+
+		analysis.FileScores = append(analysis.FileScores, FileQualityScore{
+			Path:              file.RelativePath,
+			Score:             overallScore * 100, // Convert to 0-100 scale
+			Language:          scores.Language,
+			ReadabilityScore:  readabilityScore,
+			LinkDensityScore:  linkDensityScore,
+			CompletenessScore: completenessScore,
+			AtomicityScore:    atomicityScore,
+			RecencyScore:      recencyScore,
+			SuggestedFixes:    suggestedFixes,
+			PluginScores:      pluginScores,
+		})
+
+		acc.files++
+		acc.totalScore += overallScore
+
+		// Categorize score
+		analysis.ScoreDistribution[a.qualityBand(overallScore*100)]++
+
+		// Content metrics
+		acc.totalContentLength += float64(len(file.Body))
+		acc.totalWordCount += float64(len(strings.Fields(file.Body)))
+
+		// Count files with various features
+		if len(file.Frontmatter) > 0 {
+			analysis.FilesWithFrontmatter++
+		}
+		if len(file.Headings) > 0 {
+			analysis.FilesWithHeadings++
+		}
+		if len(file.Links) > 0 {
+			analysis.FilesWithLinks++
+		}
+
+		if acc.splits {
+			if suggestion, ok := a.suggestSplit(file, acc.splitMaxAtomicity, acc.splitMinWords); ok {
+				analysis.SplitSuggestions = append(analysis.SplitSuggestions, suggestion)
+			}
+		}
+	}
+	acc.pending = nil
+}
+
+// Analysis returns the content analysis of the files added so far
+func (acc *ContentAccumulator) Analysis() ContentAnalysis {
+	acc.flush()
+	analysis := acc.analysis
+	if acc.files == 0 {
+		return analysis
+	}
+
+	// Report every band, even empty ones
+	for _, band := range QualityBands {
+		if _, ok := analysis.ScoreDistribution[band]; !ok {
+			analysis.ScoreDistribution[band] = 0
+		}
+	}
+
+	// Calculate overall metrics
+	files := float64(acc.files)
+	analysis.OverallScore = (acc.totalScore / files) * 100
+	analysis.AvgContentLength = acc.totalContentLength / files
+	analysis.AvgWordCount = acc.totalWordCount / files
+
+	// Generate quality issues and suggestions
+	analysis.QualityIssues, analysis.Suggestions = acc.analyzer.generateQualityInsights(analysis, acc.files)
+	analysis.QualityIssues = append(analysis.QualityIssues, pluginResults{errors: acc.errors}.issues()...)
+
+	// Sort file scores by score descending
+	sort.Slice(analysis.FileScores, func(i, j int) bool {
+		return analysis.FileScores[i].Score > analysis.FileScores[j].Score
+	})
+	sortSplitSuggestions(analysis.SplitSuggestions)
+
+	return analysis
+}
+
+// FieldAccumulator builds FieldAnalysis results one file at a time
+type FieldAccumulator struct {
+	analyzer *Analyzer
+	fields   []string // nil analyzes every field found
+	total    int
+	states   map[string]*fieldState
+}
+
+// fieldState is the running analysis of one field
+type fieldState struct {
+	analysis   FieldAnalysis
+	seenValues map[interface{}]bool
+}
+
+// NewFieldAccumulator returns an empty accumulator for the named fields, or
+// for every frontmatter field found if none are named
+func (a *Analyzer) NewFieldAccumulator(fields ...string) *FieldAccumulator {
+	acc := &FieldAccumulator{
+		analyzer: a,
+		fields:   fields,
+		states:   make(map[string]*fieldState),
+	}
+	for _, field := range fields {
+		acc.state(field)
+	}
+	return acc
+}
+
+// state returns the running analysis of a field, starting one if needed
+func (acc *FieldAccumulator) state(field string) *fieldState {
+	state, ok := acc.states[field]
+	if !ok {
+		state = &fieldState{
+			analysis: FieldAnalysis{
+				FieldName:         field,
+				ValueDistribution: make(map[interface{}]int),
+				TypeDistribution:  make(map[string]int),
+			},
+			seenValues: make(map[interface{}]bool),
+		}
+		acc.states[field] = state
+	}
+	return state
+}
+
+// Add folds a file's frontmatter into the analyses
+func (acc *FieldAccumulator) Add(file *vault.VaultFile) {
+	acc.total++
+	for field, value := range file.Frontmatter {
+		if acc.fields != nil {
+			if _, wanted := acc.states[field]; !wanted {
+				continue
+			}
+		}
+		state := acc.state(field)
+		analysis := &state.analysis
+		analysis.TotalFiles++
+
+		// Count value occurrences - need to handle unhashable types
+		var valueKey interface{}
+		switch v := value.(type) {
+		case []interface{}, []string, map[string]interface{}:
+			// Convert to string for map key
+			valueKey = fmt.Sprintf("%v", v)
+		default:
+			valueKey = value
+		}
+		analysis.ValueDistribution[valueKey]++
+
+		// Count type occurrences
+		analysis.TypeDistribution[acc.analyzer.getTypeName(value)]++
+
+		// Collect examples
+		if !state.seenValues[valueKey] && len(analysis.Examples) < 5 {
+			analysis.Examples = append(analysis.Examples, value)
+			state.seenValues[valueKey] = true
+		}
+	}
+}
+
+// Analyses returns the analysis of each named field in the order given, or of
+// every field found sorted by name
+func (acc *FieldAccumulator) Analyses() []FieldAnalysis {
+	fields := acc.fields
+	if fields == nil {
+		for field := range acc.states {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+	}
+
+	analyses := make([]FieldAnalysis, 0, len(fields))
+	for _, field := range fields {
+		analysis := acc.states[field].analysis
+		analysis.MissingCount = acc.total - analysis.TotalFiles
+		analysis.UniqueValues = len(analysis.ValueDistribution)
+
+		// Find predominant type, breaking ties alphabetically so output is stable
+		maxCount := 0
+		for typeName, count := range analysis.TypeDistribution {
+			if count > maxCount || (count == maxCount && typeName < analysis.PredominantType) {
+				maxCount = count
+				analysis.PredominantType = typeName
+			}
+		}
+		analyses = append(analyses, analysis)
+	}
+	return analyses
+}
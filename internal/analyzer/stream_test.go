@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestStatsAccumulator_MatchesGenerateStats(t *testing.T) {
+	testVault := createTestVault(t)
+	analyzer := NewAnalyzer()
+
+	acc := analyzer.NewStatsAccumulator()
+	for _, file := range testVault.Files {
+		acc.Add(file)
+	}
+
+	assert.Equal(t, analyzer.GenerateStats(testVault.Files), acc.Stats())
+	assert.Equal(t, NewAnalyzer().NewStatsAccumulator().Stats(), NewAnalyzer().GenerateStats(nil))
+}
+
+func TestStatsAccumulator_KeepsOnlyLinks(t *testing.T) {
+	acc := NewAnalyzer().NewStatsAccumulator()
+	acc.Add(&vault.VaultFile{
+		Path:         "a.md",
+		RelativePath: "a.md",
+		Content:      []byte("# A\n\n[[b]] [[missing]]"),
+		Body:         "# A\n\n[[b]] [[missing]]",
+		Links: []vault.Link{
+			{Type: vault.WikiLink, Target: "b"},
+			{Type: vault.WikiLink, Target: "missing"},
+		},
+	})
+	acc.Add(&vault.VaultFile{Path: "b.md", RelativePath: "b.md", Content: []byte("# B")})
+
+	stats := acc.Stats()
+	assert.Equal(t, 2, stats.TotalFiles)
+	assert.Equal(t, 1, stats.BrokenLinksCount)
+	assert.Equal(t, []string{"a.md"}, stats.OrphanedFiles)
+	for _, file := range acc.linked {
+		assert.Empty(t, file.Content)
+		assert.Empty(t, file.Body)
+	}
+}
+
+func TestLinkAccumulator_KeepsOnlyLinksAndTags(t *testing.T) {
+	acc := NewAnalyzer().NewLinkAccumulator()
+	acc.Add(&vault.VaultFile{
+		Path:         "a.md",
+		RelativePath: "a.md",
+		Body:         "[[b]]",
+		Frontmatter:  map[string]interface{}{"tags": []interface{}{"go"}, "status": "draft"},
+		Links:        []vault.Link{{Type: vault.WikiLink, Target: "b"}},
+	})
+	acc.Add(&vault.VaultFile{
+		Path:         "b.md",
+		RelativePath: "b.md",
+		Body:         "[[a]]",
+		Frontmatter:  map[string]interface{}{"tags": []interface{}{"go"}},
+		Links:        []vault.Link{{Type: vault.WikiLink, Target: "a"}},
+	})
+
+	analysis := acc.Analysis()
+	assert.Equal(t, 2, analysis.TotalLinks)
+	require.Len(t, analysis.Clusters, 1)
+	assert.Equal(t, []string{"go"}, analysis.Clusters[0].Tags)
+	for _, file := range acc.Files() {
+		assert.Empty(t, file.Body)
+		assert.NotContains(t, file.Frontmatter, "status")
+	}
+}
+
+func TestContentAccumulator_ScoresInBatches(t *testing.T) {
+	var files []*vault.VaultFile
+	for i := range contentBatchSize + 3 {
+		body := "# Topic\n\nA short note.\n"
+		if i == 0 {
+			body = "# Fiction\n\n" + words(700) + "\n\n# History\n\n" + words(50) + "\n"
+		}
+		name := fmt.Sprintf("%03d.md", i)
+		files = append(files, &vault.VaultFile{Path: name, RelativePath: name, Body: body, Headings: vault.ExtractHeadings(body)})
+	}
+	analyzer := NewAnalyzer()
+
+	acc := analyzer.NewContentAccumulator()
+	acc.SuggestSplits(0.9, DefaultSplitMinWords)
+	for _, file := range files {
+		acc.Add(file)
+		assert.Less(t, len(acc.pending), contentBatchSize)
+	}
+
+	analysis := acc.Analysis()
+	assert.Len(t, analysis.FileScores, len(files))
+	assert.Equal(t, len(files), analysis.FilesWithHeadings)
+	assert.Equal(t, analyzer.SuggestSplits(files, 0.9, DefaultSplitMinWords), analysis.SplitSuggestions)
+	assert.Len(t, analysis.SplitSuggestions, 1)
+}
+
+func TestFieldAccumulator(t *testing.T) {
+	files := []*vault.VaultFile{
+		{Path: "a.md", Frontmatter: map[string]interface{}{"status": "draft", "rating": 3}},
+		{Path: "b.md", Frontmatter: map[string]interface{}{"status": "done"}},
+		{Path: "c.md", Frontmatter: map[string]interface{}{"status": "draft", "tags": []interface{}{"x"}}},
+	}
+	analyzer := NewAnalyzer()
+
+	all := analyzer.NewFieldAccumulator()
+	named := analyzer.NewFieldAccumulator("status", "absent")
+	for _, file := range files {
+		all.Add(file)
+		named.Add(file)
+	}
+
+	analyses := all.Analyses()
+	require.Len(t, analyses, 3)
+	assert.Equal(t, []string{"rating", "status", "tags"}, []string{analyses[0].FieldName, analyses[1].FieldName, analyses[2].FieldName})
+	assert.Equal(t, 2, analyses[0].MissingCount)
+	assert.Equal(t, analyzer.AnalyzeField(files, "status"), analyses[1])
+
+	analyses = named.Analyses()
+	require.Len(t, analyses, 2)
+	assert.Equal(t, "status", analyses[0].FieldName)
+	assert.Equal(t, 2, analyses[0].UniqueValues)
+	assert.Equal(t, "absent", analyses[1].FieldName)
+	assert.Equal(t, 3, analyses[1].MissingCount)
+	assert.Equal(t, 0, analyses[1].TotalFiles)
+}
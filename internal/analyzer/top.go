@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// TopCriteria are the rankings TopFiles can produce, in report order
+var TopCriteria = []string{"size", "words", "age", "inbound", "outbound"}
+
+// RankedFile is a file and the value it was ranked by
+type RankedFile struct {
+	Path  string `json:"path"`
+	Value int64  `json:"value"` // bytes, words, days since modified or links
+}
+
+// TopFiles lists the files at the extremes of a vault, keyed by criterion
+type TopFiles map[string][]RankedFile
+
+// TopFiles ranks files by each of criteria (see TopCriteria) and returns the
+// first n of each ranking. Age is days since a file was last modified, and link
+// counts are of distinct notes linked to or from. Files' links must already be
+// parsed, as GenerateStats does.
+func (a *Analyzer) TopFiles(files []*vault.VaultFile, n int, criteria []string) (TopFiles, error) {
+	valid := make(map[string]bool, len(TopCriteria))
+	for _, criterion := range TopCriteria {
+		valid[criterion] = true
+	}
+	for _, criterion := range criteria {
+		if !valid[criterion] {
+			return nil, fmt.Errorf("unknown ranking '%s' (supported: %s)", criterion, strings.Join(TopCriteria, ", "))
+		}
+	}
+
+	graph := noteGraph(files)
+	inbound := make(map[string]int64)
+	for _, targets := range graph {
+		for _, target := range targets {
+			inbound[target]++
+		}
+	}
+
+	now := time.Now()
+	value := func(criterion string, file *vault.VaultFile) int64 {
+		relPath := filepath.ToSlash(file.RelativePath)
+		switch criterion {
+		case "size":
+			return int64(len(file.Content))
+		case "words":
+			return int64(len(strings.Fields(file.Body)))
+		case "age":
+			return int64(now.Sub(file.Modified).Hours() / 24)
+		case "inbound":
+			return inbound[relPath]
+		default:
+			return int64(len(graph[relPath]))
+		}
+	}
+
+	top := make(TopFiles, len(criteria))
+	for _, criterion := range criteria {
+		ranked := make([]RankedFile, 0, len(files))
+		modified := make(map[string]time.Time, len(files))
+		for _, file := range files {
+			relPath := filepath.ToSlash(file.RelativePath)
+			ranked = append(ranked, RankedFile{Path: relPath, Value: value(criterion, file)})
+			modified[relPath] = file.Modified
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			// Whole days hide the order of files modified on the same day
+			if criterion == "age" && !modified[ranked[i].Path].Equal(modified[ranked[j].Path]) {
+				return modified[ranked[i].Path].Before(modified[ranked[j].Path])
+			}
+			if ranked[i].Value != ranked[j].Value {
+				return ranked[i].Value > ranked[j].Value
+			}
+			return ranked[i].Path < ranked[j].Path
+		})
+		if len(ranked) > n {
+			ranked = ranked[:n]
+		}
+		top[criterion] = ranked
+	}
+	return top, nil
+}
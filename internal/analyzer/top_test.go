@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAnalyzer_TopFiles(t *testing.T) {
+	now := time.Now()
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md", Content: []byte("# Index\n\n[[a]] [[b]] [[a]] [[missing]]"), Body: "[[a]] [[b]] [[a]] [[missing]]", Modified: now,
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "a"}, {Type: vault.WikiLink, Target: "b"}, {Type: vault.WikiLink, Target: "a"}, {Type: vault.WikiLink, Target: "missing"}}},
+		{RelativePath: "a.md", Content: []byte("one two three four five"), Body: "one two three four five", Modified: now.Add(-30 * 24 * time.Hour),
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "b"}, {Type: vault.WikiLink, Target: "a"}}},
+		{RelativePath: "b.md", Content: []byte("short"), Body: "short", Modified: now.Add(-90 * 24 * time.Hour)},
+		{RelativePath: "c.md", Content: []byte("x"), Body: "x", Modified: now.Add(-time.Hour)},
+	}
+
+	top, err := NewAnalyzer().TopFiles(files, 2, TopCriteria)
+	require.NoError(t, err)
+
+	assert.Equal(t, []RankedFile{{"index.md", 38}, {"a.md", 23}}, top["size"])
+	assert.Equal(t, []RankedFile{{"a.md", 5}, {"index.md", 4}}, top["words"])
+	assert.Equal(t, []RankedFile{{"b.md", 90}, {"a.md", 30}}, top["age"])
+	// Repeated links, self links and broken links don't count
+	assert.Equal(t, []RankedFile{{"b.md", 2}, {"a.md", 1}}, top["inbound"])
+	assert.Equal(t, []RankedFile{{"index.md", 2}, {"a.md", 1}}, top["outbound"])
+
+	top, err = NewAnalyzer().TopFiles(files, 10, []string{"age"})
+	require.NoError(t, err)
+	assert.Len(t, top, 1)
+	assert.Equal(t, []string{"b.md", "a.md", "c.md", "index.md"}, []string{top["age"][0].Path, top["age"][1].Path, top["age"][2].Path, top["age"][3].Path})
+
+	_, err = NewAnalyzer().TopFiles(files, 2, []string{"popularity"})
+	assert.ErrorContains(t, err, "unknown ranking 'popularity'")
+}
@@ -0,0 +1,374 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Kinds of difference between the notes or fields of two vaults
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+	DiffRenamed = "renamed"
+)
+
+// FieldChange is a frontmatter field that differs between two versions of a note
+type FieldChange struct {
+	Field string      `json:"field"`
+	Kind  string      `json:"kind"` // added, removed or changed
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// NoteDiff is a note that differs between two vaults
+type NoteDiff struct {
+	Kind        string        `json:"kind"`
+	Path        string        `json:"path"`           // In the second vault, or the first for removed notes
+	From        string        `json:"from,omitempty"` // Path in the first vault, for renamed notes
+	Frontmatter []FieldChange `json:"frontmatter,omitempty"`
+	BodyChanged bool          `json:"body_changed,omitempty"`
+	WordsBefore int           `json:"words_before"`
+	WordsAfter  int           `json:"words_after"`
+}
+
+// VaultDiff is what differs between two vaults, or a vault and an export of it
+type VaultDiff struct {
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	Added        int         `json:"added"`
+	Removed      int         `json:"removed"`
+	Changed      int         `json:"changed"`
+	Renamed      int         `json:"renamed"`
+	Unchanged    int         `json:"unchanged"`
+	Notes        []NoteDiff  `json:"notes"`
+	LinksAdded   []GraphEdge `json:"links_added"`
+	LinksRemoved []GraphEdge `json:"links_removed"`
+	BrokenBefore int         `json:"broken_links_before"`
+	BrokenAfter  int         `json:"broken_links_after"`
+}
+
+// Empty reports whether the vaults hold the same notes and links
+func (d *VaultDiff) Empty() bool {
+	return len(d.Notes) == 0 && len(d.LinksAdded) == 0 && len(d.LinksRemoved) == 0
+}
+
+// DiffVaults compares the notes of two vaults by relative path. A note that
+// moved unchanged is reported as renamed rather than removed and added, and
+// links are compared after resolving them, so a link rewritten from wiki to
+// markdown style, or pointing at a renamed note, doesn't count as changed.
+// Links are parsed with the link parser, when one is set.
+func (a *Analyzer) DiffVaults(from, to []*vault.VaultFile, fromName, toName string) *VaultDiff {
+	for _, file := range from {
+		a.parseLinks(file)
+	}
+	for _, file := range to {
+		a.parseLinks(file)
+	}
+
+	d := &VaultDiff{
+		From:         fromName,
+		To:           toName,
+		Notes:        []NoteDiff{},
+		LinksAdded:   []GraphEdge{},
+		LinksRemoved: []GraphEdge{},
+	}
+
+	before := notesByPath(from)
+	after := notesByPath(to)
+
+	var removed, added []string
+	for relPath, old := range before {
+		file, ok := after[relPath]
+		if !ok {
+			removed = append(removed, relPath)
+			continue
+		}
+		note := CompareNotes(old, file)
+		if note.Kind == "" {
+			d.Unchanged++
+			continue
+		}
+		note.Path = relPath
+		d.Notes = append(d.Notes, note)
+		d.Changed++
+	}
+	for relPath := range after {
+		if _, ok := before[relPath]; !ok {
+			added = append(added, relPath)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	// Notes with identical content on both sides moved
+	addedByContent := make(map[string][]string)
+	for _, relPath := range added {
+		key := noteContentKey(after[relPath])
+		addedByContent[key] = append(addedByContent[key], relPath)
+	}
+	renamedTo := make(map[string]string) // path in the first vault -> path in the second
+	matched := make(map[string]bool)
+	for _, relPath := range removed {
+		key := noteContentKey(before[relPath])
+		if candidates := addedByContent[key]; len(candidates) > 0 {
+			addedByContent[key] = candidates[1:]
+			renamedTo[relPath] = candidates[0]
+			matched[candidates[0]] = true
+			words := len(strings.Fields(before[relPath].Body))
+			d.Notes = append(d.Notes, NoteDiff{Kind: DiffRenamed, Path: candidates[0], From: relPath, WordsBefore: words, WordsAfter: words})
+			d.Renamed++
+			continue
+		}
+		d.Notes = append(d.Notes, NoteDiff{Kind: DiffRemoved, Path: relPath, WordsBefore: len(strings.Fields(before[relPath].Body))})
+		d.Removed++
+	}
+	for _, relPath := range added {
+		if !matched[relPath] {
+			d.Notes = append(d.Notes, NoteDiff{Kind: DiffAdded, Path: relPath, WordsAfter: len(strings.Fields(after[relPath].Body))})
+			d.Added++
+		}
+	}
+	sort.Slice(d.Notes, func(i, j int) bool { return d.Notes[i].Path < d.Notes[j].Path })
+
+	// Compare link graphs in terms of the second vault's paths
+	rename := func(relPath string) string {
+		if to, ok := renamedTo[relPath]; ok {
+			return to
+		}
+		return relPath
+	}
+	oldEdges := make(map[GraphEdge]bool)
+	for source, targets := range noteGraph(from) {
+		for _, target := range targets {
+			oldEdges[GraphEdge{Source: rename(source), Target: rename(target)}] = true
+		}
+	}
+	newEdges := make(map[GraphEdge]bool)
+	for source, targets := range noteGraph(to) {
+		for _, target := range targets {
+			edge := GraphEdge{Source: source, Target: target}
+			newEdges[edge] = true
+			if !oldEdges[edge] {
+				d.LinksAdded = append(d.LinksAdded, edge)
+			}
+		}
+	}
+	for edge := range oldEdges {
+		if !newEdges[edge] {
+			d.LinksRemoved = append(d.LinksRemoved, edge)
+		}
+	}
+	sortEdges(d.LinksAdded)
+	sortEdges(d.LinksRemoved)
+
+	d.BrokenBefore = countBrokenLinks(from)
+	d.BrokenAfter = countBrokenLinks(to)
+	return d
+}
+
+// CompareNotes returns how file differs from old, with an empty Kind if it doesn't
+func CompareNotes(old, file *vault.VaultFile) NoteDiff {
+	note := NoteDiff{
+		WordsBefore: len(strings.Fields(old.Body)),
+		WordsAfter:  len(strings.Fields(file.Body)),
+		BodyChanged: strings.TrimRight(old.Body, "\n") != strings.TrimRight(file.Body, "\n"),
+	}
+
+	fields := make(map[string]bool, len(old.Frontmatter)+len(file.Frontmatter))
+	for field := range old.Frontmatter {
+		fields[field] = true
+	}
+	for field := range file.Frontmatter {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		oldValue, hadField := old.Frontmatter[field]
+		newValue, hasField := file.Frontmatter[field]
+		switch {
+		case !hadField:
+			note.Frontmatter = append(note.Frontmatter, FieldChange{Field: field, Kind: DiffAdded, New: newValue})
+		case !hasField:
+			note.Frontmatter = append(note.Frontmatter, FieldChange{Field: field, Kind: DiffRemoved, Old: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			note.Frontmatter = append(note.Frontmatter, FieldChange{Field: field, Kind: DiffChanged, Old: oldValue, New: newValue})
+		}
+	}
+
+	if note.BodyChanged || len(note.Frontmatter) > 0 {
+		note.Kind = DiffChanged
+	}
+	return note
+}
+
+// notesByPath indexes files by slash-separated relative path
+func notesByPath(files []*vault.VaultFile) map[string]*vault.VaultFile {
+	byPath := make(map[string]*vault.VaultFile, len(files))
+	for _, file := range files {
+		byPath[filepath.ToSlash(file.RelativePath)] = file
+	}
+	return byPath
+}
+
+// noteContentKey identifies a note's frontmatter and body, for spotting renames
+func noteContentKey(file *vault.VaultFile) string {
+	frontmatter, _ := json.Marshal(file.Frontmatter) // Map keys are sorted
+	return string(frontmatter) + "\x00" + strings.TrimRight(file.Body, "\n")
+}
+
+// countBrokenLinks counts links between notes that don't resolve to a note
+func countBrokenLinks(files []*vault.VaultFile) int {
+	resolver := newLinkResolver(files)
+	broken := 0
+	for _, file := range files {
+		for _, link := range file.Links {
+			if target, checked := resolver.resolve(file, link); checked && target == "" {
+				broken++
+			}
+		}
+	}
+	return broken
+}
+
+func sortEdges(edges []GraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+}
+
+// Text formats the difference for the terminal, with a line per note and
+// frontmatter field
+func (d *VaultDiff) Text() string {
+	var b strings.Builder
+	b.WriteString("Vault Diff\n==========\n\n")
+	fmt.Fprintf(&b, "From: %s\nTo:   %s\n\n", d.From, d.To)
+	fmt.Fprintf(&b, "Notes: %d added, %d removed, %d changed, %d renamed, %d unchanged\n",
+		d.Added, d.Removed, d.Changed, d.Renamed, d.Unchanged)
+
+	for _, note := range d.Notes {
+		switch note.Kind {
+		case DiffAdded:
+			fmt.Fprintf(&b, "  A %s\n", note.Path)
+		case DiffRemoved:
+			fmt.Fprintf(&b, "  D %s\n", note.Path)
+		case DiffRenamed:
+			fmt.Fprintf(&b, "  R %s → %s\n", note.From, note.Path)
+		case DiffChanged:
+			fmt.Fprintf(&b, "  M %s\n", note.Path)
+			for _, line := range note.details() {
+				fmt.Fprintf(&b, "      %s\n", line)
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "\nLinks: %d added, %d removed\n", len(d.LinksAdded), len(d.LinksRemoved))
+	for _, edge := range d.LinksAdded {
+		fmt.Fprintf(&b, "  + %s → %s\n", edge.Source, edge.Target)
+	}
+	for _, edge := range d.LinksRemoved {
+		fmt.Fprintf(&b, "  - %s → %s\n", edge.Source, edge.Target)
+	}
+	fmt.Fprintf(&b, "Broken links: %s\n", countChange(d.BrokenBefore, d.BrokenAfter))
+	return b.String()
+}
+
+// Markdown formats the difference as a note, in sections like a digest
+func (d *VaultDiff) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Vault diff\n\nFrom `%s` to `%s`: %d added, %d removed, %d changed, %d renamed, %d unchanged.\n",
+		d.From, d.To, d.Added, d.Removed, d.Changed, d.Renamed, d.Unchanged)
+
+	writeSection := func(title string, notes []NoteDiff, item func(note NoteDiff) string) {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", title, len(notes))
+		if len(notes) == 0 {
+			b.WriteString("None.\n")
+		}
+		for _, note := range notes {
+			b.WriteString("- " + item(note) + "\n")
+		}
+	}
+	byKind := func(kind string) []NoteDiff {
+		var notes []NoteDiff
+		for _, note := range d.Notes {
+			if note.Kind == kind {
+				notes = append(notes, note)
+			}
+		}
+		return notes
+	}
+
+	writeSection("Added notes", byKind(DiffAdded), func(note NoteDiff) string { return "`" + note.Path + "`" })
+	writeSection("Removed notes", byKind(DiffRemoved), func(note NoteDiff) string { return "`" + note.Path + "`" })
+	writeSection("Renamed notes", byKind(DiffRenamed), func(note NoteDiff) string {
+		return "`" + note.From + "` → `" + note.Path + "`"
+	})
+	writeSection("Changed notes", byKind(DiffChanged), func(note NoteDiff) string {
+		item := "`" + note.Path + "`"
+		for _, line := range note.details() {
+			item += "\n  - `" + line + "`"
+		}
+		return item
+	})
+
+	fmt.Fprintf(&b, "\n## Links\n\n%d added, %d removed; broken links %s.\n", len(d.LinksAdded), len(d.LinksRemoved), countChange(d.BrokenBefore, d.BrokenAfter))
+	if len(d.LinksAdded)+len(d.LinksRemoved) > 0 {
+		b.WriteString("\n")
+	}
+	for _, edge := range d.LinksAdded {
+		fmt.Fprintf(&b, "- Added `%s` → `%s`\n", edge.Source, edge.Target)
+	}
+	for _, edge := range d.LinksRemoved {
+		fmt.Fprintf(&b, "- Removed `%s` → `%s`\n", edge.Source, edge.Target)
+	}
+	return b.String()
+}
+
+// details describes a changed note's frontmatter and body changes
+func (n NoteDiff) details() []string {
+	var lines []string
+	for _, change := range n.Frontmatter {
+		switch change.Kind {
+		case DiffAdded:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", change.Field, formatFieldValue(change.New)))
+		case DiffRemoved:
+			lines = append(lines, fmt.Sprintf("- %s: %s", change.Field, formatFieldValue(change.Old)))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %s → %s", change.Field, formatFieldValue(change.Old), formatFieldValue(change.New)))
+		}
+	}
+	if n.BodyChanged {
+		lines = append(lines, fmt.Sprintf("body: %s words", countChange(n.WordsBefore, n.WordsAfter)))
+	}
+	return lines
+}
+
+// formatFieldValue shows a frontmatter value on one line
+func formatFieldValue(value interface{}) string {
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// countChange formats a before and after count, like "3 → 5 (+2)"
+func countChange(before, after int) string {
+	if before == after {
+		return fmt.Sprintf("%d", after)
+	}
+	return fmt.Sprintf("%d → %d (%+d)", before, after, after-before)
+}
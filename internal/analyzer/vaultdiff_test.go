@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAnalyzer_DiffVaults(t *testing.T) {
+	from := []*vault.VaultFile{
+		{RelativePath: "a.md", Frontmatter: map[string]interface{}{"status": "draft", "tags": []interface{}{"x"}},
+			Body:  "See [[b]] and [[c]].\n",
+			Links: []vault.Link{{Type: vault.WikiLink, Target: "b"}, {Type: vault.WikiLink, Target: "c"}}},
+		{RelativePath: "b.md", Body: "B note.\n"},
+		{RelativePath: "c.md", Body: "C note.\n"},
+		{RelativePath: "old.md", Body: "Gone.\n"},
+	}
+	to := []*vault.VaultFile{
+		{RelativePath: "a.md", Frontmatter: map[string]interface{}{"status": "done", "due": "2025-01-01"},
+			Body:  "See [b](sub/b.md) and [[missing]].\n",
+			Links: []vault.Link{{Type: vault.MarkdownLink, Target: "sub/b.md"}, {Type: vault.WikiLink, Target: "missing"}}},
+		{RelativePath: "sub/b.md", Body: "B note.\n"},
+		{RelativePath: "c.md", Body: "C note.\n"},
+		{RelativePath: "new.md", Body: "Fresh [[c]].\n", Links: []vault.Link{{Type: vault.WikiLink, Target: "c"}}},
+	}
+
+	// Links are already parsed, as the link parser would
+	d := NewAnalyzer().DiffVaults(from, to, "vault", "export")
+
+	assert.Equal(t, 1, d.Added)
+	assert.Equal(t, 1, d.Removed)
+	assert.Equal(t, 1, d.Changed)
+	assert.Equal(t, 1, d.Renamed)
+	assert.Equal(t, 1, d.Unchanged)
+
+	require.Len(t, d.Notes, 4)
+	assert.Equal(t, DiffChanged, d.Notes[0].Kind)
+	assert.Equal(t, []FieldChange{
+		{Field: "due", Kind: DiffAdded, New: "2025-01-01"},
+		{Field: "status", Kind: DiffChanged, Old: "draft", New: "done"},
+		{Field: "tags", Kind: DiffRemoved, Old: []interface{}{"x"}},
+	}, d.Notes[0].Frontmatter)
+	assert.True(t, d.Notes[0].BodyChanged)
+	assert.Equal(t, NoteDiff{Kind: DiffAdded, Path: "new.md", WordsAfter: 2}, d.Notes[1])
+	assert.Equal(t, NoteDiff{Kind: DiffRemoved, Path: "old.md", WordsBefore: 1}, d.Notes[2])
+	assert.Equal(t, NoteDiff{Kind: DiffRenamed, Path: "sub/b.md", From: "b.md", WordsBefore: 2, WordsAfter: 2}, d.Notes[3])
+
+	// The link to the renamed note is kept; the one to c is lost
+	assert.Equal(t, []GraphEdge{{Source: "new.md", Target: "c.md"}}, d.LinksAdded)
+	assert.Equal(t, []GraphEdge{{Source: "a.md", Target: "c.md"}}, d.LinksRemoved)
+	assert.Equal(t, 0, d.BrokenBefore)
+	assert.Equal(t, 1, d.BrokenAfter)
+	assert.False(t, d.Empty())
+
+	text := d.Text()
+	assert.Contains(t, text, "Notes: 1 added, 1 removed, 1 changed, 1 renamed, 1 unchanged")
+	assert.Contains(t, text, "  R b.md → sub/b.md\n")
+	assert.Contains(t, text, `~ status: "draft" → "done"`)
+	assert.Contains(t, text, "Broken links: 0 → 1 (+1)")
+
+	markdown := d.Markdown()
+	assert.Contains(t, markdown, "## Renamed notes (1)\n\n- `b.md` → `sub/b.md`\n")
+	assert.Contains(t, markdown, "- Removed `a.md` → `c.md`\n")
+}
+
+func TestAnalyzer_DiffVaultsIdentical(t *testing.T) {
+	files := func() []*vault.VaultFile {
+		return []*vault.VaultFile{
+			{RelativePath: "a.md", Frontmatter: map[string]interface{}{"tags": []interface{}{"x"}}, Body: "[[b]]\n",
+				Links: []vault.Link{{Type: vault.WikiLink, Target: "b"}}},
+			{RelativePath: "b.md", Body: "B\n"},
+		}
+	}
+
+	d := NewAnalyzer().DiffVaults(files(), files(), "a", "b")
+
+	assert.True(t, d.Empty())
+	assert.Equal(t, 2, d.Unchanged)
+	assert.Contains(t, d.Markdown(), "## Changed notes (0)\n\nNone.\n")
+}
@@ -0,0 +1,158 @@
+// Package anki turns notes into Anki flashcards: it finds question and answer
+// pairs and cloze deletions in a note's markdown, and writes them as a CSV
+// file for Anki's importer or as an .apkg package.
+package anki
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of card
+const (
+	BasicCard = "basic" // A heading as the question and its section as the answer
+	ClozeCard = "cloze" // A paragraph with {{c1::cloze}} deletions
+)
+
+// Card is a flashcard found in a note
+type Card struct {
+	Kind  string `json:"kind"`
+	Key   string `json:"key"`   // Identifies the card within its note: its heading, or cloze-N for the Nth cloze paragraph
+	Front string `json:"front"` // Markdown question, or the cloze paragraph
+	Back  string `json:"back"`  // Markdown answer; empty for cloze cards
+}
+
+var (
+	headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	fenceRegex   = regexp.MustCompile("^\\s*(```+|~~~+)")
+	clozeRegex   = regexp.MustCompile(`\{\{c(\d+)::`)
+)
+
+// ExtractCards finds the cards in a note's body. Each heading of
+// headingLevel with text under it is a basic card, its section down to the
+// next heading of the same or a higher level the answer. Each paragraph with
+// cloze deletions is a cloze card, and is left out of the answers it is in.
+func ExtractCards(body string, headingLevel int) []Card {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	var cards []Card
+	var heading string
+	var section []string
+	inSection := false
+	flush := func() {
+		if inSection {
+			if answer := strings.TrimSpace(strings.Join(withoutClozes(section), "\n")); answer != "" {
+				cards = append(cards, Card{Kind: BasicCard, Key: heading, Front: heading, Back: answer})
+			}
+		}
+		inSection = false
+		section = nil
+	}
+
+	fence := ""
+	var paragraph []string
+	clozes := 0
+	endParagraph := func() {
+		text := strings.TrimSpace(strings.Join(paragraph, "\n"))
+		paragraph = nil
+		if isCloze(text) {
+			clozes++
+			cards = append(cards, Card{Kind: ClozeCard, Key: "cloze-" + strconv.Itoa(clozes), Front: text})
+		}
+	}
+
+	for _, line := range lines {
+		if match := fenceRegex.FindStringSubmatch(line); match != nil {
+			endParagraph()
+			marker := strings.TrimSpace(match[1])[:3]
+			if fence == "" {
+				fence = marker
+			} else if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+		} else if fence == "" {
+			if match := headingRegex.FindStringSubmatch(line); match != nil {
+				endParagraph()
+				if level := len(match[1]); level <= headingLevel {
+					flush()
+					if level == headingLevel {
+						heading, inSection = match[2], true
+					}
+					continue
+				}
+			} else if strings.TrimSpace(line) == "" {
+				endParagraph()
+			} else {
+				paragraph = append(paragraph, line)
+			}
+		}
+		if inSection {
+			section = append(section, line)
+		}
+	}
+	endParagraph()
+	flush()
+	return cards
+}
+
+// withoutClozes drops the paragraphs with cloze deletions from lines
+func withoutClozes(lines []string) []string {
+	var kept, paragraph []string
+	fence := false
+	end := func() {
+		if !isCloze(strings.Join(paragraph, "\n")) {
+			kept = append(kept, paragraph...)
+		}
+		paragraph = nil
+	}
+	for _, line := range lines {
+		if fenceRegex.MatchString(line) {
+			end()
+			fence = !fence
+			kept = append(kept, line)
+			continue
+		}
+		if fence || strings.TrimSpace(line) == "" {
+			end()
+			kept = append(kept, line)
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	end()
+	return kept
+}
+
+// isCloze reports whether text has cloze deletions
+func isCloze(text string) bool {
+	return len(ClozeNumbers(text)) > 0
+}
+
+// ClozeNumbers returns the distinct cloze numbers in text, in order; each is
+// a card of the note
+func ClozeNumbers(text string) []int {
+	seen := make(map[int]bool)
+	var numbers []int
+	for _, match := range clozeRegex.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// GUID returns the Anki GUID of a card from its note's ID and its key.
+// Anki matches imported notes by GUID, so re-importing updates the cards
+// of earlier imports rather than duplicating them.
+func GUID(noteID, key string) string {
+	sum := sha1.Sum([]byte(noteID + "\x1f" + key))
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
@@ -0,0 +1,64 @@
+package anki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractCards(t *testing.T) {
+	body := `# Geography
+
+Intro text that isn't a card.
+
+## What is the capital of France?
+
+Paris.
+
+### Details
+
+On the Seine.
+
+## Empty heading
+
+## Rivers
+
+{{c1::The Seine}} flows through {{c2::Paris}}.
+
+The Loire is longer.
+
+` + "```" + `
+## Not a heading {{c1::in code}}
+` + "```" + `
+
+# Another top level
+
+{{c1::Berlin}} is the capital of Germany.
+`
+
+	cards := ExtractCards(body, 2)
+	assert.Equal(t, []Card{
+		{Kind: BasicCard, Key: "What is the capital of France?", Front: "What is the capital of France?", Back: "Paris.\n\n### Details\n\nOn the Seine."},
+		{Kind: ClozeCard, Key: "cloze-1", Front: "{{c1::The Seine}} flows through {{c2::Paris}}."},
+		{Kind: BasicCard, Key: "Rivers", Front: "Rivers", Back: "The Loire is longer.\n\n```\n## Not a heading {{c1::in code}}\n```"},
+		{Kind: ClozeCard, Key: "cloze-2", Front: "{{c1::Berlin}} is the capital of Germany."},
+	}, cards)
+
+	// Top-level headings as questions
+	cards = ExtractCards("# Term\n\nDefinition\n", 1)
+	assert.Equal(t, []Card{{Kind: BasicCard, Key: "Term", Front: "Term", Back: "Definition"}}, cards)
+
+	assert.Empty(t, ExtractCards("Just prose.\n", 2))
+}
+
+func TestClozeNumbers(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, ClozeNumbers("{{c3::a}} {{c1::b}} {{c2::c::hint}} {{c1::d}}"))
+	assert.Empty(t, ClozeNumbers("no clozes {{c0::zero}}"))
+}
+
+func TestGUID(t *testing.T) {
+	assert.Equal(t, GUID("note", "Question"), GUID("note", "Question"))
+	assert.NotEqual(t, GUID("note", "Question"), GUID("note", "Other"))
+	assert.NotEqual(t, GUID("note", "Question"), GUID("other", "Question"))
+	assert.Len(t, GUID("note", "Question"), 12)
+}
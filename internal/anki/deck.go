@@ -0,0 +1,263 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/preview"
+)
+
+// Entry is a card ready for Anki, its fields rendered as HTML
+type Entry struct {
+	GUID   string   `json:"guid"`
+	Kind   string   `json:"kind"`
+	Front  string   `json:"front"`
+	Back   string   `json:"back"`
+	Tags   []string `json:"tags,omitempty"`
+	Source string   `json:"source"` // Note the card came from, by relative path
+}
+
+var (
+	wikiEmbedRegex = regexp.MustCompile(`!\[\[([^\]|#]*)[^\]]*\]\]`)
+	wikiLinkRegex  = regexp.MustCompile(`\[\[([^\]|]*)(?:\|([^\]]*))?\]\]`)
+	htmlTagRegex   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// NewEntry prepares a card of the note with ID noteID for Anki. Tags are
+// nested with :: as Anki does rather than /.
+func NewEntry(noteID, source string, card Card, tags []string) Entry {
+	entry := Entry{
+		GUID:   GUID(noteID, card.Key),
+		Kind:   card.Kind,
+		Front:  renderField(card.Front),
+		Back:   renderField(card.Back),
+		Source: source,
+	}
+	for _, tag := range tags {
+		entry.Tags = append(entry.Tags, strings.ReplaceAll(strings.ReplaceAll(tag, "/", "::"), " ", "_"))
+	}
+	return entry
+}
+
+// renderField renders a card's markdown as HTML. Wiki links become their
+// text, as the notes they point to aren't in Anki.
+func renderField(markdown string) string {
+	if markdown == "" {
+		return ""
+	}
+	markdown = wikiEmbedRegex.ReplaceAllString(markdown, "$1")
+	markdown = wikiLinkRegex.ReplaceAllStringFunc(markdown, func(link string) string {
+		match := wikiLinkRegex.FindStringSubmatch(link)
+		if match[2] != "" {
+			return match[2]
+		}
+		return match[1]
+	})
+	return strings.TrimSpace(preview.RenderMarkdown(markdown))
+}
+
+// WriteCSV writes entries in the format of Anki's text importer: headers
+// naming the columns, then a row per card with its note type (Anki's
+// built-in Basic or Cloze), deck, GUID, fields and tags
+func WriteCSV(w io.Writer, deck string, entries []Entry) error {
+	header := "#separator:Comma\n#html:true\n#notetype column:1\n#deck column:2\n#guid column:3\n#tags column:6\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	for _, entry := range entries {
+		notetype := "Basic"
+		if entry.Kind == ClozeCard {
+			notetype = "Cloze"
+		}
+		if err := writer.Write([]string{notetype, deck, entry.GUID, entry.Front, entry.Back, strings.Join(entry.Tags, " ")}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// The schema of an Anki collection, version 11
+const (
+	colSchema    = `CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)`
+	notesSchema  = `CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)`
+	cardsSchema  = `CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null)`
+	revlogSchema = `CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null)`
+	gravesSchema = `CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)`
+)
+
+// WritePackage writes entries as an Anki package (.apkg) of one deck, with
+// note types of its own. Note, card, deck and note type IDs are derived from
+// GUIDs and names, so a package built from the same notes has the same IDs.
+func WritePackage(w io.Writer, deck string, entries []Entry, now time.Time) error {
+	deckID := stableID("deck\x1f" + deck)
+	basicID := stableID("model\x1fbasic")
+	clozeID := stableID("model\x1fcloze")
+
+	var notes, cards []sqliteRow
+	for i, entry := range entries {
+		noteID := stableID("note\x1f" + entry.GUID)
+		modelID, ords := basicID, []int{0}
+		if entry.Kind == ClozeCard {
+			modelID, ords = clozeID, nil
+			for _, n := range ClozeNumbers(entry.Front) {
+				ords = append(ords, n-1)
+			}
+		}
+		tags := ""
+		if len(entry.Tags) > 0 {
+			tags = " " + strings.Join(entry.Tags, " ") + " "
+		}
+		sortField := stripHTML(entry.Front)
+		notes = append(notes, sqliteRow{rowid: noteID, values: []interface{}{
+			nil, entry.GUID, modelID, now.Unix(), int64(-1), tags, entry.Front + "\x1f" + entry.Back,
+			sortField, fieldChecksum(sortField), int64(0), "",
+		}})
+		for _, ord := range ords {
+			cards = append(cards, sqliteRow{rowid: stableID("card\x1f" + entry.GUID + "\x1f" + strconv.Itoa(ord)), values: []interface{}{
+				nil, noteID, deckID, int64(ord), now.Unix(), int64(-1), int64(0), int64(0), int64(i + 1),
+				int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), "",
+			}})
+		}
+	}
+
+	col, err := collectionRow(deck, deckID, basicID, clozeID, len(entries), now)
+	if err != nil {
+		return err
+	}
+	var collection bytes.Buffer
+	err = writeSQLite(&collection, []sqliteTable{
+		{name: "col", sql: colSchema, rows: []sqliteRow{col}},
+		{name: "notes", sql: notesSchema, rows: notes},
+		{name: "cards", sql: cardsSchema, rows: cards},
+		{name: "revlog", sql: revlogSchema},
+		{name: "graves", sql: gravesSchema},
+	})
+	if err != nil {
+		return fmt.Errorf("writing collection: %w", err)
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{"collection.anki2", collection.Bytes()},
+		{"media", []byte("{}")},
+	} {
+		fw, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// collectionRow returns the collection's settings: its deck, the note types
+// and the default options
+func collectionRow(deck string, deckID, basicID, clozeID int64, count int, now time.Time) (sqliteRow, error) {
+	const css = ".card {\n font-family: arial;\n font-size: 20px;\n text-align: center;\n color: black;\n background-color: white;\n}\n"
+	field := func(name string, ord int) map[string]interface{} {
+		return map[string]interface{}{"name": name, "ord": ord, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []interface{}{}}
+	}
+	model := func(id int64, name string, kind int, fields []string, qfmt, afmt, css string) map[string]interface{} {
+		flds := make([]interface{}, len(fields))
+		for i, name := range fields {
+			flds[i] = field(name, i)
+		}
+		return map[string]interface{}{
+			"id": id, "name": name, "type": kind, "mod": now.Unix(), "usn": -1, "sortf": 0, "did": deckID,
+			"tmpls": []interface{}{map[string]interface{}{
+				"name": "Card 1", "ord": 0, "qfmt": qfmt, "afmt": afmt, "did": nil, "bqfmt": "", "bafmt": "",
+			}},
+			"flds":      flds,
+			"css":       css,
+			"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+			"latexPost": "\\end{document}",
+			"latexsvg":  false,
+			"req":       []interface{}{[]interface{}{0, "any", []int{0}}},
+			"tags":      []interface{}{},
+			"vers":      []interface{}{},
+		}
+	}
+	deckJSON := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "mod": now.Unix(), "usn": -1, "desc": "", "dyn": 0, "conf": 1, "collapsed": false, "browserCollapsed": false,
+			"lrnToday": []int{0, 0}, "revToday": []int{0, 0}, "newToday": []int{0, 0}, "timeToday": []int{0, 0}, "extendNew": 0, "extendRev": 0,
+		}
+	}
+
+	values := []interface{}{
+		map[string]interface{}{
+			"activeDecks": []int64{deckID}, "curDeck": deckID, "curModel": basicID, "nextPos": count + 1, "newSpread": 0,
+			"collapseTime": 1200, "timeLim": 0, "estTimes": true, "dueCounts": true, "sortType": "noteFld", "sortBackwards": false, "addToCur": true,
+		},
+		map[string]interface{}{
+			strconv.FormatInt(basicID, 10): model(basicID, "mdnotes Basic", 0, []string{"Front", "Back"},
+				"{{Front}}", "{{FrontSide}}\n\n<hr id=answer>\n\n{{Back}}", css),
+			strconv.FormatInt(clozeID, 10): model(clozeID, "mdnotes Cloze", 1, []string{"Text", "Back Extra"},
+				"{{cloze:Text}}", "{{cloze:Text}}<br>\n{{Back Extra}}", css+".cloze {\n font-weight: bold;\n color: blue;\n}\n"),
+		},
+		map[string]interface{}{
+			"1":                           deckJSON(1, "Default"),
+			strconv.FormatInt(deckID, 10): deckJSON(deckID, deck),
+		},
+		map[string]interface{}{
+			"1": map[string]interface{}{
+				"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+				"new":   map[string]interface{}{"bury": true, "delays": []int{1, 10}, "initialFactor": 2500, "ints": []int{1, 4, 7}, "order": 1, "perDay": 20, "separate": true},
+				"rev":   map[string]interface{}{"bury": true, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1, "maxIvl": 36500, "minSpace": 1, "perDay": 100},
+				"lapse": map[string]interface{}{"delays": []int{10}, "leechAction": 0, "leechFails": 8, "minInt": 1, "mult": 0},
+			},
+		},
+	}
+	encoded := make([]interface{}, len(values))
+	for i, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return sqliteRow{}, fmt.Errorf("encoding collection settings: %w", err)
+		}
+		encoded[i] = string(data)
+	}
+
+	year, month, day := now.Date()
+	created := time.Date(year, month, day, 0, 0, 0, 0, now.Location()).Unix()
+	return sqliteRow{rowid: 1, values: []interface{}{
+		nil, created, now.UnixMilli(), now.UnixMilli(), int64(11), int64(0), int64(0), int64(0),
+		encoded[0], encoded[1], encoded[2], encoded[3], "{}",
+	}}, nil
+}
+
+// stableID derives a positive ID below 2^53, which Anki's JavaScript can
+// hold, from s
+func stableID(s string) int64 {
+	sum := sha1.Sum([]byte(s))
+	return int64(binary.BigEndian.Uint64(sum[:8])>>12) + 1<<32
+}
+
+// stripHTML returns the text of an HTML field
+func stripHTML(field string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTagRegex.ReplaceAllString(field, "")))
+}
+
+// fieldChecksum is the checksum Anki keeps of a note's sort field to find
+// duplicates: the first eight hex digits of its SHA-1
+func fieldChecksum(text string) int64 {
+	sum := sha1.Sum([]byte(text))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
@@ -0,0 +1,96 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEntry(t *testing.T) {
+	card := Card{Kind: BasicCard, Key: "What is **Go**?", Front: "What is **Go**?", Back: "A language, see [[Go notes|my notes]] and [[Rob Pike]]."}
+	entry := NewEntry("id-1", "go.md", card, []string{"lang/go", "to review"})
+
+	assert.Equal(t, GUID("id-1", "What is **Go**?"), entry.GUID)
+	assert.Equal(t, "<p>What is <strong>Go</strong>?</p>", entry.Front)
+	assert.Equal(t, "<p>A language, see my notes and Rob Pike.</p>", entry.Back)
+	assert.Equal(t, []string{"lang::go", "to_review"}, entry.Tags)
+	assert.Equal(t, "go.md", entry.Source)
+}
+
+func TestWriteCSV(t *testing.T) {
+	entries := []Entry{
+		{GUID: "abc", Kind: BasicCard, Front: "<p>Q</p>", Back: "<p>A, \"quoted\"</p>", Tags: []string{"x", "y"}},
+		{GUID: "def", Kind: ClozeCard, Front: "<p>{{c1::Paris}} is in France</p>"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, "Geo", entries))
+	assert.Equal(t, "#separator:Comma\n#html:true\n#notetype column:1\n#deck column:2\n#guid column:3\n#tags column:6\n"+
+		"Basic,Geo,abc,<p>Q</p>,\"<p>A, \"\"quoted\"\"</p>\",x y\n"+
+		"Cloze,Geo,def,<p>{{c1::Paris}} is in France</p>,,\n", buf.String())
+}
+
+func TestWritePackage(t *testing.T) {
+	entries := []Entry{
+		{GUID: "basic", Kind: BasicCard, Front: "<p>Capital of France?</p>", Back: "<p>Paris</p>", Tags: []string{"geo"}},
+		{GUID: "cloze", Kind: ClozeCard, Front: "<p>{{c1::Paris}} is on the {{c2::Seine}}</p>"},
+	}
+	// Enough long notes to need several pages, interior pages and overflow
+	for i := 0; i < 300; i++ {
+		entries = append(entries, Entry{GUID: "long" + string(rune('a'+i%26)) + strings.Repeat("x", i), Kind: BasicCard,
+			Front: "<p>Question</p>", Back: strings.Repeat("answer ", 20*(i%40))})
+	}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePackage(&buf, "Geography", entries, now))
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "{}", string(files["media"]))
+	collection := files["collection.anki2"]
+	require.NotEmpty(t, collection)
+	assert.Equal(t, "SQLite format 3\x00", string(collection[:16]))
+	assert.Zero(t, len(collection)%sqlitePageSize)
+
+	// The same notes make the same package
+	var again bytes.Buffer
+	require.NoError(t, WritePackage(&again, "Geography", entries, now))
+	assert.Equal(t, buf.Bytes(), again.Bytes())
+
+	sqlite, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 not available")
+	}
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	require.NoError(t, os.WriteFile(path, collection, 0644))
+	out, err := exec.Command(sqlite, path,
+		"PRAGMA integrity_check;",
+		"SELECT count(*) FROM notes;",
+		"SELECT count(*) FROM cards;",
+		"SELECT group_concat(ord) FROM cards WHERE nid = (SELECT id FROM notes WHERE guid = 'cloze');",
+		"SELECT replace(flds, char(31), '|') FROM notes WHERE guid = 'basic';",
+		"SELECT sum(length(flds)) FROM notes;",
+		"SELECT ver FROM col;").CombinedOutput()
+	require.NoError(t, err, string(out))
+	total := 0
+	for _, entry := range entries {
+		total += len(entry.Front) + 1 + len(entry.Back)
+	}
+	assert.Equal(t, fmt.Sprintf("ok\n302\n303\n0,1\n<p>Capital of France?</p>|<p>Paris</p>\n%d\n11\n", total), string(out))
+}
@@ -0,0 +1,318 @@
+package anki
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// The SQLite database file format, as much of it as writing a new database
+// of rowid tables takes: https://www.sqlite.org/fileformat2.html
+
+// sqlitePageSize is the size of each database page
+const sqlitePageSize = 4096
+
+// Page types
+const (
+	interiorTablePage = 0x05
+	leafTablePage     = 0x0d
+)
+
+// sqliteTable is a rowid table written to a new database
+type sqliteTable struct {
+	name string
+	sql  string // CREATE TABLE statement
+	rows []sqliteRow
+}
+
+// sqliteRow is a table row. Values are int64, string or nil; an INTEGER
+// PRIMARY KEY column is nil, its value being the rowid.
+type sqliteRow struct {
+	rowid  int64
+	values []interface{}
+}
+
+// sqliteWriter lays out the pages of a database
+type sqliteWriter struct {
+	pages [][]byte
+}
+
+// writeSQLite writes a database holding tables to w
+func writeSQLite(w io.Writer, tables []sqliteTable) error {
+	sw := &sqliteWriter{}
+	sw.newPage() // The schema table, on the first page
+
+	schema := make([]sqliteRow, len(tables))
+	for i, table := range tables {
+		root, err := sw.writeTable(table.rows)
+		if err != nil {
+			return fmt.Errorf("writing table %s: %w", table.name, err)
+		}
+		schema[i] = sqliteRow{rowid: int64(i + 1), values: []interface{}{"table", table.name, table.name, int64(root), table.sql}}
+	}
+
+	cells := make([][]byte, len(schema))
+	for i, row := range schema {
+		cells[i] = sw.leafCell(row)
+	}
+	if !sw.fillLeaf(sw.pages[0], 100, cells) {
+		return fmt.Errorf("database schema does not fit on the first page")
+	}
+	sw.writeHeader()
+
+	for _, page := range sw.pages {
+		if _, err := w.Write(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPage adds a page, returning its number
+func (sw *sqliteWriter) newPage() int {
+	sw.pages = append(sw.pages, make([]byte, sqlitePageSize))
+	return len(sw.pages)
+}
+
+// writeHeader fills in the database header at the start of the first page
+func (sw *sqliteWriter) writeHeader() {
+	header := sw.pages[0][:100]
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], sqlitePageSize)
+	header[18], header[19] = 1, 1                                  // Legacy journal mode
+	header[21], header[22], header[23] = 64, 32, 32                // Payload fractions
+	binary.BigEndian.PutUint32(header[24:], 1)                     // File change counter
+	binary.BigEndian.PutUint32(header[28:], uint32(len(sw.pages))) // Pages
+	binary.BigEndian.PutUint32(header[40:], 1)                     // Schema cookie
+	binary.BigEndian.PutUint32(header[44:], 4)                     // Schema format
+	binary.BigEndian.PutUint32(header[56:], 1)                     // UTF-8
+	binary.BigEndian.PutUint32(header[92:], 1)                     // Version valid for
+	binary.BigEndian.PutUint32(header[96:], 3040001)               // SQLite version
+}
+
+// writeTable writes the b-tree of a table's rows, returning its root page
+func (sw *sqliteWriter) writeTable(rows []sqliteRow) (int, error) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].rowid < rows[j].rowid })
+	for i := 1; i < len(rows); i++ {
+		if rows[i].rowid == rows[i-1].rowid {
+			return 0, fmt.Errorf("duplicate rowid %d", rows[i].rowid)
+		}
+	}
+
+	// Fill leaf pages in rowid order
+	type child struct {
+		page   int
+		maxKey int64
+	}
+	var children []child
+	var cells [][]byte
+	used := 0
+	for i, row := range rows {
+		cell := sw.leafCell(row)
+		if len(cells) > 0 && 8+2*(len(cells)+1)+used+len(cell) > sqlitePageSize {
+			page := sw.newPage()
+			sw.fillLeaf(sw.pages[page-1], 0, cells)
+			children = append(children, child{page, rows[i-1].rowid})
+			cells, used = nil, 0
+		}
+		cells = append(cells, cell)
+		used += len(cell)
+	}
+	page := sw.newPage()
+	sw.fillLeaf(sw.pages[page-1], 0, cells)
+	if len(rows) > 0 {
+		children = append(children, child{page, rows[len(rows)-1].rowid})
+	}
+	if len(children) <= 1 {
+		return page, nil
+	}
+
+	// Interior pages point to up to a page of children each, level by level
+	for len(children) > 1 {
+		var parents []child
+		for start := 0; start < len(children); {
+			end := start + 1
+			size := 12
+			for end < len(children) {
+				cell := interiorCell(children[end-1].page, children[end-1].maxKey)
+				if size+2+len(cell) > sqlitePageSize {
+					break
+				}
+				size += 2 + len(cell)
+				end++
+			}
+			group := children[start:end]
+			page := sw.newPage()
+			buf := sw.pages[page-1]
+			cells := make([][]byte, len(group)-1)
+			for i, c := range group[:len(group)-1] {
+				cells[i] = interiorCell(c.page, c.maxKey)
+			}
+			writeCells(buf, 0, interiorTablePage, cells)
+			binary.BigEndian.PutUint32(buf[8:], uint32(group[len(group)-1].page))
+			parents = append(parents, child{page, group[len(group)-1].maxKey})
+			start = end
+		}
+		children = parents
+	}
+	return children[0].page, nil
+}
+
+// leafCell encodes a row as a table leaf cell, spilling a payload too large
+// for the page onto overflow pages
+func (sw *sqliteWriter) leafCell(row sqliteRow) []byte {
+	payload := encodeRecord(row.values)
+	cell := append(putVarint(uint64(len(payload))), putVarint(uint64(row.rowid))...)
+
+	usable := sqlitePageSize
+	maxLocal := usable - 35
+	if len(payload) <= maxLocal {
+		return append(cell, payload...)
+	}
+	minLocal := (usable-12)*32/255 - 23
+	local := minLocal + (len(payload)-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	cell = append(cell, payload[:local]...)
+
+	rest := payload[local:]
+	firstPage := 0
+	var previous []byte
+	for len(rest) > 0 {
+		page := sw.newPage()
+		buf := sw.pages[page-1]
+		if previous == nil {
+			firstPage = page
+		} else {
+			binary.BigEndian.PutUint32(previous, uint32(page))
+		}
+		n := copy(buf[4:], rest)
+		rest = rest[n:]
+		previous = buf[:4]
+	}
+	return binary.BigEndian.AppendUint32(cell, uint32(firstPage))
+}
+
+// fillLeaf writes cells to a leaf page whose header starts at offset,
+// reporting whether they fit
+func (sw *sqliteWriter) fillLeaf(page []byte, offset int, cells [][]byte) bool {
+	size := offset + 8
+	for _, cell := range cells {
+		size += 2 + len(cell)
+	}
+	if size > len(page) {
+		return false
+	}
+	writeCells(page, offset, leafTablePage, cells)
+	return true
+}
+
+// writeCells writes a b-tree page header at offset, the cell pointers after
+// it and the cells from the end of the page
+func writeCells(page []byte, offset int, pageType byte, cells [][]byte) {
+	headerSize := 8
+	if pageType == interiorTablePage {
+		headerSize = 12
+	}
+	content := len(page)
+	pointers := offset + headerSize
+	for i, cell := range cells {
+		content -= len(cell)
+		copy(page[content:], cell)
+		binary.BigEndian.PutUint16(page[pointers+2*i:], uint16(content))
+	}
+	page[offset] = pageType
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(content))
+}
+
+// interiorCell points to a child page whose largest rowid is maxKey
+func interiorCell(page int, maxKey int64) []byte {
+	cell := binary.BigEndian.AppendUint32(nil, uint32(page))
+	return append(cell, putVarint(uint64(maxKey))...)
+}
+
+// encodeRecord encodes values in the record format
+func encodeRecord(values []interface{}) []byte {
+	var types, body []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			types = append(types, 0)
+		case int64:
+			serial, data := encodeInt(v)
+			types = append(types, putVarint(serial)...)
+			body = append(body, data...)
+		case string:
+			types = append(types, putVarint(uint64(2*len(v)+13))...)
+			body = append(body, v...)
+		default:
+			panic(fmt.Sprintf("unsupported SQLite value %T", value))
+		}
+	}
+
+	// The header size counts its own varint
+	headerSize := len(types) + 1
+	for len(putVarint(uint64(headerSize)))+len(types) != headerSize {
+		headerSize = len(putVarint(uint64(headerSize))) + len(types)
+	}
+	record := append(putVarint(uint64(headerSize)), types...)
+	return append(record, body...)
+}
+
+// encodeInt returns the serial type and big-endian bytes of an integer in
+// the fewest bytes that hold it
+func encodeInt(v int64) (uint64, []byte) {
+	switch {
+	case v == 0:
+		return 8, nil
+	case v == 1:
+		return 9, nil
+	case v >= -1<<7 && v < 1<<7:
+		return 1, []byte{byte(v)}
+	case v >= -1<<15 && v < 1<<15:
+		return 2, binary.BigEndian.AppendUint16(nil, uint16(v))
+	case v >= -1<<23 && v < 1<<23:
+		return 3, []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	case v >= -1<<31 && v < 1<<31:
+		return 4, binary.BigEndian.AppendUint32(nil, uint32(v))
+	case v >= -1<<47 && v < 1<<47:
+		return 5, binary.BigEndian.AppendUint64(nil, uint64(v))[2:]
+	default:
+		return 6, binary.BigEndian.AppendUint64(nil, uint64(v))
+	}
+}
+
+// putVarint encodes v as a SQLite varint: big-endian groups of seven bits,
+// with a ninth byte of eight bits for the largest values
+func putVarint(v uint64) []byte {
+	if v > 1<<56-1 {
+		buf := make([]byte, 9)
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return buf
+	}
+
+	var groups []byte
+	for {
+		groups = append(groups, byte(v&0x7f))
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	buf := make([]byte, len(groups))
+	for i := range groups {
+		buf[i] = groups[len(groups)-1-i]
+		if i < len(groups)-1 {
+			buf[i] |= 0x80
+		}
+	}
+	return buf
+}
@@ -0,0 +1,31 @@
+package anki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutVarint(t *testing.T) {
+	tests := map[uint64][]byte{
+		0:          {0x00},
+		0x7f:       {0x7f},
+		0x80:       {0x81, 0x00},
+		0x3fff:     {0xff, 0x7f},
+		0x4000:     {0x81, 0x80, 0x00},
+		1<<56 - 1:  {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+		1 << 56:    {0x80, 0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00},
+		1<<64 - 1:  {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		0x12345678: {0x81, 0x91, 0xd1, 0xac, 0x78},
+	}
+	for value, want := range tests {
+		assert.Equal(t, want, putVarint(value), "%#x", value)
+	}
+}
+
+func TestEncodeRecord(t *testing.T) {
+	// Header size 6 (counting itself), then NULL, 1, a one-byte int, a 2-byte int and "hi"
+	assert.Equal(t, []byte{6, 0, 9, 1, 2, 17, 0xfe, 0x01, 0x00, 'h', 'i'},
+		encodeRecord([]interface{}{nil, int64(1), int64(-2), int64(256), "hi"}))
+	assert.Equal(t, []byte{2, 8}, encodeRecord([]interface{}{int64(0)}))
+}
@@ -0,0 +1,148 @@
+// Package archive snapshots web pages so links keep working after the
+// pages change or disappear, with the Internet Archive's Wayback Machine or
+// a self-hosted ArchiveBox.
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Provider names
+const (
+	ProviderWayback    = "wayback"
+	ProviderArchiveBox = "archivebox"
+)
+
+// Default endpoints and request rates, in requests a minute, for each provider
+const (
+	DefaultWaybackURL     = "https://web.archive.org"
+	DefaultWaybackRate    = 12
+	DefaultArchiveBoxURL  = "http://localhost:8000"
+	DefaultArchiveBoxRate = 60
+)
+
+// ArchiveBoxTokenEnvVar holds the ArchiveBox API key when none is configured
+const ArchiveBoxTokenEnvVar = "ARCHIVEBOX_API_KEY"
+
+const (
+	// waybackTimestampFormat is how Wayback capture times are written
+	waybackTimestampFormat = "20060102150405"
+	// maxErrorBody is how much of an error response is quoted in messages
+	maxErrorBody = 200
+)
+
+// Snapshot is an archived copy of a page
+type Snapshot struct {
+	URL      string    // Where the copy can be viewed
+	Archived time.Time // When the copy was taken
+}
+
+// Provider looks up and takes snapshots of pages
+type Provider interface {
+	// Lookup returns the latest snapshot of url, or nil when there is none
+	Lookup(ctx context.Context, url string) (*Snapshot, error)
+	// Save takes a new snapshot of url
+	Save(ctx context.Context, url string) (*Snapshot, error)
+	// Name identifies the provider in messages
+	Name() string
+}
+
+// Options configure a provider. Empty fields take the provider's defaults.
+type Options struct {
+	Provider   string
+	APIURL     string
+	APIToken   string
+	RateLimit  int // Requests a minute
+	HTTPClient *http.Client
+}
+
+// Providers lists the supported provider names
+func Providers() []string {
+	return []string{ProviderWayback, ProviderArchiveBox}
+}
+
+// NewProvider creates the provider named in opts, defaulting to the Wayback
+// Machine. Requests are spaced out to stay within its rate limit.
+func NewProvider(opts Options) (Provider, error) {
+	client := opts.HTTPClient
+	if client == nil {
+		// Save Page Now can take a minute to capture a page
+		client = &http.Client{Timeout: 2 * time.Minute}
+	}
+
+	switch opts.Provider {
+	case "", ProviderWayback:
+		return &wayback{
+			baseURL: strings.TrimRight(orDefault(opts.APIURL, DefaultWaybackURL), "/"),
+			client:  client,
+			limiter: newLimiter(opts.RateLimit, DefaultWaybackRate),
+		}, nil
+	case ProviderArchiveBox:
+		return &archiveBox{
+			baseURL: strings.TrimRight(orDefault(opts.APIURL, DefaultArchiveBoxURL), "/"),
+			token:   opts.APIToken,
+			client:  client,
+			limiter: newLimiter(opts.RateLimit, DefaultArchiveBoxRate),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive provider %q (use %s)", opts.Provider, strings.Join(Providers(), ", "))
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// newLimiter allows perMinute requests a minute, or fallback when that isn't set
+func newLimiter(perMinute, fallback int) *rate.Limiter {
+	if perMinute <= 0 {
+		perMinute = fallback
+	}
+	return rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), 1)
+}
+
+// do sends a request once the limiter allows it, decoding a JSON response
+// into out when it is not nil. The response is returned with its body read.
+func do(limiter *rate.Limiter, client *http.Client, req *http.Request, out interface{}) (*http.Response, error) {
+	if err := limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests:
+			return resp, fmt.Errorf("rate limited: too many requests, try a lower rate_limit")
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return resp, fmt.Errorf("authentication error: %s", resp.Status)
+		}
+		if msg := strings.TrimSpace(string(body)); msg != "" && !strings.HasPrefix(msg, "<") {
+			return resp, fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return resp, fmt.Errorf("%s", resp.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("decoding response: %w", err)
+		}
+	} else {
+		_, _ = io.Copy(io.Discard, resp.Body)
+	}
+	return resp, nil
+}
@@ -0,0 +1,149 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	provider, err := NewProvider(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderWayback, provider.Name())
+
+	provider, err = NewProvider(Options{Provider: ProviderArchiveBox})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderArchiveBox, provider.Name())
+
+	_, err = NewProvider(Options{Provider: "archive.today"})
+	assert.ErrorContains(t, err, "unknown archive provider")
+}
+
+func TestWayback(t *testing.T) {
+	saved := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cdx/search/cdx":
+			assert.Equal(t, "json", r.URL.Query().Get("output"))
+			if r.URL.Query().Get("url") == "https://example.com/gone" || !saved {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			_, _ = w.Write([]byte(`[["timestamp","original"],["20240301120000","https://example.com/post"]]`))
+		case r.URL.Path == "/save/https://example.com/post":
+			saved = true
+			w.Header().Set("Content-Location", "/web/20240302090000/https://example.com/post")
+			_, _ = w.Write([]byte("<html>captured</html>"))
+		case r.URL.Path == "/save/https://example.com/redirected":
+			http.Redirect(w, r, "/web/20240303090000/https://example.com/redirected", http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, "/web/20240303090000/"):
+			// The redirect collapses the URL's double slash
+			_, _ = w.Write([]byte("<html>captured</html>"))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(Options{APIURL: server.URL, RateLimit: 6000})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	snapshot, err := provider.Lookup(ctx, "https://example.com/post")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	snapshot, err = provider.Save(ctx, "https://example.com/post")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/web/20240302090000/https://example.com/post", snapshot.URL)
+	assert.Equal(t, time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC), snapshot.Archived)
+
+	snapshot, err = provider.Lookup(ctx, "https://example.com/post")
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, server.URL+"/web/20240301120000/https://example.com/post", snapshot.URL)
+
+	// Redirected to the capture
+	snapshot, err = provider.Save(ctx, "https://example.com/redirected")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/web/20240303090000/https://example.com/redirected", snapshot.URL)
+
+	_, err = provider.Save(ctx, "https://example.com/gone")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestParseWaybackPath(t *testing.T) {
+	timestamp, original, ok := parseWaybackPath("/web/20240301120000/https://example.com/a?b=c")
+	require.True(t, ok)
+	assert.Equal(t, "20240301120000", timestamp)
+	assert.Equal(t, "https://example.com/a?b=c", original)
+
+	_, original, ok = parseWaybackPath("https://web.archive.org/web/20240301120000/https:/example.com/")
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com/", original)
+
+	_, _, ok = parseWaybackPath("/save/https://example.com/")
+	assert.False(t, ok)
+	_, _, ok = parseWaybackPath("/web/2024/https://example.com/")
+	assert.False(t, ok)
+}
+
+func TestArchiveBox(t *testing.T) {
+	var snapshots []archiveBoxSnapshot
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-ArchiveBox-API-Key") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/core/snapshots":
+			var items []archiveBoxSnapshot
+			for _, snapshot := range snapshots {
+				if snapshot.URL == r.URL.Query().Get("url") {
+					items = append(items, snapshot)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"count": len(items), "items": items})
+		case "/api/v1/cli/add":
+			assert.Equal(t, http.MethodPost, r.Method)
+			var body struct {
+				URLs  []string `json:"urls"`
+				Depth int      `json:"depth"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, 0, body.Depth)
+			for _, u := range body.URLs {
+				snapshots = append(snapshots, archiveBoxSnapshot{URL: u, Timestamp: "1709294400.5"})
+			}
+			_, _ = w.Write([]byte(`{"success": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(Options{Provider: ProviderArchiveBox, APIURL: server.URL + "/", APIToken: "secret", RateLimit: 6000})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	snapshot, err := provider.Lookup(ctx, "https://example.com/post")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	snapshot, err = provider.Save(ctx, "https://example.com/post")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/archive/1709294400.5/", snapshot.URL)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 500000000, time.UTC), snapshot.Archived)
+
+	unauthorized, err := NewProvider(Options{Provider: ProviderArchiveBox, APIURL: server.URL, APIToken: "wrong", RateLimit: 6000})
+	require.NoError(t, err)
+	_, err = unauthorized.Lookup(ctx, "https://example.com/post")
+	assert.ErrorContains(t, err, "authentication error")
+}
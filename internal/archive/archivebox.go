@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// archiveBox archives pages with a self-hosted ArchiveBox through its REST
+// API, available from version 0.8
+type archiveBox struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+// archiveBoxSnapshot is a snapshot as the API lists it
+type archiveBoxSnapshot struct {
+	URL       string `json:"url"`
+	Timestamp string `json:"timestamp"` // Unix seconds, which also names the snapshot folder
+}
+
+func (a *archiveBox) Name() string {
+	return ProviderArchiveBox
+}
+
+func (a *archiveBox) Lookup(ctx context.Context, pageURL string) (*Snapshot, error) {
+	query := url.Values{}
+	query.Set("url", pageURL)
+	req, err := a.request(ctx, http.MethodGet, "/api/v1/core/snapshots?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var page struct {
+		Items []archiveBoxSnapshot `json:"items"`
+	}
+	if _, err := do(a.limiter, a.client, req, &page); err != nil {
+		return nil, fmt.Errorf("archivebox: looking up %s: %w", pageURL, err)
+	}
+
+	var latest *Snapshot
+	for _, item := range page.Items {
+		if item.URL != pageURL {
+			continue
+		}
+		snapshot, err := a.snapshot(item)
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil || snapshot.Archived.After(latest.Archived) {
+			latest = snapshot
+		}
+	}
+	return latest, nil
+}
+
+func (a *archiveBox) Save(ctx context.Context, pageURL string) (*Snapshot, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"urls":   []string{pageURL},
+		"depth":  0,
+		"update": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := a.request(ctx, http.MethodPost, "/api/v1/cli/add", body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := do(a.limiter, a.client, req, nil); err != nil {
+		return nil, fmt.Errorf("archivebox: saving %s: %w", pageURL, err)
+	}
+
+	// Adding answers with the command's output, so the snapshot is looked up
+	snapshot, err := a.Lookup(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("archivebox: saving %s: no snapshot was made", pageURL)
+	}
+	return snapshot, nil
+}
+
+// request builds an API request, authenticated with the configured API key
+// or ARCHIVEBOX_API_KEY
+func (a *archiveBox) request(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	token := a.token
+	if token == "" {
+		token = os.Getenv(ArchiveBoxTokenEnvVar)
+	}
+	if token != "" {
+		req.Header.Set("X-ArchiveBox-API-Key", token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// snapshot converts a listed snapshot, whose pages are served under
+// /archive/<timestamp>/
+func (a *archiveBox) snapshot(item archiveBoxSnapshot) (*Snapshot, error) {
+	seconds, err := strconv.ParseFloat(item.Timestamp, 64)
+	if err != nil {
+		return nil, fmt.Errorf("archivebox: unexpected snapshot timestamp %q", item.Timestamp)
+	}
+	return &Snapshot{
+		URL:      fmt.Sprintf("%s/archive/%s/", a.baseURL, item.Timestamp),
+		Archived: time.Unix(0, int64(seconds*float64(time.Second))).UTC(),
+	}, nil
+}
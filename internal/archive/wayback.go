@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// wayback archives pages with the Internet Archive's Wayback Machine, looking
+// snapshots up with its CDX API and taking them with Save Page Now
+type wayback struct {
+	baseURL string
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+func (w *wayback) Name() string {
+	return ProviderWayback
+}
+
+func (w *wayback) Lookup(ctx context.Context, pageURL string) (*Snapshot, error) {
+	query := url.Values{}
+	query.Set("url", pageURL)
+	query.Set("output", "json")
+	query.Set("fl", "timestamp,original")
+	query.Set("filter", "statuscode:200")
+	query.Set("limit", "-1") // The latest capture only
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.baseURL+"/cdx/search/cdx?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	// A header row, then a row per capture
+	var rows [][]string
+	if _, err := do(w.limiter, w.client, req, &rows); err != nil {
+		return nil, fmt.Errorf("wayback: looking up %s: %w", pageURL, err)
+	}
+	if len(rows) < 2 || len(rows[len(rows)-1]) < 2 {
+		return nil, nil
+	}
+	latest := rows[len(rows)-1]
+	return w.snapshot(latest[0], latest[1])
+}
+
+func (w *wayback) Save(ctx context.Context, pageURL string) (*Snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.baseURL+"/save/"+pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := do(w.limiter, w.client, req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wayback: saving %s: %w", pageURL, err)
+	}
+
+	// Save Page Now answers with the capture, naming it in Content-Location,
+	// or redirects to it
+	for _, location := range []string{resp.Header.Get("Content-Location"), resp.Request.URL.Path} {
+		if timestamp, original, ok := parseWaybackPath(location); ok {
+			return w.snapshot(timestamp, original)
+		}
+	}
+
+	// Otherwise the capture is found the same way as any other
+	snapshot, err := w.Lookup(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("wayback: saving %s: no capture was made", pageURL)
+	}
+	return snapshot, nil
+}
+
+// snapshot builds the snapshot captured at a Wayback timestamp
+func (w *wayback) snapshot(timestamp, original string) (*Snapshot, error) {
+	archived, err := time.Parse(waybackTimestampFormat, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("wayback: unexpected capture timestamp %q", timestamp)
+	}
+	return &Snapshot{
+		URL:      fmt.Sprintf("%s/web/%s/%s", w.baseURL, timestamp, original),
+		Archived: archived,
+	}, nil
+}
+
+// parseWaybackPath splits a capture path, /web/<timestamp>/<url>, into its
+// timestamp and URL
+func parseWaybackPath(location string) (timestamp, original string, ok bool) {
+	if u, err := url.Parse(location); err == nil && u.IsAbs() {
+		location = u.Path
+	}
+	rest, ok := strings.CutPrefix(location, "/web/")
+	if !ok {
+		return "", "", false
+	}
+	timestamp, original, ok = strings.Cut(rest, "/")
+	if !ok || len(timestamp) != len(waybackTimestampFormat) || original == "" {
+		return "", "", false
+	}
+	// Paths collapse the double slash of the URL's scheme
+	if !strings.Contains(original, "://") {
+		original = strings.Replace(original, ":/", "://", 1)
+	}
+	return timestamp, original, true
+}
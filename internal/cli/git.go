@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/git"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// gitCommitTrailer links a commit to the transaction that made it, for
+// 'mdnotes undo <id>'
+const gitCommitTrailer = "Mdnotes-Transaction"
+
+// commitToGit commits the files a transaction touched when the command ran
+// with --git-commit. Its messages go to stderr, since commands like mcp use
+// stdout for their protocol.
+func commitToGit(cmd *cobra.Command, tx *safety.Transaction) {
+	if enabled, _ := cmd.Flags().GetBool("git-commit"); !enabled {
+		return
+	}
+	quiet, _ := cmd.Flags().GetBool("quiet")
+
+	hash, err := git.CommitFiles(tx.Root(), transactionPaths(tx), gitCommitMessage(tx))
+	switch {
+	case err != nil:
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: changes were not committed to git: %v\n", err)
+	case hash == "":
+		if !quiet {
+			_, _ = fmt.Fprintln(os.Stderr, "No changes to commit to git")
+		}
+	case !quiet:
+		_, _ = fmt.Fprintf(os.Stderr, "✓ Committed %d files to git as %s\n", len(tx.Entries), hash[:min(7, len(hash))])
+	}
+}
+
+// transactionPaths returns every path a transaction touched, including the
+// old paths of moved files so their removal is committed too
+func transactionPaths(tx *safety.Transaction) []string {
+	var paths []string
+	for _, entry := range tx.Entries {
+		if entry.From != "" {
+			paths = append(paths, entry.From)
+		}
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+// gitCommitMessage describes a transaction as a commit message: the command
+// and number of files, a line per file in git's status letters, and a
+// trailer with the transaction ID
+func gitCommitMessage(tx *safety.Transaction) string {
+	var b strings.Builder
+	files := "files"
+	if len(tx.Entries) == 1 {
+		files = "file"
+	}
+	fmt.Fprintf(&b, "%s: %d %s changed\n\n", tx.Command, len(tx.Entries), files)
+	for _, entry := range tx.Entries {
+		var line string
+		switch entry.Op {
+		case safety.OpCreate:
+			line = "A " + entry.Path
+		case safety.OpMove:
+			line = "R " + entry.From + " -> " + entry.Path
+		case safety.OpDelete:
+			line = "D " + entry.Path
+		default:
+			line = "M " + entry.Path
+		}
+		if entry.Reason != "" {
+			line += " (" + entry.Reason + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "\n%s: %s\n", gitCommitTrailer, tx.ID)
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+func TestGitCommitMessage(t *testing.T) {
+	tx := &safety.Transaction{
+		ID:      "20250301-120000-abcdef",
+		Command: "mdnotes rename",
+		Entries: []safety.JournalEntry{
+			{Op: safety.OpMove, From: "old.md", Path: "new.md"},
+			{Op: safety.OpModify, Path: "index.md", Reason: "link update"},
+			{Op: safety.OpCreate, Path: "log.md"},
+			{Op: safety.OpDelete, Path: "tmp.md"},
+		},
+	}
+
+	assert.Equal(t, "mdnotes rename: 4 files changed\n\n"+
+		"R old.md -> new.md\n"+
+		"M index.md (link update)\n"+
+		"A log.md\n"+
+		"D tmp.md\n\n"+
+		"Mdnotes-Transaction: 20250301-120000-abcdef\n", gitCommitMessage(tx))
+	assert.Equal(t, []string{"old.md", "new.md", "index.md", "log.md", "tmp.md"}, transactionPaths(tx))
+}
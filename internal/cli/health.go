@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/config"
+)
+
+// HealthRules returns the built-in health rules with the configured weights,
+// thresholds and enabled flags applied
+func HealthRules(cfg config.HealthConfig) ([]analyzer.HealthRule, error) {
+	rules := analyzer.DefaultHealthRules()
+	for _, override := range cfg.Rules {
+		found := false
+		for i := range rules {
+			if rules[i].ID != override.ID {
+				continue
+			}
+			found = true
+			if override.Weight != nil {
+				rules[i].Weight = *override.Weight
+			}
+			if override.Threshold != nil {
+				rules[i].Threshold = *override.Threshold
+			}
+			if override.Enabled != nil {
+				rules[i].Enabled = *override.Enabled
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown health rule '%s' in analysis.health.rules", override.ID)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/events"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// BeginTransaction starts a change journal transaction for a mutating command
+// operating on path. It returns nil in dry-run mode, which journaling treats as
+// a no-op.
+func BeginTransaction(cmd *cobra.Command, path string) *safety.Transaction {
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return nil
+	}
+	journal := safety.NewJournal(safety.FindVaultRoot(path))
+	return journal.Begin(cmd.CommandPath())
+}
+
+// CommitTransaction finalizes a transaction started with BeginTransaction and
+// publishes its changes to the configured event log or webhook. When
+// provenance is enabled, changed frontmatter fields are stamped first, and
+// with --git-commit the changed files are committed. Journal, provenance, git
+// and event failures are reported as warnings since the command itself
+// succeeded. A command running in a sandbox only journals its changes, which
+// are to a copy of the vault.
+func CommitTransaction(cmd *cobra.Command, tx *safety.Transaction) {
+	if tx == nil {
+		return
+	}
+	cfg := commandConfig(cmd)
+	if provenance := cfg.Frontmatter.Provenance; provenance.Enabled {
+		if err := stampProvenance(tx, provenance.Field, time.Now()); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to record provenance: %v\n", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to write change journal: %v\n", err)
+		return
+	}
+	if len(tx.Entries) == 0 || inSandbox(cmd) {
+		return
+	}
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		_, _ = fmt.Fprintf(os.Stderr, "Recorded transaction %s (undo with: mdnotes undo %s)\n", tx.ID, tx.ID)
+	}
+	commitToGit(cmd, tx)
+
+	if publisher := events.NewPublisher(cfg.Events); publisher != nil {
+		if err := publisher.Publish(events.FromTransaction(tx)); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to publish change events: %v\n", err)
+		}
+	}
+}
+
+type sandboxKey struct{}
+
+// WithSandbox marks ctx as belonging to a command running against a sandbox
+// copy of the vault, as with --sandbox and --diff
+func WithSandbox(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, sandboxKey{}, true)
+}
+
+func inSandbox(cmd *cobra.Command) bool {
+	ctx := cmd.Context()
+	return ctx != nil && ctx.Value(sandboxKey{}) != nil
+}
+
+// commandConfig returns the command's config. Config errors are already
+// reported when presets are applied, so an unloadable config simply disables
+// events and provenance.
+func commandConfig(cmd *cobra.Command) *config.Config {
+	var cfg *config.Config
+	var err error
+	if configPath, _ := cmd.Flags().GetString("config"); configPath != "" {
+		cfg, err = config.LoadConfigFromFile(configPath)
+	} else {
+		cfg, err = config.LoadConfigWithFallback(config.GetDefaultConfigPaths())
+	}
+	if err != nil {
+		return &config.Config{}
+	}
+	return cfg
+}
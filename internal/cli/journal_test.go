@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitTransaction_Events(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.log")
+	configPath := filepath.Join(dir, "mdnotes.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("events:\n  log: "+logPath+"\n"), 0644))
+
+	commit := func(ctx context.Context) {
+		cmd := &cobra.Command{Use: "set"}
+		cmd.Flags().String("config", configPath, "")
+		cmd.Flags().Bool("dry-run", false, "")
+		cmd.SetContext(ctx)
+
+		note := filepath.Join(dir, "a.md")
+		tx := BeginTransaction(cmd, note)
+		require.NoError(t, tx.RecordWrite(note))
+		require.NoError(t, os.WriteFile(note, []byte("# A\n"), 0644))
+		CommitTransaction(cmd, tx)
+	}
+
+	// A run against a sandbox copy of the vault publishes nothing
+	commit(WithSandbox(context.Background()))
+	assert.NoFileExists(t, logPath)
+
+	commit(context.Background())
+	assert.FileExists(t, logPath)
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// NDJSONWriter writes newline-delimited JSON: one compact record per line,
+// written as soon as it is produced so consumers can process results as they arrive
+type NDJSONWriter struct {
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter creates a writer that emits records to w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &NDJSONWriter{encoder: encoder}
+}
+
+// Write emits a single record
+func (nw *NDJSONWriter) Write(record interface{}) error {
+	return nw.encoder.Encode(record)
+}
+
+// WriteNDJSON emits each record on its own line
+func WriteNDJSON[T any](w io.Writer, records []T) error {
+	nw := NewNDJSONWriter(w)
+	for _, record := range records {
+		if err := nw.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	type record struct {
+		Path  string `json:"path"`
+		Score int    `json:"score"`
+	}
+
+	var buf bytes.Buffer
+	err := WriteNDJSON(&buf, []record{{"a.md", 1}, {"<b>.md", 2}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "{\"path\":\"a.md\",\"score\":1}\n{\"path\":\"<b>.md\",\"score\":2}\n", buf.String())
+}
+
+func TestWriteNDJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteNDJSON(&buf, []int{}))
+	assert.Empty(t, buf.String())
+}
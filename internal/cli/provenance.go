@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/events"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// stampProvenance records, in each markdown file the transaction wrote, the
+// provenance of every frontmatter field the transaction added, changed or
+// removed. It runs before the transaction is committed, so undo restores the
+// unstamped original and conflict checks see the stamped content.
+func stampProvenance(tx *safety.Transaction, metaField string, now time.Time) error {
+	if metaField == "" {
+		metaField = vault.DefaultProvenanceField
+	}
+
+	for i, entry := range tx.Entries {
+		if entry.Op != safety.OpModify && entry.Op != safety.OpCreate {
+			continue
+		}
+
+		// Follow later moves to where the file ended up
+		path := entry.Path
+		for _, later := range tx.Entries[i+1:] {
+			if later.Op == safety.OpMove && later.From == path {
+				path = later.Path
+			}
+		}
+		if !strings.HasSuffix(path, ".md") {
+			continue
+		}
+
+		var before map[string]interface{}
+		if entry.Op == safety.OpModify {
+			original, err := tx.Original(entry)
+			if err != nil {
+				return err
+			}
+			before = events.Frontmatter(original)
+		}
+
+		fullPath := filepath.Join(tx.Root(), path)
+		file, err := vault.LoadVaultFile(fullPath)
+		if err != nil {
+			continue // Moved or deleted later in the command
+		}
+
+		var fields []string
+		for _, change := range events.FieldChanges(events.Event{}, before, file.Frontmatter) {
+			if change.Field != metaField {
+				fields = append(fields, change.Field)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		file.StampProvenance(metaField, fields, tx.Command, entry.Reason, now)
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", path, err)
+		}
+		if err := safety.WriteFile(fullPath, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestStampProvenance(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".obsidian"), 0755))
+	note := filepath.Join(root, "note.md")
+	untouched := filepath.Join(root, "body.md")
+	require.NoError(t, os.WriteFile(note, []byte("---\ntitle: Note\nstatus: draft\n---\nBody\n"), 0644))
+	require.NoError(t, os.WriteFile(untouched, []byte("---\ntitle: Body\n---\nOld\n"), 0644))
+
+	journal := safety.NewJournal(root)
+	tx := journal.Begin("mdnotes frontmatter set")
+	require.NoError(t, tx.RecordWrite(note))
+	require.NoError(t, os.WriteFile(note, []byte("---\ntitle: Note\nstatus: done\n---\nBody\n"), 0644))
+	require.NoError(t, tx.RecordWrite(untouched))
+	require.NoError(t, os.WriteFile(untouched, []byte("---\ntitle: Body\n---\nNew\n"), 0644))
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, stampProvenance(tx, "", now))
+	require.NoError(t, tx.Commit())
+
+	file, err := vault.LoadVaultFile(note)
+	require.NoError(t, err)
+	p, ok := file.Provenance(vault.DefaultProvenanceField, "status")
+	require.True(t, ok)
+	assert.Equal(t, "mdnotes frontmatter set", p.Source)
+	assert.True(t, file.IsManaged(vault.DefaultProvenanceField, "status"))
+	_, ok = file.Provenance(vault.DefaultProvenanceField, "title")
+	assert.False(t, ok, "unchanged fields aren't stamped")
+
+	content, err := os.ReadFile(untouched)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), vault.DefaultProvenanceField, "body-only changes aren't stamped")
+
+	assert.Empty(t, tx.Conflicts())
+	require.NoError(t, journal.Undo(tx, false))
+	content, err = os.ReadFile(note)
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Note\nstatus: draft\n---\nBody\n", string(content))
+}
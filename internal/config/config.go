@@ -6,25 +6,44 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/eoinhurrell/mdnotes/internal/i18n"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	Version     string            `yaml:"version"`
-	Vault       VaultConfig       `yaml:"vault"`
-	Frontmatter FrontmatterConfig `yaml:"frontmatter"`
-	Linkding    LinkdingConfig    `yaml:"linkding"`
-	Batch       BatchConfig       `yaml:"batch"`
-	Safety      SafetyConfig      `yaml:"safety"`
-	Downloads   DownloadConfig    `yaml:"downloads"`
-	Watch       WatchConfig       `yaml:"watch"`
-	Plugins     PluginConfig      `yaml:"plugins"`
-	Performance PerformanceConfig `yaml:"performance"`
-	Analysis    AnalysisConfig    `yaml:"analysis"`
+	Version     string                   `yaml:"version"`
+	Locale      string                   `yaml:"locale"` // Language of command output; en (default), es, de or fr
+	Vault       VaultConfig              `yaml:"vault"`
+	Frontmatter FrontmatterConfig        `yaml:"frontmatter"`
+	Linkding    LinkdingConfig           `yaml:"linkding"`
+	Readwise    ReadwiseConfig           `yaml:"readwise"`
+	Zotero      ZoteroConfig             `yaml:"zotero"`
+	Archive     ArchiveConfig            `yaml:"archive"`
+	Batch       BatchConfig              `yaml:"batch"`
+	Safety      SafetyConfig             `yaml:"safety"`
+	Downloads   DownloadConfig           `yaml:"downloads"`
+	Watch       WatchConfig              `yaml:"watch"`
+	Events      EventsConfig             `yaml:"events"`
+	Plugins     PluginConfig             `yaml:"plugins"`
+	Performance PerformanceConfig        `yaml:"performance"`
+	Analysis    AnalysisConfig           `yaml:"analysis"`
+	Lifecycle   LifecycleConfig          `yaml:"lifecycle"`
+	Templates   TemplatesConfig          `yaml:"templates"`
+	Daily       DailyConfig              `yaml:"daily"`
+	Embeddings  EmbeddingsConfig         `yaml:"embeddings"`
+	MOC         MOCConfig                `yaml:"moc"`
+	Reports     []ReportConfig           `yaml:"reports"`
+	Queries     map[string]string        `yaml:"queries"` // Saved query expressions, used as --query @name
+	Commands    map[string]CommandPreset `yaml:"commands"`
+
+	plaintextSecrets []string // Credential settings written out in the file
 }
 
 // VaultConfig contains vault-specific settings
@@ -35,8 +54,36 @@ type VaultConfig struct {
 
 // FrontmatterConfig contains frontmatter processing settings
 type FrontmatterConfig struct {
-	RequiredFields []string  `yaml:"required_fields"`
-	TypeRules      TypeRules `yaml:"type_rules"`
+	RequiredFields []string         `yaml:"required_fields"`
+	TypeRules      TypeRules        `yaml:"type_rules"`
+	Provenance     ProvenanceConfig `yaml:"provenance"`
+	TemplateFiles  TemplateFiles    `yaml:"template_files"`
+	Tidy           TidyConfig       `yaml:"tidy"`
+}
+
+// TidyConfig sets the canonical frontmatter formatting used by 'frontmatter tidy'
+type TidyConfig struct {
+	KeyOrder []string `yaml:"key_order"` // Keys placed first, in this order
+	SortKeys bool     `yaml:"sort_keys"` // Sort keys not in key_order alphabetically
+	Quotes   string   `yaml:"quotes"`    // minimal, double or single
+	Dates    string   `yaml:"dates"`     // iso or space
+	Arrays   string   `yaml:"arrays"`    // block or flow
+	Indent   int      `yaml:"indent"`    // Spaces per nesting level (default 4, as mdnotes writes frontmatter)
+}
+
+// TemplateFiles controls how 'frontmatter ensure' treats note templates and
+// generated files, which adding fields would corrupt
+type TemplateFiles struct {
+	Mode         string   `yaml:"mode"`          // skip (default), warn or process
+	Folders      []string `yaml:"folders"`       // Template folders, added to templates.dir and the common Templater folders
+	Patterns     []string `yaml:"patterns"`      // Glob patterns matching generated files
+	IgnoreSyntax bool     `yaml:"ignore_syntax"` // Don't detect templates by <% %> and {{ }} in the body
+}
+
+// ProvenanceConfig controls recording which command last set each field
+type ProvenanceConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Field   string `yaml:"field"` // Frontmatter map provenance is recorded in
 }
 
 // TypeRules defines field type validation rules
@@ -46,10 +93,44 @@ type TypeRules struct {
 
 // LinkdingConfig contains linkding integration settings
 type LinkdingConfig struct {
-	APIURL    string `yaml:"api_url"`
-	APIToken  string `yaml:"api_token"`
-	SyncTitle bool   `yaml:"sync_title"`
-	SyncTags  bool   `yaml:"sync_tags"`
+	APIURL      string `yaml:"api_url"`
+	APIToken    string `yaml:"api_token"`
+	SyncTitle   bool   `yaml:"sync_title"`
+	SyncTags    bool   `yaml:"sync_tags"`
+	NoteDir     string `yaml:"note_dir"`    // Folder for notes pulled from linkding
+	RateLimit   int    `yaml:"rate_limit"`  // Requests per second (default 5)
+	Burst       int    `yaml:"burst"`       // Requests allowed at once before rate_limit applies (default 2)
+	Concurrency int    `yaml:"concurrency"` // Notes synced in parallel (default 1)
+}
+
+// ReadwiseConfig contains Readwise highlight import settings
+type ReadwiseConfig struct {
+	APIURL   string `yaml:"api_url"` // Defaults to https://readwise.io
+	APIToken string `yaml:"api_token"`
+	Dest     string `yaml:"dest"` // Folder for imported notes
+}
+
+// ZoteroConfig contains Zotero literature note import settings
+type ZoteroConfig struct {
+	File   string `yaml:"file"`    // Better BibTeX export to read instead of the local API
+	APIURL string `yaml:"api_url"` // Defaults to http://localhost:23119
+	Dest   string `yaml:"dest"`    // Folder for literature notes
+}
+
+// ArchiveConfig contains settings for snapshotting linked pages
+type ArchiveConfig struct {
+	Provider  string `yaml:"provider"`   // wayback (default) or archivebox
+	APIURL    string `yaml:"api_url"`    // ArchiveBox server, such as http://localhost:8000
+	APIToken  string `yaml:"api_token"`  // ArchiveBox API key
+	RateLimit int    `yaml:"rate_limit"` // Requests a minute; defaults to the provider's
+}
+
+// EmbeddingsConfig selects the provider behind semantic search
+type EmbeddingsConfig struct {
+	Provider string `yaml:"provider"` // local (default), ollama or openai
+	Model    string `yaml:"model"`    // Provider-specific; empty uses the provider's default
+	APIURL   string `yaml:"api_url"`  // Overrides the provider's endpoint
+	APIKey   string `yaml:"api_key"`  // For openai; falls back to OPENAI_API_KEY
 }
 
 // BatchConfig contains batch processing settings
@@ -67,10 +148,15 @@ type SafetyConfig struct {
 
 // DownloadConfig contains settings for downloading resources
 type DownloadConfig struct {
-	AttachmentsDir string `yaml:"attachments_dir"`
-	Timeout        string `yaml:"timeout"`
-	UserAgent      string `yaml:"user_agent"`
-	MaxFileSize    int64  `yaml:"max_file_size"`
+	AttachmentsDir string   `yaml:"attachments_dir"`
+	Timeout        string   `yaml:"timeout"`
+	UserAgent      string   `yaml:"user_agent"`
+	MaxFileSize    int64    `yaml:"max_file_size"`
+	Workers        int      `yaml:"workers"`       // Downloads run in parallel
+	Retries        int      `yaml:"retries"`       // Retries of a failed download
+	RetryDelay     string   `yaml:"retry_delay"`   // Wait before the first retry, doubling for each after
+	AllowedTypes   []string `yaml:"allowed_types"` // MIME types accepted, such as image/* (empty accepts any)
+	StateFile      string   `yaml:"state_file"`    // Record of earlier downloads, relative to the vault
 }
 
 // WatchConfig contains file watching settings
@@ -81,12 +167,24 @@ type WatchConfig struct {
 	IgnorePatterns  []string    `yaml:"ignore_patterns"`
 }
 
-// WatchRule defines a file watching rule
+// WatchRule defines a file watching rule. Actions run in order for each
+// changed file matching the rule.
 type WatchRule struct {
-	Name    string   `yaml:"name"`
-	Paths   []string `yaml:"paths"`
-	Events  []string `yaml:"events"`
-	Actions []string `yaml:"actions"`
+	Name        string   `yaml:"name"`
+	Paths       []string `yaml:"paths"`
+	Events      []string `yaml:"events"`
+	Files       []string `yaml:"files"`         // Glob patterns for the file's name or path within Paths, e.g. "daily/*.md"
+	Query       string   `yaml:"query"`         // Frontmatter query the changed note must match
+	Actions     []string `yaml:"actions"`       // mdnotes commands or external programs, with {{file}} style placeholders
+	Timeout     string   `yaml:"timeout"`       // Per action, default 1m
+	StopOnError bool     `yaml:"stop_on_error"` // Skip the remaining actions after one fails
+}
+
+// EventsConfig contains settings for publishing vault change events
+type EventsConfig struct {
+	Log     string `yaml:"log"`     // NDJSON file events are appended to
+	Webhook string `yaml:"webhook"` // URL events are POSTed to
+	Timeout string `yaml:"timeout"` // Webhook request timeout
 }
 
 // PluginConfig contains plugin system settings
@@ -109,10 +207,241 @@ type PerformanceConfig struct {
 
 // AnalysisConfig contains analysis-specific settings
 type AnalysisConfig struct {
-	InboxHeadings []string `yaml:"inbox_headings"`
+	InboxHeadings []string         `yaml:"inbox_headings"`
+	Quality       QualityConfig    `yaml:"quality"`
+	Health        HealthConfig     `yaml:"health"`
+	Duplicates    DuplicatesConfig `yaml:"duplicates"`
+	Trends        TrendsConfig     `yaml:"trends"`
+}
+
+// TrendsConfig controls how trends analysis dates notes. DateSources are
+// tried in order: created, filename, git and modified.
+type TrendsConfig struct {
+	DateSources     []string `yaml:"date_sources"`
+	CreatedField    string   `yaml:"created_field"`
+	FilenamePattern string   `yaml:"filename_pattern"` // Regex whose first group is the date
+}
+
+// DuplicatesConfig controls how exact duplicate notes are detected. Content
+// is normalized before hashing, so line endings and trailing whitespace don't
+// matter.
+type DuplicatesConfig struct {
+	Hash               string `yaml:"hash"`                // xxhash (default), sha256 or md5
+	IncludeFrontmatter bool   `yaml:"include_frontmatter"` // Compare whole files rather than bodies
+}
+
+// HealthConfig tunes the rules the vault health score is made of
+type HealthConfig struct {
+	Rules []HealthRuleConfig `yaml:"rules"`
+}
+
+// HealthRuleConfig overrides a built-in health rule: missing_frontmatter,
+// orphaned_files, broken_links or duplicates. Unset fields keep the rule's
+// defaults.
+type HealthRuleConfig struct {
+	ID        string   `yaml:"id"`
+	Weight    *float64 `yaml:"weight"`    // Penalty per unit of what the rule measures
+	Threshold *float64 `yaml:"threshold"` // Measured values up to this aren't penalized
+	Enabled   *bool    `yaml:"enabled"`
+}
+
+// QualityConfig weights the built-in content quality criteria and adds
+// criteria scored by frontmatter fields or plugins
+type QualityConfig struct {
+	// Weights of the built-in criteria by name: readability, link_density,
+	// completeness, atomicity and recency. Unlisted criteria weigh 1, and a
+	// weight of 0 disables a criterion.
+	Weights map[string]float64 `yaml:"weights"`
+	// Thresholds are the lowest 0-100 scores of the excellent, good, fair and
+	// poor bands; unlisted bands keep their defaults of 90, 75, 60 and 40
+	Thresholds map[string]float64    `yaml:"thresholds"`
+	Fields     []QualityFieldConfig  `yaml:"fields"`
+	Plugins    []QualityPluginConfig `yaml:"plugins"`
+	// Language readability is scored in for notes without a lang field:
+	// auto (default) detects it, or a code such as de or fr
+	Language string `yaml:"language"`
+
+	// Profiles are named variations, such as one for an archive vault, chosen
+	// with --profile. Profile is the one used without the flag.
+	Profile  string                   `yaml:"profile"`
+	Profiles map[string]QualityConfig `yaml:"profiles"`
 }
 
-// LoadConfig loads configuration from a reader with environment variable expansion
+// WithProfile returns the quality settings with a profile applied: its
+// weights and thresholds override these, and its fields and plugins are
+// added. An empty name uses the default profile, if one is set.
+func (q QualityConfig) WithProfile(name string) (QualityConfig, error) {
+	if name == "" {
+		name = q.Profile
+	}
+	if name == "" {
+		return q, nil
+	}
+	profile, ok := q.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(q.Profiles))
+		for n := range q.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return q, fmt.Errorf("unknown quality profile '%s': none are configured in analysis.quality.profiles", name)
+		}
+		return q, fmt.Errorf("unknown quality profile '%s' (available: %s)", name, strings.Join(names, ", "))
+	}
+
+	result := QualityConfig{
+		Weights:    mergeFloats(q.Weights, profile.Weights),
+		Thresholds: mergeFloats(q.Thresholds, profile.Thresholds),
+		Fields:     append(append([]QualityFieldConfig(nil), q.Fields...), profile.Fields...),
+		Plugins:    append(append([]QualityPluginConfig(nil), q.Plugins...), profile.Plugins...),
+		Language:   q.Language,
+		Profile:    name,
+	}
+	if profile.Language != "" {
+		result.Language = profile.Language
+	}
+	return result, nil
+}
+
+func mergeFloats(base, override map[string]float64) map[string]float64 {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]float64, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// QualityFieldConfig is a criterion met by notes that set any of the given
+// frontmatter fields, e.g. a "has source" check for literature notes
+type QualityFieldConfig struct {
+	Name   string   `yaml:"name"`
+	Fields []string `yaml:"fields"`
+	Weight float64  `yaml:"weight"` // Defaults to 1
+}
+
+// QualityPluginConfig is an external command that scores each note on a
+// custom criterion. It reads the note as JSON on stdin and writes
+// {"score": 0-1, "suggestions": [...]} to stdout.
+type QualityPluginConfig struct {
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"` // Executable; relative paths resolve from the vault root
+	Args    []string `yaml:"args"`
+	Weight  float64  `yaml:"weight"`  // Defaults to 1
+	Timeout string   `yaml:"timeout"` // Per note, e.g. "10s"
+}
+
+// LifecycleConfig contains rules that archive or update notes automatically
+type LifecycleConfig struct {
+	Rules []LifecycleRule `yaml:"rules"`
+}
+
+// LifecycleRule updates and moves notes matching a query expression. Rules are
+// tried in order and the first match applies.
+type LifecycleRule struct {
+	Name   string                 `yaml:"name"`
+	When   string                 `yaml:"when"`    // Query expression, e.g. "status = 'done' AND modified before '90 days'"
+	Set    map[string]interface{} `yaml:"set"`     // Frontmatter fields to set; string values may use templates
+	MoveTo string                 `yaml:"move_to"` // Directory template relative to the vault root
+}
+
+// TemplatesConfig contains settings for creating notes with 'mdnotes new'
+type TemplatesConfig struct {
+	Dir      string                  `yaml:"dir"`      // Template directory relative to the vault root, default "templates"
+	Default  string                  `yaml:"default"`  // Template used when none is named
+	Folder   string                  `yaml:"folder"`   // Folder template for new notes, default the vault root
+	Filename string                  `yaml:"filename"` // Filename template without extension, default "{{title}}"
+	Rules    map[string]TemplateRule `yaml:"rules"`    // Per-template placement, by template name
+}
+
+// TemplateRule overrides where notes created from one template are placed.
+// Empty values fall back to the templates section defaults.
+type TemplateRule struct {
+	Folder   string `yaml:"folder"`
+	Filename string `yaml:"filename"`
+}
+
+// DailyConfig contains settings for daily notes. Empty values fall back to
+// Obsidian's daily notes settings in .obsidian/daily-notes.json.
+type DailyConfig struct {
+	Folder   string `yaml:"folder"`   // Folder for daily notes relative to the vault root
+	Format   string `yaml:"format"`   // File name date format, default "YYYY-MM-DD"; may contain '/'
+	Template string `yaml:"template"` // Template for new daily notes, by name in the templates directory
+}
+
+// MOCConfig contains defaults for 'mdnotes moc generate'
+type MOCConfig struct {
+	GroupBy         string `yaml:"group_by"`         // Frontmatter field notes are grouped by, e.g. status or tags
+	HeadingTemplate string `yaml:"heading_template"` // Group heading, default "## {{group}}"
+	ItemTemplate    string `yaml:"item_template"`    // One entry, default "- {{link}}"
+	Ungrouped       string `yaml:"ungrouped"`        // Group of notes without the field, default "Other"
+}
+
+// ReportConfig defines a report refreshed by 'mdnotes report run': notes
+// selected by a query or an analysis, rendered into a note in the vault
+type ReportConfig struct {
+	Name      string   `yaml:"name"`
+	Output    string   `yaml:"output"`    // Note the report is written to, relative to the vault root
+	Query     string   `yaml:"query"`     // Query expression or saved query (@name); empty selects every note
+	Analysis  string   `yaml:"analysis"`  // orphans or dead-ends, instead of a query
+	Fields    []string `yaml:"fields"`    // Columns of a results table; without them notes are listed as links
+	GroupBy   []string `yaml:"group_by"`  // Fields to group results by, as with --group-by
+	Aggregate string   `yaml:"aggregate"` // Aggregates per group, default "count()" when grouping
+	Limit     int      `yaml:"limit"`     // Most notes listed, 0 for all
+	Template  string   `yaml:"template"`  // Report content; variables results, count and name; default "{{results}}"
+	Every     string   `yaml:"every"`     // Skip the report if its note changed more recently, e.g. "1 day"
+}
+
+// CommandPreset contains per-command settings, keyed by dotted command path
+// (e.g. "frontmatter.ensure")
+type CommandPreset struct {
+	// Defaults maps flag names to default values; lists are used for repeatable flags
+	Defaults map[string]interface{} `yaml:"defaults"`
+}
+
+// CommandDefaults returns the configured flag defaults for a dotted command path
+func (c *Config) CommandDefaults(commandPath string) map[string]interface{} {
+	if c.Commands == nil {
+		return nil
+	}
+	return c.Commands[commandPath].Defaults
+}
+
+// queryNamePattern matches the names of saved queries
+var queryNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ResolveQuery expands a reference to a saved query ("@name") into its
+// expression. Other values are returned unchanged.
+func (c *Config) ResolveQuery(value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "@") {
+		return value, nil
+	}
+
+	name := trimmed[1:]
+	if expression, ok := c.Queries[name]; ok {
+		return expression, nil
+	}
+	if len(c.Queries) == 0 {
+		return "", fmt.Errorf("unknown saved query '@%s': no queries are defined in config", name)
+	}
+	names := make([]string, 0, len(c.Queries))
+	for n := range c.Queries {
+		names = append(names, "@"+n)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("unknown saved query '@%s' - defined queries: %s", name, strings.Join(names, ", "))
+}
+
+// LoadConfig loads configuration from a reader, expanding ${VAR_NAME}
+// environment variable references. ${keychain:NAME} references in credential
+// settings are kept as written, for Secret to resolve.
 func LoadConfig(reader io.Reader) (*Config, error) {
 	content, err := io.ReadAll(reader)
 	if err != nil {
@@ -127,6 +456,8 @@ func LoadConfig(reader io.Reader) (*Config, error) {
 		return nil, fmt.Errorf("parsing YAML: %w", err)
 	}
 
+	config.findPlaintextSecrets(content)
+
 	return &config, nil
 }
 
@@ -171,6 +502,12 @@ func DefaultConfig() *Config {
 			TypeRules: TypeRules{
 				Fields: make(map[string]string),
 			},
+			Provenance: ProvenanceConfig{
+				Field: "mdnotes_meta",
+			},
+			Tidy: TidyConfig{
+				Indent: 4,
+			},
 		},
 		Linkding: LinkdingConfig{
 			APIURL:    "",
@@ -192,6 +529,11 @@ func DefaultConfig() *Config {
 			Timeout:        "30s",
 			UserAgent:      "mdnotes/1.0",
 			MaxFileSize:    10 * 1024 * 1024, // 10MB
+			Workers:        4,
+			Retries:        3,
+			RetryDelay:     "1s",
+			AllowedTypes:   []string{"image/*", "application/pdf", "audio/*", "video/*"},
+			StateFile:      ".mdnotes/downloads.json",
 		},
 		Watch: WatchConfig{
 			Enabled:         false,
@@ -207,6 +549,9 @@ func DefaultConfig() *Config {
 				".DS_Store",
 			},
 		},
+		Events: EventsConfig{
+			Timeout: "10s",
+		},
 		Plugins: PluginConfig{
 			Enabled: false,
 			SearchPaths: []string{
@@ -271,6 +616,23 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Locale != "" {
+		if _, ok := i18n.Match(c.Locale); !ok {
+			return fmt.Errorf("invalid locale '%s': expected one of %s", c.Locale, strings.Join(i18n.Locales(), ", "))
+		}
+	}
+
+	switch c.Frontmatter.TemplateFiles.Mode {
+	case "", "skip", "warn", "process":
+	default:
+		return fmt.Errorf("invalid template_files mode '%s': expected skip, warn or process", c.Frontmatter.TemplateFiles.Mode)
+	}
+	for _, pattern := range c.Frontmatter.TemplateFiles.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid template_files pattern '%s': %w", pattern, err)
+		}
+	}
+
 	// Validate backup retention duration
 	if c.Safety.BackupRetention != "" {
 		if _, err := time.ParseDuration(c.Safety.BackupRetention); err != nil {
@@ -278,6 +640,16 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate download retry delay
+	if c.Downloads.RetryDelay != "" {
+		if _, err := time.ParseDuration(c.Downloads.RetryDelay); err != nil {
+			return fmt.Errorf("invalid download retry delay: %w", err)
+		}
+	}
+	if c.Downloads.Retries < 0 {
+		return fmt.Errorf("download retries must not be negative")
+	}
+
 	// Validate watch debounce timeout
 	if c.Watch.DebounceTimeout != "" {
 		if _, err := time.ParseDuration(c.Watch.DebounceTimeout); err != nil {
@@ -285,6 +657,16 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate event publishing settings
+	if c.Events.Timeout != "" {
+		if _, err := time.ParseDuration(c.Events.Timeout); err != nil {
+			return fmt.Errorf("invalid events timeout: %w", err)
+		}
+	}
+	if c.Events.Webhook != "" && !strings.HasPrefix(c.Events.Webhook, "http://") && !strings.HasPrefix(c.Events.Webhook, "https://") {
+		return fmt.Errorf("events webhook must be an http or https URL: %s", c.Events.Webhook)
+	}
+
 	// Validate watch rule events
 	validEvents := map[string]bool{
 		"create": true,
@@ -300,11 +682,207 @@ func (c *Config) Validate() error {
 				return fmt.Errorf("invalid watch event '%s' in rule '%s'", event, rule.Name)
 			}
 		}
+		for _, pattern := range rule.Files {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid files pattern '%s' in watch rule '%s': %w", pattern, rule.Name, err)
+			}
+		}
+		if rule.Timeout != "" {
+			if _, err := time.ParseDuration(rule.Timeout); err != nil {
+				return fmt.Errorf("invalid timeout in watch rule '%s': %w", rule.Name, err)
+			}
+		}
+	}
+
+	// Validate content quality settings and profiles
+	if err := validateQuality(c.Analysis.Quality, "analysis.quality"); err != nil {
+		return err
+	}
+	for name, profile := range c.Analysis.Quality.Profiles {
+		if profile.Profile != "" || len(profile.Profiles) > 0 {
+			return fmt.Errorf("quality profile '%s' cannot define profiles of its own", name)
+		}
+		merged, _ := c.Analysis.Quality.WithProfile(name)
+		if err := validateQuality(merged, "analysis.quality.profiles."+name); err != nil {
+			return err
+		}
+	}
+	if p := c.Analysis.Quality.Profile; p != "" {
+		if _, ok := c.Analysis.Quality.Profiles[p]; !ok {
+			return fmt.Errorf("default quality profile '%s' is not in analysis.quality.profiles", p)
+		}
+	}
+	healthRules := map[string]bool{
+		"missing_frontmatter": true,
+		"orphaned_files":      true,
+		"broken_links":        true,
+		"duplicates":          true,
+	}
+	seenRules := make(map[string]bool)
+	for _, rule := range c.Analysis.Health.Rules {
+		if !healthRules[rule.ID] {
+			return fmt.Errorf("unknown health rule '%s' in analysis.health.rules", rule.ID)
+		}
+		if seenRules[rule.ID] {
+			return fmt.Errorf("health rule '%s' is configured more than once", rule.ID)
+		}
+		seenRules[rule.ID] = true
+		if rule.Weight != nil && *rule.Weight < 0 {
+			return fmt.Errorf("health rule '%s' weight must not be negative", rule.ID)
+		}
+		if rule.Threshold != nil && *rule.Threshold < 0 {
+			return fmt.Errorf("health rule '%s' threshold must not be negative", rule.ID)
+		}
+	}
+	switch c.Analysis.Duplicates.Hash {
+	case "", "xxhash", "sha256", "md5":
+	default:
+		return fmt.Errorf("unknown hash '%s' in analysis.duplicates.hash - valid options are: xxhash, sha256, md5", c.Analysis.Duplicates.Hash)
+	}
+	for _, source := range c.Analysis.Trends.DateSources {
+		switch source {
+		case "created", "filename", "git", "modified":
+		default:
+			return fmt.Errorf("unknown date source '%s' in analysis.trends.date_sources - valid sources are: created, filename, git, modified", source)
+		}
+	}
+	if pattern := c.Analysis.Trends.FilenamePattern; pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid analysis.trends.filename_pattern: %w", err)
+		}
+	}
+	// Validate saved query names; their expressions are checked when used
+	for name, expression := range c.Queries {
+		if !queryNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid query name '%s': use letters, numbers, '_' and '-'", name)
+		}
+		if strings.TrimSpace(expression) == "" {
+			return fmt.Errorf("query '%s' has no expression", name)
+		}
+		if strings.HasPrefix(strings.TrimSpace(expression), "@") {
+			return fmt.Errorf("query '%s' cannot refer to another saved query", name)
+		}
+	}
+
+	// Validate lifecycle rules; their expressions are checked when compiled
+	names := make(map[string]bool)
+	for i, rule := range c.Lifecycle.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("lifecycle rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate lifecycle rule '%s'", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.When == "" {
+			return fmt.Errorf("lifecycle rule '%s' has no 'when' condition", rule.Name)
+		}
+		if len(rule.Set) == 0 && rule.MoveTo == "" {
+			return fmt.Errorf("lifecycle rule '%s' must set fields or move_to a directory", rule.Name)
+		}
+	}
+
+	// Validate reports; queries, aggregates and templates are checked when run
+	reportNames := make(map[string]bool)
+	for i, report := range c.Reports {
+		if report.Name == "" {
+			return fmt.Errorf("report %d has no name", i+1)
+		}
+		if reportNames[report.Name] {
+			return fmt.Errorf("duplicate report '%s'", report.Name)
+		}
+		reportNames[report.Name] = true
+		if report.Output == "" {
+			return fmt.Errorf("report '%s' has no output note", report.Name)
+		}
+		if report.Query != "" && report.Analysis != "" {
+			return fmt.Errorf("report '%s' can have a query or an analysis, not both", report.Name)
+		}
+		if report.Analysis != "" && report.Analysis != "orphans" && report.Analysis != "dead-ends" {
+			return fmt.Errorf("report '%s' has unknown analysis '%s' (supported: orphans, dead-ends)", report.Name, report.Analysis)
+		}
+		if report.Limit < 0 {
+			return fmt.Errorf("report '%s' limit must not be negative", report.Name)
+		}
 	}
 
 	return nil
 }
 
+// languagePattern matches auto, language codes and tags such as de-AT, and
+// language names
+var languagePattern = regexp.MustCompile(`^\p{L}+([-_][A-Za-z0-9]+)*$`)
+
+// validateQuality checks content quality settings; where is their place in
+// the config file, for error messages
+func validateQuality(q QualityConfig, where string) error {
+	validCriteria := map[string]bool{
+		"readability":  true,
+		"link_density": true,
+		"completeness": true,
+		"atomicity":    true,
+		"recency":      true,
+	}
+	for criterion, weight := range q.Weights {
+		if !validCriteria[criterion] {
+			return fmt.Errorf("unknown quality criterion '%s' in %s.weights", criterion, where)
+		}
+		if weight < 0 {
+			return fmt.Errorf("quality weight for '%s' must not be negative", criterion)
+		}
+	}
+	if q.Language != "" && !languagePattern.MatchString(q.Language) {
+		return fmt.Errorf("invalid language '%s' in %s.language - use auto or a language code such as de", q.Language, where)
+	}
+	validBands := map[string]bool{"excellent": true, "good": true, "fair": true, "poor": true}
+	for band, threshold := range q.Thresholds {
+		if !validBands[band] {
+			return fmt.Errorf("unknown quality band '%s' in %s.thresholds - valid bands are: excellent, good, fair, poor", band, where)
+		}
+		if threshold < 0 || threshold > 100 {
+			return fmt.Errorf("quality threshold for '%s' must be between 0 and 100", band)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, field := range q.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("quality field criterion %d in %s has no name", i+1, where)
+		}
+		if names[field.Name] || validCriteria[field.Name] {
+			return fmt.Errorf("duplicate quality criterion '%s'", field.Name)
+		}
+		names[field.Name] = true
+		if len(field.Fields) == 0 {
+			return fmt.Errorf("quality field criterion '%s' has no fields", field.Name)
+		}
+		if field.Weight < 0 {
+			return fmt.Errorf("quality field criterion '%s' weight must not be negative", field.Name)
+		}
+	}
+	for i, plugin := range q.Plugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("quality plugin %d in %s has no name", i+1, where)
+		}
+		if names[plugin.Name] || validCriteria[plugin.Name] {
+			return fmt.Errorf("duplicate quality criterion '%s'", plugin.Name)
+		}
+		names[plugin.Name] = true
+		if plugin.Command == "" {
+			return fmt.Errorf("quality plugin '%s' has no command", plugin.Name)
+		}
+		if plugin.Weight < 0 {
+			return fmt.Errorf("quality plugin '%s' weight must not be negative", plugin.Name)
+		}
+		if plugin.Timeout != "" {
+			if _, err := time.ParseDuration(plugin.Timeout); err != nil {
+				return fmt.Errorf("invalid timeout for quality plugin '%s': %w", plugin.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // SaveToFile saves the configuration to a file
 func (c *Config) SaveToFile(filepath string) error {
 	// Ensure directory exists
@@ -317,7 +895,7 @@ func (c *Config) SaveToFile(filepath string) error {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
 
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
+	if err := safety.WriteFile(filepath, data, 0644); err != nil {
 		return fmt.Errorf("writing config file: %w", err)
 	}
 
@@ -353,6 +931,42 @@ func (c *Config) Merge(other Config) *Config {
 			result.Frontmatter.TypeRules.Fields[k] = v
 		}
 	}
+	if other.Frontmatter.Provenance.Enabled {
+		result.Frontmatter.Provenance.Enabled = true
+	}
+	if other.Frontmatter.Provenance.Field != "" {
+		result.Frontmatter.Provenance.Field = other.Frontmatter.Provenance.Field
+	}
+	if other.Frontmatter.TemplateFiles.Mode != "" {
+		result.Frontmatter.TemplateFiles.Mode = other.Frontmatter.TemplateFiles.Mode
+	}
+	if len(other.Frontmatter.TemplateFiles.Folders) > 0 {
+		result.Frontmatter.TemplateFiles.Folders = other.Frontmatter.TemplateFiles.Folders
+	}
+	if len(other.Frontmatter.TemplateFiles.Patterns) > 0 {
+		result.Frontmatter.TemplateFiles.Patterns = other.Frontmatter.TemplateFiles.Patterns
+	}
+	if other.Frontmatter.TemplateFiles.IgnoreSyntax {
+		result.Frontmatter.TemplateFiles.IgnoreSyntax = true
+	}
+	if len(other.Frontmatter.Tidy.KeyOrder) > 0 {
+		result.Frontmatter.Tidy.KeyOrder = other.Frontmatter.Tidy.KeyOrder
+	}
+	if other.Frontmatter.Tidy.SortKeys {
+		result.Frontmatter.Tidy.SortKeys = true
+	}
+	if other.Frontmatter.Tidy.Quotes != "" {
+		result.Frontmatter.Tidy.Quotes = other.Frontmatter.Tidy.Quotes
+	}
+	if other.Frontmatter.Tidy.Dates != "" {
+		result.Frontmatter.Tidy.Dates = other.Frontmatter.Tidy.Dates
+	}
+	if other.Frontmatter.Tidy.Arrays != "" {
+		result.Frontmatter.Tidy.Arrays = other.Frontmatter.Tidy.Arrays
+	}
+	if other.Frontmatter.Tidy.Indent != 0 {
+		result.Frontmatter.Tidy.Indent = other.Frontmatter.Tidy.Indent
+	}
 
 	// Linkding config
 	if other.Linkding.APIURL != "" {
@@ -361,6 +975,54 @@ func (c *Config) Merge(other Config) *Config {
 	if other.Linkding.APIToken != "" {
 		result.Linkding.APIToken = other.Linkding.APIToken
 	}
+	if other.Linkding.NoteDir != "" {
+		result.Linkding.NoteDir = other.Linkding.NoteDir
+	}
+	if other.Linkding.RateLimit != 0 {
+		result.Linkding.RateLimit = other.Linkding.RateLimit
+	}
+	if other.Linkding.Burst != 0 {
+		result.Linkding.Burst = other.Linkding.Burst
+	}
+	if other.Linkding.Concurrency != 0 {
+		result.Linkding.Concurrency = other.Linkding.Concurrency
+	}
+
+	// Readwise config
+	if other.Readwise.APIURL != "" {
+		result.Readwise.APIURL = other.Readwise.APIURL
+	}
+	if other.Readwise.APIToken != "" {
+		result.Readwise.APIToken = other.Readwise.APIToken
+	}
+	if other.Readwise.Dest != "" {
+		result.Readwise.Dest = other.Readwise.Dest
+	}
+
+	// Zotero config
+	if other.Zotero.File != "" {
+		result.Zotero.File = other.Zotero.File
+	}
+	if other.Zotero.APIURL != "" {
+		result.Zotero.APIURL = other.Zotero.APIURL
+	}
+	if other.Zotero.Dest != "" {
+		result.Zotero.Dest = other.Zotero.Dest
+	}
+
+	// Archive config
+	if other.Archive.Provider != "" {
+		result.Archive.Provider = other.Archive.Provider
+	}
+	if other.Archive.APIURL != "" {
+		result.Archive.APIURL = other.Archive.APIURL
+	}
+	if other.Archive.APIToken != "" {
+		result.Archive.APIToken = other.Archive.APIToken
+	}
+	if other.Archive.RateLimit != 0 {
+		result.Archive.RateLimit = other.Archive.RateLimit
+	}
 
 	// Batch config
 	if other.Batch.MaxWorkers != 0 {
@@ -388,6 +1050,173 @@ func (c *Config) Merge(other Config) *Config {
 	if other.Downloads.MaxFileSize != 0 {
 		result.Downloads.MaxFileSize = other.Downloads.MaxFileSize
 	}
+	if other.Downloads.Workers != 0 {
+		result.Downloads.Workers = other.Downloads.Workers
+	}
+	if other.Downloads.Retries != 0 {
+		result.Downloads.Retries = other.Downloads.Retries
+	}
+	if other.Downloads.RetryDelay != "" {
+		result.Downloads.RetryDelay = other.Downloads.RetryDelay
+	}
+	if len(other.Downloads.AllowedTypes) > 0 {
+		result.Downloads.AllowedTypes = other.Downloads.AllowedTypes
+	}
+	if other.Downloads.StateFile != "" {
+		result.Downloads.StateFile = other.Downloads.StateFile
+	}
+
+	// Events config
+	if other.Events.Log != "" {
+		result.Events.Log = other.Events.Log
+	}
+	if other.Events.Webhook != "" {
+		result.Events.Webhook = other.Events.Webhook
+	}
+	if other.Events.Timeout != "" {
+		result.Events.Timeout = other.Events.Timeout
+	}
+
+	// Analysis settings
+	if len(other.Analysis.InboxHeadings) > 0 {
+		result.Analysis.InboxHeadings = other.Analysis.InboxHeadings
+	}
+	if len(other.Analysis.Quality.Weights) > 0 {
+		result.Analysis.Quality.Weights = other.Analysis.Quality.Weights
+	}
+	if len(other.Analysis.Quality.Plugins) > 0 {
+		result.Analysis.Quality.Plugins = other.Analysis.Quality.Plugins
+	}
+	if len(other.Analysis.Quality.Thresholds) > 0 {
+		result.Analysis.Quality.Thresholds = other.Analysis.Quality.Thresholds
+	}
+	if other.Analysis.Quality.Language != "" {
+		result.Analysis.Quality.Language = other.Analysis.Quality.Language
+	}
+	if len(other.Analysis.Quality.Fields) > 0 {
+		result.Analysis.Quality.Fields = other.Analysis.Quality.Fields
+	}
+	if other.Analysis.Quality.Profile != "" {
+		result.Analysis.Quality.Profile = other.Analysis.Quality.Profile
+	}
+	if len(other.Analysis.Quality.Profiles) > 0 {
+		result.Analysis.Quality.Profiles = other.Analysis.Quality.Profiles
+	}
+	if len(other.Analysis.Health.Rules) > 0 {
+		result.Analysis.Health.Rules = other.Analysis.Health.Rules
+	}
+	if other.Analysis.Duplicates.Hash != "" {
+		result.Analysis.Duplicates.Hash = other.Analysis.Duplicates.Hash
+	}
+	if other.Analysis.Duplicates.IncludeFrontmatter {
+		result.Analysis.Duplicates.IncludeFrontmatter = true
+	}
+	if len(other.Analysis.Trends.DateSources) > 0 {
+		result.Analysis.Trends.DateSources = other.Analysis.Trends.DateSources
+	}
+	if other.Analysis.Trends.CreatedField != "" {
+		result.Analysis.Trends.CreatedField = other.Analysis.Trends.CreatedField
+	}
+	if other.Analysis.Trends.FilenamePattern != "" {
+		result.Analysis.Trends.FilenamePattern = other.Analysis.Trends.FilenamePattern
+	}
+
+	// Lifecycle rules
+	if len(other.Lifecycle.Rules) > 0 {
+		result.Lifecycle.Rules = other.Lifecycle.Rules
+	}
+
+	// Reports
+	if len(other.Reports) > 0 {
+		result.Reports = other.Reports
+	}
+
+	// Embeddings config
+	if other.Embeddings.Provider != "" {
+		result.Embeddings.Provider = other.Embeddings.Provider
+	}
+	if other.Embeddings.Model != "" {
+		result.Embeddings.Model = other.Embeddings.Model
+	}
+	if other.Embeddings.APIURL != "" {
+		result.Embeddings.APIURL = other.Embeddings.APIURL
+	}
+	if other.Embeddings.APIKey != "" {
+		result.Embeddings.APIKey = other.Embeddings.APIKey
+	}
+
+	// Maps of content
+	if other.MOC.GroupBy != "" {
+		result.MOC.GroupBy = other.MOC.GroupBy
+	}
+	if other.MOC.HeadingTemplate != "" {
+		result.MOC.HeadingTemplate = other.MOC.HeadingTemplate
+	}
+	if other.MOC.ItemTemplate != "" {
+		result.MOC.ItemTemplate = other.MOC.ItemTemplate
+	}
+	if other.MOC.Ungrouped != "" {
+		result.MOC.Ungrouped = other.MOC.Ungrouped
+	}
+
+	// Daily notes
+	if other.Daily.Folder != "" {
+		result.Daily.Folder = other.Daily.Folder
+	}
+	if other.Daily.Format != "" {
+		result.Daily.Format = other.Daily.Format
+	}
+	if other.Daily.Template != "" {
+		result.Daily.Template = other.Daily.Template
+	}
+
+	// Note templates
+	if other.Templates.Dir != "" {
+		result.Templates.Dir = other.Templates.Dir
+	}
+	if other.Templates.Default != "" {
+		result.Templates.Default = other.Templates.Default
+	}
+	if other.Templates.Folder != "" {
+		result.Templates.Folder = other.Templates.Folder
+	}
+	if other.Templates.Filename != "" {
+		result.Templates.Filename = other.Templates.Filename
+	}
+	if len(other.Templates.Rules) > 0 {
+		rules := make(map[string]TemplateRule, len(c.Templates.Rules)+len(other.Templates.Rules))
+		for k, v := range c.Templates.Rules {
+			rules[k] = v
+		}
+		for k, v := range other.Templates.Rules {
+			rules[k] = v
+		}
+		result.Templates.Rules = rules
+	}
+
+	// Saved queries
+	if len(other.Queries) > 0 {
+		queries := make(map[string]string, len(c.Queries)+len(other.Queries))
+		for k, v := range c.Queries {
+			queries[k] = v
+		}
+		for k, v := range other.Queries {
+			queries[k] = v
+		}
+		result.Queries = queries
+	}
+
+	// Command presets
+	if len(other.Commands) > 0 {
+		commands := make(map[string]CommandPreset, len(c.Commands)+len(other.Commands))
+		for k, v := range c.Commands {
+			commands[k] = v
+		}
+		for k, v := range other.Commands {
+			commands[k] = v
+		}
+		result.Commands = commands
+	}
 
 	return &result
 }
@@ -401,6 +1230,11 @@ func expandEnvVars(content string) string {
 		// Extract variable name (remove ${ and })
 		varName := match[2 : len(match)-1]
 
+		// Keychain references are resolved after parsing
+		if strings.HasPrefix(varName, "keychain:") {
+			return match
+		}
+
 		// Get environment variable value
 		envValue := os.Getenv(varName)
 
@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/secrets"
 )
 
 func TestConfig_Load(t *testing.T) {
@@ -134,6 +136,189 @@ func TestConfig_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "invalid backup retention",
 		},
+		{
+			name: "lifecycle rule without action",
+			config: Config{
+				Version: "1.0",
+				Lifecycle: LifecycleConfig{Rules: []LifecycleRule{
+					{Name: "archive", When: "status = 'done'"},
+				}},
+			},
+			expectError: true,
+			errorMsg:    "must set fields or move_to",
+		},
+		{
+			name: "duplicate lifecycle rule",
+			config: Config{
+				Version: "1.0",
+				Lifecycle: LifecycleConfig{Rules: []LifecycleRule{
+					{Name: "archive", When: "status = 'done'", MoveTo: "archive"},
+					{Name: "archive", When: "status = 'dropped'", MoveTo: "archive"},
+				}},
+			},
+			expectError: true,
+			errorMsg:    "duplicate lifecycle rule",
+		},
+		{
+			name: "report with query and analysis",
+			config: Config{
+				Version: "1.0",
+				Reports: []ReportConfig{{Name: "orphans", Output: "Orphans.md", Query: "status = 'done'", Analysis: "orphans"}},
+			},
+			expectError: true,
+			errorMsg:    "a query or an analysis, not both",
+		},
+		{
+			name: "report without output",
+			config: Config{
+				Version: "1.0",
+				Reports: []ReportConfig{{Name: "reading", Query: "type = 'book'"}},
+			},
+			expectError: true,
+			errorMsg:    "has no output note",
+		},
+		{
+			name: "unknown quality criterion",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{Weights: map[string]float64{"clarity": 2}}},
+			},
+			expectError: true,
+			errorMsg:    "unknown quality criterion",
+		},
+		{
+			name: "invalid template files mode",
+			config: Config{
+				Version:     "1.0",
+				Frontmatter: FrontmatterConfig{TemplateFiles: TemplateFiles{Mode: "ignore"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid template_files mode",
+		},
+		{
+			name:        "invalid query name",
+			config:      Config{Version: "1.0", Queries: map[string]string{"my drafts": "status = 'draft'"}},
+			expectError: true,
+			errorMsg:    "invalid query name 'my drafts'",
+		},
+		{
+			name:        "query referring to a saved query",
+			config:      Config{Version: "1.0", Queries: map[string]string{"recent": "@drafts"}},
+			expectError: true,
+			errorMsg:    "cannot refer to another saved query",
+		},
+		{
+			name:        "unsupported locale",
+			config:      Config{Version: "1.0", Locale: "ja"},
+			expectError: true,
+			errorMsg:    "invalid locale 'ja'",
+		},
+		{
+			name: "invalid watch rule timeout",
+			config: Config{
+				Version: "1.0",
+				Watch:   WatchConfig{Rules: []WatchRule{{Name: "publish", Timeout: "soon"}}},
+			},
+			expectError: true,
+			errorMsg:    "invalid timeout in watch rule 'publish'",
+		},
+		{
+			name: "quality plugin without command",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{Plugins: []QualityPluginConfig{{Name: "spelling"}}}},
+			},
+			expectError: true,
+			errorMsg:    "command",
+		},
+		{
+			name: "unknown quality band",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{Thresholds: map[string]float64{"great": 95}}},
+			},
+			expectError: true,
+			errorMsg:    "unknown quality band 'great'",
+		},
+		{
+			name: "quality profile with unknown criterion",
+			config: Config{
+				Version: "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{Profiles: map[string]QualityConfig{
+					"archive": {Weights: map[string]float64{"freshness": 0}},
+				}}},
+			},
+			expectError: true,
+			errorMsg:    "analysis.quality.profiles.archive.weights",
+		},
+		{
+			name: "quality field criterion duplicating a plugin",
+			config: Config{
+				Version: "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{
+					Plugins: []QualityPluginConfig{{Name: "has_source", Command: "score.sh"}},
+					Profiles: map[string]QualityConfig{
+						"literature": {Fields: []QualityFieldConfig{{Name: "has_source", Fields: []string{"source"}}}},
+					},
+				}},
+			},
+			expectError: true,
+			errorMsg:    "duplicate quality criterion 'has_source'",
+		},
+		{
+			name: "invalid readability language",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{Language: "de at"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid language 'de at'",
+		},
+		{
+			name: "unknown default quality profile",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Quality: QualityConfig{Profile: "archive"}},
+			},
+			expectError: true,
+			errorMsg:    "default quality profile 'archive'",
+		},
+		{
+			name: "unknown health rule",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Health: HealthConfig{Rules: []HealthRuleConfig{{ID: "stale_notes"}}}},
+			},
+			expectError: true,
+			errorMsg:    "unknown health rule 'stale_notes'",
+		},
+		{
+			name: "unknown duplicates hash",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Duplicates: DuplicatesConfig{Hash: "crc32"}},
+			},
+			expectError: true,
+			errorMsg:    "unknown hash 'crc32'",
+		},
+		{
+			name: "unknown trends date source",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Trends: TrendsConfig{DateSources: []string{"created", "birthday"}}},
+			},
+			expectError: true,
+			errorMsg:    "unknown date source 'birthday'",
+		},
+		{
+			name: "invalid trends filename pattern",
+			config: Config{
+				Version:  "1.0",
+				Analysis: AnalysisConfig{Trends: TrendsConfig{FilenamePattern: "(\\d"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid analysis.trends.filename_pattern",
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,6 +439,43 @@ linkding:
 	assert.Equal(t, "https://api.example.com/v1/bookmarks", cfg.Linkding.APIURL)
 }
 
+func TestConfig_KeychainSecrets(t *testing.T) {
+	saved := secrets.Default
+	defer func() { secrets.Default = saved }()
+	secrets.Default = secrets.Memory{"linkding.token": "from-keychain"}
+	t.Setenv("TEST_READWISE_TOKEN", "from-env")
+
+	cfg, err := LoadConfig(strings.NewReader(`
+linkding:
+  api_url: https://links.example.com
+  api_token: ${keychain:linkding.token}
+readwise:
+  api_token: "${TEST_READWISE_TOKEN}"
+archive:
+  api_token: plain-token
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "${keychain:linkding.token}", cfg.Linkding.APIToken)
+	assert.Equal(t, []string{"archive.api_token"}, cfg.PlaintextSecrets())
+
+	token, err := cfg.Secret("linkding.api_token")
+	require.NoError(t, err)
+	assert.Equal(t, "from-keychain", token)
+	token, err = cfg.Secret("readwise.api_token")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", token)
+
+	// A missing secret fails only when it's needed, not when the config loads
+	cfg, err = LoadConfig(strings.NewReader("readwise:\n  api_token: ${keychain:readwise.token}\n"))
+	require.NoError(t, err)
+	_, err = cfg.Secret("readwise.api_token")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mdnotes config set-secret readwise.token")
+
+	assert.Error(t, ValidateSecretName("bad name"))
+	assert.NoError(t, ValidateSecretName("linkding.token"))
+}
+
 func TestConfig_SaveConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Vault.Path = "/test/vault"
@@ -345,3 +567,115 @@ func TestConfig_LoadWithFallbackDefault(t *testing.T) {
 	assert.Equal(t, defaultCfg.Version, cfg.Version)
 	assert.Equal(t, defaultCfg.Vault.IgnorePatterns, cfg.Vault.IgnorePatterns)
 }
+
+func TestConfig_CommandPresets(t *testing.T) {
+	yamlContent := `
+version: "1.0"
+commands:
+  frontmatter.ensure:
+    defaults:
+      ignore: [".obsidian/*", "templates/*"]
+      type: ["tags:array"]
+  links.check:
+    defaults:
+      verbose: true
+`
+
+	cfg, err := LoadConfig(strings.NewReader(yamlContent))
+	require.NoError(t, err)
+
+	ensure := cfg.CommandDefaults("frontmatter.ensure")
+	require.NotNil(t, ensure)
+	assert.Equal(t, []interface{}{".obsidian/*", "templates/*"}, ensure["ignore"])
+	assert.Equal(t, []interface{}{"tags:array"}, ensure["type"])
+
+	assert.Equal(t, true, cfg.CommandDefaults("links.check")["verbose"])
+	assert.Nil(t, cfg.CommandDefaults("headings.fix"))
+	assert.Nil(t, DefaultConfig().CommandDefaults("frontmatter.ensure"))
+
+	// Presets from the other config take precedence per command
+	other := Config{Commands: map[string]CommandPreset{
+		"links.check": {Defaults: map[string]interface{}{"verbose": false}},
+	}}
+	merged := cfg.Merge(other)
+	assert.Equal(t, false, merged.CommandDefaults("links.check")["verbose"])
+	assert.NotNil(t, merged.CommandDefaults("frontmatter.ensure"))
+}
+
+func TestConfig_ResolveQuery(t *testing.T) {
+	yamlContent := `
+version: "1.0"
+queries:
+  drafts: "status = 'draft' AND modified within '30 days'"
+  stale: "modified before '2024-01-01'"
+`
+	cfg, err := LoadConfig(strings.NewReader(yamlContent))
+	require.NoError(t, err)
+
+	expression, err := cfg.ResolveQuery("@drafts")
+	require.NoError(t, err)
+	assert.Equal(t, "status = 'draft' AND modified within '30 days'", expression)
+
+	expression, err = cfg.ResolveQuery("priority > 3")
+	require.NoError(t, err)
+	assert.Equal(t, "priority > 3", expression)
+
+	_, err = cfg.ResolveQuery("@missing")
+	assert.ErrorContains(t, err, "defined queries: @drafts, @stale")
+
+	_, err = DefaultConfig().ResolveQuery("@drafts")
+	assert.ErrorContains(t, err, "no queries are defined")
+
+	merged := cfg.Merge(Config{Queries: map[string]string{"drafts": "status = 'wip'"}})
+	assert.Equal(t, "status = 'wip'", merged.Queries["drafts"])
+	assert.Contains(t, merged.Queries, "stale")
+}
+
+func TestQualityConfig_WithProfile(t *testing.T) {
+	yamlContent := `
+version: "1.0"
+analysis:
+  quality:
+    weights:
+      readability: 2
+      recency: 1
+    thresholds:
+      excellent: 85
+    profiles:
+      archive:
+        weights:
+          recency: 0
+        thresholds:
+          poor: 30
+        language: de
+        fields:
+          - name: has_source
+            fields: [source, url]
+            weight: 2
+`
+	cfg, err := LoadConfig(strings.NewReader(yamlContent))
+	require.NoError(t, err)
+	quality := cfg.Analysis.Quality
+
+	base, err := quality.WithProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, "", base.Profile)
+	assert.Empty(t, base.Fields)
+
+	archive, err := quality.WithProfile("archive")
+	require.NoError(t, err)
+	assert.Equal(t, "archive", archive.Profile)
+	assert.Equal(t, "de", archive.Language)
+	assert.Equal(t, map[string]float64{"readability": 2, "recency": 0}, archive.Weights)
+	assert.Equal(t, map[string]float64{"excellent": 85, "poor": 30}, archive.Thresholds)
+	assert.Equal(t, []QualityFieldConfig{{Name: "has_source", Fields: []string{"source", "url"}, Weight: 2}}, archive.Fields)
+	assert.Equal(t, 1.0, quality.Weights["recency"], "profiles don't change the base settings")
+
+	_, err = quality.WithProfile("research")
+	assert.ErrorContains(t, err, "available: archive")
+
+	quality.Profile = "archive"
+	defaulted, err := quality.WithProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, "archive", defaulted.Profile)
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/eoinhurrell/mdnotes/internal/secrets"
+)
+
+// keychainRefPattern matches a reference to a secret in the keychain,
+// ${keychain:linkding.token}, which environment expansion leaves alone
+var keychainRefPattern = regexp.MustCompile(`^\$\{keychain:([^}]+)\}$`)
+
+// SecretSetting is a config setting holding a credential
+type SecretSetting struct {
+	Setting string // As written in the config, e.g. linkding.api_token
+	Secret  string // Name it's stored under by 'config set-secret'
+	value   func(*Config) *string
+}
+
+// SecretSettings lists the settings that hold credentials. Their values may
+// reference the keychain as ${keychain:NAME} as well as the environment.
+var SecretSettings = []SecretSetting{
+	{"linkding.api_token", "linkding.token", func(c *Config) *string { return &c.Linkding.APIToken }},
+	{"readwise.api_token", "readwise.token", func(c *Config) *string { return &c.Readwise.APIToken }},
+	{"archive.api_token", "archive.token", func(c *Config) *string { return &c.Archive.APIToken }},
+	{"embeddings.api_key", "embeddings.api_key", func(c *Config) *string { return &c.Embeddings.APIKey }},
+}
+
+// LookupSecretSetting returns the credential setting whose secret is stored
+// under name
+func LookupSecretSetting(name string) (SecretSetting, bool) {
+	for _, s := range SecretSettings {
+		if s.Secret == name {
+			return s, true
+		}
+	}
+	return SecretSetting{}, false
+}
+
+// ValidateSecretName checks that name can be stored and referenced
+func ValidateSecretName(name string) error {
+	if name == "" || strings.ContainsAny(name, "{}$ \t\n") {
+		return fmt.Errorf("invalid secret name '%s': use letters, digits, dots, dashes and underscores", name)
+	}
+	return nil
+}
+
+// PlaintextSecrets returns the credential settings written out in full in
+// the config file, rather than as environment or keychain references
+func (c *Config) PlaintextSecrets() []string {
+	return c.plaintextSecrets
+}
+
+// Secret returns the value of a credential setting, such as
+// "linkding.api_token", reading it from the keychain if it's a
+// ${keychain:NAME} reference. References are resolved only when a client
+// needs the credential, so a missing secret doesn't break other commands.
+func (c *Config) Secret(setting string) (string, error) {
+	for _, s := range SecretSettings {
+		if s.Setting != setting {
+			continue
+		}
+		value := *s.value(c)
+		match := keychainRefPattern.FindStringSubmatch(value)
+		if match == nil {
+			return value, nil
+		}
+		secret, err := secrets.Default.Get(match[1])
+		if errors.Is(err, secrets.ErrNotFound) {
+			return "", fmt.Errorf("%s: secret '%s' is not in the keychain; store it with 'mdnotes config set-secret %s'", s.Setting, match[1], match[1])
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: reading secret '%s': %w", s.Setting, match[1], err)
+		}
+		return secret, nil
+	}
+	return "", fmt.Errorf("unknown credential setting '%s'", setting)
+}
+
+// findPlaintextSecrets notes the credential settings given literally in the
+// unexpanded config content
+func (c *Config) findPlaintextSecrets(content []byte) {
+	var raw Config
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return
+	}
+	for _, s := range SecretSettings {
+		if value := *s.value(&raw); value != "" && !strings.Contains(value, "${") {
+			c.plaintextSecrets = append(c.plaintextSecrets, s.Setting)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package diff produces line-based unified diffs for previewing file changes.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change
+const DefaultContext = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	text string
+	aIdx int // 0-based line in a (valid for equal/delete)
+	bIdx int // 0-based line in b (valid for equal/insert)
+}
+
+// Unified returns a unified diff between two texts, or "" if they are equal
+func Unified(fromName, toName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+
+	ops := lineOps(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops, context) {
+		writeHunk(&out, ops[h[0]:h[1]])
+	}
+	return out.String()
+}
+
+// splitLines splits text into lines, dropping the empty element after a trailing newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOps computes the shortest edit script between a and b (Myers' algorithm)
+func lineOps(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk the trace backwards to recover the edit script
+	var reversed []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{kind: opEqual, text: a[x], aIdx: x, bIdx: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, op{kind: opInsert, text: b[y], aIdx: x, bIdx: y})
+			} else {
+				x--
+				reversed = append(reversed, op{kind: opDelete, text: a[x], aIdx: x, bIdx: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]op, len(reversed))
+	for i, o := range reversed {
+		ops[len(reversed)-1-i] = o
+	}
+	return ops
+}
+
+// hunks returns [start, end) ranges of ops, each covering a group of changes
+// with up to context unchanged lines around it
+func hunks(ops []op, context int) [][2]int {
+	var result [][2]int
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for j := i + 1; j < len(ops); j++ {
+			if ops[j].kind != opEqual {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		end += context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		if n := len(result); n > 0 && start <= result[n-1][1] {
+			result[n-1][1] = end
+		} else {
+			result = append(result, [2]int{start, end})
+		}
+		i = end - 1
+	}
+	return result
+}
+
+func writeHunk(out *strings.Builder, ops []op) {
+	aStart, bStart := -1, -1
+	aLen, bLen := 0, 0
+	for _, o := range ops {
+		if o.kind != opInsert {
+			if aStart < 0 {
+				aStart = o.aIdx
+			}
+			aLen++
+		}
+		if o.kind != opDelete {
+			if bStart < 0 {
+				bStart = o.bIdx
+			}
+			bLen++
+		}
+	}
+	// An empty range is reported as the line before it, per the unified format
+	if aStart < 0 {
+		aStart = ops[0].aIdx - 1
+	}
+	if bStart < 0 {
+		bStart = ops[0].bIdx - 1
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart+1, aLen, bStart+1, bLen)
+	for _, o := range ops {
+		fmt.Fprintf(out, "%c%s\n", o.kind, o.text)
+	}
+}
@@ -0,0 +1,52 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	assert.Equal(t, "", Unified("a", "b", "same\n", "same\n", DefaultContext))
+}
+
+func TestUnified_SingleChange(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\n"
+	b := "one\ntwo\nTHREE\nfour\nfive\n"
+
+	want := `--- a/note.md
++++ b/note.md
+@@ -2,3 +2,3 @@
+ two
+-three
++THREE
+ four
+`
+	assert.Equal(t, want, Unified("a/note.md", "b/note.md", a, b, 1))
+}
+
+func TestUnified_InsertAndDelete(t *testing.T) {
+	a := "---\ntitle: A\n"
+	b := "---\ntitle: A\n---\n"
+	assert.Equal(t, "--- a\n+++ b\n@@ -2,1 +2,2 @@\n title: A\n+---\n", Unified("a", "b", a, b, 1))
+
+	assert.Equal(t, "--- a\n+++ b\n@@ -1,1 +0,0 @@\n-gone\n", Unified("a", "b", "gone\n", "", 1))
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		a = append(a, line)
+		if i == 2 || i == 17 {
+			line = strings.ToUpper(line)
+		}
+		b = append(b, line)
+	}
+
+	out := Unified("a", "b", strings.Join(a, "\n"), strings.Join(b, "\n"), 2)
+	assert.Equal(t, 2, strings.Count(out, "@@ -"))
+	assert.Contains(t, out, "@@ -1,5 +1,5 @@\n a\n b\n-c\n+C\n d\n e\n")
+	assert.Contains(t, out, "@@ -16,5 +16,5 @@\n p\n q\n-r\n+R\n s\n t\n")
+}
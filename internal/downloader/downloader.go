@@ -1,7 +1,10 @@
 package downloader
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime"
@@ -9,10 +12,12 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 )
 
 // Downloader handles downloading web resources
@@ -21,8 +26,14 @@ type Downloader struct {
 	config      config.DownloadConfig
 	userAgent   string
 	maxFileSize int64
+	retries     int
+	retryDelay  time.Duration
+	state       *State
 }
 
+// maxRetryDelay caps the wait between retries, including one a server asks for
+const maxRetryDelay = time.Minute
+
 // NewDownloader creates a new downloader with the given configuration
 func NewDownloader(cfg config.DownloadConfig) (*Downloader, error) {
 	// Use default timeout if empty
@@ -61,6 +72,14 @@ func NewDownloader(cfg config.DownloadConfig) (*Downloader, error) {
 		attachmentsDir = "./resources/attachments"
 	}
 
+	retryDelay := time.Second
+	if cfg.RetryDelay != "" {
+		retryDelay, err = time.ParseDuration(cfg.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay: %w", err)
+		}
+	}
+
 	// Update config with defaults
 	finalConfig := cfg
 	finalConfig.Timeout = timeoutStr
@@ -73,9 +92,26 @@ func NewDownloader(cfg config.DownloadConfig) (*Downloader, error) {
 		config:      finalConfig,
 		userAgent:   userAgent,
 		maxFileSize: maxFileSize,
+		retries:     max(cfg.Retries, 0),
+		retryDelay:  retryDelay,
 	}, nil
 }
 
+// SetState makes the downloader consult and record to state, skipping URLs
+// it already holds and content already saved under another name
+func (d *Downloader) SetState(state *State) {
+	d.state = state
+}
+
+// MaxDuration is the longest a download can take, through all its retries
+func (d *Downloader) MaxDuration() time.Duration {
+	total := time.Duration(d.retries+1) * d.client.Timeout
+	for n := 0; n < d.retries; n++ {
+		total += min(d.retryDelay<<n, maxRetryDelay)
+	}
+	return total
+}
+
 // DownloadResult contains information about a downloaded file
 type DownloadResult struct {
 	LocalPath   string
@@ -84,9 +120,14 @@ type DownloadResult struct {
 	Size        int64
 	Extension   string
 	Skipped     bool // Indicates file already existed and was skipped
+	Cached      bool // The file was downloaded before, from this URL or with the same content
 }
 
-// DownloadResource downloads a resource from a URL to a local file
+// DownloadResource downloads a resource from a URL to a local file. Failed
+// requests are retried with exponential backoff, and the content has to be
+// one of the allowed types. With a state set, a URL downloaded before isn't
+// fetched again, and content matching a file already downloaded, by ETag or
+// hash, reuses that file.
 func (d *Downloader) DownloadResource(ctx context.Context, urlStr, baseFilename, attributeName string) (*DownloadResult, error) {
 	// Parse and validate URL
 	parsedURL, err := url.Parse(urlStr)
@@ -98,30 +139,41 @@ func (d *Downloader) DownloadResource(ctx context.Context, urlStr, baseFilename,
 		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+	if d.state != nil {
+		if entry, ok := d.state.Lookup(urlStr); ok {
+			return cachedResult(urlStr, entry), nil
+		}
 	}
 
-	req.Header.Set("User-Agent", d.userAgent)
-
-	// Make the request
-	resp, err := d.client.Do(req)
+	resp, err := d.get(ctx, urlStr)
 	if err != nil {
-		return nil, fmt.Errorf("downloading resource: %w", err)
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
-	}
-
 	// Check content length if provided
 	if resp.ContentLength > 0 && resp.ContentLength > d.maxFileSize {
 		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", resp.ContentLength, d.maxFileSize)
 	}
 
+	body := bufio.NewReader(resp.Body)
+	contentType, err := d.checkContentType(resp, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// A strong ETag names the content, so one already seen from the same
+	// host needn't be downloaded again
+	etag := resp.Header.Get("ETag")
+	if strings.HasPrefix(etag, "W/") {
+		etag = ""
+	}
+	if d.state != nil && etag != "" {
+		if entry, ok := d.state.lookupETag(parsedURL.Host, etag); ok {
+			return d.reuse(urlStr, entry)
+		}
+	}
+
 	// Determine file extension from content type or URL
 	extension := d.determineExtension(resp, urlStr)
 
@@ -139,45 +191,214 @@ func (d *Downloader) DownloadResource(ctx context.Context, urlStr, baseFilename,
 		return &DownloadResult{
 			LocalPath:   localPath,
 			OriginalURL: urlStr,
-			ContentType: resp.Header.Get("Content-Type"),
+			ContentType: contentType,
 			Size:        stat.Size(), // Use existing file size
 			Extension:   extension,
 			Skipped:     true, // Mark as skipped
 		}, nil // Not an error, just skipped
 	}
 
-	// Create local file
-	file, err := os.Create(localPath)
+	// Create local file; it only appears once the download is complete
+	file, err := safety.CreateAtomic(localPath, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("creating local file: %w", err)
 	}
-	defer file.Close()
+	defer file.Abort()
 
-	// Copy with size limit
-	limitedReader := io.LimitReader(resp.Body, d.maxFileSize+1)
-	bytesWritten, err := io.Copy(file, limitedReader)
+	// Copy with size limit, hashing the content on the way
+	hash := sha256.New()
+	limitedReader := io.LimitReader(body, d.maxFileSize+1)
+	bytesWritten, err := io.Copy(io.MultiWriter(file, hash), limitedReader)
 	if err != nil {
-		// Clean up partial file on error
-		_ = os.Remove(localPath)
 		return nil, fmt.Errorf("copying file content: %w", err)
 	}
 
 	// Check if we exceeded the size limit
 	if bytesWritten > d.maxFileSize {
-		_ = os.Remove(localPath)
 		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", bytesWritten, d.maxFileSize)
 	}
 
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if d.state != nil {
+		if entry, ok := d.state.lookupHash(sum); ok {
+			return d.reuse(urlStr, entry)
+		}
+	}
+
+	if err := file.Commit(); err != nil {
+		return nil, fmt.Errorf("saving file: %w", err)
+	}
+
+	if d.state != nil {
+		absPath, err := filepath.Abs(localPath)
+		if err != nil {
+			absPath = localPath
+		}
+		err = d.state.Record(StateEntry{
+			URL:         urlStr,
+			LocalPath:   absPath,
+			ContentType: contentType,
+			Size:        bytesWritten,
+			SHA256:      sum,
+			ETag:        etag,
+			Downloaded:  time.Now().UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &DownloadResult{
 		LocalPath:   localPath,
 		OriginalURL: urlStr,
-		ContentType: resp.Header.Get("Content-Type"),
+		ContentType: contentType,
 		Size:        bytesWritten,
 		Extension:   extension,
 		Skipped:     false, // Actually downloaded
 	}, nil
 }
 
+// get requests a URL, retrying network errors, server errors and rate
+// limiting with a delay that doubles each time. Other client errors, such as
+// 404, fail straight away.
+func (d *Downloader) get(ctx context.Context, urlStr string) (*http.Response, error) {
+	delay := d.retryDelay
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("User-Agent", d.userAgent)
+
+		wait := delay
+		retryable := true
+		resp, err := d.client.Do(req)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("downloading resource: %w", ctx.Err())
+			}
+			err = fmt.Errorf("downloading resource: %w", err)
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		default:
+			retryable = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests ||
+				resp.StatusCode == http.StatusRequestTimeout
+			if after := retryAfter(resp.Header.Get("Retry-After")); after > wait {
+				wait = after
+			}
+			_ = resp.Body.Close()
+			err = fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		}
+
+		if !retryable || attempt >= d.retries {
+			if attempt > 0 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return nil, err
+		}
+
+		timer := time.NewTimer(min(wait, maxRetryDelay))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("downloading resource: %w", ctx.Err())
+		}
+		delay *= 2
+	}
+}
+
+// retryAfter parses a Retry-After header, in seconds or as a date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// checkContentType returns the media type of a response and checks it is
+// allowed. When the server doesn't say, or only says it is binary, the type
+// is sniffed from the first bytes of the body. An HTML page served as
+// something else, as login and error pages often are, is rejected.
+func (d *Downloader) checkContentType(resp *http.Response, body *bufio.Reader) (string, error) {
+	head, _ := body.Peek(512)
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = sniffed
+		// The extension comes from the content type
+		resp.Header.Set("Content-Type", mediaType)
+	}
+	if sniffed == "text/html" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", fmt.Errorf("expected %s but got an HTML page", mediaType)
+	}
+
+	if !TypeAllowed(mediaType, d.config.AllowedTypes) {
+		return "", fmt.Errorf("content type %s is not allowed (allowed: %s)", mediaType, strings.Join(d.config.AllowedTypes, ", "))
+	}
+	return mediaType, nil
+}
+
+// TypeAllowed reports whether a media type matches one of the allowed
+// patterns: a full type such as application/pdf, or a wildcard such as
+// image/*. No patterns allow everything.
+func TypeAllowed(mediaType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType = strings.ToLower(mediaType)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == "*/*" || pattern == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// reuse records urlStr as another source of a file already downloaded
+func (d *Downloader) reuse(urlStr string, entry StateEntry) (*DownloadResult, error) {
+	entry.URL = urlStr
+	entry.Downloaded = time.Now().UTC()
+	if err := d.state.Record(entry); err != nil {
+		return nil, err
+	}
+	return cachedResult(urlStr, entry), nil
+}
+
+// cachedResult is the result for a URL whose file was already downloaded
+func cachedResult(urlStr string, entry StateEntry) *DownloadResult {
+	return &DownloadResult{
+		LocalPath:   entry.LocalPath,
+		OriginalURL: urlStr,
+		ContentType: entry.ContentType,
+		Size:        entry.Size,
+		Extension:   filepath.Ext(entry.LocalPath),
+		Skipped:     true,
+		Cached:      true,
+	}
+}
+
+// hostOf returns the host of a URL
+func hostOf(urlStr string) string {
+	parsed, err := url.Parse(urlStr)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
 // determineExtension determines the file extension from HTTP response or URL
 func (d *Downloader) determineExtension(resp *http.Response, urlStr string) string {
 	// First try from Content-Type header
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -203,6 +205,162 @@ func TestDownloadResource_ContentTypes(t *testing.T) {
 	}
 }
 
+func TestDownloadResource_RetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fake png content"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	downloader, err := NewDownloader(config.DownloadConfig{AttachmentsDir: tmpDir, Retries: 3, RetryDelay: "1ms"})
+	require.NoError(t, err)
+
+	result, err := downloader.DownloadResource(context.Background(), server.URL+"/image.png", "note", "cover")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, filepath.Join(tmpDir, "note-cover.png"), result.LocalPath)
+
+	// Running out of retries reports the attempts
+	requests.Store(-10)
+	_, err = downloader.DownloadResource(context.Background(), server.URL+"/other.png", "note", "other")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.Contains(t, err.Error(), "after 4 attempts")
+}
+
+func TestDownloadResource_NoRetryOnClientError(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	downloader, err := NewDownloader(config.DownloadConfig{AttachmentsDir: t.TempDir(), Retries: 3, RetryDelay: "1ms"})
+	require.NoError(t, err)
+
+	_, err = downloader.DownloadResource(context.Background(), server.URL, "note", "cover")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestDownloadResource_AllowedTypes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body>Hello</body></html>"))
+		case "/login":
+			// A login page where an image should be
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("<!DOCTYPE html><html><body>Sign in</body></html>"))
+		default:
+			// No content type, so it is sniffed
+			w.Header().Set("Content-Type", "")
+			w.Write([]byte("%PDF-1.4 fake pdf"))
+		}
+	}))
+	defer server.Close()
+
+	downloader, err := NewDownloader(config.DownloadConfig{
+		AttachmentsDir: t.TempDir(),
+		AllowedTypes:   []string{"image/*", "application/pdf"},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = downloader.DownloadResource(ctx, server.URL+"/page", "note", "page")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "content type text/html is not allowed")
+
+	_, err = downloader.DownloadResource(ctx, server.URL+"/login", "note", "login")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTML page")
+
+	result, err := downloader.DownloadResource(ctx, server.URL+"/paper", "note", "paper")
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", result.ContentType)
+	assert.Equal(t, ".pdf", result.Extension)
+}
+
+func TestTypeAllowed(t *testing.T) {
+	allowed := []string{"image/*", "application/pdf"}
+	assert.True(t, TypeAllowed("image/png", allowed))
+	assert.True(t, TypeAllowed("application/pdf", allowed))
+	assert.False(t, TypeAllowed("application/pdf+zip", allowed))
+	assert.False(t, TypeAllowed("imagery/png", allowed))
+	assert.False(t, TypeAllowed("text/html", allowed))
+	assert.True(t, TypeAllowed("text/html", nil))
+	assert.True(t, TypeAllowed("text/html", []string{"*/*"}))
+}
+
+func TestDownloadResource_State(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/tagged.png" || r.URL.Path == "/tagged-again.png" {
+			w.Header().Set("ETag", `"v1"`)
+		}
+		w.Write([]byte("same png content"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, ".mdnotes", "downloads.json")
+	state, err := LoadState(statePath)
+	require.NoError(t, err)
+	downloader, err := NewDownloader(config.DownloadConfig{AttachmentsDir: tmpDir})
+	require.NoError(t, err)
+	downloader.SetState(state)
+	ctx := context.Background()
+
+	first, err := downloader.DownloadResource(ctx, server.URL+"/tagged.png", "a", "cover")
+	require.NoError(t, err)
+	assert.False(t, first.Skipped)
+
+	// The same URL isn't requested again
+	again, err := downloader.DownloadResource(ctx, server.URL+"/tagged.png", "b", "cover")
+	require.NoError(t, err)
+	assert.True(t, again.Cached)
+	assert.Equal(t, "a-cover.png", filepath.Base(again.LocalPath))
+	assert.Equal(t, int32(1), requests.Load())
+
+	// The same ETag from the same host reuses the file
+	tagged, err := downloader.DownloadResource(ctx, server.URL+"/tagged-again.png", "c", "cover")
+	require.NoError(t, err)
+	assert.True(t, tagged.Cached)
+	assert.Equal(t, "a-cover.png", filepath.Base(tagged.LocalPath))
+
+	// As does the same content from another URL, without keeping a copy
+	copied, err := downloader.DownloadResource(ctx, server.URL+"/copy.png", "d", "cover")
+	require.NoError(t, err)
+	assert.True(t, copied.Cached)
+	assert.Equal(t, "a-cover.png", filepath.Base(copied.LocalPath))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "d-cover.png"))
+
+	// The state survives for the next run
+	reloaded, err := LoadState(statePath)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reloaded.Len())
+	entry, ok := reloaded.Lookup(server.URL + "/copy.png")
+	require.True(t, ok)
+	assert.Equal(t, first.Size, entry.Size)
+	assert.Len(t, entry.SHA256, 64)
+
+	// Downloads whose file is gone are fetched again
+	require.NoError(t, os.Remove(first.LocalPath))
+	_, ok = reloaded.Lookup(server.URL + "/tagged.png")
+	assert.False(t, ok)
+}
+
 // Benchmark test
 func BenchmarkDownloadResource(b *testing.B) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
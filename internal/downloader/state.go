@@ -0,0 +1,157 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// stateVersion is the version of the state file format
+const stateVersion = 1
+
+// StateEntry records a URL that was downloaded and where it was saved
+type StateEntry struct {
+	URL         string    `json:"url"`
+	LocalPath   string    `json:"local_path"`
+	ContentType string    `json:"content_type,omitempty"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	ETag        string    `json:"etag,omitempty"`
+	Downloaded  time.Time `json:"downloaded"`
+}
+
+// State remembers what earlier downloads saved, so a URL is fetched once
+// however many notes use it, content already on disk isn't saved twice, and
+// a run that was cut short picks up where it stopped. It is saved after
+// every download and is safe for concurrent use.
+type State struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]StateEntry // By URL
+}
+
+// stateFile is the state as it is stored on disk
+type stateFile struct {
+	Version   int          `json:"version"`
+	Downloads []StateEntry `json:"downloads"`
+}
+
+// LoadState reads the state saved at path. A missing file is an empty state.
+func LoadState(path string) (*State, error) {
+	state := &State{path: path, entries: make(map[string]StateEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading download state: %w", err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing download state %s: %w", path, err)
+	}
+	if file.Version > stateVersion {
+		return nil, fmt.Errorf("download state %s is version %d, newer than this mdnotes supports", path, file.Version)
+	}
+	for _, entry := range file.Downloads {
+		state.entries[entry.URL] = entry
+	}
+	return state, nil
+}
+
+// Len returns the number of downloads recorded
+func (s *State) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// Lookup returns the download recorded for a URL, as long as its file is
+// still there
+func (s *State) Lookup(url string) (StateEntry, bool) {
+	s.mu.Lock()
+	entry, ok := s.entries[url]
+	s.mu.Unlock()
+	return entry, ok && fileExists(entry.LocalPath)
+}
+
+// lookupETag returns a download from host served with the given entity tag
+func (s *State) lookupETag(host, etag string) (StateEntry, bool) {
+	return s.find(func(entry StateEntry) bool {
+		return entry.ETag == etag && hostOf(entry.URL) == host
+	})
+}
+
+// lookupHash returns a download whose content has the given SHA-256
+func (s *State) lookupHash(sum string) (StateEntry, bool) {
+	return s.find(func(entry StateEntry) bool {
+		return entry.SHA256 == sum
+	})
+}
+
+// find returns the first download, by URL, matching a condition whose
+// file is still there
+func (s *State) find(match func(StateEntry) bool) (StateEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []StateEntry
+	for _, entry := range s.entries {
+		if match(entry) {
+			found = append(found, entry)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].URL < found[j].URL })
+	for _, entry := range found {
+		if fileExists(entry.LocalPath) {
+			return entry, true
+		}
+	}
+	return StateEntry{}, false
+}
+
+// Record adds a download and saves the state
+func (s *State) Record(entry StateEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.URL] = entry
+	return s.save()
+}
+
+// Save writes the state to its file
+func (s *State) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+func (s *State) save() error {
+	file := stateFile{Version: stateVersion, Downloads: make([]StateEntry, 0, len(s.entries))}
+	for _, entry := range s.entries {
+		file.Downloads = append(file.Downloads, entry)
+	}
+	sort.Slice(file.Downloads, func(i, j int) bool { return file.Downloads[i].URL < file.Downloads[j].URL })
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("creating download state directory: %w", err)
+	}
+	if err := safety.WriteFile(s.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("saving download state: %w", err)
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,150 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestLocalProvider(t *testing.T) {
+	p, err := NewProvider(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "local:hash-512", p.Model())
+
+	vectors, err := p.Embed(context.Background(), []string{
+		"Raised beds and compost for the vegetable garden",
+		"Compost for vegetable beds",
+		"Upgrading the Kubernetes cluster",
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], 512)
+	assert.InDelta(t, 1, Cosine(vectors[0], vectors[0]), 1e-6)
+	assert.Greater(t, Cosine(vectors[0], vectors[1]), Cosine(vectors[0], vectors[2]))
+
+	again, err := p.Embed(context.Background(), []string{"Compost for vegetable beds"})
+	require.NoError(t, err)
+	assert.Equal(t, vectors[1], again[0], "embeddings are deterministic")
+
+	_, err = NewProvider(Options{Model: "word2vec"})
+	assert.ErrorContains(t, err, "unknown local model")
+	_, err = NewProvider(Options{Provider: "bert"})
+	assert.ErrorContains(t, err, "unknown embeddings provider")
+}
+
+func TestRemoteProviders(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch r.URL.Path {
+		case "/api/embed":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{1, 0}, {0, 1}}})
+		case "/v1/embeddings":
+			// Out of order, as the API allows
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": []map[string]interface{}{
+				{"index": 1, "embedding": []float32{0, 1}},
+				{"index": 0, "embedding": []float32{1, 0}},
+			}})
+		default:
+			http.Error(w, "model not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ollama, err := NewProvider(Options{Provider: ProviderOllama, APIURL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "ollama:nomic-embed-text", ollama.Model())
+	vectors, err := ollama.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+	assert.Empty(t, gotAuth)
+
+	openai, err := NewProvider(Options{Provider: ProviderOpenAI, APIURL: server.URL, APIKey: "sk-test"})
+	require.NoError(t, err)
+	vectors, err = openai.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+	assert.Equal(t, "Bearer sk-test", gotAuth)
+
+	t.Setenv(OpenAIKeyEnvVar, "")
+	_, err = NewProvider(Options{Provider: ProviderOpenAI})
+	assert.ErrorContains(t, err, "API key")
+
+	broken, err := NewProvider(Options{Provider: ProviderOllama, APIURL: server.URL + "/missing"})
+	require.NoError(t, err)
+	_, err = broken.Embed(context.Background(), []string{"a"})
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func TestOptionsOverride(t *testing.T) {
+	configured := Options{Provider: ProviderOllama, Model: "mxbai-embed-large", APIURL: "http://gpu:11434"}
+
+	assert.Equal(t, configured, configured.Override("", ""))
+	assert.Equal(t, "all-minilm", configured.Override(ProviderOllama, "all-minilm").Model)
+	assert.Equal(t, Options{Provider: ProviderLocal}, configured.Override(ProviderLocal, ""))
+}
+
+func TestRank(t *testing.T) {
+	docs := []Document{
+		{Path: "garden.md", Text: "garden soil compost", Vector: []float32{1, 0}},
+		{Path: "cluster.md", Text: "kubernetes cluster nodes", Vector: []float32{0, 1}},
+		{Path: "unembedded.md", Text: "garden tools"},
+	}
+
+	results := Rank("garden", []float32{1, 0.1}, docs, 0, 0)
+	require.Len(t, results, 2, "notes without vectors or similarity are left out")
+	assert.Equal(t, "garden.md", results[0].Path)
+
+	results = Rank("garden", []float32{1, 0.1}, docs, 0.5, 2)
+	require.Len(t, results, 2)
+	assert.Equal(t, "garden.md", results[0].Path)
+	assert.Equal(t, "unembedded.md", results[1].Path, "keyword matches rank without a vector")
+
+	results = Rank("kubernetes", nil, docs, 1, 0)
+	require.Len(t, results, 1)
+	assert.Equal(t, "cluster.md", results[0].Path)
+	assert.Equal(t, 1.0, results[0].Keyword)
+}
+
+func TestEmbedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("---\ntitle: Alpha\n---\nFirst note"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.md"), []byte("Second note"), 0644))
+
+	idx := vault.NewIndex(dir)
+	files, err := vault.NewScanner(vault.WithIndex(idx)).Walk(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Alpha\n\nFirst note", NoteText(files[0]))
+
+	provider, err := NewProvider(Options{Model: "hash-32"})
+	require.NoError(t, err)
+	stats, err := EmbedFiles(context.Background(), provider, idx, files, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, EmbedStats{Embedded: 2}, stats)
+
+	stats, err = EmbedFiles(context.Background(), provider, idx, files, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, EmbedStats{Unchanged: 2}, stats)
+
+	stats, err = EmbedFiles(context.Background(), provider, idx, files, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, EmbedStats{Embedded: 2}, stats)
+
+	vector, ok := idx.Embedding(files[1].Path, "local:hash-32")
+	assert.True(t, ok)
+	assert.Len(t, vector, 32)
+}
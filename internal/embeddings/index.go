@@ -0,0 +1,77 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// maxTextLength caps how much of a note is embedded, keeping requests within
+// typical model context limits
+const maxTextLength = 8000
+
+// NoteText is the text embedded for a note: its title followed by its body
+func NoteText(file *vault.VaultFile) string {
+	title := strings.TrimSuffix(filepath.Base(file.RelativePath), filepath.Ext(file.RelativePath))
+	if t, ok := file.Frontmatter["title"].(string); ok && t != "" {
+		title = t
+	}
+	text := title + "\n\n" + strings.TrimSpace(file.Body)
+	if len(text) > maxTextLength {
+		// Cut on a rune boundary
+		text = strings.ToValidUTF8(text[:maxTextLength], "")
+	}
+	return text
+}
+
+// EmbedStats summarizes an EmbedFiles run
+type EmbedStats struct {
+	Embedded  int // Notes sent to the provider
+	Unchanged int // Notes that already had a current vector
+	Skipped   int // Notes the index can't hold, so they have no vector
+}
+
+// EmbedFiles stores a vector in idx for every file that lacks a current one
+// from the provider's model, sending texts in batches of batchSize. With force
+// every file is re-embedded. The index is not saved.
+func EmbedFiles(ctx context.Context, provider Provider, idx *vault.Index, files []*vault.VaultFile, batchSize int, force bool) (EmbedStats, error) {
+	var stats EmbedStats
+	if batchSize <= 0 {
+		batchSize = 32
+	}
+	model := provider.Model()
+
+	var pending []*vault.VaultFile
+	for _, file := range files {
+		if !idx.IsCurrent(file.Path) {
+			stats.Skipped++
+			continue
+		}
+		if _, ok := idx.Embedding(file.Path, model); ok && !force {
+			stats.Unchanged++
+			continue
+		}
+		pending = append(pending, file)
+	}
+
+	for start := 0; start < len(pending); start += batchSize {
+		batch := pending[start:min(start+batchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for i, file := range batch {
+			texts[i] = NoteText(file)
+		}
+
+		vectors, err := provider.Embed(ctx, texts)
+		if err != nil {
+			return stats, fmt.Errorf("embedding notes: %w", err)
+		}
+		for i, file := range batch {
+			idx.SetEmbedding(file.Path, model, vectors[i])
+			stats.Embedded++
+		}
+	}
+	return stats, nil
+}
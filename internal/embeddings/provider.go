@@ -0,0 +1,303 @@
+// Package embeddings turns note text into vectors for semantic search, through
+// a remote embeddings API or a local model, and ranks notes against a query.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Provider names
+const (
+	ProviderLocal  = "local"
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Default models and endpoints for each provider
+const (
+	DefaultLocalModel  = "hash-512"
+	DefaultOllamaModel = "nomic-embed-text"
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOpenAIModel = "text-embedding-3-small"
+	DefaultOpenAIURL   = "https://api.openai.com"
+)
+
+// OpenAIKeyEnvVar holds the OpenAI API key when none is configured
+const OpenAIKeyEnvVar = "OPENAI_API_KEY"
+
+// Provider embeds texts as vectors
+type Provider interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model identifies the provider and model, e.g. "ollama:nomic-embed-text".
+	// Vectors from different models can't be compared.
+	Model() string
+}
+
+// Options configure a provider. Empty fields take the provider's defaults.
+type Options struct {
+	Provider   string
+	Model      string
+	APIURL     string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// Override replaces the provider and model with any non-empty values given,
+// as from command-line flags. Choosing a different provider drops the
+// configured model and endpoint, which belong to the old one.
+func (o Options) Override(provider, model string) Options {
+	if provider != "" && provider != o.Provider {
+		o.Provider = provider
+		o.Model, o.APIURL = "", ""
+	}
+	if model != "" {
+		o.Model = model
+	}
+	return o
+}
+
+// Providers lists the supported provider names
+func Providers() []string {
+	return []string{ProviderLocal, ProviderOllama, ProviderOpenAI}
+}
+
+// NewProvider creates the provider named in opts, defaulting to the local one
+func NewProvider(opts Options) (Provider, error) {
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Minute}
+	}
+
+	switch opts.Provider {
+	case "", ProviderLocal:
+		model := opts.Model
+		if model == "" {
+			model = DefaultLocalModel
+		}
+		dims, err := localDimensions(model)
+		if err != nil {
+			return nil, err
+		}
+		return &localProvider{model: model, dims: dims}, nil
+	case ProviderOllama:
+		return &ollamaProvider{
+			baseURL: strings.TrimRight(orDefault(opts.APIURL, DefaultOllamaURL), "/"),
+			model:   orDefault(opts.Model, DefaultOllamaModel),
+			client:  client,
+		}, nil
+	case ProviderOpenAI:
+		if opts.APIKey == "" {
+			opts.APIKey = os.Getenv(OpenAIKeyEnvVar)
+		}
+		if opts.APIKey == "" {
+			return nil, fmt.Errorf("the openai provider needs an API key (embeddings.api_key or OPENAI_API_KEY)")
+		}
+		return &openAIProvider{
+			baseURL: strings.TrimRight(orDefault(opts.APIURL, DefaultOpenAIURL), "/"),
+			model:   orDefault(opts.Model, DefaultOpenAIModel),
+			apiKey:  opts.APIKey,
+			client:  client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q (use %s)", opts.Provider, strings.Join(Providers(), ", "))
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// localProvider hashes words and word pairs into a fixed number of
+// dimensions. It needs no model download or network access and captures
+// shared vocabulary rather than meaning, which is enough to find notes about
+// the same subject.
+type localProvider struct {
+	model string
+	dims  int
+}
+
+// localDimensions parses the vector size from a local model name like "hash-512"
+func localDimensions(model string) (int, error) {
+	var dims int
+	if _, err := fmt.Sscanf(model, "hash-%d", &dims); err != nil || dims < 16 || dims > 8192 {
+		return 0, fmt.Errorf("unknown local model %q (use hash-<dimensions>, e.g. %s)", model, DefaultLocalModel)
+	}
+	return dims, nil
+}
+
+func (p *localProvider) Model() string {
+	return ProviderLocal + ":" + p.model
+}
+
+func (p *localProvider) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, p.dims)
+		words := Tokenize(text)
+		for j, word := range words {
+			p.add(vec, word, 1)
+			if j > 0 {
+				p.add(vec, words[j-1]+" "+word, 0.5)
+			}
+		}
+		// Dampen frequent words so long notes aren't dominated by them
+		for k, v := range vec {
+			if v != 0 {
+				vec[k] = float32(math.Copysign(math.Log1p(math.Abs(float64(v))), float64(v)))
+			}
+		}
+		vectors[i] = Normalize(vec)
+	}
+	return vectors, nil
+}
+
+// add hashes a feature into vec, using a second hash bit as the sign so
+// collisions tend to cancel out
+func (p *localProvider) add(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vec[sum%uint64(p.dims)] += weight
+}
+
+type ollamaProvider struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func (p *ollamaProvider) Model() string {
+	return ProviderOllama + ":" + p.model
+}
+
+func (p *ollamaProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	body := map[string]interface{}{"model": p.model, "input": texts}
+	if err := postJSON(ctx, p.client, p.baseURL+"/api/embed", "", body, &resp); err != nil {
+		return nil, fmt.Errorf("ollama: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama: got %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
+
+type openAIProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+func (p *openAIProvider) Model() string {
+	return ProviderOpenAI + ":" + p.model
+}
+
+func (p *openAIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	body := map[string]interface{}{"model": p.model, "input": texts}
+	if err := postJSON(ctx, p.client, p.baseURL+"/v1/embeddings", p.apiKey, body, &resp); err != nil {
+		return nil, fmt.Errorf("openai: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("openai: embedding index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("openai: no embedding returned for text %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// postJSON sends body to url and decodes the JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url, token string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// Tokenize splits text into lowercase words, dropping punctuation and
+// single characters
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) > 1 {
+			words = append(words, f)
+		}
+	}
+	return words
+}
+
+// Normalize scales vec to unit length in place and returns it
+func Normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return vec
+}
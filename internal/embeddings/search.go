@@ -0,0 +1,133 @@
+package embeddings
+
+import (
+	"math"
+	"sort"
+)
+
+// Document is a note that can be ranked against a query
+type Document struct {
+	Path   string
+	Text   string    // Used for keyword scoring
+	Vector []float32 // nil if the note hasn't been embedded
+}
+
+// Result is a ranked document with its component scores
+type Result struct {
+	Path    string  `json:"path"`
+	Score   float64 `json:"score"`
+	Vector  float64 `json:"vector_score"`
+	Keyword float64 `json:"keyword_score"`
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 if their sizes differ
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// KeywordScores scores each document against the query with BM25, scaled so
+// the best match scores 1
+func KeywordScores(query string, docs []Document) []float64 {
+	const k1, b = 1.2, 0.75
+
+	terms := Tokenize(query)
+	scores := make([]float64, len(docs))
+	if len(terms) == 0 || len(docs) == 0 {
+		return scores
+	}
+
+	freqs := make([]map[string]int, len(docs))
+	docFreq := make(map[string]int)
+	totalLen := 0
+	for i, doc := range docs {
+		words := Tokenize(doc.Text)
+		totalLen += len(words)
+		freqs[i] = make(map[string]int)
+		for _, w := range words {
+			freqs[i][w]++
+		}
+		for _, t := range terms {
+			if freqs[i][t] > 0 {
+				docFreq[t]++
+			}
+		}
+	}
+	avgLen := float64(totalLen) / float64(len(docs))
+	if avgLen == 0 {
+		return scores
+	}
+
+	best := 0.0
+	for i := range docs {
+		docLen := 0
+		for _, n := range freqs[i] {
+			docLen += n
+		}
+		for _, t := range terms {
+			tf := float64(freqs[i][t])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log(1 + (float64(len(docs))-float64(docFreq[t])+0.5)/(float64(docFreq[t])+0.5))
+			scores[i] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(docLen)/avgLen))
+		}
+		best = math.Max(best, scores[i])
+	}
+	if best > 0 {
+		for i := range scores {
+			scores[i] /= best
+		}
+	}
+	return scores
+}
+
+// Rank orders docs by similarity to the query vector, blended with keyword
+// relevance by keywordWeight (0 ranks by vectors alone, 1 by keywords alone),
+// and returns the best topK (all if topK <= 0). Documents with no vector can
+// still rank through their keyword score.
+func Rank(query string, queryVector []float32, docs []Document, keywordWeight float64, topK int) []Result {
+	keywordWeight = math.Max(0, math.Min(1, keywordWeight))
+	var keyword []float64
+	if keywordWeight > 0 {
+		keyword = KeywordScores(query, docs)
+	}
+
+	results := make([]Result, 0, len(docs))
+	for i, doc := range docs {
+		r := Result{Path: doc.Path}
+		if doc.Vector != nil {
+			r.Vector = Cosine(queryVector, doc.Vector)
+		}
+		if keyword != nil {
+			r.Keyword = keyword[i]
+		}
+		r.Score = (1-keywordWeight)*r.Vector + keywordWeight*r.Keyword
+		if r.Score <= 0 {
+			continue
+		}
+		results = append(results, r)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	if topK > 0 && len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
@@ -0,0 +1,246 @@
+// Package events publishes structured vault change events so external tools
+// can react to changes made by mdnotes commands or seen by watch mode without
+// polling the vault.
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Event types
+const (
+	Created      = "created"
+	Modified     = "modified"
+	Renamed      = "renamed"
+	Deleted      = "deleted"
+	FieldChanged = "field_changed"
+	Digest       = "digest" // A vault digest report, with its markdown in New
+)
+
+// Event describes a single change to a vault file
+type Event struct {
+	Time        time.Time   `json:"time"`
+	Type        string      `json:"type"`
+	Path        string      `json:"path"`
+	From        string      `json:"from,omitempty"`  // Previous path of a renamed file
+	Field       string      `json:"field,omitempty"` // Frontmatter field of a field_changed event
+	Old         interface{} `json:"old,omitempty"`
+	New         interface{} `json:"new,omitempty"`
+	Source      string      `json:"source"`                // Command that made the change, or "watch"
+	Transaction string      `json:"transaction,omitempty"` // Journal transaction, for undo
+	Reason      string      `json:"reason,omitempty"`      // Why the change was made, e.g. a lifecycle rule
+}
+
+// Sink receives published events
+type Sink interface {
+	Publish(events []Event) error
+}
+
+// Publisher fans events out to the configured sinks
+type Publisher struct {
+	sinks []Sink
+}
+
+// NewPublisher creates a publisher from config. It returns nil when no event
+// log or webhook is configured; publishing to a nil publisher does nothing.
+func NewPublisher(cfg config.EventsConfig) *Publisher {
+	var sinks []Sink
+	if cfg.Log != "" {
+		sinks = append(sinks, NewLogSink(cfg.Log))
+	}
+	if cfg.Webhook != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		sinks = append(sinks, NewWebhookSink(cfg.Webhook, timeout))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	return &Publisher{sinks: sinks}
+}
+
+// Publish sends events to every sink, returning the combined sink errors
+func (p *Publisher) Publish(events []Event) error {
+	if p == nil || len(events) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, sink := range p.sinks {
+		if err := sink.Publish(events); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogSink appends events to an NDJSON file, one event per line
+type LogSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogSink creates a sink appending to the file at path
+func NewLogSink(path string) *LogSink {
+	return &LogSink{path: path}
+}
+
+// Publish appends the events in a single write so concurrent writers don't interleave lines
+func (s *LogSink) Publish(events []Event) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("encoding event: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("creating event log directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening event log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing event log: %w", err)
+	}
+	return nil
+}
+
+// WebhookSink POSTs events to a URL as a JSON object with an "events" array
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Publish posts the events in one request
+func (s *WebhookSink) Publish(events []Event) error {
+	body, err := json.Marshal(map[string][]Event{"events": events})
+	if err != nil {
+		return fmt.Errorf("encoding events: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting events to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// FromTransaction converts a committed journal transaction into events.
+// Modified markdown files also produce a field_changed event per changed
+// frontmatter field. Paths are vault-relative and reflect where each file
+// ended up, so a file edited and then renamed is reported at its new path.
+func FromTransaction(tx *safety.Transaction) []Event {
+	var events []Event
+	for i, entry := range tx.Entries {
+		event := Event{Time: tx.CreatedAt, Path: entry.Path, Source: tx.Command, Transaction: tx.ID, Reason: entry.Reason}
+
+		// Follow later moves so events name the file's final location
+		for _, later := range tx.Entries[i+1:] {
+			if later.Op == safety.OpMove && later.From == event.Path {
+				event.Path = later.Path
+			}
+		}
+
+		switch entry.Op {
+		case safety.OpCreate:
+			event.Type = Created
+			events = append(events, event)
+		case safety.OpDelete:
+			event.Type = Deleted
+			events = append(events, event)
+		case safety.OpMove:
+			event.Type = Renamed
+			event.From = entry.From
+			events = append(events, event)
+		case safety.OpModify:
+			event.Type = Modified
+			events = append(events, event)
+
+			before, err := tx.Original(entry)
+			if err != nil {
+				continue
+			}
+			after, err := os.ReadFile(filepath.Join(tx.Root(), event.Path))
+			if err != nil {
+				continue
+			}
+			events = append(events, FieldChanges(event, Frontmatter(before), Frontmatter(after))...)
+		}
+	}
+	return events
+}
+
+// Frontmatter parses the frontmatter of markdown content, returning nil if it
+// has none or can't be parsed
+func Frontmatter(content []byte) map[string]interface{} {
+	file := &vault.VaultFile{}
+	if err := file.Parse(content); err != nil {
+		return nil
+	}
+	return file.Frontmatter
+}
+
+// FieldChanges returns a field_changed event, based on base, for every
+// frontmatter field added, removed or changed between before and after
+func FieldChanges(base Event, before, after map[string]interface{}) []Event {
+	keys := make(map[string]bool)
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	fields := make([]string, 0, len(keys))
+	for key := range keys {
+		fields = append(fields, key)
+	}
+	sort.Strings(fields)
+
+	var events []Event
+	for _, field := range fields {
+		oldValue, hadOld := before[field]
+		newValue, hasNew := after[field]
+		if hadOld == hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		event := base
+		event.Type = FieldChanged
+		event.From = ""
+		event.Field = field
+		event.Old = oldValue
+		event.New = newValue
+		events = append(events, event)
+	}
+	return events
+}
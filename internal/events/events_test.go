@@ -0,0 +1,117 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+func readLog(t *testing.T, path string) []Event {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestNewPublisher_NothingConfigured(t *testing.T) {
+	publisher := NewPublisher(config.EventsConfig{Timeout: "10s"})
+	assert.Nil(t, publisher)
+	assert.NoError(t, publisher.Publish([]Event{{Type: Created}}))
+}
+
+func TestLogSink_Appends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "events.ndjson")
+	publisher := NewPublisher(config.EventsConfig{Log: path})
+
+	require.NoError(t, publisher.Publish([]Event{{Type: Created, Path: "a.md", Source: "test"}}))
+	require.NoError(t, publisher.Publish([]Event{{Type: Deleted, Path: "a.md", Source: "test"}}))
+
+	events := readLog(t, path)
+	require.Len(t, events, 2)
+	assert.Equal(t, Created, events[0].Type)
+	assert.Equal(t, Deleted, events[1].Type)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received struct {
+		Events []Event `json:"events"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	publisher := NewPublisher(config.EventsConfig{Webhook: server.URL, Timeout: "5s"})
+	require.NoError(t, publisher.Publish([]Event{{Type: Modified, Path: "a.md"}}))
+	require.Len(t, received.Events, 1)
+	assert.Equal(t, "a.md", received.Events[0].Path)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewWebhookSink(failing.URL, time.Second).Publish([]Event{{Type: Modified}}))
+}
+
+func TestFromTransaction(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("old.md", "---\nstatus: draft\ntags: [a]\n---\n# Note\n")
+	write("index.md", "[[old]]\n")
+
+	tx := safety.NewJournal(dir).Begin("mdnotes rename")
+	require.NoError(t, tx.RecordWrite(filepath.Join(dir, "old.md")))
+	write("old.md", "---\nstatus: done\ntitle: Note\n---\n# Note\n")
+	require.NoError(t, tx.RecordMove(filepath.Join(dir, "old.md"), filepath.Join(dir, "new.md")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "old.md"), filepath.Join(dir, "new.md")))
+	require.NoError(t, tx.RecordWrite(filepath.Join(dir, "created.md")))
+	write("created.md", "new")
+	require.NoError(t, tx.Commit())
+
+	events := FromTransaction(tx)
+	var summary [][3]string
+	for _, event := range events {
+		assert.Equal(t, "mdnotes rename", event.Source)
+		assert.Equal(t, tx.ID, event.Transaction)
+		summary = append(summary, [3]string{event.Type, event.Path, event.Field})
+	}
+	assert.Equal(t, [][3]string{
+		{Modified, "new.md", ""},
+		{FieldChanged, "new.md", "status"},
+		{FieldChanged, "new.md", "tags"},
+		{FieldChanged, "new.md", "title"},
+		{Renamed, "new.md", ""},
+		{Created, "created.md", ""},
+	}, summary)
+
+	assert.Equal(t, "draft", events[1].Old)
+	assert.Equal(t, "done", events[1].New)
+	assert.Nil(t, events[2].New)
+	assert.Nil(t, events[3].Old)
+	assert.Equal(t, "old.md", events[4].From)
+}
@@ -0,0 +1,264 @@
+// Package git runs the git commands mdnotes needs to commit its changes and
+// read a vault's history.
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit in a vault's history
+type Commit struct {
+	Hash    string       `json:"hash"`
+	Author  string       `json:"author"`
+	Email   string       `json:"email"`
+	Time    time.Time    `json:"time"`
+	Subject string       `json:"subject"`
+	Files   []FileChange `json:"files"`
+}
+
+// FileChange is how a commit changed one file. Binary files have no line counts.
+type FileChange struct {
+	Path    string `json:"path"` // Relative to the directory the log was read in
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+}
+
+// IsRepository reports whether dir is inside a git work tree
+func IsRepository(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// CommitFiles stages paths, relative to dir, and commits only them with
+// message, leaving anything else staged or changed in the work tree alone.
+// Paths git ignores, or that don't exist and never did, are skipped. It
+// returns the new commit's hash, or "" when none of the paths had changes.
+func CommitFiles(dir string, paths []string, message string) (string, error) {
+	if !IsRepository(dir) {
+		return "", fmt.Errorf("%s is not in a git repository", dir)
+	}
+	paths, err := trackable(dir, paths)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	if _, err := run(dir, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return "", fmt.Errorf("staging changes: %w", err)
+	}
+	// Nothing staged for these paths means the files match HEAD already
+	if _, err := run(dir, append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		return "", nil
+	}
+	if _, err := run(dir, append([]string{"commit", "--quiet", "--message", message, "--only", "--"}, paths...)...); err != nil {
+		return "", fmt.Errorf("committing changes: %w", err)
+	}
+	hash, err := run(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("reading commit: %w", err)
+	}
+	return strings.TrimSpace(hash), nil
+}
+
+// trackable narrows paths to those git can commit: files it tracks and
+// files it doesn't ignore. A file created and removed again is neither.
+func trackable(dir string, paths []string) ([]string, error) {
+	out, err := run(dir, append([]string{"ls-files", "-z", "--cached", "--others", "--exclude-standard", "--"}, paths...)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+	var kept []string
+	seen := make(map[string]bool)
+	for _, path := range strings.Split(out, "\x00") {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			kept = append(kept, path)
+		}
+	}
+	return kept, nil
+}
+
+// logFormat starts each commit's header with a marker line, so headers can't
+// be mistaken for --numstat lines
+const logFormat = "--format=\x1e%H\x1f%an\x1f%ae\x1f%aI\x1f%s"
+
+// Log reads the history of the files under dir since the given time (all of
+// it when since is zero), newest first. Paths are relative to dir, renames
+// are recorded as a deletion and an addition, and merge commits are skipped.
+func Log(dir string, since time.Time) ([]Commit, error) {
+	if !IsRepository(dir) {
+		return nil, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	args := []string{"log", "--no-merges", "--no-renames", "--numstat", "--relative", logFormat}
+	if !since.IsZero() {
+		args = append(args, "--since="+since.Format(time.RFC3339))
+	}
+	args = append(args, "--", ".")
+	out, err := run(dir, args...)
+	if err != nil {
+		// A repository without commits has no history
+		if strings.Contains(err.Error(), "does not have any commits") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading git history: %w", err)
+	}
+	return parseLog(out)
+}
+
+// FirstCommits returns when each file under dir was first committed, by path
+// relative to dir, following renames so a moved file keeps its original date.
+// Deleted files are left out.
+func FirstCommits(dir string) (map[string]time.Time, error) {
+	if !IsRepository(dir) {
+		return nil, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	out, err := run(dir, "log", "--reverse", "--no-merges", "--find-renames", "--name-status", "--relative", "--format=\x1e%aI", "--", ".")
+	if err != nil {
+		if strings.Contains(err.Error(), "does not have any commits") {
+			return map[string]time.Time{}, nil
+		}
+		return nil, fmt.Errorf("reading git history: %w", err)
+	}
+	return parseFirstCommits(out)
+}
+
+// parseFirstCommits parses the oldest-first output of git log --name-status
+// with a marked author date per commit
+func parseFirstCommits(out string) (map[string]time.Time, error) {
+	first := make(map[string]time.Time)
+	var when time.Time
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "\x1e") {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(line[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("parsing commit time %q: %w", line[1:], err)
+			}
+			when = t
+			continue
+		}
+		// status<TAB>path, or status<TAB>old<TAB>new for renames and copies
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		switch parts[0][0] {
+		case 'A':
+			if _, ok := first[unquote(parts[1])]; !ok {
+				first[unquote(parts[1])] = when
+			}
+		case 'D':
+			// A file added again later is a new one
+			delete(first, unquote(parts[1]))
+		case 'R', 'C':
+			if len(parts) < 3 {
+				continue
+			}
+			from, to := unquote(parts[1]), unquote(parts[2])
+			// A copy is a new file; a renamed one keeps its date
+			created, ok := first[from]
+			if !ok || parts[0][0] == 'C' {
+				created = when
+			}
+			if parts[0][0] == 'R' {
+				delete(first, from)
+			}
+			first[to] = created
+		}
+	}
+	return first, nil
+}
+
+// parseLog parses the output of git log in logFormat with --numstat
+func parseLog(out string) ([]Commit, error) {
+	var commits []Commit
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x1e") {
+			fields := strings.Split(line[1:], "\x1f")
+			if len(fields) != 5 {
+				return nil, fmt.Errorf("unexpected git log header %q", line)
+			}
+			when, err := time.Parse(time.RFC3339, fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("parsing commit time %q: %w", fields[3], err)
+			}
+			commits = append(commits, Commit{
+				Hash:    fields[0],
+				Author:  fields[1],
+				Email:   fields[2],
+				Time:    when,
+				Subject: fields[4],
+				Files:   []FileChange{},
+			})
+			continue
+		}
+		if line == "" || len(commits) == 0 {
+			continue
+		}
+
+		// added<TAB>deleted<TAB>path, with "-" counts for binary files
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(parts[0])
+		deleted, _ := strconv.Atoi(parts[1])
+		last := &commits[len(commits)-1]
+		last.Files = append(last.Files, FileChange{Path: unquote(parts[2]), Added: added, Deleted: deleted})
+	}
+	return commits, scanner.Err()
+}
+
+// unquote undoes git's quoting of paths with unusual characters
+func unquote(path string) string {
+	if len(path) >= 2 && path[0] == '"' && path[len(path)-1] == '"' {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
+
+// commandError is a git command that failed, with what it printed
+type commandError struct {
+	args   []string
+	code   int
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("git %s: %s", e.args[0], e.stderr)
+	}
+	return fmt.Sprintf("git %s: exit status %d", e.args[0], e.code)
+}
+
+// run runs git in dir, returning its output. Paths are taken literally, so
+// notes named with glob characters aren't treated as patterns.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_LITERAL_PATHSPECS=1", "LC_ALL=C")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return "", fmt.Errorf("running git: %w", err)
+		}
+		return stdout.String(), &commandError{args: args, code: exitErr.ExitCode(), stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,151 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepo creates a repository with a committer identity, skipping the test
+// when git isn't installed
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Ann"},
+		{"config", "user.email", "ann@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		_, err := run(dir, args...)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+func TestCommitFiles(t *testing.T) {
+	dir := newRepo(t)
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0644))
+	}
+	write(".gitignore", "private.md\n")
+	write("a.md", "A\n")
+	write("[draft] b.md", "B\n")
+	write("private.md", "secret\n")
+	write("other.md", "untouched\n")
+
+	assert.False(t, IsRepository(t.TempDir()))
+	assert.True(t, IsRepository(dir))
+
+	hash, err := CommitFiles(dir, []string{"a.md", "[draft] b.md", "private.md", "gone.md"}, "mdnotes test: 2 files changed")
+	require.NoError(t, err)
+	assert.Len(t, hash, 40)
+
+	// Only the named, unignored files were committed
+	commits, err := Log(dir, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "mdnotes test: 2 files changed", commits[0].Subject)
+	assert.Equal(t, "Ann", commits[0].Author)
+	assert.ElementsMatch(t, []FileChange{
+		{Path: "[draft] b.md", Added: 1},
+		{Path: "a.md", Added: 1},
+	}, commits[0].Files)
+
+	// Unchanged files make no commit
+	hash, err = CommitFiles(dir, []string{"a.md"}, "nothing")
+	require.NoError(t, err)
+	assert.Empty(t, hash)
+
+	// Deletions are committed
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.md")))
+	write("a2.md", "A\n")
+	_, err = CommitFiles(dir, []string{"a.md", "a2.md"}, "move")
+	require.NoError(t, err)
+	commits, err = Log(dir, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.ElementsMatch(t, []FileChange{
+		{Path: "a.md", Deleted: 1},
+		{Path: "a2.md", Added: 1},
+	}, commits[0].Files)
+
+	_, err = CommitFiles(t.TempDir(), []string{"a.md"}, "nowhere")
+	assert.ErrorContains(t, err, "not in a git repository")
+}
+
+func TestLog_Subdirectory(t *testing.T) {
+	dir := newRepo(t)
+	vaultDir := filepath.Join(dir, "vault")
+	require.NoError(t, os.MkdirAll(vaultDir, 0755))
+
+	// A repository without commits has no history
+	commits, err := Log(vaultDir, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	require.NoError(t, os.WriteFile(filepath.Join(vaultDir, "note.md"), []byte("one\ntwo\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("outside\n"), 0644))
+	_, err = CommitFiles(dir, []string{"vault/note.md", "README.md"}, "add")
+	require.NoError(t, err)
+
+	commits, err = Log(vaultDir, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, []FileChange{{Path: "note.md", Added: 2}}, commits[0].Files)
+
+	commits, err = Log(vaultDir, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestFirstCommits(t *testing.T) {
+	dir := newRepo(t)
+	commit := func(date string, args ...string) {
+		t.Helper()
+		_, err := run(dir, args...)
+		require.NoError(t, err)
+		_, err = run(dir, "commit", "--quiet", "--message", "change", "--date", date)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("A note with enough text to be recognized as renamed\n"), 0644))
+	commit("2024-01-01T10:00:00Z", "add", "a.md")
+	_, err := run(dir, "mv", "a.md", "moved.md")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.md"), []byte("B\n"), 0644))
+	commit("2024-02-01T10:00:00Z", "add", "b.md")
+
+	first, err := FirstCommits(dir)
+	require.NoError(t, err)
+	assert.Len(t, first, 2)
+	assert.True(t, first["moved.md"].Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)), "renamed notes keep their first date")
+	assert.True(t, first["b.md"].Equal(time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestParseLog(t *testing.T) {
+	out := "\x1eabc\x1fAnn\x1fann@example.com\x1f2025-03-01T12:00:00+01:00\x1fEdit notes\n\n" +
+		"3\t1\tnotes/a.md\n" +
+		"-\t-\timage.png\n" +
+		"1\t0\t\"caf\\303\\251.md\"\n"
+
+	commits, err := parseLog(out)
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	assert.Equal(t, "abc", commits[0].Hash)
+	assert.Equal(t, "ann@example.com", commits[0].Email)
+	assert.True(t, commits[0].Time.Equal(time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []FileChange{
+		{Path: "notes/a.md", Added: 3, Deleted: 1},
+		{Path: "image.png"},
+		{Path: "café.md", Added: 1},
+	}, commits[0].Files)
+}
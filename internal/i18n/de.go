@@ -0,0 +1,123 @@
+package i18n
+
+// german holds the German translations
+var german = map[string]string{
+	// Vault health
+	"Vault Health Report":          "Gesundheitsbericht des Tresors",
+	"Health Level: %s":             "Gesundheitsstufe: %s",
+	"Score: %.1f/100":              "Punktzahl: %.1f/100",
+	"Issues Found:":                "Gefundene Probleme:",
+	"Suggestions:":                 "Vorschläge:",
+	"No issues found. Great job!":  "Keine Probleme gefunden. Gute Arbeit!",
+	"No suggestions at this time.": "Derzeit keine Vorschläge.",
+	"excellent":                    "ausgezeichnet",
+	"good":                         "gut",
+	"fair":                         "mittel",
+	"poor":                         "schwach",
+	"critical":                     "kritisch",
+	"%d files missing frontmatter": "%d Dateien ohne Frontmatter",
+	"%d orphaned files":            "%d verwaiste Dateien",
+	"%d broken links":              "%d defekte Links",
+	"%d duplicate entries":         "%d doppelte Einträge",
+	"Add frontmatter to files using 'mdnotes frontmatter ensure'":                      "Frontmatter mit 'mdnotes frontmatter ensure' zu den Dateien hinzufügen",
+	"Review orphaned files and add links to integrate them":                            "Verwaiste Dateien prüfen und durch Links einbinden",
+	"Fix broken links using 'mdnotes links check'":                                     "Defekte Links mit 'mdnotes links check' reparieren",
+	"Review and resolve duplicate content":                                             "Doppelte Inhalte prüfen und bereinigen",
+	"Rule Contributions:":                                                              "Beiträge der Regeln:",
+	"%s: disabled":                                                                     "%s: deaktiviert",
+	"%s: %.1f point penalty (%d affected; measured %.2f, weight %.1f, threshold %.2f)": "%s: %.1f Punkte Abzug (%d betroffen; gemessen %.2f, Gewicht %.1f, Schwelle %.2f)",
+
+	// Vault statistics
+	"Vault Statistics":              "Tresorstatistik",
+	"Files:":                        "Dateien:",
+	"Total files: %d":               "Dateien insgesamt: %d",
+	"Files with frontmatter: %d":    "Dateien mit Frontmatter: %d",
+	"Files without frontmatter: %d": "Dateien ohne Frontmatter: %d",
+	"Content:":                      "Inhalt:",
+	"Total size: %d bytes":          "Gesamtgröße: %d Bytes",
+	"Average file size: %.1f bytes": "Durchschnittliche Dateigröße: %.1f Bytes",
+	"Frontmatter Fields:":           "Frontmatter-Felder:",
+	"%s: %d files (%.1f%%)":         "%s: %d Dateien (%.1f %%)",
+	"Top Tags:":                     "Häufigste Tags:",
+	"#%s: %d files":                 "#%s: %d Dateien",
+	"Largest Files":                 "Größte Dateien",
+	"bytes":                         "Bytes",
+	"Longest Files":                 "Längste Dateien",
+	"words":                         "Wörter",
+	"Oldest Files":                  "Älteste Dateien",
+	"days since modified":           "Tage seit der letzten Änderung",
+	"Most Linked Files":             "Am häufigsten verlinkte Dateien",
+	"inbound links":                 "eingehende Links",
+	"Most Linking Files":            "Am häufigsten verlinkende Dateien",
+	"outbound links":                "ausgehende Links",
+	"Folders:":                      "Ordner:",
+	"(vault root)":                  "(Tresorwurzel)",
+	"%s: %d files, %d words, %d modified in the last %d days, last modified %s": "%s: %d Dateien, %d Wörter, %d in den letzten %d Tagen geändert, zuletzt geändert %s",
+	"Top tags: %s": "Häufigste Tags: %s",
+
+	// Content quality
+	"Zettelkasten Content Quality Analysis":           "Zettelkasten-Analyse der Inhaltsqualität",
+	"Overall Quality Score: %.1f/100":                 "Gesamtqualität: %.1f/100",
+	"Quality profile: %s":                             "Qualitätsprofil: %s",
+	"Scoring based on Zettelkasten principles:":       "Bewertung nach Zettelkasten-Prinzipien:",
+	"Readability (Flesch-Kincaid Reading Ease)":       "Lesbarkeit (Flesch-Kincaid-Lesbarkeitsindex)",
+	"Link Density (outbound links per 100 words)":     "Linkdichte (ausgehende Links pro 100 Wörter)",
+	"Completeness (title, summary, word count)":       "Vollständigkeit (Titel, Zusammenfassung, Wortanzahl)",
+	"Atomicity (one concept per note)":                "Atomarität (ein Konzept pro Notiz)",
+	"Recency (recently modified content)":             "Aktualität (kürzlich geänderte Inhalte)",
+	"Distribution:":                                   "Verteilung:",
+	"Excellent (%.0f-100): %d files":                  "Ausgezeichnet (%.0f-100): %d Dateien",
+	"Good (%.0f-%.0f): %d files":                      "Gut (%.0f-%.0f): %d Dateien",
+	"Fair (%.0f-%.0f): %d files":                      "Mittel (%.0f-%.0f): %d Dateien",
+	"Poor (%.0f-%.0f): %d files":                      "Schwach (%.0f-%.0f): %d Dateien",
+	"Critical (0-%.0f): %d files":                     "Kritisch (0-%.0f): %d Dateien",
+	"Content Metrics:":                                "Inhaltskennzahlen:",
+	"Average content length: %.0f characters":         "Durchschnittliche Inhaltslänge: %.0f Zeichen",
+	"Average word count: %.0f words":                  "Durchschnittliche Wortanzahl: %.0f Wörter",
+	"Files with headings: %d":                         "Dateien mit Überschriften: %d",
+	"Files with links: %d":                            "Dateien mit Links: %d",
+	"Files Needing Attention (lowest scores):":        "Dateien mit Handlungsbedarf (niedrigste Punktzahlen):",
+	"Quality Issues Found:":                           "Gefundene Qualitätsprobleme:",
+	"Improvement Suggestions:":                        "Verbesserungsvorschläge:",
+	"Individual File Scores (showing files >= %.1f):": "Punktzahlen pro Datei (Dateien >= %.1f):",
+	"Plugins: %s":                                     "Plugins: %s",
+	"Improvements: %s":                                "Verbesserungen: %s",
+	"Metrics: Read=Readability, Link=Link Density, Comp=Completeness, Atom=Atomicity, Rec=Recency": "Kennzahlen: Read=Lesbarkeit, Link=Linkdichte, Comp=Vollständigkeit, Atom=Atomarität, Rec=Aktualität",
+	"%.0f%% of files lack frontmatter":                                     "%.0f %% der Dateien fehlt Frontmatter",
+	"%.0f%% of files lack heading structure":                               "%.0f %% der Dateien fehlt eine Überschriftenstruktur",
+	"Add headings to improve content structure":                            "Überschriften hinzufügen, um den Inhalt zu gliedern",
+	"Low interconnectivity between files":                                  "Geringe Vernetzung zwischen Dateien",
+	"Add more links between related content":                               "Mehr Links zwischen verwandten Inhalten setzen",
+	"Many files have very short content":                                   "Viele Dateien haben sehr kurzen Inhalt",
+	"Consider expanding content or combining related short files":          "Inhalte ausbauen oder verwandte kurze Dateien zusammenführen",
+	"%d files have poor quality scores":                                    "%d Dateien haben niedrige Qualitätswerte",
+	"Focus on improving content structure and completeness":                "Auf bessere Gliederung und Vollständigkeit der Inhalte konzentrieren",
+	"Simplify sentence structure for better readability":                   "Satzbau vereinfachen, um die Lesbarkeit zu verbessern",
+	"Use shorter sentences and common vocabulary":                          "Kürzere Sätze und geläufige Wörter verwenden",
+	"Add more links to related concepts (aim for 2-4 links per 100 words)": "Mehr Links zu verwandten Konzepten setzen (2-4 Links pro 100 Wörter anstreben)",
+	"Consider adding a few more relevant links":                            "Einige weitere relevante Links ergänzen",
+	"Add a descriptive title in frontmatter":                               "Einen aussagekräftigen Titel im Frontmatter ergänzen",
+	"Add %s to frontmatter":                                                "%s im Frontmatter ergänzen",
+	"Add a summary or description in frontmatter":                          "Eine Zusammenfassung oder Beschreibung im Frontmatter ergänzen",
+	"Expand content - add more detail and context":                         "Inhalt ausbauen - mehr Details und Kontext ergänzen",
+	"Consider breaking this into smaller, more focused notes":              "In kleinere, fokussiertere Notizen aufteilen",
+	"Split multiple main topics into separate notes":                       "Mehrere Hauptthemen in eigene Notizen aufteilen",
+	"Review and update this note - it hasn't been modified recently":       "Diese Notiz prüfen und aktualisieren - sie wurde länger nicht geändert",
+	"Add current date to track when content was last reviewed":             "Das aktuelle Datum ergänzen, um die letzte Prüfung festzuhalten",
+	"This note has good quality - consider linking it to related concepts": "Diese Notiz ist von guter Qualität - sie mit verwandten Konzepten verlinken",
+
+	// Split suggestions
+	"Split Suggestions:":              "Vorschläge zum Aufteilen:",
+	"No notes need splitting":         "Keine Notiz muss aufgeteilt werden",
+	"%s (atomicity %.0f%%, %d words)": "%s (Atomarität %.0f %%, %d Wörter)",
+	"%s %s (line %d, %d words)":       "%s %s (Zeile %d, %d Wörter)",
+	"Run: %s":                         "Ausführen: %s",
+
+	// Inbox triage
+	"Break down into smaller tasks":    "In kleinere Aufgaben aufteilen",
+	"Prioritize by urgency":            "Nach Dringlichkeit priorisieren",
+	"Process links with linkding sync": "Links mit linkding sync verarbeiten",
+	"Convert to permanent notes":       "In dauerhafte Notizen umwandeln",
+	"Add to reading list":              "Zur Leseliste hinzufügen",
+	"Review and organize content":      "Inhalt prüfen und ordnen",
+}
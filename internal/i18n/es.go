@@ -0,0 +1,123 @@
+package i18n
+
+// spanish holds the Spanish translations
+var spanish = map[string]string{
+	// Vault health
+	"Vault Health Report":          "Informe de salud de la bóveda",
+	"Health Level: %s":             "Nivel de salud: %s",
+	"Score: %.1f/100":              "Puntuación: %.1f/100",
+	"Issues Found:":                "Problemas encontrados:",
+	"Suggestions:":                 "Sugerencias:",
+	"No issues found. Great job!":  "No se encontraron problemas. ¡Buen trabajo!",
+	"No suggestions at this time.": "No hay sugerencias por ahora.",
+	"excellent":                    "excelente",
+	"good":                         "buena",
+	"fair":                         "aceptable",
+	"poor":                         "deficiente",
+	"critical":                     "crítica",
+	"%d files missing frontmatter": "%d archivos sin frontmatter",
+	"%d orphaned files":            "%d archivos huérfanos",
+	"%d broken links":              "%d enlaces rotos",
+	"%d duplicate entries":         "%d entradas duplicadas",
+	"Add frontmatter to files using 'mdnotes frontmatter ensure'":                      "Añade frontmatter a los archivos con 'mdnotes frontmatter ensure'",
+	"Review orphaned files and add links to integrate them":                            "Revisa los archivos huérfanos y añade enlaces para integrarlos",
+	"Fix broken links using 'mdnotes links check'":                                     "Corrige los enlaces rotos con 'mdnotes links check'",
+	"Review and resolve duplicate content":                                             "Revisa y resuelve el contenido duplicado",
+	"Rule Contributions:":                                                              "Contribución de cada regla:",
+	"%s: disabled":                                                                     "%s: desactivada",
+	"%s: %.1f point penalty (%d affected; measured %.2f, weight %.1f, threshold %.2f)": "%s: %.1f puntos de penalización (%d afectados; medido %.2f, peso %.1f, umbral %.2f)",
+
+	// Vault statistics
+	"Vault Statistics":              "Estadísticas de la bóveda",
+	"Files:":                        "Archivos:",
+	"Total files: %d":               "Archivos totales: %d",
+	"Files with frontmatter: %d":    "Archivos con frontmatter: %d",
+	"Files without frontmatter: %d": "Archivos sin frontmatter: %d",
+	"Content:":                      "Contenido:",
+	"Total size: %d bytes":          "Tamaño total: %d bytes",
+	"Average file size: %.1f bytes": "Tamaño medio de archivo: %.1f bytes",
+	"Frontmatter Fields:":           "Campos de frontmatter:",
+	"%s: %d files (%.1f%%)":         "%s: %d archivos (%.1f%%)",
+	"Top Tags:":                     "Etiquetas principales:",
+	"#%s: %d files":                 "#%s: %d archivos",
+	"Largest Files":                 "Archivos más grandes",
+	"bytes":                         "bytes",
+	"Longest Files":                 "Archivos más largos",
+	"words":                         "palabras",
+	"Oldest Files":                  "Archivos más antiguos",
+	"days since modified":           "días desde la última modificación",
+	"Most Linked Files":             "Archivos más enlazados",
+	"inbound links":                 "enlaces entrantes",
+	"Most Linking Files":            "Archivos que más enlazan",
+	"outbound links":                "enlaces salientes",
+	"Folders:":                      "Carpetas:",
+	"(vault root)":                  "(raíz de la bóveda)",
+	"%s: %d files, %d words, %d modified in the last %d days, last modified %s": "%s: %d archivos, %d palabras, %d modificados en los últimos %d días, última modificación %s",
+	"Top tags: %s": "Etiquetas principales: %s",
+
+	// Content quality
+	"Zettelkasten Content Quality Analysis":           "Análisis de calidad del contenido Zettelkasten",
+	"Overall Quality Score: %.1f/100":                 "Puntuación de calidad global: %.1f/100",
+	"Quality profile: %s":                             "Perfil de calidad: %s",
+	"Scoring based on Zettelkasten principles:":       "Puntuación basada en los principios Zettelkasten:",
+	"Readability (Flesch-Kincaid Reading Ease)":       "Legibilidad (facilidad de lectura Flesch-Kincaid)",
+	"Link Density (outbound links per 100 words)":     "Densidad de enlaces (enlaces salientes cada 100 palabras)",
+	"Completeness (title, summary, word count)":       "Completitud (título, resumen, número de palabras)",
+	"Atomicity (one concept per note)":                "Atomicidad (un concepto por nota)",
+	"Recency (recently modified content)":             "Actualidad (contenido modificado recientemente)",
+	"Distribution:":                                   "Distribución:",
+	"Excellent (%.0f-100): %d files":                  "Excelente (%.0f-100): %d archivos",
+	"Good (%.0f-%.0f): %d files":                      "Buena (%.0f-%.0f): %d archivos",
+	"Fair (%.0f-%.0f): %d files":                      "Aceptable (%.0f-%.0f): %d archivos",
+	"Poor (%.0f-%.0f): %d files":                      "Deficiente (%.0f-%.0f): %d archivos",
+	"Critical (0-%.0f): %d files":                     "Crítica (0-%.0f): %d archivos",
+	"Content Metrics:":                                "Métricas de contenido:",
+	"Average content length: %.0f characters":         "Longitud media del contenido: %.0f caracteres",
+	"Average word count: %.0f words":                  "Número medio de palabras: %.0f palabras",
+	"Files with headings: %d":                         "Archivos con encabezados: %d",
+	"Files with links: %d":                            "Archivos con enlaces: %d",
+	"Files Needing Attention (lowest scores):":        "Archivos que requieren atención (puntuaciones más bajas):",
+	"Quality Issues Found:":                           "Problemas de calidad encontrados:",
+	"Improvement Suggestions:":                        "Sugerencias de mejora:",
+	"Individual File Scores (showing files >= %.1f):": "Puntuaciones por archivo (archivos >= %.1f):",
+	"Plugins: %s":                                     "Plugins: %s",
+	"Improvements: %s":                                "Mejoras: %s",
+	"Metrics: Read=Readability, Link=Link Density, Comp=Completeness, Atom=Atomicity, Rec=Recency": "Métricas: Read=legibilidad, Link=densidad de enlaces, Comp=completitud, Atom=atomicidad, Rec=actualidad",
+	"%.0f%% of files lack frontmatter":                                     "El %.0f%% de los archivos no tiene frontmatter",
+	"%.0f%% of files lack heading structure":                               "El %.0f%% de los archivos no tiene estructura de encabezados",
+	"Add headings to improve content structure":                            "Añade encabezados para mejorar la estructura del contenido",
+	"Low interconnectivity between files":                                  "Poca interconexión entre archivos",
+	"Add more links between related content":                               "Añade más enlaces entre contenidos relacionados",
+	"Many files have very short content":                                   "Muchos archivos tienen un contenido muy breve",
+	"Consider expanding content or combining related short files":          "Considera ampliar el contenido o combinar archivos breves relacionados",
+	"%d files have poor quality scores":                                    "%d archivos tienen puntuaciones de calidad bajas",
+	"Focus on improving content structure and completeness":                "Céntrate en mejorar la estructura y la completitud del contenido",
+	"Simplify sentence structure for better readability":                   "Simplifica la estructura de las frases para mejorar la legibilidad",
+	"Use shorter sentences and common vocabulary":                          "Usa frases más cortas y vocabulario común",
+	"Add more links to related concepts (aim for 2-4 links per 100 words)": "Añade más enlaces a conceptos relacionados (entre 2 y 4 enlaces cada 100 palabras)",
+	"Consider adding a few more relevant links":                            "Considera añadir algunos enlaces relevantes más",
+	"Add a descriptive title in frontmatter":                               "Añade un título descriptivo en el frontmatter",
+	"Add %s to frontmatter":                                                "Añade %s en el frontmatter",
+	"Add a summary or description in frontmatter":                          "Añade un resumen o una descripción en el frontmatter",
+	"Expand content - add more detail and context":                         "Amplía el contenido: añade más detalle y contexto",
+	"Consider breaking this into smaller, more focused notes":              "Considera dividir esto en notas más pequeñas y centradas",
+	"Split multiple main topics into separate notes":                       "Separa los distintos temas principales en notas independientes",
+	"Review and update this note - it hasn't been modified recently":       "Revisa y actualiza esta nota: no se ha modificado recientemente",
+	"Add current date to track when content was last reviewed":             "Añade la fecha actual para saber cuándo se revisó el contenido por última vez",
+	"This note has good quality - consider linking it to related concepts": "Esta nota tiene buena calidad: considera enlazarla con conceptos relacionados",
+
+	// Split suggestions
+	"Split Suggestions:":              "Sugerencias de división:",
+	"No notes need splitting":         "Ninguna nota necesita dividirse",
+	"%s (atomicity %.0f%%, %d words)": "%s (atomicidad %.0f%%, %d palabras)",
+	"%s %s (line %d, %d words)":       "%s %s (línea %d, %d palabras)",
+	"Run: %s":                         "Ejecuta: %s",
+
+	// Inbox triage
+	"Break down into smaller tasks":    "Divide en tareas más pequeñas",
+	"Prioritize by urgency":            "Prioriza por urgencia",
+	"Process links with linkding sync": "Procesa los enlaces con linkding sync",
+	"Convert to permanent notes":       "Convierte en notas permanentes",
+	"Add to reading list":              "Añade a la lista de lectura",
+	"Review and organize content":      "Revisa y organiza el contenido",
+}
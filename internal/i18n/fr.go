@@ -0,0 +1,123 @@
+package i18n
+
+// french holds the French translations
+var french = map[string]string{
+	// Vault health
+	"Vault Health Report":          "Rapport de santé du coffre",
+	"Health Level: %s":             "Niveau de santé : %s",
+	"Score: %.1f/100":              "Score : %.1f/100",
+	"Issues Found:":                "Problèmes détectés :",
+	"Suggestions:":                 "Suggestions :",
+	"No issues found. Great job!":  "Aucun problème détecté. Bravo !",
+	"No suggestions at this time.": "Aucune suggestion pour le moment.",
+	"excellent":                    "excellent",
+	"good":                         "bon",
+	"fair":                         "moyen",
+	"poor":                         "faible",
+	"critical":                     "critique",
+	"%d files missing frontmatter": "%d fichiers sans frontmatter",
+	"%d orphaned files":            "%d fichiers orphelins",
+	"%d broken links":              "%d liens cassés",
+	"%d duplicate entries":         "%d entrées en double",
+	"Add frontmatter to files using 'mdnotes frontmatter ensure'":                      "Ajoutez un frontmatter aux fichiers avec 'mdnotes frontmatter ensure'",
+	"Review orphaned files and add links to integrate them":                            "Examinez les fichiers orphelins et ajoutez des liens pour les intégrer",
+	"Fix broken links using 'mdnotes links check'":                                     "Corrigez les liens cassés avec 'mdnotes links check'",
+	"Review and resolve duplicate content":                                             "Examinez et résolvez le contenu en double",
+	"Rule Contributions:":                                                              "Contribution des règles :",
+	"%s: disabled":                                                                     "%s : désactivée",
+	"%s: %.1f point penalty (%d affected; measured %.2f, weight %.1f, threshold %.2f)": "%s : pénalité de %.1f points (%d concernés ; mesuré %.2f, poids %.1f, seuil %.2f)",
+
+	// Vault statistics
+	"Vault Statistics":              "Statistiques du coffre",
+	"Files:":                        "Fichiers :",
+	"Total files: %d":               "Nombre de fichiers : %d",
+	"Files with frontmatter: %d":    "Fichiers avec frontmatter : %d",
+	"Files without frontmatter: %d": "Fichiers sans frontmatter : %d",
+	"Content:":                      "Contenu :",
+	"Total size: %d bytes":          "Taille totale : %d octets",
+	"Average file size: %.1f bytes": "Taille moyenne des fichiers : %.1f octets",
+	"Frontmatter Fields:":           "Champs du frontmatter :",
+	"%s: %d files (%.1f%%)":         "%s : %d fichiers (%.1f %%)",
+	"Top Tags:":                     "Tags principaux :",
+	"#%s: %d files":                 "#%s : %d fichiers",
+	"Largest Files":                 "Fichiers les plus volumineux",
+	"bytes":                         "octets",
+	"Longest Files":                 "Fichiers les plus longs",
+	"words":                         "mots",
+	"Oldest Files":                  "Fichiers les plus anciens",
+	"days since modified":           "jours depuis la dernière modification",
+	"Most Linked Files":             "Fichiers les plus liés",
+	"inbound links":                 "liens entrants",
+	"Most Linking Files":            "Fichiers contenant le plus de liens",
+	"outbound links":                "liens sortants",
+	"Folders:":                      "Dossiers :",
+	"(vault root)":                  "(racine du coffre)",
+	"%s: %d files, %d words, %d modified in the last %d days, last modified %s": "%s : %d fichiers, %d mots, %d modifiés ces %d derniers jours, dernière modification %s",
+	"Top tags: %s": "Tags principaux : %s",
+
+	// Content quality
+	"Zettelkasten Content Quality Analysis":           "Analyse Zettelkasten de la qualité du contenu",
+	"Overall Quality Score: %.1f/100":                 "Score de qualité global : %.1f/100",
+	"Quality profile: %s":                             "Profil de qualité : %s",
+	"Scoring based on Zettelkasten principles:":       "Score fondé sur les principes Zettelkasten :",
+	"Readability (Flesch-Kincaid Reading Ease)":       "Lisibilité (indice de lisibilité Flesch-Kincaid)",
+	"Link Density (outbound links per 100 words)":     "Densité de liens (liens sortants pour 100 mots)",
+	"Completeness (title, summary, word count)":       "Complétude (titre, résumé, nombre de mots)",
+	"Atomicity (one concept per note)":                "Atomicité (un concept par note)",
+	"Recency (recently modified content)":             "Fraîcheur (contenu modifié récemment)",
+	"Distribution:":                                   "Répartition :",
+	"Excellent (%.0f-100): %d files":                  "Excellent (%.0f-100) : %d fichiers",
+	"Good (%.0f-%.0f): %d files":                      "Bon (%.0f-%.0f) : %d fichiers",
+	"Fair (%.0f-%.0f): %d files":                      "Moyen (%.0f-%.0f) : %d fichiers",
+	"Poor (%.0f-%.0f): %d files":                      "Faible (%.0f-%.0f) : %d fichiers",
+	"Critical (0-%.0f): %d files":                     "Critique (0-%.0f) : %d fichiers",
+	"Content Metrics:":                                "Indicateurs de contenu :",
+	"Average content length: %.0f characters":         "Longueur moyenne du contenu : %.0f caractères",
+	"Average word count: %.0f words":                  "Nombre moyen de mots : %.0f mots",
+	"Files with headings: %d":                         "Fichiers avec titres : %d",
+	"Files with links: %d":                            "Fichiers avec liens : %d",
+	"Files Needing Attention (lowest scores):":        "Fichiers à revoir (scores les plus bas) :",
+	"Quality Issues Found:":                           "Problèmes de qualité détectés :",
+	"Improvement Suggestions:":                        "Suggestions d'amélioration :",
+	"Individual File Scores (showing files >= %.1f):": "Scores par fichier (fichiers >= %.1f) :",
+	"Plugins: %s":                                     "Plugins : %s",
+	"Improvements: %s":                                "Améliorations : %s",
+	"Metrics: Read=Readability, Link=Link Density, Comp=Completeness, Atom=Atomicity, Rec=Recency": "Indicateurs : Read=lisibilité, Link=densité de liens, Comp=complétude, Atom=atomicité, Rec=fraîcheur",
+	"%.0f%% of files lack frontmatter":                                     "%.0f %% des fichiers n'ont pas de frontmatter",
+	"%.0f%% of files lack heading structure":                               "%.0f %% des fichiers n'ont pas de structure de titres",
+	"Add headings to improve content structure":                            "Ajoutez des titres pour mieux structurer le contenu",
+	"Low interconnectivity between files":                                  "Faible interconnexion entre les fichiers",
+	"Add more links between related content":                               "Ajoutez des liens entre les contenus liés",
+	"Many files have very short content":                                   "De nombreux fichiers ont un contenu très court",
+	"Consider expanding content or combining related short files":          "Envisagez d'étoffer le contenu ou de regrouper les fichiers courts liés",
+	"%d files have poor quality scores":                                    "%d fichiers ont un score de qualité faible",
+	"Focus on improving content structure and completeness":                "Concentrez-vous sur la structure et la complétude du contenu",
+	"Simplify sentence structure for better readability":                   "Simplifiez la structure des phrases pour une meilleure lisibilité",
+	"Use shorter sentences and common vocabulary":                          "Utilisez des phrases plus courtes et un vocabulaire courant",
+	"Add more links to related concepts (aim for 2-4 links per 100 words)": "Ajoutez des liens vers des concepts liés (visez 2 à 4 liens pour 100 mots)",
+	"Consider adding a few more relevant links":                            "Envisagez d'ajouter quelques liens pertinents",
+	"Add a descriptive title in frontmatter":                               "Ajoutez un titre descriptif dans le frontmatter",
+	"Add %s to frontmatter":                                                "Ajoutez %s dans le frontmatter",
+	"Add a summary or description in frontmatter":                          "Ajoutez un résumé ou une description dans le frontmatter",
+	"Expand content - add more detail and context":                         "Étoffez le contenu : ajoutez des détails et du contexte",
+	"Consider breaking this into smaller, more focused notes":              "Envisagez de découper cette note en notes plus petites et ciblées",
+	"Split multiple main topics into separate notes":                       "Séparez les différents sujets principaux en notes distinctes",
+	"Review and update this note - it hasn't been modified recently":       "Relisez et mettez à jour cette note : elle n'a pas été modifiée récemment",
+	"Add current date to track when content was last reviewed":             "Ajoutez la date du jour pour suivre la dernière relecture du contenu",
+	"This note has good quality - consider linking it to related concepts": "Cette note est de bonne qualité : pensez à la relier à des concepts proches",
+
+	// Split suggestions
+	"Split Suggestions:":              "Suggestions de découpage :",
+	"No notes need splitting":         "Aucune note à découper",
+	"%s (atomicity %.0f%%, %d words)": "%s (atomicité %.0f %%, %d mots)",
+	"%s %s (line %d, %d words)":       "%s %s (ligne %d, %d mots)",
+	"Run: %s":                         "Exécutez : %s",
+
+	// Inbox triage
+	"Break down into smaller tasks":    "Découpez en tâches plus petites",
+	"Prioritize by urgency":            "Priorisez par urgence",
+	"Process links with linkding sync": "Traitez les liens avec linkding sync",
+	"Convert to permanent notes":       "Transformez en notes permanentes",
+	"Add to reading list":              "Ajoutez à la liste de lecture",
+	"Review and organize content":      "Examinez et organisez le contenu",
+}
@@ -0,0 +1,121 @@
+// Package i18n translates user-facing output. Messages are keyed by their
+// English text, which is also what is printed when no translation exists.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// DefaultLocale is the language output is written in unless another is chosen
+const DefaultLocale = "en"
+
+// translations holds each supported locale's messages, keyed by English text
+var translations = map[string]map[string]string{
+	"es": spanish,
+	"de": german,
+	"fr": french,
+}
+
+var (
+	builder = newCatalog()
+
+	mu      sync.RWMutex
+	locale  = DefaultLocale
+	printer *message.Printer // nil for English, which is formatted as is
+)
+
+func newCatalog() *catalog.Builder {
+	b := catalog.NewBuilder(catalog.Fallback(language.English))
+	for loc, messages := range translations {
+		tag := language.Make(loc)
+		for key, msg := range messages {
+			if err := b.SetString(tag, key, msg); err != nil {
+				panic(fmt.Sprintf("i18n: invalid %s message %q: %v", loc, key, err))
+			}
+		}
+	}
+	return b
+}
+
+// Locales returns the supported locales, English first
+func Locales() []string {
+	return []string{DefaultLocale, "es", "de", "fr"}
+}
+
+// Match returns the supported locale for a language tag such as "de",
+// "es-MX" or "fr_CA.UTF-8", ignoring region and encoding
+func Match(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if idx := strings.IndexAny(tag, ".@"); idx != -1 {
+		tag = tag[:idx]
+	}
+	parsed, err := language.Parse(strings.ReplaceAll(tag, "_", "-"))
+	if err != nil {
+		return "", false
+	}
+	base, _ := parsed.Base()
+	for _, loc := range Locales() {
+		if base.String() == loc {
+			return loc, true
+		}
+	}
+	return "", false
+}
+
+// SetLocale sets the language of translated output
+func SetLocale(loc string) error {
+	matched, ok := Match(loc)
+	if !ok {
+		return fmt.Errorf("unsupported language '%s' - supported: %s", loc, strings.Join(Locales(), ", "))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	locale = matched
+	printer = nil
+	if matched != DefaultLocale {
+		printer = message.NewPrinter(language.Make(matched), message.Catalog(builder))
+	}
+	return nil
+}
+
+// Locale returns the current locale
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return locale
+}
+
+// T formats a message in the current locale. Arguments follow fmt verbs;
+// numbers are written with the locale's separators.
+func T(key string, args ...interface{}) string {
+	mu.RLock()
+	p := printer
+	mu.RUnlock()
+
+	if p == nil {
+		if len(args) == 0 {
+			return key
+		}
+		return fmt.Sprintf(key, args...)
+	}
+	return p.Sprintf(key, args...)
+}
+
+// Text translates a message that isn't a format string, such as a label
+// picked at runtime
+func Text(msg string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if translated, ok := translations[locale][msg]; ok {
+		return translated
+	}
+	return msg
+}
@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestTranslations_Complete(t *testing.T) {
+	for loc, messages := range translations {
+		for other, otherMessages := range translations {
+			for key := range otherMessages {
+				assert.Contains(t, messages, key, "%s is missing a message %s has", loc, other)
+			}
+		}
+		for key, msg := range messages {
+			keyVerbs := verbPattern.FindAllString(key, -1)
+			msgVerbs := verbPattern.FindAllString(msg, -1)
+			sort.Strings(keyVerbs)
+			sort.Strings(msgVerbs)
+			assert.Equal(t, keyVerbs, msgVerbs, "%s message %q has different verbs", loc, key)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	t.Cleanup(func() { _ = SetLocale(DefaultLocale) })
+
+	assert.Equal(t, "Total files: 1234", T("Total files: %d", 1234))
+	assert.Equal(t, "Not translated 3", T("Not translated %d", 3))
+
+	require.NoError(t, SetLocale("de"))
+	assert.Equal(t, "de", Locale())
+	assert.Equal(t, "Dateien insgesamt: 1.234", T("Total files: %d", 1234))
+	assert.Equal(t, "Punktzahl: 72,5/100", T("Score: %.1f/100", 72.5))
+	// Messages without a translation are formatted as they are
+	assert.Equal(t, "Not translated 3", T("Not translated %d", 3))
+
+	require.NoError(t, SetLocale("es-MX"))
+	assert.Equal(t, "es", Locale())
+	assert.Equal(t, "Sugerencias:", T("Suggestions:"))
+
+	err := SetLocale("ja")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "supported: en, es, de, fr")
+	assert.Equal(t, "es", Locale())
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		tag    string
+		locale string
+		ok     bool
+	}{
+		{"fr", "fr", true},
+		{"FR", "fr", true},
+		{"de-AT", "de", true},
+		{"es_ES.UTF-8", "es", true},
+		{"en_US", "en", true},
+		{"pt-BR", "", false},
+		{"", "", false},
+		{"not a tag", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			locale, ok := Match(tt.tag)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.locale, locale)
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	t.Cleanup(func() { _ = SetLocale(DefaultLocale) })
+
+	assert.Equal(t, "critical", Text("critical"))
+	require.NoError(t, SetLocale("fr"))
+	assert.Equal(t, "critique", Text("critical"))
+	assert.Equal(t, "100% done", Text("100% done"))
+}
@@ -0,0 +1,238 @@
+// Package imageopt shrinks images: it scales them down to fit a maximum
+// size and encodes them again, as JPEG at a given quality, as PNG with the
+// best compression, or as WebP with the cwebp tool.
+package imageopt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formats an image can be written as
+const (
+	JPEG = "jpeg"
+	PNG  = "png"
+	WebP = "webp"
+)
+
+// ErrUnsupported is returned for images that are not PNG or JPEG
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Options configures how an image is optimized
+type Options struct {
+	MaxDimension int    // Longest side in pixels; larger images are scaled down (0 = keep their size)
+	Quality      int    // JPEG and WebP quality, 1 to 100
+	Format       string // Format written; empty keeps the image's own
+	CwebpPath    string // cwebp executable used to write WebP (default: cwebp)
+}
+
+// Result is an optimized image
+type Result struct {
+	Data           []byte
+	Format         string
+	Width          int
+	Height         int
+	OriginalWidth  int
+	OriginalHeight int
+}
+
+// Resized reports whether the image was scaled down
+func (r *Result) Resized() bool {
+	return r.Width*r.Height < r.OriginalWidth*r.OriginalHeight
+}
+
+// Optimize decodes a PNG or JPEG image, turns it upright if its EXIF data
+// says it is rotated, scales it down to fit options.MaxDimension and encodes
+// it again. Whether the result is any smaller is for the caller to check.
+func Optimize(ctx context.Context, data []byte, options Options) (*Result, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupported
+		}
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	if format != JPEG && format != PNG {
+		return nil, ErrUnsupported
+	}
+	if format == JPEG {
+		// Encoding drops the EXIF data, so the rotation has to be applied
+		img = Orient(img, jpegOrientation(data))
+	}
+
+	bounds := img.Bounds()
+	result := &Result{Format: format, OriginalWidth: bounds.Dx(), OriginalHeight: bounds.Dy()}
+	if options.Format != "" {
+		result.Format = options.Format
+	}
+	img = Resize(img, options.MaxDimension)
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	quality := options.Quality
+	if quality < 1 || quality > 100 {
+		quality = 85
+	}
+	var buf bytes.Buffer
+	switch result.Format {
+	case JPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case PNG:
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	case WebP:
+		var webp []byte
+		webp, err = encodeWebP(ctx, img, quality, options.CwebpPath)
+		buf.Write(webp)
+	default:
+		return nil, fmt.Errorf("can't write images as %q", result.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s: %w", result.Format, err)
+	}
+	result.Data = buf.Bytes()
+	return result, nil
+}
+
+// Resize scales img down, keeping its aspect ratio, so neither side is
+// longer than maxDimension. Each pixel is the average of the pixels it
+// covers. Images that already fit are returned as they are.
+func Resize(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if maxDimension <= 0 || (w <= maxDimension && h <= maxDimension) {
+		return img
+	}
+	scale := float64(maxDimension) / float64(max(w, h))
+	nw := max(1, int(math.Round(float64(w)*scale)))
+	nh := max(1, int(math.Round(float64(h)*scale)))
+
+	src := toRGBA(img)
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, max((y+1)*h/nh, y*h/nh+1)
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, max((x+1)*w/nw, x*w/nw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// Orient turns an image with the given EXIF orientation upright. Values 2
+// to 8 are flips and rotations; anything else returns img as it is.
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	src := toRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // Flipped horizontally
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // Flipped vertically
+				sx, sy = x, h-1-y
+			case 5: // Transposed
+				sx, sy = y, x
+			case 6: // Shown rotated 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // Transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // Shown rotated 90° anticlockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// toRGBA returns img as an RGBA image with its origin at 0,0
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// encodeWebP encodes img as WebP with cwebp, which works on files
+func encodeWebP(ctx context.Context, img image.Image, quality int, cwebpPath string) ([]byte, error) {
+	cwebp, err := FindCwebp(cwebpPath)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "mdnotes-webp-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.png")
+	output := filepath.Join(dir, "out.webp")
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(input, buf.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, cwebp, "-quiet", "-q", strconv.Itoa(quality), input, "-o", output)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running cwebp: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("running cwebp: %w", err)
+	}
+	return os.ReadFile(output)
+}
+
+// FindCwebp returns the path of the cwebp executable, named or on PATH
+func FindCwebp(cwebpPath string) (string, error) {
+	if cwebpPath == "" {
+		cwebpPath = "cwebp"
+	}
+	cwebp, err := exec.LookPath(cwebpPath)
+	if err != nil {
+		return "", fmt.Errorf("cwebp not found, install libwebp from https://developers.google.com/speed/webp to convert to WebP: %w", err)
+	}
+	return cwebp, nil
+}
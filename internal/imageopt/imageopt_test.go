@@ -0,0 +1,132 @@
+package imageopt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage returns a w by h image with a red top left pixel on white
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	return img
+}
+
+func TestResize(t *testing.T) {
+	img := testImage(400, 200)
+
+	resized := Resize(img, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), resized.Bounds())
+
+	// Images that fit are left alone
+	assert.Same(t, img, Resize(img, 400))
+	assert.Same(t, img, Resize(img, 0))
+
+	// Averaging a white area stays white
+	r, g, b, a := resized.At(50, 25).RGBA()
+	assert.Equal(t, [4]uint32{0xffff, 0xffff, 0xffff, 0xffff}, [4]uint32{r, g, b, a})
+}
+
+func TestOrient(t *testing.T) {
+	img := testImage(3, 2)
+	red := color.RGBA{R: 255, A: 255}
+
+	tests := []struct {
+		orientation int
+		size        image.Point
+		red         image.Point // Where the top left pixel ends up
+	}{
+		{1, image.Pt(3, 2), image.Pt(0, 0)},
+		{2, image.Pt(3, 2), image.Pt(2, 0)},
+		{3, image.Pt(3, 2), image.Pt(2, 1)},
+		{4, image.Pt(3, 2), image.Pt(0, 1)},
+		{5, image.Pt(2, 3), image.Pt(0, 0)},
+		{6, image.Pt(2, 3), image.Pt(1, 0)},
+		{7, image.Pt(2, 3), image.Pt(1, 2)},
+		{8, image.Pt(2, 3), image.Pt(0, 2)},
+	}
+	for _, tt := range tests {
+		oriented := Orient(img, tt.orientation)
+		assert.Equal(t, tt.size, oriented.Bounds().Size(), "orientation %d", tt.orientation)
+		assert.Equal(t, color.RGBAModel.Convert(red), color.RGBAModel.Convert(oriented.At(tt.red.X, tt.red.Y)), "orientation %d", tt.orientation)
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(4, 4), nil))
+	plain := buf.Bytes()
+	assert.Equal(t, 1, jpegOrientation(plain))
+
+	// An APP1 segment with a little-endian IFD holding orientation 6
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 6)
+	tiff = append(tiff, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	rotated := append(append([]byte{0xFF, 0xD8}, app1...), plain[2:]...)
+	assert.Equal(t, 6, jpegOrientation(rotated))
+	assert.Equal(t, 1, jpegOrientation([]byte("not a jpeg")))
+
+	// The rotation is applied when optimizing
+	result, err := Optimize(context.Background(), rotated, Options{Quality: 80})
+	require.NoError(t, err)
+	assert.Equal(t, JPEG, result.Format)
+}
+
+func TestOptimize(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(300, 150)))
+
+	result, err := Optimize(context.Background(), buf.Bytes(), Options{MaxDimension: 100, Quality: 80})
+	require.NoError(t, err)
+	assert.Equal(t, PNG, result.Format)
+	assert.True(t, result.Resized())
+	assert.Equal(t, 300, result.OriginalWidth)
+	assert.Equal(t, 100, result.Width)
+	assert.Equal(t, 50, result.Height)
+
+	decoded, err := png.Decode(bytes.NewReader(result.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(100, 50), decoded.Bounds().Size())
+
+	// As JPEG instead
+	result, err = Optimize(context.Background(), buf.Bytes(), Options{Format: JPEG, Quality: 80})
+	require.NoError(t, err)
+	assert.False(t, result.Resized())
+	_, err = jpeg.Decode(bytes.NewReader(result.Data))
+	assert.NoError(t, err)
+
+	_, err = Optimize(context.Background(), []byte("GIF89a"), Options{})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestOptimize_WebPWithoutCwebp(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(10, 10)))
+
+	_, err := Optimize(context.Background(), buf.Bytes(), Options{Format: WebP, CwebpPath: "cwebp-that-does-not-exist"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cwebp not found")
+}
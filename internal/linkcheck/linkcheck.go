@@ -0,0 +1,238 @@
+// Package linkcheck checks that web links still work, classifying each as
+// fine, moved, dead, timed out or failing for another reason.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/workerpool"
+)
+
+// Statuses a link can have
+const (
+	StatusOK       = "ok"       // Answered 2xx, perhaps after temporary redirects
+	StatusRedirect = "redirect" // Moved permanently to Location, which works
+	StatusDead     = "dead"     // 404 or 410, or the host doesn't exist
+	StatusTimeout  = "timeout"  // No answer in time
+	StatusError    = "error"    // Anything else: server errors, refused access, TLS failures
+)
+
+// maxRedirects is how many redirects are followed before giving up
+const maxRedirects = 10
+
+// Result is what checking a URL found
+type Result struct {
+	URL      string
+	Status   string
+	Code     int    // Final HTTP status code, 0 when there was no answer
+	Location string // Where a redirected link ends up
+	Error    string // Why a link failed
+	Checked  time.Time
+}
+
+// Transient reports whether the result may well differ when checked again
+func (r Result) Transient() bool {
+	return r.Status == StatusTimeout || r.Status == StatusError
+}
+
+// Describe explains the result in a few words
+func (r Result) Describe() string {
+	switch r.Status {
+	case StatusRedirect:
+		return "moved to " + r.Location
+	case StatusTimeout:
+		return "timed out"
+	}
+	if r.Error != "" {
+		return r.Error
+	}
+	return http.StatusText(r.Code)
+}
+
+// Options configure a checker. Empty fields take defaults.
+type Options struct {
+	Workers   int           // Links checked at once (default 8)
+	Timeout   time.Duration // For each request (default 10s)
+	UserAgent string
+}
+
+// Checker checks web links concurrently
+type Checker struct {
+	client    *http.Client
+	workers   int
+	userAgent string
+}
+
+// NewChecker creates a checker
+func NewChecker(opts Options) *Checker {
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "mdnotes/1.0 (link checker)"
+	}
+	return &Checker{
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			// Redirects are followed by hand, to tell permanent ones apart
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		workers:   opts.Workers,
+		userAgent: opts.UserAgent,
+	}
+}
+
+// Check checks each URL once, returning the results by URL
+func (c *Checker) Check(ctx context.Context, urls []string) map[string]Result {
+	results := make(map[string]Result, len(urls))
+	if len(urls) == 0 {
+		return results
+	}
+
+	pool := workerpool.NewWorkerPool(workerpool.Config{
+		MaxWorkers: min(c.workers, len(urls)),
+		QueueSize:  len(urls),
+		// A HEAD and a GET for each redirect, plus one more
+		TaskTimeout: time.Duration(2*(maxRedirects+1)) * c.client.Timeout,
+	})
+	defer func() { _ = pool.Shutdown(time.Second) }()
+
+	checked := make([]Result, len(urls))
+	tasks := make([]workerpool.Task, len(urls))
+	for i, u := range urls {
+		tasks[i] = func(taskCtx context.Context) error {
+			checked[i] = c.CheckURL(taskCtx, u)
+			return nil
+		}
+	}
+	pool.ProcessBatch(tasks)
+
+	for i, u := range urls {
+		result := checked[i]
+		if result.URL == "" {
+			result = Result{URL: u, Status: StatusTimeout, Checked: time.Now()}
+		}
+		results[u] = result
+	}
+	return results
+}
+
+// CheckURL checks a URL, following its redirects. Each hop is requested
+// with HEAD, falling back to GET for servers that don't answer HEAD properly.
+func (c *Checker) CheckURL(ctx context.Context, rawURL string) Result {
+	result := Result{URL: rawURL, Checked: time.Now()}
+	current := rawURL
+	permanent := true
+	for hop := 0; ; hop++ {
+		resp, err := c.request(ctx, http.MethodHead, current)
+		if err == nil && headUnsupported(resp.StatusCode) {
+			resp, err = c.request(ctx, http.MethodGet, current)
+		}
+		if err != nil {
+			return classifyError(result, err)
+		}
+		result.Code = resp.StatusCode
+
+		if isRedirect(resp.StatusCode) {
+			location, err := resp.Location()
+			if err != nil {
+				result.Status = StatusError
+				result.Error = fmt.Sprintf("redirect without a valid Location (%d)", resp.StatusCode)
+				return result
+			}
+			if hop >= maxRedirects {
+				result.Status = StatusError
+				result.Error = "too many redirects"
+				return result
+			}
+			// A link is only worth updating if every hop is permanent
+			if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusPermanentRedirect {
+				permanent = false
+			}
+			current = location.String()
+			continue
+		}
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			result.Status = StatusOK
+			if current != rawURL && permanent {
+				result.Status = StatusRedirect
+				result.Location = current
+			}
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			result.Status = StatusDead
+		default:
+			result.Status = StatusError
+			result.Error = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		return result
+	}
+}
+
+// request makes one request, discarding the body
+func (c *Checker) request(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "*/*")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// Only the status matters; a little of the body lets the connection be reused
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// headUnsupported reports whether a HEAD answer may just mean the server
+// doesn't handle HEAD, so GET should be tried
+func headUnsupported(code int) bool {
+	return code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented ||
+		code == http.StatusForbidden || code == http.StatusNotFound || code >= 500
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// classifyError sets the status for a request that got no answer
+func classifyError(result Result, err error) Result {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		result.Status = StatusDead
+		result.Error = "host not found"
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		result.Status = StatusTimeout
+	default:
+		result.Status = StatusError
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		result.Error = err.Error()
+	}
+	return result
+}
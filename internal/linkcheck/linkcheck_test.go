@@ -0,0 +1,75 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok", "/new":
+			w.WriteHeader(http.StatusOK)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/missing":
+			http.NotFound(w, r)
+		case "/moved":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/temporary":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		case "/chain":
+			http.Redirect(w, r, "/moved", http.StatusPermanentRedirect)
+		case "/moved-away":
+			http.Redirect(w, r, "/missing", http.StatusMovedPermanently)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/slow":
+			time.Sleep(300 * time.Millisecond)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	paths := []string{"/ok", "/gone", "/missing", "/moved", "/temporary", "/chain", "/moved-away", "/no-head", "/loop", "/slow", "/broken"}
+	urls := make([]string, len(paths))
+	for i, path := range paths {
+		urls[i] = server.URL + path
+	}
+
+	checker := NewChecker(Options{Workers: 4, Timeout: 100 * time.Millisecond})
+	results := checker.Check(context.Background(), urls)
+	assert.Len(t, results, len(urls))
+
+	status := func(path string) string {
+		return results[server.URL+path].Status
+	}
+	assert.Equal(t, StatusOK, status("/ok"))
+	assert.Equal(t, StatusDead, status("/gone"))
+	assert.Equal(t, StatusDead, status("/missing"))
+	assert.Equal(t, StatusRedirect, status("/moved"))
+	assert.Equal(t, server.URL+"/new", results[server.URL+"/moved"].Location)
+	assert.Equal(t, StatusOK, status("/temporary"), "temporary redirects aren't worth updating")
+	assert.Equal(t, StatusRedirect, status("/chain"))
+	assert.Equal(t, server.URL+"/new", results[server.URL+"/chain"].Location)
+	assert.Equal(t, StatusDead, status("/moved-away"))
+	assert.Equal(t, StatusOK, status("/no-head"))
+	assert.Equal(t, StatusError, status("/loop"))
+	assert.Equal(t, "too many redirects", results[server.URL+"/loop"].Error)
+	assert.Equal(t, StatusTimeout, status("/slow"))
+	assert.True(t, results[server.URL+"/slow"].Transient())
+	assert.Equal(t, StatusError, status("/broken"))
+	assert.Equal(t, "500 Internal Server Error", results[server.URL+"/broken"].Describe())
+}
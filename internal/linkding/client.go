@@ -8,12 +8,13 @@ import (
 	"io"
 	"net"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 )
 
 // Client represents a Linkding API client
@@ -34,6 +35,14 @@ func WithRateLimit(reqPerSec int) ClientOption {
 	}
 }
 
+// WithBurst sets how many requests may be made at once before the rate limit
+// applies. Pass it after WithRateLimit, which resets the burst to 1.
+func WithBurst(burst int) ClientOption {
+	return func(c *Client) {
+		c.rateLimiter.SetBurst(burst)
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client
 func WithHTTPClient(client *http.Client) ClientOption {
 	return func(c *Client) {
@@ -295,6 +304,53 @@ func (c *Client) GetBookmarks(ctx context.Context) (*BookmarkListResponse, error
 	return &bookmarks, nil
 }
 
+// ListAllBookmarks retrieves every bookmark, archived or not, following the
+// API's pagination
+func (c *Client) ListAllBookmarks(ctx context.Context) ([]BookmarkResponse, error) {
+	var bookmarks []BookmarkResponse
+	for _, endpoint := range []string{"/api/bookmarks/", "/api/bookmarks/archived/"} {
+		next := c.baseURL + endpoint + "?limit=100"
+		for next != "" {
+			page, err := c.getBookmarkPage(ctx, next)
+			if err != nil {
+				return nil, err
+			}
+			bookmarks = append(bookmarks, page.Results...)
+			next = ""
+			if page.Next != nil {
+				next = *page.Next
+			}
+		}
+	}
+	return bookmarks, nil
+}
+
+// getBookmarkPage retrieves one page of a bookmark listing
+func (c *Client) getBookmarkPage(ctx context.Context, pageURL string) (*BookmarkListResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := c.doRequestWithRetry(ctx, httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := c.checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var page BookmarkListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &page, nil
+}
+
 // UpdateBookmark updates an existing bookmark
 func (c *Client) UpdateBookmark(ctx context.Context, id int, req UpdateBookmarkRequest) (*BookmarkResponse, error) {
 	body, err := json.Marshal(req)
@@ -527,15 +583,15 @@ func (c *Client) DownloadAsset(ctx context.Context, bookmarkID, assetID int, des
 	}
 
 	// Write response body to file
-	file, err := os.Create(destPath)
+	file, err := safety.CreateAtomic(destPath, 0644)
 	if err != nil {
 		return fmt.Errorf("creating destination file: %w", err)
 	}
-	defer file.Close()
+	defer file.Abort()
 
 	if _, err := io.Copy(file, resp.Body); err != nil {
 		return fmt.Errorf("copying response to file: %w", err)
 	}
 
-	return nil
+	return file.Commit()
 }
@@ -77,6 +77,34 @@ func TestClient_GetBookmarks(t *testing.T) {
 	assert.Equal(t, "Example 1", bookmarks.Results[0].Title)
 }
 
+func TestClient_ListAllBookmarks(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		var resp BookmarkListResponse
+		switch {
+		case r.URL.Path == "/api/bookmarks/" && r.URL.Query().Get("offset") == "":
+			next := server.URL + "/api/bookmarks/?limit=100&offset=100"
+			resp = BookmarkListResponse{Count: 2, Next: &next, Results: []BookmarkResponse{{ID: 1}}}
+		case r.URL.Path == "/api/bookmarks/":
+			resp = BookmarkListResponse{Count: 2, Results: []BookmarkResponse{{ID: 2}}}
+		case r.URL.Path == "/api/bookmarks/archived/":
+			resp = BookmarkListResponse{Count: 1, Results: []BookmarkResponse{{ID: 3, IsArchived: true}}}
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	bookmarks, err := client.ListAllBookmarks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, bookmarks, 3)
+	assert.Equal(t, 3, bookmarks[2].ID)
+}
+
 func TestClient_UpdateBookmark(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "PATCH", r.Method)
@@ -137,6 +165,15 @@ func TestClient_RateLimiting(t *testing.T) {
 	assert.Greater(t, elapsed, 500*time.Millisecond)
 }
 
+func TestClient_RateLimitBurst(t *testing.T) {
+	client := NewClient("http://localhost", "test-token", WithRateLimit(10), WithBurst(4))
+	assert.Equal(t, 4, client.rateLimiter.Burst())
+	assert.InDelta(t, 10, float64(client.rateLimiter.Limit()), 0.001)
+
+	client = NewClient("http://localhost", "test-token")
+	assert.Equal(t, 2, client.rateLimiter.Burst())
+}
+
 func TestClient_ErrorHandling(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -0,0 +1,159 @@
+// Package mcp serves a vault to LLM assistants over the Model Context
+// Protocol: JSON-RPC 2.0 messages, one per line, on stdin and stdout.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProtocolVersion is the MCP revision this server implements
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Tool describes a tool in tools/list
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// content is a block of tool output
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type toolResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Server answers MCP requests for a Vault
+type Server struct {
+	vault   *Vault
+	name    string
+	version string
+	mu      sync.Mutex // serializes writes to the output
+}
+
+// NewServer creates a server for v, reporting name and version to clients
+func NewServer(v *Vault, name, version string) *Server {
+	return &Server{vault: v, name: name, version: version}
+}
+
+// Serve reads requests from r and writes responses to w until r is exhausted
+// or ctx is cancelled. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(w, response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if resp, ok := s.handle(req); ok {
+			s.write(w, resp)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading requests: %w", err)
+	}
+	return nil
+}
+
+// handle answers a request, reporting false for notifications, which get no response
+func (s *Server) handle(req request) (response, bool) {
+	if len(req.ID) == 0 {
+		return response{}, false
+	}
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: codeInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}
+		return resp, true
+	}
+
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	case "tools/list":
+		resp.Result = map[string]interface{}{"tools": s.vault.Tools()}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			resp.Error = &rpcError{Code: codeInvalidParams, Message: "tools/call needs a tool name"}
+			return resp, true
+		}
+		text, err := s.vault.Call(params.Name, params.Arguments)
+		if err != nil {
+			// Tool failures are results, so the assistant can see and react to them
+			resp.Result = toolResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+			return resp, true
+		}
+		resp.Result = toolResult{Content: []content{{Type: "text", Text: text}}}
+	default:
+		resp.Error = &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+	return resp, true
+}
+
+func (s *Server) write(w io.Writer, resp response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID,
+			Error: &rpcError{Code: codeInvalidRequest, Message: fmt.Sprintf("encoding response: %v", err)}})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = w.Write(append(data, '\n'))
+}
@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVault(t *testing.T, opts Options) (*Vault, string) {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".mdnotes"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "projects"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "private"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "projects", "plan.md"), []byte("---\ntitle: Plan\n# keep me\nstatus: draft\n---\nSee [[goals]]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "projects", "goals.md"), []byte("---\ntitle: Goals\nstatus: done\n---\nBack to [[plan]]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "private", "diary.md"), []byte("---\nstatus: draft\n---\nSecret\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "projects", "locked.md"), []byte("---\nmdnotes: locked\n---\n"), 0644))
+	opts.Root = root
+	return NewVault(opts), root
+}
+
+// rpc sends requests through a server and returns its responses by id
+func rpc(t *testing.T, v *Vault, requests ...string) map[float64]map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, NewServer(v, "mdnotes", "test").Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out))
+
+	responses := make(map[float64]map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &resp))
+		id, _ := resp["id"].(float64)
+		responses[id] = resp
+	}
+	return responses
+}
+
+// toolText returns the text of a tools/call result and whether it is an error
+func toolText(t *testing.T, resp map[string]interface{}) (string, bool) {
+	t.Helper()
+	result, ok := resp["result"].(map[string]interface{})
+	require.True(t, ok, "expected a result, got %v", resp)
+	isError, _ := result["isError"].(bool)
+	return result["content"].([]interface{})[0].(map[string]interface{})["text"].(string), isError
+}
+
+func TestServer_Protocol(t *testing.T) {
+	v, _ := newTestVault(t, Options{ReadOnly: true})
+	responses := rpc(t, v,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+	)
+
+	assert.Len(t, responses, 4, "notifications get no response")
+	assert.Equal(t, ProtocolVersion, responses[1]["result"].(map[string]interface{})["protocolVersion"])
+
+	var names []string
+	for _, tool := range responses[2]["result"].(map[string]interface{})["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	assert.ElementsMatch(t, []string{"query_notes", "get_note", "link_graph", "vault_stats"}, names)
+
+	assert.Equal(t, float64(codeMethodNotFound), responses[3]["error"].(map[string]interface{})["code"])
+	assert.Equal(t, float64(codeParseError), responses[0]["error"].(map[string]interface{})["code"])
+}
+
+func TestVault_QueryRespectsAllowlist(t *testing.T) {
+	v, _ := newTestVault(t, Options{Allow: []string{"projects/*"}})
+
+	text, err := v.Call("query_notes", json.RawMessage(`{"where": "status = 'draft'"}`))
+	require.NoError(t, err)
+	var result struct {
+		Matches int `json:"matches"`
+		Notes   []struct {
+			Path string `json:"path"`
+		} `json:"notes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(text), &result))
+	assert.Equal(t, 1, result.Matches)
+	assert.Equal(t, "projects/plan.md", result.Notes[0].Path)
+
+	_, err = v.Call("get_note", json.RawMessage(`{"path": "private/diary.md"}`))
+	assert.ErrorContains(t, err, "outside the allowed paths")
+	_, err = v.Call("get_note", json.RawMessage(`{"path": "../outside.md"}`))
+	assert.ErrorContains(t, err, "invalid note path")
+}
+
+func TestVault_LinkGraph(t *testing.T) {
+	v, _ := newTestVault(t, Options{})
+
+	text, err := v.Call("link_graph", json.RawMessage(`{"format": "mermaid", "min_connections": 1}`))
+	require.NoError(t, err)
+	assert.Contains(t, text, "plan")
+	assert.Contains(t, text, "goals")
+	assert.NotContains(t, text, "diary")
+}
+
+func TestVault_UpdateFrontmatter(t *testing.T) {
+	v, root := newTestVault(t, Options{})
+
+	responses := rpc(t, v,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"update_frontmatter","arguments":{"path":"projects/plan.md","set":{"status":"active"}}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"update_frontmatter","arguments":{"path":"projects/locked.md","set":{"status":"active"}}}}`,
+	)
+
+	_, isError := toolText(t, responses[1])
+	assert.False(t, isError)
+	content, err := os.ReadFile(filepath.Join(root, "projects", "plan.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Plan\n# keep me\nstatus: active\n---\n\nSee [[goals]]\n", string(content))
+
+	text, isError := toolText(t, responses[2])
+	assert.True(t, isError)
+	assert.Contains(t, text, "locked")
+
+	// The change is journaled so it can be undone
+	entries, err := os.ReadDir(filepath.Join(root, ".mdnotes", "journal"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestVault_ReadOnly(t *testing.T) {
+	v, _ := newTestVault(t, Options{ReadOnly: true})
+
+	_, err := v.Call("update_frontmatter", json.RawMessage(`{"path": "projects/plan.md", "set": {"status": "x"}}`))
+	assert.ErrorContains(t, err, "read-only")
+}
@@ -0,0 +1,385 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Options configures the vault exposed to MCP clients
+type Options struct {
+	Root           string   // Vault root directory
+	ReadOnly       bool     // Hide and refuse tools that change files
+	Allow          []string // Vault-relative glob patterns notes must match to be visible; empty allows all
+	IgnorePatterns []string // Vault scan ignore patterns
+	Workers        int      // Scan workers; 0 uses the scanner default
+
+	// ResolveQuery expands saved query references ("@name"); nil leaves
+	// expressions as given
+	ResolveQuery func(string) (string, error)
+
+	// Commit finalizes the journal transaction of a tool call that changed
+	// files; nil commits it directly
+	Commit func(tx *safety.Transaction)
+}
+
+// Vault implements the MCP tools over a vault
+type Vault struct {
+	opts Options
+}
+
+// NewVault creates the tool set for the vault described by opts
+func NewVault(opts Options) *Vault {
+	return &Vault{opts: opts}
+}
+
+// Tools lists the available tools. Tools that change files are left out in
+// read-only mode.
+func (v *Vault) Tools() []Tool {
+	tools := []Tool{
+		{
+			Name:        "query_notes",
+			Description: "Find notes whose frontmatter matches a query expression, e.g. \"status = 'draft' AND tags contains 'project'\". Returns each note's path and frontmatter.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"where":        stringProperty("Query expression, or @name for a saved query"),
+				"limit":        map[string]interface{}{"type": "integer", "description": "Maximum number of notes to return (default 50)"},
+				"include_body": map[string]interface{}{"type": "boolean", "description": "Include each note's body"},
+			}, "where"),
+		},
+		{
+			Name:        "get_note",
+			Description: "Read a note's frontmatter and body by its vault-relative path.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"path": stringProperty("Vault-relative path of the note, e.g. projects/plan.md"),
+			}, "path"),
+		},
+		{
+			Name:        "link_graph",
+			Description: "Get the link graph between notes as JSON nodes and edges, or as Mermaid or DOT text.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"format":          map[string]interface{}{"type": "string", "enum": []string{"json", "mermaid", "dot"}, "description": "Output format (default json)"},
+				"min_connections": map[string]interface{}{"type": "integer", "description": "Leave out notes with fewer links in or out"},
+			}),
+		},
+		{
+			Name:        "vault_stats",
+			Description: "Summarize the vault: file counts, tags, frontmatter fields, orphaned notes and broken links.",
+			InputSchema: objectSchema(map[string]interface{}{}),
+		},
+	}
+	if !v.opts.ReadOnly {
+		tools = append(tools, Tool{
+			Name:        "update_frontmatter",
+			Description: "Set and remove frontmatter fields of a note. Other fields, comments and the body are left as they are. Locked notes can't be changed.",
+			InputSchema: objectSchema(map[string]interface{}{
+				"path":   stringProperty("Vault-relative path of the note"),
+				"set":    map[string]interface{}{"type": "object", "description": "Fields to set, with their new values"},
+				"remove": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Fields to remove"},
+			}, "path"),
+		})
+	}
+	return tools
+}
+
+// Call runs a tool with JSON arguments and returns its text output
+func (v *Vault) Call(name string, arguments json.RawMessage) (string, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	switch name {
+	case "query_notes":
+		var args struct {
+			Where       string `json:"where"`
+			Limit       int    `json:"limit"`
+			IncludeBody bool   `json:"include_body"`
+		}
+		if err := decodeArguments(arguments, &args); err != nil {
+			return "", err
+		}
+		return v.queryNotes(args.Where, args.Limit, args.IncludeBody)
+	case "get_note":
+		var args struct {
+			Path string `json:"path"`
+		}
+		if err := decodeArguments(arguments, &args); err != nil {
+			return "", err
+		}
+		return v.getNote(args.Path)
+	case "link_graph":
+		var args struct {
+			Format         string `json:"format"`
+			MinConnections int    `json:"min_connections"`
+		}
+		if err := decodeArguments(arguments, &args); err != nil {
+			return "", err
+		}
+		return v.linkGraph(args.Format, args.MinConnections)
+	case "vault_stats":
+		return v.vaultStats()
+	case "update_frontmatter":
+		if v.opts.ReadOnly {
+			return "", fmt.Errorf("update_frontmatter is disabled: the server is read-only")
+		}
+		var args struct {
+			Path   string                 `json:"path"`
+			Set    map[string]interface{} `json:"set"`
+			Remove []string               `json:"remove"`
+		}
+		if err := decodeArguments(arguments, &args); err != nil {
+			return "", err
+		}
+		return v.updateFrontmatter(args.Path, args.Set, args.Remove)
+	default:
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+// noteJSON is a note as returned by the tools
+type noteJSON struct {
+	Path        string                 `json:"path"`
+	Frontmatter map[string]interface{} `json:"frontmatter"`
+	Body        string                 `json:"body,omitempty"`
+}
+
+func (v *Vault) queryNotes(where string, limit int, includeBody bool) (string, error) {
+	if strings.TrimSpace(where) == "" {
+		return "", fmt.Errorf("where is required")
+	}
+	if v.opts.ResolveQuery != nil {
+		resolved, err := v.opts.ResolveQuery(where)
+		if err != nil {
+			return "", err
+		}
+		where = resolved
+	}
+	expr, err := query.NewParser(where).Parse()
+	if err != nil {
+		return "", fmt.Errorf("parsing query: %w", err)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	files, err := v.scan()
+	if err != nil {
+		return "", err
+	}
+
+	matches := 0
+	notes := []noteJSON{}
+	for _, file := range files {
+		if !expr.Evaluate(file) {
+			continue
+		}
+		matches++
+		if len(notes) < limit {
+			notes = append(notes, toNoteJSON(file, includeBody))
+		}
+	}
+	return encode(map[string]interface{}{"matches": matches, "notes": notes})
+}
+
+func (v *Vault) getNote(rel string) (string, error) {
+	file, err := v.load(rel)
+	if err != nil {
+		return "", err
+	}
+	return encode(toNoteJSON(file, true))
+}
+
+func (v *Vault) linkGraph(format string, minConnections int) (string, error) {
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "mermaid" && format != "dot" {
+		return "", fmt.Errorf("unsupported graph format %q (supported: json, mermaid, dot)", format)
+	}
+
+	files, err := v.scan()
+	if err != nil {
+		return "", err
+	}
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	links := ana.AnalyzeLinks(files)
+	graph := analyzer.NewNoteGraph(files, links.Clusters, minConnections)
+
+	if format == "json" {
+		return encode(graph)
+	}
+	var buf bytes.Buffer
+	if err := analyzer.WriteGraph(&buf, graph, format); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (v *Vault) vaultStats() (string, error) {
+	files, err := v.scan()
+	if err != nil {
+		return "", err
+	}
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	return encode(ana.GenerateStats(files))
+}
+
+func (v *Vault) updateFrontmatter(rel string, set map[string]interface{}, remove []string) (string, error) {
+	if len(set) == 0 && len(remove) == 0 {
+		return "", fmt.Errorf("nothing to change: give fields to set or remove")
+	}
+	file, err := v.load(rel)
+	if err != nil {
+		return "", err
+	}
+	if reason := file.LockReason(); reason != "" {
+		return "", fmt.Errorf("%s is locked (%s)", rel, reason)
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		file.SetField(key, set[key])
+	}
+	for _, key := range remove {
+		delete(file.Frontmatter, key)
+	}
+
+	content, err := file.Serialize()
+	if err != nil {
+		return "", fmt.Errorf("serializing %s: %w", rel, err)
+	}
+	tx := safety.NewJournal(safety.FindVaultRoot(v.opts.Root)).Begin("mdnotes mcp")
+	tx.SetReason("update_frontmatter")
+	if err := tx.RecordWrite(file.Path); err != nil {
+		return "", fmt.Errorf("journaling %s: %w", rel, err)
+	}
+	if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+		return "", err
+	}
+	if v.opts.Commit != nil {
+		v.opts.Commit(tx)
+	} else if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("writing change journal: %w", err)
+	}
+
+	return encode(toNoteJSON(file, false))
+}
+
+// scan returns the visible notes, sorted by path
+func (v *Vault) scan() ([]*vault.VaultFile, error) {
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(v.opts.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(vault.FindIndex(v.opts.Root)),
+		vault.WithWorkers(v.opts.Workers),
+	)
+	files, err := scanner.Walk(v.opts.Root)
+	if err != nil {
+		return nil, fmt.Errorf("scanning vault: %w", err)
+	}
+
+	visible := files[:0]
+	for _, file := range files {
+		if v.allowed(filepath.ToSlash(file.RelativePath)) {
+			visible = append(visible, file)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].RelativePath < visible[j].RelativePath })
+	return visible, nil
+}
+
+// load reads a visible note by its vault-relative path
+func (v *Vault) load(rel string) (*vault.VaultFile, error) {
+	rel = filepath.ToSlash(filepath.Clean(filepath.FromSlash(rel)))
+	if !filepath.IsLocal(filepath.FromSlash(rel)) || !strings.EqualFold(filepath.Ext(rel), ".md") {
+		return nil, fmt.Errorf("invalid note path %q: must be a vault-relative .md path", rel)
+	}
+	if !v.allowed(rel) {
+		return nil, fmt.Errorf("%s is outside the allowed paths", rel)
+	}
+
+	path := filepath.Join(v.opts.Root, filepath.FromSlash(rel))
+	file, err := vault.LoadVaultFile(path)
+	if err != nil {
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			return nil, fmt.Errorf("%s not found", rel)
+		}
+		return nil, err
+	}
+	file.RelativePath = rel
+	return file, nil
+}
+
+// allowed reports whether a vault-relative path matches the allowlist. A
+// pattern ending in /* or /** allows everything under that folder.
+func (v *Vault) allowed(rel string) bool {
+	if len(v.opts.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range v.opts.Allow {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+		for _, suffix := range []string{"/**", "/*"} {
+			if dir, ok := strings.CutSuffix(pattern, suffix); ok && strings.HasPrefix(rel, dir+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toNoteJSON(file *vault.VaultFile, includeBody bool) noteJSON {
+	note := noteJSON{Path: filepath.ToSlash(file.RelativePath), Frontmatter: file.Frontmatter}
+	if note.Frontmatter == nil {
+		note.Frontmatter = map[string]interface{}{}
+	}
+	if includeBody {
+		note.Body = file.Body
+	}
+	return note
+}
+
+func decodeArguments(arguments json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(arguments))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func encode(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding result: %w", err)
+	}
+	return string(data), nil
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
@@ -0,0 +1,200 @@
+// Package metrics collects counters and gauges for long-running mdnotes
+// processes and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Labels distinguish series of the same metric
+type Labels map[string]string
+
+// Metric types
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Registry holds metric families and their current values. It is safe for
+// concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	name   string
+	help   string
+	kind   string
+	series map[string]float64 // rendered label set -> value
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Register declares a metric so it is exported (with zero series) before its
+// first update. Registering an existing metric is a no-op.
+func (r *Registry) Register(name, kind, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name, kind, help)
+}
+
+// Add increments a counter
+func (r *Registry) Add(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name, Counter, "").series[renderLabels(labels)] += delta
+}
+
+// Inc increments a counter by one
+func (r *Registry) Inc(name string, labels Labels) {
+	r.Add(name, labels, 1)
+}
+
+// Set sets a gauge
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name, Gauge, "").series[renderLabels(labels)] = value
+}
+
+// Value returns the current value of a series, or 0 if it doesn't exist
+func (r *Registry) Value(name string, labels Labels) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f.series[renderLabels(labels)]
+	}
+	return 0
+}
+
+// WriteText writes all metrics in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, strings.ReplaceAll(f.help, "\n", " "))
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.kind)
+
+		labelSets := make([]string, 0, len(f.series))
+		for labels := range f.series {
+			labelSets = append(labelSets, labels)
+		}
+		sort.Strings(labelSets)
+		for _, labels := range labelSets {
+			fmt.Fprintf(&b, "%s%s %s\n", name, labels, formatValue(f.series[labels]))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the metrics page
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.WriteText(w)
+}
+
+// Serve exposes the registry at /metrics on addr until ctx is cancelled.
+// ready, if set, is called with the listening address once the server accepts
+// connections.
+func Serve(ctx context.Context, addr string, registry *Registry, ready func(addr string)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("starting metrics server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if ready != nil {
+		ready(listener.Addr().String())
+	}
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving metrics: %w", err)
+	}
+	return nil
+}
+
+// family returns the named family, creating it if needed. Callers hold r.mu.
+func (r *Registry) family(name, kind, help string) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, kind: kind, series: make(map[string]float64)}
+		r.families[name] = f
+	}
+	if help != "" {
+		f.help = help
+	}
+	return f
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// renderLabels formats a label set as {a="1",b="2"}, sorted by name
+func renderLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=\"" + labelEscaper.Replace(labels[name]) + "\""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
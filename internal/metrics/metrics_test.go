@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("mdnotes_errors_total", Counter, "Errors encountered")
+	registry.Register("mdnotes_unused", Gauge, "Registered but never set")
+	registry.Inc("mdnotes_errors_total", Labels{"stage": "action"})
+	registry.Add("mdnotes_errors_total", Labels{"stage": "action"}, 2)
+	registry.Inc("mdnotes_errors_total", Labels{"stage": `scan "full"`})
+	registry.Set("mdnotes_vault_health_score", nil, 87.5)
+	registry.Set("mdnotes_vault_health_score", nil, 90)
+
+	var b strings.Builder
+	require.NoError(t, registry.WriteText(&b))
+
+	assert.Equal(t, `# HELP mdnotes_errors_total Errors encountered
+# TYPE mdnotes_errors_total counter
+mdnotes_errors_total{stage="action"} 3
+mdnotes_errors_total{stage="scan \"full\""} 1
+# HELP mdnotes_unused Registered but never set
+# TYPE mdnotes_unused gauge
+# TYPE mdnotes_vault_health_score gauge
+mdnotes_vault_health_score 90
+`, b.String())
+
+	assert.Equal(t, float64(3), registry.Value("mdnotes_errors_total", Labels{"stage": "action"}))
+	assert.Zero(t, registry.Value("mdnotes_missing", nil))
+}
+
+func TestRenderLabels_Sorted(t *testing.T) {
+	assert.Equal(t, `{a="1",b="x\\y\n"}`, renderLabels(Labels{"b": "x\\y\n", "a": "1"}))
+	assert.Empty(t, renderLabels(nil))
+}
+
+func TestServe(t *testing.T) {
+	registry := NewRegistry()
+	registry.Set("mdnotes_vault_files", nil, 12)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, "127.0.0.1:0", registry, func(addr string) { ready <- addr })
+	}()
+	addr := <-ready
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	assert.Contains(t, string(body), "mdnotes_vault_files 12\n")
+
+	resp, err = http.Get("http://" + addr + "/other")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	cancel()
+	require.NoError(t, <-done)
+}
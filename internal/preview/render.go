@@ -0,0 +1,294 @@
+// Package preview renders exported notes as HTML and serves them locally so an
+// export can be inspected before anything is written to its destination.
+package preview
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// RenderMarkdown converts markdown to HTML. It covers the constructs common in
+// notes (headings, paragraphs, lists, task lists, block quotes, fenced code,
+// tables, links, images and emphasis) and is meant for previews, not as a
+// complete CommonMark implementation.
+func RenderMarkdown(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(src, "\n"))
+	return b.String()
+}
+
+var (
+	headingRegex   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	fenceRegex     = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+-]*)")
+	hrRegex        = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	listItemRegex  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskRegex      = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	tableSepRegex  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	headingIDRegex = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case fenceRegex.MatchString(line):
+			m := fenceRegex.FindStringSubmatch(line)
+			var code []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // closing fence
+			if m[2] != "" {
+				fmt.Fprintf(b, "<pre><code class=\"language-%s\">", html.EscapeString(m[2]))
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case headingRegex.MatchString(line):
+			m := headingRegex.FindStringSubmatch(line)
+			id := strings.Trim(headingIDRegex.ReplaceAllString(strings.ToLower(m[2]), "-"), "-")
+			fmt.Fprintf(b, "<h%d id=\"%s\">%s</h%d>\n", len(m[1]), id, renderInline(m[2]), len(m[1]))
+			i++
+
+		case hrRegex.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				content := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(content, " "))
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case listItemRegex.MatchString(line):
+			start := i
+			first := listItemRegex.FindStringSubmatch(line)
+			i++
+			for i < len(lines) {
+				next := lines[i]
+				// Switching between bullets and numbers at the same level starts a new list
+				if m := listItemRegex.FindStringSubmatch(next); m != nil && len(m[1]) <= len(first[1]) && isOrdered(m[2]) != isOrdered(first[2]) {
+					break
+				}
+				if strings.TrimSpace(next) == "" {
+					// A blank line continues the list only if more list content follows
+					if i+1 < len(lines) && (listItemRegex.MatchString(lines[i+1]) || strings.HasPrefix(lines[i+1], "  ")) {
+						i++
+						continue
+					}
+					break
+				}
+				if !listItemRegex.MatchString(next) && !strings.HasPrefix(next, " ") && !strings.HasPrefix(next, "\t") {
+					break
+				}
+				i++
+			}
+			renderList(b, lines[start:i])
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableSepRegex.MatchString(lines[i+1]):
+			start := i
+			i += 2
+			for i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+				i++
+			}
+			renderTable(b, lines[start], lines[start+2:i])
+
+		default:
+			var para []string
+			for i < len(lines) {
+				l := lines[i]
+				if strings.TrimSpace(l) == "" || headingRegex.MatchString(l) || fenceRegex.MatchString(l) ||
+					strings.HasPrefix(strings.TrimSpace(l), ">") || (len(para) > 0 && listItemRegex.MatchString(l)) {
+					break
+				}
+				para = append(para, strings.TrimSpace(l))
+				i++
+			}
+			b.WriteString("<p>" + renderInline(strings.Join(para, "\n")) + "</p>\n")
+		}
+	}
+}
+
+// renderList renders a list block; items are split at the first item's
+// indentation and their remaining lines rendered recursively
+func renderList(b *strings.Builder, lines []string) {
+	first := listItemRegex.FindStringSubmatch(lines[0])
+	indent := len(first[1])
+	tag := "ul"
+	if isOrdered(first[2]) {
+		tag = "ol"
+	}
+
+	b.WriteString("<" + tag + ">\n")
+	var item []string
+	flush := func() {
+		if len(item) == 0 {
+			return
+		}
+		text := item[0]
+		checkbox := ""
+		if m := taskRegex.FindStringSubmatch(text); m != nil {
+			checked := ""
+			if m[1] != " " {
+				checked = " checked"
+			}
+			checkbox = "<input type=\"checkbox\" disabled" + checked + "> "
+			text = m[2]
+		}
+		b.WriteString("<li>" + checkbox + renderInline(text))
+		if len(item) > 1 {
+			b.WriteString("\n")
+			renderBlocks(b, dedent(item[1:]))
+		}
+		b.WriteString("</li>\n")
+		item = nil
+	}
+
+	for _, line := range lines {
+		if m := listItemRegex.FindStringSubmatch(line); m != nil && len(m[1]) <= indent {
+			flush()
+			item = []string{m[3]}
+			continue
+		}
+		item = append(item, line)
+	}
+	flush()
+	b.WriteString("</" + tag + ">\n")
+}
+
+// isOrdered reports whether a list marker is numbered
+func isOrdered(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// dedent removes the common leading whitespace from lines
+func dedent(lines []string) []string {
+	min := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if min < 0 || n < min {
+			min = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= min && min > 0 {
+			out[i] = line[min:]
+		} else {
+			out[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return out
+}
+
+func renderTable(b *strings.Builder, header string, rows []string) {
+	cells := func(line string) []string {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(strings.TrimSuffix(line, "|"), "|")
+		parts := strings.Split(line, "|")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+
+	b.WriteString("<table>\n<thead><tr>")
+	for _, cell := range cells(header) {
+		b.WriteString("<th>" + renderInline(cell) + "</th>")
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range cells(row) {
+			b.WriteString("<td>" + renderInline(cell) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+}
+
+// inlineRegex matches inline constructs in priority order
+var inlineRegex = regexp.MustCompile(
+	"(`+)(.+?)`+" + // 1-2 code
+		`|!\[([^\]]*)\]\(([^)\s]+|<[^>]+>)(?:\s+"[^"]*")?\)` + // 3-4 image
+		`|\[\[([^\]|#]*)(#[^\]|]*)?(?:\|([^\]]+))?\]\]` + // 5-7 wiki link
+		`|\[([^\]]+)\]\(([^)\s]+|<[^>]+>)(?:\s+"[^"]*")?\)` + // 8-9 link
+		`|<(https?://[^>\s]+)>` + // 10 autolink
+		`|\*\*(.+?)\*\*|__(.+?)__` + // 11-12 bold
+		`|~~(.+?)~~` + // 13 strikethrough
+		`|\*([^*\s](?:.*?[^*\s])?)\*|\b_([^_\s](?:.*?[^_\s])?)_\b`) // 14-15 italic
+
+func renderInline(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inlineRegex.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:m[0]]))
+		last = m[1]
+
+		group := func(n int) string {
+			if m[2*n] < 0 {
+				return ""
+			}
+			return text[m[2*n]:m[2*n+1]]
+		}
+
+		switch {
+		case m[2] >= 0:
+			b.WriteString("<code>" + html.EscapeString(strings.TrimSpace(group(2))) + "</code>")
+		case m[6] >= 0:
+			fmt.Fprintf(&b, "<img src=\"%s\" alt=\"%s\">", html.EscapeString(linkTarget(group(4))), html.EscapeString(group(3)))
+		case m[10] >= 0:
+			target, fragment, label := group(5), group(6), group(7)
+			if label == "" {
+				label = target + fragment
+			}
+			// Wiki links are vault-relative; the server resolves bare note names
+			href := fragment
+			if target != "" {
+				href = "/" + strings.TrimSuffix(target, ".md") + ".md" + fragment
+			}
+			fmt.Fprintf(&b, "<a href=\"%s\">%s</a>", html.EscapeString(href), html.EscapeString(label))
+		case m[16] >= 0:
+			fmt.Fprintf(&b, "<a href=\"%s\">%s</a>", html.EscapeString(linkTarget(group(9))), renderInline(group(8)))
+		case m[20] >= 0:
+			fmt.Fprintf(&b, "<a href=\"%s\">%s</a>", html.EscapeString(group(10)), html.EscapeString(group(10)))
+		case m[22] >= 0:
+			b.WriteString("<strong>" + renderInline(group(11)) + "</strong>")
+		case m[24] >= 0:
+			b.WriteString("<strong>" + renderInline(group(12)) + "</strong>")
+		case m[26] >= 0:
+			b.WriteString("<del>" + renderInline(group(13)) + "</del>")
+		case m[28] >= 0:
+			b.WriteString("<em>" + renderInline(group(14)) + "</em>")
+		case m[30] >= 0:
+			b.WriteString("<em>" + renderInline(group(15)) + "</em>")
+		}
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+// linkTarget strips angle brackets from a markdown link destination
+func linkTarget(dest string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+}
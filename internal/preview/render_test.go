@@ -0,0 +1,70 @@
+package preview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+	}{
+		{
+			name:     "heading and paragraph",
+			input:    "# Hello *World*\n\nSome **bold** text\nwrapped",
+			contains: []string{`<h1 id="hello-world">Hello <em>World</em></h1>`, "<p>Some <strong>bold</strong> text\nwrapped</p>"},
+		},
+		{
+			name:     "escapes html",
+			input:    "a <script> & b",
+			contains: []string{"<p>a &lt;script&gt; &amp; b</p>"},
+		},
+		{
+			name:     "fenced code is not formatted",
+			input:    "```go\nx := *a* <b>\n```",
+			contains: []string{`<pre><code class="language-go">x := *a* &lt;b&gt;</code></pre>`},
+		},
+		{
+			name:     "inline code is not formatted",
+			input:    "use `**x**` here",
+			contains: []string{"<code>**x**</code>"},
+		},
+		{
+			name:     "nested and task lists",
+			input:    "- [x] done\n- item\n  - child\n1. first",
+			contains: []string{`<li><input type="checkbox" disabled checked> done</li>`, "<li>item\n<ul>\n<li>child</li>\n</ul>\n</li>", "<ol>\n<li>first</li>"},
+		},
+		{
+			name:     "links and images",
+			input:    "[Note](<My Note.md>) ![alt](img/a.png) <https://example.com>",
+			contains: []string{`<a href="My Note.md">Note</a>`, `<img src="img/a.png" alt="alt">`, `<a href="https://example.com">https://example.com</a>`},
+		},
+		{
+			name:     "wiki links",
+			input:    "[[Other Note]] [[folder/Note#Heading|alias]] [[#Local]]",
+			contains: []string{`<a href="/Other Note.md">Other Note</a>`, `<a href="/folder/Note.md#Heading">alias</a>`, `<a href="#Local">#Local</a>`},
+		},
+		{
+			name:     "blockquote and rule",
+			input:    "> quoted\n> more\n\n---",
+			contains: []string{"<blockquote>\n<p>quoted\nmore</p>\n</blockquote>", "<hr>"},
+		},
+		{
+			name:     "table",
+			input:    "| a | b |\n|---|---|\n| 1 | 2 |",
+			contains: []string{"<th>a</th><th>b</th>", "<td>1</td><td>2</td>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderMarkdown(tt.input)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
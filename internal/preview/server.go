@@ -0,0 +1,187 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Handler serves a directory of markdown notes, rendering .md files as HTML and
+// serving everything else (assets) as-is
+type Handler struct {
+	Root  string
+	Title string
+}
+
+// NewHandler creates a handler for the notes under root
+func NewHandler(root, title string) *Handler {
+	return &Handler{Root: root, Title: title}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	if urlPath == "/" {
+		h.serveIndex(w)
+		return
+	}
+
+	filePath := filepath.Join(h.Root, filepath.FromSlash(strings.TrimPrefix(urlPath, "/")))
+	info, err := os.Stat(filePath)
+	if err != nil && strings.EqualFold(path.Ext(urlPath), ".md") {
+		// Wiki links name notes without their folder, as Obsidian resolves them
+		if found := h.findNote(path.Base(urlPath)); found != "" {
+			filePath = found
+			info, err = os.Stat(filePath)
+		}
+	}
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if strings.EqualFold(filepath.Ext(filePath), ".md") {
+		h.serveNote(w, filePath, urlPath)
+		return
+	}
+	http.ServeFile(w, r, filePath)
+}
+
+// notes returns the slash-separated paths of every note under the root, sorted
+func (h *Handler) notes() ([]string, error) {
+	var notes []string
+	err := filepath.WalkDir(h.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".md") {
+			rel, _ := filepath.Rel(h.Root, p)
+			notes = append(notes, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(notes)
+	return notes, err
+}
+
+// findNote returns the path of the first note with the given file name, or ""
+func (h *Handler) findNote(name string) string {
+	notes, _ := h.notes()
+	for _, note := range notes {
+		if strings.EqualFold(path.Base(note), name) {
+			return filepath.Join(h.Root, filepath.FromSlash(note))
+		}
+	}
+	return ""
+}
+
+// serveIndex lists every note under the root
+func (h *Handler) serveIndex(w http.ResponseWriter) {
+	notes, err := h.notes()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("listing notes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "<h1>%s</h1>\n<p>%d notes</p>\n<ul>\n", html.EscapeString(h.Title), len(notes))
+	for _, note := range notes {
+		fmt.Fprintf(&body, "<li><a href=\"/%s\">%s</a></li>\n", html.EscapeString(note), html.EscapeString(note))
+	}
+	body.WriteString("</ul>\n")
+
+	writePage(w, h.Title, body.String())
+}
+
+// serveNote renders a note, showing its frontmatter above the body
+func (h *Handler) serveNote(w http.ResponseWriter, filePath, urlPath string) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading note: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var body strings.Builder
+	body.WriteString("<nav><a href=\"/\">&larr; All notes</a></nav>\n")
+
+	vf := &vault.VaultFile{}
+	markdown := string(content)
+	if err := vf.Parse(content); err == nil {
+		markdown = vf.Body
+		if len(vf.Frontmatter) > 0 {
+			keys := make([]string, 0, len(vf.Frontmatter))
+			for key := range vf.Frontmatter {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			body.WriteString("<table class=\"frontmatter\">\n")
+			for _, key := range keys {
+				fmt.Fprintf(&body, "<tr><th>%s</th><td>%s</td></tr>\n",
+					html.EscapeString(key), html.EscapeString(fmt.Sprint(vf.Frontmatter[key])))
+			}
+			body.WriteString("</table>\n")
+		}
+	}
+
+	body.WriteString(RenderMarkdown(markdown))
+	writePage(w, strings.TrimPrefix(urlPath, "/"), body.String())
+}
+
+const pageStyle = `body{font-family:system-ui,sans-serif;max-width:50em;margin:2em auto;padding:0 1em;line-height:1.5}
+pre{background:#f4f4f4;padding:.75em;overflow-x:auto}code{background:#f4f4f4}
+blockquote{border-left:3px solid #ccc;margin-left:0;padding-left:1em;color:#555}
+table{border-collapse:collapse}th,td{border:1px solid #ddd;padding:.25em .5em;text-align:left}
+table.frontmatter{font-size:.9em;margin-bottom:1.5em}img{max-width:100%}`
+
+func writePage(w http.ResponseWriter, title, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n%s</body>\n</html>\n",
+		html.EscapeString(title), pageStyle, body)
+}
+
+// Serve listens on addr and serves the notes under root until ctx is cancelled.
+// ready, if non-nil, is called with the listening address once the server accepts connections.
+func Serve(ctx context.Context, addr, root string, ready func(addr string)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("starting preview server: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           NewHandler(root, "Export Preview"),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if ready != nil {
+		ready(listener.Addr().String())
+	}
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving preview: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package preview
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "index.md"), []byte("---\ntitle: Home\n---\n# Home\n\nSee [[Child]]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "Child.md"), []byte("# Child\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "image.png"), []byte("png"), 0644))
+
+	handler := NewHandler(root, "Preview")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("index lists notes", func(t *testing.T) {
+		rec := get("/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<a href="/index.md">index.md</a>`)
+		assert.Contains(t, rec.Body.String(), `<a href="/sub/Child.md">sub/Child.md</a>`)
+	})
+
+	t.Run("note renders with frontmatter", func(t *testing.T) {
+		rec := get("/index.md")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "<tr><th>title</th><td>Home</td></tr>")
+		assert.Contains(t, rec.Body.String(), `<h1 id="home">Home</h1>`)
+		assert.NotContains(t, rec.Body.String(), "---")
+	})
+
+	t.Run("wiki link resolves by note name", func(t *testing.T) {
+		rec := get("/Child.md")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<h1 id="child">Child</h1>`)
+	})
+
+	t.Run("assets served raw", func(t *testing.T) {
+		rec := get("/sub/image.png")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "png", rec.Body.String())
+	})
+
+	t.Run("missing and escaping paths", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/missing.md").Code)
+		assert.Equal(t, http.StatusNotFound, get("/../etc/passwd").Code)
+	})
+}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Archiver moves selected notes into an archive folder, optionally setting
+// frontmatter fields on them, the way a lifecycle rule without a condition would
+type Archiver struct {
+	lp     *LifecycleProcessor
+	rule   LifecycleRule
+	prefix string // Static part of the destination; notes under it are already archived
+}
+
+// NewArchiver creates an archiver for the vault at vaultRoot moving notes to
+// dest, which may use template variables, and setting the fields in set
+func NewArchiver(vaultRoot, dest string, set map[string]interface{}) (*Archiver, error) {
+	if strings.Trim(dest, "/ ") == "" {
+		return nil, fmt.Errorf("archive destination is required")
+	}
+	rule := config.LifecycleRule{Name: "archive", Set: set, MoveTo: dest}
+	if err := validateRuleTemplates(rule); err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+
+	lp, err := NewLifecycleProcessor(nil, vaultRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &Archiver{
+		lp:     lp,
+		rule:   LifecycleRule{Name: rule.Name, Set: set, MoveTo: dest},
+		prefix: archivePrefix(dest),
+	}, nil
+}
+
+// archivePrefix returns the folder dest always lies in, before any template
+// variable, or "" if it has none
+func archivePrefix(dest string) string {
+	dest = filepath.ToSlash(dest)
+	if i := strings.Index(dest, "{{"); i >= 0 {
+		dest = dest[:i]
+		if !strings.HasSuffix(dest, "/") {
+			dest = path.Dir(dest)
+		}
+	}
+	return strings.Trim(path.Clean("/"+dest), "/")
+}
+
+// Plan returns the action archiving each file, in path order. Locked files and
+// files already in the archive folder are left out.
+func (a *Archiver) Plan(files []*vault.VaultFile) []LifecycleAction {
+	var actions []LifecycleAction
+	for _, file := range files {
+		if file.LockReason() != "" || a.archived(file) {
+			continue
+		}
+		if action := a.lp.plan(a.rule, file); len(action.Set) > 0 || action.Target != "" {
+			actions = append(actions, action)
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool { return actions[i].File.Path < actions[j].File.Path })
+	return actions
+}
+
+// archived reports whether file is already in the archive folder
+func (a *Archiver) archived(file *vault.VaultFile) bool {
+	return a.prefix != "" && strings.HasPrefix(filepath.ToSlash(file.RelativePath), a.prefix+"/")
+}
+
+// Apply sets the action's fields and then moves the note, updating links to
+// it. Changes are journaled in options.Transaction with "archive" as reason.
+func (a *Archiver) Apply(ctx context.Context, action LifecycleAction, options RenameOptions) error {
+	return a.lp.apply(ctx, action, options, "archive")
+}
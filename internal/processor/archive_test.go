@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestArchivePrefix(t *testing.T) {
+	tests := map[string]string{
+		"archive":                       "archive",
+		"archive/":                      "archive",
+		"/archive/old/":                 "archive/old",
+		"archive/{{file.dir}}":          "archive",
+		"archive/{{created|date:2006}}": "archive",
+		"archive-{{created|date:2006}}": "",
+		"{{file.dir}}/archive":          "",
+	}
+	for dest, want := range tests {
+		assert.Equal(t, want, archivePrefix(dest), dest)
+	}
+}
+
+func TestArchiver_Plan(t *testing.T) {
+	root := t.TempDir()
+	files := []*vault.VaultFile{
+		writeLifecycleNote(t, root, "projects/old.md", "---\nstatus: done\n---\n"),
+		writeLifecycleNote(t, root, "locked.md", "---\nstatus: done\nmdnotes: locked\n---\n"),
+		writeLifecycleNote(t, root, "archive/filed.md", "---\nstatus: done\n---\n"),
+	}
+
+	_, err := NewArchiver(root, "", nil)
+	assert.Error(t, err)
+	_, err = NewArchiver(root, "archive/{{file.dir", nil)
+	assert.Error(t, err)
+
+	archiver, err := NewArchiver(root, "archive/{{file.dir}}", map[string]interface{}{"archived": true})
+	require.NoError(t, err)
+	actions := archiver.Plan(files)
+	require.Len(t, actions, 1)
+
+	assert.Equal(t, "archive", actions[0].Rule)
+	assert.Equal(t, "projects/old.md", actions[0].File.RelativePath)
+	assert.Equal(t, map[string]interface{}{"archived": true}, actions[0].Set)
+	assert.Equal(t, filepath.Join(root, "archive", "projects", "old.md"), actions[0].Target)
+}
+
+func TestArchiver_ApplyUpdatesLinks(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".obsidian"), 0755))
+	file := writeLifecycleNote(t, root, "old.md", "---\nstatus: done\n---\nBody\n")
+	writeLifecycleNote(t, root, "index.md", "See [[old]]\n")
+
+	archiver, err := NewArchiver(root, "archive/", map[string]interface{}{"archived": true})
+	require.NoError(t, err)
+	actions := archiver.Plan([]*vault.VaultFile{file})
+	require.Len(t, actions, 1)
+
+	journal := safety.NewJournal(root)
+	tx := journal.Begin("mdnotes archive")
+	require.NoError(t, archiver.Apply(context.Background(), actions[0], RenameOptions{Transaction: tx}))
+	require.NoError(t, tx.Commit())
+
+	moved, err := vault.LoadVaultFile(filepath.Join(root, "archive", "old.md"))
+	require.NoError(t, err)
+	assert.Equal(t, true, moved.Frontmatter["archived"])
+	assert.NoFileExists(t, filepath.Join(root, "old.md"))
+
+	index, err := os.ReadFile(filepath.Join(root, "index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "[[archive/old]]")
+
+	require.NotEmpty(t, tx.Entries)
+	for _, entry := range tx.Entries {
+		assert.Equal(t, "archive", entry.Reason, entry.Path)
+	}
+}
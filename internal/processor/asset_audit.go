@@ -0,0 +1,480 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultMaxAssetSize is the size above which an asset is reported as
+// oversized when no other is given
+const DefaultMaxAssetSize = 5 << 20
+
+// AssetAuditOptions configures an attachment audit
+type AssetAuditOptions struct {
+	Folders        []string // Folders scanned for assets, relative to the vault (default: the whole vault)
+	IgnorePatterns []string // Patterns of paths left out, as for scanning notes
+	MaxSize        int64    // Assets larger than this are oversized (0 = no limit)
+}
+
+// AuditedAsset is an attachment found by an audit
+type AuditedAsset struct {
+	Path       string   `json:"path"` // Relative to the vault
+	Size       int64    `json:"size"`
+	References []string `json:"references,omitempty"` // Notes and canvases embedding or linking to it
+}
+
+// AssetDuplicateGroup is a set of attachments with the same content
+type AssetDuplicateGroup struct {
+	Hash  string   `json:"hash"` // SHA-256 of the content
+	Size  int64    `json:"size"` // Size of each copy
+	Paths []string `json:"paths"`
+}
+
+// AssetAudit reports the attachments of a vault that aren't used, are
+// stored more than once, or are larger than they should be
+type AssetAudit struct {
+	Assets       int                   `json:"assets"`
+	TotalSize    int64                 `json:"total_size"`
+	Unreferenced []AuditedAsset        `json:"unreferenced"`
+	Duplicates   []AssetDuplicateGroup `json:"duplicates"`
+	Oversized    []AuditedAsset        `json:"oversized"`
+}
+
+// WastedSize returns the bytes taken by unreferenced assets and by the copies
+// of duplicated assets beyond the first that are left
+func (a *AssetAudit) WastedSize() int64 {
+	var wasted int64
+	unreferenced := make(map[string]bool, len(a.Unreferenced))
+	for _, asset := range a.Unreferenced {
+		wasted += asset.Size
+		unreferenced[asset.Path] = true
+	}
+	for _, group := range a.Duplicates {
+		kept := 0
+		for _, p := range group.Paths {
+			if !unreferenced[p] {
+				kept++
+			}
+		}
+		if kept > 1 {
+			wasted += group.Size * int64(kept-1)
+		}
+	}
+	return wasted
+}
+
+// htmlSourceRegex matches file references in HTML embedded in notes
+var htmlSourceRegex = regexp.MustCompile(`(?i)\b(?:src|href|data)\s*=\s*["']([^"']+)["']`)
+
+// AuditAssets finds the attachments under the vault, every file that is not
+// a note or a canvas, and checks them against the references in notes: links
+// and embeds, HTML src and href attributes, frontmatter values such as
+// cover: image.png, and the file cards of canvases. References resolve the
+// way Obsidian resolves them: relative to the note, from the vault root, or
+// by file name anywhere in the vault. A name shared by several attachments
+// counts as a reference to each, so nothing in use is reported unreferenced.
+func AuditAssets(vaultPath string, notes []*vault.VaultFile, options AssetAuditOptions) (*AssetAudit, error) {
+	assets, canvases, err := findAssets(vaultPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := newAssetReferences(assets)
+	parser := NewLinkParser()
+	for _, note := range notes {
+		source := filepath.ToSlash(note.RelativePath)
+		for _, link := range parser.Extract(note.Body) {
+			refs.add(link.Target, source, link.Type == vault.MarkdownLink)
+		}
+		for _, match := range htmlSourceRegex.FindAllStringSubmatch(note.Body, -1) {
+			if parser.IsInternalLink(match[1]) {
+				refs.add(match[1], source, true)
+			}
+		}
+		for _, value := range note.Frontmatter {
+			refs.addFrontmatter(value, source)
+		}
+	}
+	for _, canvas := range canvases {
+		if err := refs.addCanvas(vaultPath, canvas); err != nil {
+			return nil, err
+		}
+	}
+
+	audit := &AssetAudit{
+		Assets:       len(assets),
+		Unreferenced: []AuditedAsset{},
+		Duplicates:   []AssetDuplicateGroup{},
+		Oversized:    []AuditedAsset{},
+	}
+	for _, asset := range assets {
+		audit.TotalSize += asset.Size
+		sort.Strings(asset.References)
+		if len(asset.References) == 0 {
+			audit.Unreferenced = append(audit.Unreferenced, asset)
+		}
+		if options.MaxSize > 0 && asset.Size > options.MaxSize {
+			audit.Oversized = append(audit.Oversized, asset)
+		}
+	}
+	sort.SliceStable(audit.Oversized, func(i, j int) bool {
+		return audit.Oversized[i].Size > audit.Oversized[j].Size
+	})
+
+	audit.Duplicates, err = findDuplicateAssets(vaultPath, assets)
+	if err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+// ListAssets returns the attachments under the vault, or under the folders
+// of options, in path order
+func ListAssets(vaultPath string, options AssetAuditOptions) ([]AuditedAsset, error) {
+	assets, _, err := findAssets(vaultPath, options)
+	return assets, err
+}
+
+// findAssets walks the audited folders, returning the attachments in path
+// order and the canvases, whose cards reference them
+func findAssets(vaultPath string, options AssetAuditOptions) ([]AuditedAsset, []string, error) {
+	folders := options.Folders
+	if len(folders) == 0 {
+		folders = []string{"."}
+	}
+
+	var assets []AuditedAsset
+	var canvases []string
+	seen := make(map[string]bool)
+	for _, folder := range folders {
+		root := filepath.Join(vaultPath, filepath.FromSlash(folder))
+		if _, err := os.Stat(root); err != nil {
+			return nil, nil, fmt.Errorf("reading folder %s: %w", folder, err)
+		}
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(vaultPath, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || matchesAssetIgnore(rel, options.IgnorePatterns)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || seen[rel] {
+				return nil
+			}
+			seen[rel] = true
+
+			switch strings.ToLower(filepath.Ext(rel)) {
+			case ".md":
+			case ".canvas":
+				canvases = append(canvases, rel)
+			default:
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				if info.Mode().IsRegular() {
+					assets = append(assets, AuditedAsset{Path: rel, Size: info.Size()})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("scanning %s: %w", folder, err)
+		}
+	}
+
+	// Canvases anywhere in the vault use attachments, not only in the folders audited
+	if len(options.Folders) > 0 {
+		canvases = nil
+		err := filepath.WalkDir(vaultPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != vaultPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".canvas") {
+				rel, err := filepath.Rel(vaultPath, p)
+				if err != nil {
+					return err
+				}
+				canvases = append(canvases, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("scanning for canvases: %w", err)
+		}
+	}
+
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Path < assets[j].Path })
+	return assets, canvases, nil
+}
+
+// matchesAssetIgnore reports whether a relative path matches an ignore
+// pattern, directly or through a folder pattern such as .obsidian/*
+func matchesAssetIgnore(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, path.Base(rel)); matched {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && (rel == prefix || strings.HasPrefix(rel, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// assetReferences records which notes reference each asset
+type assetReferences struct {
+	byPath map[string]*AuditedAsset   // Lower-cased relative path -> asset
+	byName map[string][]*AuditedAsset // Lower-cased file name -> assets
+	seen   map[*AuditedAsset]map[string]bool
+}
+
+// newAssetReferences indexes assets by path and by name
+func newAssetReferences(assets []AuditedAsset) *assetReferences {
+	refs := &assetReferences{
+		byPath: make(map[string]*AuditedAsset, len(assets)),
+		byName: make(map[string][]*AuditedAsset),
+		seen:   make(map[*AuditedAsset]map[string]bool),
+	}
+	for i := range assets {
+		asset := &assets[i]
+		refs.byPath[strings.ToLower(asset.Path)] = asset
+		name := strings.ToLower(path.Base(asset.Path))
+		refs.byName[name] = append(refs.byName[name], asset)
+	}
+	return refs
+}
+
+// How a reference resolved to an asset
+const (
+	resolvedFromRoot = iota // From the vault root
+	resolvedRelative        // Relative to the referencing note
+	resolvedByName          // By file name, anywhere in the vault
+)
+
+// add resolves a reference to target from source, a note or canvas
+func (r *assetReferences) add(target, source string, escaped bool) {
+	matches, _ := r.resolve(target, source, escaped)
+	r.record(matches, source)
+}
+
+// record notes source as referencing each of assets
+func (r *assetReferences) record(assets []*AuditedAsset, source string) {
+	for _, asset := range assets {
+		if r.seen[asset] == nil {
+			r.seen[asset] = make(map[string]bool)
+		}
+		if !r.seen[asset][source] {
+			r.seen[asset][source] = true
+			asset.References = append(asset.References, source)
+		}
+	}
+}
+
+// resolve returns the assets a reference to target from source may be, and
+// how it resolved to the first. Targets are tried from the vault root and
+// relative to source, then by name when neither is an asset.
+func (r *assetReferences) resolve(target, source string, escaped bool) ([]*AuditedAsset, int) {
+	target = cleanAssetTarget(target, escaped)
+	if target == "" {
+		return nil, 0
+	}
+
+	candidates := []string{path.Clean(strings.TrimPrefix(target, "/"))}
+	if !strings.HasPrefix(target, "/") {
+		candidates = append(candidates, path.Join(path.Dir(source), target))
+	}
+	var matches []*AuditedAsset
+	how := resolvedByName
+	for i, candidate := range candidates {
+		if asset, ok := r.byPath[strings.ToLower(candidate)]; ok && (len(matches) == 0 || matches[0] != asset) {
+			if len(matches) == 0 {
+				how = resolvedFromRoot + i
+			}
+			matches = append(matches, asset)
+		}
+	}
+	if len(matches) > 0 {
+		return matches, how
+	}
+
+	suffix := "/" + strings.ToLower(strings.TrimPrefix(path.Clean(target), "/"))
+	for _, asset := range r.byName[strings.ToLower(path.Base(target))] {
+		if !strings.Contains(target, "/") || strings.HasSuffix("/"+strings.ToLower(asset.Path), suffix) {
+			matches = append(matches, asset)
+		}
+	}
+	return matches, resolvedByName
+}
+
+// cleanAssetTarget strips a link target down to the path it names: no
+// alias or size, fragment or query, URL escaping or angle brackets
+func cleanAssetTarget(target string, escaped bool) string {
+	if idx := strings.Index(target, "|"); idx != -1 {
+		target = target[:idx]
+	}
+	if idx := strings.IndexAny(target, "#?"); idx != -1 {
+		target = target[:idx]
+	}
+	if escaped {
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+	}
+	target = strings.TrimSpace(strings.TrimPrefix(filepath.ToSlash(target), "<"))
+	return strings.TrimSuffix(target, ">")
+}
+
+// addFrontmatter resolves the file references in a frontmatter value: wiki
+// links, or strings naming a file with an extension
+func (r *assetReferences) addFrontmatter(value interface{}, source string) {
+	switch v := value.(type) {
+	case string:
+		v = strings.TrimSpace(v)
+		if strings.HasPrefix(v, "[[") || strings.HasPrefix(v, "![[") {
+			for _, link := range NewLinkParser().Extract(v) {
+				r.add(link.Target, source, false)
+			}
+		} else if path.Ext(v) != "" && NewLinkParser().IsInternalLink(v) {
+			r.add(v, source, false)
+		}
+	case []interface{}:
+		for _, item := range v {
+			r.addFrontmatter(item, source)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			r.addFrontmatter(item, source)
+		}
+	}
+}
+
+// addCanvas records the files shown on a canvas's cards
+func (r *assetReferences) addCanvas(vaultPath, canvas string) error {
+	data, err := os.ReadFile(filepath.Join(vaultPath, filepath.FromSlash(canvas)))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", canvas, err)
+	}
+	var doc struct {
+		Nodes []struct {
+			Type string `json:"type"`
+			File string `json:"file"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		// Canvases that don't parse reference nothing we can tell
+		return nil
+	}
+	for _, node := range doc.Nodes {
+		if node.Type == "file" && node.File != "" {
+			r.add("/"+node.File, canvas, false)
+		}
+	}
+	return nil
+}
+
+// findDuplicateAssets groups assets with the same content. Only assets of
+// the same size are hashed.
+func findDuplicateAssets(vaultPath string, assets []AuditedAsset) ([]AssetDuplicateGroup, error) {
+	bySize := make(map[int64][]string)
+	for _, asset := range assets {
+		if asset.Size > 0 {
+			bySize[asset.Size] = append(bySize[asset.Size], asset.Path)
+		}
+	}
+
+	groups := []AssetDuplicateGroup{}
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		byHash := make(map[string][]string)
+		for _, p := range paths {
+			hash, err := hashAsset(filepath.Join(vaultPath, filepath.FromSlash(p)))
+			if err != nil {
+				return nil, fmt.Errorf("hashing %s: %w", p, err)
+			}
+			byHash[hash] = append(byHash[hash], p)
+		}
+		for hash, same := range byHash {
+			if len(same) > 1 {
+				sort.Strings(same)
+				groups = append(groups, AssetDuplicateGroup{Hash: hash, Size: size, Paths: same})
+			}
+		}
+	}
+
+	// Largest waste first
+	sort.Slice(groups, func(i, j int) bool {
+		wi := groups[i].Size * int64(len(groups[i].Paths)-1)
+		wj := groups[j].Size * int64(len(groups[j].Paths)-1)
+		if wi != wj {
+			return wi > wj
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups, nil
+}
+
+// hashAsset returns the hex SHA-256 of a file's content
+func hashAsset(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ParseByteSize parses a size such as 500KB, 1.5MB or 2GB, in powers of
+// 1024; a plain number is in bytes
+func ParseByteSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if trimmed, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(trimmed), unit.size
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q - use bytes or a number with KB, MB or GB", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
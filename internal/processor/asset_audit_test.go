@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestAuditAssets(t *testing.T) {
+	vaultPath := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(vaultPath, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(vaultPath, path), []byte(content), 0644))
+	}
+	write("attachments/diagram.png", "same")
+	write("attachments/diagram copy.png", "same")
+	write("attachments/scan.pdf", "a large scan")
+	write("attachments/cover.jpg", "cover")
+	write("attachments/inline.gif", "gif")
+	write("attachments/my file.pdf", "spaces")
+	write("attachments/on-canvas.png", "canvas")
+	write("attachments/unused.zip", "zip")
+	write("other/diagram.png", "another diagram")
+	write(".obsidian/workspace.json", "{}")
+	write("board.canvas", `{"nodes":[{"id":"a","type":"file","file":"attachments/on-canvas.png"}]}`)
+
+	notes := []*vault.VaultFile{
+		{RelativePath: "notes/a.md", Frontmatter: map[string]interface{}{"cover": "attachments/cover.jpg"},
+			Body: "![[diagram.png|300]] [scan](../attachments/scan.pdf#page=2) <img src=\"attachments/inline.gif\">"},
+		{RelativePath: "b.md", Body: "[doc](attachments/my%20file.pdf) [site](https://example.com/x.png)"},
+	}
+
+	audit, err := AuditAssets(vaultPath, notes, AssetAuditOptions{MaxSize: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9, audit.Assets)
+	paths := func(assets []AuditedAsset) []string {
+		var result []string
+		for _, asset := range assets {
+			result = append(result, asset.Path)
+		}
+		return result
+	}
+	// Both diagram.png count as used, as the embed names either
+	assert.Equal(t, []string{"attachments/diagram copy.png", "attachments/unused.zip"}, paths(audit.Unreferenced))
+	// Largest first
+	assert.Equal(t, []string{"other/diagram.png", "attachments/scan.pdf"}, paths(audit.Oversized))
+	assert.Equal(t, []string{"notes/a.md"}, audit.Oversized[1].References)
+
+	require.Len(t, audit.Duplicates, 1)
+	assert.Equal(t, []string{"attachments/diagram copy.png", "attachments/diagram.png"}, audit.Duplicates[0].Paths)
+	assert.Equal(t, int64(4), audit.Duplicates[0].Size)
+	assert.Len(t, audit.Duplicates[0].Hash, 64)
+
+	// The unreferenced copy is only counted once
+	assert.Equal(t, int64(4+3), audit.WastedSize())
+
+	// Auditing a folder still takes references from the whole vault
+	audit, err = AuditAssets(vaultPath, notes, AssetAuditOptions{Folders: []string{"other"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, audit.Assets)
+	assert.Empty(t, audit.Unreferenced)
+	assert.Empty(t, audit.Oversized)
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":      0,
+		"512":    512,
+		"2KB":    2048,
+		"1.5 MB": 1536 * 1024,
+		"1g":     1 << 30,
+	}
+	for input, expected := range tests {
+		size, err := ParseByteSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, size, input)
+	}
+
+	_, err := ParseByteSize("lots")
+	assert.Error(t, err)
+}
@@ -0,0 +1,572 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Asset organize strategies: where each attachment goes under the
+// destination folder
+const (
+	OrganizePerNote = "per-note" // A folder per note, named after it; attachments several notes use go in _shared
+	OrganizeByType  = "by-type"  // A folder per kind of file: images, pdfs, audio, video, documents, other
+	OrganizeByDate  = "by-date"  // A folder per year and month the file was last modified
+)
+
+// sharedAssetFolder holds, below the destination, the attachments more than
+// one note uses when organizing per note
+const sharedAssetFolder = "_shared"
+
+// IsValidOrganizeStrategy checks if an asset organize strategy is supported
+func IsValidOrganizeStrategy(strategy string) bool {
+	switch strategy {
+	case OrganizePerNote, OrganizeByType, OrganizeByDate:
+		return true
+	}
+	return false
+}
+
+// AssetOrganizeOptions configures how attachments are organized
+type AssetOrganizeOptions struct {
+	Strategy       string
+	Dest           string   // Folder the structure is built in, relative to the vault
+	Folders        []string // Only attachments in these folders are moved (default: the whole vault)
+	IgnorePatterns []string // Patterns of paths left out, as for scanning notes
+}
+
+// AssetMove is an attachment moved to its place in the structure
+type AssetMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// AssetSkip is an attachment left where it is, and why
+type AssetSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// AssetEdit is a note or canvas whose references change with the moves
+type AssetEdit struct {
+	Path   string `json:"path"` // Relative to the vault
+	Before string `json:"-"`
+	After  string `json:"-"`
+}
+
+// AssetOrganizePlan is the moves and edits that organize a vault's
+// attachments. Nothing is changed on disk until it is applied.
+type AssetOrganizePlan struct {
+	Moves        []AssetMove `json:"moves"`
+	Edits        []AssetEdit `json:"edits"`
+	Skipped      []AssetSkip `json:"skipped"`
+	Links        int         `json:"links"`        // References rewritten
+	Unreferenced int         `json:"unreferenced"` // Attachments left alone as nothing uses them
+}
+
+// PlanAssetOrganization works out where each referenced attachment goes
+// under options.Strategy and how the notes and canvases referencing it
+// change. Unreferenced attachments stay where they are, as do those whose
+// references can't all be rewritten: ones used by locked notes or from HTML,
+// and ones referenced by a name or path other attachments share. A name
+// already taken at the destination gets a number, as Obsidian numbers
+// pasted attachments. Rewritten references keep their form where they can:
+// names stay names while they are unique, paths become paths to the new
+// place, relative paths stay relative, and embed sizes, aliases and
+// fragments are kept. The notes passed are updated in place.
+func PlanAssetOrganization(vaultPath string, notes []*vault.VaultFile, options AssetOrganizeOptions) (*AssetOrganizePlan, error) {
+	if !IsValidOrganizeStrategy(options.Strategy) {
+		return nil, fmt.Errorf("unknown strategy %q - valid options are: %s, %s, %s", options.Strategy, OrganizePerNote, OrganizeByType, OrganizeByDate)
+	}
+	dest := path.Clean(filepath.ToSlash(options.Dest))
+	if dest == "." {
+		dest = ""
+	}
+
+	o, err := newAssetOrganizer(vaultPath, notes, options.IgnorePatterns)
+	if err != nil {
+		return nil, err
+	}
+	plan := &AssetOrganizePlan{Moves: []AssetMove{}, Edits: []AssetEdit{}, Skipped: []AssetSkip{}}
+	for i := range o.assets {
+		asset := &o.assets[i]
+		if !inAssetFolders(asset.Path, options.Folders) {
+			continue
+		}
+		if len(asset.References) == 0 {
+			plan.Unreferenced++
+			continue
+		}
+		if reason, ok := o.pinned[asset]; ok {
+			plan.Skipped = append(plan.Skipped, AssetSkip{Path: asset.Path, Reason: reason})
+			continue
+		}
+		folder, err := assetFolder(vaultPath, dest, options.Strategy, asset)
+		if err != nil {
+			return nil, err
+		}
+		o.move(plan, asset, path.Join(folder, path.Base(asset.Path)))
+	}
+	if err := o.rewrite(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PlanAssetRenames works out how the notes and canvases referencing
+// attachments change when they are renamed, renames mapping paths relative
+// to the vault to their new paths. Attachments that can't be renamed, for
+// the reasons PlanAssetOrganization leaves them in place, are skipped, and
+// a new path already taken is numbered. The notes passed are updated in
+// place.
+func PlanAssetRenames(vaultPath string, notes []*vault.VaultFile, renames map[string]string, ignorePatterns []string) (*AssetOrganizePlan, error) {
+	o, err := newAssetOrganizer(vaultPath, notes, ignorePatterns)
+	if err != nil {
+		return nil, err
+	}
+	plan := &AssetOrganizePlan{Moves: []AssetMove{}, Edits: []AssetEdit{}, Skipped: []AssetSkip{}}
+	for i := range o.assets {
+		asset := &o.assets[i]
+		target, ok := renames[asset.Path]
+		if !ok {
+			continue
+		}
+		if len(asset.References) == 0 {
+			plan.Unreferenced++
+		}
+		if reason, ok := o.pinned[asset]; ok {
+			plan.Skipped = append(plan.Skipped, AssetSkip{Path: asset.Path, Reason: reason})
+			continue
+		}
+		o.move(plan, asset, path.Clean(filepath.ToSlash(target)))
+	}
+	if err := o.rewrite(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// newAssetOrganizer finds the vault's attachments and resolves the
+// references notes and canvases make to them, pinning those that can't move
+func newAssetOrganizer(vaultPath string, notes []*vault.VaultFile, ignorePatterns []string) (*assetOrganizer, error) {
+	assets, canvases, err := findAssets(vaultPath, AssetAuditOptions{IgnorePatterns: ignorePatterns})
+	if err != nil {
+		return nil, err
+	}
+	o := &assetOrganizer{
+		vaultPath: vaultPath,
+		notes:     notes,
+		assets:    assets,
+		canvases:  canvases,
+		refs:      newAssetReferences(assets),
+		parser:    NewLinkParser(),
+		locked:    make(map[string]bool),
+		pinned:    make(map[*AuditedAsset]string),
+		moved:     make(map[*AuditedAsset]string),
+		taken:     make(map[string]bool),
+		names:     make(map[string]int),
+	}
+
+	// Rewriting with nothing moved only resolves and records references
+	for _, note := range notes {
+		source := filepath.ToSlash(note.RelativePath)
+		o.rewriteText(note.Body, source)
+		for _, value := range note.Frontmatter {
+			o.rewriteValue(value, source)
+		}
+		for _, match := range htmlSourceRegex.FindAllStringSubmatch(note.Body, -1) {
+			if o.parser.IsInternalLink(match[1]) {
+				matches, _ := o.refs.resolve(match[1], source, true)
+				o.refs.record(matches, source)
+				o.pin(matches, "used from HTML in "+source)
+			}
+		}
+		if reason := note.LockReason(); reason != "" {
+			o.locked[source] = true
+			for i := range o.assets {
+				if o.refs.seen[&o.assets[i]][source] {
+					o.pin([]*AuditedAsset{&o.assets[i]}, fmt.Sprintf("used by %s, which is locked (%s)", source, reason))
+				}
+			}
+		}
+	}
+	for _, canvas := range canvases {
+		if err := o.refs.addCanvas(vaultPath, canvas); err != nil {
+			return nil, err
+		}
+	}
+
+	// Every file's path is taken, and every attachment's name is in use
+	for _, asset := range assets {
+		o.taken[strings.ToLower(asset.Path)] = true
+		o.names[strings.ToLower(path.Base(asset.Path))]++
+	}
+	for _, canvas := range canvases {
+		o.taken[strings.ToLower(canvas)] = true
+	}
+	for _, note := range notes {
+		o.taken[strings.ToLower(filepath.ToSlash(note.RelativePath))] = true
+	}
+	return o, nil
+}
+
+// move plans moving asset to target, numbering target if it is taken
+func (o *assetOrganizer) move(plan *AssetOrganizePlan, asset *AuditedAsset, target string) {
+	if strings.EqualFold(target, asset.Path) {
+		return
+	}
+	if o.taken[strings.ToLower(target)] {
+		target = numberedAssetPath(target, o.taken, o.names)
+	}
+	o.taken[strings.ToLower(target)] = true
+	o.names[strings.ToLower(path.Base(target))]++
+	o.moved[asset] = target
+	plan.Moves = append(plan.Moves, AssetMove{From: asset.Path, To: target})
+}
+
+// rewrite adds to plan the edits to the notes and canvases referencing
+// what moved
+func (o *assetOrganizer) rewrite(plan *AssetOrganizePlan) error {
+	if len(plan.Moves) == 0 {
+		return nil
+	}
+
+	// Names after the moves decide which references can stay names
+	o.names = make(map[string]int)
+	for i := range o.assets {
+		p := o.assets[i].Path
+		if moved, ok := o.moved[&o.assets[i]]; ok {
+			p = moved
+		}
+		o.names[strings.ToLower(path.Base(p))]++
+	}
+
+	for _, note := range o.notes {
+		source := filepath.ToSlash(note.RelativePath)
+		if o.locked[source] {
+			continue
+		}
+		body, count := o.rewriteText(note.Body, source)
+		for key, value := range note.Frontmatter {
+			if updated, n := o.rewriteValue(value, source); n > 0 {
+				note.SetField(key, updated)
+				count += n
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		note.Body = body
+		content, err := note.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", source, err)
+		}
+		plan.Edits = append(plan.Edits, AssetEdit{Path: source, Before: string(note.Content), After: string(content)})
+		plan.Links += count
+	}
+	for _, canvas := range o.canvases {
+		edit, count, err := o.rewriteCanvas(o.vaultPath, canvas)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			plan.Edits = append(plan.Edits, edit)
+			plan.Links += count
+		}
+	}
+	sort.Slice(plan.Edits, func(i, j int) bool { return plan.Edits[i].Path < plan.Edits[j].Path })
+	return nil
+}
+
+// inAssetFolders reports whether an asset is in one of folders, or folders
+// is empty
+func inAssetFolders(p string, folders []string) bool {
+	if len(folders) == 0 {
+		return true
+	}
+	for _, folder := range folders {
+		folder = path.Clean(filepath.ToSlash(folder))
+		if folder == "." || strings.HasPrefix(p, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// assetFolder returns the folder an asset belongs in under strategy
+func assetFolder(vaultPath, dest, strategy string, asset *AuditedAsset) (string, error) {
+	switch strategy {
+	case OrganizePerNote:
+		if len(asset.References) > 1 {
+			return path.Join(dest, sharedAssetFolder), nil
+		}
+		note := asset.References[0]
+		return path.Join(dest, strings.TrimSuffix(note, path.Ext(note))), nil
+	case OrganizeByType:
+		return path.Join(dest, assetKind(asset.Path)), nil
+	default:
+		info, err := os.Stat(filepath.Join(vaultPath, filepath.FromSlash(asset.Path)))
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", asset.Path, err)
+		}
+		modified := info.ModTime()
+		return path.Join(dest, strconv.Itoa(modified.Year()), fmt.Sprintf("%02d", int(modified.Month()))), nil
+	}
+}
+
+// assetKind returns the by-type folder for a file
+func assetKind(p string) string {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".bmp", ".tif", ".tiff", ".avif", ".heic", ".ico":
+		return "images"
+	case ".pdf":
+		return "pdfs"
+	case ".mp3", ".wav", ".m4a", ".ogg", ".flac", ".aac", ".3gp":
+		return "audio"
+	case ".mp4", ".mov", ".mkv", ".webm", ".avi", ".ogv":
+		return "video"
+	case ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".ods", ".odp", ".rtf", ".txt", ".csv", ".epub":
+		return "documents"
+	}
+	return "other"
+}
+
+// numberedAssetPath returns target with the first number after its name,
+// "photo 1.png", that makes a path not taken and a name no attachment has
+func numberedAssetPath(target string, taken map[string]bool, names map[string]int) string {
+	ext := path.Ext(target)
+	stem := strings.TrimSuffix(target, ext)
+	for n := 1; ; n++ {
+		candidate := stem + " " + strconv.Itoa(n) + ext
+		if !taken[strings.ToLower(candidate)] && names[strings.ToLower(path.Base(candidate))] == 0 {
+			return candidate
+		}
+	}
+}
+
+// assetOrganizer resolves and rewrites the references to attachments
+type assetOrganizer struct {
+	vaultPath string
+	notes     []*vault.VaultFile
+	assets    []AuditedAsset
+	canvases  []string
+	refs      *assetReferences
+	parser    *LinkParser
+	locked    map[string]bool          // Locked notes
+	pinned    map[*AuditedAsset]string // Attachments that can't move -> why
+	moved     map[*AuditedAsset]string // Attachments moving -> their new path
+	taken     map[string]bool          // Lower-cased paths of files, and of where attachments move
+	names     map[string]int           // Lower-cased file name -> attachments with it
+}
+
+// pin keeps assets where they are, for reason, unless already pinned
+func (o *assetOrganizer) pin(assets []*AuditedAsset, reason string) {
+	for _, asset := range assets {
+		if _, ok := o.pinned[asset]; !ok {
+			o.pinned[asset] = reason
+		}
+	}
+}
+
+// rewriteText rewrites the links and embeds in text from source to moved
+// attachments, returning the new text and how many changed
+func (o *assetOrganizer) rewriteText(text, source string) (string, int) {
+	var b strings.Builder
+	last, count := 0, 0
+	for _, link := range o.parser.Extract(text) {
+		replacement, ok := o.rewriteLink(link.RawText, link.Type, source)
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:link.Position.Start])
+		b.WriteString(replacement)
+		last = link.Position.End
+		count++
+	}
+	if count == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), count
+}
+
+// rewriteLink returns a link with its target changed to where the attachment
+// it references moved, keeping everything else as it was
+func (o *assetOrganizer) rewriteLink(raw string, linkType vault.LinkType, source string) (string, bool) {
+	if linkType == vault.MarkdownLink {
+		open := strings.Index(raw, "](")
+		if open == -1 || !strings.HasSuffix(raw, ")") {
+			return "", false
+		}
+		dest := raw[open+2 : len(raw)-1]
+		start, end, angle := markdownPathSpan(dest)
+		target, ok := o.rewriteTarget(dest[start:end], source, !angle)
+		if !ok {
+			return "", false
+		}
+		if !angle && (strings.Contains(dest[start:end], "%") || strings.ContainsAny(target, " ()<>")) {
+			target = escapeURLPath(target)
+		}
+		return raw[:open+2] + dest[:start] + target + dest[end:] + ")", true
+	}
+
+	open := strings.Index(raw, "[[")
+	if open == -1 || !strings.HasSuffix(raw, "]]") {
+		return "", false
+	}
+	inner := raw[open+2 : len(raw)-2]
+	end := strings.IndexAny(inner, "#|")
+	if end == -1 {
+		end = len(inner)
+	}
+	// A pipe escaped for a table
+	if end > 0 && inner[end-1] == '\\' {
+		end--
+	}
+	target, ok := o.rewriteTarget(inner[:end], source, false)
+	if !ok {
+		return "", false
+	}
+	return raw[:open+2] + target + inner[end:] + "]]", true
+}
+
+// markdownPathSpan returns where the path is in a markdown link destination:
+// inside angle brackets, or up to a title; either way before a fragment
+func markdownPathSpan(dest string) (start, end int, angle bool) {
+	start = len(dest) - len(strings.TrimLeft(dest, " "))
+	end = len(dest)
+	if strings.HasPrefix(dest[start:], "<") {
+		start++
+		angle = true
+		if idx := strings.Index(dest[start:], ">"); idx != -1 {
+			end = start + idx
+		}
+	} else if idx := strings.Index(dest[start:], " "); idx != -1 {
+		end = start + idx
+	}
+	if idx := strings.IndexAny(dest[start:end], "#?"); idx != -1 {
+		end = start + idx
+	}
+	return start, end, angle
+}
+
+// rewriteTarget records a reference to target from source and, when it is
+// to a single attachment that moved, returns the reference to its new place
+func (o *assetOrganizer) rewriteTarget(target, source string, escaped bool) (string, bool) {
+	matches, how := o.refs.resolve(target, source, escaped)
+	o.refs.record(matches, source)
+	if len(matches) > 1 {
+		o.pin(matches, fmt.Sprintf("%s references %q, which more than one attachment matches", source, cleanAssetTarget(target, escaped)))
+		return "", false
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+	newPath, ok := o.moved[matches[0]]
+	if !ok {
+		return "", false
+	}
+
+	old := cleanAssetTarget(target, escaped)
+	updated := newPath
+	name := path.Base(newPath)
+	switch {
+	case !strings.Contains(old, "/") && o.names[strings.ToLower(name)] == 1:
+		updated = name
+		if strings.EqualFold(old, name) {
+			updated = old
+		}
+	case strings.HasPrefix(old, "/"):
+		updated = "/" + newPath
+	case how == resolvedRelative:
+		if rel, err := filepath.Rel(filepath.FromSlash(path.Dir(source)), filepath.FromSlash(newPath)); err == nil {
+			updated = filepath.ToSlash(rel)
+		}
+	}
+	if updated == old {
+		return "", false
+	}
+	return updated, true
+}
+
+// rewriteValue rewrites the references to moved attachments in a
+// frontmatter value, returning it and how many changed
+func (o *assetOrganizer) rewriteValue(value interface{}, source string) (interface{}, int) {
+	switch v := value.(type) {
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "[[") || strings.HasPrefix(trimmed, "![[") {
+			return o.rewriteText(v, source)
+		}
+		if path.Ext(trimmed) != "" && o.parser.IsInternalLink(trimmed) {
+			if target, ok := o.rewriteTarget(trimmed, source, false); ok {
+				return target, 1
+			}
+		}
+	case []interface{}:
+		total := 0
+		for i, item := range v {
+			var n int
+			v[i], n = o.rewriteValue(item, source)
+			total += n
+		}
+		return v, total
+	case map[string]interface{}:
+		total := 0
+		for key, item := range v {
+			var n int
+			v[key], n = o.rewriteValue(item, source)
+			total += n
+		}
+		return v, total
+	}
+	return value, 0
+}
+
+// rewriteCanvas points a canvas's file cards for moved attachments at
+// their new place
+func (o *assetOrganizer) rewriteCanvas(vaultPath, canvas string) (AssetEdit, int, error) {
+	data, err := os.ReadFile(filepath.Join(vaultPath, filepath.FromSlash(canvas)))
+	if err != nil {
+		return AssetEdit{}, 0, fmt.Errorf("reading %s: %w", canvas, err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return AssetEdit{}, 0, nil
+	}
+	nodes, _ := doc["nodes"].([]interface{})
+	count := 0
+	for _, item := range nodes {
+		node, ok := item.(map[string]interface{})
+		if !ok || node["type"] != "file" {
+			continue
+		}
+		file, _ := node["file"].(string)
+		if file == "" {
+			continue
+		}
+		matches, _ := o.refs.resolve("/"+file, canvas, false)
+		if len(matches) != 1 {
+			continue
+		}
+		if newPath, ok := o.moved[matches[0]]; ok {
+			node["file"] = newPath
+			count++
+		}
+	}
+	if count == 0 {
+		return AssetEdit{}, 0, nil
+	}
+	updated, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return AssetEdit{}, 0, fmt.Errorf("encoding %s: %w", canvas, err)
+	}
+	return AssetEdit{Path: canvas, Before: string(data), After: string(updated)}, count, nil
+}
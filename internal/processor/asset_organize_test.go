@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func organizeTestVault(t *testing.T) (string, []*vault.VaultFile) {
+	vaultPath := t.TempDir()
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(vaultPath, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(vaultPath, path), []byte(content), 0644))
+	}
+	write("files/photo.png", "photo")
+	write("files/report final.pdf", "report")
+	write("files/cover.jpg", "cover")
+	write("files/shared.png", "shared")
+	write("files/diagram.svg", "<svg/>")
+	write("files/locked.png", "locked")
+	write("files/unused.zip", "zip")
+	write("x/dup.png", "x")
+	write("y/dup.png", "y")
+	write("attachments/_shared/shared.png", "in the way")
+	write("board.canvas", `{"nodes":[{"id":"a","type":"file","file":"files/diagram.svg","x":0,"y":0}],"edges":[]}`)
+
+	var notes []*vault.VaultFile
+	note := func(path, content string) {
+		file := &vault.VaultFile{Path: filepath.Join(vaultPath, path), RelativePath: path}
+		require.NoError(t, file.Parse([]byte(content)))
+		notes = append(notes, file)
+	}
+	note("a.md", "---\ncover: files/cover.jpg\n---\n![[photo.png|300]]\n[report](files/report%20final.pdf#page=2)\n")
+	note("sub/b.md", "![shared](../files/shared.png)\n")
+	note("c.md", "![[files/shared.png]] and ![[dup.png]]\n")
+	note("d.md", "---\nmdnotes: locked\n---\n![[locked.png]]\n")
+	return vaultPath, notes
+}
+
+func TestPlanAssetOrganization_PerNote(t *testing.T) {
+	vaultPath, notes := organizeTestVault(t)
+
+	plan, err := PlanAssetOrganization(vaultPath, notes, AssetOrganizeOptions{Strategy: OrganizePerNote, Dest: "attachments"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []AssetMove{
+		{From: "files/cover.jpg", To: "attachments/a/cover.jpg"},
+		{From: "files/diagram.svg", To: "attachments/board/diagram.svg"},
+		{From: "files/photo.png", To: "attachments/a/photo.png"},
+		{From: "files/report final.pdf", To: "attachments/a/report final.pdf"},
+		// Used by two notes, and numbered as the name is taken
+		{From: "files/shared.png", To: "attachments/_shared/shared 1.png"},
+	}, plan.Moves)
+	assert.Equal(t, 2, plan.Unreferenced)
+
+	skipped := make(map[string]string)
+	for _, skip := range plan.Skipped {
+		skipped[skip.Path] = skip.Reason
+	}
+	assert.Contains(t, skipped["files/locked.png"], "locked")
+	assert.Contains(t, skipped["x/dup.png"], "more than one attachment")
+	assert.Contains(t, skipped["y/dup.png"], "more than one attachment")
+
+	edits := make(map[string]AssetEdit)
+	for _, edit := range plan.Edits {
+		edits[edit.Path] = edit
+	}
+	require.Len(t, edits, 4)
+	// The embed by name still resolves, so only the path and the cover change
+	assert.Equal(t, "---\ncover: attachments/a/cover.jpg\n---\n\n![[photo.png|300]]\n[report](attachments/a/report%20final.pdf#page=2)\n", edits["a.md"].After)
+	assert.Equal(t, "![shared](../attachments/_shared/shared%201.png)\n", edits["sub/b.md"].After)
+	assert.Equal(t, "![[attachments/_shared/shared 1.png]] and ![[dup.png]]\n", edits["c.md"].After)
+	assert.Equal(t, "![shared](../files/shared.png)\n", edits["sub/b.md"].Before)
+
+	var canvas struct {
+		Nodes []map[string]interface{} `json:"nodes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(edits["board.canvas"].After), &canvas))
+	assert.Equal(t, "attachments/board/diagram.svg", canvas.Nodes[0]["file"])
+	assert.Equal(t, 5, plan.Links)
+}
+
+func TestPlanAssetOrganization_ByType(t *testing.T) {
+	vaultPath, notes := organizeTestVault(t)
+
+	plan, err := PlanAssetOrganization(vaultPath, notes, AssetOrganizeOptions{
+		Strategy: OrganizeByType,
+		Dest:     "media",
+		Folders:  []string{"files"},
+	})
+	require.NoError(t, err)
+
+	moved := make(map[string]string)
+	for _, move := range plan.Moves {
+		moved[move.From] = move.To
+	}
+	assert.Equal(t, map[string]string{
+		"files/cover.jpg":        "media/images/cover.jpg",
+		"files/diagram.svg":      "media/images/diagram.svg",
+		"files/photo.png":        "media/images/photo.png",
+		"files/report final.pdf": "media/pdfs/report final.pdf",
+		"files/shared.png":       "media/images/shared.png",
+	}, moved)
+	// Only files is organized
+	assert.Equal(t, 1, plan.Unreferenced)
+}
+
+func TestPlanAssetOrganization_InvalidStrategy(t *testing.T) {
+	_, err := PlanAssetOrganization(t.TempDir(), nil, AssetOrganizeOptions{Strategy: "by-size"})
+	assert.Error(t, err)
+}
+
+func TestMarkdownPathSpan(t *testing.T) {
+	tests := []struct {
+		dest  string
+		path  string
+		angle bool
+	}{
+		{"img.png", "img.png", false},
+		{"img%20a.png#frag", "img%20a.png", false},
+		{`img.png "A title"`, "img.png", false},
+		{"<my img.png#x>", "my img.png", true},
+	}
+	for _, tt := range tests {
+		start, end, angle := markdownPathSpan(tt.dest)
+		assert.Equal(t, tt.path, tt.dest[start:end], tt.dest)
+		assert.Equal(t, tt.angle, angle, tt.dest)
+	}
+}
+
+func TestPlanAssetRenames(t *testing.T) {
+	vaultPath, notes := organizeTestVault(t)
+
+	plan, err := PlanAssetRenames(vaultPath, notes, map[string]string{
+		"files/photo.png":  "files/photo.webp",
+		"files/locked.png": "files/locked.webp",
+		"files/unused.zip": "files/unused.tar",
+	}, nil)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []AssetMove{
+		{From: "files/photo.png", To: "files/photo.webp"},
+		{From: "files/unused.zip", To: "files/unused.tar"},
+	}, plan.Moves)
+	require.Len(t, plan.Skipped, 1)
+	assert.Equal(t, "files/locked.png", plan.Skipped[0].Path)
+	assert.Equal(t, 1, plan.Unreferenced)
+
+	require.Len(t, plan.Edits, 1)
+	assert.Equal(t, "a.md", plan.Edits[0].Path)
+	assert.Contains(t, plan.Edits[0].After, "![[photo.webp|300]]")
+}
@@ -0,0 +1,174 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Defaults for materialized backlinks
+const (
+	DefaultBacklinksHeading = "Backlinks"
+	DefaultBacklinksField   = "backlinks"
+)
+
+// BacklinksOptions configures how backlinks are written into notes
+type BacklinksOptions struct {
+	Heading     string // Heading of the level 2 backlinks section
+	Field       string // Frontmatter field used when Frontmatter is set
+	Frontmatter bool   // Write a frontmatter list instead of a section
+	LinkFormat  string // "wiki" (default) or "markdown"
+	Remove      bool   // Remove backlinks instead of writing them
+}
+
+// BacklinksProcessor materializes each note's inbound links as a section or
+// frontmatter list, for publishing where dynamic backlinks aren't available
+type BacklinksProcessor struct {
+	options   BacklinksOptions
+	section   *ListSection
+	backlinks map[string][]string // absolute note path -> formatted links to it
+}
+
+// NewBacklinksProcessor creates a backlinks processor
+func NewBacklinksProcessor(options BacklinksOptions) (*BacklinksProcessor, error) {
+	if options.Heading == "" {
+		options.Heading = DefaultBacklinksHeading
+	}
+	if options.Field == "" {
+		options.Field = DefaultBacklinksField
+	}
+	if options.LinkFormat == "" {
+		options.LinkFormat = "wiki"
+	}
+	if options.LinkFormat != "wiki" && options.LinkFormat != "markdown" {
+		return nil, fmt.Errorf("invalid link format '%s' (supported: wiki, markdown)", options.LinkFormat)
+	}
+
+	return &BacklinksProcessor{
+		options:   options,
+		section:   NewListSection(options.Heading),
+		backlinks: make(map[string][]string),
+	}, nil
+}
+
+// Backlinks returns the notes linking to each note, sorted by path. Links in
+// existing backlinks sections are ignored, so updates are idempotent.
+func (p *BacklinksProcessor) Backlinks(files []*vault.VaultFile) map[string][]string {
+	stripped := make([]*vault.VaultFile, len(files))
+	for i, file := range files {
+		body, _ := p.section.Remove(file.Body)
+		stripped[i] = &vault.VaultFile{RelativePath: filepath.ToSlash(file.RelativePath), Body: body}
+	}
+
+	_, backward := NewExportBacklinksHandler(stripped, false).linkGraph()
+	for _, sources := range backward {
+		sort.Strings(sources)
+	}
+	return backward
+}
+
+// Prepare computes the backlinks of the vault's files, which should be every
+// note in the vault so no inbound links are missed
+func (p *BacklinksProcessor) Prepare(files []*vault.VaultFile) {
+	p.backlinks = make(map[string][]string)
+	if p.options.Remove {
+		return
+	}
+
+	names := NoteNames(files)
+	backlinks := p.Backlinks(files)
+	for _, file := range files {
+		sources := backlinks[filepath.ToSlash(file.RelativePath)]
+		if len(sources) == 0 {
+			continue
+		}
+		links := make([]string, len(sources))
+		for i, source := range sources {
+			links[i] = p.formatLink(source, names)
+		}
+		p.backlinks[absPath(file.Path)] = links
+	}
+}
+
+// UpdateFile writes (or with Remove, removes) a file's backlinks, reporting
+// whether its content changed
+func (p *BacklinksProcessor) UpdateFile(file *vault.VaultFile) (bool, error) {
+	before, err := file.Serialize()
+	if err != nil {
+		return false, err
+	}
+
+	links := p.backlinks[absPath(file.Path)]
+	if p.options.Remove {
+		links = nil
+	}
+	if p.options.Frontmatter {
+		p.updateField(file, links)
+	} else {
+		// Notes without backlinks lose the section
+		file.Body = p.section.Update(file.Body, links)
+	}
+
+	after, err := file.Serialize()
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(before, after), nil
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// updateField sets the backlinks frontmatter list, removing it from notes
+// without backlinks
+func (p *BacklinksProcessor) updateField(file *vault.VaultFile, links []string) {
+	if len(links) == 0 {
+		delete(file.Frontmatter, p.options.Field)
+		return
+	}
+	values := make([]interface{}, len(links))
+	for i, link := range links {
+		values[i] = link
+	}
+	file.SetField(p.options.Field, values)
+}
+
+// formatLink links to the note at relPath, by name when it is unique
+func (p *BacklinksProcessor) formatLink(relPath string, names map[string]int) string {
+	return NoteLink(relPath, p.options.LinkFormat, names)
+}
+
+// NoteNames counts the notes with each name, for NoteLink
+func NoteNames(files []*vault.VaultFile) map[string]int {
+	names := make(map[string]int, len(files))
+	for _, file := range files {
+		names[noteName(file.RelativePath)]++
+	}
+	return names
+}
+
+// NoteLink links to the note at relPath in linkFormat ("wiki" or
+// "markdown"). Wiki links use the note's name when names, from NoteNames,
+// shows it is unique.
+func NoteLink(relPath, linkFormat string, names map[string]int) string {
+	relPath = filepath.ToSlash(relPath)
+	target := strings.TrimSuffix(relPath, ".md")
+	name := path.Base(target)
+	if linkFormat == "markdown" {
+		link := vault.Link{Type: vault.MarkdownLink, Text: name}
+		return link.GenerateUpdatedLink(relPath)
+	}
+	if names[noteName(relPath)] == 1 {
+		target = name
+	}
+	return "[[" + target + "]]"
+}
@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func backlinksTestFiles() []*vault.VaultFile {
+	return []*vault.VaultFile{
+		{Path: "/vault/a.md", RelativePath: "a.md", Body: "# A\n\nSee [[b]] and [[a]].\n"},
+		{Path: "/vault/b.md", RelativePath: "b.md", Body: "# B\n\n## Backlinks\n\n- [[stale]]\n\n## Notes\n\nSee [[c|C]].\n"},
+		{Path: "/vault/c.md", RelativePath: "c.md", Body: "# C\n\n[b](b.md)\n\n## Backlinks\n\n- [[a]]\n"},
+		{Path: "/vault/x/c.md", RelativePath: "x/c.md", Body: "[[b#Notes]]"},
+	}
+}
+
+func TestBacklinksProcessor_Sections(t *testing.T) {
+	files := backlinksTestFiles()
+	p, err := NewBacklinksProcessor(BacklinksOptions{})
+	require.NoError(t, err)
+
+	// Links in backlinks sections and to the note itself don't count
+	assert.Equal(t, map[string][]string{
+		"b.md": {"a.md", "c.md", "x/c.md"},
+		"c.md": {"b.md"},
+	}, p.Backlinks(files))
+
+	p.Prepare(files)
+	var changed []string
+	for _, file := range files {
+		modified, err := p.UpdateFile(file)
+		require.NoError(t, err)
+		if modified {
+			changed = append(changed, file.RelativePath)
+		}
+	}
+
+	assert.Equal(t, []string{"b.md", "c.md"}, changed)
+	assert.Equal(t, "# A\n\nSee [[b]] and [[a]].\n", files[0].Body)
+	// Updated in place, with ambiguous names linked by path
+	assert.Equal(t, "# B\n\n## Backlinks\n\n- [[a]]\n- [[c]]\n- [[x/c]]\n\n## Notes\n\nSee [[c|C]].\n", files[1].Body)
+	assert.Equal(t, "# C\n\n[b](b.md)\n\n## Backlinks\n\n- [[b]]\n", files[2].Body)
+
+	// Updates are idempotent
+	p.Prepare(files)
+	for _, file := range files {
+		modified, err := p.UpdateFile(file)
+		require.NoError(t, err)
+		assert.False(t, modified, file.RelativePath)
+	}
+
+	remover, err := NewBacklinksProcessor(BacklinksOptions{Remove: true})
+	require.NoError(t, err)
+	remover.Prepare(files)
+	for _, file := range files {
+		_, err := remover.UpdateFile(file)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "# B\n\n## Notes\n\nSee [[c|C]].\n", files[1].Body)
+	assert.Equal(t, "# C\n\n[b](b.md)\n", files[2].Body)
+}
+
+func TestBacklinksProcessor_Frontmatter(t *testing.T) {
+	files := backlinksTestFiles()
+	p, err := NewBacklinksProcessor(BacklinksOptions{Frontmatter: true, Field: "linked_from", LinkFormat: "markdown"})
+	require.NoError(t, err)
+	p.Prepare(files)
+
+	for _, file := range files {
+		_, err := p.UpdateFile(file)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []interface{}{"[a](a.md)", "[c](c.md)", "[c](x/c.md)"}, files[1].Frontmatter["linked_from"])
+	assert.NotContains(t, files[0].Frontmatter, "linked_from")
+
+	remover, err := NewBacklinksProcessor(BacklinksOptions{Frontmatter: true, Field: "linked_from", Remove: true})
+	require.NoError(t, err)
+	modified, err := remover.UpdateFile(files[1])
+	require.NoError(t, err)
+	assert.True(t, modified)
+	assert.NotContains(t, files[1].Frontmatter, "linked_from")
+
+	_, err = NewBacklinksProcessor(BacklinksOptions{LinkFormat: "html"})
+	assert.ErrorContains(t, err, "invalid link format 'html'")
+}
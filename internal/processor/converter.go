@@ -1,7 +1,9 @@
 package processor
 
 import (
+	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -16,9 +18,26 @@ const (
 	MarkdownFormat
 )
 
+// LinkPathStyle is how converted links write their targets, as Obsidian's
+// "New link format" setting does
+type LinkPathStyle int
+
+const (
+	PathAsWritten LinkPathStyle = iota // Keep the target the link was written with
+	PathShortest                       // The file name, or the vault path when the name isn't unique
+	PathRelative                       // Relative to the linking note's folder
+	PathAbsolute                       // From the vault root
+)
+
+// imageSizeRegex matches the size part of an embed's alias, as in ![[image.png|300]]
+var imageSizeRegex = regexp.MustCompile(`^\d+(x\d+)?$`)
+
 // LinkConverter handles conversion between link formats
 type LinkConverter struct {
 	parser *LinkParser
+	style  LinkPathStyle
+	paths  map[string]string   // lowercased vault path -> vault path
+	names  map[string][]string // lowercased file name -> vault paths
 }
 
 // NewLinkConverter creates a new link converter
@@ -28,8 +47,39 @@ func NewLinkConverter() *LinkConverter {
 	}
 }
 
+// WithPathStyle makes ConvertFile write link targets in style, resolving
+// them against files, the slash-separated vault paths of every file in the
+// vault. Links to files that can't be found keep their target.
+func (c *LinkConverter) WithPathStyle(style LinkPathStyle, files []string) *LinkConverter {
+	c.style = style
+	c.paths = make(map[string]string, len(files))
+	c.names = make(map[string][]string, len(files))
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		c.paths[strings.ToLower(file)] = file
+		name := strings.ToLower(path.Base(file))
+		c.names[name] = append(c.names[name], file)
+	}
+	// Obsidian prefers the shortest path when a name is ambiguous
+	for _, matches := range c.names {
+		sort.Slice(matches, func(i, j int) bool {
+			if len(matches[i]) != len(matches[j]) {
+				return len(matches[i]) < len(matches[j])
+			}
+			return matches[i] < matches[j]
+		})
+	}
+	return c
+}
+
 // Convert transforms links in content from one format to another
 func (c *LinkConverter) Convert(content string, from, to LinkFormat) string {
+	return c.convert(content, "", from, to)
+}
+
+// convert transforms links in content, a note at source, from one format
+// to another
+func (c *LinkConverter) convert(content, source string, from, to LinkFormat) string {
 	if from == to {
 		return content
 	}
@@ -60,19 +110,47 @@ func (c *LinkConverter) Convert(content string, from, to LinkFormat) string {
 			continue
 		}
 
-		newLink := c.formatLink(link, to)
-		oldLink := content[link.Position.Start:link.Position.End]
-		result = strings.Replace(result, oldLink, newLink, 1)
+		start, end := link.Position.Start, link.Position.End
+		switch {
+		case link.Type == EmbedLink:
+			link = splitEmbedAlias(link)
+		case link.Type == MarkdownLink && start > 0 && content[start-1] == '!':
+			// Markdown images are embeds, their alt text the alias
+			start--
+			link.Type = EmbedLink
+			link.Alias = strings.TrimPrefix(link.Text, "|")
+		case link.Type == WikiLink && link.Alias == "" && link.Fragment != "":
+			// Show headings and blocks the way Obsidian does
+			link.Text = link.Target + " > " + link.Fragment
+		}
+		if source != "" && c.style != PathAsWritten && link.Target != "" {
+			link.Target = c.styleTarget(link.Target, source, to)
+		}
+
+		result = result[:start] + c.formatLink(link, to) + result[end:]
 	}
 
 	return result
 }
 
+// splitEmbedAlias moves the alias or size of a wiki embed, which the parser
+// leaves in its target or fragment, to Alias
+func splitEmbedAlias(link Link) Link {
+	if link.Fragment != "" {
+		if idx := strings.Index(link.Fragment, "|"); idx != -1 {
+			link.Fragment, link.Alias = link.Fragment[:idx], link.Fragment[idx+1:]
+		}
+	} else if idx := strings.Index(link.Target, "|"); idx != -1 {
+		link.Target, link.Alias = link.Target[:idx], link.Target[idx+1:]
+	}
+	return link
+}
+
 // linkMatchesFormat checks if a link matches the specified format
 func (c *LinkConverter) linkMatchesFormat(link Link, format LinkFormat) bool {
 	switch format {
 	case WikiFormat:
-		return link.Type == WikiLink
+		return link.Type == WikiLink || link.Type == EmbedLink
 	case MarkdownFormat:
 		return link.Type == MarkdownLink
 	default:
@@ -96,22 +174,15 @@ func (c *LinkConverter) formatLink(link Link, format LinkFormat) string {
 func (c *LinkConverter) toMarkdown(link Link) string {
 	switch link.Type {
 	case WikiLink:
-		target := link.Target
-		text := link.Text
-
-		// Add .md extension if not present and not already has an extension
-		if !strings.HasSuffix(target, ".md") && !strings.Contains(filepath.Base(target), ".") {
-			target += ".md"
-		}
-
-		// Escape spaces and special characters in path
-		target = c.escapePath(target)
-
-		return "[" + text + "](" + target + ")"
+		return "[" + link.Text + "](" + c.markdownTarget(link) + ")"
 
 	case EmbedLink:
-		// Embeds stay in wiki format
-		return "![[" + link.Target + "]]"
+		// A size alone is written after an empty alt text
+		alt := link.Alias
+		if imageSizeRegex.MatchString(alt) {
+			alt = "|" + alt
+		}
+		return "![" + alt + "](" + c.markdownTarget(link) + ")"
 
 	default:
 		// Already markdown or unknown
@@ -119,23 +190,53 @@ func (c *LinkConverter) toMarkdown(link Link) string {
 	}
 }
 
+// markdownTarget returns a link's target and fragment, escaped for a
+// markdown link
+func (c *LinkConverter) markdownTarget(link Link) string {
+	target := link.Target
+
+	// Add .md extension if not present and not already has an extension
+	if target != "" && !strings.HasSuffix(target, ".md") && !strings.Contains(filepath.Base(target), ".") {
+		target += ".md"
+	}
+
+	// Escape spaces and special characters in path
+	target = c.escapePath(target)
+
+	if link.Fragment != "" {
+		target += "#" + c.escapeFragment(link.Fragment)
+	}
+	return target
+}
+
 // toWiki converts a link to wiki format
 func (c *LinkConverter) toWiki(link Link) string {
 	switch link.Type {
 	case MarkdownLink:
 		target := c.normalizePath(link.Target)
 		text := link.Text
+		full := target
+		if link.Fragment != "" {
+			full += "#" + link.Fragment
+		}
 
-		// If text is empty or same as target, use simple format
-		if text == "" || text == target || text == link.Target {
-			return "[[" + target + "]]"
+		// If text is empty or what Obsidian would show anyway, use simple format
+		if text == "" || text == target || text == link.Target || text == full ||
+			(link.Fragment != "" && text == target+" > "+link.Fragment) {
+			return "[[" + full + "]]"
 		}
 
-		return "[[" + target + "|" + text + "]]"
+		return "[[" + full + "|" + text + "]]"
 
 	case EmbedLink:
-		// Embeds stay the same
-		return "![[" + link.Target + "]]"
+		target := c.normalizePath(link.Target)
+		if link.Fragment != "" {
+			target += "#" + link.Fragment
+		}
+		if link.Alias == "" || link.Alias == link.Target {
+			return "![[" + target + "]]"
+		}
+		return "![[" + target + "|" + link.Alias + "]]"
 
 	default:
 		// Already wiki or unknown
@@ -154,16 +255,95 @@ func (c *LinkConverter) normalizePath(path string) string {
 	return path
 }
 
-// escapePath URL-escapes spaces in path for markdown links
+// pathEscaper URL-escapes the characters that would end or confuse a
+// markdown link target, keeping others, including parentheses, readable
+var pathEscaper = strings.NewReplacer(
+	"%", "%25", " ", "%20", "#", "%23", "?", "%3F", "^", "%5E",
+	"<", "%3C", ">", "%3E", "[", "%5B", "]", "%5D", "|", "%7C",
+	"`", "%60", "\"", "%22", "{", "%7B", "}", "%7D", "\\", "%5C",
+)
+
+// escapePath URL-escapes a path for markdown links
 func (c *LinkConverter) escapePath(path string) string {
-	// Only escape spaces, keep other characters readable
-	return strings.ReplaceAll(path, " ", "%20")
+	escaped := pathEscaper.Replace(path)
+	// Balanced parentheses are fine in a link; unbalanced ones would end it
+	if strings.Count(escaped, "(") != strings.Count(escaped, ")") {
+		escaped = strings.NewReplacer("(", "%28", ")", "%29").Replace(escaped)
+	}
+	return escaped
+}
+
+// escapeFragment URL-escapes a heading or block reference for markdown links
+func (c *LinkConverter) escapeFragment(fragment string) string {
+	if strings.HasPrefix(fragment, "^") {
+		return "^" + c.escapePath(fragment[1:])
+	}
+	return c.escapePath(fragment)
+}
+
+// styleTarget rewrites target, linked from the note at source, in the
+// converter's path style for format. Targets that don't resolve to a vault
+// file are returned as they are.
+func (c *LinkConverter) styleTarget(target, source string, format LinkFormat) string {
+	resolved := c.resolve(target, source)
+	if resolved == "" {
+		return target
+	}
+
+	styled := resolved
+	switch c.style {
+	case PathShortest:
+		if len(c.names[strings.ToLower(path.Base(resolved))]) == 1 {
+			styled = path.Base(resolved)
+		}
+	case PathRelative:
+		if rel, err := filepath.Rel(filepath.FromSlash(path.Dir(source)), filepath.FromSlash(resolved)); err == nil {
+			styled = filepath.ToSlash(rel)
+		}
+	}
+
+	if format == WikiFormat {
+		styled = c.normalizePath(styled)
+	}
+	return styled
+}
+
+// resolve finds the vault file a link target from source points to: from
+// the vault root, then relative to source, then by name. It returns "" when
+// there is none.
+func (c *LinkConverter) resolve(target, source string) string {
+	target = path.Clean(filepath.ToSlash(target))
+	candidates := []string{target}
+	if !strings.HasSuffix(strings.ToLower(target), ".md") {
+		candidates = append(candidates, target+".md")
+	}
+
+	for _, candidate := range candidates {
+		if file, ok := c.paths[strings.ToLower(strings.TrimPrefix(candidate, "/"))]; ok {
+			return file
+		}
+		if !strings.HasPrefix(candidate, "/") {
+			if file, ok := c.paths[strings.ToLower(path.Join(path.Dir(source), candidate))]; ok {
+				return file
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		suffix := "/" + strings.ToLower(strings.TrimPrefix(candidate, "/"))
+		for _, file := range c.names[strings.ToLower(path.Base(candidate))] {
+			if strings.HasSuffix("/"+strings.ToLower(file), suffix) {
+				return file
+			}
+		}
+	}
+	return ""
 }
 
 // ConvertFile converts all links in a file from one format to another
 func (c *LinkConverter) ConvertFile(file *vault.VaultFile, from, to LinkFormat) bool {
 	originalBody := file.Body
-	file.Body = c.Convert(file.Body, from, to)
+	file.Body = c.convert(file.Body, filepath.ToSlash(file.RelativePath), from, to)
 
 	// Update the parsed links
 	c.parser.UpdateFile(file)
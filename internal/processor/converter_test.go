@@ -45,7 +45,7 @@ func TestLinkConverter_Convert(t *testing.T) {
 			content: "Wiki [[note|alias]] and markdown [text](file.md) with embed ![[image.png]]",
 			from:    WikiFormat,
 			to:      MarkdownFormat,
-			want:    "Wiki [alias](note.md) and markdown [text](file.md) with embed ![[image.png]]",
+			want:    "Wiki [alias](note.md) and markdown [text](file.md) with embed ![](image.png)",
 		},
 		{
 			name:    "no changes needed",
@@ -61,6 +61,41 @@ func TestLinkConverter_Convert(t *testing.T) {
 			to:      MarkdownFormat,
 			want:    "[Display Text](Note%20with%20spaces%20and%20(parentheses).md)",
 		},
+		{
+			name:    "wiki headings and blocks to markdown",
+			content: "See [[note#My Heading]], [[note#^abc123|the block]] and [[Other Note#Part 2|part two]]",
+			from:    WikiFormat,
+			to:      MarkdownFormat,
+			want:    "See [note > My Heading](note.md#My%20Heading), [the block](note.md#^abc123) and [part two](Other%20Note.md#Part%202)",
+		},
+		{
+			name:    "markdown headings and blocks to wiki",
+			content: "See [note > My Heading](note.md#My%20Heading), [the block](note.md#^abc123) and [part two](<Other Note.md#Part 2>)",
+			from:    MarkdownFormat,
+			to:      WikiFormat,
+			want:    "See [[note#My Heading]], [[note#^abc123|the block]] and [[Other Note#Part 2|part two]]",
+		},
+		{
+			name:    "wiki embeds to markdown",
+			content: "![[diagram.png]] ![[photo one.jpg|300]] ![[note#Summary]] ![[chart.png|A chart]]",
+			from:    WikiFormat,
+			to:      MarkdownFormat,
+			want:    "![](diagram.png) ![|300](photo%20one.jpg) ![](note.md#Summary) ![A chart](chart.png)",
+		},
+		{
+			name:    "markdown embeds to wiki",
+			content: "![](diagram.png) ![|300](photo%20one.jpg) ![](note.md#Summary) ![A chart](chart.png) and [link](note.md)",
+			from:    MarkdownFormat,
+			to:      WikiFormat,
+			want:    "![[diagram.png]] ![[photo one.jpg|300]] ![[note#Summary]] ![[chart.png|A chart]] and [[note|link]]",
+		},
+		{
+			name:    "characters that would break markdown links are escaped",
+			content: "[[Q&A [draft]|notes]] and [[50% done]]",
+			from:    WikiFormat,
+			to:      MarkdownFormat,
+			want:    "[notes](Q&A%20%5Bdraft%5D.md) and [50% done](50%25%20done.md)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -118,10 +153,10 @@ func TestLinkConverter_FormatLink(t *testing.T) {
 			want:   "[[note]]",
 		},
 		{
-			name:   "preserve embed format",
+			name:   "embed to markdown",
 			link:   Link{Type: EmbedLink, Target: "image.png"},
 			format: MarkdownFormat,
-			want:   "![[image.png]]",
+			want:   "![](image.png)",
 		},
 	}
 
@@ -170,6 +205,8 @@ func TestLinkConverter_EscapePath(t *testing.T) {
 		{"parentheses", "note (with) parens", "note%20(with)%20parens"},
 		{"normal path", "normal-note", "normal-note"},
 		{"mixed", "complex path (test)", "complex%20path%20(test)"},
+		{"unbalanced parentheses", "note :)", "note%20:%29"},
+		{"link syntax", "a#b [c]", "a%23b%20%5Bc%5D"},
 	}
 
 	for _, tt := range tests {
@@ -182,3 +219,55 @@ func TestLinkConverter_EscapePath(t *testing.T) {
 		})
 	}
 }
+
+func TestLinkConverter_PathStyles(t *testing.T) {
+	files := []string{"index.md", "projects/alpha.md", "projects/notes/beta.md", "archive/beta.md", "assets/diagram.png"}
+	content := "[[alpha]] [[projects/notes/beta#Plan|plan]] ![[diagram.png]] [[missing]]"
+
+	tests := []struct {
+		name   string
+		style  LinkPathStyle
+		source string
+		to     LinkFormat
+		want   string
+	}{
+		{
+			name:   "absolute",
+			style:  PathAbsolute,
+			source: "projects/notes/beta.md",
+			to:     MarkdownFormat,
+			want:   "[alpha](projects/alpha.md) [plan](projects/notes/beta.md#Plan) ![](assets/diagram.png) [missing](missing.md)",
+		},
+		{
+			name:   "relative",
+			style:  PathRelative,
+			source: "projects/notes/beta.md",
+			to:     MarkdownFormat,
+			want:   "[alpha](../alpha.md) [plan](beta.md#Plan) ![](../../assets/diagram.png) [missing](missing.md)",
+		},
+		{
+			name:   "shortest keeps the path of ambiguous names",
+			style:  PathShortest,
+			source: "index.md",
+			to:     MarkdownFormat,
+			want:   "[alpha](alpha.md) [plan](projects/notes/beta.md#Plan) ![](diagram.png) [missing](missing.md)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := NewLinkConverter().WithPathStyle(tt.style, files)
+			got := converter.convert(content, tt.source, WikiFormat, tt.to)
+			if got != tt.want {
+				t.Errorf("convert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Wiki links drop the .md extension
+	converter := NewLinkConverter().WithPathStyle(PathAbsolute, files)
+	got := converter.convert("[a](alpha.md) ![](diagram.png)", "index.md", MarkdownFormat, WikiFormat)
+	if want := "[[projects/alpha|a]] ![[assets/diagram.png]]"; got != want {
+		t.Errorf("convert() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultDailyFormat is the daily note file name format Obsidian uses by default
+const DefaultDailyFormat = "YYYY-MM-DD"
+
+// DailyNotes maps dates to daily note paths and back
+type DailyNotes struct {
+	Folder   string // Folder relative to the vault root, "" for the root
+	Format   string // File name date format, YYYY-MM-DD style or a Go layout
+	Template string // Template for new notes, "" for none
+	layout   string
+}
+
+// NewDailyNotes creates daily note settings for a folder and date format
+func NewDailyNotes(folder, format, template string) *DailyNotes {
+	if format == "" {
+		format = DefaultDailyFormat
+	}
+	return &DailyNotes{
+		Folder:   strings.Trim(filepath.ToSlash(folder), "/"),
+		Format:   format,
+		Template: template,
+		layout:   templates.DateLayout(format),
+	}
+}
+
+// obsidianDailySettings is .obsidian/daily-notes.json, written by Obsidian's
+// daily notes plugin
+type obsidianDailySettings struct {
+	Folder   string `json:"folder"`
+	Format   string `json:"format"`
+	Template string `json:"template"`
+}
+
+// LoadDailyNotes returns the daily note settings of the vault at vaultRoot:
+// the configured ones, falling back to Obsidian's daily notes settings and
+// then to notes named YYYY-MM-DD in the vault root
+func LoadDailyNotes(cfg config.DailyConfig, vaultRoot string) (*DailyNotes, error) {
+	var obsidian obsidianDailySettings
+	data, err := os.ReadFile(filepath.Join(vaultRoot, ".obsidian", "daily-notes.json"))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &obsidian); err != nil {
+			return nil, fmt.Errorf("parsing .obsidian/daily-notes.json: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("reading .obsidian/daily-notes.json: %w", err)
+	}
+
+	pick := func(configured, fallback string) string {
+		if configured != "" {
+			return configured
+		}
+		return fallback
+	}
+	return NewDailyNotes(
+		pick(cfg.Folder, obsidian.Folder),
+		pick(cfg.Format, obsidian.Format),
+		pick(cfg.Template, obsidian.Template),
+	), nil
+}
+
+// Name returns the file name of the daily note for date, without .md. A
+// format containing '/' yields a path within Folder.
+func (d *DailyNotes) Name(date time.Time) string {
+	return date.Format(d.layout)
+}
+
+// Path returns the path of the daily note for date, relative to the vault root
+func (d *DailyNotes) Path(date time.Time) string {
+	return filepath.FromSlash(strings.TrimPrefix(d.Folder+"/"+d.Name(date)+".md", "/"))
+}
+
+// DateOf returns the date of the daily note at relPath, relative to the vault
+// root, or false if relPath isn't a daily note
+func (d *DailyNotes) DateOf(relPath string) (time.Time, bool) {
+	name := filepath.ToSlash(relPath)
+	if d.Folder != "" {
+		if !strings.HasPrefix(name, d.Folder+"/") {
+			return time.Time{}, false
+		}
+		name = name[len(d.Folder)+1:]
+	}
+	if !strings.HasSuffix(name, ".md") {
+		return time.Time{}, false
+	}
+	name = strings.TrimSuffix(name, ".md")
+
+	date, err := time.ParseInLocation(d.layout, name, time.Local)
+	if err != nil || date.Format(d.layout) != name {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// Dates returns the dates that have a daily note among files, keyed by
+// YYYY-MM-DD
+func (d *DailyNotes) Dates(files []*vault.VaultFile) map[string]*vault.VaultFile {
+	dates := make(map[string]*vault.VaultFile)
+	for _, file := range files {
+		if date, ok := d.DateOf(file.RelativePath); ok {
+			dates[date.Format("2006-01-02")] = file
+		}
+	}
+	return dates
+}
+
+// ParseDay parses a day given as today, yesterday, tomorrow, a signed number
+// of days from today such as -3, or a YYYY-MM-DD date
+func ParseDay(value string, now time.Time) (time.Time, error) {
+	today := StartOfDay(now)
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		if offset, err := strconv.Atoi(value); err == nil {
+			return today.AddDate(0, 0, offset), nil
+		}
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s': expected today, yesterday, tomorrow, +N/-N days or YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// StartOfDay returns midnight at the start of t's day
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// DateRange is an inclusive range of days
+type DateRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Days returns the number of days in the range
+func (r DateRange) Days() int {
+	return daysBetween(r.Start, r.End) + 1
+}
+
+// MarshalJSON writes the range as YYYY-MM-DD days, or null when it is empty
+func (r DateRange) MarshalJSON() ([]byte, error) {
+	if r.Start.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+		Days  int    `json:"days"`
+	}{r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), r.Days()})
+}
+
+// DailyReport summarizes daily notes over a range of days
+type DailyReport struct {
+	From          time.Time   `json:"from"`
+	To            time.Time   `json:"to"`
+	Days          int         `json:"days"`
+	Present       int         `json:"present"`
+	Missing       int         `json:"missing"`
+	Gaps          []DateRange `json:"gaps"`           // Runs of missing days, oldest first
+	CurrentStreak int         `json:"current_streak"` // Days with a note up to To, or the day before when To has none yet
+	LongestStreak DateRange   `json:"longest_streak"`
+}
+
+// MarshalJSON writes the report with YYYY-MM-DD days
+func (r DailyReport) MarshalJSON() ([]byte, error) {
+	type report DailyReport
+	return json.Marshal(struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		report
+	}{r.From.Format("2006-01-02"), r.To.Format("2006-01-02"), report(r)})
+}
+
+// Report summarizes the daily notes among files from one day to another
+func (d *DailyNotes) Report(files []*vault.VaultFile, from, to time.Time) DailyReport {
+	from, to = StartOfDay(from), StartOfDay(to)
+	report := DailyReport{From: from, To: to, Gaps: []DateRange{}}
+	if to.Before(from) {
+		return report
+	}
+	dates := d.Dates(files)
+
+	var run DateRange
+	longest := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		report.Days++
+		if dates[day.Format("2006-01-02")] == nil {
+			report.Missing++
+			if n := len(report.Gaps); n > 0 && daysBetween(report.Gaps[n-1].End, day) == 1 {
+				report.Gaps[n-1].End = day
+			} else {
+				report.Gaps = append(report.Gaps, DateRange{Start: day, End: day})
+			}
+			continue
+		}
+
+		report.Present++
+		if run.Start.IsZero() || daysBetween(run.End, day) != 1 {
+			run.Start = day
+		}
+		run.End = day
+		if run.Days() > longest {
+			longest = run.Days()
+			report.LongestStreak = run
+		}
+	}
+
+	day := to
+	if dates[day.Format("2006-01-02")] == nil {
+		day = day.AddDate(0, 0, -1)
+	}
+	for ; !day.Before(from) && dates[day.Format("2006-01-02")] != nil; day = day.AddDate(0, 0, -1) {
+		report.CurrentStreak++
+	}
+	return report
+}
+
+// FirstDate returns the date of the earliest daily note among files
+func (d *DailyNotes) FirstDate(files []*vault.VaultFile) (time.Time, bool) {
+	var dates []string
+	for date := range d.Dates(files) {
+		dates = append(dates, date)
+	}
+	if len(dates) == 0 {
+		return time.Time{}, false
+	}
+	sort.Strings(dates)
+	first, _ := time.ParseInLocation("2006-01-02", dates[0], time.Local)
+	return first, true
+}
+
+// daysBetween counts calendar days from a to b, ignoring DST changes
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
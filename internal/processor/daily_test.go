@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func day(s string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return t
+}
+
+func TestDailyNotes_PathAndDate(t *testing.T) {
+	daily := NewDailyNotes("journal/", "YYYY/MM/YYYY-MM-DD dddd", "")
+
+	assert.Equal(t, filepath.Join("journal", "2024", "03", "2024-03-05 Tuesday.md"), daily.Path(day("2024-03-05")))
+
+	date, ok := daily.DateOf("journal/2024/03/2024-03-05 Tuesday.md")
+	require.True(t, ok)
+	assert.True(t, date.Equal(day("2024-03-05")))
+
+	for _, path := range []string{
+		"2024/03/2024-03-05 Tuesday.md",          // Outside the folder
+		"journal/2024/03/2024-03-05 Monday.md",   // Wrong weekday
+		"journal/2024/03/2024-03-05 Tuesday.txt", // Not a note
+		"journal/Weekly review.md",
+	} {
+		_, ok := daily.DateOf(path)
+		assert.False(t, ok, path)
+	}
+
+	root := NewDailyNotes("", "", "")
+	assert.Equal(t, "2024-03-05.md", root.Path(day("2024-03-05")))
+}
+
+func TestLoadDailyNotes(t *testing.T) {
+	vaultRoot := t.TempDir()
+
+	daily, err := LoadDailyNotes(config.DailyConfig{}, vaultRoot)
+	require.NoError(t, err)
+	assert.Equal(t, "", daily.Folder)
+	assert.Equal(t, DefaultDailyFormat, daily.Format)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(vaultRoot, ".obsidian"), 0755))
+	settings := `{"folder": "Journal", "format": "YYYYMMDD", "template": "Templates/Daily"}`
+	require.NoError(t, os.WriteFile(filepath.Join(vaultRoot, ".obsidian", "daily-notes.json"), []byte(settings), 0644))
+
+	daily, err = LoadDailyNotes(config.DailyConfig{Folder: "days"}, vaultRoot)
+	require.NoError(t, err)
+	assert.Equal(t, "days", daily.Folder, "config takes precedence")
+	assert.Equal(t, "YYYYMMDD", daily.Format)
+	assert.Equal(t, "Templates/Daily", daily.Template)
+}
+
+func TestParseDay(t *testing.T) {
+	now := time.Date(2024, 3, 5, 15, 30, 0, 0, time.Local)
+	tests := map[string]string{
+		"":           "2024-03-05",
+		"today":      "2024-03-05",
+		"Yesterday":  "2024-03-04",
+		"tomorrow":   "2024-03-06",
+		"-7":         "2024-02-27",
+		"+1":         "2024-03-06",
+		"2023-12-31": "2023-12-31",
+	}
+	for value, want := range tests {
+		got, err := ParseDay(value, now)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got.Format("2006-01-02"), value)
+	}
+
+	_, err := ParseDay("next week", now)
+	assert.Error(t, err)
+}
+
+func TestDailyNotes_Report(t *testing.T) {
+	daily := NewDailyNotes("journal", "", "")
+	var files []*vault.VaultFile
+	for _, date := range []string{"2024-03-01", "2024-03-02", "2024-03-03", "2024-03-05", "2024-03-08", "2024-03-09"} {
+		files = append(files, &vault.VaultFile{RelativePath: "journal/" + date + ".md"})
+	}
+	files = append(files, &vault.VaultFile{RelativePath: "2024-03-04.md"})
+
+	first, ok := daily.FirstDate(files)
+	require.True(t, ok)
+	assert.Equal(t, "2024-03-01", first.Format("2006-01-02"))
+
+	report := daily.Report(files, day("2024-03-01"), day("2024-03-10"))
+	assert.Equal(t, 10, report.Days)
+	assert.Equal(t, 6, report.Present)
+	assert.Equal(t, 4, report.Missing)
+	require.Len(t, report.Gaps, 3)
+	assert.Equal(t, "2024-03-06", report.Gaps[1].Start.Format("2006-01-02"))
+	assert.Equal(t, 2, report.Gaps[1].Days())
+	assert.Equal(t, 2, report.CurrentStreak, "a missing last day doesn't end the streak")
+	assert.Equal(t, 3, report.LongestStreak.Days())
+	assert.Equal(t, "2024-03-01", report.LongestStreak.Start.Format("2006-01-02"))
+
+	report = daily.Report(files, day("2024-03-01"), day("2024-03-11"))
+	assert.Equal(t, 0, report.CurrentStreak)
+
+	data, err := json.Marshal(daily.Report(nil, day("2024-03-01"), day("2024-03-01")))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"from": "2024-03-01", "to": "2024-03-01", "days": 1, "present": 0, "missing": 1,
+		"gaps": [{"start": "2024-03-01", "end": "2024-03-01", "days": 1}],
+		"current_streak": 0, "longest_streak": null}`, string(data))
+}
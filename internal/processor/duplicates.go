@@ -0,0 +1,232 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Strategies for choosing which copy of a duplicated note to keep
+const (
+	KeepOriginal = "keep-original"
+	KeepNewest   = "keep-newest"
+	KeepLargest  = "keep-largest"
+)
+
+// DuplicateStrategies lists the valid duplicate resolution strategies
+var DuplicateStrategies = []string{KeepOriginal, KeepNewest, KeepLargest}
+
+// DuplicateGroup is a note with the Obsidian copies and sync-conflict files
+// made of it
+type DuplicateGroup struct {
+	Original *vault.VaultFile
+	Copies   []*vault.VaultFile
+}
+
+// Files returns the original followed by its copies
+func (g DuplicateGroup) Files() []*vault.VaultFile {
+	return append([]*vault.VaultFile{g.Original}, g.Copies...)
+}
+
+// DuplicateResolution is the result of resolving a duplicate group. The kept
+// note always stays at the original's path, so only copies are removed.
+type DuplicateResolution struct {
+	Kept    *vault.VaultFile   // The original, rewritten with the chosen content
+	Chosen  *vault.VaultFile   // The file whose content was kept
+	Removed []*vault.VaultFile // Copies to delete
+	Merged  []string           // Frontmatter fields filled in or extended from other files
+}
+
+// FindDuplicateGroups groups the Obsidian copies ("Note 1.md") and
+// sync-conflict files among files with the note they duplicate, sorted by the
+// original's path. A file that is both kind of duplicate is listed once.
+func FindDuplicateGroups(files []*vault.VaultFile) []DuplicateGroup {
+	byPath := make(map[string]*vault.VaultFile, len(files))
+	for _, file := range files {
+		byPath[file.RelativePath] = file
+	}
+
+	a := analyzer.NewAnalyzer()
+	copiesOf := make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(original, copy string) {
+		if seen[copy] || original == copy {
+			return
+		}
+		seen[copy] = true
+		copiesOf[original] = append(copiesOf[original], copy)
+	}
+	for _, c := range a.FindObsidianCopies(files) {
+		add(c.OriginalFile, c.CopyFile)
+	}
+	for _, c := range a.FindSyncConflictFiles(files) {
+		add(c.OriginalFile, c.ConflictFile)
+	}
+
+	var groups []DuplicateGroup
+	for original, copies := range copiesOf {
+		// A copy of a copy, however deep, belongs with the note they were
+		// all made from. Each copy has one original, so this always ends.
+		if seen[original] {
+			continue
+		}
+		group := DuplicateGroup{Original: byPath[original]}
+		pending := append([]string(nil), copies...)
+		for len(pending) > 0 {
+			path := pending[0]
+			pending = append(pending[1:], copiesOf[path]...)
+			group.Copies = append(group.Copies, byPath[path])
+		}
+		sort.Slice(group.Copies, func(i, j int) bool {
+			return group.Copies[i].RelativePath < group.Copies[j].RelativePath
+		})
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Original.RelativePath < groups[j].Original.RelativePath
+	})
+	return groups
+}
+
+// SplitDivergedCopies separates the copies in group whose bodies match the
+// original's, exactly or as near-duplicates at threshold (0 for the default),
+// from those whose bodies differ. A file named like a copy of a note may be
+// an unrelated note, such as "Chapter 1.md" beside "Chapter.md", so diverged
+// copies aren't resolved by a strategy.
+func SplitDivergedCopies(group DuplicateGroup, threshold float64) (DuplicateGroup, []*vault.VaultFile) {
+	a := analyzer.NewAnalyzer()
+	a.SetSimilarityThreshold(threshold)
+	matching := DuplicateGroup{Original: group.Original}
+	var diverged []*vault.VaultFile
+	for _, file := range group.Copies {
+		if a.SimilarBodies(group.Original.Body, file.Body) {
+			matching.Copies = append(matching.Copies, file)
+		} else {
+			diverged = append(diverged, file)
+		}
+	}
+	return matching, diverged
+}
+
+// ChooseDuplicate picks the file of group whose content a strategy keeps.
+// Ties go to the original, then to the first copy.
+func ChooseDuplicate(group DuplicateGroup, strategy string) (*vault.VaultFile, error) {
+	chosen := group.Original
+	switch strategy {
+	case KeepOriginal:
+	case KeepNewest:
+		for _, file := range group.Copies {
+			if file.Modified.After(chosen.Modified) {
+				chosen = file
+			}
+		}
+	case KeepLargest:
+		for _, file := range group.Copies {
+			if len(file.Content) > len(chosen.Content) {
+				chosen = file
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy '%s' - valid options are: keep-original, keep-newest, keep-largest", strategy)
+	}
+	return chosen, nil
+}
+
+// ResolveDuplicates keeps chosen's content at the original's path and marks
+// the copies for removal. Frontmatter is merged: chosen's fields win, fields
+// missing from it are filled in from the other files, and list fields such as
+// tags and aliases combine the values of every file.
+func ResolveDuplicates(group DuplicateGroup, chosen *vault.VaultFile) DuplicateResolution {
+	kept := group.Original
+	resolution := DuplicateResolution{Kept: kept, Chosen: chosen}
+
+	if chosen != kept {
+		// Frontmatter keys not set by chosen are merged back in below
+		original := kept.Frontmatter
+		kept.Frontmatter = make(map[string]interface{}, len(chosen.Frontmatter))
+		for key, value := range chosen.Frontmatter {
+			kept.SetField(key, value)
+		}
+		kept.Body = chosen.Body
+		resolution.addMerged(mergeFrontmatter(kept, original)...)
+	}
+
+	for _, file := range group.Copies {
+		if file != chosen {
+			resolution.addMerged(mergeFrontmatter(kept, file.Frontmatter)...)
+		}
+		resolution.Removed = append(resolution.Removed, file)
+	}
+	sort.Strings(resolution.Merged)
+	return resolution
+}
+
+// mergeFrontmatter adds the fields of from missing in kept, and the values of
+// list fields kept lacks, returning the fields it changed
+func mergeFrontmatter(kept *vault.VaultFile, from map[string]interface{}) []string {
+	var changed []string
+	keys := make([]string, 0, len(from))
+	for key := range from {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := from[key]
+		existing, ok := kept.GetField(key)
+		if !ok {
+			kept.SetField(key, value)
+			changed = append(changed, key)
+			continue
+		}
+		existingList, ok1 := existing.([]interface{})
+		valueList, ok2 := value.([]interface{})
+		if !ok1 || !ok2 {
+			continue
+		}
+		merged := append([]interface{}{}, existingList...)
+		for _, item := range valueList {
+			if !containsValue(merged, item) {
+				merged = append(merged, item)
+			}
+		}
+		if len(merged) > len(existingList) {
+			kept.SetField(key, merged)
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
+
+func (r *DuplicateResolution) addMerged(keys ...string) {
+	for _, key := range keys {
+		if !slices.Contains(r.Merged, key) {
+			r.Merged = append(r.Merged, key)
+		}
+	}
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if fmt.Sprint(item) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// DuplicateMoves returns the link rewrites that point links to removed copies
+// at the kept note
+func DuplicateMoves(resolutions []DuplicateResolution) []FileMove {
+	var moves []FileMove
+	for _, resolution := range resolutions {
+		for _, file := range resolution.Removed {
+			moves = append(moves, FileMove{From: file.RelativePath, To: resolution.Kept.RelativePath})
+		}
+	}
+	return moves
+}
@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestFindDuplicateGroups(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "Note.md"},
+		{RelativePath: "Note 1.md"},
+		{RelativePath: "Note 1 1.md"},
+		{RelativePath: "Note.sync-conflict-20240101-120000-ABCDEFGH.md"},
+		{RelativePath: "Other.md"},
+		{RelativePath: "Lonely 2.md"},
+	}
+
+	groups := FindDuplicateGroups(files)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Note.md", groups[0].Original.RelativePath)
+
+	var copies []string
+	for _, file := range groups[0].Copies {
+		copies = append(copies, file.RelativePath)
+	}
+	// "Note 1.md" matches both the Obsidian and iCloud patterns but is listed once
+	assert.Equal(t, []string{"Note 1 1.md", "Note 1.md", "Note.sync-conflict-20240101-120000-ABCDEFGH.md"}, copies)
+}
+
+func TestFindDuplicateGroups_DeeplyNestedCopies(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "Note.md"},
+		{RelativePath: "Note 1.md"},
+		{RelativePath: "Note 1 1.md"},
+		{RelativePath: "Note 1 1 1.md"},
+		{RelativePath: "Note 1 1.sync-conflict-20240101-120000-ABCDEFGH.md"},
+	}
+
+	groups := FindDuplicateGroups(files)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Note.md", groups[0].Original.RelativePath)
+
+	var copies []string
+	for _, file := range groups[0].Copies {
+		copies = append(copies, file.RelativePath)
+	}
+	assert.Equal(t, []string{"Note 1 1 1.md", "Note 1 1.md", "Note 1 1.sync-conflict-20240101-120000-ABCDEFGH.md", "Note 1.md"}, copies)
+}
+
+func TestSplitDivergedCopies(t *testing.T) {
+	group := DuplicateGroup{
+		Original: &vault.VaultFile{RelativePath: "Chapter.md", Body: "The opening chapter sets the scene\n"},
+		Copies: []*vault.VaultFile{
+			{RelativePath: "Chapter 1.md", Body: "An unrelated note that only happens to share a name\n"},
+			{RelativePath: "Chapter 2.md", Body: "The opening chapter sets the scene \r\n\r\n"},
+			{RelativePath: "Chapter 3.md", Body: "The opening chapter sets the whole scene\n"},
+		},
+	}
+
+	matching, diverged := SplitDivergedCopies(group, 0)
+	assert.Equal(t, group.Original, matching.Original)
+	require.Len(t, matching.Copies, 2)
+	assert.Equal(t, "Chapter 2.md", matching.Copies[0].RelativePath)
+	assert.Equal(t, "Chapter 3.md", matching.Copies[1].RelativePath)
+	require.Len(t, diverged, 1)
+	assert.Equal(t, "Chapter 1.md", diverged[0].RelativePath)
+
+	// Only exact matches at a threshold of 1
+	matching, diverged = SplitDivergedCopies(group, 1)
+	require.Len(t, matching.Copies, 1)
+	assert.Len(t, diverged, 2)
+}
+
+func TestChooseDuplicate(t *testing.T) {
+	now := time.Now()
+	group := DuplicateGroup{
+		Original: &vault.VaultFile{RelativePath: "Note.md", Content: []byte("medium"), Modified: now.Add(-time.Hour)},
+		Copies: []*vault.VaultFile{
+			{RelativePath: "Note 1.md", Content: []byte("the largest one"), Modified: now.Add(-2 * time.Hour)},
+			{RelativePath: "Note 2.md", Content: []byte("new"), Modified: now},
+		},
+	}
+
+	tests := []struct {
+		strategy string
+		want     string
+	}{
+		{KeepOriginal, "Note.md"},
+		{KeepNewest, "Note 2.md"},
+		{KeepLargest, "Note 1.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			chosen, err := ChooseDuplicate(group, tt.strategy)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, chosen.RelativePath)
+		})
+	}
+
+	_, err := ChooseDuplicate(group, "keep-oldest")
+	assert.ErrorContains(t, err, "unknown strategy")
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	original := &vault.VaultFile{
+		RelativePath: "Note.md",
+		Frontmatter:  map[string]interface{}{"title": "Old", "tags": []interface{}{"a"}, "created": "2024-01-01"},
+		Body:         "old body\n",
+	}
+	newer := &vault.VaultFile{
+		RelativePath: "Note 1.md",
+		Frontmatter:  map[string]interface{}{"title": "New", "tags": []interface{}{"b"}},
+		Body:         "new body\n",
+	}
+	conflict := &vault.VaultFile{
+		RelativePath: "Note (1).md",
+		Frontmatter:  map[string]interface{}{"tags": []interface{}{"a", "c"}, "status": "draft"},
+	}
+	group := DuplicateGroup{Original: original, Copies: []*vault.VaultFile{newer, conflict}}
+
+	resolution := ResolveDuplicates(group, newer)
+	assert.Same(t, original, resolution.Kept)
+	assert.Equal(t, []*vault.VaultFile{newer, conflict}, resolution.Removed)
+	assert.Equal(t, []string{"created", "status", "tags"}, resolution.Merged)
+
+	// The chosen version's content wins; the rest is merged in
+	assert.Equal(t, "new body\n", original.Body)
+	assert.Equal(t, "New", original.Frontmatter["title"])
+	assert.Equal(t, "2024-01-01", original.Frontmatter["created"])
+	assert.Equal(t, "draft", original.Frontmatter["status"])
+	assert.Equal(t, []interface{}{"b", "a", "c"}, original.Frontmatter["tags"])
+
+	moves := DuplicateMoves([]DuplicateResolution{resolution})
+	assert.Equal(t, []FileMove{{From: "Note 1.md", To: "Note.md"}, {From: "Note (1).md", To: "Note.md"}}, moves)
+}
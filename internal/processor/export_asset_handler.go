@@ -164,6 +164,11 @@ func (ah *ExportAssetHandler) resolveAssetPath(target, sourceRelativePath string
 		target = target[:hashIndex]
 	}
 
+	// Remove embed sizes (image.png|300)
+	if pipeIndex := strings.Index(target, "|"); pipeIndex != -1 {
+		target = target[:pipeIndex]
+	}
+
 	// Skip if it's not a supported asset extension
 	ext := strings.ToLower(filepath.Ext(target))
 	supported := false
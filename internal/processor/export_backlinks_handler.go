@@ -20,14 +20,21 @@ type BacklinksDiscoveryResult struct {
 // ExportBacklinksHandler handles backlink discovery for export
 type ExportBacklinksHandler struct {
 	allVaultFiles []*vault.VaultFile
+	paths         map[string]bool // relative paths of allVaultFiles
 	verbose       bool
 	maxDepth      int // maximum depth to prevent runaway recursion
 }
 
 // NewExportBacklinksHandler creates a new backlinks handler
 func NewExportBacklinksHandler(allVaultFiles []*vault.VaultFile, verbose bool) *ExportBacklinksHandler {
+	paths := make(map[string]bool, len(allVaultFiles))
+	for _, file := range allVaultFiles {
+		paths[file.RelativePath] = true
+	}
+
 	return &ExportBacklinksHandler{
 		allVaultFiles: allVaultFiles,
+		paths:         paths,
 		verbose:       verbose,
 		maxDepth:      10, // reasonable limit to prevent infinite recursion
 	}
@@ -211,10 +218,5 @@ func (bh *ExportBacklinksHandler) resolveLinkPath(target, sourceRelativePath str
 
 // fileExists checks if a file exists in the vault files list
 func (bh *ExportBacklinksHandler) fileExists(filePath string) bool {
-	for _, file := range bh.allVaultFiles {
-		if file.RelativePath == filePath {
-			return true
-		}
-	}
-	return false
+	return bh.paths[filePath]
 }
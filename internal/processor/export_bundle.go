@@ -0,0 +1,344 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// OneFileBundle merges an export into a single document
+const OneFileBundle = "onefile"
+
+// BundleFormat is the document format of a bundled export
+type BundleFormat string
+
+const (
+	MarkdownBundle BundleFormat = "md"
+	PDFBundle      BundleFormat = "pdf"
+	EPUBBundle     BundleFormat = "epub"
+	DOCXBundle     BundleFormat = "docx"
+)
+
+// GetBundleFormats returns all supported bundle formats
+func GetBundleFormats() []BundleFormat {
+	return []BundleFormat{MarkdownBundle, PDFBundle, EPUBBundle, DOCXBundle}
+}
+
+// IsValidBundleFormat checks if a bundle format is supported
+func IsValidBundleFormat(format string) bool {
+	for _, f := range GetBundleFormats() {
+		if string(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Orders of the notes in a bundle
+const (
+	BundleOrderPath    = "path"    // By relative path
+	BundleOrderTitle   = "title"   // By title
+	BundleOrderCreated = "created" // By created field, oldest first
+	BundleOrderLinks   = "links"   // Following links from notes nothing else links to
+)
+
+// IsValidBundleOrder reports whether order is a supported bundle order
+func IsValidBundleOrder(order string) bool {
+	switch order {
+	case BundleOrderPath, BundleOrderTitle, BundleOrderCreated, BundleOrderLinks:
+		return true
+	}
+	return false
+}
+
+var (
+	bundleHeadingPattern = regexp.MustCompile(`^(#{1,6})(\s.*)?$`)
+	bundleFencePattern   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// ExportBundler merges exported notes into one markdown document, with each
+// note as a chapter. Links between bundled notes point to their chapters,
+// links to other notes become plain text and assets are linked in place.
+type ExportBundler struct {
+	files    []*vault.VaultFile
+	analyzer *ExportLinkAnalyzer
+	assets   *ExportAssetHandler
+	graph    *ExportBacklinksHandler
+
+	vaultPath string
+	assetDir  string            // Directory asset links are relative to
+	anchors   map[string]string // relative path -> chapter anchor
+	missing   map[string]bool   // Linked assets that don't exist
+}
+
+// NewExportBundler creates a bundler for the selected files. Asset links in
+// the bundle are relative to assetDir, where the document is rendered from.
+func NewExportBundler(selectedFiles, allFiles []*vault.VaultFile, vaultPath, assetDir string) *ExportBundler {
+	b := &ExportBundler{
+		files:     selectedFiles,
+		analyzer:  NewExportLinkAnalyzer(selectedFiles, allFiles),
+		assets:    NewExportAssetHandler(vaultPath, "", false),
+		graph:     NewExportBacklinksHandler(selectedFiles, false),
+		vaultPath: vaultPath,
+		assetDir:  assetDir,
+		anchors:   make(map[string]string, len(selectedFiles)),
+		missing:   make(map[string]bool),
+	}
+
+	// Chapter anchors come from paths, numbered when slugs collide
+	paths := make([]string, 0, len(selectedFiles))
+	for _, file := range selectedFiles {
+		paths = append(paths, file.RelativePath)
+	}
+	sort.Strings(paths)
+	used := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		base := templates.Slugify(strings.TrimSuffix(filepath.ToSlash(p), ".md"))
+		if base == "" {
+			base = "note"
+		}
+		anchor := base
+		for i := 2; used[anchor]; i++ {
+			anchor = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[anchor] = true
+		b.anchors[p] = anchor
+	}
+	return b
+}
+
+// Order returns the bundled notes in the given order
+func (b *ExportBundler) Order(order string) []*vault.VaultFile {
+	ordered := append([]*vault.VaultFile(nil), b.files...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].RelativePath < ordered[j].RelativePath
+	})
+
+	switch order {
+	case BundleOrderTitle:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return strings.ToLower(bundleTitle(ordered[i])) < strings.ToLower(bundleTitle(ordered[j]))
+		})
+	case BundleOrderCreated:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return bundleCreated(ordered[i]).Before(bundleCreated(ordered[j]))
+		})
+	case BundleOrderLinks:
+		ordered = b.linkOrder(ordered)
+	}
+	return ordered
+}
+
+// linkOrder walks the links between bundled notes depth first, in the order
+// they appear, starting from the notes no other bundled note links to. Notes
+// only reachable through a cycle are started from in path order.
+func (b *ExportBundler) linkOrder(byPath []*vault.VaultFile) []*vault.VaultFile {
+	forward, backward := b.graph.linkGraph()
+	files := make(map[string]*vault.VaultFile, len(byPath))
+	for _, file := range byPath {
+		files[file.RelativePath] = file
+	}
+
+	var ordered []*vault.VaultFile
+	visited := make(map[string]bool, len(byPath))
+	var visit func(p string)
+	visit = func(p string) {
+		if visited[p] {
+			return
+		}
+		visited[p] = true
+		ordered = append(ordered, files[p])
+		for _, target := range forward[p] {
+			visit(target)
+		}
+	}
+
+	for _, file := range byPath {
+		if len(backward[file.RelativePath]) == 0 {
+			visit(file.RelativePath)
+		}
+	}
+	for _, file := range byPath {
+		visit(file.RelativePath)
+	}
+	return ordered
+}
+
+// Markdown returns the bundle of files, in order, under a title block
+func (b *ExportBundler) Markdown(files []*vault.VaultFile, title string) (string, error) {
+	var out strings.Builder
+	if title != "" {
+		metadata, err := yaml.Marshal(map[string]string{"title": title})
+		if err != nil {
+			return "", fmt.Errorf("encoding bundle title: %w", err)
+		}
+		out.WriteString("---\n")
+		out.Write(metadata)
+		out.WriteString("---\n\n")
+	}
+
+	for i, file := range files {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		chapter := bundleTitle(file)
+		fmt.Fprintf(&out, "# %s {#%s}\n\n", chapter, b.anchors[file.RelativePath])
+		body := strings.TrimSpace(b.convertBody(file, chapter))
+		if body != "" {
+			out.WriteString(body + "\n")
+		}
+	}
+	return out.String(), nil
+}
+
+// MissingAssets returns the linked assets that weren't found in the vault
+func (b *ExportBundler) MissingAssets() []string {
+	missing := make([]string, 0, len(b.missing))
+	for assetPath := range b.missing {
+		missing = append(missing, assetPath)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// convertBody rewrites a note's links and nests its headings under the
+// chapter heading, dropping a leading H1 that repeats the chapter title
+func (b *ExportBundler) convertBody(file *vault.VaultFile, chapter string) string {
+	body := file.Body
+	links := b.analyzer.extractAllLinks(body)
+	for i := len(links) - 1; i >= 0; i-- {
+		link := links[i]
+		replacement, ok := b.convertLink(link, file)
+		if !ok {
+			continue
+		}
+		body = body[:link.Position.Start] + replacement + body[link.Position.End:]
+	}
+
+	lines := strings.Split(body, "\n")
+	inFence, seenContent := false, false
+	kept := lines[:0]
+	for _, line := range lines {
+		if bundleFencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if !inFence {
+			if matches := bundleHeadingPattern.FindStringSubmatch(line); matches != nil {
+				if !seenContent && matches[1] == "#" && strings.EqualFold(strings.TrimSpace(matches[2]), chapter) {
+					seenContent = true
+					continue
+				}
+				if len(matches[1]) < 6 {
+					line = "#" + line
+				}
+			}
+		}
+		if strings.TrimSpace(line) != "" {
+			seenContent = true
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// convertLink returns the replacement for a link, if it needs one
+func (b *ExportBundler) convertLink(link vault.Link, file *vault.VaultFile) (string, bool) {
+	if !b.analyzer.parser.IsInternalLink(link.Target) {
+		return "", false
+	}
+
+	target, _, text := splitLink(link)
+	if target == "" {
+		// Headings get new ids in the bundle, so point to the note's chapter
+		if link.Type == vault.MarkdownLink {
+			return "", false
+		}
+		return fmt.Sprintf("[%s](#%s)", text, b.anchors[file.RelativePath]), true
+	}
+
+	if b.analyzer.isAssetFile(target) || (filepath.Ext(target) != "" && filepath.Ext(target) != ".md") {
+		assetPath := b.assets.resolveAssetPath(target, file.RelativePath)
+		if assetPath == "" {
+			return "", false
+		}
+		if !b.assets.assetExists(assetPath) {
+			b.missing[assetPath] = true
+			return text, true
+		}
+		rel, err := filepath.Rel(b.assetDir, filepath.Join(b.vaultPath, assetPath))
+		if err != nil {
+			return "", false
+		}
+		assetURL := escapeURLPath(filepath.ToSlash(rel))
+		if link.Type == vault.EmbedLink && isImageFile(assetPath) {
+			return fmt.Sprintf("![%s](%s)", text, assetURL), true
+		}
+		return fmt.Sprintf("[%s](%s)", text, assetURL), true
+	}
+
+	resolved := b.analyzer.resolveTargetPath(target, file.RelativePath)
+	anchor, ok := b.anchors[resolved]
+	if !ok {
+		return text, true
+	}
+	return fmt.Sprintf("[%s](#%s)", text, anchor), true
+}
+
+// bundleTitle returns a note's title field, or its filename
+func bundleTitle(file *vault.VaultFile) string {
+	if title, ok := file.Frontmatter["title"].(string); ok && strings.TrimSpace(title) != "" {
+		return strings.TrimSpace(title)
+	}
+	return strings.TrimSuffix(filepath.Base(file.RelativePath), filepath.Ext(file.RelativePath))
+}
+
+// bundleCreated returns a note's created date, or its modification time
+func bundleCreated(file *vault.VaultFile) time.Time {
+	if t, err := parseTimeField(file.Frontmatter["created"]); err == nil {
+		return t
+	}
+	return file.Modified
+}
+
+// RenderBundle converts a bundled markdown document to outputPath with
+// pandoc, which picks the format from the file extension. Relative asset
+// links are resolved from resourceDir.
+func RenderBundle(ctx context.Context, markdown, outputPath, pandocPath, resourceDir string, extraArgs []string) error {
+	if pandocPath == "" {
+		pandocPath = "pandoc"
+	}
+	pandoc, err := exec.LookPath(pandocPath)
+	if err != nil {
+		return fmt.Errorf("pandoc not found, install it from https://pandoc.org or export with --format md: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	args := append([]string{"--from", "markdown", "--standalone", "--resource-path", resourceDir, "--output", outputPath}, extraArgs...)
+	cmd := exec.CommandContext(ctx, pandoc, args...)
+	cmd.Dir = resourceDir
+	cmd.Stdin = strings.NewReader(markdown)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("running pandoc: %w: %s", err, msg)
+		}
+		return fmt.Errorf("running pandoc: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestExportBundler_Markdown(t *testing.T) {
+	vaultPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(vaultPath, "images"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(vaultPath, "images", "my pic.png"), []byte("png"), 0644))
+
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md", Body: "# Index\n\nStart with [[one]], then [[sub/two#Details|the details]].\n\n## Part\n\n[[private]] and [[#Part]]\n"},
+		{RelativePath: "one.md", Frontmatter: map[string]interface{}{"title": "Chapter One"},
+			Body: "Intro\n\n# Section\n\n![[images/my pic.png]] ![[images/gone.png]]\n\n```\n# not a heading\n```\n"},
+		{RelativePath: "sub/two.md", Body: "Back to [index](../index.md)\n\n###### Deep\n"},
+		{RelativePath: "private.md"},
+	}
+
+	bundler := NewExportBundler(files[:3], files, vaultPath, vaultPath)
+	markdown, err := bundler.Markdown(bundler.Order(BundleOrderPath), "My Book")
+	require.NoError(t, err)
+
+	expected := "---\ntitle: My Book\n---\n\n" +
+		"# index {#index}\n\n" +
+		"Start with [one](#one), then [the details](#sub-two).\n\n### Part\n\nprivate and [Part](#index)\n\n" +
+		"# Chapter One {#one}\n\n" +
+		"Intro\n\n## Section\n\n![my pic.png](images/my%20pic.png) gone.png\n\n```\n# not a heading\n```\n\n" +
+		"# two {#sub-two}\n\n" +
+		"Back to [index](#index)\n\n###### Deep\n"
+	assert.Equal(t, expected, markdown)
+	assert.Equal(t, []string{"images/gone.png"}, bundler.MissingAssets())
+}
+
+func TestExportBundler_Order(t *testing.T) {
+	now := time.Now()
+	files := []*vault.VaultFile{
+		{RelativePath: "a.md", Body: "[[c]]", Frontmatter: map[string]interface{}{"title": "Zebra", "created": "2024-03-01"}},
+		{RelativePath: "b.md", Body: "[[a]]", Frontmatter: map[string]interface{}{"title": "apple"}, Modified: now},
+		{RelativePath: "c.md", Body: "[[d]] [[missing]]", Frontmatter: map[string]interface{}{"created": "2024-01-01"}},
+		{RelativePath: "d.md", Body: "back to [[c]]", Frontmatter: map[string]interface{}{"created": "2024-02-01"}},
+		{RelativePath: "e.md", Body: "[[f]]", Frontmatter: map[string]interface{}{"created": "2024-02-01"}},
+		{RelativePath: "f.md", Body: "[[e]]", Frontmatter: map[string]interface{}{"created": "2024-02-01"}},
+	}
+	bundler := NewExportBundler(files, files, t.TempDir(), "")
+
+	paths := func(order string) []string {
+		var result []string
+		for _, file := range bundler.Order(order) {
+			result = append(result, file.RelativePath)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"a.md", "b.md", "c.md", "d.md", "e.md", "f.md"}, paths(BundleOrderPath))
+	assert.Equal(t, []string{"b.md", "c.md", "d.md", "e.md", "f.md", "a.md"}, paths(BundleOrderTitle))
+	// Notes without a created date fall back to their modification time
+	assert.Equal(t, []string{"c.md", "d.md", "e.md", "f.md", "a.md", "b.md"}, paths(BundleOrderCreated))
+	// b.md is the only note nothing links to; e.md and f.md only link to each other
+	assert.Equal(t, []string{"b.md", "a.md", "c.md", "d.md", "e.md", "f.md"}, paths(BundleOrderLinks))
+}
+
+func TestRenderBundle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+
+	dir := t.TempDir()
+	pandoc := filepath.Join(dir, "pandoc")
+	script := "#!/bin/sh\nout=\"\"\nfor arg; do [ \"$prev\" = --output ] && out=\"$arg\"; prev=\"$arg\"; done\n" +
+		"{ pwd; echo \"$@\"; cat; } > \"$out\"\n"
+	require.NoError(t, os.WriteFile(pandoc, []byte(script), 0755))
+
+	vaultPath := t.TempDir()
+	output := filepath.Join(dir, "out", "book.epub")
+	err := RenderBundle(context.Background(), "# Chapter\n", output, pandoc, vaultPath, []string{"--toc"})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	lines := strings.Split(string(content), "\n")
+	resolved, err := filepath.EvalSymlinks(vaultPath)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, lines[0])
+	assert.Equal(t, "--from markdown --standalone --resource-path "+vaultPath+" --output "+output+" --toc", lines[1])
+	assert.Equal(t, "# Chapter", lines[2])
+
+	failing := filepath.Join(dir, "failing")
+	require.NoError(t, os.WriteFile(failing, []byte("#!/bin/sh\necho 'pdflatex not found' >&2\nexit 47\n"), 0755))
+	err = RenderBundle(context.Background(), "", output, failing, vaultPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pdflatex not found")
+
+	err = RenderBundle(context.Background(), "", output, filepath.Join(dir, "nonexistent"), vaultPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pandoc not found")
+}
@@ -0,0 +1,233 @@
+package processor
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// GraphBundle writes the exported notes and the links between them as a
+// graph, rather than the notes themselves
+const GraphBundle = "graph"
+
+// Graph bundle formats
+const (
+	CanvasGraph BundleFormat = "canvas" // Obsidian JSON Canvas, opened as a board in the vault
+	JSONGraph   BundleFormat = "json"   // Nodes and edges, for graph tools
+)
+
+// IsValidGraphFormat checks if a graph bundle format is supported
+func IsValidGraphFormat(format string) bool {
+	return format == string(CanvasGraph) || format == string(JSONGraph)
+}
+
+// Canvas node layout, in canvas pixels. Notes are laid out in columns by
+// their distance along links from the notes nothing else links to.
+const (
+	canvasNodeWidth  = 400
+	canvasNodeHeight = 400
+	canvasColumnGap  = 200
+	canvasRowGap     = 100
+)
+
+// ExportGraph is a snapshot of the exported notes and the links between them
+type ExportGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an exported note
+type GraphNode struct {
+	ID    string   `json:"id"` // Relative path in the vault
+	Title string   `json:"title"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// GraphEdge is a note's links to another exported note
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Count  int    `json:"count"`           // Links from source to target
+	Embed  bool   `json:"embed,omitempty"` // Whether any of them embeds target
+}
+
+// Canvas is a JSON Canvas document: https://jsoncanvas.org
+type Canvas struct {
+	Nodes []CanvasNode `json:"nodes"`
+	Edges []CanvasEdge `json:"edges"`
+}
+
+// CanvasNode is a card on a canvas showing a file of the vault
+type CanvasNode struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	File   string `json:"file"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// CanvasEdge is an arrow between two cards
+type CanvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	ToNode   string `json:"toNode"`
+	Label    string `json:"label,omitempty"`
+}
+
+// BuildExportGraph returns the graph of the selected notes: a node per note
+// and an edge per pair of notes where one links to the other. Links to notes
+// outside the selection, to assets and to URLs are left out.
+func BuildExportGraph(selectedFiles, allFiles []*vault.VaultFile) ExportGraph {
+	analyzer := NewExportLinkAnalyzer(selectedFiles, allFiles)
+	tags := NewTagProcessor()
+
+	graph := ExportGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	seen := make(map[string]bool, len(selectedFiles))
+	for _, file := range selectedFiles {
+		if seen[file.RelativePath] {
+			continue
+		}
+		seen[file.RelativePath] = true
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:    file.RelativePath,
+			Title: bundleTitle(file),
+			Tags:  tags.Tags(file),
+		})
+
+		edges := make(map[string]*GraphEdge)
+		for _, link := range analyzer.extractAllLinks(file.Body) {
+			if !analyzer.parser.IsInternalLink(link.Target) {
+				continue
+			}
+			target, _, _ := splitLink(link)
+			if target == "" {
+				continue
+			}
+			resolved := analyzer.resolveTargetPath(target, file.RelativePath)
+			if resolved == file.RelativePath || !analyzer.exportedFiles[resolved] {
+				continue
+			}
+			edge, ok := edges[resolved]
+			if !ok {
+				edge = &GraphEdge{Source: file.RelativePath, Target: resolved}
+				edges[resolved] = edge
+			}
+			edge.Count++
+			edge.Embed = edge.Embed || link.Type == vault.EmbedLink
+		}
+		for _, edge := range edges {
+			graph.Edges = append(graph.Edges, *edge)
+		}
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Source != graph.Edges[j].Source {
+			return graph.Edges[i].Source < graph.Edges[j].Source
+		}
+		return graph.Edges[i].Target < graph.Edges[j].Target
+	})
+	return graph
+}
+
+// Canvas lays the graph out as a JSON Canvas. Each note is a file card in a
+// column by how many links it is from the notes nothing else in the graph
+// links to; notes only reachable through a cycle start new columns from
+// the first. Node and edge IDs come from paths, so a canvas written from the
+// same notes is the same.
+func (g ExportGraph) Canvas() Canvas {
+	outgoing := make(map[string][]string)
+	incoming := make(map[string]int)
+	for _, edge := range g.Edges {
+		outgoing[edge.Source] = append(outgoing[edge.Source], edge.Target)
+		incoming[edge.Target]++
+	}
+
+	// Breadth first from the roots, then from each note not reached yet
+	column := make(map[string]int, len(g.Nodes))
+	var order []string
+	walk := func(starts []string) {
+		queue := starts
+		for _, start := range starts {
+			column[start] = 0
+		}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			order = append(order, current)
+			for _, next := range outgoing[current] {
+				if _, ok := column[next]; !ok {
+					column[next] = column[current] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	var roots []string
+	for _, node := range g.Nodes {
+		if incoming[node.ID] == 0 {
+			roots = append(roots, node.ID)
+		}
+	}
+	walk(roots)
+	for _, node := range g.Nodes {
+		if _, ok := column[node.ID]; !ok {
+			walk([]string{node.ID})
+		}
+	}
+
+	canvas := Canvas{Nodes: []CanvasNode{}, Edges: []CanvasEdge{}}
+	rows := make(map[int]int)
+	for _, id := range order {
+		col := column[id]
+		canvas.Nodes = append(canvas.Nodes, CanvasNode{
+			ID:     canvasID(id),
+			Type:   "file",
+			File:   id,
+			X:      col * (canvasNodeWidth + canvasColumnGap),
+			Y:      rows[col] * (canvasNodeHeight + canvasRowGap),
+			Width:  canvasNodeWidth,
+			Height: canvasNodeHeight,
+		})
+		rows[col]++
+	}
+	for _, edge := range g.Edges {
+		canvasEdge := CanvasEdge{
+			ID:       canvasID(edge.Source + "\x00" + edge.Target),
+			FromNode: canvasID(edge.Source),
+			ToNode:   canvasID(edge.Target),
+		}
+		if edge.Embed {
+			canvasEdge.Label = "embeds"
+		}
+		canvas.Edges = append(canvas.Edges, canvasEdge)
+	}
+	return canvas
+}
+
+// Marshal encodes the graph in format, as indented JSON
+func (g ExportGraph) Marshal(format BundleFormat) ([]byte, error) {
+	var data []byte
+	var err error
+	if format == CanvasGraph {
+		data, err = json.MarshalIndent(g.Canvas(), "", "  ")
+	} else {
+		data, err = json.MarshalIndent(g, "", "  ")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// canvasID returns a 16 digit hex ID for a canvas node or edge, the form
+// Obsidian gives them
+func canvasID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestBuildExportGraph(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md", Frontmatter: map[string]interface{}{"title": "Start", "tags": []interface{}{"rust"}},
+			Body: "[[one]], [[sub/two#Details|details]] and ![[one]]. [[private]], [[index]], ![[img.png]], https://example.com"},
+		{RelativePath: "one.md", Body: "Next: [two](sub/two.md)"},
+		{RelativePath: "sub/two.md", Body: "Back to [[index]]"},
+		{RelativePath: "private.md", Body: "[[one]]"},
+	}
+
+	graph := BuildExportGraph(files[:3], files)
+
+	assert.Equal(t, []GraphNode{
+		{ID: "index.md", Title: "Start", Tags: []string{"rust"}},
+		{ID: "one.md", Title: "one"},
+		{ID: "sub/two.md", Title: "two"},
+	}, graph.Nodes)
+	assert.Equal(t, []GraphEdge{
+		{Source: "index.md", Target: "one.md", Count: 2, Embed: true},
+		{Source: "index.md", Target: "sub/two.md", Count: 1},
+		{Source: "one.md", Target: "sub/two.md", Count: 1},
+		{Source: "sub/two.md", Target: "index.md", Count: 1},
+	}, graph.Edges)
+}
+
+func TestExportGraph_Canvas(t *testing.T) {
+	graph := ExportGraph{
+		Nodes: []GraphNode{{ID: "a.md"}, {ID: "b.md"}, {ID: "c.md"}, {ID: "d.md"}, {ID: "e.md"}, {ID: "f.md"}},
+		Edges: []GraphEdge{
+			{Source: "a.md", Target: "b.md", Count: 1},
+			{Source: "a.md", Target: "c.md", Count: 1, Embed: true},
+			{Source: "c.md", Target: "d.md", Count: 1},
+			// e and f only link to each other
+			{Source: "e.md", Target: "f.md", Count: 1},
+			{Source: "f.md", Target: "e.md", Count: 1},
+		},
+	}
+
+	canvas := graph.Canvas()
+	positions := make(map[string][2]int)
+	ids := make(map[string]string)
+	for _, node := range canvas.Nodes {
+		assert.Equal(t, "file", node.Type)
+		assert.Len(t, node.ID, 16)
+		positions[node.File] = [2]int{node.X, node.Y}
+		ids[node.ID] = node.File
+	}
+
+	// Columns follow links from the roots; a cycle starts a column of its own
+	assert.Equal(t, map[string][2]int{
+		"a.md": {0, 0},
+		"b.md": {600, 0},
+		"c.md": {600, 500},
+		"d.md": {1200, 0},
+		"e.md": {0, 500},
+		"f.md": {600, 1000},
+	}, positions)
+
+	require.Len(t, canvas.Edges, 5)
+	assert.Equal(t, "a.md", ids[canvas.Edges[1].FromNode])
+	assert.Equal(t, "c.md", ids[canvas.Edges[1].ToNode])
+	assert.Equal(t, "embeds", canvas.Edges[1].Label)
+
+	// The same notes give the same canvas
+	first, err := graph.Marshal(CanvasGraph)
+	require.NoError(t, err)
+	second, err := graph.Marshal(CanvasGraph)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	var decoded map[string][]map[string]interface{}
+	require.NoError(t, json.Unmarshal(first, &decoded))
+	assert.Equal(t, "a.md", decoded["nodes"][0]["file"])
+	assert.Contains(t, decoded["edges"][0], "fromNode")
+}
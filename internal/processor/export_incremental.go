@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// ExportManifestFile records what an incremental export wrote; it is kept in
+// the root of the output directory
+const ExportManifestFile = ".mdnotes-export.json"
+
+// exportManifestVersion is bumped whenever manifest entries change meaning
+const exportManifestVersion = 1
+
+// ExportManifest lists the files an incremental export wrote, each with a
+// fingerprint of its content: a hash for notes and indexes, and the source's
+// size and modification time for assets
+type ExportManifest struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"` // output-relative path -> fingerprint
+}
+
+// HasExportManifest reports whether outputPath holds an incremental export
+func HasExportManifest(outputPath string) bool {
+	_, err := os.Stat(filepath.Join(outputPath, ExportManifestFile))
+	return err == nil
+}
+
+// incrementalExport decides which output files need writing by comparing
+// their fingerprints with the previous export's manifest. It is safe for
+// concurrent use by the parallel writers.
+type incrementalExport struct {
+	outputPath string
+	previous   map[string]string
+
+	mu        sync.Mutex
+	current   map[string]string
+	unchanged int
+}
+
+// loadIncrementalExport reads the manifest of the previous export to
+// outputPath; without one every file is written
+func loadIncrementalExport(outputPath string) (*incrementalExport, error) {
+	ie := &incrementalExport{
+		outputPath: outputPath,
+		previous:   make(map[string]string),
+		current:    make(map[string]string),
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputPath, ExportManifestFile))
+	if os.IsNotExist(err) {
+		return ie, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading export manifest: %w", err)
+	}
+
+	var manifest ExportManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing export manifest %s: %w", ExportManifestFile, err)
+	}
+	// Entries from another version can't be compared, so everything is rewritten
+	// but the files they list are still removed when no longer exported
+	for path, fingerprint := range manifest.Files {
+		if manifest.Version != exportManifestVersion {
+			fingerprint = ""
+		}
+		ie.previous[path] = fingerprint
+	}
+	return ie, nil
+}
+
+// contentFingerprint returns the fingerprint of a file's content
+func contentFingerprint(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// sourceFingerprint returns the fingerprint of a copied file, from the size
+// and modification time of its source
+func sourceFingerprint(info os.FileInfo) string {
+	return "stat:" + strconv.FormatInt(info.Size(), 10) + ":" + strconv.FormatInt(info.ModTime().UnixNano(), 10)
+}
+
+// needsWrite records that the export produces path with the given
+// fingerprint and reports whether it has to be written: it is new, changed
+// or missing from the output directory
+func (ie *incrementalExport) needsWrite(path, fingerprint string) bool {
+	rel, err := filepath.Rel(ie.outputPath, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+
+	ie.current[rel] = fingerprint
+	if previous, ok := ie.previous[rel]; ok && previous != "" && previous == fingerprint {
+		if _, err := os.Stat(path); err == nil {
+			ie.unchanged++
+			return false
+		}
+	}
+	return true
+}
+
+// produced reports whether this export has already written path
+func (ie *incrementalExport) produced(path string) bool {
+	rel, err := filepath.Rel(ie.outputPath, path)
+	if err != nil {
+		return false
+	}
+
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+	_, ok := ie.current[filepath.ToSlash(rel)]
+	return ok
+}
+
+// removeStale deletes the files the previous export wrote that this one
+// didn't, along with directories left empty, and returns how many it removed
+func (ie *incrementalExport) removeStale() (int, error) {
+	ie.mu.Lock()
+	defer ie.mu.Unlock()
+
+	var stale []string
+	for rel := range ie.previous {
+		if _, ok := ie.current[rel]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+
+	removed := 0
+	for _, rel := range stale {
+		// Only remove files inside the output directory, whatever the manifest says
+		if !filepath.IsLocal(filepath.FromSlash(rel)) || rel == ExportManifestFile {
+			continue
+		}
+		path := filepath.Join(ie.outputPath, filepath.FromSlash(rel))
+		if err := os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return removed, fmt.Errorf("removing %s: %w", rel, err)
+		}
+		removed++
+
+		for dir := filepath.Dir(path); dir != ie.outputPath && dir != "."; dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break // Not empty
+			}
+		}
+	}
+	return removed, nil
+}
+
+// save writes the manifest of this export
+func (ie *incrementalExport) save() error {
+	ie.mu.Lock()
+	manifest := ExportManifest{Version: exportManifestVersion, Files: ie.current}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	ie.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding export manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(ie.outputPath, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	if err := safety.WriteFile(filepath.Join(ie.outputPath, ExportManifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing export manifest: %w", err)
+	}
+	return nil
+}
+
+// writeExportFile writes a file of the export, unless an incremental export
+// already holds it with the same content
+func writeExportFile(ie *incrementalExport, path string, content []byte) error {
+	if ie != nil && !ie.needsWrite(path, contentFingerprint(content)) {
+		return nil
+	}
+	return safety.WriteFile(path, content, 0644)
+}
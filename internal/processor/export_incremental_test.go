@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportProcessor_Incremental(t *testing.T) {
+	vaultPath := t.TempDir()
+	outputPath := filepath.Join(t.TempDir(), "out")
+	write := func(rel, content string) {
+		path := filepath.Join(vaultPath, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("a.md", "# A\n\nSee [[c]]\n")
+	write("notes/b.md", "![[images/pic.png]]\n")
+	write("c.md", "# C\n")
+	write("images/pic.png", "png")
+
+	options := ExportOptions{
+		VaultPath:     vaultPath,
+		OutputPath:    outputPath,
+		ProcessLinks:  true,
+		LinkStrategy:  "remove",
+		IncludeAssets: true,
+		Incremental:   true,
+	}
+	export := func() *ExportResult {
+		result, err := NewExportProcessor(options).ProcessExport(context.Background(), options)
+		require.NoError(t, err)
+		return result
+	}
+	// Output files written before now keep their old modification time
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	age := func() {
+		for _, rel := range []string{"a.md", "c.md", "notes/b.md", "images/pic.png"} {
+			require.NoError(t, os.Chtimes(filepath.Join(outputPath, rel), past, past))
+		}
+	}
+	rewritten := func(rel string) bool {
+		info, err := os.Stat(filepath.Join(outputPath, rel))
+		require.NoError(t, err)
+		return !info.ModTime().Equal(past)
+	}
+
+	result := export()
+	assert.Equal(t, 3, result.FilesExported)
+	assert.Equal(t, 1, result.AssetsCopied)
+	assert.Zero(t, result.FilesUnchanged)
+
+	data, err := os.ReadFile(filepath.Join(outputPath, ExportManifestFile))
+	require.NoError(t, err)
+	var manifest ExportManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Len(t, manifest.Files, 4)
+	assert.Contains(t, manifest.Files, "images/pic.png")
+
+	// Nothing changed, so nothing is written
+	age()
+	result = export()
+	assert.Equal(t, 4, result.FilesUnchanged)
+	assert.Zero(t, result.FilesRemoved)
+	assert.Zero(t, result.AssetsCopied)
+	for _, rel := range []string{"a.md", "c.md", "notes/b.md", "images/pic.png"} {
+		assert.False(t, rewritten(rel), rel)
+	}
+
+	// Edited notes are rewritten and deleted notes and unused assets removed
+	age()
+	write("c.md", "# C\n\nMore\n")
+	require.NoError(t, os.Remove(filepath.Join(vaultPath, "notes/b.md")))
+	result = export()
+	assert.Equal(t, 2, result.FilesExported)
+	assert.Equal(t, 1, result.FilesUnchanged)
+	assert.Equal(t, 2, result.FilesRemoved)
+	assert.True(t, rewritten("c.md"))
+	assert.False(t, rewritten("a.md"))
+	assert.NoDirExists(t, filepath.Join(outputPath, "notes"))
+	assert.NoDirExists(t, filepath.Join(outputPath, "images"))
+
+	// Files missing from the output are written again
+	require.NoError(t, os.Remove(filepath.Join(outputPath, "a.md")))
+	result = export()
+	assert.Equal(t, 1, result.FilesUnchanged)
+	assert.FileExists(t, filepath.Join(outputPath, "a.md"))
+}
+
+func TestIncrementalExport_RemoveStaleStaysInOutput(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "out")
+	require.NoError(t, os.MkdirAll(outputPath, 0755))
+	outside := filepath.Join(dir, "keep.md")
+	require.NoError(t, os.WriteFile(outside, []byte("keep"), 0644))
+
+	manifest := `{"version": 1, "files": {"../keep.md": "sha256:x", "gone.md": "sha256:y"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(outputPath, ExportManifestFile), []byte(manifest), 0644))
+
+	ie, err := loadIncrementalExport(outputPath)
+	require.NoError(t, err)
+	removed, err := ie.removeStale()
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	assert.FileExists(t, outside)
+}
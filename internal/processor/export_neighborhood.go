@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Link directions followed when expanding an export to linked notes
+const (
+	LinkDirectionForward  = "forward"  // Notes the exported notes link to
+	LinkDirectionBackward = "backward" // Notes linking to the exported notes
+	LinkDirectionBoth     = "both"
+)
+
+// IsValidLinkDirection reports whether direction is a supported link direction
+func IsValidLinkDirection(direction string) bool {
+	switch direction {
+	case LinkDirectionForward, LinkDirectionBackward, LinkDirectionBoth:
+		return true
+	}
+	return false
+}
+
+// NeighborhoodDiscoveryResult contains the notes found within a number of
+// link hops of the exported notes
+type NeighborhoodDiscoveryResult struct {
+	LinkedFiles []*vault.VaultFile // Notes added, in the order they were reached
+	Depths      map[string]int     // Hops from the nearest exported note, by relative path
+}
+
+// DiscoverNeighborhood finds the notes reachable within depth link hops of
+// the exported notes, following links in the given direction. A depth of 0
+// follows links until no new notes are found.
+func (bh *ExportBacklinksHandler) DiscoverNeighborhood(ctx context.Context, exportedFiles []*vault.VaultFile, depth int, direction string) *NeighborhoodDiscoveryResult {
+	result := &NeighborhoodDiscoveryResult{Depths: make(map[string]int)}
+
+	byPath := make(map[string]*vault.VaultFile, len(bh.allVaultFiles))
+	for _, file := range bh.allVaultFiles {
+		byPath[file.RelativePath] = file
+	}
+	forward, backward := bh.linkGraph()
+
+	frontier := make([]string, 0, len(exportedFiles))
+	for _, file := range exportedFiles {
+		if _, seen := result.Depths[file.RelativePath]; !seen {
+			result.Depths[file.RelativePath] = 0
+			frontier = append(frontier, file.RelativePath)
+		}
+	}
+
+	for hop := 1; len(frontier) > 0 && (depth <= 0 || hop <= depth); hop++ {
+		select {
+		case <-ctx.Done():
+			return result
+		default:
+		}
+
+		var next []string
+		for _, path := range frontier {
+			var neighbors []string
+			if direction != LinkDirectionBackward {
+				neighbors = append(neighbors, forward[path]...)
+			}
+			if direction != LinkDirectionForward {
+				neighbors = append(neighbors, backward[path]...)
+			}
+
+			for _, neighbor := range neighbors {
+				if _, seen := result.Depths[neighbor]; seen {
+					continue
+				}
+				result.Depths[neighbor] = hop
+				result.LinkedFiles = append(result.LinkedFiles, byPath[neighbor])
+				next = append(next, neighbor)
+
+				if bh.verbose {
+					fmt.Printf("Found linked note (%d hops): %s\n", hop, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return result
+}
+
+// linkGraph returns the notes each note links to and the notes linking to
+// it, by relative path. Links to files outside the vault's notes are dropped.
+func (bh *ExportBacklinksHandler) linkGraph() (forward, backward map[string][]string) {
+	forward = make(map[string][]string)
+	backward = make(map[string][]string)
+	parser := NewLinkParser()
+
+	for _, file := range bh.allVaultFiles {
+		seen := make(map[string]bool)
+		for _, link := range parser.Extract(file.Body) {
+			target := bh.resolveLinkPath(link.Target, file.RelativePath)
+			if target == "" || target == file.RelativePath || seen[target] || !bh.fileExists(target) {
+				continue
+			}
+			seen[target] = true
+			forward[file.RelativePath] = append(forward[file.RelativePath], target)
+			backward[target] = append(backward[target], file.RelativePath)
+		}
+	}
+	return forward, backward
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func neighborhoodPaths(result *NeighborhoodDiscoveryResult) []string {
+	var paths []string
+	for _, file := range result.LinkedFiles {
+		paths = append(paths, file.RelativePath)
+	}
+	return paths
+}
+
+func TestBacklinksHandler_DiscoverNeighborhood(t *testing.T) {
+	// hub -> a -> b -> c, and d -> hub
+	files := []*vault.VaultFile{
+		{RelativePath: "hub.md", Body: "See [[a]] and [[hub]] and [[missing]]"},
+		{RelativePath: "notes/a.md", Body: "Next: [[b]]"},
+		{RelativePath: "notes/b.md", Body: "Then [c](c.md)"},
+		{RelativePath: "notes/c.md", Body: "End"},
+		{RelativePath: "d.md", Body: "Back to [[hub]]"},
+	}
+	handler := NewExportBacklinksHandler(files, false)
+	selected := files[:1]
+
+	tests := []struct {
+		name      string
+		depth     int
+		direction string
+		expected  []string
+	}{
+		{"one hop forward", 1, LinkDirectionForward, []string{"notes/a.md"}},
+		{"two hops forward", 2, LinkDirectionForward, []string{"notes/a.md", "notes/b.md"}},
+		{"unlimited forward", 0, LinkDirectionForward, []string{"notes/a.md", "notes/b.md", "notes/c.md"}},
+		{"backward", 2, LinkDirectionBackward, []string{"d.md"}},
+		{"both", 1, LinkDirectionBoth, []string{"notes/a.md", "d.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := handler.DiscoverNeighborhood(context.Background(), selected, tt.depth, tt.direction)
+			assert.Equal(t, tt.expected, neighborhoodPaths(result))
+		})
+	}
+
+	result := handler.DiscoverNeighborhood(context.Background(), selected, 0, LinkDirectionForward)
+	assert.Equal(t, map[string]int{"hub.md": 0, "notes/a.md": 1, "notes/b.md": 2, "notes/c.md": 3}, result.Depths)
+
+	// Notes reached from several selected notes are included once
+	result = handler.DiscoverNeighborhood(context.Background(), []*vault.VaultFile{files[1], files[3]}, 1, LinkDirectionBoth)
+	assert.Equal(t, []string{"notes/b.md", "hub.md"}, neighborhoodPaths(result))
+}
+
+func TestIsValidLinkDirection(t *testing.T) {
+	assert.True(t, IsValidLinkDirection("forward"))
+	assert.True(t, IsValidLinkDirection("both"))
+	assert.False(t, IsValidLinkDirection("sideways"))
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
 
@@ -61,6 +62,9 @@ type ExportProcessor struct {
 	scanner  *vault.Scanner
 	verbose  bool
 	progress *ExportProgressReporter
+	site     *ExportSiteConverter // Set while exporting for a site target
+
+	incremental *incrementalExport // Set while exporting incrementally
 }
 
 // ExportOptions contains configuration for export operations
@@ -75,10 +79,21 @@ type ExportOptions struct {
 	LinkStrategy    string
 	IncludeAssets   bool
 	WithBacklinks   bool
+	WithLinks       bool   // Include notes within LinkDepth link hops of the selection
+	LinkDepth       int    // Hops followed for WithLinks (0 = no limit)
+	LinkDirection   string // forward, backward or both (default forward)
 	Slugify         bool
 	Flatten         bool
-	ParallelWorkers int  // Number of parallel workers (0 = auto-detect)
-	OptimizeMemory  bool // Use memory-optimized processing
+	ParallelWorkers int      // Number of parallel workers (0 = auto-detect)
+	OptimizeMemory  bool     // Use memory-optimized processing
+	Target          string   // Static site layout: hugo, jekyll, astro or obsidian-publish (empty = plain copy)
+	Bundle          string   // "onefile" merges the notes into the document at OutputPath, "graph" writes their links there
+	BundleFormat    string   // Bundle document format: md, pdf, epub or docx, or canvas or json for graphs
+	BundleOrder     string   // Order of notes in the bundle: path, title, created or links
+	BundleTitle     string   // Title of the bundle document
+	Pandoc          string   // pandoc executable used to render bundles (default "pandoc")
+	PandocArgs      []string // Extra arguments passed to pandoc
+	Incremental     bool     // Only write files changed since the last incremental export, removing ones no longer exported
 }
 
 // ExportResult contains the results of an export operation
@@ -101,8 +116,24 @@ type ExportResult struct {
 	AssetsMissing int
 	// Backlinks statistics
 	BacklinksIncluded int
+	// Linked notes included by WithLinks
+	LinkedIncluded int
 	// Filename processing statistics
 	FilesRenamed int
+	// Section indexes generated for a site target
+	SectionIndexes int
+	// Index of published notes and site files (publish.css, publish.js,
+	// favicon.ico) written for Obsidian Publish
+	PublishIndex     string
+	PublishSiteFiles int
+	// Format of the single document written by a bundled export
+	BundleFormat string
+	// Links between notes in a graph bundle
+	GraphLinks int
+	// Incremental export statistics: output files left as they were, and
+	// files of the previous export removed because they are no longer exported
+	FilesUnchanged int
+	FilesRemoved   int
 	// Performance metrics
 	Performance *PerformanceMetrics
 }
@@ -149,7 +180,14 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 	}
 	result.FilesSelected = len(selectedFiles)
 
-	// Step 3: Expand with backlinks (if requested)
+	// Step 3: Expand with linked notes and backlinks (if requested)
+	if options.WithLinks {
+		ep.progress.StartPhase(0, "🔗 Discovering linked notes...")
+		neighborhood := ep.expandWithLinks(ctx, selectedFiles, files, options)
+		selectedFiles = append(selectedFiles, neighborhood.LinkedFiles...)
+		result.LinkedIncluded = len(neighborhood.LinkedFiles)
+		ep.progress.FinishPhase(fmt.Sprintf("✅ Added %d linked notes", result.LinkedIncluded))
+	}
 	if options.WithBacklinks {
 		ep.progress.StartPhase(0, "🔗 Discovering backlinks...")
 		backlinkResult, err := ep.expandWithBacklinks(ctx, selectedFiles, files, options)
@@ -161,6 +199,16 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 		ep.progress.FinishPhase(fmt.Sprintf("✅ Added %d backlink files", result.BacklinksIncluded))
 	}
 
+	// Merge the notes into a single document instead of copying them (if requested)
+	if options.Bundle != "" {
+		if err := ep.writeBundle(ctx, result, selectedFiles, files, options); err != nil {
+			return nil, err
+		}
+		result.Duration = time.Since(startTime)
+		result.Performance = &PerformanceMetrics{}
+		return result, nil
+	}
+
 	// Step 4: Normalize filenames (if requested)
 	var filenameMap map[string]string
 	if options.Slugify || options.Flatten {
@@ -182,15 +230,46 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 		}
 	}
 
+	// Lay out notes and assets for a static site generator (if requested)
+	ep.site = nil
+	contentOptions := options
+	if options.Target != "" {
+		profile, err := GetSiteProfile(SiteTarget(options.Target))
+		if err != nil {
+			return nil, err
+		}
+		ep.site = NewExportSiteConverter(profile, selectedFiles, files, filenameMap, options.VaultPath)
+		contentOptions.OutputPath = filepath.Join(options.OutputPath, profile.ContentDir)
+		// Obsidian Publish keeps wiki links, which the converter rewrites itself
+		if profile.Target == ObsidianPublishTarget {
+			options.ProcessLinks = false
+			contentOptions.ProcessLinks = false
+		}
+	}
+
 	// Step 5: Calculate total size and collect file paths
 	result.TotalSize = ep.calculateTotalSize(selectedFiles)
 	result.SelectedFiles = make([]string, len(selectedFiles))
 	for i, file := range selectedFiles {
 		result.SelectedFiles[i] = filenameMap[file.RelativePath] // Use normalized paths
+		if ep.site != nil {
+			result.SelectedFiles[i] = filepath.Join(ep.site.Profile().ContentDir, result.SelectedFiles[i])
+		}
 	}
 
 	// Step 6: Copy files (if not dry run)
+	ep.incremental = nil
 	if !options.DryRun {
+		if options.Incremental {
+			ep.incremental, err = loadIncrementalExport(options.OutputPath)
+			if err != nil {
+				return nil, err
+			}
+			if ep.site != nil {
+				ep.site.incremental = ep.incremental
+			}
+		}
+
 		ep.progress.StartPhase(len(selectedFiles), "📄 Copying files...")
 
 		// Determine if we should use parallel processing
@@ -200,9 +279,9 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 			// Copy files with link processing and filename normalization
 			var linkResult *LinkProcessingResult
 			if useParallel && !options.OptimizeMemory {
-				linkResult, err = ep.copyFilesWithLinkProcessingParallel(ctx, selectedFiles, files, filenameMap, options)
+				linkResult, err = ep.copyFilesWithLinkProcessingParallel(ctx, selectedFiles, files, filenameMap, contentOptions)
 			} else {
-				linkResult, err = ep.copyFilesWithLinkProcessingAndNormalization(ctx, selectedFiles, files, filenameMap, options)
+				linkResult, err = ep.copyFilesWithLinkProcessingAndNormalization(ctx, selectedFiles, files, filenameMap, contentOptions)
 			}
 			if err != nil {
 				return nil, fmt.Errorf("copying files with link processing: %w", err)
@@ -215,9 +294,9 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 		} else {
 			// Copy files with filename normalization only
 			if useParallel && !options.OptimizeMemory {
-				err = ep.copyFilesWithNormalizationParallel(ctx, selectedFiles, filenameMap, options)
+				err = ep.copyFilesWithNormalizationParallel(ctx, selectedFiles, filenameMap, contentOptions)
 			} else {
-				err = ep.copyFilesWithNormalization(ctx, selectedFiles, filenameMap, options)
+				err = ep.copyFilesWithNormalization(ctx, selectedFiles, filenameMap, contentOptions)
 			}
 			if err != nil {
 				return nil, fmt.Errorf("copying files: %w", err)
@@ -226,6 +305,20 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 		result.FilesExported = len(selectedFiles)
 		ep.progress.FinishPhase(fmt.Sprintf("✅ Copied %d files", result.FilesExported))
 
+		if ep.site != nil {
+			result.SectionIndexes, err = ep.site.WriteSectionIndexes(contentOptions.OutputPath)
+			if err != nil {
+				return nil, fmt.Errorf("writing section indexes: %w", err)
+			}
+			if ep.site.Profile().Target == ObsidianPublishTarget {
+				result.PublishSiteFiles, err = ep.site.WritePublishFiles(options.OutputPath, selectedFiles)
+				if err != nil {
+					return nil, err
+				}
+				result.PublishIndex = PublishIndexFile
+			}
+		}
+
 		// Step 7: Process assets (if requested and not dry run)
 		if options.IncludeAssets {
 			ep.progress.StartPhase(0, "🖼️ Processing assets...")
@@ -237,6 +330,18 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 			result.AssetsMissing = assetResult.AssetsMissing
 			ep.progress.FinishPhase(fmt.Sprintf("✅ Processed %d assets", result.AssetsCopied))
 		}
+
+		// Step 8: Remove what the previous incremental export wrote and this one didn't
+		if ep.incremental != nil {
+			result.FilesRemoved, err = ep.incremental.removeStale()
+			if err != nil {
+				return nil, fmt.Errorf("removing stale files: %w", err)
+			}
+			result.FilesUnchanged = ep.incremental.unchanged
+			if err := ep.incremental.save(); err != nil {
+				return nil, err
+			}
+		}
 	} else {
 		// For dry run, analyze what would be processed
 
@@ -273,6 +378,13 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 			result.AssetsCopied = assetResult.AssetsCopied
 			result.AssetsMissing = assetResult.AssetsMissing
 		}
+
+		if ep.site != nil {
+			result.SectionIndexes = len(ep.site.SectionIndexes())
+			if ep.site.Profile().Target == ObsidianPublishTarget {
+				result.PublishIndex = PublishIndexFile
+			}
+		}
 	}
 
 	result.Duration = time.Since(startTime)
@@ -290,6 +402,92 @@ func (ep *ExportProcessor) ProcessExport(ctx context.Context, options ExportOpti
 	return result, nil
 }
 
+// writeBundle merges the selected files into the document at the output
+// path, rendering it with pandoc unless the format is markdown
+func (ep *ExportProcessor) writeBundle(ctx context.Context, result *ExportResult, selectedFiles, allFiles []*vault.VaultFile, options ExportOptions) error {
+	if options.Bundle == GraphBundle {
+		return ep.writeGraph(result, selectedFiles, allFiles, options)
+	}
+	format := BundleFormat(options.BundleFormat)
+	if format == "" {
+		format = MarkdownBundle
+	}
+	// Markdown is read from where it is written; pandoc renders from the vault
+	assetDir := options.VaultPath
+	if format == MarkdownBundle {
+		assetDir = filepath.Dir(options.OutputPath)
+	}
+
+	bundler := NewExportBundler(selectedFiles, allFiles, options.VaultPath, assetDir)
+	ordered := bundler.Order(options.BundleOrder)
+	result.BundleFormat = string(format)
+	result.TotalSize = ep.calculateTotalSize(ordered)
+	result.SelectedFiles = make([]string, len(ordered))
+	for i, file := range ordered {
+		result.SelectedFiles[i] = file.RelativePath
+	}
+
+	markdown, err := bundler.Markdown(ordered, options.BundleTitle)
+	if err != nil {
+		return err
+	}
+	result.AssetsMissing = len(bundler.MissingAssets())
+	if options.DryRun {
+		return nil
+	}
+
+	ep.progress.StartPhase(0, fmt.Sprintf("📚 Bundling %d notes into %s...", len(ordered), filepath.Base(options.OutputPath)))
+	if format == MarkdownBundle {
+		if err := os.MkdirAll(filepath.Dir(options.OutputPath), 0755); err != nil {
+			return fmt.Errorf("creating output directory: %w", err)
+		}
+		if err := safety.WriteFile(options.OutputPath, []byte(markdown), 0644); err != nil {
+			return fmt.Errorf("writing bundle: %w", err)
+		}
+	} else if err := RenderBundle(ctx, markdown, options.OutputPath, options.Pandoc, assetDir, options.PandocArgs); err != nil {
+		return err
+	}
+	result.FilesExported = len(ordered)
+	ep.progress.FinishPhase(fmt.Sprintf("✅ Bundled %d notes", result.FilesExported))
+	return nil
+}
+
+// writeGraph writes the selected files and the links between them as a
+// graph to the output path
+func (ep *ExportProcessor) writeGraph(result *ExportResult, selectedFiles, allFiles []*vault.VaultFile, options ExportOptions) error {
+	format := BundleFormat(options.BundleFormat)
+	if format == "" {
+		format = CanvasGraph
+	}
+	graph := BuildExportGraph(selectedFiles, allFiles)
+	result.BundleFormat = string(format)
+	result.GraphLinks = len(graph.Edges)
+	result.SelectedFiles = make([]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		result.SelectedFiles[i] = node.ID
+	}
+
+	data, err := graph.Marshal(format)
+	if err != nil {
+		return fmt.Errorf("encoding graph: %w", err)
+	}
+	result.TotalSize = int64(len(data))
+	if options.DryRun {
+		return nil
+	}
+
+	ep.progress.StartPhase(0, fmt.Sprintf("🕸️ Writing graph of %d notes to %s...", len(graph.Nodes), filepath.Base(options.OutputPath)))
+	if err := os.MkdirAll(filepath.Dir(options.OutputPath), 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	if err := safety.WriteFile(options.OutputPath, data, 0644); err != nil {
+		return fmt.Errorf("writing graph: %w", err)
+	}
+	result.FilesExported = len(graph.Nodes)
+	ep.progress.FinishPhase(fmt.Sprintf("✅ Wrote graph of %d notes and %d links", result.FilesExported, result.GraphLinks))
+	return nil
+}
+
 // scanVaultFiles scans the vault and returns all markdown files
 func (ep *ExportProcessor) scanVaultFiles(ctx context.Context, vaultPath string) ([]*vault.VaultFile, error) {
 	var files []*vault.VaultFile
@@ -322,10 +520,11 @@ func (ep *ExportProcessor) filterFilesByQuery(files []*vault.VaultFile, queryStr
 
 	// Parse the query using the existing query engine
 	parser := query.NewParser(queryStr)
-	expression, err := parser.Parse()
+	expression, err := parser.Compile()
 	if err != nil {
 		return nil, fmt.Errorf("parsing query: %w", err)
 	}
+	query.Bind(expression, files, NewLinkParser())
 
 	var filteredFiles []*vault.VaultFile
 	for _, file := range files {
@@ -386,11 +585,36 @@ func (ep *ExportProcessor) analyzeLinkProcessing(selectedFiles, allFiles []*vaul
 
 // processAssets handles asset discovery and copying for exported files
 func (ep *ExportProcessor) processAssets(ctx context.Context, selectedFiles []*vault.VaultFile, options ExportOptions) (*AssetProcessingResult, error) {
-	// Create asset handler
-	assetHandler := NewExportAssetHandler(options.VaultPath, options.OutputPath, ep.verbose)
+	// Create asset handler, serving assets from the site's asset folder for site targets
+	assetOutput := options.OutputPath
+	if ep.site != nil {
+		assetOutput = filepath.Join(options.OutputPath, ep.site.Profile().AssetDir)
+	}
+	assetHandler := NewExportAssetHandler(options.VaultPath, assetOutput, ep.verbose)
 
 	// Discover assets referenced by exported files
 	discovery := assetHandler.DiscoverAssets(selectedFiles)
+	if ep.site != nil {
+		ep.site.FilterAssets(discovery)
+	}
+
+	// Assets whose source hasn't changed since the last incremental export
+	// aren't copied again, and linked notes keep their exported content
+	if ep.incremental != nil {
+		for assetPath := range discovery.AssetFiles {
+			if ep.incremental.produced(filepath.Join(assetOutput, assetPath)) {
+				delete(discovery.AssetFiles, assetPath)
+				continue
+			}
+			info, err := os.Stat(filepath.Join(options.VaultPath, assetPath))
+			if err != nil {
+				continue
+			}
+			if !ep.incremental.needsWrite(filepath.Join(assetOutput, assetPath), sourceFingerprint(info)) {
+				delete(discovery.AssetFiles, assetPath)
+			}
+		}
+	}
 
 	if ep.verbose && discovery.TotalAssets > 0 {
 		fmt.Printf("Found %d asset references in exported files\n", discovery.TotalAssets)
@@ -409,6 +633,9 @@ func (ep *ExportProcessor) analyzeAssetProcessing(selectedFiles []*vault.VaultFi
 
 	// Discover assets that would be copied
 	discovery := assetHandler.DiscoverAssets(selectedFiles)
+	if ep.site != nil {
+		ep.site.FilterAssets(discovery)
+	}
 
 	return &AssetProcessingResult{
 		AssetsCopied:  len(discovery.AssetFiles),
@@ -418,6 +645,23 @@ func (ep *ExportProcessor) analyzeAssetProcessing(selectedFiles []*vault.VaultFi
 	}
 }
 
+// expandWithLinks finds the notes within options.LinkDepth hops of the selected files
+func (ep *ExportProcessor) expandWithLinks(ctx context.Context, selectedFiles, allFiles []*vault.VaultFile, options ExportOptions) *NeighborhoodDiscoveryResult {
+	direction := options.LinkDirection
+	if direction == "" {
+		direction = LinkDirectionForward
+	}
+
+	handler := NewExportBacklinksHandler(allFiles, ep.verbose)
+	result := handler.DiscoverNeighborhood(ctx, selectedFiles, options.LinkDepth, direction)
+
+	if ep.verbose && len(result.LinkedFiles) > 0 {
+		fmt.Printf("Found %d linked notes\n", len(result.LinkedFiles))
+	}
+
+	return result
+}
+
 // expandWithBacklinks finds and includes files that link to exported files
 func (ep *ExportProcessor) expandWithBacklinks(ctx context.Context, selectedFiles, allFiles []*vault.VaultFile, options ExportOptions) (*BacklinksDiscoveryResult, error) {
 	// Create backlinks handler
@@ -473,7 +717,7 @@ func (ep *ExportProcessor) copyFilesWithNormalization(ctx context.Context, files
 
 		// Update links in file content if filename normalization occurred
 		content := file.Body
-		if filenameMap[file.RelativePath] != file.RelativePath {
+		if ep.site == nil && filenameMap[file.RelativePath] != file.RelativePath {
 			normalizer := NewExportFilenameNormalizer(FilenameNormalizationOptions{
 				Slugify: options.Slugify,
 				Flatten: options.Flatten,
@@ -531,7 +775,7 @@ func (ep *ExportProcessor) copyFilesWithLinkProcessingAndNormalization(ctx conte
 
 		// Update links for filename normalization
 		processedContent := linkResult.RewrittenContent
-		if filenameMap[file.RelativePath] != file.RelativePath {
+		if ep.site == nil && filenameMap[file.RelativePath] != file.RelativePath {
 			normalizer := NewExportFilenameNormalizer(FilenameNormalizationOptions{
 				Slugify: options.Slugify,
 				Flatten: options.Flatten,
@@ -584,14 +828,21 @@ func (ep *ExportProcessor) writeNormalizedFile(processedBody string, originalFil
 		Modified:     originalFile.Modified,
 	}
 
+	// Rewrite frontmatter and links for the site generator; links are
+	// resolved against the original paths, so normalization leaves them alone
+	if ep.site != nil {
+		processedFile.Frontmatter = ep.site.ConvertFrontmatter(originalFile)
+		processedFile.Body = ep.site.ConvertBody(originalFile, processedBody)
+	}
+
 	// Serialize the file (this will include frontmatter + processed body)
 	content, err := processedFile.Serialize()
 	if err != nil {
 		return fmt.Errorf("serializing processed file: %w", err)
 	}
 
-	// Write to output file
-	err = os.WriteFile(outputPath, content, 0644)
+	// Write to output file, skipping files an incremental export already has
+	err = writeExportFile(ep.incremental, outputPath, content)
 	if err != nil {
 		return fmt.Errorf("writing file: %w", err)
 	}
@@ -613,7 +864,7 @@ func (ep *ExportProcessor) copyFilesWithNormalizationParallel(ctx context.Contex
 
 		// Update links in file content if filename normalization occurred
 		content := file.Body
-		if filenameMap[file.RelativePath] != file.RelativePath {
+		if ep.site == nil && filenameMap[file.RelativePath] != file.RelativePath {
 			normalizer := NewExportFilenameNormalizer(FilenameNormalizationOptions{
 				Slugify: opts.Slugify,
 				Flatten: opts.Flatten,
@@ -660,7 +911,7 @@ func (ep *ExportProcessor) copyFilesWithLinkProcessingParallel(ctx context.Conte
 
 		// Update links for filename normalization
 		processedContent := linkResult.RewrittenContent
-		if filenameMap[file.RelativePath] != file.RelativePath {
+		if ep.site == nil && filenameMap[file.RelativePath] != file.RelativePath {
 			normalizer := NewExportFilenameNormalizer(FilenameNormalizationOptions{
 				Slugify: opts.Slugify,
 				Flatten: opts.Flatten,
@@ -0,0 +1,215 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultPublishQuery selects the notes of an Obsidian Publish export when no
+// query is given, matching the property Obsidian Publish itself uses
+const DefaultPublishQuery = "publish = true"
+
+// PublishIndexFile is the index of published notes written at the root of an
+// Obsidian Publish export
+const PublishIndexFile = "publish-index.json"
+
+// publishSiteFiles customize an Obsidian Publish site from the vault root and
+// are copied when they exist
+var publishSiteFiles = []string{"publish.css", "publish.js", "favicon.ico"}
+
+// PublishIndex lists the notes of an Obsidian Publish export, for sitemaps
+// and for checking what a push will publish
+type PublishIndex struct {
+	Notes []PublishedNote `json:"notes"`
+}
+
+// PublishedNote is a note in the publish index
+type PublishedNote struct {
+	Path        string    `json:"path"` // Relative to the export root
+	URL         string    `json:"url"`  // Path on the site, from the permalink field when set
+	Title       string    `json:"title"`
+	Aliases     []string  `json:"aliases,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Modified    time.Time `json:"modified"`
+	Links       []string  `json:"links"` // Published notes linked to, by path
+}
+
+// publishLink returns the replacement for a link in an Obsidian Publish
+// export, if it needs one. Links stay wiki links: links to published notes
+// are only rewritten when the note was renamed, links to assets point at the
+// asset's path in the vault so they resolve wherever the note is, and links
+// to unpublished notes become plain text.
+func (sc *ExportSiteConverter) publishLink(link vault.Link, file *vault.VaultFile) (string, bool) {
+	target, fragment, text := splitLink(link)
+	if target == "" {
+		return "", false
+	}
+
+	if sc.analyzer.isAssetFile(target) || (filepath.Ext(target) != "" && filepath.Ext(target) != ".md") {
+		assetPath := sc.assets.resolveAssetPath(target, file.RelativePath)
+		if assetPath == "" {
+			return "", false
+		}
+		assetPath = filepath.ToSlash(assetPath)
+		switch link.Type {
+		case vault.MarkdownLink:
+			return fmt.Sprintf("[%s](%s)", link.Text, escapeURLPath(assetPath)), true
+		case vault.EmbedLink:
+			// Keep embed sizes, as in ![[image.png|300]]
+			if idx := strings.Index(link.Target, "|"); idx != -1 {
+				assetPath += link.Target[idx:]
+			}
+			return "![[" + assetPath + "]]", true
+		default:
+			return wikiLink(assetPath, link), true
+		}
+	}
+
+	resolved := sc.analyzer.resolveTargetPath(target, file.RelativePath)
+	exported, ok := sc.filenameMap[resolved]
+	if !ok || !sc.analyzer.exportedFiles[resolved] {
+		return text, true
+	}
+	if exported == resolved {
+		return "", false
+	}
+
+	exported = filepath.ToSlash(exported)
+	if fragment != "" {
+		fragment = "#" + fragment
+	}
+	switch link.Type {
+	case vault.MarkdownLink:
+		return fmt.Sprintf("[%s](%s%s)", link.Text, escapeURLPath(exported), fragment), true
+	case vault.EmbedLink:
+		return "![[" + strings.TrimSuffix(exported, ".md") + fragment + "]]", true
+	default:
+		return wikiLink(strings.TrimSuffix(exported, ".md")+fragment, link), true
+	}
+}
+
+// wikiLink returns a wiki link to target, keeping the alias of link
+func wikiLink(target string, link vault.Link) string {
+	if link.Text != "" && link.Text != link.Target {
+		return "[[" + target + "|" + link.Text + "]]"
+	}
+	return "[[" + target + "]]"
+}
+
+// PublishIndex builds the index of the exported notes, ordered by path
+func (sc *ExportSiteConverter) PublishIndex(files []*vault.VaultFile) PublishIndex {
+	tags := NewTagProcessor()
+	index := PublishIndex{Notes: []PublishedNote{}}
+	for _, file := range files {
+		exported, ok := sc.filenameMap[file.RelativePath]
+		if !ok {
+			continue
+		}
+		exported = filepath.ToSlash(exported)
+
+		note := PublishedNote{
+			Path:     exported,
+			URL:      publishURL(exported, file),
+			Title:    strings.TrimSuffix(path.Base(exported), ".md"),
+			Aliases:  fileAliases(file),
+			Tags:     tags.Tags(file),
+			Modified: file.Modified,
+			Links:    sc.publishedLinks(file),
+		}
+		if title, ok := file.Frontmatter["title"].(string); ok && title != "" {
+			note.Title = title
+		}
+		if description, ok := file.Frontmatter["description"].(string); ok {
+			note.Description = description
+		}
+		index.Notes = append(index.Notes, note)
+	}
+
+	sort.Slice(index.Notes, func(i, j int) bool {
+		return index.Notes[i].Path < index.Notes[j].Path
+	})
+	return index
+}
+
+// publishedLinks returns the exported paths of the published notes file
+// links to
+func (sc *ExportSiteConverter) publishedLinks(file *vault.VaultFile) []string {
+	links := []string{}
+	seen := make(map[string]bool)
+	for _, link := range sc.analyzer.extractAllLinks(file.Body) {
+		if !sc.analyzer.parser.IsInternalLink(link.Target) {
+			continue
+		}
+		target, _, _ := splitLink(link)
+		if target == "" {
+			continue
+		}
+		resolved := sc.analyzer.resolveTargetPath(target, file.RelativePath)
+		exported, ok := sc.filenameMap[resolved]
+		if !ok || !sc.analyzer.exportedFiles[resolved] || resolved == file.RelativePath {
+			continue
+		}
+		exported = filepath.ToSlash(exported)
+		if !seen[exported] {
+			seen[exported] = true
+			links = append(links, exported)
+		}
+	}
+	sort.Strings(links)
+	return links
+}
+
+// publishURL returns a note's path on an Obsidian Publish site: its permalink
+// field, or its path without the extension and with spaces as plus signs
+func publishURL(exportedPath string, file *vault.VaultFile) string {
+	if permalink, ok := file.Frontmatter["permalink"].(string); ok && strings.Trim(permalink, "/") != "" {
+		return "/" + strings.Trim(permalink, "/")
+	}
+	segments := strings.Split(strings.TrimSuffix(exportedPath, ".md"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(strings.ReplaceAll(segment, " ", "+"))
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// WritePublishFiles writes the publish index to the export root and copies
+// the site's publish.css, publish.js and favicon from the vault root. It
+// returns the number of site files copied.
+func (sc *ExportSiteConverter) WritePublishFiles(outputPath string, files []*vault.VaultFile) (int, error) {
+	data, err := json.MarshalIndent(sc.PublishIndex(files), "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("marshaling publish index: %w", err)
+	}
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return 0, fmt.Errorf("creating output directory: %w", err)
+	}
+	if err := writeExportFile(sc.incremental, filepath.Join(outputPath, PublishIndexFile), append(data, '\n')); err != nil {
+		return 0, fmt.Errorf("writing publish index: %w", err)
+	}
+
+	copied := 0
+	for _, name := range publishSiteFiles {
+		content, err := os.ReadFile(filepath.Join(sc.assets.vaultPath, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return copied, fmt.Errorf("reading %s: %w", name, err)
+		}
+		if err := writeExportFile(sc.incremental, filepath.Join(outputPath, name), content); err != nil {
+			return copied, fmt.Errorf("writing %s: %w", name, err)
+		}
+		copied++
+	}
+	return copied, nil
+}
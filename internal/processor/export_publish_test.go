@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestExportSiteConverter_PublishLinks(t *testing.T) {
+	body := "See [[First Post]], [[second#Some Heading|the second]] and [[private]].\n" +
+		"Also [first](posts/First%20Post.md), [[#Local Part]] and [web](https://example.com).\n" +
+		"![[my diagram.png|300]] ![alt](../images/my%20diagram.png)\n"
+
+	// Links to published notes and the note's own headings are kept
+	converter, files := newTestSiteConverter(t, ObsidianPublishTarget)
+	assert.Equal(t, "See [[First Post]], [[second#Some Heading|the second]] and private.\n"+
+		"Also [first](posts/First%20Post.md), [[#Local Part]] and [web](https://example.com).\n"+
+		"![[images/my diagram.png|300]] ![alt](images/my%20diagram.png)\n",
+		converter.ConvertBody(&vault.VaultFile{RelativePath: "images/index.md"}, body))
+
+	// Links to renamed notes follow them
+	converter.filenameMap["posts/First Post.md"] = "posts/first-post.md"
+	assert.Equal(t, "See [[posts/first-post]], [[second#Some Heading|the second]] and private.\n"+
+		"Also [first](posts/first-post.md), [[#Local Part]] and [web](https://example.com).\n"+
+		"![[images/my diagram.png|300]] ![alt](images/my%20diagram.png)\n",
+		converter.ConvertBody(&vault.VaultFile{RelativePath: "images/index.md"}, body))
+
+	// Notes keep their frontmatter as it is
+	assert.Equal(t, files[1].Frontmatter, converter.ConvertFrontmatter(files[1]))
+	assert.Empty(t, converter.SectionIndexes())
+}
+
+func TestExportSiteConverter_WritePublishFiles(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md", Modified: modified, Body: "Start with [[Big Idea]] and [[private]] #intro",
+			Frontmatter: map[string]interface{}{"publish": true, "description": "Welcome"}},
+		{RelativePath: "ideas/Big Idea.md", Modified: modified, Body: "Back [[index]]",
+			Frontmatter: map[string]interface{}{"title": "The Big Idea", "aliases": []interface{}{"idea"}, "tags": []interface{}{"ideas"}}},
+		{RelativePath: "about.md", Modified: modified, Frontmatter: map[string]interface{}{"permalink": "/about-me/"}},
+		{RelativePath: "private.md"},
+	}
+	filenameMap := map[string]string{"index.md": "index.md", "ideas/Big Idea.md": "ideas/Big Idea.md", "about.md": "about.md"}
+
+	vaultPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(vaultPath, "publish.css"), []byte("body {}"), 0644))
+	profile, err := GetSiteProfile(ObsidianPublishTarget)
+	require.NoError(t, err)
+	converter := NewExportSiteConverter(profile, files[:3], files, filenameMap, vaultPath)
+
+	output := t.TempDir()
+	copied, err := converter.WritePublishFiles(output, files[:3])
+	require.NoError(t, err)
+	assert.Equal(t, 1, copied)
+	assert.FileExists(t, filepath.Join(output, "publish.css"))
+
+	data, err := os.ReadFile(filepath.Join(output, PublishIndexFile))
+	require.NoError(t, err)
+	var index PublishIndex
+	require.NoError(t, json.Unmarshal(data, &index))
+	assert.Equal(t, []PublishedNote{
+		{Path: "about.md", URL: "/about-me", Title: "about", Modified: modified, Links: []string{}},
+		{Path: "ideas/Big Idea.md", URL: "/ideas/Big+Idea", Title: "The Big Idea", Aliases: []string{"idea"},
+			Tags: []string{"ideas"}, Modified: modified, Links: []string{"index.md"}},
+		{Path: "index.md", URL: "/index", Title: "index", Tags: []string{"intro"}, Description: "Welcome",
+			Modified: modified, Links: []string{"ideas/Big Idea.md"}},
+	}, index.Notes)
+}
@@ -0,0 +1,355 @@
+package processor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// SiteTarget represents a static site generator an export can be laid out for
+type SiteTarget string
+
+const (
+	HugoTarget            SiteTarget = "hugo"
+	JekyllTarget          SiteTarget = "jekyll"
+	AstroTarget           SiteTarget = "astro"
+	ObsidianPublishTarget SiteTarget = "obsidian-publish"
+)
+
+// SiteProfile describes where a static site generator expects content and
+// how it names frontmatter fields
+type SiteProfile struct {
+	Target     SiteTarget
+	ContentDir string            // Notes, relative to the export root
+	AssetDir   string            // Assets, relative to the export root
+	AssetURL   string            // URL prefix AssetDir is served from
+	IndexFile  string            // Section index file name ("" for none)
+	Fields     map[string]string // Frontmatter fields renamed for the generator
+}
+
+var siteProfiles = map[SiteTarget]SiteProfile{
+	HugoTarget: {
+		Target:     HugoTarget,
+		ContentDir: "content",
+		AssetDir:   "static",
+		AssetURL:   "/",
+		IndexFile:  "_index.md",
+		Fields:     map[string]string{"created": "date", "modified": "lastmod"},
+	},
+	JekyllTarget: {
+		Target:     JekyllTarget,
+		ContentDir: "",
+		AssetDir:   "assets",
+		AssetURL:   "/assets/",
+		IndexFile:  "index.md",
+		Fields:     map[string]string{"created": "date", "modified": "last_modified_at"},
+	},
+	AstroTarget: {
+		Target:     AstroTarget,
+		ContentDir: filepath.Join("src", "pages"),
+		AssetDir:   "public",
+		AssetURL:   "/",
+		IndexFile:  "index.md",
+		Fields:     map[string]string{"created": "pubDate", "modified": "updatedDate"},
+	},
+	// Obsidian Publish reads a vault as it is: notes and assets keep their
+	// paths and wiki links
+	ObsidianPublishTarget: {
+		Target: ObsidianPublishTarget,
+	},
+}
+
+// GetSiteTargets returns all supported site targets
+func GetSiteTargets() []SiteTarget {
+	return []SiteTarget{HugoTarget, JekyllTarget, AstroTarget, ObsidianPublishTarget}
+}
+
+// IsValidSiteTarget checks if a site target is supported
+func IsValidSiteTarget(target string) bool {
+	_, ok := siteProfiles[SiteTarget(target)]
+	return ok
+}
+
+// GetSiteProfile returns the layout of a site target
+func GetSiteProfile(target SiteTarget) (SiteProfile, error) {
+	profile, ok := siteProfiles[target]
+	if !ok {
+		return SiteProfile{}, fmt.Errorf("unknown site target '%s'", target)
+	}
+	return profile, nil
+}
+
+// ExportSiteConverter rewrites exported notes for a static site generator:
+// frontmatter fields are renamed, links between notes become the generator's
+// references and links to assets point where the assets are served from
+type ExportSiteConverter struct {
+	profile     SiteProfile
+	analyzer    *ExportLinkAnalyzer
+	assets      *ExportAssetHandler
+	filenameMap map[string]string  // original relative path -> exported relative path
+	incremental *incrementalExport // Set by an incremental export to skip unchanged indexes
+}
+
+// NewExportSiteConverter creates a converter for the selected files, whose
+// exported paths (relative to the content directory) are given by filenameMap
+func NewExportSiteConverter(profile SiteProfile, selectedFiles, allFiles []*vault.VaultFile, filenameMap map[string]string, vaultPath string) *ExportSiteConverter {
+	return &ExportSiteConverter{
+		profile:     profile,
+		analyzer:    NewExportLinkAnalyzer(selectedFiles, allFiles),
+		assets:      NewExportAssetHandler(vaultPath, "", false),
+		filenameMap: filenameMap,
+	}
+}
+
+// Profile returns the converter's site profile
+func (sc *ExportSiteConverter) Profile() SiteProfile {
+	return sc.profile
+}
+
+// ConvertFrontmatter returns a copy of a note's frontmatter with fields renamed
+// for the generator and a title taken from the filename when it has none.
+// Fields are not renamed over ones the note already has.
+func (sc *ExportSiteConverter) ConvertFrontmatter(file *vault.VaultFile) map[string]interface{} {
+	frontmatter := make(map[string]interface{}, len(file.Frontmatter)+1)
+	for key, value := range file.Frontmatter {
+		frontmatter[key] = value
+	}
+
+	for from, to := range sc.profile.Fields {
+		value, ok := frontmatter[from]
+		if !ok {
+			continue
+		}
+		if _, exists := frontmatter[to]; exists {
+			continue
+		}
+		frontmatter[to] = value
+		delete(frontmatter, from)
+	}
+
+	// Obsidian Publish titles notes by their filename
+	if _, ok := frontmatter["title"]; !ok && sc.profile.Target != ObsidianPublishTarget {
+		frontmatter["title"] = strings.TrimSuffix(filepath.Base(file.RelativePath), filepath.Ext(file.RelativePath))
+	}
+	return frontmatter
+}
+
+// ConvertBody rewrites the links in a note's body. Links to exported notes
+// become references, links to assets point to AssetDir, and links to notes
+// outside the export become plain text.
+func (sc *ExportSiteConverter) ConvertBody(file *vault.VaultFile, body string) string {
+	links := sc.analyzer.extractAllLinks(body)
+
+	// Replace in reverse order to keep positions valid
+	for i := len(links) - 1; i >= 0; i-- {
+		link := links[i]
+		replacement, ok := sc.convertLink(link, file)
+		if !ok {
+			continue
+		}
+		start := link.Position.Start
+		end := link.Position.End
+		body = body[:start] + replacement + body[end:]
+	}
+	return body
+}
+
+// convertLink returns the replacement for a link, if it needs one
+func (sc *ExportSiteConverter) convertLink(link vault.Link, file *vault.VaultFile) (string, bool) {
+	if !sc.analyzer.parser.IsInternalLink(link.Target) {
+		return "", false
+	}
+	if sc.profile.Target == ObsidianPublishTarget {
+		return sc.publishLink(link, file)
+	}
+
+	target, fragment, text := splitLink(link)
+	if target == "" {
+		// Links within the note only need a generator-style anchor
+		if link.Type == vault.MarkdownLink {
+			return "", false
+		}
+		return fmt.Sprintf("[%s](#%s)", text, headingAnchor(fragment)), true
+	}
+
+	if sc.analyzer.isAssetFile(target) || (filepath.Ext(target) != "" && filepath.Ext(target) != ".md") {
+		assetPath := sc.assets.resolveAssetPath(target, file.RelativePath)
+		if assetPath == "" {
+			return "", false
+		}
+		assetURL := sc.profile.AssetURL + escapeURLPath(filepath.ToSlash(assetPath))
+		if link.Type == vault.EmbedLink && isImageFile(assetPath) {
+			return fmt.Sprintf("![%s](%s)", text, assetURL), true
+		}
+		return fmt.Sprintf("[%s](%s)", text, assetURL), true
+	}
+
+	resolved := sc.analyzer.resolveTargetPath(target, file.RelativePath)
+	exported, ok := sc.filenameMap[resolved]
+	if !ok || !sc.analyzer.exportedFiles[resolved] {
+		return text, true
+	}
+	return fmt.Sprintf("[%s](%s)", text, sc.reference(exported, fragment)), true
+}
+
+// splitLink returns a link's target path, its heading or block fragment and
+// the text to show for it. Wiki links without an alias are shown as the note
+// name, followed by the heading they point to.
+func splitLink(link vault.Link) (target, fragment, text string) {
+	target = link.Target
+	if link.Type == vault.EmbedLink {
+		// Drop embed sizes, as in ![[image.png|300]]
+		if idx := strings.Index(target, "|"); idx != -1 {
+			target = target[:idx]
+		}
+	}
+	if idx := strings.Index(target, "#"); idx != -1 {
+		target, fragment = target[:idx], target[idx+1:]
+	}
+	if link.Type == vault.MarkdownLink {
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+	}
+
+	text = link.Text
+	if link.Type != vault.MarkdownLink && (text == "" || text == link.Target || link.Type == vault.EmbedLink) {
+		switch {
+		case target == "":
+			text = fragment
+		case fragment != "" && !strings.HasPrefix(fragment, "^"):
+			text = path.Base(strings.TrimSuffix(target, ".md")) + " > " + fragment
+		default:
+			text = path.Base(strings.TrimSuffix(target, ".md"))
+		}
+	}
+	return target, fragment, text
+}
+
+// reference returns the generator's reference to an exported note
+func (sc *ExportSiteConverter) reference(exportedPath, fragment string) string {
+	notePath := filepath.ToSlash(exportedPath)
+	anchor := ""
+	if fragment != "" && !strings.HasPrefix(fragment, "^") {
+		anchor = "#" + headingAnchor(fragment)
+	}
+
+	switch sc.profile.Target {
+	case HugoTarget:
+		return fmt.Sprintf(`{{< ref "%s%s" >}}`, notePath, anchor)
+	case JekyllTarget:
+		return fmt.Sprintf("{%% link %s %%}%s", notePath, anchor)
+	default:
+		route := strings.TrimSuffix(notePath, ".md")
+		if path.Base(route) == "index" {
+			route = path.Dir(route)
+		}
+		if route == "." {
+			return "/" + anchor
+		}
+		return "/" + escapeURLPath(route) + "/" + anchor
+	}
+}
+
+// SectionIndexes returns the section index notes to generate: one for each
+// folder of exported notes that doesn't already have one, titled after the
+// folder. Paths are relative to the content directory.
+func (sc *ExportSiteConverter) SectionIndexes() []*vault.VaultFile {
+	if sc.profile.IndexFile == "" {
+		return nil
+	}
+	exported := make(map[string]bool, len(sc.filenameMap))
+	dirs := make(map[string]bool)
+	for original, exportedPath := range sc.filenameMap {
+		if !sc.analyzer.exportedFiles[original] {
+			continue
+		}
+		exported[filepath.ToSlash(exportedPath)] = true
+		for dir := path.Dir(filepath.ToSlash(exportedPath)); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	var indexes []*vault.VaultFile
+	for dir := range dirs {
+		indexPath := path.Join(dir, sc.profile.IndexFile)
+		if exported[indexPath] {
+			continue
+		}
+		indexes = append(indexes, &vault.VaultFile{
+			RelativePath: filepath.FromSlash(indexPath),
+			Frontmatter:  map[string]interface{}{"title": path.Base(dir)},
+		})
+	}
+
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].RelativePath < indexes[j].RelativePath
+	})
+	return indexes
+}
+
+// WriteSectionIndexes writes the section indexes under contentPath
+func (sc *ExportSiteConverter) WriteSectionIndexes(contentPath string) (int, error) {
+	indexes := sc.SectionIndexes()
+	for _, index := range indexes {
+		content, err := index.Serialize()
+		if err != nil {
+			return 0, fmt.Errorf("serializing section index %s: %w", index.RelativePath, err)
+		}
+		outputPath := filepath.Join(contentPath, index.RelativePath)
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return 0, fmt.Errorf("creating section directory: %w", err)
+		}
+		if err := writeExportFile(sc.incremental, outputPath, content); err != nil {
+			return 0, fmt.Errorf("writing section index %s: %w", index.RelativePath, err)
+		}
+	}
+	return len(indexes), nil
+}
+
+// FilterAssets drops notes from discovered assets; links to them become
+// references rather than copies in the asset folder
+func (sc *ExportSiteConverter) FilterAssets(discovery *AssetDiscoveryResult) {
+	for assetPath := range discovery.AssetFiles {
+		if strings.EqualFold(filepath.Ext(assetPath), ".md") {
+			delete(discovery.AssetFiles, assetPath)
+		}
+	}
+	missing := discovery.MissingAssets[:0]
+	for _, assetPath := range discovery.MissingAssets {
+		if !strings.EqualFold(filepath.Ext(assetPath), ".md") {
+			missing = append(missing, assetPath)
+		}
+	}
+	discovery.MissingAssets = missing
+}
+
+// headingAnchor converts a heading to the id generators give it
+func headingAnchor(heading string) string {
+	return templates.Slugify(heading)
+}
+
+// escapeURLPath escapes each segment of a slash-separated path for use in a URL
+func escapeURLPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// isImageFile reports whether a path is an image that can be embedded
+func isImageFile(p string) bool {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".bmp", ".tiff":
+		return true
+	}
+	return false
+}
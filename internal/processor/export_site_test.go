@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func newTestSiteConverter(t *testing.T, target SiteTarget) (*ExportSiteConverter, []*vault.VaultFile) {
+	vaultPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(vaultPath, "images"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(vaultPath, "images", "my diagram.png"), []byte("png"), 0644))
+
+	files := []*vault.VaultFile{
+		{RelativePath: "index.md", Body: "Home"},
+		{RelativePath: "posts/First Post.md", Frontmatter: map[string]interface{}{"created": "2024-01-01", "modified": "2024-02-01"}},
+		{RelativePath: "posts/deep/second.md", Frontmatter: map[string]interface{}{"title": "Second", "created": "2024-01-01", "date": "2023-12-31"}},
+		{RelativePath: "private.md"},
+	}
+	filenameMap := map[string]string{
+		"index.md":             "index.md",
+		"posts/First Post.md":  "posts/First Post.md",
+		"posts/deep/second.md": "posts/deep/second.md",
+	}
+
+	profile, err := GetSiteProfile(target)
+	require.NoError(t, err)
+	return NewExportSiteConverter(profile, files[:3], files, filenameMap, vaultPath), files
+}
+
+func TestExportSiteConverter_ConvertBody(t *testing.T) {
+	body := "See [[First Post]], [[second#Some Heading|the second]] and [[private]].\n" +
+		"Also [first](posts/First%20Post.md), [[#Local Part]] and [web](https://example.com).\n" +
+		"![[images/my diagram.png]] ![alt](images/my%20diagram.png)\n"
+
+	tests := []struct {
+		target   SiteTarget
+		expected string
+	}{
+		{HugoTarget, "See [First Post]({{< ref \"posts/First Post.md\" >}}), [the second]({{< ref \"posts/deep/second.md#some-heading\" >}}) and private.\n" +
+			"Also [first]({{< ref \"posts/First Post.md\" >}}), [Local Part](#local-part) and [web](https://example.com).\n" +
+			"![my diagram.png](/images/my%20diagram.png) ![alt](/images/my%20diagram.png)\n"},
+		{JekyllTarget, "See [First Post]({% link posts/First Post.md %}), [the second]({% link posts/deep/second.md %}#some-heading) and private.\n" +
+			"Also [first]({% link posts/First Post.md %}), [Local Part](#local-part) and [web](https://example.com).\n" +
+			"![my diagram.png](/assets/images/my%20diagram.png) ![alt](/assets/images/my%20diagram.png)\n"},
+		{AstroTarget, "See [First Post](/posts/First%20Post/), [the second](/posts/deep/second/#some-heading) and private.\n" +
+			"Also [first](/posts/First%20Post/), [Local Part](#local-part) and [web](https://example.com).\n" +
+			"![my diagram.png](/images/my%20diagram.png) ![alt](/images/my%20diagram.png)\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.target), func(t *testing.T) {
+			converter, files := newTestSiteConverter(t, tt.target)
+			assert.Equal(t, tt.expected, converter.ConvertBody(files[0], body))
+		})
+	}
+}
+
+func TestExportSiteConverter_ConvertFrontmatter(t *testing.T) {
+	converter, files := newTestSiteConverter(t, HugoTarget)
+
+	assert.Equal(t, map[string]interface{}{
+		"date":    "2024-01-01",
+		"lastmod": "2024-02-01",
+		"title":   "First Post",
+	}, converter.ConvertFrontmatter(files[1]))
+
+	// Existing fields are not overwritten
+	assert.Equal(t, map[string]interface{}{
+		"title":   "Second",
+		"created": "2024-01-01",
+		"date":    "2023-12-31",
+	}, converter.ConvertFrontmatter(files[2]))
+
+	// The note itself is left alone
+	assert.Contains(t, files[1].Frontmatter, "created")
+
+	converter, _ = newTestSiteConverter(t, AstroTarget)
+	assert.Equal(t, "2024-01-01", converter.ConvertFrontmatter(files[1])["pubDate"])
+}
+
+func TestExportSiteConverter_SectionIndexes(t *testing.T) {
+	converter, _ := newTestSiteConverter(t, HugoTarget)
+
+	var paths []string
+	for _, index := range converter.SectionIndexes() {
+		paths = append(paths, filepath.ToSlash(index.RelativePath))
+	}
+	assert.Equal(t, []string{"posts/_index.md", "posts/deep/_index.md"}, paths)
+	assert.Equal(t, "deep", converter.SectionIndexes()[1].Frontmatter["title"])
+
+	output := t.TempDir()
+	count, err := converter.WriteSectionIndexes(output)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	content, err := os.ReadFile(filepath.Join(output, "posts", "_index.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: posts\n---\n", string(content))
+}
+
+func TestIsValidSiteTarget(t *testing.T) {
+	for _, target := range GetSiteTargets() {
+		assert.True(t, IsValidSiteTarget(string(target)))
+	}
+	assert.False(t, IsValidSiteTarget("gatsby"))
+	assert.False(t, IsValidSiteTarget(""))
+}
@@ -2,8 +2,8 @@ package processor
 
 import (
 	"fmt"
-	"os"
 
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/selector"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
@@ -20,8 +20,15 @@ type FileProcessor struct {
 
 	// Callbacks
 	ProcessFile     func(file *vault.VaultFile) (modified bool, err error)
+	SkipFile        func(file *vault.VaultFile) (reason string) // Reports why a file is left untouched, or ""
 	OnFileProcessed func(file *vault.VaultFile, modified bool)
 	OnProgress      func(current, total int, filename string)
+
+	// Serialize produces the bytes written for a modified file (default: file.Serialize)
+	Serialize func(file *vault.VaultFile) ([]byte, error)
+
+	// Transaction journals the original content of written files for undo
+	Transaction *safety.Transaction
 }
 
 // ProcessResult contains the results of a file processing operation
@@ -29,9 +36,17 @@ type ProcessResult struct {
 	TotalFiles     int
 	ProcessedFiles int
 	Errors         []error
+	Skipped        []SkippedFile             // Files skipped without processing
 	Selection      *selector.SelectionResult // Information about file selection
 }
 
+// SkippedFile records a file that was deliberately left untouched
+type SkippedFile struct {
+	Path   string
+	Reason string
+	Locked bool // Skipped for a do-not-modify marker rather than by SkipFile
+}
+
 // ProcessPath processes files at the given path using the configured selection mode
 func (fp *FileProcessor) ProcessPath(path string) (*ProcessResult, error) {
 	// Create file selector with processor settings
@@ -86,6 +101,24 @@ func (fp *FileProcessor) ProcessPath(path string) (*ProcessResult, error) {
 			fp.OnProgress(i+1, len(files), file.RelativePath)
 		}
 
+		// Never touch files carrying a do-not-modify marker
+		if reason := file.LockReason(); reason != "" {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: file.RelativePath, Reason: reason, Locked: true})
+			if fp.Verbose {
+				fmt.Printf("Examining: %s - Skipped (locked: %s)\n", file.RelativePath, reason)
+			}
+			continue
+		}
+		if fp.SkipFile != nil {
+			if reason := fp.SkipFile(file); reason != "" {
+				result.Skipped = append(result.Skipped, SkippedFile{Path: file.RelativePath, Reason: reason})
+				if fp.Verbose {
+					fmt.Printf("Examining: %s - Skipped (%s)\n", file.RelativePath, reason)
+				}
+				continue
+			}
+		}
+
 		// Process the file
 		modified, err := fp.ProcessFile(file)
 		if err != nil {
@@ -116,12 +149,21 @@ func (fp *FileProcessor) ProcessPath(path string) (*ProcessResult, error) {
 
 // writeFile writes a vault file back to disk, preserving frontmatter order
 func (fp *FileProcessor) writeFile(file *vault.VaultFile) error {
-	content, err := file.Serialize()
+	serialize := fp.Serialize
+	if serialize == nil {
+		serialize = (*vault.VaultFile).Serialize
+	}
+
+	content, err := serialize(file)
 	if err != nil {
 		return fmt.Errorf("serializing: %w", err)
 	}
 
-	if err := os.WriteFile(file.Path, content, 0644); err != nil {
+	if err := fp.Transaction.RecordWrite(file.Path); err != nil {
+		return fmt.Errorf("journaling: %w", err)
+	}
+
+	if err := safety.WriteFile(file.Path, content, 0644); err != nil {
 		return fmt.Errorf("writing: %w", err)
 	}
 
@@ -139,6 +181,17 @@ func (fp *FileProcessor) PrintSummary(result *ProcessResult) {
 
 	// Show summary unless quiet mode is enabled
 	if !fp.Quiet {
+		// Verbose mode already reported skipped files inline
+		if !fp.Verbose {
+			for _, skipped := range result.Skipped {
+				if skipped.Locked {
+					fmt.Printf("⚠ Skipped: %s (locked: %s)\n", skipped.Path, skipped.Reason)
+				} else {
+					fmt.Printf("⚠ Skipped: %s (%s)\n", skipped.Path, skipped.Reason)
+				}
+			}
+		}
+
 		if fp.DryRun {
 			fmt.Printf("\nDry run completed. Would modify %d files.\n", result.ProcessedFiles)
 		} else {
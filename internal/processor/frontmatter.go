@@ -1,6 +1,9 @@
 package processor
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 	"github.com/eoinhurrell/mdnotes/pkg/template"
 )
@@ -42,3 +45,82 @@ func (p *FrontmatterProcessor) Ensure(file *vault.VaultFile, field string, defau
 
 	return true
 }
+
+// Remove deletes a field, returning true if it existed
+func (p *FrontmatterProcessor) Remove(file *vault.VaultFile, field string) bool {
+	if _, exists := file.Frontmatter[field]; !exists {
+		return false
+	}
+	delete(file.Frontmatter, field)
+	return true
+}
+
+// RenameConflict selects what Rename does when the target field already exists
+type RenameConflict string
+
+const (
+	// RenameSkip leaves both fields alone
+	RenameSkip RenameConflict = "skip"
+	// RenameOverwrite replaces the target value with the renamed field's value
+	RenameOverwrite RenameConflict = "overwrite"
+	// RenameMerge combines both values into a single list of unique items
+	RenameMerge RenameConflict = "merge"
+)
+
+// ParseRenameConflict validates a rename conflict strategy name
+func ParseRenameConflict(name string) (RenameConflict, error) {
+	switch c := RenameConflict(strings.ToLower(name)); c {
+	case RenameSkip, RenameOverwrite, RenameMerge:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unknown conflict strategy %q (supported: skip, overwrite, merge)", name)
+	}
+}
+
+// Rename moves a field to a new name, keeping its position in the frontmatter.
+// When the target already exists, conflict decides the outcome. Returns true if
+// the file was modified.
+func (p *FrontmatterProcessor) Rename(file *vault.VaultFile, from, to string, conflict RenameConflict) bool {
+	value, exists := file.Frontmatter[from]
+	if !exists || from == to {
+		return false
+	}
+
+	if existing, taken := file.Frontmatter[to]; taken {
+		switch conflict {
+		case RenameOverwrite:
+		case RenameMerge:
+			// The merged list keeps the target's position
+			file.Frontmatter[to] = mergeUnique(existing, value)
+			delete(file.Frontmatter, from)
+			return true
+		default:
+			return false
+		}
+	}
+
+	return file.RenameField(from, to)
+}
+
+// mergeUnique combines values, flattening lists, into a list without duplicates
+func mergeUnique(values ...interface{}) []interface{} {
+	var merged []interface{}
+	seen := make(map[string]bool)
+	for _, value := range values {
+		items, isList := value.([]interface{})
+		if !isList {
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+			key := fmt.Sprintf("%T:%v", item, item)
+			if !seen[key] {
+				seen[key] = true
+				merged = append(merged, item)
+			}
+		}
+	}
+	return merged
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestFrontmatterProcessor_Ensure(t *testing.T) {
@@ -159,3 +160,82 @@ func TestFrontmatterProcessor_EnsureWithTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestFrontmatterProcessor_Remove(t *testing.T) {
+	file := &vault.VaultFile{
+		Frontmatter: map[string]interface{}{
+			"title":     "Test",
+			"legacy_id": 42,
+		},
+	}
+
+	p := NewFrontmatterProcessor()
+	assert.True(t, p.Remove(file, "legacy_id"))
+	assert.False(t, p.Remove(file, "legacy_id"))
+	assert.Equal(t, map[string]interface{}{"title": "Test"}, file.Frontmatter)
+}
+
+func TestFrontmatterProcessor_Rename(t *testing.T) {
+	tests := []struct {
+		name     string
+		fm       map[string]interface{}
+		conflict RenameConflict
+		want     map[string]interface{}
+		modified bool
+	}{
+		{
+			name:     "rename field",
+			fm:       map[string]interface{}{"alias": "Other"},
+			conflict: RenameSkip,
+			want:     map[string]interface{}{"aliases": "Other"},
+			modified: true,
+		},
+		{
+			name:     "missing field",
+			fm:       map[string]interface{}{"title": "Test"},
+			conflict: RenameSkip,
+			want:     map[string]interface{}{"title": "Test"},
+			modified: false,
+		},
+		{
+			name:     "skip existing target",
+			fm:       map[string]interface{}{"alias": "Other", "aliases": []interface{}{"Old"}},
+			conflict: RenameSkip,
+			want:     map[string]interface{}{"alias": "Other", "aliases": []interface{}{"Old"}},
+			modified: false,
+		},
+		{
+			name:     "overwrite existing target",
+			fm:       map[string]interface{}{"alias": "Other", "aliases": []interface{}{"Old"}},
+			conflict: RenameOverwrite,
+			want:     map[string]interface{}{"aliases": "Other"},
+			modified: true,
+		},
+		{
+			name:     "merge into existing target",
+			fm:       map[string]interface{}{"alias": []interface{}{"Other", "Old"}, "aliases": []interface{}{"Old"}},
+			conflict: RenameMerge,
+			want:     map[string]interface{}{"aliases": []interface{}{"Old", "Other"}},
+			modified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &vault.VaultFile{Frontmatter: tt.fm}
+
+			p := NewFrontmatterProcessor()
+			assert.Equal(t, tt.modified, p.Rename(file, "alias", "aliases", tt.conflict))
+			assert.Equal(t, tt.want, file.Frontmatter)
+		})
+	}
+}
+
+func TestParseRenameConflict(t *testing.T) {
+	conflict, err := ParseRenameConflict("Merge")
+	assert.NoError(t, err)
+	assert.Equal(t, RenameMerge, conflict)
+
+	_, err = ParseRenameConflict("replace")
+	assert.Error(t, err)
+}
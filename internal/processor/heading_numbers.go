@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Heading number styles
+const (
+	HeadingNumberDotted      = "1.1.1"  // 1 Intro, 1.1 Background
+	HeadingNumberDottedFinal = "1.1.1." // 1. Intro, 1.1. Background
+)
+
+var (
+	// headingNumberRegex matches a number heading numbering may have added
+	headingNumberRegex = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3})*\.?[ \t]+`)
+	// wikiAnchorLinkRegex matches wiki links and embeds to a heading
+	wikiAnchorLinkRegex = regexp.MustCompile(`(!?\[\[)([^\]|#]*)#([^\]|]+)((?:\|[^\]]*)?\]\])`)
+	// markdownAnchorLinkRegex matches markdown links to a heading
+	markdownAnchorLinkRegex = regexp.MustCompile(`(\]\(<?)([^)#\s>]*)#([^)>]+)(>?\))`)
+)
+
+// HeadingNumberOptions configures heading numbering
+type HeadingNumberOptions struct {
+	Style    string // HeadingNumberDotted (default) or HeadingNumberDottedFinal
+	MinLevel int    // Shallowest heading level numbered (default 2)
+	MaxDepth int    // How many levels are numbered, from MinLevel (default 3)
+}
+
+// HeadingNumberer adds, refreshes and removes hierarchical heading numbers
+type HeadingNumberer struct {
+	opts HeadingNumberOptions
+}
+
+// NewHeadingNumberer validates opts and fills in defaults
+func NewHeadingNumberer(opts HeadingNumberOptions) (*HeadingNumberer, error) {
+	if opts.Style == "" {
+		opts.Style = HeadingNumberDotted
+	}
+	if opts.MinLevel == 0 {
+		opts.MinLevel = 2
+	}
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = 3
+	}
+	if opts.Style != HeadingNumberDotted && opts.Style != HeadingNumberDottedFinal {
+		return nil, fmt.Errorf("invalid numbering style '%s' (supported: %s, %s)", opts.Style, HeadingNumberDotted, HeadingNumberDottedFinal)
+	}
+	if opts.MinLevel < 1 || opts.MinLevel > 6 {
+		return nil, fmt.Errorf("invalid minimum heading level %d (must be between 1 and 6)", opts.MinLevel)
+	}
+	if opts.MaxDepth < 1 {
+		return nil, fmt.Errorf("invalid depth %d (must be at least 1)", opts.MaxDepth)
+	}
+	return &HeadingNumberer{opts: opts}, nil
+}
+
+// Number numbers the file's headings from MinLevel down MaxDepth levels,
+// replacing numbers they already have, and updates links within the note
+// to the headings it renames. It returns how many headings changed.
+func (n *HeadingNumberer) Number(file *vault.VaultFile) int {
+	return n.apply(file, true)
+}
+
+// Strip removes numbers from the file's headings from MinLevel down, and
+// updates links within the note to them. It returns how many headings changed.
+func (n *HeadingNumberer) Strip(file *vault.VaultFile) int {
+	return n.apply(file, false)
+}
+
+func (n *HeadingNumberer) apply(file *vault.VaultFile, number bool) int {
+	renamed := make(map[string]string) // lowercased old anchor -> new anchor
+	var levels []int                   // levels of the open headings, outermost first
+	var counts []int                   // last number used at each depth
+
+	body, changed := mapProseLines(file.Body, func(line string) (string, int) {
+		m := atxHeadingRegex.FindStringSubmatchIndex(line)
+		if m == nil {
+			return line, 0
+		}
+		level := m[3] - m[2]
+		if level < n.opts.MinLevel {
+			// A higher heading starts the numbering again
+			levels, counts = nil, nil
+			return line, 0
+		}
+
+		text := line[m[4]:m[5]]
+		newText := headingNumberRegex.ReplaceAllString(text, "")
+		if newText == "" {
+			return line, 0
+		}
+
+		// Numbers follow the heading tree, so a skipped level isn't numbered 0
+		for len(levels) > 0 && levels[len(levels)-1] >= level {
+			levels = levels[:len(levels)-1]
+		}
+		depth := len(levels)
+		levels = append(levels, level)
+		for len(counts) <= depth {
+			counts = append(counts, 0)
+		}
+		counts = counts[:depth+1]
+		counts[depth]++
+
+		if number && depth < n.opts.MaxDepth {
+			newText = n.format(counts) + " " + newText
+		}
+		if newText == text {
+			return line, 0
+		}
+		renamed[strings.ToLower(headingLinkAnchor(text))] = headingLinkAnchor(newText)
+		return line[:m[4]] + newText + line[m[5]:], 1
+	})
+	if changed == 0 {
+		return 0
+	}
+
+	file.Body = updateHeadingLinks(body, file.RelativePath, renamed)
+	return changed
+}
+
+// format writes a heading number in the numberer's style
+func (n *HeadingNumberer) format(counts []int) string {
+	parts := make([]string, len(counts))
+	for i, count := range counts {
+		parts[i] = strconv.Itoa(count)
+	}
+	number := strings.Join(parts, ".")
+	if n.opts.Style == HeadingNumberDottedFinal {
+		number += "."
+	}
+	return number
+}
+
+// updateHeadingLinks points links in body, the note at relPath, to the
+// headings renamed from one anchor to another. Links to other notes'
+// headings and links in code are left alone.
+func updateHeadingLinks(body, relPath string, renamed map[string]string) string {
+	if len(renamed) == 0 {
+		return body
+	}
+	name := strings.ToLower(strings.TrimSuffix(path.Base(filepath.ToSlash(relPath)), ".md"))
+	self := func(target string) bool {
+		return target == "" || strings.ToLower(strings.TrimSuffix(path.Base(target), ".md")) == name
+	}
+	rename := func(anchor string) (string, bool) {
+		if strings.HasPrefix(anchor, "^") {
+			return "", false
+		}
+		updated, ok := renamed[strings.ToLower(headingLinkAnchor(anchor))]
+		return updated, ok
+	}
+
+	body, _ = mapProseLines(body, func(line string) (string, int) {
+		line = wikiAnchorLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+			m := wikiAnchorLinkRegex.FindStringSubmatch(link)
+			if !self(m[2]) {
+				return link
+			}
+			if updated, ok := rename(m[3]); ok {
+				return m[1] + m[2] + "#" + updated + m[4]
+			}
+			return link
+		})
+		line = markdownAnchorLinkRegex.ReplaceAllStringFunc(line, func(link string) string {
+			m := markdownAnchorLinkRegex.FindStringSubmatch(link)
+			target, err := url.PathUnescape(m[2])
+			if err != nil || !self(target) {
+				return link
+			}
+			anchor, err := url.PathUnescape(m[3])
+			if err != nil {
+				return link
+			}
+			if updated, ok := rename(anchor); ok {
+				if strings.HasPrefix(m[1], "](<") {
+					return m[1] + m[2] + "#" + updated + m[4]
+				}
+				return m[1] + m[2] + "#" + pathEscaper.Replace(updated) + m[4]
+			}
+			return link
+		})
+		return line, 0
+	})
+	return body
+}
@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestHeadingNumberer(t *testing.T) {
+	body := "# Guide\n\n" +
+		"See [[#Setup]], [[Guide#Install Steps|installing]] and [usage](#Usage%20Notes).\n" +
+		"Other notes keep theirs: [[Other#Setup]], and blocks: [[#^abc]]\n\n" +
+		"## Setup\n\n### Install Steps\n\n#### Detail\n\n##### Deeper\n\n" +
+		"```md\n## Not a heading\n[[#Setup]]\n```\n\n" +
+		"## Usage Notes\n\n#### Skipped level\n\n# Appendix\n\n## Extra\n"
+
+	file := &vault.VaultFile{RelativePath: "docs/Guide.md", Body: body}
+	numberer, err := NewHeadingNumberer(HeadingNumberOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, numberer.Number(file))
+	assert.Equal(t, "# Guide\n\n"+
+		"See [[#1 Setup]], [[Guide#1.1 Install Steps|installing]] and [usage](#2%20Usage%20Notes).\n"+
+		"Other notes keep theirs: [[Other#Setup]], and blocks: [[#^abc]]\n\n"+
+		"## 1 Setup\n\n### 1.1 Install Steps\n\n#### 1.1.1 Detail\n\n##### Deeper\n\n"+
+		"```md\n## Not a heading\n[[#Setup]]\n```\n\n"+
+		"## 2 Usage Notes\n\n#### 2.1 Skipped level\n\n# Appendix\n\n## 1 Extra\n", file.Body)
+
+	// Numbering again changes nothing
+	numbered := file.Body
+	assert.Equal(t, 0, numberer.Number(file))
+	assert.Equal(t, numbered, file.Body)
+
+	// Numbers are refreshed when headings change
+	file.Body = "## New First\n\n" + numbered[len("# Guide\n\n"):]
+	numberer.Number(file)
+	assert.Contains(t, file.Body, "## 1 New First\n")
+	assert.Contains(t, file.Body, "See [[#2 Setup]], [[Guide#2.1 Install Steps|installing]] and [usage](#3%20Usage%20Notes).")
+
+	// Stripping restores the headings and links
+	file.Body = numbered
+	assert.Equal(t, 6, numberer.Strip(file))
+	assert.Equal(t, body, file.Body)
+}
+
+func TestHeadingNumberer_Style(t *testing.T) {
+	numberer, err := NewHeadingNumberer(HeadingNumberOptions{Style: HeadingNumberDottedFinal, MinLevel: 1, MaxDepth: 2})
+	require.NoError(t, err)
+
+	file := &vault.VaultFile{Body: "# One\n## Sub\n### Deep\n# Two\n"}
+	numberer.Number(file)
+	assert.Equal(t, "# 1. One\n## 1.1. Sub\n### Deep\n# 2. Two\n", file.Body)
+
+	_, err = NewHeadingNumberer(HeadingNumberOptions{Style: "I.A.1"})
+	assert.ErrorContains(t, err, "invalid numbering style")
+	_, err = NewHeadingNumberer(HeadingNumberOptions{MinLevel: 7})
+	assert.ErrorContains(t, err, "invalid minimum heading level")
+}
@@ -0,0 +1,276 @@
+package processor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// ImportFormats lists the table formats accepted by ReadImportTable
+var ImportFormats = []string{"csv", "tsv", "json"}
+
+// ImportFormatForPath guesses a table format from a file extension
+func ImportFormatForPath(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".tsv", ".tab":
+		return "tsv"
+	default:
+		return "csv"
+	}
+}
+
+// ImportTable holds rows of frontmatter values read from a spreadsheet export
+type ImportTable struct {
+	Columns []string                 // Column names in table order
+	Rows    []map[string]interface{} // Values by column; CSV cells are strings
+}
+
+// ReadImportTable reads a CSV or TSV table with a header row, or a JSON array
+// of objects
+func ReadImportTable(r io.Reader, format string) (*ImportTable, error) {
+	switch format {
+	case "csv", "tsv":
+		return readDelimitedTable(r, format == "tsv")
+	case "json":
+		return readJSONTable(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s (supported: %s)", format, strings.Join(ImportFormats, ", "))
+	}
+}
+
+func readDelimitedTable(r io.Reader, tabs bool) (*ImportTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if tabs {
+		reader.Comma = '\t'
+		reader.LazyQuotes = true
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading table: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("table is empty")
+	}
+
+	header := records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff") // Spreadsheet exports often start with a BOM
+	}
+	table := &ImportTable{}
+	for _, column := range header {
+		table.Columns = append(table.Columns, strings.TrimSpace(column))
+	}
+
+	for i, record := range records[1:] {
+		if len(record) > len(header) {
+			return nil, fmt.Errorf("row %d has %d cells but the header has %d", i+2, len(record), len(header))
+		}
+		row := make(map[string]interface{}, len(record))
+		for j, cell := range record {
+			row[table.Columns[j]] = cell
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func readJSONTable(r io.Reader) (*ImportTable, error) {
+	var rows []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("reading table: expected a JSON array of objects: %w", err)
+	}
+
+	table := &ImportTable{Rows: rows}
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for column, value := range row {
+			row[column] = normalizeJSONValue(value)
+			if !seen[column] {
+				seen[column] = true
+				table.Columns = append(table.Columns, column)
+			}
+		}
+	}
+	sort.Strings(table.Columns)
+	return table, nil
+}
+
+// normalizeJSONValue turns whole JSON numbers into ints so they are written
+// to frontmatter without a decimal point
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeJSONValue(v[i])
+		}
+	}
+	return value
+}
+
+// FrontmatterImporter applies table rows to the notes they match by a key
+// column. Rows match on the note path when the key is "path" or "file", and
+// on the frontmatter field of the same name otherwise.
+type FrontmatterImporter struct {
+	Key     string
+	Columns []string          // Columns to import (all but the key column)
+	Types   map[string]string // Explicit types by column, as for 'frontmatter set'
+
+	rows    map[string]map[string]interface{}
+	order   []string
+	matched map[string]bool
+	caster  *TypeCaster
+}
+
+// NewFrontmatterImporter indexes the table's rows by the key column. Rows
+// without a key are ignored; duplicate keys are an error.
+func NewFrontmatterImporter(table *ImportTable, key string) (*FrontmatterImporter, error) {
+	hasKey := false
+	fi := &FrontmatterImporter{
+		Key:     key,
+		Types:   make(map[string]string),
+		rows:    make(map[string]map[string]interface{}),
+		matched: make(map[string]bool),
+		caster:  NewTypeCaster(),
+	}
+	for _, column := range table.Columns {
+		if column == key {
+			hasKey = true
+		} else if column != "" {
+			fi.Columns = append(fi.Columns, column)
+		}
+	}
+	if !hasKey {
+		return nil, fmt.Errorf("key column '%s' not found in table (columns: %s)", key, strings.Join(table.Columns, ", "))
+	}
+
+	for i, row := range table.Rows {
+		value, ok := row[key]
+		if !ok || value == nil || strings.TrimSpace(fmt.Sprint(value)) == "" {
+			continue
+		}
+		k := fi.normalizeKey(fmt.Sprint(value))
+		if _, exists := fi.rows[k]; exists {
+			return nil, fmt.Errorf("duplicate key '%v' in row %d", value, i+1)
+		}
+		fi.rows[k] = row
+		fi.order = append(fi.order, k)
+	}
+	return fi, nil
+}
+
+func (fi *FrontmatterImporter) matchesPath() bool {
+	return fi.Key == "path" || fi.Key == "file"
+}
+
+func (fi *FrontmatterImporter) normalizeKey(key string) string {
+	key = strings.TrimSpace(key)
+	if fi.matchesPath() {
+		key = strings.TrimPrefix(filepath.ToSlash(key), "./")
+		key = strings.TrimSuffix(key, ".md")
+	}
+	return key
+}
+
+// Match returns the row for a note, trying its relative path and then its
+// file name when matching on paths
+func (fi *FrontmatterImporter) Match(file *vault.VaultFile) (map[string]interface{}, bool) {
+	var candidates []string
+	if fi.matchesPath() {
+		rel := strings.TrimSuffix(filepath.ToSlash(file.RelativePath), ".md")
+		candidates = []string{rel, path.Base(rel)}
+	} else if value, exists := file.GetField(fi.Key); exists && value != nil {
+		candidates = []string{fi.normalizeKey(fmt.Sprint(value))}
+	}
+
+	for _, candidate := range candidates {
+		if row, ok := fi.rows[candidate]; ok {
+			fi.matched[candidate] = true
+			return row, true
+		}
+	}
+	return nil, false
+}
+
+// Unmatched returns the keys of rows that no note has matched, in table order
+func (fi *FrontmatterImporter) Unmatched() []string {
+	var keys []string
+	for _, key := range fi.order {
+		if !fi.matched[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Apply sets the row's values on the note and returns the columns whose value
+// changed. Empty cells are skipped, "null" clears a field, and bracketed
+// lists become arrays.
+func (fi *FrontmatterImporter) Apply(file *vault.VaultFile, row map[string]interface{}) ([]string, error) {
+	var changed []string
+	for _, column := range fi.Columns {
+		raw, ok := row[column]
+		if !ok {
+			continue
+		}
+
+		value, err := fi.convert(column, raw)
+		if err != nil {
+			return changed, err
+		}
+		if s, isString := value.(string); isString && strings.TrimSpace(s) == "" {
+			continue
+		}
+		if current, exists := file.GetField(column); exists && fmt.Sprint(current) == fmt.Sprint(value) {
+			continue
+		}
+
+		file.SetField(column, value)
+		changed = append(changed, column)
+	}
+	return changed, nil
+}
+
+func (fi *FrontmatterImporter) convert(column string, raw interface{}) (interface{}, error) {
+	s, isString := raw.(string)
+	if !isString {
+		return raw, nil
+	}
+	if s == "null" {
+		return nil, nil
+	}
+
+	typeName, hasType := fi.Types[column]
+	if !hasType {
+		trimmed := strings.TrimSpace(s)
+		if !strings.HasPrefix(trimmed, "[") || !strings.HasSuffix(trimmed, "]") {
+			return s, nil
+		}
+		typeName = "array"
+	}
+	if strings.TrimSpace(s) == "" {
+		return s, nil
+	}
+
+	value, err := fi.caster.Cast(s, typeName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot cast '%s' value %q to %s: %w", column, s, typeName, err)
+	}
+	return value, nil
+}
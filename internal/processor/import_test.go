@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func importNote(t *testing.T, rel, content string) *vault.VaultFile {
+	t.Helper()
+	file := &vault.VaultFile{Path: rel, RelativePath: rel}
+	require.NoError(t, file.Parse([]byte(content)))
+	return file
+}
+
+func TestReadImportTable(t *testing.T) {
+	table, err := ReadImportTable(strings.NewReader("\ufeffpath,rating, tags\nbooks/dune.md,5,\"[scifi, classic]\"\n"), "csv")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"path", "rating", "tags"}, table.Columns)
+	assert.Equal(t, []map[string]interface{}{{"path": "books/dune.md", "rating": "5", "tags": "[scifi, classic]"}}, table.Rows)
+
+	table, err = ReadImportTable(strings.NewReader("path\trating\ndune\t4\n"), "tsv")
+	require.NoError(t, err)
+	assert.Equal(t, "4", table.Rows[0]["rating"])
+
+	table, err = ReadImportTable(strings.NewReader(`[{"isbn": "123", "pages": 412, "score": 4.5, "tags": ["a", 1]}]`), "json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"isbn", "pages", "score", "tags"}, table.Columns)
+	assert.Equal(t, 412, table.Rows[0]["pages"])
+	assert.Equal(t, 4.5, table.Rows[0]["score"])
+	assert.Equal(t, []interface{}{"a", 1}, table.Rows[0]["tags"])
+
+	_, err = ReadImportTable(strings.NewReader("a,b\n1,2,3\n"), "csv")
+	assert.ErrorContains(t, err, "row 2 has 3 cells")
+	_, err = ReadImportTable(strings.NewReader(`{"a": 1}`), "json")
+	assert.Error(t, err)
+	_, err = ReadImportTable(strings.NewReader(""), "xlsx")
+	assert.ErrorContains(t, err, "unsupported import format")
+}
+
+func TestFrontmatterImporter_MatchByPath(t *testing.T) {
+	table := &ImportTable{
+		Columns: []string{"path", "rating", "tags", "subtitle", "series"},
+		Rows: []map[string]interface{}{
+			{"path": "./books/dune.md", "rating": "5", "tags": "[scifi, classic]", "subtitle": "", "series": "null"},
+			{"path": "hyperion", "rating": "4"},
+			{"path": "missing", "rating": "3"},
+			{"path": "", "rating": "1"},
+		},
+	}
+	fi, err := NewFrontmatterImporter(table, "path")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rating", "tags", "subtitle", "series"}, fi.Columns)
+
+	dune := importNote(t, "books/dune.md", "---\nrating: 3\nsubtitle: keep\nseries: Dune\n---\n")
+	row, ok := fi.Match(dune)
+	require.True(t, ok)
+	changed, err := fi.Apply(dune, row)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rating", "tags", "series"}, changed)
+	assert.Equal(t, "5", dune.Frontmatter["rating"])
+	assert.Equal(t, []string{"scifi", "classic"}, dune.Frontmatter["tags"])
+	assert.Equal(t, "keep", dune.Frontmatter["subtitle"], "empty cells are skipped")
+	assert.Nil(t, dune.Frontmatter["series"])
+
+	changed, err = fi.Apply(dune, row)
+	require.NoError(t, err)
+	assert.Empty(t, changed, "reapplying a row changes nothing")
+
+	hyperion := importNote(t, "books/scifi/hyperion.md", "# Hyperion\n")
+	_, ok = fi.Match(hyperion)
+	assert.True(t, ok, "rows may name just the file")
+
+	_, ok = fi.Match(importNote(t, "other.md", ""))
+	assert.False(t, ok)
+	assert.Equal(t, []string{"missing"}, fi.Unmatched())
+}
+
+func TestFrontmatterImporter_MatchByField(t *testing.T) {
+	table := &ImportTable{
+		Columns: []string{"isbn", "read"},
+		Rows:    []map[string]interface{}{{"isbn": "9780441013593", "read": "2024-03-01"}},
+	}
+	fi, err := NewFrontmatterImporter(table, "isbn")
+	require.NoError(t, err)
+	fi.Types["read"] = "date"
+
+	note := importNote(t, "dune.md", "---\nisbn: 9780441013593\n---\n")
+	row, ok := fi.Match(note)
+	require.True(t, ok)
+	changed, err := fi.Apply(note, row)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read"}, changed)
+	assert.IsType(t, vault.Date{}, note.Frontmatter["read"])
+
+	fi.Types["read"] = "number"
+	_, err = fi.Apply(importNote(t, "x.md", ""), row)
+	assert.ErrorContains(t, err, "cannot cast 'read'")
+}
+
+func TestNewFrontmatterImporter_Errors(t *testing.T) {
+	_, err := NewFrontmatterImporter(&ImportTable{Columns: []string{"title"}}, "path")
+	assert.ErrorContains(t, err, "key column 'path' not found")
+
+	_, err = NewFrontmatterImporter(&ImportTable{
+		Columns: []string{"path"},
+		Rows:    []map[string]interface{}{{"path": "a.md"}, {"path": "a"}},
+	}, "path")
+	assert.ErrorContains(t, err, "duplicate key 'a' in row 2")
+}
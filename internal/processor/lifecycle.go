@@ -0,0 +1,187 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// LifecycleRule is a compiled lifecycle rule from the configuration
+type LifecycleRule struct {
+	Name   string
+	When   query.Expression
+	Set    map[string]interface{}
+	MoveTo string
+}
+
+// LifecycleAction is the change a lifecycle rule makes to one note
+type LifecycleAction struct {
+	Rule   string
+	File   *vault.VaultFile
+	Set    map[string]interface{} // Only fields whose value changes
+	Target string                 // Path the note moves to, or "" if it stays
+}
+
+// LifecycleProcessor finds notes matching lifecycle rules and applies them
+type LifecycleProcessor struct {
+	rules     []LifecycleRule
+	vaultRoot string
+	organizer *Organizer
+}
+
+// NewLifecycleProcessor compiles the configured rules for the vault at vaultRoot
+func NewLifecycleProcessor(rules []config.LifecycleRule, vaultRoot string) (*LifecycleProcessor, error) {
+	if abs, err := filepath.Abs(vaultRoot); err == nil {
+		vaultRoot = abs
+	}
+	lp := &LifecycleProcessor{vaultRoot: vaultRoot, organizer: NewOrganizer()}
+	for _, rule := range rules {
+		expr, err := query.NewParser(rule.When).Compile()
+		if err != nil {
+			return nil, fmt.Errorf("lifecycle rule '%s': parsing condition: %w", rule.Name, err)
+		}
+		if err := validateRuleTemplates(rule); err != nil {
+			return nil, fmt.Errorf("lifecycle rule '%s': %w", rule.Name, err)
+		}
+		lp.rules = append(lp.rules, LifecycleRule{Name: rule.Name, When: expr, Set: rule.Set, MoveTo: rule.MoveTo})
+	}
+	return lp, nil
+}
+
+// validateRuleTemplates checks the templates in a rule's set values and move_to
+func validateRuleTemplates(rule config.LifecycleRule) error {
+	renderer := templates.NewRenderer()
+	for field, value := range rule.Set {
+		if template, ok := value.(string); ok {
+			if err := renderer.Validate(template); err != nil {
+				return fmt.Errorf("set '%s': %w", field, err)
+			}
+		}
+	}
+	if err := renderer.Validate(rule.MoveTo); err != nil {
+		return fmt.Errorf("move_to: %w", err)
+	}
+	return nil
+}
+
+// Rules returns the compiled rules in the order they are tried
+func (lp *LifecycleProcessor) Rules() []LifecycleRule {
+	return lp.rules
+}
+
+// Plan returns the action for each file matched by a rule, in path order. The
+// first matching rule applies. Files already in the state a rule describes
+// and locked files are left out.
+func (lp *LifecycleProcessor) Plan(files []*vault.VaultFile) []LifecycleAction {
+	var actions []LifecycleAction
+	for _, file := range files {
+		if file.LockReason() != "" {
+			continue
+		}
+		for _, rule := range lp.rules {
+			if !rule.When.Evaluate(file) {
+				continue
+			}
+			if action := lp.plan(rule, file); len(action.Set) > 0 || action.Target != "" {
+				actions = append(actions, action)
+			}
+			break
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool { return actions[i].File.Path < actions[j].File.Path })
+	return actions
+}
+
+func (lp *LifecycleProcessor) plan(rule LifecycleRule, file *vault.VaultFile) LifecycleAction {
+	action := LifecycleAction{Rule: rule.Name, File: file}
+
+	for field, value := range rule.Set {
+		if template, ok := value.(string); ok {
+			value = lp.organizer.GenerateFilename(template, file)
+		}
+		if current, exists := file.GetField(field); exists && fmt.Sprint(current) == fmt.Sprint(value) {
+			continue
+		}
+		if action.Set == nil {
+			action.Set = make(map[string]interface{})
+		}
+		action.Set[field] = value
+	}
+
+	if rule.MoveTo != "" {
+		dir := lp.organizer.GenerateDirectoryPath(rule.MoveTo, file)
+		target := filepath.Join(lp.vaultRoot, dir, filepath.Base(file.Path))
+		if source, err := filepath.Abs(file.Path); err != nil || target != source {
+			action.Target = target
+		}
+	}
+	return action
+}
+
+// Apply sets the action's fields and then moves the note, updating links to
+// it. Changes are journaled in options.Transaction with the rule as reason.
+// options.DryRun is ignored; skip Apply to preview a plan.
+func (lp *LifecycleProcessor) Apply(ctx context.Context, action LifecycleAction, options RenameOptions) error {
+	return lp.apply(ctx, action, options, "lifecycle rule "+action.Rule)
+}
+
+// apply carries out an action, journaling its changes with reason
+func (lp *LifecycleProcessor) apply(ctx context.Context, action LifecycleAction, options RenameOptions, reason string) error {
+	tx := options.Transaction
+	tx.SetReason(reason)
+	defer tx.SetReason("")
+
+	if action.Target != "" {
+		if rel, err := filepath.Rel(lp.vaultRoot, action.Target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("move target %s is outside the vault", action.Target)
+		}
+		if _, err := os.Stat(action.Target); err == nil {
+			return fmt.Errorf("move target %s already exists", action.Target)
+		}
+	}
+
+	if len(action.Set) > 0 {
+		// Reload the note, since moves applied earlier may have updated its links
+		file, err := vault.LoadVaultFile(action.File.Path)
+		if err != nil {
+			return err
+		}
+		for field, value := range action.Set {
+			file.SetField(field, value)
+		}
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", action.File.RelativePath, err)
+		}
+		if err := tx.RecordWrite(action.File.Path); err != nil {
+			return fmt.Errorf("journaling %s: %w", action.File.RelativePath, err)
+		}
+		if err := safety.WriteFile(action.File.Path, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", action.File.RelativePath, err)
+		}
+	}
+
+	if action.Target != "" {
+		source, err := filepath.Abs(action.File.Path)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", action.File.RelativePath, err)
+		}
+		options.VaultRoot = lp.vaultRoot
+		renamer := NewRenameProcessor(options)
+		defer func() { _ = renamer.Cleanup() }()
+		if _, err := renamer.ProcessRename(ctx, source, action.Target, options); err != nil {
+			return fmt.Errorf("moving %s: %w", action.File.RelativePath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+var archiveDoneRule = config.LifecycleRule{
+	Name:   "archive-done",
+	When:   "status = 'done' AND modified before '90 days'",
+	Set:    map[string]interface{}{"status": "archived", "archived": "{{current_date}}"},
+	MoveTo: "archive/{{created|date:2006}}",
+}
+
+func writeLifecycleNote(t *testing.T, root, rel, content string) *vault.VaultFile {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	file, err := vault.LoadVaultFile(path)
+	require.NoError(t, err)
+	file.RelativePath = rel
+	return file
+}
+
+func TestLifecycleProcessor_Plan(t *testing.T) {
+	root := t.TempDir()
+	recent := time.Now().AddDate(0, 0, -5).Format("2006-01-02")
+	files := []*vault.VaultFile{
+		writeLifecycleNote(t, root, "old.md", "---\nstatus: done\ncreated: 2023-05-01\nmodified: 2024-01-01\n---\n"),
+		writeLifecycleNote(t, root, "recent.md", "---\nstatus: done\nmodified: "+recent+"\n---\n"),
+		writeLifecycleNote(t, root, "locked.md", "---\nstatus: done\nmodified: 2024-01-01\nmdnotes: locked\n---\n"),
+		writeLifecycleNote(t, root, "archive/2023/filed.md", "---\nstatus: done\ncreated: 2023-01-01\nmodified: 2024-01-01\narchived: x\n---\n"),
+	}
+
+	_, err := NewLifecycleProcessor([]config.LifecycleRule{{Name: "bad", When: "status ="}}, root)
+	assert.ErrorContains(t, err, "lifecycle rule 'bad'")
+
+	lp, err := NewLifecycleProcessor([]config.LifecycleRule{archiveDoneRule}, root)
+	require.NoError(t, err)
+	actions := lp.Plan(files)
+	require.Len(t, actions, 2)
+
+	filed := actions[0]
+	assert.Equal(t, "archive/2023/filed.md", filed.File.RelativePath)
+	assert.Empty(t, filed.Target, "already in its archive directory")
+	assert.Equal(t, map[string]interface{}{"status": "archived", "archived": time.Now().Format("2006-01-02")}, filed.Set)
+
+	old := actions[1]
+	assert.Equal(t, "archive-done", old.Rule)
+	assert.Equal(t, filepath.Join(root, "archive", "2023", "old.md"), old.Target)
+}
+
+func TestLifecycleProcessor_ApplyJournalsRule(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, ".obsidian"), 0755))
+	file := writeLifecycleNote(t, root, "old.md", "---\nstatus: done\ncreated: 2023-05-01\nmodified: 2024-01-01\n---\nBody\n")
+	writeLifecycleNote(t, root, "index.md", "See [[old]]\n")
+
+	lp, err := NewLifecycleProcessor([]config.LifecycleRule{archiveDoneRule}, root)
+	require.NoError(t, err)
+	actions := lp.Plan([]*vault.VaultFile{file})
+	require.Len(t, actions, 1)
+
+	journal := safety.NewJournal(root)
+	tx := journal.Begin("mdnotes lifecycle run")
+	require.NoError(t, lp.Apply(context.Background(), actions[0], RenameOptions{Transaction: tx}))
+	require.NoError(t, tx.Commit())
+
+	moved, err := vault.LoadVaultFile(filepath.Join(root, "archive", "2023", "old.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "archived", moved.Frontmatter["status"])
+	assert.NoFileExists(t, filepath.Join(root, "old.md"))
+
+	index, err := os.ReadFile(filepath.Join(root, "index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "[[archive/2023/old]]")
+
+	require.NotEmpty(t, tx.Entries)
+	for _, entry := range tx.Entries {
+		assert.Equal(t, "lifecycle rule archive-done", entry.Reason, entry.Path)
+	}
+
+	require.NoError(t, journal.Undo(tx, false))
+	assert.FileExists(t, filepath.Join(root, "old.md"))
+}
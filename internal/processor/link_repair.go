@@ -0,0 +1,237 @@
+package processor
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultRepairMinScore is the lowest similarity a fuzzy suggestion may have
+const DefaultRepairMinScore = 0.6
+
+// maxSimilarScore keeps similar names below renames and aliases, which are
+// certain, even when they differ only in case or separators
+const maxSimilarScore = 0.95
+
+// Reasons a repair was suggested
+const (
+	RepairRenamed = "renamed"
+	RepairAlias   = "alias"
+	RepairSimilar = "similar name"
+)
+
+// zettelPrefix matches timestamp IDs prefixed to note names, as in
+// "20241020122921-Polysecure"
+var zettelPrefix = regexp.MustCompile(`^\d{8,14}[-_ ]+`)
+
+// RepairSuggestion is a note a broken link may have meant
+type RepairSuggestion struct {
+	Target string  `json:"target"` // Vault-relative path of the note
+	Score  float64 `json:"score"`  // Confidence from 0 to 1
+	Reason string  `json:"reason"`
+}
+
+// LinkRepairer suggests the notes broken links most likely meant, from
+// recorded renames, frontmatter aliases and similar note names
+type LinkRepairer struct {
+	MinScore float64 // Lowest score of suggestions from similar names
+
+	notes   map[string]bool     // vault-relative paths
+	names   map[string][]string // lowercased note name -> paths
+	aliases map[string][]string // lowercased alias -> paths
+	renames map[string]string   // lowercased old path and name, without .md -> new path
+}
+
+// NewLinkRepairer creates a repairer for the notes among files
+func NewLinkRepairer(files []*vault.VaultFile) *LinkRepairer {
+	r := &LinkRepairer{
+		MinScore: DefaultRepairMinScore,
+		notes:    make(map[string]bool, len(files)),
+		names:    make(map[string][]string, len(files)),
+		aliases:  make(map[string][]string),
+		renames:  make(map[string]string),
+	}
+	for _, file := range files {
+		relPath := filepath.ToSlash(file.RelativePath)
+		r.notes[relPath] = true
+		name := noteName(relPath)
+		r.names[name] = append(r.names[name], relPath)
+
+		for _, alias := range fileAliases(file) {
+			key := strings.ToLower(alias)
+			r.aliases[key] = append(r.aliases[key], relPath)
+		}
+	}
+	return r
+}
+
+// AddRename records that the note at from was moved to to. Renames should be
+// added oldest first, so that later moves of the same note win.
+func (r *LinkRepairer) AddRename(from, to string) {
+	from = strings.ToLower(strings.TrimSuffix(filepath.ToSlash(from), ".md"))
+	to = filepath.ToSlash(to)
+	// Follow notes that were renamed more than once
+	for key, target := range r.renames {
+		if strings.ToLower(strings.TrimSuffix(target, ".md")) == from {
+			r.renames[key] = to
+		}
+	}
+	r.renames[from] = to
+	r.renames[path.Base(from)] = to
+}
+
+// Suggest returns up to limit notes link may have meant, best first
+func (r *LinkRepairer) Suggest(link vault.Link, limit int) []RepairSuggestion {
+	target := strings.TrimPrefix(filepath.ToSlash(link.Target), "/")
+	if target == "" {
+		return nil
+	}
+	if ext := path.Ext(target); ext != "" && ext != ".md" && attachmentExt.MatchString(ext) {
+		return nil
+	}
+	key := strings.ToLower(strings.TrimSuffix(target, ".md"))
+	name := path.Base(key)
+
+	best := make(map[string]RepairSuggestion)
+	add := func(target string, score float64, reason string) {
+		if existing, ok := best[target]; !ok || score > existing.Score {
+			best[target] = RepairSuggestion{Target: target, Score: score, Reason: reason}
+		}
+	}
+
+	for _, candidate := range []string{key, name} {
+		if renamed, ok := r.renames[candidate]; ok && r.notes[renamed] {
+			add(renamed, 1, RepairRenamed)
+		}
+	}
+	if paths := r.aliases[name]; len(paths) > 0 {
+		// An alias shared by several notes is ambiguous
+		for _, p := range paths {
+			add(p, 1/float64(len(paths)), RepairAlias)
+		}
+	}
+
+	normalized := normalizeNoteName(name)
+	for candidate, paths := range r.names {
+		score := min(nameSimilarity(normalized, normalizeNoteName(candidate)), maxSimilarScore)
+		if score < r.MinScore {
+			continue
+		}
+		for _, p := range paths {
+			// Prefer the note in the folder the link named
+			s := score
+			if len(paths) > 1 && !strings.HasPrefix(strings.ToLower(p), path.Dir(key)+"/") {
+				s *= 0.95
+			}
+			add(p, s, RepairSimilar)
+		}
+	}
+
+	suggestions := make([]RepairSuggestion, 0, len(best))
+	for _, suggestion := range best {
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Target < suggestions[j].Target
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// RepairedLink returns the text of link rewritten to point at target. Wiki
+// links use the note name when it is unique in the vault, and links repaired
+// through an alias keep showing the text they were written with.
+func (r *LinkRepairer) RepairedLink(link vault.Link, suggestion RepairSuggestion) string {
+	target := suggestion.Target
+	if link.Type == vault.WikiLink || link.Type == vault.EmbedLink {
+		if len(r.names[noteName(target)]) == 1 {
+			target = path.Base(target)
+		}
+		if suggestion.Reason == RepairAlias && link.Type == vault.WikiLink && link.Alias == "" {
+			link.Alias = link.Target
+		}
+	}
+	return link.GenerateUpdatedLink(target)
+}
+
+// attachmentExt matches file extensions of non-note link targets
+var attachmentExt = regexp.MustCompile(`^\.[A-Za-z0-9]{1,5}$`)
+
+// noteName returns the lowercased name of the note at relPath
+func noteName(relPath string) string {
+	return strings.ToLower(strings.TrimSuffix(path.Base(relPath), ".md"))
+}
+
+// normalizeNoteName strips timestamp IDs and treats -, _ and spaces alike
+func normalizeNoteName(name string) string {
+	name = zettelPrefix.ReplaceAllString(name, "")
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// nameSimilarity scores two normalized names from 0 to 1 by edit distance
+func nameSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// fileAliases returns the aliases in a file's frontmatter
+func fileAliases(file *vault.VaultFile) []string {
+	var aliases []string
+	for _, field := range []string{"aliases", "alias"} {
+		switch v := file.Frontmatter[field].(type) {
+		case string:
+			if v != "" {
+				aliases = append(aliases, v)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok && s != "" {
+					aliases = append(aliases, s)
+				}
+			}
+		case []string:
+			aliases = append(aliases, v...)
+		}
+	}
+	return aliases
+}
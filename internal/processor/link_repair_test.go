@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestLinkRepairer_Suggest(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "notes/roadmap.md", Frontmatter: map[string]interface{}{"aliases": []interface{}{"Big Plan"}}},
+		{RelativePath: "books/20241020122921-Polysecure.md"},
+		{RelativePath: "notes/new-name.md"},
+		{RelativePath: "a/draft.md", Frontmatter: map[string]interface{}{"alias": "Shared"}},
+		{RelativePath: "b/draft.md", Frontmatter: map[string]interface{}{"aliases": []interface{}{"Shared"}}},
+	}
+	repairer := NewLinkRepairer(files)
+	repairer.AddRename("notes/old-name.md", "notes/middle-name.md")
+	repairer.AddRename("notes/middle-name.md", "notes/new-name.md")
+
+	tests := []struct {
+		name   string
+		target string
+		want   []RepairSuggestion
+	}{
+		{"renamed twice", "old-name", []RepairSuggestion{{Target: "notes/new-name.md", Score: 1, Reason: RepairRenamed}}},
+		{"renamed by path", "notes/old-name.md", []RepairSuggestion{{Target: "notes/new-name.md", Score: 1, Reason: RepairRenamed}}},
+		{"alias", "big plan", []RepairSuggestion{{Target: "notes/roadmap.md", Score: 1, Reason: RepairAlias}}},
+		{"timestamp prefix", "Polysecure", []RepairSuggestion{{Target: "books/20241020122921-Polysecure.md", Score: maxSimilarScore, Reason: RepairSimilar}}},
+		{"separators and case", "New Name", []RepairSuggestion{{Target: "notes/new-name.md", Score: maxSimilarScore, Reason: RepairSimilar}}},
+		{"shared alias", "Shared", []RepairSuggestion{{Target: "a/draft.md", Score: 0.5, Reason: RepairAlias}, {Target: "b/draft.md", Score: 0.5, Reason: RepairAlias}}},
+		{"attachment", "image.png", nil},
+		{"nothing similar", "completely different", []RepairSuggestion{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repairer.Suggest(vault.Link{Type: vault.WikiLink, Target: tt.target}, 3)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Misspellings score by edit distance
+	got := repairer.Suggest(vault.Link{Type: vault.WikiLink, Target: "roadmapp"}, 3)
+	require.Len(t, got, 1)
+	assert.Equal(t, "notes/roadmap.md", got[0].Target)
+	assert.InDelta(t, 0.875, got[0].Score, 0.001)
+
+	repairer.MinScore = 0.9
+	assert.Empty(t, repairer.Suggest(vault.Link{Type: vault.WikiLink, Target: "roadmapp"}, 3))
+}
+
+func TestLinkRepairer_RepairedLink(t *testing.T) {
+	repairer := NewLinkRepairer([]*vault.VaultFile{
+		{RelativePath: "notes/roadmap.md"},
+		{RelativePath: "a/draft.md"},
+		{RelativePath: "b/draft.md"},
+	})
+
+	tests := []struct {
+		name       string
+		link       vault.Link
+		suggestion RepairSuggestion
+		want       string
+	}{
+		{"unique name", vault.Link{Type: vault.WikiLink, Target: "roadmapp", Fragment: "Goals"}, RepairSuggestion{Target: "notes/roadmap.md", Reason: RepairSimilar}, "[[roadmap#Goals]]"},
+		{"ambiguous name", vault.Link{Type: vault.WikiLink, Target: "drafts"}, RepairSuggestion{Target: "b/draft.md", Reason: RepairSimilar}, "[[b/draft]]"},
+		{"alias keeps text", vault.Link{Type: vault.WikiLink, Target: "Big Plan"}, RepairSuggestion{Target: "notes/roadmap.md", Reason: RepairAlias}, "[[roadmap|Big Plan]]"},
+		{"markdown link", vault.Link{Type: vault.MarkdownLink, Target: "roadmap.md", Text: "plan"}, RepairSuggestion{Target: "notes/roadmap.md", Reason: RepairSimilar}, "[plan](notes/roadmap.md)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repairer.RepairedLink(tt.link, tt.suggestion))
+		})
+	}
+}
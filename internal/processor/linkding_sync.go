@@ -2,10 +2,17 @@ package processor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/eoinhurrell/mdnotes/internal/linkding"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
 
@@ -17,11 +24,16 @@ type LinkdingClient interface {
 	GetBookmark(ctx context.Context, id int) (*linkding.BookmarkResponse, error)
 	DeleteBookmark(ctx context.Context, id int) error
 	CheckBookmark(ctx context.Context, url string) (*linkding.CheckBookmarkResponse, error)
+	ListAllBookmarks(ctx context.Context) ([]linkding.BookmarkResponse, error)
 }
 
 // ProgressCallback is called for each file processed during sync
 type ProgressCallback func(result SyncResult)
 
+// BatchCallback is called with the results of each batch of files synced.
+// Returning an error stops the sync.
+type BatchCallback func(results []SyncResult) error
+
 // LinkdingSyncConfig configures the Linkding synchronization
 type LinkdingSyncConfig struct {
 	URLField         string           // Frontmatter field containing the URL
@@ -37,6 +49,13 @@ type LinkdingSyncConfig struct {
 	DryRun           bool             // Whether to perform a dry run
 	SkipVerification bool             // Whether to skip verification of existing bookmarks
 	ProgressCallback ProgressCallback // Optional callback for real-time progress
+	Direction        string           // Two-way sync direction: push, pull or both
+	Prefer           string           // Side that wins a two-way conflict: local, remote or newest
+	NoteDir          string           // Folder for notes imported from Linkding, relative to VaultRoot
+	VaultRoot        string           // Vault root imported notes are created under
+	Concurrency      int              // Files synced in parallel (default 1)
+	BatchSize        int              // Files per batch reported to BatchCallback (default: all)
+	BatchCallback    BatchCallback    // Optional callback after each batch, e.g. to save progress
 }
 
 // LinkdingSync handles synchronization between vault files and Linkding
@@ -51,6 +70,7 @@ type SyncResult struct {
 	Action     string // "created", "updated", "skipped", "error"
 	BookmarkID int
 	Error      error
+	Conflict   bool // Both note and bookmark changed since the last two-way sync
 }
 
 // NewLinkdingSync creates a new Linkding sync processor
@@ -85,6 +105,11 @@ func (ls *LinkdingSync) SetClient(client LinkdingClient) {
 	ls.client = client
 }
 
+// SetBatchCallback sets the callback SyncBatch calls after each batch
+func (ls *LinkdingSync) SetBatchCallback(callback BatchCallback) {
+	ls.config.BatchCallback = callback
+}
+
 // FindUnsyncedFiles returns files that have URLs but no Linkding IDs
 func (ls *LinkdingSync) FindUnsyncedFiles(files []*vault.VaultFile) []*vault.VaultFile {
 	var unsynced []*vault.VaultFile
@@ -226,76 +251,216 @@ func (ls *LinkdingSync) UpdateExisting(ctx context.Context, file *vault.VaultFil
 	return nil
 }
 
-// SyncBatch synchronizes multiple files with Linkding
+// SyncBatch synchronizes multiple files with Linkding. Files are synced by
+// Concurrency workers in batches of BatchSize, and BatchCallback receives each
+// batch's results in file order once the batch is done. When ctx is cancelled,
+// files already being synced are finished and reported before it returns.
 func (ls *LinkdingSync) SyncBatch(ctx context.Context, files []*vault.VaultFile) ([]SyncResult, error) {
+	batchSize := ls.config.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(files)
+	}
+
 	var results []SyncResult
+	for start := 0; start < len(files); start += batchSize {
+		end := min(start+batchSize, len(files))
+		batch := ls.syncFiles(ctx, files[start:end])
+		results = append(results, batch...)
+
+		if ls.config.BatchCallback != nil && len(batch) > 0 {
+			if err := ls.config.BatchCallback(batch); err != nil {
+				return results, err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+	}
 
-	for _, file := range files {
+	return results, nil
+}
+
+// syncFiles syncs files with Concurrency workers, returning the results in
+// file order. Files not started before ctx is cancelled are left out.
+func (ls *LinkdingSync) syncFiles(ctx context.Context, files []*vault.VaultFile) []SyncResult {
+	workers := ls.config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]SyncResult, len(files))
+	started := make([]bool, len(files))
+	indexes := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Requests in flight finish even if ctx is cancelled, so their results are kept
+	syncCtx := context.WithoutCancel(ctx)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := ls.syncResult(syncCtx, files[i])
+
+				mu.Lock()
+				results[i] = result
+				// Call progress callback if provided
+				if ls.config.ProgressCallback != nil {
+					ls.config.ProgressCallback(result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range files {
 		select {
 		case <-ctx.Done():
-			return results, ctx.Err()
-		default:
+			break feed
+		case indexes <- i:
+			started[i] = true
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	var done []SyncResult
+	for i, result := range results {
+		if started[i] {
+			done = append(done, result)
 		}
+	}
+	return done
+}
 
-		result := SyncResult{File: file}
+// syncResult syncs a single file and describes what was done
+func (ls *LinkdingSync) syncResult(ctx context.Context, file *vault.VaultFile) SyncResult {
+	result := SyncResult{File: file}
 
-		if !ls.hasURL(file) {
-			result.Action = "skipped"
-			results = append(results, result)
-			continue
+	if !ls.hasURL(file) {
+		result.Action = "skipped"
+		return result
+	}
+
+	// Store initial state to determine action
+	hadLinkdingID := ls.hasLinkdingID(file)
+	var initialID int
+	if hadLinkdingID {
+		if id, ok := file.Frontmatter[ls.config.IDField].(int); ok {
+			initialID = id
+		} else if f, ok := file.Frontmatter[ls.config.IDField].(float64); ok {
+			initialID = int(f)
 		}
+	}
 
-		// Store initial state to determine action
-		hadLinkdingID := ls.hasLinkdingID(file)
-		var initialID int
-		if hadLinkdingID {
+	err := ls.SyncFile(ctx, file)
+	if err != nil {
+		result.Action = "error"
+		result.Error = err
+	} else {
+		// Determine what action was taken
+		if ls.hasLinkdingID(file) {
 			if id, ok := file.Frontmatter[ls.config.IDField].(int); ok {
-				initialID = id
+				result.BookmarkID = id
+				if hadLinkdingID && initialID == id {
+					result.Action = "verified"
+				} else if hadLinkdingID && initialID != id {
+					result.Action = "updated"
+				} else {
+					result.Action = "created"
+				}
 			} else if f, ok := file.Frontmatter[ls.config.IDField].(float64); ok {
-				initialID = int(f)
-			}
-		}
-
-		err := ls.SyncFile(ctx, file)
-		if err != nil {
-			result.Action = "error"
-			result.Error = err
-		} else {
-			// Determine what action was taken
-			if ls.hasLinkdingID(file) {
-				if id, ok := file.Frontmatter[ls.config.IDField].(int); ok {
-					result.BookmarkID = id
-					if hadLinkdingID && initialID == id {
-						result.Action = "verified"
-					} else if hadLinkdingID && initialID != id {
-						result.Action = "updated"
-					} else {
-						result.Action = "created"
-					}
-				} else if f, ok := file.Frontmatter[ls.config.IDField].(float64); ok {
-					result.BookmarkID = int(f)
-					if hadLinkdingID && initialID == int(f) {
-						result.Action = "verified"
-					} else if hadLinkdingID && initialID != int(f) {
-						result.Action = "updated"
-					} else {
-						result.Action = "created"
-					}
+				result.BookmarkID = int(f)
+				if hadLinkdingID && initialID == int(f) {
+					result.Action = "verified"
+				} else if hadLinkdingID && initialID != int(f) {
+					result.Action = "updated"
+				} else {
+					result.Action = "created"
 				}
-			} else {
-				result.Action = "skipped"
 			}
+		} else {
+			result.Action = "skipped"
 		}
+	}
+
+	return result
+}
+
+// DefaultLinkdingCursorFile is where the progress of an interrupted push sync
+// is kept, relative to the vault root
+const DefaultLinkdingCursorFile = ".mdnotes/linkding-cursor.json"
+
+// LinkdingCursor records how far a push sync got through the vault's notes, in
+// path order, so an interrupted sync can resume instead of starting over
+type LinkdingCursor struct {
+	After string   `json:"after"` // Notes up to and including this path are done
+	Retry []string `json:"retry"` // Notes before After that failed and are synced again
+}
+
+// LoadLinkdingCursor reads the cursor at path. A missing file yields an empty
+// cursor, which resumes from the start.
+func LoadLinkdingCursor(path string) (*LinkdingCursor, error) {
+	cursor := &LinkdingCursor{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cursor, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading linkding cursor: %w", err)
+	}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, fmt.Errorf("parsing linkding cursor %s: %w", path, err)
+	}
+	return cursor, nil
+}
 
-		results = append(results, result)
+// Save writes the cursor to path
+func (c *LinkdingCursor) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding linkding cursor: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating linkding cursor directory: %w", err)
+	}
+	if err := safety.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing linkding cursor: %w", err)
+	}
+	return nil
+}
 
-		// Call progress callback if provided
-		if ls.config.ProgressCallback != nil {
-			ls.config.ProgressCallback(result)
+// Remaining returns the files still to sync, sorted by path
+func (c *LinkdingCursor) Remaining(files []*vault.VaultFile) []*vault.VaultFile {
+	sorted := append([]*vault.VaultFile(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].RelativePath < sorted[j].RelativePath
+	})
+
+	var remaining []*vault.VaultFile
+	for _, file := range sorted {
+		if c.After == "" || file.RelativePath > c.After || slices.Contains(c.Retry, file.RelativePath) {
+			remaining = append(remaining, file)
 		}
 	}
+	return remaining
+}
 
-	return results, nil
+// Advance moves the cursor past a batch of results, which must be in path
+// order, remembering the files that failed
+func (c *LinkdingCursor) Advance(results []SyncResult) {
+	for _, result := range results {
+		path := result.File.RelativePath
+		c.Retry = slices.DeleteFunc(c.Retry, func(p string) bool { return p == path })
+		if result.Action == "error" {
+			c.Retry = append(c.Retry, path)
+		}
+		if path > c.After {
+			c.After = path
+		}
+	}
 }
 
 // hasURL checks if the file has a valid URL
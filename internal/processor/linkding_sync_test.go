@@ -2,10 +2,13 @@ package processor
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/eoinhurrell/mdnotes/internal/linkding"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
@@ -61,6 +64,14 @@ func (m *MockLinkdingClient) CheckBookmark(ctx context.Context, url string) (*li
 	return args.Get(0).(*linkding.CheckBookmarkResponse), args.Error(1)
 }
 
+func (m *MockLinkdingClient) ListAllBookmarks(ctx context.Context) ([]linkding.BookmarkResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]linkding.BookmarkResponse), args.Error(1)
+}
+
 func TestLinkdingSync_FindUnsyncedFiles(t *testing.T) {
 	files := []*vault.VaultFile{
 		{
@@ -352,6 +363,101 @@ func TestLinkdingSync_SyncBatch(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestLinkdingSync_SyncBatchConcurrentBatches(t *testing.T) {
+	mockClient := &MockLinkdingClient{}
+	mockClient.On("GetBookmark", mock.Anything, mock.Anything).Return(&linkding.BookmarkResponse{}, nil)
+
+	var files []*vault.VaultFile
+	for i := 1; i <= 10; i++ {
+		files = append(files, &vault.VaultFile{
+			RelativePath: fmt.Sprintf("note%02d.md", i),
+			Frontmatter: map[string]interface{}{
+				"url":         fmt.Sprintf("https://example%d.com", i),
+				"linkding_id": i,
+			},
+		})
+	}
+
+	var batches [][]string
+	ls := NewLinkdingSync(LinkdingSyncConfig{
+		Concurrency: 4,
+		BatchSize:   3,
+		BatchCallback: func(results []SyncResult) error {
+			var paths []string
+			for _, result := range results {
+				paths = append(paths, result.File.RelativePath)
+			}
+			batches = append(batches, paths)
+			return nil
+		},
+	})
+	ls.SetClient(mockClient)
+
+	results, err := ls.SyncBatch(context.Background(), files)
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+	for i, result := range results {
+		assert.Equal(t, files[i], result.File, "results keep file order")
+		assert.Equal(t, "verified", result.Action)
+	}
+	assert.Equal(t, [][]string{
+		{"note01.md", "note02.md", "note03.md"},
+		{"note04.md", "note05.md", "note06.md"},
+		{"note07.md", "note08.md", "note09.md"},
+		{"note10.md"},
+	}, batches)
+}
+
+func TestLinkdingSync_SyncBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ls := NewLinkdingSync(LinkdingSyncConfig{})
+	ls.SetClient(&MockLinkdingClient{})
+
+	files := []*vault.VaultFile{{Frontmatter: map[string]interface{}{"url": "https://example.com"}}}
+	results, err := ls.SyncBatch(ctx, files)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, results)
+}
+
+func TestLinkdingCursor(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "c.md"},
+		{RelativePath: "a.md"},
+		{RelativePath: "b.md"},
+		{RelativePath: "d.md"},
+	}
+
+	cursor := &LinkdingCursor{}
+	assert.Equal(t, []*vault.VaultFile{files[1], files[2], files[0], files[3]}, cursor.Remaining(files))
+
+	cursor.Advance([]SyncResult{
+		{File: files[1], Action: "created"},
+		{File: files[2], Action: "error"},
+		{File: files[0], Action: "verified"},
+	})
+	assert.Equal(t, "c.md", cursor.After)
+	assert.Equal(t, []string{"b.md"}, cursor.Retry)
+
+	path := filepath.Join(t.TempDir(), ".mdnotes", "linkding-cursor.json")
+	require.NoError(t, cursor.Save(path))
+	loaded, err := LoadLinkdingCursor(path)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, loaded)
+
+	// Failed notes are retried along with the notes not yet reached
+	assert.Equal(t, []*vault.VaultFile{files[2], files[3]}, loaded.Remaining(files))
+
+	loaded.Advance([]SyncResult{{File: files[2], Action: "created"}})
+	assert.Empty(t, loaded.Retry)
+	assert.Equal(t, "c.md", loaded.After)
+
+	missing, err := LoadLinkdingCursor(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, &LinkdingCursor{}, missing)
+}
+
 // TestLinkdingSync_IsArchivedFlag verifies that bookmarks are created and updated with IsArchived = true
 func TestLinkdingSync_IsArchivedFlag(t *testing.T) {
 	config := LinkdingSyncConfig{
@@ -0,0 +1,386 @@
+package processor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/linkding"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Directions a Linkding sync can copy changes in
+const (
+	LinkdingPush = "push" // Notes to bookmarks
+	LinkdingPull = "pull" // Bookmarks to notes
+	LinkdingBoth = "both"
+)
+
+// LinkdingDirections lists the sync directions
+var LinkdingDirections = []string{LinkdingPush, LinkdingPull, LinkdingBoth}
+
+// Policies for a note and bookmark that both changed since the last sync
+const (
+	PreferLocal  = "local"
+	PreferRemote = "remote"
+	PreferNewest = "newest"
+)
+
+// LinkdingPolicies lists the conflict policies
+var LinkdingPolicies = []string{PreferLocal, PreferRemote, PreferNewest}
+
+// DefaultLinkdingStateFile is where two-way sync state is kept, relative to
+// the vault root
+const DefaultLinkdingStateFile = ".mdnotes/linkding-state.json"
+
+// LinkdingState remembers each note and bookmark as they were after the last
+// two-way sync, so the next sync can tell which side changed
+type LinkdingState struct {
+	Bookmarks map[int]LinkdingStateEntry `json:"bookmarks"`
+}
+
+// LinkdingStateEntry is the synced state of one bookmark
+type LinkdingStateEntry struct {
+	Path           string `json:"path"`            // Note the bookmark is synced with
+	Fields         string `json:"fields"`          // Hash of the note's synced fields
+	RemoteModified string `json:"remote_modified"` // The bookmark's date_modified
+}
+
+// LoadLinkdingState reads the sync state at path. A missing file yields an
+// empty state, as before the first sync.
+func LoadLinkdingState(path string) (*LinkdingState, error) {
+	state := &LinkdingState{Bookmarks: make(map[int]LinkdingStateEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading linkding state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing linkding state %s: %w", path, err)
+	}
+	if state.Bookmarks == nil {
+		state.Bookmarks = make(map[int]LinkdingStateEntry)
+	}
+	return state, nil
+}
+
+// Save writes the sync state to path
+func (s *LinkdingState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding linkding state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating linkding state directory: %w", err)
+	}
+	if err := safety.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing linkding state: %w", err)
+	}
+	return nil
+}
+
+// bookmarkFields are the note fields a two-way sync keeps equal to the bookmark
+type bookmarkFields struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+func (f bookmarkFields) hash() string {
+	data, _ := json.Marshal(f)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Reconcile synchronizes notes and bookmarks in the configured direction,
+// recording what was synced in state. Notes are matched to bookmarks by ID,
+// then by URL. A change on one side since the last sync is copied to the
+// other; when both changed, the configured policy picks the side that wins.
+// Notes without a bookmark get one when pushing, and bookmarks without a note
+// are imported as new notes in NoteDir when pulling. Locked notes are matched
+// but never changed.
+func (ls *LinkdingSync) Reconcile(ctx context.Context, files []*vault.VaultFile, state *LinkdingState) ([]SyncResult, error) {
+	bookmarks, err := ls.client.ListAllBookmarks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing bookmarks: %w", err)
+	}
+	byID := make(map[int]*linkding.BookmarkResponse, len(bookmarks))
+	byURL := make(map[string]*linkding.BookmarkResponse, len(bookmarks))
+	for i := range bookmarks {
+		byID[bookmarks[i].ID] = &bookmarks[i]
+		byURL[strings.TrimSpace(bookmarks[i].URL)] = &bookmarks[i]
+	}
+
+	push := ls.config.Direction != LinkdingPull
+	pull := ls.config.Direction != LinkdingPush
+	matched := make(map[int]bool)
+	paths := make(map[string]bool, len(files))
+	var results []SyncResult
+
+	for _, file := range files {
+		paths[strings.ToLower(file.RelativePath)] = true
+	}
+
+	for _, file := range ls.FindAllSyncableFiles(files) {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := SyncResult{File: file, Action: "unchanged"}
+
+		id, hasID := ls.linkdingID(file)
+		bookmark := byID[id]
+		if bookmark == nil {
+			bookmark = byURL[ls.noteFields(file).URL]
+		}
+		if bookmark != nil {
+			matched[bookmark.ID] = true
+		}
+
+		switch {
+		case file.IsLocked():
+			result.Action = "skipped"
+		case bookmark == nil && push:
+			result = ls.createBookmark(ctx, file, state)
+		case bookmark == nil:
+			result.Action = "skipped"
+		default:
+			if !hasID || id != bookmark.ID {
+				file.SetField(ls.config.IDField, bookmark.ID)
+				result.Action = "linked"
+			}
+			result.BookmarkID = bookmark.ID
+			ls.reconcileFile(ctx, file, bookmark, state, &result, push, pull)
+		}
+
+		results = append(results, result)
+		if ls.config.ProgressCallback != nil {
+			ls.config.ProgressCallback(result)
+		}
+	}
+
+	if pull {
+		for i := range bookmarks {
+			bookmark := &bookmarks[i]
+			if matched[bookmark.ID] {
+				continue
+			}
+			file := ls.importBookmark(bookmark, paths)
+			state.Bookmarks[bookmark.ID] = LinkdingStateEntry{
+				Path:           file.RelativePath,
+				Fields:         ls.noteFields(file).hash(),
+				RemoteModified: bookmark.DateModified,
+			}
+			result := SyncResult{File: file, Action: "imported", BookmarkID: bookmark.ID}
+			results = append(results, result)
+			if ls.config.ProgressCallback != nil {
+				ls.config.ProgressCallback(result)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// reconcileFile copies whichever of file and bookmark changed since the last
+// sync to the other
+func (ls *LinkdingSync) reconcileFile(ctx context.Context, file *vault.VaultFile, bookmark *linkding.BookmarkResponse, state *LinkdingState, result *SyncResult, push, pull bool) {
+	local := ls.noteFields(file)
+	remote := remoteFields(bookmark)
+
+	if local.hash() != remote.hash() {
+		entry, known := state.Bookmarks[bookmark.ID]
+		localChanged := !known || entry.Fields != local.hash()
+		remoteChanged := !known || entry.RemoteModified != bookmark.DateModified
+
+		useLocal := localChanged && !remoteChanged
+		if localChanged == remoteChanged {
+			result.Conflict = true
+			useLocal = ls.preferLocal(file, bookmark)
+		}
+
+		switch {
+		case useLocal && push:
+			if !ls.config.DryRun {
+				updated, err := ls.client.UpdateBookmark(ctx, bookmark.ID, linkding.UpdateBookmarkRequest{
+					URL:         local.URL,
+					Title:       local.Title,
+					Description: local.Description,
+					Tags:        local.Tags,
+				})
+				if err != nil {
+					result.Action = "error"
+					result.Error = fmt.Errorf("updating bookmark %d: %w", bookmark.ID, err)
+					return
+				}
+				bookmark = updated
+			}
+			result.Action = "pushed"
+		case !useLocal && pull:
+			ls.applyBookmark(file, remote)
+			result.Action = "pulled"
+		default:
+			// The winning side can't be copied in this direction
+			if result.Action != "linked" {
+				result.Action = "skipped"
+			}
+			return
+		}
+	}
+
+	state.Bookmarks[bookmark.ID] = LinkdingStateEntry{
+		Path:           file.RelativePath,
+		Fields:         ls.noteFields(file).hash(),
+		RemoteModified: bookmark.DateModified,
+	}
+}
+
+// createBookmark creates a bookmark for a note that has none
+func (ls *LinkdingSync) createBookmark(ctx context.Context, file *vault.VaultFile, state *LinkdingState) SyncResult {
+	result := SyncResult{File: file, Action: "created"}
+	if ls.config.DryRun {
+		return result
+	}
+
+	local := ls.noteFields(file)
+	bookmark, err := ls.client.CreateBookmark(ctx, linkding.CreateBookmarkRequest{
+		URL:         local.URL,
+		Title:       local.Title,
+		Description: local.Description,
+		Tags:        local.Tags,
+	})
+	if err != nil {
+		result.Action = "error"
+		result.Error = fmt.Errorf("creating bookmark: %w", err)
+		return result
+	}
+
+	file.SetField(ls.config.IDField, bookmark.ID)
+	result.BookmarkID = bookmark.ID
+	state.Bookmarks[bookmark.ID] = LinkdingStateEntry{
+		Path:           file.RelativePath,
+		Fields:         local.hash(),
+		RemoteModified: bookmark.DateModified,
+	}
+	return result
+}
+
+// importBookmark creates a literature note for a bookmark, named after its
+// title, in NoteDir
+func (ls *LinkdingSync) importBookmark(bookmark *linkding.BookmarkResponse, paths map[string]bool) *vault.VaultFile {
+	name := sanitizeNoteName(bookmark.Title)
+	if name == "" {
+		name = "Bookmark " + strconv.Itoa(bookmark.ID)
+	}
+	rel := filepath.Join(ls.config.NoteDir, name+".md")
+	if paths[strings.ToLower(rel)] {
+		rel = filepath.Join(ls.config.NoteDir, fmt.Sprintf("%s %d.md", name, bookmark.ID))
+	}
+	paths[strings.ToLower(rel)] = true
+
+	file := &vault.VaultFile{
+		Path:         filepath.Join(ls.config.VaultRoot, rel),
+		RelativePath: rel,
+		Frontmatter:  make(map[string]interface{}),
+		Modified:     time.Now(),
+	}
+	file.SetField(ls.config.IDField, bookmark.ID)
+	ls.applyBookmark(file, remoteFields(bookmark))
+	heading := bookmark.Title
+	if heading == "" {
+		heading = bookmark.URL
+	}
+	file.Body = "# " + heading + "\n"
+	return file
+}
+
+// applyBookmark sets the note's synced fields to the bookmark's, removing
+// fields the bookmark leaves empty
+func (ls *LinkdingSync) applyBookmark(file *vault.VaultFile, remote bookmarkFields) {
+	set := func(field, value string) {
+		if value == "" {
+			delete(file.Frontmatter, field)
+		} else {
+			file.SetField(field, value)
+		}
+	}
+	set(ls.config.URLField, remote.URL)
+	set(ls.config.TitleField, remote.Title)
+	set(ls.config.DescriptionField, remote.Description)
+	if len(remote.Tags) == 0 {
+		delete(file.Frontmatter, ls.config.TagsField)
+	} else {
+		tags := make([]interface{}, len(remote.Tags))
+		for i, tag := range remote.Tags {
+			tags[i] = tag
+		}
+		file.SetField(ls.config.TagsField, tags)
+	}
+}
+
+// noteFields reads the synced fields from a note
+func (ls *LinkdingSync) noteFields(file *vault.VaultFile) bookmarkFields {
+	str := func(field string) string {
+		value, _ := file.Frontmatter[field].(string)
+		return strings.TrimSpace(value)
+	}
+	tags := ls.getTags(file)
+	sort.Strings(tags)
+	return bookmarkFields{
+		URL:         str(ls.config.URLField),
+		Title:       str(ls.config.TitleField),
+		Description: str(ls.config.DescriptionField),
+		Tags:        tags,
+	}
+}
+
+// remoteFields reads the synced fields from a bookmark
+func remoteFields(bookmark *linkding.BookmarkResponse) bookmarkFields {
+	tags := append([]string{}, bookmark.Tags...)
+	sort.Strings(tags)
+	return bookmarkFields{
+		URL:         strings.TrimSpace(bookmark.URL),
+		Title:       strings.TrimSpace(bookmark.Title),
+		Description: strings.TrimSpace(bookmark.Description),
+		Tags:        tags,
+	}
+}
+
+// preferLocal applies the conflict policy, reporting whether the note wins
+func (ls *LinkdingSync) preferLocal(file *vault.VaultFile, bookmark *linkding.BookmarkResponse) bool {
+	switch ls.config.Prefer {
+	case PreferLocal:
+		return true
+	case PreferRemote:
+		return false
+	default:
+		modified, err := time.Parse(time.RFC3339, bookmark.DateModified)
+		if err != nil {
+			return true
+		}
+		return !file.Modified.Before(modified)
+	}
+}
+
+// linkdingID returns the note's bookmark ID, if it has a valid one
+func (ls *LinkdingSync) linkdingID(file *vault.VaultFile) (int, bool) {
+	switch v := file.Frontmatter[ls.config.IDField].(type) {
+	case int:
+		return v, v > 0
+	case float64:
+		return int(v), v > 0
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,211 @@
+package processor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/linkding"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func newReconcileSync(client LinkdingClient, direction, prefer string) *LinkdingSync {
+	sync := NewLinkdingSync(LinkdingSyncConfig{
+		Direction: direction,
+		Prefer:    prefer,
+		NoteDir:   "Literature",
+		VaultRoot: "/vault",
+	})
+	sync.client = client
+	return sync
+}
+
+func syncedNote(path string, id int, title string) *vault.VaultFile {
+	return &vault.VaultFile{
+		Path:         path,
+		RelativePath: path,
+		Frontmatter: map[string]interface{}{
+			"url":         "https://example.com/" + path,
+			"title":       title,
+			"linkding_id": id,
+		},
+	}
+}
+
+func TestLinkdingSync_Reconcile_Pull(t *testing.T) {
+	mockClient := &MockLinkdingClient{}
+	mockClient.On("ListAllBookmarks", mock.Anything).Return([]linkding.BookmarkResponse{
+		{ID: 1, URL: "https://example.com/note.md", Title: "Remote title", Tags: []string{"go"}, DateModified: "2024-01-02T00:00:00Z"},
+		{ID: 2, URL: "https://example.com/new", Title: "New: Article", Description: "About it", DateModified: "2024-01-01T00:00:00Z"},
+	}, nil)
+
+	note := syncedNote("note.md", 1, "Local title")
+	state := &LinkdingState{Bookmarks: map[int]LinkdingStateEntry{
+		1: {Path: "note.md", Fields: NewLinkdingSync(LinkdingSyncConfig{}).noteFields(note).hash(), RemoteModified: "2024-01-01T00:00:00Z"},
+	}}
+
+	sync := newReconcileSync(mockClient, LinkdingPull, PreferNewest)
+	results, err := sync.Reconcile(context.Background(), []*vault.VaultFile{note}, state)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// Only the bookmark changed, so it wins without a conflict
+	assert.Equal(t, "pulled", results[0].Action)
+	assert.False(t, results[0].Conflict)
+	assert.Equal(t, "Remote title", note.Frontmatter["title"])
+	assert.Equal(t, []interface{}{"go"}, note.Frontmatter["tags"])
+
+	imported := results[1]
+	assert.Equal(t, "imported", imported.Action)
+	assert.Equal(t, filepath.Join("Literature", "New- Article.md"), imported.File.RelativePath)
+	assert.Equal(t, filepath.Join("/vault", "Literature", "New- Article.md"), imported.File.Path)
+	assert.Equal(t, 2, imported.File.Frontmatter["linkding_id"])
+	assert.Equal(t, "About it", imported.File.Frontmatter["description"])
+	assert.Equal(t, "# New: Article\n", imported.File.Body)
+
+	assert.Equal(t, "2024-01-02T00:00:00Z", state.Bookmarks[1].RemoteModified)
+	assert.Contains(t, state.Bookmarks, 2)
+	mockClient.AssertNotCalled(t, "UpdateBookmark", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLinkdingSync_Reconcile_Push(t *testing.T) {
+	mockClient := &MockLinkdingClient{}
+	mockClient.On("ListAllBookmarks", mock.Anything).Return([]linkding.BookmarkResponse{
+		{ID: 1, URL: "https://example.com/note.md", Title: "Old title", DateModified: "2024-01-01T00:00:00Z"},
+		{ID: 2, URL: "https://example.com/remote-only", Title: "Remote only"},
+	}, nil)
+	mockClient.On("UpdateBookmark", mock.Anything, 1, mock.MatchedBy(func(req linkding.UpdateBookmarkRequest) bool {
+		return req.Title == "New title"
+	})).Return(&linkding.BookmarkResponse{ID: 1, URL: "https://example.com/note.md", Title: "New title", DateModified: "2024-01-03T00:00:00Z"}, nil)
+	mockClient.On("CreateBookmark", mock.Anything, mock.MatchedBy(func(req linkding.CreateBookmarkRequest) bool {
+		return req.URL == "https://example.com/unsynced"
+	})).Return(&linkding.BookmarkResponse{ID: 3}, nil)
+
+	note := syncedNote("note.md", 1, "New title")
+	unsynced := &vault.VaultFile{
+		Path:        "unsynced.md",
+		Frontmatter: map[string]interface{}{"url": "https://example.com/unsynced"},
+	}
+	state := &LinkdingState{Bookmarks: map[int]LinkdingStateEntry{
+		1: {Path: "note.md", Fields: "stale", RemoteModified: "2024-01-01T00:00:00Z"},
+	}}
+
+	sync := newReconcileSync(mockClient, LinkdingPush, PreferNewest)
+	results, err := sync.Reconcile(context.Background(), []*vault.VaultFile{note, unsynced}, state)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "remote-only bookmarks aren't imported when pushing")
+
+	assert.Equal(t, "pushed", results[0].Action)
+	assert.Equal(t, "created", results[1].Action)
+	assert.Equal(t, 3, unsynced.Frontmatter["linkding_id"])
+	assert.Equal(t, "2024-01-03T00:00:00Z", state.Bookmarks[1].RemoteModified)
+	mockClient.AssertExpectations(t)
+}
+
+func TestLinkdingSync_Reconcile_Conflict(t *testing.T) {
+	remoteModified := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	bookmarks := []linkding.BookmarkResponse{
+		{ID: 1, URL: "https://example.com/note.md", Title: "Remote title", DateModified: remoteModified.Format(time.RFC3339)},
+	}
+
+	tests := []struct {
+		name      string
+		prefer    string
+		modified  time.Time
+		wantTitle string
+		action    string
+	}{
+		{"prefer local", PreferLocal, remoteModified.Add(-time.Hour), "Local title", "pushed"},
+		{"prefer remote", PreferRemote, remoteModified.Add(time.Hour), "Remote title", "pulled"},
+		{"newest is local", PreferNewest, remoteModified.Add(time.Hour), "Local title", "pushed"},
+		{"newest is remote", PreferNewest, remoteModified.Add(-time.Hour), "Remote title", "pulled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockLinkdingClient{}
+			mockClient.On("ListAllBookmarks", mock.Anything).Return(bookmarks, nil)
+			mockClient.On("UpdateBookmark", mock.Anything, 1, mock.Anything).Return(&bookmarks[0], nil)
+
+			note := syncedNote("note.md", 1, "Local title")
+			note.Modified = tt.modified
+			// Both sides differ from the last sync
+			state := &LinkdingState{Bookmarks: map[int]LinkdingStateEntry{
+				1: {Path: "note.md", Fields: "stale", RemoteModified: "2024-01-01T00:00:00Z"},
+			}}
+
+			sync := newReconcileSync(mockClient, LinkdingBoth, tt.prefer)
+			results, err := sync.Reconcile(context.Background(), []*vault.VaultFile{note}, state)
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.True(t, results[0].Conflict)
+			assert.Equal(t, tt.action, results[0].Action)
+			assert.Equal(t, tt.wantTitle, note.Frontmatter["title"])
+		})
+	}
+}
+
+func TestLinkdingSync_Reconcile_LinksByURL(t *testing.T) {
+	mockClient := &MockLinkdingClient{}
+	mockClient.On("ListAllBookmarks", mock.Anything).Return([]linkding.BookmarkResponse{
+		{ID: 7, URL: "https://example.com/page", Title: "Page"},
+	}, nil)
+
+	note := &vault.VaultFile{
+		Path:        "page.md",
+		Frontmatter: map[string]interface{}{"url": "https://example.com/page", "title": "Page"},
+	}
+	state := &LinkdingState{Bookmarks: map[int]LinkdingStateEntry{}}
+
+	sync := newReconcileSync(mockClient, LinkdingBoth, PreferNewest)
+	results, err := sync.Reconcile(context.Background(), []*vault.VaultFile{note}, state)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "linked", results[0].Action)
+	assert.Equal(t, 7, note.Frontmatter["linkding_id"])
+	assert.Contains(t, state.Bookmarks, 7)
+}
+
+func TestLinkdingSync_Reconcile_DryRun(t *testing.T) {
+	mockClient := &MockLinkdingClient{}
+	mockClient.On("ListAllBookmarks", mock.Anything).Return([]linkding.BookmarkResponse{
+		{ID: 1, URL: "https://example.com/note.md", Title: "Remote title", DateModified: "2024-01-02T00:00:00Z"},
+	}, nil)
+
+	note := syncedNote("note.md", 1, "Local title")
+	unsynced := &vault.VaultFile{
+		Path:        "unsynced.md",
+		Frontmatter: map[string]interface{}{"url": "https://example.com/unsynced"},
+	}
+	state := &LinkdingState{Bookmarks: map[int]LinkdingStateEntry{}}
+
+	sync := NewLinkdingSync(LinkdingSyncConfig{Direction: LinkdingBoth, Prefer: PreferLocal, DryRun: true})
+	sync.client = mockClient
+	results, err := sync.Reconcile(context.Background(), []*vault.VaultFile{note, unsynced}, state)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "pushed", results[0].Action)
+	assert.Equal(t, "created", results[1].Action)
+	mockClient.AssertNotCalled(t, "UpdateBookmark", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "CreateBookmark", mock.Anything, mock.Anything)
+}
+
+func TestLinkdingState_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".mdnotes", "linkding-state.json")
+
+	state, err := LoadLinkdingState(path)
+	require.NoError(t, err)
+	assert.Empty(t, state.Bookmarks)
+
+	state.Bookmarks[4] = LinkdingStateEntry{Path: "note.md", Fields: "abc", RemoteModified: "2024-01-01T00:00:00Z"}
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadLinkdingState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state.Bookmarks, loaded.Bookmarks)
+}
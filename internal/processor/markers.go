@@ -0,0 +1,47 @@
+package processor
+
+import "strings"
+
+// Markers around query results written into a note
+const (
+	QueryStartMarker = "<!-- mdnotes:query start -->"
+	QueryEndMarker   = "<!-- mdnotes:query end -->"
+)
+
+// ReplaceMarkedBlock puts content between the start and end markers in body,
+// replacing what was there. Without the markers, they are added with content
+// at the end of body. Text outside the markers is the user's and is never
+// changed.
+func ReplaceMarkedBlock(body, startMarker, endMarker, content string) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	block := startMarker + "\n" + content + endMarker + "\n"
+
+	if start, end, ok := findMarkedBlock(body, startMarker, endMarker); ok {
+		return body[:start] + block + body[end:]
+	}
+	rest := strings.TrimRight(body, "\n")
+	if rest == "" {
+		return block
+	}
+	return rest + "\n\n" + block
+}
+
+// findMarkedBlock returns where the block between the markers in body starts
+// and ends, including the newline after its end marker
+func findMarkedBlock(body, startMarker, endMarker string) (start, end int, ok bool) {
+	start = strings.Index(body, startMarker)
+	if start < 0 {
+		return 0, 0, false
+	}
+	end = strings.Index(body[start:], endMarker)
+	if end < 0 {
+		return 0, 0, false
+	}
+	end += start + len(endMarker)
+	if end < len(body) && body[end] == '\n' {
+		end++
+	}
+	return start, end, true
+}
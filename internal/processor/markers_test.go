@@ -0,0 +1,18 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceMarkedBlock(t *testing.T) {
+	block := QueryStartMarker + "\n- [[A]]\n" + QueryEndMarker + "\n"
+
+	assert.Equal(t, block, ReplaceMarkedBlock("", QueryStartMarker, QueryEndMarker, "- [[A]]"))
+	assert.Equal(t, "# Dashboard\n\n"+block, ReplaceMarkedBlock("# Dashboard\n", QueryStartMarker, QueryEndMarker, "- [[A]]\n"))
+
+	existing := "Intro\n" + QueryStartMarker + "\n- [[Old]]\n" + QueryEndMarker + "\nOutro\n"
+	assert.Equal(t, "Intro\n"+block+"Outro\n", ReplaceMarkedBlock(existing, QueryStartMarker, QueryEndMarker, "- [[A]]\n"))
+	assert.Equal(t, "Intro\n"+QueryStartMarker+"\n"+QueryEndMarker+"\nOutro\n", ReplaceMarkedBlock(existing, QueryStartMarker, QueryEndMarker, ""))
+}
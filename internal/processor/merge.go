@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NoteMerge is the result of merging one note into another
+type NoteMerge struct {
+	Into    *vault.VaultFile // The note kept, with the other's content appended
+	From    *vault.VaultFile // The note merged in, for the caller to delete or archive
+	Heading string           // Heading the merged content was placed under
+	Merged  []string         // Frontmatter fields filled in or extended
+}
+
+// MergeNotes appends from's body to into's under a level 2 heading: heading
+// when given, otherwise the H1 from's body starts with, its title or its file
+// name. from's other headings are demoted to sit beneath it.
+//
+// Frontmatter is merged the way duplicates are: into's fields win, fields it
+// lacks are copied and list fields combine. Tags and aliases combine even when
+// written as a single value, and from's name becomes an alias of into.
+func MergeNotes(into, from *vault.VaultFile, heading string) NoteMerge {
+	merge := NoteMerge{Into: into, From: from}
+	fromName := strings.TrimSuffix(filepath.Base(from.RelativePath), ".md")
+	intoName := strings.TrimSuffix(filepath.Base(into.RelativePath), ".md")
+
+	lines := strings.Split(strings.TrimLeft(from.Body, "\n"), "\n")
+	if m := atxHeadingRegex.FindStringSubmatch(lines[0]); m != nil && len(m[1]) == 1 {
+		if heading == "" {
+			heading = strings.TrimSpace(m[2])
+		}
+		lines = lines[1:]
+	}
+	if heading == "" {
+		if title, ok := from.Frontmatter["title"].(string); ok && strings.TrimSpace(title) != "" {
+			heading = strings.TrimSpace(title)
+		} else {
+			heading = fromName
+		}
+	}
+	merge.Heading = heading
+
+	content := strings.Trim(demoteHeadings(strings.Join(lines, "\n"), 3), "\n")
+	section := "## " + heading + "\n"
+	if content != "" {
+		section += "\n" + content + "\n"
+	}
+	if body := strings.TrimRight(into.Body, "\n"); body != "" {
+		into.Body = body + "\n\n" + section
+	} else {
+		into.Body = section
+	}
+
+	for _, key := range []string{"tags", "aliases"} {
+		existing := listValues(into.Frontmatter[key])
+		combined := append([]interface{}{}, existing...)
+		additions := listValues(from.Frontmatter[key])
+		if key == "aliases" && !strings.EqualFold(fromName, intoName) {
+			additions = append(additions, fromName)
+		}
+		for _, value := range additions {
+			if !containsValue(combined, value) {
+				combined = append(combined, value)
+			}
+		}
+		if len(combined) > len(existing) {
+			into.SetField(key, combined)
+			merge.addMerged(key)
+		}
+	}
+	merge.addMerged(mergeFrontmatter(into, from.Frontmatter)...)
+	return merge
+}
+
+// demoteHeadings shifts the headings of body, outside code blocks, so the
+// shallowest is at level top. Headings are never shifted up or past level 6.
+func demoteHeadings(body string, top int) string {
+	shallowest := 0
+	mapProseLines(body, func(line string) (string, int) {
+		if m := atxHeadingRegex.FindStringSubmatch(line); m != nil && (shallowest == 0 || len(m[1]) < shallowest) {
+			shallowest = len(m[1])
+		}
+		return line, 0
+	})
+	if shallowest == 0 || shallowest >= top {
+		return body
+	}
+
+	shift := top - shallowest
+	body, _ = mapProseLines(body, func(line string) (string, int) {
+		m := atxHeadingRegex.FindStringSubmatchIndex(line)
+		if m == nil {
+			return line, 0
+		}
+		level := min(m[3]-m[2]+shift, 6)
+		return line[:m[2]] + strings.Repeat("#", level) + line[m[3]:], 1
+	})
+	return body
+}
+
+// listValues returns a frontmatter value as a list, treating a single value
+// as a list of one
+func listValues(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		return []interface{}{v}
+	default:
+		return []interface{}{v}
+	}
+}
+
+func (m *NoteMerge) addMerged(keys ...string) {
+	for _, key := range keys {
+		if !slices.Contains(m.Merged, key) {
+			m.Merged = append(m.Merged, key)
+		}
+	}
+}
+
+// MergeMove returns the link rewrite that points links to the merged note
+// at the one it was merged into
+func (m NoteMerge) MergeMove() FileMove {
+	return FileMove{From: m.From.RelativePath, To: m.Into.RelativePath}
+}
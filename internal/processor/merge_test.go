@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestMergeNotes(t *testing.T) {
+	into := &vault.VaultFile{
+		RelativePath: "notes/Coffee.md",
+		Frontmatter:  map[string]interface{}{"tags": []interface{}{"drinks"}, "status": "draft"},
+		Body:         "# Coffee\n\nBeans.\n\n## Brewing\n\nPour over.\n",
+	}
+	from := &vault.VaultFile{
+		RelativePath: "inbox/Espresso.md",
+		Frontmatter: map[string]interface{}{
+			"tags":    "coffee",
+			"aliases": []interface{}{"Short black"},
+			"status":  "done",
+			"source":  "cafe",
+		},
+		Body: "\n# Espresso notes\n\nPressure.\n\n# Grind\n\nFine.\n\n```\n# not a heading\n```\n",
+	}
+
+	merge := MergeNotes(into, from, "")
+
+	assert.Equal(t, "Espresso notes", merge.Heading)
+	assert.Equal(t, "# Coffee\n\nBeans.\n\n## Brewing\n\nPour over.\n\n## Espresso notes\n\nPressure.\n\n### Grind\n\nFine.\n\n```\n# not a heading\n```\n", into.Body)
+	assert.Equal(t, []interface{}{"drinks", "coffee"}, into.Frontmatter["tags"])
+	assert.Equal(t, []interface{}{"Short black", "Espresso"}, into.Frontmatter["aliases"])
+	assert.Equal(t, "draft", into.Frontmatter["status"])
+	assert.Equal(t, "cafe", into.Frontmatter["source"])
+	assert.Equal(t, []string{"tags", "aliases", "source"}, merge.Merged)
+	assert.Equal(t, FileMove{From: "inbox/Espresso.md", To: "notes/Coffee.md"}, merge.MergeMove())
+}
+
+func TestMergeNotes_Heading(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    *vault.VaultFile
+		heading string
+		want    string
+	}{
+		{
+			name: "title",
+			from: &vault.VaultFile{RelativePath: "b.md", Frontmatter: map[string]interface{}{"title": "Bee"}, Body: "Text.\n"},
+			want: "A.\n\n## Bee\n\nText.\n",
+		},
+		{
+			name: "file name",
+			from: &vault.VaultFile{RelativePath: "b.md", Body: "#### Deep\n"},
+			want: "A.\n\n## b\n\n#### Deep\n",
+		},
+		{
+			name:    "given heading replaces H1",
+			from:    &vault.VaultFile{RelativePath: "b.md", Body: "# Bee\n## Part\n"},
+			heading: "Merged",
+			want:    "A.\n\n## Merged\n\n### Part\n",
+		},
+		{
+			name: "empty body",
+			from: &vault.VaultFile{RelativePath: "b.md", Body: "# Bee\n"},
+			want: "A.\n\n## Bee\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			into := &vault.VaultFile{RelativePath: "a.md", Body: "A.\n"}
+			MergeNotes(into, tt.from, tt.heading)
+			assert.Equal(t, tt.want, into.Body)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package processor
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Markers around the generated part of a map of content
+const (
+	MOCStartMarker = "<!-- mdnotes:moc start -->"
+	MOCEndMarker   = "<!-- mdnotes:moc end -->"
+)
+
+// Default templates for map of content groups and entries
+const (
+	DefaultMOCHeadingTemplate = "## {{group}}"
+	DefaultMOCItemTemplate    = "- {{link}}"
+	DefaultMOCUngrouped       = "Other"
+)
+
+// MOCOptions configures a map of content
+type MOCOptions struct {
+	GroupBy         string // Frontmatter field to group by; "" lists notes without groups
+	HeadingTemplate string // Group heading; variables: group, count
+	ItemTemplate    string // One entry; variables: link plus the note's, as in rename templates
+	Ungrouped       string // Group of notes without a value for GroupBy
+	LinkFormat      string // wiki (default) or markdown
+}
+
+// MOCGenerator renders the generated block of a map of content
+type MOCGenerator struct {
+	opts     MOCOptions
+	renderer *templates.Renderer
+}
+
+// NewMOCGenerator validates the templates in opts and fills in defaults
+func NewMOCGenerator(opts MOCOptions) (*MOCGenerator, error) {
+	if opts.HeadingTemplate == "" {
+		opts.HeadingTemplate = DefaultMOCHeadingTemplate
+	}
+	if opts.ItemTemplate == "" {
+		opts.ItemTemplate = DefaultMOCItemTemplate
+	}
+	if opts.Ungrouped == "" {
+		opts.Ungrouped = DefaultMOCUngrouped
+	}
+	if opts.LinkFormat == "" {
+		opts.LinkFormat = "wiki"
+	}
+	if opts.LinkFormat != "wiki" && opts.LinkFormat != "markdown" {
+		return nil, fmt.Errorf("invalid link format '%s' (supported: wiki, markdown)", opts.LinkFormat)
+	}
+
+	renderer := templates.NewRenderer()
+	if err := renderer.Validate(opts.HeadingTemplate); err != nil {
+		return nil, fmt.Errorf("heading template: %w", err)
+	}
+	if err := renderer.Validate(opts.ItemTemplate); err != nil {
+		return nil, fmt.Errorf("item template: %w", err)
+	}
+	return &MOCGenerator{opts: opts, renderer: renderer}, nil
+}
+
+// MOCGroup is a group of notes in a map of content
+type MOCGroup struct {
+	Name  string
+	Notes []*vault.VaultFile
+}
+
+// Groups sorts notes into groups by the GroupBy field, in name order with
+// the ungrouped notes last. A note with a list value, like tags, is in the
+// group of each value. Without GroupBy there is one unnamed group.
+func (g *MOCGenerator) Groups(notes []*vault.VaultFile) []MOCGroup {
+	sorted := append([]*vault.VaultFile(nil), notes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RelativePath < sorted[j].RelativePath })
+	if g.opts.GroupBy == "" {
+		return []MOCGroup{{Notes: sorted}}
+	}
+
+	byName := make(map[string][]*vault.VaultFile)
+	var ungrouped []*vault.VaultFile
+	for _, note := range sorted {
+		values := groupValues(note.Frontmatter[g.opts.GroupBy], g.opts.GroupBy == "tags")
+		if len(values) == 0 {
+			ungrouped = append(ungrouped, note)
+		}
+		for _, value := range values {
+			byName[value] = append(byName[value], note)
+		}
+	}
+
+	groups := make([]MOCGroup, 0, len(byName)+1)
+	for name, members := range byName {
+		groups = append(groups, MOCGroup{Name: name, Notes: members})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	if len(ungrouped) > 0 {
+		groups = append(groups, MOCGroup{Name: g.opts.Ungrouped, Notes: ungrouped})
+	}
+	return groups
+}
+
+// Render returns the generated block listing notes, markers included. names,
+// from NoteNames over the whole vault, keeps wiki links unambiguous.
+func (g *MOCGenerator) Render(notes []*vault.VaultFile, names map[string]int) (string, error) {
+	var b strings.Builder
+	b.WriteString(MOCStartMarker + "\n")
+	for i, group := range g.Groups(notes) {
+		if group.Name != "" {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			heading, err := g.renderer.Render(g.opts.HeadingTemplate, templates.Vars{
+				"group": group.Name,
+				"count": len(group.Notes),
+			})
+			if err != nil {
+				return "", fmt.Errorf("rendering heading for %s: %w", group.Name, err)
+			}
+			b.WriteString(heading + "\n\n")
+		}
+		for _, note := range group.Notes {
+			item, err := g.renderer.Render(g.opts.ItemTemplate, mocItemScope{
+				link: NoteLink(note.RelativePath, g.opts.LinkFormat, names),
+				file: templates.FileScope(note),
+			})
+			if err != nil {
+				return "", fmt.Errorf("rendering entry for %s: %w", note.RelativePath, err)
+			}
+			b.WriteString(item + "\n")
+		}
+	}
+	b.WriteString(MOCEndMarker + "\n")
+	return b.String(), nil
+}
+
+// UpdateMOCBlock replaces the generated block in body, or appends it when
+// the body has no markers yet
+func UpdateMOCBlock(body, block string) string {
+	if start, end, ok := findMarkedBlock(body, MOCStartMarker, MOCEndMarker); ok {
+		return body[:start] + block + body[end:]
+	}
+
+	body = strings.TrimRight(body, "\n")
+	if body != "" {
+		body += "\n\n"
+	}
+	return body + block
+}
+
+// MOCChildren returns the notes under folder, a vault-relative path where ""
+// is the whole vault, leaving out the map itself at outputRel. Without
+// recursive only the folder's own notes are included.
+func MOCChildren(files []*vault.VaultFile, folder, outputRel string, recursive bool) []*vault.VaultFile {
+	folder = strings.Trim(path.Clean("/"+filepath.ToSlash(folder)), "/")
+	outputRel = filepath.ToSlash(outputRel)
+
+	var children []*vault.VaultFile
+	for _, file := range files {
+		relPath := filepath.ToSlash(file.RelativePath)
+		if relPath == outputRel {
+			continue
+		}
+		dir := path.Dir(relPath)
+		if dir == "." {
+			dir = ""
+		}
+		switch {
+		case dir == folder:
+		case recursive && (folder == "" || strings.HasPrefix(dir, folder+"/")):
+		default:
+			continue
+		}
+		children = append(children, file)
+	}
+	return children
+}
+
+// groupValues returns the groups a frontmatter value puts a note in
+func groupValues(value interface{}, isTags bool) []string {
+	var raw []string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for _, item := range v {
+			if item != nil {
+				raw = append(raw, fmt.Sprint(item))
+			}
+		}
+	case []string:
+		raw = v
+	case string:
+		if isTags {
+			raw = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+		} else {
+			raw = []string{v}
+		}
+	default:
+		raw = []string{fmt.Sprint(v)}
+	}
+
+	seen := make(map[string]bool, len(raw))
+	var values []string
+	for _, value := range raw {
+		value = strings.TrimSpace(value)
+		if isTags {
+			value = strings.TrimPrefix(value, "#")
+		}
+		if value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// mocItemScope adds link to a note's template variables
+type mocItemScope struct {
+	link string
+	file templates.Scope
+}
+
+func (s mocItemScope) Lookup(name string) (interface{}, bool) {
+	if name == "link" {
+		return s.link, true
+	}
+	return s.file.Lookup(name)
+}
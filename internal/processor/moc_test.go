@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func mocNote(relPath string, frontmatter map[string]interface{}) *vault.VaultFile {
+	return &vault.VaultFile{Path: "/vault/" + relPath, RelativePath: relPath, Frontmatter: frontmatter}
+}
+
+func TestMOCGenerator_Render(t *testing.T) {
+	notes := []*vault.VaultFile{
+		mocNote("projects/garden.md", map[string]interface{}{"status": "active", "tags": []interface{}{"home", "#outdoor"}}),
+		mocNote("projects/shed.md", map[string]interface{}{"status": "done", "tags": "outdoor"}),
+		mocNote("projects/taxes.md", map[string]interface{}{}),
+	}
+	names := NoteNames(notes)
+
+	t.Run("ungrouped", func(t *testing.T) {
+		g, err := NewMOCGenerator(MOCOptions{})
+		require.NoError(t, err)
+		block, err := g.Render(notes, names)
+		require.NoError(t, err)
+		assert.Equal(t, MOCStartMarker+"\n- [[garden]]\n- [[shed]]\n- [[taxes]]\n"+MOCEndMarker+"\n", block)
+	})
+
+	t.Run("by status", func(t *testing.T) {
+		g, err := NewMOCGenerator(MOCOptions{GroupBy: "status", HeadingTemplate: "### {{group|upper}} ({{count}})"})
+		require.NoError(t, err)
+		block, err := g.Render(notes, names)
+		require.NoError(t, err)
+		assert.Equal(t, MOCStartMarker+"\n"+
+			"### ACTIVE (1)\n\n- [[garden]]\n\n"+
+			"### DONE (1)\n\n- [[shed]]\n\n"+
+			"### OTHER (1)\n\n- [[taxes]]\n"+
+			MOCEndMarker+"\n", block)
+	})
+
+	t.Run("by tags with item template", func(t *testing.T) {
+		g, err := NewMOCGenerator(MOCOptions{GroupBy: "tags", ItemTemplate: "- {{link}} ({{status|default:new}})", Ungrouped: "Untagged"})
+		require.NoError(t, err)
+		groups := g.Groups(notes)
+		require.Len(t, groups, 3)
+		assert.Equal(t, "home", groups[0].Name)
+		assert.Equal(t, "outdoor", groups[1].Name)
+		assert.Len(t, groups[1].Notes, 2, "notes are listed under each of their tags")
+		assert.Equal(t, "Untagged", groups[2].Name)
+
+		block, err := g.Render(notes, names)
+		require.NoError(t, err)
+		assert.Contains(t, block, "## outdoor\n\n- [[garden]] (active)\n- [[shed]] (done)\n")
+		assert.Contains(t, block, "## Untagged\n\n- [[taxes]] (new)\n")
+	})
+
+	_, err := NewMOCGenerator(MOCOptions{ItemTemplate: "{{link|nosuchfilter}}"})
+	assert.ErrorContains(t, err, "item template")
+	_, err = NewMOCGenerator(MOCOptions{LinkFormat: "html"})
+	assert.ErrorContains(t, err, "invalid link format")
+}
+
+func TestUpdateMOCBlock(t *testing.T) {
+	block := MOCStartMarker + "\n- [[a]]\n" + MOCEndMarker + "\n"
+
+	assert.Equal(t, "# Index\n\n"+block, UpdateMOCBlock("# Index\n", block))
+	assert.Equal(t, block, UpdateMOCBlock("", block))
+
+	body := "# Index\n\nIntro.\n\n" + MOCStartMarker + "\n- [[old]]\n" + MOCEndMarker + "\n\nFooter.\n"
+	updated := UpdateMOCBlock(body, block)
+	assert.Equal(t, "# Index\n\nIntro.\n\n"+block+"\nFooter.\n", updated)
+	assert.Equal(t, updated, UpdateMOCBlock(updated, block), "regenerating is idempotent")
+}
+
+func TestMOCChildren(t *testing.T) {
+	files := []*vault.VaultFile{
+		mocNote("Projects MOC.md", nil),
+		mocNote("projects/a.md", nil),
+		mocNote("projects/old/b.md", nil),
+		mocNote("projects/index.md", nil),
+		mocNote("projectsx/c.md", nil),
+	}
+	paths := func(notes []*vault.VaultFile) []string {
+		var out []string
+		for _, note := range notes {
+			out = append(out, note.RelativePath)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"projects/a.md", "projects/old/b.md", "projects/index.md"},
+		paths(MOCChildren(files, "projects/", "Projects MOC.md", true)))
+	assert.Equal(t, []string{"projects/a.md"}, paths(MOCChildren(files, "projects", "projects/index.md", false)))
+	assert.Len(t, MOCChildren(files, "", "Projects MOC.md", true), 4)
+}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Line ending styles for NormalizeRules.LineEndings
+const (
+	LineEndingsKeep = "keep"
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// NormalizeRules defines whitespace normalization applied to raw file content
+type NormalizeRules struct {
+	LineEndings       string // keep, lf or crlf
+	TrimTrailingSpace bool   // Strip trailing whitespace, keeping two-space markdown line breaks
+	FinalNewline      bool   // End the file with exactly one newline
+}
+
+// NormalizeStats reports what a normalization changed
+type NormalizeStats struct {
+	LineEndingsChanged  bool
+	LinesTrimmed        int
+	FinalNewlineChanged bool
+}
+
+// Validate checks the rules are usable
+func (r NormalizeRules) Validate() error {
+	switch r.LineEndings {
+	case LineEndingsKeep, LineEndingsLF, LineEndingsCRLF:
+	default:
+		return fmt.Errorf("unsupported line endings %q (supported: lf, crlf, keep)", r.LineEndings)
+	}
+	if r.LineEndings == LineEndingsKeep && !r.TrimTrailingSpace && !r.FinalNewline {
+		return fmt.Errorf("nothing to normalize: use --line-endings, --trim-trailing-space or --final-newline")
+	}
+	return nil
+}
+
+// Normalize applies the rules to raw file content. Frontmatter and body are
+// treated alike so the whole file ends up consistent.
+func Normalize(content []byte, rules NormalizeRules) ([]byte, NormalizeStats) {
+	var stats NormalizeStats
+	if len(content) == 0 {
+		return content, stats
+	}
+
+	text := string(content)
+	hadCRLF := strings.Contains(text, "\r\n")
+	hadBareLF := strings.Count(text, "\n") > strings.Count(text, "\r\n")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lines := strings.Split(text, "\n")
+	if rules.TrimTrailingSpace {
+		for i, line := range lines {
+			trimmed := trimTrailingSpace(line)
+			if trimmed != line {
+				lines[i] = trimmed
+				stats.LinesTrimmed++
+			}
+		}
+	}
+	text = strings.Join(lines, "\n")
+
+	if rules.FinalNewline {
+		withNewline := strings.TrimRight(text, "\n") + "\n"
+		if withNewline != text {
+			stats.FinalNewlineChanged = true
+			text = withNewline
+		}
+	}
+
+	newline := "\n"
+	switch rules.LineEndings {
+	case LineEndingsCRLF:
+		newline = "\r\n"
+		stats.LineEndingsChanged = hadBareLF
+	case LineEndingsLF:
+		stats.LineEndingsChanged = hadCRLF
+	default:
+		// Keep the file's predominant style
+		if hadCRLF && !hadBareLF {
+			newline = "\r\n"
+		}
+	}
+	if newline != "\n" {
+		text = strings.ReplaceAll(text, "\n", newline)
+	}
+
+	result := []byte(text)
+	if bytes.Equal(result, content) {
+		return content, NormalizeStats{}
+	}
+	return result, stats
+}
+
+// trimTrailingSpace removes trailing spaces and tabs. A line ending in exactly
+// two spaces is a markdown hard line break and is left alone.
+func trimTrailingSpace(line string) string {
+	trimmed := strings.TrimRight(line, " \t")
+	if strings.TrimSpace(trimmed) != "" && line[len(trimmed):] == "  " {
+		return line
+	}
+	return trimmed
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		rules   NormalizeRules
+		want    string
+		trimmed int
+	}{
+		{
+			name:  "crlf to lf",
+			input: "---\r\ntitle: A\r\n---\r\nBody\r\n",
+			rules: NormalizeRules{LineEndings: LineEndingsLF},
+			want:  "---\ntitle: A\n---\nBody\n",
+		},
+		{
+			name:  "mixed to crlf",
+			input: "one\r\ntwo\nthree",
+			rules: NormalizeRules{LineEndings: LineEndingsCRLF},
+			want:  "one\r\ntwo\r\nthree",
+		},
+		{
+			name:    "trim trailing space keeps hard breaks",
+			input:   "title  \nline \t\nbreak  \n   \n",
+			rules:   NormalizeRules{LineEndings: LineEndingsKeep, TrimTrailingSpace: true},
+			want:    "title  \nline\nbreak  \n\n",
+			trimmed: 2,
+		},
+		{
+			name:  "final newline added",
+			input: "Body",
+			rules: NormalizeRules{LineEndings: LineEndingsKeep, FinalNewline: true},
+			want:  "Body\n",
+		},
+		{
+			name:  "extra trailing newlines collapsed with crlf kept",
+			input: "Body\r\n\r\n\r\n",
+			rules: NormalizeRules{LineEndings: LineEndingsKeep, FinalNewline: true},
+			want:  "Body\r\n",
+		},
+		{
+			name:  "already normalized",
+			input: "Body\n",
+			rules: NormalizeRules{LineEndings: LineEndingsLF, TrimTrailingSpace: true, FinalNewline: true},
+			want:  "Body\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stats := Normalize([]byte(tt.input), tt.rules)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.trimmed, stats.LinesTrimmed)
+		})
+	}
+}
+
+func TestNormalizeRules_Validate(t *testing.T) {
+	assert.NoError(t, NormalizeRules{LineEndings: LineEndingsLF}.Validate())
+	assert.Error(t, NormalizeRules{LineEndings: "cr"}.Validate())
+	assert.Error(t, NormalizeRules{LineEndings: LineEndingsKeep}.Validate())
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// QueryMarkdown renders query results as markdown for a note, the way a
+// Dataview LIST or TABLE shows them: a bullet list of links to files or, when
+// fields are given, a GFM table of a link to each note and the fields. A
+// "file" field places the link column; without one it comes first. names,
+// from NoteNames, keeps the links unambiguous. No files render as "".
+func QueryMarkdown(files []*vault.VaultFile, fields []string, names map[string]int) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	if len(fields) == 0 {
+		var b strings.Builder
+		for _, file := range files {
+			b.WriteString("- " + NoteLink(file.RelativePath, "wiki", names) + "\n")
+		}
+		return b.String()
+	}
+
+	columns := fields
+	hasFile := false
+	for _, field := range fields {
+		hasFile = hasFile || field == "file"
+	}
+	if !hasFile {
+		columns = append([]string{"file"}, fields...)
+	}
+
+	rows := make([][]string, len(files))
+	for i, file := range files {
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			if column == "file" {
+				// A | in the link would split the cell
+				rows[i][j] = strings.ReplaceAll(NoteLink(file.RelativePath, "wiki", names), "|", "\\|")
+			} else if value, ok := query.FieldValue(file, column); ok {
+				rows[i][j] = query.MarkdownCell(value)
+			}
+		}
+	}
+	return query.MarkdownTable(columns, rows)
+}
@@ -0,0 +1,30 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestQueryMarkdown(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "books/Dune.md", Frontmatter: map[string]interface{}{
+			"rating": 5,
+			"tags":   []interface{}{"scifi", "classic"},
+			"read":   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		{RelativePath: "books/Notes.md", Frontmatter: map[string]interface{}{"rating": "a | b"}},
+		{RelativePath: "films/Notes.md"},
+	}
+	names := NoteNames(files)
+
+	assert.Equal(t, "- [[Dune]]\n- [[books/Notes]]\n- [[films/Notes]]\n", QueryMarkdown(files, nil, names))
+	assert.Equal(t, "| file | rating | tags | read |\n| --- | --- | --- | --- |\n"+
+		"| [[Dune]] | 5 | scifi, classic | 2024-03-01 |\n"+
+		"| [[books/Notes]] | a \\| b |  |  |\n", QueryMarkdown(files[:2], []string{"rating", "tags", "read"}, names))
+	assert.Equal(t, "| rating | file |\n| --- | --- |\n| 5 | [[Dune]] |\n", QueryMarkdown(files[:1], []string{"rating", "file"}, names))
+	assert.Equal(t, "", QueryMarkdown(nil, nil, names))
+}
@@ -0,0 +1,388 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/readwise"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultReadwiseStateFile is where the time of the last Readwise import is
+// kept, relative to the vault root
+const DefaultReadwiseStateFile = ".mdnotes/readwise-state.json"
+
+// readwiseHeading starts the section of a note the importer maintains
+const readwiseHeading = "## Highlights"
+
+// readwiseBlockID matches the block ID marking a highlight's list item
+var readwiseBlockID = regexp.MustCompile(`\^rw(\d+)\s*$`)
+
+// ReadwiseState remembers when highlights were last imported, so the next
+// import only fetches highlights updated since
+type ReadwiseState struct {
+	LastSync time.Time `json:"last_sync"`
+}
+
+// LoadReadwiseState reads the import state at path. A missing file yields a
+// zero state, which imports everything.
+func LoadReadwiseState(path string) (*ReadwiseState, error) {
+	state := &ReadwiseState{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading readwise state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing readwise state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes the import state to path
+func (s *ReadwiseState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding readwise state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating readwise state directory: %w", err)
+	}
+	if err := safety.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing readwise state: %w", err)
+	}
+	return nil
+}
+
+// ReadwiseImporter creates and updates a literature note per Readwise source
+type ReadwiseImporter struct {
+	VaultRoot string    // Vault root new notes are created under
+	Dest      string    // Folder for new notes, relative to VaultRoot
+	IDField   string    // Frontmatter field holding the Readwise book ID
+	Now       time.Time // Time recorded in synced_at
+}
+
+// NewReadwiseImporter creates an importer writing new notes to dest
+func NewReadwiseImporter(vaultRoot, dest string) *ReadwiseImporter {
+	return &ReadwiseImporter{
+		VaultRoot: vaultRoot,
+		Dest:      dest,
+		IDField:   "readwise_id",
+		Now:       time.Now(),
+	}
+}
+
+// ReadwiseImportResult describes what importing one source did to its note
+type ReadwiseImportResult struct {
+	File    *vault.VaultFile
+	Title   string
+	Action  string // "created", "updated", "unchanged", "skipped"
+	Added   int    // Highlights added to the note
+	Updated int    // Highlights whose text or note changed
+	Removed int    // Highlights deleted or discarded in Readwise
+}
+
+// Import merges books into their notes among files, matched by readwise_id,
+// creating a note in Dest for each new source. Each highlight is a list item
+// under the "## Highlights" heading carrying a ^rw<id> block ID, so later
+// imports update, add and remove highlights in place while leaving the rest of
+// the note alone. Locked notes are skipped.
+func (ri *ReadwiseImporter) Import(books []readwise.Book, files []*vault.VaultFile) []ReadwiseImportResult {
+	existing := make(map[int]*vault.VaultFile)
+	paths := make(map[string]bool, len(files))
+	for _, file := range files {
+		paths[strings.ToLower(file.RelativePath)] = true
+		if id, ok := ri.bookID(file); ok {
+			existing[id] = file
+		}
+	}
+
+	var results []ReadwiseImportResult
+	for _, book := range mergeBooks(books) {
+		title := book.ReadableTitle
+		if title == "" {
+			title = book.Title
+		}
+		result := ReadwiseImportResult{Title: title}
+
+		file := existing[book.ID]
+		switch {
+		case file == nil:
+			file = ri.newNote(book, title, paths)
+			result.Action = "created"
+		case file.IsLocked():
+			result.File = file
+			result.Action = "skipped"
+			results = append(results, result)
+			continue
+		default:
+			result.Action = "unchanged"
+		}
+		result.File = file
+
+		var changed bool
+		file.Body, changed = mergeHighlights(file.Body, book.Highlights, &result)
+		if result.Action == "created" && result.Added == 0 {
+			// Nothing left to import for a source whose highlights were all removed
+			delete(paths, strings.ToLower(file.RelativePath))
+			continue
+		}
+		if result.Action == "created" || changed {
+			if result.Action != "created" {
+				result.Action = "updated"
+			}
+			ri.setFrontmatter(file, book)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// newNote creates an empty literature note for book in Dest
+func (ri *ReadwiseImporter) newNote(book readwise.Book, title string, paths map[string]bool) *vault.VaultFile {
+	name := sanitizeNoteName(title)
+	if name == "" {
+		name = "Readwise " + strconv.Itoa(book.ID)
+	}
+	rel := filepath.Join(ri.Dest, name+".md")
+	if paths[strings.ToLower(rel)] {
+		rel = filepath.Join(ri.Dest, fmt.Sprintf("%s %d.md", name, book.ID))
+	}
+	paths[strings.ToLower(rel)] = true
+
+	file := &vault.VaultFile{
+		Path:         filepath.Join(ri.VaultRoot, rel),
+		RelativePath: rel,
+		Frontmatter:  make(map[string]interface{}),
+		Modified:     ri.Now,
+	}
+	file.SetField("title", title)
+	file.SetField(ri.IDField, book.ID)
+	if book.Category != "" {
+		file.SetField("category", book.Category)
+	}
+	if len(book.Tags) > 0 {
+		tags := make([]interface{}, len(book.Tags))
+		for i, tag := range book.Tags {
+			tags[i] = tag.Name
+		}
+		file.SetField("tags", tags)
+	}
+
+	file.Body = "# " + title + "\n"
+	if note := strings.TrimSpace(book.DocumentNote); note != "" {
+		file.Body += "\n" + note + "\n"
+	}
+	return file
+}
+
+// setFrontmatter records the source's author and URL and the time of import
+func (ri *ReadwiseImporter) setFrontmatter(file *vault.VaultFile, book readwise.Book) {
+	if book.Author != "" {
+		file.SetField("author", book.Author)
+	}
+	if book.SourceURL != "" {
+		file.SetField("url", book.SourceURL)
+	}
+	file.SetField("synced_at", ri.Now.UTC().Format(time.RFC3339))
+}
+
+// bookID returns the note's Readwise book ID, if it has one
+func (ri *ReadwiseImporter) bookID(file *vault.VaultFile) (int, bool) {
+	switch v := file.Frontmatter[ri.IDField].(type) {
+	case int:
+		return v, v > 0
+	case float64:
+		return int(v), v > 0
+	default:
+		return 0, false
+	}
+}
+
+// mergeBooks combines books the export returned more than once and orders
+// each book's highlights by their position in the source
+func mergeBooks(books []readwise.Book) []readwise.Book {
+	var merged []readwise.Book
+	index := make(map[int]int)
+	for _, book := range books {
+		if i, ok := index[book.ID]; ok {
+			merged[i].Highlights = append(merged[i].Highlights, book.Highlights...)
+			continue
+		}
+		index[book.ID] = len(merged)
+		book.Highlights = append([]readwise.Highlight{}, book.Highlights...)
+		merged = append(merged, book)
+	}
+	for _, book := range merged {
+		sort.SliceStable(book.Highlights, func(i, j int) bool {
+			if book.Highlights[i].Location != book.Highlights[j].Location {
+				return book.Highlights[i].Location < book.Highlights[j].Location
+			}
+			return book.Highlights[i].ID < book.Highlights[j].ID
+		})
+	}
+	return merged
+}
+
+// highlightItem is a chunk of the highlights section: a highlight's list
+// item, or any other line
+type highlightItem struct {
+	id    int
+	lines []string
+}
+
+// mergeHighlights applies highlights to the highlights section of body,
+// adding the section if missing, and reports whether anything changed
+func mergeHighlights(body string, highlights []readwise.Highlight, result *ReadwiseImportResult) (string, bool) {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	if body == "" {
+		lines = nil
+	}
+
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == readwiseHeading {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		if len(highlights) == 0 {
+			return body, false
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, readwiseHeading)
+		start = len(lines)
+	}
+	end := len(lines)
+	for i := start; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+
+	items := parseHighlightItems(lines[start:end])
+	byID := make(map[int]int)
+	for i, item := range items {
+		if item.id > 0 {
+			byID[item.id] = i
+		}
+	}
+
+	removed := make(map[int]bool)
+	var added []highlightItem
+	for _, highlight := range highlights {
+		i, exists := byID[highlight.ID]
+		if highlight.IsDeleted || highlight.IsDiscard {
+			if exists && !removed[i] {
+				removed[i] = true
+				result.Removed++
+			}
+			continue
+		}
+		rendered := renderHighlight(highlight)
+		switch {
+		case !exists:
+			added = append(added, highlightItem{id: highlight.ID, lines: rendered})
+			byID[highlight.ID] = -1
+			result.Added++
+		case i >= 0 && strings.Join(items[i].lines, "\n") != strings.Join(rendered, "\n"):
+			items[i].lines = rendered
+			result.Updated++
+		}
+	}
+	if result.Added+result.Updated+result.Removed == 0 {
+		return body, false
+	}
+
+	// Rebuild the section, keeping a blank line around its content
+	var section []string
+	for i, item := range items {
+		if !removed[i] {
+			section = append(section, item.lines...)
+		}
+	}
+	for len(section) > 0 && strings.TrimSpace(section[len(section)-1]) == "" {
+		section = section[:len(section)-1]
+	}
+	for len(section) > 0 && strings.TrimSpace(section[0]) == "" {
+		section = section[1:]
+	}
+	for _, item := range added {
+		section = append(section, item.lines...)
+	}
+
+	merged := append([]string{}, lines[:start]...)
+	merged = append(merged, "")
+	merged = append(merged, section...)
+	if end < len(lines) {
+		merged = append(merged, "")
+		merged = append(merged, lines[end:]...)
+	}
+	return strings.Join(merged, "\n") + "\n", true
+}
+
+// parseHighlightItems splits section lines into list items, each with its
+// indented continuation lines, and single other lines
+func parseHighlightItems(lines []string) []highlightItem {
+	var items []highlightItem
+	for _, line := range lines {
+		continuation := len(items) > 0 && len(items[len(items)-1].lines) > 0 &&
+			strings.HasPrefix(items[len(items)-1].lines[0], "- ") &&
+			(strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"))
+		if continuation {
+			items[len(items)-1].lines = append(items[len(items)-1].lines, line)
+		} else {
+			items = append(items, highlightItem{lines: []string{line}})
+		}
+	}
+	for i := range items {
+		for _, line := range items[i].lines {
+			if match := readwiseBlockID.FindStringSubmatch(line); match != nil {
+				items[i].id, _ = strconv.Atoi(match[1])
+				break
+			}
+		}
+	}
+	return items
+}
+
+// renderHighlight renders a highlight as a list item ending in its block ID,
+// with its note nested beneath
+func renderHighlight(highlight readwise.Highlight) []string {
+	textLines := strings.Split(strings.TrimSpace(highlight.Text), "\n")
+	var lines []string
+	for i, line := range textLines {
+		line = strings.TrimRight(line, " \t")
+		if i == 0 {
+			lines = append(lines, "- "+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	lines[len(lines)-1] += fmt.Sprintf(" ^rw%d", highlight.ID)
+
+	if note := strings.TrimSpace(highlight.Note); note != "" {
+		for i, line := range strings.Split(note, "\n") {
+			if i == 0 {
+				lines = append(lines, "  - **Note:** "+line)
+			} else {
+				lines = append(lines, "    "+line)
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,170 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/readwise"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func newTestReadwiseImporter() *ReadwiseImporter {
+	importer := NewReadwiseImporter("/vault", "sources")
+	importer.Now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return importer
+}
+
+func TestReadwiseImporter_CreatesNote(t *testing.T) {
+	books := []readwise.Book{{
+		ID:        7,
+		Title:     "Deep Work",
+		Author:    "Cal Newport",
+		Category:  "books",
+		SourceURL: "https://example.com/deep-work",
+		Tags:      []readwise.Tag{{Name: "focus"}},
+		Highlights: []readwise.Highlight{
+			{ID: 2, Text: "Second passage", Location: 20, Note: "Agree"},
+			{ID: 1, Text: "First passage\nspans lines", Location: 10},
+			{ID: 3, Text: "Discarded", Location: 30, IsDiscard: true},
+		},
+	}}
+
+	results := newTestReadwiseImporter().Import(books, nil)
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "created", result.Action)
+	assert.Equal(t, 2, result.Added)
+
+	file := result.File
+	assert.Equal(t, filepath.Join("sources", "Deep Work.md"), file.RelativePath)
+	assert.Equal(t, filepath.Join("/vault", "sources", "Deep Work.md"), file.Path)
+	assert.Equal(t, 7, file.Frontmatter["readwise_id"])
+	assert.Equal(t, "Cal Newport", file.Frontmatter["author"])
+	assert.Equal(t, "https://example.com/deep-work", file.Frontmatter["url"])
+	assert.Equal(t, "books", file.Frontmatter["category"])
+	assert.Equal(t, []interface{}{"focus"}, file.Frontmatter["tags"])
+	assert.Equal(t, "2024-03-01T12:00:00Z", file.Frontmatter["synced_at"])
+	assert.Equal(t, `# Deep Work
+
+## Highlights
+
+- First passage
+  spans lines ^rw1
+- Second passage ^rw2
+  - **Note:** Agree
+`, file.Body)
+}
+
+func TestReadwiseImporter_UpdatesNote(t *testing.T) {
+	file := &vault.VaultFile{
+		Path:         "/vault/reading/Deep Work.md",
+		RelativePath: "reading/Deep Work.md",
+		Frontmatter:  map[string]interface{}{"readwise_id": 7, "title": "My title"},
+		Body: `# Deep Work
+
+Some thoughts of my own.
+
+## Highlights
+
+- First passage ^rw1
+- Second passage ^rw2
+  - **Note:** Agree
+- Third passage ^rw4
+
+## Related
+
+[[Focus]]
+`,
+	}
+
+	books := []readwise.Book{{
+		ID:    7,
+		Title: "Deep Work",
+		Highlights: []readwise.Highlight{
+			{ID: 2, Text: "Second passage", Note: "Changed my mind"},
+			{ID: 4, Text: "Third passage", IsDeleted: true},
+			{ID: 5, Text: "New passage"},
+		},
+	}}
+
+	results := newTestReadwiseImporter().Import(books, []*vault.VaultFile{file})
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "updated", result.Action)
+	assert.Equal(t, 1, result.Added)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Removed)
+	assert.Equal(t, "My title", file.Frontmatter["title"])
+	assert.Equal(t, "2024-03-01T12:00:00Z", file.Frontmatter["synced_at"])
+	assert.Equal(t, `# Deep Work
+
+Some thoughts of my own.
+
+## Highlights
+
+- First passage ^rw1
+- Second passage ^rw2
+  - **Note:** Changed my mind
+- New passage ^rw5
+
+## Related
+
+[[Focus]]
+`, file.Body)
+
+	// Importing the same highlights again changes nothing
+	results = newTestReadwiseImporter().Import(books, []*vault.VaultFile{file})
+	assert.Equal(t, "unchanged", results[0].Action)
+}
+
+func TestReadwiseImporter_AddsSection(t *testing.T) {
+	file := &vault.VaultFile{
+		RelativePath: "Article.md",
+		Frontmatter:  map[string]interface{}{"readwise_id": 3},
+		Body:         "# Article\n\nNotes.\n",
+	}
+	books := []readwise.Book{{ID: 3, Highlights: []readwise.Highlight{{ID: 9, Text: "Quote"}}}}
+
+	results := newTestReadwiseImporter().Import(books, []*vault.VaultFile{file})
+	require.Len(t, results, 1)
+	assert.Equal(t, "updated", results[0].Action)
+	assert.Equal(t, "# Article\n\nNotes.\n\n## Highlights\n\n- Quote ^rw9\n", file.Body)
+}
+
+func TestReadwiseImporter_SkipsLockedAndNames(t *testing.T) {
+	locked := &vault.VaultFile{
+		RelativePath: "Locked.md",
+		Frontmatter:  map[string]interface{}{"readwise_id": 1, vault.LockField: vault.LockValue},
+	}
+	taken := &vault.VaultFile{RelativePath: filepath.Join("sources", "Taken.md")}
+	books := []readwise.Book{
+		{ID: 1, Highlights: []readwise.Highlight{{ID: 1, Text: "a"}}},
+		{ID: 2, ReadableTitle: "Taken", Title: "taken", Highlights: []readwise.Highlight{{ID: 2, Text: "b"}}},
+		{ID: 3, Title: "Gone", Highlights: []readwise.Highlight{{ID: 3, Text: "c", IsDeleted: true}}},
+	}
+
+	results := newTestReadwiseImporter().Import(books, []*vault.VaultFile{locked, taken})
+	require.Len(t, results, 2, "a new source without live highlights gets no note")
+	assert.Equal(t, "skipped", results[0].Action)
+	assert.Equal(t, "created", results[1].Action)
+	assert.Equal(t, filepath.Join("sources", "Taken 2.md"), results[1].File.RelativePath)
+}
+
+func TestReadwiseState_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".mdnotes", "readwise-state.json")
+
+	state, err := LoadReadwiseState(path)
+	require.NoError(t, err)
+	assert.True(t, state.LastSync.IsZero())
+
+	state.LastSync = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadReadwiseState(path)
+	require.NoError(t, err)
+	assert.True(t, state.LastSync.Equal(loaded.LastSync))
+}
@@ -7,10 +7,12 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/eoinhurrell/mdnotes/internal/rgsearch"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 	"github.com/eoinhurrell/mdnotes/internal/vault"
 	"github.com/eoinhurrell/mdnotes/internal/workerpool"
 	"github.com/eoinhurrell/mdnotes/pkg/template"
@@ -35,6 +37,7 @@ type RenameOptions struct {
 	DryRun         bool
 	Verbose        bool
 	Workers        int
+	Transaction    *safety.Transaction // Journals changes for undo when set
 }
 
 // RenameResult contains the results of a rename operation
@@ -45,6 +48,7 @@ type RenameResult struct {
 	FilesModified int
 	LinksUpdated  int
 	ModifiedFiles []string
+	SkippedFiles  []SkippedFile // Linking files left untouched because they are locked
 	Duration      time.Duration
 }
 
@@ -53,6 +57,7 @@ type FileProcessResult struct {
 	File         *vault.VaultFile
 	Modified     bool
 	LinksUpdated int
+	SkipReason   string // Set when matching links were found but the file is locked
 	Error        error
 }
 
@@ -107,6 +112,13 @@ func (rp *RenameProcessor) ProcessRename(ctx context.Context, sourcePath, target
 		return nil, fmt.Errorf("getting relative target path: %w", err)
 	}
 
+	// Refuse to move a file that carries a do-not-modify marker
+	if sourceFile, err := vault.LoadVaultFile(sourcePath); err == nil {
+		if reason := sourceFile.LockReason(); reason != "" {
+			return nil, fmt.Errorf("%s is locked (%s)", sourceRel, reason)
+		}
+	}
+
 	// Create file move record
 	move := FileMove{From: sourceRel, To: targetRel}
 
@@ -120,11 +132,11 @@ func (rp *RenameProcessor) ProcessRename(ctx context.Context, sourcePath, target
 
 	// If not dry run, save modified files and perform rename
 	if !options.DryRun {
-		if err := rp.saveModifiedFiles(modifiedFiles); err != nil {
+		if err := rp.saveModifiedFiles(modifiedFiles, options.Transaction); err != nil {
 			return result, fmt.Errorf("saving modified files: %w", err)
 		}
 
-		if err := rp.performFileRename(sourcePath, targetPath); err != nil {
+		if err := rp.performFileRename(sourcePath, targetPath, options.Transaction); err != nil {
 			return result, fmt.Errorf("renaming file: %w", err)
 		}
 	}
@@ -196,6 +208,15 @@ func (rp *RenameProcessor) processFile(file *vault.VaultFile, move FileMove, lin
 		fmt.Printf("Examining: %s - found %d matching links: %v\n", file.RelativePath, matchingLinks, matchedTargets)
 	}
 
+	// Leave links in locked files untouched
+	if reason := file.LockReason(); reason != "" {
+		if verbose {
+			fmt.Printf("Examining: %s - Skipped (locked: %s)\n", file.RelativePath, reason)
+		}
+		result.SkipReason = reason
+		return result
+	}
+
 	// Update links if not dry run
 	if !dryRun {
 		if rp.linkUpdater.UpdateFile(file, []FileMove{move}) {
@@ -237,7 +258,7 @@ func (rp *RenameProcessor) linkMatchesMove(link vault.Link, move FileMove) bool
 }
 
 // saveModifiedFiles saves all modified files atomically
-func (rp *RenameProcessor) saveModifiedFiles(files []*vault.VaultFile) error {
+func (rp *RenameProcessor) saveModifiedFiles(files []*vault.VaultFile, tx *safety.Transaction) error {
 	var errors []error
 
 	for _, file := range files {
@@ -247,17 +268,13 @@ func (rp *RenameProcessor) saveModifiedFiles(files []*vault.VaultFile) error {
 			continue
 		}
 
-		// Write atomically by writing to temp file then renaming
-		tempPath := file.Path + ".tmp"
-		if err := os.WriteFile(tempPath, content, 0644); err != nil {
-			errors = append(errors, fmt.Errorf("writing temp file for %s: %w", file.RelativePath, err))
+		if err := tx.RecordWrite(file.Path); err != nil {
+			errors = append(errors, fmt.Errorf("journaling %s: %w", file.RelativePath, err))
 			continue
 		}
 
-		if err := os.Rename(tempPath, file.Path); err != nil {
-			// Clean up temp file on failure
-			os.Remove(tempPath)
-			errors = append(errors, fmt.Errorf("renaming temp file for %s: %w", file.RelativePath, err))
+		if err := safety.WriteFile(file.Path, content, 0644); err != nil {
+			errors = append(errors, fmt.Errorf("writing %s: %w", file.RelativePath, err))
 			continue
 		}
 	}
@@ -270,13 +287,17 @@ func (rp *RenameProcessor) saveModifiedFiles(files []*vault.VaultFile) error {
 }
 
 // performFileRename renames the source file to target path
-func (rp *RenameProcessor) performFileRename(sourcePath, targetPath string) error {
+func (rp *RenameProcessor) performFileRename(sourcePath, targetPath string, tx *safety.Transaction) error {
 	// Create target directory if needed
 	targetDir := filepath.Dir(targetPath)
 	if err := os.MkdirAll(targetDir, 0755); err != nil {
 		return fmt.Errorf("creating target directory: %w", err)
 	}
 
+	if err := tx.RecordMove(sourcePath, targetPath); err != nil {
+		return fmt.Errorf("journaling rename: %w", err)
+	}
+
 	// Perform the atomic rename
 	if err := os.Rename(sourcePath, targetPath); err != nil {
 		return fmt.Errorf("renaming file: %w", err)
@@ -355,6 +376,13 @@ func (rp *RenameProcessor) processRenameWithOptimizedSearch(ctx context.Context,
 		}
 
 		processResult := taskResults[i]
+		if processResult != nil && processResult.SkipReason != "" {
+			result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
+				Path:   processResult.File.RelativePath,
+				Reason: processResult.SkipReason,
+				Locked: true,
+			})
+		}
 		if processResult != nil && processResult.Modified {
 			result.FilesModified++
 			result.LinksUpdated += processResult.LinksUpdated
@@ -544,6 +572,13 @@ func (rp *RenameProcessor) processFullRenameFallback(ctx context.Context, move F
 		}
 
 		processResult := taskResults[i]
+		if processResult != nil && processResult.SkipReason != "" {
+			result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
+				Path:   processResult.File.RelativePath,
+				Reason: processResult.SkipReason,
+				Locked: true,
+			})
+		}
 		if processResult != nil && processResult.Modified {
 			result.FilesModified++
 			result.LinksUpdated += processResult.LinksUpdated
@@ -555,6 +590,164 @@ func (rp *RenameProcessor) processFullRenameFallback(ctx context.Context, move F
 	return modifiedFiles, errors
 }
 
+// LinkEdit is the content of a file before and after its links were updated
+type LinkEdit struct {
+	Path   string // Relative to the vault root
+	Before string
+	After  string
+}
+
+// BatchRenameResult contains the results of moving several files at once
+type BatchRenameResult struct {
+	Moves         []FileMove // Relative to the vault root
+	FilesScanned  int
+	FilesModified int
+	LinksUpdated  int
+	Edits         []LinkEdit    // Link updates, in path order
+	SkippedFiles  []SkippedFile // Linking files left untouched because they are locked
+	Duration      time.Duration
+}
+
+// ProcessMoves moves several files, given by absolute paths, updating links
+// to all of them in a single pass over the vault. Every move is checked
+// before anything changes: sources must exist and not be locked, and targets
+// must be inside the vault, distinct and not exist yet. With options.DryRun
+// nothing is written, but the result still holds the link edits.
+func (rp *RenameProcessor) ProcessMoves(ctx context.Context, moves []FileMove, options RenameOptions) (*BatchRenameResult, error) {
+	startTime := time.Now()
+	result := &BatchRenameResult{}
+
+	targets := make(map[string]string, len(moves))
+	for _, move := range moves {
+		sourceRel, err := filepath.Rel(options.VaultRoot, move.From)
+		if err != nil {
+			return nil, fmt.Errorf("getting relative source path: %w", err)
+		}
+		targetRel, err := filepath.Rel(options.VaultRoot, move.To)
+		if err != nil || targetRel == ".." || strings.HasPrefix(targetRel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("target %s is outside the vault", move.To)
+		}
+		if _, err := os.Stat(move.From); err != nil {
+			return nil, fmt.Errorf("accessing %s: %w", sourceRel, err)
+		}
+		if sourceFile, err := vault.LoadVaultFile(move.From); err == nil {
+			if reason := sourceFile.LockReason(); reason != "" {
+				return nil, fmt.Errorf("%s is locked (%s)", sourceRel, reason)
+			}
+		}
+		if other, exists := targets[move.To]; exists {
+			return nil, fmt.Errorf("both %s and %s would move to %s", other, sourceRel, targetRel)
+		}
+		if _, err := os.Stat(move.To); err == nil {
+			return nil, fmt.Errorf("target file already exists: %s", targetRel)
+		}
+		targets[move.To] = sourceRel
+		result.Moves = append(result.Moves, FileMove{From: filepath.ToSlash(sourceRel), To: filepath.ToSlash(targetRel)})
+	}
+
+	var allFiles []*vault.VaultFile
+	if err := rp.scanner.WalkWithCallback(options.VaultRoot, func(file *vault.VaultFile) error {
+		allFiles = append(allFiles, file)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("scanning vault: %w", err)
+	}
+
+	linkRegex := rp.compileOptimizedLinkRegex("")
+	tasks := make([]workerpool.Task, len(allFiles))
+	taskResults := make([]*FileProcessResult, len(allFiles))
+	for i, file := range allFiles {
+		i, file := i, file // Capture loop variables
+		tasks[i] = func(ctx context.Context) error {
+			processResult := rp.processMovesFile(file, result.Moves, linkRegex)
+			taskResults[i] = &processResult
+			return nil
+		}
+	}
+
+	var modifiedFiles []*vault.VaultFile
+	for i := range rp.pool.ProcessBatch(tasks) {
+		result.FilesScanned++
+		processResult := taskResults[i]
+		if processResult == nil {
+			continue
+		}
+		if processResult.SkipReason != "" {
+			result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
+				Path:   processResult.File.RelativePath,
+				Reason: processResult.SkipReason,
+				Locked: true,
+			})
+		}
+		if !processResult.Modified {
+			continue
+		}
+		after, err := processResult.File.Serialize()
+		if err != nil {
+			return result, fmt.Errorf("serializing %s: %w", processResult.File.RelativePath, err)
+		}
+		result.FilesModified++
+		result.LinksUpdated += processResult.LinksUpdated
+		result.Edits = append(result.Edits, LinkEdit{
+			Path:   filepath.ToSlash(processResult.File.RelativePath),
+			Before: string(processResult.File.Content),
+			After:  string(after),
+		})
+		modifiedFiles = append(modifiedFiles, processResult.File)
+	}
+	sort.Slice(result.Edits, func(i, j int) bool { return result.Edits[i].Path < result.Edits[j].Path })
+
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	if !options.DryRun {
+		if err := rp.saveModifiedFiles(modifiedFiles, options.Transaction); err != nil {
+			return result, fmt.Errorf("saving modified files: %w", err)
+		}
+		for _, move := range moves {
+			if err := rp.performFileRename(move.From, move.To, options.Transaction); err != nil {
+				return result, fmt.Errorf("renaming %s: %w", move.From, err)
+			}
+		}
+	}
+
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// processMovesFile updates the links in file pointing to any of moves
+func (rp *RenameProcessor) processMovesFile(file *vault.VaultFile, moves []FileMove, linkRegex *regexp.Regexp) FileProcessResult {
+	result := FileProcessResult{File: file}
+	if !linkRegex.MatchString(file.Body) {
+		return result
+	}
+
+	rp.linkParser.UpdateFile(file)
+	matchingLinks := 0
+	for _, link := range file.Links {
+		for _, move := range moves {
+			if rp.linkMatchesMove(link, move) {
+				matchingLinks++
+				break
+			}
+		}
+	}
+	if matchingLinks == 0 {
+		return result
+	}
+
+	if reason := file.LockReason(); reason != "" {
+		result.SkipReason = reason
+		return result
+	}
+	if rp.linkUpdater.UpdateFile(file, moves) {
+		result.Modified = true
+		result.LinksUpdated = matchingLinks
+	}
+	return result
+}
+
 // GenerateNameFromTemplate generates a new filename using the template system
 func GenerateNameFromTemplate(sourcePath, templateStr string) (string, error) {
 	// Get file info
@@ -622,10 +815,7 @@ func GenerateNameFromTemplate(sourcePath, templateStr string) (string, error) {
 		tempFile.Frontmatter["created"] = createdTime.Format("2006-01-02")
 	}
 
-	// Use the template engine
-	result := engine.Process(templateStr, tempFile)
-
-	return result, nil
+	return engine.Render(templateStr, tempFile)
 }
 
 // parseTimeField attempts to parse various time field formats
@@ -228,6 +228,60 @@ Another file with references:
 	t.Logf("  Processing rate: %.2f files/ms", float64(result.FilesScanned)/float64(duration.Milliseconds()))
 }
 
+func TestRenameProcessor_ProcessMoves(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"a.md":     "# A\n\nSee [[b]]",
+		"b.md":     "# B",
+		"index.md": "[[a]] and [B](b.md)",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moves := []FileMove{
+		{From: filepath.Join(tempDir, "a.md"), To: filepath.Join(tempDir, "archive", "a.md")},
+		{From: filepath.Join(tempDir, "b.md"), To: filepath.Join(tempDir, "archive", "b.md")},
+	}
+	options := RenameOptions{VaultRoot: tempDir, DryRun: true}
+	rp := NewRenameProcessor(options)
+	defer rp.Cleanup()
+
+	result, err := rp.ProcessMoves(context.Background(), moves, options)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if result.FilesModified != 2 || result.LinksUpdated != 3 {
+		t.Errorf("expected 3 links in 2 files, got %d in %d", result.LinksUpdated, result.FilesModified)
+	}
+	if len(result.Edits) != 2 || result.Edits[1].Path != "index.md" || result.Edits[1].After != "[[archive/a]] and [B](archive/b.md)" {
+		t.Errorf("unexpected edits: %+v", result.Edits)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "a.md")); err != nil {
+		t.Errorf("dry run moved a.md: %v", err)
+	}
+
+	options.DryRun = false
+	if _, err := rp.ProcessMoves(context.Background(), moves, options); err != nil {
+		t.Fatalf("moving: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "archive", "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# A\n\nSee [[archive/b]]" {
+		t.Errorf("links between moved files not updated: %q", content)
+	}
+
+	// Every move is checked before anything changes
+	conflict := []FileMove{{From: filepath.Join(tempDir, "index.md"), To: filepath.Join(tempDir, "archive", "a.md")}}
+	if _, err := rp.ProcessMoves(context.Background(), conflict, options); err == nil {
+		t.Error("expected an error moving onto an existing file")
+	}
+}
+
 func TestGenerateNameFromTemplate(t *testing.T) {
 	// Create a temporary test file
 	tempDir, err := os.MkdirTemp("", "mdnotes_template_test")
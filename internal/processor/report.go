@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/templates"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultReportTemplate is the content of a report without a template
+const DefaultReportTemplate = "{{results}}"
+
+// ReportMarkers returns the markers around the named report in its note,
+// so several reports can share a note
+func ReportMarkers(name string) (start, end string) {
+	return "<!-- mdnotes:report " + name + " start -->", "<!-- mdnotes:report " + name + " end -->"
+}
+
+// Report is a compiled report from the configuration
+type Report struct {
+	Name       string
+	Output     string       // Note the report is written to, relative to the vault root
+	Query      *query.Query // nil selects every note
+	Analysis   string       // orphans or dead-ends, selected by the caller instead of Query
+	Fields     []string
+	GroupBy    []string
+	Aggregates []query.Aggregate // Set when grouping or aggregating
+	Limit      int
+	Template   string
+	Every      time.Duration // 0 refreshes the report on every run
+	renderer   *templates.Renderer
+}
+
+// NewReport compiles a configured report, resolving a saved query from cfg
+func NewReport(rc config.ReportConfig, cfg *config.Config) (*Report, error) {
+	r := &Report{
+		Name:     rc.Name,
+		Output:   filepath.Clean(rc.Output),
+		Analysis: rc.Analysis,
+		Fields:   rc.Fields,
+		GroupBy:  rc.GroupBy,
+		Limit:    rc.Limit,
+		Template: rc.Template,
+		renderer: templates.NewRenderer(),
+	}
+	if filepath.Ext(r.Output) == "" {
+		r.Output += ".md"
+	}
+
+	if rc.Query != "" {
+		expression, err := cfg.ResolveQuery(rc.Query)
+		if err != nil {
+			return nil, fmt.Errorf("report '%s': %w", rc.Name, err)
+		}
+		q, err := query.ParseQuery(expression)
+		if err != nil {
+			return nil, fmt.Errorf("report '%s': parsing query: %w", rc.Name, err)
+		}
+		r.Query = q
+	}
+
+	if len(rc.GroupBy) > 0 || rc.Aggregate != "" {
+		spec := rc.Aggregate
+		if spec == "" {
+			spec = "count()"
+		}
+		aggregates, err := query.ParseAggregates(spec)
+		if err != nil {
+			return nil, fmt.Errorf("report '%s': %w", rc.Name, err)
+		}
+		r.Aggregates = aggregates
+	}
+
+	if r.Template == "" {
+		r.Template = DefaultReportTemplate
+	}
+	if err := r.renderer.Validate(r.Template); err != nil {
+		return nil, fmt.Errorf("report '%s': template: %w", rc.Name, err)
+	}
+
+	if rc.Every != "" {
+		every, err := query.ParseDuration(rc.Every)
+		if err != nil {
+			return nil, fmt.Errorf("report '%s': invalid every '%s': %w", rc.Name, rc.Every, err)
+		}
+		r.Every = every
+	}
+	return r, nil
+}
+
+// Due reports whether a report whose note was last changed at modified needs
+// refreshing at now. A zero modified time means the note doesn't exist yet.
+func (r *Report) Due(modified, now time.Time) bool {
+	return r.Every == 0 || modified.IsZero() || now.Sub(modified) >= r.Every
+}
+
+// Sources returns the notes a report draws on: files without the report's
+// own note, whose links would otherwise count in analyses
+func (r *Report) Sources(files []*vault.VaultFile) []*vault.VaultFile {
+	var sources []*vault.VaultFile
+	for _, file := range files {
+		if filepath.Clean(file.RelativePath) != r.Output {
+			sources = append(sources, file)
+		}
+	}
+	return sources
+}
+
+// Select returns the sources matching the report's query, in the order of
+// its ORDER BY clause, or all of them without one. files is the whole vault,
+// for link-based query fields.
+func (r *Report) Select(files []*vault.VaultFile) []*vault.VaultFile {
+	sources := r.Sources(files)
+	if r.Query == nil {
+		return sources
+	}
+	r.Query.Bind(files, NewLinkParser())
+	return r.Query.Run(sources)
+}
+
+// Render returns the report's content for the selected notes: a list, table
+// or aggregate table of them, placed in the template as {{results}}, with
+// {{count}} the number of notes before the limit. names, from NoteNames over
+// the whole vault, keeps wiki links unambiguous.
+func (r *Report) Render(notes []*vault.VaultFile, names map[string]int) (string, error) {
+	var results string
+	if r.Aggregates != nil {
+		if len(notes) > 0 {
+			results = query.GroupAndAggregate(notes, r.GroupBy, r.Aggregates).Markdown()
+		}
+	} else {
+		listed := notes
+		if r.Limit > 0 && len(listed) > r.Limit {
+			listed = listed[:r.Limit]
+		}
+		results = QueryMarkdown(listed, r.Fields, names)
+	}
+
+	content, err := r.renderer.Render(r.Template, templates.Vars{
+		"results": results,
+		"count":   len(notes),
+		"name":    r.Name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("report '%s': rendering template: %w", r.Name, err)
+	}
+	return content, nil
+}
+
+// Apply puts content between the report's markers in body, adding them at
+// the end of body when it has none yet
+func (r *Report) Apply(body, content string) string {
+	start, end := ReportMarkers(r.Name)
+	return ReplaceMarkedBlock(body, start, end, content)
+}
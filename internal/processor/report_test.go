@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestReport_Render(t *testing.T) {
+	cfg := &config.Config{Queries: map[string]string{"books": "type = 'book'"}}
+	files := []*vault.VaultFile{
+		mocNote("Reading queue.md", map[string]interface{}{"type": "book"}),
+		mocNote("books/Dune.md", map[string]interface{}{"type": "book", "status": "reading"}),
+		mocNote("books/Emma.md", map[string]interface{}{"type": "book", "status": "queued"}),
+		mocNote("books/Ulysses.md", map[string]interface{}{"type": "book", "status": "queued"}),
+		mocNote("notes/Coffee.md", map[string]interface{}{"type": "note"}),
+	}
+	names := NoteNames(files)
+
+	t.Run("list with template", func(t *testing.T) {
+		r, err := NewReport(config.ReportConfig{
+			Name:     "queue",
+			Output:   "Reading queue",
+			Query:    "@books",
+			Limit:    2,
+			Template: "{{count}} books:\n\n{{results}}",
+		}, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "Reading queue.md", r.Output)
+
+		notes := r.Select(files)
+		require.Len(t, notes, 3, "the report's own note is left out")
+		content, err := r.Render(notes, names)
+		require.NoError(t, err)
+		assert.Equal(t, "3 books:\n\n- [[Dune]]\n- [[Emma]]\n", content)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		r, err := NewReport(config.ReportConfig{Name: "t", Output: "T.md", Query: "status = 'queued'", Fields: []string{"status"}}, cfg)
+		require.NoError(t, err)
+		content, err := r.Render(r.Select(files), names)
+		require.NoError(t, err)
+		assert.Equal(t, "| file | status |\n| --- | --- |\n| [[Emma]] | queued |\n| [[Ulysses]] | queued |\n", content)
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		r, err := NewReport(config.ReportConfig{Name: "s", Output: "S.md", Query: "type = 'book' ORDER BY file.name DESC LIMIT 3"}, cfg)
+		require.NoError(t, err)
+		content, err := r.Render(r.Select(files), names)
+		require.NoError(t, err)
+		assert.Equal(t, "- [[Ulysses]]\n- [[Reading queue]]\n- [[Emma]]\n", content)
+	})
+
+	t.Run("grouped", func(t *testing.T) {
+		r, err := NewReport(config.ReportConfig{Name: "g", Output: "Reading queue.md", GroupBy: []string{"type"}}, cfg)
+		require.NoError(t, err)
+		content, err := r.Render(r.Select(files), names)
+		require.NoError(t, err)
+		assert.Equal(t, "| type | count() |\n| --- | --- |\n| book | 3 |\n| note | 1 |\n", content)
+	})
+
+	for _, rc := range []config.ReportConfig{
+		{Name: "bad", Output: "B.md", Query: "@missing"},
+		{Name: "bad", Output: "B.md", Query: "status ="},
+		{Name: "bad", Output: "B.md", Aggregate: "median(pages)"},
+		{Name: "bad", Output: "B.md", Template: "{{results|nosuchfilter}}"},
+		{Name: "bad", Output: "B.md", Every: "soon"},
+	} {
+		_, err := NewReport(rc, cfg)
+		assert.Error(t, err, "%+v", rc)
+	}
+}
+
+func TestReport_DueAndApply(t *testing.T) {
+	r, err := NewReport(config.ReportConfig{Name: "weekly", Output: "Review.md", Every: "1 day"}, &config.Config{})
+	require.NoError(t, err)
+
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	assert.True(t, r.Due(time.Time{}, now))
+	assert.False(t, r.Due(now.Add(-time.Hour), now))
+	assert.True(t, r.Due(now.Add(-25*time.Hour), now))
+
+	start, end := ReportMarkers("weekly")
+	body := r.Apply("# Review\n", "- [[a]]")
+	assert.Equal(t, "# Review\n\n"+start+"\n- [[a]]\n"+end+"\n", body)
+	assert.Equal(t, "# Review\n\n"+start+"\n- [[b]]\n"+end+"\n", r.Apply(body, "- [[b]]\n"))
+}
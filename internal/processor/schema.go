@@ -0,0 +1,356 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/pkg/template"
+)
+
+// SchemaTypes are the field types a schema can require
+var SchemaTypes = []string{"string", "number", "integer", "boolean", "date", "array"}
+
+// FieldSchema describes one frontmatter field
+type FieldSchema struct {
+	Type     string        `yaml:"type" json:"type"`         // One of SchemaTypes, "" for any
+	Required bool          `yaml:"required" json:"required"` // Field must be present and not empty
+	Enum     []interface{} `yaml:"enum" json:"enum"`         // Allowed values
+	Pattern  string        `yaml:"pattern" json:"pattern"`   // Regular expression string values must match
+	Default  interface{}   `yaml:"default" json:"default"`   // Value --fix sets when the field is missing; strings may use template variables
+
+	pattern *regexp.Regexp
+}
+
+// FolderSchema overrides field definitions for notes in a folder
+type FolderSchema struct {
+	Fields map[string]*FieldSchema `yaml:"fields" json:"fields"`
+}
+
+// Schema describes the frontmatter notes should have. Field definitions in
+// Folders replace vault-wide ones for notes under that folder, the deepest
+// folder winning.
+type Schema struct {
+	Fields  map[string]*FieldSchema  `yaml:"fields" json:"fields"`
+	Folders map[string]*FolderSchema `yaml:"folders" json:"folders"`
+
+	// Base is the folder, relative to the vault root, that the relative paths
+	// of checked files start from; folders are matched from the vault root
+	Base string `yaml:"-" json:"-"`
+}
+
+// SchemaFix is a change Fix made to a field
+type SchemaFix struct {
+	Field  string
+	Action string // "default", "cast" or "enum"
+	Value  interface{}
+}
+
+// String describes the fix
+func (f SchemaFix) String() string {
+	switch f.Action {
+	case "default":
+		return fmt.Sprintf("set %s=%v", f.Field, f.Value)
+	case "cast":
+		return fmt.Sprintf("cast %s to %v", f.Field, f.Value)
+	default:
+		return fmt.Sprintf("normalize %s to %v", f.Field, f.Value)
+	}
+}
+
+// LoadSchema reads a schema from a YAML or JSON file
+func LoadSchema(filename string) (*Schema, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return ParseSchema(data)
+}
+
+// ParseSchema parses a YAML or JSON schema and checks its definitions
+func ParseSchema(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	if len(schema.Fields) == 0 && len(schema.Folders) == 0 {
+		return nil, fmt.Errorf("schema defines no fields")
+	}
+
+	if err := compileFields(schema.Fields); err != nil {
+		return nil, err
+	}
+	folders := make(map[string]*FolderSchema, len(schema.Folders))
+	for folder, override := range schema.Folders {
+		if override == nil {
+			continue
+		}
+		if err := compileFields(override.Fields); err != nil {
+			return nil, fmt.Errorf("folder '%s': %w", folder, err)
+		}
+		folders[strings.Trim(path.Clean("/"+folder), "/")] = override
+	}
+	schema.Folders = folders
+	return &schema, nil
+}
+
+func compileFields(fields map[string]*FieldSchema) error {
+	renderer := template.NewEngine()
+	for name, field := range fields {
+		if field == nil {
+			fields[name] = &FieldSchema{}
+			continue
+		}
+		if field.Type != "" && !containsString(SchemaTypes, field.Type) {
+			return fmt.Errorf("field '%s': invalid type '%s' - valid options are: %s", name, field.Type, strings.Join(SchemaTypes, ", "))
+		}
+		if field.Pattern != "" {
+			pattern, err := regexp.Compile(field.Pattern)
+			if err != nil {
+				return fmt.Errorf("field '%s': invalid pattern: %w", name, err)
+			}
+			field.pattern = pattern
+		}
+		if value, ok := field.Default.(string); ok {
+			if _, err := renderer.Render(value, &vault.VaultFile{}); err != nil {
+				return fmt.Errorf("field '%s': invalid default: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldsFor returns the field definitions that apply to the note at relPath,
+// relative to the vault root
+func (s *Schema) FieldsFor(relPath string) map[string]*FieldSchema {
+	fields := make(map[string]*FieldSchema, len(s.Fields))
+	for name, field := range s.Fields {
+		fields[name] = field
+	}
+
+	relPath = strings.TrimPrefix(path.Clean("/"+path.Join(s.Base, strings.ReplaceAll(relPath, "\\", "/"))), "/")
+	var folders []string
+	for folder := range s.Folders {
+		if folder == "" || strings.HasPrefix(relPath, folder+"/") {
+			folders = append(folders, folder)
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return len(folders[i]) < len(folders[j]) })
+	for _, folder := range folders {
+		for name, field := range s.Folders[folder].Fields {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// Validate checks a file's frontmatter against the schema
+func (s *Schema) Validate(file *vault.VaultFile) []ValidationError {
+	fields := s.FieldsFor(file.RelativePath)
+	var errors []ValidationError
+	for _, name := range sortedFieldNames(fields) {
+		field := fields[name]
+		value, exists := file.Frontmatter[name]
+		if !exists || isEmptyValue(value) {
+			if field.Required {
+				errors = append(errors, ValidationError{Field: name, Type: "missing_required", File: file.Path})
+			}
+			continue
+		}
+
+		if field.Type != "" && !matchesSchemaType(value, field.Type) {
+			errors = append(errors, ValidationError{Field: name, Type: "invalid_type", Expected: field.Type, File: file.Path})
+			continue
+		}
+		if len(field.Enum) > 0 && enumIndex(field.Enum, value, false) < 0 {
+			errors = append(errors, ValidationError{Field: name, Type: "invalid_enum", Expected: formatEnum(field.Enum), File: file.Path})
+		}
+		if field.pattern != nil {
+			if str, ok := value.(string); ok && !field.pattern.MatchString(str) {
+				errors = append(errors, ValidationError{Field: name, Type: "pattern_mismatch", Expected: field.Pattern, File: file.Path})
+			}
+		}
+	}
+	return errors
+}
+
+// Fix sets defaults for missing fields, casts values to their field's type
+// where they convert cleanly and normalizes enum values that differ only in
+// case. It changes file in memory and returns what it changed.
+func (s *Schema) Fix(file *vault.VaultFile) []SchemaFix {
+	fields := s.FieldsFor(file.RelativePath)
+	caster := NewTypeCaster()
+	engine := template.NewEngine()
+	var fixes []SchemaFix
+
+	for _, name := range sortedFieldNames(fields) {
+		field := fields[name]
+		value, exists := file.Frontmatter[name]
+		if !exists || isEmptyValue(value) {
+			if field.Default == nil {
+				continue
+			}
+			value = field.Default
+			if text, ok := value.(string); ok {
+				rendered, err := engine.Render(text, file)
+				if err != nil {
+					continue
+				}
+				value = rendered
+				if field.Type != "" && field.Type != "string" {
+					if cast, ok := castToSchemaType(caster, rendered, field.Type); ok {
+						value = cast
+					}
+				}
+			}
+			file.SetField(name, value)
+			fixes = append(fixes, SchemaFix{Field: name, Action: "default", Value: value})
+			continue
+		}
+
+		if field.Type != "" && !matchesSchemaType(value, field.Type) {
+			if cast, ok := castToSchemaType(caster, value, field.Type); ok {
+				file.SetField(name, cast)
+				fixes = append(fixes, SchemaFix{Field: name, Action: "cast", Value: field.Type})
+				value = cast
+			}
+		}
+		if len(field.Enum) > 0 && enumIndex(field.Enum, value, false) < 0 {
+			if i := enumIndex(field.Enum, value, true); i >= 0 {
+				file.SetField(name, field.Enum[i])
+				fixes = append(fixes, SchemaFix{Field: name, Action: "enum", Value: field.Enum[i]})
+			}
+		}
+	}
+	return fixes
+}
+
+func sortedFieldNames(fields map[string]*FieldSchema) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isEmptyValue reports whether a field value counts as missing
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	str, ok := value.(string)
+	return ok && strings.TrimSpace(str) == ""
+}
+
+// matchesSchemaType reports whether value has the schema type
+func matchesSchemaType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "integer":
+		switch v := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return v == float64(int64(v))
+		}
+		return false
+	case "date":
+		switch v := value.(type) {
+		case vault.Date, time.Time:
+			return true
+		case string:
+			_, ok := parseSchemaDate(v)
+			return ok
+		}
+		return false
+	default:
+		return NewTypeCaster().isType(value, schemaType)
+	}
+}
+
+// castToSchemaType converts value to the schema type, if it converts cleanly
+func castToSchemaType(caster *TypeCaster, value interface{}, schemaType string) (interface{}, bool) {
+	switch schemaType {
+	case "string":
+		switch value.(type) {
+		case int, int32, int64, float32, float64, bool, vault.Date:
+			return fmt.Sprint(value), true
+		}
+		return nil, false
+	case "integer":
+		cast, ok := castToSchemaType(caster, value, "number")
+		if !ok || !matchesSchemaType(cast, "integer") {
+			return nil, false
+		}
+		if f, isFloat := cast.(float64); isFloat {
+			return int(f), true
+		}
+		return cast, true
+	case "date":
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		if t, ok := parseSchemaDate(str); ok {
+			return vault.Date{Time: t}, true
+		}
+		return nil, false
+	case "array":
+		if _, ok := value.(string); !ok {
+			return []interface{}{value}, true
+		}
+	}
+
+	cast, err := caster.Cast(value, schemaType)
+	if err != nil {
+		return nil, false
+	}
+	return cast, true
+}
+
+// parseSchemaDate parses the date and datetime formats frontmatter dates use
+func parseSchemaDate(value string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// enumIndex returns the index of value among allowed, comparing as text, or
+// -1. foldCase ignores case differences.
+func enumIndex(allowed []interface{}, value interface{}, foldCase bool) int {
+	text := fmt.Sprint(value)
+	for i, candidate := range allowed {
+		option := fmt.Sprint(candidate)
+		if option == text || (foldCase && strings.EqualFold(option, text)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func formatEnum(allowed []interface{}) string {
+	options := make([]string, len(allowed))
+	for i, option := range allowed {
+		options[i] = fmt.Sprint(option)
+	}
+	return strings.Join(options, ", ")
+}
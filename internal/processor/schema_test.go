@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+const testSchema = `
+fields:
+  title: {type: string, required: true}
+  status: {type: string, enum: [draft, active, done], default: draft}
+  priority: {type: integer}
+  created: {type: date, default: "{{file.mtime|date:YYYY-MM-DD}}"}
+  tags: {type: array}
+  slug: {type: string, pattern: "^[a-z-]+$"}
+folders:
+  journal:
+    fields:
+      status: {type: string, required: true, enum: [open, closed]}
+  journal/2024:
+    fields:
+      mood: {type: string, required: true}
+`
+
+func schemaTestFile(relPath string, frontmatter map[string]interface{}) *vault.VaultFile {
+	return &vault.VaultFile{
+		Path:         relPath,
+		RelativePath: relPath,
+		Frontmatter:  frontmatter,
+		Modified:     time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestParseSchema_Errors(t *testing.T) {
+	tests := map[string]string{
+		"fields: {a: {type: text}}":                 "invalid type 'text'",
+		"fields: {a: {pattern: '['}}":               "invalid pattern",
+		"fields: {a: {default: '{{a'}}":             "invalid default",
+		"folders: {x: {fields: {a: {type: nope}}}}": "folder 'x'",
+		"{}": "no fields",
+	}
+	for schema, want := range tests {
+		_, err := ParseSchema([]byte(schema))
+		assert.ErrorContains(t, err, want, schema)
+	}
+
+	// JSON works too
+	schema, err := ParseSchema([]byte(`{"fields": {"title": {"type": "string", "required": true}}}`))
+	require.NoError(t, err)
+	assert.True(t, schema.Fields["title"].Required)
+}
+
+func TestSchema_FieldsFor(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"draft", "active", "done"}, schema.FieldsFor("note.md")["status"].Enum)
+	assert.Equal(t, []interface{}{"open", "closed"}, schema.FieldsFor("journal/day.md")["status"].Enum)
+	assert.NotContains(t, schema.FieldsFor("journal/day.md"), "mood")
+	assert.Contains(t, schema.FieldsFor("journal/2024/day.md"), "mood")
+	assert.Contains(t, schema.FieldsFor("journal/2024/day.md"), "status", "outer folder overrides still apply")
+	assert.NotContains(t, schema.FieldsFor("journalling/day.md"), "mood")
+
+	schema.Base = "journal"
+	assert.Equal(t, []interface{}{"open", "closed"}, schema.FieldsFor("day.md")["status"].Enum)
+}
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	valid := schemaTestFile("note.md", map[string]interface{}{
+		"title": "Note", "status": "draft", "priority": 2,
+		"created": vault.Date{Time: time.Now()}, "tags": []interface{}{"a"}, "slug": "a-note",
+	})
+	assert.Empty(t, schema.Validate(valid))
+
+	invalid := schemaTestFile("note.md", map[string]interface{}{
+		"title": "", "status": "Draft", "priority": 2.5, "created": "someday", "tags": "a", "slug": "A Note",
+	})
+	var got []string
+	for _, e := range schema.Validate(invalid) {
+		got = append(got, e.Field+":"+e.Type)
+	}
+	assert.Equal(t, []string{
+		"created:invalid_type", "priority:invalid_type", "slug:pattern_mismatch",
+		"status:invalid_enum", "tags:invalid_type", "title:missing_required",
+	}, got)
+
+	// Date strings count as dates
+	dated := schemaTestFile("note.md", map[string]interface{}{"title": "x", "created": "2024-03-05T10:00:00"})
+	assert.Empty(t, schema.Validate(dated))
+}
+
+func TestSchema_Fix(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	file := schemaTestFile("note.md", map[string]interface{}{
+		"title": "Note", "status": "ACTIVE", "priority": "3", "tags": "a, b", "slug": "Not Fixable",
+	})
+	fixes := schema.Fix(file)
+
+	var got []string
+	for _, fix := range fixes {
+		got = append(got, fix.String())
+	}
+	assert.Equal(t, []string{"set created=2024-05-06", "cast priority to integer", "normalize status to active", "cast tags to array"}, got)
+	assert.Equal(t, vault.Date{Time: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)}, file.Frontmatter["created"])
+	assert.Equal(t, 3, file.Frontmatter["priority"])
+	assert.Equal(t, []string{"a", "b"}, file.Frontmatter["tags"])
+
+	var remaining []string
+	for _, e := range schema.Validate(file) {
+		remaining = append(remaining, e.Field+":"+e.Type)
+	}
+	assert.Equal(t, []string{"slug:pattern_mismatch"}, remaining)
+
+	assert.Empty(t, schema.Fix(file), "fixing is idempotent")
+}
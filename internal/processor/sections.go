@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sectionEndPattern matches headings that end a level 2 section
+var sectionEndPattern = regexp.MustCompile(`(?m)^#{1,2}[ \t]`)
+
+// ListSection is a level 2 section holding a list that mdnotes maintains in
+// notes, like backlinks or related notes
+type ListSection struct {
+	heading string
+	pattern *regexp.Regexp
+}
+
+// NewListSection creates a list section titled heading
+func NewListSection(heading string) *ListSection {
+	return &ListSection{
+		heading: heading,
+		pattern: regexp.MustCompile(`(?m)^##[ \t]+` + regexp.QuoteMeta(heading) + `[ \t]*$`),
+	}
+}
+
+// Update replaces the section in body with one listing items, keeping its
+// place in the note, or appends one. Without items the section is removed.
+func (s *ListSection) Update(body string, items []string) string {
+	body, at := s.Remove(body)
+	if len(items) == 0 {
+		return body
+	}
+
+	var section strings.Builder
+	section.WriteString("## " + s.heading + "\n\n")
+	for _, item := range items {
+		section.WriteString("- " + item + "\n")
+	}
+
+	if at < 0 {
+		body = strings.TrimRight(body, "\n")
+		if body != "" {
+			body += "\n\n"
+		}
+		return body + section.String()
+	}
+	return body[:at] + section.String() + "\n" + body[at:]
+}
+
+// Remove returns body without the section, and where the section started
+// when other sections follow it, or -1
+func (s *ListSection) Remove(body string) (string, int) {
+	loc := s.pattern.FindStringIndex(body)
+	if loc == nil {
+		return body, -1
+	}
+	next := sectionEndPattern.FindStringIndex(body[loc[1]:])
+	if next == nil {
+		before := strings.TrimRight(body[:loc[0]], "\n")
+		if before != "" {
+			before += "\n"
+		}
+		return before, -1
+	}
+	end := loc[1] + next[0]
+	return body[:loc[0]] + body[end:], loc[0]
+}
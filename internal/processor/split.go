@@ -0,0 +1,351 @@
+package processor
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// NoteSection is a heading and the lines under it, up to the next heading of
+// the same or a higher level
+type NoteSection struct {
+	Heading vault.Heading
+	Start   int // Index of the heading line in the body's lines
+	End     int // Index of the first line after the section
+}
+
+// FindSection finds the section under heading in body. The heading may be
+// given with its level, as in "## Topic", or as plain text matching a heading
+// at any level; the first match is used.
+func FindSection(body, heading string) (NoteSection, error) {
+	level := 0
+	text := strings.TrimSpace(heading)
+	if trimmed := strings.TrimLeft(text, "#"); trimmed != text && strings.HasPrefix(trimmed, " ") {
+		level = len(text) - len(trimmed)
+		text = strings.TrimSpace(trimmed)
+	}
+
+	headings := vault.ExtractHeadings(body)
+	lineCount := len(strings.Split(body, "\n"))
+	for i, h := range headings {
+		if h.Text != text || (level != 0 && h.Level != level) {
+			continue
+		}
+		section := NoteSection{Heading: h, Start: h.Line - 1, End: lineCount}
+		for _, next := range headings[i+1:] {
+			if next.Level <= h.Level {
+				section.End = next.Line - 1
+				break
+			}
+		}
+		return section, nil
+	}
+	return NoteSection{}, fmt.Errorf("heading '%s' not found", heading)
+}
+
+// NoteSplitter moves sections of a note into new notes of their own
+type NoteSplitter struct {
+	Link bool // Leave a link to each new note where its section was
+}
+
+// Split moves the sections under headings out of file into new notes named
+// after the headings, in the same folder. Each new note starts with its
+// section's heading as a level 1 heading, with subheadings promoted to match.
+// file's body is updated; the new notes are returned for the caller to write.
+func (s *NoteSplitter) Split(file *vault.VaultFile, headings []string) ([]*vault.VaultFile, error) {
+	lines := strings.Split(file.Body, "\n")
+	headingLines := make(map[int]int) // line index -> heading level
+	for _, h := range vault.ExtractHeadings(file.Body) {
+		headingLines[h.Line-1] = h.Level
+	}
+
+	sections := make([]NoteSection, 0, len(headings))
+	for _, heading := range headings {
+		section, err := FindSection(file.Body, heading)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range sections {
+			if section.Start < other.End && other.Start < section.End {
+				return nil, fmt.Errorf("sections '%s' and '%s' overlap", other.Heading.Text, section.Heading.Text)
+			}
+		}
+		sections = append(sections, section)
+	}
+
+	dir := filepath.Dir(file.RelativePath)
+	replacements := make(map[int]string, len(sections))
+	var notes []*vault.VaultFile
+	for _, section := range sections {
+		name := SectionNoteName(section.Heading.Text)
+		if name == "" {
+			return nil, fmt.Errorf("heading '%s' does not make a valid note name", section.Heading.Text)
+		}
+		relPath := filepath.Join(dir, name+".md")
+		if relPath == filepath.Clean(file.RelativePath) {
+			return nil, fmt.Errorf("section '%s' would replace the note itself", section.Heading.Text)
+		}
+
+		notes = append(notes, &vault.VaultFile{
+			Path:         filepath.Join(filepath.Dir(file.Path), name+".md"),
+			RelativePath: relPath,
+			Body:         promoteSection(lines, headingLines, section),
+		})
+		replacements[section.Start] = name
+	}
+
+	file.Body = s.removeSections(lines, sections, replacements)
+	return notes, nil
+}
+
+// Extract moves the section under heading out of file into note, a new note
+// whose Path and RelativePath the caller has set, promoting it as Split does.
+// The frontmatter fields in carry are copied to the new note. Links inside
+// the section to headings left behind are pointed at file, and links left
+// in file to the moved headings at the new note. file's body is updated;
+// what moved is returned, for updating links in other notes.
+func (s *NoteSplitter) Extract(file *vault.VaultFile, heading string, note *vault.VaultFile, carry []string) (SectionMove, error) {
+	section, err := FindSection(file.Body, heading)
+	if err != nil {
+		return SectionMove{}, err
+	}
+	if filepath.Clean(note.RelativePath) == filepath.Clean(file.RelativePath) {
+		return SectionMove{}, fmt.Errorf("section '%s' would replace the note itself", section.Heading.Text)
+	}
+
+	lines := strings.Split(file.Body, "\n")
+	headingLines := make(map[int]int) // line index -> heading level
+	move := SectionMove{From: file.RelativePath, To: note.RelativePath}
+	for _, h := range vault.ExtractHeadings(file.Body) {
+		headingLines[h.Line-1] = h.Level
+		if h.Line-1 >= section.Start && h.Line-1 < section.End {
+			move.Headings = append(move.Headings, h.Text)
+		}
+	}
+
+	note.Body = promoteSection(lines, headingLines, section)
+	for _, field := range carry {
+		if value, ok := file.GetField(field); ok {
+			note.SetField(field, value)
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(note.RelativePath), ".md")
+	file.Body = s.removeSections(lines, []NoteSection{section}, map[int]string{section.Start: name})
+
+	// Links between the two notes' headings now cross from one to the other
+	note.Body = rewriteLinks(note.Body, func(link Link) (Link, bool) {
+		if link.Target != "" || link.Fragment == "" || move.moved(link.Fragment) {
+			return link, false
+		}
+		if link.Type == MarkdownLink {
+			link.Target = crossNoteTarget("", note.RelativePath, file.RelativePath)
+		} else {
+			link.Target = strings.TrimSuffix(filepath.Base(file.RelativePath), ".md")
+		}
+		return link, true
+	})
+	file.Body, _ = UpdateSectionLinks(file.Body, file.RelativePath, move)
+	return move, nil
+}
+
+// promoteSection returns the lines of section, with its headings promoted
+// so its own heading is level 1
+func promoteSection(lines []string, headingLines map[int]int, section NoteSection) string {
+	shift := section.Heading.Level - 1
+	content := make([]string, 0, section.End-section.Start)
+	for i := section.Start; i < section.End; i++ {
+		line := lines[i]
+		if level, ok := headingLines[i]; ok && shift > 0 {
+			trimmed := strings.TrimLeft(line, " \t")
+			line = strings.Repeat("#", level-shift) + trimmed[level:]
+		}
+		content = append(content, line)
+	}
+	return strings.TrimRight(strings.Join(content, "\n"), "\n") + "\n"
+}
+
+// removeSections returns the body without sections, with a link to the
+// note each moved to, by the names keyed by section start, when Link is set
+func (s *NoteSplitter) removeSections(lines []string, sections []NoteSection, names map[int]string) string {
+	starts := make(map[int]NoteSection, len(sections))
+	for _, section := range sections {
+		starts[section.Start] = section
+	}
+
+	var body []string
+	for i := 0; i < len(lines); i++ {
+		section, ok := starts[i]
+		if !ok {
+			body = append(body, lines[i])
+			continue
+		}
+		if s.Link {
+			body = append(body, "[["+names[i]+"]]", "")
+		}
+		i = section.End - 1
+	}
+	return strings.TrimRight(strings.Join(body, "\n"), "\n") + "\n"
+}
+
+// SectionMove records a section moved from one note into another, for
+// updating the links to it
+type SectionMove struct {
+	From     string   // Vault-relative path of the note the section left
+	To       string   // Vault-relative path of the note it moved to
+	Headings []string // The section's heading, then its subheadings
+}
+
+// moved reports whether a link fragment names one of the moved headings
+func (m SectionMove) moved(fragment string) bool {
+	anchor := strings.ToLower(headingLinkAnchor(fragment))
+	for _, heading := range m.Headings {
+		if strings.ToLower(headingLinkAnchor(heading)) == anchor {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateSectionLinks points links in body, the note at relPath, to the
+// moved section's headings at the note it moved to. A link to the section's
+// own heading becomes a link to the note. It returns the new body and how
+// many links changed.
+func UpdateSectionLinks(body, relPath string, move SectionMove) (string, int) {
+	if len(move.Headings) == 0 {
+		return body, 0
+	}
+	fromName := strings.ToLower(strings.TrimSuffix(filepath.Base(move.From), ".md"))
+	toName := strings.TrimSuffix(filepath.Base(move.To), ".md")
+	sameNote := filepath.Clean(relPath) == filepath.Clean(move.From)
+
+	changed := 0
+	body = rewriteLinks(body, func(link Link) (Link, bool) {
+		if link.Fragment == "" || strings.HasPrefix(link.Fragment, "^") || !move.moved(link.Fragment) {
+			return link, false
+		}
+		target := filepath.ToSlash(link.Target)
+		if target == "" {
+			if !sameNote {
+				return link, false
+			}
+		} else if strings.ToLower(strings.TrimSuffix(path.Base(target), ".md")) != fromName {
+			return link, false
+		}
+
+		switch {
+		case link.Type == MarkdownLink:
+			link.Target = crossNoteTarget(target, move.From, move.To)
+		case strings.Contains(target, "/"):
+			link.Target = strings.TrimSuffix(filepath.ToSlash(move.To), ".md")
+		default:
+			link.Target = toName
+		}
+		if headingLinkAnchor(link.Fragment) == headingLinkAnchor(move.Headings[0]) {
+			link.Fragment = ""
+		}
+		changed++
+		return link, true
+	})
+	return body, changed
+}
+
+// crossNoteTarget returns the markdown link target for the note at to, in
+// place of target, a link to the note at from. The link keeps its folder
+// when the notes share one, and otherwise names the note from the vault root.
+func crossNoteTarget(target, from, to string) string {
+	if filepath.Dir(from) == filepath.Dir(to) {
+		return path.Join(path.Dir(target), filepath.Base(to))
+	}
+	return filepath.ToSlash(to)
+}
+
+// sameNoteWikiLinkRegex matches wiki links and embeds to a heading or block
+// in the same note, which the link parser leaves out
+var sameNoteWikiLinkRegex = regexp.MustCompile(`(!?)\[\[#([^\]|]+)(?:\|([^\]]*))?\]\]`)
+
+// rewriteLinks replaces the links in body that fn changes
+func rewriteLinks(body string, fn func(link Link) (Link, bool)) string {
+	links := NewLinkParser().Extract(body)
+	for _, m := range sameNoteWikiLinkRegex.FindAllStringSubmatchIndex(body, -1) {
+		link := Link{Type: WikiLink, Fragment: body[m[4]:m[5]], Position: Position{Start: m[0], End: m[1]}}
+		if m[3] > m[2] {
+			link.Type = EmbedLink
+		}
+		if m[6] >= 0 {
+			link.Alias = body[m[6]:m[7]]
+		}
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Position.Start < links[j].Position.Start
+	})
+
+	for i := len(links) - 1; i >= 0; i-- {
+		link := links[i]
+		if link.Type == EmbedLink {
+			link = splitEmbedAlias(link)
+		}
+		updated, ok := fn(link)
+		if !ok {
+			continue
+		}
+		body = body[:link.Position.Start] + formatLinkAsWritten(updated) + body[link.Position.End:]
+	}
+	return body
+}
+
+// formatLinkAsWritten writes a link in the syntax it was found in
+func formatLinkAsWritten(link Link) string {
+	switch link.Type {
+	case MarkdownLink:
+		target := link.Target
+		if link.Encoding == "angle" {
+			if link.Fragment != "" {
+				target += "#" + link.Fragment
+			}
+			return "[" + link.Text + "](<" + target + ">)"
+		}
+		target = pathEscaper.Replace(target)
+		if link.Fragment != "" {
+			target += "#" + NewLinkConverter().escapeFragment(link.Fragment)
+		}
+		return "[" + link.Text + "](" + target + ")"
+	case EmbedLink:
+		target := link.Target
+		if link.Fragment != "" {
+			target += "#" + link.Fragment
+		}
+		if link.Alias != "" {
+			target += "|" + link.Alias
+		}
+		return "![[" + target + "]]"
+	default:
+		target := link.Target
+		if link.Fragment != "" {
+			target += "#" + link.Fragment
+		}
+		if link.Alias != "" {
+			target += "|" + link.Alias
+		}
+		return "[[" + target + "]]"
+	}
+}
+
+// SectionNoteName returns the name, without extension, of the note a
+// section with the heading text is split into
+func SectionNoteName(heading string) string {
+	return sanitizeNoteName(heading)
+}
+
+// sanitizeNoteName replaces characters that can't appear in a note's file
+// name or a wiki link
+func sanitizeNoteName(name string) string {
+	replacer := strings.NewReplacer("/", "-", "\\", "-", ":", "-", "#", "", "|", "-", "[", "", "]", "", "^", "")
+	return strings.TrimSpace(replacer.Replace(name))
+}
@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+const splitTestBody = `# Reading
+
+Intro.
+
+## Fiction
+
+Novels.
+
+### Sci-fi
+
+` + "```md\n# not a heading\n```" + `
+
+## History: Ancient
+
+Empires.
+
+## Misc
+
+Odds and ends.
+`
+
+func TestFindSection(t *testing.T) {
+	tests := []struct {
+		heading    string
+		start, end int
+		wantErr    bool
+	}{
+		{heading: "## Fiction", start: 4, end: 14},
+		{heading: "Fiction", start: 4, end: 14},
+		{heading: "### Sci-fi", start: 8, end: 14},
+		{heading: "## Misc", start: 18, end: 22},
+		{heading: "# Reading", start: 0, end: 22},
+		{heading: "# Fiction", wantErr: true},
+		{heading: "not a heading", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.heading, func(t *testing.T) {
+			section, err := FindSection(splitTestBody, tt.heading)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.start, section.Start)
+			assert.Equal(t, tt.end, section.End)
+		})
+	}
+}
+
+func TestNoteSplitter_Split(t *testing.T) {
+	file := &vault.VaultFile{Path: "/vault/notes/reading.md", RelativePath: "notes/reading.md", Body: splitTestBody}
+
+	notes, err := (&NoteSplitter{Link: true}).Split(file, []string{"## Fiction", "History: Ancient"})
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+
+	assert.Equal(t, "notes/Fiction.md", notes[0].RelativePath)
+	assert.Equal(t, "/vault/notes/Fiction.md", notes[0].Path)
+	assert.Equal(t, "# Fiction\n\nNovels.\n\n## Sci-fi\n\n```md\n# not a heading\n```\n", notes[0].Body)
+	assert.Equal(t, "notes/History- Ancient.md", notes[1].RelativePath)
+	assert.Equal(t, "# History: Ancient\n\nEmpires.\n", notes[1].Body)
+
+	assert.Equal(t, "# Reading\n\nIntro.\n\n[[Fiction]]\n\n[[History- Ancient]]\n\n## Misc\n\nOdds and ends.\n", file.Body)
+}
+
+func TestNoteSplitter_Errors(t *testing.T) {
+	splitter := &NoteSplitter{}
+	file := func() *vault.VaultFile {
+		return &vault.VaultFile{RelativePath: "Fiction.md", Body: splitTestBody}
+	}
+
+	_, err := splitter.Split(file(), []string{"## Fiction", "### Sci-fi"})
+	assert.ErrorContains(t, err, "overlap")
+
+	_, err = splitter.Split(file(), []string{"## Fiction"})
+	assert.ErrorContains(t, err, "would replace the note itself")
+
+	_, err = splitter.Split(file(), []string{"## Missing"})
+	assert.ErrorContains(t, err, "heading '## Missing' not found")
+
+	// Without links, sections are just removed
+	f := &vault.VaultFile{RelativePath: "reading.md", Body: splitTestBody}
+	_, err = splitter.Split(f, []string{"## Misc"})
+	require.NoError(t, err)
+	assert.NotContains(t, f.Body, "Misc")
+	assert.NotContains(t, f.Body, "[[")
+}
+
+func TestNoteSplitter_Extract(t *testing.T) {
+	file := &vault.VaultFile{Path: "/vault/notes/reading.md", RelativePath: "notes/reading.md"}
+	require.NoError(t, file.Parse([]byte("---\ntags: [books]\nrating: 4\n---\n# Reading\n\n"+
+		"See [[#Fiction]] and [[#Sci-fi|sci-fi]], or [[#Misc]].\n\n"+
+		"## Fiction\n\nNovels, unlike [odds](#Misc).\n\n### Sci-fi\n\nDune.\n\n## Misc\n\nOdds and ends.\n")))
+
+	note := &vault.VaultFile{Path: "/vault/notes/Novels.md", RelativePath: "notes/Novels.md"}
+	move, err := (&NoteSplitter{Link: true}).Extract(file, "## Fiction", note, []string{"tags", "missing"})
+	require.NoError(t, err)
+
+	assert.Equal(t, SectionMove{From: "notes/reading.md", To: "notes/Novels.md", Headings: []string{"Fiction", "Sci-fi"}}, move)
+	assert.Equal(t, "# Fiction\n\nNovels, unlike [odds](reading.md#Misc).\n\n## Sci-fi\n\nDune.\n", note.Body)
+	assert.Equal(t, map[string]interface{}{"tags": []interface{}{"books"}}, note.Frontmatter)
+	assert.Equal(t, "# Reading\n\nSee [[Novels]] and [[Novels#Sci-fi|sci-fi]], or [[#Misc]].\n\n[[Novels]]\n\n## Misc\n\nOdds and ends.\n", file.Body)
+
+	_, err = (&NoteSplitter{}).Extract(file, "## Misc", &vault.VaultFile{RelativePath: "notes/reading.md"}, nil)
+	assert.ErrorContains(t, err, "would replace the note itself")
+}
+
+func TestUpdateSectionLinks(t *testing.T) {
+	move := SectionMove{From: "notes/reading.md", To: "topics/Fiction.md", Headings: []string{"Fiction", "Sci-fi"}}
+	body := "[[reading#Fiction]] [[notes/reading#Sci-fi|SF]] ![[reading#Fiction]] [[reading#Misc]] [[other#Fiction]] " +
+		"[f](notes/reading.md#Fiction) [s](<reading.md#Sci-fi>) [[#Fiction]]"
+
+	updated, changed := UpdateSectionLinks(body, "index.md", move)
+	assert.Equal(t, 5, changed)
+	assert.Equal(t, "[[Fiction]] [[topics/Fiction#Sci-fi|SF]] ![[Fiction]] [[reading#Misc]] [[other#Fiction]] "+
+		"[f](topics/Fiction.md) [s](<topics/Fiction.md#Sci-fi>) [[#Fiction]]", updated)
+}
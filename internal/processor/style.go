@@ -0,0 +1,466 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// StyleTransform is a single opt-in rewrite of markdown prose. Transforms are
+// applied in sequence by a StyleProcessor and report how many changes they made.
+type StyleTransform interface {
+	Name() string
+	Apply(body string) (string, int)
+}
+
+// StyleRules selects which style transforms to apply. Empty values disable a rule.
+type StyleRules struct {
+	Quotes     string // "curly" or "straight"
+	Emphasis   string // "asterisk" or "underscore" marker for italics
+	Strong     string // "asterisk" or "underscore" marker for bold
+	Bullets    string // "-", "*" or "+"
+	ListIndent int    // Spaces per list nesting level (2 or 4); 0 leaves indentation alone
+	Checkboxes bool   // Normalize task checkbox spacing and case
+	CodeLang   bool   // Add language hints to unlabelled fenced code blocks
+}
+
+// StyleProcessor applies a sequence of style transforms to file bodies
+type StyleProcessor struct {
+	transforms []StyleTransform
+}
+
+// NewStyleProcessor builds a processor from rules, validating each rule
+func NewStyleProcessor(rules StyleRules) (*StyleProcessor, error) {
+	var transforms []StyleTransform
+
+	switch rules.Quotes {
+	case "":
+	case "curly", "straight":
+		transforms = append(transforms, QuotesTransform{Curly: rules.Quotes == "curly"})
+	default:
+		return nil, fmt.Errorf("unsupported quotes style %q (supported: curly, straight)", rules.Quotes)
+	}
+
+	for _, rule := range []struct {
+		name, value string
+		strong      bool
+	}{
+		{"strong", rules.Strong, true},
+		{"emphasis", rules.Emphasis, false},
+	} {
+		switch rule.value {
+		case "":
+		case "asterisk", "underscore":
+			transforms = append(transforms, EmphasisTransform{Strong: rule.strong, Marker: emphasisMarker(rule.value)})
+		default:
+			return nil, fmt.Errorf("unsupported %s marker %q (supported: asterisk, underscore)", rule.name, rule.value)
+		}
+	}
+
+	switch rules.Bullets {
+	case "":
+	case "-", "*", "+":
+		transforms = append(transforms, BulletTransform{Marker: rules.Bullets})
+	default:
+		return nil, fmt.Errorf("unsupported bullet marker %q (supported: -, *, +)", rules.Bullets)
+	}
+
+	// Checkbox fixes turn items like "-[ ]" into list items, so run them before re-indenting
+	if rules.Checkboxes {
+		transforms = append(transforms, CheckboxTransform{})
+	}
+
+	switch rules.ListIndent {
+	case 0:
+	case 2, 4:
+		transforms = append(transforms, ListIndentTransform{Indent: rules.ListIndent})
+	default:
+		return nil, fmt.Errorf("unsupported list indent %d (supported: 2, 4)", rules.ListIndent)
+	}
+
+	if rules.CodeLang {
+		transforms = append(transforms, CodeLangTransform{})
+	}
+
+	if len(transforms) == 0 {
+		return nil, fmt.Errorf("no style rules selected")
+	}
+
+	return &StyleProcessor{transforms: transforms}, nil
+}
+
+// Process applies every transform to the file body and returns change counts by transform name
+func (sp *StyleProcessor) Process(file *vault.VaultFile) map[string]int {
+	changes := make(map[string]int)
+	body := file.Body
+	for _, transform := range sp.transforms {
+		var n int
+		body, n = transform.Apply(body)
+		if n > 0 {
+			changes[transform.Name()] += n
+		}
+	}
+	file.Body = body
+	return changes
+}
+
+func emphasisMarker(style string) string {
+	if style == "underscore" {
+		return "_"
+	}
+	return "*"
+}
+
+var (
+	fenceRegex         = regexp.MustCompile("^\\s*(```+|~~~+)\\s*(.*)$")
+	protectedSpanRegex = regexp.MustCompile("`+[^`]*`+|\\[\\[[^\\]]*\\]\\]|\\]\\([^)]*\\)|<[^>\\s][^>]*>|https?://\\S+")
+)
+
+// mapProseLines calls fn for every line outside fenced code blocks
+func mapProseLines(body string, fn func(line string) (string, int)) (string, int) {
+	lines := strings.Split(body, "\n")
+	total := 0
+	fence := ""
+	for i, line := range lines {
+		if m := fenceRegex.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && m[2] == "" {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		var n int
+		lines[i], n = fn(line)
+		total += n
+	}
+	return strings.Join(lines, "\n"), total
+}
+
+// mapProseSegments calls fn for the parts of a line outside inline code, links and URLs
+func mapProseSegments(line string, fn func(segment string) (string, int)) (string, int) {
+	spans := protectedSpanRegex.FindAllStringIndex(line, -1)
+	if len(spans) == 0 {
+		return fn(line)
+	}
+
+	var b strings.Builder
+	total, last := 0, 0
+	for _, span := range spans {
+		segment, n := fn(line[last:span[0]])
+		b.WriteString(segment)
+		b.WriteString(line[span[0]:span[1]])
+		total += n
+		last = span[1]
+	}
+	segment, n := fn(line[last:])
+	b.WriteString(segment)
+	return b.String(), total + n
+}
+
+// QuotesTransform converts between straight and typographic quotes
+type QuotesTransform struct {
+	Curly bool
+}
+
+// Name returns the transform name
+func (t QuotesTransform) Name() string { return "quotes" }
+
+// Apply rewrites quotes in prose
+func (t QuotesTransform) Apply(body string) (string, int) {
+	return mapProseLines(body, func(line string) (string, int) {
+		return mapProseSegments(line, t.applySegment)
+	})
+}
+
+func (t QuotesTransform) applySegment(segment string) (string, int) {
+	if !t.Curly {
+		replacer := strings.NewReplacer("“", `"`, "”", `"`, "„", `"`, "‘", "'", "’", "'")
+		result := replacer.Replace(segment)
+		if result == segment {
+			return segment, 0
+		}
+		return result, strings.Count(segment, "“") + strings.Count(segment, "”") + strings.Count(segment, "„") +
+			strings.Count(segment, "‘") + strings.Count(segment, "’")
+	}
+
+	runes := []rune(segment)
+	changes := 0
+	for i, r := range runes {
+		if r != '"' && r != '\'' {
+			continue
+		}
+
+		opening := i == 0 || strings.ContainsRune(" \t([{—–-*_", runes[i-1])
+		switch {
+		case r == '"' && opening:
+			runes[i] = '“'
+		case r == '"':
+			runes[i] = '”'
+		case opening:
+			runes[i] = '‘'
+		default:
+			runes[i] = '’' // closing quote or apostrophe
+		}
+		changes++
+	}
+	return string(runes), changes
+}
+
+// EmphasisTransform makes italic or bold markers consistent
+type EmphasisTransform struct {
+	Strong bool   // bold (** / __) rather than italics (* / _)
+	Marker string // "*" or "_"
+}
+
+var (
+	strongAsteriskRegex     = regexp.MustCompile(`(^|[^\w*\\])\*\*(\S(?:[^*]*\S)?)\*\*([^\w*]|$)`)
+	strongUnderscoreRegex   = regexp.MustCompile(`(^|[^\w_\\])__(\S(?:[^_]*\S)?)__([^\w_]|$)`)
+	emphasisAsteriskRegex   = regexp.MustCompile(`(^|[^\w*\\])\*([^*\s](?:[^*]*[^*\s])?)\*([^\w*]|$)`)
+	emphasisUnderscoreRegex = regexp.MustCompile(`(^|[^\w_\\])_([^_\s](?:[^_]*[^_\s])?)_([^\w_]|$)`)
+)
+
+// Name returns the transform name
+func (t EmphasisTransform) Name() string {
+	if t.Strong {
+		return "strong"
+	}
+	return "emphasis"
+}
+
+// Apply rewrites emphasis markers in prose
+func (t EmphasisTransform) Apply(body string) (string, int) {
+	var from *regexp.Regexp
+	to := t.Marker
+	switch {
+	case t.Strong && t.Marker == "*":
+		from, to = strongUnderscoreRegex, "**"
+	case t.Strong:
+		from, to = strongAsteriskRegex, "__"
+	case t.Marker == "*":
+		from = emphasisUnderscoreRegex
+	default:
+		from = emphasisAsteriskRegex
+	}
+
+	return mapProseLines(body, func(line string) (string, int) {
+		return mapProseSegments(line, func(segment string) (string, int) {
+			changes := 0
+			// Adjacent matches share boundary characters, so repeat until stable
+			for {
+				matches := len(from.FindAllStringIndex(segment, -1))
+				if matches == 0 {
+					return segment, changes
+				}
+				segment = from.ReplaceAllString(segment, "${1}"+to+"${2}"+to+"${3}")
+				changes += matches
+			}
+		})
+	})
+}
+
+// BulletTransform makes unordered list markers consistent
+type BulletTransform struct {
+	Marker string
+}
+
+var (
+	bulletRegex        = regexp.MustCompile(`^(\s*)[-*+](\s+)`)
+	thematicBreakRegex = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+)
+
+// Name returns the transform name
+func (t BulletTransform) Name() string { return "bullets" }
+
+// Apply rewrites list markers outside code blocks
+func (t BulletTransform) Apply(body string) (string, int) {
+	return mapProseLines(body, func(line string) (string, int) {
+		if thematicBreakRegex.MatchString(line) {
+			return line, 0
+		}
+		m := bulletRegex.FindStringSubmatchIndex(line)
+		if m == nil {
+			return line, 0
+		}
+		markerPos := m[3] // end of leading indentation
+		if line[markerPos:markerPos+1] == t.Marker {
+			return line, 0
+		}
+		return line[:markerPos] + t.Marker + line[markerPos+1:], 1
+	})
+}
+
+// ListIndentTransform re-indents nested list items with a consistent number of
+// spaces per level. Nesting depth is taken from the existing indentation, with
+// tabs counted as four columns.
+type ListIndentTransform struct {
+	Indent int
+}
+
+var listItemRegex = regexp.MustCompile(`^([ \t]*)([-*+]|\d+[.)])([ \t]|$)`)
+
+// Name returns the transform name
+func (t ListIndentTransform) Name() string { return "list-indent" }
+
+// Apply re-indents list items outside code blocks
+func (t ListIndentTransform) Apply(body string) (string, int) {
+	var levels []int // original indentation width of each open nesting level
+
+	return mapProseLines(body, func(line string) (string, int) {
+		m := listItemRegex.FindStringSubmatch(line)
+		if m == nil || thematicBreakRegex.MatchString(line) {
+			// Unindented text ends the list; blank and continuation lines don't
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+				levels = nil
+			}
+			return line, 0
+		}
+
+		width := indentWidth(m[1])
+		for len(levels) > 0 && levels[len(levels)-1] > width {
+			levels = levels[:len(levels)-1]
+		}
+		if len(levels) == 0 || levels[len(levels)-1] < width {
+			levels = append(levels, width)
+		}
+
+		indent := strings.Repeat(" ", (len(levels)-1)*t.Indent)
+		if indent == m[1] {
+			return line, 0
+		}
+		return indent + line[len(m[1]):], 1
+	})
+}
+
+// indentWidth returns the column width of leading whitespace, with tab stops every 4 columns
+func indentWidth(indent string) int {
+	width := 0
+	for _, r := range indent {
+		if r == '\t' {
+			width += 4 - width%4
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// CheckboxTransform normalizes task checkboxes to "- [ ] task" / "- [x] task",
+// fixing spacing variants like "-[ ]", "- []" and "- [X]task". Custom status
+// characters (e.g. "[/]", "[-]") are preserved.
+type CheckboxTransform struct{}
+
+var checkboxRegex = regexp.MustCompile(`^([ \t]*)([-*+]|\d+[.)])[ \t]*\[[ \t]*([^\]\s]?)[ \t]*\][ \t]*(.*)$`)
+
+// Name returns the transform name
+func (t CheckboxTransform) Name() string { return "checkboxes" }
+
+// Apply normalizes checkboxes outside code blocks
+func (t CheckboxTransform) Apply(body string) (string, int) {
+	return mapProseLines(body, func(line string) (string, int) {
+		m := checkboxRegex.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(m[4], "(") {
+			// Not a task, or a markdown link like "- [a](url)"
+			return line, 0
+		}
+
+		status := m[3]
+		switch status {
+		case "":
+			status = " "
+		case "X":
+			status = "x"
+		}
+
+		normalized := m[1] + m[2] + " [" + status + "]"
+		if m[4] != "" {
+			normalized += " " + m[4]
+		}
+		if normalized == line {
+			return line, 0
+		}
+		return normalized, 1
+	})
+}
+
+// CodeLangTransform adds a language hint to fenced code blocks without one,
+// when the language can be inferred confidently from the content
+type CodeLangTransform struct{}
+
+// Name returns the transform name
+func (t CodeLangTransform) Name() string { return "code-lang" }
+
+// Apply labels unlabelled code fences
+func (t CodeLangTransform) Apply(body string) (string, int) {
+	lines := strings.Split(body, "\n")
+	changes := 0
+
+	for i := 0; i < len(lines); i++ {
+		m := fenceRegex.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		// Find the closing fence
+		end := -1
+		for j := i + 1; j < len(lines); j++ {
+			if c := fenceRegex.FindStringSubmatch(lines[j]); c != nil && c[1][0] == m[1][0] && len(c[1]) >= len(m[1]) && c[2] == "" {
+				end = j
+				break
+			}
+		}
+		if end == -1 {
+			break
+		}
+
+		if m[2] == "" {
+			if lang := InferCodeLanguage(strings.Join(lines[i+1:end], "\n")); lang != "" {
+				lines[i] = strings.TrimRight(lines[i], " \t") + lang
+				changes++
+			}
+		}
+		i = end
+	}
+
+	return strings.Join(lines, "\n"), changes
+}
+
+var codeLangHints = []struct {
+	lang    string
+	pattern *regexp.Regexp
+}{
+	{"bash", regexp.MustCompile(`\A#!/(usr/)?bin/(env )?(ba|z)?sh|(?m)^\$ \S`)},
+	{"python", regexp.MustCompile(`\A#!/usr/bin/env python|(?m)^(def \w+\(.*\):|class \w+(\(.*\))?:|from [\w.]+ import |if __name__ == ["']__main__["']:)`)},
+	{"go", regexp.MustCompile(`(?m)^package \w+$|^func (\(\w+ \*?\w+\) )?\w+\(`)},
+	{"php", regexp.MustCompile(`\A<\?php`)},
+	{"html", regexp.MustCompile(`(?i)\A\s*(<!doctype html|<html)`)},
+	{"sql", regexp.MustCompile(`(?is)\A\s*(select\s.+\sfrom\s|insert\s+into\s|create\s+table\s|update\s+\w+\s+set\s)`)},
+	{"c", regexp.MustCompile(`(?m)^#include\s*[<"]`)},
+	{"javascript", regexp.MustCompile(`(?m)^(const|let) \w+ = .*;$|^function \w+\(.*\)\s*\{|^import .* from ['"].*['"];?$`)},
+}
+
+// InferCodeLanguage guesses the language of a code block, returning "" when unsure
+func InferCodeLanguage(code string) string {
+	trimmed := strings.TrimSpace(code)
+	if trimmed == "" {
+		return ""
+	}
+
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	for _, hint := range codeLangHints {
+		if hint.pattern.MatchString(code) {
+			return hint.lang
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestQuotesTransform(t *testing.T) {
+	body := "He said \"it's fine\" and 'left'.\n`code \"stays\"` and [[Note \"x\"]]\n```\nprint(\"raw\")\n```"
+
+	curly, n := QuotesTransform{Curly: true}.Apply(body)
+	assert.Equal(t, "He said “it’s fine” and ‘left’.\n`code \"stays\"` and [[Note \"x\"]]\n```\nprint(\"raw\")\n```", curly)
+	assert.Equal(t, 5, n)
+
+	straight, n := QuotesTransform{}.Apply(curly)
+	assert.Equal(t, body, straight)
+	assert.Equal(t, 5, n)
+}
+
+func TestEmphasisTransform(t *testing.T) {
+	body := "Some _italic_ _words_ and __bold__ with snake_case_name and `_code_`"
+
+	asterisk, n := EmphasisTransform{Marker: "*"}.Apply(body)
+	assert.Equal(t, "Some *italic* *words* and __bold__ with snake_case_name and `_code_`", asterisk)
+	assert.Equal(t, 2, n)
+
+	strong, n := EmphasisTransform{Strong: true, Marker: "*"}.Apply(asterisk)
+	assert.Equal(t, "Some *italic* *words* and **bold** with snake_case_name and `_code_`", strong)
+	assert.Equal(t, 1, n)
+
+	back, _ := EmphasisTransform{Marker: "_"}.Apply("* list item with *italic* and **bold**")
+	assert.Equal(t, "* list item with _italic_ and **bold**", back)
+}
+
+func TestBulletTransform(t *testing.T) {
+	body := "* one\n  + nested\n- three\n* * *\n---\n```\n* code\n```"
+
+	got, n := BulletTransform{Marker: "-"}.Apply(body)
+	assert.Equal(t, "- one\n  - nested\n- three\n* * *\n---\n```\n* code\n```", got)
+	assert.Equal(t, 2, n)
+}
+
+func TestCodeLangTransform(t *testing.T) {
+	body := "```\npackage main\n\nfunc main() {}\n```\n\n```\n{\"a\": 1}\n```\n\n```\nsomething unknown\n```\n\n```python\nx = 1\n```"
+
+	got, n := CodeLangTransform{}.Apply(body)
+	assert.Equal(t, "```go\npackage main\n\nfunc main() {}\n```\n\n```json\n{\"a\": 1}\n```\n\n```\nsomething unknown\n```\n\n```python\nx = 1\n```", got)
+	assert.Equal(t, 2, n)
+}
+
+func TestInferCodeLanguage(t *testing.T) {
+	assert.Equal(t, "bash", InferCodeLanguage("#!/bin/bash\necho hi"))
+	assert.Equal(t, "bash", InferCodeLanguage("$ go test ./..."))
+	assert.Equal(t, "python", InferCodeLanguage("def main():\n    pass"))
+	assert.Equal(t, "sql", InferCodeLanguage("SELECT id FROM notes"))
+	assert.Equal(t, "", InferCodeLanguage("just some text"))
+}
+
+func TestStyleProcessor(t *testing.T) {
+	_, err := NewStyleProcessor(StyleRules{})
+	assert.Error(t, err)
+
+	_, err = NewStyleProcessor(StyleRules{Bullets: "x"})
+	assert.Error(t, err)
+
+	sp, err := NewStyleProcessor(StyleRules{Quotes: "curly", Bullets: "-"})
+	require.NoError(t, err)
+
+	file := &vault.VaultFile{Body: "* \"quoted\""}
+	changes := sp.Process(file)
+	assert.Equal(t, "- “quoted”", file.Body)
+	assert.Equal(t, map[string]int{"quotes": 2, "bullets": 1}, changes)
+}
+
+func TestListIndentTransform(t *testing.T) {
+	body := "- one\n\t- tab child\n\t\t- tab grandchild\n- two\n    - four space child\n      continuation\n\nText\n  - new list, indented"
+
+	got, n := ListIndentTransform{Indent: 2}.Apply(body)
+	assert.Equal(t, "- one\n  - tab child\n    - tab grandchild\n- two\n  - four space child\n      continuation\n\nText\n- new list, indented", got)
+	assert.Equal(t, 4, n)
+}
+
+func TestCheckboxTransform(t *testing.T) {
+	body := "- [ ] ok\n-[ ] no space\n- [] empty\n- [X]done\n  * [ x ] nested\n- [/] in progress\n- [a](link.md)\n- [[Wiki]]\n1. [x] numbered"
+
+	got, n := CheckboxTransform{}.Apply(body)
+	assert.Equal(t, "- [ ] ok\n- [ ] no space\n- [ ] empty\n- [x] done\n  * [x] nested\n- [/] in progress\n- [a](link.md)\n- [[Wiki]]\n1. [x] numbered", got)
+	assert.Equal(t, 4, n)
+}
+
+func TestStyleProcessor_CheckboxesBeforeListIndent(t *testing.T) {
+	sp, err := NewStyleProcessor(StyleRules{ListIndent: 2, Checkboxes: true})
+	require.NoError(t, err)
+
+	file := &vault.VaultFile{Body: "- a\n\t-[X]b"}
+	sp.Process(file)
+	assert.Equal(t, "- a\n  - [x] b", file.Body)
+}
@@ -0,0 +1,530 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// inlineTagRegex matches an inline #tag, including nested tags such as
+// #project/active, preceded by the start of the text, whitespace, "(" or ","
+var inlineTagRegex = regexp.MustCompile(`(^|[\s(,])#([\p{L}\p{N}_\-/]+)`)
+
+// TagProcessor finds, renames and merges tags in frontmatter tag lists and
+// inline #tags in note bodies
+type TagProcessor struct{}
+
+// NewTagProcessor creates a new tag processor
+func NewTagProcessor() *TagProcessor {
+	return &TagProcessor{}
+}
+
+// TagChange counts the tags rewritten in a file
+type TagChange struct {
+	Frontmatter int // Entries renamed in the frontmatter tags field
+	Inline      int // Inline #tags renamed in the body
+}
+
+// Total returns the number of tags rewritten
+func (c TagChange) Total() int {
+	return c.Frontmatter + c.Inline
+}
+
+// NormalizeTag strips surrounding whitespace, a leading "#" and trailing "/"
+// from a tag as written on the command line or in frontmatter
+func NormalizeTag(tag string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(tag), "#"), "/")
+}
+
+// ValidateTag checks that tag can be written as an inline #tag
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+	if !isTag(tag) {
+		return fmt.Errorf("invalid tag '%s': tags may only contain letters, numbers, '_', '-' and '/', and at least one non-digit", tag)
+	}
+	if strings.Contains(tag, "//") {
+		return fmt.Errorf("invalid tag '%s': empty nested tag segment", tag)
+	}
+	return nil
+}
+
+// isTag reports whether s is a valid tag name; purely numeric names such as
+// issue numbers are not tags
+func isTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	hasNonDigit := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r), r == '_', r == '-', r == '/':
+			hasNonDigit = true
+		case unicode.IsDigit(r):
+		default:
+			return false
+		}
+	}
+	return hasNonDigit
+}
+
+// Tags returns the distinct tags of a file, from frontmatter and inline
+// #tags, in the order they first appear. Tags are compared case-insensitively
+// as Obsidian does; the first spelling seen is kept.
+func (p *TagProcessor) Tags(file *vault.VaultFile) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			return
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+
+	for _, tag := range frontmatterTags(file.Frontmatter["tags"]) {
+		add(tag)
+	}
+	for _, tag := range p.InlineTags(file) {
+		add(tag)
+	}
+	return tags
+}
+
+// InlineTags returns the distinct inline #tags of a file's body outside code,
+// links and URLs, in the order they first appear
+func (p *TagProcessor) InlineTags(file *vault.VaultFile) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	mapProseLines(file.Body, func(line string) (string, int) {
+		return mapProseSegments(line, func(segment string) (string, int) {
+			for _, m := range inlineTagRegex.FindAllStringSubmatch(segment, -1) {
+				if isTag(m[2]) && !seen[strings.ToLower(m[2])] {
+					seen[strings.ToLower(m[2])] = true
+					tags = append(tags, m[2])
+				}
+			}
+			return segment, 0
+		})
+	})
+	return tags
+}
+
+// frontmatterTags returns the entries of a frontmatter tags value, which may
+// be a list or a comma separated string
+func frontmatterTags(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		var tags []string
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				tags = append(tags, str)
+			}
+		}
+		return tags
+	case []string:
+		return v
+	case string:
+		var tags []string
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	default:
+		return nil
+	}
+}
+
+// renameTag returns the new name for tag under renames, which map old tag
+// names to new ones. Nested tags follow their parent, so renaming "project"
+// to "work" turns "project/active" into "work/active". Matching is
+// case-insensitive and the longest matching old name wins.
+func renameTag(tag string, renames map[string]string) (string, bool) {
+	lower := strings.ToLower(tag)
+	best := ""
+	for old := range renames {
+		oldLower := strings.ToLower(old)
+		if (lower == oldLower || strings.HasPrefix(lower, oldLower+"/")) && len(old) > len(best) {
+			best = old
+		}
+	}
+	if best == "" {
+		return tag, false
+	}
+	renamed := renames[best] + tag[len(best):]
+	return renamed, renamed != tag
+}
+
+// Uses reports whether the file has a tag that renames would rename
+func (p *TagProcessor) Uses(file *vault.VaultFile, renames map[string]string) bool {
+	for _, tag := range p.Tags(file) {
+		if _, ok := renameTag(tag, renames); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename rewrites the tags of a file according to renames, which map old tag
+// names to new ones, in the frontmatter tags field and inline #tags outside
+// code. Frontmatter entries that end up duplicated, as when two tags are
+// merged into one, are collapsed.
+func (p *TagProcessor) Rename(file *vault.VaultFile, renames map[string]string) TagChange {
+	var change TagChange
+
+	if value, exists := file.Frontmatter["tags"]; exists {
+		if renamed, n := renameFrontmatterTags(value, renames); n > 0 {
+			file.SetField("tags", renamed)
+			change.Frontmatter = n
+		}
+	}
+
+	file.Body, change.Inline = mapProseLines(file.Body, func(line string) (string, int) {
+		return mapProseSegments(line, func(segment string) (string, int) {
+			count := 0
+			segment = inlineTagRegex.ReplaceAllStringFunc(segment, func(match string) string {
+				m := inlineTagRegex.FindStringSubmatch(match)
+				if !isTag(m[2]) {
+					return match
+				}
+				renamed, ok := renameTag(m[2], renames)
+				if !ok {
+					return match
+				}
+				count++
+				return m[1] + "#" + renamed
+			})
+			return segment, count
+		})
+	})
+
+	return change
+}
+
+// renameFrontmatterTags renames the entries of a frontmatter tags value,
+// keeping its shape, and returns the new value with the number of entries
+// renamed
+func renameFrontmatterTags(value interface{}, renames map[string]string) (interface{}, int) {
+	tags := frontmatterTags(value)
+	count := 0
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		// Keep a "#" prefix where the vault writes tags that way
+		prefix := ""
+		if strings.HasPrefix(strings.TrimSpace(tag), "#") {
+			prefix = "#"
+		}
+		name := NormalizeTag(tag)
+		if renamed, ok := renameTag(name, renames); ok {
+			name = renamed
+			tag = prefix + renamed
+			count++
+		}
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		result = append(result, tag)
+	}
+	if count == 0 {
+		return value, 0
+	}
+
+	if _, ok := value.(string); ok {
+		return strings.Join(result, ", "), count
+	}
+	list := make([]interface{}, len(result))
+	for i, tag := range result {
+		list[i] = tag
+	}
+	return list, count
+}
+
+// TagSync counts the tags copied and removed by a sync
+type TagSync struct {
+	Added   int      // Tags copied to the destination
+	Removed int      // Tags removed from the source
+	Invalid []string // Frontmatter tags that can't be written as inline #tags
+}
+
+// Changed reports whether the sync modified the file
+func (s TagSync) Changed() bool {
+	return s.Added > 0 || s.Removed > 0
+}
+
+// Add adds tag to the file's frontmatter tags, reporting false if the note
+// already has it, in frontmatter or inline
+func (p *TagProcessor) Add(file *vault.VaultFile, tag string) bool {
+	for _, existing := range p.Tags(file) {
+		if strings.EqualFold(existing, tag) {
+			return false
+		}
+	}
+	var values []interface{}
+	switch current := file.Frontmatter["tags"].(type) {
+	case []interface{}:
+		values = append(values, current...)
+	case string:
+		for _, t := range strings.Split(current, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				values = append(values, t)
+			}
+		}
+	}
+	file.SetField("tags", append(values, tag))
+	return true
+}
+
+// SyncToFrontmatter adds the file's inline #tags missing from its frontmatter
+// tags field, keeping the field a list or comma separated string as it is.
+// With remove, the inline tags are then removed from the body, along with
+// lines left holding nothing else.
+func (p *TagProcessor) SyncToFrontmatter(file *vault.VaultFile, remove bool) TagSync {
+	var sync TagSync
+
+	existing := make(map[string]bool)
+	current := frontmatterTags(file.Frontmatter["tags"])
+	for _, tag := range current {
+		existing[strings.ToLower(NormalizeTag(tag))] = true
+	}
+	var missing []string
+	for _, tag := range p.InlineTags(file) {
+		if !existing[strings.ToLower(tag)] {
+			missing = append(missing, tag)
+		}
+	}
+
+	if len(missing) > 0 {
+		sync.Added = len(missing)
+		if _, ok := file.Frontmatter["tags"].(string); ok {
+			file.SetField("tags", strings.Join(append(current, missing...), ", "))
+		} else {
+			list := make([]interface{}, 0, len(current)+len(missing))
+			for _, tag := range append(current, missing...) {
+				list = append(list, tag)
+			}
+			file.SetField("tags", list)
+		}
+	}
+
+	if remove {
+		file.Body, sync.Removed = removeInlineTags(file.Body)
+	}
+	return sync
+}
+
+// SyncToInline appends the file's frontmatter tags missing from its body as a
+// line of #tags at the end of the body. With remove, the frontmatter tags
+// field is then removed, keeping only tags that can't be written inline.
+func (p *TagProcessor) SyncToInline(file *vault.VaultFile, remove bool) TagSync {
+	var sync TagSync
+
+	existing := make(map[string]bool)
+	for _, tag := range p.InlineTags(file) {
+		existing[strings.ToLower(tag)] = true
+	}
+	var missing []string
+	var valid, invalid []string
+	for _, entry := range frontmatterTags(file.Frontmatter["tags"]) {
+		tag := NormalizeTag(entry)
+		if ValidateTag(tag) != nil {
+			invalid = append(invalid, entry)
+			continue
+		}
+		valid = append(valid, entry)
+		if !existing[strings.ToLower(tag)] {
+			existing[strings.ToLower(tag)] = true
+			missing = append(missing, "#"+tag)
+		}
+	}
+	sync.Invalid = invalid
+
+	if len(missing) > 0 {
+		sync.Added = len(missing)
+		body := strings.TrimRight(file.Body, "\n")
+		if body != "" {
+			body += "\n\n"
+		}
+		file.Body = body + strings.Join(missing, " ") + "\n"
+	}
+
+	if remove && len(valid) > 0 {
+		sync.Removed = len(valid)
+		switch {
+		case len(invalid) == 0:
+			delete(file.Frontmatter, "tags")
+		case isStringValue(file.Frontmatter["tags"]):
+			file.SetField("tags", strings.Join(invalid, ", "))
+		default:
+			list := make([]interface{}, len(invalid))
+			for i, tag := range invalid {
+				list[i] = tag
+			}
+			file.SetField("tags", list)
+		}
+	}
+	return sync
+}
+
+func isStringValue(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+// removedLine marks a line emptied by removing its tags, to be dropped
+const removedLine = "\x00"
+
+// removeInlineTags removes inline #tags from body outside code, links and
+// URLs, with the space that separated each from its neighbours, and drops
+// lines that held nothing but tags. It returns the new body and the number
+// of tags removed.
+func removeInlineTags(body string) (string, int) {
+	body, removed := mapProseLines(body, func(line string) (string, int) {
+		result, n := mapProseSegments(line, func(segment string) (string, int) {
+			var b strings.Builder
+			count, last := 0, 0
+			for _, m := range inlineTagRegex.FindAllStringSubmatchIndex(segment, -1) {
+				if !isTag(segment[m[4]:m[5]]) {
+					continue
+				}
+				start, end := m[3], m[5]
+				if end < len(segment) && segment[end] == ' ' {
+					end++ // Take the following space
+				} else if m[3] > m[2] && m[2] >= last && segment[m[2]] == ' ' {
+					start = m[2] // At the end of the text, take the preceding space
+				}
+				b.WriteString(segment[last:start])
+				last = end
+				count++
+			}
+			b.WriteString(segment[last:])
+			return b.String(), count
+		})
+		if n == 0 {
+			return line, 0
+		}
+		if strings.TrimSpace(result) == "" {
+			return removedLine, n
+		}
+		if !strings.HasSuffix(line, " ") {
+			result = strings.TrimRight(result, " ")
+		}
+		return result, n
+	})
+	if removed == 0 {
+		return body, 0
+	}
+
+	lines := strings.Split(body, "\n")
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if line == removedLine {
+			continue
+		}
+		// Don't leave two blank lines where a paragraph of tags was removed
+		if strings.TrimSpace(line) == "" && i > 0 && lines[i-1] == removedLine &&
+			len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n"), removed
+}
+
+// Save writes modified files as a single change: every file is serialized
+// before anything is written, and if a write fails the files already written
+// are restored to their original content
+func (p *TagProcessor) Save(files []*vault.VaultFile, tx *safety.Transaction) error {
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := file.Serialize()
+		if err != nil {
+			return fmt.Errorf("serializing %s: %w", file.RelativePath, err)
+		}
+		contents[i] = content
+	}
+
+	for i, file := range files {
+		err := tx.RecordWrite(file.Path)
+		if err == nil {
+			err = safety.WriteFile(file.Path, contents[i], 0644)
+		}
+		if err != nil {
+			for _, written := range files[:i] {
+				_ = safety.WriteFile(written.Path, written.Content, 0644)
+			}
+			return fmt.Errorf("writing %s (no files were changed): %w", file.RelativePath, err)
+		}
+	}
+	return nil
+}
+
+// TagNode is a tag in the nested tag hierarchy
+type TagNode struct {
+	Name     string     // Last segment of the tag, e.g. "active"
+	Tag      string     // Full tag, e.g. "project/active"
+	Files    int        // Files tagged with exactly this tag
+	Total    int        // Files tagged with this tag or any tag nested under it
+	Children []*TagNode // Nested tags, sorted by name
+}
+
+// BuildTagTree arranges tags into their nested hierarchy. fileTags holds
+// each file's distinct tags; the returned root nodes and their children are
+// sorted by name.
+func BuildTagTree(fileTags [][]string) []*TagNode {
+	root := &TagNode{}
+	nodes := make(map[string]*TagNode)
+	node := func(tag string) *TagNode {
+		key := strings.ToLower(tag)
+		if n, ok := nodes[key]; ok {
+			return n
+		}
+		parent := root
+		name := tag
+		if idx := strings.LastIndex(tag, "/"); idx != -1 {
+			parent = nodes[strings.ToLower(tag[:idx])]
+			name = tag[idx+1:]
+		}
+		n := &TagNode{Name: name, Tag: tag}
+		parent.Children = append(parent.Children, n)
+		nodes[key] = n
+		return n
+	}
+
+	for _, tags := range fileTags {
+		counted := make(map[*TagNode]bool)
+		for _, tag := range tags {
+			segments := strings.Split(tag, "/")
+			for i := range segments {
+				n := node(strings.Join(segments[:i+1], "/"))
+				if !counted[n] {
+					counted[n] = true
+					n.Total++
+				}
+			}
+			nodes[strings.ToLower(tag)].Files++
+		}
+	}
+
+	var sortNodes func(nodes []*TagNode)
+	sortNodes = func(nodes []*TagNode) {
+		sort.Slice(nodes, func(i, j int) bool {
+			return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+		})
+		for _, n := range nodes {
+			sortNodes(n.Children)
+		}
+	}
+	sortNodes(root.Children)
+	return root.Children
+}
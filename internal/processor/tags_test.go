@@ -0,0 +1,185 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func parseTagFile(t *testing.T, content string) *vault.VaultFile {
+	t.Helper()
+	file := &vault.VaultFile{RelativePath: "note.md"}
+	require.NoError(t, file.Parse([]byte(content)))
+	return file
+}
+
+func TestTagProcessor_Tags(t *testing.T) {
+	file := parseTagFile(t, "---\ntags: [ml, '#Project/active']\n---\n"+
+		"#inbox at the start, (#paren) and #ML again\n"+
+		"`#code` [[#heading]] [x](note.md#ml) http://x.com/#anchor issue #123\n"+
+		"```\n#fenced\n```\n# Heading\n")
+
+	assert.Equal(t, []string{"ml", "Project/active", "inbox", "paren"}, NewTagProcessor().Tags(file))
+}
+
+func TestTagProcessor_Rename(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		renames  map[string]string
+		tags     interface{}
+		body     string
+		expected TagChange
+	}{
+		{
+			name:     "frontmatter list and inline",
+			content:  "---\ntags: [todo, misc]\n---\nA #todo item and #Todo again\n",
+			renames:  map[string]string{"todo": "task"},
+			tags:     []interface{}{"task", "misc"},
+			body:     "A #task item and #task again\n",
+			expected: TagChange{Frontmatter: 1, Inline: 2},
+		},
+		{
+			name:     "nested tags follow their parent",
+			content:  "---\ntags: [project/active]\n---\n#project and #project/done but not #projects\n",
+			renames:  map[string]string{"project": "work"},
+			tags:     []interface{}{"work/active"},
+			body:     "#work and #work/done but not #projects\n",
+			expected: TagChange{Frontmatter: 1, Inline: 2},
+		},
+		{
+			name:     "merge collapses duplicates",
+			content:  "---\ntags: [ml, ai, machine-learning]\n---\n#ml #machine-learning\n",
+			renames:  map[string]string{"ml": "ai", "machine-learning": "ai"},
+			tags:     []interface{}{"ai"},
+			body:     "#ai #ai\n",
+			expected: TagChange{Frontmatter: 2, Inline: 2},
+		},
+		{
+			name:     "comma separated string keeps its shape",
+			content:  "---\ntags: \"#draft, misc\"\n---\nBody\n",
+			renames:  map[string]string{"draft": "wip"},
+			tags:     "#wip, misc",
+			body:     "Body\n",
+			expected: TagChange{Frontmatter: 1},
+		},
+		{
+			name:     "code and links are left alone",
+			content:  "---\ntags: [other]\n---\n`#todo` [[#todo]] http://x.com/#todo\n```\n#todo\n```\n",
+			renames:  map[string]string{"todo": "task"},
+			tags:     []interface{}{"other"},
+			body:     "`#todo` [[#todo]] http://x.com/#todo\n```\n#todo\n```\n",
+			expected: TagChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := parseTagFile(t, tt.content)
+			change := NewTagProcessor().Rename(file, tt.renames)
+			assert.Equal(t, tt.expected, change)
+			assert.Equal(t, tt.tags, file.Frontmatter["tags"])
+			assert.Equal(t, tt.body, file.Body)
+		})
+	}
+}
+
+func TestTagProcessor_SyncToFrontmatter(t *testing.T) {
+	file := parseTagFile(t, "---\ntags: [ml]\n---\nSome #project/foo text #ML here #end\n\n#a #b\n\n`#code`\n")
+
+	sync := NewTagProcessor().SyncToFrontmatter(file, false)
+	assert.Equal(t, 4, sync.Added)
+	assert.Equal(t, []interface{}{"ml", "project/foo", "end", "a", "b"}, file.Frontmatter["tags"])
+	assert.Contains(t, file.Body, "#project/foo")
+
+	sync = NewTagProcessor().SyncToFrontmatter(file, true)
+	assert.Zero(t, sync.Added)
+	assert.Equal(t, 5, sync.Removed)
+	assert.Equal(t, "Some text here\n\n`#code`\n", file.Body)
+
+	// A comma separated tags string stays one, and files without frontmatter get it
+	file = parseTagFile(t, "---\ntags: misc\n---\n#new\n")
+	NewTagProcessor().SyncToFrontmatter(file, false)
+	assert.Equal(t, "misc, new", file.Frontmatter["tags"])
+
+	file = parseTagFile(t, "Just #one\n")
+	NewTagProcessor().SyncToFrontmatter(file, false)
+	assert.Equal(t, []interface{}{"one"}, file.Frontmatter["tags"])
+}
+
+func TestTagProcessor_SyncToInline(t *testing.T) {
+	file := parseTagFile(t, "---\ntags: [ml, '#project/foo', two words]\n---\nAbout #ml\n")
+
+	sync := NewTagProcessor().SyncToInline(file, false)
+	assert.Equal(t, 1, sync.Added)
+	assert.Equal(t, []string{"two words"}, sync.Invalid)
+	assert.Equal(t, "About #ml\n\n#project/foo\n", file.Body)
+
+	sync = NewTagProcessor().SyncToInline(file, true)
+	assert.Zero(t, sync.Added)
+	assert.Equal(t, 2, sync.Removed)
+	assert.Equal(t, []interface{}{"two words"}, file.Frontmatter["tags"])
+
+	file = parseTagFile(t, "---\ntitle: T\ntags: [a]\n---\n")
+	NewTagProcessor().SyncToInline(file, true)
+	assert.NotContains(t, file.Frontmatter, "tags")
+	assert.Equal(t, "#a\n", file.Body)
+}
+
+func TestTagProcessor_SaveRestoresOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	var files []*vault.VaultFile
+	for _, name := range []string{"a.md", "b.md"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("#old\n"), 0644))
+		file, err := vault.LoadVaultFile(path)
+		require.NoError(t, err)
+		file.RelativePath = name
+		NewTagProcessor().Rename(file, map[string]string{"old": "new"})
+		files = append(files, file)
+	}
+	// A directory in place of the second file makes its write fail
+	require.NoError(t, os.Remove(files[1].Path))
+	require.NoError(t, os.Mkdir(files[1].Path, 0755))
+
+	err := NewTagProcessor().Save(files, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "b.md")
+
+	content, err := os.ReadFile(files[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "#old\n", string(content))
+}
+
+func TestBuildTagTree(t *testing.T) {
+	roots := BuildTagTree([][]string{
+		{"project/active", "misc"},
+		{"project", "project/done"},
+		{"Project/active"},
+	})
+
+	require.Len(t, roots, 2)
+	assert.Equal(t, "misc", roots[0].Name)
+	project := roots[1]
+	assert.Equal(t, "project", project.Tag)
+	assert.Equal(t, 1, project.Files)
+	assert.Equal(t, 3, project.Total)
+	require.Len(t, project.Children, 2)
+	assert.Equal(t, "project/active", project.Children[0].Tag)
+	assert.Equal(t, 2, project.Children[0].Files)
+	assert.Equal(t, "done", project.Children[1].Name)
+}
+
+func TestValidateTag(t *testing.T) {
+	assert.NoError(t, ValidateTag("lang/go-1"))
+	assert.NoError(t, ValidateTag("café"))
+	assert.Error(t, ValidateTag(""))
+	assert.Error(t, ValidateTag("2024"))
+	assert.Error(t, ValidateTag("two words"))
+	assert.Error(t, ValidateTag("a//b"))
+}
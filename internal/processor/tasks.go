@@ -0,0 +1,382 @@
+package processor
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Task statuses, named as the Obsidian Tasks plugin names them. Other status
+// characters are reported as they are.
+const (
+	TaskTodo       = "todo"
+	TaskDone       = "done"
+	TaskInProgress = "in_progress"
+	TaskCancelled  = "cancelled"
+)
+
+// TaskPriorities are the Obsidian Tasks priorities from highest to lowest;
+// tasks without a priority marker are "normal"
+var TaskPriorities = []string{"highest", "high", "medium", "normal", "low", "lowest"}
+
+var (
+	// taskRegex matches a checkbox list item: indent, status and text
+	taskRegex = regexp.MustCompile(`^([ \t]*)(?:[-*+]|\d+[.)])[ \t]+\[(.)\][ \t]+(.*)$`)
+	// taskHeadingRegex matches the headings tasks are grouped under
+	taskHeadingRegex = regexp.MustCompile(`^#{1,6}[ \t]+(.+?)[ \t#]*$`)
+
+	// taskDateSignifiers maps Obsidian Tasks date emoji to task fields
+	taskDateSignifiers = []struct {
+		field string
+		regex *regexp.Regexp
+	}{
+		{"due", taskDateRegex(`📅|📆|🗓`)},
+		{"scheduled", taskDateRegex(`⏳|⌛`)},
+		{"start", taskDateRegex(`🛫`)},
+		{"created", taskDateRegex(`➕`)},
+		{"completed", taskDateRegex(`✅`)},
+		{"cancelled", taskDateRegex(`❌`)},
+	}
+	taskPriorityRegex   = regexp.MustCompile(`[ \t]*(🔺|⏫|🔼|🔽|⏬)\x{FE0F}?`)
+	taskRecurrenceRegex = regexp.MustCompile(`[ \t]*🔁\x{FE0F}?[ \t]*([^📅📆🗓⏳⌛🛫➕✅❌🔺⏫🔼🔽⏬🆔⛔^#]*)`)
+	taskDependencyRegex = regexp.MustCompile(`[ \t]*(?:🆔|⛔)\x{FE0F}?[ \t]*[\w,-]+`)
+	taskSpaceRegex      = regexp.MustCompile(`[ \t]+`)
+)
+
+func taskDateRegex(emoji string) *regexp.Regexp {
+	return regexp.MustCompile(`[ \t]*(?:` + emoji + `)\x{FE0F}?[ \t]*(\d{4}-\d{2}-\d{2})`)
+}
+
+var taskPriorityEmoji = map[string]string{"🔺": "highest", "⏫": "high", "🔼": "medium", "🔽": "low", "⏬": "lowest"}
+
+// Task is a checkbox list item in a note
+type Task struct {
+	File       *vault.VaultFile `json:"-"`
+	Path       string           `json:"path"`
+	Line       int              `json:"line"` // Line number in the file
+	Section    string           `json:"section,omitempty"`
+	Status     string           `json:"status"`
+	Symbol     string           `json:"symbol"` // Character between the brackets
+	Text       string           `json:"text"`   // Description without Tasks metadata
+	Priority   string           `json:"priority"`
+	Due        string           `json:"due,omitempty"` // Dates are YYYY-MM-DD
+	Scheduled  string           `json:"scheduled,omitempty"`
+	Start      string           `json:"start,omitempty"`
+	Created    string           `json:"created,omitempty"`
+	Completed  string           `json:"completed,omitempty"`
+	Cancelled  string           `json:"cancelled,omitempty"`
+	Recurrence string           `json:"recurrence,omitempty"`
+	Tags       []string         `json:"tags,omitempty"`
+}
+
+// Open reports whether the task is still to do or in progress
+func (t Task) Open() bool {
+	return t.Status != TaskDone && t.Status != TaskCancelled
+}
+
+// ParseTasks returns the tasks in a note, skipping code blocks
+func ParseTasks(file *vault.VaultFile) []Task {
+	// Line numbers count from the top of the file, frontmatter included
+	offset := 0
+	if content := string(file.Content); file.Body != "" && strings.HasSuffix(content, file.Body) {
+		offset = strings.Count(content[:len(content)-len(file.Body)], "\n")
+	}
+
+	var tasks []Task
+	section, fence := "", ""
+	for i, line := range strings.Split(file.Body, "\n") {
+		if m := fenceRegex.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && m[2] == "" {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		if m := taskHeadingRegex.FindStringSubmatch(line); m != nil {
+			section = m[1]
+			continue
+		}
+
+		m := taskRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		task := parseTaskText(m[3])
+		task.File = file
+		task.Path = filepath.ToSlash(file.RelativePath)
+		task.Line = offset + i + 1
+		task.Section = section
+		task.Symbol = m[2]
+		task.Status = taskStatus(m[2])
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// parseTaskText splits a task's text into its description and Obsidian Tasks
+// metadata
+func parseTaskText(text string) Task {
+	task := Task{Priority: "normal"}
+
+	for _, signifier := range taskDateSignifiers {
+		if m := signifier.regex.FindStringSubmatch(text); m != nil {
+			switch signifier.field {
+			case "due":
+				task.Due = m[1]
+			case "scheduled":
+				task.Scheduled = m[1]
+			case "start":
+				task.Start = m[1]
+			case "created":
+				task.Created = m[1]
+			case "completed":
+				task.Completed = m[1]
+			case "cancelled":
+				task.Cancelled = m[1]
+			}
+			text = signifier.regex.ReplaceAllString(text, "")
+		}
+	}
+	if m := taskPriorityRegex.FindStringSubmatch(text); m != nil {
+		task.Priority = taskPriorityEmoji[m[1]]
+		text = taskPriorityRegex.ReplaceAllString(text, "")
+	}
+	if m := taskRecurrenceRegex.FindStringSubmatch(text); m != nil {
+		task.Recurrence = strings.TrimSpace(m[1])
+		text = taskRecurrenceRegex.ReplaceAllString(text, "")
+	}
+
+	text = taskDependencyRegex.ReplaceAllString(text, "")
+
+	task.Text = strings.TrimSpace(taskSpaceRegex.ReplaceAllString(text, " "))
+	seen := make(map[string]bool)
+	for _, m := range inlineTagRegex.FindAllStringSubmatch(task.Text, -1) {
+		if isTag(m[2]) && !seen[strings.ToLower(m[2])] {
+			seen[strings.ToLower(m[2])] = true
+			task.Tags = append(task.Tags, m[2])
+		}
+	}
+	return task
+}
+
+func taskStatus(symbol string) string {
+	switch symbol {
+	case " ":
+		return TaskTodo
+	case "x", "X":
+		return TaskDone
+	case "/":
+		return TaskInProgress
+	case "-":
+		return TaskCancelled
+	}
+	return symbol
+}
+
+// Fields returns the task's fields as the query language sees them:
+//
+//	text, status, done, priority, due, scheduled, start, created, completed,
+//	cancelled, recurrence, tags, section, line, and note.<field> for the
+//	frontmatter of the note the task is in
+//
+// Dates are YYYY-MM-DD strings and are only present when set.
+func (t Task) Fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"text":     t.Text,
+		"status":   t.Status,
+		"done":     t.Status == TaskDone,
+		"priority": t.Priority,
+		"line":     t.Line,
+	}
+	for name, value := range map[string]string{
+		"due": t.Due, "scheduled": t.Scheduled, "start": t.Start, "created": t.Created,
+		"completed": t.Completed, "cancelled": t.Cancelled, "recurrence": t.Recurrence, "section": t.Section,
+	} {
+		if value != "" {
+			fields[name] = value
+		}
+	}
+	if len(t.Tags) > 0 {
+		tags := make([]interface{}, len(t.Tags))
+		for i, tag := range t.Tags {
+			tags[i] = tag
+		}
+		fields["tags"] = tags
+	}
+	if t.File != nil && t.File.Frontmatter != nil {
+		fields["note"] = t.File.Frontmatter
+	}
+	return fields
+}
+
+// AsFile returns a stand-in note whose frontmatter holds the task's Fields,
+// so query expressions can be evaluated against the task. The file.* pseudo
+// fields describe the note the task is in.
+func (t Task) AsFile() *vault.VaultFile {
+	file := &vault.VaultFile{Frontmatter: t.Fields(), RelativePath: t.Path}
+	if t.File != nil {
+		file.Path = t.File.Path
+		file.RelativePath = t.File.RelativePath
+		file.Modified = t.File.Modified
+	}
+	return file
+}
+
+// PriorityRank orders priorities from 0 for highest to 5 for lowest
+func PriorityRank(priority string) int {
+	for i, p := range TaskPriorities {
+		if p == priority {
+			return i
+		}
+	}
+	return 3 // normal
+}
+
+// SortTasks orders tasks by "due" (earliest first, undated last), "priority"
+// (highest first) or "path"; ties are broken by due date, priority, path and
+// line
+func SortTasks(tasks []Task, by string) {
+	byDue := func(a, b Task) int {
+		switch {
+		case a.Due == b.Due:
+			return 0
+		case a.Due == "":
+			return 1
+		case b.Due == "":
+			return -1
+		case a.Due < b.Due:
+			return -1
+		}
+		return 1
+	}
+	byPriority := func(a, b Task) int { return PriorityRank(a.Priority) - PriorityRank(b.Priority) }
+	byPath := func(a, b Task) int {
+		if a.Path != b.Path {
+			return strings.Compare(a.Path, b.Path)
+		}
+		return a.Line - b.Line
+	}
+
+	order := []func(a, b Task) int{byDue, byPriority, byPath}
+	switch by {
+	case "priority":
+		order = []func(a, b Task) int{byPriority, byDue, byPath}
+	case "path":
+		order = []func(a, b Task) int{byPath}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		for _, compare := range order {
+			if c := compare(tasks[i], tasks[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// TaskCount is a number of tasks for a note, tag or priority
+type TaskCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TaskReport summarizes tasks as of a day
+type TaskReport struct {
+	Date       string         `json:"date"`
+	Total      int            `json:"total"`
+	Open       int            `json:"open"` // To do or in progress
+	InProgress int            `json:"in_progress"`
+	Done       int            `json:"done"`
+	Cancelled  int            `json:"cancelled"`
+	Overdue    []Task         `json:"overdue"`  // Open tasks due before the day, earliest first
+	DueSoon    []Task         `json:"due_soon"` // Open tasks due on the day or within the week after it
+	Undated    int            `json:"undated"`  // Open tasks without a due date
+	Priorities []TaskCount    `json:"priorities"`
+	Notes      []TaskCount    `json:"notes"` // Open tasks per note, most first
+	Tags       []TaskCount    `json:"tags"`  // Open tasks per tag, most first
+	Completed  map[string]int `json:"completed"`
+}
+
+// NewTaskReport summarizes tasks as of today. Completed counts the tasks
+// completed on each of the last seven days.
+func NewTaskReport(tasks []Task, today time.Time) TaskReport {
+	day := today.Format("2006-01-02")
+	weekAhead := today.AddDate(0, 0, 7).Format("2006-01-02")
+	weekAgo := today.AddDate(0, 0, -6).Format("2006-01-02")
+
+	report := TaskReport{
+		Date:      day,
+		Total:     len(tasks),
+		Overdue:   []Task{},
+		DueSoon:   []Task{},
+		Completed: make(map[string]int),
+	}
+	priorities := make(map[string]int)
+	notes := make(map[string]int)
+	tags := make(map[string]int)
+
+	for _, task := range tasks {
+		switch task.Status {
+		case TaskDone:
+			report.Done++
+			if task.Completed >= weekAgo && task.Completed <= day {
+				report.Completed[task.Completed]++
+			}
+			continue
+		case TaskCancelled:
+			report.Cancelled++
+			continue
+		case TaskInProgress:
+			report.InProgress++
+		}
+
+		report.Open++
+		priorities[task.Priority]++
+		notes[task.Path]++
+		for _, tag := range task.Tags {
+			tags[strings.ToLower(tag)]++
+		}
+		switch {
+		case task.Due == "":
+			report.Undated++
+		case task.Due < day:
+			report.Overdue = append(report.Overdue, task)
+		case task.Due <= weekAhead:
+			report.DueSoon = append(report.DueSoon, task)
+		}
+	}
+
+	SortTasks(report.Overdue, "due")
+	SortTasks(report.DueSoon, "due")
+	for _, priority := range TaskPriorities {
+		if priorities[priority] > 0 {
+			report.Priorities = append(report.Priorities, TaskCount{Name: priority, Count: priorities[priority]})
+		}
+	}
+	report.Notes = rankTaskCounts(notes)
+	report.Tags = rankTaskCounts(tags)
+	return report
+}
+
+// rankTaskCounts sorts counts from most to fewest, then by name
+func rankTaskCounts(counts map[string]int) []TaskCount {
+	ranked := make([]TaskCount, 0, len(counts))
+	for name, count := range counts {
+		ranked = append(ranked, TaskCount{Name: name, Count: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
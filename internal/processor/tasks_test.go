@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func parseTestTasks(t *testing.T, content string) []Task {
+	file := &vault.VaultFile{RelativePath: "projects/launch.md"}
+	require.NoError(t, file.Parse([]byte(content)))
+	return ParseTasks(file)
+}
+
+func TestParseTasks(t *testing.T) {
+	tasks := parseTestTasks(t, `---
+status: active
+---
+# Launch
+
+## Prep
+- [ ] Write press release 📅 2024-01-10 ⏫ #work
+  - [/] Book venue 🛫 2024-01-01 ⏳️ 2024-01-04 📅 2024-01-05 🔼
+1. [x] Pick date ➕ 2023-12-20 ✅ 2024-01-02
+- [-] Old idea ❌ 2024-01-03 🆔 abc123
+- [ ] Water plants 🔁 every week on Sunday 📅 2024-01-07 ⏬
+- [?] Custom status
+- [link](https://example.com)
+- [ ]
+
+`+"```"+`
+- [ ] Not a task
+`+"```"+`
+`)
+	require.Len(t, tasks, 6)
+
+	first := tasks[0]
+	assert.Equal(t, "projects/launch.md", first.Path)
+	assert.Equal(t, 7, first.Line, "line numbers count the frontmatter")
+	assert.Equal(t, "Prep", first.Section)
+	assert.Equal(t, TaskTodo, first.Status)
+	assert.Equal(t, "Write press release #work", first.Text)
+	assert.Equal(t, "2024-01-10", first.Due)
+	assert.Equal(t, "high", first.Priority)
+	assert.Equal(t, []string{"work"}, first.Tags)
+
+	venue := tasks[1]
+	assert.Equal(t, TaskInProgress, venue.Status)
+	assert.Equal(t, "Book venue", venue.Text)
+	assert.Equal(t, "2024-01-01", venue.Start)
+	assert.Equal(t, "2024-01-04", venue.Scheduled)
+	assert.Equal(t, "2024-01-05", venue.Due)
+	assert.Equal(t, "medium", venue.Priority)
+
+	assert.Equal(t, TaskDone, tasks[2].Status)
+	assert.Equal(t, "2023-12-20", tasks[2].Created)
+	assert.Equal(t, "2024-01-02", tasks[2].Completed)
+	assert.Equal(t, TaskCancelled, tasks[3].Status)
+	assert.Equal(t, "Old idea", tasks[3].Text)
+	assert.Equal(t, "2024-01-03", tasks[3].Cancelled)
+	assert.Equal(t, "every week on Sunday", tasks[4].Recurrence)
+	assert.Equal(t, "Water plants", tasks[4].Text)
+	assert.Equal(t, "lowest", tasks[4].Priority)
+	assert.Equal(t, "?", tasks[5].Status)
+	assert.Equal(t, "normal", tasks[5].Priority)
+}
+
+func TestTask_Query(t *testing.T) {
+	tasks := parseTestTasks(t, `---
+status: active
+---
+- [ ] Due early 📅 2024-01-10 ⏫ #work
+- [x] Done ✅ 2024-01-02
+- [ ] Undated #home
+`)
+
+	match := func(where string) []string {
+		expr, err := query.NewParser(where).Parse()
+		require.NoError(t, err)
+		var texts []string
+		for _, task := range tasks {
+			if expr.Evaluate(task.AsFile()) {
+				texts = append(texts, task.Text)
+			}
+		}
+		return texts
+	}
+
+	assert.Equal(t, []string{"Due early #work"}, match("due before '2025-01-01'"))
+	assert.Equal(t, []string{"Due early #work"}, match("due = '2024-01-10'"))
+	assert.Equal(t, []string{"Done"}, match("done = true"))
+	assert.Equal(t, []string{"Undated #home"}, match("tags contains 'home'"))
+	assert.Equal(t, []string{"Due early #work"}, match("priority = 'high' AND note.status = 'active'"))
+	assert.Len(t, match("file.path = 'projects/launch.md'"), 3)
+}
+
+func TestSortTasks(t *testing.T) {
+	tasks := []Task{
+		{Text: "undated high", Priority: "high", Path: "a.md", Line: 1},
+		{Text: "late", Due: "2024-02-01", Priority: "normal", Path: "a.md", Line: 2},
+		{Text: "early low", Due: "2024-01-01", Priority: "low", Path: "b.md", Line: 1},
+		{Text: "early highest", Due: "2024-01-01", Priority: "highest", Path: "c.md", Line: 1},
+	}
+
+	SortTasks(tasks, "due")
+	assert.Equal(t, []string{"early highest", "early low", "late", "undated high"}, taskTexts(tasks))
+
+	SortTasks(tasks, "priority")
+	assert.Equal(t, []string{"early highest", "undated high", "late", "early low"}, taskTexts(tasks))
+
+	SortTasks(tasks, "path")
+	assert.Equal(t, []string{"undated high", "late", "early low", "early highest"}, taskTexts(tasks))
+}
+
+func taskTexts(tasks []Task) []string {
+	texts := make([]string, len(tasks))
+	for i, task := range tasks {
+		texts[i] = task.Text
+	}
+	return texts
+}
+
+func TestNewTaskReport(t *testing.T) {
+	tasks := []Task{
+		{Text: "overdue", Status: TaskTodo, Due: "2024-03-01", Priority: "high", Path: "a.md", Tags: []string{"Work"}},
+		{Text: "today", Status: TaskInProgress, Due: "2024-03-10", Priority: "normal", Path: "a.md"},
+		{Text: "next week", Status: TaskTodo, Due: "2024-03-17", Priority: "normal", Path: "b.md", Tags: []string{"work"}},
+		{Text: "later", Status: TaskTodo, Due: "2024-04-01", Priority: "normal", Path: "b.md"},
+		{Text: "undated", Status: TaskTodo, Priority: "low", Path: "b.md"},
+		{Text: "done", Status: TaskDone, Completed: "2024-03-09", Path: "a.md"},
+		{Text: "done long ago", Status: TaskDone, Completed: "2024-01-01", Path: "a.md"},
+		{Text: "cancelled", Status: TaskCancelled, Path: "a.md"},
+	}
+
+	report := NewTaskReport(tasks, time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2024-03-10", report.Date)
+	assert.Equal(t, 8, report.Total)
+	assert.Equal(t, 5, report.Open)
+	assert.Equal(t, 1, report.InProgress)
+	assert.Equal(t, 2, report.Done)
+	assert.Equal(t, 1, report.Cancelled)
+	assert.Equal(t, 1, report.Undated)
+	assert.Equal(t, []string{"overdue"}, taskTexts(report.Overdue))
+	assert.Equal(t, []string{"today", "next week"}, taskTexts(report.DueSoon))
+	assert.Equal(t, []TaskCount{{"high", 1}, {"normal", 3}, {"low", 1}}, report.Priorities)
+	assert.Equal(t, []TaskCount{{"b.md", 3}, {"a.md", 2}}, report.Notes)
+	assert.Equal(t, []TaskCount{{"work", 2}}, report.Tags)
+	assert.Equal(t, map[string]int{"2024-03-09": 1}, report.Completed)
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultTemplateFolders are the folders Templater and core Templates setups
+// commonly keep templates in
+var DefaultTemplateFolders = []string{"templates", "_templates"}
+
+var (
+	templaterSyntax   = regexp.MustCompile(`<%[\s\S]*?%>`)
+	placeholderSyntax = regexp.MustCompile(`\{\{[^{}\n]+\}\}`)
+	fencedCode        = regexp.MustCompile("(?ms)^(```|~~~).*?^(```|~~~)[ \t]*$")
+	inlineCode        = regexp.MustCompile("`[^`\n]+`")
+)
+
+// generatedMarkers in the first lines of a body mark a script-generated file
+var generatedMarkers = []string{"DO NOT EDIT", "<!-- generated", "%% generated"}
+
+// TemplateDetector recognises note templates and generated files, which
+// commands that add frontmatter would corrupt
+type TemplateDetector struct {
+	Folders      []string // Template folder names or paths, matched case-insensitively
+	Patterns     []string // Glob patterns matching generated files
+	IgnoreSyntax bool     // Only detect by folder, pattern and generated marker
+}
+
+// NewTemplateDetector creates a detector for the default template folders and
+// any extra folders given
+func NewTemplateDetector(folders ...string) *TemplateDetector {
+	d := &TemplateDetector{Folders: append([]string{}, DefaultTemplateFolders...)}
+	for _, folder := range folders {
+		if folder != "" {
+			d.Folders = append(d.Folders, folder)
+		}
+	}
+	return d
+}
+
+// Reason describes why file looks like a template or generated file, or
+// returns "" if it doesn't
+func (d *TemplateDetector) Reason(file *vault.VaultFile) string {
+	path := filepath.ToSlash(file.RelativePath)
+
+	lowerPath := "/" + strings.ToLower(path)
+	for _, folder := range d.Folders {
+		folder = strings.Trim(strings.ToLower(filepath.ToSlash(folder)), "/")
+		if folder != "" && strings.Contains(lowerPath, "/"+folder+"/") {
+			return "in template folder " + folder
+		}
+	}
+
+	for _, pattern := range d.Patterns {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return "matches generated file pattern " + pattern
+		}
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return "matches generated file pattern " + pattern
+		}
+	}
+
+	head := file.Body
+	if lines := strings.SplitN(head, "\n", 6); len(lines) > 5 {
+		head = strings.Join(lines[:5], "\n")
+	}
+	for _, marker := range generatedMarkers {
+		if strings.Contains(head, marker) {
+			return "generated file marker '" + marker + "'"
+		}
+	}
+
+	if d.IgnoreSyntax {
+		return ""
+	}
+	for _, value := range file.Frontmatter {
+		if s, ok := value.(string); ok && templaterSyntax.MatchString(s) {
+			return "Templater syntax <% %> in frontmatter"
+		}
+	}
+	body := inlineCode.ReplaceAllString(fencedCode.ReplaceAllString(file.Body, ""), "")
+	if templaterSyntax.MatchString(body) {
+		return "Templater syntax <% %>"
+	}
+	if placeholderSyntax.MatchString(body) {
+		return "template placeholders {{ }}"
+	}
+	return ""
+}
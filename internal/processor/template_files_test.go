@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestTemplateDetector_Reason(t *testing.T) {
+	detector := NewTemplateDetector("meta/tpl")
+	detector.Patterns = []string{"*.generated.md", "dashboards/*"}
+
+	tests := []struct {
+		name        string
+		path        string
+		frontmatter map[string]interface{}
+		body        string
+		want        string
+	}{
+		{"plain note", "notes/idea.md", nil, "# Idea\n\nSome text.", ""},
+		{"default folder", "templates/daily.md", nil, "# Daily", "in template folder templates"},
+		{"nested default folder", "vault/_Templates/daily.md", nil, "# Daily", "in template folder _templates"},
+		{"configured folder", "meta/tpl/daily.md", nil, "# Daily", "in template folder meta/tpl"},
+		{"folder name as file name", "notes/templates.md", nil, "# Templates", ""},
+		{"pattern on name", "notes/index.generated.md", nil, "# Index", "matches generated file pattern *.generated.md"},
+		{"pattern on path", "dashboards/tasks.md", nil, "# Tasks", "matches generated file pattern dashboards/*"},
+		{"generated marker", "index.md", nil, "<!-- generated by build.sh -->\n# Index", "generated file marker '<!-- generated'"},
+		{"do not edit marker", "index.md", nil, "# Index\nDO NOT EDIT: regenerate with make", "generated file marker 'DO NOT EDIT'"},
+		{"marker far down", "index.md", nil, "# Index\n\n\n\n\n\nDO NOT EDIT this sentence", ""},
+		{"templater body", "daily.md", nil, "# <% tp.file.title %>", "Templater syntax <% %>"},
+		{"templater frontmatter", "daily.md", map[string]interface{}{"created": "<% tp.date.now() %>"}, "# Daily", "Templater syntax <% %> in frontmatter"},
+		{"placeholders", "daily.md", nil, "Date: {{date}}", "template placeholders {{ }}"},
+		{"placeholders in fenced code", "jinja.md", nil, "```jinja\n{{ name }}\n```\n", ""},
+		{"placeholders in inline code", "jinja.md", nil, "Write `{{ name }}` for a variable.", ""},
+		{"placeholders in frontmatter only", "note.md", map[string]interface{}{"slug": "{{title|slug}}"}, "# Note", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &vault.VaultFile{RelativePath: tt.path, Frontmatter: tt.frontmatter, Body: tt.body}
+			assert.Equal(t, tt.want, detector.Reason(file))
+		})
+	}
+
+	detector.IgnoreSyntax = true
+	assert.Empty(t, detector.Reason(&vault.VaultFile{RelativePath: "daily.md", Body: "# <% tp.file.title %>"}))
+}
@@ -0,0 +1,261 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Quoting styles for TidyRules.Quotes
+const (
+	TidyQuotesMinimal = "minimal" // Quote strings only where YAML requires it
+	TidyQuotesDouble  = "double"
+	TidyQuotesSingle  = "single"
+)
+
+// Date styles for TidyRules.Dates
+const (
+	TidyDatesISO   = "iso"   // 2006-01-02 and 2006-01-02T15:04:05Z07:00
+	TidyDatesSpace = "space" // 2006-01-02 and 2006-01-02 15:04:05, as mdnotes writes them
+)
+
+// Array styles for TidyRules.Arrays
+const (
+	TidyArraysBlock = "block"
+	TidyArraysFlow  = "flow"
+)
+
+// TidyRules defines how YAML frontmatter is reformatted. Empty values leave
+// that aspect of the formatting alone.
+type TidyRules struct {
+	KeyOrder []string // Keys moved to the top, in this order
+	SortKeys bool     // Sort the remaining keys alphabetically instead of keeping their order
+	Quotes   string   // minimal, double or single
+	Dates    string   // iso or space
+	Arrays   string   // block or flow; flow applies only to lists of scalars
+	Indent   int      // Spaces per nesting level
+}
+
+// Validate checks the rules are usable
+func (r TidyRules) Validate() error {
+	switch r.Quotes {
+	case "", TidyQuotesMinimal, TidyQuotesDouble, TidyQuotesSingle:
+	default:
+		return fmt.Errorf("unsupported quote style %q (supported: minimal, double, single)", r.Quotes)
+	}
+	switch r.Dates {
+	case "", TidyDatesISO, TidyDatesSpace:
+	default:
+		return fmt.Errorf("unsupported date style %q (supported: iso, space)", r.Dates)
+	}
+	switch r.Arrays {
+	case "", TidyArraysBlock, TidyArraysFlow:
+	default:
+		return fmt.Errorf("unsupported array style %q (supported: block, flow)", r.Arrays)
+	}
+	if r.Indent < 2 || r.Indent > 9 {
+		return fmt.Errorf("unsupported indent %d (supported: 2-9)", r.Indent)
+	}
+	return nil
+}
+
+// TidyFrontmatter rewrites the YAML frontmatter of raw file content according
+// to the rules. Only formatting changes: keys, values and comments are kept,
+// and the body is left byte-for-byte as it was. Content without YAML
+// frontmatter is returned unchanged. It reports whether anything changed.
+func TidyFrontmatter(content []byte, rules TidyRules) ([]byte, bool, error) {
+	newline := "\n"
+	if bytes.HasPrefix(content, []byte("---\r\n")) {
+		newline = "\r\n"
+	} else if !bytes.HasPrefix(content, []byte("---\n")) {
+		return content, false, nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	endIndex := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			endIndex = i
+			break
+		}
+	}
+	if endIndex == -1 {
+		return content, false, nil
+	}
+
+	original := strings.Join(lines[1:endIndex], "")
+	yamlText := strings.ReplaceAll(original, "\r\n", "\n")
+	if strings.TrimSpace(yamlText) == "" {
+		return content, false, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlText), &doc); err != nil {
+		return nil, false, fmt.Errorf("parsing frontmatter: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return content, false, nil
+	}
+
+	root := doc.Content[0]
+	reorderKeys(root, rules.KeyOrder, rules.SortKeys)
+	tidyNode(root, rules)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(rules.Indent)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, false, fmt.Errorf("formatting frontmatter: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, false, fmt.Errorf("formatting frontmatter: %w", err)
+	}
+
+	tidied := strings.ReplaceAll(buf.String(), "\n", newline)
+	if tidied == original {
+		return content, false, nil
+	}
+
+	var out strings.Builder
+	out.WriteString(lines[0])
+	out.WriteString(tidied)
+	for _, line := range lines[endIndex:] {
+		out.WriteString(line)
+	}
+	return []byte(out.String()), true, nil
+}
+
+// reorderKeys moves the keys named in order to the front of a mapping node,
+// keeping or sorting the rest
+func reorderKeys(mapping *yaml.Node, order []string, sortRest bool) {
+	type pair struct{ key, value *yaml.Node }
+
+	pairs := make([]pair, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		pairs = append(pairs, pair{mapping.Content[i], mapping.Content[i+1]})
+	}
+
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		if _, exists := rank[key]; !exists {
+			rank[key] = i
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ri, iRanked := rank[pairs[i].key.Value]
+		rj, jRanked := rank[pairs[j].key.Value]
+		switch {
+		case iRanked && jRanked:
+			return ri < rj
+		case iRanked || jRanked:
+			return iRanked
+		case sortRest:
+			return pairs[i].key.Value < pairs[j].key.Value
+		default:
+			return false
+		}
+	})
+
+	mapping.Content = mapping.Content[:0]
+	for _, p := range pairs {
+		mapping.Content = append(mapping.Content, p.key, p.value)
+	}
+}
+
+// tidyNode applies the quoting, date and array rules to a node and its children
+func tidyNode(node *yaml.Node, rules TidyRules) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			tidyNode(node.Content[i], rules)
+		}
+	case yaml.SequenceNode:
+		switch rules.Arrays {
+		case TidyArraysBlock:
+			node.Style &^= yaml.FlowStyle
+		case TidyArraysFlow:
+			if scalarsOnly(node) {
+				node.Style |= yaml.FlowStyle
+			}
+		}
+		for _, child := range node.Content {
+			tidyNode(child, rules)
+		}
+	case yaml.ScalarNode:
+		tidyScalar(node, rules)
+	}
+}
+
+// scalarsOnly reports whether a sequence holds only scalars, which read well inline
+func scalarsOnly(node *yaml.Node) bool {
+	for _, child := range node.Content {
+		if child.Kind != yaml.ScalarNode || strings.Contains(child.Value, "\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// tidyScalar restyles a scalar value. Multi-line literal and folded strings
+// and explicitly tagged values keep their style.
+func tidyScalar(node *yaml.Node, rules TidyRules) {
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.TaggedStyle) != 0 {
+		return
+	}
+
+	switch node.ShortTag() {
+	case "!!str":
+		if strings.Contains(node.Value, "\n") {
+			return
+		}
+		switch rules.Quotes {
+		case TidyQuotesMinimal:
+			// The encoder adds quotes back where a plain value would change type
+			node.Style = 0
+		case TidyQuotesDouble:
+			node.Style = yaml.DoubleQuotedStyle
+		case TidyQuotesSingle:
+			node.Style = yaml.SingleQuotedStyle
+		}
+	case "!!timestamp":
+		if rules.Dates != "" && node.Style == 0 {
+			if formatted, ok := formatTimestamp(node.Value, rules.Dates); ok {
+				node.Value = formatted
+			}
+		}
+	}
+}
+
+// timestampLayouts are the timestamp forms YAML resolves, as accepted by yaml.v3
+var timestampLayouts = []string{
+	"2006-1-2T15:4:5.999999999Z07:00",
+	"2006-1-2t15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+// formatTimestamp rewrites a timestamp in the given style without changing the
+// instant it refers to
+func formatTimestamp(value, style string) (string, bool) {
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+
+		if layout == "2006-1-2" {
+			return t.Format("2006-01-02"), true
+		}
+		// The space form has no zone, so YAML reads it as UTC
+		if style == TidyDatesSpace && t.Location() == time.UTC {
+			return t.Format("2006-01-02 15:04:05.999999999"), true
+		}
+		return t.Format(time.RFC3339Nano), true
+	}
+	return value, false
+}
@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTidyFrontmatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rules TidyRules
+		want  string
+	}{
+		{
+			name:  "key order with remaining keys kept in place",
+			input: "---\nzeta: 1\ntags: [a]\ntitle: Note\nalpha: 2\n---\nBody\n",
+			rules: TidyRules{KeyOrder: []string{"title", "tags"}, Indent: 4},
+			want:  "---\ntitle: Note\ntags: [a]\nzeta: 1\nalpha: 2\n---\nBody\n",
+		},
+		{
+			name:  "remaining keys sorted",
+			input: "---\nzeta: 1\ntitle: Note\nalpha: 2\n---\nBody\n",
+			rules: TidyRules{KeyOrder: []string{"title"}, SortKeys: true, Indent: 4},
+			want:  "---\ntitle: Note\nalpha: 2\nzeta: 1\n---\nBody\n",
+		},
+		{
+			name:  "minimal quotes keep quotes that preserve the type",
+			input: "---\ntitle: \"Plain\"\nid: '123'\nnote: 'a: b'\n---\n",
+			rules: TidyRules{Quotes: TidyQuotesMinimal, Indent: 4},
+			want:  "---\ntitle: Plain\nid: \"123\"\nnote: 'a: b'\n---\n",
+		},
+		{
+			name:  "double quotes only strings",
+			input: "---\ntitle: Plain\ncount: 3\ndone: true\n---\n",
+			rules: TidyRules{Quotes: TidyQuotesDouble, Indent: 4},
+			want:  "---\ntitle: \"Plain\"\ncount: 3\ndone: true\n---\n",
+		},
+		{
+			name:  "dates padded and datetimes in space style",
+			input: "---\ncreated: 2024-1-5\nupdated: 2024-01-05T09:30:00Z\nzoned: 2024-01-05T09:30:00+02:00\n---\n",
+			rules: TidyRules{Dates: TidyDatesSpace, Indent: 4},
+			want:  "---\ncreated: 2024-01-05\nupdated: 2024-01-05 09:30:00\nzoned: 2024-01-05T09:30:00+02:00\n---\n",
+		},
+		{
+			name:  "iso datetimes",
+			input: "---\nupdated: 2024-01-05 09:30:00\n---\n",
+			rules: TidyRules{Dates: TidyDatesISO, Indent: 4},
+			want:  "---\nupdated: 2024-01-05T09:30:00Z\n---\n",
+		},
+		{
+			name:  "flow arrays to block",
+			input: "---\ntags: [a, b]\n---\n",
+			rules: TidyRules{Arrays: TidyArraysBlock, Indent: 2},
+			want:  "---\ntags:\n  - a\n  - b\n---\n",
+		},
+		{
+			name:  "block arrays to flow skip nested maps",
+			input: "---\ntags:\n  - a\n  - b\nitems:\n  - name: x\n---\n",
+			rules: TidyRules{Arrays: TidyArraysFlow, Indent: 2},
+			want:  "---\ntags: [a, b]\nitems:\n  - name: x\n---\n",
+		},
+		{
+			name:  "comments and crlf kept",
+			input: "---\r\n# heading\r\ntitle: Note # inline\r\n---\r\nBody\r\n",
+			rules: TidyRules{Indent: 4},
+			want:  "---\r\n# heading\r\ntitle: Note # inline\r\n---\r\nBody\r\n",
+		},
+		{
+			name:  "no frontmatter",
+			input: "Body\n",
+			rules: TidyRules{Quotes: TidyQuotesDouble, Indent: 4},
+			want:  "Body\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := TidyFrontmatter([]byte(tt.input), tt.rules)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.want != tt.input, changed)
+		})
+	}
+}
+
+func TestTidyFrontmatter_InvalidYAML(t *testing.T) {
+	_, _, err := TidyFrontmatter([]byte("---\ntitle: [unclosed\n---\n"), TidyRules{Indent: 4})
+	assert.Error(t, err)
+}
+
+func TestTidyRules_Validate(t *testing.T) {
+	assert.NoError(t, TidyRules{Quotes: TidyQuotesSingle, Dates: TidyDatesISO, Arrays: TidyArraysFlow, Indent: 2}.Validate())
+	assert.Error(t, TidyRules{Quotes: "smart", Indent: 4}.Validate())
+	assert.Error(t, TidyRules{Dates: "unix", Indent: 4}.Validate())
+	assert.Error(t, TidyRules{Arrays: "inline", Indent: 4}.Validate())
+	assert.Error(t, TidyRules{Indent: 0}.Validate())
+}
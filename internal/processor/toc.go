@@ -0,0 +1,201 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Markers around a generated table of contents
+const (
+	TOCStartMarker = "<!-- mdnotes:toc start -->"
+	TOCEndMarker   = "<!-- mdnotes:toc end -->"
+)
+
+var (
+	// atxHeadingRegex matches a heading line, allowing closing #s
+	atxHeadingRegex = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	// headingAnchorUnsafe matches what Obsidian leaves out of links to headings
+	headingAnchorUnsafe = regexp.MustCompile(`[#|^:\[\]]+|%%`)
+	// markdownLinkText matches a markdown link, to show only its text
+	markdownLinkText = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	// wikiLinkText matches a wiki link, to show only its alias or target
+	wikiLinkText = regexp.MustCompile(`\[\[(?:[^\]|]*\|)?([^\]]*)\]\]`)
+)
+
+// TOCOptions configures a table of contents
+type TOCOptions struct {
+	MinLevel   int    // Shallowest heading level listed (default 2)
+	MaxLevel   int    // Deepest heading level listed (default 3)
+	LinkFormat string // wiki (default) or markdown
+}
+
+// TOCGenerator builds and maintains a note's table of contents
+type TOCGenerator struct {
+	opts TOCOptions
+}
+
+// NewTOCGenerator validates opts and fills in defaults
+func NewTOCGenerator(opts TOCOptions) (*TOCGenerator, error) {
+	if opts.MinLevel == 0 {
+		opts.MinLevel = 2
+	}
+	if opts.MaxLevel == 0 {
+		opts.MaxLevel = 3
+	}
+	if opts.LinkFormat == "" {
+		opts.LinkFormat = "wiki"
+	}
+	if opts.MinLevel < 1 || opts.MaxLevel > 6 || opts.MinLevel > opts.MaxLevel {
+		return nil, fmt.Errorf("invalid heading levels %d to %d (must be between 1 and 6)", opts.MinLevel, opts.MaxLevel)
+	}
+	if opts.LinkFormat != "wiki" && opts.LinkFormat != "markdown" {
+		return nil, fmt.Errorf("invalid link format '%s' (supported: wiki, markdown)", opts.LinkFormat)
+	}
+	return &TOCGenerator{opts: opts}, nil
+}
+
+// Headings returns the headings of body listed in its table of contents:
+// those within the levels, outside code blocks and the table itself
+func (g *TOCGenerator) Headings(body string) []Heading {
+	var headings []Heading
+	fence := ""
+	inTOC := false
+	for i, line := range strings.Split(body, "\n") {
+		if m := fenceRegex.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && m[2] == "" {
+				fence = ""
+			}
+			continue
+		}
+		switch strings.TrimSpace(line) {
+		case TOCStartMarker:
+			inTOC = true
+			continue
+		case TOCEndMarker:
+			inTOC = false
+			continue
+		}
+		if fence != "" || inTOC {
+			continue
+		}
+
+		m := atxHeadingRegex.FindStringSubmatch(line)
+		if m == nil || strings.TrimSpace(m[2]) == "" {
+			continue
+		}
+		level := len(m[1])
+		if level < g.opts.MinLevel || level > g.opts.MaxLevel {
+			continue
+		}
+		headings = append(headings, Heading{Level: level, Text: strings.TrimSpace(m[2]), Line: i + 1})
+	}
+	return headings
+}
+
+// Render returns the table of contents for body, markers included, or ""
+// when it has no headings to list
+func (g *TOCGenerator) Render(body string) string {
+	headings := g.Headings(body)
+	if len(headings) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(TOCStartMarker + "\n")
+	// Nesting follows the heading tree, so a skipped level doesn't indent twice
+	var parents []int
+	for _, heading := range headings {
+		for len(parents) > 0 && parents[len(parents)-1] >= heading.Level {
+			parents = parents[:len(parents)-1]
+		}
+		b.WriteString(strings.Repeat("  ", len(parents)) + "- " + g.link(heading.Text) + "\n")
+		parents = append(parents, heading.Level)
+	}
+	b.WriteString(TOCEndMarker + "\n")
+	return b.String()
+}
+
+// link returns a link to the heading with text in the same note
+func (g *TOCGenerator) link(text string) string {
+	// Links in headings show as their text
+	display := wikiLinkText.ReplaceAllString(markdownLinkText.ReplaceAllString(text, "$1"), "$1")
+
+	anchor := headingLinkAnchor(text)
+	if g.opts.LinkFormat == "markdown" {
+		return "[" + strings.NewReplacer("[", "\\[", "]", "\\]").Replace(display) + "](#" + pathEscaper.Replace(anchor) + ")"
+	}
+	if anchor == display {
+		return "[[#" + anchor + "]]"
+	}
+	return "[[#" + anchor + "|" + strings.ReplaceAll(display, "|", "-") + "]]"
+}
+
+// headingLinkAnchor returns how a link to the heading with text names it,
+// without the characters Obsidian leaves out
+func headingLinkAnchor(text string) string {
+	return strings.Join(strings.Fields(headingAnchorUnsafe.ReplaceAllString(text, " ")), " ")
+}
+
+// Update adds or refreshes the table of contents in body. An existing table
+// is replaced where it is; a new one goes after the first H1 or, without
+// one, at the top. A table with no headings left to list is removed.
+func (g *TOCGenerator) Update(body string) string {
+	block := g.Render(body)
+	if block == "" {
+		return RemoveTOC(body)
+	}
+
+	if start, end, ok := findTOC(body); ok {
+		return body[:start] + block + body[end:]
+	}
+
+	lines := strings.SplitAfter(body, "\n")
+	fence := ""
+	offset := 0
+	for _, line := range lines {
+		offset += len(line)
+		if m := fenceRegex.FindStringSubmatch(strings.TrimRight(line, "\n")); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && m[2] == "" {
+				fence = ""
+			}
+			continue
+		}
+		if m := atxHeadingRegex.FindStringSubmatch(strings.TrimRight(line, "\n")); fence == "" && m != nil && len(m[1]) == 1 {
+			before := body[:offset]
+			if !strings.HasSuffix(before, "\n") {
+				before += "\n"
+			}
+			return before + "\n" + block + "\n" + strings.TrimLeft(body[offset:], "\n")
+		}
+	}
+
+	rest := strings.TrimLeft(body, "\n")
+	if rest == "" {
+		return block
+	}
+	return block + "\n" + rest
+}
+
+// RemoveTOC removes the table of contents from body, with the blank line
+// after it
+func RemoveTOC(body string) string {
+	start, end, ok := findTOC(body)
+	if !ok {
+		return body
+	}
+	if strings.HasPrefix(body[end:], "\n") {
+		end++
+	}
+	return body[:start] + body[end:]
+}
+
+// findTOC returns where the table of contents in body starts and ends,
+// including the newline after its end marker
+func findTOC(body string) (start, end int, ok bool) {
+	return findMarkedBlock(body, TOCStartMarker, TOCEndMarker)
+}
@@ -0,0 +1,62 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOCGenerator_Update(t *testing.T) {
+	body := "# Project\n\nIntro.\n\n## Goals\n\n### Q1: Launch\n\n#### Detail\n\n## Notes on [[Other|others]]\n\n```bash\n# not a heading\n```\n\n## Done ##\n"
+
+	generator, err := NewTOCGenerator(TOCOptions{})
+	require.NoError(t, err)
+
+	updated := generator.Update(body)
+	assert.Equal(t, "# Project\n\n"+
+		TOCStartMarker+"\n"+
+		"- [[#Goals]]\n"+
+		"  - [[#Q1 Launch|Q1: Launch]]\n"+
+		"- [[#Notes on Other others|Notes on others]]\n"+
+		"- [[#Done]]\n"+
+		TOCEndMarker+"\n\n"+
+		"Intro.\n\n## Goals\n\n### Q1: Launch\n\n#### Detail\n\n## Notes on [[Other|others]]\n\n```bash\n# not a heading\n```\n\n## Done ##\n", updated)
+
+	// Running again changes nothing
+	assert.Equal(t, updated, generator.Update(updated))
+
+	// The table follows the headings, staying where it is
+	changed := updated + "\n## Added\n"
+	assert.Contains(t, generator.Update(changed), "- [[#Done]]\n- [[#Added]]\n"+TOCEndMarker)
+
+	// Removing it restores the note
+	assert.Equal(t, body, RemoveTOC(updated))
+}
+
+func TestTOCGenerator_Placement(t *testing.T) {
+	generator, err := NewTOCGenerator(TOCOptions{MinLevel: 1, MaxLevel: 4, LinkFormat: "markdown"})
+	require.NoError(t, err)
+
+	// Without an H1 the table goes at the top
+	assert.Equal(t, TOCStartMarker+"\n"+
+		"- [First Part](#First%20Part)\n"+
+		"  - [Deep](#Deep)\n"+
+		"  - [Second](#Second)\n"+
+		TOCEndMarker+"\n\n"+
+		"## First Part\n#### Deep\n### Second\n",
+		generator.Update("\n## First Part\n#### Deep\n### Second\n"))
+
+	// A note with no headings gets no table, and loses an old one
+	assert.Equal(t, "Just text\n", generator.Update("Just text\n"))
+	assert.Equal(t, "Just text\n", generator.Update(TOCStartMarker+"\n- [[#Gone]]\n"+TOCEndMarker+"\nJust text\n"))
+}
+
+func TestNewTOCGenerator_Invalid(t *testing.T) {
+	_, err := NewTOCGenerator(TOCOptions{MinLevel: 4, MaxLevel: 2})
+	assert.ErrorContains(t, err, "invalid heading levels")
+	_, err = NewTOCGenerator(TOCOptions{MaxLevel: 7})
+	assert.ErrorContains(t, err, "invalid heading levels")
+	_, err = NewTOCGenerator(TOCOptions{LinkFormat: "html"})
+	assert.ErrorContains(t, err, "invalid link format")
+}
@@ -15,7 +15,7 @@ type ValidationRules struct {
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field    string // Field name with error
-	Type     string // Error type (missing_required, invalid_type)
+	Type     string // Error type (missing_required, invalid_type, invalid_enum, pattern_mismatch)
 	Expected string // Expected value/type
 	File     string // File path
 }
@@ -99,6 +99,10 @@ func (e ValidationError) Error() string {
 		return "field '" + e.Field + "' is required"
 	case "invalid_type":
 		return "field '" + e.Field + "' must be of type " + e.Expected
+	case "invalid_enum":
+		return "field '" + e.Field + "' must be one of: " + e.Expected
+	case "pattern_mismatch":
+		return "field '" + e.Field + "' must match " + e.Expected
 	default:
 		return "validation error in field '" + e.Field + "'"
 	}
@@ -1,10 +1,13 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -13,8 +16,15 @@ import (
 	"github.com/fsnotify/fsnotify"
 
 	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/events"
+	"github.com/eoinhurrell/mdnotes/internal/metrics"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
 )
 
+// DefaultWatchActionTimeout bounds how long a single watch action may run
+const DefaultWatchActionTimeout = time.Minute
+
 // WatchProcessor monitors file system changes and executes configured actions
 type WatchProcessor struct {
 	config        *config.Config
@@ -24,6 +34,27 @@ type WatchProcessor struct {
 	debounceMutex sync.Mutex
 	ctx           context.Context
 	cancel        context.CancelFunc
+
+	// Change event publishing; frontmatter holds the last seen frontmatter of
+	// each markdown file so field changes can be reported
+	events           *events.Publisher
+	frontmatter      map[string]map[string]interface{}
+	frontmatterMutex sync.Mutex
+
+	metrics *metrics.Registry
+
+	// Compiled rule queries and timeouts, by rule index
+	queries  []query.Expression
+	timeouts []time.Duration
+
+	// mdnotes actions run this program with these leading arguments
+	mdnotes     string
+	mdnotesArgs []string
+
+	// written holds the content hash of files as actions left them, so the
+	// events actions cause don't trigger the rules again
+	written      map[string][32]byte
+	writtenMutex sync.Mutex
 }
 
 // NewWatchProcessor creates a new watch processor
@@ -42,11 +73,62 @@ func NewWatchProcessor(cfg *config.Config) (*WatchProcessor, error) {
 		debounceMap: make(map[string]*time.Timer),
 		ctx:         ctx,
 		cancel:      cancel,
+		events:      events.NewPublisher(cfg.Events),
+		frontmatter: make(map[string]map[string]interface{}),
+		written:     make(map[string][32]byte),
+	}
+
+	for _, rule := range cfg.Watch.Rules {
+		var expr query.Expression
+		if rule.Query != "" {
+			if expr, err = query.NewParser(rule.Query).Compile(); err != nil {
+				_ = watcher.Close()
+				cancel()
+				return nil, fmt.Errorf("watch rule '%s': parsing query: %w", rule.Name, err)
+			}
+		}
+		timeout := DefaultWatchActionTimeout
+		if rule.Timeout != "" {
+			if timeout, err = time.ParseDuration(rule.Timeout); err != nil {
+				_ = watcher.Close()
+				cancel()
+				return nil, fmt.Errorf("watch rule '%s': invalid timeout: %w", rule.Name, err)
+			}
+		}
+		wp.queries = append(wp.queries, expr)
+		wp.timeouts = append(wp.timeouts, timeout)
 	}
 
 	return wp, nil
 }
 
+// SetMdnotesCommand sets the program, and any leading arguments such as
+// --config, that "mdnotes ..." actions run. By default the mdnotes on PATH is
+// used.
+func (wp *WatchProcessor) SetMdnotesCommand(program string, args ...string) {
+	wp.mdnotes = program
+	wp.mdnotesArgs = args
+}
+
+// Watch processor metric names
+const (
+	MetricWatchEvents    = "mdnotes_watch_events_total"
+	MetricFilesProcessed = "mdnotes_files_processed_total"
+	MetricErrors         = "mdnotes_errors_total"
+	MetricRunDuration    = "mdnotes_last_run_duration_seconds"
+	MetricRunTimestamp   = "mdnotes_last_run_timestamp_seconds"
+)
+
+// SetMetrics records processing metrics in registry
+func (wp *WatchProcessor) SetMetrics(registry *metrics.Registry) {
+	registry.Register(MetricWatchEvents, metrics.Counter, "File system events handled, by event type")
+	registry.Register(MetricFilesProcessed, metrics.Counter, "Files that matched a watch rule and had its actions run")
+	registry.Register(MetricErrors, metrics.Counter, "Errors encountered, by stage")
+	registry.Register(MetricRunDuration, metrics.Gauge, "Duration of the most recent run, by task")
+	registry.Register(MetricRunTimestamp, metrics.Gauge, "Unix time the most recent run finished, by task")
+	wp.metrics = registry
+}
+
 // Start begins watching configured paths
 func (wp *WatchProcessor) Start() error {
 	if !wp.config.Watch.Enabled {
@@ -93,6 +175,9 @@ func (wp *WatchProcessor) addPath(path string) error {
 			if info.IsDir() && !wp.shouldIgnore(walkPath) {
 				return wp.watcher.Add(walkPath)
 			}
+			if wp.events != nil && strings.HasSuffix(strings.ToLower(walkPath), ".md") {
+				wp.rememberFrontmatter(walkPath)
+			}
 			return nil
 		})
 	} else {
@@ -166,17 +251,107 @@ func (wp *WatchProcessor) debounceEvent(event fsnotify.Event) {
 
 // executeActions executes configured actions for a file system event
 func (wp *WatchProcessor) executeActions(event fsnotify.Event) {
+	start := time.Now()
 	eventType := wp.getEventType(event)
+	if wp.causedByActions(event.Name, eventType) {
+		wp.rememberFrontmatter(event.Name)
+		return
+	}
+	wp.publishEvent(event.Name, eventType)
 
-	for _, rule := range wp.config.Watch.Rules {
-		if wp.matchesRule(event.Name, eventType, rule) {
-			for _, action := range rule.Actions {
-				if err := wp.executeAction(action, event.Name); err != nil {
-					log.Printf("Error executing action '%s' for file '%s': %v", action, event.Name, err)
+	matched := false
+	ran := false
+	for i, rule := range wp.config.Watch.Rules {
+		if !wp.matchesRule(event.Name, eventType, rule) || !wp.matchesQuery(i, event.Name) {
+			continue
+		}
+		matched = true
+		for _, action := range rule.Actions {
+			ran = true
+			if err := wp.executeAction(action, event.Name, eventType, rule.Name, wp.timeout(i)); err != nil {
+				log.Printf("Error executing action '%s' for file '%s': %v", action, event.Name, err)
+				if wp.metrics != nil {
+					wp.metrics.Inc(MetricErrors, metrics.Labels{"stage": "action"})
+				}
+				if rule.StopOnError {
+					log.Printf("Skipping remaining actions of rule '%s' for file '%s'", rule.Name, event.Name)
+					break
 				}
 			}
 		}
 	}
+	if ran {
+		wp.rememberWritten(event.Name)
+	}
+
+	if wp.metrics != nil {
+		wp.metrics.Inc(MetricWatchEvents, metrics.Labels{"event": eventType})
+		if matched {
+			wp.metrics.Inc(MetricFilesProcessed, nil)
+			task := metrics.Labels{"task": "actions"}
+			wp.metrics.Set(MetricRunDuration, task, time.Since(start).Seconds())
+			wp.metrics.Set(MetricRunTimestamp, task, float64(time.Now().Unix()))
+		}
+	}
+}
+
+// publishEvent reports a file change to the configured event sinks. The
+// watcher can't pair the two halves of a rename, so a renamed file is reported
+// as deleted at its old path and created at its new one.
+func (wp *WatchProcessor) publishEvent(path, eventType string) {
+	if wp.events == nil {
+		return
+	}
+
+	event := events.Event{Time: time.Now(), Path: path, Source: "watch"}
+	if rel, err := filepath.Rel(".", path); err == nil {
+		event.Path = filepath.ToSlash(rel)
+	}
+
+	var published []events.Event
+	switch eventType {
+	case "create", "write":
+		event.Type = events.Modified
+		if eventType == "create" {
+			event.Type = events.Created
+		}
+		published = append(published, event)
+
+		wp.frontmatterMutex.Lock()
+		before, known := wp.frontmatter[path]
+		wp.frontmatterMutex.Unlock()
+		after := wp.rememberFrontmatter(path)
+		if known {
+			published = append(published, events.FieldChanges(event, before, after)...)
+		}
+	case "remove", "rename":
+		event.Type = events.Deleted
+		published = append(published, event)
+
+		wp.frontmatterMutex.Lock()
+		delete(wp.frontmatter, path)
+		wp.frontmatterMutex.Unlock()
+	default:
+		return
+	}
+
+	if err := wp.events.Publish(published); err != nil {
+		log.Printf("Error publishing events for file '%s': %v", path, err)
+	}
+}
+
+// rememberFrontmatter records and returns the current frontmatter of a file
+func (wp *WatchProcessor) rememberFrontmatter(path string) map[string]interface{} {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	frontmatter := events.Frontmatter(content)
+
+	wp.frontmatterMutex.Lock()
+	wp.frontmatter[path] = frontmatter
+	wp.frontmatterMutex.Unlock()
+	return frontmatter
 }
 
 // getEventType converts fsnotify event to string
@@ -197,6 +372,56 @@ func (wp *WatchProcessor) getEventType(event fsnotify.Event) string {
 	}
 }
 
+// rememberWritten records the content actions left a file with
+func (wp *WatchProcessor) rememberWritten(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	wp.writtenMutex.Lock()
+	wp.written[path] = sha256.Sum256(content)
+	wp.writtenMutex.Unlock()
+}
+
+// causedByActions reports whether a create or write event only reflects what
+// the actions run for the file last left in it
+func (wp *WatchProcessor) causedByActions(path, eventType string) bool {
+	wp.writtenMutex.Lock()
+	defer wp.writtenMutex.Unlock()
+
+	hash, exists := wp.written[path]
+	if !exists {
+		return false
+	}
+	delete(wp.written, path)
+	if eventType != "create" && eventType != "write" {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	return err == nil && sha256.Sum256(content) == hash
+}
+
+// timeout returns the action timeout of rule i
+func (wp *WatchProcessor) timeout(i int) time.Duration {
+	if i < len(wp.timeouts) {
+		return wp.timeouts[i]
+	}
+	return DefaultWatchActionTimeout
+}
+
+// matchesQuery checks the note against the query of rule i, if it has one.
+// Notes that can't be read, such as removed ones, don't match a query.
+func (wp *WatchProcessor) matchesQuery(i int, filePath string) bool {
+	if i >= len(wp.queries) || wp.queries[i] == nil {
+		return true
+	}
+	file, err := vault.LoadVaultFile(filePath)
+	if err != nil {
+		return false
+	}
+	return wp.queries[i].Evaluate(file)
+}
+
 // matchesRule checks if an event matches a watch rule
 func (wp *WatchProcessor) matchesRule(filePath, eventType string, rule config.WatchRule) bool {
 	// Check if event type matches
@@ -213,7 +438,7 @@ func (wp *WatchProcessor) matchesRule(filePath, eventType string, rule config.Wa
 
 	// Check if path matches
 	for _, rulePath := range rule.Paths {
-		if wp.pathMatches(filePath, rulePath) {
+		if wp.pathMatches(filePath, rulePath) && filesMatch(filePath, rulePath, rule.Files) {
 			return true
 		}
 	}
@@ -221,6 +446,32 @@ func (wp *WatchProcessor) matchesRule(filePath, eventType string, rule config.Wa
 	return false
 }
 
+// filesMatch checks a file against a rule's file patterns, which match the
+// file's name or its path relative to the rule path
+func filesMatch(filePath, rulePath string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	candidates := []string{filepath.Base(filePath), filepath.ToSlash(filePath)}
+	absFilePath, err1 := filepath.Abs(filePath)
+	absRulePath, err2 := filepath.Abs(rulePath)
+	if err1 == nil && err2 == nil {
+		if rel, err := filepath.Rel(absRulePath, absFilePath); err == nil && !strings.HasPrefix(rel, "..") {
+			candidates = append(candidates, filepath.ToSlash(rel))
+		}
+	}
+
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if matched, _ := filepath.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // pathMatches checks if a file path matches a rule path pattern
 func (wp *WatchProcessor) pathMatches(filePath, rulePath string) bool {
 	// Convert to absolute paths for comparison
@@ -247,52 +498,119 @@ func (wp *WatchProcessor) pathMatches(filePath, rulePath string) bool {
 	return false
 }
 
-// executeAction executes a single action command
-func (wp *WatchProcessor) executeAction(action, filePath string) error {
-	// Replace {{file}} placeholder with actual file path
-	action = strings.ReplaceAll(action, "{{file}}", filePath)
-	action = strings.ReplaceAll(action, "{{dir}}", filepath.Dir(filePath))
-	action = strings.ReplaceAll(action, "{{basename}}", filepath.Base(filePath))
-
-	log.Printf("Executing action: %s", action)
-
-	// Parse action command
-	parts := strings.Fields(action)
+// executeAction runs one action for a file. Actions starting with mdnotes run
+// the mdnotes command; anything else runs that program directly, without a
+// shell. Arguments may be quoted, and {{file}}, {{dir}}, {{basename}},
+// {{event}} and {{rule}} are replaced in each argument. The program also gets
+// MDNOTES_FILE, MDNOTES_EVENT and MDNOTES_RULE in its environment.
+func (wp *WatchProcessor) executeAction(action, filePath, eventType, ruleName string, timeout time.Duration) error {
+	parts, err := splitAction(action)
+	if err != nil {
+		return err
+	}
 	if len(parts) == 0 {
 		return fmt.Errorf("empty action command")
 	}
 
-	// For now, we'll support basic mdnotes commands
-	// In a full implementation, this could be extended to support shell commands
-	if parts[0] == "mdnotes" {
-		return wp.executeMdnotesCommand(parts[1:], filePath)
+	replacer := strings.NewReplacer(
+		"{{file}}", filePath,
+		"{{dir}}", filepath.Dir(filePath),
+		"{{basename}}", filepath.Base(filePath),
+		"{{event}}", eventType,
+		"{{rule}}", ruleName,
+	)
+	for i, part := range parts {
+		parts[i] = replacer.Replace(part)
 	}
 
-	return fmt.Errorf("unsupported action command: %s", parts[0])
-}
-
-// executeMdnotesCommand executes an mdnotes command
-func (wp *WatchProcessor) executeMdnotesCommand(args []string, filePath string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("no mdnotes command specified")
+	program, args := parts[0], parts[1:]
+	if program == "mdnotes" {
+		if len(args) == 0 {
+			return fmt.Errorf("no mdnotes command specified")
+		}
+		program = wp.mdnotes
+		if program == "" {
+			program = "mdnotes"
+		}
+		args = append(append([]string{}, wp.mdnotesArgs...), args...)
+	}
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return fmt.Errorf("unsupported action command: %w", err)
 	}
 
-	log.Printf("Would execute mdnotes command: %v for file: %s", args, filePath)
+	log.Printf("Executing action: %s", strings.Join(parts, " "))
+
+	ctx, cancel := context.WithTimeout(wp.ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(),
+		"MDNOTES_FILE="+filePath,
+		"MDNOTES_EVENT="+eventType,
+		"MDNOTES_RULE="+ruleName,
+	)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	if out := strings.TrimSpace(output.String()); out != "" {
+		log.Printf("%s", out)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	return err
+}
 
-	// In a full implementation, this would use the actual command processors
-	// For now, we'll just log the action
-	switch args[0] {
-	case "frontmatter":
-		if len(args) > 1 && args[1] == "ensure" {
-			log.Printf("Would ensure frontmatter for: %s", filePath)
-		}
-	case "linkding":
-		if len(args) > 1 && args[1] == "sync" {
-			log.Printf("Would sync linkding for: %s", filePath)
+// splitAction splits an action into arguments on whitespace, keeping text in
+// single or double quotes together. Backslash escapes the next character
+// outside single quotes.
+func splitAction(action string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, c := range action {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
 		}
-	default:
-		log.Printf("Unsupported mdnotes command: %s", args[0])
 	}
-
-	return nil
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in action: %s", quote, action)
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
 }
@@ -3,6 +3,8 @@ package processor
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/eoinhurrell/mdnotes/internal/config"
+	"github.com/eoinhurrell/mdnotes/internal/metrics"
 )
 
 func TestNewWatchProcessor(t *testing.T) {
@@ -166,11 +169,30 @@ func TestPathMatches(t *testing.T) {
 	}
 }
 
+// fakeProgram writes a shell script that appends its arguments, and the
+// MDNOTES_EVENT variable, to a log file
+func fakeProgram(t *testing.T, dir, name string) (program, logPath string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	program = filepath.Join(dir, name)
+	logPath = filepath.Join(dir, name+".log")
+	script := "#!/bin/sh\necho \"$MDNOTES_EVENT $*\" >> '" + logPath + "'\n"
+	require.NoError(t, os.WriteFile(program, []byte(script), 0755))
+	return program, logPath
+}
+
 func TestExecuteAction(t *testing.T) {
+	dir := t.TempDir()
+	mdnotes, mdnotesLog := fakeProgram(t, dir, "mdnotes")
+	hook, hookLog := fakeProgram(t, dir, "hook")
+
 	cfg := &config.Config{}
 	wp, err := NewWatchProcessor(cfg)
 	require.NoError(t, err)
 	defer wp.Stop()
+	wp.SetMdnotesCommand(mdnotes, "--config", "watch.yaml")
 
 	tests := []struct {
 		name     string
@@ -180,8 +202,14 @@ func TestExecuteAction(t *testing.T) {
 	}{
 		{
 			name:     "valid mdnotes command",
-			action:   "mdnotes frontmatter ensure {{file}}",
-			filePath: "/test/file.md",
+			action:   "mdnotes frontmatter ensure --field tags --default \"[]\" {{file}}",
+			filePath: "/test/my file.md",
+			wantErr:  false,
+		},
+		{
+			name:     "external hook",
+			action:   hook + " '{{basename}}' {{dir}} {{rule}}",
+			filePath: "/test/my file.md",
 			wantErr:  false,
 		},
 		{
@@ -196,11 +224,17 @@ func TestExecuteAction(t *testing.T) {
 			filePath: "/test/file.md",
 			wantErr:  true,
 		},
+		{
+			name:     "unterminated quote",
+			action:   "mdnotes frontmatter ensure --default \"[]",
+			filePath: "/test/file.md",
+			wantErr:  true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := wp.executeAction(tt.action, tt.filePath)
+			err := wp.executeAction(tt.action, tt.filePath, "write", "notes", time.Second)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -208,6 +242,123 @@ func TestExecuteAction(t *testing.T) {
 			}
 		})
 	}
+
+	content, err := os.ReadFile(mdnotesLog)
+	require.NoError(t, err)
+	assert.Equal(t, "write --config watch.yaml frontmatter ensure --field tags --default [] /test/my file.md\n", string(content))
+
+	content, err = os.ReadFile(hookLog)
+	require.NoError(t, err)
+	assert.Equal(t, "write my file.md /test notes\n", string(content))
+
+	err = wp.executeAction("sleep 5", "/test/file.md", "write", "slow", 50*time.Millisecond)
+	assert.ErrorContains(t, err, "timed out")
+}
+
+func TestSplitAction(t *testing.T) {
+	tests := []struct {
+		action string
+		want   []string
+	}{
+		{"mdnotes headings fix {{file}}", []string{"mdnotes", "headings", "fix", "{{file}}"}},
+		{`mdnotes frontmatter ensure --default "[]"`, []string{"mdnotes", "frontmatter", "ensure", "--default", "[]"}},
+		{`sh -c 'echo "$MDNOTES_FILE" > out'`, []string{"sh", "-c", `echo "$MDNOTES_FILE" > out`}},
+		{`hook my\ file ""`, []string{"hook", "my file", ""}},
+		{"  spaced\targs  ", []string{"spaced", "args"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			got, err := splitAction(tt.action)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecuteActions_FilesAndQuery(t *testing.T) {
+	dir := t.TempDir()
+	hook, hookLog := fakeProgram(t, dir, "hook")
+	notes := filepath.Join(dir, "notes")
+	require.NoError(t, os.MkdirAll(filepath.Join(notes, "daily"), 0755))
+
+	daily := filepath.Join(notes, "daily", "today.md")
+	draft := filepath.Join(notes, "draft.md")
+	published := filepath.Join(notes, "post.md")
+	require.NoError(t, os.WriteFile(daily, []byte("# Today\n"), 0644))
+	require.NoError(t, os.WriteFile(draft, []byte("---\nstatus: draft\n---\n"), 0644))
+	require.NoError(t, os.WriteFile(published, []byte("---\nstatus: published\n---\n"), 0644))
+
+	cfg := &config.Config{
+		Watch: config.WatchConfig{
+			Enabled: true,
+			Rules: []config.WatchRule{
+				{Name: "daily", Paths: []string{notes}, Files: []string{"daily/*.md"}, Events: []string{"write"}, Actions: []string{hook + " daily {{basename}}"}},
+				{Name: "publish", Paths: []string{notes}, Query: "status = 'published'", Events: []string{"write"}, Actions: []string{hook + " publish {{basename}}"}},
+			},
+		},
+	}
+	wp, err := NewWatchProcessor(cfg)
+	require.NoError(t, err)
+	defer wp.Stop()
+
+	for _, path := range []string{daily, draft, published} {
+		wp.executeActions(fsnotify.Event{Name: path, Op: fsnotify.Write})
+	}
+
+	content, err := os.ReadFile(hookLog)
+	require.NoError(t, err)
+	assert.Equal(t, "write daily today.md\nwrite publish post.md\n", string(content))
+
+	cfg.Watch.Rules[1].Query = "status = "
+	_, err = NewWatchProcessor(cfg)
+	assert.ErrorContains(t, err, "watch rule 'publish'")
+}
+
+func TestExecuteActions_IgnoresOwnChanges(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(note, []byte("# Note\n"), 0644))
+
+	counter := filepath.Join(dir, "runs")
+	cfg := &config.Config{
+		Watch: config.WatchConfig{
+			Enabled: true,
+			Rules: []config.WatchRule{{
+				Name:   "stamp",
+				Paths:  []string{dir},
+				Events: []string{"write"},
+				Actions: []string{
+					"sh -c 'echo run >> " + counter + "; echo stamped >> \"$MDNOTES_FILE\"'",
+					"false",
+					"sh -c 'echo skipped >> " + counter + "'",
+				},
+				StopOnError: true,
+			}},
+		},
+	}
+	wp, err := NewWatchProcessor(cfg)
+	require.NoError(t, err)
+	defer wp.Stop()
+
+	// The event caused by the action's own write doesn't run it again
+	wp.executeActions(fsnotify.Event{Name: note, Op: fsnotify.Write})
+	wp.executeActions(fsnotify.Event{Name: note, Op: fsnotify.Write})
+
+	content, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(content))
+
+	// A later edit does
+	require.NoError(t, os.WriteFile(note, []byte("# Edited\n"), 0644))
+	wp.executeActions(fsnotify.Event{Name: note, Op: fsnotify.Write})
+
+	content, err = os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "run\nrun\n", string(content))
 }
 
 func TestStartStop(t *testing.T) {
@@ -299,3 +450,63 @@ func TestDebounceEvent(t *testing.T) {
 
 	assert.False(t, exists, "Timer should be cleaned up after debounce timeout")
 }
+
+func TestPublishEvent(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.ndjson")
+	note := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(note, []byte("---\nstatus: draft\n---\n"), 0644))
+
+	cfg := &config.Config{
+		Watch: config.WatchConfig{
+			Enabled: true,
+			Rules:   []config.WatchRule{{Name: "all", Paths: []string{dir}, Events: []string{"write"}}},
+		},
+		Events: config.EventsConfig{Log: logPath},
+	}
+
+	wp, err := NewWatchProcessor(cfg)
+	require.NoError(t, err)
+	defer wp.Stop()
+	require.NoError(t, wp.addPath(dir))
+
+	require.NoError(t, os.WriteFile(note, []byte("---\nstatus: done\n---\n"), 0644))
+	wp.publishEvent(note, "write")
+	wp.publishEvent(note, "remove")
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"type":"modified"`)
+	assert.Contains(t, lines[1], `"type":"field_changed"`)
+	assert.Contains(t, lines[1], `"old":"draft","new":"done"`)
+	assert.Contains(t, lines[2], `"type":"deleted"`)
+	assert.Contains(t, lines[2], `"source":"watch"`)
+}
+
+func TestExecuteActions_RecordsMetrics(t *testing.T) {
+	cfg := &config.Config{
+		Watch: config.WatchConfig{
+			Enabled: true,
+			Rules: []config.WatchRule{
+				{Name: "notes", Paths: []string{"notes"}, Events: []string{"write"}, Actions: []string{"unknown {{file}}"}},
+			},
+		},
+	}
+
+	wp, err := NewWatchProcessor(cfg)
+	require.NoError(t, err)
+	defer wp.Stop()
+
+	registry := metrics.NewRegistry()
+	wp.SetMetrics(registry)
+
+	wp.executeActions(fsnotify.Event{Name: "notes/a.md", Op: fsnotify.Write})
+	wp.executeActions(fsnotify.Event{Name: "other/b.md", Op: fsnotify.Write})
+
+	assert.Equal(t, float64(2), registry.Value(MetricWatchEvents, metrics.Labels{"event": "write"}))
+	assert.Equal(t, float64(1), registry.Value(MetricFilesProcessed, nil))
+	assert.Equal(t, float64(1), registry.Value(MetricErrors, metrics.Labels{"stage": "action"}))
+	assert.NotZero(t, registry.Value(MetricRunTimestamp, metrics.Labels{"task": "actions"}))
+}
@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// WebLink is a web URL a note links to
+type WebLink struct {
+	URL  string
+	Line int // 1-based, counting from the top of the file
+}
+
+var (
+	// webURLRegex finds http and https URLs, in markdown links, <autolinks> or bare
+	webURLRegex     = regexp.MustCompile(`https?://[^\s<>"\x60\]]+`)
+	inlineCodeRegex = regexp.MustCompile("`+[^`]*`+")
+)
+
+// ExtractWebLinks returns the http and https URLs in a note's body, in
+// order and once per line, skipping code. Punctuation ending a sentence and
+// the parenthesis closing a markdown link are not part of the URL.
+func ExtractWebLinks(file *vault.VaultFile) []WebLink {
+	// Line numbers count from the top of the file, frontmatter included
+	offset := 0
+	if content := string(file.Content); file.Body != "" && strings.HasSuffix(content, file.Body) {
+		offset = strings.Count(content[:len(content)-len(file.Body)], "\n")
+	}
+
+	var links []WebLink
+	fence := ""
+	for i, line := range strings.Split(file.Body, "\n") {
+		if m := fenceRegex.FindStringSubmatch(line); m != nil {
+			if fence == "" {
+				fence = m[1]
+			} else if m[1][0] == fence[0] && len(m[1]) >= len(fence) && m[2] == "" {
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" || !strings.Contains(line, "http") {
+			continue
+		}
+
+		line = inlineCodeRegex.ReplaceAllString(line, "")
+		seen := make(map[string]bool)
+		for _, match := range webURLRegex.FindAllString(line, -1) {
+			url := trimURL(match)
+			if url == "" || seen[url] {
+				continue
+			}
+			seen[url] = true
+			links = append(links, WebLink{URL: url, Line: offset + i + 1})
+		}
+	}
+	return links
+}
+
+// trimURL drops trailing punctuation from a URL found in text, keeping a
+// closing parenthesis only when the URL opened one, as Wikipedia's do
+func trimURL(url string) string {
+	for url != "" {
+		last := url[len(url)-1]
+		switch {
+		case strings.ContainsRune(".,;:!?'*_~", rune(last)):
+			url = url[:len(url)-1]
+		case last == ')' && strings.Count(url, "(") < strings.Count(url, ")"):
+			url = url[:len(url)-1]
+		default:
+			if url == "http://" || url == "https://" {
+				return ""
+			}
+			return url
+		}
+	}
+	return url
+}
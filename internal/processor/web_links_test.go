@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestExtractWebLinks(t *testing.T) {
+	content := "---\nurl: https://example.com/frontmatter\n---\n" +
+		"# Links\n" +
+		"See [the docs](https://example.com/docs) and <https://example.com/auto>.\n" +
+		"Bare https://example.com/bare, twice: https://example.com/bare\n" +
+		"Wikipedia: https://en.wikipedia.org/wiki/Go_(programming_language).\n" +
+		"Inline `https://example.com/code` is skipped\n" +
+		"```\nhttps://example.com/fenced\n```\n" +
+		"After the fence: http://example.com/plain?a=1&b=2!\n"
+
+	file := &vault.VaultFile{}
+	require.NoError(t, file.Parse([]byte(content)))
+
+	assert.Equal(t, []WebLink{
+		{URL: "https://example.com/docs", Line: 5},
+		{URL: "https://example.com/auto", Line: 5},
+		{URL: "https://example.com/bare", Line: 6},
+		{URL: "https://en.wikipedia.org/wiki/Go_(programming_language)", Line: 7},
+		{URL: "http://example.com/plain?a=1&b=2", Line: 12},
+	}, ExtractWebLinks(file))
+}
+
+func TestTrimURL(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/a.":       "https://example.com/a",
+		"https://example.com/a),":      "https://example.com/a",
+		"https://example.com/(a)":      "https://example.com/(a)",
+		"https://example.com/a_(b))":   "https://example.com/a_(b)",
+		"https://example.com/path/?q=": "https://example.com/path/?q=",
+		"https://":                     "",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, trimURL(input), input)
+	}
+}
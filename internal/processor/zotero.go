@@ -0,0 +1,202 @@
+package processor
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/internal/zotero"
+)
+
+var (
+	// citationGroupRegex matches a bracketed Pandoc citation such as
+	// [@doe2020] or [see @doe2020, p. 3; @roe2021]
+	citationGroupRegex = regexp.MustCompile(`\[([^\[\]]*@[^\[\]]*)\]`)
+	// citationKeyRegex matches one citation key within a group, with the
+	// optional '-' that suppresses the author
+	citationKeyRegex = regexp.MustCompile(`(^|[\s;(])-?@(\w(?:[\w:.#$%&+?<>~/-]*\w)?)`)
+)
+
+// ZoteroImporter creates and refreshes a literature note per Zotero item
+type ZoteroImporter struct {
+	VaultRoot string // Vault root new notes are created under
+	Dest      string // Folder for new notes, relative to VaultRoot
+}
+
+// NewZoteroImporter creates an importer writing new notes to dest
+func NewZoteroImporter(vaultRoot, dest string) *ZoteroImporter {
+	return &ZoteroImporter{VaultRoot: vaultRoot, Dest: dest}
+}
+
+// ZoteroImportResult describes what importing one item did to its note
+type ZoteroImportResult struct {
+	File    *vault.VaultFile
+	CiteKey string
+	Action  string // "created", "updated", "unchanged", "skipped"
+}
+
+// Import refreshes the literature notes among files, matched by their citekey
+// field, with the items' metadata, creating a note named after the citekey in
+// Dest for each new item. Only metadata fields are written; the body of an
+// existing note is left alone. Items without a citation key and locked notes
+// are skipped.
+func (zi *ZoteroImporter) Import(items []zotero.Item, files []*vault.VaultFile) []ZoteroImportResult {
+	existing := make(map[string]*vault.VaultFile)
+	paths := make(map[string]bool, len(files))
+	for _, file := range files {
+		paths[strings.ToLower(file.RelativePath)] = true
+		if key, ok := file.Frontmatter["citekey"].(string); ok && key != "" {
+			existing[key] = file
+		}
+	}
+
+	var results []ZoteroImportResult
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.CiteKey == "" || seen[item.CiteKey] {
+			continue
+		}
+		seen[item.CiteKey] = true
+		result := ZoteroImportResult{CiteKey: item.CiteKey}
+
+		file := existing[item.CiteKey]
+		switch {
+		case file == nil:
+			file = zi.newNote(item, paths)
+			existing[item.CiteKey] = file
+			result.Action = "created"
+		case file.IsLocked():
+			result.Action = "skipped"
+		case zi.setFrontmatter(file, item):
+			result.Action = "updated"
+		default:
+			result.Action = "unchanged"
+		}
+		result.File = file
+		results = append(results, result)
+	}
+	return results
+}
+
+// newNote creates a literature note named after the item's citekey
+func (zi *ZoteroImporter) newNote(item zotero.Item, paths map[string]bool) *vault.VaultFile {
+	rel := filepath.Join(zi.Dest, sanitizeNoteName(item.CiteKey)+".md")
+	paths[strings.ToLower(rel)] = true
+
+	file := &vault.VaultFile{
+		Path:         filepath.Join(zi.VaultRoot, rel),
+		RelativePath: rel,
+		Frontmatter:  make(map[string]interface{}),
+	}
+	zi.setFrontmatter(file, item)
+
+	title := item.Title
+	if title == "" {
+		title = item.CiteKey
+	}
+	file.Body = "# " + title + "\n"
+	return file
+}
+
+// setFrontmatter writes the item's metadata to the note's frontmatter,
+// reporting whether anything changed. Fields the item leaves empty are
+// removed.
+func (zi *ZoteroImporter) setFrontmatter(file *vault.VaultFile, item zotero.Item) bool {
+	var authors interface{}
+	if len(item.Authors) > 0 {
+		list := make([]interface{}, len(item.Authors))
+		for i, author := range item.Authors {
+			list[i] = author
+		}
+		authors = list
+	}
+	var year interface{} = item.Year
+	if n, err := strconv.Atoi(item.Year); err == nil {
+		year = n
+	}
+
+	fields := []struct {
+		name  string
+		value interface{}
+	}{
+		{"citekey", item.CiteKey},
+		{"title", item.Title},
+		{"authors", authors},
+		{"year", year},
+		{"doi", item.DOI},
+		{"url", item.URL},
+		{"journal", item.Container},
+		{"item_type", item.Type},
+		{"zotero_key", item.Key},
+		{"abstract", item.Abstract},
+	}
+
+	changed := false
+	for _, field := range fields {
+		current, exists := file.Frontmatter[field.name]
+		if field.value == nil || field.value == "" {
+			if exists {
+				delete(file.Frontmatter, field.name)
+				changed = true
+			}
+			continue
+		}
+		if !exists || !reflect.DeepEqual(normalizeField(current), field.value) {
+			file.SetField(field.name, field.value)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// normalizeField converts values read from YAML to the types setFrontmatter
+// writes, so unchanged fields compare equal
+func normalizeField(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// LinkCitations rewrites the Pandoc citations in file whose keys all have a
+// literature note, given as citekey to note name, as wiki links to those
+// notes: "[see @doe2020, p. 3]" becomes "see [[doe2020]], p. 3". Citations in
+// code are left alone. It returns the number of citations rewritten.
+func LinkCitations(file *vault.VaultFile, notes map[string]string) int {
+	body, total := mapProseLines(file.Body, func(line string) (string, int) {
+		return mapProseSegments(line, func(segment string) (string, int) {
+			count := 0
+			segment = citationGroupRegex.ReplaceAllStringFunc(segment, func(group string) string {
+				inner := group[1 : len(group)-1]
+				keys := citationKeyRegex.FindAllStringSubmatch(inner, -1)
+				if len(keys) == 0 {
+					return group
+				}
+				for _, key := range keys {
+					if _, ok := notes[key[2]]; !ok {
+						return group
+					}
+				}
+				count += len(keys)
+				return citationKeyRegex.ReplaceAllStringFunc(inner, func(match string) string {
+					m := citationKeyRegex.FindStringSubmatch(match)
+					return m[1] + "[[" + notes[m[2]] + "]]"
+				})
+			})
+			return segment, count
+		})
+	})
+	if total > 0 {
+		file.Body = body
+	}
+	return total
+}
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/eoinhurrell/mdnotes/internal/zotero"
+)
+
+func TestZoteroImporter_Import(t *testing.T) {
+	existing := &vault.VaultFile{
+		RelativePath: "reading/roe.md",
+		Frontmatter:  map[string]interface{}{"citekey": "roe2021", "title": "Old title", "doi": "10.1/old"},
+		Body:         "# Roe\n\nMy notes.\n",
+	}
+	unchanged := &vault.VaultFile{
+		RelativePath: "reading/poe.md",
+		Frontmatter: map[string]interface{}{
+			"citekey": "poe2019", "title": "Same", "authors": []interface{}{"Poe, Edgar"}, "year": 2019,
+		},
+	}
+	locked := &vault.VaultFile{
+		RelativePath: "locked.md",
+		Frontmatter:  map[string]interface{}{"citekey": "lee2018", vault.LockField: vault.LockValue},
+	}
+	items := []zotero.Item{
+		{CiteKey: "doe2020", Type: "article", Title: "The Art of Note-Taking", Authors: []string{"Doe, Jane"},
+			Year: "2020", DOI: "10.1000/xyz123", Container: "Notes Quarterly", Abstract: "A study."},
+		{CiteKey: "roe2021", Title: "New title", Year: "2021"},
+		{CiteKey: "poe2019", Title: "Same", Authors: []string{"Poe, Edgar"}, Year: "2019"},
+		{CiteKey: "lee2018", Title: "Locked"},
+		{Title: "No key"},
+		{CiteKey: "doe2020", Title: "Duplicate"},
+	}
+
+	results := NewZoteroImporter("/vault", "refs").Import(items, []*vault.VaultFile{existing, unchanged, locked})
+	require.Len(t, results, 4)
+
+	created := results[0]
+	assert.Equal(t, "created", created.Action)
+	assert.Equal(t, filepath.Join("refs", "doe2020.md"), created.File.RelativePath)
+	assert.Equal(t, filepath.Join("/vault", "refs", "doe2020.md"), created.File.Path)
+	assert.Equal(t, "doe2020", created.File.Frontmatter["citekey"])
+	assert.Equal(t, []interface{}{"Doe, Jane"}, created.File.Frontmatter["authors"])
+	assert.Equal(t, 2020, created.File.Frontmatter["year"])
+	assert.Equal(t, "Notes Quarterly", created.File.Frontmatter["journal"])
+	assert.Equal(t, "A study.", created.File.Frontmatter["abstract"])
+	assert.Equal(t, "# The Art of Note-Taking\n", created.File.Body)
+
+	assert.Equal(t, "updated", results[1].Action)
+	assert.Equal(t, "New title", existing.Frontmatter["title"])
+	assert.NotContains(t, existing.Frontmatter, "doi", "fields the item no longer has are removed")
+	assert.Equal(t, "# Roe\n\nMy notes.\n", existing.Body)
+
+	assert.Equal(t, "unchanged", results[2].Action)
+	assert.Equal(t, "skipped", results[3].Action)
+}
+
+func TestLinkCitations(t *testing.T) {
+	file := &vault.VaultFile{Body: "As argued [see @doe2020, p. 3; -@roe2021], notes help.\n" +
+		"Unknown keys stay [@smith1999] and so do mixed ones [@doe2020; @smith1999].\n" +
+		"Mail me@doe2020 or `[@doe2020]` in code.\n" +
+		"```\n[@doe2020]\n```\n"}
+	notes := map[string]string{"doe2020": "doe2020", "roe2021": "Roe 2021"}
+
+	assert.Equal(t, 2, LinkCitations(file, notes))
+	assert.Equal(t, "As argued see [[doe2020]], p. 3; [[Roe 2021]], notes help.\n"+
+		"Unknown keys stay [@smith1999] and so do mixed ones [@doe2020; @smith1999].\n"+
+		"Mail me@doe2020 or `[@doe2020]` in code.\n"+
+		"```\n[@doe2020]\n```\n", file.Body)
+}
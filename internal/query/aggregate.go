@@ -0,0 +1,277 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// AggregateFunctions lists the supported aggregation functions
+var AggregateFunctions = []string{"count", "sum", "avg", "min", "max"}
+
+// Aggregate is an aggregation function over a frontmatter field, such as
+// avg(priority). count() has no field and counts notes.
+type Aggregate struct {
+	Func  string
+	Field string
+}
+
+// String returns the aggregate as written, e.g. "avg(priority)"
+func (a Aggregate) String() string {
+	return a.Func + "(" + a.Field + ")"
+}
+
+var aggregatePattern = regexp.MustCompile(`^(\w+)\s*\(\s*([^()]*?)\s*\)$`)
+
+// ParseAggregates parses a comma-separated list like "count(), avg(priority)"
+func ParseAggregates(spec string) ([]Aggregate, error) {
+	var aggregates []Aggregate
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		matches := aggregatePattern.FindStringSubmatch(part)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid aggregate '%s': expected function(field), e.g. avg(priority)", part)
+		}
+		name := strings.ToLower(matches[1])
+		field := strings.Trim(matches[2], `'"`)
+
+		known := false
+		for _, fn := range AggregateFunctions {
+			known = known || fn == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown aggregate function '%s' (supported: %s)", name, strings.Join(AggregateFunctions, ", "))
+		}
+		if field == "" && name != "count" {
+			return nil, fmt.Errorf("aggregate %s() needs a field, e.g. %s(priority)", name, name)
+		}
+
+		aggregates = append(aggregates, Aggregate{Func: name, Field: field})
+	}
+
+	if len(aggregates) == 0 {
+		return nil, fmt.Errorf("no aggregates in '%s'", spec)
+	}
+	return aggregates, nil
+}
+
+// GroupRow is one row of an aggregation: the group's key values and the
+// value of each aggregate over the notes in the group
+type GroupRow struct {
+	Keys   []string      // One value per group-by field; "" when the field is missing
+	Values []interface{} // One value per aggregate; nil when there is nothing to aggregate
+}
+
+// AggregateResult is a grouped result table
+type AggregateResult struct {
+	GroupBy    []string
+	Aggregates []Aggregate
+	Rows       []GroupRow
+}
+
+// Columns returns the column headers: the group-by fields, then the aggregates
+func (r *AggregateResult) Columns() []string {
+	columns := append([]string{}, r.GroupBy...)
+	for _, aggregate := range r.Aggregates {
+		columns = append(columns, aggregate.String())
+	}
+	return columns
+}
+
+// GroupAndAggregate groups files by the values of the groupBy fields and
+// computes the aggregates for each group, ordered by group key. A note whose
+// group-by field is a list counts towards a group for each item, so grouping
+// by tags counts notes per tag. Without groupBy all files form one group.
+func GroupAndAggregate(files []*vault.VaultFile, groupBy []string, aggregates []Aggregate) *AggregateResult {
+	result := &AggregateResult{GroupBy: groupBy, Aggregates: aggregates}
+
+	groups := make(map[string][]*vault.VaultFile)
+	keys := make(map[string][]string)
+	for _, file := range files {
+		for _, key := range groupKeys(file, groupBy) {
+			id := strings.Join(key, "\x00")
+			if _, exists := groups[id]; !exists {
+				keys[id] = key
+			}
+			groups[id] = append(groups[id], file)
+		}
+	}
+	if len(groupBy) == 0 && len(groups) == 0 {
+		groups[""] = nil
+		keys[""] = nil
+	}
+
+	ids := make([]string, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessGroupKey(keys[ids[i]], keys[ids[j]]) })
+
+	for _, id := range ids {
+		row := GroupRow{Keys: keys[id]}
+		for _, aggregate := range aggregates {
+			row.Values = append(row.Values, aggregate.apply(groups[id]))
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
+
+// groupKeys returns every combination of the file's group-by values
+func groupKeys(file *vault.VaultFile, groupBy []string) [][]string {
+	keys := [][]string{{}}
+	for _, field := range groupBy {
+		values := []string{""}
+		if value, exists := FieldValue(file, field); exists && value != nil {
+			values = listValues(value)
+		}
+
+		var next [][]string
+		for _, key := range keys {
+			for _, value := range values {
+				next = append(next, append(append([]string{}, key...), value))
+			}
+		}
+		keys = next
+	}
+	return keys
+}
+
+// listValues returns the items of a list value, or the value itself
+func listValues(value interface{}) []string {
+	var values []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			values = append(values, fmt.Sprintf("%v", item))
+		}
+	case []string:
+		values = append(values, v...)
+	default:
+		values = []string{fmt.Sprintf("%v", v)}
+	}
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
+// lessGroupKey orders keys field by field, numerically where both values are
+// numbers, with missing values last
+func lessGroupKey(a, b []string) bool {
+	for i := range a {
+		if a[i] == b[i] {
+			continue
+		}
+		if a[i] == "" || b[i] == "" {
+			return b[i] == ""
+		}
+		if compareLess(a[i], b[i]) {
+			return true
+		}
+		if compareGreater(a[i], b[i]) {
+			return false
+		}
+		return a[i] < b[i]
+	}
+	return false
+}
+
+func (a Aggregate) apply(files []*vault.VaultFile) interface{} {
+	if a.Func == "count" {
+		if a.Field == "" {
+			return len(files)
+		}
+		n := 0
+		for _, file := range files {
+			if value, exists := FieldValue(file, a.Field); exists && value != nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	var numbers []float64
+	var dates []time.Time
+	for _, file := range files {
+		value, exists := FieldValue(file, a.Field)
+		if !exists || value == nil {
+			continue
+		}
+		if n, err := convertToFloat(value); err == nil {
+			numbers = append(numbers, n)
+		} else if d, err := aggregateDate(value); err == nil {
+			dates = append(dates, d)
+		}
+	}
+
+	switch a.Func {
+	case "sum":
+		if len(numbers) == 0 {
+			return nil
+		}
+		sum := 0.0
+		for _, n := range numbers {
+			sum += n
+		}
+		return wholeNumber(sum)
+	case "avg":
+		if len(numbers) == 0 {
+			return nil
+		}
+		sum := 0.0
+		for _, n := range numbers {
+			sum += n
+		}
+		return wholeNumber(sum / float64(len(numbers)))
+	case "min", "max":
+		// Numbers take precedence; min and max of dates are only used when no
+		// value in the group is numeric
+		if len(numbers) > 0 {
+			best := numbers[0]
+			for _, n := range numbers[1:] {
+				if (a.Func == "min" && n < best) || (a.Func == "max" && n > best) {
+					best = n
+				}
+			}
+			return wholeNumber(best)
+		}
+		if len(dates) > 0 {
+			best := dates[0]
+			for _, d := range dates[1:] {
+				if (a.Func == "min" && d.Before(best)) || (a.Func == "max" && d.After(best)) {
+					best = d
+				}
+			}
+			return vault.Date{Time: best}
+		}
+	}
+	return nil
+}
+
+func aggregateDate(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case vault.Date:
+		return v.Time, nil
+	case time.Time:
+		return v, nil
+	default:
+		return parseDate(v)
+	}
+}
+
+// wholeNumber returns whole floats as ints so counts and sums print cleanly
+func wholeNumber(f float64) interface{} {
+	if f == float64(int64(f)) {
+		return int(f)
+	}
+	return f
+}
@@ -0,0 +1,69 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestParseAggregates(t *testing.T) {
+	aggregates, err := ParseAggregates("count(), AVG( priority ), max('date modified')")
+	require.NoError(t, err)
+	assert.Equal(t, []Aggregate{
+		{Func: "count"},
+		{Func: "avg", Field: "priority"},
+		{Func: "max", Field: "date modified"},
+	}, aggregates)
+	assert.Equal(t, "avg(priority)", aggregates[1].String())
+
+	for spec, message := range map[string]string{
+		"count":          "invalid aggregate",
+		"median(rating)": "unknown aggregate function",
+		"sum()":          "needs a field",
+		" , ":            "no aggregates",
+	} {
+		_, err := ParseAggregates(spec)
+		assert.ErrorContains(t, err, message, spec)
+	}
+}
+
+func TestGroupAndAggregate(t *testing.T) {
+	files := []*vault.VaultFile{
+		{Frontmatter: map[string]interface{}{"status": "done", "priority": 3, "tags": []interface{}{"work", "urgent"}, "due": "2024-03-01"}},
+		{Frontmatter: map[string]interface{}{"status": "done", "priority": 4, "tags": []interface{}{"work"}, "due": "2024-01-15"}},
+		{Frontmatter: map[string]interface{}{"status": "draft", "priority": "2"}},
+		{Frontmatter: map[string]interface{}{"priority": 10}},
+	}
+	aggregates, err := ParseAggregates("count(), count(tags), sum(priority), avg(priority), min(due), max(priority)")
+	require.NoError(t, err)
+
+	result := GroupAndAggregate(files, []string{"status"}, aggregates)
+	assert.Equal(t, []string{"status", "count()", "count(tags)", "sum(priority)", "avg(priority)", "min(due)", "max(priority)"}, result.Columns())
+	require.Len(t, result.Rows, 3)
+	assert.Equal(t, GroupRow{Keys: []string{"done"}, Values: []interface{}{2, 2, 7, 3.5, mustDate(t, "2024-01-15"), 4}}, result.Rows[0])
+	assert.Equal(t, GroupRow{Keys: []string{"draft"}, Values: []interface{}{1, 0, 2, 2, nil, 2}}, result.Rows[1])
+	assert.Equal(t, []string{""}, result.Rows[2].Keys, "missing values group last")
+
+	byTag := GroupAndAggregate(files, []string{"tags"}, []Aggregate{{Func: "count"}})
+	require.Len(t, byTag.Rows, 3)
+	assert.Equal(t, GroupRow{Keys: []string{"urgent"}, Values: []interface{}{1}}, byTag.Rows[0])
+	assert.Equal(t, GroupRow{Keys: []string{"work"}, Values: []interface{}{2}}, byTag.Rows[1])
+	assert.Equal(t, GroupRow{Keys: []string{""}, Values: []interface{}{2}}, byTag.Rows[2])
+
+	byPriority := GroupAndAggregate(files, []string{"priority"}, []Aggregate{{Func: "count"}})
+	assert.Equal(t, []string{"2"}, byPriority.Rows[0].Keys)
+	assert.Equal(t, []string{"10"}, byPriority.Rows[3].Keys, "numeric keys sort numerically")
+
+	total := GroupAndAggregate(nil, nil, []Aggregate{{Func: "count"}, {Func: "avg", Field: "priority"}})
+	assert.Equal(t, []GroupRow{{Values: []interface{}{0, nil}}}, total.Rows)
+}
+
+func mustDate(t *testing.T, s string) vault.Date {
+	t.Helper()
+	date, err := parseDate(s)
+	require.NoError(t, err)
+	return vault.Date{Time: date}
+}
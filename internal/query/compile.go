@@ -0,0 +1,264 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Compiled is an expression prepared for evaluating against many notes, as in
+// watch mode and exports of large vaults. Compiling resolves each field name
+// once into an accessor, splitting paths such as book.authors[0] into their
+// keys and indexes, and converts each literal once into the form its operator
+// compares with: regular expressions are compiled and numbers, dates,
+// durations and ranges parsed, so evaluating a note parses nothing from the
+// query. A Compiled matches exactly the notes its expression does and is safe
+// to evaluate from several goroutines.
+type Compiled struct {
+	expr Expression
+	eval func(file *vault.VaultFile) bool
+}
+
+// Compile prepares expr for evaluating against many notes. Bind may be called
+// with expr or the result, before or after compiling.
+func Compile(expr Expression) *Compiled {
+	if c, ok := expr.(*Compiled); ok {
+		return c
+	}
+	return &Compiled{expr: expr, eval: compileExpression(expr)}
+}
+
+// Compile parses the input and compiles the expression
+func (p *Parser) Compile() (*Compiled, error) {
+	expr, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return Compile(expr), nil
+}
+
+// Evaluate reports whether file matches the expression
+func (c *Compiled) Evaluate(file *vault.VaultFile) bool {
+	return c.eval(file)
+}
+
+// Expression returns the expression c was compiled from
+func (c *Compiled) Expression() Expression {
+	return c.expr
+}
+
+func compileExpression(expr Expression) func(file *vault.VaultFile) bool {
+	switch e := expr.(type) {
+	case *Compiled:
+		return e.eval
+	case *LogicalExpression:
+		left, right := compileExpression(e.Left), compileExpression(e.Right)
+		switch e.Operator {
+		case "AND":
+			return func(file *vault.VaultFile) bool { return left(file) && right(file) }
+		case "OR":
+			return func(file *vault.VaultFile) bool { return left(file) || right(file) }
+		}
+	case *NotExpression:
+		inner := compileExpression(e.Expr)
+		return func(file *vault.VaultFile) bool { return !inner(file) }
+	case *FieldExpression:
+		field := compileField(e.Name, &e.links)
+		return func(file *vault.VaultFile) bool {
+			_, exists := field(file)
+			return exists
+		}
+	case *ComparisonExpression:
+		return compileComparison(e)
+	}
+	return expr.Evaluate
+}
+
+// fieldAccessor returns the value of a query field in a note
+type fieldAccessor func(file *vault.VaultFile) (interface{}, bool)
+
+// compileField returns an accessor looking up name as lookupField does.
+// links points at the expression's link counts, which Bind may set later.
+func compileField(name string, links **linkCounts) fieldAccessor {
+	pseudo, isPseudo := strings.CutPrefix(name, "file.")
+	_, isContent := contentField(&vault.VaultFile{}, name) // any note tells whether it's one
+	var path []pathSegment
+	if strings.ContainsAny(name, ".[") {
+		path, _ = splitPath(name)
+	}
+
+	return func(file *vault.VaultFile) (interface{}, bool) {
+		// A key that is literally dotted wins over the path it spells
+		if value, exists := file.GetField(name); exists {
+			return value, true
+		}
+		if isPseudo {
+			if value, ok := fileField(file, pseudo, *links); ok {
+				return value, true
+			}
+		}
+		if isContent {
+			return contentField(file, name)
+		}
+		if path == nil {
+			return nil, false
+		}
+		return walkPath(file.Frontmatter, path)
+	}
+}
+
+func compileComparison(e *ComparisonExpression) func(file *vault.VaultFile) bool {
+	field := compileField(e.Field, &e.links)
+
+	// Predicates also apply to fields that aren't there
+	switch e.Operator {
+	case "exists", "not exists", "is null", "is not null", "is empty", "is not empty":
+		return func(file *vault.VaultFile) bool {
+			value, exists := field(file)
+			switch e.Operator {
+			case "exists":
+				return exists
+			case "not exists":
+				return !exists
+			case "is null":
+				return value == nil
+			case "is not null":
+				return value != nil
+			case "is empty":
+				return isEmptyValue(value)
+			default:
+				return !isEmptyValue(value)
+			}
+		}
+	}
+
+	test := compileTest(e.Operator, e.Value)
+	function := e.Func
+	return func(file *vault.VaultFile) bool {
+		value, exists := field(file)
+		if !exists {
+			return false
+		}
+		if function != "" {
+			result, err := EvaluateFunction(function, []interface{}{value})
+			if err != nil {
+				return false
+			}
+			value = result
+		}
+		return test(value)
+	}
+}
+
+// compileTest returns a test of a field's value against literal, matching
+// compareValues, with the literal converted once up front
+func compileTest(operator string, literal interface{}) func(value interface{}) bool {
+	if operator == "!=" {
+		equal := compileTest("=", literal)
+		return func(value interface{}) bool { return !equal(value) }
+	}
+	if base, negated := strings.CutPrefix(operator, "not "); negated {
+		test := compileTest(base, literal)
+		return func(value interface{}) bool { return !test(value) }
+	}
+
+	text := fmt.Sprintf("%v", literal)
+	switch operator {
+	case "=":
+		return func(value interface{}) bool { return formatValue(value) == text }
+	case ">", "<", ">=", "<=":
+		number, err := convertToFloat(literal)
+		numeric := err == nil
+		return func(value interface{}) bool {
+			formatted := formatValue(value)
+			if strings.HasSuffix(operator, "=") && formatted == text {
+				return true
+			}
+			if numeric {
+				if f, err := convertToFloat(value); err == nil {
+					if operator[0] == '>' {
+						return f > number
+					}
+					return f < number
+				}
+			}
+			if operator[0] == '>' {
+				return formatted > text
+			}
+			return formatted < text
+		}
+	case "contains":
+		needle := strings.ToLower(text)
+		return func(value interface{}) bool { return containsText(value, needle) }
+	case "matches":
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return func(interface{}) bool { return false } // Invalid regex pattern
+		}
+		return func(value interface{}) bool { return matchRegexp(value, re) }
+	case "between":
+		r, ok := parseRange(literal)
+		return func(value interface{}) bool { return ok && r.contains(value) }
+	case "after", "before":
+		return compileDateTest(operator, literal)
+	case "within":
+		duration, err := parseDuration(text)
+		return func(value interface{}) bool {
+			date, dateErr := parseDate(value)
+			if err != nil || dateErr != nil {
+				return false
+			}
+			// Check if the field date is within the duration (plus or minus) from now
+			now := time.Now()
+			return date.After(now.Add(-duration)) && date.Before(now.Add(duration))
+		}
+	}
+	return func(value interface{}) bool { return compareValues(operator, value, literal) }
+}
+
+// compileDateTest compiles after and before, whose literal is a date or a
+// duration before now. Durations are measured from the time of each
+// evaluation, so long-running watches stay correct.
+func compileDateTest(operator string, literal interface{}) func(value interface{}) bool {
+	compare := func(date, bound time.Time) bool {
+		if operator == "after" {
+			return date.After(bound)
+		}
+		return date.Before(bound)
+	}
+
+	if bound, err := parseDate(literal); err == nil {
+		return func(value interface{}) bool {
+			date, err := parseDate(value)
+			return err == nil && compare(date, bound)
+		}
+	}
+	duration, err := parseDuration(fmt.Sprintf("%v", literal))
+	if err != nil {
+		return func(interface{}) bool { return false }
+	}
+	return func(value interface{}) bool {
+		date, err := parseDate(value)
+		return err == nil && compare(date, time.Now().Add(-duration))
+	}
+}
+
+// formatValue formats a value as fmt's %v verb does, without its overhead
+// for the common scalar types
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
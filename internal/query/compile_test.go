@@ -0,0 +1,140 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestCompile(t *testing.T) {
+	now := time.Now()
+	notes := []map[string]interface{}{
+		{},
+		{"status": "draft", "priority": 5, "tags": []string{"go", "Machine_Learning"}, "created": vault.Date{Time: now.AddDate(0, 0, -3)}},
+		{"status": "done", "priority": 2.5, "tags": []interface{}{"go", 3}, "created": "2023-05-01", "rating": "10"},
+		{"status": nil, "priority": "high", "book": map[string]interface{}{"authors": []interface{}{"Le Guin", "Herbert"}}, "book.title": "Dune"},
+		{"status": "", "priority": 3, "archived": true, "tags": []string{}, "created": now.Add(48 * time.Hour)},
+	}
+	expressions := []string{
+		`status = "draft"`,
+		`status != 'draft'`,
+		`priority > 3`,
+		`priority >= 3`,
+		`priority < 3`,
+		`priority <= 2.5`,
+		`priority >= 'a'`,
+		`rating > 9`,
+		`archived = true`,
+		`tags contains 'learning'`,
+		`tags not contains 'GO'`,
+		`tags has 'go'`,
+		`tags in 'go'`,
+		`status starts_with 'dr'`,
+		`status not ends_with 'ne'`,
+		`status matches '^d(raft|one)$'`,
+		`status not matches '['`,
+		`priority between '2,4'`,
+		`created between '2023-01-01,2023-12-31'`,
+		`created after '2024-01-01'`,
+		`created before '30 days'`,
+		`created within '7 days'`,
+		`created within 'soon'`,
+		`book.authors[0] = 'Le Guin'`,
+		`book.authors[-1] contains 'herb'`,
+		`book.title = 'Dune'`,
+		`status exists AND NOT status is null`,
+		`status is empty OR tags is empty`,
+		`tags is not empty`,
+		`typeof(priority) = 'number'`,
+		`len(tags) > 1`,
+		`upper(status) = 'DRAFT'`,
+		`file.name = 'note' AND priority`,
+		`heading contains 'intro'`,
+		`wordcount > 2`,
+		`(priority > 3 OR status = 'done') AND NOT archived = true`,
+	}
+
+	for _, expression := range expressions {
+		expr, err := NewParser(expression).Parse()
+		if err != nil {
+			t.Fatalf("parsing %q: %v", expression, err)
+		}
+		compiled, err := NewParser(expression).Compile()
+		if err != nil {
+			t.Fatalf("compiling %q: %v", expression, err)
+		}
+		for i, frontmatter := range notes {
+			file := createTestFile(frontmatter)
+			file.RelativePath = "note.md"
+			file.Body = "# Intro\n\nSome words here.\n"
+			if got, want := compiled.Evaluate(file), expr.Evaluate(file); got != want {
+				t.Errorf("%q on note %d: compiled gave %v, expression %v", expression, i, got, want)
+			}
+		}
+	}
+}
+
+func TestCompileBind(t *testing.T) {
+	files := []*vault.VaultFile{
+		{RelativePath: "a.md", Links: []vault.Link{{Target: "b"}}},
+		{RelativePath: "b.md", Links: []vault.Link{}},
+	}
+	compiled, err := NewParser("file.links_in > 0").Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	Bind(compiled, files, nil)
+	if compiled.Evaluate(files[0]) || !compiled.Evaluate(files[1]) {
+		t.Error("expected only b.md to have links in after binding")
+	}
+}
+
+// benchmarkNotes returns n notes with varied frontmatter, as in a large vault
+func benchmarkNotes(n int) []*vault.VaultFile {
+	statuses := []string{"draft", "done", "queued", "archived"}
+	files := make([]*vault.VaultFile, n)
+	for i := range files {
+		files[i] = createTestFile(map[string]interface{}{
+			"status":   statuses[i%len(statuses)],
+			"priority": i % 7,
+			"tags":     []interface{}{"work", fmt.Sprintf("project-%d", i%50)},
+			"created":  fmt.Sprintf("2024-%02d-%02d", i%12+1, i%28+1),
+			"book":     map[string]interface{}{"authors": []interface{}{fmt.Sprintf("Author %d", i%100)}},
+		})
+	}
+	return files
+}
+
+const benchmarkQuery = `(priority > 3 OR status = 'draft') AND tags matches '^project-(1|2)\d$' AND created after '2024-03-01' AND book.authors[0] contains 'author 1'`
+
+func BenchmarkEvaluateExpression(b *testing.B) {
+	files := benchmarkNotes(50000)
+	expr, err := NewParser(benchmarkQuery).Parse()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, file := range files {
+			expr.Evaluate(file)
+		}
+	}
+}
+
+func BenchmarkEvaluateCompiled(b *testing.B) {
+	files := benchmarkNotes(50000)
+	compiled, err := NewParser(benchmarkQuery).Compile()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, file := range files {
+			compiled.Evaluate(file)
+		}
+	}
+}
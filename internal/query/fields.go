@@ -0,0 +1,276 @@
+package query
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// segmentRegex splits one segment of a field path into its key and indexes,
+// e.g. "authors[0]" into "authors" and "[0]"
+var segmentRegex = regexp.MustCompile(`^([^\[\]]*)((?:\[-?\d+\])*)$`)
+
+var indexRegex = regexp.MustCompile(`\[(-?\d+)\]`)
+
+// linkCounts holds how many distinct notes link to and from each note, keyed
+// by slash-separated relative path, for the file.links_in and file.links_out
+// pseudo fields
+type linkCounts struct {
+	in, out map[string]int
+}
+
+// FieldValue returns the value of a query field in file: a frontmatter field
+// by name, a path into nested frontmatter such as book.author or authors[0]
+// (negative indexes count from the end), one of the pseudo fields
+//
+//	file.path, file.name, file.basename, file.ext, file.dir, file.mtime,
+//	file.size, file.wordcount, file.links_in, file.links_out
+//
+// or one of the content fields body (the text after the frontmatter), heading
+// (the text of each heading, as a list) and wordcount. Frontmatter fields of
+// the same names win over content fields. file.links_in and file.links_out
+// are only available to expressions passed to Bind.
+func FieldValue(file *vault.VaultFile, name string) (interface{}, bool) {
+	return lookupField(file, name, nil)
+}
+
+func lookupField(file *vault.VaultFile, name string, links *linkCounts) (interface{}, bool) {
+	// A key that is literally dotted wins over the path it spells
+	if value, exists := file.GetField(name); exists {
+		return value, true
+	}
+	if strings.HasPrefix(name, "file.") {
+		if value, ok := fileField(file, strings.TrimPrefix(name, "file."), links); ok {
+			return value, true
+		}
+	}
+	if value, ok := contentField(file, name); ok {
+		return value, true
+	}
+	if !strings.ContainsAny(name, ".[") {
+		return nil, false
+	}
+	path, ok := splitPath(name)
+	if !ok {
+		return nil, false
+	}
+	return walkPath(file.Frontmatter, path)
+}
+
+// pathSegment is one dot-separated segment of a field path: a key, which is
+// empty for a segment of only indexes, and the list indexes that follow it
+type pathSegment struct {
+	key     string
+	indexes []int
+}
+
+// splitPath splits a field path such as book.authors[0] into its segments
+func splitPath(name string) ([]pathSegment, bool) {
+	var path []pathSegment
+	for _, segment := range strings.Split(name, ".") {
+		match := segmentRegex.FindStringSubmatch(segment)
+		if match == nil {
+			return nil, false
+		}
+		s := pathSegment{key: match[1]}
+		for _, index := range indexRegex.FindAllStringSubmatch(match[2], -1) {
+			n, _ := strconv.Atoi(index[1])
+			s.indexes = append(s.indexes, n)
+		}
+		path = append(path, s)
+	}
+	return path, true
+}
+
+// walkPath follows path into frontmatter
+func walkPath(frontmatter map[string]interface{}, path []pathSegment) (interface{}, bool) {
+	var value interface{} = frontmatter
+	for _, segment := range path {
+		if segment.key != "" {
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if value, ok = fields[segment.key]; !ok {
+				return nil, false
+			}
+		}
+		for _, n := range segment.indexes {
+			var ok bool
+			if value, ok = elementAt(value, n); !ok {
+				return nil, false
+			}
+		}
+	}
+	return value, true
+}
+
+// elementAt returns the nth element of a list, counting from the end when n is
+// negative
+func elementAt(value interface{}, n int) (interface{}, bool) {
+	var items []interface{}
+	switch list := value.(type) {
+	case []interface{}:
+		items = list
+	case []string:
+		for _, item := range list {
+			items = append(items, item)
+		}
+	default:
+		return nil, false
+	}
+	if n < 0 {
+		n += len(items)
+	}
+	if n < 0 || n >= len(items) {
+		return nil, false
+	}
+	return items[n], true
+}
+
+// fileField returns a pseudo field describing the note itself; names match the
+// file.* variables available to templates
+func fileField(file *vault.VaultFile, name string, links *linkCounts) (interface{}, bool) {
+	path := file.Path
+	if path == "" {
+		path = file.RelativePath
+	}
+	base := filepath.Base(path)
+	relPath := filepath.ToSlash(file.RelativePath)
+
+	switch name {
+	case "path":
+		return relPath, true
+	case "name":
+		return strings.TrimSuffix(base, filepath.Ext(base)), true
+	case "basename":
+		return base, true
+	case "ext":
+		return strings.TrimPrefix(filepath.Ext(base), "."), true
+	case "dir":
+		dir := filepath.ToSlash(filepath.Dir(file.RelativePath))
+		if dir == "." || dir == "/" {
+			return "", true
+		}
+		return dir, true
+	case "mtime":
+		return file.Modified, true
+	case "size":
+		if file.Content != nil {
+			return len(file.Content), true
+		}
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return nil, false
+		}
+		return int(info.Size()), true
+	case "wordcount":
+		return len(strings.Fields(file.Body)), true
+	case "links_in":
+		if links == nil {
+			return nil, false
+		}
+		return links.in[relPath], true
+	case "links_out":
+		if links == nil {
+			return nil, false
+		}
+		return links.out[relPath], true
+	}
+	return nil, false
+}
+
+// contentField returns a field describing the note's body
+func contentField(file *vault.VaultFile, name string) (interface{}, bool) {
+	switch name {
+	case "body":
+		return file.Body, true
+	case "heading":
+		headings := file.Headings
+		if headings == nil {
+			headings = vault.ExtractHeadings(file.Body)
+		}
+		texts := make([]string, len(headings))
+		for i, heading := range headings {
+			texts[i] = heading.Text
+		}
+		return texts, true
+	case "wordcount":
+		return len(strings.Fields(file.Body)), true
+	}
+	return nil, false
+}
+
+// Bind prepares expr to be evaluated against files, which is needed when it
+// uses file.links_in or file.links_out: links are counted between the notes
+// in files, parsing them with parser for files whose links aren't parsed yet.
+// Expressions that don't use those fields are left untouched.
+func Bind(expr Expression, files []*vault.VaultFile, parser vault.LinkParser) {
+	if !usesLinkFields(expr) {
+		return
+	}
+	bindLinks(expr, countLinks(files, parser))
+}
+
+func usesLinkFields(expr Expression) bool {
+	found := false
+	walkFields(expr, func(name string) {
+		if name == "file.links_in" || name == "file.links_out" {
+			found = true
+		}
+	})
+	return found
+}
+
+func bindLinks(expr Expression, links *linkCounts) {
+	switch e := expr.(type) {
+	case *ComparisonExpression:
+		e.links = links
+	case *FieldExpression:
+		e.links = links
+	case *ContainsExpression:
+		e.links = links
+	case *DateExpression:
+		e.links = links
+	case *NotExpression:
+		bindLinks(e.Expr, links)
+	case *LogicalExpression:
+		bindLinks(e.Left, links)
+		bindLinks(e.Right, links)
+	case *FunctionCallExpression:
+		for _, arg := range e.Args {
+			bindLinks(arg, links)
+		}
+	case *Compiled:
+		bindLinks(e.expr, links)
+	}
+}
+
+// walkFields calls fn with the name of every field expr refers to
+func walkFields(expr Expression, fn func(name string)) {
+	switch e := expr.(type) {
+	case *ComparisonExpression:
+		fn(e.Field)
+	case *FieldExpression:
+		fn(e.Name)
+	case *ContainsExpression:
+		fn(e.Field)
+	case *DateExpression:
+		fn(e.Field)
+	case *NotExpression:
+		walkFields(e.Expr, fn)
+	case *LogicalExpression:
+		walkFields(e.Left, fn)
+		walkFields(e.Right, fn)
+	case *FunctionCallExpression:
+		for _, arg := range e.Args {
+			walkFields(arg, fn)
+		}
+	case *Compiled:
+		walkFields(e.expr, fn)
+	}
+}
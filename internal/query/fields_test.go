@@ -0,0 +1,161 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestFieldPathTokenization(t *testing.T) {
+	parser := NewParser(`book.authors[-1].name = 'X' AND file.size > 10`)
+	expected := []string{"book.authors[-1].name", "=", "X", "AND", "file.size", ">", "10", ""}
+	if len(parser.tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d: %v", len(expected), len(parser.tokens), parser.tokens)
+	}
+	for i, token := range parser.tokens {
+		if token.Value != expected[i] {
+			t.Errorf("Token %d: expected %q, got %q", i, expected[i], token.Value)
+		}
+	}
+	if parser.tokens[0].Type != TokenIdentifier {
+		t.Errorf("Expected a field path to be one identifier, got type %v", parser.tokens[0].Type)
+	}
+}
+
+func TestFieldPathEvaluation(t *testing.T) {
+	frontmatter := map[string]interface{}{
+		"book": map[string]interface{}{
+			"author":  "Le Guin",
+			"authors": []interface{}{"Le Guin", map[string]interface{}{"name": "Delany"}},
+		},
+		"ratings":    []interface{}{3, 5},
+		"legacy.key": "dotted",
+	}
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`book.author = 'Le Guin'`, true},
+		{`book.authors[0] = 'Le Guin'`, true},
+		{`book.authors[1].name = 'Delany'`, true},
+		{`book.authors[-1].name = 'Delany'`, true},
+		{`book.authors[2] = 'Delany'`, false},
+		{`book.missing`, false},
+		{`ratings[1] > 4`, true},
+		{`legacy.key = 'dotted'`, true},
+		{`file.path = 'notes/file.md'`, true},
+		{`file.name = 'file' AND file.ext = 'md' AND file.dir = 'notes'`, true},
+		{`file.size = 32`, true},
+		{`file.wordcount = 4 AND book.author = 'Le Guin'`, true},
+		{`file.mtime within '1 day'`, true},
+		{`file.links_in = 0`, false},
+		{`file.unknown`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := NewParser(tt.expression).Parse()
+			if err != nil {
+				t.Fatalf("Failed to parse expression %q: %v", tt.expression, err)
+			}
+
+			file := createTestFile(frontmatter)
+			file.RelativePath = "notes/file.md"
+			file.Content = []byte("---\nx: 1\n---\none two three four\n")
+			file.Body = "one two three four\n"
+
+			if result := expr.Evaluate(file); result != tt.expected {
+				t.Errorf("Expression %q evaluated to %v, expected %v", tt.expression, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindLinkCounts(t *testing.T) {
+	link := func(target string) vault.Link { return vault.Link{Type: vault.WikiLink, Target: target} }
+	files := []*vault.VaultFile{
+		{RelativePath: "hub.md", Links: []vault.Link{link("a"), link("b"), link("b"), link("missing")}},
+		{RelativePath: "a.md", Links: []vault.Link{link("hub")}},
+		{RelativePath: "b.md"},
+		{RelativePath: "orphan.md", Links: []vault.Link{link("orphan")}},
+	}
+
+	tests := []struct {
+		expression string
+		expected   []string
+	}{
+		{`file.links_out >= 2`, []string{"hub.md"}},
+		{`file.links_in = 1`, []string{"hub.md", "a.md", "b.md"}},
+		{`file.links_in = 0 AND file.links_out = 0`, []string{"orphan.md"}},
+		{`NOT (file.links_in > 0)`, []string{"orphan.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := NewParser(tt.expression).Parse()
+			if err != nil {
+				t.Fatalf("Failed to parse expression %q: %v", tt.expression, err)
+			}
+			Bind(expr, files, nil)
+
+			var matched []string
+			for _, file := range files {
+				if expr.Evaluate(file) {
+					matched = append(matched, file.RelativePath)
+				}
+			}
+			if len(matched) != len(tt.expected) {
+				t.Fatalf("Expression %q matched %v, expected %v", tt.expression, matched, tt.expected)
+			}
+			for i := range matched {
+				if matched[i] != tt.expected[i] {
+					t.Errorf("Expression %q matched %v, expected %v", tt.expression, matched, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestContentFields(t *testing.T) {
+	body := "# Meeting notes\n\nDiscussed the TODO list for launch.\n\n## Action items\n\n```\n# not a heading\n```\n"
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{`body contains 'todo list'`, true},
+		{`body contains 'retro'`, false},
+		{`body matches 'TODO\s+list'`, true},
+		{`body matches '^launch'`, false},
+		{`heading contains 'action'`, true},
+		{`heading has 'Meeting notes'`, true},
+		{`heading contains 'not a heading'`, false},
+		{`wordcount > 10 AND wordcount < 20`, true},
+		{`wordcount > 20`, false},
+		{`status = 'done' AND body contains 'launch'`, true},
+		{`heading = 'overridden'`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := NewParser(tt.expression).Parse()
+			if err != nil {
+				t.Fatalf("Failed to parse expression %q: %v", tt.expression, err)
+			}
+
+			file := createTestFile(map[string]interface{}{"status": "done"})
+			file.Body = body
+			if result := expr.Evaluate(file); result != tt.expected {
+				t.Errorf("Expression %q evaluated to %v, expected %v", tt.expression, result, tt.expected)
+			}
+		})
+	}
+
+	// A frontmatter field of the same name wins
+	file := createTestFile(map[string]interface{}{"heading": "overridden"})
+	file.Body = body
+	if value, _ := FieldValue(file, "heading"); value != "overridden" {
+		t.Errorf("Expected the frontmatter heading field, got %v", value)
+	}
+}
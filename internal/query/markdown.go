@@ -0,0 +1,82 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Markdown renders the grouped result as a GFM table. Missing group values
+// are shown as "(none)".
+func (r *AggregateResult) Markdown() string {
+	rows := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		for _, key := range row.Keys {
+			if key == "" {
+				key = "(none)"
+			}
+			rows[i] = append(rows[i], MarkdownCell(key))
+		}
+		for _, value := range row.Values {
+			if f, ok := value.(float64); ok {
+				value = fmt.Sprintf("%.2f", f)
+			}
+			rows[i] = append(rows[i], MarkdownCell(value))
+		}
+	}
+	return MarkdownTable(r.Columns(), rows)
+}
+
+// MarkdownTable renders a GFM table of cells already escaped for one, with
+// the header escaped here
+func MarkdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	escaped := make([]string, len(header))
+	rule := make([]string, len(header))
+	for i, column := range header {
+		escaped[i] = MarkdownCell(column)
+		rule[i] = "---"
+	}
+	writeRow(escaped)
+	writeRow(rule)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// MarkdownCell renders a value for a table cell: lists joined with commas,
+// dates without a midnight time, and | and line breaks escaped
+func MarkdownCell(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			text = v.Format("2006-01-02")
+		} else {
+			text = v.Format("2006-01-02 15:04")
+		}
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = MarkdownCell(item)
+		}
+		return strings.Join(items, ", ")
+	case []string:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = MarkdownCell(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		text = fmt.Sprintf("%v", v)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,28 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownCell(t *testing.T) {
+	assert.Equal(t, "", MarkdownCell(nil))
+	assert.Equal(t, "2024-03-01", MarkdownCell(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2024-03-01 09:30", MarkdownCell(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)))
+	assert.Equal(t, "scifi, classic", MarkdownCell([]interface{}{"scifi", "classic"}))
+	assert.Equal(t, "a \\| b c", MarkdownCell("a | b\nc"))
+}
+
+func TestAggregateResult_Markdown(t *testing.T) {
+	result := &AggregateResult{
+		GroupBy:    []string{"status"},
+		Aggregates: []Aggregate{{Func: "count"}, {Func: "avg", Field: "priority"}},
+		Rows: []GroupRow{
+			{Keys: []string{"done"}, Values: []interface{}{2, 3.5}},
+			{Keys: []string{""}, Values: []interface{}{1, nil}},
+		},
+	}
+	assert.Equal(t, "| status | count() | avg(priority) |\n| --- | --- | --- |\n| done | 2 | 3.50 |\n| (none) | 1 |  |\n", result.Markdown())
+}
@@ -48,8 +48,10 @@ type NotExpression struct {
 // ComparisonExpression represents field comparisons with full operator support
 type ComparisonExpression struct {
 	Field    string
-	Operator string // "=", "!=", ">", ">=", "<", "<=", "contains", "not contains", "in", "not in"
+	Func     string // Function applied to the field's value before comparing, e.g. "typeof"
+	Operator string // "=", "!=", ">", ">=", "<", "<=", "contains", "not contains", "in", "not in", "exists", "is null", "is empty", ...
 	Value    interface{}
+	links    *linkCounts
 }
 
 // LogicalExpression represents AND/OR operations with proper precedence
@@ -72,19 +74,22 @@ type LiteralExpression struct {
 
 // FieldExpression represents field references
 type FieldExpression struct {
-	Name string
+	Name  string
+	links *linkCounts
 }
 
 // Legacy expressions for backward compatibility
 type ContainsExpression struct {
 	Field string
 	Value string
+	links *linkCounts
 }
 
 type DateExpression struct {
 	Field    string
 	Operator string // "after", "before", "within"
 	Value    interface{}
+	links    *linkCounts
 }
 
 // Parser handles parsing query expressions with lexical analysis
@@ -199,10 +204,18 @@ func (p *Parser) tokenize() {
 			continue
 		}
 
-		// Identifiers and keywords
+		// Identifiers and keywords, including field paths such as book.authors[0].name
 		if isAlpha(input[pos]) || input[pos] == '_' {
-			for pos < len(input) && (isAlphaNumeric(input[pos]) || input[pos] == '_') {
-				pos++
+			for pos < len(input) {
+				if isAlphaNumeric(input[pos]) || input[pos] == '_' {
+					pos++
+				} else if input[pos] == '.' && pos+1 < len(input) && (isAlpha(input[pos+1]) || input[pos+1] == '_') {
+					pos++
+				} else if end := indexEnd(input, pos); end > pos {
+					pos = end
+				} else {
+					break
+				}
 			}
 			value := input[start:pos]
 
@@ -354,6 +367,17 @@ func (p *Parser) parseComparisonExpression() (Expression, error) {
 		return nil, err
 	}
 
+	// Predicates on whether a field is there and has a value
+	if fieldExpr, ok := left.(*FieldExpression); ok {
+		predicate, found, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return &ComparisonExpression{Field: fieldExpr.Name, Operator: predicate}, nil
+		}
+	}
+
 	// Check for comparison operators
 	if p.current().Type == TokenOperator {
 		op := p.current().Value
@@ -377,13 +401,20 @@ func (p *Parser) parseComparisonExpression() (Expression, error) {
 			return nil, fmt.Errorf("comparison operator '%s' requires a literal value on the right side", op)
 		}
 
-		// Left side must be a field expression
+		// Left side must be a field expression, or a function of one
 		if fieldExpr, ok := left.(*FieldExpression); ok {
 			return &ComparisonExpression{
 				Field:    fieldExpr.Name,
 				Operator: op,
 				Value:    rightValue,
 			}, nil
+		} else if field, fn, ok := fieldFunction(left); ok {
+			return &ComparisonExpression{
+				Field:    field,
+				Func:     fn,
+				Operator: op,
+				Value:    rightValue,
+			}, nil
 		} else {
 			return nil, fmt.Errorf("comparison operator '%s' requires a field on the left side", op)
 		}
@@ -430,6 +461,13 @@ func (p *Parser) parseComparisonExpression() (Expression, error) {
 					Operator: keyword,
 					Value:    rightValue,
 				}, nil
+			} else if field, fn, ok := fieldFunction(left); ok {
+				return &ComparisonExpression{
+					Field:    field,
+					Func:     fn,
+					Operator: keyword,
+					Value:    rightValue,
+				}, nil
 			} else {
 				return nil, fmt.Errorf("operator '%s' requires a field on the left side", keyword)
 			}
@@ -439,6 +477,65 @@ func (p *Parser) parseComparisonExpression() (Expression, error) {
 	return left, nil
 }
 
+// parsePredicate parses a predicate following a field: exists, not exists,
+// is [not] null or is [not] empty. Its words aren't reserved, so fields named
+// like them can still be queried.
+func (p *Parser) parsePredicate() (string, bool, error) {
+	if p.isWord("exists") {
+		p.advance()
+		return "exists", true, nil
+	}
+	if next := p.peek(); p.current().Type == TokenKeyword && p.current().Value == "NOT" &&
+		next.Type == TokenIdentifier && strings.EqualFold(next.Value, "exists") {
+		p.advance() // consume NOT
+		p.advance() // consume exists
+		return "not exists", true, nil
+	}
+	if !p.isWord("is") {
+		return "", false, nil
+	}
+	p.advance() // consume is
+
+	negated := false
+	if p.current().Type == TokenKeyword && p.current().Value == "NOT" {
+		negated = true
+		p.advance()
+	}
+	var predicate string
+	switch {
+	case p.isWord("null"):
+		predicate = "null"
+	case p.isWord("empty"):
+		predicate = "empty"
+	default:
+		return "", false, fmt.Errorf("expected 'null' or 'empty' after 'is' at position %d", p.current().Pos)
+	}
+	p.advance()
+	if negated {
+		return "is not " + predicate, true, nil
+	}
+	return "is " + predicate, true, nil
+}
+
+// fieldFunction returns the field and function of a call such as
+// typeof(field) on the left of a comparison, which compares the function of
+// the field's value
+func fieldFunction(expr Expression) (field, name string, ok bool) {
+	call, isCall := expr.(*FunctionCallExpression)
+	if !isCall || len(call.Args) != 1 {
+		return "", "", false
+	}
+	arg, isField := call.Args[0].(*FieldExpression)
+	if !isField {
+		return "", "", false
+	}
+	switch name := strings.ToLower(call.Name); name {
+	case "typeof", "len", "count", "lower", "upper":
+		return arg.Name, name, true
+	}
+	return "", "", false
+}
+
 // parseTerm handles terms (identifiers, literals, function calls, parentheses)
 func (p *Parser) parseTerm() (Expression, error) {
 	token := p.current()
@@ -554,6 +651,26 @@ func isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
+// indexEnd returns the position just past an array index such as [2] or [-1]
+// starting at pos, or pos if there isn't one
+func indexEnd(input string, pos int) int {
+	if input[pos] != '[' {
+		return pos
+	}
+	end := pos + 1
+	if end < len(input) && input[end] == '-' {
+		end++
+	}
+	digits := end
+	for end < len(input) && isDigit(input[end]) {
+		end++
+	}
+	if end == digits || end >= len(input) || input[end] != ']' {
+		return pos
+	}
+	return end + 1
+}
+
 func isAlpha(c byte) bool {
 	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 }
@@ -576,7 +693,7 @@ func (e *LiteralExpression) Evaluate(file *vault.VaultFile) bool {
 }
 
 func (e *FieldExpression) Evaluate(file *vault.VaultFile) bool {
-	_, exists := file.GetField(e.Name)
+	_, exists := lookupField(file, e.Name, e.links)
 	return exists
 }
 
@@ -587,58 +704,88 @@ func isAlphaNumeric(c byte) bool {
 // Evaluation methods
 
 func (e *ComparisonExpression) Evaluate(file *vault.VaultFile) bool {
-	value, exists := file.GetField(e.Field)
+	value, exists := lookupField(file, e.Field, e.links)
+
+	// Predicates also apply to fields that aren't there
+	switch e.Operator {
+	case "exists":
+		return exists
+	case "not exists":
+		return !exists
+	case "is null":
+		return value == nil
+	case "is not null":
+		return value != nil
+	case "is empty":
+		return isEmptyValue(value)
+	case "is not empty":
+		return !isEmptyValue(value)
+	}
+
 	if !exists {
 		return false
 	}
+	if e.Func != "" {
+		result, err := EvaluateFunction(e.Func, []interface{}{value})
+		if err != nil {
+			return false
+		}
+		value = result
+	}
 
-	switch e.Operator {
+	return compareValues(e.Operator, value, e.Value)
+}
+
+// compareValues applies a comparison operator to a field's value and the
+// literal it is compared with
+func compareValues(operator string, value, literal interface{}) bool {
+	switch operator {
 	case "=":
-		return compareEqual(value, e.Value)
+		return compareEqual(value, literal)
 	case "!=":
-		return !compareEqual(value, e.Value)
+		return !compareEqual(value, literal)
 	case ">":
-		return compareGreater(value, e.Value)
+		return compareGreater(value, literal)
 	case "<":
-		return compareLess(value, e.Value)
+		return compareLess(value, literal)
 	case ">=":
-		return compareGreater(value, e.Value) || compareEqual(value, e.Value)
+		return compareGreater(value, literal) || compareEqual(value, literal)
 	case "<=":
-		return compareLess(value, e.Value) || compareEqual(value, e.Value)
+		return compareLess(value, literal) || compareEqual(value, literal)
 	case "contains":
-		return evaluateContains(value, e.Value)
+		return evaluateContains(value, literal)
 	case "not contains":
-		return !evaluateContains(value, e.Value)
+		return !evaluateContains(value, literal)
 	case "in":
-		return evaluateIn(e.Value, value)
+		return evaluateIn(literal, value)
 	case "not in":
-		return !evaluateIn(e.Value, value)
+		return !evaluateIn(literal, value)
 	case "after":
-		return evaluateDateComparison(value, e.Value, "after")
+		return evaluateDateComparison(value, literal, "after")
 	case "before":
-		return evaluateDateComparison(value, e.Value, "before")
+		return evaluateDateComparison(value, literal, "before")
 	case "within":
-		return evaluateDateComparison(value, e.Value, "within")
+		return evaluateDateComparison(value, literal, "within")
 	case "has":
-		return evaluateHas(value, e.Value)
+		return evaluateHas(value, literal)
 	case "not has":
-		return !evaluateHas(value, e.Value)
+		return !evaluateHas(value, literal)
 	case "starts_with":
-		return evaluateStartsWith(value, e.Value)
+		return evaluateStartsWith(value, literal)
 	case "not starts_with":
-		return !evaluateStartsWith(value, e.Value)
+		return !evaluateStartsWith(value, literal)
 	case "ends_with":
-		return evaluateEndsWith(value, e.Value)
+		return evaluateEndsWith(value, literal)
 	case "not ends_with":
-		return !evaluateEndsWith(value, e.Value)
+		return !evaluateEndsWith(value, literal)
 	case "matches":
-		return evaluateMatches(value, e.Value)
+		return evaluateMatches(value, literal)
 	case "not matches":
-		return !evaluateMatches(value, e.Value)
+		return !evaluateMatches(value, literal)
 	case "between":
-		return evaluateBetween(value, e.Value)
+		return evaluateBetween(value, literal)
 	case "not between":
-		return !evaluateBetween(value, e.Value)
+		return !evaluateBetween(value, literal)
 	default:
 		return false
 	}
@@ -656,7 +803,7 @@ func (e *LogicalExpression) Evaluate(file *vault.VaultFile) bool {
 }
 
 func (e *ContainsExpression) Evaluate(file *vault.VaultFile) bool {
-	value, exists := file.GetField(e.Field)
+	value, exists := lookupField(file, e.Field, e.links)
 	if !exists {
 		return false
 	}
@@ -685,7 +832,7 @@ func (e *ContainsExpression) Evaluate(file *vault.VaultFile) bool {
 }
 
 func (e *DateExpression) Evaluate(file *vault.VaultFile) bool {
-	value, exists := file.GetField(e.Field)
+	value, exists := lookupField(file, e.Field, e.links)
 	if !exists {
 		return false
 	}
@@ -698,13 +845,13 @@ func (e *DateExpression) Evaluate(file *vault.VaultFile) bool {
 
 	switch e.Operator {
 	case "after":
-		compareDate, err := parseDate(e.Value)
+		compareDate, err := ParseDateOrAgo(e.Value)
 		if err != nil {
 			return false
 		}
 		return fieldDate.After(compareDate)
 	case "before":
-		compareDate, err := parseDate(e.Value)
+		compareDate, err := ParseDateOrAgo(e.Value)
 		if err != nil {
 			return false
 		}
@@ -724,31 +871,32 @@ func (e *DateExpression) Evaluate(file *vault.VaultFile) bool {
 // Helper evaluation functions
 
 func evaluateContains(haystack, needle interface{}) bool {
+	return containsText(haystack, strings.ToLower(fmt.Sprintf("%v", needle)))
+}
+
+// containsText reports whether haystack, or an item of it when it's a list,
+// contains needle, which is already lower case, ignoring case
+func containsText(haystack interface{}, needle string) bool {
 	switch h := haystack.(type) {
 	case string:
-		needleStr := fmt.Sprintf("%v", needle)
-		return strings.Contains(strings.ToLower(h), strings.ToLower(needleStr))
+		return strings.Contains(strings.ToLower(h), needle)
 	case []interface{}:
-		needleStr := strings.ToLower(fmt.Sprintf("%v", needle))
 		for _, item := range h {
-			if strings.Contains(strings.ToLower(fmt.Sprintf("%v", item)), needleStr) {
+			if strings.Contains(strings.ToLower(fmt.Sprintf("%v", item)), needle) {
 				return true
 			}
 		}
 		return false
 	case []string:
-		needleStr := strings.ToLower(fmt.Sprintf("%v", needle))
 		for _, item := range h {
-			if strings.Contains(strings.ToLower(item), needleStr) {
+			if strings.Contains(strings.ToLower(item), needle) {
 				return true
 			}
 		}
 		return false
 	default:
 		// Convert to string and check
-		haystackStr := strings.ToLower(fmt.Sprintf("%v", h))
-		needleStr := strings.ToLower(fmt.Sprintf("%v", needle))
-		return strings.Contains(haystackStr, needleStr)
+		return strings.Contains(strings.ToLower(fmt.Sprintf("%v", h)), needle)
 	}
 }
 
@@ -785,13 +933,13 @@ func evaluateDateComparison(fieldValue, compareValue interface{}, operator strin
 
 	switch operator {
 	case "after":
-		compareDate, err := parseDate(compareValue)
+		compareDate, err := ParseDateOrAgo(compareValue)
 		if err != nil {
 			return false
 		}
 		return fieldDate.After(compareDate)
 	case "before":
-		compareDate, err := parseDate(compareValue)
+		compareDate, err := ParseDateOrAgo(compareValue)
 		if err != nil {
 			return false
 		}
@@ -826,11 +974,16 @@ func EvaluateFunction(name string, args []interface{}) (interface{}, error) {
 			return nil, fmt.Errorf("date() argument must be a string")
 		}
 		return parseDate(dateStr)
-	case "len":
+	case "len", "count":
 		if len(args) != 1 {
-			return nil, fmt.Errorf("len() takes exactly one argument")
+			return nil, fmt.Errorf("%s() takes exactly one argument", name)
 		}
 		return evaluateLen(args[0]), nil
+	case "typeof":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("typeof() takes exactly one argument")
+		}
+		return typeOf(args[0]), nil
 	case "lower":
 		if len(args) != 1 {
 			return nil, fmt.Errorf("lower() takes exactly one argument")
@@ -846,6 +999,48 @@ func EvaluateFunction(name string, args []interface{}) (interface{}, error) {
 	}
 }
 
+// typeOf names the type of a frontmatter value: string, number, boolean,
+// date, array, object or null
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case int, int64, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case vault.Date, time.Time:
+		return "date"
+	case []interface{}, []string:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// isEmptyValue reports whether a field value is missing or null, or an empty
+// or blank string, list or object
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
 func evaluateLen(value interface{}) int {
 	switch v := value.(type) {
 	case string:
@@ -903,6 +1098,12 @@ func convertToFloat(v interface{}) (float64, error) {
 }
 
 func parseDate(v interface{}) (time.Time, error) {
+	switch date := v.(type) {
+	case time.Time:
+		return date, nil
+	case vault.Date:
+		return date.Time, nil
+	}
 	dateStr := fmt.Sprintf("%v", v)
 
 	// Try common date formats
@@ -925,6 +1126,37 @@ func parseDate(v interface{}) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
 }
 
+// ParseDateOrAgo parses a date, or a duration such as "90 days" meaning that
+// long before now, so "modified before '90 days'" matches notes older than that
+func ParseDateOrAgo(v interface{}) (time.Time, error) {
+	date, err := parseDate(v)
+	if err == nil {
+		return date, nil
+	}
+	duration, durationErr := parseDuration(fmt.Sprintf("%v", v))
+	if durationErr != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-duration), nil
+}
+
+// compactDurationPattern matches durations like "30d", "2w" and "1y"
+var compactDurationPattern = regexp.MustCompile(`^(\d+)([dwy])$`)
+
+// ParseDuration parses a duration such as "90 days", "2 weeks", "30d" or "36h"
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if m := compactDurationPattern.FindStringSubmatch(strings.ToLower(s)); m != nil {
+		num, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, err
+		}
+		days := map[string]int{"d": 1, "w": 7, "y": 365}[m[2]]
+		return time.Duration(num*days) * 24 * time.Hour, nil
+	}
+	return parseDuration(s)
+}
+
 func parseDuration(s string) (time.Duration, error) {
 	// Handle common duration formats including minutes and hours
 	re := regexp.MustCompile(`(\d+)\s*(minutes?|mins?|hours?|hrs?|days?|weeks?|months?|years?)`)
@@ -1041,7 +1273,12 @@ func evaluateMatches(fieldValue, pattern interface{}) bool {
 	if err != nil {
 		return false // Invalid regex pattern
 	}
+	return matchRegexp(fieldValue, re)
+}
 
+// matchRegexp reports whether re matches the field value, or an item of it
+// when it's a list
+func matchRegexp(fieldValue interface{}, re *regexp.Regexp) bool {
 	switch h := fieldValue.(type) {
 	case []interface{}:
 		for _, item := range h {
@@ -1064,36 +1301,58 @@ func evaluateMatches(fieldValue, pattern interface{}) bool {
 
 // evaluateBetween checks if numeric/date field value is between two values
 func evaluateBetween(fieldValue, rangeValue interface{}) bool {
+	r, ok := parseRange(rangeValue)
+	return ok && r.contains(fieldValue)
+}
+
+// valueRange is the inclusive range of a between comparison, with its bounds
+// parsed as numbers and dates where they are both
+type valueRange struct {
+	minStr, maxStr     string
+	minFloat, maxFloat float64
+	numeric            bool
+	minDate, maxDate   time.Time
+	dated              bool
+}
+
+// parseRange parses a range such as "1,5" or "2024-01-01,2024-06-30"
+func parseRange(rangeValue interface{}) (valueRange, bool) {
 	// Expected format: "min,max" or "start_date,end_date"
 	rangeStr := fmt.Sprintf("%v", rangeValue)
 	parts := strings.Split(rangeStr, ",")
 	if len(parts) != 2 {
-		return false
+		return valueRange{}, false
 	}
 
-	minStr := strings.TrimSpace(parts[0])
-	maxStr := strings.TrimSpace(parts[1])
+	r := valueRange{minStr: strings.TrimSpace(parts[0]), maxStr: strings.TrimSpace(parts[1])}
+	minFloat, minErr := convertToFloat(r.minStr)
+	maxFloat, maxErr := convertToFloat(r.maxStr)
+	r.minFloat, r.maxFloat, r.numeric = minFloat, maxFloat, minErr == nil && maxErr == nil
 
-	// Try numeric comparison first
-	fieldFloat, fieldErr := convertToFloat(fieldValue)
-	minFloat, minErr := convertToFloat(minStr)
-	maxFloat, maxErr := convertToFloat(maxStr)
+	minDate, minErr := parseDate(r.minStr)
+	maxDate, maxErr := parseDate(r.maxStr)
+	r.minDate, r.maxDate, r.dated = minDate, maxDate, minErr == nil && maxErr == nil
+	return r, true
+}
 
-	if fieldErr == nil && minErr == nil && maxErr == nil {
-		return fieldFloat >= minFloat && fieldFloat <= maxFloat
+// contains reports whether fieldValue is in the range
+func (r valueRange) contains(fieldValue interface{}) bool {
+	// Try numeric comparison first
+	if r.numeric {
+		if fieldFloat, err := convertToFloat(fieldValue); err == nil {
+			return fieldFloat >= r.minFloat && fieldFloat <= r.maxFloat
+		}
 	}
 
 	// Try date comparison
-	fieldDate, fieldErr := parseDate(fieldValue)
-	minDate, minErr := parseDate(minStr)
-	maxDate, maxErr := parseDate(maxStr)
-
-	if fieldErr == nil && minErr == nil && maxErr == nil {
-		return (fieldDate.After(minDate) || fieldDate.Equal(minDate)) &&
-			(fieldDate.Before(maxDate) || fieldDate.Equal(maxDate))
+	if r.dated {
+		if fieldDate, err := parseDate(fieldValue); err == nil {
+			return (fieldDate.After(r.minDate) || fieldDate.Equal(r.minDate)) &&
+				(fieldDate.Before(r.maxDate) || fieldDate.Equal(r.maxDate))
+		}
 	}
 
 	// Fall back to string comparison
 	fieldStr := fmt.Sprintf("%v", fieldValue)
-	return fieldStr >= minStr && fieldStr <= maxStr
+	return fieldStr >= r.minStr && fieldStr <= r.maxStr
 }
@@ -473,6 +473,31 @@ func TestExpressionEvaluation(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name:       "before duration - old date should match",
+			expression: `modified before '90 days'`,
+			frontmatter: map[string]interface{}{
+				"modified": time.Now().AddDate(0, 0, -120).Format("2006-01-02"),
+			},
+			expected: true,
+		},
+		{
+			name:       "before duration - recent date should not match",
+			expression: `status = 'done' AND modified before '90 days'`,
+			frontmatter: map[string]interface{}{
+				"status":   "done",
+				"modified": time.Now().AddDate(0, 0, -10).Format("2006-01-02"),
+			},
+			expected: false,
+		},
+		{
+			name:       "after duration - recent date should match",
+			expression: `created after "2 weeks"`,
+			frontmatter: map[string]interface{}{
+				"created": time.Now().AddDate(0, 0, -3).Format("2006-01-02"),
+			},
+			expected: true,
+		},
 		{
 			name:       "has operator - exact array element match",
 			expression: `tags has "learning"`,
@@ -836,6 +861,28 @@ func TestDurationParsing(t *testing.T) {
 	}
 }
 
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":     30 * 24 * time.Hour,
+		"2w":      14 * 24 * time.Hour,
+		"1Y":      365 * 24 * time.Hour,
+		"90 days": 90 * 24 * time.Hour,
+		"36h":     36 * time.Hour,
+	}
+	for input, expected := range tests {
+		result, err := ParseDuration(input)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error: %v", input, err)
+		} else if result != expected {
+			t.Errorf("ParseDuration(%q) = %v, expected %v", input, result, expected)
+		}
+	}
+
+	if _, err := ParseDuration("soon"); err == nil {
+		t.Error("ParseDuration(\"soon\") should fail")
+	}
+}
+
 // Test helper evaluation functions
 func TestHelperEvaluationFunctions(t *testing.T) {
 	t.Run("evaluateContains", func(t *testing.T) {
@@ -1054,6 +1101,71 @@ func TestErrorCases(t *testing.T) {
 	}
 }
 
+func TestFieldPredicates(t *testing.T) {
+	file := createTestFile(map[string]interface{}{
+		"status":   "draft",
+		"created":  nil,
+		"summary":  "  ",
+		"tags":     []interface{}{"a", "b", "c"},
+		"aliases":  []interface{}{},
+		"priority": 3,
+		"done":     false,
+		"due":      vault.Date{Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		"book":     map[string]interface{}{"author": "Le Guin"},
+		"is":       "a field",
+	})
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{"status exists", true},
+		{"created exists", true},
+		{"missing exists", false},
+		{"missing not exists AND status = 'draft'", true},
+		{"created NOT EXISTS", false},
+		{"created is null", true},
+		{"missing is null", true},
+		{"status is null", false},
+		{"status is not null", true},
+		{"created is not null", false},
+		{"summary is empty", true},
+		{"aliases is empty", true},
+		{"missing is empty", true},
+		{"tags is empty", false},
+		{"tags is not empty AND priority is not empty", true},
+		{"NOT (status exists)", false},
+		{"typeof(tags) = 'array'", true},
+		{"typeof(status) = 'string' AND typeof(priority) = 'number'", true},
+		{"typeof(done) = 'boolean' AND typeof(due) = 'date'", true},
+		{"typeof(book) = 'object' AND typeof(created) = 'null'", true},
+		{"typeof(missing) = 'null'", false},
+		{"typeof(tags) != 'string'", true},
+		{"typeof(tags) matches '^(array|object)$'", true},
+		{"count(tags) > 2 AND len(aliases) = 0", true},
+		{"lower(book.author) = 'le guin'", true},
+		{"is = 'a field'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := NewParser(tt.expression).Parse()
+			if err != nil {
+				t.Fatalf("Failed to parse expression %q: %v", tt.expression, err)
+			}
+			if result := expr.Evaluate(file); result != tt.expected {
+				t.Errorf("Expression %q evaluated to %v, expected %v", tt.expression, result, tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"status is", "status is draft", "typeof(status, tags) = 'string'", "now() = 'x'"} {
+		if _, err := NewParser(input).Parse(); err == nil {
+			t.Errorf("Expected error for input %q, but got none", input)
+		}
+	}
+}
+
 // Benchmark tests
 func BenchmarkSimpleExpression(b *testing.B) {
 	expression := `status = "draft"`
@@ -0,0 +1,237 @@
+package query
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Query is a filter expression with optional ORDER BY and LIMIT clauses, as in
+//
+//	status = 'draft' ORDER BY file.mtime DESC LIMIT 10
+type Query struct {
+	Where   Expression // nil matches every note; compiled when parsed
+	OrderBy []SortKey
+	Limit   int // 0 for no limit
+	links   *linkCounts
+}
+
+// SortKey orders notes by a query field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseQuery parses a query: an expression, which may be left out, followed
+// by optional ORDER BY and LIMIT clauses
+func ParseQuery(input string) (*Query, error) {
+	return NewParser(input).ParseQuery()
+}
+
+// ParseQuery parses the tokens into a query
+func (p *Parser) ParseQuery() (*Query, error) {
+	q := &Query{}
+	if !p.atClause() {
+		expr, err := p.parseOrExpression()
+		if err != nil {
+			return nil, err
+		}
+		q.Where = Compile(expr)
+	}
+
+	if p.isWord("order") && p.peek().Type == TokenIdentifier && strings.EqualFold(p.peek().Value, "by") {
+		p.advance() // consume ORDER
+		p.advance() // consume BY
+		keys, err := p.parseSortKeys()
+		if err != nil {
+			return nil, err
+		}
+		q.OrderBy = keys
+	}
+
+	if p.isWord("limit") {
+		p.advance() // consume LIMIT
+		token := p.current()
+		n, err := strconv.Atoi(token.Value)
+		if token.Type != TokenNumber || err != nil || n < 1 {
+			return nil, fmt.Errorf("LIMIT needs a positive whole number at position %d", token.Pos)
+		}
+		p.advance()
+		q.Limit = n
+	}
+
+	if p.current().Type != TokenEOF {
+		return nil, fmt.Errorf("unexpected token '%s' at position %d", p.current().Value, p.current().Pos)
+	}
+	return q, nil
+}
+
+// ParseSortKeys parses a comma-separated list of fields, each optionally
+// followed by ASC or DESC, such as "priority desc, title"
+func ParseSortKeys(spec string) ([]SortKey, error) {
+	p := NewParser(spec)
+	keys, err := p.parseSortKeys()
+	if err != nil {
+		return nil, err
+	}
+	if p.current().Type != TokenEOF {
+		return nil, fmt.Errorf("unexpected token '%s' at position %d", p.current().Value, p.current().Pos)
+	}
+	return keys, nil
+}
+
+func (p *Parser) parseSortKeys() ([]SortKey, error) {
+	var keys []SortKey
+	for {
+		token := p.current()
+		if token.Type != TokenIdentifier {
+			return nil, fmt.Errorf("expected a field to sort by at position %d", token.Pos)
+		}
+		p.advance()
+		key := SortKey{Field: token.Value}
+		if p.isWord("desc") {
+			key.Desc = true
+			p.advance()
+		} else if p.isWord("asc") {
+			p.advance()
+		}
+		keys = append(keys, key)
+
+		if p.current().Type != TokenComma {
+			return keys, nil
+		}
+		p.advance() // consume ','
+	}
+}
+
+// atClause reports whether the parser is at the end of the expression part
+// of a query: at ORDER BY, LIMIT n or the end of input. Neither is reserved,
+// so fields named order or limit can still be queried.
+func (p *Parser) atClause() bool {
+	next := p.peek()
+	return p.current().Type == TokenEOF ||
+		(p.isWord("order") && next.Type == TokenIdentifier && strings.EqualFold(next.Value, "by")) ||
+		(p.isWord("limit") && next.Type == TokenNumber)
+}
+
+// isWord reports whether the current token is the identifier word, in any case
+func (p *Parser) isWord(word string) bool {
+	token := p.current()
+	return token.Type == TokenIdentifier && strings.EqualFold(token.Value, word)
+}
+
+// peek returns the token after the current one
+func (p *Parser) peek() Token {
+	if p.pos+1 >= len(p.tokens) {
+		return Token{Type: TokenEOF}
+	}
+	return p.tokens[p.pos+1]
+}
+
+// Bind prepares the query to be run against files, counting links between
+// them when the expression or a sort key uses file.links_in or
+// file.links_out; see Bind
+func (q *Query) Bind(files []*vault.VaultFile, parser vault.LinkParser) {
+	needed := q.Where != nil && usesLinkFields(q.Where)
+	for _, key := range q.OrderBy {
+		needed = needed || key.Field == "file.links_in" || key.Field == "file.links_out"
+	}
+	if !needed {
+		return
+	}
+	q.links = countLinks(files, parser)
+	if q.Where != nil {
+		bindLinks(q.Where, q.links)
+	}
+}
+
+// Match reports whether file matches the query's expression
+func (q *Query) Match(file *vault.VaultFile) bool {
+	return q.Where == nil || q.Where.Evaluate(file)
+}
+
+// Run returns the files matching the query, sorted and limited by its clauses
+func (q *Query) Run(files []*vault.VaultFile) []*vault.VaultFile {
+	var matches []*vault.VaultFile
+	for _, file := range files {
+		if q.Match(file) {
+			matches = append(matches, file)
+		}
+	}
+	return q.Arrange(matches)
+}
+
+// Arrange sorts files, already matched, by the query's ORDER BY clause and
+// cuts them to its LIMIT
+func (q *Query) Arrange(files []*vault.VaultFile) []*vault.VaultFile {
+	q.Sort(files)
+	if q.Limit > 0 && len(files) > q.Limit {
+		files = files[:q.Limit]
+	}
+	return files
+}
+
+// Sort orders files by the query's sort keys, keeping the order of files
+// that compare equal. Numbers and dates compare by value and other values as
+// text, ignoring case; notes without a field come last in either direction.
+func (q *Query) Sort(files []*vault.VaultFile) {
+	if len(q.OrderBy) == 0 {
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		for _, key := range q.OrderBy {
+			a, aok := lookupField(files[i], key.Field, q.links)
+			b, bok := lookupField(files[j], key.Field, q.links)
+			aok, bok = aok && a != nil, bok && b != nil
+			if aok != bok {
+				return aok
+			}
+			if !aok {
+				continue
+			}
+			if c := compareSortValues(a, b); c != 0 {
+				return (c < 0) != key.Desc
+			}
+		}
+		return false
+	})
+}
+
+// compareSortValues returns -1, 0 or 1 as a sorts before, with or after b
+func compareSortValues(a, b interface{}) int {
+	if x, err := convertToFloat(a); err == nil {
+		if y, err := convertToFloat(b); err == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+	if x, err := aggregateDate(a); err == nil {
+		if y, err := aggregateDate(b); err == nil {
+			return x.Compare(y)
+		}
+	}
+	x, y := fmt.Sprintf("%v", a), fmt.Sprintf("%v", b)
+	if c := strings.Compare(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+		return c
+	}
+	return strings.Compare(x, y)
+}
+
+// countLinks counts the links between files, parsing them with parser for
+// files whose links aren't parsed yet
+func countLinks(files []*vault.VaultFile, parser vault.LinkParser) *linkCounts {
+	if parser != nil {
+		for _, file := range files {
+			if file.Links == nil {
+				parser.UpdateFile(file)
+			}
+		}
+	}
+	links := &linkCounts{}
+	links.in, links.out = analyzer.LinkCounts(files)
+	return links
+}
@@ -0,0 +1,92 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input   string
+		where   bool
+		orderBy []SortKey
+		limit   int
+	}{
+		{input: "status = 'draft'", where: true},
+		{input: "status = 'draft' ORDER BY file.mtime DESC LIMIT 10", where: true, orderBy: []SortKey{{Field: "file.mtime", Desc: true}}, limit: 10},
+		{input: "order by priority desc, title asc", orderBy: []SortKey{{Field: "priority", Desc: true}, {Field: "title"}}},
+		{input: "limit 5", limit: 5},
+		{input: "limit > 3 AND order = 'first'", where: true},
+		{input: "(a = 1 OR b = 2) Order By a", where: true, orderBy: []SortKey{{Field: "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := ParseQuery(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, q.Where != nil)
+			assert.Equal(t, tt.orderBy, q.OrderBy)
+			assert.Equal(t, tt.limit, q.Limit)
+		})
+	}
+
+	for _, input := range []string{
+		"status = 'draft' ORDER BY",
+		"status = 'draft' LIMIT 0",
+		"status = 'draft' LIMIT 10 ORDER BY title",
+		"ORDER BY title DESC DESC",
+	} {
+		_, err := ParseQuery(input)
+		assert.Error(t, err, input)
+	}
+
+	keys, err := ParseSortKeys("file.mtime desc, title")
+	require.NoError(t, err)
+	assert.Equal(t, []SortKey{{Field: "file.mtime", Desc: true}, {Field: "title"}}, keys)
+	_, err = ParseSortKeys("title = 'x'")
+	assert.Error(t, err)
+}
+
+func TestQuery_Run(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	note := func(name string, modified time.Time, frontmatter map[string]interface{}) *vault.VaultFile {
+		return &vault.VaultFile{RelativePath: name + ".md", Modified: modified, Frontmatter: frontmatter}
+	}
+	files := []*vault.VaultFile{
+		note("a", day(3), map[string]interface{}{"status": "draft", "priority": 2, "title": "beta"}),
+		note("b", day(1), map[string]interface{}{"status": "draft", "priority": 10, "title": "Alpha"}),
+		note("c", day(5), map[string]interface{}{"status": "done", "priority": 5}),
+		note("d", day(4), map[string]interface{}{"status": "draft", "title": "gamma"}),
+	}
+	paths := func(files []*vault.VaultFile) []string {
+		var result []string
+		for _, file := range files {
+			result = append(result, file.RelativePath)
+		}
+		return result
+	}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"status = 'draft' ORDER BY file.mtime DESC LIMIT 2", []string{"d.md", "a.md"}},
+		{"ORDER BY priority", []string{"a.md", "c.md", "b.md", "d.md"}},
+		{"ORDER BY priority DESC", []string{"b.md", "c.md", "a.md", "d.md"}},
+		{"ORDER BY title", []string{"b.md", "a.md", "d.md", "c.md"}},
+		{"ORDER BY status DESC, priority DESC", []string{"b.md", "a.md", "d.md", "c.md"}},
+		{"LIMIT 1", []string{"a.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			q, err := ParseQuery(tt.input)
+			require.NoError(t, err)
+			q.Bind(files, nil)
+			assert.Equal(t, tt.want, paths(q.Run(files)))
+		})
+	}
+}
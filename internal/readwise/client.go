@@ -0,0 +1,202 @@
+package readwise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultBaseURL is the Readwise API host
+const DefaultBaseURL = "https://readwise.io"
+
+// Client represents a Readwise API client
+type Client struct {
+	baseURL     string
+	apiToken    string
+	httpClient  *http.Client
+	rateLimiter *rate.Limiter
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithBaseURL sets the API host, for testing or proxies
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithRateLimit sets the rate limit for API calls
+func WithRateLimit(reqPerMinute int) ClientOption {
+	return func(c *Client) {
+		c.rateLimiter = rate.NewLimiter(rate.Limit(float64(reqPerMinute)/60), 1)
+	}
+}
+
+// WithHTTPClient sets a custom HTTP client
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// NewClient creates a new Readwise API client
+func NewClient(apiToken string, opts ...ClientOption) *Client {
+	client := &Client{
+		baseURL:    DefaultBaseURL,
+		apiToken:   apiToken,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		// The export endpoint allows 20 requests a minute
+		rateLimiter: rate.NewLimiter(rate.Every(3*time.Second), 1),
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+// Book is a source of highlights: a book, article, tweet or podcast, from
+// Readwise or Readwise Reader
+type Book struct {
+	ID            int         `json:"user_book_id"`
+	Title         string      `json:"title"`
+	ReadableTitle string      `json:"readable_title"`
+	Author        string      `json:"author"`
+	Source        string      `json:"source"`
+	Category      string      `json:"category"`
+	SourceURL     string      `json:"source_url"`
+	ReadwiseURL   string      `json:"readwise_url"`
+	DocumentNote  string      `json:"document_note"`
+	Tags          []Tag       `json:"book_tags"`
+	Highlights    []Highlight `json:"highlights"`
+}
+
+// Highlight is a passage highlighted in a book
+type Highlight struct {
+	ID            int    `json:"id"`
+	Text          string `json:"text"`
+	Note          string `json:"note"`
+	Location      int    `json:"location"`
+	LocationType  string `json:"location_type"`
+	URL           string `json:"url"`
+	HighlightedAt string `json:"highlighted_at"`
+	UpdatedAt     string `json:"updated_at"`
+	IsDeleted     bool   `json:"is_deleted"`
+	IsDiscard     bool   `json:"is_discard"`
+	Tags          []Tag  `json:"tags"`
+}
+
+// Tag is a Readwise tag
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ExportResponse is one page of the highlight export
+type ExportResponse struct {
+	Count          int     `json:"count"`
+	NextPageCursor *string `json:"nextPageCursor"`
+	Results        []Book  `json:"results"`
+}
+
+// Export retrieves every book with highlights updated after updatedAfter,
+// following the API's pagination. A zero updatedAfter exports everything.
+// Each book carries only its highlights updated in that window.
+func (c *Client) Export(ctx context.Context, updatedAfter time.Time) ([]Book, error) {
+	var books []Book
+	cursor := ""
+	for {
+		query := url.Values{}
+		if !updatedAfter.IsZero() {
+			query.Set("updatedAfter", updatedAfter.UTC().Format(time.RFC3339))
+		}
+		if cursor != "" {
+			query.Set("pageCursor", cursor)
+		}
+
+		page, err := c.getExportPage(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, page.Results...)
+		if page.NextPageCursor == nil || *page.NextPageCursor == "" {
+			return books, nil
+		}
+		cursor = *page.NextPageCursor
+	}
+}
+
+// getExportPage retrieves one page of the export, waiting out rate limiting
+func (c *Client) getExportPage(ctx context.Context, query url.Values) (*ExportResponse, error) {
+	const maxRetries = 3
+
+	for attempt := 0; ; attempt++ {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v2/export/?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		httpReq.Header.Set("Authorization", "Token "+c.apiToken)
+		httpReq.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("executing request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRetries {
+			_ = resp.Body.Close()
+			delay := time.Minute
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(seconds) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+				continue
+			}
+		}
+
+		page, err := decodeExportPage(resp)
+		_ = resp.Body.Close()
+		return page, err
+	}
+}
+
+func decodeExportPage(resp *http.Response) (*ExportResponse, error) {
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+	var page ExportResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &page, nil
+}
+
+// checkResponse checks the HTTP response for errors
+func checkResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("authentication error: invalid API token")
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limited: too many requests")
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
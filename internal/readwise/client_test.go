@@ -0,0 +1,75 @@
+package readwise
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Export(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/v2/export/", r.URL.Path)
+		assert.Equal(t, "Token test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "2024-01-02T03:04:05Z", r.URL.Query().Get("updatedAfter"))
+
+		var resp ExportResponse
+		if r.URL.Query().Get("pageCursor") == "" {
+			cursor := "page2"
+			resp = ExportResponse{Count: 2, NextPageCursor: &cursor, Results: []Book{{ID: 1, Title: "First"}}}
+		} else {
+			assert.Equal(t, "page2", r.URL.Query().Get("pageCursor"))
+			resp = ExportResponse{Count: 2, Results: []Book{{ID: 2, Title: "Second", Highlights: []Highlight{{ID: 10, Text: "quote"}}}}}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL), WithRateLimit(6000))
+	books, err := client.Export(context.Background(), time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	assert.Equal(t, "Second", books[1].Title)
+	assert.Equal(t, "quote", books[1].Highlights[0].Text)
+}
+
+func TestClient_Export_RateLimited(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Empty(t, r.URL.Query().Get("updatedAfter"))
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(ExportResponse{Results: []Book{{ID: 1}}})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL), WithRateLimit(6000))
+	books, err := client.Export(context.Background(), time.Time{})
+
+	require.NoError(t, err)
+	assert.Len(t, books, 1)
+	assert.Equal(t, 2, requests)
+}
+
+func TestClient_Export_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("bad-token", WithBaseURL(server.URL), WithRateLimit(6000))
+	_, err := client.Export(context.Background(), time.Time{})
+
+	assert.ErrorContains(t, err, "invalid API token")
+}
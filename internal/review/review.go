@@ -0,0 +1,296 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// DefaultQueueFile is the review queue location relative to the vault root
+const DefaultQueueFile = ".mdnotes/review-queue.json"
+
+// Weighting computes a selection weight for a file
+type Weighting struct {
+	Term   string // "age", "recency", "words", or a numeric frontmatter field
+	Invert bool   // true for "1/term" expressions
+}
+
+// ParseWeighting parses a weight expression such as "age", "1/recency" or "rating".
+// An empty expression yields uniform weighting.
+func ParseWeighting(expr string) (*Weighting, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	w := &Weighting{}
+	if strings.HasPrefix(expr, "1/") {
+		w.Invert = true
+		expr = strings.TrimSpace(strings.TrimPrefix(expr, "1/"))
+	}
+	if expr == "" {
+		return nil, fmt.Errorf("weight expression is missing a term")
+	}
+	w.Term = expr
+
+	return w, nil
+}
+
+// Weight returns the weight for a file at the given time. Weights are never negative;
+// files without a usable value receive a weight of zero and are only chosen once
+// every weighted file has been exhausted.
+func (w *Weighting) Weight(file *vault.VaultFile, now time.Time) float64 {
+	if w == nil {
+		return 1
+	}
+
+	var value float64
+	switch w.Term {
+	case "age":
+		value = ageDays(file, now)
+	case "recency":
+		value = 1 / (1 + ageDays(file, now))
+	case "words":
+		value = float64(len(strings.Fields(file.Body)))
+	default:
+		raw, exists := file.GetField(w.Term)
+		if !exists {
+			return 0
+		}
+		f, ok := toFloat(raw)
+		if !ok {
+			return 0
+		}
+		value = f
+	}
+
+	if w.Invert {
+		if value == 0 {
+			return 0
+		}
+		value = 1 / value
+	}
+
+	if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
+// ageDays returns the number of days since the file was last modified
+func ageDays(file *vault.VaultFile, now time.Time) float64 {
+	if file.Modified.IsZero() {
+		return 0
+	}
+	days := now.Sub(file.Modified).Hours() / 24
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// toFloat converts a frontmatter value to a float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Picker selects notes at random, optionally weighted
+type Picker struct {
+	weighting *Weighting
+	rng       *rand.Rand
+	now       func() time.Time
+}
+
+// NewPicker creates a picker. A zero seed uses a time-based seed.
+func NewPicker(weighting *Weighting, seed uint64) *Picker {
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	return &Picker{
+		weighting: weighting,
+		rng:       rand.New(rand.NewPCG(seed, seed>>1|1)),
+		now:       time.Now,
+	}
+}
+
+// Pick selects up to n distinct files from the pool without replacement
+func (p *Picker) Pick(pool []*vault.VaultFile, n int) []*vault.VaultFile {
+	if n <= 0 || len(pool) == 0 {
+		return nil
+	}
+
+	now := p.now()
+	remaining := make([]*vault.VaultFile, len(pool))
+	copy(remaining, pool)
+	weights := make([]float64, len(remaining))
+	for i, file := range remaining {
+		weights[i] = p.weighting.Weight(file, now)
+	}
+
+	var picked []*vault.VaultFile
+	for len(picked) < n && len(remaining) > 0 {
+		idx := p.choose(weights)
+		picked = append(picked, remaining[idx])
+
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+		weights = append(weights[:idx], weights[idx+1:]...)
+	}
+
+	return picked
+}
+
+// choose returns an index chosen proportionally to weights, falling back to
+// a uniform choice when every weight is zero
+func (p *Picker) choose(weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return p.rng.IntN(len(weights))
+	}
+
+	target := p.rng.Float64() * total
+	for i, w := range weights {
+		target -= w
+		if target < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// Queue tracks notes already surfaced so repeats are avoided until the pool is exhausted
+type Queue struct {
+	Seen      []string  `json:"seen"`
+	Cycles    int       `json:"cycles"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	path string
+}
+
+// LoadQueue loads a queue from disk, returning an empty queue if the file does not exist
+func LoadQueue(path string) (*Queue, error) {
+	q := &Queue{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		return nil, fmt.Errorf("reading review queue: %w", err)
+	}
+
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("parsing review queue %s: %w", path, err)
+	}
+
+	return q, nil
+}
+
+// Save writes the queue to disk, creating the parent directory if needed
+func (q *Queue) Save() error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("creating queue directory: %w", err)
+	}
+
+	q.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling review queue: %w", err)
+	}
+
+	if err := safety.WriteFile(q.path, data, 0644); err != nil {
+		return fmt.Errorf("writing review queue: %w", err)
+	}
+
+	return nil
+}
+
+// Reset clears the seen list
+func (q *Queue) Reset() {
+	q.Seen = nil
+}
+
+// Next picks up to n unseen files from the pool and records them as seen.
+// When every file in the pool has been seen, the queue starts a new cycle.
+func (q *Queue) Next(picker *Picker, pool []*vault.VaultFile, n int) []*vault.VaultFile {
+	var picked []*vault.VaultFile
+
+	for len(picked) < n {
+		unseen := q.unseen(pool, picked)
+		if len(unseen) == 0 {
+			if len(picked) >= len(pool) || len(pool) == 0 {
+				break
+			}
+			// Pool exhausted: start a new cycle, which this round's picks
+			// already belong to, so they don't come back before it ends
+			q.Seen = nil
+			for _, file := range picked {
+				q.Seen = append(q.Seen, file.RelativePath)
+			}
+			q.Cycles++
+			continue
+		}
+
+		batch := picker.Pick(unseen, n-len(picked))
+		for _, file := range batch {
+			q.Seen = append(q.Seen, file.RelativePath)
+		}
+		picked = append(picked, batch...)
+	}
+
+	return picked
+}
+
+// Remaining returns how many files in the pool have not yet been surfaced this cycle
+func (q *Queue) Remaining(pool []*vault.VaultFile) int {
+	return len(q.unseen(pool, nil))
+}
+
+// unseen returns pool files not in the seen list or the exclusion list
+func (q *Queue) unseen(pool, exclude []*vault.VaultFile) []*vault.VaultFile {
+	seen := make(map[string]bool, len(q.Seen)+len(exclude))
+	for _, path := range q.Seen {
+		seen[path] = true
+	}
+	for _, file := range exclude {
+		seen[file.RelativePath] = true
+	}
+
+	var result []*vault.VaultFile
+	for _, file := range pool {
+		if !seen[file.RelativePath] {
+			result = append(result, file)
+		}
+	}
+
+	// Keep ordering deterministic for seeded runs
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RelativePath < result[j].RelativePath
+	})
+
+	return result
+}
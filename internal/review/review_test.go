@@ -0,0 +1,155 @@
+package review
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func testPool(now time.Time) []*vault.VaultFile {
+	return []*vault.VaultFile{
+		{RelativePath: "a.md", Modified: now.AddDate(0, 0, -1), Frontmatter: map[string]interface{}{"rating": 1}},
+		{RelativePath: "b.md", Modified: now.AddDate(0, 0, -100), Frontmatter: map[string]interface{}{"rating": 5}},
+		{RelativePath: "c.md", Modified: now.AddDate(0, 0, -10), Frontmatter: map[string]interface{}{}},
+	}
+}
+
+func TestParseWeighting(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    *Weighting
+		wantErr bool
+	}{
+		{expr: "", want: nil},
+		{expr: "age", want: &Weighting{Term: "age"}},
+		{expr: "1/recency", want: &Weighting{Term: "recency", Invert: true}},
+		{expr: " rating ", want: &Weighting{Term: "rating"}},
+		{expr: "1/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseWeighting(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWeighting_Weight(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	pool := testPool(now)
+
+	age := &Weighting{Term: "age"}
+	assert.InDelta(t, 1.0, age.Weight(pool[0], now), 0.001)
+	assert.InDelta(t, 100.0, age.Weight(pool[1], now), 0.001)
+
+	inverseRecency := &Weighting{Term: "recency", Invert: true}
+	assert.Greater(t, inverseRecency.Weight(pool[1], now), inverseRecency.Weight(pool[0], now))
+
+	rating := &Weighting{Term: "rating"}
+	assert.Equal(t, 5.0, rating.Weight(pool[1], now))
+	assert.Equal(t, 0.0, rating.Weight(pool[2], now), "missing field weighs zero")
+
+	var uniform *Weighting
+	assert.Equal(t, 1.0, uniform.Weight(pool[0], now))
+}
+
+func TestPicker_PickDistinct(t *testing.T) {
+	now := time.Now()
+	picker := NewPicker(nil, 42)
+
+	picked := picker.Pick(testPool(now), 5)
+	assert.Len(t, picked, 3)
+
+	seen := map[string]bool{}
+	for _, f := range picked {
+		assert.False(t, seen[f.RelativePath], "duplicate pick %s", f.RelativePath)
+		seen[f.RelativePath] = true
+	}
+}
+
+func TestPicker_PickWeighted(t *testing.T) {
+	now := time.Now()
+	pool := testPool(now)
+	picker := NewPicker(&Weighting{Term: "age"}, 7)
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[picker.Pick(pool, 1)[0].RelativePath]++
+	}
+
+	assert.Greater(t, counts["b.md"], counts["c.md"])
+	assert.Greater(t, counts["c.md"], counts["a.md"])
+}
+
+func TestPicker_Seeded(t *testing.T) {
+	now := time.Now()
+	first := NewPicker(nil, 99).Pick(testPool(now), 3)
+	second := NewPicker(nil, 99).Pick(testPool(now), 3)
+
+	for i := range first {
+		assert.Equal(t, first[i].RelativePath, second[i].RelativePath)
+	}
+}
+
+func TestQueue_AvoidsRepeatsUntilExhausted(t *testing.T) {
+	now := time.Now()
+	pool := testPool(now)
+	queuePath := filepath.Join(t.TempDir(), ".mdnotes", "review-queue.json")
+
+	queue, err := LoadQueue(queuePath)
+	require.NoError(t, err)
+
+	picker := NewPicker(nil, 1)
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		picked := queue.Next(picker, pool, 1)
+		require.Len(t, picked, 1)
+		assert.False(t, seen[picked[0].RelativePath], "repeat before pool exhausted")
+		seen[picked[0].RelativePath] = true
+	}
+	assert.Equal(t, 0, queue.Remaining(pool))
+
+	require.NoError(t, queue.Save())
+
+	reloaded, err := LoadQueue(queuePath)
+	require.NoError(t, err)
+	assert.Len(t, reloaded.Seen, 3)
+
+	// Exhausted pool starts a new cycle
+	picked := reloaded.Next(picker, pool, 1)
+	assert.Len(t, picked, 1)
+	assert.Equal(t, 1, reloaded.Cycles)
+	assert.Equal(t, 2, reloaded.Remaining(pool))
+}
+
+func TestQueue_NextAcrossCycleBoundary(t *testing.T) {
+	now := time.Now()
+	pool := testPool(now)
+	queue := &Queue{Seen: []string{"a.md", "b.md"}}
+
+	picked := queue.Next(NewPicker(nil, 3), pool, 3)
+	require.Len(t, picked, 3)
+
+	paths := map[string]bool{}
+	for _, f := range picked {
+		paths[f.RelativePath] = true
+	}
+	assert.Len(t, paths, 3, "a single request never repeats a note")
+	assert.Equal(t, 1, queue.Cycles)
+
+	// The note picked before the boundary belongs to the new cycle too, so the
+	// next run can only pick what the new cycle hasn't surfaced yet
+	assert.Len(t, queue.Seen, 3)
+	assert.Equal(t, 0, queue.Remaining(pool))
+}
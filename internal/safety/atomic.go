@@ -0,0 +1,140 @@
+package safety
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WriteOption configures an atomic write
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	preserveModTime bool
+}
+
+// PreserveModTime keeps the modification time of the file being replaced, so
+// cosmetic rewrites don't make a note look recently edited. It has no effect
+// when the file doesn't exist yet.
+func PreserveModTime() WriteOption {
+	return func(o *writeOptions) {
+		o.preserveModTime = true
+	}
+}
+
+// AtomicFile is a file that only appears at its destination once committed.
+// Writes go to a temporary file in the same directory, which Commit syncs and
+// renames over the destination, so a crash or error part-way through leaves
+// either the old content or the new content, never a truncated file.
+type AtomicFile struct {
+	*os.File
+	path    string
+	perm    os.FileMode
+	modTime time.Time
+	done    bool
+}
+
+// CreateAtomic starts an atomic write to path. If path already exists its
+// permissions are kept; otherwise the file is created with perm. Symlinks are
+// followed, so the link target is replaced rather than the link itself. The
+// caller must call Commit or Abort; Abort after Commit is a no-op, so it can
+// be deferred.
+func CreateAtomic(path string, perm os.FileMode, opts ...WriteOption) (*AtomicFile, error) {
+	var options writeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	af := &AtomicFile{path: path, perm: perm}
+	if info, err := os.Stat(path); err == nil {
+		af.perm = info.Mode().Perm()
+		if options.preserveModTime {
+			af.modTime = info.ModTime()
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file for %s: %w", path, err)
+	}
+	af.File = tmp
+	return af, nil
+}
+
+// Commit flushes the written content to disk and moves it into place
+func (af *AtomicFile) Commit() error {
+	if af.done {
+		return fmt.Errorf("atomic write to %s already finished", af.path)
+	}
+	af.done = true
+	tmp := af.Name()
+
+	if err := af.Sync(); err != nil {
+		_ = af.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("syncing %s: %w", af.path, err)
+	}
+	if err := af.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("closing %s: %w", af.path, err)
+	}
+	if err := os.Chmod(tmp, af.perm); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("setting permissions on %s: %w", af.path, err)
+	}
+	if !af.modTime.IsZero() {
+		if err := os.Chtimes(tmp, af.modTime, af.modTime); err != nil {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("preserving modification time of %s: %w", af.path, err)
+		}
+	}
+	if err := os.Rename(tmp, af.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replacing %s: %w", af.path, err)
+	}
+
+	syncDir(filepath.Dir(af.path))
+	return nil
+}
+
+// Abort discards the write, leaving the destination untouched
+func (af *AtomicFile) Abort() {
+	if af.done {
+		return
+	}
+	af.done = true
+	_ = af.Close()
+	_ = os.Remove(af.Name())
+}
+
+// WriteFile is an atomic replacement for os.WriteFile. It never leaves a
+// partially written file at path: readers see either the previous content or
+// data in full.
+func WriteFile(path string, data []byte, perm os.FileMode, opts ...WriteOption) error {
+	af, err := CreateAtomic(path, perm, opts...)
+	if err != nil {
+		return err
+	}
+	defer af.Abort()
+
+	if _, err := af.Write(data); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return af.Commit()
+}
+
+// syncDir makes a rename durable by syncing its directory. Not every platform
+// supports syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,92 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile_CreatesAndReplaces(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "note.md")
+
+	require.NoError(t, WriteFile(path, []byte("first"), 0644))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(content))
+
+	require.NoError(t, WriteFile(path, []byte("second"), 0644))
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFile_PreservesPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.md")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0600))
+
+	require.NoError(t, WriteFile(path, []byte("new"), 0644))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestWriteFile_PreserveModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	require.NoError(t, WriteFile(path, []byte("new"), 0644, PreserveModTime()))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(old))
+}
+
+func TestWriteFile_FollowsSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.md")
+	link := filepath.Join(tmpDir, "link.md")
+	require.NoError(t, os.WriteFile(target, []byte("old"), 0644))
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, WriteFile(link, []byte("new"), 0644))
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "link should still be a symlink")
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+}
+
+func TestCreateAtomic_AbortLeavesOriginal(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("original"), 0644))
+
+	af, err := CreateAtomic(path, 0644)
+	require.NoError(t, err)
+	_, err = af.Write([]byte("partial"))
+	require.NoError(t, err)
+	af.Abort()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content))
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
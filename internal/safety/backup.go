@@ -153,7 +153,7 @@ func (bm *BackupManager) restoreFile(backup *Backup, targetPath string) error {
 	}
 
 	// Write content to target
-	if err := os.WriteFile(targetPath, backup.Content, 0644); err != nil {
+	if err := WriteFile(targetPath, backup.Content, 0644); err != nil {
 		return fmt.Errorf("writing restored file: %w", err)
 	}
 
@@ -261,7 +261,7 @@ func (bm *BackupManager) CleanupOld(maxAge time.Duration) int {
 // writeToDisk writes a file backup to disk for persistence
 func (bm *BackupManager) writeToDisk(backup *Backup) error {
 	backupPath := filepath.Join(bm.backupDir, backup.ID+".backup")
-	return os.WriteFile(backupPath, backup.Content, 0644)
+	return WriteFile(backupPath, backup.Content, 0644)
 }
 
 // writeDirectoryToDisk writes a directory backup to disk
@@ -0,0 +1,482 @@
+package safety
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJournalDir is the change journal location relative to the vault root
+const DefaultJournalDir = ".mdnotes/journal"
+
+// DefaultJournalLimit is how many transactions are kept before the oldest are pruned
+const DefaultJournalLimit = 50
+
+// ErrNoTransaction is returned when there is no transaction left to undo
+var ErrNoTransaction = errors.New("no transaction to undo")
+
+// EntryOp is the kind of file change recorded in a journal entry
+type EntryOp string
+
+const (
+	OpModify EntryOp = "modify" // File content was rewritten
+	OpCreate EntryOp = "create" // File did not exist before the change
+	OpMove   EntryOp = "move"   // File was renamed or moved
+	OpDelete EntryOp = "delete" // File was removed
+)
+
+// JournalEntry records a single file change. Paths are relative to the vault root.
+type JournalEntry struct {
+	Op    EntryOp `json:"op"`
+	Path  string  `json:"path"`            // Path after the change
+	From  string  `json:"from,omitempty"`  // Path before a move
+	Blob  string  `json:"blob,omitempty"`  // Stored original content of a modified or deleted file
+	After string  `json:"after,omitempty"` // Content hash after the change, for conflict detection
+	// Reason says why the change was made, e.g. the automation rule that triggered it
+	Reason string `json:"reason,omitempty"`
+}
+
+// Transaction groups the file changes made by one command so they can be reverted together
+type Transaction struct {
+	ID        string         `json:"id"`
+	Command   string         `json:"command"`
+	CreatedAt time.Time      `json:"created_at"`
+	Entries   []JournalEntry `json:"entries"`
+	UndoneAt  *time.Time     `json:"undone_at,omitempty"`
+
+	journal  *Journal
+	recorded map[string]bool // paths whose original state is already captured
+	reason   string          // stamped on entries as they are recorded
+	mu       sync.Mutex
+}
+
+// Journal stores transactions under a vault's .mdnotes/journal directory.
+// Each transaction is a directory holding transaction.json and the original
+// content of every modified file, written before the file is changed.
+type Journal struct {
+	root  string
+	dir   string
+	Limit int
+}
+
+// NewJournal creates a journal for the vault at root
+func NewJournal(root string) *Journal {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Journal{
+		root:  root,
+		dir:   filepath.Join(root, DefaultJournalDir),
+		Limit: DefaultJournalLimit,
+	}
+}
+
+// FindVaultRoot returns the nearest directory at or above path containing an
+// .obsidian or .mdnotes directory, falling back to path itself (or its
+// directory, when path is a file)
+func FindVaultRoot(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		abs = filepath.Dir(abs)
+	}
+
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		for _, marker := range []string{".obsidian", ".mdnotes"} {
+			if info, err := os.Stat(filepath.Join(dir, marker)); err == nil && info.IsDir() {
+				return dir
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			return abs
+		}
+	}
+}
+
+// Begin starts a new transaction for command. Nothing is written until the
+// first change is recorded.
+func (j *Journal) Begin(command string) *Transaction {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	now := time.Now()
+	return &Transaction{
+		ID:        now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		Command:   command,
+		CreatedAt: now,
+		journal:   j,
+		recorded:  make(map[string]bool),
+	}
+}
+
+// SetReason sets the reason recorded with subsequent entries, or clears it
+// when reason is empty
+func (t *Transaction) SetReason(reason string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reason = reason
+}
+
+// RecordWrite captures the current content of path before it is overwritten.
+// Only the first write to a path within a transaction is recorded.
+func (t *Transaction) RecordWrite(path string) error {
+	if t == nil {
+		return nil
+	}
+	rel, err := t.journal.rel(path)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.recorded[rel] {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(t.journal.root, rel))
+	if os.IsNotExist(err) {
+		t.Entries = append(t.Entries, JournalEntry{Op: OpCreate, Path: rel, Reason: t.reason})
+		t.recorded[rel] = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s for journal: %w", rel, err)
+	}
+
+	blob, err := t.writeBlob(content)
+	if err != nil {
+		return err
+	}
+	t.Entries = append(t.Entries, JournalEntry{Op: OpModify, Path: rel, Blob: blob, Reason: t.reason})
+	t.recorded[rel] = true
+	return nil
+}
+
+// RecordMove records that from is about to be moved to to
+func (t *Transaction) RecordMove(from, to string) error {
+	if t == nil {
+		return nil
+	}
+	relFrom, err := t.journal.rel(from)
+	if err != nil {
+		return err
+	}
+	relTo, err := t.journal.rel(to)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Entries = append(t.Entries, JournalEntry{Op: OpMove, Path: relTo, From: relFrom, Reason: t.reason})
+	// Later writes to either path are recorded against the post-move state
+	delete(t.recorded, relFrom)
+	delete(t.recorded, relTo)
+	return nil
+}
+
+// RecordDelete captures the content of path before it is removed
+func (t *Transaction) RecordDelete(path string) error {
+	if t == nil {
+		return nil
+	}
+	rel, err := t.journal.rel(path)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filepath.Join(t.journal.root, rel))
+	if err != nil {
+		return fmt.Errorf("reading %s for journal: %w", rel, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	blob, err := t.writeBlob(content)
+	if err != nil {
+		return err
+	}
+	t.Entries = append(t.Entries, JournalEntry{Op: OpDelete, Path: rel, Blob: blob, Reason: t.reason})
+	delete(t.recorded, rel)
+	return nil
+}
+
+// Commit finalizes the transaction, recording the resulting content hashes.
+// Transactions without changes are discarded.
+func (t *Transaction) Commit() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.Entries) == 0 {
+		_ = os.RemoveAll(t.path())
+		return nil
+	}
+
+	for i := range t.Entries {
+		entry := &t.Entries[i]
+		entry.After = hashFile(filepath.Join(t.journal.root, entry.Path))
+	}
+
+	if err := t.save(); err != nil {
+		return err
+	}
+	return t.journal.prune()
+}
+
+// List returns the journal's transactions, newest first
+func (j *Journal) List() ([]*Transaction, error) {
+	dirs, err := os.ReadDir(j.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+
+	var transactions []*Transaction
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		tx, err := j.Load(d.Name())
+		if err != nil {
+			continue // incomplete or damaged transaction
+		}
+		transactions = append(transactions, tx)
+	}
+
+	sort.Slice(transactions, func(a, b int) bool {
+		return transactions[a].CreatedAt.After(transactions[b].CreatedAt)
+	})
+	return transactions, nil
+}
+
+// Load reads the transaction with the given ID
+func (j *Journal) Load(id string) (*Transaction, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return nil, fmt.Errorf("invalid transaction ID %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(j.dir, id, "transaction.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("transaction %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading transaction %s: %w", id, err)
+	}
+
+	tx := &Transaction{journal: j}
+	if err := json.Unmarshal(data, tx); err != nil {
+		return nil, fmt.Errorf("parsing transaction %s: %w", id, err)
+	}
+	return tx, nil
+}
+
+// Last returns the most recent transaction that has not been undone
+func (j *Journal) Last() (*Transaction, error) {
+	transactions, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range transactions {
+		if tx.UndoneAt == nil {
+			return tx, nil
+		}
+	}
+	return nil, ErrNoTransaction
+}
+
+// Root returns the vault root the transaction's paths are relative to
+func (t *Transaction) Root() string {
+	return t.journal.root
+}
+
+// Original returns the content a modified or deleted file had before the
+// transaction
+func (t *Transaction) Original(entry JournalEntry) ([]byte, error) {
+	if entry.Op != OpModify && entry.Op != OpDelete {
+		return nil, fmt.Errorf("%s has no stored original", entry.Path)
+	}
+	return os.ReadFile(filepath.Join(t.path(), "blobs", entry.Blob))
+}
+
+// Conflicts returns the paths changed since the transaction was committed.
+// Undoing over them would discard those later edits.
+func (t *Transaction) Conflicts() []string {
+	var conflicts []string
+	for _, entry := range t.Entries {
+		if hashFile(filepath.Join(t.journal.root, entry.Path)) != entry.After {
+			conflicts = append(conflicts, entry.Path)
+			continue
+		}
+		if entry.Op == OpMove && entry.From != entry.Path {
+			if _, err := os.Stat(filepath.Join(t.journal.root, entry.From)); err == nil {
+				conflicts = append(conflicts, entry.From)
+			}
+		}
+	}
+	return conflicts
+}
+
+// Undo reverts the transaction's changes in reverse order. Files modified since
+// the transaction are left alone unless force is set.
+func (j *Journal) Undo(tx *Transaction, force bool) error {
+	if tx.UndoneAt != nil {
+		return fmt.Errorf("transaction %s was already undone", tx.ID)
+	}
+	if !force {
+		if conflicts := tx.Conflicts(); len(conflicts) > 0 {
+			return fmt.Errorf("files changed since transaction %s: %s (use --force to undo anyway)",
+				tx.ID, strings.Join(conflicts, ", "))
+		}
+	}
+
+	var errs []string
+	for i := len(tx.Entries) - 1; i >= 0; i-- {
+		if err := j.revert(tx, tx.Entries[i]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	now := time.Now()
+	tx.UndoneAt = &now
+	if err := tx.save(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("undoing transaction %s: %s", tx.ID, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// revert restores a single entry to its state before the transaction
+func (j *Journal) revert(tx *Transaction, entry JournalEntry) error {
+	path := filepath.Join(j.root, entry.Path)
+	switch entry.Op {
+	case OpModify:
+		content, err := tx.Original(entry)
+		if err != nil {
+			return fmt.Errorf("reading original of %s: %w", entry.Path, err)
+		}
+		if err := WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("restoring %s: %w", entry.Path, err)
+		}
+	case OpCreate:
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing %s: %w", entry.Path, err)
+		}
+	case OpDelete:
+		content, err := tx.Original(entry)
+		if err != nil {
+			return fmt.Errorf("reading original of %s: %w", entry.Path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", entry.Path, err)
+		}
+		if err := WriteFile(path, content, 0644); err != nil {
+			return fmt.Errorf("restoring %s: %w", entry.Path, err)
+		}
+	case OpMove:
+		from := filepath.Join(j.root, entry.From)
+		if err := os.MkdirAll(filepath.Dir(from), 0755); err != nil {
+			return fmt.Errorf("creating directory for %s: %w", entry.From, err)
+		}
+		if err := os.Rename(path, from); err != nil {
+			return fmt.Errorf("moving %s back to %s: %w", entry.Path, entry.From, err)
+		}
+	default:
+		return fmt.Errorf("unknown journal operation %q", entry.Op)
+	}
+	return nil
+}
+
+// rel converts path to a vault-relative path, rejecting paths outside the vault
+func (j *Journal) rel(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(j.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the vault %s", path, j.root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// prune removes the oldest transactions beyond the journal limit
+func (j *Journal) prune() error {
+	if j.Limit <= 0 {
+		return nil
+	}
+	transactions, err := j.List()
+	if err != nil {
+		return err
+	}
+	for _, tx := range transactions[min(j.Limit, len(transactions)):] {
+		if err := os.RemoveAll(tx.path()); err != nil {
+			return fmt.Errorf("pruning transaction %s: %w", tx.ID, err)
+		}
+	}
+	return nil
+}
+
+func (t *Transaction) path() string {
+	return filepath.Join(t.journal.dir, t.ID)
+}
+
+func (t *Transaction) save() error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding transaction: %w", err)
+	}
+	if err := os.MkdirAll(t.path(), 0755); err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+	if err := WriteFile(filepath.Join(t.path(), "transaction.json"), data, 0644); err != nil {
+		return fmt.Errorf("writing transaction: %w", err)
+	}
+	return nil
+}
+
+// writeBlob stores content under its hash, so identical originals are kept once
+func (t *Transaction) writeBlob(content []byte) (string, error) {
+	blob := hashBytes(content)
+	dir := filepath.Join(t.path(), "blobs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating journal directory: %w", err)
+	}
+	if err := WriteFile(filepath.Join(dir, blob), content, 0644); err != nil {
+		return "", fmt.Errorf("writing journal blob: %w", err)
+	}
+	return blob, nil
+}
+
+func hashBytes(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the content hash of path, or "" if it doesn't exist
+func hashFile(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return hashBytes(content)
+}
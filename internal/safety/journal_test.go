@@ -0,0 +1,173 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJournalFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func readJournalFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestJournal_UndoRestoresModifiedAndCreatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.md")
+	created := filepath.Join(dir, "new.md")
+	writeJournalFile(t, note, "original")
+
+	journal := NewJournal(dir)
+	tx := journal.Begin("mdnotes frontmatter set")
+	require.NoError(t, tx.RecordWrite(note))
+	writeJournalFile(t, note, "first edit")
+	require.NoError(t, tx.RecordWrite(note)) // later writes keep the first original
+	writeJournalFile(t, note, "second edit")
+	require.NoError(t, tx.RecordWrite(created))
+	writeJournalFile(t, created, "created")
+	require.NoError(t, tx.Commit())
+
+	last, err := journal.Last()
+	require.NoError(t, err)
+	assert.Equal(t, tx.ID, last.ID)
+	assert.Len(t, last.Entries, 2)
+
+	require.NoError(t, journal.Undo(last, false))
+	assert.Equal(t, "original", readJournalFile(t, note))
+	assert.NoFileExists(t, created)
+
+	// An undone transaction is not offered again
+	_, err = journal.Last()
+	assert.ErrorIs(t, err, ErrNoTransaction)
+	assert.Error(t, journal.Undo(last, false))
+}
+
+func TestJournal_UndoMove(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "old.md")
+	target := filepath.Join(dir, "sub", "new.md")
+	linking := filepath.Join(dir, "index.md")
+	writeJournalFile(t, source, "note")
+	writeJournalFile(t, linking, "[[old]]")
+
+	journal := NewJournal(dir)
+	tx := journal.Begin("mdnotes rename")
+	require.NoError(t, tx.RecordWrite(linking))
+	writeJournalFile(t, linking, "[[new]]")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	require.NoError(t, tx.RecordMove(source, target))
+	require.NoError(t, os.Rename(source, target))
+	require.NoError(t, tx.Commit())
+
+	loaded, err := journal.Load(tx.ID)
+	require.NoError(t, err)
+	require.NoError(t, journal.Undo(loaded, false))
+
+	assert.Equal(t, "note", readJournalFile(t, source))
+	assert.NoFileExists(t, target)
+	assert.Equal(t, "[[old]]", readJournalFile(t, linking))
+}
+
+func TestJournal_UndoRefusesConflicts(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.md")
+	writeJournalFile(t, note, "original")
+
+	journal := NewJournal(dir)
+	tx := journal.Begin("mdnotes headings fix")
+	require.NoError(t, tx.RecordWrite(note))
+	writeJournalFile(t, note, "fixed")
+	require.NoError(t, tx.Commit())
+
+	// Edited again after the transaction
+	writeJournalFile(t, note, "edited by hand")
+	assert.Equal(t, []string{"note.md"}, tx.Conflicts())
+
+	err := journal.Undo(tx, false)
+	assert.ErrorContains(t, err, "note.md")
+	assert.Equal(t, "edited by hand", readJournalFile(t, note))
+
+	require.NoError(t, journal.Undo(tx, true))
+	assert.Equal(t, "original", readJournalFile(t, note))
+}
+
+func TestJournal_EmptyTransactionsAndPruning(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.md")
+	writeJournalFile(t, note, "v0")
+
+	journal := NewJournal(dir)
+	journal.Limit = 2
+
+	require.NoError(t, journal.Begin("noop").Commit())
+	transactions, err := journal.List()
+	require.NoError(t, err)
+	assert.Empty(t, transactions)
+
+	var ids []string
+	for _, content := range []string{"v1", "v2", "v3"} {
+		tx := journal.Begin("edit")
+		require.NoError(t, tx.RecordWrite(note))
+		writeJournalFile(t, note, content)
+		require.NoError(t, tx.Commit())
+		ids = append(ids, tx.ID)
+	}
+
+	transactions, err = journal.List()
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	assert.NotContains(t, []string{transactions[0].ID, transactions[1].ID}, ids[0])
+}
+
+func TestJournal_RejectsPathsOutsideVault(t *testing.T) {
+	vault := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "other.md")
+	writeJournalFile(t, outside, "x")
+
+	tx := NewJournal(vault).Begin("edit")
+	assert.Error(t, tx.RecordWrite(outside))
+
+	_, err := NewJournal(vault).Load("../escape")
+	assert.Error(t, err)
+
+	// A nil transaction (dry run) records nothing
+	var none *Transaction
+	assert.NoError(t, none.RecordWrite(outside))
+	assert.NoError(t, none.Commit())
+}
+
+func TestFindVaultRoot(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".obsidian"), 0755))
+	writeJournalFile(t, filepath.Join(dir, "notes", "deep", "a.md"), "a")
+
+	assert.Equal(t, dir, FindVaultRoot(filepath.Join(dir, "notes", "deep")))
+	assert.Equal(t, dir, FindVaultRoot(filepath.Join(dir, "notes", "deep", "a.md")))
+}
+
+func TestJournal_UndoDelete(t *testing.T) {
+	dir := t.TempDir()
+	removed := filepath.Join(dir, "sub", "copy.md")
+	writeJournalFile(t, removed, "duplicate")
+
+	journal := NewJournal(dir)
+	tx := journal.Begin("mdnotes duplicates resolve")
+	require.NoError(t, tx.RecordDelete(removed))
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "sub")))
+	require.NoError(t, tx.Commit())
+
+	assert.Empty(t, tx.Conflicts())
+	require.NoError(t, journal.Undo(tx, false))
+	assert.Equal(t, "duplicate", readJournalFile(t, removed))
+}
@@ -0,0 +1,250 @@
+package safety
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SandboxChangeKind describes how a file differs between a vault and its sandbox
+type SandboxChangeKind string
+
+const (
+	SandboxAdded    SandboxChangeKind = "added"
+	SandboxModified SandboxChangeKind = "modified"
+	SandboxDeleted  SandboxChangeKind = "deleted"
+	SandboxRenamed  SandboxChangeKind = "renamed"
+)
+
+// SandboxChange is a file that differs between a vault and its sandbox. Paths
+// are relative to the vault root; From is set for renames.
+type SandboxChange struct {
+	Kind SandboxChangeKind `json:"kind"`
+	Path string            `json:"path"`
+	From string            `json:"from,omitempty"`
+}
+
+// Sandbox is a disposable shadow copy of a vault that operations can be run
+// against without touching the vault itself.
+//
+// Markdown files and everything in dot-directories (.obsidian, .mdnotes) are
+// copied, since mdnotes rewrites them in place. Other files are attachments,
+// which mdnotes only moves or deletes, so they are hard-linked when the temp
+// directory is on the same filesystem. .git is not copied.
+type Sandbox struct {
+	root   string
+	shadow string
+	tmpDir string
+}
+
+// NewSandbox copies the vault at root into a new temporary directory
+func NewSandbox(root string) (*Sandbox, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolving vault path: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "mdnotes-sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating sandbox directory: %w", err)
+	}
+	s := &Sandbox{root: abs, shadow: filepath.Join(tmpDir, filepath.Base(abs)), tmpDir: tmpDir}
+
+	if err := s.populate(); err != nil {
+		_ = s.Close()
+		return nil, fmt.Errorf("copying vault into sandbox: %w", err)
+	}
+	return s, nil
+}
+
+// Root returns the absolute path of the original vault
+func (s *Sandbox) Root() string {
+	return s.root
+}
+
+// Shadow returns the absolute path of the sandbox copy of the vault
+func (s *Sandbox) Shadow() string {
+	return s.shadow
+}
+
+// Path maps a path inside the vault to the same path inside the sandbox. ok
+// is false for paths outside the vault, which are returned unchanged.
+func (s *Sandbox) Path(path string) (mapped string, ok bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path, false
+	}
+	rel, err := filepath.Rel(s.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return filepath.Join(s.shadow, rel), true
+}
+
+// Close removes the sandbox
+func (s *Sandbox) Close() error {
+	return os.RemoveAll(s.tmpDir)
+}
+
+func (s *Sandbox) populate() error {
+	return filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(s.shadow, rel)
+
+		switch {
+		case d.IsDir():
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(path), ".md") && !inDotDir(rel) {
+			if err := os.Link(path, target); err == nil {
+				return nil
+			}
+		}
+		return copyRegularFile(path, target)
+	})
+}
+
+// Changes compares the sandbox with the vault, pairing deleted and added
+// files with identical content as renames. Files under .mdnotes, which holds
+// mdnotes' own bookkeeping, are not compared.
+func (s *Sandbox) Changes() ([]SandboxChange, error) {
+	before, err := hashTree(s.root)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault: %w", err)
+	}
+	after, err := hashTree(s.shadow)
+	if err != nil {
+		return nil, fmt.Errorf("reading sandbox: %w", err)
+	}
+
+	var changes []SandboxChange
+	var deleted []string
+	addedByHash := make(map[string][]string)
+	for path, hash := range before {
+		newHash, ok := after[path]
+		switch {
+		case !ok:
+			deleted = append(deleted, path)
+		case newHash != hash:
+			changes = append(changes, SandboxChange{Kind: SandboxModified, Path: path})
+		}
+	}
+	var added []string
+	for path, hash := range after {
+		if _, ok := before[path]; !ok {
+			added = append(added, path)
+			addedByHash[hash] = append(addedByHash[hash], path)
+		}
+	}
+	sort.Strings(deleted)
+	sort.Strings(added)
+	for _, paths := range addedByHash {
+		sort.Strings(paths)
+	}
+
+	renamed := make(map[string]bool)
+	for _, path := range deleted {
+		candidates := addedByHash[before[path]]
+		if len(candidates) == 0 {
+			changes = append(changes, SandboxChange{Kind: SandboxDeleted, Path: path})
+			continue
+		}
+		addedByHash[before[path]] = candidates[1:]
+		renamed[candidates[0]] = true
+		changes = append(changes, SandboxChange{Kind: SandboxRenamed, Path: candidates[0], From: path})
+	}
+	for _, path := range added {
+		if !renamed[path] {
+			changes = append(changes, SandboxChange{Kind: SandboxAdded, Path: path})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// hashTree returns the content hash of every regular file under root, keyed
+// by slash-separated relative path
+func hashTree(root string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (d.Name() == ".git" || d.Name() == ".mdnotes") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(rel)] = hashFile(path)
+		return nil
+	})
+	return hashes, err
+}
+
+// inDotDir reports whether a relative path lies inside a hidden directory
+func inDotDir(rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func copyRegularFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// Keep modification times, which some operations sort or filter by
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,65 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandbox_ChangesLeaveVaultUntouched(t *testing.T) {
+	root := t.TempDir()
+	write := func(dir, name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(root, "edit.md", "before")
+	write(root, "old.md", "moved content")
+	write(root, "gone.md", "deleted")
+	write(root, "assets/image.png", "png")
+	write(root, ".mdnotes/index.gob", "index")
+	write(root, ".git/HEAD", "ref")
+
+	sandbox, err := NewSandbox(root)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sandbox.Close() })
+	shadow := sandbox.Shadow()
+	assert.NoDirExists(t, filepath.Join(shadow, ".git"))
+	assert.FileExists(t, filepath.Join(shadow, "assets/image.png"))
+
+	mapped, ok := sandbox.Path(filepath.Join(root, "edit.md"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(shadow, "edit.md"), mapped)
+	_, ok = sandbox.Path(filepath.Dir(root))
+	assert.False(t, ok)
+
+	write(shadow, "edit.md", "after")
+	require.NoError(t, os.Mkdir(filepath.Join(shadow, "notes"), 0755))
+	require.NoError(t, os.Rename(filepath.Join(shadow, "old.md"), filepath.Join(shadow, "notes/new.md")))
+	require.NoError(t, os.Remove(filepath.Join(shadow, "gone.md")))
+	require.NoError(t, os.Remove(filepath.Join(shadow, "assets/image.png")))
+	write(shadow, "added.md", "new")
+	write(shadow, ".mdnotes/index.gob", "rebuilt")
+
+	changes, err := sandbox.Changes()
+	require.NoError(t, err)
+	assert.Equal(t, []SandboxChange{
+		{Kind: SandboxAdded, Path: "added.md"},
+		{Kind: SandboxDeleted, Path: "assets/image.png"},
+		{Kind: SandboxModified, Path: "edit.md"},
+		{Kind: SandboxDeleted, Path: "gone.md"},
+		{Kind: SandboxRenamed, Path: "notes/new.md", From: "old.md"},
+	}, changes)
+
+	content, err := os.ReadFile(filepath.Join(root, "edit.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "before", string(content))
+	assert.FileExists(t, filepath.Join(root, "old.md"))
+	assert.FileExists(t, filepath.Join(root, "assets/image.png"))
+
+	require.NoError(t, sandbox.Close())
+	assert.NoDirExists(t, shadow)
+}
@@ -0,0 +1,151 @@
+// Package secrets keeps integration credentials, such as API tokens, in the
+// operating system's keychain rather than in config files that may be synced
+// along with a vault.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Service is the keychain service secrets are stored under
+const Service = "mdnotes"
+
+// ErrNotFound is returned for a secret that isn't in the store
+var ErrNotFound = errors.New("secret not found")
+
+// Store reads and writes named secrets
+type Store interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+}
+
+// Default is the store config references and 'config set-secret' use
+var Default Store = Keychain{}
+
+// Keychain stores secrets in the OS keychain: the login keychain on macOS,
+// through the security tool, and the Secret Service (GNOME Keyring, KWallet)
+// elsewhere, through secret-tool from libsecret. Windows isn't supported.
+type Keychain struct {
+	goos string // Overrides runtime.GOOS, for tests
+}
+
+func (k Keychain) os() string {
+	if k.goos != "" {
+		return k.goos
+	}
+	return runtime.GOOS
+}
+
+// Get returns the named secret from the keychain
+func (k Keychain) Get(name string) (string, error) {
+	var out string
+	var err error
+	switch k.os() {
+	case "darwin":
+		out, _, err = run("", "security", "find-generic-password", "-s", Service, "-a", name, "-w")
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrNotFound
+		}
+	case "windows":
+		return "", errUnsupported
+	default:
+		var stderr string
+		out, stderr, err = run("", "secret-tool", "lookup", "service", Service, "account", name)
+		// secret-tool exits with an error and prints nothing for a missing
+		// secret, and explains any other failure on standard error
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && out == "" && stderr == "" {
+			return "", ErrNotFound
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Set stores the named secret in the keychain, replacing any earlier value.
+// The secret is passed on standard input, never as an argument, where it
+// would show in the process list.
+func (k Keychain) Set(name, value string) error {
+	switch k.os() {
+	case "darwin":
+		// security takes the password only as an argument, or from a
+		// terminal prompt, so the command is given to its interactive mode
+		// on standard input. A failed command there is reported on standard
+		// error, without necessarily a failing exit status.
+		if strings.Contains(value, "\n") {
+			return fmt.Errorf("security: a secret can't contain a line break")
+		}
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(Service), quote(name), quote(value))
+		_, stderr, err := run(command, "security", "-i")
+		if err == nil && stderr != "" {
+			err = fmt.Errorf("security: %s", stderr)
+		}
+		return err
+	case "windows":
+		return errUnsupported
+	default:
+		// secret-tool reads the secret from standard input
+		_, _, err := run(value, "secret-tool", "store", "--label", Service+": "+name, "service", Service, "account", name)
+		return err
+	}
+}
+
+// quote quotes s as a single argument for security's interactive mode
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+var errUnsupported = fmt.Errorf("the keychain isn't supported on %s; use an environment variable reference such as ${LINKDING_TOKEN} instead", runtime.GOOS)
+
+// run runs a keychain tool with input on its standard input, returning its
+// standard output and, trimmed, its standard error
+func run(input, tool string, args ...string) (string, string, error) {
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		if tool == "secret-tool" {
+			return "", "", fmt.Errorf("secret-tool not found; install libsecret-tools (or your distribution's libsecret package), or use an environment variable reference instead")
+		}
+		return "", "", fmt.Errorf("%s not found: %w", tool, err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	msg := strings.TrimSpace(stderr.String())
+	if err != nil {
+		if msg != "" {
+			return stdout.String(), msg, fmt.Errorf("%s: %s: %w", tool, msg, err)
+		}
+		return stdout.String(), msg, fmt.Errorf("%s: %w", tool, err)
+	}
+	return stdout.String(), msg, nil
+}
+
+// Memory is a Store held in memory, for tests
+type Memory map[string]string
+
+// Get returns the named secret
+func (m Memory) Get(name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores the named secret
+func (m Memory) Set(name, value string) error {
+	m[name] = value
+	return nil
+}
@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTool puts a shell script named tool first on PATH. The script saves its
+// arguments and standard input in the returned dir before running body.
+func fakeTool(t *testing.T, tool, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\ncat > " + filepath.Join(dir, "stdin") + "\n" + body + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, tool), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestKeychain_SecretTool(t *testing.T) {
+	keychain := Keychain{goos: "linux"}
+
+	fakeTool(t, "secret-tool", `[ "$5" = "known" ] && printf 'tok-123\n' && exit 0; exit 1`)
+	value, err := keychain.Get("known")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-123", value)
+	_, err = keychain.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	fakeTool(t, "secret-tool", `echo "no D-Bus session" >&2; exit 1`)
+	_, err = keychain.Get("known")
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.ErrorContains(t, err, "secret-tool: no D-Bus session")
+
+	dir := fakeTool(t, "secret-tool", "")
+	require.NoError(t, keychain.Set("linkding.token", "s3cret"))
+	assert.Equal(t, "s3cret", readFile(t, filepath.Join(dir, "stdin")))
+	assert.NotContains(t, readFile(t, filepath.Join(dir, "args")), "s3cret")
+
+	t.Setenv("PATH", t.TempDir())
+	_, err = keychain.Get("known")
+	assert.ErrorContains(t, err, "secret-tool not found")
+}
+
+func TestKeychain_Security(t *testing.T) {
+	keychain := Keychain{goos: "darwin"}
+
+	fakeTool(t, "security", `[ "$5" = "known" ] && printf 'tok-123\n' && exit 0; echo "The specified item could not be found in the keychain." >&2; exit 44`)
+	value, err := keychain.Get("known")
+	require.NoError(t, err)
+	assert.Equal(t, "tok-123", value)
+	_, err = keychain.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	fakeTool(t, "security", `echo "User interaction is not allowed." >&2; exit 36`)
+	_, err = keychain.Get("known")
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.ErrorContains(t, err, "security: User interaction is not allowed.")
+
+	dir := fakeTool(t, "security", "")
+	require.NoError(t, keychain.Set("linkding.token", `s3"cret`))
+	assert.Equal(t, "-i\n", readFile(t, filepath.Join(dir, "args")))
+	assert.Equal(t, `add-generic-password -U -s "mdnotes" -a "linkding.token" -w "s3\"cret"`+"\n", readFile(t, filepath.Join(dir, "stdin")))
+
+	// Interactive mode reports a failed command without a failing status
+	fakeTool(t, "security", `echo "security: SecKeychainItemCreateFromContent: write permissions error." >&2`)
+	assert.ErrorContains(t, keychain.Set("linkding.token", "s3cret"), "write permissions error")
+}
@@ -2,6 +2,7 @@ package selector
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -31,8 +32,11 @@ const (
 // FileSelector provides unified file selection across all commands
 type FileSelector struct {
 	IgnorePatterns []string
-	QueryFilter    string // Optional query to filter files
-	SourceFile     string // File path for FilesFromFile mode
+	QueryFilter    string           // Optional query to filter files
+	SourceFile     string           // File path for FilesFromFile mode
+	LinkParser     vault.LinkParser // Optional parser for links stored in the vault index
+	Index          *vault.Index     // Optional vault index to use instead of finding one
+	Workers        int              // Files parsed in parallel when scanning; below 2 scans sequentially
 }
 
 // SelectionResult contains the results of file selection
@@ -43,6 +47,11 @@ type SelectionResult struct {
 	Source      string // Description of selection source
 }
 
+// DefaultLinkParser parses links for queries that use file.links_in or
+// file.links_out when a selector has no LinkParser of its own. The command
+// layer sets it, since the parser lives in the processor package.
+var DefaultLinkParser vault.LinkParser
+
 // NewFileSelector creates a new file selector with default settings
 func NewFileSelector() *FileSelector {
 	return &FileSelector{
@@ -68,6 +77,25 @@ func (fs *FileSelector) WithSourceFile(path string) *FileSelector {
 	return fs
 }
 
+// WithWorkers sets how many files are parsed in parallel when scanning
+func (fs *FileSelector) WithWorkers(workers int) *FileSelector {
+	fs.Workers = workers
+	return fs
+}
+
+// WithLinkParser sets the parser used to index links when a vault index is in use
+func (fs *FileSelector) WithLinkParser(parser vault.LinkParser) *FileSelector {
+	fs.LinkParser = parser
+	return fs
+}
+
+// WithIndex makes scans use idx, already loaded by the caller, rather than
+// loading the vault index themselves
+func (fs *FileSelector) WithIndex(idx *vault.Index) *FileSelector {
+	fs.Index = idx
+	return fs
+}
+
 // SelectFiles selects files based on the specified mode and input
 func (fs *FileSelector) SelectFiles(input string, mode SelectionMode) (*SelectionResult, error) {
 	switch mode {
@@ -84,6 +112,62 @@ func (fs *FileSelector) SelectFiles(input string, mode SelectionMode) (*Selectio
 	}
 }
 
+// StreamFiles selects files like SelectFiles but hands them to fn one at a
+// time. Scanning a whole directory without a query streams files as they are
+// parsed, so the vault never has to be held in memory at once; other modes
+// select the files first and then hand them over. fn returning an error stops
+// the selection with that error.
+func (fs *FileSelector) StreamFiles(ctx context.Context, input string, mode SelectionMode, fn func(*vault.VaultFile) error) ([]vault.ParseError, error) {
+	if mode == AutoDetect && fs.QueryFilter == "" {
+		if info, err := os.Stat(input); err == nil && info.IsDir() {
+			return fs.streamDirectory(ctx, input, fn)
+		}
+	}
+
+	selection, err := fs.SelectFiles(input, mode)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range selection.Files {
+		if err := fn(file); err != nil {
+			return selection.ParseErrors, err
+		}
+	}
+	return selection.ParseErrors, nil
+}
+
+// streamDirectory hands each markdown file under path to fn as it is parsed
+func (fs *FileSelector) streamDirectory(ctx context.Context, path string, fn func(*vault.VaultFile) error) ([]vault.ParseError, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	idx := fs.findIndex(path)
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(fs.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(idx),
+		vault.WithWorkers(fs.Workers),
+	)
+
+	var fnErr error
+	for file := range scanner.Iter(ctx, path) {
+		if fnErr != nil {
+			continue // drain the scan after cancelling it
+		}
+		if fnErr = fn(file); fnErr != nil {
+			cancel()
+		}
+	}
+	if fnErr != nil {
+		return scanner.GetParseErrors(), fnErr
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanning directory: %w", err)
+	}
+	saveIndex(idx)
+	return scanner.GetParseErrors(), nil
+}
+
 // selectAutoDetect automatically detects if input is file or directory
 func (fs *FileSelector) selectAutoDetect(path string) (*SelectionResult, error) {
 	// Check if path exists
@@ -98,14 +182,18 @@ func (fs *FileSelector) selectAutoDetect(path string) (*SelectionResult, error)
 
 	if info.IsDir() {
 		// Scan directory
+		idx := fs.findIndex(path)
 		scanner := vault.NewScanner(
 			vault.WithIgnorePatterns(fs.IgnorePatterns),
 			vault.WithContinueOnErrors(),
+			vault.WithIndex(idx),
+			vault.WithWorkers(fs.Workers),
 		)
 		files, err = scanner.Walk(path)
 		if err != nil {
 			return nil, fmt.Errorf("scanning directory: %w", err)
 		}
+		saveIndex(idx)
 		parseErrors = scanner.GetParseErrors()
 		source = fmt.Sprintf("directory: %s", path)
 	} else {
@@ -147,14 +235,18 @@ func (fs *FileSelector) selectFromQuery(path string) (*SelectionResult, error) {
 	}
 
 	// First scan all files in the path
+	idx := fs.findIndex(path)
 	scanner := vault.NewScanner(
 		vault.WithIgnorePatterns(fs.IgnorePatterns),
 		vault.WithContinueOnErrors(),
+		vault.WithIndex(idx),
+		vault.WithWorkers(fs.Workers),
 	)
 	allFiles, err := scanner.Walk(path)
 	if err != nil {
 		return nil, fmt.Errorf("scanning directory for query: %w", err)
 	}
+	saveIndex(idx)
 
 	// Apply query filter
 	filteredFiles, err := fs.applyQueryFilter(allFiles)
@@ -258,6 +350,29 @@ func (fs *FileSelector) selectFromReader(reader io.Reader, sourceName string, mo
 	}, nil
 }
 
+// findIndex returns the vault index covering path, if one has been built
+func (fs *FileSelector) findIndex(path string) *vault.Index {
+	idx := fs.Index
+	if idx == nil {
+		idx = vault.FindIndex(path)
+	}
+	if idx != nil && fs.LinkParser != nil {
+		idx.SetLinkParser(fs.LinkParser)
+	}
+	return idx
+}
+
+// saveIndex persists files re-parsed during a scan. The index is only a cache,
+// so failing to save it is reported as a warning.
+func saveIndex(idx *vault.Index) {
+	if idx == nil {
+		return
+	}
+	if err := idx.Save(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
 // loadSingleFile loads and parses a single markdown file
 func (fs *FileSelector) loadSingleFile(path string) (*vault.VaultFile, error) {
 	content, err := os.ReadFile(path)
@@ -302,10 +417,15 @@ func (fs *FileSelector) applyQueryFilter(files []*vault.VaultFile) ([]*vault.Vau
 
 	// Parse the query expression
 	parser := query.NewParser(fs.QueryFilter)
-	expr, err := parser.Parse()
+	expr, err := parser.Compile()
 	if err != nil {
 		return nil, fmt.Errorf("parsing query expression: %w", err)
 	}
+	linkParser := fs.LinkParser
+	if linkParser == nil {
+		linkParser = DefaultLinkParser
+	}
+	query.Bind(expr, files, linkParser)
 
 	// Filter files that match the query
 	var filteredFiles []*vault.VaultFile
@@ -350,6 +470,7 @@ func GetGlobalSelectionConfig(cmd *cobra.Command) (SelectionMode, *FileSelector,
 	fromFile, _ := cmd.Root().PersistentFlags().GetString("from-file")
 	fromStdin, _ := cmd.Root().PersistentFlags().GetBool("from-stdin")
 	ignorePatterns, _ := cmd.Root().PersistentFlags().GetStringSlice("ignore")
+	workers, _ := cmd.Root().PersistentFlags().GetInt("workers")
 
 	// Determine selection mode based on flags
 	mode := AutoDetect
@@ -365,7 +486,8 @@ func GetGlobalSelectionConfig(cmd *cobra.Command) (SelectionMode, *FileSelector,
 	fileSelector := NewFileSelector().
 		WithIgnorePatterns(ignorePatterns).
 		WithQuery(query).
-		WithSourceFile(fromFile)
+		WithSourceFile(fromFile).
+		WithWorkers(workers)
 
 	return mode, fileSelector, nil
 }
@@ -1,6 +1,8 @@
 package selector
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -278,3 +280,39 @@ func TestSelectionResult_GetSelectionSummary(t *testing.T) {
 	assert.Contains(t, summary, "directory: /test")
 	assert.Contains(t, summary, "2 parse errors")
 }
+
+func TestFileSelector_StreamFiles(t *testing.T) {
+	tmpDir := createTestDir(t)
+	createTestFile(t, tmpDir, "a.md", "---\nstatus: draft\n---\n# A")
+	createTestFile(t, tmpDir, "b.md", "---\nstatus: done\n---\n# B")
+	createTestFile(t, tmpDir, "broken.md", "---\ntitle: [broken\n---\n")
+	createTestFile(t, tmpDir, "c.md", "---\nstatus: draft\n---\n# C")
+
+	var streamed []string
+	parseErrors, err := NewFileSelector().WithWorkers(2).StreamFiles(context.Background(), tmpDir, AutoDetect, func(file *vault.VaultFile) error {
+		streamed = append(streamed, file.RelativePath)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.md", "b.md", "c.md"}, streamed)
+	require.Len(t, parseErrors, 1)
+	assert.Equal(t, "broken.md", parseErrors[0].Path)
+
+	// Queries fall back to selecting first
+	streamed = nil
+	_, err = NewFileSelector().WithQuery("status = 'draft'").StreamFiles(context.Background(), tmpDir, AutoDetect, func(file *vault.VaultFile) error {
+		streamed = append(streamed, file.RelativePath)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.md", "c.md"}, streamed)
+
+	stop := errors.New("stop")
+	calls := 0
+	_, err = NewFileSelector().StreamFiles(context.Background(), tmpDir, AutoDetect, func(file *vault.VaultFile) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
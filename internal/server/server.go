@@ -0,0 +1,565 @@
+// Package server exposes a vault over a local HTTP/JSON API, so dashboards
+// and scripts can read stats, run queries, fetch the link graph and edit notes
+// without running the CLI for every request.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/processor"
+	"github.com/eoinhurrell/mdnotes/internal/query"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Options configures a Server
+type Options struct {
+	Root           string   // Vault root directory
+	Token          string   // Bearer token required on every request; empty disables authentication
+	ReadOnly       bool     // Reject requests that change files
+	IgnorePatterns []string // Vault scan ignore patterns
+	Workers        int      // Scan workers; 0 uses the scanner default
+
+	// ResolveQuery expands saved query references ("@name"); nil leaves
+	// expressions as given
+	ResolveQuery func(string) (string, error)
+
+	// Commit finalizes the journal transaction of a request that changed
+	// files; nil commits it directly
+	Commit func(tx *safety.Transaction)
+}
+
+// Server is the HTTP handler for the vault API
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// New creates a server for the vault described by opts
+func New(opts Options) *Server {
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /api/stats", s.handleStats)
+	s.mux.HandleFunc("GET /api/query", s.handleQuery)
+	s.mux.HandleFunc("GET /api/graph", s.handleGraph)
+	s.mux.HandleFunc("GET /api/files", s.handleListFiles)
+	s.mux.HandleFunc("GET /api/files/{path...}", s.handleGetFile)
+	s.mux.HandleFunc("PUT /api/files/{path...}", s.handlePutFile)
+	s.mux.HandleFunc("PATCH /api/files/{path...}", s.handlePatchFile)
+	s.mux.HandleFunc("DELETE /api/files/{path...}", s.handleDeleteFile)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mdnotes"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized checks the request's bearer token in constant time
+func (s *Server) authorized(r *http.Request) bool {
+	if s.opts.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+// FileResponse is a note as returned by the files endpoints
+type FileResponse struct {
+	Path        string                 `json:"path"`
+	Frontmatter map[string]interface{} `json:"frontmatter"`
+	Body        string                 `json:"body,omitempty"`
+	Modified    time.Time              `json:"modified"`
+	Locked      bool                   `json:"locked,omitempty"`
+}
+
+// FileRequest is the body of PUT and PATCH requests on a note. PUT replaces
+// the note with Content, or with Frontmatter and Body when Content is empty.
+// PATCH sets the Frontmatter fields given, removes those listed in Remove and
+// replaces the body only when Body is present.
+type FileRequest struct {
+	Content     *string                `json:"content,omitempty"`
+	Frontmatter map[string]interface{} `json:"frontmatter,omitempty"`
+	Body        *string                `json:"body,omitempty"`
+	Remove      []string               `json:"remove,omitempty"`
+}
+
+// QueryResponse lists the notes matching a query
+type QueryResponse struct {
+	Query string         `json:"query"`
+	Count int            `json:"count"`
+	Files []FileResponse `json:"files"`
+}
+
+func (s *Server) scan() ([]*vault.VaultFile, error) {
+	scanner := vault.NewScanner(
+		vault.WithIgnorePatterns(s.opts.IgnorePatterns),
+		vault.WithContinueOnErrors(),
+		vault.WithIndex(vault.FindIndex(s.opts.Root)),
+		vault.WithWorkers(s.opts.Workers),
+	)
+	files, err := scanner.Walk(s.opts.Root)
+	if err != nil {
+		return nil, fmt.Errorf("scanning vault: %w", err)
+	}
+	return files, nil
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	files, err := s.scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	writeJSON(w, http.StatusOK, ana.GenerateStats(files))
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	where := r.URL.Query().Get("where")
+	if where == "" {
+		writeError(w, http.StatusBadRequest, "missing 'where' parameter")
+		return
+	}
+	if s.opts.ResolveQuery != nil {
+		resolved, err := s.opts.ResolveQuery(where)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		where = resolved
+	}
+	expr, err := query.NewParser(where).Parse()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("parsing query: %v", err))
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit %q", value))
+			return
+		}
+	}
+	withBody := r.URL.Query().Get("body") == "true"
+
+	files, err := s.scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sortFiles(files)
+
+	resp := QueryResponse{Query: where, Files: []FileResponse{}}
+	for _, file := range files {
+		if !expr.Evaluate(file) {
+			continue
+		}
+		resp.Count++
+		if limit == 0 || len(resp.Files) < limit {
+			resp.Files = append(resp.Files, fileResponse(file, withBody))
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && !analyzer.IsGraphFormat(format) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported graph format %q (supported: json, %s)",
+			format, strings.Join(analyzer.GraphFormats, ", ")))
+		return
+	}
+	minConnections := 0
+	if value := r.URL.Query().Get("min-connections"); value != "" {
+		var err error
+		if minConnections, err = strconv.Atoi(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid min-connections %q", value))
+			return
+		}
+	}
+
+	files, err := s.scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ana := analyzer.NewAnalyzer()
+	ana.SetLinkParser(processor.NewLinkParser())
+	links := ana.AnalyzeLinks(files)
+	graph := analyzer.NewNoteGraph(files, links.Clusters, minConnections)
+
+	if format == "json" {
+		writeJSON(w, http.StatusOK, graph)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := analyzer.WriteGraph(w, graph, format); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	files, err := s.scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sortFiles(files)
+
+	resp := make([]FileResponse, 0, len(files))
+	for _, file := range files {
+		resp = append(resp, fileResponse(file, false))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request) {
+	path, rel, ok := s.notePath(w, r)
+	if !ok {
+		return
+	}
+	file, err := loadFile(path, rel)
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s not found", rel))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, fileResponse(file, true))
+}
+
+func (s *Server) handlePutFile(w http.ResponseWriter, r *http.Request) {
+	path, rel, ok := s.writablePath(w, r)
+	if !ok {
+		return
+	}
+	var req FileRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	status := http.StatusOK
+	existing, err := loadFile(path, rel)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		status = http.StatusCreated
+	case err == nil && existing.IsLocked():
+		writeError(w, http.StatusLocked, fmt.Sprintf("%s is locked (%s)", rel, existing.LockReason()))
+		return
+	}
+
+	var content []byte
+	if req.Content != nil {
+		content = []byte(*req.Content)
+	} else {
+		file := &vault.VaultFile{Path: path, RelativePath: rel, Frontmatter: req.Frontmatter}
+		if req.Body != nil {
+			file.Body = *req.Body
+		}
+		if content, err = file.Serialize(); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("serializing note: %v", err))
+			return
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("creating directory: %v", err))
+		return
+	}
+	if err := s.write(path, content); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondWithFile(w, status, path, rel)
+}
+
+func (s *Server) handlePatchFile(w http.ResponseWriter, r *http.Request) {
+	path, rel, ok := s.writablePath(w, r)
+	if !ok {
+		return
+	}
+	var req FileRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Content != nil {
+		writeError(w, http.StatusBadRequest, "PATCH does not accept 'content'; use PUT to replace a note")
+		return
+	}
+
+	file, err := loadFile(path, rel)
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s not found", rel))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if file.IsLocked() {
+		writeError(w, http.StatusLocked, fmt.Sprintf("%s is locked (%s)", rel, file.LockReason()))
+		return
+	}
+
+	for key, value := range req.Frontmatter {
+		file.SetField(key, value)
+	}
+	for _, key := range req.Remove {
+		delete(file.Frontmatter, key)
+	}
+	if req.Body != nil {
+		file.Body = *req.Body
+	}
+
+	content, err := file.Serialize()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("serializing note: %v", err))
+		return
+	}
+	if err := s.write(path, content); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondWithFile(w, http.StatusOK, path, rel)
+}
+
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	path, rel, ok := s.writablePath(w, r)
+	if !ok {
+		return
+	}
+	file, err := loadFile(path, rel)
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s not found", rel))
+		return
+	}
+	if err == nil && file.IsLocked() {
+		writeError(w, http.StatusLocked, fmt.Sprintf("%s is locked (%s)", rel, file.LockReason()))
+		return
+	}
+
+	tx := s.begin()
+	if err := tx.RecordDelete(path); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("journaling: %v", err))
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("deleting %s: %v", rel, err))
+		return
+	}
+	s.commit(tx)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// write journals and atomically writes a note
+func (s *Server) write(path string, content []byte) error {
+	tx := s.begin()
+	if err := tx.RecordWrite(path); err != nil {
+		return fmt.Errorf("journaling: %w", err)
+	}
+	if err := safety.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+	s.commit(tx)
+	return nil
+}
+
+func (s *Server) begin() *safety.Transaction {
+	return safety.NewJournal(safety.FindVaultRoot(s.opts.Root)).Begin("mdnotes serve")
+}
+
+func (s *Server) commit(tx *safety.Transaction) {
+	if s.opts.Commit != nil {
+		s.opts.Commit(tx)
+		return
+	}
+	_ = tx.Commit()
+}
+
+func (s *Server) respondWithFile(w http.ResponseWriter, status int, path, rel string) {
+	file, err := loadFile(path, rel)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, status, fileResponse(file, true))
+}
+
+// notePath resolves the request's note path, rejecting paths that aren't
+// markdown files inside the vault
+func (s *Server) notePath(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	rel := r.PathValue("path")
+	if !filepath.IsLocal(filepath.FromSlash(rel)) || !strings.EqualFold(filepath.Ext(rel), ".md") {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid note path %q: must be a vault-relative .md path", rel))
+		return "", "", false
+	}
+	path := filepath.Join(s.opts.Root, filepath.FromSlash(rel))
+	if !s.insideVault(path) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("invalid note path %q: it leads outside the vault", rel))
+		return "", "", false
+	}
+	return path, rel, true
+}
+
+// insideVault reports whether path stays inside the vault once symlinks are
+// resolved, since reads and writes follow them. A path that doesn't exist yet
+// is checked through its nearest existing parent.
+func (s *Server) insideVault(path string) bool {
+	root, err := filepath.EvalSymlinks(s.opts.Root)
+	if err != nil {
+		return false
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return false
+	}
+
+	existing, rest := path, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			resolved, err = filepath.Abs(filepath.Join(resolved, rest))
+			if err != nil {
+				return false
+			}
+			rel, err := filepath.Rel(root, resolved)
+			return err == nil && (rel == "." || filepath.IsLocal(rel))
+		}
+		// A dangling symlink would be followed when the file is created
+		if _, lerr := os.Lstat(existing); lerr == nil || !os.IsNotExist(err) {
+			return false
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return false
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// writablePath is notePath for requests that change files
+func (s *Server) writablePath(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	if s.opts.ReadOnly {
+		writeError(w, http.StatusForbidden, "server is read-only")
+		return "", "", false
+	}
+	return s.notePath(w, r)
+}
+
+func loadFile(path, rel string) (*vault.VaultFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &vault.VaultFile{Path: path, RelativePath: rel, Modified: info.ModTime()}
+	if err := file.Parse(content); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", rel, err)
+	}
+	return file, nil
+}
+
+func fileResponse(file *vault.VaultFile, withBody bool) FileResponse {
+	resp := FileResponse{
+		Path:        filepath.ToSlash(file.RelativePath),
+		Frontmatter: file.Frontmatter,
+		Modified:    file.Modified,
+		Locked:      file.IsLocked(),
+	}
+	if resp.Frontmatter == nil {
+		resp.Frontmatter = map[string]interface{}{}
+	}
+	if withBody {
+		resp.Body = file.Body
+	}
+	return resp
+}
+
+func sortFiles(files []*vault.VaultFile) {
+	sort.Slice(files, func(i, j int) bool { return files[i].RelativePath < files[j].RelativePath })
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 10<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// Serve listens on addr and serves the API until ctx is cancelled. ready, if
+// non-nil, is called with the listening address once the server accepts
+// connections.
+func Serve(ctx context.Context, addr string, handler http.Handler, ready func(addr string)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("starting API server: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if ready != nil {
+		ready(listener.Addr().String())
+	}
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving API: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/analyzer"
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+func newTestServer(t *testing.T, opts Options) (*Server, string) {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".mdnotes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.md"), []byte("---\ntitle: A\nstatus: draft\n---\nSee [[b]]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.md"), []byte("---\ntitle: B\nstatus: done\n---\nBack to [[a]]\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "locked.md"), []byte("---\nmdnotes: locked\n---\nKeep\n"), 0644))
+	opts.Root = root
+	return New(opts), root
+}
+
+func do(t *testing.T, s *Server, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_Authentication(t *testing.T) {
+	s, _ := newTestServer(t, Options{Token: "secret"})
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/files", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/files", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, http.StatusOK, do(t, s, http.MethodGet, "/api/files", "").Code)
+}
+
+func TestServer_Query(t *testing.T) {
+	s, _ := newTestServer(t, Options{
+		Token: "secret",
+		ResolveQuery: func(q string) (string, error) {
+			if q == "@drafts" {
+				return "status = 'draft'", nil
+			}
+			return q, nil
+		},
+	})
+
+	rec := do(t, s, http.MethodGet, "/api/query?where=%40drafts", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp QueryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Count)
+	require.Len(t, resp.Files, 1)
+	assert.Equal(t, "a.md", resp.Files[0].Path)
+	assert.Equal(t, "A", resp.Files[0].Frontmatter["title"])
+
+	assert.Equal(t, http.StatusBadRequest, do(t, s, http.MethodGet, "/api/query", "").Code)
+}
+
+func TestServer_StatsAndGraph(t *testing.T) {
+	s, _ := newTestServer(t, Options{Token: "secret"})
+
+	rec := do(t, s, http.MethodGet, "/api/stats", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats analyzer.VaultStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, 3, stats.TotalFiles)
+
+	rec = do(t, s, http.MethodGet, "/api/graph?min-connections=1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var graph analyzer.NoteGraph
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &graph))
+	assert.Len(t, graph.Nodes, 2)
+	assert.Len(t, graph.Edges, 2)
+
+	rec = do(t, s, http.MethodGet, "/api/graph?format=mermaid", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "graph")
+
+	assert.Equal(t, http.StatusBadRequest, do(t, s, http.MethodGet, "/api/graph?format=png", "").Code)
+}
+
+func TestServer_FileCRUD(t *testing.T) {
+	var committed []*safety.Transaction
+	s, root := newTestServer(t, Options{
+		Token:  "secret",
+		Commit: func(tx *safety.Transaction) { committed = append(committed, tx); _ = tx.Commit() },
+	})
+
+	rec := do(t, s, http.MethodGet, "/api/files/a.md", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var file FileResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &file))
+	assert.Equal(t, "See [[b]]\n", file.Body)
+
+	rec = do(t, s, http.MethodPut, "/api/files/notes/new.md", `{"frontmatter": {"title": "New"}, "body": "Hello\n"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	content, err := os.ReadFile(filepath.Join(root, "notes", "new.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: New\n---\n\nHello\n", string(content))
+
+	rec = do(t, s, http.MethodPatch, "/api/files/a.md", `{"frontmatter": {"status": "done"}, "remove": ["title"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	content, err = os.ReadFile(filepath.Join(root, "a.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nstatus: done\n---\n\nSee [[b]]\n", string(content))
+
+	rec = do(t, s, http.MethodDelete, "/api/files/b.md", "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.NoFileExists(t, filepath.Join(root, "b.md"))
+
+	assert.Len(t, committed, 3)
+	assert.Equal(t, http.StatusNotFound, do(t, s, http.MethodGet, "/api/files/b.md", "").Code)
+}
+
+func TestServer_RejectsUnsafeWrites(t *testing.T) {
+	s, root := newTestServer(t, Options{Token: "secret"})
+
+	assert.Equal(t, http.StatusLocked, do(t, s, http.MethodPatch, "/api/files/locked.md", `{"body": "changed"}`).Code)
+	assert.Equal(t, http.StatusLocked, do(t, s, http.MethodDelete, "/api/files/locked.md", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(t, s, http.MethodPut, "/api/files/script.sh", `{"content": "x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(t, s, http.MethodPut, "/api/files/..%2Fescape.md", `{"content": "x"}`).Code)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(root), "escape.md"))
+
+	// Symlinks leading out of the vault are followed by reads and writes
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.md"), []byte("private\n"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.md"), filepath.Join(root, "linked.md")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "elsewhere")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "missing.md"), filepath.Join(root, "dangling.md")))
+	assert.Equal(t, http.StatusForbidden, do(t, s, http.MethodGet, "/api/files/linked.md", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(t, s, http.MethodPut, "/api/files/linked.md", `{"content": "x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(t, s, http.MethodPut, "/api/files/elsewhere/new.md", `{"content": "x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(t, s, http.MethodPut, "/api/files/dangling.md", `{"content": "x"}`).Code)
+	content, err := os.ReadFile(filepath.Join(outside, "secret.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "private\n", string(content))
+	assert.NoFileExists(t, filepath.Join(outside, "new.md"))
+	assert.NoFileExists(t, filepath.Join(outside, "missing.md"))
+
+	readOnly, _ := newTestServer(t, Options{Token: "secret", ReadOnly: true})
+	assert.Equal(t, http.StatusForbidden, do(t, readOnly, http.MethodDelete, "/api/files/a.md", "").Code)
+	assert.Equal(t, http.StatusOK, do(t, readOnly, http.MethodGet, "/api/files/a.md", "").Code)
+}
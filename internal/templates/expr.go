@@ -0,0 +1,461 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// expr is a parsed template expression such as `count + 1` or `title|slug`
+type expr interface {
+	eval(r *Renderer, scope Scope) (interface{}, error)
+}
+
+type literal struct{ value interface{} }
+
+type variable struct{ path []string }
+
+type negation struct{ operand expr }
+
+type binary struct {
+	op          byte
+	left, right expr
+}
+
+type filtered struct {
+	operand expr
+	name    string
+	arg     string
+}
+
+// duration is a literal like 7d or 2w, used to shift dates
+type duration struct {
+	n    int
+	unit string
+}
+
+func (l *literal) eval(*Renderer, Scope) (interface{}, error) { return l.value, nil }
+
+func (v *variable) eval(r *Renderer, scope Scope) (interface{}, error) {
+	return r.lookup(v.path, scope), nil
+}
+
+func (n *negation) eval(r *Renderer, scope Scope) (interface{}, error) {
+	value, err := n.operand.eval(r, scope)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := value.(duration); ok {
+		return duration{n: -d.n, unit: d.unit}, nil
+	}
+	return arithmetic('-', 0, value)
+}
+
+func (b *binary) eval(r *Renderer, scope Scope) (interface{}, error) {
+	left, err := b.left.eval(r, scope)
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.right.eval(r, scope)
+	if err != nil {
+		return nil, err
+	}
+	return arithmetic(b.op, left, right)
+}
+
+func (f *filtered) eval(r *Renderer, scope Scope) (interface{}, error) {
+	value, err := f.operand.eval(r, scope)
+	if err != nil {
+		return nil, err
+	}
+	filter, ok := r.filters[f.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown filter '%s'", f.name)
+	}
+	result, err := filter(value, f.arg)
+	if err != nil {
+		return nil, fmt.Errorf("filter '%s': %w", f.name, err)
+	}
+	return result, nil
+}
+
+// exprParser parses the body of a {{...}} placeholder:
+//
+//	pipeline := sum ('|' filter[:arg])*
+//	sum      := product (('+' | '-') product)*
+//	product  := unary (('*' | '/' | '%') unary)*
+//	unary    := '-' unary | number | duration | string | name(.name)* | '(' pipeline ')'
+//
+// Filter arguments are taken verbatim up to the next '|' so date layouts may
+// contain spaces and colons.
+type exprParser struct {
+	src   string
+	pos   int
+	known func(name string) bool
+}
+
+func (r *Renderer) parseExpr(src string) (expr, error) {
+	p := &exprParser{src: src, known: func(name string) bool {
+		_, ok := r.filters[name]
+		return ok
+	}}
+	e, err := p.parsePipeline()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", src, err)
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("invalid expression '%s': unexpected '%s'", src, p.src[p.pos:])
+	}
+	return e, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) parsePipeline() (expr, error) {
+	e, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '|' {
+		p.pos++
+		p.skipSpace()
+		name := p.name()
+		if name == "" {
+			return nil, fmt.Errorf("expected a filter name after '|'")
+		}
+		if !p.known(name) {
+			return nil, fmt.Errorf("unknown filter '%s'", name)
+		}
+		f := &filtered{operand: e, name: name}
+		if p.peek() == ':' {
+			p.pos++
+			f.arg = p.rawArg()
+		}
+		e = f
+	}
+	return e, nil
+}
+
+// rawArg reads a filter argument up to the next '|' or unbalanced ')',
+// outside quotes
+func (p *exprParser) rawArg() string {
+	start, depth := p.pos, 0
+	var quote byte
+	for ; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return strings.TrimSpace(p.src[start:p.pos])
+			}
+			depth--
+		case c == '|':
+			return strings.TrimSpace(p.src[start:p.pos])
+		}
+	}
+	return strings.TrimSpace(p.src[start:])
+}
+
+func (p *exprParser) parseSum() (expr, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseProduct() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '-':
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negation{operand: operand}, nil
+	case c == '(':
+		p.pos++
+		e, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return e, nil
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		s := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return &literal{value: s}, nil
+	case c >= '0' && c <= '9':
+		return p.parseNumber()
+	case isNameStart(c):
+		path := []string{p.name()}
+		for p.pos < len(p.src) && p.src[p.pos] == '.' {
+			p.pos++
+			segment := p.name()
+			if segment == "" {
+				return nil, fmt.Errorf("expected a name after '.'")
+			}
+			path = append(path, segment)
+		}
+		if len(path) == 1 && (path[0] == "true" || path[0] == "false") {
+			return &literal{value: path[0] == "true"}, nil
+		}
+		return &variable{path: path}, nil
+	default:
+		return nil, fmt.Errorf("unexpected '%c'", c)
+	}
+}
+
+func (p *exprParser) parseNumber() (expr, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+		p.pos++
+	}
+	digits := p.src[start:p.pos]
+
+	unitStart := p.pos
+	for p.pos < len(p.src) && unicode.IsLetter(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if unit := p.src[unitStart:p.pos]; unit != "" {
+		n, err := strconv.Atoi(digits)
+		if err != nil || !isDurationUnit(unit) {
+			return nil, fmt.Errorf("invalid duration '%s%s' (units: d, w, mo, y, h, m, s)", digits, unit)
+		}
+		return &literal{value: duration{n: n, unit: unit}}, nil
+	}
+
+	if n, err := strconv.Atoi(digits); err == nil {
+		return &literal{value: n}, nil
+	}
+	f, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number '%s'", digits)
+	}
+	return &literal{value: f}, nil
+}
+
+// name reads a variable or filter name. Hyphens are allowed inside names
+// when followed by a letter, so fields like date-created can be used; put
+// spaces around '-' to subtract.
+func (p *exprParser) name() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if isNameStart(c) || c >= '0' && c <= '9' {
+			p.pos++
+			continue
+		}
+		if c == '-' && p.pos > start && p.pos+1 < len(p.src) && isNameStart(p.src[p.pos+1]) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDurationUnit(unit string) bool {
+	switch unit {
+	case "d", "w", "mo", "y", "h", "m", "s":
+		return true
+	}
+	return false
+}
+
+// arithmetic applies op to two values. Dates shift by durations or by a
+// number of days, and subtracting dates gives the days between them. Numbers,
+// including numeric strings, use integer arithmetic where both sides are
+// whole; a missing value counts as 0. Otherwise '+' concatenates.
+func arithmetic(op byte, left, right interface{}) (interface{}, error) {
+	if d, ok := left.(duration); ok && op == '+' {
+		left, right = right, d
+	}
+	if t, ok := asTime(left); ok {
+		return dateArithmetic(op, left, t, right)
+	}
+
+	l, lok := asNumber(left)
+	r, rok := asNumber(right)
+	if lok && rok {
+		return numberArithmetic(op, l, r)
+	}
+	if op == '+' {
+		return toString(left) + toString(right), nil
+	}
+	return nil, fmt.Errorf("cannot apply '%c' to '%s' and '%s'", op, toString(left), toString(right))
+}
+
+func dateArithmetic(op byte, left interface{}, t time.Time, right interface{}) (interface{}, error) {
+	if other, ok := asTime(right); ok && op == '-' {
+		return wholeNumber(t.Sub(other).Hours() / 24), nil
+	}
+
+	var d duration
+	switch v := right.(type) {
+	case duration:
+		d = v
+	default:
+		n, ok := asNumber(right)
+		if !ok || n != math.Trunc(n) {
+			return nil, fmt.Errorf("cannot apply '%c' to a date and '%s'", op, toString(right))
+		}
+		d = duration{n: int(n), unit: "d"}
+	}
+	if op != '+' && op != '-' {
+		return nil, fmt.Errorf("cannot apply '%c' to a date", op)
+	}
+	if op == '-' {
+		d.n = -d.n
+	}
+
+	switch d.unit {
+	case "d":
+		t = t.AddDate(0, 0, d.n)
+	case "w":
+		t = t.AddDate(0, 0, 7*d.n)
+	case "mo":
+		t = t.AddDate(0, d.n, 0)
+	case "y":
+		t = t.AddDate(d.n, 0, 0)
+	case "h":
+		t = t.Add(time.Duration(d.n) * time.Hour)
+	case "m":
+		t = t.Add(time.Duration(d.n) * time.Minute)
+	case "s":
+		t = t.Add(time.Duration(d.n) * time.Second)
+	}
+
+	if _, isDate := left.(vault.Date); isDate {
+		return vault.Date{Time: t}, nil
+	}
+	return t, nil
+}
+
+func numberArithmetic(op byte, l, r float64) (interface{}, error) {
+	switch op {
+	case '+':
+		return wholeNumber(l + r), nil
+	case '-':
+		return wholeNumber(l - r), nil
+	case '*':
+		return wholeNumber(l * r), nil
+	case '/':
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return wholeNumber(l / r), nil
+	case '%':
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return wholeNumber(math.Mod(l, r)), nil
+	}
+	return nil, fmt.Errorf("unknown operator '%c'", op)
+}
+
+// wholeNumber returns whole floats as ints so results print without a
+// decimal point
+func wholeNumber(f float64) interface{} {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+	return f
+}
+
+func asNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case nil:
+		return 0, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func asTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case vault.Date:
+		return v.Time, true
+	case time.Time:
+		return v, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,262 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+func defaultFilters() map[string]Filter {
+	return map[string]Filter{
+		"upper":           stringFilter(strings.ToUpper),
+		"lower":           stringFilter(strings.ToLower),
+		"title":           stringFilter(titleCase),
+		"trim":            stringFilter(strings.TrimSpace),
+		"slug":            stringFilter(Slugify),
+		"slugify":         stringFilter(Slugify),
+		"slug_underscore": stringFilter(SlugifyWithUnderscore),
+		"date":            dateFilter,
+		"default":         defaultFilter,
+		"replace":         replaceFilter,
+		"truncate":        truncateFilter,
+		"join":            joinFilter,
+		"length":          lengthFilter,
+		"round":           roundFilter,
+	}
+}
+
+func stringFilter(fn func(string) string) Filter {
+	return func(value interface{}, _ string) (interface{}, error) {
+		return fn(toString(value)), nil
+	}
+}
+
+// dateFilter formats a date with a Go layout or YYYY-MM-DD style tokens.
+// Values that aren't dates are returned unchanged.
+func dateFilter(value interface{}, arg string) (interface{}, error) {
+	layout := "2006-01-02"
+	if arg != "" {
+		layout = DateLayout(unquote(arg))
+	}
+
+	t, ok := asTime(value)
+	if !ok {
+		if t, ok = parseDate(toString(value)); !ok {
+			return value, nil
+		}
+	}
+	return t.Format(layout), nil
+}
+
+func defaultFilter(value interface{}, arg string) (interface{}, error) {
+	if value == nil || toString(value) == "" {
+		return unquote(arg), nil
+	}
+	return value, nil
+}
+
+func replaceFilter(value interface{}, arg string) (interface{}, error) {
+	args := splitArgs(arg)
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected replace:old:new")
+	}
+	return strings.ReplaceAll(toString(value), args[0], args[1]), nil
+}
+
+func truncateFilter(value interface{}, arg string) (interface{}, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("expected truncate:length, got '%s'", arg)
+	}
+	s := toString(value)
+	if utf8.RuneCountInString(s) <= n {
+		return s, nil
+	}
+	return string([]rune(s)[:n]), nil
+}
+
+func joinFilter(value interface{}, arg string) (interface{}, error) {
+	sep := ", "
+	if arg != "" {
+		sep = unquote(arg)
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = toString(item)
+		}
+		return strings.Join(items, sep), nil
+	case []string:
+		return strings.Join(v, sep), nil
+	}
+	return toString(value), nil
+}
+
+func lengthFilter(value interface{}, _ string) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case []interface{}:
+		return len(v), nil
+	case []string:
+		return len(v), nil
+	}
+	return utf8.RuneCountInString(toString(value)), nil
+}
+
+func roundFilter(value interface{}, arg string) (interface{}, error) {
+	places := 0
+	if arg != "" {
+		var err error
+		if places, err = strconv.Atoi(arg); err != nil {
+			return nil, fmt.Errorf("expected round:places, got '%s'", arg)
+		}
+	}
+	n, ok := asNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("cannot round '%s'", toString(value))
+	}
+	scale := math.Pow(10, float64(places))
+	return wholeNumber(math.Round(n*scale) / scale), nil
+}
+
+// splitArgs splits a filter argument on colons outside quotes
+func splitArgs(arg string) []string {
+	var args []string
+	var quote rune
+	start := 0
+	for i, c := range arg {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':':
+			args = append(args, unquote(arg[start:i]))
+			start = i + 1
+		}
+	}
+	return append(args, unquote(arg[start:]))
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func titleCase(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '-' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+var dateFormats = []string{
+	"2006-01-02T15:04:05Z",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"20060102150405",
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, format := range dateFormats {
+		if t, err := time.Parse(format, strings.TrimSpace(s)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dateTokens maps YYYY-MM-DD style tokens to Go layout elements, longest first
+var dateTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"dddd", "Monday"}, {"ddd", "Mon"},
+	{"DD", "02"}, {"D", "2"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"},
+	{"ss", "05"}, {"s", "5"},
+	{"A", "PM"}, {"a", "pm"},
+	{"ZZ", "-0700"}, {"Z", "-07:00"},
+}
+
+var tokenFormatPattern = regexp.MustCompile(`Y|D|HH|hh|mm|ss`)
+
+// DateLayout converts a date format written with tokens like YYYY-MM-DD HH:mm
+// to a Go time layout. Text in [brackets] is kept as is. Formats that already
+// are Go layouts, such as 2006-01-02, are returned unchanged.
+func DateLayout(format string) string {
+	if !tokenFormatPattern.MatchString(format) {
+		return format
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				b.WriteString(format[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, t := range dateTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				b.WriteString(t.layout)
+				i += len(t.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(format[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify converts a string to a lowercase, hyphen-separated slug
+func Slugify(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// SlugifyWithUnderscore converts a string to a lowercase slug using underscores
+func SlugifyWithUnderscore(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+var datestringPattern = regexp.MustCompile(`^(\d{14})-`)
+
+// ExtractDatestring returns the YYYYMMDDHHMMSS prefix of a filename, or ""
+func ExtractDatestring(filename string) string {
+	if matches := datestringPattern.FindStringSubmatch(filename); len(matches) >= 2 {
+		return matches[1]
+	}
+	return ""
+}
+
+// TrimDatestring removes the YYYYMMDDHHMMSS- prefix from a filename
+func TrimDatestring(filename string) string {
+	return datestringPattern.ReplaceAllString(filename, "")
+}
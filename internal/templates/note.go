@@ -0,0 +1,97 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{.*?\}\}`)
+var protectedPattern = regexp.MustCompile(`__mdnotes_template_(\d+)__`)
+
+// RenderNote renders a note template, such as a file in the templates
+// directory used by 'mdnotes new'. Placeholders are expanded in frontmatter
+// string values and in the body. They may be left unquoted in YAML, as in
+// "created: {{current_date}}", and a value that is a single placeholder keeps
+// its type, so dates stay dates and numbers stay numbers.
+func (r *Renderer) RenderNote(content []byte, scope Scope) (*vault.VaultFile, error) {
+	// Swap placeholders for plain tokens so the frontmatter parses as YAML
+	var placeholders []string
+	protected := placeholderPattern.ReplaceAllStringFunc(string(content), func(placeholder string) string {
+		placeholders = append(placeholders, placeholder)
+		return fmt.Sprintf("__mdnotes_template_%d__", len(placeholders)-1)
+	})
+	restore := func(s string) string {
+		return protectedPattern.ReplaceAllStringFunc(s, func(token string) string {
+			i, _ := strconv.Atoi(protectedPattern.FindStringSubmatch(token)[1])
+			return placeholders[i]
+		})
+	}
+
+	file := &vault.VaultFile{}
+	if err := file.Parse([]byte(protected)); err != nil {
+		return nil, err
+	}
+
+	for key, value := range file.Frontmatter {
+		rendered, err := r.renderValue(value, scope, restore)
+		if err != nil {
+			return nil, fmt.Errorf("frontmatter field '%s': %w", key, err)
+		}
+		file.SetField(key, rendered)
+	}
+
+	body, err := r.Render(restore(file.Body), scope)
+	if err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+	file.Body = body
+	return file, nil
+}
+
+// renderValue renders the strings in a frontmatter value, descending into
+// lists and maps
+func (r *Renderer) renderValue(value interface{}, scope Scope, restore func(string) string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		tmpl := restore(v)
+		if tmpl == v {
+			return v, nil
+		}
+		if placeholderPattern.FindString(tmpl) == tmpl {
+			expression := strings.TrimSpace(tmpl[2 : len(tmpl)-2])
+			if expression != "else" && expression != "end" && !strings.HasPrefix(expression, "if ") {
+				result, err := r.Eval(expression, scope)
+				if _, isDuration := result.(duration); isDuration {
+					return toString(result), err
+				}
+				return result, err
+			}
+		}
+		return r.Render(tmpl, scope)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered, err := r.renderValue(item, scope, restore)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = rendered
+		}
+		return items, nil
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered, err := r.renderValue(item, scope, restore)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = rendered
+		}
+		return fields, nil
+	}
+	return value, nil
+}
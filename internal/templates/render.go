@@ -0,0 +1,339 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+	"github.com/google/uuid"
+)
+
+// Renderer expands the {{name|filter}} placeholders used in command flags and
+// configuration, such as ensure defaults, rename templates and lifecycle
+// rules. Unlike Engine it has no dot prefix and supports arithmetic:
+//
+//	{{filename}}  {{title|slug}}  {{file.mtime|date:YYYY-MM-DD}}
+//	{{current_date + 7d}}  {{(priority|default:0) + 1}}
+//	{{if status}}{{status|upper}}{{else}}DRAFT{{end}}
+//
+// Missing variables render as empty strings and count as 0 in arithmetic.
+type Renderer struct {
+	now     time.Time
+	filters map[string]Filter
+	cache   map[string][]node
+	mu      sync.RWMutex
+}
+
+// Filter transforms a value. arg is the text after the filter name's colon,
+// or "" when there is none.
+type Filter func(value interface{}, arg string) (interface{}, error)
+
+// NewRenderer creates a renderer with the built-in filters
+func NewRenderer() *Renderer {
+	r := &Renderer{
+		now:     time.Now(),
+		filters: make(map[string]Filter),
+		cache:   make(map[string][]node),
+	}
+	for name, filter := range defaultFilters() {
+		r.filters[name] = filter
+	}
+	return r
+}
+
+// SetCurrentTime sets the time current_date, current_datetime and now refer to
+func (r *Renderer) SetCurrentTime(t time.Time) {
+	r.now = t
+}
+
+// RegisterFilter adds or replaces a filter
+func (r *Renderer) RegisterFilter(name string, filter Filter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.filters[name] = filter
+	r.cache = make(map[string][]node)
+}
+
+// Filters returns the names of the registered filters
+func (r *Renderer) Filters() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.filters))
+	for name := range r.filters {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Validate reports syntax errors and unknown filters in a template without
+// rendering it
+func (r *Renderer) Validate(tmpl string) error {
+	_, err := r.compile(tmpl)
+	return err
+}
+
+// Render expands every placeholder in tmpl using scope, which may be nil
+func (r *Renderer) Render(tmpl string, scope Scope) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+
+	nodes, err := r.compile(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := renderNodes(r, nodes, scope, &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Eval evaluates a single expression, without braces, and returns its typed
+// value: a string, int, float64, bool, vault.Date or time.Time
+func (r *Renderer) Eval(expression string, scope Scope) (interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, err := r.parseExpr(expression)
+	if err != nil {
+		return nil, err
+	}
+	return e.eval(r, scope)
+}
+
+func (r *Renderer) compile(tmpl string) ([]node, error) {
+	r.mu.RLock()
+	nodes, exists := r.cache[tmpl]
+	r.mu.RUnlock()
+	if exists {
+		return nodes, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &templateParser{renderer: r, src: tmpl}
+	nodes, stop, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if stop != "" {
+		return nil, fmt.Errorf("'{{%s}}' without a matching '{{if}}'", stop)
+	}
+	r.cache[tmpl] = nodes
+	return nodes, nil
+}
+
+// lookup resolves a variable: built-ins first, then the scope by full dotted
+// name, then by descending into the maps of the first name's value
+func (r *Renderer) lookup(path []string, scope Scope) interface{} {
+	name := strings.Join(path, ".")
+	switch name {
+	case "current_date", "today":
+		year, month, day := r.now.Date()
+		return vault.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, r.now.Location())}
+	case "current_datetime", "now":
+		return r.now
+	case "uuid":
+		return uuid.New().String()
+	}
+
+	if scope == nil {
+		return nil
+	}
+	if value, ok := scope.Lookup(name); ok {
+		return value
+	}
+	if len(path) == 1 {
+		return nil
+	}
+
+	value, ok := scope.Lookup(path[0])
+	if !ok {
+		return nil
+	}
+	for _, key := range path[1:] {
+		m, isMap := value.(map[string]interface{})
+		if !isMap {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// node is a parsed piece of a template
+type node interface {
+	render(r *Renderer, scope Scope, b *strings.Builder) error
+}
+
+type textNode string
+
+type exprNode struct{ expr expr }
+
+type ifNode struct {
+	cond            expr
+	then, otherwise []node
+}
+
+func (t textNode) render(_ *Renderer, _ Scope, b *strings.Builder) error {
+	b.WriteString(string(t))
+	return nil
+}
+
+func (n *exprNode) render(r *Renderer, scope Scope, b *strings.Builder) error {
+	value, err := n.expr.eval(r, scope)
+	if err != nil {
+		return err
+	}
+	b.WriteString(toString(value))
+	return nil
+}
+
+func (n *ifNode) render(r *Renderer, scope Scope, b *strings.Builder) error {
+	value, err := n.cond.eval(r, scope)
+	if err != nil {
+		return err
+	}
+	if truthy(value) {
+		return renderNodes(r, n.then, scope, b)
+	}
+	return renderNodes(r, n.otherwise, scope, b)
+}
+
+func renderNodes(r *Renderer, nodes []node, scope Scope, b *strings.Builder) error {
+	for _, n := range nodes {
+		if err := n.render(r, scope, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type templateParser struct {
+	renderer *Renderer
+	src      string
+	pos      int
+}
+
+// parseNodes parses up to the end of the template or an {{else}} or {{end}},
+// which it returns as stop
+func (p *templateParser) parseNodes() (nodes []node, stop string, err error) {
+	for p.pos < len(p.src) {
+		start := strings.Index(p.src[p.pos:], "{{")
+		if start < 0 {
+			nodes = append(nodes, textNode(p.src[p.pos:]))
+			p.pos = len(p.src)
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, textNode(p.src[p.pos:p.pos+start]))
+		}
+
+		open := p.pos + start + 2
+		end := strings.Index(p.src[open:], "}}")
+		if end < 0 {
+			return nil, "", fmt.Errorf("unclosed '{{' in template '%s'", p.src)
+		}
+		body := strings.TrimSpace(p.src[open : open+end])
+		p.pos = open + end + 2
+
+		switch {
+		case body == "else" || body == "end":
+			return nodes, body, nil
+		case strings.HasPrefix(body, "if ") || strings.HasPrefix(body, "if\t"):
+			n, err := p.parseIf(strings.TrimSpace(body[2:]))
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, n)
+		default:
+			e, err := p.renderer.parseExpr(body)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, &exprNode{expr: e})
+		}
+	}
+	return nodes, "", nil
+}
+
+func (p *templateParser) parseIf(condition string) (*ifNode, error) {
+	cond, err := p.renderer.parseExpr(condition)
+	if err != nil {
+		return nil, err
+	}
+	n := &ifNode{cond: cond}
+
+	var stop string
+	n.then, stop, err = p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if stop == "else" {
+		n.otherwise, stop, err = p.parseNodes()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if stop != "end" {
+		return nil, fmt.Errorf("'{{if %s}}' without a matching '{{end}}'", condition)
+	}
+	return n, nil
+}
+
+// toString renders a value the way it is written into notes
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case vault.Date:
+		return v.String()
+	case time.Time:
+		return v.Format("2006-01-02T15:04:05Z")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case duration:
+		return strconv.Itoa(v.n) + v.unit
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = toString(item)
+		}
+		return strings.Join(items, ", ")
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// truthy reports whether a value counts as set in {{if}}
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case []string:
+		return len(v) > 0
+	}
+	return true
+}
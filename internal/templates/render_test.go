@@ -0,0 +1,239 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+func testRenderer() *Renderer {
+	r := NewRenderer()
+	r.SetCurrentTime(time.Date(2024, 3, 10, 14, 5, 0, 0, time.UTC))
+	return r
+}
+
+func testFile() *vault.VaultFile {
+	return &vault.VaultFile{
+		Path:         "/vault/projects/20240101093000-Launch Plan.md",
+		RelativePath: "projects/20240101093000-Launch Plan.md",
+		Modified:     time.Date(2024, 2, 29, 8, 30, 0, 0, time.UTC),
+		Frontmatter: map[string]interface{}{
+			"title":        "Launch Plan",
+			"priority":     2,
+			"estimate":     "1.5",
+			"tags":         []interface{}{"work", "q1"},
+			"due":          vault.Date{Time: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+			"date-created": "2024-01-01",
+			"project":      map[string]interface{}{"status": "active"},
+		},
+	}
+}
+
+func TestRenderer_Render(t *testing.T) {
+	r := testRenderer()
+	scope := FileScope(testFile())
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"plain text", "no placeholders", "no placeholders"},
+		{"current date", "{{current_date}}", "2024-03-10"},
+		{"current datetime", "{{current_datetime}}", "2024-03-10T14:05:00Z"},
+		{"filename", "{{filename}}", "20240101093000-Launch Plan"},
+		{"filename without datestring", "{{filename_without_datestring|slug_underscore}}", "launch_plan"},
+		{"existing datestring", "{{existing_datestring|date:YYYY-MM-DD HH:mm}}", "2024-01-01 09:30"},
+		{"title slug", "{{title|slug}}", "launch-plan"},
+		{"chained filters", "{{ title | upper | replace:' ':_ }}", "LAUNCH_PLAN"},
+		{"file properties", "{{file.dir}}/{{file.name|truncate:14}}.{{file.ext}}", "projects/20240101093000.md"},
+		{"file mtime with tokens", "{{file.mtime|date:YYYY-MM-DD}}", "2024-02-29"},
+		{"file mtime with go layout", "{{file.mtime|date:Jan 2, 2006 15:04}}", "Feb 29, 2024 08:30"},
+		{"legacy file_mtime", "{{file_mtime}}", "2024-02-29"},
+		{"frontmatter namespace", "{{fm.priority}} {{frontmatter.title}}", "2 Launch Plan"},
+		{"nested field", "{{project.status}}", "active"},
+		{"hyphenated field", "{{date-created|date:DD/MM/YYYY}}", "01/01/2024"},
+		{"local datetime string", "{{'2024-03-05T14:30:00'|date:YYYYMMDDHHmm}}", "202403051430"},
+		{"list", "{{tags}} ({{tags|length}})", "work, q1 (2)"},
+		{"join", "{{tags|join:' #'}}", "work #q1"},
+		{"missing field", "[{{nonexistent}}]", "[]"},
+		{"default", "{{status|default:draft}}", "draft"},
+		{"arithmetic", "{{priority + 1}} {{priority * 3 - 1}} {{(priority + 1) * 2}}", "3 5 6"},
+		{"numeric strings", "{{estimate * 2}}", "3"},
+		{"division", "{{7 / 2}} {{priority / 2}}", "3.5 1"},
+		{"missing counts as zero", "{{count + 1}}", "1"},
+		{"filter inside arithmetic", "{{(status|default:4) + 1}}", "5"},
+		{"string concatenation", "{{title + '!'}}", "Launch Plan!"},
+		{"date plus days", "{{current_date + 7}}", "2024-03-17"},
+		{"date plus duration", "{{current_date + 2w}} {{due - 1mo}}", "2024-03-24 2024-02-15"},
+		{"days between dates", "{{due - current_date}}", "5"},
+		{"formatted date arithmetic", "{{(file.mtime + 1d)|date:YYYY-MM-DD}}", "2024-03-01"},
+		{"if", "{{if title}}has title{{end}}", "has title"},
+		{"if else", "{{if status}}{{status}}{{else}}none{{end}}", "none"},
+		{"nested if", "{{if title}}{{if status}}a{{else}}b{{end}}{{end}}", "b"},
+		{"round", "{{10 / 3|round:2}}", "3.33"},
+		{"title filter", "{{'hello big world'|title}}", "Hello Big World"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Render(tt.template, scope)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRenderer_UUID(t *testing.T) {
+	r := NewRenderer()
+
+	got, err := r.Render("{{uuid}} {{uuid}}", nil)
+	require.NoError(t, err)
+
+	parts := strings.Split(got, " ")
+	require.Len(t, parts, 2)
+	assert.Len(t, parts[0], 36)
+	assert.NotEqual(t, parts[0], parts[1])
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	r := testRenderer()
+
+	tests := []struct {
+		template string
+		errorMsg string
+	}{
+		{"{{filename", "unclosed"},
+		{"{{filename|nope}}", "unknown filter 'nope'"},
+		{"{{}}", "unexpected end"},
+		{"{{(priority + 1}}", "missing ')'"},
+		{"{{'open}}", "unterminated string"},
+		{"{{priority +}}", "unexpected end"},
+		{"{{3x}}", "invalid duration"},
+		{"{{if title}}no end", "without a matching '{{end}}'"},
+		{"text{{end}}", "without a matching '{{if}}'"},
+		{"{{title title}}", "unexpected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			err := r.Validate(tt.template)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+
+	_, err := r.Render("{{priority / 0}}", FileScope(testFile()))
+	assert.ErrorContains(t, err, "division by zero")
+
+	_, err = r.Render("{{title * 2}}", FileScope(testFile()))
+	assert.ErrorContains(t, err, "cannot apply '*'")
+}
+
+func TestRenderer_Eval(t *testing.T) {
+	r := testRenderer()
+	scope := Vars{"count": 4, "when": time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}
+
+	value, err := r.Eval("count + 1", scope)
+	require.NoError(t, err)
+	assert.Equal(t, 5, value)
+
+	value, err = r.Eval("when + 1mo", scope)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), value)
+
+	value, err = r.Eval("current_date", nil)
+	require.NoError(t, err)
+	assert.Equal(t, vault.Date{Time: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)}, value)
+}
+
+func TestRenderer_RegisterFilter(t *testing.T) {
+	r := testRenderer()
+	require.Error(t, r.Validate("{{title|reverse}}"))
+
+	r.RegisterFilter("reverse", func(value interface{}, _ string) (interface{}, error) {
+		runes := []rune(toString(value))
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	})
+
+	got, err := r.Render("{{title|reverse}}", Vars{"title": "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "cba", got)
+}
+
+func TestRenderer_RenderNote(t *testing.T) {
+	r := testRenderer()
+	content := `---
+title: {{title}}
+created: {{current_date}}
+review: "{{current_date + 7d}}"
+count: {{n + 1}}
+label: {{title|upper}} v{{n}}
+tags: [meeting, {{kind}}]
+meta:
+  source: {{kind}}
+---
+
+# {{title}}
+{{if n}}Count: {{n}}{{end}}
+`
+
+	note, err := r.RenderNote([]byte(content), Vars{"title": "Sync: weekly", "n": 2, "kind": "team"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Sync: weekly", note.Frontmatter["title"])
+	assert.Equal(t, vault.Date{Time: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)}, note.Frontmatter["created"])
+	assert.Equal(t, vault.Date{Time: time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)}, note.Frontmatter["review"])
+	assert.Equal(t, 3, note.Frontmatter["count"])
+	assert.Equal(t, "SYNC: WEEKLY v2", note.Frontmatter["label"])
+	assert.Equal(t, []interface{}{"meeting", "team"}, note.Frontmatter["tags"])
+	assert.Equal(t, map[string]interface{}{"source": "team"}, note.Frontmatter["meta"])
+	assert.Equal(t, "# Sync: weekly\nCount: 2\n", note.Body)
+
+	_, err = r.RenderNote([]byte("---\ntitle: {{title|nope}}\n---\n"), nil)
+	assert.ErrorContains(t, err, "frontmatter field 'title'")
+}
+
+func TestFileScope_Fallbacks(t *testing.T) {
+	r := testRenderer()
+	file := &vault.VaultFile{
+		Path:         "/vault/Untitled.md",
+		RelativePath: "Untitled.md",
+		Modified:     time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		Frontmatter:  map[string]interface{}{},
+	}
+
+	got, err := r.Render("{{title}}|{{created}}|{{parent_dir}}|{{file.dir}}", FileScope(file))
+	require.NoError(t, err)
+	assert.Equal(t, "Untitled|2023-06-01||", got)
+}
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"YYYY-MM-DD", "2006-01-02"},
+		{"YYYYMMDDHHmm", "200601021504"},
+		{"DD MMM YY", "02 Jan 06"},
+		{"dddd, MMMM D", "Monday, January 2"},
+		{"hh:mm A", "03:04 PM"},
+		{"[Week of] MMM D", "Week of Jan 2"},
+		{"2006-01-02", "2006-01-02"},
+		{"Jan 2, 2006", "Jan 2, 2006"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			assert.Equal(t, tt.want, DateLayout(tt.format))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package templates
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/vault"
+)
+
+// Scope resolves the variables a template refers to
+type Scope interface {
+	Lookup(name string) (interface{}, bool)
+}
+
+// Vars is a Scope over a map. Dotted names like project.status look into
+// nested maps.
+type Vars map[string]interface{}
+
+// Lookup returns the variable called name
+func (v Vars) Lookup(name string) (interface{}, bool) {
+	value, ok := v[name]
+	return value, ok
+}
+
+// FileScope returns the variables of a note:
+//
+//	filename, title, relative_path, parent_dir, created, file_mtime,
+//	filename_without_datestring, existing_datestring
+//	file.name, file.basename, file.ext, file.path, file.dir, file.mtime
+//	fm.<field> (or frontmatter.<field>), and every frontmatter field by name
+//
+// title falls back to the filename and created to the modification date.
+func FileScope(file *vault.VaultFile) Scope {
+	return fileScope{file: file}
+}
+
+type fileScope struct {
+	file *vault.VaultFile
+}
+
+func (s fileScope) Lookup(name string) (interface{}, bool) {
+	file := s.file
+	if file == nil {
+		return nil, false
+	}
+
+	base := filepath.Base(file.Path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+
+	switch name {
+	case "filename", "file.name":
+		return stem, true
+	case "file.basename":
+		return base, true
+	case "file.ext":
+		return strings.TrimPrefix(filepath.Ext(base), "."), true
+	case "filename_without_datestring":
+		return TrimDatestring(stem), true
+	case "existing_datestring":
+		return ExtractDatestring(stem), true
+	case "relative_path", "file.path":
+		return file.RelativePath, true
+	case "parent_dir":
+		dir := filepath.Dir(file.RelativePath)
+		if dir == "." || dir == "/" {
+			return "", true
+		}
+		return filepath.Base(dir), true
+	case "file.dir":
+		dir := filepath.ToSlash(filepath.Dir(file.RelativePath))
+		if dir == "." || dir == "/" {
+			return "", true
+		}
+		return dir, true
+	case "file_mtime":
+		return vault.Date{Time: file.Modified}, true
+	case "file_mtime_iso", "file.mtime":
+		return file.Modified, true
+	case "fm", "frontmatter":
+		return file.Frontmatter, true
+	case "title":
+		if value, exists := file.GetField("title"); exists && value != nil && toString(value) != "" {
+			return value, true
+		}
+		return stem, true
+	case "created":
+		value, exists := file.GetField("created")
+		if !exists {
+			return vault.Date{Time: file.Modified}, true
+		}
+		if t, ok := value.(time.Time); ok {
+			return vault.Date{Time: t}, true
+		}
+		return value, true
+	}
+
+	return file.GetField(name)
+}
@@ -0,0 +1,268 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DuplicateKey describes a frontmatter key that appears more than once
+type DuplicateKey struct {
+	Key   string
+	Lines []int // 1-based line numbers in the file
+}
+
+// DuplicateKeyError is returned by Parse when frontmatter contains duplicate keys
+type DuplicateKeyError struct {
+	Duplicates []DuplicateKey
+}
+
+func (e *DuplicateKeyError) Error() string {
+	parts := make([]string, 0, len(e.Duplicates))
+	for _, dup := range e.Duplicates {
+		lines := make([]string, 0, len(dup.Lines))
+		for _, line := range dup.Lines {
+			lines = append(lines, fmt.Sprintf("%d", line))
+		}
+		parts = append(parts, fmt.Sprintf("%q (lines %s)", dup.Key, strings.Join(lines, ", ")))
+	}
+	return "duplicate keys: " + strings.Join(parts, "; ")
+}
+
+// DuplicateKeyStrategy controls how duplicate keys are resolved
+type DuplicateKeyStrategy string
+
+const (
+	KeepFirst   DuplicateKeyStrategy = "keep-first"
+	KeepLast    DuplicateKeyStrategy = "keep-last"
+	MergeArrays DuplicateKeyStrategy = "merge"
+)
+
+// ParseDuplicateKeyStrategy validates a strategy name
+func ParseDuplicateKeyStrategy(name string) (DuplicateKeyStrategy, error) {
+	switch s := DuplicateKeyStrategy(name); s {
+	case KeepFirst, KeepLast, MergeArrays:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown duplicate key strategy %q (supported: keep-first, keep-last, merge)", name)
+	}
+}
+
+// topLevelMapping decodes frontmatter into a node tree without yaml's
+// duplicate key check and returns the top-level mapping, if any
+func topLevelMapping(frontmatter string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(frontmatter), &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+// FindDuplicateKeys returns top-level keys that appear more than once in the
+// frontmatter. lineOffset is added to line numbers so they match the file.
+func FindDuplicateKeys(frontmatter string, lineOffset int) []DuplicateKey {
+	mapping, err := topLevelMapping(frontmatter)
+	if err != nil || mapping == nil {
+		return nil
+	}
+
+	var order []string
+	lines := make(map[string][]int)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if _, seen := lines[key.Value]; !seen {
+			order = append(order, key.Value)
+		}
+		lines[key.Value] = append(lines[key.Value], key.Line+lineOffset)
+	}
+
+	var duplicates []DuplicateKey
+	for _, key := range order {
+		if len(lines[key]) > 1 {
+			duplicates = append(duplicates, DuplicateKey{Key: key, Lines: lines[key]})
+		}
+	}
+	return duplicates
+}
+
+// ResolveDuplicateKeys rewrites frontmatter so each top-level key appears once.
+// Entries that are kept are copied verbatim, preserving formatting and comments.
+func ResolveDuplicateKeys(frontmatter string, strategy DuplicateKeyStrategy) (string, error) {
+	mapping, err := topLevelMapping(frontmatter)
+	if err != nil {
+		return "", err
+	}
+	if mapping == nil {
+		return frontmatter, nil
+	}
+	if mapping.Style&yaml.FlowStyle != 0 {
+		return "", fmt.Errorf("cannot resolve duplicate keys in flow-style frontmatter")
+	}
+
+	lines := strings.Split(frontmatter, "\n")
+	pairs := len(mapping.Content) / 2
+
+	// Each entry spans from its key line up to the next key
+	starts := make([]int, pairs)
+	occurrences := make(map[string][]int)
+	for p := 0; p < pairs; p++ {
+		key := mapping.Content[p*2]
+		starts[p] = key.Line - 1
+		occurrences[key.Value] = append(occurrences[key.Value], p)
+	}
+
+	drop := make(map[int]bool)
+	replace := make(map[int]string)
+	for key, indexes := range occurrences {
+		if len(indexes) < 2 {
+			continue
+		}
+
+		switch strategy {
+		case KeepFirst:
+			for _, p := range indexes[1:] {
+				drop[p] = true
+			}
+		case KeepLast:
+			for _, p := range indexes[:len(indexes)-1] {
+				drop[p] = true
+			}
+		case MergeArrays:
+			values := make([]*yaml.Node, 0, len(indexes))
+			for _, p := range indexes {
+				values = append(values, mapping.Content[p*2+1])
+			}
+			merged, err := mergeSequenceValues(key, values)
+			if err != nil {
+				return "", err
+			}
+			replace[indexes[0]] = merged
+			for _, p := range indexes[1:] {
+				drop[p] = true
+			}
+		default:
+			return "", fmt.Errorf("unknown duplicate key strategy %q", strategy)
+		}
+	}
+
+	var out []string
+	if pairs > 0 {
+		out = append(out, lines[:starts[0]]...)
+	}
+	for p := 0; p < pairs; p++ {
+		end := len(lines)
+		if p+1 < pairs {
+			end = starts[p+1]
+		}
+
+		switch {
+		case drop[p]:
+			continue
+		case replace[p] != "":
+			out = append(out, replace[p])
+		default:
+			out = append(out, lines[starts[p]:end]...)
+		}
+	}
+
+	return strings.Join(out, "\n"), nil
+}
+
+// mergeSequenceValues combines scalar and sequence values into a single
+// sequence of unique items and returns it encoded as a "key: value" entry
+func mergeSequenceValues(key string, values []*yaml.Node) (string, error) {
+	merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	seen := make(map[string]bool)
+
+	add := func(item *yaml.Node) error {
+		encoded, err := yaml.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if !seen[string(encoded)] {
+			seen[string(encoded)] = true
+			merged.Content = append(merged.Content, item)
+		}
+		return nil
+	}
+
+	for _, value := range values {
+		switch value.Kind {
+		case yaml.SequenceNode:
+			if merged.Style == 0 {
+				merged.Style = value.Style
+			}
+			for _, item := range value.Content {
+				if err := add(item); err != nil {
+					return "", err
+				}
+			}
+		case yaml.ScalarNode, yaml.AliasNode:
+			if err := add(value); err != nil {
+				return "", err
+			}
+		default:
+			return "", fmt.Errorf("cannot merge duplicate key %q: only scalar and list values can be merged", key)
+		}
+	}
+
+	entry := &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: key}, merged},
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(entry); err != nil {
+		return "", fmt.Errorf("encoding merged value for %q: %w", key, err)
+	}
+	_ = encoder.Close()
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// FixDuplicateKeys resolves duplicate frontmatter keys in a file's content.
+// It reports whether the content was changed.
+func FixDuplicateKeys(content []byte, strategy DuplicateKeyStrategy) ([]byte, bool, error) {
+	text := string(content)
+	if !strings.HasPrefix(text, "---\n") && !strings.HasPrefix(text, "---\r\n") {
+		return content, false, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	endIndex := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			endIndex = i
+			break
+		}
+	}
+	if endIndex == -1 {
+		return content, false, nil
+	}
+
+	frontmatter := strings.Join(lines[1:endIndex], "\n")
+	if len(FindDuplicateKeys(frontmatter, 0)) == 0 {
+		return content, false, nil
+	}
+
+	resolved, err := ResolveDuplicateKeys(frontmatter, strategy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var out []string
+	out = append(out, lines[0])
+	if resolved != "" {
+		out = append(out, strings.Split(resolved, "\n")...)
+	}
+	out = append(out, lines[endIndex:]...)
+
+	return []byte(strings.Join(out, "\n")), true, nil
+}
@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const duplicateContent = `---
+title: A
+tags: [a, b]
+status: draft
+tags:
+  - b
+  - c
+status: done
+---
+
+Body`
+
+func TestParse_DuplicateKeys(t *testing.T) {
+	vf := &VaultFile{}
+	err := vf.Parse([]byte(duplicateContent))
+	require.Error(t, err)
+
+	var dupErr *DuplicateKeyError
+	require.True(t, errors.As(err, &dupErr))
+	assert.Equal(t, []DuplicateKey{
+		{Key: "tags", Lines: []int{3, 5}},
+		{Key: "status", Lines: []int{4, 8}},
+	}, dupErr.Duplicates)
+}
+
+func TestFixDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		strategy DuplicateKeyStrategy
+		want     map[string]interface{}
+	}{
+		{KeepFirst, map[string]interface{}{"title": "A", "tags": []interface{}{"a", "b"}, "status": "draft"}},
+		{KeepLast, map[string]interface{}{"title": "A", "tags": []interface{}{"b", "c"}, "status": "done"}},
+		{MergeArrays, map[string]interface{}{"title": "A", "tags": []interface{}{"a", "b", "c"}, "status": []interface{}{"draft", "done"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			fixed, changed, err := FixDuplicateKeys([]byte(duplicateContent), tt.strategy)
+			require.NoError(t, err)
+			assert.True(t, changed)
+
+			vf := &VaultFile{}
+			require.NoError(t, vf.Parse(fixed))
+			assert.Equal(t, tt.want, vf.Frontmatter)
+			assert.Equal(t, "Body", vf.Body)
+			assert.Equal(t, []string{"title", "tags", "status"}, vf.frontmatterOrder)
+		})
+	}
+}
+
+func TestFixDuplicateKeys_MergeRejectsMappings(t *testing.T) {
+	content := "---\nmeta:\n  a: 1\nmeta:\n  b: 2\n---\n"
+	_, _, err := FixDuplicateKeys([]byte(content), MergeArrays)
+	assert.Error(t, err)
+
+	unchanged, changed, err := FixDuplicateKeys([]byte("---\ntitle: A\n---\n"), KeepFirst)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, "---\ntitle: A\n---\n", string(unchanged))
+}
@@ -53,8 +53,10 @@ type VaultFile struct {
 	RelativePath        string
 	Content             []byte
 	Frontmatter         map[string]interface{}
-	frontmatterOrder    []string // Preserve original field order
-	originalFrontmatter string   // Store original frontmatter text for reference
+	FrontmatterFormat   FrontmatterFormat // Syntax of the frontmatter block; empty means YAML
+	frontmatterOrder    []string          // Preserve original field order
+	originalFrontmatter string            // Store original frontmatter text for reference
+	originalFormat      FrontmatterFormat // Syntax originalFrontmatter was parsed as
 	Body                string
 	Links               []Link
 	Headings            []Heading
@@ -284,6 +286,16 @@ type Heading struct {
 func (vf *VaultFile) Parse(content []byte) error {
 	vf.Content = content
 	vf.Frontmatter = make(map[string]interface{})
+	vf.FrontmatterFormat = FormatYAML
+	vf.originalFormat = FormatYAML
+
+	// TOML (+++) and JSON ({...}) frontmatter, as used by Hugo and some importers
+	if bytes.HasPrefix(content, []byte("+++\n")) || bytes.HasPrefix(content, []byte("+++\r\n")) {
+		return vf.parseTOML(content)
+	}
+	if bytes.HasPrefix(content, []byte("{")) && vf.parseJSON(content) {
+		return nil
+	}
 
 	// Check for frontmatter
 	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
@@ -329,6 +341,11 @@ func (vf *VaultFile) Parse(content []byte) error {
 
 		// Parse YAML content
 		if err := yaml.Unmarshal([]byte(frontmatterContent), &vf.Frontmatter); err != nil {
+			// yaml reports only the first duplicate key; collect all of them with
+			// file line numbers (frontmatter starts on line 2)
+			if duplicates := FindDuplicateKeys(frontmatterContent, 1); len(duplicates) > 0 {
+				return fmt.Errorf("parsing frontmatter: %w", &DuplicateKeyError{Duplicates: duplicates})
+			}
 			return fmt.Errorf("parsing frontmatter: %w", err)
 		}
 
@@ -338,14 +355,7 @@ func (vf *VaultFile) Parse(content []byte) error {
 	}
 
 	// Extract body (everything after closing ---)
-	if endIndex+1 < len(lines) {
-		bodyLines := lines[endIndex+1:]
-		// Remove leading empty line if present
-		if len(bodyLines) > 0 && strings.TrimSpace(bodyLines[0]) == "" {
-			bodyLines = bodyLines[1:]
-		}
-		vf.Body = strings.Join(bodyLines, "\n")
-	}
+	vf.Body = bodyAfter(lines, endIndex)
 
 	return nil
 }
@@ -354,18 +364,25 @@ func (vf *VaultFile) Parse(content []byte) error {
 func (vf *VaultFile) Serialize() ([]byte, error) {
 	var buf bytes.Buffer
 
-	// Write frontmatter if it exists and is not empty
+	// Write frontmatter if it exists and is not empty, in the file's original format
 	if len(vf.Frontmatter) > 0 {
-		buf.WriteString("---\n")
-
-		// Serialize frontmatter preserving order
-		frontmatterYAML, err := vf.serializeFrontmatterWithOrder()
+		var frontmatter string
+		var err error
+		switch vf.FrontmatterFormat {
+		case FormatTOML:
+			frontmatter, err = vf.serializeTOML()
+		case FormatJSON:
+			frontmatter, err = vf.serializeJSON()
+		default:
+			// Serialize frontmatter preserving order
+			frontmatter, err = vf.serializeFrontmatterWithOrder()
+			frontmatter = "---\n" + frontmatter + "---\n"
+		}
 		if err != nil {
 			return nil, fmt.Errorf("marshaling frontmatter: %w", err)
 		}
 
-		buf.WriteString(frontmatterYAML)
-		buf.WriteString("---\n")
+		buf.WriteString(frontmatter)
 
 		// Add blank line after frontmatter if body exists
 		if vf.Body != "" {
@@ -384,6 +401,48 @@ func (vf *VaultFile) HasFrontmatter() bool {
 	return len(vf.Frontmatter) > 0
 }
 
+// Lock markers that protect a file from modification by mutating commands
+const (
+	// LockField is the frontmatter field checked for a lock value
+	LockField = "mdnotes"
+	// LockValue is the LockField value that locks a file
+	LockValue = "locked"
+	// LockMarker is an HTML comment that locks a file when present in the body
+	LockMarker = "<!-- mdnotes:locked -->"
+	// LockMarkerObsidian is the Obsidian comment form of LockMarker
+	LockMarkerObsidian = "%% mdnotes:locked %%"
+)
+
+// LockReason returns a description of why the file is locked against modification,
+// or an empty string if the file is not locked.
+// A file is locked by "mdnotes: locked" (or "mdnotes: {locked: true}") in its
+// frontmatter, or by a lock marker comment anywhere in its body.
+func (vf *VaultFile) LockReason() string {
+	if value, exists := vf.Frontmatter[LockField]; exists {
+		switch v := value.(type) {
+		case string:
+			if strings.EqualFold(strings.TrimSpace(v), LockValue) {
+				return "frontmatter 'mdnotes: locked'"
+			}
+		case map[string]interface{}:
+			if locked, ok := v["locked"].(bool); ok && locked {
+				return "frontmatter 'mdnotes.locked: true'"
+			}
+		}
+	}
+
+	if strings.Contains(vf.Body, LockMarker) || strings.Contains(vf.Body, LockMarkerObsidian) {
+		return "lock marker comment"
+	}
+
+	return ""
+}
+
+// IsLocked returns true if the file carries a do-not-modify marker
+func (vf *VaultFile) IsLocked() bool {
+	return vf.LockReason() != ""
+}
+
 // GetField returns a frontmatter field value
 func (vf *VaultFile) GetField(key string) (interface{}, bool) {
 	value, exists := vf.Frontmatter[key]
@@ -402,6 +461,32 @@ func (vf *VaultFile) SetField(key string, value interface{}) {
 	vf.Frontmatter[key] = value
 }
 
+// RenameField moves a frontmatter field to a new key, keeping its position.
+// Any existing value under the new key is replaced. Returns false if the field
+// doesn't exist.
+func (vf *VaultFile) RenameField(from, to string) bool {
+	value, exists := vf.Frontmatter[from]
+	if !exists {
+		return false
+	}
+	delete(vf.Frontmatter, from)
+	vf.Frontmatter[to] = value
+
+	order := make([]string, 0, len(vf.frontmatterOrder))
+	for _, key := range vf.frontmatterOrder {
+		switch key {
+		case to:
+			continue
+		case from:
+			order = append(order, to)
+		default:
+			order = append(order, key)
+		}
+	}
+	vf.frontmatterOrder = order
+	return true
+}
+
 // extractFieldOrder extracts the order of fields from the original YAML content
 func extractFieldOrder(yamlContent string) []string {
 	var order []string
@@ -442,23 +527,52 @@ func extractFieldOrder(yamlContent string) []string {
 	return order
 }
 
-// serializeFrontmatterWithOrder serializes frontmatter while preserving field order
+// serializeFrontmatterWithOrder serializes frontmatter while preserving field
+// order. Fields that haven't changed since the file was parsed are written
+// back verbatim, along with comments, so edits only touch the fields they change.
 func (vf *VaultFile) serializeFrontmatterWithOrder() (string, error) {
 	if len(vf.Frontmatter) == 0 {
 		return "", nil
 	}
 
+	var layout *yamlLayout
+	if vf.originalFrontmatter != "" && vf.originalFormat == FormatYAML {
+		layout = parseYAMLLayout(vf.originalFrontmatter)
+	}
+
 	var lines []string
 	processedKeys := make(map[string]bool)
 
+	write := func(key string) error {
+		value := vf.Frontmatter[key]
+		if layout != nil {
+			if seg, ok := layout.segments[key]; ok {
+				segLines, err := seg.render(key, value)
+				if err != nil {
+					return fmt.Errorf("formatting field %s: %w", key, err)
+				}
+				lines = append(lines, segLines...)
+				return nil
+			}
+		}
+		yamlLine, err := formatYAMLField(key, value)
+		if err != nil {
+			return fmt.Errorf("formatting field %s: %w", key, err)
+		}
+		lines = append(lines, yamlLine)
+		return nil
+	}
+
+	if layout != nil {
+		lines = append(lines, layout.preamble...)
+	}
+
 	// First, write fields in their original order
 	for _, key := range vf.frontmatterOrder {
-		if value, exists := vf.Frontmatter[key]; exists {
-			yamlLine, err := formatYAMLField(key, value)
-			if err != nil {
-				return "", fmt.Errorf("formatting field %s: %w", key, err)
+		if _, exists := vf.Frontmatter[key]; exists && !processedKeys[key] {
+			if err := write(key); err != nil {
+				return "", err
 			}
-			lines = append(lines, yamlLine)
 			processedKeys[key] = true
 		}
 	}
@@ -475,14 +589,16 @@ func (vf *VaultFile) serializeFrontmatterWithOrder() (string, error) {
 	sort.Strings(newKeys)
 
 	for _, key := range newKeys {
-		yamlLine, err := formatYAMLField(key, vf.Frontmatter[key])
-		if err != nil {
-			return "", fmt.Errorf("formatting field %s: %w", key, err)
+		if err := write(key); err != nil {
+			return "", err
 		}
-		lines = append(lines, yamlLine)
 	}
 
-	return strings.Join(lines, "\n") + "\n", nil
+	if layout != nil {
+		lines = append(lines, layout.postamble...)
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n", nil
 }
 
 // formatYAMLField formats a single YAML field properly
@@ -160,3 +160,54 @@ Content here.`,
 		})
 	}
 }
+
+func TestVaultFile_LockReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		locked  bool
+	}{
+		{
+			name:    "frontmatter lock",
+			content: "---\nmdnotes: locked\ntitle: Generated\n---\n\nBody",
+			locked:  true,
+		},
+		{
+			name:    "nested frontmatter lock",
+			content: "---\nmdnotes:\n  locked: true\n---\n\nBody",
+			locked:  true,
+		},
+		{
+			name:    "html marker comment",
+			content: "# Generated\n\n<!-- mdnotes:locked -->\nBody",
+			locked:  true,
+		},
+		{
+			name:    "obsidian marker comment",
+			content: "%% mdnotes:locked %%\n# Generated",
+			locked:  true,
+		},
+		{
+			name:    "other mdnotes value",
+			content: "---\nmdnotes: managed\n---\n\nBody",
+			locked:  false,
+		},
+		{
+			name:    "no marker",
+			content: "---\ntitle: Plain\n---\n\nBody",
+			locked:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vf := &VaultFile{}
+			if err := vf.Parse([]byte(tt.content)); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := vf.IsLocked(); got != tt.locked {
+				t.Errorf("IsLocked() = %v, want %v (reason %q)", got, tt.locked, vf.LockReason())
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// FrontmatterFormat identifies the syntax of a file's frontmatter block
+type FrontmatterFormat string
+
+const (
+	// FormatYAML is frontmatter between --- delimiters (the default)
+	FormatYAML FrontmatterFormat = "yaml"
+	// FormatTOML is frontmatter between +++ delimiters
+	FormatTOML FrontmatterFormat = "toml"
+	// FormatJSON is a JSON object at the start of the file
+	FormatJSON FrontmatterFormat = "json"
+)
+
+// ParseFrontmatterFormat validates a frontmatter format name
+func ParseFrontmatterFormat(name string) (FrontmatterFormat, error) {
+	switch f := FrontmatterFormat(strings.ToLower(name)); f {
+	case FormatYAML, FormatTOML, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown frontmatter format %q (supported: yaml, toml, json)", name)
+	}
+}
+
+// tomlKeyRegex matches top-level TOML keys and table headers
+var tomlKeyRegex = regexp.MustCompile(`^(?:\[\[?\s*("[^"]+"|[A-Za-z0-9_-]+)|("[^"]+"|[A-Za-z0-9_-]+)\s*=)`)
+
+// parseTOML parses a file with +++ delimited TOML frontmatter
+func (vf *VaultFile) parseTOML(content []byte) error {
+	lines := strings.Split(string(content), "\n")
+
+	endIndex := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "+++" {
+			endIndex = i
+			break
+		}
+	}
+	if endIndex == -1 {
+		vf.Body = string(content)
+		return nil
+	}
+
+	frontmatterContent := strings.Join(lines[1:endIndex], "\n")
+	if strings.TrimSpace(frontmatterContent) != "" {
+		vf.originalFrontmatter = frontmatterContent
+
+		var fm map[string]interface{}
+		if err := toml.Unmarshal([]byte(frontmatterContent), &fm); err != nil {
+			return fmt.Errorf("parsing TOML frontmatter: %w", err)
+		}
+		for key, value := range fm {
+			vf.Frontmatter[key] = normalizeTOMLValue(value)
+		}
+
+		// Keys after a table header belong to that table, so only headers count from then on
+		inTable := false
+		for _, line := range lines[1:endIndex] {
+			m := tomlKeyRegex.FindStringSubmatch(line)
+			if m == nil || (inTable && m[1] == "") {
+				continue
+			}
+			if m[1] != "" {
+				inTable = true
+			}
+
+			key := strings.Trim(m[1]+m[2], `"`)
+			if !containsString(vf.frontmatterOrder, key) {
+				vf.frontmatterOrder = append(vf.frontmatterOrder, key)
+			}
+		}
+	}
+
+	vf.FrontmatterFormat = FormatTOML
+	vf.originalFormat = FormatTOML
+	vf.Body = bodyAfter(lines, endIndex)
+	return nil
+}
+
+// parseJSON parses a file that begins with a JSON object. It reports false when
+// the content does not start with a complete JSON object on its own lines, in
+// which case the file is treated as having no frontmatter.
+func (vf *VaultFile) parseJSON(content []byte) bool {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return false
+	}
+
+	fm := make(map[string]interface{})
+	var order []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return false
+		}
+
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return false
+		}
+		fm[key] = normalizeJSONValue(value)
+		if !containsString(order, key) {
+			order = append(order, key)
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return false
+	}
+
+	offset := int(decoder.InputOffset())
+	rest := content[offset:]
+	if len(rest) > 0 && !bytes.HasPrefix(rest, []byte("\n")) && !bytes.HasPrefix(rest, []byte("\r\n")) {
+		return false
+	}
+
+	vf.Frontmatter = fm
+	vf.frontmatterOrder = order
+	vf.originalFrontmatter = string(content[:offset])
+	vf.FrontmatterFormat = FormatJSON
+	vf.originalFormat = FormatJSON
+
+	lines := strings.Split(string(rest), "\n")
+	vf.Body = bodyAfter(lines, 0)
+	return true
+}
+
+// bodyAfter returns the lines following the closing frontmatter line, dropping
+// one leading blank line
+func bodyAfter(lines []string, endIndex int) string {
+	if endIndex+1 >= len(lines) {
+		return ""
+	}
+	bodyLines := lines[endIndex+1:]
+	if len(bodyLines) > 0 && strings.TrimSpace(bodyLines[0]) == "" {
+		bodyLines = bodyLines[1:]
+	}
+	return strings.Join(bodyLines, "\n")
+}
+
+// normalizeTOMLValue converts TOML-specific types to the types YAML parsing produces
+func normalizeTOMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return int(v)
+	case toml.LocalDate:
+		return Date{Time: v.AsTime(time.UTC)}
+	case toml.LocalDateTime:
+		return Date{Time: v.AsTime(time.UTC)}
+	case time.Time:
+		return Date{Time: v}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeTOMLValue(item)
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeTOMLValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// normalizeJSONValue converts json.Number to int or float64 like YAML parsing would
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONValue(item)
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// orderedKeys returns frontmatter keys in their original order, followed by
+// new keys sorted alphabetically
+func (vf *VaultFile) orderedKeys() []string {
+	var keys []string
+	processed := make(map[string]bool)
+	for _, key := range vf.frontmatterOrder {
+		if _, exists := vf.Frontmatter[key]; exists && !processed[key] {
+			keys = append(keys, key)
+			processed[key] = true
+		}
+	}
+
+	var newKeys []string
+	for key := range vf.Frontmatter {
+		if !processed[key] {
+			newKeys = append(newKeys, key)
+		}
+	}
+	sort.Strings(newKeys)
+
+	return append(keys, newKeys...)
+}
+
+// serializeTOML renders frontmatter as a +++ delimited TOML block. Tables are
+// written after plain keys, since TOML keys following a table header belong to it.
+func (vf *VaultFile) serializeTOML() (string, error) {
+	var plain, tables []string
+	for _, key := range vf.orderedKeys() {
+		value, err := tomlValue(vf.Frontmatter[key])
+		if err != nil {
+			return "", fmt.Errorf("formatting field %s: %w", key, err)
+		}
+
+		data, err := toml.Marshal(map[string]interface{}{key: value})
+		if err != nil {
+			return "", fmt.Errorf("formatting field %s: %w", key, err)
+		}
+
+		if isTOMLTable(value) {
+			tables = append(tables, strings.TrimRight(string(data), "\n"))
+		} else {
+			plain = append(plain, strings.TrimRight(string(data), "\n"))
+		}
+	}
+
+	var buf strings.Builder
+	buf.WriteString("+++\n")
+	for _, line := range plain {
+		buf.WriteString(line + "\n")
+	}
+	for _, table := range tables {
+		buf.WriteString("\n" + table + "\n")
+	}
+	buf.WriteString("+++\n")
+	return buf.String(), nil
+}
+
+// tomlValue converts a frontmatter value to a type TOML can encode natively
+func tomlValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("TOML cannot represent null values")
+	case Date:
+		hour, min, sec := v.Clock()
+		date := toml.LocalDate{Year: v.Year(), Month: int(v.Month()), Day: v.Day()}
+		if hour == 0 && min == 0 && sec == 0 {
+			return date, nil
+		}
+		return toml.LocalDateTime{LocalDate: date, LocalTime: toml.LocalTime{Hour: hour, Minute: min, Second: sec}}, nil
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := tomlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := tomlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = converted
+		}
+		return m, nil
+	default:
+		return value, nil
+	}
+}
+
+// isTOMLTable reports whether a value is encoded as a table or array of tables
+func isTOMLTable(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		if len(v) == 0 {
+			return false
+		}
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// serializeJSON renders frontmatter as an indented JSON object, preserving key order
+func (vf *VaultFile) serializeJSON() (string, error) {
+	keys := vf.orderedKeys()
+
+	var buf strings.Builder
+	buf.WriteString("{\n")
+	for i, key := range keys {
+		keyJSON, _ := json.Marshal(key)
+		valueJSON, err := json.MarshalIndent(jsonValue(vf.Frontmatter[key]), "  ", "  ")
+		if err != nil {
+			return "", fmt.Errorf("formatting field %s: %w", key, err)
+		}
+
+		buf.WriteString("  " + string(keyJSON) + ": " + string(valueJSON))
+		if i < len(keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
+
+// jsonValue converts dates to their YAML string form for JSON encoding
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Date:
+		hour, min, sec := v.Clock()
+		if hour == 0 && min == 0 && sec == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05")
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = jsonValue(item)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = jsonValue(item)
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_TOMLFrontmatter(t *testing.T) {
+	content := "+++\ntitle = \"Hugo post\"\ndate = 2023-04-05\ntags = [\"a\", \"b\"]\nweight = 3\n\n[params]\nauthor = \"me\"\n+++\n\nBody text"
+
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+
+	assert.Equal(t, FormatTOML, vf.FrontmatterFormat)
+	assert.Equal(t, "Hugo post", vf.Frontmatter["title"])
+	assert.Equal(t, 3, vf.Frontmatter["weight"])
+	assert.Equal(t, []interface{}{"a", "b"}, vf.Frontmatter["tags"])
+	assert.Equal(t, Date{Time: time.Date(2023, 4, 5, 0, 0, 0, 0, time.UTC)}, vf.Frontmatter["date"])
+	assert.Equal(t, map[string]interface{}{"author": "me"}, vf.Frontmatter["params"])
+	assert.Equal(t, []string{"title", "date", "tags", "weight", "params"}, vf.frontmatterOrder)
+	assert.Equal(t, "Body text", vf.Body)
+
+	// Round trip keeps the TOML format
+	vf.SetField("status", "draft")
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+
+	reparsed := &VaultFile{}
+	require.NoError(t, reparsed.Parse(out))
+	assert.Equal(t, FormatTOML, reparsed.FrontmatterFormat)
+	assert.Equal(t, vf.Frontmatter, reparsed.Frontmatter)
+	assert.Equal(t, "Body text", reparsed.Body)
+}
+
+func TestParse_JSONFrontmatter(t *testing.T) {
+	content := "{\n  \"title\": \"JSON note\",\n  \"rating\": 4.5,\n  \"count\": 2\n}\n\nJSON body"
+
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+
+	assert.Equal(t, FormatJSON, vf.FrontmatterFormat)
+	assert.Equal(t, map[string]interface{}{"title": "JSON note", "rating": 4.5, "count": 2}, vf.Frontmatter)
+	assert.Equal(t, []string{"title", "rating", "count"}, vf.frontmatterOrder)
+	assert.Equal(t, "JSON body", vf.Body)
+
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, content, string(out))
+}
+
+func TestParse_BraceBodyIsNotFrontmatter(t *testing.T) {
+	content := "{not json} just text"
+
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+	assert.Empty(t, vf.Frontmatter)
+	assert.Equal(t, content, vf.Body)
+}
+
+func TestSerialize_ConvertFormat(t *testing.T) {
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte("+++\ntitle = \"A\"\ncount = 1\n+++\nBody")))
+
+	vf.FrontmatterFormat = FormatYAML
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: A\ncount: 1\n---\n\nBody", string(out))
+
+	vf.FrontmatterFormat = FormatTOML
+	vf.SetField("empty", nil)
+	_, err = vf.Serialize()
+	assert.Error(t, err, "TOML has no null")
+}
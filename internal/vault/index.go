@@ -0,0 +1,611 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
+)
+
+// DefaultIndexFile is the vault index location relative to the vault root
+const DefaultIndexFile = ".mdnotes/index.gob"
+
+// indexVersion is bumped whenever indexed data changes meaning, discarding older indexes
+const indexVersion = 1
+
+// LinkParser populates a file's Links field (implemented by processor.LinkParser)
+type LinkParser interface {
+	UpdateFile(file *VaultFile)
+}
+
+// Index persists parsed frontmatter, headings and links for every file in a
+// vault so repeated scans only re-parse files whose modification time or size
+// changed.
+type Index struct {
+	root       string
+	linkParser LinkParser
+	mu         sync.Mutex // Guards Entries and the counters during parallel scans
+	hits       int
+	misses     int
+	dirty      bool
+
+	Version int
+	Entries map[string]*IndexEntry
+
+	// WebLinks caches what checking web links found, by URL, for
+	// 'links check --external'
+	WebLinks map[string]WebLinkCheck
+}
+
+// WebLinkCheck is the result of checking a web link
+type WebLinkCheck struct {
+	Status   string
+	Code     int
+	Location string
+	Error    string
+	Checked  time.Time
+}
+
+// IndexEntry holds the parsed form of one file, valid while ModTime and Size match
+type IndexEntry struct {
+	ModTime     int64 // UnixNano
+	Size        int64
+	Format      FrontmatterFormat
+	Frontmatter map[string]indexValue
+	Order       []string
+	Original    string
+	BodyStart   int // byte offset of the body within the file
+	Headings    []Heading
+	Links       []Link
+	LinksParsed bool
+
+	// Embedding is the note's vector from EmbeddingModel, set by 'index embed'.
+	// Re-parsing a changed note replaces the entry, dropping a stale vector.
+	Embedding      []float32
+	EmbeddingModel string
+
+	// Results holds what analyses found in the note, by name, for 'analyze'
+	// to reuse; like the embedding, they are dropped when the note changes
+	Results map[string][]byte
+}
+
+// Kinds of indexed frontmatter values
+const (
+	kindNil uint8 = iota
+	kindString
+	kindInt
+	kindFloat
+	kindBool
+	kindDate
+	kindTime
+	kindList
+	kindMap
+)
+
+// indexValue is a frontmatter value with its Go type recorded, so values
+// decode to exactly what parsing produced (Date vs time.Time, int vs float64,
+// empty vs missing lists) and each lookup gets its own copy
+type indexValue struct {
+	Kind   uint8
+	String string
+	Int    int
+	Float  float64
+	Bool   bool
+	Time   time.Time
+	List   []indexValue
+	Map    map[string]indexValue
+}
+
+// NewIndex creates an empty index for the vault at root
+func NewIndex(root string) *Index {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Index{
+		root:    root,
+		Version: indexVersion,
+		Entries: make(map[string]*IndexEntry),
+	}
+}
+
+// LoadIndex loads the index of the vault at root. It returns an error wrapping
+// os.ErrNotExist if no index has been built. An unreadable or outdated index
+// yields an empty one, since that only costs a full re-parse.
+func LoadIndex(root string) (*Index, error) {
+	idx := NewIndex(root)
+
+	data, err := os.ReadFile(idx.Path())
+	if err != nil {
+		return nil, fmt.Errorf("reading vault index: %w", err)
+	}
+
+	var loaded Index
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&loaded); err != nil || loaded.Version != indexVersion || loaded.Entries == nil {
+		idx.dirty = true
+		return idx, nil
+	}
+	idx.Entries = loaded.Entries
+	idx.WebLinks = loaded.WebLinks
+	return idx, nil
+}
+
+// FindIndex returns the index covering path, searching path and its parent
+// directories for a built index. It returns nil if there is none, so scans
+// only use an index once 'mdnotes index build' has created one.
+func FindIndex(path string) *Index {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, DefaultIndexFile)); err == nil {
+			idx, err := LoadIndex(dir)
+			if err != nil {
+				return nil
+			}
+			return idx
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// RemoveIndex deletes the index of the vault at root, if any
+func RemoveIndex(root string) error {
+	err := os.Remove(NewIndex(root).Path())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing vault index: %w", err)
+	}
+	return nil
+}
+
+// SetLinkParser makes the index parse and store links for files it (re)parses
+func (idx *Index) SetLinkParser(parser LinkParser) {
+	idx.linkParser = parser
+}
+
+// Root returns the vault root the index covers
+func (idx *Index) Root() string {
+	return idx.root
+}
+
+// Path returns the index file location
+func (idx *Index) Path() string {
+	return filepath.Join(idx.root, DefaultIndexFile)
+}
+
+// Len returns the number of indexed files
+func (idx *Index) Len() int {
+	return len(idx.Entries)
+}
+
+// Stats returns how many files were served from the index and how many were
+// parsed since it was loaded
+func (idx *Index) Stats() (reused, parsed int) {
+	return idx.hits, idx.misses
+}
+
+// IsCurrent reports whether path has an index entry matching its current
+// modification time and size
+func (idx *Index) IsCurrent(path string) bool {
+	entry, ok := idx.Entries[idx.key(path)]
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size()
+}
+
+// Embedding returns the stored vector for path if it was made by model and
+// the note hasn't changed since
+func (idx *Index) Embedding(path, model string) ([]float32, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.Entries[idx.key(path)]
+	if !ok || entry.EmbeddingModel != model || entry.Embedding == nil {
+		return nil, false
+	}
+	return entry.Embedding, true
+}
+
+// SetEmbedding stores the vector model made for path. It reports false if
+// path isn't indexed, since there is nowhere to keep the vector.
+func (idx *Index) SetEmbedding(path, model string, vector []float32) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.Entries[idx.key(path)]
+	if !ok {
+		return false
+	}
+	entry.Embedding = vector
+	entry.EmbeddingModel = model
+	idx.dirty = true
+	return true
+}
+
+// current returns the entry for file if it was indexed as file is now, by
+// modification time and size. Callers hold idx.mu.
+func (idx *Index) current(file *VaultFile) (*IndexEntry, bool) {
+	entry, ok := idx.Entries[idx.key(file.Path)]
+	if !ok || entry.ModTime != file.Modified.UnixNano() || entry.Size != int64(len(file.Content)) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// Links returns the stored links of file if they have been parsed and file
+// is unchanged since it was indexed
+func (idx *Index) Links(file *VaultFile) ([]Link, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.current(file)
+	if !ok || !entry.LinksParsed {
+		return nil, false
+	}
+	return append([]Link{}, entry.Links...), true
+}
+
+// SetLinks stores the parsed links of file. It reports false if file isn't
+// indexed as it is now, since the links may not match the indexed note.
+func (idx *Index) SetLinks(file *VaultFile, links []Link) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.current(file)
+	if !ok {
+		return false
+	}
+	entry.Links = append([]Link(nil), links...)
+	entry.LinksParsed = true
+	idx.dirty = true
+	return true
+}
+
+// Result returns the analysis result stored for file under name, if file is
+// unchanged since it was indexed
+func (idx *Index) Result(file *VaultFile, name string) ([]byte, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.current(file)
+	if !ok {
+		return nil, false
+	}
+	value, ok := entry.Results[name]
+	return value, ok
+}
+
+// SetResult stores an analysis result for file under name. It reports false
+// if file isn't indexed as it is now.
+func (idx *Index) SetResult(file *VaultFile, name string, value []byte) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.current(file)
+	if !ok {
+		return false
+	}
+	if entry.Results == nil {
+		entry.Results = make(map[string][]byte)
+	}
+	entry.Results[name] = value
+	idx.dirty = true
+	return true
+}
+
+// WebLink returns the stored result of checking url if it was checked
+// within maxAge
+func (idx *Index) WebLink(url string, maxAge time.Duration) (WebLinkCheck, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	check, ok := idx.WebLinks[url]
+	if !ok || time.Since(check.Checked) > maxAge {
+		return WebLinkCheck{}, false
+	}
+	return check, true
+}
+
+// SetWebLink stores the result of checking url
+func (idx *Index) SetWebLink(url string, check WebLinkCheck) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.WebLinks == nil {
+		idx.WebLinks = make(map[string]WebLinkCheck)
+	}
+	idx.WebLinks[url] = check
+	idx.dirty = true
+}
+
+// Prune drops entries for files that no longer exist and returns how many were removed
+func (idx *Index) Prune() int {
+	removed := 0
+	for key := range idx.Entries {
+		if _, err := os.Stat(filepath.Join(idx.root, filepath.FromSlash(key))); os.IsNotExist(err) {
+			delete(idx.Entries, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		idx.dirty = true
+	}
+	return removed
+}
+
+// Save writes the index if anything changed
+func (idx *Index) Save() error {
+	if !idx.dirty {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(idx); err != nil {
+		return fmt.Errorf("encoding vault index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.Path()), 0755); err != nil {
+		return fmt.Errorf("creating index directory: %w", err)
+	}
+
+	if err := safety.WriteFile(idx.Path(), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing vault index: %w", err)
+	}
+	idx.dirty = false
+	return nil
+}
+
+// loadFile returns the parsed file at path, reusing the indexed result when
+// the file is unchanged and re-parsing (and re-indexing) it otherwise. The
+// content is always read, since callers rely on Content and Body. It is safe
+// to call from several goroutines.
+func (idx *Index) loadFile(path, relPath string) (*VaultFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vf := &VaultFile{
+		Path:         path,
+		RelativePath: relPath,
+		Modified:     info.ModTime(),
+	}
+
+	key := idx.key(path)
+	if idx.restoreEntry(key, vf, content, info) {
+		return vf, nil
+	}
+
+	if err := vf.Parse(content); err != nil {
+		idx.mu.Lock()
+		defer idx.mu.Unlock()
+		idx.misses++
+		if _, ok := idx.Entries[key]; ok {
+			delete(idx.Entries, key)
+			idx.dirty = true
+		}
+		return nil, err
+	}
+	vf.Headings = ExtractHeadings(vf.Body)
+	if idx.linkParser != nil {
+		idx.linkParser.UpdateFile(vf)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.misses++
+	idx.store(key, vf, info)
+	return vf, nil
+}
+
+// restoreEntry fills vf from the index entry for key if the file is unchanged,
+// reporting whether it did
+func (idx *Index) restoreEntry(key string, vf *VaultFile, content []byte, info os.FileInfo) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.Entries[key]
+	if !ok || entry.ModTime != info.ModTime().UnixNano() || entry.Size != info.Size() {
+		return false
+	}
+	if !entry.restore(vf, content, idx.linkParser != nil) {
+		return false
+	}
+
+	idx.hits++
+	if idx.linkParser != nil && !entry.LinksParsed {
+		idx.linkParser.UpdateFile(vf)
+		entry.Links = append([]Link(nil), vf.Links...)
+		entry.LinksParsed = true
+		idx.dirty = true
+	}
+	return true
+}
+
+// store records a freshly parsed file. Files whose frontmatter holds types the
+// index can't represent
+// are left out of the index and simply parsed on every scan.
+func (idx *Index) store(key string, vf *VaultFile, info os.FileInfo) {
+	delete(idx.Entries, key)
+	idx.dirty = true
+
+	if !bytes.HasSuffix(vf.Content, []byte(vf.Body)) {
+		return
+	}
+	frontmatter, ok := encodeMap(vf.Frontmatter)
+	if !ok {
+		return
+	}
+
+	idx.Entries[key] = &IndexEntry{
+		ModTime:     info.ModTime().UnixNano(),
+		Size:        info.Size(),
+		Format:      vf.FrontmatterFormat,
+		Frontmatter: frontmatter,
+		Order:       append([]string(nil), vf.frontmatterOrder...),
+		Original:    vf.originalFrontmatter,
+		BodyStart:   len(vf.Content) - len(vf.Body),
+		Headings:    append([]Heading(nil), vf.Headings...),
+		Links:       append([]Link(nil), vf.Links...),
+		LinksParsed: idx.linkParser != nil,
+	}
+}
+
+// key returns the index key for path: its slash-separated path relative to the vault root
+func (idx *Index) key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(idx.root, abs); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// restore fills vf from the entry, reporting false if the entry is unusable.
+// Links are only filled in when requested, so files look the same to callers
+// that don't parse links whether or not they came from the index.
+func (e *IndexEntry) restore(vf *VaultFile, content []byte, withLinks bool) bool {
+	if e.BodyStart < 0 || e.BodyStart > len(content) {
+		return false
+	}
+	vf.Content = content
+	vf.Frontmatter = decodeMap(e.Frontmatter)
+	vf.FrontmatterFormat = e.Format
+	vf.originalFormat = e.Format
+	vf.frontmatterOrder = append([]string(nil), e.Order...)
+	vf.originalFrontmatter = e.Original
+	vf.Body = string(content[e.BodyStart:])
+	vf.Headings = append([]Heading(nil), e.Headings...)
+	if withLinks && e.LinksParsed {
+		vf.Links = append([]Link{}, e.Links...)
+	}
+	return true
+}
+
+// encodeValue converts a parsed frontmatter value, reporting false for types
+// parsing doesn't produce
+func encodeValue(value interface{}) (indexValue, bool) {
+	switch v := value.(type) {
+	case nil:
+		return indexValue{Kind: kindNil}, true
+	case string:
+		return indexValue{Kind: kindString, String: v}, true
+	case int:
+		return indexValue{Kind: kindInt, Int: v}, true
+	case float64:
+		return indexValue{Kind: kindFloat, Float: v}, true
+	case bool:
+		return indexValue{Kind: kindBool, Bool: v}, true
+	case Date:
+		return indexValue{Kind: kindDate, Time: v.Time}, true
+	case time.Time:
+		return indexValue{Kind: kindTime, Time: v}, true
+	case []interface{}:
+		list := make([]indexValue, len(v))
+		for i, item := range v {
+			encoded, ok := encodeValue(item)
+			if !ok {
+				return indexValue{}, false
+			}
+			list[i] = encoded
+		}
+		return indexValue{Kind: kindList, List: list}, true
+	case map[string]interface{}:
+		m, ok := encodeMap(v)
+		return indexValue{Kind: kindMap, Map: m}, ok
+	}
+	return indexValue{}, false
+}
+
+func encodeMap(m map[string]interface{}) (map[string]indexValue, bool) {
+	encoded := make(map[string]indexValue, len(m))
+	for key, value := range m {
+		v, ok := encodeValue(value)
+		if !ok {
+			return nil, false
+		}
+		encoded[key] = v
+	}
+	return encoded, true
+}
+
+// decodeValue is the inverse of encodeValue
+func decodeValue(v indexValue) interface{} {
+	switch v.Kind {
+	case kindString:
+		return v.String
+	case kindInt:
+		return v.Int
+	case kindFloat:
+		return v.Float
+	case kindBool:
+		return v.Bool
+	case kindDate:
+		return Date{Time: v.Time}
+	case kindTime:
+		return v.Time
+	case kindList:
+		list := make([]interface{}, len(v.List))
+		for i, item := range v.List {
+			list[i] = decodeValue(item)
+		}
+		return list
+	case kindMap:
+		return decodeMap(v.Map)
+	}
+	return nil
+}
+
+func decodeMap(m map[string]indexValue) map[string]interface{} {
+	decoded := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		decoded[key] = decodeValue(value)
+	}
+	return decoded
+}
+
+var headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
+
+// ExtractHeadings parses the markdown headings in body, skipping fenced code blocks
+func ExtractHeadings(body string) []Heading {
+	var headings []Heading
+	inCodeBlock := false
+	for i, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+		if matches := headingRegex.FindStringSubmatch(trimmed); matches != nil {
+			headings = append(headings, Heading{
+				Level: len(matches[1]),
+				Text:  strings.TrimSpace(matches[2]),
+				Line:  i + 1,
+			})
+		}
+	}
+	return headings
+}
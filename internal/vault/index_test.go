@@ -0,0 +1,172 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingLinkParser counts parses and gives every file the same link
+type countingLinkParser struct {
+	calls int
+}
+
+func (p *countingLinkParser) UpdateFile(file *VaultFile) {
+	p.calls++
+	file.Links = []Link{{Type: WikiLink, Target: "parsed"}}
+}
+
+func scanWithIndex(t *testing.T, dir string, idx *Index) map[string]*VaultFile {
+	t.Helper()
+	files, err := NewScanner(WithIndex(idx)).Walk(dir)
+	require.NoError(t, err)
+	byPath := make(map[string]*VaultFile)
+	for _, file := range files {
+		byPath[file.RelativePath] = file
+	}
+	return byPath
+}
+
+func TestIndex_ReusesUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	content := "---\ntitle: Note\ncount: 3\nratio: 0.5\ndraft: false\ncreated: 2024-01-02\nupdated: 2024-01-02 10:30:00\ntags: []\nmeta:\n  nested: [a, 1]\nempty:\n---\n\n# Heading\n\n```\n# not a heading\n```\n\n## Sub\nBody"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "note.md"), []byte(content), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.md"), []byte("plain"), 0644))
+
+	parsed := &VaultFile{}
+	require.NoError(t, parsed.Parse([]byte(content)))
+
+	idx := NewIndex(dir)
+	parser := &countingLinkParser{}
+	idx.SetLinkParser(parser)
+	first := scanWithIndex(t, dir, idx)
+	reused, reparsed := idx.Stats()
+	assert.Equal(t, 0, reused)
+	assert.Equal(t, 2, reparsed)
+	require.NoError(t, idx.Save())
+
+	loaded, err := LoadIndex(dir)
+	require.NoError(t, err)
+	loaded.SetLinkParser(parser)
+	second := scanWithIndex(t, dir, loaded)
+	reused, reparsed = loaded.Stats()
+	assert.Equal(t, 2, reused)
+	assert.Equal(t, 0, reparsed)
+	assert.Equal(t, 2, parser.calls, "links should come from the index")
+
+	note := second["note.md"]
+	assert.Equal(t, parsed.Frontmatter, note.Frontmatter)
+	assert.IsType(t, Date{}, note.Frontmatter["created"])
+	assert.Equal(t, []interface{}{}, note.Frontmatter["tags"])
+	assert.Equal(t, parsed.Body, note.Body)
+	assert.Equal(t, first["note.md"].Headings, note.Headings)
+	assert.Equal(t, []Heading{{Level: 1, Text: "Heading", Line: 1}, {Level: 2, Text: "Sub", Line: 7}}, note.Headings)
+	assert.Equal(t, []Link{{Type: WikiLink, Target: "parsed"}}, note.Links)
+	assert.Equal(t, "plain", second["other.md"].Body)
+
+	serialized, err := note.Serialize()
+	require.NoError(t, err)
+	expected, err := parsed.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(serialized))
+
+	// Each lookup gets its own copy of the frontmatter
+	note.Frontmatter["title"] = "Changed"
+	third := scanWithIndex(t, dir, loaded)
+	assert.Equal(t, "Note", third["note.md"].Frontmatter["title"])
+}
+
+func TestIndex_ReparsesChangedAndSkipsLinksUnlessRequested(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("---\ntitle: Old\n---\nBody"), 0644))
+
+	idx := NewIndex(dir)
+	idx.SetLinkParser(&countingLinkParser{})
+	scanWithIndex(t, dir, idx)
+	require.NoError(t, idx.Save())
+
+	require.NoError(t, os.WriteFile(path, []byte("---\ntitle: New title\n---\nBody"), 0644))
+	loaded, err := LoadIndex(dir)
+	require.NoError(t, err)
+	files := scanWithIndex(t, dir, loaded)
+	_, reparsed := loaded.Stats()
+	assert.Equal(t, 1, reparsed)
+	assert.Equal(t, "New title", files["note.md"].Frontmatter["title"])
+	assert.Nil(t, files["note.md"].Links)
+}
+
+func TestIndex_PruneAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.md"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.md"), []byte("b"), 0644))
+
+	idx := NewIndex(dir)
+	scanWithIndex(t, dir, idx)
+	require.NoError(t, idx.Save())
+	assert.Equal(t, 2, idx.Len())
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.md")))
+	assert.Equal(t, 1, idx.Prune())
+	assert.Equal(t, 1, idx.Len())
+
+	sub := filepath.Join(dir, "sub")
+	require.NoError(t, os.Mkdir(sub, 0755))
+	found := FindIndex(sub)
+	require.NotNil(t, found)
+	assert.Equal(t, idx.Root(), found.Root())
+
+	require.NoError(t, RemoveIndex(dir))
+	assert.Nil(t, FindIndex(dir))
+	_, err := LoadIndex(dir)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoError(t, RemoveIndex(dir))
+}
+
+func TestIndex_EmbeddingsSurviveSaveAndDropOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("Body"), 0644))
+
+	idx := NewIndex(dir)
+	scanWithIndex(t, dir, idx)
+	assert.True(t, idx.SetEmbedding(path, "local:hash-16", []float32{1, 0}))
+	assert.False(t, idx.SetEmbedding(filepath.Join(dir, "missing.md"), "local:hash-16", []float32{1}))
+	require.NoError(t, idx.Save())
+
+	loaded, err := LoadIndex(dir)
+	require.NoError(t, err)
+	scanWithIndex(t, dir, loaded)
+	vector, ok := loaded.Embedding(path, "local:hash-16")
+	assert.True(t, ok)
+	assert.Equal(t, []float32{1, 0}, vector)
+	_, ok = loaded.Embedding(path, "ollama:other")
+	assert.False(t, ok, "vectors from another model don't count")
+
+	require.NoError(t, os.WriteFile(path, []byte("Changed body"), 0644))
+	scanWithIndex(t, dir, loaded)
+	_, ok = loaded.Embedding(path, "local:hash-16")
+	assert.False(t, ok, "editing a note drops its vector")
+}
+
+func TestIndex_WebLinksExpire(t *testing.T) {
+	dir := t.TempDir()
+	idx := NewIndex(dir)
+	idx.SetWebLink("https://example.com/fresh", WebLinkCheck{Status: "ok", Code: 200, Checked: time.Now()})
+	idx.SetWebLink("https://example.com/stale", WebLinkCheck{Status: "dead", Code: 404, Checked: time.Now().Add(-48 * time.Hour)})
+	require.NoError(t, idx.Save())
+
+	loaded, err := LoadIndex(dir)
+	require.NoError(t, err)
+	check, ok := loaded.WebLink("https://example.com/fresh", 24*time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, 200, check.Code)
+	_, ok = loaded.WebLink("https://example.com/stale", 24*time.Hour)
+	assert.False(t, ok, "results older than the TTL are checked again")
+	_, ok = loaded.WebLink("https://example.com/stale", 72*time.Hour)
+	assert.True(t, ok)
+}
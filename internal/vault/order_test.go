@@ -220,3 +220,17 @@ No frontmatter here.
 	assert.Contains(t, string(serialized), "2023-01-15")
 	assert.Contains(t, string(serialized), "# Just Content")
 }
+
+func TestVaultFile_RenameFieldKeepsPosition(t *testing.T) {
+	content := "---\ntitle: Note\nalias: Other\naliases: [old]\nstatus: draft\n---\n\nBody\n"
+
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+
+	assert.True(t, vf.RenameField("alias", "aliases"))
+	assert.False(t, vf.RenameField("missing", "other"))
+
+	serialized, err := vf.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Note\naliases: Other\nstatus: draft\n---\n\nBody\n", string(serialized))
+}
@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultProvenanceField is the frontmatter field provenance is recorded in
+const DefaultProvenanceField = "mdnotes_meta"
+
+// Provenance records which command last set a frontmatter field and the
+// value it set, so later runs can tell machine-managed fields from fields
+// edited by hand
+type Provenance struct {
+	Source  string    // Command that set the field, e.g. "mdnotes frontmatter set"
+	Reason  string    // Why it was set, e.g. the lifecycle rule, if any
+	Updated time.Time // When it was set
+	Hash    string    // Hash of the value it was set to
+}
+
+// ProvenanceHash returns a short hash of a frontmatter value as parsed from a file
+func ProvenanceHash(value interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%T:%v", value, value)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Provenance returns the recorded provenance of field from the metaField map
+func (vf *VaultFile) Provenance(metaField, field string) (Provenance, bool) {
+	meta, ok := vf.Frontmatter[metaField].(map[string]interface{})
+	if !ok {
+		return Provenance{}, false
+	}
+	entry, ok := meta[field].(map[string]interface{})
+	if !ok {
+		return Provenance{}, false
+	}
+
+	p := Provenance{}
+	p.Source, _ = entry["source"].(string)
+	p.Reason, _ = entry["reason"].(string)
+	p.Hash, _ = entry["hash"].(string)
+	switch updated := entry["updated"].(type) {
+	case time.Time:
+		p.Updated = updated
+	case string:
+		p.Updated, _ = time.Parse(time.RFC3339, updated)
+	}
+	return p, true
+}
+
+// IsManaged reports whether field still holds the value mdnotes last set it
+// to. Fields without provenance, or edited since, are not managed.
+func (vf *VaultFile) IsManaged(metaField, field string) bool {
+	p, ok := vf.Provenance(metaField, field)
+	if !ok {
+		return false
+	}
+	value, exists := vf.Frontmatter[field]
+	return exists && p.Hash == ProvenanceHash(value)
+}
+
+// StampProvenance records provenance in metaField for each of fields, or
+// removes it for fields no longer present. The metaField map is removed when
+// it becomes empty.
+func (vf *VaultFile) StampProvenance(metaField string, fields []string, source, reason string, now time.Time) {
+	meta, _ := vf.Frontmatter[metaField].(map[string]interface{})
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+
+	sorted := append([]string{}, fields...)
+	sort.Strings(sorted)
+	for _, field := range sorted {
+		if field == metaField {
+			continue
+		}
+		value, exists := vf.Frontmatter[field]
+		if !exists {
+			delete(meta, field)
+			continue
+		}
+		entry := map[string]interface{}{
+			"source":  source,
+			"updated": now.UTC().Truncate(time.Second),
+			"hash":    ProvenanceHash(value),
+		}
+		if reason != "" {
+			entry["reason"] = reason
+		}
+		meta[field] = entry
+	}
+
+	if len(meta) == 0 {
+		delete(vf.Frontmatter, metaField)
+		return
+	}
+	vf.SetField(metaField, meta)
+}
@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultFile_StampProvenance(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	file := &VaultFile{}
+	require.NoError(t, file.Parse([]byte("---\ntitle: Note\nstatus: draft\n---\nBody\n")))
+
+	file.StampProvenance(DefaultProvenanceField, []string{"status", DefaultProvenanceField}, "mdnotes frontmatter set", "", now)
+	p, ok := file.Provenance(DefaultProvenanceField, "status")
+	require.True(t, ok)
+	assert.Equal(t, Provenance{Source: "mdnotes frontmatter set", Updated: now, Hash: ProvenanceHash("draft")}, p)
+	assert.True(t, file.IsManaged(DefaultProvenanceField, "status"))
+	assert.False(t, file.IsManaged(DefaultProvenanceField, "title"), "fields without provenance aren't managed")
+
+	// Provenance survives a round trip through the file
+	content, err := file.Serialize()
+	require.NoError(t, err)
+	reloaded := &VaultFile{}
+	require.NoError(t, reloaded.Parse(content))
+	assert.True(t, reloaded.IsManaged(DefaultProvenanceField, "status"))
+	p, _ = reloaded.Provenance(DefaultProvenanceField, "status")
+	assert.Equal(t, now, p.Updated)
+
+	reloaded.SetField("status", "done")
+	assert.False(t, reloaded.IsManaged(DefaultProvenanceField, "status"), "edited since mdnotes set it")
+
+	// Removed fields lose their provenance, and the map goes once empty
+	delete(reloaded.Frontmatter, "status")
+	reloaded.StampProvenance(DefaultProvenanceField, []string{"status"}, "mdnotes frontmatter set", "", now)
+	assert.NotContains(t, reloaded.Frontmatter, DefaultProvenanceField)
+}
@@ -0,0 +1,118 @@
+package vault
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// repairKeyRegex matches "key: value" lines, optionally as a list item
+	repairKeyRegex = regexp.MustCompile(`^(\s*(?:- +)?[^\s#"'\-][^:]*?:[ \t]+)(\S.*?)\s*$`)
+	// repairListRegex matches scalar list items
+	repairListRegex = regexp.MustCompile(`^(\s*- +)(\S.*?)\s*$`)
+	// repairYAMLLineRegex matches lines that plausibly belong to a frontmatter block
+	repairYAMLLineRegex = regexp.MustCompile(`^(\s+\S|- |-$|[^\s#][^:]*:(\s|$))`)
+)
+
+// RepairFrontmatter fixes common frontmatter breakages: a byte order mark before
+// the opening delimiter, a missing closing delimiter, tab indentation, and
+// unquoted values that YAML would misread (values starting with '#' become
+// comments, values containing ": " are invalid). It returns the repaired
+// content and a description of each repair; no repairs means content is unchanged.
+func RepairFrontmatter(content []byte) ([]byte, []string) {
+	var repairs []string
+
+	bom := []byte("\xEF\xBB\xBF")
+	if bytes.HasPrefix(content, bom) && bytes.HasPrefix(content[len(bom):], []byte("---")) {
+		content = content[len(bom):]
+		repairs = append(repairs, "removed byte order mark")
+	}
+
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		return content, repairs
+	}
+
+	lines := strings.Split(string(content), "\n")
+	endIndex := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			endIndex = i
+			break
+		}
+	}
+
+	if endIndex == -1 {
+		// Frontmatter runs until the first line that doesn't look like YAML
+		endIndex = 1
+		for endIndex < len(lines) && repairYAMLLineRegex.MatchString(strings.TrimRight(lines[endIndex], "\r")) {
+			endIndex++
+		}
+		if endIndex == 1 {
+			return content, repairs
+		}
+
+		lines = append(lines[:endIndex], append([]string{"---"}, lines[endIndex:]...)...)
+		repairs = append(repairs, fmt.Sprintf("added missing closing --- at line %d", endIndex+1))
+	}
+
+	blockIndent := -1
+	for i := 1; i < endIndex; i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "\t") {
+			trimmed := strings.TrimLeft(line, " \t")
+			indent := line[:len(line)-len(trimmed)]
+			line = strings.ReplaceAll(indent, "\t", "  ") + trimmed
+			repairs = append(repairs, fmt.Sprintf("replaced tab indentation on line %d", i+1))
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if strings.TrimSpace(line) == "" || indent > blockIndent {
+				lines[i] = line
+				continue
+			}
+			blockIndent = -1
+		}
+
+		if m := repairKeyRegex.FindStringSubmatch(line); m != nil {
+			value := strings.TrimRight(m[2], "\r")
+			if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				blockIndent = indent
+			} else if needsRepairQuoting(value) {
+				line = m[1] + quoteYAMLValue(value)
+				repairs = append(repairs, fmt.Sprintf("quoted value on line %d", i+1))
+			}
+		} else if m := repairListRegex.FindStringSubmatch(line); m != nil {
+			value := strings.TrimRight(m[2], "\r")
+			if strings.HasPrefix(value, "#") {
+				line = m[1] + quoteYAMLValue(value)
+				repairs = append(repairs, fmt.Sprintf("quoted value on line %d", i+1))
+			}
+		}
+
+		lines[i] = line
+	}
+
+	if len(repairs) == 0 {
+		return content, nil
+	}
+	return []byte(strings.Join(lines, "\n")), repairs
+}
+
+// needsRepairQuoting reports whether an unquoted scalar would be misread by YAML
+func needsRepairQuoting(value string) bool {
+	if strings.ContainsAny(value[:1], `"'[{&*!`) {
+		return false
+	}
+	return strings.HasPrefix(value, "#") || strings.Contains(value, ": ")
+}
+
+// quoteYAMLValue wraps a value in double quotes, escaping as needed
+func quoteYAMLValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairFrontmatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		repairs int
+	}{
+		{
+			name:    "byte order mark",
+			input:   "\xEF\xBB\xBF---\ntitle: A\n---\nBody",
+			want:    "---\ntitle: A\n---\nBody",
+			repairs: 1,
+		},
+		{
+			name:    "tab indentation",
+			input:   "---\ntags:\n\t- a\n\t- b\n---\nBody",
+			want:    "---\ntags:\n  - a\n  - b\n---\nBody",
+			repairs: 2,
+		},
+		{
+			name:    "unquoted colon and hash",
+			input:   "---\ntitle: Note: A Subtitle\ntopic: #project\ntags:\n  - #inbox\nurl: https://example.com\n---\nBody",
+			want:    "---\ntitle: \"Note: A Subtitle\"\ntopic: \"#project\"\ntags:\n  - \"#inbox\"\nurl: https://example.com\n---\nBody",
+			repairs: 3,
+		},
+		{
+			name:    "missing closing delimiter",
+			input:   "---\ntitle: A\ntags: [a]\n\n# Heading\nBody",
+			want:    "---\ntitle: A\ntags: [a]\n---\n\n# Heading\nBody",
+			repairs: 1,
+		},
+		{
+			name:    "block scalars left alone",
+			input:   "---\nsummary: |\n  Note: this stays\n  #and this\ntitle: A\n---\n",
+			want:    "---\nsummary: |\n  Note: this stays\n  #and this\ntitle: A\n---\n",
+			repairs: 0,
+		},
+		{
+			name:    "valid frontmatter untouched",
+			input:   "---\ntitle: \"Quoted: fine\"\n---\nBody",
+			want:    "---\ntitle: \"Quoted: fine\"\n---\nBody",
+			repairs: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repairs := RepairFrontmatter([]byte(tt.input))
+			assert.Equal(t, tt.want, string(got))
+			assert.Len(t, repairs, tt.repairs)
+
+			vf := &VaultFile{}
+			require.NoError(t, vf.Parse(got))
+		})
+	}
+}
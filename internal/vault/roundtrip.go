@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlSegment is the original text of one top-level frontmatter field: any
+// comment lines directly above the key, the key itself and its value lines
+type yamlSegment struct {
+	lines       []string // Original lines, comments first
+	keyLine     int      // Index in lines of the line holding the key
+	lineComment string   // Comment trailing a single-line value, if any
+	value       interface{}
+}
+
+// yamlLayout is the original YAML frontmatter split into per-field segments,
+// so fields that weren't changed can be written back exactly as they were
+type yamlLayout struct {
+	preamble  []string // Comment lines before the first key
+	segments  map[string]yamlSegment
+	postamble []string // Comment lines after the last field
+}
+
+// parseYAMLLayout splits YAML frontmatter text into segments using the
+// positions yaml.Node records. It returns nil when the text isn't a plain
+// mapping, in which case callers fall back to re-marshaling every field.
+func parseYAMLLayout(text string) *yamlLayout {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return nil
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	mapping := doc.Content[0]
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	type field struct {
+		key   *yaml.Node
+		value *yaml.Node
+		start int
+	}
+	fields := make([]field, 0, len(mapping.Content)/2)
+	prevKeyLine := -1
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		keyLine := key.Line - 1
+		if keyLine <= prevKeyLine || keyLine >= len(lines) {
+			// Flow mappings and other unusual layouts have no per-line fields
+			return nil
+		}
+
+		// Comment lines directly above a key belong to it. Indented lines may
+		// be part of the previous value, so only column-0 comments count.
+		start := keyLine
+		for start-1 > prevKeyLine && strings.HasPrefix(lines[start-1], "#") {
+			start--
+		}
+		fields = append(fields, field{key: key, value: value, start: start})
+		prevKeyLine = keyLine
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	layout := &yamlLayout{
+		preamble: lines[:fields[0].start],
+		segments: make(map[string]yamlSegment, len(fields)),
+	}
+
+	for i, f := range fields {
+		end := len(lines)
+		if i+1 < len(fields) {
+			end = fields[i+1].start
+		} else {
+			end = trailingCommentStart(lines, f.key.Line, end)
+			layout.postamble = lines[end:]
+		}
+
+		var value interface{}
+		if err := f.value.Decode(&value); err != nil {
+			return nil
+		}
+		if t, ok := value.(time.Time); ok {
+			value = Date{Time: t}
+		}
+
+		seg := yamlSegment{
+			lines:   lines[f.start:end],
+			keyLine: f.key.Line - 1 - f.start,
+			value:   value,
+		}
+		if f.value.Line == f.key.Line && f.value.Kind == yaml.ScalarNode {
+			seg.lineComment = f.value.LineComment
+		}
+		layout.segments[f.key.Value] = seg
+	}
+
+	return layout
+}
+
+// trailingCommentStart returns where the comments closing the frontmatter
+// begin, so they survive when the last field is removed. Blank lines between
+// the last value and those comments stay with the value.
+func trailingCommentStart(lines []string, after, end int) int {
+	start := end
+	for i := end - 1; i >= after; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(lines[i], "#"):
+			start = i
+		case trimmed == "":
+			continue
+		default:
+			return start
+		}
+	}
+	return start
+}
+
+// render writes a field back. Unchanged fields keep their original text;
+// changed ones keep the comments above them and, for single-line values,
+// their trailing comment.
+func (seg yamlSegment) render(key string, value interface{}) ([]string, error) {
+	if reflect.DeepEqual(seg.value, value) {
+		return seg.lines, nil
+	}
+
+	formatted, err := formatYAMLField(key, value)
+	if err != nil {
+		return nil, err
+	}
+	if seg.lineComment != "" && !strings.Contains(formatted, "\n") {
+		formatted += " " + seg.lineComment
+	}
+
+	out := append([]string{}, seg.lines[:seg.keyLine]...)
+	return append(out, strings.Split(formatted, "\n")...), nil
+}
@@ -0,0 +1,103 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerialize_PreservesUntouchedFormatting(t *testing.T) {
+	content := `---
+# Managed by hand
+title:   "My Note"
+tags:
+  - one   # first
+  - two
+status: draft # revisit
+created: 2024-01-02
+---
+
+Body
+`
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, content, string(out), "unmodified file should round-trip exactly")
+
+	vf.SetField("status", "done")
+	vf.SetField("priority", 1)
+	out, err = vf.Serialize()
+	require.NoError(t, err)
+
+	expected := `---
+# Managed by hand
+title:   "My Note"
+tags:
+  - one   # first
+  - two
+status: done # revisit
+created: 2024-01-02
+priority: 1
+---
+
+Body
+`
+	assert.Equal(t, expected, string(out))
+}
+
+func TestSerialize_KeepsCommentsAroundRemovedFields(t *testing.T) {
+	content := `---
+title: Note
+# about the draft flag
+draft: true
+# end of fields
+---
+Body
+`
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+
+	delete(vf.Frontmatter, "draft")
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+
+	assert.Equal(t, `---
+title: Note
+# end of fields
+---
+
+Body
+`, string(out))
+}
+
+func TestSerialize_RenamedFieldKeepsPosition(t *testing.T) {
+	content := `---
+a: 1
+# comment for b
+b: 2
+c: 3
+---
+`
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+	require.True(t, vf.RenameField("b", "beta"))
+
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, "---\na: 1\nbeta: 2\nc: 3\n---\n", string(out))
+}
+
+func TestSerialize_ConvertedFormatIgnoresOriginalLayout(t *testing.T) {
+	content := "{\n  \"title\": \"Note\",\n  \"count\": 2\n}\nBody\n"
+	vf := &VaultFile{}
+	require.NoError(t, vf.Parse([]byte(content)))
+	require.Equal(t, FormatJSON, vf.FrontmatterFormat)
+
+	vf.FrontmatterFormat = FormatYAML
+	out, err := vf.Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: Note\ncount: 2\n---\n\nBody\n", string(out))
+}
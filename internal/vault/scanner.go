@@ -1,11 +1,13 @@
 package vault
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // Scanner walks directories and finds markdown files
@@ -13,6 +15,9 @@ type Scanner struct {
 	ignorePatterns   []string
 	continueOnErrors bool
 	parseErrors      []ParseError
+	index            *Index
+	workers          int
+	iterErr          error
 }
 
 // ParseError represents a file parsing error
@@ -38,6 +43,22 @@ func WithContinueOnErrors() ScannerOption {
 	}
 }
 
+// WithIndex makes the scanner reuse parsed results from idx for unchanged
+// files and record newly parsed ones. A nil index is ignored.
+func WithIndex(idx *Index) ScannerOption {
+	return func(s *Scanner) {
+		s.index = idx
+	}
+}
+
+// WithWorkers parses files with n goroutines while walking. Values below 2
+// keep the scan sequential.
+func WithWorkers(n int) ScannerOption {
+	return func(s *Scanner) {
+		s.workers = n
+	}
+}
+
 // NewScanner creates a new scanner with optional configuration
 func NewScanner(opts ...ScannerOption) *Scanner {
 	s := &Scanner{
@@ -61,54 +82,160 @@ func (s *Scanner) GetParseErrors() []ParseError {
 func (s *Scanner) Walk(root string) ([]*VaultFile, error) {
 	var files []*VaultFile
 
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	err := s.WalkWithCallback(root, func(vf *VaultFile) error {
+		files = append(files, vf)
+		return nil
+	})
 
-		// Get relative path from root
-		relPath, err := filepath.Rel(root, path)
+	return files, err
+}
+
+// WalkWithCallback scans a directory tree and calls the callback for each markdown file
+// This enables streaming processing for better memory efficiency. With more
+// than one worker, files are parsed in parallel but the callback is still
+// called from a single goroutine, in the same order as a sequential walk.
+func (s *Scanner) WalkWithCallback(root string, callback func(*VaultFile) error) error {
+	if s.workers > 1 {
+		return s.walkParallel(root, callback)
+	}
+
+	return s.walkMarkdown(root, func(path, relPath string) error {
+		// Load the file
+		vf, err := s.loadFile(path, relPath)
 		if err != nil {
-			return err
+			return s.loadError(path, relPath, err)
 		}
 
-		// Check if path should be ignored
-		if s.shouldIgnore(relPath) {
-			if d.IsDir() {
-				return filepath.SkipDir
+		// Call the callback
+		return callback(vf)
+	})
+}
+
+// Iter scans a directory tree in the background and sends each markdown file
+// on the returned channel, in the same order as Walk. Only files not yet
+// received are held in memory, so large vaults can be processed one file at a
+// time. The channel is closed when the scan finishes, fails or ctx is
+// cancelled; Err reports why it stopped.
+func (s *Scanner) Iter(ctx context.Context, root string) <-chan *VaultFile {
+	files := make(chan *VaultFile, max(s.workers, 1))
+	s.iterErr = nil
+
+	go func() {
+		defer close(files)
+		s.iterErr = s.WalkWithCallback(root, func(vf *VaultFile) error {
+			if err := ctx.Err(); err != nil {
+				return err
 			}
-			return nil
-		}
+			select {
+			case files <- vf:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
 
-		// Only process markdown files
-		if d.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
-		}
+	return files
+}
 
-		// Load the file
-		vf, err := s.loadFile(path, relPath)
-		if err != nil {
-			if s.continueOnErrors {
-				// Store the error and continue
-				s.parseErrors = append(s.parseErrors, ParseError{
-					Path:  relPath,
-					Error: err,
-				})
+// Err returns the error that stopped the last Iter scan, if any. It is only
+// meaningful once the channel returned by Iter has been closed.
+func (s *Scanner) Err() error {
+	return s.iterErr
+}
+
+// scanJob is a file queued for parsing by a parallel walk
+type scanJob struct {
+	seq           int
+	path, relPath string
+}
+
+// scanResult is a parsed file, or the error parsing it, from a parallel walk
+type scanResult struct {
+	scanJob
+	vf  *VaultFile
+	err error
+}
+
+// walkParallel parses files with a pool of workers while the directory tree is
+// walked, handing results to the callback in walk order
+func (s *Scanner) walkParallel(root string, callback func(*VaultFile) error) error {
+	jobs := make(chan scanJob, s.workers)
+	results := make(chan scanResult, s.workers)
+	done := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { close(done) }) }
+	defer stop()
+
+	var walkErr error
+	go func() {
+		defer close(jobs)
+		seq := 0
+		err := s.walkMarkdown(root, func(path, relPath string) error {
+			select {
+			case jobs <- scanJob{seq: seq, path: path, relPath: relPath}:
+				seq++
 				return nil
+			case <-done:
+				return filepath.SkipAll
 			}
-			return fmt.Errorf("loading %s: %w", path, err)
-		}
+		})
+		walkErr = err
+	}()
 
-		files = append(files, vf)
-		return nil
-	})
+	var wg sync.WaitGroup
+	for w := 0; w < s.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				vf, err := s.loadFile(job.path, job.relPath)
+				results <- scanResult{scanJob: job, vf: vf, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-	return files, err
+	// Results arrive out of order; hold them until the earlier ones are in
+	var firstErr error
+	pending := make(map[int]scanResult)
+	next := 0
+	for result := range results {
+		pending[result.seq] = result
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if firstErr != nil {
+				continue
+			}
+			if ready.err != nil {
+				firstErr = s.loadError(ready.path, ready.relPath, ready.err)
+			} else {
+				firstErr = callback(ready.vf)
+			}
+			if firstErr != nil {
+				stop()
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return walkErr
 }
 
-// WalkWithCallback scans a directory tree and calls the callback for each markdown file
-// This enables streaming processing for better memory efficiency
-func (s *Scanner) WalkWithCallback(root string, callback func(*VaultFile) error) error {
+// walkMarkdown calls fn for each markdown file under root that isn't ignored,
+// in lexical order
+func (s *Scanner) walkMarkdown(root string, fn func(path, relPath string) error) error {
 	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -133,25 +260,24 @@ func (s *Scanner) WalkWithCallback(root string, callback func(*VaultFile) error)
 			return nil
 		}
 
-		// Load the file
-		vf, err := s.loadFile(path, relPath)
-		if err != nil {
-			if s.continueOnErrors {
-				// Store the error and continue
-				s.parseErrors = append(s.parseErrors, ParseError{
-					Path:  relPath,
-					Error: err,
-				})
-				return nil
-			}
-			return fmt.Errorf("loading %s: %w", path, err)
-		}
-
-		// Call the callback
-		return callback(vf)
+		return fn(path, relPath)
 	})
 }
 
+// loadError records a file that failed to load and returns nil when the
+// scanner continues on errors, or returns the error otherwise
+func (s *Scanner) loadError(path, relPath string, err error) error {
+	if s.continueOnErrors {
+		// Store the error and continue
+		s.parseErrors = append(s.parseErrors, ParseError{
+			Path:  relPath,
+			Error: err,
+		})
+		return nil
+	}
+	return fmt.Errorf("loading %s: %w", path, err)
+}
+
 // shouldIgnore checks if a path matches any ignore pattern
 func (s *Scanner) shouldIgnore(path string) bool {
 	for _, pattern := range s.ignorePatterns {
@@ -173,6 +299,10 @@ func (s *Scanner) shouldIgnore(path string) bool {
 
 // loadFile reads and parses a markdown file
 func (s *Scanner) loadFile(path, relPath string) (*VaultFile, error) {
+	if s.index != nil {
+		return s.index.loadFile(path, relPath)
+	}
+
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -193,6 +323,7 @@ func (s *Scanner) loadFile(path, relPath string) (*VaultFile, error) {
 	if err := vf.Parse(content); err != nil {
 		return nil, err
 	}
+	vf.Headings = ExtractHeadings(vf.Body)
 
 	return vf, nil
 }
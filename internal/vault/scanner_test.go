@@ -1,8 +1,12 @@
 package vault
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -115,3 +119,134 @@ func TestScanner_NonexistentDirectory(t *testing.T) {
 		t.Error("Expected error for nonexistent directory, got nil")
 	}
 }
+
+func TestScanner_ParallelMatchesSequential(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 60; i++ {
+		content := fmt.Sprintf("---\ntitle: Note %d\n---\n\n# Note %d\n", i, i)
+		if i%15 == 0 {
+			content = "---\ntitle: [broken\n---\n"
+		}
+		path := filepath.Join(tmpDir, fmt.Sprintf("dir%d", i%4), fmt.Sprintf("note%02d.md", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	relPaths := func(files []*VaultFile) []string {
+		var paths []string
+		for _, f := range files {
+			paths = append(paths, f.RelativePath)
+		}
+		return paths
+	}
+
+	sequential := NewScanner(WithContinueOnErrors())
+	want, err := sequential.Walk(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, idx := range []*Index{nil, NewIndex(tmpDir)} {
+		parallel := NewScanner(WithContinueOnErrors(), WithWorkers(8), WithIndex(idx))
+		got, err := parallel.Walk(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(relPaths(want), relPaths(got)) {
+			t.Errorf("parallel walk order = %v, want %v", relPaths(got), relPaths(want))
+		}
+		if len(parallel.GetParseErrors()) != len(sequential.GetParseErrors()) {
+			t.Errorf("parallel walk found %d parse errors, want %d", len(parallel.GetParseErrors()), len(sequential.GetParseErrors()))
+		}
+		for i, parseErr := range parallel.GetParseErrors() {
+			if parseErr.Path != sequential.GetParseErrors()[i].Path {
+				t.Errorf("parse error %d for %s, want %s", i, parseErr.Path, sequential.GetParseErrors()[i].Path)
+			}
+		}
+	}
+}
+
+func TestScanner_ParallelStopsOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestVault(t, tmpDir)
+
+	stopErr := errors.New("stop")
+	calls := 0
+	scanner := NewScanner(WithWorkers(4))
+	err := scanner.WalkWithCallback(tmpDir, func(vf *VaultFile) error {
+		calls++
+		return stopErr
+	})
+	if !errors.Is(err, stopErr) {
+		t.Errorf("WalkWithCallback() error = %v, want %v", err, stopErr)
+	}
+	if calls != 1 {
+		t.Errorf("callback called %d times after an error, want 1", calls)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "broken.md"), []byte("---\ntitle: [broken\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewScanner(WithWorkers(4)).Walk(tmpDir); err == nil || !strings.Contains(err.Error(), "broken.md") {
+		t.Errorf("Walk() error = %v, want an error loading broken.md", err)
+	}
+}
+
+func TestScanner_Iter(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestVault(t, tmpDir)
+
+	want, err := NewScanner(WithIgnorePatterns([]string{".obsidian/*"})).Walk(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{0, 4} {
+		scanner := NewScanner(WithIgnorePatterns([]string{".obsidian/*"}), WithWorkers(workers))
+		var got []string
+		for vf := range scanner.Iter(context.Background(), tmpDir) {
+			got = append(got, vf.RelativePath)
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("Err() = %v with %d workers", err, workers)
+		}
+		var wantPaths []string
+		for _, vf := range want {
+			wantPaths = append(wantPaths, vf.RelativePath)
+		}
+		if !reflect.DeepEqual(got, wantPaths) {
+			t.Errorf("Iter() with %d workers = %v, want %v", workers, got, wantPaths)
+		}
+	}
+}
+
+func TestScanner_IterCancel(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		if err := os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("note%02d.md", i)), []byte("# Note\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scanner := NewScanner(WithWorkers(4))
+	files := scanner.Iter(ctx, tmpDir)
+	<-files
+	cancel()
+	for range files {
+	}
+	if err := scanner.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want %v", err, context.Canceled)
+	}
+
+	scanner = NewScanner()
+	for range scanner.Iter(context.Background(), filepath.Join(tmpDir, "missing")) {
+	}
+	if scanner.Err() == nil {
+		t.Error("Err() = nil for a missing directory, want an error")
+	}
+}
@@ -0,0 +1,299 @@
+package zotero
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// latexEscapes are the escaped characters Better BibTeX writes in field values
+var latexEscapes = strings.NewReplacer(`\&`, "&", `\%`, "%", `\_`, "_", `\$`, "$", `\#`, "#", `\{`, "{", `\}`, "}", "~", " ", "---", "—", "--", "–")
+
+var spaceRegex = regexp.MustCompile(`\s+`)
+
+// ParseBibTeX reads items from a BibTeX or BibLaTeX file, as exported by
+// Better BibTeX. @string abbreviations, @comment and @preamble blocks are
+// skipped.
+func ParseBibTeX(r io.Reader) ([]Item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading BibTeX: %w", err)
+	}
+
+	p := &bibParser{src: string(data)}
+	var items []Item
+	for {
+		entryType, ok := p.nextEntry()
+		if !ok {
+			return items, nil
+		}
+		switch entryType {
+		case "comment", "preamble", "string":
+			if err := p.skipBlock(); err != nil {
+				return nil, fmt.Errorf("parsing BibTeX @%s: %w", entryType, err)
+			}
+			continue
+		}
+
+		key, fields, err := p.entry()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, bibItem(entryType, key, fields))
+	}
+}
+
+// bibItem builds an item from an entry's raw fields
+func bibItem(entryType, key string, fields map[string]string) Item {
+	item := Item{
+		CiteKey:  key,
+		Type:     entryType,
+		Title:    cleanValue(fields["title"]),
+		DOI:      strings.TrimSpace(fields["doi"]),
+		URL:      strings.TrimSpace(fields["url"]),
+		Abstract: cleanValue(fields["abstract"]),
+		Year:     cleanValue(fields["year"]),
+	}
+	if date := cleanValue(fields["date"]); item.Year == "" && len(date) >= 4 {
+		item.Year = date[:4]
+	}
+	for _, field := range []string{"journaltitle", "journal", "booktitle"} {
+		if fields[field] != "" {
+			item.Container = cleanValue(fields[field])
+			break
+		}
+	}
+	if authors := strings.TrimSpace(spaceRegex.ReplaceAllString(fields["author"], " ")); authors != "" {
+		for _, author := range splitAuthors(authors) {
+			item.Authors = append(item.Authors, bibName(author))
+		}
+	}
+	return item
+}
+
+// splitAuthors splits a name list on "and" outside braces
+func splitAuthors(raw string) []string {
+	var names []string
+	depth, start := 0, 0
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ' ':
+			if depth == 0 && strings.HasPrefix(raw[i:], " and ") {
+				names = append(names, raw[start:i])
+				start = i + len(" and ")
+				i += len(" and ") - 1
+			}
+		}
+	}
+	return append(names, raw[start:])
+}
+
+// bibName normalizes a name to "Last, First". Braced names, which BibTeX
+// treats as a whole such as institutions, are kept as they are.
+func bibName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+		return cleanValue(name)
+	}
+	name = cleanValue(name)
+	if strings.Contains(name, ",") {
+		return name
+	}
+	if i := strings.LastIndex(name, " "); i > 0 {
+		return name[i+1:] + ", " + name[:i]
+	}
+	return name
+}
+
+// cleanValue strips braces and LaTeX escapes from a field value and collapses
+// whitespace
+func cleanValue(value string) string {
+	value = latexEscapes.Replace(value)
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '{' || value[i] == '}' {
+			continue
+		}
+		b.WriteByte(value[i])
+	}
+	return strings.TrimSpace(spaceRegex.ReplaceAllString(b.String(), " "))
+}
+
+// bibParser walks BibTeX source
+type bibParser struct {
+	src string
+	pos int
+}
+
+// nextEntry advances past the next "@type" and returns the lowercased type
+func (p *bibParser) nextEntry() (string, bool) {
+	i := strings.IndexByte(p.src[p.pos:], '@')
+	if i < 0 {
+		return "", false
+	}
+	p.pos += i + 1
+	start := p.pos
+	for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+		p.pos++
+	}
+	return strings.ToLower(p.src[start:p.pos]), true
+}
+
+// skipBlock skips the braced or parenthesized block following an entry type
+func (p *bibParser) skipBlock() error {
+	p.skipSpace()
+	if p.pos >= len(p.src) || (p.src[p.pos] != '{' && p.src[p.pos] != '(') {
+		return nil
+	}
+	_, err := p.braced()
+	return err
+}
+
+// entry reads "{key, field = value, ...}"
+func (p *bibParser) entry() (string, map[string]string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || (p.src[p.pos] != '{' && p.src[p.pos] != '(') {
+		return "", nil, fmt.Errorf("parsing BibTeX: expected '{' at offset %d", p.pos)
+	}
+	closer := byte('}')
+	if p.src[p.pos] == '(' {
+		closer = ')'
+	}
+	p.pos++
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != ',' && p.src[p.pos] != closer {
+		p.pos++
+	}
+	key := strings.TrimSpace(p.src[start:p.pos])
+
+	fields := make(map[string]string)
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return "", nil, fmt.Errorf("parsing BibTeX: entry %s is not closed", key)
+		}
+		switch p.src[p.pos] {
+		case closer:
+			p.pos++
+			return key, fields, nil
+		case ',':
+			p.pos++
+			continue
+		}
+
+		start := p.pos
+		for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+		name := strings.ToLower(p.src[start:p.pos])
+		p.skipSpace()
+		if name == "" || p.pos >= len(p.src) || p.src[p.pos] != '=' {
+			return "", nil, fmt.Errorf("parsing BibTeX: malformed field in entry %s", key)
+		}
+		p.pos++
+
+		value, err := p.value(closer)
+		if err != nil {
+			return "", nil, fmt.Errorf("parsing BibTeX entry %s: %w", key, err)
+		}
+		fields[name] = value
+	}
+}
+
+// value reads a field value: braced or quoted strings and bare words joined
+// with '#'
+func (p *bibParser) value(closer byte) (string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return "", fmt.Errorf("value is not closed")
+		}
+		switch c := p.src[p.pos]; {
+		case c == '{':
+			part, err := p.braced()
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		case c == '"':
+			p.pos++
+			start, depth := p.pos, 0
+			for p.pos < len(p.src) && (p.src[p.pos] != '"' || depth > 0) {
+				switch p.src[p.pos] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+				p.pos++
+			}
+			if p.pos >= len(p.src) {
+				return "", fmt.Errorf("quoted value is not closed")
+			}
+			parts = append(parts, p.src[start:p.pos])
+			p.pos++
+		default:
+			start := p.pos
+			for p.pos < len(p.src) && p.src[p.pos] != ',' && p.src[p.pos] != '#' && p.src[p.pos] != closer && !isSpace(p.src[p.pos]) {
+				p.pos++
+			}
+			parts = append(parts, p.src[start:p.pos])
+		}
+
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '#' {
+			p.pos++
+			continue
+		}
+		return strings.Join(parts, ""), nil
+	}
+}
+
+// braced reads a balanced {...} or (...) block, returning its content with
+// inner braces kept
+func (p *bibParser) braced() (string, error) {
+	open := p.src[p.pos]
+	closer := byte('}')
+	if open == '(' {
+		closer = ')'
+	}
+	p.pos++
+	start, depth := p.pos, 0
+	for ; p.pos < len(p.src); p.pos++ {
+		switch c := p.src[p.pos]; {
+		case c == '\\':
+			p.pos++
+		case c == '{':
+			depth++
+		case c == closer && depth == 0:
+			content := p.src[start:p.pos]
+			p.pos++
+			return content, nil
+		case c == '}':
+			depth--
+		}
+	}
+	return "", fmt.Errorf("braces are not balanced")
+}
+
+func (p *bibParser) skipSpace() {
+	for p.pos < len(p.src) && isSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_-:.+/", c) >= 0
+}
@@ -0,0 +1,82 @@
+package zotero
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBibTeX(t *testing.T) {
+	src := `@comment{jabref-meta: databaseType:biblatex;}
+@string{jnl = "Journal of Notes"}
+
+@article{doe2020,
+  title = {{The Art} of \& Note-Taking},
+  author = {Doe, Jane and Richard Roe and {World Health Organization}},
+  journaltitle = {Notes Quarterly},
+  date = {2020-05-01},
+  doi = {10.1000/xyz123},
+  url = {https://example.com/doe},
+  abstract = {A study
+    of notes.},
+}
+
+@book(roe2021,
+  title = "Quoted " # "Title",
+  year = 2021
+)
+`
+	items, err := ParseBibTeX(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	doe := items[0]
+	assert.Equal(t, "doe2020", doe.CiteKey)
+	assert.Equal(t, "article", doe.Type)
+	assert.Equal(t, "The Art of & Note-Taking", doe.Title)
+	assert.Equal(t, []string{"Doe, Jane", "Roe, Richard", "World Health Organization"}, doe.Authors)
+	assert.Equal(t, "2020", doe.Year)
+	assert.Equal(t, "Notes Quarterly", doe.Container)
+	assert.Equal(t, "10.1000/xyz123", doe.DOI)
+	assert.Equal(t, "https://example.com/doe", doe.URL)
+	assert.Equal(t, "A study of notes.", doe.Abstract)
+
+	roe := items[1]
+	assert.Equal(t, "roe2021", roe.CiteKey)
+	assert.Equal(t, "Quoted Title", roe.Title)
+	assert.Equal(t, "2021", roe.Year)
+}
+
+func TestParseBibTeX_Unbalanced(t *testing.T) {
+	_, err := ParseBibTeX(strings.NewReader("@article{doe2020, title = {Open"))
+	assert.Error(t, err)
+}
+
+func TestParseCSLJSON(t *testing.T) {
+	src := `[{
+  "id": "doe2020",
+  "type": "article-journal",
+  "title": "The Art of Note-Taking",
+  "author": [{"family": "Doe", "given": "Jane"}, {"literal": "World Health Organization"}],
+  "issued": {"date-parts": [[2020, 5, 1]]},
+  "container-title": "Notes Quarterly",
+  "DOI": "10.1000/xyz123"
+}, {
+  "id": "item-2",
+  "citation-key": "roe2021",
+  "type": "book",
+  "issued": {"raw": "2021-03"}
+}]`
+	items, err := ParseCSLJSON(strings.NewReader(src))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.Equal(t, "doe2020", items[0].CiteKey)
+	assert.Equal(t, []string{"Doe, Jane", "World Health Organization"}, items[0].Authors)
+	assert.Equal(t, "2020", items[0].Year)
+	assert.Equal(t, "Notes Quarterly", items[0].Container)
+	assert.Equal(t, "roe2021", items[1].CiteKey, "citation-key takes precedence over id")
+	assert.Equal(t, "2021", items[1].Year)
+}
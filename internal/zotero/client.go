@@ -0,0 +1,162 @@
+package zotero
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is where Zotero 7 serves its local API, once enabled in
+// Settings > Advanced
+const DefaultAPIURL = "http://localhost:23119"
+
+// extraCiteKey matches the citation key Better BibTeX pins in an item's Extra
+// field
+var extraCiteKey = regexp.MustCompile(`(?mi)^\s*Citation Key:\s*(\S+)\s*$`)
+
+// Client reads a library through the Zotero local API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the local API served at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiItem is an item in the API's JSON format
+type apiItem struct {
+	Key  string `json:"key"`
+	Data struct {
+		ItemType         string       `json:"itemType"`
+		Title            string       `json:"title"`
+		Creators         []apiCreator `json:"creators"`
+		Date             string       `json:"date"`
+		DOI              string       `json:"DOI"`
+		URL              string       `json:"url"`
+		PublicationTitle string       `json:"publicationTitle"`
+		BookTitle        string       `json:"bookTitle"`
+		ProceedingsTitle string       `json:"proceedingsTitle"`
+		AbstractNote     string       `json:"abstractNote"`
+		CitationKey      string       `json:"citationKey"`
+		Extra            string       `json:"extra"`
+	} `json:"data"`
+}
+
+type apiCreator struct {
+	CreatorType string `json:"creatorType"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Name        string `json:"name"`
+}
+
+var yearRegex = regexp.MustCompile(`\b\d{4}\b`)
+
+// Items retrieves the library's top-level items, skipping notes and
+// attachments, following the API's pagination. Items get the citation key
+// Zotero or Better BibTeX stores with them; items without one have an empty
+// CiteKey.
+func (c *Client) Items(ctx context.Context) ([]Item, error) {
+	const pageSize = 100
+
+	var items []Item
+	for start := 0; ; start += pageSize {
+		url := fmt.Sprintf("%s/api/users/0/items/top?format=json&limit=%d&start=%d", c.baseURL, pageSize, start)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Zotero-API-Version", "3")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("executing request (is Zotero running with its local API enabled?): %w", err)
+		}
+		var page []apiItem
+		err = checkResponse(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			if err != nil {
+				err = fmt.Errorf("decoding response: %w", err)
+			}
+		}
+		total, _ := strconv.Atoi(resp.Header.Get("Total-Results"))
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range page {
+			if entry.Data.ItemType == "note" || entry.Data.ItemType == "attachment" {
+				continue
+			}
+			items = append(items, entry.item())
+		}
+		if len(page) < pageSize || (total > 0 && start+pageSize >= total) {
+			return items, nil
+		}
+	}
+}
+
+// item converts an API item
+func (a apiItem) item() Item {
+	data := a.Data
+	item := Item{
+		CiteKey:  data.CitationKey,
+		Key:      a.Key,
+		Type:     data.ItemType,
+		Title:    data.Title,
+		DOI:      data.DOI,
+		URL:      data.URL,
+		Abstract: data.AbstractNote,
+		Year:     yearRegex.FindString(data.Date),
+	}
+	if item.CiteKey == "" {
+		if m := extraCiteKey.FindStringSubmatch(data.Extra); m != nil {
+			item.CiteKey = m[1]
+		}
+	}
+	for _, container := range []string{data.PublicationTitle, data.BookTitle, data.ProceedingsTitle} {
+		if container != "" {
+			item.Container = container
+			break
+		}
+	}
+	for _, creator := range data.Creators {
+		if creator.CreatorType != "author" {
+			continue
+		}
+		switch {
+		case creator.Name != "":
+			item.Authors = append(item.Authors, creator.Name)
+		case creator.FirstName != "":
+			item.Authors = append(item.Authors, creator.LastName+", "+creator.FirstName)
+		default:
+			item.Authors = append(item.Authors, creator.LastName)
+		}
+	}
+	return item
+}
+
+// checkResponse checks the HTTP response for errors
+func checkResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return fmt.Errorf("local API access refused: enable \"Allow other applications on this computer to communicate with Zotero\" in Zotero's settings")
+	case http.StatusNotFound:
+		return fmt.Errorf("local API not found: Zotero 7 or later is required")
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
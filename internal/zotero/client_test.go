@@ -0,0 +1,56 @@
+package zotero
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Items(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/users/0/items/top", r.URL.Path)
+		assert.Equal(t, "3", r.Header.Get("Zotero-API-Version"))
+		assert.Equal(t, "0", r.URL.Query().Get("start"))
+
+		w.Header().Set("Total-Results", "3")
+		fmt.Fprint(w, `[
+  {"key": "AAAA1111", "data": {"itemType": "journalArticle", "title": "The Art of Note-Taking",
+    "creators": [{"creatorType": "author", "firstName": "Jane", "lastName": "Doe"},
+                 {"creatorType": "editor", "firstName": "Ed", "lastName": "Itor"},
+                 {"creatorType": "author", "name": "World Health Organization"}],
+    "date": "May 2020", "DOI": "10.1000/xyz123", "publicationTitle": "Notes Quarterly",
+    "abstractNote": "A study.", "citationKey": "doe2020"}},
+  {"key": "BBBB2222", "data": {"itemType": "book", "title": "Pinned", "extra": "tex.note: x\nCitation Key: roe2021"}},
+  {"key": "CCCC3333", "data": {"itemType": "note"}}
+]`)
+	}))
+	defer server.Close()
+
+	items, err := NewClient(server.URL).Items(context.Background())
+	require.NoError(t, err)
+	require.Len(t, items, 2, "notes are skipped")
+
+	doe := items[0]
+	assert.Equal(t, "doe2020", doe.CiteKey)
+	assert.Equal(t, "AAAA1111", doe.Key)
+	assert.Equal(t, []string{"Doe, Jane", "World Health Organization"}, doe.Authors)
+	assert.Equal(t, "2020", doe.Year)
+	assert.Equal(t, "Notes Quarterly", doe.Container)
+	assert.Equal(t, "roe2021", items[1].CiteKey, "citation key pinned in Extra")
+}
+
+func TestClient_ItemsForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Items(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "local API access refused")
+}
@@ -0,0 +1,112 @@
+package zotero
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Item is a reference from a Zotero library
+type Item struct {
+	CiteKey   string // Better BibTeX citation key
+	Key       string // Zotero item key, when read from the API
+	Type      string // Item type, e.g. article or book
+	Title     string
+	Authors   []string // "Last, First" or an institution's name
+	Year      string
+	DOI       string
+	URL       string
+	Container string // Journal, book or proceedings the item appeared in
+	Abstract  string
+}
+
+// ReadFile reads the items of a Better BibTeX export: BibTeX or BibLaTeX
+// (.bib) or CSL JSON (.json)
+func ReadFile(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ParseCSLJSON(f)
+	}
+	return ParseBibTeX(f)
+}
+
+// cslItem is an item in CSL JSON
+type cslItem struct {
+	ID             string    `json:"id"`
+	CitationKey    string    `json:"citation-key"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Author         []cslName `json:"author"`
+	Issued         cslDate   `json:"issued"`
+	DOI            string    `json:"DOI"`
+	URL            string    `json:"URL"`
+	ContainerTitle string    `json:"container-title"`
+	Abstract       string    `json:"abstract"`
+}
+
+type cslName struct {
+	Family  string `json:"family"`
+	Given   string `json:"given"`
+	Literal string `json:"literal"`
+}
+
+type cslDate struct {
+	DateParts [][]interface{} `json:"date-parts"`
+	Raw       string          `json:"raw"`
+}
+
+// ParseCSLJSON reads items from CSL JSON, as exported by Better BibTeX's
+// "Better CSL JSON" format
+func ParseCSLJSON(r io.Reader) ([]Item, error) {
+	var entries []cslItem
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("parsing CSL JSON: %w", err)
+	}
+
+	items := make([]Item, 0, len(entries))
+	for _, entry := range entries {
+		item := Item{
+			CiteKey:   entry.CitationKey,
+			Type:      entry.Type,
+			Title:     entry.Title,
+			DOI:       entry.DOI,
+			URL:       entry.URL,
+			Container: entry.ContainerTitle,
+			Abstract:  entry.Abstract,
+		}
+		if item.CiteKey == "" {
+			item.CiteKey = entry.ID
+		}
+		for _, name := range entry.Author {
+			switch {
+			case name.Literal != "":
+				item.Authors = append(item.Authors, name.Literal)
+			case name.Given != "":
+				item.Authors = append(item.Authors, name.Family+", "+name.Given)
+			default:
+				item.Authors = append(item.Authors, name.Family)
+			}
+		}
+		if len(entry.Issued.DateParts) > 0 && len(entry.Issued.DateParts[0]) > 0 {
+			switch year := entry.Issued.DateParts[0][0].(type) {
+			case float64:
+				item.Year = strconv.Itoa(int(year))
+			case string:
+				item.Year = year
+			}
+		} else if len(entry.Issued.Raw) >= 4 {
+			item.Year = entry.Issued.Raw[:4]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/eoinhurrell/mdnotes/internal/safety"
 )
 
 // Document represents a markdown file with frontmatter
@@ -148,7 +150,7 @@ func (p *Processor) Write(doc *Document, filePath string) error {
 		return err
 	}
 
-	return os.WriteFile(filePath, content, 0644)
+	return safety.WriteFile(filePath, content, 0644)
 }
 
 // Serialize converts a document back to markdown with frontmatter